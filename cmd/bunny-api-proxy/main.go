@@ -3,27 +3,66 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sipico/bunny-api-proxy/internal/acme"
+	"github.com/sipico/bunny-api-proxy/internal/activation"
 	"github.com/sipico/bunny-api-proxy/internal/admin"
+	"github.com/sipico/bunny-api-proxy/internal/audit"
 	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/blocklist"
 	"github.com/sipico/bunny-api-proxy/internal/bunny"
 	"github.com/sipico/bunny-api-proxy/internal/config"
+	"github.com/sipico/bunny-api-proxy/internal/diskguard"
+	"github.com/sipico/bunny-api-proxy/internal/docs"
+	"github.com/sipico/bunny-api-proxy/internal/domainperms"
+	"github.com/sipico/bunny-api-proxy/internal/externaldns"
+	"github.com/sipico/bunny-api-proxy/internal/freeze"
+	"github.com/sipico/bunny-api-proxy/internal/health"
+	"github.com/sipico/bunny-api-proxy/internal/honeypot"
+	"github.com/sipico/bunny-api-proxy/internal/idempotency"
+	"github.com/sipico/bunny-api-proxy/internal/instance"
+	"github.com/sipico/bunny-api-proxy/internal/jobs"
+	"github.com/sipico/bunny-api-proxy/internal/keycheck"
+	"github.com/sipico/bunny-api-proxy/internal/logging"
+	"github.com/sipico/bunny-api-proxy/internal/maintenance"
 	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	internalMiddleware "github.com/sipico/bunny-api-proxy/internal/middleware"
+	"github.com/sipico/bunny-api-proxy/internal/outbox"
+	"github.com/sipico/bunny-api-proxy/internal/provision"
 	"github.com/sipico/bunny-api-proxy/internal/proxy"
+	"github.com/sipico/bunny-api-proxy/internal/replay"
+	"github.com/sipico/bunny-api-proxy/internal/schedule"
+	"github.com/sipico/bunny-api-proxy/internal/slo"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/ttlpolicy"
+	"github.com/sipico/bunny-api-proxy/internal/usage"
+	"github.com/sipico/bunny-api-proxy/internal/watchdog"
+	"github.com/sipico/bunny-api-proxy/internal/webhooks"
+	"github.com/sipico/bunny-api-proxy/internal/zonetags"
 )
 
 const version = "2026.01.2"
@@ -32,7 +71,13 @@ const serverShutdownTimeout = 30 * time.Second
 func main() {
 	// Handle health check subcommand for distroless container health checks
 	if len(os.Args) > 1 && os.Args[1] == "health" { // coverage-ignore: health subcommand only used in container HEALTHCHECK
-		os.Exit(runHealthCheck()) // coverage-ignore: health subcommand only used in container HEALTHCHECK
+		os.Exit(runHealthCheck(os.Args[2:])) // coverage-ignore: health subcommand only used in container HEALTHCHECK
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" { // coverage-ignore: exits the process
+		os.Exit(runMigrate(os.Args[2:], os.Stdout, os.Stderr)) // coverage-ignore: exits the process
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--migrate-only" { // coverage-ignore: exits the process
+		os.Exit(runMigrateOnly(os.Stdout, os.Stderr)) // coverage-ignore: exits the process
 	}
 
 	if err := run(); err != nil { // coverage-ignore: run() errors only occur in production failures
@@ -42,7 +87,19 @@ func main() {
 
 // runHealthCheck performs an HTTP health check against the local server.
 // Returns 0 on success, 1 on failure. Used by container HEALTHCHECK.
-func runHealthCheck() int {
+//
+// With --ready it checks /ready instead of /health and prints the report;
+// --strict additionally fails when the report is degraded.
+func runHealthCheck(args []string) int {
+	fs := flag.NewFlagSet("health", flag.ContinueOnError)
+	ready := fs.Bool("ready", false, "check /ready, including dependencies")
+	strict := fs.Bool("strict", false, "with --ready, fail when degraded")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *ready {
+		return doReadyCheck("http://localhost:8080/ready", *strict, os.Stdout)
+	}
 	return doHealthCheck("http://localhost:8080/health")
 }
 
@@ -62,17 +119,73 @@ func doHealthCheck(url string) int {
 	return 0
 }
 
+// doReadyCheck fetches the readiness report and writes it to out.
+// Down fails; degraded fails only when strict.
+func doReadyCheck(url string, strict bool, out io.Writer) int {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 1
+	}
+	//nolint:errcheck // Response body close errors are unrecoverable in health check
+	defer resp.Body.Close()
+
+	var report health.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return 1
+	}
+	//nolint:errcheck // Output write errors are unrecoverable in health check
+	json.NewEncoder(out).Encode(report)
+
+	switch report.Status {
+	case health.StatusOK:
+		return 0
+	case health.StatusDegraded:
+		if strict {
+			return 1
+		}
+		return 0
+	default:
+		return 1
+	}
+}
+
 // serverComponents holds all initialized server components for testing
 type serverComponents struct {
 	logger           *slog.Logger
 	logLevel         *slog.LevelVar
 	store            storage.Storage
 	bunnyClient      *bunny.Client
+	accounts         map[string]*bunny.Client // further accounts by name
 	bootstrapService *auth.BootstrapService
+	outbox           *outbox.Outbox
+	audit            *audit.Logger
+	usage            *usage.Tracker
+	maintenance      *maintenance.Worker
+	slo              *slo.Tracker
+	watchdog         *watchdog.Watchdog
+	diskGuard        *diskguard.Guard
+	instances        *instance.Registry
+	blocklist        *blocklist.Blocklist
+	freezes          *freeze.Schedule
+	ttlPolicies      *ttlpolicy.Policies
+	zoneTags         *zonetags.Syncer
+	replay           *replay.Guard
+	idempotency      *idempotency.Guard
+	jobs             *jobs.Runner
+	schedule         *schedule.Runner
+	keyChecker       *keycheck.Checker     // nil if the key check is disabled
+	apiKeyProvider   config.SecretProvider // nil unless the API key is read from Vault
+	apiKey           string                // API key in use at startup
+	certificates     *acme.Manager         // nil unless ACME is enabled
+	tlsConfig        *tls.Config           // nil unless the TLS listener is enabled
+	certFile         *certificateFile      // nil unless TLS_CERT_FILE is set
+	proxyHandler     *proxy.Handler
 	proxyRouter      http.Handler
 	adminRouter      http.Handler
 	mainRouter       *chi.Mux
 	metricsRouter    http.Handler
+	shuttingDown     *atomic.Bool // set on SIGTERM; fails readiness while draining
 }
 
 // initializeComponents sets up all server components with proper error handling
@@ -83,8 +196,31 @@ func initializeComponents(cfg *config.Config) (*serverComponents, error) {
 		return nil, fmt.Errorf("invalid log level %q: %w", cfg.LogLevel, err)
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	// 3. Initialize storage and resolve the persistent instance ID
+	dbOpts, err := databaseOptions(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("storage initialization failed: %w", err)
+	}
+	store, err := storage.New(cfg.DatabasePath, dbOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage initialization failed: %w", err)
+	}
+	// Hostname is the container ID under Docker and the pod name under Kubernetes
+	hostname, _ := os.Hostname() //nolint:errcheck // an empty hostname only disables ID reuse
+	instanceID, err := instance.Resolve(context.Background(), store, cfg.InstanceID, hostname)
+	if err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, fmt.Errorf("instance ID resolution failed: %w", err)
+	}
+
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})).
+		With("instance_id", instanceID)
 	slog.SetDefault(logger)
+	instanceRegistry := instance.NewRegistry(store, storage.Instance{
+		ID:       instanceID,
+		Hostname: hostname,
+		Version:  version,
+	}, logger)
 
 	logger.Info("Server starting",
 		"version", version,
@@ -103,24 +239,41 @@ func initializeComponents(cfg *config.Config) (*serverComponents, error) {
 		// Log that metrics were already initialized
 		logger.Debug("Metrics already initialized")
 	}
+	metrics.SetInfo(version, instanceID)
+	metrics.SetDBPoolStats(store.PoolStats)
 
-	// 3. Initialize storage
-	store, err := storage.New(cfg.DatabasePath)
-	if err != nil {
-		return nil, fmt.Errorf("storage initialization failed: %w", err)
-	}
+	// 4. Create webhook service and the outbox that drains change events to it
+	webhookService := webhooks.NewService(store, logger, webhooks.WithMaxWebhooksPerToken(cfg.WebhookMaxPerToken))
+	eventOutbox := outbox.New(store, logger, webhookService)
 
-	// 4. Create bunny client with real API key and logging transport
+	// 5. Create bunny client with real API key and logging transport
 	var bunnyOpts []bunny.Option
 	if cfg.BunnyAPIURL != "" {
 		bunnyOpts = append(bunnyOpts, bunny.WithBaseURL(cfg.BunnyAPIURL))
 	}
+	if len(cfg.BunnyAPIURLOverrides) > 0 {
+		classURLs, err := bunny.ParseEndpointBaseURLs(cfg.BunnyAPIURLOverrides)
+		if err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, fmt.Errorf("bunny client initialization failed: %w", err)
+		}
+		bunnyOpts = append(bunnyOpts, bunny.WithEndpointBaseURLs(classURLs))
+	}
 
-	// Wire up LoggingTransport to log bunny.net API calls
+	// Wire up LoggingTransport to log bunny.net API calls; admins can switch
+	// on body logging for a while without lowering the log level
+	bodyLogging := logging.NewBodySwitch()
 	loggingTransport := &bunny.LoggingTransport{
 		Transport: http.DefaultTransport,
 		Logger:    logger,
 		Prefix:    "BUNNY",
+		LogBodies: func(ctx context.Context) bool {
+			var tokenID int64
+			if token := auth.TokenFromContext(ctx); token != nil {
+				tokenID = token.ID
+			}
+			return bodyLogging.Enabled(tokenID)
+		},
 	}
 	// Wrap with RetryTransport to retry on timeout errors
 	retryTransport := &bunny.RetryTransport{
@@ -132,36 +285,331 @@ func initializeComponents(cfg *config.Config) (*serverComponents, error) {
 		Timeout:   30 * time.Second,
 	}
 	bunnyOpts = append(bunnyOpts, bunny.WithHTTPClient(httpClient))
+	bunnyOpts = append(bunnyOpts, bunny.WithSchemaCheck(bunny.SchemaCheck(cfg.BunnySchemaCheck), logger))
+	bunnyOpts = append(bunnyOpts, bunny.WithRetry(bunny.RetryPolicy{
+		MaxAttempts: cfg.BunnyRetryMaxAttempts,
+		BaseDelay:   cfg.BunnyRetryBaseDelay,
+	}))
+	bunnyOpts = append(bunnyOpts, bunny.WithCircuitBreaker(bunny.BreakerPolicy{
+		Threshold: cfg.BunnyCircuitBreakerThreshold,
+		Cooldown:  cfg.BunnyCircuitBreakerCooldown,
+	}))
+	userAgent := cfg.BunnyUserAgent
+	if userAgent == "" {
+		userAgent = fmt.Sprintf("bunny-api-proxy/%s (+%s)", version, instanceID)
+	}
+	bunnyOpts = append(bunnyOpts, bunny.WithUserAgent(userAgent), bunny.WithContact(cfg.BunnyContact))
+	bunnyOpts = append(bunnyOpts, bunny.WithAlerter(eventOutbox))
+
+	// The API key is read from Vault when configured, and replaced in the
+	// client when it is rotated there
+	bunnyAPIKey := cfg.BunnyAPIKey
+	apiKeyProvider := cfg.BunnyAPIKeyProvider()
+	if apiKeyProvider != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		key, err := apiKeyProvider.Fetch(ctx)
+		cancel()
+		if err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, fmt.Errorf("failed to read the bunny.net API key from Vault: %w", err)
+		}
+		bunnyAPIKey = key
+	}
+	bunnyClient := bunny.NewClient(bunnyAPIKey, bunnyOpts...)
+
+	// Further accounts share the client options; background jobs such as
+	// zone tag sync and health checks use the default account only
+	accountClients := make(map[string]proxy.BunnyClient, len(cfg.BunnyAccounts))
+	accounts := make(map[string]*bunny.Client, len(cfg.BunnyAccounts))
+	keyAccounts := map[string]keycheck.Verifier{"": bunnyClient}
+	for name, key := range cfg.BunnyAccounts {
+		client := bunny.NewClient(key, bunnyOpts...)
+		accountClients[name], accounts[name], keyAccounts[name] = client, client, client
+	}
+	// Every account's key is verified, so a key rotated in bunny.net is
+	// noticed before clients start getting 502s
+	var keyChecker *keycheck.Checker
+	if cfg.UpstreamKeyCheckInterval > 0 {
+		keyChecker = keycheck.New(keyAccounts, cfg.UpstreamKeyCheckInterval, logger, keycheck.WithAlerter(eventOutbox))
+	}
+
+	// Provision tokens from the config file before bootstrap state is read,
+	// since a provisioned admin token completes bootstrap
+	if cfg.TokensConfigFile != "" {
+		tokensFile, err := provision.Load(cfg.TokensConfigFile, os.Getenv)
+		if err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, fmt.Errorf("token provisioning failed: %w", err)
+		}
+		res, err := provision.Reconcile(context.Background(), store, tokensFile, logger)
+		if err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, fmt.Errorf("token provisioning failed: %w", err)
+		}
+		logger.Info("Tokens provisioned", "file", cfg.TokensConfigFile, "created", res.Created,
+			"updated", res.Updated, "unchanged", res.Unchanged, "pruned", res.Pruned)
+	}
+
+	// 6. Create bootstrap service for managing master key and bootstrap state
+	bootstrapService := auth.NewBootstrapService(store, bunnyAPIKey,
+		auth.WithBootstrapRecord(store), auth.WithBootstrapWindow(cfg.BootstrapWindow))
+
+	// The disk guard turns the proxy read-only when storage fills up; write
+	// failures on the request path report a full disk straight away
+	diskGuard := diskguard.New(store, databaseDir(cfg.DatabasePath), logger,
+		diskguard.WithMinFreeSpace(int64(cfg.DiskMinFreeMB)<<20),
+		diskguard.WithDBSizeLimit(int64(cfg.DBSizeLimitMB)<<20),
+		diskguard.WithAlerter(eventOutbox))
+	auditOpts := []audit.Option{audit.WithWriteErrorHandler(diskGuard.ReportError)}
+	if cfg.AuditStream != "" {
+		auditStream, err := openAuditStream(cfg.AuditStream)
+		if err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, fmt.Errorf("audit stream initialization failed: %w", err)
+		}
+		auditOpts = append(auditOpts, audit.WithStream(auditStream, instanceID))
+	}
+	auditLogger := audit.New(store, logger, auditOpts...)
+	usageTracker := usage.New(store, logger, usage.WithWriteErrorHandler(diskGuard.ReportError))
+	maintenanceWorker := maintenance.New(store, logger,
+		maintenance.WithSizeAlert(int64(cfg.DBSizeAlertMB)<<20, eventOutbox))
+	resourceWatchdog := watchdog.New(store, watchdog.Thresholds{
+		Goroutines:    cfg.WatchdogGoroutineThreshold,
+		OpenFDs:       cfg.WatchdogFDThreshold,
+		DBConnections: cfg.WatchdogDBConnThreshold,
+	}, logger, watchdog.WithAlerter(eventOutbox))
+	sloTracker := slo.New(slo.Objectives{
+		Availability:     cfg.SLOAvailabilityTarget,
+		Latency:          cfg.SLOLatencyTarget,
+		LatencyThreshold: cfg.SLOLatencyThreshold,
+	}, slo.WithInstanceID(instanceID), slo.WithUpstreamHealth(bunnyClient.EndpointHealth))
+	// Load the blocklist before serving so no request slips through
+	ipBlocklist := blocklist.New(store, logger, blocklist.WithTrustForwardedFor(cfg.BlocklistTrustForwardedFor))
+	if err := ipBlocklist.Reload(context.Background()); err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, fmt.Errorf("blocklist initialization failed: %w", err)
+	}
+	// Load zone freezes before serving so a frozen zone is never changed
+	freezeSchedule := freeze.New(store, logger)
+	if err := freezeSchedule.Reload(context.Background()); err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, fmt.Errorf("zone freeze initialization failed: %w", err)
+	}
+	// Load TTL policies before serving so every record created gets them
+	ttlPolicies := ttlpolicy.New(store, logger)
+	if err := ttlPolicies.Reload(context.Background()); err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, fmt.Errorf("TTL policy initialization failed: %w", err)
+	}
+	var decoys *honeypot.Honeypot
+	if cfg.HoneypotEnabled {
+		var err error
+		decoys, err = honeypot.New(cfg.HoneypotPaths, ipBlocklist, logger,
+			honeypot.WithAlerter(eventOutbox), honeypot.WithBanDuration(cfg.HoneypotBanDuration))
+		if err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, fmt.Errorf("honeypot initialization failed: %w", err)
+		}
+	}
+
+	// Tag zones by naming rules or an external source; tag permissions are
+	// applied to newly tagged zones on every sync
+	zoneTagRules, err := zonetags.ParseRules(cfg.ZoneTagRules)
+	if err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, fmt.Errorf("zone tag initialization failed: %w", err)
+	}
+	zoneTagSyncer := zonetags.New(store, bunnyClient, logger,
+		zonetags.WithRules(zoneTagRules),
+		zonetags.WithSource(cfg.ZoneTagSource),
+		zonetags.WithInterval(cfg.ZoneTagSyncInterval))
+
+	// Domain permissions are matched against each account's cached zone list
+	domainZones := make(map[string]domainperms.ZoneLister, len(accountClients))
+	for name, client := range accountClients {
+		domainZones[name] = client
+	}
+	domainResolver := domainperms.New(store, bunnyClient, logger,
+		domainperms.WithCacheTTL(cfg.DomainPermissionCacheTTL),
+		domainperms.WithAccounts(domainZones))
+
+	// Mutating proxy requests may be checked for replays; nonces are shared
+	// through the database so every instance sees them
+	replayGuard, err := replay.New(store, cfg.ReplayProtection, logger,
+		replay.WithWindow(cfg.ReplayWindow),
+		replay.WithWriteErrorHandler(diskGuard.ReportError))
+	if err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, fmt.Errorf("replay protection initialization failed: %w", err)
+	}
 
-	bunnyClient := bunny.NewClient(cfg.BunnyAPIKey, bunnyOpts...)
+	// Retried mutating requests with an Idempotency-Key get the stored
+	// response; keys are shared through the database like nonces
+	idempotencyGuard := idempotency.New(store, logger,
+		idempotency.WithTTL(cfg.IdempotencyTTL),
+		idempotency.WithWriteErrorHandler(diskGuard.ReportError))
 
-	// 5. Create bootstrap service for managing master key and bootstrap state
-	bootstrapService := auth.NewBootstrapService(store, cfg.BunnyAPIKey)
+	// Asynchronous imports run one at a time in a background worker
+	jobRunner := jobs.New(store, logger, jobs.WithStopGrace(serverShutdownTimeout))
 
-	// 6. Create proxy handler and router
+	// 7. Create proxy handler and router
 	proxyHandler := proxy.NewHandler(bunnyClient, logger)
-	proxyAuthenticator := auth.NewAuthenticator(store, bootstrapService)
-	// Chain authentication and permission checking middleware
+	proxyHandler.SetAccountPassthrough(cfg.AccountPassthroughEnabled)
+	proxyHandler.SetEventPublisher(eventOutbox)
+	proxyHandler.SetRecordMetaStore(store)
+	proxyHandler.SetRecordHistoryStore(store)
+	proxyHandler.SetJobRunner(jobRunner)
+	proxyHandler.SetScheduleStore(store)
+	proxyHandler.SetProtectedRecordStore(store)
+	proxyHandler.SetRequestLimits(cfg.MaxRequestBodyBytes, cfg.ImportMaxBodyBytes, cfg.ImportTimeout)
+	proxyHandler.SetRecordLimits(cfg.RecordMaxTXTLength, cfg.ImportMaxRecords, cfg.ImportMaxAsyncRecords)
+	proxyHandler.SetActivityStore(store)
+	proxyHandler.SetAccounts(accountClients)
+	proxyHandler.SetResponseCache(cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries)
+	proxyHandler.SetTTLPolicy(ttlPolicies)
+	proxyHandler.SetRecordValidation(cfg.RecordValidation == "strict", int32(cfg.RecordMinTTL), int32(cfg.RecordMaxTTL)) //nolint:gosec // bounded by config
+	fingerprints := tlsFingerprintSource(cfg)
+	proxyAuthenticator := auth.NewAuthenticator(store, bootstrapService,
+		auth.WithFingerprints(fingerprints), auth.WithClientCertificates(store),
+		auth.WithDomainResolver(domainResolver), auth.WithCache(cfg.AuthCacheTTL), auth.WithLogger(logger))
+	// Chain authentication, usage tracking, audit logging, replay protection,
+	// permission checking, zone freezes and idempotency key middleware. Audit
+	// runs before the replay and permission checks so rejected requests are
+	// recorded too. Idempotency keys are only claimed by requests that passed
+	// every check.
+	// Per-token metrics also run before those checks, so their denials are
+	// counted against the token.
+	tokenMetrics := metrics.NewTokenMetrics(cfg.MetricsTokenLabels, cfg.MetricsTokenAllowlist,
+		cfg.MetricsTokenMaxLabels, tokenNameFromRequest)
 	proxyAuthChain := func(next http.Handler) http.Handler {
-		return proxyAuthenticator.Authenticate(proxyAuthenticator.CheckPermissions(next))
+		return proxyAuthenticator.Authenticate(usageTracker.Middleware(tokenMetrics.Middleware(
+			auditLogger.Middleware(replayGuard.Middleware(proxyAuthenticator.CheckPermissions(
+				freezeSchedule.Middleware(idempotencyGuard.Middleware(next))))))))
 	}
 	proxyRouter := proxy.NewRouter(proxyHandler, proxyAuthChain, logger)
 
-	// 7. Create admin handler and router
+	// Scheduled record changes are made by the proxy handler once due, unless
+	// the zone has been frozen or the token's permissions revoked since
+	scheduleRunner := schedule.New(store, proxyHandler, proxyAuthenticator, logger, schedule.WithFreezes(freezeSchedule))
+
+	// Optional built-in ACME client; challenge records go through the proxy
+	// router so the ACME token's permissions apply
+	var certManager *acme.Manager
+	if len(cfg.ACMEDomains) > 0 {
+		certManager = acme.NewManager(acme.Config{
+			Domains:      cfg.ACMEDomains,
+			DirectoryURL: cfg.ACMEDirectoryURL,
+			Email:        cfg.ACMEEmail,
+			CertDir:      cfg.ACMECertDir,
+			RenewBefore:  cfg.ACMERenewBefore,
+		}, acme.NewProxySolver(proxyRouter, cfg.ACMEAccessKey), logger)
+	}
+	// Otherwise the TLS listener serves the certificate files, read again on SIGHUP
+	var certs certificateSource
+	var certFile *certificateFile
+	switch {
+	case certManager != nil:
+		certs = certManager
+	case cfg.TLSCertFile != "":
+		if certFile, err = loadCertificateFile(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+			_ = store.Close() //nolint:errcheck
+			return nil, err
+		}
+		certs = certFile
+	}
+	tlsConfig, err := listenerTLSConfig(cfg, certs)
+	if err != nil {
+		_ = store.Close() //nolint:errcheck
+		return nil, err
+	}
+
+	// 8. Create admin handler and router
 	adminHandler := admin.NewHandler(store, logLevel, logger)
 	adminHandler.SetBootstrapService(bootstrapService)
+	adminHandler.SetWebhookService(webhookService)
+	adminHandler.SetEventPublisher(eventOutbox)
+	adminHandler.SetInstanceRegistry(instanceRegistry)
+	adminHandler.SetBlocklist(ipBlocklist)
+	adminHandler.SetFreezeSchedule(freezeSchedule)
+	adminHandler.SetTTLPolicies(ttlPolicies)
+	adminHandler.SetProtectedRecordStore(store)
+	adminHandler.SetZoneTags(zoneTagSyncer)
+	adminHandler.SetDomainPermissionStore(store)
+	adminHandler.SetRecordHistoryStore(store)
+	adminHandler.SetChangeReverter(proxyHandler)
+	adminHandler.SetAuditLog(auditLogger)
+	adminHandler.SetUsageTracker(usageTracker)
+	adminHandler.SetFingerprints(fingerprints)
+	adminHandler.SetClientCertStore(store)
+	adminHandler.SetAuthCache(proxyAuthenticator)
+	zoneTagSyncer.SetAuthCache(proxyAuthenticator)
+	adminHandler.SetRoleStore(store)
+	adminHandler.SetInvitationStore(store)
+	adminHandler.SetBodyLogging(bodyLogging)
+	adminHandler.SetAccounts(slices.Collect(maps.Keys(cfg.BunnyAccounts)))
+	if cfg.AdminSessionTTL > 0 {
+		adminHandler.SetSessions(store, cfg.AdminSessionTTL)
+	}
+	// Operators may sign in to the admin API with SSO instead of admin tokens
+	if cfg.OIDCIssuer != "" {
+		adminHandler.SetAuthProviders(admin.NewOIDCProvider(admin.OIDCOptions{
+			Issuer:        cfg.OIDCIssuer,
+			Audience:      cfg.OIDCAudience,
+			JWKSURL:       cfg.OIDCJWKSURL,
+			RoleClaim:     cfg.OIDCRoleClaim,
+			AdminRoles:    cfg.OIDCAdminRoles,
+			UsernameClaim: cfg.OIDCUsernameClaim,
+		}))
+	}
+	if len(cfg.AdminCORSAllowedOrigins) > 0 {
+		adminHandler.SetCORS(internalMiddleware.CORSConfig{
+			AllowedOrigins:   cfg.AdminCORSAllowedOrigins,
+			AllowedMethods:   cfg.AdminCORSAllowedMethods,
+			AllowedHeaders:   cfg.AdminCORSAllowedHeaders,
+			AllowCredentials: cfg.AdminCORSAllowCredentials,
+		})
+	}
+	adminHandler.SetConfig(cfg)
+	adminHandler.SetDocs(docs.Page{Version: version, ProxyRoutes: proxyDocsRoutes(proxyHandler.Routes())})
+	shuttingDown := new(atomic.Bool)
+	readyChecks := []health.Check{{Name: "storage", Run: diskGuard.Check}, bootstrapCheck(bootstrapService), shutdownCheck(shuttingDown)}
+	if cfg.HealthCheckUpstream {
+		readyChecks = append(readyChecks, upstreamCheck(bunnyClient))
+	}
+	if keyChecker != nil {
+		readyChecks = append(readyChecks, health.Check{Name: "upstream_key", Run: keyChecker.Check})
+	}
+	ready := readyHandler(store, readyChecks...)
+	adminHandler.SetReadiness(ready)
+	adminHandler.SetLiveness(http.HandlerFunc(healthHandler))
 	adminRouter := adminHandler.NewRouter()
 
-	// 8. Assemble main router
+	// 9. Assemble main router
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(metrics.Middleware)
+	// Blocked clients are rejected before any authentication
+	r.Use(ipBlocklist.Middleware)
+	// Decoy paths ban the client before routing, so they work under /admin too
+	if decoys != nil {
+		r.Use(decoys.Middleware)
+	}
+	// Writes get a clear 503 instead of opaque 500s while storage is full
+	r.Use(diskGuard.Middleware(cfg.StorageDegradedAllowDelete))
 
 	r.Get("/health", healthHandler)
-	r.Get("/ready", readyHandler(store))
+	r.Get("/ready", ready)
+	r.Get("/status", sloTracker.HandleStatus)
+	r.Get("/openapi.json", adminHandler.HandleOpenAPI)
 	r.Mount("/admin", adminRouter)
-	r.Mount("/", proxyRouter)
+	// DNS-01 helper for ACME clients; record changes go through the proxy
+	// router with the caller's key
+	r.Mount("/acme", acme.NewHTTPReqRouter(proxyRouter, logger))
+	// external-dns webhook provider, also scoped to the caller's key
+	r.Mount("/externaldns", externaldns.NewRouter(proxyRouter, logger))
+	// Only proxied requests count towards the SLOs
+	r.Mount("/", sloTracker.Middleware(proxyRouter))
 
 	// 10. Assemble metrics router on a separate internal listener
 	metricsRouter := chi.NewRouter()
@@ -172,22 +620,66 @@ func initializeComponents(cfg *config.Config) (*serverComponents, error) {
 		logLevel:         logLevel,
 		store:            store,
 		bunnyClient:      bunnyClient,
+		accounts:         accounts,
 		bootstrapService: bootstrapService,
+		outbox:           eventOutbox,
+		audit:            auditLogger,
+		usage:            usageTracker,
+		maintenance:      maintenanceWorker,
+		slo:              sloTracker,
+		watchdog:         resourceWatchdog,
+		diskGuard:        diskGuard,
+		instances:        instanceRegistry,
+		blocklist:        ipBlocklist,
+		freezes:          freezeSchedule,
+		ttlPolicies:      ttlPolicies,
+		zoneTags:         zoneTagSyncer,
+		replay:           replayGuard,
+		idempotency:      idempotencyGuard,
+		jobs:             jobRunner,
+		schedule:         scheduleRunner,
+		keyChecker:       keyChecker,
+		apiKeyProvider:   apiKeyProvider,
+		apiKey:           bunnyAPIKey,
+		certificates:     certManager,
+		tlsConfig:        tlsConfig,
+		certFile:         certFile,
+		proxyHandler:     proxyHandler,
 		proxyRouter:      proxyRouter,
 		adminRouter:      adminRouter,
 		mainRouter:       r,
 		metricsRouter:    metricsRouter,
+		shuttingDown:     shuttingDown,
 	}, nil
 }
 
+// tokenNameFromRequest returns the name of the request's authenticated
+// token, or "" for the master key and unauthenticated requests.
+func tokenNameFromRequest(r *http.Request) string {
+	if token := auth.TokenFromContext(r.Context()); token != nil {
+		return token.Name
+	}
+	return ""
+}
+
+// proxyDocsRoutes converts the proxy's route table for the operator documentation.
+func proxyDocsRoutes(routes []proxy.RouteInfo) []docs.Route {
+	out := make([]docs.Route, 0, len(routes))
+	for _, rt := range routes {
+		out = append(out, docs.Route{Method: rt.Method, Path: rt.Path, Access: rt.Access, Description: rt.Description})
+	}
+	return out
+}
+
 // createServer creates and returns an HTTP server with the given configuration
 func createServer(cfg *config.Config, handler http.Handler) *http.Server {
 	return &http.Server{
-		Addr:         cfg.ListenAddr,
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              cfg.ListenAddr,
+		Handler:           handler,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
 	}
 }
 
@@ -202,6 +694,91 @@ func createMetricsServer(cfg *config.Config, handler http.Handler) *http.Server
 	}
 }
 
+// createTLSServer creates an HTTPS server for the main router on the TLS listener
+func createTLSServer(cfg *config.Config, handler http.Handler, tlsConfig *tls.Config) *http.Server {
+	server := createServer(cfg, handler)
+	server.Addr = cfg.TLSListenAddr
+	server.TLSConfig = tlsConfig
+	return server
+}
+
+// listenerTLSConfig returns the TLS listener's configuration, serving the
+// certificate kept current by the ACME manager or the one in TLS_CERT_FILE,
+// and verifying client certificates against TLS_CLIENT_CA when set.
+// Returns nil if the TLS listener is disabled.
+func listenerTLSConfig(cfg *config.Config, certs certificateSource) (*tls.Config, error) {
+	if !cfg.TLSEnabled() || certs == nil {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.GetCertificate}
+
+	if cfg.TLSClientCA != "" {
+		bundle, err := os.ReadFile(cfg.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS_CLIENT_CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("TLS_CLIENT_CA %s contains no PEM certificates", cfg.TLSClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if cfg.TLSClientAuth == "required" {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return tlsConfig, nil
+}
+
+// tlsFingerprintSource returns where the TLS fingerprints tokens are pinned to
+// are read from: the header set by trusted TLS terminators, and the client
+// certificate on the TLS listener when it verifies them. Returns nil, leaving
+// pinning unenforced, when neither is configured.
+func tlsFingerprintSource(cfg *config.Config) *auth.FingerprintSource {
+	if cfg.TLSFingerprintHeader == "" && cfg.TLSClientCA == "" {
+		return nil
+	}
+	return &auth.FingerprintSource{Header: cfg.TLSFingerprintHeader, TrustedProxies: cfg.TLSFingerprintTrustedProxies}
+}
+
+// listenerRoles are the FileDescriptorName values of sockets passed by
+// systemd, in the order unnamed sockets are assigned.
+var listenerRoles = []string{"main", "metrics", "tls"}
+
+// activatedListeners assigns the sockets passed by systemd socket activation
+// to the main, metrics and TLS listeners, keyed by role. Sockets are matched
+// by FileDescriptorName; if none carries a role name, they are assigned in
+// order. All sockets are closed on error.
+func activatedListeners(passed []activation.Listener) (map[string]net.Listener, error) {
+	named := slices.ContainsFunc(passed, func(l activation.Listener) bool {
+		return slices.Contains(listenerRoles, l.Name)
+	})
+	listeners := make(map[string]net.Listener, len(passed))
+	for i, l := range passed {
+		role := l.Name
+		if !named && i < len(listenerRoles) {
+			role = listenerRoles[i]
+		}
+		if !slices.Contains(listenerRoles, role) || listeners[role] != nil {
+			for _, p := range passed {
+				_ = p.Close()
+			}
+			return nil, fmt.Errorf("unexpected socket %q passed by systemd: set FileDescriptorName to main, metrics or tls", l.Name)
+		}
+		listeners[role] = l.Listener
+	}
+	return listeners, nil
+}
+
+// serve serves on l when systemd passed a socket, otherwise on the server's address.
+func serve(server *http.Server, l net.Listener) error {
+	if l == nil {
+		return server.ListenAndServe()
+	}
+	return server.Serve(l)
+}
+
 // startServerAndWaitForShutdown starts the server and waits for shutdown signal or error
 func startServerAndWaitForShutdown(logger *slog.Logger, server *http.Server) error {
 	logger.Info("Server listening", "address", server.Addr)
@@ -240,8 +817,11 @@ func startServerAndWaitForShutdown(logger *slog.Logger, server *http.Server) err
 	return nil
 }
 
-// startServersAndWaitForShutdown starts the main and metrics servers, handles graceful shutdown for both
-func startServersAndWaitForShutdown(logger *slog.Logger, mainServer *http.Server, metricsServer *http.Server, metricsErrors chan error) error {
+// startServersAndWaitForShutdown starts the main and metrics servers, handles graceful shutdown for both.
+// The main server serves on mainListener if it is not nil. On a signal, drain
+// is called, if not nil, before the servers stop accepting connections.
+func startServersAndWaitForShutdown(logger *slog.Logger, mainServer *http.Server, mainListener net.Listener,
+	metricsServer *http.Server, metricsErrors chan error, drain func()) error {
 	logger.Info("Server listening", "address", mainServer.Addr)
 
 	// Channel to signal server shutdown
@@ -249,7 +829,7 @@ func startServersAndWaitForShutdown(logger *slog.Logger, mainServer *http.Server
 
 	// Start main server in a goroutine
 	go func() {
-		mainErrors <- mainServer.ListenAndServe()
+		mainErrors <- serve(mainServer, mainListener)
 	}()
 
 	// Wait for shutdown signal or server error
@@ -267,6 +847,9 @@ func startServersAndWaitForShutdown(logger *slog.Logger, mainServer *http.Server
 		}
 	case sig := <-sigChan:
 		logger.Info("Received signal, shutting down", "signal", sig.String())
+		if drain != nil {
+			drain()
+		}
 
 		// Graceful shutdown with timeout
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
@@ -314,51 +897,332 @@ func run() error {
 		}
 	}()
 
+	// Drain the event outbox, prune the audit log, flush token usage, vacuum
+	// the database, publish SLO burn rates, watch for resource leaks and full
+	// storage, send instance heartbeats, refresh the blocklist, zone freezes and
+	// TTL policies, sync zone tags, prune replay nonces and idempotency keys,
+	// run import jobs, make scheduled record changes, warm the response cache,
+	// reload settings on SIGHUP, verify the bunny.net API keys and pick up a
+	// key rotated in Vault in the background; workers are stopped before
+	// storage closes
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(18)
+	go func() {
+		defer workers.Done()
+		components.outbox.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.audit.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.usage.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.maintenance.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.slo.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.watchdog.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.diskGuard.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.instances.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.blocklist.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.freezes.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.ttlPolicies.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.zoneTags.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.replay.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.idempotency.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.jobs.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		components.schedule.Run(workerCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		warmResponseCache(workerCtx, cfg, components)
+	}()
+	go func() {
+		defer workers.Done()
+		watchReload(workerCtx, cfg, components)
+	}()
+	if components.keyChecker != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			components.keyChecker.Run(workerCtx)
+		}()
+	}
+	if components.apiKeyProvider != nil && cfg.VaultRefreshInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			config.WatchSecret(workerCtx, "BUNNY_API_KEY", components.apiKeyProvider, components.apiKey,
+				cfg.VaultRefreshInterval, rotateAPIKey(components), components.logger)
+		}()
+	}
+	defer func() {
+		stopWorkers()
+		workers.Wait()
+	}()
+
 	// Create servers
 	mainServer := createServer(cfg, components.mainRouter)
 	metricsServer := createMetricsServer(cfg, components.metricsRouter)
 
+	// Serve the sockets passed by systemd socket activation instead of
+	// binding the configured addresses
+	passed, err := activation.Listeners()
+	if err != nil {
+		return fmt.Errorf("socket activation failed: %w", err)
+	}
+	listeners, err := activatedListeners(passed)
+	if err != nil {
+		return err
+	}
+	for role, server := range map[string]*http.Server{"main": mainServer, "metrics": metricsServer} {
+		if l := listeners[role]; l != nil {
+			server.Addr = l.Addr().String()
+		}
+	}
+	if l := listeners["tls"]; l != nil && components.tlsConfig == nil {
+		components.logger.Warn("closing the tls socket passed by systemd: TLS is not configured")
+		_ = l.Close()
+	}
+
+	// Renew the ACME certificate and serve HTTPS alongside plain HTTP; the
+	// TLS listener is drained after the main servers have shut down
+	if components.certificates != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			components.certificates.Run(workerCtx)
+		}()
+	}
+	if components.tlsConfig != nil {
+		tlsServer := createTLSServer(cfg, components.mainRouter, components.tlsConfig)
+		tlsListener := listeners["tls"]
+		if tlsListener != nil {
+			tlsServer.Addr = tlsListener.Addr().String()
+		}
+		go func() {
+			components.logger.Info("TLS listener starting", "address", tlsServer.Addr, "domains", cfg.ACMEDomains,
+				"client_certificates", cfg.TLSClientCA != "")
+			var err error
+			if tlsListener != nil {
+				err = tlsServer.ServeTLS(tlsListener, "", "")
+			} else {
+				err = tlsServer.ListenAndServeTLS("", "")
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				components.logger.Error("TLS listener failed", "error", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+			defer cancel()
+			if err := tlsServer.Shutdown(shutdownCtx); err != nil { // coverage-ignore: shutdown errors during signal handling rarely occur in tests
+				components.logger.Error("TLS server shutdown failed", "error", err) // coverage-ignore: shutdown errors during signal handling rarely occur in tests
+			}
+		}()
+	}
+
 	// Start metrics server in a goroutine
 	metricsErrors := make(chan error, 1)
 	go func() {
 		components.logger.Info("Metrics listener starting", "address", metricsServer.Addr)
-		metricsErrors <- metricsServer.ListenAndServe()
+		metricsErrors <- serve(metricsServer, listeners["metrics"])
 	}()
 
-	// Start main server and handle graceful shutdown for both
-	return startServersAndWaitForShutdown(components.logger, mainServer, metricsServer, metricsErrors)
+	// Start main server and handle graceful shutdown for both. Readiness
+	// fails from the signal on, while requests are still served for the
+	// drain period
+	drain := func() {
+		components.shuttingDown.Store(true)
+		if cfg.ShutdownDrain > 0 {
+			components.logger.Info("Draining before shutdown", "delay", cfg.ShutdownDrain)
+			time.Sleep(cfg.ShutdownDrain)
+		}
+	}
+	return startServersAndWaitForShutdown(components.logger, mainServer, listeners["main"], metricsServer, metricsErrors, drain)
 }
 
-// healthHandler returns OK if the process is alive
-func healthHandler(w http.ResponseWriter, _ *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	//nolint:errcheck // Response write errors are unrecoverable
-	fmt.Fprint(w, `{"status":"ok"}`)
+// warmupHotZoneWindow is how far back audit entries count towards a zone
+// being prefetched first during cache warm-up.
+const warmupHotZoneWindow = 24 * time.Hour
+
+// warmResponseCache prefetches zones into the response cache when warm-up
+// is enabled, starting with the zones changed most often recently.
+func warmResponseCache(ctx context.Context, cfg *config.Config, c *serverComponents) {
+	if !cfg.CacheWarmupEnabled || cfg.ResponseCacheTTL <= 0 || cfg.CacheWarmupZones == 0 {
+		return
+	}
+
+	hotZones, err := c.store.ListHotZones(ctx, time.Now().Add(-warmupHotZoneWindow), cfg.CacheWarmupZones)
+	if err != nil {
+		c.logger.Warn("cache warm-up: failed to list hot zones", "error", err)
+	}
+	c.proxyHandler.WarmResponseCache(ctx, hotZones, cfg.CacheWarmupZones, cfg.CacheWarmupParallelism)
 }
 
-// readyHandler returns OK if the service is ready to serve requests (DB connected)
-func readyHandler(store storage.Storage) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Check database connectivity with a lightweight ping
-		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
-		defer cancel()
+// healthHandler reports that the process is alive, with its version. It runs
+// no checks, so liveness probes stay cheap.
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	health.Live(version)(w, r)
+}
 
-		// Test database connectivity with Ping (lightweight SELECT 1)
-		err := store.Ping(ctx)
+// readyHandler reports readiness as ok, degraded or down, with the version.
+// The database is always checked and marks the service down when
+// unavailable; extra checks only degrade it.
+func readyHandler(store storage.Storage, extra ...health.Check) http.HandlerFunc {
+	checks := append([]health.Check{{
+		Name:     "database",
+		Critical: true,
+		Run: func(ctx context.Context) error {
+			// Lightweight SELECT 1; the error itself may leak the DB path
+			if err := store.Ping(ctx); err != nil {
+				return errors.New("database unavailable")
+			}
+			return nil
+		},
+	}}, extra...)
+	checker := health.New(checks...)
+	checker.SetVersion(version)
+	return checker.ServeHTTP
+}
+
+// databaseOptions returns the storage options set by the configuration,
+// reading the encryption key from its file if one is given.
+func databaseOptions(cfg *config.Config) ([]storage.Option, error) {
+	opts := []storage.Option{
+		storage.WithBusyTimeout(cfg.DatabaseBusyTimeout),
+		storage.WithMaxReadConnections(cfg.DatabaseMaxReadConnections),
+	}
+
+	rawKey := cfg.DatabaseEncryptionKey
+	if cfg.DatabaseEncryptionKeyFile != "" {
+		data, err := os.ReadFile(cfg.DatabaseEncryptionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DATABASE_ENCRYPTION_KEY_FILE: %w", err)
+		}
+		rawKey = string(data)
+	}
+	if rawKey != "" {
+		key, err := storage.ParseEncryptionKey(rawKey)
 		if err != nil {
-			// Database error
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusServiceUnavailable)
-			//nolint:errcheck // Response write errors are unrecoverable
-			fmt.Fprint(w, `{"status":"not_ready","error":"database unavailable"}`)
-			return
+			return nil, fmt.Errorf("invalid database encryption key: %w", err)
 		}
+		opts = append(opts, storage.WithEncryptionKey(key))
+	}
+
+	if cfg.TokenHashIterations > 0 {
+		opts = append(opts, storage.WithTokenHashing(storage.TokenHashParams{
+			Memory:      uint32(cfg.TokenHashMemoryKiB),  //nolint:gosec // bounded by config
+			Iterations:  uint32(cfg.TokenHashIterations), //nolint:gosec // bounded by config
+			Parallelism: uint8(cfg.TokenHashParallelism), //nolint:gosec // bounded by config
+		}))
+	}
+	return opts, nil
+}
+
+// databaseDir returns the directory holding the database file, or "" for an
+// in-memory database, which has no volume to watch.
+func databaseDir(path string) string {
+	if path == "" || path == ":memory:" || strings.HasPrefix(path, "file:") {
+		return ""
+	}
+	return filepath.Dir(path)
+}
+
+// openAuditStream returns the destination of the audit stream: standard
+// output, standard error, or a file opened for appending.
+func openAuditStream(dest string) (io.Writer, error) {
+	switch dest {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// upstreamCheck checks that the bunny.net API is reachable. Results are
+// cached briefly so frequent probes do not turn into upstream traffic.
+func upstreamCheck(client *bunny.Client) health.Check {
+	return health.Check{
+		Name:     "upstream",
+		CacheFor: 10 * time.Second,
+		Run:      client.Ping,
+	}
+}
+
+// shutdownCheck fails readiness once shutdown has begun, so load balancers
+// stop routing to the instance while it drains.
+func shutdownCheck(shuttingDown *atomic.Bool) health.Check {
+	return health.Check{
+		Name:     "shutdown",
+		Critical: true,
+		Run: func(context.Context) error {
+			if shuttingDown.Load() {
+				return errors.New("shutting down")
+			}
+			return nil
+		},
+	}
+}
 
-		// Database is accessible
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		//nolint:errcheck // Response write errors are unrecoverable
-		fmt.Fprint(w, `{"status":"ok"}`)
+// bootstrapCheck reports the bootstrap state as its detail: "unconfigured"
+// while the master key may create the first admin token, then "configured",
+// or "expired" if the bootstrap window closed first. Every state is healthy;
+// only failing to read it degrades readiness.
+func bootstrapCheck(bootstrap *auth.BootstrapService) health.Check {
+	return health.Check{
+		Name:     "bootstrap",
+		CacheFor: 10 * time.Second,
+		RunDetail: func(ctx context.Context) (string, error) {
+			state, err := bootstrap.GetState(ctx)
+			if err != nil {
+				return "", errors.New("bootstrap state unavailable")
+			}
+			return strings.ToLower(state.String()), nil
+		},
 	}
 }
@@ -3,18 +3,37 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/sipico/bunny-api-proxy/internal/activation"
+	"github.com/sipico/bunny-api-proxy/internal/audit"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
 	"github.com/sipico/bunny-api-proxy/internal/config"
+	"github.com/sipico/bunny-api-proxy/internal/health"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
 
@@ -85,8 +104,8 @@ func TestReadyHandlerWithClosedStorage(t *testing.T) {
 	}
 
 	body := w.Body.String()
-	if !strings.Contains(body, `"status":"not_ready"`) {
-		t.Errorf("expected status not_ready in response, got %s", body)
+	if !strings.Contains(body, `"status":"down"`) {
+		t.Errorf("expected status down in response, got %s", body)
 	}
 }
 
@@ -124,6 +143,24 @@ func TestInitializeComponentsWithValidConfig(t *testing.T) {
 	if components.adminRouter == nil {
 		t.Error("adminRouter is nil")
 	}
+	if components.outbox == nil {
+		t.Error("outbox is nil")
+	}
+	if components.audit == nil {
+		t.Error("audit is nil")
+	}
+	if components.usage == nil {
+		t.Error("usage is nil")
+	}
+	if components.maintenance == nil {
+		t.Error("maintenance is nil")
+	}
+	if components.slo == nil {
+		t.Error("slo is nil")
+	}
+	if components.watchdog == nil {
+		t.Error("watchdog is nil")
+	}
 	if components.mainRouter == nil {
 		t.Error("mainRouter is nil")
 	}
@@ -235,6 +272,24 @@ func TestInitializeComponentsWithInvalidDataPath(t *testing.T) {
 	}
 }
 
+func TestInitializeComponentsVaultUnreachable(t *testing.T) {
+	vault := httptest.NewServer(http.NotFoundHandler())
+	vault.Close()
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_TOKEN", "hvs.token")
+	t.Setenv("VAULT_BUNNY_API_KEY_PATH", "secret/data/bunny")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	_, err = initializeComponents(cfg)
+	if err == nil || !strings.Contains(err.Error(), "from Vault") {
+		t.Fatalf("expected a Vault error, got: %v", err)
+	}
+}
+
 func TestRunWithInvalidLogLevel(t *testing.T) {
 	t.Setenv("DATABASE_PATH", ":memory:")
 	t.Setenv("LOG_LEVEL", "invalid_level")
@@ -376,10 +431,48 @@ func TestReadyHandlerResponseBody(t *testing.T) {
 
 	handler(w, req)
 
-	body := w.Body.String()
-	expectedBody := `{"status":"ok"}`
-	if body != expectedBody {
-		t.Errorf("expected body %s, got %s", expectedBody, body)
+	var report health.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	if report.Status != health.StatusOK || report.Version != version || len(report.Checks) != 1 ||
+		report.Checks["database"].Status != health.StatusOK {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestBootstrapCheck(t *testing.T) {
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	checker := health.New(bootstrapCheck(auth.NewBootstrapService(store, "master-key")))
+
+	got := checker.Check(context.Background()).Checks["bootstrap"]
+	if got.Status != health.StatusOK || got.Detail != "unconfigured" {
+		t.Errorf("unexpected bootstrap result: %+v", got)
+	}
+
+	// A broken database degrades the check without leaking the error
+	store.Close()
+	checker = health.New(bootstrapCheck(auth.NewBootstrapService(store, "master-key")))
+	report := checker.Check(context.Background())
+	if report.Status != health.StatusDegraded || report.Checks["bootstrap"].Error != "bootstrap state unavailable" {
+		t.Errorf("unexpected report with a closed database: %+v", report)
+	}
+}
+
+func TestShutdownCheck(t *testing.T) {
+	shuttingDown := new(atomic.Bool)
+	checker := health.New(shutdownCheck(shuttingDown))
+	if report := checker.Check(context.Background()); report.Status != health.StatusOK {
+		t.Errorf("expected ok before shutdown, got %+v", report)
+	}
+
+	shuttingDown.Store(true)
+	report := checker.Check(context.Background())
+	if report.Status != health.StatusDown || report.Checks["shutdown"].Error != "shutting down" {
+		t.Errorf("expected down while shutting down, got %+v", report)
 	}
 }
 
@@ -391,7 +484,7 @@ func TestHealthHandlerResponseBody(t *testing.T) {
 	healthHandler(w, req)
 
 	body := w.Body.String()
-	expectedBody := `{"status":"ok"}`
+	expectedBody := `{"status":"ok","version":"` + version + `"}` + "\n"
 	if body != expectedBody {
 		t.Errorf("expected body %s, got %s", expectedBody, body)
 	}
@@ -416,8 +509,8 @@ func TestReadyHandlerErrorResponseFormat(t *testing.T) {
 	}
 
 	body := w.Body.String()
-	if !strings.Contains(body, `"status":"not_ready"`) {
-		t.Errorf("expected not_ready status in response, got %s", body)
+	if !strings.Contains(body, `"status":"down"`) {
+		t.Errorf("expected down status in response, got %s", body)
 	}
 	if !strings.Contains(body, `"error":"database unavailable"`) {
 		t.Errorf("expected error message in response, got %s", body)
@@ -537,6 +630,582 @@ func TestInitializeComponentsReadyEndpoint(t *testing.T) {
 	}
 }
 
+// TestInitializeComponentsStorageDegraded validates that a disk-full write
+// failure makes the proxy read-only and degrades readiness
+func TestInitializeComponentsStorageDegraded(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("LOG_LEVEL", "info")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	components.diskGuard.ReportError(fmt.Errorf("append audit entry: %w", syscall.ENOSPC))
+
+	w := httptest.NewRecorder()
+	components.mainRouter.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/dnszone", strings.NewReader("{}")))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected write to return 503 while degraded, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	components.mainRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	var report health.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+	// The failing storage check is down, but it is not critical, so the proxy
+	// as a whole is only degraded and keeps receiving traffic
+	if w.Code != http.StatusOK || report.Status != health.StatusDegraded || report.Checks["storage"].Status != health.StatusDown {
+		t.Errorf("unexpected readiness while degraded: %d %+v", w.Code, report)
+	}
+}
+
+func TestDatabaseDir(t *testing.T) {
+	tests := map[string]string{
+		"/data/proxy.db": "/data",
+		"proxy.db":       ".",
+		":memory:":       "",
+		"file::memory:":  "",
+		"":               "",
+	}
+	for path, want := range tests {
+		if got := databaseDir(path); got != want {
+			t.Errorf("databaseDir(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestDatabaseOptions(t *testing.T) {
+	key := strings.Repeat("ab", 32)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(key+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		wantOpts int
+		wantErr  bool
+	}{
+		{"no key", config.Config{DatabaseMaxReadConnections: 4}, 2, false},
+		{"key", config.Config{DatabaseMaxReadConnections: 4, DatabaseEncryptionKey: key}, 3, false},
+		{"key file", config.Config{DatabaseMaxReadConnections: 4, DatabaseEncryptionKeyFile: keyFile}, 3, false},
+		{"token hashing", config.Config{DatabaseMaxReadConnections: 4, TokenHashMemoryKiB: 64, TokenHashIterations: 1, TokenHashParallelism: 1}, 3, false},
+		{"invalid key", config.Config{DatabaseEncryptionKey: "short"}, 0, true},
+		{"missing key file", config.Config{DatabaseEncryptionKeyFile: filepath.Join(t.TempDir(), "missing")}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := databaseOptions(&tt.cfg)
+			if (err != nil) != tt.wantErr || len(opts) != tt.wantOpts {
+				t.Errorf("databaseOptions() = %d options, %v", len(opts), err)
+			}
+		})
+	}
+}
+
+// TestInitializeComponentsStatusEndpoint validates that the SLO status endpoint is registered
+func TestInitializeComponentsStatusEndpoint(t *testing.T) {
+
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("LOG_LEVEL", "info")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	w := httptest.NewRecorder()
+	components.mainRouter.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status endpoint to return 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"objectives"`) {
+		t.Errorf("expected SLO objectives in status body, got %s", w.Body.String())
+	}
+}
+
+// TestInitializeComponentsInstanceID validates that the instance ID is reported in /status
+func TestInitializeComponentsInstanceID(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("LOG_LEVEL", "info")
+
+	for _, configured := range []string{"proxy-eu-1", ""} {
+		t.Setenv("INSTANCE_ID", configured)
+		cfg, err := config.Load()
+		if err != nil {
+			t.Fatalf("failed to load config: %v", err)
+		}
+
+		components, err := initializeComponents(cfg)
+		if err != nil {
+			t.Fatalf("failed to initialize components: %v", err)
+		}
+		id := components.instances.ID()
+		if (configured != "" && id != configured) || id == "" {
+			t.Errorf("expected instance ID %q, got %q", configured, id)
+		}
+
+		w := httptest.NewRecorder()
+		components.mainRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+		if !strings.Contains(w.Body.String(), `"instance_id":"`+id+`"`) {
+			t.Errorf("expected instance ID in status body, got %s", w.Body.String())
+		}
+		_ = components.store.Close()
+	}
+}
+
+func TestWarmResponseCache(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	mockAPIServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/dnszone" {
+			fmt.Fprint(w, `{"Items":[{"Id":1,"Domain":"example.com"}],"TotalItems":1}`)
+			return
+		}
+		fmt.Fprint(w, `{"Id":1,"Domain":"example.com","Records":[]}`)
+	}))
+	defer mockAPIServer.Close()
+
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("BUNNY_API_URL", mockAPIServer.URL)
+	t.Setenv("RESPONSE_CACHE_TTL_SECONDS", "60")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	// Disabled by default
+	warmResponseCache(context.Background(), cfg, components)
+	if len(requests) != 0 {
+		t.Fatalf("expected no upstream requests with warm-up disabled, got %v", requests)
+	}
+
+	cfg.CacheWarmupEnabled = true
+	warmResponseCache(context.Background(), cfg, components)
+	if requests["/dnszone"] != 1 || requests["/dnszone/1"] != 1 {
+		t.Errorf("expected zone list and zone 1 to be fetched once, got %v", requests)
+	}
+}
+
+func TestInitializeComponentsBlocklist(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	if _, err := components.blocklist.Add(context.Background(), "192.0.2.0/24", "test", "admin", 0); err != nil {
+		t.Fatalf("failed to add blocklist entry: %v", err)
+	}
+
+	// httptest requests come from 192.0.2.1; blocked before auth on every route
+	for _, path := range []string{"/dnszone", "/admin/api/whoami"} {
+		w := httptest.NewRecorder()
+		components.mainRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+func TestInitializeComponentsACME(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	if components.certificates != nil {
+		t.Error("expected no certificate manager when ACME_DOMAINS is unset")
+	}
+	_ = components.store.Close() //nolint:errcheck
+
+	t.Setenv("ACME_DOMAINS", "proxy.example.com")
+	t.Setenv("ACME_ACCESS_KEY", "acme-token")
+	t.Setenv("ACME_CERT_DIR", t.TempDir())
+	t.Setenv("TLS_LISTEN_ADDR", ":8443")
+	cfg, err = config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err = initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+	if components.certificates == nil {
+		t.Fatal("expected a certificate manager when ACME_DOMAINS is set")
+	}
+
+	server := createTLSServer(cfg, components.mainRouter, components.tlsConfig)
+	if server.Addr != ":8443" {
+		t.Errorf("expected TLS server address :8443, got %s", server.Addr)
+	}
+	if server.TLSConfig == nil || server.TLSConfig.GetCertificate == nil {
+		t.Fatal("expected TLS config to serve the managed certificate")
+	}
+	if _, err := server.TLSConfig.GetCertificate(nil); err == nil {
+		t.Error("expected no certificate before the first renewal")
+	}
+}
+
+// writeSelfSignedCert writes a self-signed certificate and its key as PEM
+// files in dir and returns their paths.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "proxy.internal"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestActivatedListeners(t *testing.T) {
+	t.Parallel()
+
+	listen := func(names ...string) []activation.Listener {
+		var passed []activation.Listener
+		for _, name := range names {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listen failed: %v", err)
+			}
+			t.Cleanup(func() { l.Close() })
+			passed = append(passed, activation.Listener{Name: name, Listener: l})
+		}
+		return passed
+	}
+
+	// Named sockets are matched by name
+	passed := listen("metrics", "main")
+	listeners, err := activatedListeners(passed)
+	if err != nil {
+		t.Fatalf("activatedListeners failed: %v", err)
+	}
+	if listeners["main"] != passed[1].Listener || listeners["metrics"] != passed[0].Listener || listeners["tls"] != nil {
+		t.Errorf("unexpected listeners %v", listeners)
+	}
+
+	// Sockets named after their unit are taken in order
+	passed = listen("bunny-api-proxy.socket", "bunny-api-proxy.socket")
+	if listeners, err = activatedListeners(passed); err != nil || listeners["main"] != passed[0].Listener ||
+		listeners["metrics"] != passed[1].Listener {
+		t.Errorf("unexpected listeners %v, %v", listeners, err)
+	}
+
+	if listeners, err = activatedListeners(nil); err != nil || len(listeners) != 0 {
+		t.Errorf("expected no listeners, got %v, %v", listeners, err)
+	}
+
+	for _, names := range [][]string{{"main", "admin"}, {"main", "main"}, {"a", "b", "c", "d"}} {
+		if _, err := activatedListeners(listen(names...)); err == nil {
+			t.Errorf("expected error for sockets %v", names)
+		}
+	}
+}
+
+func TestListenerTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir)
+
+	if tlsConfig, err := listenerTLSConfig(&config.Config{}, nil); err != nil || tlsConfig != nil {
+		t.Errorf("expected no TLS config without a certificate, got %v, %v", tlsConfig, err)
+	}
+
+	cfg := &config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientAuth: "optional"}
+	certs, err := loadCertificateFile(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("loadCertificateFile failed: %v", err)
+	}
+	tlsConfig, err := listenerTLSConfig(cfg, certs)
+	if err != nil {
+		t.Fatalf("listenerTLSConfig failed: %v", err)
+	}
+	if cert, _ := tlsConfig.GetCertificate(nil); cert == nil || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Errorf("expected the file certificate without client auth, got %+v", tlsConfig)
+	}
+
+	// The self-signed certificate doubles as the client CA
+	cfg.TLSClientCA = certFile
+	tlsConfig, err = listenerTLSConfig(cfg, certs)
+	if err != nil {
+		t.Fatalf("listenerTLSConfig failed: %v", err)
+	}
+	if tlsConfig.ClientCAs == nil || tlsConfig.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("expected optional client certificates, got %v", tlsConfig.ClientAuth)
+	}
+	cfg.TLSClientAuth = "required"
+	if tlsConfig, err = listenerTLSConfig(cfg, certs); err != nil || tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("expected required client certificates, got %v, %v", tlsConfig, err)
+	}
+
+	cfg.TLSClientCA = keyFile
+	if _, err := listenerTLSConfig(cfg, certs); err == nil {
+		t.Error("expected error for a client CA without certificates")
+	}
+	cfg.TLSClientCA = filepath.Join(dir, "missing.pem")
+	if _, err := listenerTLSConfig(cfg, certs); err == nil {
+		t.Error("expected error for a missing client CA")
+	}
+	if _, err := loadCertificateFile(certFile, certFile); err == nil {
+		t.Error("expected error for an invalid key pair")
+	}
+}
+
+func TestInitializeComponentsHoneypot(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("HONEYPOT_ENABLED", "true")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/admin/api/keys", http.StatusNotFound}, // decoy bans the client
+		{"/health", http.StatusForbidden},        // now rejected everywhere
+	} {
+		w := httptest.NewRecorder()
+		components.mainRouter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.want, w.Code)
+		}
+	}
+
+	entries, err := components.store.ListBlocklistEntries(context.Background())
+	if err != nil {
+		t.Fatalf("ListBlocklistEntries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].CIDR != "192.0.2.1/32" {
+		t.Errorf("expected the client to be blocklisted, got %+v", entries)
+	}
+	events, err := components.store.ListDueOutboxEvents(context.Background(), time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutboxEvents failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Event != "system.alert" {
+		t.Errorf("expected one system alert, got %+v", events)
+	}
+}
+
+func TestInitializeComponentsZoneTags(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("ZONE_TAG_RULES", "prod=*.prod.example.com")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+	if !components.zoneTags.Enabled() {
+		t.Error("expected zone tag sync to be enabled")
+	}
+
+	cfg.ZoneTagRules = []string{"Not A Tag=*.example.com"}
+	if _, err := initializeComponents(cfg); err == nil {
+		t.Error("expected error for an invalid zone tag rule")
+	}
+}
+
+func TestInitializeComponentsEndpointOverrides(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("BUNNY_API_URL_OVERRIDES", "statistics=https://stats.example.net")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	cfg.BunnyAPIURLOverrides = []string{"pullzones=https://stats.example.net"}
+	if _, err := initializeComponents(cfg); err == nil {
+		t.Error("expected error for an unknown endpoint class")
+	}
+}
+
+func TestInitializeComponentsTokensConfigFile(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("TEST_ADMIN_SECRET", "provisioned-admin-secret-0123456789abcdef")
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	content := `{"tokens": [{"name": "ops", "is_admin": true, "secret_env": "TEST_ADMIN_SECRET"}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TOKENS_CONFIG_FILE", path)
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+	if n, err := components.store.CountAdminTokens(context.Background()); err != nil || n != 1 {
+		t.Errorf("CountAdminTokens = %d, %v, want the provisioned admin token", n, err)
+	}
+
+	cfg.TokensConfigFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := initializeComponents(cfg); err == nil {
+		t.Error("expected error for a missing tokens config file")
+	}
+}
+
+func TestInitializeComponentsReplayProtection(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("REPLAY_PROTECTION", "required")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+	if !components.replay.Enabled() {
+		t.Error("expected replay protection to be enabled")
+	}
+
+	cfg.ReplayProtection = "always"
+	if _, err := initializeComponents(cfg); err == nil {
+		t.Error("expected error for an invalid replay protection mode")
+	}
+}
+
+func TestInitializeComponentsAuditStream(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	t.Setenv("AUDIT_STREAM", filepath.Join(t.TempDir(), "audit.jsonl"))
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	cfg.AuditStream = filepath.Join(t.TempDir(), "missing", "audit.jsonl")
+	if _, err := initializeComponents(cfg); err == nil {
+		t.Error("expected error for an audit stream in a missing directory")
+	}
+}
+
+func TestOpenAuditStream(t *testing.T) {
+	for dest, want := range map[string]io.Writer{"stdout": os.Stdout, "stderr": os.Stderr} {
+		if w, err := openAuditStream(dest); err != nil || w != want {
+			t.Errorf("openAuditStream(%q) = %v, %v", dest, w, err)
+		}
+	}
+}
+
+func TestInitializeComponentsAdminAudit(t *testing.T) {
+	t.Setenv("DATABASE_PATH", ":memory:")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	components, err := initializeComponents(cfg)
+	if err != nil {
+		t.Fatalf("failed to initialize components: %v", err)
+	}
+	defer components.store.Close()
+
+	ctx := context.Background()
+	if _, err := components.store.CreateToken(ctx, "admin", true, auth.HashToken("admin-key")); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodGet, "/admin/api/audit", nil)
+		req.Header.Set("AccessKey", "admin-key")
+		w := httptest.NewRecorder()
+		components.mainRouter.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+
+	entries, err := components.store.ListAuditEntries(ctx, storage.AuditFilter{Action: audit.ActionAdmin})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "/admin/api/audit" || entries[0].TokenName != "admin" {
+		t.Errorf("expected admin requests to be audited with their full path, got %+v", entries)
+	}
+}
+
 // TestInitializeComponentsValidatorCreated validates validator is created
 func TestInitializeComponentsValidatorCreated(t *testing.T) {
 
@@ -1081,6 +1750,7 @@ func TestCreateServerTimeouts(t *testing.T) {
 		expected time.Duration
 	}{
 		{"ReadTimeout", server.ReadTimeout, 15 * time.Second},
+		{"ReadHeaderTimeout", server.ReadHeaderTimeout, 10 * time.Second},
 		{"WriteTimeout", server.WriteTimeout, 15 * time.Second},
 		{"IdleTimeout", server.IdleTimeout, 60 * time.Second},
 	}
@@ -1090,6 +1760,15 @@ func TestCreateServerTimeouts(t *testing.T) {
 			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, tc.actual)
 		}
 	}
+
+	// Timeouts come from the configuration
+	t.Setenv("HTTP_WRITE_TIMEOUT_SECONDS", "120")
+	if cfg, err = config.Load(); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if server := createServer(cfg, handler); server.WriteTimeout != 2*time.Minute {
+		t.Errorf("WriteTimeout: expected 2m0s, got %v", server.WriteTimeout)
+	}
 }
 
 // TestStartServerAndWaitForShutdownWithServerError tests server shutdown when ListenAndServe returns error
@@ -1699,10 +2378,83 @@ func TestDoHealthCheck500Status(t *testing.T) {
 func TestRunHealthCheckUsesCorrectURL(t *testing.T) {
 	// This test verifies the function exists and returns 1 when no server is running
 	// on localhost:8080 (which should be the case during unit tests)
-	result := runHealthCheck()
+	result := runHealthCheck(nil)
 	if result != 1 {
 		t.Errorf("expected runHealthCheck to return 1 when no server is running, got %d", result)
 	}
+	if result := runHealthCheck([]string{"--ready"}); result != 1 {
+		t.Errorf("expected runHealthCheck --ready to return 1 when no server is running, got %d", result)
+	}
+	if result := runHealthCheck([]string{"--bogus"}); result != 1 {
+		t.Errorf("expected runHealthCheck to return 1 for an unknown flag, got %d", result)
+	}
+}
+
+// TestDoReadyCheck tests the exit code for each readiness status
+func TestDoReadyCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		body   string
+		strict bool
+		want   int
+	}{
+		{`{"status":"ok","checks":{}}`, false, 0},
+		{`{"status":"ok","checks":{}}`, true, 0},
+		{`{"status":"degraded","checks":{}}`, false, 0},
+		{`{"status":"degraded","checks":{}}`, true, 1},
+		{`{"status":"down","checks":{}}`, false, 1},
+		{`not json`, false, 1},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tt.body))
+		}))
+		var out strings.Builder
+		if got := doReadyCheck(server.URL, tt.strict, &out); got != tt.want {
+			t.Errorf("doReadyCheck(%s, strict=%v) = %d, want %d", tt.body, tt.strict, got, tt.want)
+		}
+		if tt.want == 0 && !strings.Contains(out.String(), `"status"`) {
+			t.Errorf("expected report on output, got %q", out.String())
+		}
+		server.Close()
+	}
+
+	if got := doReadyCheck("http://localhost:99999/ready", false, io.Discard); got != 1 {
+		t.Errorf("expected 1 for connection error, got %d", got)
+	}
+}
+
+// TestReadyHandlerUpstream tests that an unreachable upstream degrades readiness
+func TestReadyHandlerUpstream(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create test storage: %v", err)
+	}
+	defer store.Close()
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer upstream.Close()
+
+	client := bunny.NewClient("secret-key", bunny.WithBaseURL(upstream.URL))
+	handler := readyHandler(store, upstreamCheck(client))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 when degraded, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"status":"degraded"`) || !strings.Contains(body, `"upstream":{"status":"down"`) {
+		t.Errorf("expected degraded report with upstream down, got %s", body)
+	}
+	if strings.Contains(body, "secret-key") {
+		t.Errorf("report leaks the API key: %s", body)
+	}
 }
 
 // TestMainRouterDoesNotHaveMetricsEndpoint verifies that /metrics is not on the main router
@@ -1891,7 +2643,7 @@ func TestStartServersAndWaitForShutdownGraceful(t *testing.T) {
 	// Start both servers in a goroutine
 	done := make(chan error, 1)
 	go func() {
-		done <- startServersAndWaitForShutdown(logger, mainServer, metricsServer, metricsErrors)
+		done <- startServersAndWaitForShutdown(logger, mainServer, nil, metricsServer, metricsErrors, nil)
 	}()
 
 	// Give servers time to start
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/sipico/bunny-api-proxy/internal/config"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const migrateUsage = `usage: bunny-api-proxy migrate plan|verify [--db path]
+       bunny-api-proxy migrate down --to version [--db path]
+
+  plan    print the SQL this version would run against the database
+  verify  run it against a temporary copy and check the result
+  down    revert the migrations newer than --to, before downgrading
+
+The database defaults to DATABASE_PATH. plan and verify never modify it.
+`
+
+// runMigrate implements the migrate subcommand, so operators can review a
+// schema upgrade before starting a new version against production data.
+// Returns the process exit code.
+func runMigrate(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || (args[0] != "plan" && args[0] != "verify" && args[0] != "down") {
+		fmt.Fprint(stderr, migrateUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dbPath := fs.String("db", "", "database file (default DATABASE_PATH)")
+	to := -1
+	if args[0] == "down" {
+		fs.IntVar(&to, "to", -1, "schema version to migrate down to, 0 for an empty database")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if args[0] == "down" && to < 0 {
+		fmt.Fprint(stderr, migrateUsage)
+		return 2
+	}
+	if *dbPath == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+			return 1
+		}
+		*dbPath = cfg.DatabasePath
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "plan":
+		return migratePlan(ctx, *dbPath, stdout, stderr)
+	case "down":
+		return migrateDown(ctx, *dbPath, to, stdout, stderr)
+	}
+	return migrateVerify(ctx, *dbPath, stdout, stderr)
+}
+
+// runMigrateOnly implements --migrate-only: it applies pending migrations the
+// way startup does and exits, so upgrades can run as a separate deploy step.
+// Returns the process exit code.
+func runMigrateOnly(stdout, stderr io.Writer) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load configuration: %v\n", err)
+		return 1
+	}
+	opts, err := databaseOptions(cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	store, err := storage.New(cfg.DatabasePath, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "migration failed: %v\n", err)
+		return 1
+	}
+	if err := store.Close(); err != nil { // coverage-ignore: closing a freshly opened database does not fail
+		fmt.Fprintf(stderr, "failed to close database: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "%s: at schema version %d\n", cfg.DatabasePath, storage.SchemaVersion)
+	return 0
+}
+
+// migrateDown reverts migrations and lists them. Exits 1 on failure.
+func migrateDown(ctx context.Context, dbPath string, to int, stdout, stderr io.Writer) int {
+	reverted, err := storage.MigrateDown(ctx, dbPath, to)
+	for _, m := range reverted {
+		fmt.Fprintf(stdout, "reverted %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+	if len(reverted) == 0 {
+		fmt.Fprintf(stdout, "%s: already at or below schema version %d\n", dbPath, to)
+		return 0
+	}
+	fmt.Fprintf(stdout, "%s: reverted %d migrations\n", dbPath, len(reverted))
+	return 0
+}
+
+// migratePlan prints the pending statements as an SQL script.
+func migratePlan(ctx context.Context, dbPath string, stdout, stderr io.Writer) int {
+	plan, err := storage.PlanMigration(ctx, dbPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "-- %s: schema version %d\n", dbPath, storage.SchemaVersion)
+	if len(plan) == 0 {
+		fmt.Fprintln(stdout, "-- up to date, nothing to run")
+		return 0
+	}
+	for _, stmt := range plan {
+		fmt.Fprintf(stdout, "%s;\n", stmt)
+	}
+	return 0
+}
+
+// migrateVerify migrates a copy and reports the result. Exits 1 on any problem.
+func migrateVerify(ctx context.Context, dbPath string, stdout, stderr io.Writer) int {
+	report, err := storage.VerifyMigration(ctx, dbPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "%s: applied %d statements to a copy\n", dbPath, len(report.Applied))
+	tables := make([]string, 0, len(report.RowCounts))
+	for table := range report.RowCounts {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	for _, table := range tables {
+		fmt.Fprintf(stdout, "  %-20s %d rows\n", table, report.RowCounts[table])
+	}
+
+	if len(report.Problems) > 0 {
+		for _, p := range report.Problems {
+			fmt.Fprintf(stdout, "problem: %s\n", p)
+		}
+		return 1
+	}
+	fmt.Fprintln(stdout, "ok")
+	return 0
+}
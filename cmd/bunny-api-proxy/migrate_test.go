@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestRunMigrate(t *testing.T) {
+	dir := t.TempDir()
+	oldDB := filepath.Join(dir, "old.db")
+	db, err := sql.Open("sqlite", oldDB)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE tokens (id INTEGER PRIMARY KEY AUTOINCREMENT, key_hash TEXT NOT NULL UNIQUE, name TEXT NOT NULL, is_admin BOOLEAN NOT NULL DEFAULT FALSE, created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)"); err != nil {
+		t.Fatalf("failed to create tokens table: %v", err)
+	}
+	db.Close()
+
+	currentDB := filepath.Join(dir, "current.db")
+	store, err := storage.New(currentDB)
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	store.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		wantCode int
+		wantOut  string
+	}{
+		{"no action", nil, "", 2, ""},
+		{"unknown action", []string{"apply"}, "", 2, ""},
+		{"bad flag", []string{"plan", "--bogus"}, "", 2, ""},
+		{"plan old", []string{"plan", "--db", oldDB}, "", 0, "ALTER TABLE tokens ADD COLUMN expires_at"},
+		{"plan current", []string{"plan", "--db", currentDB}, "", 0, "up to date"},
+		{"plan from DATABASE_PATH", []string{"plan"}, currentDB, 0, "up to date"},
+		{"plan missing", []string{"plan", "--db", filepath.Join(dir, "missing.db")}, "", 1, ""},
+		{"verify old", []string{"verify", "--db", oldDB}, "", 0, "ok"},
+		{"verify missing", []string{"verify", "--db", filepath.Join(dir, "missing.db")}, "", 1, ""},
+		{"down without target", []string{"down", "--db", currentDB}, "", 2, ""},
+		{"down to current", []string{"down", "--to", strconv.Itoa(storage.SchemaVersion), "--db", currentDB}, "", 0, "already at or below"},
+		{"down before baseline", []string{"down", "--to", "3", "--db", currentDB}, "", 1, ""},
+		{"down without history", []string{"down", "--to", "0", "--db", oldDB}, "", 1, ""},
+		{"down missing", []string{"down", "--to", "0", "--db", filepath.Join(dir, "missing.db")}, "", 1, ""},
+		{"down to empty", []string{"down", "--to", "0", "--db", currentDB}, "", 0, "reverted 0019_baseline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DATABASE_PATH", tt.env)
+			var stdout, stderr strings.Builder
+			if code := runMigrate(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d (stderr: %s)", code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantOut) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestRunMigrateOnly(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "proxy.db")
+	t.Setenv("DATABASE_PATH", dbPath)
+
+	var stdout, stderr strings.Builder
+	if code := runMigrateOnly(&stdout, &stderr); code != 0 {
+		t.Fatalf("exit code = %d, want 0 (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "at schema version") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if plan, err := storage.PlanMigration(context.Background(), dbPath); err != nil || len(plan) != 0 {
+		t.Errorf("expected a migrated database, got plan %v, %v", plan, err)
+	}
+
+	t.Setenv("DATABASE_PATH", filepath.Join(t.TempDir(), "missing", "proxy.db"))
+	if code := runMigrateOnly(&stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1 for an unusable database path", code)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/sipico/bunny-api-proxy/internal/config"
+)
+
+// reloadable lists the settings applied by reloadConfig. Changes to other
+// settings take effect on the next restart.
+var reloadable = []string{"LOG_LEVEL", "BLOCKLIST_TRUST_FORWARDED_FOR"}
+
+// certificateSource provides the TLS listener's certificate.
+type certificateSource interface {
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// certificateFile serves the certificate in TLS_CERT_FILE and TLS_KEY_FILE,
+// which Reload reads again so renewed certificates are served without a
+// restart.
+type certificateFile struct {
+	certFile, keyFile string
+	cert              atomic.Pointer[tls.Certificate]
+}
+
+// loadCertificateFile reads a certificate and its key from PEM files.
+func loadCertificateFile(certFile, keyFile string) (*certificateFile, error) {
+	c := &certificateFile{certFile: certFile, keyFile: keyFile}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the files again. The previous certificate is kept on error.
+func (c *certificateFile) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate returns the current certificate.
+func (c *certificateFile) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// watchReload reloads the configuration on SIGHUP until ctx is cancelled.
+func watchReload(ctx context.Context, cfg *config.Config, c *serverComponents) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if next, err := reloadConfig(cfg, config.Load, c); err != nil {
+				c.logger.Error("configuration reload failed", "error", err)
+			} else {
+				cfg = next
+			}
+		}
+	}
+}
+
+// reloadConfig loads the configuration again and applies the settings that
+// can change while serving: the log level, whether the blocklist trusts
+// X-Forwarded-For, rotated bunny.net API keys, and the certificate in
+// TLS_CERT_FILE, which is read again even when the setting is unchanged. In-flight requests are not affected.
+// Nothing is applied if the new configuration is invalid. It returns the
+// configuration now in effect.
+func reloadConfig(current *config.Config, load func() (*config.Config, error), c *serverComponents) (*config.Config, error) {
+	cfg, err := load()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		return current, fmt.Errorf("invalid configuration: %w", err)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return current, fmt.Errorf("invalid log level %q: %w", cfg.LogLevel, err)
+	}
+
+	c.logLevel.Set(level)
+	c.blocklist.SetTrustForwardedFor(cfg.BlocklistTrustForwardedFor)
+	if c.certFile != nil {
+		if err := c.certFile.Reload(); err != nil {
+			c.logger.Error("TLS certificate reload failed; serving the previous certificate", "error", err)
+		}
+	}
+
+	rotated := rotateKeys(current, cfg, c)
+
+	var changed, needRestart []string
+	before := current.Describe()
+	for i, s := range cfg.Describe() {
+		if s.Value == before[i].Value {
+			continue
+		}
+		if slices.Contains(reloadable, s.Name) || slices.Contains(rotated, s.Name) {
+			changed = append(changed, s.Name)
+		} else {
+			needRestart = append(needRestart, s.Name)
+		}
+	}
+	c.logger.Info("configuration reloaded", "changed", changed, "tls_certificate", c.certFile != nil)
+	if len(needRestart) > 0 {
+		c.logger.Warn("changed settings take effect after a restart", "settings", needRestart)
+	}
+
+	// Settings that need a restart keep their running values
+	next := *current
+	next.LogLevel, next.BlocklistTrustForwardedFor = cfg.LogLevel, cfg.BlocklistTrustForwardedFor
+	if slices.Contains(rotated, "BUNNY_API_KEY") {
+		next.BunnyAPIKey = cfg.BunnyAPIKey
+	}
+	if slices.Contains(rotated, "BUNNY_ACCOUNTS") {
+		next.BunnyAccounts = cfg.BunnyAccounts
+	}
+	return &next, nil
+}
+
+// rotateKeys applies the bunny.net API keys that changed in cfg: the default
+// account's, unless it is read from Vault, and those of further accounts.
+// Adding or removing accounts needs a restart. It returns the settings it
+// applied.
+func rotateKeys(current, cfg *config.Config, c *serverComponents) []string {
+	var rotated []string
+	if c.apiKeyProvider == nil && cfg.BunnyAPIKey != current.BunnyAPIKey {
+		rotateAPIKey(c)(cfg.BunnyAPIKey)
+		rotated = append(rotated, "BUNNY_API_KEY")
+	}
+	if !maps.Equal(cfg.BunnyAccounts, current.BunnyAccounts) &&
+		slices.Equal(slices.Sorted(maps.Keys(cfg.BunnyAccounts)), slices.Sorted(maps.Keys(current.BunnyAccounts))) {
+		for name, key := range cfg.BunnyAccounts {
+			if key != current.BunnyAccounts[name] {
+				c.accounts[name].SetAPIKey(key)
+				c.logger.Info("bunny.net API key rotated", "account", name)
+			}
+		}
+		rotated = append(rotated, "BUNNY_ACCOUNTS")
+	}
+	return rotated
+}
+
+// rotateAPIKey returns the function that applies a rotated BUNNY_API_KEY:
+// later upstream requests send it, and it replaces the old key as the
+// bootstrap master key.
+func rotateAPIKey(c *serverComponents) func(string) {
+	return func(key string) {
+		c.bunnyClient.SetAPIKey(key)
+		c.bootstrapService.SetMasterKey(key)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/blocklist"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/config"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func newReloadComponents() *serverComponents {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &serverComponents{
+		logger:    logger,
+		logLevel:  new(slog.LevelVar),
+		blocklist: blocklist.New(&mockstore.MockStorage{}, logger),
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	current := &config.Config{BunnyAPIKey: "test-key", LogLevel: "info", ListenAddr: ":8080"}
+	c := newReloadComponents()
+
+	next, err := reloadConfig(current, func() (*config.Config, error) {
+		return &config.Config{BunnyAPIKey: "test-key", LogLevel: "debug", ListenAddr: ":9090",
+			BlocklistTrustForwardedFor: true}, nil
+	}, c)
+	if err != nil {
+		t.Fatalf("reloadConfig failed: %v", err)
+	}
+	if c.logLevel.Level() != slog.LevelDebug {
+		t.Errorf("expected debug log level, got %s", c.logLevel.Level())
+	}
+	r := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+	r.RemoteAddr = "198.51.100.1:1234"
+	r.Header.Set("X-Forwarded-For", "192.0.2.1")
+	if got, _ := c.blocklist.ClientIP(r); got.String() != "192.0.2.1" {
+		t.Errorf("expected the blocklist to trust X-Forwarded-For, got %s", got)
+	}
+	// Settings that need a restart keep their running values
+	if next.LogLevel != "debug" || !next.BlocklistTrustForwardedFor || next.ListenAddr != ":8080" {
+		t.Errorf("unexpected configuration in effect: %+v", next)
+	}
+}
+
+func TestReloadConfig_Invalid(t *testing.T) {
+	current := &config.Config{BunnyAPIKey: "test-key", LogLevel: "info"}
+	loads := map[string]func() (*config.Config, error){
+		"load error":    func() (*config.Config, error) { return nil, errors.New("bad file") },
+		"failed checks": func() (*config.Config, error) { return &config.Config{LogLevel: "debug"}, nil },
+		"bad log level": func() (*config.Config, error) { return &config.Config{BunnyAPIKey: "k", LogLevel: "loud"}, nil },
+	}
+	for name, load := range loads {
+		c := newReloadComponents()
+		next, err := reloadConfig(current, load, c)
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if next != current || c.logLevel.Level() != slog.LevelInfo {
+			t.Errorf("%s: expected nothing to be applied", name)
+		}
+	}
+}
+
+// accessKeys serves empty zone lists and records the AccessKey of each
+// request.
+type accessKeys struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (a *accessKeys) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	a.keys = append(a.keys, r.Header.Get("AccessKey"))
+	a.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"Items":[]}`)
+}
+
+// last returns the AccessKey of the latest request.
+func (a *accessKeys) last() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.keys) == 0 {
+		return ""
+	}
+	return a.keys[len(a.keys)-1]
+}
+
+// sentKey makes a request with client and returns the AccessKey it sent.
+func sentKey(t *testing.T, client *bunny.Client, keys *accessKeys) string {
+	t.Helper()
+	if _, err := client.ListZones(context.Background(), nil); err != nil {
+		t.Fatalf("ListZones failed: %v", err)
+	}
+	return keys.last()
+}
+
+// newRotationComponents returns reload components with a default and an "eu"
+// account client sending requests to server.
+func newRotationComponents(server *httptest.Server) *serverComponents {
+	c := newReloadComponents()
+	c.bunnyClient = bunny.NewClient("old-key", bunny.WithBaseURL(server.URL))
+	c.accounts = map[string]*bunny.Client{"eu": bunny.NewClient("eu-old-key", bunny.WithBaseURL(server.URL))}
+	c.bootstrapService = auth.NewBootstrapService(&mockstore.MockStorage{}, "old-key")
+	return c
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	keys := &accessKeys{}
+	server := httptest.NewServer(keys)
+	t.Cleanup(server.Close)
+	c := newRotationComponents(server)
+
+	rotateAPIKey(c)("new-key")
+	if got := sentKey(t, c.bunnyClient, keys); got != "new-key" {
+		t.Errorf("AccessKey = %q, want the rotated key", got)
+	}
+	if c.bootstrapService.IsMasterKey("old-key") {
+		t.Error("expected the rotated-out key to stop working as the master key")
+	}
+	if !c.bootstrapService.IsMasterKey("new-key") {
+		t.Error("expected the rotated key to work as the master key")
+	}
+}
+
+func TestReloadConfig_RotatesKeys(t *testing.T) {
+	keys := &accessKeys{}
+	server := httptest.NewServer(keys)
+	t.Cleanup(server.Close)
+	c := newRotationComponents(server)
+
+	current := &config.Config{BunnyAPIKey: "old-key", BunnyAccounts: map[string]string{"eu": "eu-old-key"}, LogLevel: "info"}
+	next, err := reloadConfig(current, func() (*config.Config, error) {
+		return &config.Config{BunnyAPIKey: "new-key", BunnyAccounts: map[string]string{"eu": "eu-new-key"}, LogLevel: "info"}, nil
+	}, c)
+	if err != nil {
+		t.Fatalf("reloadConfig failed: %v", err)
+	}
+	if got := sentKey(t, c.bunnyClient, keys); got != "new-key" {
+		t.Errorf("default account AccessKey = %q, want the rotated key", got)
+	}
+	if got := sentKey(t, c.accounts["eu"], keys); got != "eu-new-key" {
+		t.Errorf("eu account AccessKey = %q, want the rotated key", got)
+	}
+	if c.bootstrapService.IsMasterKey("old-key") || !c.bootstrapService.IsMasterKey("new-key") {
+		t.Error("expected the master key to follow BUNNY_API_KEY")
+	}
+	if next.BunnyAPIKey != "new-key" || next.BunnyAccounts["eu"] != "eu-new-key" {
+		t.Errorf("expected the rotated keys to be in effect, got %+v", next)
+	}
+
+	// Adding an account needs a restart, so no key is applied
+	next, err = reloadConfig(next, func() (*config.Config, error) {
+		return &config.Config{BunnyAPIKey: "new-key", LogLevel: "info",
+			BunnyAccounts: map[string]string{"eu": "eu-newer-key", "us": "us-key"}}, nil
+	}, c)
+	if err != nil {
+		t.Fatalf("reloadConfig failed: %v", err)
+	}
+	if got := sentKey(t, c.accounts["eu"], keys); got != "eu-new-key" {
+		t.Errorf("eu account AccessKey = %q, want it unchanged", got)
+	}
+	if len(next.BunnyAccounts) != 1 {
+		t.Errorf("expected the running accounts to be kept, got %v", next.BunnyAccounts)
+	}
+}
+
+func TestCertificateFileReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir)
+	certs, err := loadCertificateFile(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("loadCertificateFile failed: %v", err)
+	}
+	first, _ := certs.GetCertificate(&tls.ClientHelloInfo{})
+
+	// A renewed certificate is served after a reload
+	renewedCert, renewedKey := writeSelfSignedCert(t, t.TempDir())
+	for src, dst := range map[string]string{renewedCert: certFile, renewedKey: keyFile} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", src, err)
+		}
+		if err := os.WriteFile(dst, data, 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", dst, err)
+		}
+	}
+	if err := certs.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	renewed, _ := certs.GetCertificate(&tls.ClientHelloInfo{})
+	if renewed == first {
+		t.Error("expected the renewed certificate after a reload")
+	}
+
+	// A broken file keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := certs.Reload(); err == nil {
+		t.Error("expected error for an invalid key")
+	}
+	if cert, _ := certs.GetCertificate(&tls.ClientHelloInfo{}); cert != renewed {
+		t.Error("expected the previous certificate to be kept")
+	}
+}
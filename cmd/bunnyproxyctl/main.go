@@ -0,0 +1,216 @@
+// Command bunnyproxyctl administers a running bunny-api-proxy through its
+// admin and proxy APIs: tokens, permissions, zones and records.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+)
+
+const usage = `usage: bunnyproxyctl [--url URL] [--config FILE] [--json] <command> [arguments]
+
+commands:
+  token list
+  token create --name NAME [--admin] [--zones IDS --actions ACTIONS --record-types TYPES] [--roles IDS] [--expires DURATION|RFC3339]
+  token delete ID
+  token rotate ID [--keep-old]
+  permission add TOKEN-ID --zone ID --actions ACTIONS [--record-types TYPES] [--resource dnszone|pullzone|storagezone]
+  permission remove TOKEN-ID PERMISSION-ID
+  zone list [--search TEXT] [--page N] [--per-page N]
+  record add ZONE-ID --type TYPE --name NAME --value VALUE [--ttl SECONDS]
+  record delete ZONE-ID RECORD-ID
+
+The proxy URL and admin token are read from BUNNYPROXY_URL and BUNNYPROXY_TOKEN,
+or from the config file, a JSON object {"url": ..., "token": ...}. The config
+file defaults to bunnyproxyctl/config.json in the user config directory.
+Lists are comma-separated.
+`
+
+// settings are the connection settings in a config file.
+type settings struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// command runs a subcommand with its arguments.
+type command func(env *environment, args []string) error
+
+// environment is what subcommands run with.
+type environment struct {
+	client *client.Client
+	stdout io.Writer
+	json   bool
+}
+
+// errUsage reports invalid arguments; the usage text has been printed.
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run parses the global flags, connects and runs the subcommand.
+// Returns the process exit code: 2 for invalid arguments, 1 for failures.
+func run(args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("bunnyproxyctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+	proxyURL := fs.String("url", "", "proxy URL (default BUNNYPROXY_URL)")
+	configPath := fs.String("config", "", "config file")
+	asJSON := fs.Bool("json", false, "print JSON instead of tables")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 2 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)+" "+fs.Arg(1)]
+	if !ok {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	conn, err := loadSettings(*configPath, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "bunnyproxyctl: %v\n", err)
+		return 1
+	}
+	if *proxyURL != "" {
+		conn.URL = *proxyURL
+	}
+	if conn.URL == "" || conn.Token == "" {
+		fmt.Fprintln(stderr, "bunnyproxyctl: set BUNNYPROXY_URL and BUNNYPROXY_TOKEN, or url and token in the config file")
+		return 2
+	}
+	c, err := client.New(conn.URL, conn.Token, client.WithUserAgent("bunnyproxyctl"))
+	if err != nil {
+		fmt.Fprintf(stderr, "bunnyproxyctl: %v\n", err)
+		return 2
+	}
+
+	err = cmd(&environment{client: c, stdout: stdout, json: *asJSON}, fs.Args()[2:])
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprint(stderr, usage)
+		return 2
+	case err != nil:
+		fmt.Fprintf(stderr, "bunnyproxyctl: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// commands maps "<noun> <verb>" to its implementation.
+var commands = map[string]command{
+	"token list":        tokenList,
+	"token create":      tokenCreate,
+	"token delete":      tokenDelete,
+	"token rotate":      tokenRotate,
+	"permission add":    permissionAdd,
+	"permission remove": permissionRemove,
+	"zone list":         zoneList,
+	"record add":        recordAdd,
+	"record delete":     recordDelete,
+}
+
+// loadSettings reads the config file, if any, and overrides it with the
+// environment. A missing default config file is not an error.
+func loadSettings(path string, getenv func(string) string) (settings, error) {
+	var s settings
+	explicit := path != ""
+	if !explicit {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "bunnyproxyctl", "config.json")
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &s); err != nil {
+				return s, fmt.Errorf("invalid config file %s: %w", path, err)
+			}
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return s, err
+		}
+	}
+	if v := getenv("BUNNYPROXY_URL"); v != "" {
+		s.URL = v
+	}
+	if v := getenv("BUNNYPROXY_TOKEN"); v != "" {
+		s.Token = v
+	}
+	return s, nil
+}
+
+// parseArgs parses a subcommand's flags, which may come before or after its
+// positional arguments, and returns the positional arguments. It fails
+// unless there are exactly want of them.
+func parseArgs(fs *flag.FlagSet, args []string, want int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(positional) != want {
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// parseID parses a positive ID argument.
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return id, nil
+}
+
+// parseIDs parses a comma-separated list of IDs; empty gives nil.
+func parseIDs(s string) ([]int64, error) {
+	var ids []int64
+	for _, part := range splitList(s) {
+		id, err := parseID(part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// printJSON writes v as indented JSON.
+func (e *environment) printJSON(v any) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+)
+
+// fakeProxy records requests and answers them from a table keyed by
+// "METHOD path".
+type fakeProxy struct {
+	mu        sync.Mutex
+	requests  []string
+	bodies    map[string]string
+	responses map[string]string
+}
+
+func newFakeProxy(t *testing.T, responses map[string]string) (*fakeProxy, string) {
+	t.Helper()
+	f := &fakeProxy{bodies: map[string]string{}, responses: responses}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.Path
+		var body strings.Builder
+		if r.Body != nil {
+			buf := make([]byte, 4096)
+			n, _ := r.Body.Read(buf)
+			body.Write(buf[:n])
+		}
+		f.mu.Lock()
+		f.requests = append(f.requests, key)
+		f.bodies[key] = body.String()
+		f.mu.Unlock()
+
+		if r.Header.Get("AccessKey") != "admin-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, ok := f.responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"Not found"}`)) //nolint:errcheck
+			return
+		}
+		if resp == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(resp)) //nolint:errcheck
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+// emptyConfig writes an empty config file, so tests do not read the user's.
+func emptyConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runCtl runs the command against url with the admin key.
+func runCtl(t *testing.T, url string, args ...string) (int, string, string) {
+	t.Helper()
+	env := map[string]string{"BUNNYPROXY_URL": url, "BUNNYPROXY_TOKEN": "admin-key"}
+	var stdout, stderr strings.Builder
+	code := run(append([]string{"--config", emptyConfig(t)}, args...), &stdout, &stderr, func(k string) string { return env[k] })
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	t.Parallel()
+	_, url := newFakeProxy(t, nil)
+	for _, args := range [][]string{
+		nil,
+		{"token"},
+		{"token", "frobnicate"},
+		{"token", "delete"},
+		{"token", "delete", "1", "2"},
+		{"token", "create"},
+		{"record", "add", "5", "--type", "TXT"},
+		{"--bogus", "token", "list"},
+	} {
+		if code, _, _ := runCtl(t, url, args...); code != 2 {
+			t.Errorf("%q: exit code %d, want 2", args, code)
+		}
+	}
+
+	var stderr strings.Builder
+	if code := run([]string{"--config", emptyConfig(t), "token", "list"}, &stderr, &stderr, func(string) string { return "" }); code != 2 {
+		t.Errorf("without a URL: exit code %d, want 2", code)
+	}
+}
+
+func TestRun_TokenCommands(t *testing.T) {
+	t.Parallel()
+	f, url := newFakeProxy(t, map[string]string{
+		"GET /admin/api/tokens":                `[{"id":1,"name":"ops","is_admin":true,"created_at":"2026-01-15T09:12:03Z","use_count":3}]`,
+		"POST /admin/api/tokens":               `{"id":4,"name":"ci","token":"4.secret","is_admin":false}`,
+		"DELETE /admin/api/tokens/4":           ``,
+		"POST /admin/api/tokens/4/permissions": `{"id":9,"resource":"dnszone","zone_id":12,"allowed_actions":["list_records"],"record_types":["TXT"]}`,
+	})
+
+	code, out, stderr := runCtl(t, url, "token", "list")
+	if code != 0 || !strings.Contains(out, "ops") || !strings.Contains(out, "admin") {
+		t.Errorf("token list: %d %q %q", code, out, stderr)
+	}
+
+	code, out, _ = runCtl(t, url, "token", "create", "--name", "ci", "--zones", "12,13", "--actions", "list_records", "--record-types", "TXT", "--expires", "24h")
+	if code != 0 || !strings.Contains(out, "key: 4.secret") {
+		t.Errorf("token create: %d %q", code, out)
+	}
+	var body map[string]any
+	if err := json.Unmarshal([]byte(f.bodies["POST /admin/api/tokens"]), &body); err != nil || body["expires_at"] == nil || len(body["zones"].([]any)) != 2 {
+		t.Errorf("unexpected create body: %s", f.bodies["POST /admin/api/tokens"])
+	}
+
+	if code, out, _ = runCtl(t, url, "permission", "add", "4", "--zone", "12", "--actions", "list_records", "--record-types", "TXT"); code != 0 || !strings.Contains(out, "added permission 9") {
+		t.Errorf("permission add: %d %q", code, out)
+	}
+	if code, out, _ = runCtl(t, url, "token", "delete", "4"); code != 0 || !strings.Contains(out, "deleted token 4") {
+		t.Errorf("token delete: %d %q", code, out)
+	}
+	if code, _, stderr = runCtl(t, url, "token", "delete", "5"); code != 1 || !strings.Contains(stderr, "Not found") {
+		t.Errorf("deleting a missing token: %d %q", code, stderr)
+	}
+	if code, out, _ = runCtl(t, url, "--json", "token", "list"); code != 0 || !strings.Contains(out, `"use_count": 3`) {
+		t.Errorf("token list --json: %d %q", code, out)
+	}
+}
+
+func TestRun_TokenRotate(t *testing.T) {
+	t.Parallel()
+	f, url := newFakeProxy(t, map[string]string{
+		"GET /admin/api/tokens/2": `{"id":2,"name":"acme","is_admin":false,"account":"eu",
+			"permissions":[{"ID":5,"ZoneID":12,"AllowedActions":["add_record"],"RecordTypes":["TXT"]},
+			{"ID":6,"Resource":"pullzone","ZoneID":40,"AllowedActions":["purge_pullzone"]}]}`,
+		"POST /admin/api/tokens":               `{"id":7,"name":"acme","token":"7.new","is_admin":false}`,
+		"POST /admin/api/tokens/7/permissions": `{"id":11,"resource":"pullzone","zone_id":40}`,
+		"DELETE /admin/api/tokens/2":           ``,
+	})
+
+	code, out, stderr := runCtl(t, url, "token", "rotate", "2")
+	if code != 0 || !strings.Contains(out, "key: 7.new") {
+		t.Fatalf("token rotate: %d %q %q", code, out, stderr)
+	}
+	want := []string{"GET /admin/api/tokens/2", "POST /admin/api/tokens", "POST /admin/api/tokens/7/permissions", "DELETE /admin/api/tokens/2"}
+	if strings.Join(f.requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %q, want %q", f.requests, want)
+	}
+	if body := f.bodies["POST /admin/api/tokens"]; !strings.Contains(body, `"zones":[12]`) || !strings.Contains(body, `"account":"eu"`) {
+		t.Errorf("unexpected create body: %s", body)
+	}
+}
+
+func TestRun_TokenRotate_CleansUp(t *testing.T) {
+	t.Parallel()
+	f, url := newFakeProxy(t, map[string]string{
+		"GET /admin/api/tokens/2": `{"id":2,"name":"acme","permissions":[{"ID":5,"ZoneID":12,"AllowedActions":["add_record"],"RecordTypes":["TXT"]},
+			{"ID":6,"ZoneID":13,"AllowedActions":["add_record"],"RecordTypes":["TXT"]}]}`,
+		"POST /admin/api/tokens":     `{"id":7,"name":"acme","token":"7.new"}`,
+		"DELETE /admin/api/tokens/7": ``,
+	})
+
+	if code, _, stderr := runCtl(t, url, "token", "rotate", "2"); code != 1 || !strings.Contains(stderr, "copying permission 6") {
+		t.Errorf("expected the rotation to fail: %d %q", code, stderr)
+	}
+	if last := f.requests[len(f.requests)-1]; last != "DELETE /admin/api/tokens/7" {
+		t.Errorf("expected the new token to be removed, last request %q", last)
+	}
+}
+
+func TestRun_RecordCommands(t *testing.T) {
+	t.Parallel()
+	f, url := newFakeProxy(t, map[string]string{
+		"GET /dnszone":                  `{"CurrentPage":1,"TotalItems":1,"Items":[{"Id":12,"Domain":"example.com","Records":[{"Id":1}]}]}`,
+		"POST /dnszone/12/records":      `{"Id":99,"Type":3,"Name":"_acme-challenge","Value":"abc"}`,
+		"DELETE /dnszone/12/records/99": ``,
+	})
+
+	if code, out, _ := runCtl(t, url, "zone", "list"); code != 0 || !strings.Contains(out, "example.com") {
+		t.Errorf("zone list: %d %q", code, out)
+	}
+	code, out, _ := runCtl(t, url, "record", "add", "12", "--type", "txt", "--name", "_acme-challenge", "--value", "abc", "--ttl", "60")
+	if code != 0 || !strings.Contains(out, "added record 99: TXT") {
+		t.Errorf("record add: %d %q", code, out)
+	}
+	if body := f.bodies["POST /dnszone/12/records"]; !strings.Contains(body, `"Type":3`) || !strings.Contains(body, `"Ttl":60`) {
+		t.Errorf("unexpected record body: %s", body)
+	}
+	if code, _, stderr := runCtl(t, url, "record", "add", "12", "--type", "BOGUS", "--value", "x"); code != 1 || !strings.Contains(stderr, "unknown record type") {
+		t.Errorf("unknown type: %d %q", code, stderr)
+	}
+	if code, out, _ := runCtl(t, url, "record", "delete", "12", "99"); code != 0 || !strings.Contains(out, "deleted record 99") {
+		t.Errorf("record delete: %d %q", code, out)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"url":"https://file.example.com","token":"file-token"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := loadSettings(path, func(k string) string {
+		if k == "BUNNYPROXY_TOKEN" {
+			return "env-token"
+		}
+		return ""
+	})
+	if err != nil || s.URL != "https://file.example.com" || s.Token != "env-token" {
+		t.Errorf("loadSettings() = %+v, %v; want the file URL and the environment token", s, err)
+	}
+
+	if _, err := loadSettings(filepath.Join(dir, "missing.json"), func(string) string { return "" }); err == nil {
+		t.Error("expected an error for a missing explicit config file")
+	}
+	if err := os.WriteFile(path, []byte(`not json`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadSettings(path, func(string) string { return "" }); err == nil {
+		t.Error("expected an error for an invalid config file")
+	}
+}
+
+func TestRotationRequest(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	admin := &client.TokenDetail{Token: client.Token{ID: 1, Name: "ops", IsAdmin: true}}
+	if req, rest, err := rotationRequest(admin, now); err != nil || !req.IsAdmin || rest != nil {
+		t.Errorf("admin token: %+v %v %v", req, rest, err)
+	}
+
+	expired := &client.TokenDetail{Token: client.Token{ID: 2, ExpiresAt: now.Add(-time.Hour)}}
+	if _, _, err := rotationRequest(expired, now); err == nil {
+		t.Error("expected expired tokens to be refused")
+	}
+
+	pullOnly := &client.TokenDetail{Token: client.Token{ID: 3}, Permissions: []client.Permission{{Resource: "pullzone", ZoneID: 4}}}
+	if _, _, err := rotationRequest(pullOnly, now); err == nil {
+		t.Error("expected tokens without a DNS zone permission or role to be refused")
+	}
+
+	withRole := &client.TokenDetail{Token: client.Token{ID: 5, ExpiresAt: now.Add(time.Hour)}, Roles: []client.Role{{ID: 8}}}
+	req, _, err := rotationRequest(withRole, now)
+	if err != nil || len(req.Roles) != 1 || req.ExpiresAt == nil || !req.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("token with a role: %+v %v", req, err)
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, err := parseExpiry("48h", now); err != nil || !got.Equal(now.Add(48*time.Hour)) {
+		t.Errorf("parseExpiry(48h) = %v, %v", got, err)
+	}
+	if got, err := parseExpiry("2026-06-01T00:00:00Z", now); err != nil || got.Month() != time.June {
+		t.Errorf("parseExpiry(RFC3339) = %v, %v", got, err)
+	}
+	for _, bad := range []string{"-1h", "tomorrow"} {
+		if _, err := parseExpiry(bad, now); err == nil {
+			t.Errorf("parseExpiry(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+)
+
+// tokenList prints all tokens.
+func tokenList(env *environment, args []string) error {
+	if _, err := parseArgs(flag.NewFlagSet("token list", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+	tokens, err := env.client.ListTokens(context.Background())
+	if err != nil {
+		return err
+	}
+	if env.json {
+		return env.printJSON(tokens)
+	}
+
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tTYPE\tCREATED\tEXPIRES\tLAST USED")
+	for _, t := range tokens {
+		kind := "scoped"
+		if t.IsAdmin {
+			kind = "admin"
+		}
+		expires := formatTime(t.ExpiresAt)
+		if t.Expired {
+			expires += " (expired)"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.Name, kind, formatTime(t.CreatedAt), expires, formatTime(t.LastUsedAt))
+	}
+	return tw.Flush()
+}
+
+// tokenCreate creates a token and prints its key, which is shown only once.
+func tokenCreate(env *environment, args []string) error {
+	fs := flag.NewFlagSet("token create", flag.ContinueOnError)
+	name := fs.String("name", "", "token name")
+	admin := fs.Bool("admin", false, "create an admin token")
+	zones := fs.String("zones", "", "zone IDs")
+	actions := fs.String("actions", "", "allowed actions")
+	recordTypes := fs.String("record-types", "", "allowed record types")
+	roles := fs.String("roles", "", "role IDs")
+	expires := fs.String("expires", "", "expiry, as a duration from now or an RFC3339 time")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errUsage
+	}
+
+	req := client.CreateTokenRequest{
+		Name:        *name,
+		IsAdmin:     *admin,
+		Actions:     splitList(*actions),
+		RecordTypes: splitList(*recordTypes),
+	}
+	var err error
+	if req.Zones, err = parseIDs(*zones); err != nil {
+		return err
+	}
+	if req.Roles, err = parseIDs(*roles); err != nil {
+		return err
+	}
+	if *expires != "" {
+		t, err := parseExpiry(*expires, time.Now())
+		if err != nil {
+			return err
+		}
+		req.ExpiresAt = &t
+	}
+
+	token, err := env.client.CreateToken(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	return env.printCreated(token)
+}
+
+// tokenDelete deletes a token.
+func tokenDelete(env *environment, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("token delete", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if err := env.client.DeleteToken(context.Background(), id); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "deleted token %d\n", id)
+	return nil
+}
+
+// tokenRotate replaces a token with a new one with the same name, type,
+// permissions, roles, account and expiry, prints the new key and deletes the
+// old token. Tag and domain permissions and client certificate mappings are
+// not copied.
+func tokenRotate(env *environment, args []string) error {
+	fs := flag.NewFlagSet("token rotate", flag.ContinueOnError)
+	keepOld := fs.Bool("keep-old", false, "keep the old token, for a gradual rollover")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	old, err := env.client.GetToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	req, rest, err := rotationRequest(old, time.Now())
+	if err != nil {
+		return err
+	}
+	token, err := env.client.CreateToken(ctx, req)
+	if err != nil {
+		return err
+	}
+	for _, p := range rest {
+		_, err := env.client.AddPermission(ctx, token.ID, client.PermissionRequest{
+			Resource: p.Resource, ZoneID: p.ZoneID, AllowedActions: p.AllowedActions, RecordTypes: p.RecordTypes,
+		})
+		if err != nil {
+			// Leave the old token as it was
+			if delErr := env.client.DeleteToken(ctx, token.ID); delErr != nil {
+				return fmt.Errorf("copying permission %d: %w; the new token %d could not be removed: %w", p.ID, err, token.ID, delErr)
+			}
+			return fmt.Errorf("copying permission %d: %w", p.ID, err)
+		}
+	}
+
+	if !*keepOld {
+		if err := env.client.DeleteToken(ctx, id); err != nil {
+			return fmt.Errorf("created token %d with key %s, but deleting token %d failed: %w", token.ID, token.Key, id, err)
+		}
+	}
+	return env.printCreated(token)
+}
+
+// rotationRequest returns the request creating a copy of old and the
+// permissions to add to it afterwards. The first DNS zone permission is
+// granted by the request itself, since scoped tokens are created with one.
+func rotationRequest(old *client.TokenDetail, now time.Time) (client.CreateTokenRequest, []client.Permission, error) {
+	req := client.CreateTokenRequest{
+		Name:              old.Name,
+		IsAdmin:           old.IsAdmin,
+		PinTLSFingerprint: old.PinTLSFingerprint,
+		Account:           old.Account,
+	}
+	if !old.ExpiresAt.IsZero() {
+		if !old.ExpiresAt.After(now) {
+			return req, nil, fmt.Errorf("token %d has expired", old.ID)
+		}
+		expires := old.ExpiresAt
+		req.ExpiresAt = &expires
+	}
+	for _, r := range old.Roles {
+		req.Roles = append(req.Roles, r.ID)
+	}
+	if old.IsAdmin {
+		return req, nil, nil
+	}
+
+	var rest []client.Permission
+	for _, p := range old.Permissions {
+		if req.Zones == nil && p.Resource == "dnszone" {
+			req.Zones = []int64{p.ZoneID}
+			req.Actions = p.AllowedActions
+			req.RecordTypes = p.RecordTypes
+			continue
+		}
+		rest = append(rest, p)
+	}
+	if req.Zones == nil && req.Roles == nil {
+		return req, nil, errors.New("tokens without a DNS zone permission or a role cannot be rotated")
+	}
+	return req, rest, nil
+}
+
+// permissionAdd adds a permission to a scoped token.
+func permissionAdd(env *environment, args []string) error {
+	fs := flag.NewFlagSet("permission add", flag.ContinueOnError)
+	zone := fs.Int64("zone", 0, "zone ID")
+	actions := fs.String("actions", "", "allowed actions")
+	recordTypes := fs.String("record-types", "", "allowed record types")
+	resource := fs.String("resource", "", "dnszone (default), pullzone or storagezone")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	tokenID, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if *zone <= 0 || *actions == "" {
+		return errUsage
+	}
+
+	perm, err := env.client.AddPermission(context.Background(), tokenID, client.PermissionRequest{
+		Resource:       *resource,
+		ZoneID:         *zone,
+		AllowedActions: splitList(*actions),
+		RecordTypes:    splitList(*recordTypes),
+	})
+	if err != nil {
+		return err
+	}
+	if env.json {
+		return env.printJSON(perm)
+	}
+	fmt.Fprintf(env.stdout, "added permission %d: %s %d, actions %s, record types %s\n", perm.ID, perm.Resource, perm.ZoneID,
+		strings.Join(perm.AllowedActions, ","), strings.Join(perm.RecordTypes, ","))
+	return nil
+}
+
+// permissionRemove removes a permission from a token.
+func permissionRemove(env *environment, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("permission remove", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	tokenID, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	permID, err := parseID(positional[1])
+	if err != nil {
+		return err
+	}
+	if err := env.client.DeletePermission(context.Background(), tokenID, permID); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "removed permission %d from token %d\n", permID, tokenID)
+	return nil
+}
+
+// printCreated prints a new token and its key.
+func (e *environment) printCreated(token *client.CreatedToken) error {
+	if e.json {
+		return e.printJSON(token)
+	}
+	fmt.Fprintf(e.stdout, "created token %d (%s)\nkey: %s\nThe key is shown only once; store it now.\n", token.ID, token.Name, token.Key)
+	return nil
+}
+
+// parseExpiry parses an expiry given as a duration from now, such as "720h",
+// or as an RFC3339 time.
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid expiry %q: must be in the future", s)
+		}
+		return now.Add(d).UTC().Truncate(time.Second), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q: use a duration such as 720h or an RFC3339 time", s)
+	}
+	return t, nil
+}
+
+// formatTime formats a time for tables, "-" when unset.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
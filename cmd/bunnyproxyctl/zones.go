@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+)
+
+// zoneList prints a page of zones.
+func zoneList(env *environment, args []string) error {
+	fs := flag.NewFlagSet("zone list", flag.ContinueOnError)
+	var opts client.ListZonesOptions
+	fs.StringVar(&opts.Search, "search", "", "only zones whose name contains this")
+	fs.IntVar(&opts.Page, "page", 0, "page number")
+	fs.IntVar(&opts.PerPage, "per-page", 0, "zones per page")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	zones, err := env.client.ListZones(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+	if env.json {
+		return env.printJSON(zones)
+	}
+
+	tw := tabwriter.NewWriter(env.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDOMAIN\tRECORDS")
+	for _, z := range zones.Items {
+		fmt.Fprintf(tw, "%d\t%s\t%d\n", z.ID, z.Domain, len(z.Records))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if zones.HasMoreItems {
+		fmt.Fprintf(env.stdout, "page %d of %d zones; use --page for more\n", zones.CurrentPage, zones.TotalItems)
+	}
+	return nil
+}
+
+// recordAdd adds a record to a zone.
+func recordAdd(env *environment, args []string) error {
+	fs := flag.NewFlagSet("record add", flag.ContinueOnError)
+	typeName := fs.String("type", "", "record type, such as TXT")
+	name := fs.String("name", "", "record name relative to the zone, empty for the apex")
+	value := fs.String("value", "", "record value")
+	ttl := fs.Int("ttl", 0, "TTL in seconds")
+	positional, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	zoneID, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if *typeName == "" || *value == "" {
+		return errUsage
+	}
+	recordType, err := client.ParseRecordType(*typeName)
+	if err != nil {
+		return err
+	}
+
+	record, err := env.client.AddRecord(context.Background(), zoneID, client.RecordRequest{
+		Type:  recordType,
+		Name:  *name,
+		Value: *value,
+		TTL:   int32(*ttl), //nolint:gosec // bunny.net validates the TTL
+	})
+	if err != nil {
+		return err
+	}
+	if env.json {
+		return env.printJSON(record)
+	}
+	fmt.Fprintf(env.stdout, "added record %d: %s %q %q\n", record.ID, record.Type, record.Name, record.Value)
+	return nil
+}
+
+// recordDelete deletes a record from a zone.
+func recordDelete(env *environment, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("record delete", flag.ContinueOnError), args, 2)
+	if err != nil {
+		return err
+	}
+	zoneID, err := parseID(positional[0])
+	if err != nil {
+		return err
+	}
+	recordID, err := parseID(positional[1])
+	if err != nil {
+		return err
+	}
+	if err := env.client.DeleteRecord(context.Background(), zoneID, recordID); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "deleted record %d from zone %d\n", recordID, zoneID)
+	return nil
+}
@@ -0,0 +1,123 @@
+// Command loadgen drives ACME-like workloads (add a TXT record, poll until it
+// is listed, delete it) against a bunny-api-proxy and reports latency
+// percentiles and error rates. Without --url it starts an in-process proxy
+// backed by mockbunny, for local tuning and CI performance gates.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+	"github.com/sipico/bunny-api-proxy/tests/load"
+)
+
+const usage = `usage: loadgen [flags]
+
+Runs concurrent ACME-like challenges against a proxy and prints latency
+percentiles and error rates per operation. Exits 1 if a threshold is exceeded.
+
+The proxy URL and token are read from --url and BUNNYPROXY_TOKEN. Without
+--url, an in-process proxy backed by mockbunny is started with its own zone
+and token. The token needs list_records, add_record and delete_record for TXT
+records on --zone.
+
+flags:
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+// run parses the flags, runs the workload and prints the report.
+// Returns the process exit code: 2 for invalid arguments, 1 for failures and
+// exceeded thresholds.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("loadgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	proxyURL := fs.String("url", "", "proxy URL (default: in-process proxy backed by mockbunny)")
+	zoneID := fs.Int64("zone", 0, "zone ID to create challenge records in (required with --url)")
+	workers := fs.Int("workers", 10, "concurrent clients")
+	iterations := fs.Int("iterations", 0, "challenges per client (0: until --duration)")
+	duration := fs.Duration("duration", 0, "how long to start new challenges (0: until --iterations)")
+	polls := fs.Int("polls", 3, "most record listings per challenge")
+	pollInterval := fs.Duration("poll-interval", 0, "wait between listings")
+	recordName := fs.String("record-name", "_acme-challenge.load", "prefix of the challenge record names")
+	maxErrorRate := fs.Float64("max-error-rate", 1, "fail if more than this share of requests fail, between 0 and 1")
+	maxP99 := fs.Duration("max-p99", 0, "fail if an operation's p99 latency exceeds this (0: no limit)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 || (*iterations <= 0 && *duration <= 0) || *maxErrorRate < 0 || *maxErrorRate > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg := load.Config{
+		ZoneID:       *zoneID,
+		Workers:      *workers,
+		Iterations:   *iterations,
+		Duration:     *duration,
+		Polls:        *polls,
+		PollInterval: *pollInterval,
+		RecordName:   *recordName,
+	}
+	if *proxyURL == "" {
+		env, err := load.NewEnv("loadgen.example.com")
+		if err != nil {
+			fmt.Fprintf(stderr, "loadgen: %v\n", err)
+			return 1
+		}
+		defer env.Close()
+		cfg = env.Config(cfg)
+	} else {
+		token := getenv("BUNNYPROXY_TOKEN")
+		if token == "" || *zoneID <= 0 {
+			fmt.Fprintln(stderr, "loadgen: --url requires --zone and BUNNYPROXY_TOKEN")
+			return 2
+		}
+		c, err := client.New(*proxyURL, token, client.WithUserAgent("loadgen"), client.WithRetry(client.RetryPolicy{}))
+		if err != nil {
+			fmt.Fprintf(stderr, "loadgen: %v\n", err)
+			return 2
+		}
+		cfg.Client = c
+	}
+
+	report, err := load.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "loadgen: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "loadgen: %v\n", err)
+		return 1
+	}
+
+	if err := report.Check(load.Thresholds{MaxErrorRate: *maxErrorRate, MaxP99: *maxP99}); err != nil {
+		fmt.Fprintf(stderr, "loadgen: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/tests/load"
+)
+
+// fakeProxy answers the record requests of the workload for zone 7, listing
+// every record added and not yet deleted.
+func fakeProxy(t *testing.T) string {
+	t.Helper()
+	var mu sync.Mutex
+	records := map[int64]map[string]any{}
+	var nextID int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("AccessKey") != "scoped-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/dnszone/7/records":
+			var rec map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			nextID++
+			rec["Id"] = nextID
+			records[nextID] = rec
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(rec) //nolint:errcheck
+		case r.Method == http.MethodGet && r.URL.Path == "/dnszone/7/records":
+			list := []map[string]any{}
+			for _, rec := range records {
+				if rec["Name"] == r.URL.Query().Get("name") {
+					list = append(list, rec)
+				}
+			}
+			json.NewEncoder(w).Encode(list) //nolint:errcheck
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/dnszone/7/records/"):
+			for id := range records {
+				if r.URL.Path == "/dnszone/7/records/"+strconv.FormatInt(id, 10) {
+					delete(records, id)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func getenv(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestRunAgainstProxy(t *testing.T) {
+	t.Parallel()
+	url := fakeProxy(t)
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"--url", url, "--zone", "7", "--workers", "3", "--iterations", "4", "--json", "--max-error-rate", "0.01"},
+		&stdout, &stderr, getenv(map[string]string{"BUNNYPROXY_TOKEN": "scoped-key"}))
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr.String())
+	}
+	var report load.Report
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON report: %v\n%s", err, stdout.String())
+	}
+	if report.Challenges != 12 || report.ErrorRate() != 0 || report.Operations[1].Requests != 12 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestRunThresholdExceeded(t *testing.T) {
+	t.Parallel()
+	url := fakeProxy(t)
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), []string{"--url", url, "--zone", "7", "--iterations", "1", "--max-error-rate", "0.5"},
+		&stdout, &stderr, getenv(map[string]string{"BUNNYPROXY_TOKEN": "wrong-key"}))
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "error rate 100.00% exceeds 50.00%") {
+		t.Errorf("expected the exceeded threshold, got %q", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "OPERATION") {
+		t.Errorf("expected the report before failing, got %q", stdout.String())
+	}
+}
+
+func TestRunInvalidArguments(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"unknown flag", []string{"--bogus"}, nil},
+		{"no iterations or duration", []string{"--workers", "2"}, nil},
+		{"error rate above 1", []string{"--iterations", "1", "--max-error-rate", "2"}, nil},
+		{"extra arguments", []string{"--iterations", "1", "extra"}, nil},
+		{"url without token", []string{"--url", "http://127.0.0.1:1", "--zone", "1", "--iterations", "1"}, nil},
+		{"url without zone", []string{"--url", "http://127.0.0.1:1", "--iterations", "1"}, map[string]string{"BUNNYPROXY_TOKEN": "key"}},
+		{"invalid url", []string{"--url", "ftp://proxy", "--zone", "1", "--iterations", "1"}, map[string]string{"BUNNYPROXY_TOKEN": "key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(context.Background(), tt.args, &stdout, &stderr, getenv(tt.env)); code != 2 {
+				t.Errorf("expected exit code 2, got %d", code)
+			}
+		})
+	}
+}
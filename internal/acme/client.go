@@ -0,0 +1,543 @@
+// Package acme renews TLS certificates from an ACME certificate authority
+// (RFC 8555) using DNS-01 challenges, so the proxy can serve HTTPS without
+// an external ACME client.
+//
+// Only what the proxy needs is implemented: ES256 account keys, DNS-01
+// challenges and PEM certificate chains.
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// LetsEncryptURL is the production Let's Encrypt directory.
+const LetsEncryptURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+const (
+	// defaultPollInterval is how often pending authorizations and orders are re-checked.
+	defaultPollInterval = 2 * time.Second
+
+	// defaultPropagationDelay gives bunny.net's nameservers time to serve a
+	// new TXT record before the CA is asked to look it up.
+	defaultPropagationDelay = 30 * time.Second
+
+	// maxResponseSize bounds ACME responses; certificate chains are a few KB.
+	maxResponseSize = 1 << 20
+
+	// cleanupTimeout bounds challenge record removal after the order ends.
+	cleanupTimeout = 30 * time.Second
+)
+
+// Solver publishes and removes DNS-01 challenge records.
+type Solver interface {
+	// Present publishes value as a TXT record at fqdn.
+	Present(ctx context.Context, fqdn, value string) error
+	// CleanUp removes a TXT record published by Present.
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// Problem is an error document returned by the CA (RFC 7807).
+type Problem struct {
+	Status int    `json:"status"`
+	Type   string `json:"type"`
+	Detail string `json:"detail"`
+}
+
+func (p *Problem) Error() string {
+	return fmt.Sprintf("acme: %s (status %d): %s", p.Type, p.Status, p.Detail)
+}
+
+const problemBadNonce = "urn:ietf:params:acme:error:badNonce"
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to talk to the CA.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithContact registers the account with an email address for expiry notices.
+func WithContact(email string) Option {
+	return func(c *Client) {
+		c.email = email
+	}
+}
+
+// WithPollInterval sets how often pending authorizations and orders are re-checked.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = d
+	}
+}
+
+// WithPropagationDelay sets how long to wait after publishing challenge
+// records before asking the CA to validate them.
+func WithPropagationDelay(d time.Duration) Option {
+	return func(c *Client) {
+		c.propagationDelay = d
+	}
+}
+
+// Client obtains certificates from an ACME CA. It is not safe for concurrent use.
+type Client struct {
+	directoryURL     string
+	key              *ecdsa.PrivateKey
+	http             *http.Client
+	email            string
+	pollInterval     time.Duration
+	propagationDelay time.Duration
+
+	dir   *directory
+	kid   string
+	nonce string
+}
+
+// NewClient creates a client for the CA at directoryURL with the given account key.
+func NewClient(directoryURL string, key *ecdsa.PrivateKey, opts ...Option) *Client {
+	c := &Client{
+		directoryURL:     directoryURL,
+		key:              key,
+		http:             &http.Client{Timeout: 30 * time.Second},
+		pollInterval:     defaultPollInterval,
+		propagationDelay: defaultPropagationDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type directory struct {
+	NewNonce   string `json:"newNonce"`
+	NewAccount string `json:"newAccount"`
+	NewOrder   string `json:"newOrder"`
+}
+
+type identifier struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type order struct {
+	Status         string   `json:"status"`
+	Authorizations []string `json:"authorizations"`
+	Finalize       string   `json:"finalize"`
+	Certificate    string   `json:"certificate"`
+	Error          *Problem `json:"error"`
+}
+
+type authorization struct {
+	Identifier identifier  `json:"identifier"`
+	Status     string      `json:"status"`
+	Challenges []challenge `json:"challenges"`
+}
+
+type challenge struct {
+	Type   string   `json:"type"`
+	URL    string   `json:"url"`
+	Token  string   `json:"token"`
+	Status string   `json:"status"`
+	Error  *Problem `json:"error"`
+}
+
+// ChallengeFQDN returns the name of the TXT record answering a DNS-01
+// challenge for domain. Wildcard domains share their base name's record.
+func ChallengeFQDN(domain string) string {
+	if len(domain) > 2 && domain[:2] == "*." {
+		domain = domain[2:]
+	}
+	return "_acme-challenge." + domain
+}
+
+// Obtain orders a certificate for domains, answering DNS-01 challenges with
+// solver. It returns the PEM certificate chain and the PEM private key.
+func (c *Client) Obtain(ctx context.Context, domains []string, solver Solver) (certPEM, keyPEM []byte, err error) {
+	if len(domains) == 0 {
+		return nil, nil, errors.New("acme: no domains")
+	}
+	if err := c.register(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]identifier, len(domains))
+	for i, d := range domains {
+		ids[i] = identifier{Type: "dns", Value: d}
+	}
+	var o order
+	resp, err := c.post(ctx, c.dir.NewOrder, map[string]any{"identifiers": ids}, &o)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create order: %w", err)
+	}
+	orderURL := resp.Header.Get("Location")
+
+	if err := c.authorize(ctx, o.Authorizations, solver); err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate certificate key: %w", err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSR: %w", err)
+	}
+	if _, err := c.post(ctx, o.Finalize, map[string]string{"csr": b64(csr)}, &o); err != nil {
+		return nil, nil, fmt.Errorf("failed to finalize order: %w", err)
+	}
+	for o.Status != "valid" {
+		if o.Status != "pending" && o.Status != "ready" && o.Status != "processing" {
+			return nil, nil, fmt.Errorf("order is %s: %w", o.Status, problemOrNil(o.Error))
+		}
+		if err := sleep(ctx, c.pollInterval); err != nil {
+			return nil, nil, err
+		}
+		if _, err := c.post(ctx, orderURL, nil, &o); err != nil {
+			return nil, nil, fmt.Errorf("failed to poll order: %w", err)
+		}
+	}
+
+	_, chain, err := c.postRaw(ctx, o.Certificate, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download certificate: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode certificate key: %w", err)
+	}
+	return chain, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// authorize answers the DNS-01 challenge of every pending authorization.
+// Records are published together, so one propagation wait covers them all,
+// and removed again once the CA has checked them.
+func (c *Client) authorize(ctx context.Context, urls []string, solver Solver) error {
+	type pending struct {
+		authzURL string
+		chal     challenge
+		fqdn     string
+		value    string
+	}
+	var todo []pending
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+		for _, p := range todo {
+			//nolint:errcheck // a leftover TXT record is harmless and replaced on the next renewal
+			solver.CleanUp(cleanupCtx, p.fqdn, p.value)
+		}
+	}()
+
+	for _, u := range urls {
+		var a authorization
+		if _, err := c.post(ctx, u, nil, &a); err != nil {
+			return fmt.Errorf("failed to fetch authorization: %w", err)
+		}
+		if a.Status == "valid" {
+			continue
+		}
+		var chal *challenge
+		for i := range a.Challenges {
+			if a.Challenges[i].Type == "dns-01" {
+				chal = &a.Challenges[i]
+			}
+		}
+		if chal == nil {
+			return fmt.Errorf("no dns-01 challenge offered for %s", a.Identifier.Value)
+		}
+		value, err := c.dnsValue(chal.Token)
+		if err != nil {
+			return err
+		}
+		p := pending{authzURL: u, chal: *chal, fqdn: ChallengeFQDN(a.Identifier.Value), value: value}
+		if err := solver.Present(ctx, p.fqdn, p.value); err != nil {
+			return fmt.Errorf("failed to publish challenge for %s: %w", a.Identifier.Value, err)
+		}
+		todo = append(todo, p)
+	}
+	if len(todo) == 0 {
+		return nil
+	}
+
+	if err := sleep(ctx, c.propagationDelay); err != nil {
+		return err
+	}
+	for _, p := range todo {
+		if _, err := c.post(ctx, p.chal.URL, struct{}{}, nil); err != nil {
+			return fmt.Errorf("failed to accept challenge: %w", err)
+		}
+	}
+	for _, p := range todo {
+		if err := c.waitAuthorization(ctx, p.authzURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitAuthorization polls an authorization until the CA has decided on it.
+func (c *Client) waitAuthorization(ctx context.Context, url string) error {
+	for {
+		var a authorization
+		if _, err := c.post(ctx, url, nil, &a); err != nil {
+			return fmt.Errorf("failed to poll authorization: %w", err)
+		}
+		switch a.Status {
+		case "valid":
+			return nil
+		case "pending", "processing":
+		default:
+			var cause *Problem
+			for _, ch := range a.Challenges {
+				if ch.Error != nil {
+					cause = ch.Error
+				}
+			}
+			return fmt.Errorf("authorization for %s is %s: %w", a.Identifier.Value, a.Status, problemOrNil(cause))
+		}
+		if err := sleep(ctx, c.pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// register fetches the directory and creates or looks up the account.
+func (c *Client) register(ctx context.Context) error {
+	if c.kid != "" {
+		return nil
+	}
+	if c.dir == nil {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.directoryURL, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create directory request: %w", err)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to fetch directory: %w", err)
+		}
+		//nolint:errcheck
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to fetch directory: status %d", resp.StatusCode)
+		}
+		var dir directory
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&dir); err != nil {
+			return fmt.Errorf("failed to decode directory: %w", err)
+		}
+		c.dir = &dir
+	}
+
+	payload := map[string]any{"termsOfServiceAgreed": true}
+	if c.email != "" {
+		payload["contact"] = []string{"mailto:" + c.email}
+	}
+	resp, err := c.post(ctx, c.dir.NewAccount, payload, nil)
+	if err != nil {
+		return fmt.Errorf("failed to register account: %w", err)
+	}
+	c.kid = resp.Header.Get("Location")
+	if c.kid == "" {
+		return errors.New("acme: account response has no Location")
+	}
+	return nil
+}
+
+// post sends a JWS-signed request and decodes the JSON response into out.
+// A nil payload sends a POST-as-GET.
+func (c *Client) post(ctx context.Context, url string, payload, out any) (*http.Response, error) {
+	resp, body, err := c.postRaw(ctx, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		if err := json.Unmarshal(body, out); err != nil {
+			return nil, fmt.Errorf("failed to decode response from %s: %w", url, err)
+		}
+	}
+	return resp, nil
+}
+
+// postRaw sends a JWS-signed request and returns the response body. A
+// rejected nonce is retried once with the fresh nonce the CA returned.
+func (c *Client) postRaw(ctx context.Context, url string, payload any) (*http.Response, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		jws, err := c.sign(ctx, url, payload)
+		if err != nil {
+			return nil, nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jws))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/jose+json")
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("request to %s failed: %w", url, err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		_ = resp.Body.Close() //nolint:errcheck
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read response from %s: %w", url, err)
+		}
+		c.nonce = resp.Header.Get("Replay-Nonce")
+
+		if resp.StatusCode < 400 {
+			return resp, body, nil
+		}
+		p := &Problem{Status: resp.StatusCode}
+		_ = json.Unmarshal(body, p) //nolint:errcheck // a non-JSON error keeps just the status
+		p.Status = resp.StatusCode
+		if p.Type == problemBadNonce && attempt == 0 {
+			continue
+		}
+		return nil, nil, p
+	}
+}
+
+// sign wraps payload in a flattened JWS signed with the account key.
+// Before registration the key itself is embedded; afterwards the account URL.
+func (c *Client) sign(ctx context.Context, url string, payload any) ([]byte, error) {
+	nonce, err := c.nextNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+	protected := map[string]any{"alg": "ES256", "nonce": nonce, "url": url}
+	if c.kid != "" {
+		protected["kid"] = c.kid
+	} else {
+		jwk, err := publicJWK(c.key)
+		if err != nil {
+			return nil, err
+		}
+		protected["jwk"] = jwk
+	}
+	header, err := json.Marshal(protected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JWS header: %w", err)
+	}
+	var encodedPayload string
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JWS payload: %w", err)
+		}
+		encodedPayload = b64(data)
+	}
+	encodedHeader := b64(header)
+
+	digest := sha256.Sum256([]byte(encodedHeader + "." + encodedPayload))
+	r, s, err := ecdsa.Sign(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return json.Marshal(map[string]string{
+		"protected": encodedHeader,
+		"payload":   encodedPayload,
+		"signature": b64(sig),
+	})
+}
+
+// nextNonce returns the nonce from the last response, or fetches a new one.
+func (c *Client) nextNonce(ctx context.Context) (string, error) {
+	if c.nonce != "" {
+		n := c.nonce
+		c.nonce = ""
+		return n, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.dir.NewNonce, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create nonce request: %w", err)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+	_ = resp.Body.Close() //nolint:errcheck
+	n := resp.Header.Get("Replay-Nonce")
+	if n == "" {
+		return "", errors.New("acme: no nonce in newNonce response")
+	}
+	return n, nil
+}
+
+// jwk is an EC public key. Fields are in the lexicographic order RFC 7638
+// requires for thumbprints.
+type jwk struct {
+	Crv string `json:"crv"`
+	Kty string `json:"kty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func publicJWK(key *ecdsa.PrivateKey) (*jwk, error) {
+	pub, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, fmt.Errorf("invalid account key: %w", err)
+	}
+	// Uncompressed point: 0x04 || X || Y
+	point := pub.Bytes()
+	return &jwk{Crv: "P-256", Kty: "EC", X: b64(point[1:33]), Y: b64(point[33:])}, nil
+}
+
+// dnsValue is the TXT record value answering a DNS-01 challenge token.
+func (c *Client) dnsValue(token string) (string, error) {
+	k, err := publicJWK(c.key)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWK: %w", err)
+	}
+	thumbprint := sha256.Sum256(data)
+	keyAuth := sha256.Sum256([]byte(token + "." + b64(thumbprint[:])))
+	return b64(keyAuth[:]), nil
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func problemOrNil(p *Problem) error {
+	if p == nil {
+		return errors.New("no details from CA")
+	}
+	return p
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestClient(t *testing.T, ca *fakeCA) *Client {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate account key: %v", err)
+	}
+	return NewClient(ca.directoryURL(), key,
+		WithHTTPClient(ca.srv.Client()),
+		WithContact("ops@example.com"),
+		WithPollInterval(0),
+		WithPropagationDelay(0))
+}
+
+func TestObtain(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	ca := newFakeCA(t, dns)
+	client := newTestClient(t, ca)
+
+	domains := []string{"example.com", "*.example.com"}
+	certPEM, keyPEM, err := client.Obtain(context.Background(), domains, dns)
+	if err != nil {
+		t.Fatalf("Obtain failed: %v", err)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("certificate and key do not match: %v", err)
+	}
+	if !slices.Equal(cert.Leaf.DNSNames, domains) {
+		t.Errorf("DNSNames = %v, want %v", cert.Leaf.DNSNames, domains)
+	}
+	if len(cert.Certificate) != 2 {
+		t.Errorf("expected leaf and issuer in chain, got %d certificates", len(cert.Certificate))
+	}
+	if n := dns.count(); n != 0 {
+		t.Errorf("expected challenge records to be cleaned up, %d names left", n)
+	}
+
+	// A second order reuses the registered account
+	if _, _, err := client.Obtain(context.Background(), []string{"www.example.com"}, dns); err != nil {
+		t.Fatalf("second Obtain failed: %v", err)
+	}
+	if ca.accounts != 1 {
+		t.Errorf("expected one account registration, got %d", ca.accounts)
+	}
+}
+
+func TestObtain_RetriesBadNonce(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	ca := newFakeCA(t, dns)
+	ca.badNonceOnce = true
+
+	if _, _, err := newTestClient(t, ca).Obtain(context.Background(), []string{"example.com"}, dns); err != nil {
+		t.Fatalf("Obtain failed: %v", err)
+	}
+}
+
+func TestObtain_FailedChallenge(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	dns.mangle = true
+	ca := newFakeCA(t, dns)
+
+	_, _, err := newTestClient(t, ca).Obtain(context.Background(), []string{"example.com"}, dns)
+	var p *Problem
+	if !errors.As(err, &p) || !strings.Contains(p.Type, "incorrectResponse") {
+		t.Fatalf("expected incorrectResponse problem, got %v", err)
+	}
+	if n := dns.count(); n != 0 {
+		t.Errorf("expected challenge records to be cleaned up after failure, %d names left", n)
+	}
+}
+
+func TestObtain_NoDomains(t *testing.T) {
+	t.Parallel()
+	ca := newFakeCA(t, newFakeDNS())
+	if _, _, err := newTestClient(t, ca).Obtain(context.Background(), nil, newFakeDNS()); err == nil {
+		t.Fatal("expected error for empty domain list")
+	}
+}
+
+func TestChallengeFQDN(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"example.com":     "_acme-challenge.example.com",
+		"*.example.com":   "_acme-challenge.example.com",
+		"www.example.com": "_acme-challenge.www.example.com",
+	}
+	for domain, want := range tests {
+		if got := ChallengeFQDN(domain); got != want {
+			t.Errorf("ChallengeFQDN(%q) = %q, want %q", domain, got, want)
+		}
+	}
+}
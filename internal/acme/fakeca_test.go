@@ -0,0 +1,459 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeCA is a minimal ACME server. It verifies request signatures and
+// nonces, and validates DNS-01 challenges against a fakeDNS.
+type fakeCA struct {
+	t   *testing.T
+	srv *httptest.Server
+	dns *fakeDNS
+
+	caKey  *ecdsa.PrivateKey
+	caCert *x509.Certificate
+
+	mu           sync.Mutex
+	nonces       map[string]bool
+	nextNonce    int
+	badNonceOnce bool
+	accountKey   *ecdsa.PublicKey
+	accounts     int
+	authzs       []*fakeAuthz
+	order        map[string]any
+	orderDomains []string
+	chain        []byte
+	expiry       time.Duration
+}
+
+type fakeAuthz struct {
+	domain string
+	token  string
+	status string
+}
+
+func newFakeCA(t *testing.T, dns *fakeDNS) *fakeCA {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+
+	ca := &fakeCA{t: t, dns: dns, caKey: caKey, caCert: caCert, nonces: map[string]bool{}, expiry: 90 * 24 * time.Hour}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /directory", ca.handleDirectory)
+	mux.HandleFunc("HEAD /nonce", func(w http.ResponseWriter, _ *http.Request) { ca.setNonce(w) })
+	mux.HandleFunc("POST /account", ca.handleAccount)
+	mux.HandleFunc("POST /order", ca.handleNewOrder)
+	mux.HandleFunc("POST /order/1", ca.handleOrder)
+	mux.HandleFunc("POST /authz/{i}", ca.handleAuthz)
+	mux.HandleFunc("POST /chall/{i}", ca.handleChallenge)
+	mux.HandleFunc("POST /finalize", ca.handleFinalize)
+	mux.HandleFunc("POST /cert", ca.handleCert)
+	ca.srv = httptest.NewServer(mux)
+	t.Cleanup(ca.srv.Close)
+	return ca
+}
+
+func (ca *fakeCA) directoryURL() string { return ca.srv.URL + "/directory" }
+
+func (ca *fakeCA) setNonce(w http.ResponseWriter) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	ca.nextNonce++
+	n := fmt.Sprintf("nonce-%d", ca.nextNonce)
+	ca.nonces[n] = true
+	w.Header().Set("Replay-Nonce", n)
+}
+
+func (ca *fakeCA) problem(w http.ResponseWriter, status int, typ, detail string) {
+	ca.setNonce(w)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(map[string]any{"type": "urn:ietf:params:acme:error:" + typ, "detail": detail, "status": status})
+}
+
+func (ca *fakeCA) reply(w http.ResponseWriter, status int, body any) {
+	ca.setNonce(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(body)
+}
+
+// verify checks the request's JWS and returns its decoded payload.
+func (ca *fakeCA) verify(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	var jws struct{ Protected, Payload, Signature string }
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		ca.problem(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil, false
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(jws.Protected) //nolint:errcheck
+	var protected struct {
+		Alg   string
+		Nonce string
+		URL   string
+		Kid   string
+		JWK   *jwk
+	}
+	if err := json.Unmarshal(header, &protected); err != nil {
+		ca.problem(w, http.StatusBadRequest, "malformed", err.Error())
+		return nil, false
+	}
+
+	ca.mu.Lock()
+	validNonce := ca.nonces[protected.Nonce]
+	delete(ca.nonces, protected.Nonce)
+	if ca.badNonceOnce {
+		ca.badNonceOnce = false
+		validNonce = false
+	}
+	key := ca.accountKey
+	ca.mu.Unlock()
+	if !validNonce {
+		ca.problem(w, http.StatusBadRequest, "badNonce", "nonce "+protected.Nonce)
+		return nil, false
+	}
+	if protected.Alg != "ES256" || protected.URL != ca.srv.URL+r.URL.Path {
+		ca.problem(w, http.StatusBadRequest, "malformed", "bad alg or url")
+		return nil, false
+	}
+
+	switch {
+	case protected.JWK != nil && r.URL.Path == "/account":
+		x, _ := base64.RawURLEncoding.DecodeString(protected.JWK.X) //nolint:errcheck
+		y, _ := base64.RawURLEncoding.DecodeString(protected.JWK.Y) //nolint:errcheck
+		key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	case protected.Kid == ca.srv.URL+"/account/1" && key != nil:
+	default:
+		ca.problem(w, http.StatusUnauthorized, "unauthorized", "unknown account")
+		return nil, false
+	}
+
+	sig, _ := base64.RawURLEncoding.DecodeString(jws.Signature) //nolint:errcheck
+	digest := sha256.Sum256([]byte(jws.Protected + "." + jws.Payload))
+	if len(sig) != 64 || !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		ca.problem(w, http.StatusBadRequest, "malformed", "bad signature")
+		return nil, false
+	}
+	if r.URL.Path == "/account" {
+		ca.mu.Lock()
+		ca.accountKey = key
+		ca.mu.Unlock()
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(jws.Payload) //nolint:errcheck
+	return payload, true
+}
+
+func (ca *fakeCA) handleDirectory(w http.ResponseWriter, _ *http.Request) {
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(map[string]string{
+		"newNonce":   ca.srv.URL + "/nonce",
+		"newAccount": ca.srv.URL + "/account",
+		"newOrder":   ca.srv.URL + "/order",
+	})
+}
+
+func (ca *fakeCA) handleAccount(w http.ResponseWriter, r *http.Request) {
+	payload, ok := ca.verify(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		TermsOfServiceAgreed bool `json:"termsOfServiceAgreed"`
+	}
+	if err := json.Unmarshal(payload, &req); err != nil || !req.TermsOfServiceAgreed {
+		ca.problem(w, http.StatusBadRequest, "malformed", "terms not agreed")
+		return
+	}
+	ca.mu.Lock()
+	ca.accounts++
+	ca.mu.Unlock()
+	w.Header().Set("Location", ca.srv.URL+"/account/1")
+	ca.reply(w, http.StatusCreated, map[string]string{"status": "valid"})
+}
+
+func (ca *fakeCA) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	payload, ok := ca.verify(w, r)
+	if !ok {
+		return
+	}
+	var req struct{ Identifiers []identifier }
+	if err := json.Unmarshal(payload, &req); err != nil {
+		ca.problem(w, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	ca.mu.Lock()
+	ca.authzs = nil
+	ca.orderDomains = nil
+	var urls []string
+	for i, id := range req.Identifiers {
+		ca.authzs = append(ca.authzs, &fakeAuthz{domain: id.Value, token: fmt.Sprintf("token-%d", i), status: "pending"})
+		ca.orderDomains = append(ca.orderDomains, id.Value)
+		urls = append(urls, fmt.Sprintf("%s/authz/%d", ca.srv.URL, i))
+	}
+	ca.order = map[string]any{"status": "pending", "authorizations": urls, "finalize": ca.srv.URL + "/finalize"}
+	order := ca.order
+	ca.mu.Unlock()
+	w.Header().Set("Location", ca.srv.URL+"/order/1")
+	ca.reply(w, http.StatusCreated, order)
+}
+
+func (ca *fakeCA) handleOrder(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ca.verify(w, r); !ok {
+		return
+	}
+	ca.mu.Lock()
+	order := ca.order
+	ca.mu.Unlock()
+	ca.reply(w, http.StatusOK, order)
+}
+
+func (ca *fakeCA) authz(r *http.Request) *fakeAuthz {
+	var i int
+	fmt.Sscan(r.PathValue("i"), &i) //nolint:errcheck
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	if i < 0 || i >= len(ca.authzs) {
+		return nil
+	}
+	return ca.authzs[i]
+}
+
+func (ca *fakeCA) handleAuthz(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ca.verify(w, r); !ok {
+		return
+	}
+	a := ca.authz(r)
+	if a == nil {
+		ca.problem(w, http.StatusNotFound, "malformed", "no such authorization")
+		return
+	}
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	domain := strings.TrimPrefix(a.domain, "*.")
+	chal := map[string]any{"type": "dns-01", "url": ca.srv.URL + "/chall/" + r.PathValue("i"), "token": a.token, "status": a.status}
+	if a.status == "invalid" {
+		chal["error"] = map[string]any{"type": "urn:ietf:params:acme:error:incorrectResponse", "detail": "wrong TXT value"}
+	}
+	ca.setNonceLocked(w)
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(map[string]any{
+		"identifier": identifier{Type: "dns", Value: domain},
+		"status":     a.status,
+		"wildcard":   domain != a.domain,
+		"challenges": []any{map[string]any{"type": "http-01", "url": ca.srv.URL + "/unused", "token": "x"}, chal},
+	})
+}
+
+func (ca *fakeCA) setNonceLocked(w http.ResponseWriter) {
+	ca.nextNonce++
+	n := fmt.Sprintf("nonce-%d", ca.nextNonce)
+	ca.nonces[n] = true
+	w.Header().Set("Replay-Nonce", n)
+}
+
+// expectedValue computes the DNS-01 TXT value independently of the client.
+func (ca *fakeCA) expectedValue(token string) string {
+	ca.mu.Lock()
+	key := ca.accountKey
+	ca.mu.Unlock()
+	x := base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32)))
+	y := base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32)))
+	thumb := sha256.Sum256([]byte(`{"crv":"P-256","kty":"EC","x":"` + x + `","y":"` + y + `"}`))
+	sum := sha256.Sum256([]byte(token + "." + base64.RawURLEncoding.EncodeToString(thumb[:])))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func (ca *fakeCA) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ca.verify(w, r); !ok {
+		return
+	}
+	a := ca.authz(r)
+	if a == nil {
+		ca.problem(w, http.StatusNotFound, "malformed", "no such challenge")
+		return
+	}
+	fqdn := "_acme-challenge." + strings.TrimPrefix(a.domain, "*.")
+	status := "invalid"
+	if ca.dns.has(fqdn, ca.expectedValue(a.token)) {
+		status = "valid"
+	}
+	ca.mu.Lock()
+	a.status = status
+	ready := true
+	for _, az := range ca.authzs {
+		ready = ready && az.status == "valid"
+	}
+	if ready {
+		ca.order["status"] = "ready"
+	}
+	ca.mu.Unlock()
+	ca.reply(w, http.StatusOK, map[string]string{"type": "dns-01", "status": "processing"})
+}
+
+func (ca *fakeCA) handleFinalize(w http.ResponseWriter, r *http.Request) {
+	payload, ok := ca.verify(w, r)
+	if !ok {
+		return
+	}
+	var req struct{ CSR string }
+	if err := json.Unmarshal(payload, &req); err != nil {
+		ca.problem(w, http.StatusBadRequest, "malformed", err.Error())
+		return
+	}
+	der, _ := base64.RawURLEncoding.DecodeString(req.CSR) //nolint:errcheck
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil || csr.CheckSignature() != nil {
+		ca.problem(w, http.StatusBadRequest, "badCSR", "invalid CSR")
+		return
+	}
+
+	ca.mu.Lock()
+	ready := ca.order["status"] == "ready"
+	ca.mu.Unlock()
+	if !ready {
+		ca.problem(w, http.StatusForbidden, "orderNotReady", "order not ready")
+		return
+	}
+
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(ca.expiry),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leaf, err := x509.CreateCertificate(rand.Reader, tmpl, ca.caCert, csr.PublicKey, ca.caKey)
+	if err != nil {
+		ca.t.Errorf("failed to issue certificate: %v", err)
+		return
+	}
+	ca.chain = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.caCert.Raw})...)
+	// Report processing first so the client has to poll the order
+	ca.order["status"] = "processing"
+	order := map[string]any{}
+	for k, v := range ca.order {
+		order[k] = v
+	}
+	ca.order["status"] = "valid"
+	ca.order["certificate"] = ca.srv.URL + "/cert"
+	ca.setNonceLocked(w)
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck
+	json.NewEncoder(w).Encode(order)
+}
+
+func (ca *fakeCA) handleCert(w http.ResponseWriter, r *http.Request) {
+	if _, ok := ca.verify(w, r); !ok {
+		return
+	}
+	ca.mu.Lock()
+	chain := ca.chain
+	ca.mu.Unlock()
+	ca.setNonce(w)
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	//nolint:errcheck
+	io.Copy(w, strings.NewReader(string(chain)))
+}
+
+// fakeDNS is a Solver that keeps TXT records in memory.
+type fakeDNS struct {
+	mu      sync.Mutex
+	records map[string][]string
+	mangle  bool
+}
+
+func newFakeDNS() *fakeDNS {
+	return &fakeDNS{records: map[string][]string{}}
+}
+
+func (d *fakeDNS) Present(_ context.Context, fqdn, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mangle {
+		value += "x"
+	}
+	d.records[fqdn] = append(d.records[fqdn], value)
+	return nil
+}
+
+func (d *fakeDNS) CleanUp(_ context.Context, fqdn, value string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mangle {
+		value += "x"
+	}
+	var kept []string
+	for _, v := range d.records[fqdn] {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	if len(kept) == 0 {
+		delete(d.records, fqdn)
+	} else {
+		d.records[fqdn] = kept
+	}
+	return nil
+}
+
+func (d *fakeDNS) has(fqdn, value string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, v := range d.records[fqdn] {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *fakeDNS) count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.records)
+}
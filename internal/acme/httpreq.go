@@ -0,0 +1,146 @@
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// challengePrefix is the label DNS-01 challenge records live under.
+const challengePrefix = "_acme-challenge."
+
+// maxHTTPReqBody caps the size of present and cleanup request bodies.
+const maxHTTPReqBody = 64 << 10
+
+// httpreqRequest is the body of a present or cleanup request. It accepts
+// both formats of lego's httpreq provider: the default one with the record
+// name and value, and RAW mode with the domain and key authorization.
+type httpreqRequest struct {
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+
+	Domain  string `json:"domain"`
+	Token   string `json:"token"`
+	KeyAuth string `json:"keyAuth"`
+}
+
+// challenge returns the TXT record name and value the request asks for.
+func (req *httpreqRequest) challenge() (fqdn, value string, err error) {
+	switch {
+	case req.FQDN != "":
+		fqdn, value = req.FQDN, req.Value
+	case req.Domain != "":
+		// Wildcard names are validated at the base domain
+		fqdn = challengePrefix + strings.TrimPrefix(req.Domain, "*.")
+		if req.KeyAuth != "" {
+			digest := sha256.Sum256([]byte(req.KeyAuth))
+			value = b64(digest[:])
+		}
+	default:
+		return "", "", errors.New("fqdn or domain is required")
+	}
+	fqdn = strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	if !strings.HasPrefix(fqdn, challengePrefix) || len(fqdn) == len(challengePrefix) {
+		return "", "", errors.New("fqdn must be an " + strings.TrimSuffix(challengePrefix, ".") + " name")
+	}
+	if value == "" {
+		return "", "", errors.New("value or keyAuth is required")
+	}
+	return fqdn, value, nil
+}
+
+// NewHTTPReqRouter serves POST /present and POST /cleanup for DNS-01
+// clients such as lego's httpreq provider, so they can answer challenges
+// without knowing bunny.net zone IDs. The zone is found from the name.
+//
+// Callers authenticate with their own proxy key, in the AccessKey header or
+// as the Basic auth password. Record requests are sent through proxy with
+// that key, so the key's permissions, the audit log and change events apply
+// exactly as if the client had called the record endpoints itself.
+func NewHTTPReqRouter(proxy http.Handler, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := chi.NewRouter()
+	r.Post("/present", httpreqHandler(proxy, logger, "present", (*ProxySolver).Present))
+	r.Post("/cleanup", httpreqHandler(proxy, logger, "cleanup", (*ProxySolver).CleanUp))
+	return r
+}
+
+// httpreqHandler decodes a challenge request and applies op with a solver
+// authenticated as the caller.
+func httpreqHandler(proxy http.Handler, logger *slog.Logger, name string, op func(*ProxySolver, context.Context, string, string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("AccessKey")
+		if key == "" {
+			_, key, _ = r.BasicAuth()
+		}
+		if key == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="bunny-api-proxy"`)
+			writeError(w, http.StatusUnauthorized, "missing API key")
+			return
+		}
+
+		var req httpreqRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHTTPReqBody)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		fqdn, value, err := req.challenge()
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// Drop this router's routing state so the proxy router routes the
+		// solver's requests from the top.
+		ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+		err = op(NewProxySolver(proxy, key), ctx, fqdn, value)
+		if err != nil {
+			status, message := httpreqErrorStatus(err)
+			logger.Warn("acme: challenge request failed", "op", name, "fqdn", fqdn, "status", status, "error", err)
+			writeError(w, status, message)
+			return
+		}
+
+		logger.Info("acme: challenge record updated", "op", name, "fqdn", fqdn)
+		w.Header().Set("Content-Type", "application/json")
+		//nolint:errcheck
+		_ = json.NewEncoder(w).Encode(map[string]string{"fqdn": fqdn + ".", "value": value})
+	}
+}
+
+// httpreqErrorStatus maps a solver error to a response status and message.
+// Authentication and permission failures keep their status; anything else
+// from the proxy is reported as a bad gateway.
+func httpreqErrorStatus(err error) (int, string) {
+	if errors.Is(err, ErrZoneNotFound) {
+		return http.StatusNotFound, "no zone found for domain"
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		switch reqErr.Status {
+		case http.StatusUnauthorized:
+			return http.StatusUnauthorized, "invalid API key"
+		case http.StatusForbidden:
+			return http.StatusForbidden, "permission denied"
+		case http.StatusServiceUnavailable:
+			return http.StatusServiceUnavailable, "service unavailable"
+		}
+	}
+	return http.StatusBadGateway, "failed to update challenge record"
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,118 @@
+package acme
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newHTTPReqTestServer mounts the httpreq router under /acme next to the
+// proxy router, as the main router does.
+func newHTTPReqTestServer(proxy http.Handler) http.Handler {
+	r := chi.NewRouter()
+	r.Mount("/acme", NewHTTPReqRouter(proxy, nil))
+	r.Mount("/", proxy)
+	return r
+}
+
+func TestHTTPReq_PresentAndCleanup(t *testing.T) {
+	t.Parallel()
+	router, server, zones, key := newSolverTestProxy(t, "example.com")
+	h := newHTTPReqTestServer(router)
+
+	// lego's default format, authenticated with Basic auth
+	req := httptest.NewRequest(http.MethodPost, "/acme/present",
+		strings.NewReader(`{"fqdn":"_acme-challenge.www.example.com.","value":"value-1"}`))
+	req.SetBasicAuth("lego", key)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("present: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	records := server.GetZone(zones["example.com"]).Records
+	if len(records) != 1 || records[0].Name != "_acme-challenge.www" || records[0].Value != "value-1" {
+		t.Fatalf("unexpected records after present: %+v", records)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/acme/cleanup",
+		strings.NewReader(`{"fqdn":"_acme-challenge.www.example.com.","value":"value-1"}`))
+	req.Header.Set("AccessKey", key)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cleanup: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if records := server.GetZone(zones["example.com"]).Records; len(records) != 0 {
+		t.Errorf("expected no records after cleanup, got %+v", records)
+	}
+}
+
+func TestHTTPReq_RawMode(t *testing.T) {
+	t.Parallel()
+	router, server, zones, key := newSolverTestProxy(t, "example.com")
+	h := newHTTPReqTestServer(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/acme/present",
+		strings.NewReader(`{"domain":"*.example.com","token":"tok","keyAuth":"tok.thumbprint"}`))
+	req.Header.Set("AccessKey", key)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	digest := sha256.Sum256([]byte("tok.thumbprint"))
+	records := server.GetZone(zones["example.com"]).Records
+	if len(records) != 1 || records[0].Name != "_acme-challenge" || records[0].Value != b64(digest[:]) {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["fqdn"] != "_acme-challenge.example.com." || resp["value"] != b64(digest[:]) {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestHTTPReq_Errors(t *testing.T) {
+	t.Parallel()
+	router, _, _, key := newSolverTestProxy(t, "example.com", "other.com")
+	h := newHTTPReqTestServer(router)
+
+	tests := []struct {
+		name string
+		key  string
+		body string
+		want int
+	}{
+		{"missing key", "", `{"fqdn":"_acme-challenge.example.com","value":"v"}`, http.StatusUnauthorized},
+		{"invalid key", "wrong-key", `{"fqdn":"_acme-challenge.example.com","value":"v"}`, http.StatusUnauthorized},
+		{"invalid JSON", key, `{`, http.StatusBadRequest},
+		{"missing name", key, `{"value":"v"}`, http.StatusBadRequest},
+		{"missing value", key, `{"fqdn":"_acme-challenge.example.com"}`, http.StatusBadRequest},
+		{"not a challenge name", key, `{"fqdn":"www.example.com","value":"v"}`, http.StatusBadRequest},
+		{"zone without permission", key, `{"fqdn":"_acme-challenge.other.com","value":"v"}`, http.StatusNotFound},
+		{"unknown zone", key, `{"fqdn":"_acme-challenge.example.net","value":"v"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, "/acme/present", strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set("AccessKey", tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// checkInterval is how often the certificate's expiry is checked.
+	checkInterval = 12 * time.Hour
+
+	// retryInterval is how soon a failed renewal is retried.
+	retryInterval = time.Hour
+
+	// obtainTimeout bounds a single certificate order.
+	obtainTimeout = 10 * time.Minute
+)
+
+// Files kept in Config.CertDir.
+const (
+	accountKeyFile = "account.key"
+	certFile       = "cert.pem"
+	keyFile        = "key.pem"
+)
+
+// Config configures a Manager.
+type Config struct {
+	// Domains are the names on the certificate; the first is its common name.
+	Domains []string
+	// DirectoryURL is the CA's ACME directory.
+	DirectoryURL string
+	// Email is registered with the CA for expiry notices. Optional.
+	Email string
+	// CertDir holds the account key, certificate and certificate key.
+	CertDir string
+	// RenewBefore renews the certificate this long before it expires.
+	RenewBefore time.Duration
+}
+
+// Manager keeps a certificate for the configured domains current and serves
+// it to a TLS listener.
+type Manager struct {
+	cfg    Config
+	solver Solver
+	logger *slog.Logger
+	opts   []Option
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewManager creates a manager; opts configure the ACME client it uses.
+func NewManager(cfg Config, solver Solver, logger *slog.Logger, opts ...Option) *Manager {
+	return &Manager{cfg: cfg, solver: solver, logger: logger, opts: opts}
+}
+
+// GetCertificate returns the current certificate, for tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil {
+		return nil, errors.New("acme: no certificate obtained yet")
+	}
+	return m.cert, nil
+}
+
+// Load reads a previously obtained certificate from CertDir. A missing
+// certificate is not an error.
+func (m *Manager) Load() error {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(m.cfg.CertDir, certFile), filepath.Join(m.cfg.CertDir, keyFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	m.setCert(&cert)
+	return nil
+}
+
+// Renew obtains a new certificate if there is none, it expires within
+// RenewBefore, or it does not cover every configured domain.
+func (m *Manager) Renew(ctx context.Context) error {
+	if !m.due(time.Now()) {
+		return nil
+	}
+
+	key, err := m.accountKey()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, obtainTimeout)
+	defer cancel()
+	opts := append([]Option{WithContact(m.cfg.Email)}, m.opts...)
+	certPEM, keyPEM, err := NewClient(m.cfg.DirectoryURL, key, opts...).Obtain(ctx, m.cfg.Domains, m.solver)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("CA returned an unusable certificate: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(m.cfg.CertDir, keyFile), keyPEM); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(m.cfg.CertDir, certFile), certPEM); err != nil {
+		return err
+	}
+	m.setCert(&cert)
+	m.logger.Info("acme: certificate renewed", "domains", m.cfg.Domains, "expires", cert.Leaf.NotAfter)
+	return nil
+}
+
+// Run loads the stored certificate and renews it when due until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	if err := m.Load(); err != nil {
+		m.logger.Warn("acme: ignoring stored certificate", "error", err)
+	}
+	for {
+		wait := checkInterval
+		if err := m.Renew(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			m.logger.Error("acme: certificate renewal failed", "domains", m.cfg.Domains, "error", err)
+			wait = retryInterval
+		}
+		if sleep(ctx, wait) != nil {
+			return
+		}
+	}
+}
+
+func (m *Manager) setCert(cert *tls.Certificate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cert = cert
+}
+
+// due reports whether the certificate needs to be (re)issued at now.
+func (m *Manager) due(now time.Time) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.cert == nil || m.cert.Leaf == nil {
+		return true
+	}
+	if now.Add(m.cfg.RenewBefore).After(m.cert.Leaf.NotAfter) {
+		return true
+	}
+	for _, d := range m.cfg.Domains {
+		if !slices.Contains(m.cert.Leaf.DNSNames, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// accountKey loads the ACME account key from CertDir, creating it on first use.
+func (m *Manager) accountKey() (*ecdsa.PrivateKey, error) {
+	path := filepath.Join(m.cfg.CertDir, accountKeyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("failed to parse account key %s: no PEM data", path)
+		}
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse account key %s: %w", path, err)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read account key: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate account key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode account key: %w", err)
+	}
+	if err := writeFileAtomic(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// writeFileAtomic writes data to path with owner-only permissions, replacing
+// any existing file only once the new content is complete.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // gone after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close() //nolint:errcheck
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package acme
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, ca *fakeCA, dns *fakeDNS, dir string, domains ...string) *Manager {
+	t.Helper()
+	return NewManager(Config{
+		Domains:      domains,
+		DirectoryURL: ca.directoryURL(),
+		CertDir:      dir,
+		RenewBefore:  30 * 24 * time.Hour,
+	}, dns, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithHTTPClient(ca.srv.Client()), WithPollInterval(0), WithPropagationDelay(0))
+}
+
+func TestManager_RenewStoresAndServesCertificate(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	ca := newFakeCA(t, dns)
+	dir := t.TempDir()
+	m := newTestManager(t, ca, dns, dir, "proxy.example.com")
+
+	if _, err := m.GetCertificate(nil); err == nil {
+		t.Fatal("expected error before a certificate is obtained")
+	}
+	if err := m.Renew(context.Background()); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+	cert, err := m.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if cert.Leaf.DNSNames[0] != "proxy.example.com" {
+		t.Errorf("unexpected certificate names %v", cert.Leaf.DNSNames)
+	}
+
+	for _, name := range []string{accountKeyFile, certFile, keyFile} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", name, err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("%s has mode %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+
+	// A fresh certificate is not renewed again
+	ca.mu.Lock()
+	ca.order = nil
+	ca.mu.Unlock()
+	if err := m.Renew(context.Background()); err != nil {
+		t.Fatalf("Renew of a fresh certificate failed: %v", err)
+	}
+
+	// A restarted manager picks up the stored certificate
+	restarted := newTestManager(t, ca, dns, dir, "proxy.example.com")
+	if err := restarted.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if restarted.due(time.Now()) {
+		t.Error("stored certificate should not be due for renewal")
+	}
+}
+
+func TestManager_Due(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	ca := newFakeCA(t, dns)
+	ca.expiry = 10 * 24 * time.Hour
+	m := newTestManager(t, ca, dns, t.TempDir(), "a.example.com")
+
+	if !m.due(time.Now()) {
+		t.Error("missing certificate should be due")
+	}
+	if err := m.Renew(context.Background()); err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+	// Expires in 10 days, inside the 30-day renewal window
+	if !m.due(time.Now()) {
+		t.Error("certificate expiring within RenewBefore should be due")
+	}
+
+	m.cfg.RenewBefore = 24 * time.Hour
+	if m.due(time.Now()) {
+		t.Error("certificate outside RenewBefore should not be due")
+	}
+	m.cfg.Domains = append(m.cfg.Domains, "b.example.com")
+	if !m.due(time.Now()) {
+		t.Error("certificate missing a configured domain should be due")
+	}
+}
+
+func TestManager_LoadMissingIsNotAnError(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	m := newTestManager(t, newFakeCA(t, dns), dns, t.TempDir(), "example.com")
+	if err := m.Load(); err != nil {
+		t.Fatalf("Load with no stored certificate failed: %v", err)
+	}
+}
+
+func TestManager_AccountKeyReused(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	m := newTestManager(t, newFakeCA(t, dns), dns, t.TempDir(), "example.com")
+
+	first, err := m.accountKey()
+	if err != nil {
+		t.Fatalf("accountKey failed: %v", err)
+	}
+	second, err := m.accountKey()
+	if err != nil {
+		t.Fatalf("accountKey failed: %v", err)
+	}
+	if !first.Equal(second) {
+		t.Error("expected the stored account key to be reused")
+	}
+}
+
+func TestManager_RunStopsOnCancel(t *testing.T) {
+	t.Parallel()
+	dns := newFakeDNS()
+	m := newTestManager(t, newFakeCA(t, dns), dns, t.TempDir(), "example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		if _, err := m.GetCertificate(nil); err == nil {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("Run did not obtain a certificate")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
@@ -0,0 +1,172 @@
+package acme
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/replay"
+)
+
+// challengeTTL is the TTL of challenge TXT records; short, since they are
+// removed minutes after creation.
+const challengeTTL = 60
+
+// recordTypeTXT is bunny.net's type code for TXT records.
+const recordTypeTXT = 3
+
+// ErrZoneNotFound is returned when no zone visible to the solver's token
+// contains the challenge name.
+var ErrZoneNotFound = errors.New("acme: no zone found for domain")
+
+// RequestError is returned when the proxy answers a solver request with an
+// error status, for example because the access key lacks a permission.
+type RequestError struct {
+	Method string
+	Path   string
+	Status int
+	Body   string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Path, e.Status, e.Body)
+}
+
+// ProxySolver answers DNS-01 challenges by sending record requests through
+// the proxy's own handler, authenticated with an access key. The usual
+// permission checks, audit log and change events apply, so the key needs
+// add_record and delete_record for TXT records on the zones involved.
+type ProxySolver struct {
+	handler   http.Handler
+	accessKey string
+}
+
+// NewProxySolver creates a solver that sends requests to handler, which
+// should be the proxy router including its authentication middleware.
+func NewProxySolver(handler http.Handler, accessKey string) *ProxySolver {
+	return &ProxySolver{handler: handler, accessKey: accessKey}
+}
+
+// Present creates a TXT record with value at fqdn in the zone containing it.
+func (s *ProxySolver) Present(ctx context.Context, fqdn, value string) error {
+	zone, name, err := s.FindZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"Type": recordTypeTXT, "Name": name, "Value": value, "Ttl": challengeTTL}
+	return s.do(ctx, http.MethodPost, fmt.Sprintf("/dnszone/%d/records", zone.ID), body, nil)
+}
+
+// CleanUp deletes the TXT records with value at fqdn. It succeeds if there
+// is nothing to delete.
+func (s *ProxySolver) CleanUp(ctx context.Context, fqdn, value string) error {
+	zone, name, err := s.FindZone(ctx, fqdn)
+	if err != nil {
+		return err
+	}
+	var full bunny.Zone
+	if err := s.do(ctx, http.MethodGet, fmt.Sprintf("/dnszone/%d", zone.ID), nil, &full); err != nil {
+		return err
+	}
+	for _, rec := range full.Records {
+		if rec.Type != recordTypeTXT || !strings.EqualFold(rec.Name, name) || rec.Value != value {
+			continue
+		}
+		if err := s.do(ctx, http.MethodDelete, fmt.Sprintf("/dnszone/%d/records/%d", zone.ID, rec.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindZone returns the most specific zone containing fqdn, and fqdn's record
+// name relative to that zone ("" for the apex).
+func (s *ProxySolver) FindZone(ctx context.Context, fqdn string) (*bunny.Zone, string, error) {
+	fqdn = strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	labels := strings.Split(fqdn, ".")
+	for i := range labels {
+		candidate := strings.Join(labels[i:], ".")
+		var zones bunny.ListZonesResponse
+		path := "/dnszone?search=" + url.QueryEscape(candidate)
+		if err := s.do(ctx, http.MethodGet, path, nil, &zones); err != nil {
+			return nil, "", err
+		}
+		for _, z := range zones.Items {
+			if strings.EqualFold(z.Domain, candidate) {
+				return &z, strings.TrimSuffix(strings.TrimSuffix(fqdn, candidate), "."), nil
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("%w: %s", ErrZoneNotFound, fqdn)
+}
+
+// do serves one request through the proxy handler and decodes the JSON
+// response into out.
+func (s *ProxySolver) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("AccessKey", s.accessKey)
+	// Each request is new, so it passes replay protection when it is required
+	req.Header.Set(replay.TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(replay.NonceHeader, rand.Text())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := &responseBuffer{header: make(http.Header)}
+	s.handler.ServeHTTP(w, req)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= 300 {
+		return &RequestError{Method: method, Path: path, Status: w.status, Body: strings.TrimSpace(w.body.String())}
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.body.Bytes(), out); err != nil {
+			return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// responseBuffer is an in-memory http.ResponseWriter.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header { return b.header }
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
@@ -0,0 +1,129 @@
+package acme
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/proxy"
+	"github.com/sipico/bunny-api-proxy/internal/replay"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockbunny"
+)
+
+// newSolverTestProxy returns a proxy router backed by mockbunny, and a
+// scoped token allowed to manage TXT records in the first zone only.
+func newSolverTestProxy(t *testing.T, zoneDomains ...string) (http.Handler, *mockbunny.Server, map[string]int64, string) {
+	t.Helper()
+	server := mockbunny.New()
+	t.Cleanup(server.Close)
+	zones := make(map[string]int64)
+	for _, d := range zoneDomains {
+		zones[d] = server.AddZone(d)
+	}
+
+	db, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() }) //nolint:errcheck
+
+	const key = "acme-key"
+	hash := sha256.Sum256([]byte(key))
+	token, err := db.CreateToken(context.Background(), "acme", false, hex.EncodeToString(hash[:]))
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	_, err = db.AddPermissionForToken(context.Background(), token.ID, &storage.Permission{
+		ZoneID:         zones[zoneDomains[0]],
+		AllowedActions: []string{"add_record", "delete_record"},
+		RecordTypes:    []string{"TXT"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add permission: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := proxy.NewHandler(bunny.NewClient("test", bunny.WithBaseURL(server.URL())), logger)
+	authenticator := auth.NewAuthenticator(db, auth.NewBootstrapService(db, "master-key"))
+	// Replay protection is required, so the solver must send fresh headers
+	guard, err := replay.New(db, replay.ModeRequired, logger)
+	if err != nil {
+		t.Fatalf("failed to create replay guard: %v", err)
+	}
+	chain := func(next http.Handler) http.Handler {
+		return authenticator.Authenticate(guard.Middleware(authenticator.CheckPermissions(next)))
+	}
+	return proxy.NewRouter(handler, chain, logger), server, zones, key
+}
+
+func TestProxySolver_PresentAndCleanUp(t *testing.T) {
+	t.Parallel()
+	router, server, zones, key := newSolverTestProxy(t, "example.com")
+	solver := NewProxySolver(router, key)
+	ctx := context.Background()
+
+	if err := solver.Present(ctx, "_acme-challenge.www.example.com", "value-1"); err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+	if err := solver.Present(ctx, "_acme-challenge.www.example.com.", "value-2"); err != nil {
+		t.Fatalf("Present failed: %v", err)
+	}
+	records := server.GetZone(zones["example.com"]).Records
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if records[0].Name != "_acme-challenge.www" || records[0].Type != recordTypeTXT || records[0].Value != "value-1" {
+		t.Errorf("unexpected record %+v", records[0])
+	}
+
+	if err := solver.CleanUp(ctx, "_acme-challenge.www.example.com", "value-1"); err != nil {
+		t.Fatalf("CleanUp failed: %v", err)
+	}
+	records = server.GetZone(zones["example.com"]).Records
+	if len(records) != 1 || records[0].Value != "value-2" {
+		t.Errorf("expected only value-2 to remain, got %+v", records)
+	}
+
+	// Cleaning up twice is harmless
+	if err := solver.CleanUp(ctx, "_acme-challenge.www.example.com", "value-1"); err != nil {
+		t.Errorf("repeated CleanUp failed: %v", err)
+	}
+}
+
+func TestProxySolver_FindZonePrefersMostSpecific(t *testing.T) {
+	t.Parallel()
+	router, _, zones, key := newSolverTestProxy(t, "sub.example.com", "example.com")
+	solver := NewProxySolver(router, key)
+
+	zone, name, err := solver.FindZone(context.Background(), "_acme-challenge.sub.example.com")
+	if err != nil {
+		t.Fatalf("FindZone failed: %v", err)
+	}
+	if zone.ID != zones["sub.example.com"] || name != "_acme-challenge" {
+		t.Errorf("FindZone = %d %q, want zone %d name _acme-challenge", zone.ID, name, zones["sub.example.com"])
+	}
+}
+
+func TestProxySolver_PermissionsApply(t *testing.T) {
+	t.Parallel()
+	// The token only has a permission for the first zone
+	router, _, _, key := newSolverTestProxy(t, "example.com", "other.com")
+	solver := NewProxySolver(router, key)
+
+	err := solver.Present(context.Background(), "_acme-challenge.other.com", "value")
+	if !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("expected zone outside the token's permissions to be invisible, got %v", err)
+	}
+
+	if err := NewProxySolver(router, "wrong-key").Present(context.Background(), "_acme-challenge.example.com", "v"); err == nil {
+		t.Error("expected an invalid access key to be rejected")
+	}
+}
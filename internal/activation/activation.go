@@ -0,0 +1,87 @@
+// Package activation reads listening sockets passed by systemd socket
+// activation.
+//
+// systemd opens the sockets itself and passes them to the service from file
+// descriptor 3 on, announcing them with LISTEN_PID, LISTEN_FDS and
+// LISTEN_FDNAMES. Because systemd keeps the sockets open while the service
+// restarts, connections made in between wait in the socket's backlog instead
+// of being refused, and the service can serve privileged ports without
+// binding them.
+package activation
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// firstFD is the first file descriptor passed by systemd.
+const firstFD = 3
+
+// ErrNotListening is returned for a passed descriptor that is not a
+// listening stream socket, such as a datagram socket or a regular file.
+var ErrNotListening = errors.New("passed file descriptor is not a listening socket")
+
+// Listener is one passed socket.
+type Listener struct {
+	// Name is the socket's FileDescriptorName, which defaults to the name of
+	// its socket unit.
+	Name string
+	net.Listener
+}
+
+// Listeners returns the sockets passed to this process, in the order systemd
+// passed them, or nil if none were passed. The LISTEN_* variables are
+// removed from the environment so child processes do not take them as their
+// own.
+func Listeners() ([]Listener, error) {
+	defer func() {
+		for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			//nolint:errcheck // Unsetenv only fails for invalid names
+			os.Unsetenv(name)
+		}
+	}()
+	return listeners(os.Getenv, os.Getpid(), firstFD)
+}
+
+// listeners turns count descriptors starting at first into listeners, as
+// announced by getenv for process pid.
+func listeners(getenv func(string) string, pid, first int) ([]Listener, error) {
+	// The variables are meant for the process systemd started; a child
+	// that inherited them must ignore them
+	if getenv("LISTEN_PID") != strconv.Itoa(pid) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", getenv("LISTEN_FDS"))
+	}
+	var names []string
+	if s := getenv("LISTEN_FDNAMES"); s != "" {
+		names = strings.Split(s, ":")
+	}
+
+	out := make([]Listener, 0, count)
+	for i := range count {
+		name := "unknown"
+		if i < len(names) {
+			name = names[i]
+		}
+		fd := first + i
+		// FileListener duplicates the descriptor, so the original is closed
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, prev := range out {
+				_ = prev.Close()
+			}
+			return nil, fmt.Errorf("file descriptor %d (%s): %w", fd, name, ErrNotListening)
+		}
+		out = append(out, Listener{Name: name, Listener: l})
+	}
+	return out, nil
+}
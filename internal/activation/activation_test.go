@@ -0,0 +1,83 @@
+//go:build unix
+
+package activation
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+// passFD returns a raw descriptor for f that is not owned by an *os.File, as
+// systemd would pass it.
+func passFD(t *testing.T, f *os.File) int {
+	t.Helper()
+	defer f.Close()
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("dup failed: %v", err)
+	}
+	return fd
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestListeners(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("File failed: %v", err)
+	}
+	fd := passFD(t, f)
+
+	got, err := listeners(env(map[string]string{
+		"LISTEN_PID": "42", "LISTEN_FDS": "1", "LISTEN_FDNAMES": "main",
+	}), 42, fd)
+	if err != nil {
+		t.Fatalf("listeners failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "main" || got[0].Addr().String() != l.Addr().String() {
+		t.Fatalf("unexpected listeners %+v", got)
+	}
+	defer got[0].Close()
+}
+
+func TestListeners_NotPassed(t *testing.T) {
+	for name, vars := range map[string]map[string]string{
+		"no variables":  {},
+		"other process": {"LISTEN_PID": "7", "LISTEN_FDS": "1"},
+	} {
+		got, err := listeners(env(vars), 42, firstFD)
+		if err != nil || got != nil {
+			t.Errorf("%s: expected no listeners, got %v, %v", name, got, err)
+		}
+	}
+
+	if _, err := listeners(env(map[string]string{"LISTEN_PID": "42", "LISTEN_FDS": "x"}), 42, firstFD); err == nil {
+		t.Error("expected error for invalid LISTEN_FDS")
+	}
+}
+
+func TestListeners_NotASocket(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "fd")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	fd := passFD(t, f)
+
+	_, err = listeners(env(map[string]string{
+		"LISTEN_PID": strconv.Itoa(os.Getpid()), "LISTEN_FDS": "1",
+	}), os.Getpid(), fd)
+	if !errors.Is(err, ErrNotListening) {
+		t.Errorf("expected ErrNotListening, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// SetAccounts sets the names of the bunny.net accounts configured beside the
+// default one, which tokens may be assigned to.
+func (h *Handler) SetAccounts(names []string) {
+	h.accounts = slices.Sorted(slices.Values(names))
+}
+
+// checkAccount reports whether account is empty (the default account) or
+// configured, writing a 400 response if it is not.
+func (h *Handler) checkAccount(w http.ResponseWriter, account string) bool {
+	if account == "" || slices.Contains(h.accounts, account) {
+		return true
+	}
+	hint := "No accounts are configured besides the default; add them with BUNNY_ACCOUNTS."
+	if len(h.accounts) > 0 {
+		hint = "Configured accounts: " + strings.Join(h.accounts, ", ") + ". Leave account empty for the default account."
+	}
+	WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Unknown account", hint)
+	return false
+}
+
+// AccountsResponse lists the upstream accounts tokens can be assigned to.
+// The default account, used by tokens without one, is not listed.
+type AccountsResponse struct {
+	Accounts []string `json:"accounts"`
+}
+
+// HandleListAccounts returns the names of the configured accounts.
+// GET /api/accounts
+func (h *Handler) HandleListAccounts(w http.ResponseWriter, _ *http.Request) {
+	accounts := h.accounts
+	if accounts == nil {
+		accounts = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(AccountsResponse{Accounts: accounts})
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// SetTokenAccountRequest is the request body for PUT /api/tokens/{id}/account.
+type SetTokenAccountRequest struct {
+	Account string `json:"account"`
+}
+
+// HandleSetTokenAccount assigns a token to an upstream account; an empty
+// account assigns it to the default one. Takes effect on the token's next request.
+// PUT /api/tokens/{id}/account
+// Body: {"account": "client-a"}
+func (h *Handler) HandleSetTokenAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid token ID", "Token ID must be a number.")
+		return
+	}
+
+	var req SetTokenAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	if !h.checkAccount(w, req.Account) {
+		return
+	}
+
+	if err := h.storage.SetTokenAccount(r.Context(), id, req.Account); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+			return
+		}
+		h.logger.Error("failed to set token account", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to set token account")
+		return
+	}
+
+	h.logger.Info("token account changed", "id", id, "account", req.Account, "by", actorName(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
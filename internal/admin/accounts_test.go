@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestTokenAccounts(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetAccounts([]string{"client-b", "client-a"})
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodGet, "/api/accounts", "")
+	var accounts AccountsResponse
+	if err := json.NewDecoder(w.Body).Decode(&accounts); err != nil || len(accounts.Accounts) != 2 || accounts.Accounts[0] != "client-a" {
+		t.Fatalf("unexpected accounts: %+v, %v", accounts, err)
+	}
+
+	w = doWebhookRequest(t, router, http.MethodPost, "/api/tokens",
+		`{"name":"ci","zones":[1],"actions":["list_records"],"record_types":["TXT"],"account":"client-a"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created CreateUnifiedTokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil || created.Account != "client-a" {
+		t.Fatalf("unexpected response: %+v, %v", created, err)
+	}
+	tokenPath := "/api/tokens/" + strconv.FormatInt(created.ID, 10)
+
+	w = doWebhookRequest(t, router, http.MethodPost, "/api/tokens",
+		`{"name":"other","zones":[1],"actions":["list_records"],"record_types":["TXT"],"account":"client-c"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown account, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doWebhookRequest(t, router, http.MethodPut, tokenPath+"/account", `{"account":"client-b"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doWebhookRequest(t, router, http.MethodGet, tokenPath, "")
+	var detail UnifiedTokenDetailResponse
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil || detail.Account != "client-b" {
+		t.Errorf("unexpected token: %+v, %v", detail, err)
+	}
+
+	for name, tc := range map[string]struct {
+		path, body string
+		want       int
+	}{
+		"default account": {tokenPath + "/account", `{"account":""}`, http.StatusNoContent},
+		"unknown account": {tokenPath + "/account", `{"account":"client-c"}`, http.StatusBadRequest},
+		"unknown token":   {"/api/tokens/9999/account", `{"account":"client-a"}`, http.StatusNotFound},
+		"invalid token":   {"/api/tokens/abc/account", `{"account":"client-a"}`, http.StatusBadRequest},
+		"invalid request": {tokenPath + "/account", `{`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPut, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if token, err := store.GetTokenByID(ctx, created.ID); err != nil || token.Account != "" {
+		t.Errorf("expected the default account, got %+v, %v", token, err)
+	}
+}
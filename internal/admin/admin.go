@@ -5,9 +5,23 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"net/http"
+	"time"
 
+	"github.com/sipico/bunny-api-proxy/internal/audit"
 	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/blocklist"
+	"github.com/sipico/bunny-api-proxy/internal/config"
+	"github.com/sipico/bunny-api-proxy/internal/docs"
+	"github.com/sipico/bunny-api-proxy/internal/freeze"
+	"github.com/sipico/bunny-api-proxy/internal/instance"
+	"github.com/sipico/bunny-api-proxy/internal/logging"
+	internalMiddleware "github.com/sipico/bunny-api-proxy/internal/middleware"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/ttlpolicy"
+	"github.com/sipico/bunny-api-proxy/internal/usage"
+	"github.com/sipico/bunny-api-proxy/internal/webhooks"
+	"github.com/sipico/bunny-api-proxy/internal/zonetags"
 )
 
 // Common errors
@@ -24,6 +38,66 @@ type Handler struct {
 	logger    *slog.Logger
 	logLevel  *slog.LevelVar
 	bootstrap *auth.BootstrapService
+	webhooks  *webhooks.Service
+	instances *instance.Registry
+	blocklist *blocklist.Blocklist
+	freezes   *freeze.Schedule
+	ttl       *ttlpolicy.Policies
+	audit     *audit.Logger
+	usage     *usage.Tracker
+	zoneTags  *zonetags.Syncer
+	config    *config.Config
+	readiness http.Handler
+	liveness  http.Handler
+
+	// events receives security events; nil disables them.
+	events EventPublisher
+
+	// sessions stores admin sessions; nil disables them.
+	sessions   SessionStore
+	sessionTTL time.Duration
+
+	// clientCerts maps client certificates to tokens; nil disables them.
+	clientCerts ClientCertStore
+
+	// authProviders authenticate bearer credentials; none disables them.
+	authProviders []AuthProvider
+
+	// roles stores roles; nil disables them.
+	roles RoleStore
+
+	// invitations stores invitation links; nil disables them.
+	invitations InvitationStore
+
+	// bodyLogging switches upstream body logging; nil disables its endpoints.
+	bodyLogging *logging.BodySwitch
+
+	// docs is the operator documentation; nil disables GET /docs.
+	docs        *docs.Page
+	docsContent *docsContent // built by NewRouter
+
+	// accounts names the upstream accounts tokens may be assigned to.
+	accounts []string
+
+	// domainPerms stores domain permissions; nil disables them.
+	domainPerms DomainPermissionStore
+
+	// protected stores record names scoped tokens may not change; nil disables them.
+	protected ProtectedRecordStore
+
+	// history lists record changes made through the proxy; nil disables it.
+	history RecordHistoryStore
+
+	// reverter undoes record changes; nil disables it.
+	reverter ChangeReverter
+
+	// authCache caches the proxy's tokens and permissions; nil if none.
+	authCache AuthCache
+
+	// cors lets browsers on other origins call the admin API; nil disables it.
+	cors *internalMiddleware.CORSConfig
+
+	fingerprints *auth.FingerprintSource
 }
 
 // Storage interface for admin operations
@@ -34,10 +108,16 @@ type Storage interface {
 
 	// Unified token operations
 	CreateToken(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error)
+	CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*storage.Token, error)
 	GetTokenByID(ctx context.Context, id int64) (*storage.Token, error)
 	GetTokenByHash(ctx context.Context, keyHash string) (*storage.Token, error)
+	GetTokenByKey(ctx context.Context, id int64, secretHash string) (*storage.Token, error)
 	ListTokens(ctx context.Context) ([]*storage.Token, error)
 	DeleteToken(ctx context.Context, id int64) error
+	SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error
+	SetTokenPinning(ctx context.Context, id int64, pin bool) error
+	SetTokenAccount(ctx context.Context, id int64, account string) error
+	PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error)
 	CountAdminTokens(ctx context.Context) (int, error)
 
 	// Unified permission operations
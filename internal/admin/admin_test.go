@@ -5,6 +5,7 @@ import (
 	"io"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -78,6 +79,10 @@ func (m *mockStorageForAdminTest) CreateToken(ctx context.Context, name string,
 	return &storage.Token{ID: 1, Name: name, IsAdmin: isAdmin, KeyHash: keyHash}, nil
 }
 
+func (m *mockStorageForAdminTest) CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*storage.Token, error) {
+	return &storage.Token{ID: 1, Name: name, IsAdmin: isAdmin, KeyHash: secretHash}, nil
+}
+
 func (m *mockStorageForAdminTest) GetTokenByID(ctx context.Context, id int64) (*storage.Token, error) {
 	return nil, storage.ErrNotFound
 }
@@ -94,6 +99,22 @@ func (m *mockStorageForAdminTest) CountAdminTokens(ctx context.Context) (int, er
 	return 1, nil
 }
 
+func (m *mockStorageForAdminTest) SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *mockStorageForAdminTest) SetTokenAccount(ctx context.Context, id int64, account string) error {
+	return nil
+}
+
+func (m *mockStorageForAdminTest) SetTokenPinning(ctx context.Context, id int64, pin bool) error {
+	return nil
+}
+
+func (m *mockStorageForAdminTest) PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error) {
+	return fingerprint, nil
+}
+
 func (m *mockStorageForAdminTest) AddPermissionForToken(ctx context.Context, tokenID int64, perm *storage.Permission) (*storage.Permission, error) {
 	perm.ID = 1
 	perm.TokenID = tokenID
@@ -115,3 +136,7 @@ func (m *mockStorageForAdminTest) GetPermissionsForToken(ctx context.Context, to
 func (m *mockStorageForAdminTest) GetTokenByHash(ctx context.Context, keyHash string) (*storage.Token, error) {
 	return nil, storage.ErrNotFound
 }
+
+func (m *mockStorageForAdminTest) GetTokenByKey(ctx context.Context, id int64, secretHash string) (*storage.Token, error) {
+	return nil, storage.ErrNotFound
+}
@@ -2,18 +2,19 @@ package admin
 
 import (
 	"crypto/rand"
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sipico/bunny-api-proxy/internal/auth"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
 )
 
 // SetLogLevelRequest is the request body for POST /api/loglevel
@@ -75,12 +76,15 @@ func generateRandomKey(length int) (string, error) {
 // Unified Token API Handlers (Issue 147)
 // =============================================================================
 
-// WhoamiResponse represents the current token's identity.
+// WhoamiResponse represents the current token's identity. Provider and
+// Subject identify a user authenticated with a bearer token.
 type WhoamiResponse struct {
 	TokenID     int64                 `json:"token_id,omitempty"`
 	Name        string                `json:"name,omitempty"`
 	IsAdmin     bool                  `json:"is_admin"`
 	IsMasterKey bool                  `json:"is_master_key"`
+	Provider    string                `json:"provider,omitempty"`
+	Subject     string                `json:"subject,omitempty"`
 	Permissions []*storage.Permission `json:"permissions,omitempty"`
 }
 
@@ -93,6 +97,10 @@ func (h *Handler) HandleWhoami(w http.ResponseWriter, r *http.Request) {
 		IsMasterKey: auth.IsMasterKeyFromContext(ctx),
 		IsAdmin:     auth.IsAdminFromContext(ctx),
 	}
+	if principal := principalFromContext(ctx); principal != nil {
+		resp.Provider = principal.Provider
+		resp.Subject = principal.Subject
+	}
 
 	// Get token from context if available
 	token := auth.TokenFromContext(ctx)
@@ -126,11 +134,55 @@ type UnifiedTokenResponse struct {
 	Name      string `json:"name"`
 	IsAdmin   bool   `json:"is_admin"`
 	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Expired   bool   `json:"expired,omitempty"`
+
+	PinTLSFingerprint bool `json:"pin_tls_fingerprint,omitempty"`
+
+	// Account is the upstream account; empty for the default one.
+	Account string `json:"account,omitempty"`
+
+	// LastUsedAt is empty for tokens that were never used. Both fields are
+	// updated in batches and can lag behind recent requests.
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	UseCount   int64  `json:"use_count"`
+}
+
+// unusedBefore reports whether a token has not been used since the cutoff.
+// Tokens that were never used count from when they were created.
+func unusedBefore(t *storage.Token, cutoff time.Time) bool {
+	last := t.LastUsedAt
+	if last.IsZero() {
+		last = t.CreatedAt
+	}
+	return last.Before(cutoff)
+}
+
+// formatOptionalTime formats t as RFC3339, or "" for the zero time, such as
+// a token that never expires or was never used.
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
 }
 
 // HandleListUnifiedTokens returns all tokens (unified model).
 // GET /api/tokens
+// The optional unused_since query parameter (RFC3339) limits the list to tokens
+// not used since then, counting never-used tokens from their creation.
 func (h *Handler) HandleListUnifiedTokens(w http.ResponseWriter, r *http.Request) {
+	var unusedSince time.Time
+	if v := r.URL.Query().Get("unused_since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid unused_since",
+				"Use an RFC3339 timestamp, e.g. 2026-01-02T15:04:05Z.")
+			return
+		}
+		unusedSince = t
+	}
+
 	tokens, err := h.storage.ListTokens(r.Context())
 	if err != nil {
 		h.logger.Error("failed to list tokens", "error", err)
@@ -138,14 +190,26 @@ func (h *Handler) HandleListUnifiedTokens(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	response := make([]UnifiedTokenResponse, len(tokens))
-	for i, t := range tokens {
-		response[i] = UnifiedTokenResponse{
+	now := time.Now()
+	response := make([]UnifiedTokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		if !unusedSince.IsZero() && !unusedBefore(t, unusedSince) {
+			continue
+		}
+		response = append(response, UnifiedTokenResponse{
 			ID:        t.ID,
 			Name:      t.Name,
 			IsAdmin:   t.IsAdmin,
 			CreatedAt: t.CreatedAt.Format(time.RFC3339),
-		}
+			ExpiresAt: formatOptionalTime(t.ExpiresAt),
+			Expired:   t.IsExpired(now),
+
+			PinTLSFingerprint: t.PinTLSFingerprint,
+			Account:           t.Account,
+
+			LastUsedAt: formatOptionalTime(t.LastUsedAt),
+			UseCount:   t.UseCount,
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -162,24 +226,46 @@ type CreateUnifiedTokenRequest struct {
 	Zones       []int64  `json:"zones,omitempty"`
 	Actions     []string `json:"actions,omitempty"`
 	RecordTypes []string `json:"record_types,omitempty"`
+	Roles       []int64  `json:"roles,omitempty"`      // role IDs; scoped tokens only
+	ExpiresAt   string   `json:"expires_at,omitempty"` // RFC3339; scoped tokens only
+
+	// PinTLSFingerprint binds the token to the TLS client that first uses it; scoped tokens only
+	PinTLSFingerprint bool `json:"pin_tls_fingerprint,omitempty"`
+
+	// Account is the upstream account the token's requests are sent to; empty for the default one
+	Account string `json:"account,omitempty"`
 }
 
 // CreateUnifiedTokenResponse includes the token (shown only once).
 type CreateUnifiedTokenResponse struct {
-	ID      int64  `json:"id"`
-	Name    string `json:"name"`
-	Token   string `json:"token"` // Plain token, shown once
-	IsAdmin bool   `json:"is_admin"`
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Token     string `json:"token"` // Plain token, shown once
+	IsAdmin   bool   `json:"is_admin"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+
+	PinTLSFingerprint bool   `json:"pin_tls_fingerprint,omitempty"`
+	Account           string `json:"account,omitempty"`
 }
 
 // HandleCreateUnifiedToken creates a new token (admin or scoped).
 // POST /api/tokens
-// Body: {"name": "...", "is_admin": true/false, "zones": [...], "actions": [...], "record_types": [...]}
+// Body: {"name": "...", "is_admin": true/false, "zones": [...], "actions": [...], "record_types": [...], "roles": [...], "expires_at": "...", "pin_tls_fingerprint": true/false, "account": "..."}
+//
+// Scoped tokens need zones, actions and record types, roles, or both. Zones
+// are granted the same actions and record types; roles grant their
+// permissions for as long as the token holds them.
+//
+// Scoped tokens may set expires_at (RFC3339, in the future), after which they
+// are rejected. Admin tokens cannot expire, so an expiry cannot lock admins out.
+// For the same reason only scoped tokens may pin a TLS fingerprint.
 //
 // Bootstrap logic:
 //   - During UNCONFIGURED state: only allow creating admin tokens (is_admin: true)
 //   - Master key is allowed during bootstrap
 //   - After admin exists: master key is locked out, only admin tokens can manage
+//   - Creating an admin token records the end of bootstrap, so the master key
+//     stays locked out even if every admin token is later removed
 func (h *Handler) HandleCreateUnifiedToken(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -224,7 +310,16 @@ func (h *Handler) HandleCreateUnifiedToken(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Validate permissions for scoped tokens
-	if !req.IsAdmin {
+	if len(req.Roles) > 0 {
+		if req.IsAdmin {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Admin tokens do not use roles")
+			return
+		}
+		if !h.checkRoles(ctx, w, req.Roles) {
+			return
+		}
+	}
+	if !req.IsAdmin && (len(req.Roles) == 0 || len(req.Zones) > 0) {
 		if len(req.Zones) == 0 {
 			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Scoped tokens require at least one zone")
 			return
@@ -237,22 +332,50 @@ func (h *Handler) HandleCreateUnifiedToken(w http.ResponseWriter, r *http.Reques
 			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Scoped tokens require at least one record type")
 			return
 		}
+		recordTypes, ok := normalizeRecordTypes(w, req.RecordTypes)
+		if !ok {
+			return
+		}
+		req.RecordTypes = recordTypes
 	}
 
-	// Generate secure token
-	plainToken, err := generateRandomKey(64) // 64 hex chars = 32 bytes = 256 bits
+	var expiresAt time.Time
+	if req.ExpiresAt != "" {
+		if req.IsAdmin {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Admin tokens cannot expire")
+			return
+		}
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid expires_at",
+				"Use an RFC3339 timestamp, e.g. 2026-01-02T15:04:05Z.")
+			return
+		}
+		if !t.After(time.Now()) {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "expires_at must be in the future")
+			return
+		}
+		expiresAt = t
+	}
+
+	if req.PinTLSFingerprint && req.IsAdmin {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Admin tokens cannot pin a TLS fingerprint")
+		return
+	}
+	if !h.checkAccount(w, req.Account) {
+		return
+	}
+
+	// Generate the secret of the token's <id>.<secret> key
+	secret, err := generateRandomKey(64) // 64 hex chars = 32 bytes = 256 bits
 	if err != nil {
 		h.logger.Error("failed to generate secure token", "error", err)
 		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to generate token")
 		return
 	}
 
-	// Hash the token for storage
-	hash := sha256.Sum256([]byte(plainToken))
-	keyHash := hex.EncodeToString(hash[:])
-
-	// Create the token
-	token, err := h.storage.CreateToken(ctx, req.Name, req.IsAdmin, keyHash)
+	// Create the token, storing only the hash of its secret
+	token, err := h.storage.CreateKeyToken(ctx, req.Name, req.IsAdmin, auth.HashToken(secret))
 	if err != nil {
 		if errors.Is(err, storage.ErrDuplicate) {
 			WriteErrorWithHint(w, http.StatusConflict, "duplicate_token",
@@ -284,15 +407,84 @@ func (h *Handler) HandleCreateUnifiedToken(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	h.logger.Info("token created", "id", token.ID, "name", req.Name, "is_admin", req.IsAdmin)
+	for _, roleID := range req.Roles {
+		if err := h.roles.AssignRole(ctx, token.ID, roleID); err != nil && !errors.Is(err, storage.ErrDuplicate) {
+			h.logger.Error("failed to assign role", "error", err, "token_id", token.ID, "role_id", roleID)
+			if delErr := h.storage.DeleteToken(ctx, token.ID); delErr != nil {
+				h.logger.Error("failed to clean up token after role error", "error", delErr)
+			}
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to assign roles")
+			return
+		}
+	}
+
+	if !expiresAt.IsZero() {
+		if err := h.storage.SetTokenExpiry(ctx, token.ID, expiresAt); err != nil {
+			h.logger.Error("failed to set token expiry", "error", err, "token_id", token.ID)
+			if delErr := h.storage.DeleteToken(ctx, token.ID); delErr != nil {
+				h.logger.Error("failed to clean up token after expiry error", "error", delErr)
+			}
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to set token expiry")
+			return
+		}
+	}
+
+	if req.PinTLSFingerprint {
+		if err := h.storage.SetTokenPinning(ctx, token.ID, true); err != nil {
+			h.logger.Error("failed to enable TLS fingerprint pinning", "error", err, "token_id", token.ID)
+			if delErr := h.storage.DeleteToken(ctx, token.ID); delErr != nil {
+				h.logger.Error("failed to clean up token after pinning error", "error", delErr)
+			}
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to enable TLS fingerprint pinning")
+			return
+		}
+	}
+
+	if req.Account != "" {
+		if err := h.storage.SetTokenAccount(ctx, token.ID, req.Account); err != nil {
+			h.logger.Error("failed to set token account", "error", err, "token_id", token.ID)
+			if delErr := h.storage.DeleteToken(ctx, token.ID); delErr != nil {
+				h.logger.Error("failed to clean up token after account error", "error", delErr)
+			}
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to set token account")
+			return
+		}
+	}
+
+	// The first admin token ends bootstrap for good
+	if req.IsAdmin && h.bootstrap != nil {
+		if err := h.bootstrap.Complete(ctx); err != nil {
+			h.logger.Error("failed to record bootstrap completion", "error", err, "token_id", token.ID)
+		}
+	}
+
+	h.logger.Info("token created", "id", token.ID, "name", req.Name, "is_admin", req.IsAdmin,
+		"expires_at", formatOptionalTime(expiresAt), "pin_tls_fingerprint", req.PinTLSFingerprint, "account", req.Account)
+	created := map[string]any{"token_id": token.ID, "token_name": req.Name, "is_admin": req.IsAdmin}
+	if !req.IsAdmin {
+		created["zones"] = req.Zones
+		created["allowed_actions"] = req.Actions
+		created["record_types"] = req.RecordTypes
+		if len(req.Roles) > 0 {
+			created["roles"] = req.Roles
+		}
+	}
+	if !expiresAt.IsZero() {
+		created["expires_at"] = formatOptionalTime(expiresAt)
+	}
+	h.publish(ctx, webhook.EventTokenCreated, created)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	encErr := json.NewEncoder(w).Encode(CreateUnifiedTokenResponse{
-		ID:      token.ID,
-		Name:    req.Name,
-		Token:   plainToken, // Return plaintext once
-		IsAdmin: req.IsAdmin,
+		ID:        token.ID,
+		Name:      req.Name,
+		Token:     auth.FormatKey(token.ID, secret), // Return plaintext once
+		IsAdmin:   req.IsAdmin,
+		ExpiresAt: formatOptionalTime(expiresAt),
+
+		PinTLSFingerprint: req.PinTLSFingerprint,
+		Account:           req.Account,
 	})
 	if encErr != nil {
 		_ = encErr
@@ -300,12 +492,25 @@ func (h *Handler) HandleCreateUnifiedToken(w http.ResponseWriter, r *http.Reques
 }
 
 // UnifiedTokenDetailResponse includes token details and permissions.
+// Permissions are the token's own; those of its roles are listed with Roles.
 type UnifiedTokenDetailResponse struct {
 	ID          int64                 `json:"id"`
 	Name        string                `json:"name"`
 	IsAdmin     bool                  `json:"is_admin"`
 	CreatedAt   string                `json:"created_at"`
+	ExpiresAt   string                `json:"expires_at,omitempty"`
+	Expired     bool                  `json:"expired,omitempty"`
 	Permissions []*storage.Permission `json:"permissions,omitempty"`
+	Roles       []RoleResponse        `json:"roles,omitempty"`
+
+	// TLSFingerprint is empty until a pinned token is first used.
+	PinTLSFingerprint bool   `json:"pin_tls_fingerprint,omitempty"`
+	TLSFingerprint    string `json:"tls_fingerprint,omitempty"`
+
+	Account string `json:"account,omitempty"`
+
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	UseCount   int64  `json:"use_count"`
 }
 
 // HandleGetUnifiedToken returns token details.
@@ -336,6 +541,16 @@ func (h *Handler) HandleGetUnifiedToken(w http.ResponseWriter, r *http.Request)
 		Name:      token.Name,
 		IsAdmin:   token.IsAdmin,
 		CreatedAt: token.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: formatOptionalTime(token.ExpiresAt),
+		Expired:   token.IsExpired(time.Now()),
+
+		PinTLSFingerprint: token.PinTLSFingerprint,
+		TLSFingerprint:    token.TLSFingerprint,
+
+		Account: token.Account,
+
+		LastUsedAt: formatOptionalTime(token.LastUsedAt),
+		UseCount:   token.UseCount,
 	}
 
 	// Get permissions for scoped tokens
@@ -346,7 +561,22 @@ func (h *Handler) HandleGetUnifiedToken(w http.ResponseWriter, r *http.Request)
 			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get permissions")
 			return
 		}
-		resp.Permissions = perms
+		for _, p := range perms {
+			if p.RoleID == 0 {
+				resp.Permissions = append(resp.Permissions, p)
+			}
+		}
+	}
+	if !token.IsAdmin && h.roles != nil {
+		roles, err := h.roles.ListTokenRoles(ctx, token.ID)
+		if err != nil {
+			h.logger.Error("failed to get roles", "error", err, "token_id", id)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get roles")
+			return
+		}
+		for _, role := range roles {
+			resp.Roles = append(resp.Roles, toRoleResponse(role))
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -408,11 +638,16 @@ func (h *Handler) HandleDeleteUnifiedToken(w http.ResponseWriter, r *http.Reques
 	}
 
 	h.logger.Info("token deleted", "id", id)
+	h.publish(ctx, webhook.EventTokenDeleted, map[string]any{
+		"token_id": id, "token_name": token.Name, "is_admin": token.IsAdmin,
+	})
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // AddPermissionRequest is the request body for POST /api/tokens/{id}/permissions.
 type AddPermissionRequest struct {
+	// Resource is "dnszone" (default), "pullzone" or "storagezone"; ZoneID refers to it.
+	Resource       string   `json:"resource,omitempty"`
 	ZoneID         int64    `json:"zone_id"`
 	AllowedActions []string `json:"allowed_actions"`
 	RecordTypes    []string `json:"record_types"`
@@ -421,14 +656,68 @@ type AddPermissionRequest struct {
 // PermissionResponse represents a permission in API responses.
 type PermissionResponse struct {
 	ID             int64    `json:"id"`
+	Resource       string   `json:"resource"`
 	ZoneID         int64    `json:"zone_id"`
 	AllowedActions []string `json:"allowed_actions"`
 	RecordTypes    []string `json:"record_types"`
 }
 
+func toPermissionResponse(p *storage.Permission) PermissionResponse {
+	return PermissionResponse{
+		ID:             p.ID,
+		Resource:       p.Resource,
+		ZoneID:         p.ZoneID,
+		AllowedActions: p.AllowedActions,
+		RecordTypes:    p.RecordTypes,
+	}
+}
+
+// permissionFromRequest validates a token or role permission request.
+// It writes a 400 response and returns false if the request is invalid.
+func permissionFromRequest(w http.ResponseWriter, req AddPermissionRequest) (*storage.Permission, bool) {
+	if req.Resource == "" {
+		req.Resource = storage.ResourceDNSZone
+	}
+	if !storage.IsValidResource(req.Resource) {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid resource",
+			`Resource must be "dnszone", "pullzone" or "storagezone".`)
+		return nil, false
+	}
+	if req.ZoneID <= 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Zone ID must be greater than 0")
+		return nil, false
+	}
+	if len(req.AllowedActions) == 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one action is required")
+		return nil, false
+	}
+	var recordTypes []string
+	if req.Resource == storage.ResourceDNSZone {
+		if len(req.RecordTypes) == 0 {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one record type is required")
+			return nil, false
+		}
+		var ok bool
+		if recordTypes, ok = normalizeRecordTypes(w, req.RecordTypes); !ok {
+			return nil, false
+		}
+	} else if len(req.RecordTypes) > 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Record types only apply to DNS zone permissions")
+		return nil, false
+	}
+
+	return &storage.Permission{
+		Resource:       req.Resource,
+		ZoneID:         req.ZoneID,
+		AllowedActions: req.AllowedActions,
+		RecordTypes:    recordTypes,
+	}, true
+}
+
 // HandleAddTokenPermission adds a permission to a token.
 // POST /api/tokens/{id}/permissions
 // Body: {"zone_id": 123, "allowed_actions": [...], "record_types": [...]}
+// Pull and storage zone permissions set "resource" and omit record_types.
 func (h *Handler) HandleAddTokenPermission(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	tokenID, err := strconv.ParseInt(idStr, 10, 64)
@@ -464,26 +753,10 @@ func (h *Handler) HandleAddTokenPermission(w http.ResponseWriter, r *http.Reques
 		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
 		return
 	}
-
-	// Validate required fields
-	if req.ZoneID <= 0 {
-		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Zone ID must be greater than 0")
+	perm, ok := permissionFromRequest(w, req)
+	if !ok {
 		return
 	}
-	if len(req.AllowedActions) == 0 {
-		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one action is required")
-		return
-	}
-	if len(req.RecordTypes) == 0 {
-		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one record type is required")
-		return
-	}
-
-	perm := &storage.Permission{
-		ZoneID:         req.ZoneID,
-		AllowedActions: req.AllowedActions,
-		RecordTypes:    req.RecordTypes,
-	}
 
 	createdPerm, err := h.storage.AddPermissionForToken(ctx, tokenID, perm)
 	if err != nil {
@@ -492,16 +765,16 @@ func (h *Handler) HandleAddTokenPermission(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	h.logger.Info("permission added", "token_id", tokenID, "permission_id", createdPerm.ID, "zone_id", req.ZoneID)
+	h.logger.Info("permission added", "token_id", tokenID, "permission_id", createdPerm.ID, "resource", createdPerm.Resource, "zone_id", createdPerm.ZoneID)
+	h.publish(ctx, webhook.EventPermissionAdded, map[string]any{
+		"token_id": tokenID, "token_name": token.Name, "permission_id": createdPerm.ID,
+		"resource": createdPerm.Resource, "resource_id": createdPerm.ZoneID,
+		"allowed_actions": createdPerm.AllowedActions, "record_types": createdPerm.RecordTypes,
+	})
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	encErr := json.NewEncoder(w).Encode(PermissionResponse{
-		ID:             createdPerm.ID,
-		ZoneID:         createdPerm.ZoneID,
-		AllowedActions: createdPerm.AllowedActions,
-		RecordTypes:    createdPerm.RecordTypes,
-	})
+	encErr := json.NewEncoder(w).Encode(toPermissionResponse(createdPerm))
 	if encErr != nil {
 		_ = encErr
 	}
@@ -528,7 +801,7 @@ func (h *Handler) HandleDeleteTokenPermission(w http.ResponseWriter, r *http.Req
 	ctx := r.Context()
 
 	// Verify token exists
-	_, err = h.storage.GetTokenByID(ctx, tokenID)
+	token, err := h.storage.GetTokenByID(ctx, tokenID)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
@@ -552,5 +825,21 @@ func (h *Handler) HandleDeleteTokenPermission(w http.ResponseWriter, r *http.Req
 	}
 
 	h.logger.Info("permission deleted", "token_id", tokenID, "permission_id", permID)
+	h.publish(ctx, webhook.EventPermissionRemoved, map[string]any{
+		"token_id": tokenID, "token_name": token.Name, "permission_id": permID,
+	})
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// normalizeRecordTypes returns the canonical names for a permission's record types,
+// so "SCRIPT" and "PullZone" are stored the same way the proxy classifies records.
+// It writes a 400 response and returns false if any type is unknown.
+func normalizeRecordTypes(w http.ResponseWriter, types []string) ([]string, bool) {
+	normalized, err := auth.NormalizeRecordTypes(types)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid record_types: "+err.Error(),
+			"Valid types: "+strings.Join(auth.RecordTypeNames(), ", ")+".")
+		return nil, false
+	}
+	return normalized, true
+}
@@ -525,7 +525,7 @@ func TestHandleCreateUnifiedToken(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mock := newMockUnifiedStorage()
-			mock.CreateTokenFunc = func(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error) {
+			mock.CreateKeyTokenFunc = func(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error) {
 				if tt.mockCreateErr != nil {
 					return nil, tt.mockCreateErr
 				}
@@ -576,14 +576,121 @@ func TestHandleCreateUnifiedToken(t *testing.T) {
 				if resp.Token == "" {
 					t.Error("expected token to be set in response")
 				}
-				if len(resp.Token) != 64 {
-					t.Errorf("expected token length 64, got %d", len(resp.Token))
+				if id, secret, ok := auth.ParseKey(resp.Token); !ok || id != tt.mockToken.ID || len(secret) != 64 {
+					t.Errorf("expected a <id>.<secret> token for ID %d with a 64 character secret, got %q", tt.mockToken.ID, resp.Token)
 				}
 			}
 		})
 	}
 }
 
+func TestHandleCreateUnifiedToken_Expiry(t *testing.T) {
+	t.Parallel()
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	scoped := func(expiresAt string) CreateUnifiedTokenRequest {
+		return CreateUnifiedTokenRequest{
+			Name:        "ci-token",
+			Zones:       []int64{123},
+			Actions:     []string{"add_record"},
+			RecordTypes: []string{"TXT"},
+			ExpiresAt:   expiresAt,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       CreateUnifiedTokenRequest
+		expiryErr  error
+		wantStatus int
+		wantExpiry bool
+		wantDelete bool
+	}{
+		{"scoped token with expiry", scoped(future.Format(time.RFC3339)), nil, http.StatusCreated, true, false},
+		{"admin token with expiry", CreateUnifiedTokenRequest{Name: "admin", IsAdmin: true, ExpiresAt: future.Format(time.RFC3339)}, nil, http.StatusBadRequest, false, false},
+		{"expiry in the past", scoped(time.Now().Add(-time.Hour).Format(time.RFC3339)), nil, http.StatusBadRequest, false, false},
+		{"invalid expiry", scoped("tomorrow"), nil, http.StatusBadRequest, false, false},
+		{"storage error setting expiry", scoped(future.Format(time.RFC3339)), storage.ErrNotFound, http.StatusInternalServerError, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotExpiry time.Time
+			deleted := false
+			mock := newMockUnifiedStorage()
+			mock.CreateKeyTokenFunc = func(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error) {
+				return &storage.Token{ID: 7, Name: name, IsAdmin: isAdmin}, nil
+			}
+			mock.SetTokenExpiryFunc = func(ctx context.Context, id int64, expiresAt time.Time) error {
+				gotExpiry = expiresAt
+				return tt.expiryErr
+			}
+			mock.DeleteTokenFunc = func(ctx context.Context, id int64) error {
+				deleted = true
+				return nil
+			}
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+
+			bodyBytes, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest("POST", "/api/tokens", bytes.NewBuffer(bodyBytes))
+			w := httptest.NewRecorder()
+
+			h.HandleCreateUnifiedToken(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantExpiry != !gotExpiry.IsZero() {
+				t.Errorf("expected expiry set = %v, got %v", tt.wantExpiry, gotExpiry)
+			}
+			if tt.wantExpiry && !gotExpiry.Equal(future) {
+				t.Errorf("expected expiry %v, got %v", future, gotExpiry)
+			}
+			if deleted != tt.wantDelete {
+				t.Errorf("expected token cleanup = %v, got %v", tt.wantDelete, deleted)
+			}
+			if tt.wantStatus == http.StatusCreated {
+				var resp CreateUnifiedTokenResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.ExpiresAt != future.Format(time.RFC3339) {
+					t.Errorf("expected expires_at %q, got %q", future.Format(time.RFC3339), resp.ExpiresAt)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleListUnifiedTokens_Expiry(t *testing.T) {
+	t.Parallel()
+	past := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock := newMockUnifiedStorage()
+	mock.ListTokensFunc = func(ctx context.Context) ([]*storage.Token, error) {
+		return []*storage.Token{
+			{ID: 1, Name: "admin", IsAdmin: true},
+			{ID: 2, Name: "ci-token", ExpiresAt: past},
+		}, nil
+	}
+	h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+
+	w := httptest.NewRecorder()
+	h.HandleListUnifiedTokens(w, httptest.NewRequest("GET", "/api/tokens", nil))
+
+	var resp []UnifiedTokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp) != 2 {
+		t.Fatalf("expected 2 tokens, got %d", len(resp))
+	}
+	if resp[0].ExpiresAt != "" || resp[0].Expired {
+		t.Errorf("expected token without expiry, got %+v", resp[0])
+	}
+	if resp[1].ExpiresAt != "2025-01-01T00:00:00Z" || !resp[1].Expired {
+		t.Errorf("expected expired token, got %+v", resp[1])
+	}
+}
+
 func TestHandleGetUnifiedToken(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -872,6 +979,80 @@ func TestHandleAddTokenPermission(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 			wantBody:   "invalid_request",
 		},
+		{
+			name:      "bunny-specific record types are normalized",
+			tokenID:   "2",
+			mockToken: &storage.Token{ID: 2, Name: "scoped", IsAdmin: false},
+			body: AddPermissionRequest{
+				ZoneID:         123,
+				AllowedActions: []string{"add_record"},
+				RecordTypes:    []string{"script", "PULL_ZONE", "Redirect", "SCRIPT"},
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"record_types":["Script","PullZone","SPF"]`,
+		},
+		{
+			name:      "pull zone permission without record types",
+			tokenID:   "2",
+			mockToken: &storage.Token{ID: 2, Name: "scoped", IsAdmin: false},
+			body: AddPermissionRequest{
+				Resource:       storage.ResourcePullZone,
+				ZoneID:         456,
+				AllowedActions: []string{"purge_pull_zone"},
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"resource":"pullzone","zone_id":456`,
+		},
+		{
+			name:      "storage zone permission",
+			tokenID:   "2",
+			mockToken: &storage.Token{ID: 2, Name: "scoped", IsAdmin: false},
+			body: AddPermissionRequest{
+				Resource:       storage.ResourceStorageZone,
+				ZoneID:         789,
+				AllowedActions: []string{"reset_storage_zone_password"},
+			},
+			wantStatus: http.StatusCreated,
+			wantBody:   `"resource":"storagezone","zone_id":789`,
+		},
+		{
+			name:      "pull zone permission with record types",
+			tokenID:   "2",
+			mockToken: &storage.Token{ID: 2, Name: "scoped", IsAdmin: false},
+			body: AddPermissionRequest{
+				Resource:       storage.ResourcePullZone,
+				ZoneID:         456,
+				AllowedActions: []string{"purge_pull_zone"},
+				RecordTypes:    []string{"TXT"},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid_request",
+		},
+		{
+			name:      "unknown resource",
+			tokenID:   "2",
+			mockToken: &storage.Token{ID: 2, Name: "scoped", IsAdmin: false},
+			body: AddPermissionRequest{
+				Resource:       "storagebox",
+				ZoneID:         456,
+				AllowedActions: []string{"list_records"},
+				RecordTypes:    []string{"TXT"},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "Invalid resource",
+		},
+		{
+			name:      "unknown record type",
+			tokenID:   "2",
+			mockToken: &storage.Token{ID: 2, Name: "scoped", IsAdmin: false},
+			body: AddPermissionRequest{
+				ZoneID:         123,
+				AllowedActions: []string{"list_records"},
+				RecordTypes:    []string{"TXT", "WORKER"},
+			},
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `unknown record type \"WORKER\"`,
+		},
 		{
 			name:      "storage error on add",
 			tokenID:   "2",
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/audit"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// Limits for GET /api/audit.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// SetAuditLog records admin API requests in the audit log and enables the
+// audit log endpoint. Must be called before NewRouter.
+func (h *Handler) SetAuditLog(l *audit.Logger) {
+	h.audit = l
+}
+
+// AuditEntryResponse represents an audit entry in API responses.
+type AuditEntryResponse struct {
+	ID         int64  `json:"id"`
+	TokenID    int64  `json:"token_id"`
+	TokenName  string `json:"token_name"`
+	Action     string `json:"action,omitempty"`
+	ZoneID     int64  `json:"zone_id,omitempty"`
+	RecordType string `json:"record_type,omitempty"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	RequestID  string `json:"request_id,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// AuditLogResponse is the response body for GET /api/audit.
+// NextBeforeID is set when more entries may follow; pass it as before_id to
+// fetch the next page.
+type AuditLogResponse struct {
+	Entries      []AuditEntryResponse `json:"entries"`
+	NextBeforeID int64                `json:"next_before_id,omitempty"`
+}
+
+// HandleListAudit returns audit log entries, newest first.
+// GET /api/audit?token_id=&zone_id=&action=&record_type=&result=&since=&until=&before_id=&limit=
+// result is "success" (status below 400) or "failure"; since and until are RFC3339.
+func (h *Handler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	entries, err := h.audit.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("failed to list audit log", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list audit log")
+		return
+	}
+
+	resp := AuditLogResponse{Entries: make([]AuditEntryResponse, 0, len(entries))}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, AuditEntryResponse{
+			ID:         e.ID,
+			TokenID:    e.TokenID,
+			TokenName:  e.TokenName,
+			Action:     e.Action,
+			ZoneID:     e.ZoneID,
+			RecordType: e.RecordType,
+			Method:     e.Method,
+			Path:       e.Path,
+			Status:     e.Status,
+			RequestID:  e.RequestID,
+			CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	if len(entries) == filter.Limit {
+		resp.NextBeforeID = entries[len(entries)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(resp)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// auditFilterError is a query parameter validation error, shown to the caller.
+type auditFilterError string
+
+func (e auditFilterError) Error() string { return string(e) }
+
+// parseAuditFilter builds a storage filter from the GET /api/audit query.
+func parseAuditFilter(r *http.Request) (storage.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := storage.AuditFilter{
+		Action:     q.Get("action"),
+		RecordType: q.Get("record_type"),
+		Limit:      defaultAuditLimit,
+	}
+
+	ids := []struct {
+		param string
+		dst   *int64
+	}{
+		{"token_id", &filter.TokenID},
+		{"zone_id", &filter.ZoneID},
+		{"before_id", &filter.BeforeID},
+	}
+	for _, p := range ids {
+		if s := q.Get(p.param); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 1 {
+				return filter, auditFilterError("Invalid " + p.param + " parameter")
+			}
+			*p.dst = n
+		}
+	}
+
+	times := []struct {
+		param string
+		dst   *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	}
+	for _, p := range times {
+		if s := q.Get(p.param); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return filter, auditFilterError("Invalid " + p.param + " parameter, expected RFC3339")
+			}
+			*p.dst = t
+		}
+	}
+
+	switch q.Get("result") {
+	case "":
+	case "success":
+		filter.MaxStatus = http.StatusBadRequest - 1
+	case "failure":
+		filter.MinStatus = http.StatusBadRequest
+	default:
+		return filter, auditFilterError("Invalid result parameter, expected success or failure")
+	}
+
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			return filter, auditFilterError("Invalid limit parameter")
+		}
+		filter.Limit = n
+	}
+
+	return filter, nil
+}
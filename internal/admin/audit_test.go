@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/audit"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestAuditEndpoint(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	admin, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey))
+	if err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	if _, err := store.CreateToken(ctx, "scoped", false, auth.HashToken("scoped-token")); err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+	for _, e := range []*storage.AuditEntry{
+		{TokenID: 2, Action: "add_record", ZoneID: 5, RecordType: "TXT", Method: "POST", Path: "/dnszone/5/records", Status: 201},
+		{TokenID: 2, Action: "delete_record", ZoneID: 5, RecordType: "TXT", Method: "DELETE", Path: "/dnszone/5/records/9", Status: 403},
+		{TokenID: 2, Action: "add_record", ZoneID: 6, RecordType: "A", Method: "POST", Path: "/dnszone/6/records", Status: 201},
+	} {
+		if _, err := store.AppendAuditEntry(ctx, e); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetAuditLog(audit.New(store, logger))
+	router := h.NewRouter()
+
+	list := func(query string) AuditLogResponse {
+		t.Helper()
+		w := doWebhookRequest(t, router, http.MethodGet, "/api/audit"+query, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /api/audit%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp AuditLogResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	resp := list("?zone_id=5&result=failure")
+	if len(resp.Entries) != 1 || resp.Entries[0].Action != "delete_record" || resp.Entries[0].Status != 403 {
+		t.Errorf("expected the denied delete, got %+v", resp.Entries)
+	}
+	if resp.NextBeforeID != 0 {
+		t.Errorf("expected no next page, got %d", resp.NextBeforeID)
+	}
+
+	// Page through the proxy entries two at a time
+	resp = list("?token_id=2&limit=2")
+	if len(resp.Entries) != 2 || resp.Entries[0].ZoneID != 6 || resp.NextBeforeID != resp.Entries[1].ID {
+		t.Fatalf("unexpected first page: %+v", resp)
+	}
+	resp = list("?token_id=2&limit=2&before_id=" + strconv.FormatInt(resp.NextBeforeID, 10))
+	if len(resp.Entries) != 1 || resp.Entries[0].Action != "add_record" || resp.Entries[0].ZoneID != 5 {
+		t.Errorf("unexpected second page: %+v", resp.Entries)
+	}
+
+	// The admin requests above were recorded too
+	resp = list("?action=admin&token_id=" + strconv.FormatInt(admin.ID, 10))
+	if len(resp.Entries) != 3 || resp.Entries[0].Path != "/api/audit" || resp.Entries[0].TokenName != "admin" {
+		t.Errorf("expected earlier admin requests to be audited, got %+v", resp.Entries)
+	}
+
+	for _, query := range []string{"?zone_id=abc", "?token_id=0", "?since=yesterday", "?result=maybe", "?limit=0", "?limit=5000"} {
+		if w := doWebhookRequest(t, router, http.MethodGet, "/api/audit"+query, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/audit%s: expected 400, got %d", query, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/audit", nil)
+	req.Header.Set("AccessKey", "scoped-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected scoped token to be refused, got %d", w.Code)
+	}
+}
+
+func TestParseAuditFilter(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet,
+		"/api/audit?record_type=TXT&result=success&since=2025-01-01T00:00:00Z&until=2025-01-02T00:00:00Z", nil)
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		t.Fatalf("parseAuditFilter failed: %v", err)
+	}
+	if filter.RecordType != "TXT" || filter.MaxStatus != 399 || filter.MinStatus != 0 || filter.Limit != defaultAuditLimit {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+	if !filter.Since.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || !filter.Until.Equal(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected time range: %v - %v", filter.Since, filter.Until)
+	}
+}
+
+func TestAuditEndpointNotRegisteredWithoutAuditLog(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	router := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil))).NewRouter()
+	if w := doWebhookRequest(t, router, http.MethodGet, "/api/audit", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without an audit log, got %d", w.Code)
+	}
+}
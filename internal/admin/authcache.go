@@ -0,0 +1,31 @@
+package admin
+
+import "net/http"
+
+// AuthCache caches authenticated tokens and their permissions.
+// It is satisfied by *auth.Authenticator.
+type AuthCache interface {
+	InvalidateCache()
+}
+
+// SetAuthCache drops the cached tokens and permissions after every change
+// made through the admin API, so revoked tokens and changed permissions take
+// effect at once. Must be called before NewRouter.
+func (h *Handler) SetAuthCache(c AuthCache) {
+	h.authCache = c
+}
+
+// invalidateAuthCache is middleware that drops the auth cache after every
+// mutating request, whether or not it succeeded. Token, permission, role,
+// domain and tag changes all affect cached permissions, and admin changes are
+// rare enough that dropping everything costs little.
+func (h *Handler) invalidateAuthCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			h.authCache.InvalidateCache()
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+// countingAuthCache counts invalidations.
+type countingAuthCache struct {
+	invalidations int
+}
+
+func (c *countingAuthCache) InvalidateCache() {
+	c.invalidations++
+}
+
+func TestAuthCacheInvalidatedOnChanges(t *testing.T) {
+	t.Parallel()
+	adminHash := auth.HashToken("admin-secret")
+	mock := &mockstore.MockStorage{
+		GetTokenByHashFunc: func(_ context.Context, keyHash string) (*storage.Token, error) {
+			if keyHash == adminHash {
+				return &storage.Token{ID: 1, Name: "admin", IsAdmin: true, KeyHash: adminHash}, nil
+			}
+			return nil, storage.ErrNotFound
+		},
+		ListTokensFunc: func(context.Context) ([]*storage.Token, error) {
+			return nil, nil
+		},
+		GetTokenByIDFunc: func(context.Context, int64) (*storage.Token, error) {
+			return nil, storage.ErrNotFound
+		},
+	}
+	cache := &countingAuthCache{}
+	h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+	h.SetAuthCache(cache)
+	router := h.NewRouter()
+
+	for _, tt := range []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/tokens", 0},
+		{http.MethodDelete, "/api/tokens/9", 1}, // failed changes invalidate too
+		{http.MethodGet, "/health", 1},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set("AccessKey", "admin-secret")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if cache.invalidations != tt.want {
+			t.Errorf("after %s %s: %d invalidations, want %d", tt.method, tt.path, cache.invalidations, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+var (
+	// ErrUnknownCredential is returned by an AuthProvider for a credential it
+	// does not handle, such as a JWT from another issuer, so the next
+	// provider is tried.
+	ErrUnknownCredential = errors.New("credential not handled by this provider")
+	// ErrInvalidCredential is wrapped by the errors an AuthProvider returns
+	// for a credential it handles but rejects, such as an expired JWT.
+	ErrInvalidCredential = errors.New("invalid credential")
+)
+
+// AuthProvider authenticates admin API requests with an Authorization:
+// Bearer credential instead of an admin token, such as a JWT from the
+// operators' single sign-on. Errors other than ErrUnknownCredential and
+// ErrInvalidCredential mean the credential could not be checked.
+type AuthProvider interface {
+	Authenticate(ctx context.Context, credential string) (*Principal, error)
+}
+
+// Principal is a user authenticated by an AuthProvider.
+type Principal struct {
+	// Provider names the provider, such as "oidc".
+	Provider string
+	// Subject identifies the user at the provider.
+	Subject string
+	// Name names the user in logs and audit entries.
+	Name string
+	// IsAdmin reports whether the user has an admin role. Other users are
+	// refused, as the admin API has no scoped access for them.
+	IsAdmin bool
+}
+
+// token returns the token a principal acts as. It is not stored, so its ID
+// is 0; its name attributes audit entries and changes to the principal.
+func (p *Principal) token() *storage.Token {
+	return &storage.Token{Name: p.Provider + ":" + p.Name, IsAdmin: p.IsAdmin}
+}
+
+// SetAuthProviders lets the admin API accept Authorization: Bearer
+// credentials from requests without an AccessKey header. Providers are
+// tried in order. Must be called before NewRouter.
+func (h *Handler) SetAuthProviders(providers ...AuthProvider) {
+	h.authProviders = providers
+}
+
+type principalContextKey struct{}
+
+// principalFromContext returns the principal a request authenticated as, or
+// nil if it used a token.
+func principalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalContextKey{}).(*Principal)
+	return p
+}
+
+// bearerCredential returns the credential of an Authorization: Bearer header.
+func bearerCredential(r *http.Request) (string, bool) {
+	scheme, credential, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	credential = strings.TrimSpace(credential)
+	return credential, credential != ""
+}
+
+// authenticateBearer serves an admin API request as the principal an auth
+// provider authenticates its bearer credential as.
+func (h *Handler) authenticateBearer(w http.ResponseWriter, r *http.Request, next http.Handler, credential string) {
+	ctx := r.Context()
+	for _, provider := range h.authProviders {
+		principal, err := provider.Authenticate(ctx, credential)
+		if errors.Is(err, ErrUnknownCredential) {
+			continue
+		}
+		if errors.Is(err, ErrInvalidCredential) {
+			h.logger.Warn("invalid admin bearer token", "error", err, "remote_addr", r.RemoteAddr)
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			h.logger.Error("failed to verify admin bearer token", "error", err)
+			http.Error(w, "Identity provider unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if !principal.IsAdmin {
+			h.logger.Warn("admin bearer token without an admin role", "provider", principal.Provider,
+				"principal", principal.Name, "remote_addr", r.RemoteAddr)
+			WriteErrorWithHint(w, http.StatusForbidden, ErrCodeAdminRequired,
+				"Your identity does not have an admin role",
+				"Ask an administrator to grant you an admin role at your identity provider.")
+			return
+		}
+
+		ctx = auth.WithToken(ctx, principal.token())
+		ctx = auth.WithAdmin(ctx, true)
+		ctx = context.WithValue(ctx, principalContextKey{}, principal)
+		h.logger.Debug("admin API request via bearer token", "provider", principal.Provider, "principal", principal.Name)
+		next.ServeHTTP(w, r.WithContext(ctx))
+		return
+	}
+
+	h.logger.Warn("invalid admin bearer token", "remote_addr", r.RemoteAddr)
+	http.Error(w, "Invalid token", http.StatusUnauthorized)
+}
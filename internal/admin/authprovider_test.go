@@ -0,0 +1,181 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeAuthProvider authenticates the credentials in its principals map and
+// returns err for any other credential.
+type fakeAuthProvider struct {
+	principals map[string]*Principal
+	err        error
+}
+
+func (p *fakeAuthProvider) Authenticate(_ context.Context, credential string) (*Principal, error) {
+	if principal, ok := p.principals[credential]; ok {
+		return principal, nil
+	}
+	return nil, p.err
+}
+
+// newBearerRouter returns an admin router with sessions enabled and the given
+// auth providers, and the plaintext of an admin token stored in it.
+func newBearerRouter(t *testing.T, providers ...AuthProvider) (chi.Router, string) {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	adminKey := "admin-token-plaintext"
+	if _, err := store.CreateToken(context.Background(), "ops", true, auth.HashToken(adminKey)); err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetSessions(store, 15*time.Minute)
+	h.SetAuthProviders(providers...)
+	return h.NewRouter(), adminKey
+}
+
+// bearerRequest serves a request with the given Authorization and AccessKey
+// headers, each omitted if empty.
+func bearerRequest(router http.Handler, method, path, authorization, accessKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if accessKey != "" {
+		req.Header.Set("AccessKey", accessKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTokenAuthMiddleware_Bearer(t *testing.T) {
+	t.Parallel()
+	provider := &fakeAuthProvider{
+		principals: map[string]*Principal{
+			"admin-jwt":  {Provider: "oidc", Subject: "user-1", Name: "alice@example.com", IsAdmin: true},
+			"viewer-jwt": {Provider: "oidc", Subject: "user-2", Name: "bob@example.com"},
+		},
+		err: fmt.Errorf("%w: expired", ErrInvalidCredential),
+	}
+	router, adminKey := newBearerRouter(t, provider)
+
+	tests := []struct {
+		name          string
+		authorization string
+		accessKey     string
+		wantStatus    int
+	}{
+		{name: "admin role", authorization: "Bearer admin-jwt", wantStatus: http.StatusOK},
+		{name: "lowercase scheme", authorization: "bearer admin-jwt", wantStatus: http.StatusOK},
+		{name: "no admin role", authorization: "Bearer viewer-jwt", wantStatus: http.StatusForbidden},
+		{name: "invalid credential", authorization: "Bearer expired-jwt", wantStatus: http.StatusUnauthorized},
+		{name: "other scheme", authorization: "Basic YWxpY2U6c2VjcmV0", wantStatus: http.StatusUnauthorized},
+		{name: "empty credential", authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "access key takes precedence", authorization: "Bearer viewer-jwt", accessKey: adminKey, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := bearerRequest(router, http.MethodGet, "/api/whoami", tt.authorization, tt.accessKey)
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTokenAuthMiddleware_BearerWhoami(t *testing.T) {
+	t.Parallel()
+	router, _ := newBearerRouter(t, &fakeAuthProvider{
+		principals: map[string]*Principal{
+			"admin-jwt": {Provider: "oidc", Subject: "user-1", Name: "alice@example.com", IsAdmin: true},
+		},
+		err: ErrInvalidCredential,
+	})
+
+	w := bearerRequest(router, http.MethodGet, "/api/whoami", "Bearer admin-jwt", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp WhoamiResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Name != "oidc:alice@example.com" || !resp.IsAdmin || resp.IsMasterKey {
+		t.Errorf("unexpected identity: %+v", resp)
+	}
+	if resp.Provider != "oidc" || resp.Subject != "user-1" {
+		t.Errorf("expected provider oidc and subject user-1, got %q and %q", resp.Provider, resp.Subject)
+	}
+}
+
+func TestTokenAuthMiddleware_BearerProviderChain(t *testing.T) {
+	t.Parallel()
+	first := &fakeAuthProvider{err: ErrUnknownCredential}
+	second := &fakeAuthProvider{
+		principals: map[string]*Principal{
+			"second-jwt": {Provider: "second", Subject: "user-1", Name: "alice", IsAdmin: true},
+		},
+		err: ErrUnknownCredential,
+	}
+	router, _ := newBearerRouter(t, first, second)
+
+	if w := bearerRequest(router, http.MethodGet, "/api/whoami", "Bearer second-jwt", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the second provider to authenticate, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := bearerRequest(router, http.MethodGet, "/api/whoami", "Bearer unknown-jwt", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 when no provider handles the credential, got %d", w.Code)
+	}
+}
+
+func TestTokenAuthMiddleware_BearerProviderUnavailable(t *testing.T) {
+	t.Parallel()
+	router, _ := newBearerRouter(t, &fakeAuthProvider{err: errors.New("connection refused")})
+
+	if w := bearerRequest(router, http.MethodGet, "/api/whoami", "Bearer some-jwt", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTokenAuthMiddleware_BearerWithoutProviders(t *testing.T) {
+	t.Parallel()
+	router, _ := newBearerRouter(t)
+
+	if w := bearerRequest(router, http.MethodGet, "/api/whoami", "Bearer some-jwt", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleCreateSession_Bearer(t *testing.T) {
+	t.Parallel()
+	router, _ := newBearerRouter(t, &fakeAuthProvider{
+		principals: map[string]*Principal{
+			"admin-jwt": {Provider: "oidc", Subject: "user-1", Name: "alice@example.com", IsAdmin: true},
+		},
+		err: ErrInvalidCredential,
+	})
+
+	if w := bearerRequest(router, http.MethodPost, "/api/sessions", "Bearer admin-jwt", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+}
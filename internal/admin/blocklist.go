@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/blocklist"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// SetBlocklist enables the blocklist management endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetBlocklist(b *blocklist.Blocklist) {
+	h.blocklist = b
+}
+
+// CreateBlocklistEntryRequest is the request body for POST /api/blocklist.
+// TTLSeconds makes the entry a temporary ban; zero blocks permanently.
+type CreateBlocklistEntryRequest struct {
+	CIDR       string `json:"cidr"`
+	Reason     string `json:"reason,omitempty"`
+	TTLSeconds int64  `json:"ttl_seconds,omitempty"`
+}
+
+// BlocklistEntryResponse represents a blocklist entry in API responses.
+type BlocklistEntryResponse struct {
+	ID        int64  `json:"id"`
+	CIDR      string `json:"cidr"`
+	Reason    string `json:"reason"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+func toBlocklistEntryResponse(e *storage.BlocklistEntry) BlocklistEntryResponse {
+	resp := BlocklistEntryResponse{
+		ID:        e.ID,
+		CIDR:      e.CIDR,
+		Reason:    e.Reason,
+		CreatedBy: e.CreatedBy,
+		CreatedAt: e.CreatedAt.Format(time.RFC3339),
+	}
+	if !e.ExpiresAt.IsZero() {
+		resp.ExpiresAt = e.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// HandleListBlocklist returns the active blocklist entries.
+// GET /api/blocklist
+func (h *Handler) HandleListBlocklist(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.blocklist.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list blocklist", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list blocklist")
+		return
+	}
+
+	response := make([]BlocklistEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		response = append(response, toBlocklistEntryResponse(e))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleCreateBlocklistEntry blocks an IP address or CIDR network.
+// POST /api/blocklist
+// Blocking a network that contains the caller's own address is refused.
+func (h *Handler) HandleCreateBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	var req CreateBlocklistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	if req.TTLSeconds < 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "ttl_seconds must not be negative")
+		return
+	}
+
+	prefix, err := blocklist.ParseCIDR(req.CIDR)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error(),
+			"Use an IP address (203.0.113.7) or CIDR network (203.0.113.0/24).")
+		return
+	}
+	if addr, ok := h.blocklist.ClientIP(r); ok && prefix.Contains(addr) {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Refusing to block your own address "+addr.String())
+		return
+	}
+
+	entry, err := h.blocklist.Add(r.Context(), req.CIDR, req.Reason, adminActor(r), time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, storage.ErrDuplicate) {
+			WriteErrorWithHint(w, http.StatusConflict, "duplicate_entry", "Network is already blocked",
+				"Delete the existing entry first to change its reason or expiry.")
+			return
+		}
+		h.logger.Error("failed to add blocklist entry", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to add blocklist entry")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toBlocklistEntryResponse(entry))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteBlocklistEntry unblocks an entry.
+// DELETE /api/blocklist/{id}
+func (h *Handler) HandleDeleteBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid blocklist entry ID", "Entry ID must be a number.")
+		return
+	}
+
+	if err := h.blocklist.Remove(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Blocklist entry not found")
+			return
+		}
+		h.logger.Error("failed to delete blocklist entry", "id", id, "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete blocklist entry")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminActor names the admin making a blocklist or freeze change.
+func adminActor(r *http.Request) string {
+	if token := auth.TokenFromContext(r.Context()); token != nil {
+		return token.Name
+	}
+	return "master_key"
+}
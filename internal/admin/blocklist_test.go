@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/blocklist"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestBlocklistEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	if _, err := store.CreateToken(ctx, "scoped", false, auth.HashToken("scoped-token")); err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetBlocklist(blocklist.New(store, logger))
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodPost, "/api/blocklist", `{"cidr":"203.0.113.9/24","reason":"scanner","ttl_seconds":3600}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created BlocklistEntryResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.CIDR != "203.0.113.0/24" || created.CreatedBy != "admin" || created.ExpiresAt == "" {
+		t.Errorf("unexpected entry: %+v", created)
+	}
+
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"duplicate":       {`{"cidr":"203.0.113.0/24"}`, http.StatusConflict},
+		"invalid cidr":    {`{"cidr":"example.com"}`, http.StatusBadRequest},
+		"negative ttl":    {`{"cidr":"198.51.100.1","ttl_seconds":-1}`, http.StatusBadRequest},
+		"own address":     {`{"cidr":"192.0.2.0/24"}`, http.StatusBadRequest}, // httptest's RemoteAddr is 192.0.2.1
+		"invalid request": {`{`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPost, "/api/blocklist", tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, "/api/blocklist", "")
+	var list []BlocklistEntryResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID || list[0].Reason != "scanner" {
+		t.Errorf("unexpected blocklist: %+v", list)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/blocklist", nil)
+	req.Header.Set("AccessKey", "scoped-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for scoped token, got %d", w.Code)
+	}
+
+	path := "/api/blocklist/" + strconv.FormatInt(created.ID, 10)
+	if w := doWebhookRequest(t, router, http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting twice, got %d", w.Code)
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, "/api/blocklist/abc", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+}
+
+func TestBlocklistEndpoints_NotRegisteredWithoutBlocklist(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	router := NewHandler(store, nil, nil).NewRouter()
+	if w := doWebhookRequest(t, router, http.MethodGet, "/api/blocklist", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a blocklist, got %d", w.Code)
+	}
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/logging"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// Body logging is meant for short debugging sessions.
+const (
+	defaultBodyLoggingTTL = 10 * time.Minute
+	maxBodyLoggingTTL     = time.Hour
+)
+
+// SetBodyLogging enables the upstream body logging endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetBodyLogging(s *logging.BodySwitch) {
+	h.bodyLogging = s
+}
+
+// EnableBodyLoggingRequest is the request body for PUT /api/debug/body-logging.
+// TokenID limits logging to one token's calls; zero logs every call.
+type EnableBodyLoggingRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	TokenID    int64 `json:"token_id,omitempty"`
+}
+
+// BodyLoggingResponse is the state of upstream body logging.
+type BodyLoggingResponse struct {
+	Enabled   bool   `json:"enabled"`
+	TokenID   int64  `json:"token_id,omitempty"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// writeBodyLogging writes the current body logging state.
+func (h *Handler) writeBodyLogging(w http.ResponseWriter) {
+	until, tokenID := h.bodyLogging.Status()
+	resp := BodyLoggingResponse{Enabled: !until.IsZero(), TokenID: tokenID}
+	if resp.Enabled {
+		resp.ExpiresAt = until.UTC().Format(time.RFC3339)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(resp)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleGetBodyLogging returns whether upstream body logging is on.
+// GET /api/debug/body-logging
+func (h *Handler) HandleGetBodyLogging(w http.ResponseWriter, _ *http.Request) {
+	h.writeBodyLogging(w)
+}
+
+// HandleEnableBodyLogging logs bunny.net request and response bodies at INFO
+// level for a limited time, with secrets in TXT values redacted.
+// PUT /api/debug/body-logging
+// Body: {"ttl_seconds": 600, "token_id": 5}
+func (h *Handler) HandleEnableBodyLogging(w http.ResponseWriter, r *http.Request) {
+	var req EnableBodyLoggingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if req.TTLSeconds == 0 {
+		ttl = defaultBodyLoggingTTL
+	}
+	if ttl <= 0 || ttl > maxBodyLoggingTTL {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid ttl_seconds",
+			"Body logging can be enabled for at most 3600 seconds.")
+		return
+	}
+	if req.TokenID < 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid token_id")
+		return
+	}
+	if req.TokenID != 0 {
+		if _, err := h.storage.GetTokenByID(r.Context(), req.TokenID); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+				return
+			}
+			h.logger.Error("failed to get token", "error", err)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get token")
+			return
+		}
+	}
+
+	until := h.bodyLogging.Enable(ttl, req.TokenID)
+	h.logger.Warn("upstream body logging enabled",
+		"by", actorName(r.Context()), "token_id", req.TokenID, "until", until.UTC().Format(time.RFC3339))
+	h.writeBodyLogging(w)
+}
+
+// HandleDisableBodyLogging turns upstream body logging off.
+// DELETE /api/debug/body-logging
+func (h *Handler) HandleDisableBodyLogging(w http.ResponseWriter, r *http.Request) {
+	h.bodyLogging.Disable()
+	h.logger.Info("upstream body logging disabled", "by", actorName(r.Context()))
+	w.WriteHeader(http.StatusNoContent)
+}
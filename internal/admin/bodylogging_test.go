@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/logging"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestBodyLoggingEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	scoped, err := store.CreateToken(ctx, "scoped", false, auth.HashToken("scoped-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+
+	switcher := logging.NewBodySwitch()
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetBodyLogging(switcher)
+	router := h.NewRouter()
+
+	decode := func(body io.Reader) BodyLoggingResponse {
+		t.Helper()
+		var resp BodyLoggingResponse
+		if err := json.NewDecoder(body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	w := doWebhookRequest(t, router, http.MethodGet, "/api/debug/body-logging", "")
+	if resp := decode(w.Body); w.Code != http.StatusOK || resp.Enabled {
+		t.Fatalf("expected body logging off, got %d: %+v", w.Code, resp)
+	}
+
+	body := `{"ttl_seconds":300,"token_id":` + strconv.FormatInt(scoped.ID, 10) + `}`
+	w = doWebhookRequest(t, router, http.MethodPut, "/api/debug/body-logging", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp := decode(w.Body); !resp.Enabled || resp.TokenID != scoped.ID || resp.ExpiresAt == "" {
+		t.Errorf("unexpected state: %+v", resp)
+	}
+	if !switcher.Enabled(scoped.ID) || switcher.Enabled(scoped.ID+1) {
+		t.Error("switch not limited to the scoped token")
+	}
+
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"ttl too long":    {`{"ttl_seconds":7200}`, http.StatusBadRequest},
+		"negative ttl":    {`{"ttl_seconds":-1}`, http.StatusBadRequest},
+		"unknown token":   {`{"token_id":9999}`, http.StatusNotFound},
+		"invalid request": {`{`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPut, "/api/debug/body-logging", tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	if w := doWebhookRequest(t, router, http.MethodDelete, "/api/debug/body-logging", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if switcher.Enabled(scoped.ID) {
+		t.Error("body logging still on after DELETE")
+	}
+}
@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// ClientCertStore defines the storage operations needed to map client
+// certificates to tokens.
+type ClientCertStore interface {
+	SetTokenClientCert(ctx context.Context, tokenID int64, commonName string) error
+	GetTokenClientCert(ctx context.Context, tokenID int64) (string, error)
+	GetTokenByClientCert(ctx context.Context, commonName string) (*storage.Token, error)
+	DeleteTokenClientCert(ctx context.Context, tokenID int64) error
+}
+
+// SetClientCertStore enables the client certificate mapping endpoints and
+// lets admin tokens authenticate with a mapped client certificate instead of
+// the AccessKey header. Must be called before NewRouter.
+func (h *Handler) SetClientCertStore(store ClientCertStore) {
+	h.clientCerts = store
+}
+
+// ClientCertRequest is the request body for PUT /api/tokens/{id}/client-cert.
+type ClientCertRequest struct {
+	CommonName string `json:"common_name"`
+}
+
+// ClientCertResponse is the client certificate a token is mapped to.
+type ClientCertResponse struct {
+	TokenID    int64  `json:"token_id"`
+	CommonName string `json:"common_name"`
+}
+
+// HandleGetTokenClientCert returns the client certificate a token is mapped to.
+// GET /api/tokens/{id}/client-cert
+func (h *Handler) HandleGetTokenClientCert(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTokenID(w, r)
+	if !ok {
+		return
+	}
+
+	commonName, err := h.clientCerts.GetTokenClientCert(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token has no client certificate")
+			return
+		}
+		h.logger.Error("failed to get token client certificate", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get client certificate")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(ClientCertResponse{TokenID: id, CommonName: commonName})
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleSetTokenClientCert maps client certificates with a subject common
+// name to a token, replacing any previous mapping.
+// PUT /api/tokens/{id}/client-cert
+func (h *Handler) HandleSetTokenClientCert(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTokenID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ClientCertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	req.CommonName = strings.TrimSpace(req.CommonName)
+	if req.CommonName == "" {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "common_name is required",
+			"Use the subject common name (CN) of the client certificate.")
+		return
+	}
+
+	if err := h.clientCerts.SetTokenClientCert(r.Context(), id, req.CommonName); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+		case errors.Is(err, storage.ErrDuplicate):
+			WriteErrorWithHint(w, http.StatusConflict, "duplicate_entry", "Common name is mapped to another token",
+				"Remove the other token's client certificate first.")
+		default:
+			h.logger.Error("failed to set token client certificate", "error", err, "id", id)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to set client certificate")
+		}
+		return
+	}
+
+	h.logger.Info("token client certificate set", "id", id, "common_name", req.CommonName)
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(ClientCertResponse{TokenID: id, CommonName: req.CommonName})
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteTokenClientCert removes a token's client certificate mapping.
+// DELETE /api/tokens/{id}/client-cert
+func (h *Handler) HandleDeleteTokenClientCert(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTokenID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.clientCerts.DeleteTokenClientCert(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token has no client certificate")
+			return
+		}
+		h.logger.Error("failed to delete token client certificate", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete client certificate")
+		return
+	}
+
+	h.logger.Info("token client certificate removed", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseTokenID reads the {id} URL parameter, writing a 400 response if it is
+// not a number.
+func parseTokenID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid token ID", "Token ID must be a number.")
+		return 0, false
+	}
+	return id, true
+}
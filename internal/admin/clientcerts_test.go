@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// withChiParams returns req with chi URL parameters set.
+func withChiParams(req *http.Request, params map[string]string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandleSetTokenClientCert(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.SetTokenClientCertFunc = func(_ context.Context, tokenID int64, commonName string) error {
+		switch {
+		case tokenID == 9:
+			return storage.ErrNotFound
+		case commonName == "taken.internal":
+			return storage.ErrDuplicate
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+	}{
+		{"valid", "2", `{"common_name":" billing.internal "}`, http.StatusOK},
+		{"missing name", "2", `{}`, http.StatusBadRequest},
+		{"invalid JSON", "2", `{`, http.StatusBadRequest},
+		{"invalid ID", "abc", `{"common_name":"billing.internal"}`, http.StatusBadRequest},
+		{"unknown token", "9", `{"common_name":"billing.internal"}`, http.StatusNotFound},
+		{"name taken", "2", `{"common_name":"taken.internal"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+			h.SetClientCertStore(mock)
+
+			req := withChiParams(httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body)), map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+			h.HandleSetTokenClientCert(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK {
+				var resp ClientCertResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.TokenID != 2 || resp.CommonName != "billing.internal" {
+					t.Errorf("unexpected response: %+v", resp)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleTokenClientCert_NotMapped(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.DeleteTokenClientCertFunc = func(context.Context, int64) error { return storage.ErrNotFound }
+	h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+	h.SetClientCertStore(mock)
+
+	for name, handle := range map[string]http.HandlerFunc{
+		"get":    h.HandleGetTokenClientCert,
+		"delete": h.HandleDeleteTokenClientCert,
+	} {
+		w := httptest.NewRecorder()
+		handle(w, withChiParams(httptest.NewRequest(http.MethodGet, "/", nil), map[string]string{"id": "2"}))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestTokenAuthMiddleware_ClientCertificate(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.GetTokenByClientCertFunc = func(_ context.Context, commonName string) (*storage.Token, error) {
+		if commonName == "ops.internal" {
+			return &storage.Token{ID: 1, Name: "ops", IsAdmin: true}, nil
+		}
+		return nil, storage.ErrNotFound
+	}
+	h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+	h.SetClientCertStore(mock)
+	router := h.NewRouter()
+
+	for commonName, want := range map[string]int{"ops.internal": http.StatusOK, "unknown.internal": http.StatusUnauthorized} {
+		leaf := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}, VerifiedChains: [][]*x509.Certificate{{leaf}}}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", commonName, want, w.Code)
+		}
+	}
+}
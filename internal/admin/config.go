@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sipico/bunny-api-proxy/internal/config"
+)
+
+// SetConfig enables the effective configuration endpoint.
+// Must be called before NewRouter; the route is not registered otherwise.
+func (h *Handler) SetConfig(cfg *config.Config) {
+	h.config = cfg
+}
+
+// ConfigSettingResponse represents one configuration variable in API responses.
+type ConfigSettingResponse struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	Default    string `json:"default"`
+	Secret     bool   `json:"secret,omitempty"`
+	NonDefault bool   `json:"non_default"`
+}
+
+// HandleGetConfig returns the effective configuration, one entry per
+// environment variable, with secrets redacted.
+// GET /api/config
+// With ?non_default=true only settings that differ from their defaults are listed.
+func (h *Handler) HandleGetConfig(w http.ResponseWriter, r *http.Request) {
+	onlyChanged := r.URL.Query().Get("non_default") == "true"
+
+	response := make([]ConfigSettingResponse, 0)
+	for _, s := range h.config.Describe() {
+		if onlyChanged && !s.NonDefault {
+			continue
+		}
+		response = append(response, ConfigSettingResponse{
+			Name:       s.Name,
+			Value:      s.Value,
+			Default:    s.Default,
+			Secret:     s.Secret,
+			NonDefault: s.NonDefault,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
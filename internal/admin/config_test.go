@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/config"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestHandleGetConfig(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	cfg := config.Defaults()
+	cfg.LogLevel = "debug"
+	cfg.BunnyAPIKey = "secret-key"
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetConfig(cfg)
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodGet, "/api/config", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var all []ConfigSettingResponse
+	if err := json.NewDecoder(w.Body).Decode(&all); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(all) != len(cfg.Describe()) {
+		t.Errorf("expected every setting, got %d", len(all))
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, "/api/config?non_default=true", "")
+	var changed []ConfigSettingResponse
+	if err := json.NewDecoder(w.Body).Decode(&changed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(changed) != 2 || changed[0].Name != "LOG_LEVEL" || changed[0].Value != "debug" || changed[0].Default != "info" {
+		t.Fatalf("unexpected non-default settings: %+v", changed)
+	}
+	if key := changed[1]; key.Name != "BUNNY_API_KEY" || key.Value != "[redacted]" || !key.Secret {
+		t.Errorf("expected redacted API key, got %+v", key)
+	}
+}
+
+func TestHandleGetConfig_NotConfigured(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	router := NewHandler(store, nil, slog.New(slog.NewTextHandler(io.Discard, nil))).NewRouter()
+	if w := doWebhookRequest(t, router, http.MethodGet, "/api/config", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a config, got %d", w.Code)
+	}
+}
@@ -0,0 +1,13 @@
+package admin
+
+import (
+	internalMiddleware "github.com/sipico/bunny-api-proxy/internal/middleware"
+)
+
+// SetCORS lets browser clients on the configured origins, such as a
+// separately hosted admin panel, call the admin API directly. Preflight
+// requests are answered without authentication. Must be called before
+// NewRouter.
+func (h *Handler) SetCORS(cfg internalMiddleware.CORSConfig) {
+	h.cors = &cfg
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	internalMiddleware "github.com/sipico/bunny-api-proxy/internal/middleware"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func TestCORSPreflightSkipsAuth(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(&mockstore.MockStorage{}, new(slog.LevelVar), slog.Default())
+	h.SetCORS(internalMiddleware.CORSConfig{
+		AllowedOrigins: []string{"https://admin.example.com"},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"AccessKey", "Content-Type"},
+	})
+	router := h.NewRouter()
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/tokens", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "https://admin.example.com" {
+		t.Errorf("preflight: status %d, headers %v", w.Code, w.Header())
+	}
+
+	// Authentication still applies to the actual request
+	req = httptest.NewRequest(http.MethodGet, "/api/tokens", nil)
+	req.Header.Set("Origin", "https://admin.example.com")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Access-Control-Allow-Origin") != "https://admin.example.com" {
+		t.Errorf("unauthenticated request: status %d, headers %v; want a readable 401", w.Code, w.Header())
+	}
+}
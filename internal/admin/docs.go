@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/docs"
+)
+
+// SetDocs enables the operator documentation at GET /docs, the OpenAPI
+// document at GET /docs/openapi.json and Swagger UI at GET /docs/api. page
+// supplies the version and proxy routes; the admin routes are taken from the
+// router built by NewRouter. Must be called before NewRouter.
+func (h *Handler) SetDocs(page docs.Page) {
+	h.docs = &page
+}
+
+// docsContent is the documentation for an admin router, built on the first
+// request so the routes registered after the docs routes are included.
+type docsContent struct {
+	router chi.Router
+	base   docs.Page
+
+	once     sync.Once
+	page     docs.Page
+	rendered string
+	openAPI  []byte
+}
+
+func (d *docsContent) build() {
+	d.once.Do(func() {
+		d.page = d.base
+		d.page.AdminRoutes = adminRoutes(d.router)
+		d.rendered = d.page.HTML()
+		spec, err := d.page.OpenAPI()
+		if err != nil { // coverage-ignore: the document is plain data
+			spec = []byte("{}")
+		}
+		d.openAPI = spec
+	})
+}
+
+// HandleDocs serves the operator documentation.
+// GET /docs
+// With ?format=markdown the Markdown source is returned instead of HTML.
+func (h *Handler) HandleDocs(w http.ResponseWriter, r *http.Request) {
+	h.docsContent.build()
+
+	if r.URL.Query().Get("format") == "markdown" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		//nolint:errcheck // Response write errors are unrecoverable
+		w.Write([]byte(h.docsContent.page.Markdown()))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	//nolint:errcheck // Response write errors are unrecoverable
+	w.Write([]byte(h.docsContent.rendered))
+}
+
+// HandleOpenAPI serves the OpenAPI 3 document for the admin and proxy APIs.
+// GET /docs/openapi.json, and GET /openapi.json on the main router
+// It responds 404 when the documentation is not enabled.
+func (h *Handler) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if h.docsContent == nil {
+		http.NotFound(w, r)
+		return
+	}
+	h.docsContent.build()
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck // Response write errors are unrecoverable
+	w.Write(h.docsContent.openAPI)
+}
+
+// HandleSwaggerUI serves Swagger UI for the OpenAPI document.
+// GET /docs/api
+func (h *Handler) HandleSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", docs.SwaggerUICSP)
+	//nolint:errcheck // Response write errors are unrecoverable
+	w.Write([]byte(docs.SwaggerUI("Bunny API Proxy API", "openapi.json")))
+}
+
+// adminRoutes lists the routes registered on the admin router, sorted by path,
+// as mounted under /admin.
+func adminRoutes(router chi.Router) []docs.Route {
+	var routes []docs.Route
+	walkErr := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, docs.Route{Method: method, Path: "/admin" + route})
+		return nil
+	})
+	if walkErr != nil { // coverage-ignore: the walk function never fails
+		return nil
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/docs"
+)
+
+func TestHandleDocs(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(newMockUnifiedStorage(), new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetDocs(docs.Page{
+		Version:     "2026.01.2",
+		ProxyRoutes: []docs.Route{{Method: http.MethodGet, Path: "/dnszone", Access: "any", Description: "List zones"}},
+	})
+	router := h.NewRouter()
+
+	// Public: no AccessKey needed
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected HTML, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<code>/admin/api/tokens</code>", "<code>/admin/docs</code>", "<code>/dnszone</code>", "2026.01.2"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("docs missing %q", want)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs?format=markdown", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/markdown") || !strings.Contains(w.Body.String(), "| GET | `/admin/api/whoami` |") {
+		t.Errorf("unexpected markdown response: %s", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestHandleDocs_Disabled(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(newMockUnifiedStorage(), new(slog.LevelVar), slog.Default())
+	w := httptest.NewRecorder()
+	h.NewRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without SetDocs, got %d", w.Code)
+	}
+}
+
+func TestHandleOpenAPI(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(newMockUnifiedStorage(), new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetDocs(docs.Page{Version: "2026.01.2"})
+	router := h.NewRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected JSON, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `"/admin/api/tokens/{id}"`) {
+		t.Error("expected the admin routes in the OpenAPI document")
+	}
+
+	// Also served on the main router at /openapi.json
+	w = httptest.NewRecorder()
+	h.HandleOpenAPI(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("HandleOpenAPI: expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/api", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Security-Policy") != docs.SwaggerUICSP {
+		t.Errorf("Swagger UI: status %d, CSP %q", w.Code, w.Header().Get("Content-Security-Policy"))
+	}
+	if !strings.Contains(w.Body.String(), `"openapi.json"`) {
+		t.Error("expected Swagger UI to load openapi.json")
+	}
+}
+
+func TestHandleOpenAPI_Disabled(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(newMockUnifiedStorage(), new(slog.LevelVar), slog.Default())
+	h.NewRouter()
+	w := httptest.NewRecorder()
+	h.HandleOpenAPI(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without SetDocs, got %d", w.Code)
+	}
+}
@@ -0,0 +1,180 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/domainperms"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// DomainPermissionStore defines the storage operations needed to manage
+// domain permissions.
+type DomainPermissionStore interface {
+	AddDomainPermission(ctx context.Context, p *storage.DomainPermission) (*storage.DomainPermission, error)
+	ListDomainPermissions(ctx context.Context, tokenID int64) ([]*storage.DomainPermission, error)
+	DeleteDomainPermission(ctx context.Context, tokenID, id int64) error
+}
+
+// SetDomainPermissionStore enables the domain permission endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetDomainPermissionStore(store DomainPermissionStore) {
+	h.domainPerms = store
+}
+
+// AddDomainPermissionRequest is the request body for POST /api/tokens/{id}/domain-permissions.
+type AddDomainPermissionRequest struct {
+	Pattern        string   `json:"pattern"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types"`
+}
+
+// DomainPermissionResponse represents a domain permission in API responses.
+type DomainPermissionResponse struct {
+	ID             int64    `json:"id"`
+	Pattern        string   `json:"pattern"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+func toDomainPermissionResponse(p *storage.DomainPermission) DomainPermissionResponse {
+	return DomainPermissionResponse{
+		ID:             p.ID,
+		Pattern:        p.Pattern,
+		AllowedActions: p.AllowedActions,
+		RecordTypes:    p.RecordTypes,
+		CreatedAt:      p.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// HandleListDomainPermissions returns a token's domain permissions.
+// GET /api/tokens/{id}/domain-permissions
+func (h *Handler) HandleListDomainPermissions(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.tagPermissionToken(w, r)
+	if !ok {
+		return
+	}
+
+	perms, err := h.domainPerms.ListDomainPermissions(r.Context(), token.ID)
+	if err != nil {
+		h.logger.Error("failed to list domain permissions", "error", err, "token_id", token.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list domain permissions")
+		return
+	}
+
+	response := make([]DomainPermissionResponse, 0, len(perms))
+	for _, p := range perms {
+		response = append(response, toDomainPermissionResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleAddDomainPermission grants a token a DNS zone permission on every
+// zone matching a domain pattern, including zones created later.
+// POST /api/tokens/{id}/domain-permissions
+// Body: {"pattern": "*.customer1.example.com", "allowed_actions": [...], "record_types": [...]}
+func (h *Handler) HandleAddDomainPermission(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.tagPermissionToken(w, r)
+	if !ok {
+		return
+	}
+	if token.IsAdmin {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			"Admin tokens do not use zone permissions",
+			"Admin tokens have full access. Permissions are only for scoped tokens.")
+		return
+	}
+
+	var req AddDomainPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+
+	pattern, ok := domainperms.NormalizePattern(req.Pattern)
+	if !ok {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid domain pattern",
+			"Use a domain such as customer1.example.com, or *.customer1.example.com for every zone below it.")
+		return
+	}
+	if len(req.AllowedActions) == 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one action is required")
+		return
+	}
+	if len(req.RecordTypes) == 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one record type is required")
+		return
+	}
+	recordTypes, ok := normalizeRecordTypes(w, req.RecordTypes)
+	if !ok {
+		return
+	}
+
+	created, err := h.domainPerms.AddDomainPermission(r.Context(), &storage.DomainPermission{
+		TokenID:        token.ID,
+		Pattern:        pattern,
+		AllowedActions: req.AllowedActions,
+		RecordTypes:    recordTypes,
+	})
+	if err != nil {
+		h.logger.Error("failed to add domain permission", "error", err, "token_id", token.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to add domain permission")
+		return
+	}
+
+	h.logger.Info("domain permission added", "token_id", token.ID, "domain_permission_id", created.ID, "pattern", created.Pattern)
+	h.publish(r.Context(), webhook.EventPermissionAdded, map[string]any{
+		"token_id": token.ID, "token_name": token.Name, "domain_permission_id": created.ID,
+		"resource": "domain", "pattern": created.Pattern,
+		"allowed_actions": created.AllowedActions, "record_types": created.RecordTypes,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toDomainPermissionResponse(created))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteDomainPermission removes a domain permission.
+// DELETE /api/tokens/{id}/domain-permissions/{did}
+func (h *Handler) HandleDeleteDomainPermission(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.tagPermissionToken(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "did"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid domain permission ID", "Domain permission ID must be a number.")
+		return
+	}
+
+	if err := h.domainPerms.DeleteDomainPermission(r.Context(), token.ID, id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Domain permission not found")
+			return
+		}
+		h.logger.Error("failed to delete domain permission", "error", err, "token_id", token.ID, "domain_permission_id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete domain permission")
+		return
+	}
+
+	h.logger.Info("domain permission deleted", "token_id", token.ID, "domain_permission_id", id)
+	h.publish(r.Context(), webhook.EventPermissionRemoved, map[string]any{
+		"token_id": token.ID, "token_name": token.Name, "domain_permission_id": id,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestDomainPermissionEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	admin, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey))
+	if err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	scoped, err := store.CreateToken(ctx, "scoped", false, auth.HashToken("scoped-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetDomainPermissionStore(store)
+	router := h.NewRouter()
+
+	base := "/api/tokens/" + strconv.FormatInt(scoped.ID, 10) + "/domain-permissions"
+	w := doWebhookRequest(t, router, http.MethodPost, base, `{"pattern":"*.Customer1.example.com.","allowed_actions":["list_records"],"record_types":["txt"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created DomainPermissionResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.Pattern != "*.customer1.example.com" || len(created.RecordTypes) != 1 || created.RecordTypes[0] != "TXT" {
+		t.Errorf("unexpected domain permission: %+v", created)
+	}
+
+	for name, tc := range map[string]struct {
+		path string
+		body string
+		want int
+	}{
+		"invalid pattern":  {base, `{"pattern":"*.com","allowed_actions":["list_records"],"record_types":["TXT"]}`, http.StatusBadRequest},
+		"no actions":       {base, `{"pattern":"example.com","record_types":["TXT"]}`, http.StatusBadRequest},
+		"no record types":  {base, `{"pattern":"example.com","allowed_actions":["list_records"]}`, http.StatusBadRequest},
+		"bad record type":  {base, `{"pattern":"example.com","allowed_actions":["list_records"],"record_types":["BOGUS"]}`, http.StatusBadRequest},
+		"invalid JSON":     {base, `{`, http.StatusBadRequest},
+		"admin token":      {"/api/tokens/" + strconv.FormatInt(admin.ID, 10) + "/domain-permissions", `{"pattern":"example.com","allowed_actions":["list_records"],"record_types":["TXT"]}`, http.StatusBadRequest},
+		"token not found":  {"/api/tokens/999/domain-permissions", `{}`, http.StatusNotFound},
+		"invalid token ID": {"/api/tokens/abc/domain-permissions", `{}`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPost, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, base, "")
+	var list []DomainPermissionResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("unexpected domain permissions: %+v", list)
+	}
+
+	if w := doWebhookRequest(t, router, http.MethodDelete, base+"/abc", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, base+"/"+strconv.FormatInt(created.ID, 10), ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, base+"/"+strconv.FormatInt(created.ID, 10), ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for deleted domain permission, got %d", w.Code)
+	}
+}
@@ -28,6 +28,18 @@ const (
 	// ErrCodeNoAdminTokenExists indicates first token must be admin.
 	ErrCodeNoAdminTokenExists = "no_admin_token_exists"
 
+	// ErrCodeZoneNotPermitted indicates the token has no permission for a requested zone.
+	ErrCodeZoneNotPermitted = "zone_not_permitted"
+
+	// ErrCodeQuotaExceeded indicates a per-token resource limit was reached.
+	ErrCodeQuotaExceeded = "quota_exceeded"
+
+	// ErrCodeConflict indicates the resource changed in a way that prevents the request.
+	ErrCodeConflict = "conflict"
+
+	// ErrCodeUpstreamError indicates bunny.net failed a request made on the caller's behalf.
+	ErrCodeUpstreamError = "upstream_error"
+
 	// ErrCodeInternalError indicates a server error.
 	ErrCodeInternalError = "internal_error"
 )
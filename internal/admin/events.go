@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+)
+
+// EventPublisher persists security events for asynchronous delivery.
+// It is satisfied by *outbox.Outbox.
+type EventPublisher interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// SetEventPublisher enables security events for token and permission changes.
+func (h *Handler) SetEventPublisher(p EventPublisher) {
+	h.events = p
+}
+
+// publish emits a security event if a publisher is configured, adding the
+// actor that made the change. Failures are logged and never fail the
+// request: the change has already been stored.
+func (h *Handler) publish(ctx context.Context, event string, data map[string]any) {
+	if h.events == nil {
+		return
+	}
+	data["actor"] = actorName(ctx)
+	if err := h.events.Publish(ctx, event, data); err != nil {
+		h.logger.Error("failed to publish event", "event", event, "error", err)
+	}
+}
+
+// actorName returns the name of the token making a request, or "master_key".
+func actorName(ctx context.Context) string {
+	if token := auth.TokenFromContext(ctx); token != nil {
+		return token.Name
+	}
+	if auth.IsMasterKeyFromContext(ctx) {
+		return "master_key"
+	}
+	return ""
+}
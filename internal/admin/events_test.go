@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+type publishedEvent struct {
+	name string
+	data map[string]any
+}
+
+// fakePublisher records published events and optionally fails.
+type fakePublisher struct {
+	events []publishedEvent
+	err    error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, event string, data any) error {
+	p.events = append(p.events, publishedEvent{name: event, data: data.(map[string]any)})
+	return p.err
+}
+
+func TestHandler_PublishesSecurityEvents(t *testing.T) {
+	t.Parallel()
+
+	mock := newMockUnifiedStorage()
+	mock.CreateKeyTokenFunc = func(_ context.Context, name string, isAdmin bool, _ string) (*storage.Token, error) {
+		return &storage.Token{ID: 2, Name: name, IsAdmin: isAdmin}, nil
+	}
+	mock.GetTokenByIDFunc = func(_ context.Context, id int64) (*storage.Token, error) {
+		return &storage.Token{ID: id, Name: "ci"}, nil
+	}
+	mock.AddPermissionForTokenFunc = func(_ context.Context, _ int64, perm *storage.Permission) (*storage.Permission, error) {
+		perm.ID = 5
+		return perm, nil
+	}
+
+	tests := []struct {
+		name      string
+		handle    func(*Handler) http.HandlerFunc
+		method    string
+		body      string
+		params    map[string]string
+		wantEvent string
+	}{
+		{"create token", func(h *Handler) http.HandlerFunc { return h.HandleCreateUnifiedToken }, http.MethodPost,
+			`{"name":"ci","zones":[1],"actions":["add_record"],"record_types":["TXT"]}`, nil, webhook.EventTokenCreated},
+		{"delete token", func(h *Handler) http.HandlerFunc { return h.HandleDeleteUnifiedToken }, http.MethodDelete,
+			"", map[string]string{"id": "2"}, webhook.EventTokenDeleted},
+		{"add permission", func(h *Handler) http.HandlerFunc { return h.HandleAddTokenPermission }, http.MethodPost,
+			`{"zone_id":1,"allowed_actions":["add_record"],"record_types":["TXT"]}`, map[string]string{"id": "2"}, webhook.EventPermissionAdded},
+		{"remove permission", func(h *Handler) http.HandlerFunc { return h.HandleDeleteTokenPermission }, http.MethodDelete,
+			"", map[string]string{"id": "2", "pid": "5"}, webhook.EventPermissionRemoved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pub := &fakePublisher{}
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+			h.SetEventPublisher(pub)
+
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			rctx := chi.NewRouteContext()
+			for k, v := range tt.params {
+				rctx.URLParams.Add(k, v)
+			}
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			req = req.WithContext(auth.WithToken(ctx, &storage.Token{ID: 1, Name: "ops", IsAdmin: true}))
+
+			w := httptest.NewRecorder()
+			tt.handle(h)(w, req)
+
+			if w.Code >= 300 {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if len(pub.events) != 1 || pub.events[0].name != tt.wantEvent {
+				t.Fatalf("expected one %s event, got %+v", tt.wantEvent, pub.events)
+			}
+			data := pub.events[0].data
+			if data["actor"] != "ops" || data["token_id"] != int64(2) {
+				t.Errorf("unexpected event data: %v", data)
+			}
+			// Security events carry no zone, so they only reach admin webhooks without a zone filter
+			if _, ok := data["zone_id"]; ok {
+				t.Errorf("event data must not have zone_id: %v", data)
+			}
+		})
+	}
+}
+
+func TestHandler_PublishFailureDoesNotFailRequest(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.GetTokenByIDFunc = func(_ context.Context, id int64) (*storage.Token, error) {
+		return &storage.Token{ID: id, Name: "ci"}, nil
+	}
+	h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+	h.SetEventPublisher(&fakePublisher{err: errors.New("disk full")})
+
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "2")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	w := httptest.NewRecorder()
+	h.HandleDeleteUnifiedToken(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", w.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// SetFingerprints enforces TLS fingerprint pinning on the admin API, reading
+// the client's fingerprint from s. Must be called before NewRouter.
+func (h *Handler) SetFingerprints(s *auth.FingerprintSource) {
+	h.fingerprints = s
+}
+
+// HandleResetTokenFingerprint forgets the TLS fingerprint a token is pinned to,
+// so its next use pins a new one. Use it when a legitimate client changes.
+// DELETE /api/tokens/{id}/tls-fingerprint
+func (h *Handler) HandleResetTokenFingerprint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid token ID", "Token ID must be a number.")
+		return
+	}
+
+	ctx := r.Context()
+
+	token, err := h.storage.GetTokenByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+			return
+		}
+		h.logger.Error("failed to get token", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get token")
+		return
+	}
+	if !token.PinTLSFingerprint {
+		WriteError(w, http.StatusConflict, ErrCodeInvalidRequest, "Token does not pin a TLS fingerprint")
+		return
+	}
+
+	if err := h.storage.SetTokenPinning(ctx, id, true); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+			return
+		}
+		h.logger.Error("failed to reset TLS fingerprint", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to reset TLS fingerprint")
+		return
+	}
+
+	h.logger.Info("token TLS fingerprint reset", "id", id, "previous", token.TLSFingerprint)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestTokenFingerprintPinning(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetFingerprints(&auth.FingerprintSource{Header: "X-JA4", TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32")}})
+	router := h.NewRouter()
+
+	// Admin tokens cannot pin, so a changed admin client cannot lock admins out
+	w := doWebhookRequest(t, router, http.MethodPost, "/api/tokens", `{"name":"admin2","is_admin":true,"pin_tls_fingerprint":true}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("pinned admin token: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doWebhookRequest(t, router, http.MethodPost, "/api/tokens",
+		`{"name":"ci","zones":[1],"actions":["list_records"],"record_types":["TXT"],"pin_tls_fingerprint":true}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created CreateUnifiedTokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !created.PinTLSFingerprint {
+		t.Error("expected pin_tls_fingerprint in create response")
+	}
+
+	whoami := func(fingerprint string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+		req.Header.Set("AccessKey", created.Token)
+		req.Header.Set("X-JA4", fingerprint)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := whoami("fp-a"); code != http.StatusOK {
+		t.Errorf("first use: expected 200, got %d", code)
+	}
+	if code := whoami("fp-b"); code != http.StatusUnauthorized {
+		t.Errorf("different client: expected 401, got %d", code)
+	}
+
+	path := "/api/tokens/" + strconv.FormatInt(created.ID, 10)
+	w = doWebhookRequest(t, router, http.MethodGet, path, "")
+	var detail UnifiedTokenDetailResponse
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !detail.PinTLSFingerprint || detail.TLSFingerprint != "fp-a" {
+		t.Errorf("expected token pinned to fp-a, got %v %q", detail.PinTLSFingerprint, detail.TLSFingerprint)
+	}
+
+	// Resetting lets the next client pin itself
+	if w := doWebhookRequest(t, router, http.MethodDelete, path+"/tls-fingerprint", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("reset: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if code := whoami("fp-b"); code != http.StatusOK {
+		t.Errorf("after reset: expected 200, got %d", code)
+	}
+	if code := whoami("fp-a"); code != http.StatusUnauthorized {
+		t.Errorf("old client after reset: expected 401, got %d", code)
+	}
+}
+
+func TestHandleResetTokenFingerprint_Errors(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	router := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil))).NewRouter()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/tokens/abc/tls-fingerprint", http.StatusBadRequest},
+		{"/api/tokens/999/tls-fingerprint", http.StatusNotFound},
+		{"/api/tokens/1/tls-fingerprint", http.StatusConflict}, // the admin token is not pinned
+	}
+	for _, tt := range tests {
+		if w := doWebhookRequest(t, router, http.MethodDelete, tt.path, ""); w.Code != tt.want {
+			t.Errorf("DELETE %s: expected %d, got %d", tt.path, tt.want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/freeze"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// SetFreezeSchedule enables the zone freeze management endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetFreezeSchedule(s *freeze.Schedule) {
+	h.freezes = s
+}
+
+// CreateFreezeRequest is the request body for POST /api/freezes.
+// StartsAt defaults to now. EndsAt and DurationSeconds are alternatives;
+// without either the freeze lasts until it is lifted.
+type CreateFreezeRequest struct {
+	ZoneID          int64  `json:"zone_id"`
+	Reason          string `json:"reason,omitempty"`
+	StartsAt        string `json:"starts_at,omitempty"`
+	EndsAt          string `json:"ends_at,omitempty"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+}
+
+// FreezeResponse represents a zone freeze in API responses.
+type FreezeResponse struct {
+	ID        int64  `json:"id"`
+	ZoneID    int64  `json:"zone_id"`
+	Reason    string `json:"reason"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at,omitempty"`
+}
+
+func toFreezeResponse(f *storage.ZoneFreeze) FreezeResponse {
+	resp := FreezeResponse{
+		ID:        f.ID,
+		ZoneID:    f.ZoneID,
+		Reason:    f.Reason,
+		CreatedBy: f.CreatedBy,
+		CreatedAt: f.CreatedAt.Format(time.RFC3339),
+		StartsAt:  f.StartsAt.Format(time.RFC3339),
+	}
+	if !f.EndsAt.IsZero() {
+		resp.EndsAt = f.EndsAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// HandleListFreezes returns the current and upcoming zone freezes.
+// GET /api/freezes
+func (h *Handler) HandleListFreezes(w http.ResponseWriter, r *http.Request) {
+	freezes, err := h.freezes.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list zone freezes", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list zone freezes")
+		return
+	}
+
+	response := make([]FreezeResponse, 0, len(freezes))
+	for _, f := range freezes {
+		response = append(response, toFreezeResponse(f))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleCreateFreeze declares a freeze window for a zone.
+// POST /api/freezes
+func (h *Handler) HandleCreateFreeze(w http.ResponseWriter, r *http.Request) {
+	var req CreateFreezeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	if req.ZoneID <= 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "zone_id is required")
+		return
+	}
+	if req.DurationSeconds < 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "duration_seconds must not be negative")
+		return
+	}
+	if req.DurationSeconds > 0 && req.EndsAt != "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Use either ends_at or duration_seconds, not both")
+		return
+	}
+
+	var startsAt, endsAt time.Time
+	for _, field := range []struct {
+		name  string
+		value string
+		dest  *time.Time
+	}{
+		{"starts_at", req.StartsAt, &startsAt},
+		{"ends_at", req.EndsAt, &endsAt},
+	} {
+		if field.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, field.value)
+		if err != nil {
+			WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid "+field.name,
+				"Use an RFC 3339 time, such as 2026-01-02T15:04:05Z.")
+			return
+		}
+		*field.dest = t
+	}
+	if req.DurationSeconds > 0 {
+		start := startsAt
+		if start.IsZero() {
+			start = time.Now()
+		}
+		endsAt = start.Add(time.Duration(req.DurationSeconds) * time.Second)
+	}
+
+	f, err := h.freezes.Add(r.Context(), req.ZoneID, req.Reason, adminActor(r), startsAt, endsAt)
+	if err != nil {
+		if errors.Is(err, freeze.ErrInvalidWindow) {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.logger.Error("failed to add zone freeze", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to add zone freeze")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toFreezeResponse(f))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteFreeze lifts a zone freeze.
+// DELETE /api/freezes/{id}
+func (h *Handler) HandleDeleteFreeze(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid freeze ID", "Freeze ID must be a number.")
+		return
+	}
+
+	if err := h.freezes.Remove(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Zone freeze not found")
+			return
+		}
+		h.logger.Error("failed to delete zone freeze", "id", id, "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete zone freeze")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
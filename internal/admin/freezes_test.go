@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/freeze"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestFreezeEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	schedule := freeze.New(store, logger)
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetFreezeSchedule(schedule)
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodPost, "/api/freezes", `{"zone_id":123,"reason":"incident 42","duration_seconds":3600}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created FreezeResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.ZoneID != 123 || created.CreatedBy != "admin" || created.EndsAt == "" {
+		t.Errorf("unexpected freeze: %+v", created)
+	}
+	if schedule.Active(123) == nil {
+		t.Error("zone 123 is not frozen after creating the freeze")
+	}
+
+	later := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	for name, tc := range map[string]struct {
+		body string
+		want int
+	}{
+		"scheduled":       {`{"zone_id":124,"starts_at":"` + later + `","duration_seconds":600}`, http.StatusCreated},
+		"missing zone":    {`{"reason":"x"}`, http.StatusBadRequest},
+		"negative":        {`{"zone_id":1,"duration_seconds":-1}`, http.StatusBadRequest},
+		"both ends":       {`{"zone_id":1,"ends_at":"` + later + `","duration_seconds":60}`, http.StatusBadRequest},
+		"invalid time":    {`{"zone_id":1,"starts_at":"tomorrow"}`, http.StatusBadRequest},
+		"already ended":   {`{"zone_id":1,"ends_at":"2020-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		"invalid request": {`{`, http.StatusBadRequest},
+		"until lifted":    {`{"zone_id":125}`, http.StatusCreated},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPost, "/api/freezes", tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, "/api/freezes", "")
+	var list []FreezeResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 3 || list[0].ID != created.ID || list[0].Reason != "incident 42" {
+		t.Errorf("unexpected freezes: %+v", list)
+	}
+
+	path := "/api/freezes/" + strconv.FormatInt(created.ID, 10)
+	if w := doWebhookRequest(t, router, http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if schedule.Active(123) != nil {
+		t.Error("zone 123 is still frozen after lifting the freeze")
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting twice, got %d", w.Code)
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, "/api/freezes/abc", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid ID, got %d", w.Code)
+	}
+}
+
+func TestFreezeEndpoints_NotRegisteredWithoutSchedule(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	router := NewHandler(store, nil, nil).NewRouter()
+	if w := doWebhookRequest(t, router, http.MethodGet, "/api/freezes", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a freeze schedule, got %d", w.Code)
+	}
+}
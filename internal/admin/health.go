@@ -7,9 +7,20 @@ import (
 	"time"
 )
 
+// SetLiveness serves /health from the given handler instead of the built-in
+// one, so it reports the same document as the main /health.
+func (h *Handler) SetLiveness(live http.Handler) {
+	h.liveness = live
+}
+
 // HandleHealth returns basic health status
 // GET /health
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if h.liveness != nil {
+		h.liveness.ServeHTTP(w, r)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	err := json.NewEncoder(w).Encode(map[string]string{
@@ -21,10 +32,21 @@ func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// SetReadiness serves /ready from the given handler instead of the built-in
+// database check, so it reports the same dependencies as the main /ready.
+func (h *Handler) SetReadiness(ready http.Handler) {
+	h.readiness = ready
+}
+
 // HandleReady checks database connectivity
 // GET /ready
 // Returns 200 if database is accessible, 503 otherwise
 func (h *Handler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	if h.readiness != nil {
+		h.readiness.ServeHTTP(w, r)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 
 	if h.storage == nil {
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -30,6 +31,10 @@ func (m *mockStorage) CreateToken(ctx context.Context, name string, isAdmin bool
 	return &storage.Token{ID: 1, Name: name, IsAdmin: isAdmin, KeyHash: keyHash}, nil
 }
 
+func (m *mockStorage) CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*storage.Token, error) {
+	return &storage.Token{ID: 1, Name: name, IsAdmin: isAdmin, KeyHash: secretHash}, nil
+}
+
 func (m *mockStorage) GetTokenByID(ctx context.Context, id int64) (*storage.Token, error) {
 	return nil, storage.ErrNotFound
 }
@@ -46,6 +51,22 @@ func (m *mockStorage) CountAdminTokens(ctx context.Context) (int, error) {
 	return 1, nil
 }
 
+func (m *mockStorage) SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	return nil
+}
+
+func (m *mockStorage) SetTokenAccount(ctx context.Context, id int64, account string) error {
+	return nil
+}
+
+func (m *mockStorage) SetTokenPinning(ctx context.Context, id int64, pin bool) error {
+	return nil
+}
+
+func (m *mockStorage) PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error) {
+	return fingerprint, nil
+}
+
 func (m *mockStorage) AddPermissionForToken(ctx context.Context, tokenID int64, perm *storage.Permission) (*storage.Permission, error) {
 	perm.ID = 1
 	perm.TokenID = tokenID
@@ -68,6 +89,10 @@ func (m *mockStorage) GetTokenByHash(ctx context.Context, keyHash string) (*stor
 	return nil, storage.ErrNotFound
 }
 
+func (m *mockStorage) GetTokenByKey(ctx context.Context, id int64, secretHash string) (*storage.Token, error) {
+	return nil, storage.ErrNotFound
+}
+
 // failingPingStorage embeds mockStorage but returns an error from Ping
 type failingPingStorage struct {
 	mockStorage
@@ -177,6 +202,37 @@ func TestHandleReady(t *testing.T) {
 	}
 }
 
+func TestHandleReadyWithReadiness(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, new(slog.LevelVar), slog.Default())
+	h.SetReadiness(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w := httptest.NewRecorder()
+	h.HandleReady(w, httptest.NewRequest("GET", "/ready", nil))
+
+	// The readiness handler replaces the built-in check, even without storage
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected status %d, got %d", http.StatusTeapot, w.Code)
+	}
+}
+
+func TestHandleHealthWithLiveness(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(nil, new(slog.LevelVar), slog.Default())
+	h.SetLiveness(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w := httptest.NewRecorder()
+	h.HandleHealth(w, httptest.NewRequest("GET", "/health", nil))
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected status %d, got %d", http.StatusTeapot, w.Code)
+	}
+}
+
 func TestNewRouter(t *testing.T) {
 	t.Parallel()
 	h := NewHandler(&mockStorage{}, new(slog.LevelVar), slog.Default())
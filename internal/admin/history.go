@@ -0,0 +1,126 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// Limits for GET /api/zones/{zoneID}/history.
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// RecordHistoryStore defines the storage operations needed to read the
+// history of record changes.
+type RecordHistoryStore interface {
+	ListRecordChanges(ctx context.Context, zoneID int64, filter storage.RecordChangeFilter) ([]*storage.RecordChange, error)
+}
+
+// SetRecordHistoryStore enables the zone history endpoint.
+// Must be called before NewRouter; the route is not registered otherwise.
+func (h *Handler) SetRecordHistoryStore(store RecordHistoryStore) {
+	h.history = store
+}
+
+// RecordChangeResponse represents a record change in API responses. Before
+// is absent for a create and After for a delete. ID and CreatedAt are absent
+// for a revert whose history entry could not be stored.
+type RecordChangeResponse struct {
+	ID        int64           `json:"id"`
+	RecordID  int64           `json:"record_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	Actor     string          `json:"actor"`
+	CreatedAt string          `json:"created_at,omitempty"`
+}
+
+func toRecordChangeResponse(c *storage.RecordChange) RecordChangeResponse {
+	resp := RecordChangeResponse{
+		ID:       c.ID,
+		RecordID: c.RecordID,
+		Action:   c.Action,
+		Before:   c.Before,
+		After:    c.After,
+		Actor:    c.Actor,
+	}
+	if !c.CreatedAt.IsZero() {
+		resp.CreatedAt = c.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// ZoneHistoryResponse is the response body for GET /api/zones/{zoneID}/history.
+// NextBeforeID is set when more changes may follow; pass it as before_id to
+// fetch the next page.
+type ZoneHistoryResponse struct {
+	ZoneID       int64                  `json:"zone_id"`
+	Changes      []RecordChangeResponse `json:"changes"`
+	NextBeforeID int64                  `json:"next_before_id,omitempty"`
+}
+
+// HandleListZoneHistory returns the record changes made through the proxy
+// to a zone, newest first.
+// GET /api/zones/{zoneID}/history?record_id=&before_id=&limit=
+func (h *Handler) HandleListZoneHistory(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil || zoneID < 1 {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid zone ID", "Zone ID must be a number.")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := storage.RecordChangeFilter{Limit: defaultHistoryLimit}
+	for _, p := range []struct {
+		param string
+		dst   *int64
+	}{
+		{"record_id", &filter.RecordID},
+		{"before_id", &filter.BeforeID},
+	} {
+		if s := q.Get(p.param); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 1 {
+				WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid "+p.param+" parameter")
+				return
+			}
+			*p.dst = n
+		}
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxHistoryLimit {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid limit parameter")
+			return
+		}
+		filter.Limit = n
+	}
+
+	changes, err := h.history.ListRecordChanges(r.Context(), zoneID, filter)
+	if err != nil {
+		h.logger.Error("failed to list record changes", "zone_id", zoneID, "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list zone history")
+		return
+	}
+
+	resp := ZoneHistoryResponse{ZoneID: zoneID, Changes: make([]RecordChangeResponse, 0, len(changes))}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, toRecordChangeResponse(c))
+	}
+	if len(changes) == filter.Limit {
+		resp.NextBeforeID = changes[len(changes)-1].ID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(resp)
+	if encErr != nil {
+		_ = encErr
+	}
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestZoneHistoryEndpoint(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	for _, c := range []storage.RecordChange{
+		{ZoneID: 123, RecordID: 1, Action: storage.RecordChangeCreate, After: []byte(`{"Value":"a"}`), Actor: "ci"},
+		{ZoneID: 123, RecordID: 1, Action: storage.RecordChangeUpdate, Before: []byte(`{"Value":"a"}`), After: []byte(`{"Value":"b"}`), Actor: "ci"},
+		{ZoneID: 123, RecordID: 2, Action: storage.RecordChangeDelete, Before: []byte(`{"Value":"c"}`), Actor: "ops"},
+		{ZoneID: 456, RecordID: 3, Action: storage.RecordChangeCreate, After: []byte(`{}`)},
+	} {
+		if _, err := store.AddRecordChange(ctx, &c); err != nil {
+			t.Fatalf("failed to add record change: %v", err)
+		}
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRecordHistoryStore(store)
+	router := h.NewRouter()
+
+	list := func(path string) ZoneHistoryResponse {
+		t.Helper()
+		w := doWebhookRequest(t, router, http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+		var resp ZoneHistoryResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	resp := list("/api/zones/123/history")
+	if resp.ZoneID != 123 || len(resp.Changes) != 3 || resp.NextBeforeID != 0 {
+		t.Fatalf("unexpected history: %+v", resp)
+	}
+	deleted, updated := resp.Changes[0], resp.Changes[1]
+	if deleted.Action != storage.RecordChangeDelete || deleted.After != nil || deleted.Actor != "ops" {
+		t.Errorf("unexpected delete: %+v", deleted)
+	}
+	if string(updated.Before) != `{"Value":"a"}` || string(updated.After) != `{"Value":"b"}` {
+		t.Errorf("unexpected update: %+v", updated)
+	}
+
+	resp = list("/api/zones/123/history?record_id=1&limit=1")
+	if len(resp.Changes) != 1 || resp.Changes[0].Action != storage.RecordChangeUpdate || resp.NextBeforeID == 0 {
+		t.Fatalf("unexpected first page: %+v", resp)
+	}
+	resp = list("/api/zones/123/history?record_id=1&before_id=" + strconv.FormatInt(resp.NextBeforeID, 10))
+	if len(resp.Changes) != 1 || resp.Changes[0].Action != storage.RecordChangeCreate {
+		t.Errorf("unexpected second page: %+v", resp)
+	}
+
+	for _, path := range []string{
+		"/api/zones/abc/history",
+		"/api/zones/123/history?limit=0",
+		"/api/zones/123/history?record_id=x",
+	} {
+		if w := doWebhookRequest(t, router, http.MethodGet, path, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, w.Code)
+		}
+	}
+}
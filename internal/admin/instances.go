@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/instance"
+)
+
+// SetInstanceRegistry enables the instance listing endpoint.
+// Must be called before NewRouter; the route is not registered otherwise.
+func (h *Handler) SetInstanceRegistry(registry *instance.Registry) {
+	h.instances = registry
+}
+
+// InstanceResponse represents a proxy instance in API responses.
+type InstanceResponse struct {
+	ID         string `json:"id"`
+	Hostname   string `json:"hostname"`
+	Version    string `json:"version"`
+	StartedAt  string `json:"started_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	Active     bool   `json:"active"`
+	Current    bool   `json:"current"`
+}
+
+// HandleListInstances returns the proxy instances sharing this database.
+// GET /api/instances
+// Instances that missed several heartbeats are listed as inactive until pruned.
+func (h *Handler) HandleListInstances(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.instances.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list instances", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list instances")
+		return
+	}
+
+	response := make([]InstanceResponse, 0, len(statuses))
+	for _, s := range statuses {
+		response = append(response, InstanceResponse{
+			ID:         s.ID,
+			Hostname:   s.Hostname,
+			Version:    s.Version,
+			StartedAt:  s.StartedAt.Format(time.RFC3339),
+			LastSeenAt: s.LastSeenAt.Format(time.RFC3339),
+			Active:     s.Active,
+			Current:    s.Current,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/instance"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func TestHandleListInstances(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	if _, err := store.CreateToken(ctx, "scoped", false, auth.HashToken("scoped-token")); err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+	for _, inst := range []*storage.Instance{
+		{ID: "self", Hostname: "proxy-0", Version: "1.0.0"},
+		{ID: "gone", Hostname: "proxy-1", Version: "0.9.0", LastSeenAt: time.Now().Add(-time.Hour)},
+	} {
+		if err := store.UpsertInstance(ctx, inst); err != nil {
+			t.Fatalf("UpsertInstance failed: %v", err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetInstanceRegistry(instance.NewRegistry(store, storage.Instance{ID: "self"}, logger))
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodGet, "/api/instances", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got []InstanceResponse
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "self" || !got[0].Current || !got[0].Active || got[1].Active || got[1].Version != "0.9.0" {
+		t.Errorf("unexpected instances: %+v", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/instances", nil)
+	req.Header.Set("AccessKey", "scoped-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for scoped token, got %d", w.Code)
+	}
+}
+
+func TestHandleListInstances_StoreError(t *testing.T) {
+	t.Parallel()
+
+	store := &mockstore.MockStorage{ListInstancesFunc: func(context.Context) ([]*storage.Instance, error) {
+		return nil, errors.New("db down")
+	}}
+	h := NewHandler(store, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetInstanceRegistry(instance.NewRegistry(store, storage.Instance{ID: "self"}, nil))
+
+	w := httptest.NewRecorder()
+	h.HandleListInstances(w, httptest.NewRequest(http.MethodGet, "/api/instances", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
@@ -2,6 +2,7 @@ package admin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"log/slog"
@@ -9,6 +10,7 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/auth"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
@@ -44,8 +46,10 @@ func newTestServer(t *testing.T) *testServer {
 	h := NewHandler(store, logLevel, logger)
 
 	// Create bootstrap service and set it on handler
-	bootstrap := auth.NewBootstrapService(store, masterKey)
+	bootstrap := auth.NewBootstrapService(store, masterKey, auth.WithBootstrapRecord(store))
 	h.SetBootstrapService(bootstrap)
+	h.SetRoleStore(store)
+	h.SetInvitationStore(store)
 
 	// Create test server
 	router := h.NewRouter()
@@ -258,6 +262,73 @@ func TestIntegration_BootstrapFlow(t *testing.T) {
 	})
 }
 
+func TestIntegration_BootstrapHardening(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// During bootstrap the master key can only create the first admin token
+	t.Run("master key limited to token creation", func(t *testing.T) {
+		resp := ts.doRequest(t, "GET", "/api/tokens", nil, ts.masterKey)
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected 403, got %d", resp.StatusCode)
+		}
+		if errResp := parseErrorResponse(t, resp); errResp.Error != ErrCodeAdminRequired {
+			t.Errorf("expected error code %s, got %s", ErrCodeAdminRequired, errResp.Error)
+		}
+	})
+
+	resp := ts.doRequest(t, "POST", "/api/tokens", CreateUnifiedTokenRequest{Name: "admin", IsAdmin: true}, ts.masterKey)
+	var created CreateUnifiedTokenResponse
+	parseJSON(t, resp, &created)
+	_ = resp.Body.Close()
+
+	// Removing every admin token does not reopen bootstrap
+	t.Run("master key stays locked without admin tokens", func(t *testing.T) {
+		if err := ts.storage.DeleteToken(context.Background(), created.ID); err != nil {
+			t.Fatalf("failed to delete token: %v", err)
+		}
+
+		resp := ts.doRequest(t, "POST", "/api/tokens", CreateUnifiedTokenRequest{Name: "admin", IsAdmin: true}, ts.masterKey)
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected 403, got %d", resp.StatusCode)
+		}
+		if errResp := parseErrorResponse(t, resp); errResp.Error != ErrCodeMasterKeyLocked {
+			t.Errorf("expected error code %s, got %s", ErrCodeMasterKeyLocked, errResp.Error)
+		}
+	})
+}
+
+func TestIntegration_BootstrapWindowClosed(t *testing.T) {
+	t.Parallel()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetBootstrapService(auth.NewBootstrapService(store, "master-key", auth.WithBootstrapWindow(time.Nanosecond)))
+	router := h.NewRouter()
+	time.Sleep(time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tokens", bytes.NewBufferString(`{"name":"admin","is_admin":true}`))
+	req.Header.Set("AccessKey", "master-key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(ErrCodeMasterKeyLocked)) {
+		t.Errorf("expected error code %s, got %s", ErrCodeMasterKeyLocked, w.Body.String())
+	}
+}
+
 // =============================================================================
 // Integration Test: Token Management
 // =============================================================================
@@ -604,6 +675,188 @@ func TestIntegration_PermissionManagement(t *testing.T) {
 // Integration Test: Whoami Endpoint
 // =============================================================================
 
+func TestIntegration_Roles(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+	defer ts.close()
+
+	var admin CreateUnifiedTokenResponse
+	parseJSON(t, ts.doRequest(t, "POST", "/api/tokens", CreateUnifiedTokenRequest{Name: "admin", IsAdmin: true}, ts.masterKey), &admin)
+
+	resp := ts.doRequest(t, "POST", "/api/roles", CreateRoleRequest{
+		Name: "acme",
+		Permissions: []AddPermissionRequest{
+			{ZoneID: 100, AllowedActions: []string{"add_record", "delete_record"}, RecordTypes: []string{"txt"}},
+		},
+	}, admin.Token)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create role: expected 201, got %d", resp.StatusCode)
+	}
+	var role RoleResponse
+	parseJSON(t, resp, &role)
+	if len(role.Permissions) != 1 || role.Permissions[0].RecordTypes[0] != "TXT" {
+		t.Fatalf("unexpected role: %+v", role)
+	}
+	rolePath := "/api/roles/" + strconv.FormatInt(role.ID, 10)
+
+	// A token can hold a role without permissions of its own
+	resp = ts.doRequest(t, "POST", "/api/tokens", CreateUnifiedTokenRequest{Name: "ci", Roles: []int64{role.ID}}, admin.Token)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create token with role: expected 201, got %d", resp.StatusCode)
+	}
+	var scoped CreateUnifiedTokenResponse
+	parseJSON(t, resp, &scoped)
+	tokenPath := "/api/tokens/" + strconv.FormatInt(scoped.ID, 10)
+
+	whoamiZones := func() []int64 {
+		t.Helper()
+		var who WhoamiResponse
+		parseJSON(t, ts.doRequest(t, "GET", "/api/whoami", nil, scoped.Token), &who)
+		zones := make([]int64, 0, len(who.Permissions))
+		for _, p := range who.Permissions {
+			zones = append(zones, p.ZoneID)
+		}
+		return zones
+	}
+	if zones := whoamiZones(); len(zones) != 1 || zones[0] != 100 {
+		t.Fatalf("expected the role's zone, got %v", zones)
+	}
+
+	var detail UnifiedTokenDetailResponse
+	parseJSON(t, ts.doRequest(t, "GET", tokenPath, nil, admin.Token), &detail)
+	if len(detail.Permissions) != 0 || len(detail.Roles) != 1 || detail.Roles[0].Name != "acme" || detail.Roles[0].TokenCount != 1 {
+		t.Errorf("unexpected token details: %+v", detail)
+	}
+
+	// Changing the role changes the token's permissions
+	resp = ts.doRequest(t, "POST", rolePath+"/permissions", AddPermissionRequest{
+		ZoneID: 200, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+	}, admin.Token)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("add role permission: expected 201, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+	if zones := whoamiZones(); len(zones) != 2 || zones[1] != 200 {
+		t.Fatalf("expected the added zone, got %v", zones)
+	}
+
+	resp = ts.doRequest(t, "PUT", tokenPath+"/roles/"+strconv.FormatInt(role.ID, 10), nil, admin.Token)
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("assign held role: expected 409, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+
+	resp = ts.doRequest(t, "DELETE", tokenPath+"/roles/"+strconv.FormatInt(role.ID, 10), nil, admin.Token)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("unassign role: expected 204, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+	if zones := whoamiZones(); len(zones) != 0 {
+		t.Fatalf("expected no permissions after unassigning, got %v", zones)
+	}
+
+	resp = ts.doRequest(t, "POST", "/api/tokens", CreateUnifiedTokenRequest{Name: "other", Roles: []int64{999}}, admin.Token)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("create token with unknown role: expected 400, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+
+	resp = ts.doRequest(t, "GET", "/api/roles", nil, scoped.Token)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("list roles as scoped token: expected 403, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+
+	resp = ts.doRequest(t, "DELETE", rolePath, nil, admin.Token)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete role: expected 204, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+	resp = ts.doRequest(t, "GET", rolePath, nil, admin.Token)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get deleted role: expected 404, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestIntegration_Invitations(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+	defer ts.close()
+
+	var admin CreateUnifiedTokenResponse
+	parseJSON(t, ts.doRequest(t, "POST", "/api/tokens", CreateUnifiedTokenRequest{Name: "admin", IsAdmin: true}, ts.masterKey), &admin)
+
+	var role RoleResponse
+	parseJSON(t, ts.doRequest(t, "POST", "/api/roles", CreateRoleRequest{
+		Name: "acme",
+		Permissions: []AddPermissionRequest{
+			{ZoneID: 100, AllowedActions: []string{"add_record"}, RecordTypes: []string{"TXT"}},
+		},
+	}, admin.Token), &role)
+
+	resp := ts.doRequest(t, "POST", "/api/invitations", CreateInvitationRequest{
+		Name: "contractor", Roles: []int64{role.ID}, TokenTTLSeconds: 3600,
+	}, admin.Token)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create invitation: expected 201, got %d", resp.StatusCode)
+	}
+	var inv CreateInvitationResponse
+	parseJSON(t, resp, &inv)
+	if inv.Status != "pending" || inv.CreatedBy != "admin" || inv.InviteURL != "/admin/invitations/"+inv.Code {
+		t.Fatalf("unexpected invitation: %+v", inv)
+	}
+
+	// Claiming needs no credentials and returns the token's secret
+	resp = ts.doRequest(t, "POST", "/invitations/"+inv.Code, nil, "")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("claim invitation: expected 201, got %d", resp.StatusCode)
+	}
+	var claimed CreateUnifiedTokenResponse
+	parseJSON(t, resp, &claimed)
+	if claimed.Token == "" || claimed.Name != "contractor" || claimed.IsAdmin || claimed.ExpiresAt == "" {
+		t.Fatalf("unexpected claimed token: %+v", claimed)
+	}
+
+	var who WhoamiResponse
+	parseJSON(t, ts.doRequest(t, "GET", "/api/whoami", nil, claimed.Token), &who)
+	if who.TokenID != claimed.ID || len(who.Permissions) != 1 || who.Permissions[0].ZoneID != 100 {
+		t.Errorf("claimed token has unexpected identity: %+v", who)
+	}
+
+	resp = ts.doRequest(t, "POST", "/invitations/"+inv.Code, nil, "")
+	if resp.StatusCode != http.StatusGone {
+		t.Errorf("second claim: expected 410, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+
+	var list []InvitationResponse
+	parseJSON(t, ts.doRequest(t, "GET", "/api/invitations", nil, admin.Token), &list)
+	if len(list) != 1 || list[0].Status != "claimed" || list[0].TokenID != claimed.ID {
+		t.Errorf("unexpected invitation list: %+v", list)
+	}
+
+	resp = ts.doRequest(t, "POST", "/api/invitations", CreateInvitationRequest{Name: "x", Roles: []int64{role.ID}}, claimed.Token)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("create invitation as scoped token: expected 403, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+
+	// A revoked invitation cannot be claimed
+	var revoked CreateInvitationResponse
+	parseJSON(t, ts.doRequest(t, "POST", "/api/invitations", CreateInvitationRequest{Name: "other", Roles: []int64{role.ID}}, admin.Token), &revoked)
+	resp = ts.doRequest(t, "DELETE", "/api/invitations/"+strconv.FormatInt(revoked.ID, 10), nil, admin.Token)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete invitation: expected 204, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+	resp = ts.doRequest(t, "POST", "/invitations/"+revoked.Code, nil, "")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("claim revoked invitation: expected 404, got %d", resp.StatusCode)
+	}
+	_ = resp.Body.Close()
+}
+
 func TestIntegration_WhoamiEndpoint(t *testing.T) {
 	t.Parallel()
 	ts := newTestServer(t)
@@ -0,0 +1,347 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// invitationPrefix marks an invitation code, so it is not mistaken for a token.
+const invitationPrefix = "inv_"
+
+const (
+	// defaultInvitationTTL is how long an invitation can be claimed when the
+	// request does not say.
+	defaultInvitationTTL = 24 * time.Hour
+
+	// maxInvitationTTL is the longest an invitation can stay claimable.
+	maxInvitationTTL = 7 * 24 * time.Hour
+)
+
+// InvitationStore defines the storage operations needed for invitations.
+type InvitationStore interface {
+	CreateInvitation(ctx context.Context, inv *storage.Invitation, inviteHash string) (*storage.Invitation, error)
+	GetInvitationByHash(ctx context.Context, inviteHash string) (*storage.Invitation, error)
+	ListInvitations(ctx context.Context) ([]*storage.Invitation, error)
+	DeleteInvitation(ctx context.Context, id int64) error
+	ClaimInvitation(ctx context.Context, id, tokenID int64, now time.Time) error
+}
+
+// SetInvitationStore enables invitations, which mint scoped tokens from roles.
+// Has no effect without SetRoleStore. Must be called before NewRouter.
+func (h *Handler) SetInvitationStore(store InvitationStore) {
+	h.invitations = store
+}
+
+// CreateInvitationRequest is the request body for POST /api/invitations.
+type CreateInvitationRequest struct {
+	// Name is the name of the token the invitation mints.
+	Name  string  `json:"name"`
+	Roles []int64 `json:"roles"`
+	// TTLSeconds is how long the invitation can be claimed; zero gives a day.
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// TokenTTLSeconds makes the minted token expire that long after the
+	// claim; zero means it never expires.
+	TokenTTLSeconds int64 `json:"token_ttl_seconds,omitempty"`
+}
+
+// InvitationResponse represents an invitation in API responses. Its code is
+// only returned when it is created.
+type InvitationResponse struct {
+	ID              int64   `json:"id"`
+	Name            string  `json:"name"`
+	Roles           []int64 `json:"roles"`
+	TokenTTLSeconds int64   `json:"token_ttl_seconds,omitempty"`
+	Status          string  `json:"status"`
+	CreatedBy       string  `json:"created_by,omitempty"`
+	CreatedAt       string  `json:"created_at"`
+	ExpiresAt       string  `json:"expires_at"`
+	ClaimedAt       string  `json:"claimed_at,omitempty"`
+	TokenID         int64   `json:"token_id,omitempty"`
+}
+
+// CreateInvitationResponse is returned once when an invitation is created.
+// InviteURL is relative to the proxy's address. Neither field is in the
+// debug log allowlist, so both are masked there.
+type CreateInvitationResponse struct {
+	InvitationResponse
+	Code      string `json:"code"`
+	InviteURL string `json:"invite_url"`
+}
+
+// Invitation statuses.
+const (
+	invitationPending = "pending"
+	invitationClaimed = "claimed"
+	invitationExpired = "expired"
+)
+
+func invitationStatus(inv *storage.Invitation, now time.Time) string {
+	switch {
+	case !inv.ClaimedAt.IsZero():
+		return invitationClaimed
+	case !inv.ExpiresAt.After(now):
+		return invitationExpired
+	}
+	return invitationPending
+}
+
+func toInvitationResponse(inv *storage.Invitation, now time.Time) InvitationResponse {
+	return InvitationResponse{
+		ID:              inv.ID,
+		Name:            inv.TokenName,
+		Roles:           inv.RoleIDs,
+		TokenTTLSeconds: int64(inv.TokenTTL / time.Second),
+		Status:          invitationStatus(inv, now),
+		CreatedBy:       inv.CreatedBy,
+		CreatedAt:       inv.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt:       inv.ExpiresAt.UTC().Format(time.RFC3339),
+		ClaimedAt:       formatOptionalTime(inv.ClaimedAt),
+		TokenID:         inv.TokenID,
+	}
+}
+
+// HandleListInvitations lists invitations, newest first. Codes are not included.
+// GET /api/invitations
+func (h *Handler) HandleListInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.invitations.ListInvitations(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list invitations", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list invitations")
+		return
+	}
+
+	now := time.Now()
+	resp := make([]InvitationResponse, 0, len(invitations))
+	for _, inv := range invitations {
+		resp = append(resp, toInvitationResponse(inv, now))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(resp)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleCreateInvitation creates a one-time link that mints a scoped token
+// with the given roles. The admin never sees the token's secret.
+// POST /api/invitations
+func (h *Handler) HandleCreateInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Token name is required")
+		return
+	}
+	if len(req.Roles) == 0 {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one role is required",
+			"The token minted by an invitation gets its permissions from roles. Create one with POST /admin/api/roles.")
+		return
+	}
+	if req.TTLSeconds < 0 || req.TokenTTLSeconds < 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "ttl_seconds and token_ttl_seconds must not be negative")
+		return
+	}
+	ttl := defaultInvitationTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > maxInvitationTTL {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "ttl_seconds must be at most 604800 (7 days)")
+		return
+	}
+	if !h.checkRoles(ctx, w, req.Roles) {
+		return
+	}
+
+	secret, err := generateRandomKey(64)
+	if err != nil {
+		h.logger.Error("failed to generate invitation code", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to generate invitation")
+		return
+	}
+	code := invitationPrefix + secret
+
+	now := time.Now()
+	inv, err := h.invitations.CreateInvitation(ctx, &storage.Invitation{
+		TokenName: req.Name,
+		RoleIDs:   req.Roles,
+		TokenTTL:  time.Duration(req.TokenTTLSeconds) * time.Second,
+		CreatedBy: actorName(ctx),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, auth.HashToken(code))
+	if err != nil {
+		h.logger.Error("failed to create invitation", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to create invitation")
+		return
+	}
+
+	h.logger.Info("invitation created", "id", inv.ID, "name", inv.TokenName, "roles", inv.RoleIDs,
+		"expires_at", inv.ExpiresAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(CreateInvitationResponse{
+		InvitationResponse: toInvitationResponse(inv, now),
+		Code:               code,
+		InviteURL:          "/admin/invitations/" + code,
+	})
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteInvitation revokes an invitation. A token it already minted is kept.
+// DELETE /api/invitations/{id}
+func (h *Handler) HandleDeleteInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid invitation ID", "Invitation ID must be a number.")
+		return
+	}
+
+	if err := h.invitations.DeleteInvitation(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Invitation not found")
+			return
+		}
+		h.logger.Error("failed to delete invitation", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete invitation")
+		return
+	}
+
+	h.logger.Info("invitation deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleClaimInvitation mints the invitation's token and returns its secret
+// to the caller. It needs no credentials: the code in the path is the
+// credential, and it works once.
+// POST /invitations/{code}
+func (h *Handler) HandleClaimInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	now := time.Now()
+
+	code := chi.URLParam(r, "code")
+	if !strings.HasPrefix(code, invitationPrefix) {
+		WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Invitation not found")
+		return
+	}
+	inv, err := h.invitations.GetInvitationByHash(ctx, auth.HashToken(code))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Invitation not found")
+			return
+		}
+		h.logger.Error("failed to get invitation", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get invitation")
+		return
+	}
+	switch invitationStatus(inv, now) {
+	case invitationClaimed:
+		WriteError(w, http.StatusGone, ErrCodeNotFound, "Invitation has already been claimed")
+		return
+	case invitationExpired:
+		WriteErrorWithHint(w, http.StatusGone, ErrCodeNotFound, "Invitation has expired",
+			"Ask an administrator for a new invitation.")
+		return
+	}
+
+	secret, err := generateRandomKey(64)
+	if err != nil {
+		h.logger.Error("failed to generate secure token", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to generate token")
+		return
+	}
+	token, err := h.storage.CreateKeyToken(ctx, inv.TokenName, false, auth.HashToken(secret))
+	if err != nil {
+		h.logger.Error("failed to create token", "error", err, "invitation_id", inv.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to create token")
+		return
+	}
+
+	// abandon deletes the token when the claim cannot complete
+	abandon := func() {
+		if delErr := h.storage.DeleteToken(ctx, token.ID); delErr != nil {
+			h.logger.Error("failed to clean up token after invitation error", "error", delErr, "token_id", token.ID)
+		}
+	}
+
+	for _, roleID := range inv.RoleIDs {
+		if err := h.roles.AssignRole(ctx, token.ID, roleID); err != nil && !errors.Is(err, storage.ErrDuplicate) {
+			abandon()
+			if errors.Is(err, storage.ErrNotFound) {
+				WriteErrorWithHint(w, http.StatusConflict, ErrCodeNotFound, "A role of this invitation no longer exists",
+					"Ask an administrator for a new invitation.")
+				return
+			}
+			h.logger.Error("failed to assign role", "error", err, "token_id", token.ID, "role_id", roleID)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to assign roles")
+			return
+		}
+	}
+
+	var expiresAt time.Time
+	if inv.TokenTTL > 0 {
+		expiresAt = now.Add(inv.TokenTTL)
+		if err := h.storage.SetTokenExpiry(ctx, token.ID, expiresAt); err != nil {
+			abandon()
+			h.logger.Error("failed to set token expiry", "error", err, "token_id", token.ID)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to set token expiry")
+			return
+		}
+	}
+
+	// Claiming last means a failed claim leaves the invitation usable, and
+	// a concurrent claim of the same invitation loses here
+	if err := h.invitations.ClaimInvitation(ctx, inv.ID, token.ID, now); err != nil {
+		abandon()
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusGone, ErrCodeNotFound, "Invitation has already been claimed")
+			return
+		}
+		h.logger.Error("failed to claim invitation", "error", err, "id", inv.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to claim invitation")
+		return
+	}
+
+	h.logger.Info("invitation claimed", "id", inv.ID, "token_id", token.ID, "name", inv.TokenName,
+		"client_ip", h.clientIP(r), "expires_at", formatOptionalTime(expiresAt))
+	created := map[string]any{
+		"token_id": token.ID, "token_name": inv.TokenName, "is_admin": false,
+		"roles": inv.RoleIDs, "invitation_id": inv.ID, "invited_by": inv.CreatedBy,
+	}
+	if !expiresAt.IsZero() {
+		created["expires_at"] = formatOptionalTime(expiresAt)
+	}
+	h.publish(ctx, webhook.EventTokenCreated, created)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(CreateUnifiedTokenResponse{
+		ID:        token.ID,
+		Name:      inv.TokenName,
+		Token:     auth.FormatKey(token.ID, secret), // Return plaintext once
+		ExpiresAt: formatOptionalTime(expiresAt),
+	})
+	if encErr != nil {
+		_ = encErr
+	}
+}
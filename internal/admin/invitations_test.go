@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestHandleCreateInvitation(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.GetRoleFunc = func(_ context.Context, id int64) (*storage.Role, error) {
+		if id == 1 {
+			return &storage.Role{ID: 1, Name: "acme"}, nil
+		}
+		return nil, storage.ErrNotFound
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid", `{"name":"contractor","roles":[1]}`, http.StatusCreated},
+		{"missing name", `{"roles":[1]}`, http.StatusBadRequest},
+		{"no roles", `{"name":"contractor"}`, http.StatusBadRequest},
+		{"unknown role", `{"name":"contractor","roles":[2]}`, http.StatusBadRequest},
+		{"negative ttl", `{"name":"contractor","roles":[1],"ttl_seconds":-1}`, http.StatusBadRequest},
+		{"ttl too long", `{"name":"contractor","roles":[1],"ttl_seconds":604801}`, http.StatusBadRequest},
+		{"invalid JSON", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+			h.SetRoleStore(mock)
+			h.SetInvitationStore(mock)
+
+			w := httptest.NewRecorder()
+			h.HandleCreateInvitation(w, httptest.NewRequest(http.MethodPost, "/api/invitations", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleClaimInvitation_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		invitation *storage.Invitation
+		assignErr  error
+		claimErr   error
+		wantStatus int
+		wantDelete bool
+	}{
+		{name: "unknown", wantStatus: http.StatusNotFound},
+		{name: "claimed", invitation: &storage.Invitation{ID: 1, ExpiresAt: time.Now().Add(time.Hour), ClaimedAt: time.Now()}, wantStatus: http.StatusGone},
+		{name: "expired", invitation: &storage.Invitation{ID: 1, ExpiresAt: time.Now().Add(-time.Second)}, wantStatus: http.StatusGone},
+		{name: "role deleted", invitation: &storage.Invitation{ID: 1, RoleIDs: []int64{1}, ExpiresAt: time.Now().Add(time.Hour)},
+			assignErr: storage.ErrNotFound, wantStatus: http.StatusConflict, wantDelete: true},
+		{name: "lost race", invitation: &storage.Invitation{ID: 1, RoleIDs: []int64{1}, ExpiresAt: time.Now().Add(time.Hour)},
+			claimErr: storage.ErrNotFound, wantStatus: http.StatusGone, wantDelete: true},
+		{name: "claim fails", invitation: &storage.Invitation{ID: 1, RoleIDs: []int64{1}, ExpiresAt: time.Now().Add(time.Hour)},
+			claimErr: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantDelete: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mock := newMockUnifiedStorage()
+			mock.GetInvitationByHashFunc = func(context.Context, string) (*storage.Invitation, error) {
+				if tt.invitation == nil {
+					return nil, storage.ErrNotFound
+				}
+				return tt.invitation, nil
+			}
+			mock.CreateKeyTokenFunc = func(_ context.Context, name string, _ bool, _ string) (*storage.Token, error) {
+				return &storage.Token{ID: 7, Name: name}, nil
+			}
+			mock.AssignRoleFunc = func(context.Context, int64, int64) error { return tt.assignErr }
+			mock.ClaimInvitationFunc = func(context.Context, int64, int64, time.Time) error { return tt.claimErr }
+			deleted := false
+			mock.DeleteTokenFunc = func(_ context.Context, id int64) error {
+				deleted = id == 7
+				return nil
+			}
+
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+			h.SetRoleStore(mock)
+			h.SetInvitationStore(mock)
+
+			req := withChiParams(httptest.NewRequest(http.MethodPost, "/", nil), map[string]string{"code": invitationPrefix + "abc"})
+			w := httptest.NewRecorder()
+			h.HandleClaimInvitation(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if deleted != tt.wantDelete {
+				t.Errorf("token deleted = %v, want %v", deleted, tt.wantDelete)
+			}
+		})
+	}
+}
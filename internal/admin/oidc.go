@@ -0,0 +1,394 @@
+package admin
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oidcRequestTimeout bounds each request to the issuer.
+	oidcRequestTimeout = 10 * time.Second
+	// oidcKeysMaxAge is how long signing keys are used before they are
+	// fetched again.
+	oidcKeysMaxAge = time.Hour
+	// oidcKeysMinRefresh is the least time between fetches for a JWT signed
+	// with an unknown key, so such JWTs cannot flood the issuer.
+	oidcKeysMinRefresh = time.Minute
+	// oidcLeeway is the clock skew allowed when checking exp and nbf.
+	oidcLeeway = time.Minute
+)
+
+// OIDCOptions configures an OIDCProvider.
+type OIDCOptions struct {
+	// Issuer must match the iss claim exactly.
+	Issuer string
+	// Audience must be in the aud claim.
+	Audience string
+	// JWKSURL serves the issuer's signing keys; empty reads jwks_uri from
+	// the issuer's /.well-known/openid-configuration.
+	JWKSURL string
+	// RoleClaim is the claim listing the user's roles, as a string array or
+	// a space-separated string. A dotted path reads a nested claim, such as
+	// realm_access.roles.
+	RoleClaim string
+	// AdminRoles are the roles that make a user an admin.
+	AdminRoles []string
+	// UsernameClaim names the user; sub is used if the JWT lacks it.
+	UsernameClaim string
+
+	// HTTPClient sends the requests; nil uses a client with a timeout.
+	HTTPClient *http.Client
+}
+
+// OIDCProvider is an AuthProvider accepting JWTs issued by an OpenID Connect
+// provider, such as the ID or access tokens of a single sign-on login. JWTs
+// must be signed with RS*, PS*, ES* or EdDSA by a key the issuer publishes.
+// Keys are fetched on first use and again hourly, or when a JWT names a key
+// not seen yet.
+type OIDCProvider struct {
+	opts OIDCOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	jwksURL   string
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewOIDCProvider creates a provider for one issuer.
+func NewOIDCProvider(opts OIDCOptions) *OIDCProvider {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: oidcRequestTimeout}
+	}
+	return &OIDCProvider{opts: opts, now: time.Now, jwksURL: opts.JWKSURL}
+}
+
+// jwtHeader is the JOSE header of a JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Authenticate verifies a JWT and returns its user. JWTs from other issuers
+// and credentials that are not JWTs are ErrUnknownCredential.
+func (p *OIDCProvider) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	parts := strings.Split(credential, ".")
+	if len(parts) != 3 {
+		return nil, ErrUnknownCredential
+	}
+	var header jwtHeader
+	var claims map[string]any
+	if decodeSegment(parts[0], &header) != nil || decodeSegment(parts[1], &claims) != nil {
+		return nil, ErrUnknownCredential
+	}
+	if iss, _ := claims["iss"].(string); iss != p.opts.Issuer {
+		return nil, ErrUnknownCredential
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidCredential)
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+	name, _ := claims[p.opts.UsernameClaim].(string)
+	if name == "" {
+		name = subject
+	}
+	isAdmin := slices.ContainsFunc(claimStrings(claims, p.opts.RoleClaim), func(role string) bool {
+		return slices.Contains(p.opts.AdminRoles, role)
+	})
+	return &Principal{Provider: "oidc", Subject: subject, Name: name, IsAdmin: isAdmin}, nil
+}
+
+// checkClaims checks the subject, audience and validity period of a JWT.
+func (p *OIDCProvider) checkClaims(claims map[string]any) error {
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidCredential)
+	}
+	if !slices.Contains(claimStrings(claims, "aud"), p.opts.Audience) {
+		return fmt.Errorf("%w: audience not accepted", ErrInvalidCredential)
+	}
+	now := p.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidCredential)
+	}
+	if now.Add(-oidcLeeway).After(time.Unix(int64(exp), 0)) {
+		return fmt.Errorf("%w: expired", ErrInvalidCredential)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcLeeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidCredential)
+	}
+	return nil
+}
+
+// key returns the signing key with the given ID, fetching the keys if they
+// are stale or the ID is new. An empty ID matches the only key. While the
+// issuer is unreachable, known keys are used past their age.
+func (p *OIDCProvider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.lookup(kid)
+	age := p.now().Sub(p.fetchedAt)
+	switch {
+	case ok && age < oidcKeysMaxAge:
+		return key, nil
+	case !ok && p.keys != nil && age < oidcKeysMinRefresh:
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidCredential, kid)
+	}
+
+	if err := p.fetchKeys(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+	if key, ok = p.lookup(kid); !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidCredential, kid)
+	}
+	return key, nil
+}
+
+// lookup finds a fetched key. Must be called with mu held.
+func (p *OIDCProvider) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// jwk is a JSON Web Key; only the fields of signature keys are decoded.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys replaces the keys with the issuer's current signing keys,
+// discovering the JWKS URL first if needed. Must be called with mu held.
+func (p *OIDCProvider) fetchKeys(ctx context.Context) error {
+	if p.jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := p.get(ctx, strings.TrimSuffix(p.opts.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("oidc discovery failed: %w", err)
+		}
+		if discovery.Issuer != p.opts.Issuer {
+			return fmt.Errorf("oidc discovery failed: document is for issuer %q", discovery.Issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("oidc discovery failed: no jwks_uri")
+		}
+		p.jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.get(ctx, p.jwksURL, &set); err != nil {
+		return fmt.Errorf("oidc signing keys unavailable: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped, as JWTs they sign are rejected
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	p.keys, p.fetchedAt = keys, p.now()
+	return nil
+}
+
+// get fetches a JSON document from the issuer.
+func (p *OIDCProvider) get(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// publicKey decodes an RSA, EC or Ed25519 public key.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("invalid EC key")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// verifySignature checks a JWS signature over signed with key. The algorithm
+// must suit the key's type, so a JWT cannot choose a weaker check.
+func verifySignature(alg string, key crypto.PublicKey, signed string, sig []byte) error {
+	invalid := fmt.Errorf("%w: bad signature", ErrInvalidCredential)
+	if alg == "EdDSA" {
+		if pub, ok := key.(ed25519.PublicKey); ok && ed25519.Verify(pub, []byte(signed), sig) {
+			return nil
+		}
+		return invalid
+	}
+
+	var hash crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidCredential, alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		switch alg[:2] {
+		case "RS":
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+		case "PS":
+			err = rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		default:
+			return invalid
+		}
+		if err != nil {
+			return invalid
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		// Each curve has one hash: ES256 is P-256, ES384 P-384, ES512 P-521
+		bits := pub.Curve.Params().BitSize
+		size := (bits + 7) / 8
+		if alg[:2] != "ES" || len(sig) != 2*size || alg[2:] != strconv.Itoa(min(bits, 512)) {
+			return invalid
+		}
+		r, s := new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return invalid
+		}
+		return nil
+	default:
+		return invalid
+	}
+}
+
+// decodeSegment decodes a base64url JSON segment of a JWT.
+func decodeSegment(segment string, out any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// claimStrings returns a claim at a dotted path as strings: a string array,
+// or a string split on spaces, as scope claims are.
+func claimStrings(claims map[string]any, path string) []string {
+	var value any = claims
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[name]
+	}
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		var out []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,323 @@
+package admin
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testIssuer is an OpenID Connect issuer serving discovery and a JWKS with
+// one RSA and one EC P-256 signing key.
+type testIssuer struct {
+	server     *httptest.Server
+	rsaKey     *rsa.PrivateKey
+	ecKey      *ecdsa.PrivateKey
+	jwksServed atomic.Int32
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+	iss := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   iss.server.URL,
+			"jwks_uri": iss.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		iss.jwksServed.Add(1)
+		ecPub, _ := ecKey.PublicKey.Bytes()
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec-1", "crv": "P-256",
+				"x": b64(ecPub[1:33]), "y": b64(ecPub[33:]),
+			},
+			{"kty": "oct", "kid": "hmac-1", "k": b64([]byte("secret"))},
+		}})
+	})
+	iss.server = httptest.NewServer(mux)
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+// provider returns an OIDCProvider for the issuer with admin role "proxy-admin".
+func (iss *testIssuer) provider() *OIDCProvider {
+	return NewOIDCProvider(OIDCOptions{
+		Issuer:        iss.server.URL,
+		Audience:      "bunny-api-proxy",
+		RoleClaim:     "roles",
+		AdminRoles:    []string{"proxy-admin"},
+		UsernameClaim: "email",
+	})
+}
+
+// claims returns valid claims of an admin user, with overrides applied.
+func (iss *testIssuer) claims(overrides map[string]any) map[string]any {
+	claims := map[string]any{
+		"iss":   iss.server.URL,
+		"sub":   "user-123",
+		"aud":   "bunny-api-proxy",
+		"email": "alice@example.com",
+		"roles": []string{"viewer", "proxy-admin"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+// sign returns a JWT of claims signed with the given algorithm and key ID.
+func (iss *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	var err error
+	switch alg {
+	case "RS256":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, iss.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, iss.ecKey, digest[:])
+		if err == nil {
+			sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	default:
+		t.Fatalf("unsupported test algorithm %q", alg)
+	}
+	if err != nil {
+		t.Fatalf("failed to sign JWT: %v", err)
+	}
+	return signed + "." + b64(sig)
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestOIDCProvider_Authenticate(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	p := iss.provider()
+
+	tests := []struct {
+		name      string
+		alg, kid  string
+		overrides map[string]any
+		wantErr   error
+		wantAdmin bool
+	}{
+		{name: "RSA admin", alg: "RS256", kid: "rsa-1", wantAdmin: true},
+		{name: "EC admin", alg: "ES256", kid: "ec-1", wantAdmin: true},
+		{name: "audience array", alg: "RS256", kid: "rsa-1", wantAdmin: true,
+			overrides: map[string]any{"aud": []string{"other", "bunny-api-proxy"}}},
+		{name: "space-separated roles", alg: "RS256", kid: "rsa-1", wantAdmin: true,
+			overrides: map[string]any{"roles": "viewer proxy-admin"}},
+		{name: "not an admin", alg: "RS256", kid: "rsa-1",
+			overrides: map[string]any{"roles": []string{"viewer"}}},
+		{name: "no roles", alg: "RS256", kid: "rsa-1",
+			overrides: map[string]any{"roles": nil}},
+		{name: "other issuer", alg: "RS256", kid: "rsa-1", wantErr: ErrUnknownCredential,
+			overrides: map[string]any{"iss": "https://other.example.com"}},
+		{name: "expired", alg: "RS256", kid: "rsa-1", wantErr: ErrInvalidCredential,
+			overrides: map[string]any{"exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "expired within leeway", alg: "RS256", kid: "rsa-1", wantAdmin: true,
+			overrides: map[string]any{"exp": time.Now().Add(-30 * time.Second).Unix()}},
+		{name: "not valid yet", alg: "RS256", kid: "rsa-1", wantErr: ErrInvalidCredential,
+			overrides: map[string]any{"nbf": time.Now().Add(time.Hour).Unix()}},
+		{name: "missing exp", alg: "RS256", kid: "rsa-1", wantErr: ErrInvalidCredential,
+			overrides: map[string]any{"exp": nil}},
+		{name: "missing sub", alg: "RS256", kid: "rsa-1", wantErr: ErrInvalidCredential,
+			overrides: map[string]any{"sub": nil}},
+		{name: "wrong audience", alg: "RS256", kid: "rsa-1", wantErr: ErrInvalidCredential,
+			overrides: map[string]any{"aud": "other"}},
+		{name: "algorithm not matching key", alg: "ES256", kid: "rsa-1", wantErr: ErrInvalidCredential},
+		{name: "unsupported key type", alg: "RS256", kid: "hmac-1", wantErr: ErrInvalidCredential},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			jwt := iss.sign(t, tt.alg, tt.kid, iss.claims(tt.overrides))
+
+			principal, err := p.Authenticate(context.Background(), jwt)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.Provider != "oidc" || principal.Subject != "user-123" || principal.Name != "alice@example.com" {
+				t.Errorf("unexpected principal: %+v", principal)
+			}
+			if principal.IsAdmin != tt.wantAdmin {
+				t.Errorf("expected IsAdmin %v, got %v", tt.wantAdmin, principal.IsAdmin)
+			}
+		})
+	}
+}
+
+func TestOIDCProvider_BadSignature(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	other := newTestIssuer(t)
+
+	// Signed by another issuer's key under this issuer's key ID
+	jwt := other.sign(t, "RS256", "rsa-1", iss.claims(nil))
+	if _, err := iss.provider().Authenticate(context.Background(), jwt); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected ErrInvalidCredential, got %v", err)
+	}
+}
+
+func TestOIDCProvider_NotAJWT(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	p := iss.provider()
+
+	for _, credential := range []string{"opaque-token", "a.b.c", "e30.e30"} {
+		if _, err := p.Authenticate(context.Background(), credential); !errors.Is(err, ErrUnknownCredential) {
+			t.Errorf("%q: expected ErrUnknownCredential, got %v", credential, err)
+		}
+	}
+	if n := iss.jwksServed.Load(); n != 0 {
+		t.Errorf("expected no key fetches, got %d", n)
+	}
+}
+
+func TestOIDCProvider_NestedRoleClaim(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	p := NewOIDCProvider(OIDCOptions{
+		Issuer:        iss.server.URL,
+		Audience:      "bunny-api-proxy",
+		RoleClaim:     "realm_access.roles",
+		AdminRoles:    []string{"proxy-admin"},
+		UsernameClaim: "preferred_username",
+	})
+
+	jwt := iss.sign(t, "RS256", "rsa-1", iss.claims(map[string]any{
+		"roles":        nil,
+		"realm_access": map[string]any{"roles": []string{"proxy-admin"}},
+	}))
+	principal, err := p.Authenticate(context.Background(), jwt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !principal.IsAdmin {
+		t.Error("expected the nested role to make the user an admin")
+	}
+	if principal.Name != "user-123" {
+		t.Errorf("expected the name to fall back to sub, got %q", principal.Name)
+	}
+}
+
+func TestOIDCProvider_JWKSURLWithoutDiscovery(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	p := NewOIDCProvider(OIDCOptions{
+		Issuer:     "https://login.example.com",
+		Audience:   "bunny-api-proxy",
+		JWKSURL:    iss.server.URL + "/keys",
+		RoleClaim:  "roles",
+		AdminRoles: []string{"proxy-admin"},
+	})
+
+	jwt := iss.sign(t, "RS256", "rsa-1", iss.claims(map[string]any{"iss": "https://login.example.com"}))
+	if _, err := p.Authenticate(context.Background(), jwt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOIDCProvider_KeyCaching(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	p := iss.provider()
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	// Valid past the clock moves below
+	jwt := iss.sign(t, "RS256", "rsa-1", iss.claims(map[string]any{"exp": now.Add(24 * time.Hour).Unix()}))
+	for range 3 {
+		if _, err := p.Authenticate(context.Background(), jwt); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := iss.jwksServed.Load(); n != 1 {
+		t.Errorf("expected keys to be fetched once, got %d", n)
+	}
+
+	// Unknown key IDs refetch at most once a minute
+	unknown := iss.sign(t, "RS256", "rotated", iss.claims(nil))
+	for range 3 {
+		if _, err := p.Authenticate(context.Background(), unknown); !errors.Is(err, ErrInvalidCredential) {
+			t.Fatalf("expected ErrInvalidCredential, got %v", err)
+		}
+	}
+	if n := iss.jwksServed.Load(); n != 1 {
+		t.Errorf("expected no refetch within a minute, got %d fetches", n)
+	}
+	now = now.Add(2 * time.Minute)
+	//nolint:errcheck // Only the fetch is checked
+	p.Authenticate(context.Background(), unknown)
+	if n := iss.jwksServed.Load(); n != 2 {
+		t.Errorf("expected a refetch after a minute, got %d fetches", n)
+	}
+
+	// Known keys are used past their age while the issuer is down
+	iss.server.Close()
+	now = now.Add(2 * time.Hour)
+	if _, err := p.Authenticate(context.Background(), jwt); err != nil {
+		t.Errorf("expected stale keys to be used while the issuer is down, got %v", err)
+	}
+}
+
+func TestOIDCProvider_IssuerUnavailable(t *testing.T) {
+	t.Parallel()
+	iss := newTestIssuer(t)
+	p := iss.provider()
+	jwt := iss.sign(t, "RS256", "rsa-1", iss.claims(nil))
+	iss.server.Close()
+
+	_, err := p.Authenticate(context.Background(), jwt)
+	if err == nil || errors.Is(err, ErrInvalidCredential) || errors.Is(err, ErrUnknownCredential) {
+		t.Errorf("expected an availability error, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// ProtectedRecordStore defines the storage operations needed to manage
+// protected record names.
+type ProtectedRecordStore interface {
+	AddProtectedRecord(ctx context.Context, p *storage.ProtectedRecord) (*storage.ProtectedRecord, error)
+	ListProtectedRecords(ctx context.Context, zoneID int64) ([]*storage.ProtectedRecord, error)
+	DeleteProtectedRecord(ctx context.Context, id int64) error
+}
+
+// SetProtectedRecordStore enables the protected record endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetProtectedRecordStore(store ProtectedRecordStore) {
+	h.protected = store
+}
+
+// AddProtectedRecordRequest is the request body for
+// POST /api/zones/{zoneID}/protected-records. Name is relative to the zone,
+// with "@" for the apex; an empty Type protects the name for every type.
+type AddProtectedRecordRequest struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ProtectedRecordResponse represents a protected record in API responses.
+type ProtectedRecordResponse struct {
+	ID        int64  `json:"id"`
+	ZoneID    int64  `json:"zone_id"`
+	Name      string `json:"name"`
+	Type      string `json:"type,omitempty"`
+	Reason    string `json:"reason"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt string `json:"created_at"`
+}
+
+func toProtectedRecordResponse(p *storage.ProtectedRecord) ProtectedRecordResponse {
+	name := p.Name
+	if name == "" {
+		name = "@"
+	}
+	return ProtectedRecordResponse{
+		ID:        p.ID,
+		ZoneID:    p.ZoneID,
+		Name:      name,
+		Type:      p.RecordType,
+		Reason:    p.Reason,
+		CreatedBy: p.CreatedBy,
+		CreatedAt: p.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// HandleListProtectedRecords returns a zone's protected record names.
+// GET /api/zones/{zoneID}/protected-records
+func (h *Handler) HandleListProtectedRecords(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := protectedRecordZone(w, r)
+	if !ok {
+		return
+	}
+
+	protected, err := h.protected.ListProtectedRecords(r.Context(), zoneID)
+	if err != nil {
+		h.logger.Error("failed to list protected records", "error", err, "zone_id", zoneID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list protected records")
+		return
+	}
+
+	response := make([]ProtectedRecordResponse, 0, len(protected))
+	for _, p := range protected {
+		response = append(response, toProtectedRecordResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleAddProtectedRecord stops scoped tokens from updating or deleting
+// records with a name, and optionally a type, in a zone.
+// POST /api/zones/{zoneID}/protected-records
+// Body: {"name": "@", "type": "MX", "reason": "mail delivery"}
+func (h *Handler) HandleAddProtectedRecord(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := protectedRecordZone(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddProtectedRecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+
+	name, ok := normalizeProtectedName(req.Name)
+	if !ok {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid record name",
+			`Use a name relative to the zone, such as www, or "@" for the apex.`)
+		return
+	}
+	var recordType string
+	if req.Type != "" {
+		if recordType, ok = auth.NormalizeRecordType(req.Type); !ok {
+			WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid record type",
+				"Valid types: "+strings.Join(auth.RecordTypeNames(), ", ")+". Leave it out to protect every type.")
+			return
+		}
+	}
+
+	created, err := h.protected.AddProtectedRecord(r.Context(), &storage.ProtectedRecord{
+		ZoneID:     zoneID,
+		Name:       name,
+		RecordType: recordType,
+		Reason:     req.Reason,
+		CreatedBy:  adminActor(r),
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrDuplicate) {
+			WriteError(w, http.StatusConflict, ErrCodeConflict, "Record is already protected")
+			return
+		}
+		h.logger.Error("failed to add protected record", "error", err, "zone_id", zoneID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to add protected record")
+		return
+	}
+
+	h.logger.Info("protected record added", "zone_id", zoneID, "protected_record_id", created.ID,
+		"name", req.Name, "type", recordType, "created_by", created.CreatedBy)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toProtectedRecordResponse(created))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteProtectedRecord lifts the protection of a record name.
+// DELETE /api/zones/{zoneID}/protected-records/{id}
+func (h *Handler) HandleDeleteProtectedRecord(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := protectedRecordZone(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid protected record ID", "ID must be a number.")
+		return
+	}
+
+	// Only the zone's own protections can be lifted through its path
+	protected, err := h.protected.ListProtectedRecords(r.Context(), zoneID)
+	if err != nil {
+		h.logger.Error("failed to list protected records", "error", err, "zone_id", zoneID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete protected record")
+		return
+	}
+	found := false
+	for _, p := range protected {
+		found = found || p.ID == id
+	}
+	if found {
+		err = h.protected.DeleteProtectedRecord(r.Context(), id)
+	}
+	if !found || errors.Is(err, storage.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Protected record not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to delete protected record", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete protected record")
+		return
+	}
+
+	h.logger.Info("protected record removed", "zone_id", zoneID, "protected_record_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// protectedRecordZone parses the {zoneID} path parameter, writing a 400
+// response and returning false if it is invalid.
+func protectedRecordZone(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil || zoneID < 1 {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid zone ID", "Zone ID must be a number.")
+		return 0, false
+	}
+	return zoneID, true
+}
+
+// normalizeProtectedName lowercases a record name relative to the zone, as
+// bunny.net names records, mapping "@" to the apex's empty name.
+func normalizeProtectedName(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if name == "@" {
+		return "", true
+	}
+	if name == "" || strings.ContainsAny(name, " \t@") || strings.Contains(name, "..") {
+		return "", false
+	}
+	return name, true
+}
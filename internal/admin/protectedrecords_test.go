@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestProtectedRecordEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetProtectedRecordStore(store)
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodPost, "/api/zones/123/protected-records", `{"name":"@","type":"mx","reason":"mail"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created ProtectedRecordResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.ZoneID != 123 || created.Name != "@" || created.Type != "MX" || created.CreatedBy != "admin" {
+		t.Errorf("unexpected protected record: %+v", created)
+	}
+
+	for name, tc := range map[string]struct {
+		path, body string
+		want       int
+	}{
+		"any type":        {"/api/zones/123/protected-records", `{"name":"WWW."}`, http.StatusCreated},
+		"duplicate":       {"/api/zones/123/protected-records", `{"name":"@","type":"MX"}`, http.StatusConflict},
+		"invalid zone":    {"/api/zones/abc/protected-records", `{"name":"www"}`, http.StatusBadRequest},
+		"empty name":      {"/api/zones/123/protected-records", `{"name":""}`, http.StatusBadRequest},
+		"invalid type":    {"/api/zones/123/protected-records", `{"name":"www","type":"BOGUS"}`, http.StatusBadRequest},
+		"invalid request": {"/api/zones/123/protected-records", `{`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPost, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, "/api/zones/123/protected-records", "")
+	var list []ProtectedRecordResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 2 || list[1].Name != "www" || list[1].Type != "" {
+		t.Errorf("unexpected protected records: %+v", list)
+	}
+
+	path := "/api/zones/123/protected-records/" + strconv.FormatInt(created.ID, 10)
+	if w := doWebhookRequest(t, router, http.MethodDelete, "/api/zones/456/protected-records/"+strconv.FormatInt(created.ID, 10), ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting through another zone, got %d", w.Code)
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, path, ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, path, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting twice, got %d", w.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/proxy"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// ChangeReverter undoes recorded record changes. It is satisfied by
+// *proxy.Handler, which returns proxy.ErrRevertConflict and
+// proxy.ErrRevertUpstream.
+type ChangeReverter interface {
+	RevertChange(ctx context.Context, changeID int64, actor string) (*storage.RecordChange, error)
+}
+
+// SetChangeReverter enables the revert endpoint.
+// Must be called before NewRouter; the route is not registered otherwise.
+func (h *Handler) SetChangeReverter(r ChangeReverter) {
+	h.reverter = r
+}
+
+// HandleRevertChange undoes a record change by applying its inverse to
+// bunny.net, and returns the change the revert made.
+// POST /api/changes/{changeID}/revert
+func (h *Handler) HandleRevertChange(w http.ResponseWriter, r *http.Request) {
+	changeID, err := strconv.ParseInt(chi.URLParam(r, "changeID"), 10, 64)
+	if err != nil || changeID < 1 {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid change ID", "Change ID must be a number.")
+		return
+	}
+
+	change, err := h.reverter.RevertChange(r.Context(), changeID, adminActor(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Change not found")
+		case errors.Is(err, proxy.ErrRevertConflict):
+			WriteErrorWithHint(w, http.StatusConflict, ErrCodeConflict, err.Error(),
+				"Check the zone history for later changes to the record.")
+		case errors.Is(err, proxy.ErrRevertUpstream):
+			WriteError(w, http.StatusBadGateway, ErrCodeUpstreamError, err.Error())
+		default:
+			h.logger.Error("failed to revert change", "change_id", changeID, "error", err)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to revert change")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(toRecordChangeResponse(change))
+	if encErr != nil {
+		_ = encErr
+	}
+}
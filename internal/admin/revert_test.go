@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/proxy"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeReverter reverts change 1 and fails the others.
+type fakeReverter struct {
+	actor string
+}
+
+func (f *fakeReverter) RevertChange(_ context.Context, changeID int64, actor string) (*storage.RecordChange, error) {
+	f.actor = actor
+	switch changeID {
+	case 1:
+		return &storage.RecordChange{ID: 9, ZoneID: 123, RecordID: 7, Action: storage.RecordChangeCreate, After: []byte(`{"Id":7}`), Actor: actor}, nil
+	case 2:
+		return nil, fmt.Errorf("%w: record 7 was changed or deleted since", proxy.ErrRevertConflict)
+	case 3:
+		return nil, fmt.Errorf("%w: resource not found", proxy.ErrRevertUpstream)
+	}
+	return nil, storage.ErrNotFound
+}
+
+func TestRevertChangeEndpoint(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	reverter := &fakeReverter{}
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetChangeReverter(reverter)
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodPost, "/api/changes/1/revert", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var change RecordChangeResponse
+	if err := json.NewDecoder(w.Body).Decode(&change); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if change.ID != 9 || change.Action != storage.RecordChangeCreate || change.Actor != "admin" || reverter.actor != "admin" {
+		t.Errorf("unexpected revert: %+v", change)
+	}
+
+	for path, want := range map[string]int{
+		"/api/changes/2/revert":   http.StatusConflict,
+		"/api/changes/3/revert":   http.StatusBadGateway,
+		"/api/changes/4/revert":   http.StatusNotFound,
+		"/api/changes/abc/revert": http.StatusBadRequest,
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPost, path, ""); w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
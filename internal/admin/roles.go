@@ -0,0 +1,355 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// RoleStore defines the storage operations needed to manage roles.
+type RoleStore interface {
+	CreateRole(ctx context.Context, role *storage.Role) (*storage.Role, error)
+	GetRole(ctx context.Context, id int64) (*storage.Role, error)
+	ListRoles(ctx context.Context) ([]*storage.Role, error)
+	ListTokenRoles(ctx context.Context, tokenID int64) ([]*storage.Role, error)
+	DeleteRole(ctx context.Context, id int64) error
+	AddRolePermission(ctx context.Context, roleID int64, perm *storage.Permission) (*storage.Permission, error)
+	RemoveRolePermission(ctx context.Context, roleID, permID int64) error
+	AssignRole(ctx context.Context, tokenID, roleID int64) error
+	UnassignRole(ctx context.Context, tokenID, roleID int64) error
+}
+
+// SetRoleStore enables the role endpoints, the roles field of POST
+// /api/tokens and the roles shown with token details.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetRoleStore(store RoleStore) {
+	h.roles = store
+}
+
+// CreateRoleRequest is the request body for POST /api/roles.
+type CreateRoleRequest struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Permissions []AddPermissionRequest `json:"permissions"`
+}
+
+// RoleResponse represents a role in API responses.
+type RoleResponse struct {
+	ID          int64                `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Permissions []PermissionResponse `json:"permissions"`
+	TokenCount  int                  `json:"token_count"`
+	CreatedAt   string               `json:"created_at"`
+}
+
+func toRoleResponse(role *storage.Role) RoleResponse {
+	perms := make([]PermissionResponse, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		perms = append(perms, toPermissionResponse(p))
+	}
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: perms,
+		TokenCount:  role.TokenCount,
+		CreatedAt:   role.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// HandleListRoles returns all roles with their permissions.
+// GET /api/roles
+func (h *Handler) HandleListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roles.ListRoles(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list roles", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list roles")
+		return
+	}
+
+	response := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, toRoleResponse(role))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleCreateRole creates a role with a set of permissions.
+// POST /api/roles
+// Body: {"name": "...", "description": "...", "permissions": [{"zone_id": 123, "allowed_actions": [...], "record_types": [...]}]}
+func (h *Handler) HandleCreateRole(w http.ResponseWriter, r *http.Request) {
+	var req CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Role name is required")
+		return
+	}
+
+	role := &storage.Role{Name: req.Name, Description: req.Description}
+	for _, p := range req.Permissions {
+		perm, ok := permissionFromRequest(w, p)
+		if !ok {
+			return
+		}
+		role.Permissions = append(role.Permissions, perm)
+	}
+
+	created, err := h.roles.CreateRole(r.Context(), role)
+	if err != nil {
+		if errors.Is(err, storage.ErrDuplicate) {
+			WriteErrorWithHint(w, http.StatusConflict, "duplicate_entry", "A role with this name already exists",
+				"Choose another name, or add permissions to the existing role.")
+			return
+		}
+		h.logger.Error("failed to create role", "error", err, "name", req.Name)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to create role")
+		return
+	}
+
+	h.logger.Info("role created", "id", created.ID, "name", created.Name, "permissions", len(created.Permissions))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toRoleResponse(created))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleGetRole returns a role with its permissions.
+// GET /api/roles/{id}
+func (h *Handler) HandleGetRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseRoleID(w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	role, err := h.roles.GetRole(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Role not found")
+			return
+		}
+		h.logger.Error("failed to get role", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get role")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(toRoleResponse(role))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteRole deletes a role. Tokens holding it lose its permissions.
+// DELETE /api/roles/{id}
+func (h *Handler) HandleDeleteRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseRoleID(w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	if err := h.roles.DeleteRole(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Role not found")
+			return
+		}
+		h.logger.Error("failed to delete role", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete role")
+		return
+	}
+
+	h.logger.Info("role deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleAddRolePermission adds a permission to a role, granting it to every
+// token holding the role.
+// POST /api/roles/{id}/permissions
+// Body: as for POST /api/tokens/{id}/permissions
+func (h *Handler) HandleAddRolePermission(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseRoleID(w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+
+	var req AddPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	perm, ok := permissionFromRequest(w, req)
+	if !ok {
+		return
+	}
+
+	created, err := h.roles.AddRolePermission(r.Context(), id, perm)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Role not found")
+			return
+		}
+		h.logger.Error("failed to add role permission", "error", err, "role_id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to add permission")
+		return
+	}
+
+	h.logger.Info("role permission added", "role_id", id, "permission_id", created.ID, "resource", created.Resource, "zone_id", created.ZoneID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toPermissionResponse(created))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteRolePermission removes a permission from a role.
+// DELETE /api/roles/{id}/permissions/{pid}
+func (h *Handler) HandleDeleteRolePermission(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseRoleID(w, chi.URLParam(r, "id"))
+	if !ok {
+		return
+	}
+	permID, err := strconv.ParseInt(chi.URLParam(r, "pid"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			"Invalid permission ID", "Permission ID must be a number.")
+		return
+	}
+
+	if err := h.roles.RemoveRolePermission(r.Context(), id, permID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Permission not found for this role")
+			return
+		}
+		h.logger.Error("failed to delete role permission", "error", err, "role_id", id, "permission_id", permID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete permission")
+		return
+	}
+
+	h.logger.Info("role permission deleted", "role_id", id, "permission_id", permID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleAssignTokenRole gives a scoped token a role.
+// PUT /api/tokens/{id}/roles/{rid}
+func (h *Handler) HandleAssignTokenRole(w http.ResponseWriter, r *http.Request) {
+	tokenID, ok := parseTokenID(w, r)
+	if !ok {
+		return
+	}
+	roleID, ok := parseRoleID(w, chi.URLParam(r, "rid"))
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	token, err := h.storage.GetTokenByID(ctx, tokenID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+			return
+		}
+		h.logger.Error("failed to get token", "error", err, "id", tokenID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get token")
+		return
+	}
+	if token.IsAdmin {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			"Admin tokens do not use roles",
+			"Admin tokens have full access. Roles are only for scoped tokens.")
+		return
+	}
+
+	if err := h.roles.AssignRole(ctx, tokenID, roleID); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Role not found")
+		case errors.Is(err, storage.ErrDuplicate):
+			WriteError(w, http.StatusConflict, "duplicate_entry", "Token already has this role")
+		default:
+			h.logger.Error("failed to assign role", "error", err, "token_id", tokenID, "role_id", roleID)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to assign role")
+		}
+		return
+	}
+
+	h.logger.Info("role assigned", "token_id", tokenID, "role_id", roleID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleUnassignTokenRole takes a role away from a token.
+// DELETE /api/tokens/{id}/roles/{rid}
+func (h *Handler) HandleUnassignTokenRole(w http.ResponseWriter, r *http.Request) {
+	tokenID, ok := parseTokenID(w, r)
+	if !ok {
+		return
+	}
+	roleID, ok := parseRoleID(w, chi.URLParam(r, "rid"))
+	if !ok {
+		return
+	}
+
+	if err := h.roles.UnassignRole(r.Context(), tokenID, roleID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token does not have this role")
+			return
+		}
+		h.logger.Error("failed to unassign role", "error", err, "token_id", tokenID, "role_id", roleID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to unassign role")
+		return
+	}
+
+	h.logger.Info("role unassigned", "token_id", tokenID, "role_id", roleID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkRoles verifies that roles exist before a token is given them. It
+// writes an error response and returns false otherwise.
+func (h *Handler) checkRoles(ctx context.Context, w http.ResponseWriter, ids []int64) bool {
+	if h.roles == nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Roles are not enabled")
+		return false
+	}
+	for _, id := range ids {
+		if _, err := h.roles.GetRole(ctx, id); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Unknown role "+strconv.FormatInt(id, 10),
+					"List roles with GET /admin/api/roles.")
+				return false
+			}
+			h.logger.Error("failed to get role", "error", err, "id", id)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get role")
+			return false
+		}
+	}
+	return true
+}
+
+// parseRoleID parses a role ID URL parameter, writing a 400 response if it
+// is not a number.
+func parseRoleID(w http.ResponseWriter, s string) (int64, bool) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid role ID", "Role ID must be a number.")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestHandleCreateRole(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.CreateRoleFunc = func(_ context.Context, role *storage.Role) (*storage.Role, error) {
+		if role.Name == "taken" {
+			return nil, storage.ErrDuplicate
+		}
+		created := *role
+		created.ID = 1
+		return &created, nil
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid", `{"name":"acme","permissions":[{"zone_id":1,"allowed_actions":["add_record"],"record_types":["TXT"]}]}`, http.StatusCreated},
+		{"no permissions", `{"name":"empty"}`, http.StatusCreated},
+		{"missing name", `{"name":" "}`, http.StatusBadRequest},
+		{"invalid JSON", `{`, http.StatusBadRequest},
+		{"invalid record type", `{"name":"acme","permissions":[{"zone_id":1,"allowed_actions":["add_record"],"record_types":["BOGUS"]}]}`, http.StatusBadRequest},
+		{"invalid zone", `{"name":"acme","permissions":[{"zone_id":0,"allowed_actions":["add_record"],"record_types":["TXT"]}]}`, http.StatusBadRequest},
+		{"duplicate name", `{"name":"taken"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+			h.SetRoleStore(mock)
+
+			w := httptest.NewRecorder()
+			h.HandleCreateRole(w, httptest.NewRequest(http.MethodPost, "/api/roles", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleAssignTokenRole(t *testing.T) {
+	t.Parallel()
+	mock := newMockUnifiedStorage()
+	mock.GetTokenByIDFunc = func(_ context.Context, id int64) (*storage.Token, error) {
+		switch id {
+		case 1:
+			return &storage.Token{ID: 1, Name: "admin", IsAdmin: true}, nil
+		case 2:
+			return &storage.Token{ID: 2, Name: "ci"}, nil
+		}
+		return nil, storage.ErrNotFound
+	}
+	mock.AssignRoleFunc = func(_ context.Context, _, roleID int64) error {
+		switch roleID {
+		case 9:
+			return storage.ErrNotFound
+		case 5:
+			return storage.ErrDuplicate
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		id, rid    string
+		wantStatus int
+	}{
+		{"valid", "2", "3", http.StatusNoContent},
+		{"admin token", "1", "3", http.StatusBadRequest},
+		{"unknown token", "7", "3", http.StatusNotFound},
+		{"unknown role", "2", "9", http.StatusNotFound},
+		{"already held", "2", "5", http.StatusConflict},
+		{"invalid role ID", "2", "abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+			h.SetRoleStore(mock)
+
+			req := withChiParams(httptest.NewRequest(http.MethodPut, "/", nil), map[string]string{"id": tt.id, "rid": tt.rid})
+			w := httptest.NewRecorder()
+			h.HandleAssignTokenRole(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
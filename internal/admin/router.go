@@ -14,6 +14,11 @@ func (h *Handler) NewRouter() chi.Router {
 	adminAllowlist := []string{
 		"id", "name", "created_at", "zone_id",
 		"allowed_actions", "record_types", "level", "is_admin",
+		"url", "events", "zone_ids", "hostname", "version",
+		"cidr", "reason", "ttl_seconds", "expires_at", "pin_tls_fingerprint",
+		"entries", "token_id", "token_name", "action", "record_type", "method", "path", "status", "next_before_id",
+		"client_ip", "user_agent", "common_name", "enabled", "account", "accounts", "pattern",
+		"starts_at", "ends_at", "duration_seconds", "created_by",
 	}
 
 	// Middleware (order matters)
@@ -21,18 +26,54 @@ func (h *Handler) NewRouter() chi.Router {
 	r.Use(internalMiddleware.HTTPLogging(h.logger, adminAllowlist)) // Logging with allowlist
 	r.Use(middleware.Recoverer)                                     // Panic recovery
 	r.Use(internalMiddleware.MaxBodySize(1 << 20))                  // 1MB limit
+	if h.cors != nil {
+		r.Use(internalMiddleware.CORS(*h.cors)) // Answers preflights before auth
+	}
 
 	// Public endpoints (no auth)
 	r.Get("/health", h.HandleHealth)
 	r.Get("/ready", h.HandleReady)
 
+	// Operator documentation and OpenAPI document (only when docs are configured)
+	if h.docs != nil {
+		h.docsContent = &docsContent{router: r, base: *h.docs}
+		r.Get("/docs", h.HandleDocs)
+		r.Get("/docs/api", h.HandleSwaggerUI)
+		r.Get("/docs/openapi.json", h.HandleOpenAPI)
+	}
+
+	// Invitation claims (no auth: the code is the credential)
+	if h.invitations != nil && h.roles != nil {
+		r.Post("/invitations/{code}", h.HandleClaimInvitation)
+	}
+
 	// Admin API (token auth)
 	r.Route("/api", func(r chi.Router) {
 		r.Use(h.TokenAuthMiddleware)
+		if h.audit != nil {
+			r.Use(h.audit.AdminMiddleware)
+		}
+		if h.usage != nil {
+			r.Use(h.usage.Middleware)
+		}
+		if h.authCache != nil {
+			r.Use(h.invalidateAuthCache)
+		}
 
 		// Whoami endpoint - available to any authenticated token
 		r.Get("/whoami", h.HandleWhoami)
 
+		// Webhook management (only when a webhook service is configured).
+		// Admins manage all webhooks; scoped tokens manage their own, limited to their zones.
+		if h.webhooks != nil {
+			r.Get("/webhooks", h.HandleListWebhooks)
+			r.Post("/webhooks", h.HandleCreateWebhook)
+			r.Get("/webhooks/{id}", h.HandleGetWebhook)
+			r.Delete("/webhooks/{id}", h.HandleDeleteWebhook)
+			r.Post("/webhooks/{id}/test", h.HandleTestWebhook)
+			r.Get("/webhooks/{id}/deliveries", h.HandleListWebhookDeliveries)
+		}
+
 		// Admin-only endpoints - require admin token
 		r.Group(func(r chi.Router) {
 			r.Use(h.RequireAdmin)
@@ -40,6 +81,13 @@ func (h *Handler) NewRouter() chi.Router {
 			// Log level management
 			r.Post("/loglevel", h.HandleSetLogLevel)
 
+			// Upstream body logging for debugging (only when a switch is configured)
+			if h.bodyLogging != nil {
+				r.Get("/debug/body-logging", h.HandleGetBodyLogging)
+				r.Put("/debug/body-logging", h.HandleEnableBodyLogging)
+				r.Delete("/debug/body-logging", h.HandleDisableBodyLogging)
+			}
+
 			// Unified token management (Issue 147)
 			r.Get("/tokens", h.HandleListUnifiedTokens)
 			r.Post("/tokens", h.HandleCreateUnifiedToken)
@@ -47,6 +95,108 @@ func (h *Handler) NewRouter() chi.Router {
 			r.Delete("/tokens/{id}", h.HandleDeleteUnifiedToken)
 			r.Post("/tokens/{id}/permissions", h.HandleAddTokenPermission)
 			r.Delete("/tokens/{id}/permissions/{pid}", h.HandleDeleteTokenPermission)
+			r.Delete("/tokens/{id}/tls-fingerprint", h.HandleResetTokenFingerprint)
+			r.Put("/tokens/{id}/account", h.HandleSetTokenAccount)
+			r.Get("/accounts", h.HandleListAccounts)
+
+			// Client certificate mapping (only when a client certificate store is configured)
+			if h.clientCerts != nil {
+				r.Get("/tokens/{id}/client-cert", h.HandleGetTokenClientCert)
+				r.Put("/tokens/{id}/client-cert", h.HandleSetTokenClientCert)
+				r.Delete("/tokens/{id}/client-cert", h.HandleDeleteTokenClientCert)
+			}
+
+			// Roles: permission sets shared by tokens (only when a role store is configured)
+			if h.roles != nil {
+				r.Get("/roles", h.HandleListRoles)
+				r.Post("/roles", h.HandleCreateRole)
+				r.Get("/roles/{id}", h.HandleGetRole)
+				r.Delete("/roles/{id}", h.HandleDeleteRole)
+				r.Post("/roles/{id}/permissions", h.HandleAddRolePermission)
+				r.Delete("/roles/{id}/permissions/{pid}", h.HandleDeleteRolePermission)
+				r.Put("/tokens/{id}/roles/{rid}", h.HandleAssignTokenRole)
+				r.Delete("/tokens/{id}/roles/{rid}", h.HandleUnassignTokenRole)
+			}
+
+			// Invitations: one-time links minting tokens with roles (only with roles)
+			if h.invitations != nil && h.roles != nil {
+				r.Get("/invitations", h.HandleListInvitations)
+				r.Post("/invitations", h.HandleCreateInvitation)
+				r.Delete("/invitations/{id}", h.HandleDeleteInvitation)
+			}
+
+			// Short-lived admin sessions (only when sessions are configured)
+			if h.sessions != nil {
+				r.Post("/sessions", h.HandleCreateSession)
+				r.Delete("/sessions/current", h.HandleDeleteCurrentSession)
+			}
+
+			// Instances sharing this database (only when a registry is configured)
+			if h.instances != nil {
+				r.Get("/instances", h.HandleListInstances)
+			}
+
+			// Effective configuration (only when a config is provided)
+			if h.config != nil {
+				r.Get("/config", h.HandleGetConfig)
+			}
+
+			// Audit log (only when an audit log is configured)
+			if h.audit != nil {
+				r.Get("/audit", h.HandleListAudit)
+			}
+
+			// Client IP blocklist (only when a blocklist is configured)
+			if h.blocklist != nil {
+				r.Get("/blocklist", h.HandleListBlocklist)
+				r.Post("/blocklist", h.HandleCreateBlocklistEntry)
+				r.Delete("/blocklist/{id}", h.HandleDeleteBlocklistEntry)
+			}
+
+			// Zone change freezes (only when a freeze schedule is configured)
+			if h.freezes != nil {
+				r.Get("/freezes", h.HandleListFreezes)
+				r.Post("/freezes", h.HandleCreateFreeze)
+				r.Delete("/freezes/{id}", h.HandleDeleteFreeze)
+			}
+
+			// Record TTL policies (only when TTL policies are configured)
+			if h.ttl != nil {
+				r.Get("/ttl-policies", h.HandleListTTLPolicies)
+				r.Put("/ttl-policies/{zone}", h.HandleSetTTLPolicy)
+				r.Delete("/ttl-policies/{zone}", h.HandleDeleteTTLPolicy)
+			}
+
+			// Zone tags and tag permissions (only when zone tags are configured)
+			if h.zoneTags != nil {
+				r.Get("/zone-tags", h.HandleListZoneTags)
+				r.Post("/zone-tags/sync", h.HandleSyncZoneTags)
+				r.Get("/tokens/{id}/tag-permissions", h.HandleListTagPermissions)
+				r.Post("/tokens/{id}/tag-permissions", h.HandleAddTagPermission)
+				r.Delete("/tokens/{id}/tag-permissions/{tid}", h.HandleDeleteTagPermission)
+			}
+
+			// Record change history and reverts (only when configured)
+			if h.history != nil {
+				r.Get("/zones/{zoneID}/history", h.HandleListZoneHistory)
+			}
+			if h.reverter != nil {
+				r.Post("/changes/{changeID}/revert", h.HandleRevertChange)
+			}
+
+			// Domain permissions (only when a domain permission store is configured)
+			if h.domainPerms != nil {
+				r.Get("/tokens/{id}/domain-permissions", h.HandleListDomainPermissions)
+				r.Post("/tokens/{id}/domain-permissions", h.HandleAddDomainPermission)
+				r.Delete("/tokens/{id}/domain-permissions/{did}", h.HandleDeleteDomainPermission)
+			}
+
+			// Protected record names (only when a protected record store is configured)
+			if h.protected != nil {
+				r.Get("/zones/{zoneID}/protected-records", h.HandleListProtectedRecords)
+				r.Post("/zones/{zoneID}/protected-records", h.HandleAddProtectedRecord)
+				r.Delete("/zones/{zoneID}/protected-records/{id}", h.HandleDeleteProtectedRecord)
+			}
 		})
 	})
 
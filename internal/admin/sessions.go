@@ -0,0 +1,214 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// sessionPrefix marks a session credential, so the auth middleware can tell
+// it apart from an admin token without a second lookup.
+const sessionPrefix = "sess_"
+
+// SessionStore defines the storage operations needed for admin sessions.
+type SessionStore interface {
+	CreateAdminSession(ctx context.Context, sess *storage.AdminSession, sessionHash string) (*storage.AdminSession, error)
+	GetAdminSessionByHash(ctx context.Context, sessionHash string) (*storage.AdminSession, error)
+	DeleteAdminSession(ctx context.Context, id int64) error
+	PruneAdminSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SetSessions enables POST /api/sessions, which exchanges an admin token for
+// a session credential lasting at most maxTTL. Must be called before NewRouter.
+func (h *Handler) SetSessions(store SessionStore, maxTTL time.Duration) {
+	h.sessions = store
+	h.sessionTTL = maxTTL
+}
+
+type sessionContextKey struct{}
+
+// sessionFromContext returns the session a request authenticated with, or nil.
+func sessionFromContext(ctx context.Context) *storage.AdminSession {
+	sess, _ := ctx.Value(sessionContextKey{}).(*storage.AdminSession)
+	return sess
+}
+
+// CreateSessionRequest is the optional request body for POST /api/sessions.
+// TTLSeconds shortens the session; zero or a longer value gives the maximum.
+type CreateSessionRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+}
+
+// SessionResponse is returned once when a session is created. The session
+// credential cannot be retrieved again.
+type SessionResponse struct {
+	Session   string `json:"session"`
+	ExpiresAt string `json:"expires_at"`
+	ClientIP  string `json:"client_ip"`
+	UserAgent string `json:"user_agent"`
+}
+
+// HandleCreateSession exchanges the calling admin token for a short-lived
+// session credential bound to the caller's IP address and user agent.
+// POST /api/sessions
+func (h *Handler) HandleCreateSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := auth.TokenFromContext(ctx)
+	if token == nil {
+		WriteErrorWithHint(w, http.StatusForbidden, ErrCodeAdminRequired,
+			"Sessions require an admin token",
+			"The master API key cannot start a session. Create an admin token first.")
+		return
+	}
+	if principalFromContext(ctx) != nil {
+		WriteErrorWithHint(w, http.StatusForbidden, ErrCodeAdminRequired,
+			"Sessions require an admin token",
+			"Bearer tokens from your identity provider cannot start a session. Use them directly instead.")
+		return
+	}
+	if sessionFromContext(ctx) != nil {
+		WriteErrorWithHint(w, http.StatusForbidden, ErrCodeAdminRequired,
+			"A session cannot start another session",
+			"Use the admin token to start a new session.")
+		return
+	}
+
+	var req CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+	if req.TTLSeconds < 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "ttl_seconds must not be negative")
+		return
+	}
+
+	now := time.Now()
+	ttl := h.sessionTTL
+	if req.TTLSeconds > 0 && time.Duration(req.TTLSeconds)*time.Second < ttl {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	expiresAt := now.Add(ttl)
+	// A session never outlives the token it was exchanged for
+	if !token.ExpiresAt.IsZero() && token.ExpiresAt.Before(expiresAt) {
+		expiresAt = token.ExpiresAt
+	}
+
+	// Expired sessions are removed here rather than by a background job
+	if _, err := h.sessions.PruneAdminSessions(ctx, now); err != nil {
+		h.logger.Warn("failed to prune admin sessions", "error", err)
+	}
+
+	secret, err := generateRandomKey(64)
+	if err != nil {
+		h.logger.Error("failed to generate session credential", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to generate session")
+		return
+	}
+	credential := sessionPrefix + secret
+
+	sess, err := h.sessions.CreateAdminSession(ctx, &storage.AdminSession{
+		TokenID:   token.ID,
+		ClientIP:  h.clientIP(r),
+		UserAgent: r.UserAgent(),
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}, auth.HashToken(credential))
+	if err != nil {
+		h.logger.Error("failed to create admin session", "error", err, "token_id", token.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to create session")
+		return
+	}
+
+	h.logger.Info("admin session created", "token_id", token.ID, "session_id", sess.ID,
+		"client_ip", sess.ClientIP, "expires_at", sess.ExpiresAt)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(SessionResponse{
+		Session:   credential,
+		ExpiresAt: sess.ExpiresAt.Format(time.RFC3339),
+		ClientIP:  sess.ClientIP,
+		UserAgent: sess.UserAgent,
+	})
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteCurrentSession ends the session the request authenticated with.
+// DELETE /api/sessions/current
+func (h *Handler) HandleDeleteCurrentSession(w http.ResponseWriter, r *http.Request) {
+	sess := sessionFromContext(r.Context())
+	if sess == nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			"Request was not made with a session",
+			"Send the session credential in the AccessKey header.")
+		return
+	}
+
+	if err := h.sessions.DeleteAdminSession(r.Context(), sess.ID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Error("failed to delete admin session", "error", err, "session_id", sess.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to end session")
+		return
+	}
+
+	h.logger.Info("admin session ended", "token_id", sess.TokenID, "session_id", sess.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateSession returns the session for a credential and the admin token
+// it was exchanged for. It fails with storage.ErrNotFound unless the session
+// is unexpired, the request comes from the client that created it, and the
+// token still exists, is unexpired and is an admin.
+func (h *Handler) validateSession(r *http.Request, credential string) (*storage.AdminSession, *storage.Token, error) {
+	ctx := r.Context()
+	sess, err := h.sessions.GetAdminSessionByHash(ctx, auth.HashToken(credential))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if !sess.ExpiresAt.After(now) {
+		h.logger.Debug("expired admin session rejected", "session_id", sess.ID)
+		return nil, nil, storage.ErrNotFound
+	}
+	if ip := h.clientIP(r); ip != sess.ClientIP || r.UserAgent() != sess.UserAgent {
+		h.logger.Warn("admin session used from another client", "session_id", sess.ID,
+			"client_ip", ip, "session_client_ip", sess.ClientIP)
+		return nil, nil, storage.ErrNotFound
+	}
+
+	token, err := h.storage.GetTokenByID(ctx, sess.TokenID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !token.IsAdmin || token.IsExpired(now) {
+		return nil, nil, storage.ErrNotFound
+	}
+	return sess, token, nil
+}
+
+// clientIP returns the address a session is bound to. It matches the address
+// the blocklist checks, so X-Forwarded-For is honoured only when trusted there.
+func (h *Handler) clientIP(r *http.Request) string {
+	if h.blocklist != nil {
+		if addr, ok := h.blocklist.ClientIP(r); ok {
+			return addr.String()
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	return host
+}
@@ -0,0 +1,198 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// newSessionRouter returns an admin router with sessions enabled and the
+// plaintext of an admin token stored in it.
+func newSessionRouter(t *testing.T, maxTTL time.Duration) (chi.Router, *storage.SQLiteStorage, string) {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	adminKey := "admin-token-plaintext"
+	if _, err := store.CreateToken(context.Background(), "ops", true, auth.HashToken(adminKey)); err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetSessions(store, maxTTL)
+	return h.NewRouter(), store, adminKey
+}
+
+// sessionRequest serves a request from the given client address and user agent.
+func sessionRequest(router http.Handler, method, path, body, accessKey, remoteAddr, userAgent string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("AccessKey", accessKey)
+	req.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSessions_Lifecycle(t *testing.T) {
+	t.Parallel()
+	router, _, adminKey := newSessionRouter(t, 15*time.Minute)
+	const addr, ua = "192.0.2.1:5000", "bunnyctl/1.0"
+
+	w := sessionRequest(router, http.MethodPost, "/api/sessions", "", adminKey, addr, ua)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp SessionResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if !strings.HasPrefix(resp.Session, sessionPrefix) || resp.ClientIP != "192.0.2.1" || resp.UserAgent != ua {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	expires, err := time.Parse(time.RFC3339, resp.ExpiresAt)
+	if err != nil || time.Until(expires) > 15*time.Minute || time.Until(expires) < 14*time.Minute {
+		t.Errorf("unexpected expires_at %q", resp.ExpiresAt)
+	}
+
+	// The session works like the admin token from the same client
+	if w := sessionRequest(router, http.MethodGet, "/api/tokens", "", resp.Session, "192.0.2.1:6000", ua); w.Code != http.StatusOK {
+		t.Errorf("expected 200 from the same client, got %d", w.Code)
+	}
+
+	// ...but not from another address or user agent
+	if w := sessionRequest(router, http.MethodGet, "/api/tokens", "", resp.Session, "198.51.100.7:5000", ua); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 from another address, got %d", w.Code)
+	}
+	if w := sessionRequest(router, http.MethodGet, "/api/tokens", "", resp.Session, addr, "curl/8.0"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 from another user agent, got %d", w.Code)
+	}
+
+	// A session cannot start another one
+	if w := sessionRequest(router, http.MethodPost, "/api/sessions", "", resp.Session, addr, ua); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a session starting a session, got %d", w.Code)
+	}
+
+	// Ending the session revokes it
+	if w := sessionRequest(router, http.MethodDelete, "/api/sessions/current", "", resp.Session, addr, ua); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := sessionRequest(router, http.MethodGet, "/api/tokens", "", resp.Session, addr, ua); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after ending the session, got %d", w.Code)
+	}
+}
+
+func TestSessions_TTL(t *testing.T) {
+	t.Parallel()
+	router, _, adminKey := newSessionRouter(t, 15*time.Minute)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantTTL    time.Duration
+	}{
+		{"shorter", `{"ttl_seconds":60}`, http.StatusCreated, time.Minute},
+		{"capped", `{"ttl_seconds":86400}`, http.StatusCreated, 15 * time.Minute},
+		{"negative", `{"ttl_seconds":-1}`, http.StatusBadRequest, 0},
+		{"invalid JSON", `{`, http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := sessionRequest(router, http.MethodPost, "/api/sessions", tt.body, adminKey, "192.0.2.1:5000", "ui")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusCreated {
+				return
+			}
+			var resp SessionResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			expires, _ := time.Parse(time.RFC3339, resp.ExpiresAt)
+			if ttl := time.Until(expires); ttl > tt.wantTTL || ttl < tt.wantTTL-time.Minute {
+				t.Errorf("session lasts %v, want about %v", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestSessions_RejectedAfterTokenChanges(t *testing.T) {
+	t.Parallel()
+	router, store, adminKey := newSessionRouter(t, 15*time.Minute)
+	const addr, ua = "192.0.2.1:5000", "ui"
+
+	w := sessionRequest(router, http.MethodPost, "/api/sessions", "", adminKey, addr, ua)
+	var resp SessionResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	// Expiring the token ends its sessions too
+	token, err := store.GetTokenByHash(context.Background(), auth.HashToken(adminKey))
+	if err != nil {
+		t.Fatalf("failed to get token: %v", err)
+	}
+	if err := store.SetTokenExpiry(context.Background(), token.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to expire token: %v", err)
+	}
+	if w := sessionRequest(router, http.MethodGet, "/api/tokens", "", resp.Session, addr, ua); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after the token expired, got %d", w.Code)
+	}
+}
+
+func TestSessions_Disabled(t *testing.T) {
+	t.Parallel()
+	ts := newTestServer(t)
+	defer ts.close()
+
+	// Without SetSessions a session-shaped key is just an unknown token
+	resp := ts.doRequest(t, http.MethodGet, "/api/whoami", nil, sessionPrefix+"abc")
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleCreateSession_MasterKey(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(newMockUnifiedStorage(), new(slog.LevelVar), slog.Default())
+	h.SetSessions(newMockUnifiedStorage(), time.Minute)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	ctx := auth.WithAdmin(auth.WithMasterKey(req.Context(), true), true)
+	w := httptest.NewRecorder()
+	h.HandleCreateSession(w, req.WithContext(ctx))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for the master key, got %d", w.Code)
+	}
+}
+
+func TestHandleDeleteCurrentSession_NoSession(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(newMockUnifiedStorage(), new(slog.LevelVar), slog.Default())
+	h.SetSessions(newMockUnifiedStorage(), time.Minute)
+
+	w := httptest.NewRecorder()
+	h.HandleDeleteCurrentSession(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
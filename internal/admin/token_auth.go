@@ -2,8 +2,10 @@ package admin
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/auth"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
@@ -12,10 +14,28 @@ import (
 // TokenAuthMiddleware validates AccessKey tokens for admin API
 // It accepts:
 // - AccessKey header: validated against stored admin tokens or master API key
+// - AccessKey header with a session credential, when sessions are enabled
+// - Without an AccessKey header, an Authorization: Bearer credential accepted by an auth provider
+// - Without either header, a verified client certificate mapped to an admin token
 func (h *Handler) TokenAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the fingerprint first, so a forged header is removed from
+		// every request
+		var fingerprint string
+		if h.fingerprints != nil {
+			fingerprint = h.fingerprints.Fingerprint(r)
+		}
+
 		accessKey := r.Header.Get("AccessKey")
 		if accessKey == "" {
+			if credential, ok := bearerCredential(r); ok && len(h.authProviders) > 0 {
+				h.authenticateBearer(w, r, next, credential)
+				return
+			}
+			if name := auth.ClientCertName(r); name != "" && h.clientCerts != nil {
+				h.authenticateClientCert(w, r, next, name)
+				return
+			}
 			http.Error(w, "missing API key", http.StatusUnauthorized)
 			return
 		}
@@ -28,20 +48,56 @@ func (h *Handler) TokenAuthMiddleware(next http.Handler) http.Handler {
 
 		ctx := r.Context()
 
+		// Session credentials act as the admin token they were exchanged for
+		if h.sessions != nil && strings.HasPrefix(token, sessionPrefix) {
+			sess, sessionToken, err := h.validateSession(r, token)
+			if err != nil {
+				if !errors.Is(err, storage.ErrNotFound) {
+					h.logger.Error("failed to validate admin session", "error", err)
+					http.Error(w, "Internal error", http.StatusInternalServerError)
+					return
+				}
+				h.logger.Warn("invalid admin session attempt", "remote_addr", r.RemoteAddr)
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			ctx = auth.WithToken(ctx, sessionToken)
+			ctx = auth.WithAdmin(ctx, true)
+			ctx = context.WithValue(ctx, sessionContextKey{}, sess)
+			h.logger.Debug("admin API request via session", "token_name", sessionToken.Name, "session_id", sess.ID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// First, check if this is the master API key using the bootstrap service
 		if h.bootstrap != nil && h.bootstrap.IsMasterKey(token) {
 			// Master key is allowed only during bootstrap (UNCONFIGURED state)
-			canUse, err := h.bootstrap.CanUseMasterKey(ctx)
+			state, err := h.bootstrap.GetState(ctx)
 			if err != nil {
 				h.logger.Error("failed to check bootstrap state", "error", err)
 				http.Error(w, "Internal error", http.StatusInternalServerError)
 				return
 			}
-			if !canUse {
+			switch state {
+			case auth.StateUnconfigured:
+			case auth.StateExpired:
+				h.logger.Warn("master key refused after the bootstrap window", "remote_addr", r.RemoteAddr)
+				WriteErrorWithHint(w, http.StatusForbidden, ErrCodeMasterKeyLocked,
+					"Bootstrap window has closed. The master API key can no longer create an admin token.",
+					"Restart the proxy to reopen the window, or raise BOOTSTRAP_WINDOW_SECONDS.")
+				return
+			default:
 				WriteError(w, http.StatusForbidden, ErrCodeMasterKeyLocked,
 					"Master API key is locked. Use an admin token instead.")
 				return
 			}
+			// During bootstrap the master key only creates the first admin token
+			if !masterKeyAllowed(r) {
+				WriteErrorWithHint(w, http.StatusForbidden, ErrCodeAdminRequired,
+					"The master API key can only create the first admin token",
+					"Create an admin token with POST /admin/api/tokens and use it instead.")
+				return
+			}
 			// Master key authenticated - set context flags
 			ctx = auth.WithMasterKey(ctx, true)
 			ctx = auth.WithAdmin(ctx, true)
@@ -52,6 +108,12 @@ func (h *Handler) TokenAuthMiddleware(next http.Handler) http.Handler {
 
 		// Check against unified tokens (Issue 147)
 		unifiedToken, err := h.validateUnifiedToken(ctx, token)
+		if err == nil && unifiedToken != nil && h.fingerprints != nil {
+			err = auth.CheckFingerprint(ctx, h.storage, unifiedToken, fingerprint)
+			if err != nil {
+				h.logger.Warn("token TLS fingerprint rejected", "token_id", unifiedToken.ID, "error", err)
+			}
+		}
 		if err == nil && unifiedToken != nil {
 			// Add token and admin status to context
 			ctx = auth.WithToken(ctx, unifiedToken)
@@ -67,9 +129,51 @@ func (h *Handler) TokenAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// masterKeyAllowed reports whether the master key may make a request during
+// bootstrap: creating a token or checking whoami.
+func masterKeyAllowed(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost:
+		return strings.HasSuffix(r.URL.Path, "/api/tokens")
+	case http.MethodGet:
+		return strings.HasSuffix(r.URL.Path, "/api/whoami")
+	default:
+		return false
+	}
+}
+
+// authenticateClientCert serves an admin API request as the token its client
+// certificate's common name is mapped to.
+func (h *Handler) authenticateClientCert(w http.ResponseWriter, r *http.Request, next http.Handler, commonName string) {
+	ctx := r.Context()
+	token, err := h.clientCerts.GetTokenByClientCert(ctx, commonName)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Error("failed to look up client certificate", "error", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || token.IsExpired(time.Now()) {
+		h.logger.Warn("invalid admin client certificate", "common_name", commonName, "remote_addr", r.RemoteAddr)
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	ctx = auth.WithToken(ctx, token)
+	ctx = auth.WithAdmin(ctx, token.IsAdmin)
+	h.logger.Debug("admin API request via client certificate", "token_name", token.Name, "is_admin", token.IsAdmin)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 // validateUnifiedToken validates a token against the unified token system.
-// Returns the token if valid, or nil if not found.
+// Returns the token if valid, or storage.ErrNotFound if it doesn't exist or has expired.
 func (h *Handler) validateUnifiedToken(ctx context.Context, token string) (*storage.Token, error) {
-	keyHash := auth.HashToken(token)
-	return h.storage.GetTokenByHash(ctx, keyHash)
+	t, err := auth.LookupKey(ctx, h.storage, token)
+	if err != nil {
+		return nil, err
+	}
+	if t.IsExpired(time.Now()) {
+		h.logger.Debug("expired token rejected", "token_id", t.ID)
+		return nil, storage.ErrNotFound
+	}
+	return t, nil
 }
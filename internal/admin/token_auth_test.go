@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/auth"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
@@ -90,7 +91,7 @@ func TestTokenAuthMiddlewareMasterKey(t *testing.T) {
 		handler := h.TokenAuthMiddleware(testHandler)
 
 		// Create request with master key
-		req := httptest.NewRequest("GET", "/api/test", nil)
+		req := httptest.NewRequest("GET", "/api/whoami", nil)
 		req.Header.Set("AccessKey", masterKey)
 		w := httptest.NewRecorder()
 
@@ -271,6 +272,24 @@ func TestValidateUnifiedToken(t *testing.T) {
 		}
 	})
 
+	t.Run("returns ErrNotFound when token expired", func(t *testing.T) {
+		mock := &mockstore.MockStorage{GetTokenByHashFunc: func(ctx context.Context, keyHash string) (*storage.Token, error) {
+			return &storage.Token{ID: 42, Name: "ci-token", KeyHash: tokenHash, ExpiresAt: time.Now().Add(-time.Second)}, nil
+		},
+		}
+
+		h := NewHandler(mock, new(slog.LevelVar), slog.Default())
+
+		token, err := h.validateUnifiedToken(context.Background(), knownToken)
+
+		if err != storage.ErrNotFound {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if token != nil {
+			t.Error("expected nil token, got", token)
+		}
+	})
+
 	t.Run("returns ErrNotFound when token not found", func(t *testing.T) {
 		mock := &mockstore.MockStorage{GetTokenByHashFunc: func(ctx context.Context, keyHash string) (*storage.Token, error) {
 			return nil, storage.ErrNotFound
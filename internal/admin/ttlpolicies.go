@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/ttlpolicy"
+)
+
+// globalTTLPolicy names the global policy in /api/ttl-policies/{zone}.
+const globalTTLPolicy = "global"
+
+// SetTTLPolicies enables the record TTL policy endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetTTLPolicies(p *ttlpolicy.Policies) {
+	h.ttl = p
+}
+
+// SetTTLPolicyRequest is the request body for PUT /api/ttl-policies/{zone}.
+// A value left out or 0 is not set.
+type SetTTLPolicyRequest struct {
+	MinTTL     int32 `json:"min_ttl,omitempty"`
+	MaxTTL     int32 `json:"max_ttl,omitempty"`
+	DefaultTTL int32 `json:"default_ttl,omitempty"`
+}
+
+// TTLPolicyResponse represents a TTL policy in API responses. ZoneID is 0
+// for the global policy.
+type TTLPolicyResponse struct {
+	ZoneID     int64  `json:"zone_id"`
+	MinTTL     int32  `json:"min_ttl,omitempty"`
+	MaxTTL     int32  `json:"max_ttl,omitempty"`
+	DefaultTTL int32  `json:"default_ttl,omitempty"`
+	UpdatedBy  string `json:"updated_by"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+func toTTLPolicyResponse(p *storage.TTLPolicy) TTLPolicyResponse {
+	return TTLPolicyResponse{
+		ZoneID:     p.ZoneID,
+		MinTTL:     p.MinTTL,
+		MaxTTL:     p.MaxTTL,
+		DefaultTTL: p.DefaultTTL,
+		UpdatedBy:  p.UpdatedBy,
+		UpdatedAt:  p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// HandleListTTLPolicies returns the global and per-zone TTL policies.
+// GET /api/ttl-policies
+func (h *Handler) HandleListTTLPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.ttl.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list TTL policies", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list TTL policies")
+		return
+	}
+
+	response := make([]TTLPolicyResponse, 0, len(policies))
+	for _, p := range policies {
+		response = append(response, toTTLPolicyResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleSetTTLPolicy creates or replaces the TTL policy of a zone, or the
+// global policy.
+// PUT /api/ttl-policies/{zone}
+func (h *Handler) HandleSetTTLPolicy(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := ttlPolicyZone(w, r)
+	if !ok {
+		return
+	}
+
+	var req SetTTLPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+
+	p, err := h.ttl.Set(r.Context(), &storage.TTLPolicy{
+		ZoneID:     zoneID,
+		MinTTL:     req.MinTTL,
+		MaxTTL:     req.MaxTTL,
+		DefaultTTL: req.DefaultTTL,
+		UpdatedBy:  adminActor(r),
+	})
+	if err != nil {
+		if errors.Is(err, ttlpolicy.ErrInvalidPolicy) {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.logger.Error("failed to set TTL policy", "zone_id", zoneID, "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to set TTL policy")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(toTTLPolicyResponse(p))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteTTLPolicy removes the TTL policy of a zone, or the global policy.
+// DELETE /api/ttl-policies/{zone}
+func (h *Handler) HandleDeleteTTLPolicy(w http.ResponseWriter, r *http.Request) {
+	zoneID, ok := ttlPolicyZone(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.ttl.Remove(r.Context(), zoneID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "TTL policy not found")
+			return
+		}
+		h.logger.Error("failed to delete TTL policy", "zone_id", zoneID, "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete TTL policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ttlPolicyZone parses the {zone} path parameter: "global", stored as zone
+// 0, or a zone ID. Writes a 400 response and returns false if it is neither.
+func ttlPolicyZone(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	zone := chi.URLParam(r, "zone")
+	if zone == globalTTLPolicy {
+		return 0, true
+	}
+	zoneID, err := strconv.ParseInt(zone, 10, 64)
+	if err != nil || zoneID <= 0 {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid zone",
+			`Use a zone ID, or "global" for the policy of every zone without its own.`)
+		return 0, false
+	}
+	return zoneID, true
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/ttlpolicy"
+)
+
+func TestTTLPolicyEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	policies := ttlpolicy.New(store, logger)
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetTTLPolicies(policies)
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodPut, "/api/ttl-policies/global", `{"min_ttl":60,"max_ttl":86400,"default_ttl":300}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var global TTLPolicyResponse
+	if err := json.NewDecoder(w.Body).Decode(&global); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if global.ZoneID != 0 || global.MinTTL != 60 || global.DefaultTTL != 300 || global.UpdatedBy != "admin" {
+		t.Errorf("unexpected policy: %+v", global)
+	}
+	if got := policies.TTL(123, 30); got != 60 {
+		t.Errorf("TTL after setting the global policy = %d, want 60", got)
+	}
+
+	for name, tc := range map[string]struct {
+		path, body string
+		want       int
+	}{
+		"zone":            {"/api/ttl-policies/123", `{"max_ttl":3600}`, http.StatusOK},
+		"invalid zone":    {"/api/ttl-policies/abc", `{"max_ttl":3600}`, http.StatusBadRequest},
+		"zone 0":          {"/api/ttl-policies/0", `{"max_ttl":3600}`, http.StatusBadRequest},
+		"empty":           {"/api/ttl-policies/124", `{}`, http.StatusBadRequest},
+		"min above max":   {"/api/ttl-policies/124", `{"min_ttl":600,"max_ttl":60}`, http.StatusBadRequest},
+		"invalid request": {"/api/ttl-policies/124", `{`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPut, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+	if got := policies.TTL(123, 30); got != 30 {
+		t.Errorf("TTL with a zone policy = %d, want 30", got)
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, "/api/ttl-policies", "")
+	var list []TTLPolicyResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 2 || list[0].ZoneID != 0 || list[1].ZoneID != 123 || list[1].MaxTTL != 3600 {
+		t.Errorf("unexpected policies: %+v", list)
+	}
+
+	if w := doWebhookRequest(t, router, http.MethodDelete, "/api/ttl-policies/123", ""); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := policies.TTL(123, 30); got != 60 {
+		t.Errorf("TTL after deleting the zone policy = %d, want the global policy's 60", got)
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, "/api/ttl-policies/123", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting twice, got %d", w.Code)
+	}
+}
@@ -0,0 +1,9 @@
+package admin
+
+import "github.com/sipico/bunny-api-proxy/internal/usage"
+
+// SetUsageTracker counts admin API requests towards each token's last used
+// time and use count. Must be called before NewRouter.
+func (h *Handler) SetUsageTracker(t *usage.Tracker) {
+	h.usage = t
+}
@@ -0,0 +1,133 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/usage"
+)
+
+func TestTokenUsageTracking(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	admin, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey))
+	if err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	idle, err := store.CreateToken(ctx, "idle", false, auth.HashToken("idle-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tracker := usage.New(store, logger)
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetBootstrapService(auth.NewBootstrapService(store, "master-key"))
+	h.SetUsageTracker(tracker)
+	router := h.NewRouter()
+
+	// Admin API requests count as uses of the admin token
+	for range 2 {
+		if w := doWebhookRequest(t, router, http.MethodGet, "/api/whoami", ""); w.Code != http.StatusOK {
+			t.Fatalf("whoami: expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if err := tracker.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	list := func(query string) (int, []UnifiedTokenResponse) {
+		w := doWebhookRequest(t, router, http.MethodGet, "/api/tokens"+query, "")
+		var tokens []UnifiedTokenResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &tokens); err != nil {
+				t.Fatalf("failed to decode tokens: %v", err)
+			}
+		}
+		return w.Code, tokens
+	}
+
+	status, tokens := list("")
+	if status != http.StatusOK || len(tokens) != 2 {
+		t.Fatalf("expected two tokens, got %d %+v", status, tokens)
+	}
+	for _, tok := range tokens {
+		switch tok.ID {
+		case admin.ID:
+			// The list request itself is not flushed yet
+			if tok.UseCount != 2 || tok.LastUsedAt == "" {
+				t.Errorf("expected admin token used twice, got %+v", tok)
+			}
+		case idle.ID:
+			if tok.UseCount != 0 || tok.LastUsedAt != "" {
+				t.Errorf("expected idle token unused, got %+v", tok)
+			}
+		}
+	}
+
+	// A future cutoff matches every token; one an hour ago matches none, since
+	// the admin token was just used and the idle token was just created
+	cutoff := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if err := tracker.Flush(ctx); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	status, tokens = list("?unused_since=" + cutoff)
+	if status != http.StatusOK || len(tokens) != 2 {
+		t.Errorf("expected both tokens unused before a future cutoff, got %d %+v", status, tokens)
+	}
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	status, tokens = list("?unused_since=" + past)
+	if status != http.StatusOK || len(tokens) != 0 {
+		t.Errorf("expected no tokens unused since an hour ago, got %d %+v", status, tokens)
+	}
+
+	if status, _ := list("?unused_since=90d"); status != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid unused_since, got %d", status)
+	}
+
+	// The detail view reports the same counters
+	w := doWebhookRequest(t, router, http.MethodGet, "/api/tokens/"+strconv.FormatInt(admin.ID, 10), "")
+	var detail UnifiedTokenDetailResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("failed to decode token: %v", err)
+	}
+	if detail.UseCount < 2 || detail.LastUsedAt == "" {
+		t.Errorf("expected usage in token details, got %+v", detail)
+	}
+}
+
+// TestUnusedBefore verifies never-used tokens count from their creation.
+func TestUnusedBefore(t *testing.T) {
+	t.Parallel()
+	cutoff := time.Unix(1700000000, 0)
+	tests := []struct {
+		name  string
+		token storage.Token
+		want  bool
+	}{
+		{"used after cutoff", storage.Token{CreatedAt: cutoff.Add(-time.Hour), LastUsedAt: cutoff.Add(time.Minute)}, false},
+		{"used before cutoff", storage.Token{CreatedAt: cutoff.Add(-time.Hour), LastUsedAt: cutoff.Add(-time.Minute)}, true},
+		{"never used, old", storage.Token{CreatedAt: cutoff.Add(-time.Hour)}, true},
+		{"never used, new", storage.Token{CreatedAt: cutoff.Add(time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := unusedBefore(&tt.token, cutoff); got != tt.want {
+			t.Errorf("%s: unusedBefore = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
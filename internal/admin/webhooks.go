@@ -0,0 +1,322 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/webhooks"
+)
+
+// SetWebhookService enables the webhook management endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetWebhookService(svc *webhooks.Service) {
+	h.webhooks = svc
+}
+
+// CreateWebhookRequest is the request body for POST /api/webhooks.
+type CreateWebhookRequest struct {
+	URL     string   `json:"url"`
+	Secret  string   `json:"secret,omitempty"`
+	Events  []string `json:"events,omitempty"`
+	ZoneIDs []int64  `json:"zone_ids,omitempty"`
+}
+
+// WebhookResponse represents a webhook in API responses (never includes the secret).
+type WebhookResponse struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	ZoneIDs   []int64  `json:"zone_ids"`
+	TokenID   int64    `json:"token_id,omitempty"`
+	Enabled   bool     `json:"enabled"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// CreateWebhookResponse includes the signing secret (shown only once).
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// WebhookDeliveryResponse represents a delivery attempt in API responses.
+type WebhookDeliveryResponse struct {
+	ID            int64  `json:"id"`
+	EventID       string `json:"event_id"`
+	Event         string `json:"event"`
+	SchemaVersion int    `json:"schema_version"`
+	StatusCode    int    `json:"status_code"`
+	Success       bool   `json:"success"`
+	Error         string `json:"error,omitempty"`
+	DurationMS    int64  `json:"duration_ms"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+func toWebhookResponse(hook *storage.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    hook.Events,
+		ZoneIDs:   hook.ZoneIDs,
+		TokenID:   hook.TokenID,
+		Enabled:   hook.Enabled,
+		CreatedAt: hook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryResponse(d *storage.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:            d.ID,
+		EventID:       d.EventID,
+		Event:         d.Event,
+		SchemaVersion: d.SchemaVersion,
+		StatusCode:    d.StatusCode,
+		Success:       d.Success,
+		Error:         d.Error,
+		DurationMS:    d.DurationMS,
+	}
+	if !d.CreatedAt.IsZero() {
+		resp.CreatedAt = d.CreatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// HandleListWebhooks returns registered webhooks.
+// GET /api/webhooks
+// Admins see all webhooks; scoped tokens see only their own.
+func (h *Handler) HandleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.webhooks.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list webhooks", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list webhooks")
+		return
+	}
+
+	owner := webhookOwner(r)
+	response := make([]WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		if owner != 0 && hook.TokenID != owner {
+			continue
+		}
+		response = append(response, toWebhookResponse(hook))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleCreateWebhook registers a new webhook.
+// POST /api/webhooks
+// Body: {"url": "https://...", "secret": "optional", "events": ["optional", ...], "zone_ids": [1, ...]}
+// Webhooks created by scoped tokens are owned by the token, limited to its zones
+// and count against the per-token quota.
+func (h *Handler) HandleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+
+	hook, err := h.webhooks.Create(r.Context(), &storage.Webhook{
+		URL:     req.URL,
+		Secret:  req.Secret,
+		Events:  req.Events,
+		ZoneIDs: req.ZoneIDs,
+		TokenID: webhookOwner(r),
+		Enabled: true,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, webhooks.ErrInvalidURL), errors.Is(err, webhooks.ErrZonesRequired):
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		case errors.Is(err, webhooks.ErrZoneNotPermitted):
+			WriteError(w, http.StatusForbidden, ErrCodeZoneNotPermitted, err.Error())
+		case errors.Is(err, webhooks.ErrQuotaExceeded):
+			WriteErrorWithHint(w, http.StatusConflict, ErrCodeQuotaExceeded, err.Error(),
+				"Delete an existing webhook or ask an admin to raise WEBHOOK_MAX_PER_TOKEN.")
+		default:
+			h.logger.Error("failed to create webhook", "error", err)
+			WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to create webhook")
+		}
+		return
+	}
+
+	h.logger.Info("webhook created", "id", hook.ID, "url", hook.URL, "token_id", hook.TokenID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(CreateWebhookResponse{
+		WebhookResponse: toWebhookResponse(hook),
+		Secret:          hook.Secret, // Return secret once
+	})
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleGetWebhook returns a single webhook.
+// GET /api/webhooks/{id}
+func (h *Handler) HandleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	hook, ok := h.lookupWebhook(w, r, id)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(toWebhookResponse(hook))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteWebhook deletes a webhook and its delivery history.
+// DELETE /api/webhooks/{id}
+func (h *Handler) HandleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.lookupWebhook(w, r, id); !ok {
+		return
+	}
+
+	if err := h.webhooks.Delete(r.Context(), id); err != nil {
+		h.writeWebhookLookupError(w, err, id)
+		return
+	}
+
+	h.logger.Info("webhook deleted", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTestWebhook sends a webhook.test event synchronously and returns the delivery result.
+// POST /api/webhooks/{id}/test
+// Receiver failures are reported in the response body with status 200.
+func (h *Handler) HandleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.lookupWebhook(w, r, id); !ok {
+		return
+	}
+
+	delivery, err := h.webhooks.SendTest(r.Context(), id)
+	if err != nil {
+		h.writeWebhookLookupError(w, err, id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(toWebhookDeliveryResponse(delivery))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleListWebhookDeliveries returns recent delivery attempts for a webhook, newest first.
+// GET /api/webhooks/{id}/deliveries?limit=N
+func (h *Handler) HandleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	ctx := r.Context()
+
+	// Distinguish unknown webhooks from webhooks with no deliveries
+	if _, ok := h.lookupWebhook(w, r, id); !ok {
+		return
+	}
+
+	deliveries, err := h.webhooks.Deliveries(ctx, id, limit)
+	if err != nil {
+		h.logger.Error("failed to list webhook deliveries", "error", err, "id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list deliveries")
+		return
+	}
+
+	response := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		response[i] = toWebhookDeliveryResponse(d)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// parseWebhookID parses the {id} URL parameter, writing a 400 response on failure.
+func parseWebhookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid webhook ID", "Webhook ID must be a number.")
+		return 0, false
+	}
+	return id, true
+}
+
+// webhookOwner returns the token ID that owns webhooks created by this request,
+// or 0 for admins (including the master key), who manage all webhooks.
+func webhookOwner(r *http.Request) int64 {
+	ctx := r.Context()
+	if auth.IsAdminFromContext(ctx) {
+		return 0
+	}
+	if token := auth.TokenFromContext(ctx); token != nil {
+		return token.ID
+	}
+	return 0
+}
+
+// lookupWebhook loads a webhook the caller may access, writing an error response otherwise.
+// Scoped tokens get 404 for webhooks they do not own, so other webhooks are not revealed.
+func (h *Handler) lookupWebhook(w http.ResponseWriter, r *http.Request, id int64) (*storage.Webhook, bool) {
+	hook, err := h.webhooks.Get(r.Context(), id)
+	if err != nil {
+		h.writeWebhookLookupError(w, err, id)
+		return nil, false
+	}
+	if owner := webhookOwner(r); owner != 0 && hook.TokenID != owner {
+		h.writeWebhookLookupError(w, storage.ErrNotFound, id)
+		return nil, false
+	}
+	return hook, true
+}
+
+// writeWebhookLookupError maps storage errors for a webhook ID to API responses.
+func (h *Handler) writeWebhookLookupError(w http.ResponseWriter, err error, id int64) {
+	if errors.Is(err, storage.ErrNotFound) {
+		WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Webhook not found")
+		return
+	}
+	h.logger.Error("webhook operation failed", "error", err, "id", id)
+	WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Webhook operation failed")
+}
@@ -0,0 +1,280 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/webhooks"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+const webhookTestAdminKey = "webhook-admin-token"
+
+// newWebhookTestRouter creates an admin router with webhooks enabled and one admin token.
+func newWebhookTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetBootstrapService(auth.NewBootstrapService(store, "master-key"))
+	h.SetWebhookService(webhooks.NewService(store, logger))
+	return h.NewRouter()
+}
+
+func doWebhookRequest(t *testing.T, router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("AccessKey", webhookTestAdminKey)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhookEndpoints_Lifecycle(t *testing.T) {
+	t.Parallel()
+
+	var gotSignature string
+	var gotBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(webhook.HeaderSignature)
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	router := newWebhookTestRouter(t)
+
+	// Create
+	w := doWebhookRequest(t, router, http.MethodPost, "/api/webhooks", `{"url":"`+receiver.URL+`","events":["record.created"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created CreateWebhookResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode create response: %v", err)
+	}
+	if created.Secret == "" || created.URL != receiver.URL || !created.Enabled {
+		t.Errorf("unexpected create response: %+v", created)
+	}
+	base := "/api/webhooks/" + strconv.FormatInt(created.ID, 10)
+
+	// List and get never expose the secret
+	for _, path := range []string{"/api/webhooks", base} {
+		w = doWebhookRequest(t, router, http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", path, w.Code)
+		}
+		if bytes.Contains(w.Body.Bytes(), []byte(created.Secret)) {
+			t.Errorf("GET %s leaked the webhook secret", path)
+		}
+	}
+
+	// Test delivery
+	w = doWebhookRequest(t, router, http.MethodPost, base+"/test", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("test: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var delivery WebhookDeliveryResponse
+	if err := json.NewDecoder(w.Body).Decode(&delivery); err != nil {
+		t.Fatalf("failed to decode delivery: %v", err)
+	}
+	if !delivery.Success || delivery.Event != webhook.EventTest || delivery.SchemaVersion != webhook.SchemaVersion {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+	if err := webhook.Verify(created.Secret, gotBody, gotSignature, webhook.DefaultTolerance); err != nil {
+		t.Errorf("receiver could not verify delivery: %v", err)
+	}
+
+	// Delivery history
+	w = doWebhookRequest(t, router, http.MethodGet, base+"/deliveries?limit=5", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("deliveries: expected 200, got %d", w.Code)
+	}
+	var history []WebhookDeliveryResponse
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode history: %v", err)
+	}
+	if len(history) != 1 || history[0].EventID != delivery.EventID || history[0].CreatedAt == "" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	// Delete
+	w = doWebhookRequest(t, router, http.MethodDelete, base, "")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	w = doWebhookRequest(t, router, http.MethodGet, base, "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get after delete: expected 404, got %d", w.Code)
+	}
+}
+
+func TestWebhookEndpoints_Errors(t *testing.T) {
+	t.Parallel()
+	router := newWebhookTestRouter(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		wantCode int
+	}{
+		{"create invalid JSON", http.MethodPost, "/api/webhooks", "{", http.StatusBadRequest},
+		{"create invalid URL", http.MethodPost, "/api/webhooks", `{"url":"ftp://example.com"}`, http.StatusBadRequest},
+		{"get invalid ID", http.MethodGet, "/api/webhooks/abc", "", http.StatusBadRequest},
+		{"get unknown", http.MethodGet, "/api/webhooks/999", "", http.StatusNotFound},
+		{"delete unknown", http.MethodDelete, "/api/webhooks/999", "", http.StatusNotFound},
+		{"test unknown", http.MethodPost, "/api/webhooks/999/test", "", http.StatusNotFound},
+		{"test invalid ID", http.MethodPost, "/api/webhooks/x/test", "", http.StatusBadRequest},
+		{"deliveries unknown", http.MethodGet, "/api/webhooks/999/deliveries", "", http.StatusNotFound},
+		{"deliveries invalid limit", http.MethodGet, "/api/webhooks/1/deliveries?limit=0", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := doWebhookRequest(t, router, tt.method, tt.path, tt.body)
+			if w.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWebhookEndpoints_NotRegisteredWithoutService(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if _, err := store.CreateToken(context.Background(), "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+
+	h := NewHandler(store, new(slog.LevelVar), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w := doWebhookRequest(t, h.NewRouter(), http.MethodGet, "/api/webhooks", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when webhooks are disabled, got %d", w.Code)
+	}
+}
+
+func TestWebhookEndpoints_ScopedToken(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	if _, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey)); err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	scoped, err := store.CreateToken(ctx, "team", false, auth.HashToken("team-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+	if _, err := store.AddPermissionForToken(ctx, scoped.ID, &storage.Permission{
+		ZoneID: 10, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+	}); err != nil {
+		t.Fatalf("failed to add permission: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetWebhookService(webhooks.NewService(store, logger, webhooks.WithMaxWebhooksPerToken(1)))
+	router := h.NewRouter()
+
+	do := func(key, method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("AccessKey", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Admin webhook, invisible to the scoped token
+	w := do(webhookTestAdminKey, http.MethodPost, "/api/webhooks", `{"url":"https://ops.example.com"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("admin create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var adminHook CreateWebhookResponse
+	_ = json.NewDecoder(w.Body).Decode(&adminHook)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"zones required", `{"url":"https://team.example.com"}`, http.StatusBadRequest},
+		{"zone not permitted", `{"url":"https://team.example.com","zone_ids":[11]}`, http.StatusForbidden},
+		{"permitted zone", `{"url":"https://team.example.com","zone_ids":[10]}`, http.StatusCreated},
+		{"quota exceeded", `{"url":"https://team.example.com/2","zone_ids":[10]}`, http.StatusConflict},
+	}
+	var owned CreateWebhookResponse
+	for _, tt := range tests {
+		w := do("team-token", http.MethodPost, "/api/webhooks", tt.body)
+		if w.Code != tt.wantCode {
+			t.Fatalf("%s: expected %d, got %d: %s", tt.name, tt.wantCode, w.Code, w.Body.String())
+		}
+		if w.Code == http.StatusCreated {
+			_ = json.NewDecoder(w.Body).Decode(&owned)
+		}
+	}
+	if owned.TokenID != scoped.ID || len(owned.ZoneIDs) != 1 || owned.ZoneIDs[0] != 10 {
+		t.Errorf("unexpected owned webhook: %+v", owned)
+	}
+
+	// Scoped token lists only its own webhooks
+	w = do("team-token", http.MethodGet, "/api/webhooks", "")
+	var listed []WebhookResponse
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatalf("failed to decode list: %v", err)
+	}
+	if len(listed) != 1 || listed[0].ID != owned.ID {
+		t.Errorf("expected only the owned webhook, got %+v", listed)
+	}
+
+	// Other webhooks look like they do not exist
+	adminPath := "/api/webhooks/" + strconv.FormatInt(adminHook.ID, 10)
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if w := do("team-token", method, adminPath, ""); w.Code != http.StatusNotFound {
+			t.Errorf("%s admin webhook as scoped token: expected 404, got %d", method, w.Code)
+		}
+	}
+
+	// Admin sees both
+	w = do(webhookTestAdminKey, http.MethodGet, "/api/webhooks", "")
+	listed = nil
+	_ = json.NewDecoder(w.Body).Decode(&listed)
+	if len(listed) != 2 {
+		t.Errorf("expected admin to see 2 webhooks, got %d", len(listed))
+	}
+
+	// Owner can delete its webhook
+	if w := do("team-token", http.MethodDelete, "/api/webhooks/"+strconv.FormatInt(owned.ID, 10), ""); w.Code != http.StatusNoContent {
+		t.Errorf("owner delete: expected 204, got %d", w.Code)
+	}
+}
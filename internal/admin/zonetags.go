@@ -0,0 +1,257 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/zonetags"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// SetZoneTags enables the zone tag and tag permission endpoints.
+// Must be called before NewRouter; the routes are not registered otherwise.
+func (h *Handler) SetZoneTags(s *zonetags.Syncer) {
+	h.zoneTags = s
+}
+
+// ZoneTagResponse lists the zones carrying a tag.
+type ZoneTagResponse struct {
+	Tag   string       `json:"tag"`
+	Zones []TaggedZone `json:"zones"`
+}
+
+// TaggedZone is a zone in a ZoneTagResponse.
+type TaggedZone struct {
+	ID     int64  `json:"id"`
+	Domain string `json:"domain"`
+}
+
+// AddTagPermissionRequest is the request body for POST /api/tokens/{id}/tag-permissions.
+type AddTagPermissionRequest struct {
+	Tag            string   `json:"tag"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types"`
+}
+
+// TagPermissionResponse represents a tag permission in API responses.
+type TagPermissionResponse struct {
+	ID             int64    `json:"id"`
+	Tag            string   `json:"tag"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+func toTagPermissionResponse(p *storage.TagPermission) TagPermissionResponse {
+	return TagPermissionResponse{
+		ID:             p.ID,
+		Tag:            p.Tag,
+		AllowedActions: p.AllowedActions,
+		RecordTypes:    p.RecordTypes,
+		CreatedAt:      p.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// HandleListZoneTags returns each tag with the zones carrying it, as of the
+// last sync.
+// GET /api/zone-tags
+func (h *Handler) HandleListZoneTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.zoneTags.ListZoneTags(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list zone tags", "error", err)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list zone tags")
+		return
+	}
+
+	// Tags are ordered by tag, so zones of one tag are adjacent
+	response := make([]ZoneTagResponse, 0)
+	for _, t := range tags {
+		if n := len(response); n == 0 || response[n-1].Tag != t.Tag {
+			response = append(response, ZoneTagResponse{Tag: t.Tag, Zones: []TaggedZone{}})
+		}
+		last := &response[len(response)-1]
+		last.Zones = append(last.Zones, TaggedZone{ID: t.ZoneID, Domain: t.Domain})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleSyncZoneTags re-tags zones now instead of waiting for the next sync.
+// POST /api/zone-tags/sync
+func (h *Handler) HandleSyncZoneTags(w http.ResponseWriter, r *http.Request) {
+	result, err := h.zoneTags.Sync(r.Context())
+	if err != nil {
+		if errors.Is(err, zonetags.ErrNotConfigured) {
+			WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Zone tag sync is not configured",
+				"Set ZONE_TAG_RULES or ZONE_TAG_SOURCE.")
+			return
+		}
+		h.logger.Error("failed to sync zone tags", "error", err)
+		WriteError(w, http.StatusBadGateway, ErrCodeInternalError, "Failed to sync zone tags")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(result)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleListTagPermissions returns a token's tag permissions.
+// GET /api/tokens/{id}/tag-permissions
+func (h *Handler) HandleListTagPermissions(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.tagPermissionToken(w, r)
+	if !ok {
+		return
+	}
+
+	perms, err := h.zoneTags.ListTagPermissions(r.Context(), token.ID)
+	if err != nil {
+		h.logger.Error("failed to list tag permissions", "error", err, "token_id", token.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to list tag permissions")
+		return
+	}
+
+	response := make([]TagPermissionResponse, 0, len(perms))
+	for _, p := range perms {
+		response = append(response, toTagPermissionResponse(p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encErr := json.NewEncoder(w).Encode(response)
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleAddTagPermission grants a token a DNS zone permission on every zone
+// with a tag, including zones tagged by later syncs.
+// POST /api/tokens/{id}/tag-permissions
+// Body: {"tag": "prod", "allowed_actions": [...], "record_types": [...]}
+func (h *Handler) HandleAddTagPermission(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.tagPermissionToken(w, r)
+	if !ok {
+		return
+	}
+	if token.IsAdmin {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+			"Admin tokens do not use zone permissions",
+			"Admin tokens have full access. Permissions are only for scoped tokens.")
+		return
+	}
+
+	var req AddTagPermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid JSON in request body")
+		return
+	}
+
+	req.Tag = strings.ToLower(strings.TrimSpace(req.Tag))
+	if !zonetags.ValidTag(req.Tag) {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid tag",
+			"Tags use lowercase letters, digits, '_', '.' and '-'.")
+		return
+	}
+	if len(req.AllowedActions) == 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one action is required")
+		return
+	}
+	if len(req.RecordTypes) == 0 {
+		WriteError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "At least one record type is required")
+		return
+	}
+	recordTypes, ok := normalizeRecordTypes(w, req.RecordTypes)
+	if !ok {
+		return
+	}
+
+	created, err := h.zoneTags.AddTagPermission(r.Context(), &storage.TagPermission{
+		TokenID:        token.ID,
+		Tag:            req.Tag,
+		AllowedActions: req.AllowedActions,
+		RecordTypes:    recordTypes,
+	})
+	if err != nil {
+		h.logger.Error("failed to add tag permission", "error", err, "token_id", token.ID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to add tag permission")
+		return
+	}
+
+	h.logger.Info("tag permission added", "token_id", token.ID, "tag_permission_id", created.ID, "tag", created.Tag)
+	h.publish(r.Context(), webhook.EventPermissionAdded, map[string]any{
+		"token_id": token.ID, "token_name": token.Name, "tag_permission_id": created.ID,
+		"resource": "tag", "tag": created.Tag,
+		"allowed_actions": created.AllowedActions, "record_types": created.RecordTypes,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	encErr := json.NewEncoder(w).Encode(toTagPermissionResponse(created))
+	if encErr != nil {
+		_ = encErr
+	}
+}
+
+// HandleDeleteTagPermission removes a tag permission and the zone
+// permissions it created.
+// DELETE /api/tokens/{id}/tag-permissions/{tid}
+func (h *Handler) HandleDeleteTagPermission(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.tagPermissionToken(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "tid"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid tag permission ID", "Tag permission ID must be a number.")
+		return
+	}
+
+	if err := h.zoneTags.DeleteTagPermission(r.Context(), token.ID, id); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Tag permission not found")
+			return
+		}
+		h.logger.Error("failed to delete tag permission", "error", err, "token_id", token.ID, "tag_permission_id", id)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to delete tag permission")
+		return
+	}
+
+	h.logger.Info("tag permission deleted", "token_id", token.ID, "tag_permission_id", id)
+	h.publish(r.Context(), webhook.EventPermissionRemoved, map[string]any{
+		"token_id": token.ID, "token_name": token.Name, "tag_permission_id": id,
+	})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tagPermissionToken loads the token named in the URL, writing an error
+// response if it is invalid or missing.
+func (h *Handler) tagPermissionToken(w http.ResponseWriter, r *http.Request) (*storage.Token, bool) {
+	tokenID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		WriteErrorWithHint(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid token ID", "Token ID must be a number.")
+		return nil, false
+	}
+
+	token, err := h.storage.GetTokenByID(r.Context(), tokenID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			WriteError(w, http.StatusNotFound, ErrCodeNotFound, "Token not found")
+			return nil, false
+		}
+		h.logger.Error("failed to get token", "error", err, "id", tokenID)
+		WriteError(w, http.StatusInternalServerError, ErrCodeInternalError, "Failed to get token")
+		return nil, false
+	}
+	return token, true
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/zonetags"
+)
+
+func TestZoneTagEndpoints(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+	admin, err := store.CreateToken(ctx, "admin", true, auth.HashToken(webhookTestAdminKey))
+	if err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	scoped, err := store.CreateToken(ctx, "scoped", false, auth.HashToken("scoped-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+	if err := store.ReplaceZoneTags(ctx, []storage.ZoneTag{
+		{ZoneID: 1, Domain: "a.example.com", Tag: "prod"},
+		{ZoneID: 2, Domain: "b.example.com", Tag: "prod"},
+		{ZoneID: 3, Domain: "c.example.com", Tag: "dev"},
+	}); err != nil {
+		t.Fatalf("failed to store zone tags: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewHandler(store, new(slog.LevelVar), logger)
+	h.SetZoneTags(zonetags.New(store, nil, logger))
+	router := h.NewRouter()
+
+	w := doWebhookRequest(t, router, http.MethodGet, "/api/zone-tags", "")
+	var tags []ZoneTagResponse
+	if err := json.NewDecoder(w.Body).Decode(&tags); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Tag != "dev" || tags[1].Tag != "prod" || len(tags[1].Zones) != 2 {
+		t.Errorf("unexpected zone tags: %+v", tags)
+	}
+
+	if w := doWebhookRequest(t, router, http.MethodPost, "/api/zone-tags/sync", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 when sync is not configured, got %d: %s", w.Code, w.Body.String())
+	}
+
+	base := "/api/tokens/" + strconv.FormatInt(scoped.ID, 10) + "/tag-permissions"
+	w = doWebhookRequest(t, router, http.MethodPost, base, `{"tag":"Prod","allowed_actions":["list_records"],"record_types":["txt"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created TagPermissionResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.Tag != "prod" || len(created.RecordTypes) != 1 || created.RecordTypes[0] != "TXT" {
+		t.Errorf("unexpected tag permission: %+v", created)
+	}
+
+	perms, err := store.GetPermissionsForToken(ctx, scoped.ID)
+	if err != nil {
+		t.Fatalf("failed to get permissions: %v", err)
+	}
+	if len(perms) != 2 || perms[0].TagPermissionID != created.ID {
+		t.Errorf("expected a permission per tagged zone, got %+v", perms)
+	}
+
+	for name, tc := range map[string]struct {
+		path string
+		body string
+		want int
+	}{
+		"invalid tag":      {base, `{"tag":"bad tag","allowed_actions":["list_records"],"record_types":["TXT"]}`, http.StatusBadRequest},
+		"no actions":       {base, `{"tag":"prod","record_types":["TXT"]}`, http.StatusBadRequest},
+		"no record types":  {base, `{"tag":"prod","allowed_actions":["list_records"]}`, http.StatusBadRequest},
+		"invalid JSON":     {base, `{`, http.StatusBadRequest},
+		"admin token":      {"/api/tokens/" + strconv.FormatInt(admin.ID, 10) + "/tag-permissions", `{"tag":"prod","allowed_actions":["list_records"],"record_types":["TXT"]}`, http.StatusBadRequest},
+		"token not found":  {"/api/tokens/999/tag-permissions", `{}`, http.StatusNotFound},
+		"invalid token ID": {"/api/tokens/abc/tag-permissions", `{}`, http.StatusBadRequest},
+	} {
+		if w := doWebhookRequest(t, router, http.MethodPost, tc.path, tc.body); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d: %s", name, tc.want, w.Code, w.Body.String())
+		}
+	}
+
+	w = doWebhookRequest(t, router, http.MethodGet, base, "")
+	var list []TagPermissionResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("unexpected tag permissions: %+v", list)
+	}
+
+	if w := doWebhookRequest(t, router, http.MethodDelete, base+"/"+strconv.FormatInt(created.ID, 10), ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if perms, _ := store.GetPermissionsForToken(ctx, scoped.ID); len(perms) != 0 {
+		t.Errorf("expected tag permission's permissions to be removed, got %+v", perms)
+	}
+	if w := doWebhookRequest(t, router, http.MethodDelete, base+"/"+strconv.FormatInt(created.ID, 10), ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for deleted tag permission, got %d", w.Code)
+	}
+}
@@ -0,0 +1,213 @@
+// Package audit records authenticated proxy and admin API requests in the audit log.
+//
+// The Middleware runs between authentication and permission checks, so denied
+// requests are recorded alongside successful ones. AdminMiddleware does the same
+// for the admin API. Entries older than the retention period are pruned by Run.
+// WithStream additionally writes each entry as a JSON line for SIEM ingestion.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/middleware"
+	"github.com/sipico/bunny-api-proxy/internal/outbox"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// retention is how long audit entries are kept.
+	retention = 30 * 24 * time.Hour
+
+	// pruneInterval is how often expired entries are removed.
+	pruneInterval = time.Hour
+
+	// masterKeyName is recorded as the token name for master key requests.
+	masterKeyName = "master-key"
+)
+
+// ActionAdmin is the action recorded for admin API requests.
+const ActionAdmin = "admin"
+
+// Store defines the storage operations needed by the audit logger.
+type Store interface {
+	AppendAuditEntry(ctx context.Context, e *storage.AuditEntry) (*storage.AuditEntry, error)
+	AppendAuditEntryWithEvents(ctx context.Context, e *storage.AuditEntry, events []*storage.OutboxEvent) (*storage.AuditEntry, error)
+	PruneAuditEntries(ctx context.Context, before time.Time) (int64, error)
+	ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]*storage.AuditEntry, error)
+}
+
+// Option configures a Logger.
+type Option func(*Logger)
+
+// WithWriteErrorHandler calls fn with every error from writing an audit
+// entry, so storage failures can be detected from request traffic.
+func WithWriteErrorHandler(fn func(error)) Option {
+	return func(l *Logger) {
+		l.onWriteError = fn
+	}
+}
+
+// Logger writes audit entries for proxied requests.
+type Logger struct {
+	store        Store
+	logger       *slog.Logger
+	now          func() time.Time
+	onWriteError func(error)
+	stream       *stream
+}
+
+// New creates an audit logger.
+func New(store Store, logger *slog.Logger, opts ...Option) *Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	l := &Logger{
+		store:  store,
+		logger: logger,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Middleware records every request that reached it once the response is written.
+// It must run after auth.Authenticator.Authenticate; unauthenticated requests never reach it.
+// Failing to record an entry is logged and never fails the request.
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := newEntry(r)
+		// ParseRequest restores the body it reads, so handlers still see it.
+		if req, err := auth.ParseRequest(r); err == nil {
+			entry.Action = string(req.Action)
+			entry.ZoneID = req.ZoneID
+			entry.RecordType = req.RecordType
+		}
+		l.serveAndRecord(w, r, next, entry)
+	})
+}
+
+// AdminMiddleware records every admin API request that reached it with
+// ActionAdmin, once the response is written. It must run after the admin
+// token authentication; the full request path is recorded.
+func (l *Logger) AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := newEntry(r)
+		entry.Action = ActionAdmin
+		l.serveAndRecord(w, r, next, entry)
+	})
+}
+
+// List returns audit entries matching the filter, newest first.
+func (l *Logger) List(ctx context.Context, filter storage.AuditFilter) ([]*storage.AuditEntry, error) {
+	return l.store.ListAuditEntries(ctx, filter)
+}
+
+// newEntry starts an audit entry for an authenticated request.
+func newEntry(r *http.Request) *storage.AuditEntry {
+	ctx := r.Context()
+	entry := &storage.AuditEntry{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		RequestID: middleware.GetRequestID(ctx),
+	}
+	if token := auth.TokenFromContext(ctx); token != nil {
+		entry.TokenID = token.ID
+		entry.TokenName = token.Name
+	} else if auth.IsMasterKeyFromContext(ctx) {
+		entry.TokenName = masterKeyName
+	}
+	return entry
+}
+
+// serveAndRecord calls next and then stores entry with the response status,
+// together with the events published while serving it. Failing to record an
+// entry is logged and never fails the request.
+func (l *Logger) serveAndRecord(w http.ResponseWriter, r *http.Request, next http.Handler, entry *storage.AuditEntry) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	ctx, pending := outbox.WithPending(r.Context())
+	next.ServeHTTP(rec, r.WithContext(ctx))
+
+	entry.Status = rec.status
+	entry.CreatedAt = l.now()
+	if l.stream != nil {
+		if err := l.stream.write(entry); err != nil {
+			l.logger.Error("failed to write audit stream", "error", err, "path", entry.Path, "token_id", entry.TokenID)
+		}
+	}
+	// Record the entry even if the client went away mid-request.
+	var err error
+	if events := pending.Take(); len(events) > 0 {
+		_, err = l.store.AppendAuditEntryWithEvents(context.WithoutCancel(ctx), entry, events)
+	} else {
+		_, err = l.store.AppendAuditEntry(context.WithoutCancel(ctx), entry)
+	}
+	if err != nil {
+		l.logger.Error("failed to write audit entry", "error", err, "path", entry.Path, "token_id", entry.TokenID)
+		if l.onWriteError != nil {
+			l.onWriteError(err)
+		}
+	}
+}
+
+// Run prunes expired audit entries until ctx is cancelled.
+func (l *Logger) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		l.prune(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune removes entries older than the retention period.
+func (l *Logger) prune(ctx context.Context) {
+	n, err := l.store.PruneAuditEntries(ctx, l.now().Add(-retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			l.logger.Error("failed to prune audit log", "error", err)
+		}
+		return
+	}
+	if n > 0 {
+		l.logger.Debug("pruned audit entries", "count", n)
+	}
+}
+
+// statusRecorder captures the response status code.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader captures the first status code written.
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write marks the header as written with the default status.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
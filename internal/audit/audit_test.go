@@ -0,0 +1,232 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/outbox"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// recordingStore captures appended entries.
+func recordingStore() (*mockstore.MockStorage, func() []*storage.AuditEntry) {
+	var mu sync.Mutex
+	var entries []*storage.AuditEntry
+	store := &mockstore.MockStorage{
+		AppendAuditEntryFunc: func(_ context.Context, e *storage.AuditEntry) (*storage.AuditEntry, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			entries = append(entries, e)
+			return e, nil
+		},
+	}
+	return store, func() []*storage.AuditEntry {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*storage.AuditEntry(nil), entries...)
+	}
+}
+
+func TestMiddleware_RecordsRequest(t *testing.T) {
+	t.Parallel()
+	store, entries := recordingStore()
+	l := New(store, testLogger())
+
+	var body string
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusForbidden)
+		w.WriteHeader(http.StatusOK) // ignored
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/dnszone/5/records", strings.NewReader(`{"Type":3}`))
+	r = r.WithContext(auth.WithToken(r.Context(), &storage.Token{ID: 7, Name: "acme"}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if body != `{"Type":3}` {
+		t.Errorf("handler should still see the request body, got %q", body)
+	}
+	got := entries()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(got))
+	}
+	e := got[0]
+	if e.TokenID != 7 || e.TokenName != "acme" || e.Action != string(auth.ActionAddRecord) || e.ZoneID != 5 ||
+		e.RecordType != "TXT" || e.Method != http.MethodPost || e.Path != "/dnszone/5/records" || e.Status != http.StatusForbidden {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestAdminMiddleware_RecordsRequest(t *testing.T) {
+	t.Parallel()
+	store, entries := recordingStore()
+	l := New(store, testLogger())
+
+	handler := l.AdminMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/admin/api/tokens", strings.NewReader(`{"name":"ci"}`))
+	r = r.WithContext(auth.WithToken(r.Context(), &storage.Token{ID: 1, Name: "root", IsAdmin: true}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	got := entries()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(got))
+	}
+	e := got[0]
+	if e.TokenID != 1 || e.Action != ActionAdmin || e.ZoneID != 0 || e.Method != http.MethodPost ||
+		e.Path != "/admin/api/tokens" || e.Status != http.StatusCreated {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestMiddleware_RecordsEventsWithEntry(t *testing.T) {
+	t.Parallel()
+	var gotEntry *storage.AuditEntry
+	var gotEvents []*storage.OutboxEvent
+	store := &mockstore.MockStorage{
+		AppendAuditEntryFunc: func(context.Context, *storage.AuditEntry) (*storage.AuditEntry, error) {
+			t.Error("entry with events should be written with AppendAuditEntryWithEvents")
+			return nil, nil
+		},
+		AppendAuditEntryWithEventsFunc: func(_ context.Context, e *storage.AuditEntry, events []*storage.OutboxEvent) (*storage.AuditEntry, error) {
+			gotEntry, gotEvents = e, events
+			return e, nil
+		},
+	}
+	l := New(store, testLogger())
+	events := outbox.New(store, testLogger())
+
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := events.Publish(r.Context(), "record.created", map[string]any{"zone_id": 5}); err != nil {
+			t.Errorf("Publish() error = %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	r := httptest.NewRequest(http.MethodPut, "/dnszone/5/records", strings.NewReader(`{"Type":3}`))
+	r = r.WithContext(auth.WithToken(r.Context(), &storage.Token{ID: 7, Name: "acme"}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if gotEntry == nil || gotEntry.Status != http.StatusCreated {
+		t.Fatalf("unexpected entry: %+v", gotEntry)
+	}
+	if len(gotEvents) != 1 || gotEvents[0].Event != "record.created" {
+		t.Errorf("unexpected events: %+v", gotEvents)
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	var gotFilter storage.AuditFilter
+	store := &mockstore.MockStorage{
+		ListAuditEntriesFunc: func(_ context.Context, filter storage.AuditFilter) ([]*storage.AuditEntry, error) {
+			gotFilter = filter
+			return []*storage.AuditEntry{{ID: 3}}, nil
+		},
+	}
+
+	got, err := New(store, testLogger()).List(context.Background(), storage.AuditFilter{ZoneID: 5, Limit: 10})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(got) != 1 || gotFilter.ZoneID != 5 || gotFilter.Limit != 10 {
+		t.Errorf("expected filter to be passed through, got %+v and %d entries", gotFilter, len(got))
+	}
+}
+
+func TestMiddleware_MasterKeyAndUnknownRoute(t *testing.T) {
+	t.Parallel()
+	store, entries := recordingStore()
+	l := New(store, testLogger())
+
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+	r = r.WithContext(auth.WithMasterKey(r.Context(), true))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	got := entries()
+	if len(got) != 1 || got[0].TokenID != 0 || got[0].TokenName != masterKeyName || got[0].Action != "" || got[0].Status != http.StatusOK {
+		t.Errorf("unexpected entries: %+v", got)
+	}
+}
+
+func TestMiddleware_StoreFailureDoesNotFailRequest(t *testing.T) {
+	t.Parallel()
+	store := &mockstore.MockStorage{
+		AppendAuditEntryFunc: func(context.Context, *storage.AuditEntry) (*storage.AuditEntry, error) {
+			return nil, errors.New("disk full")
+		},
+	}
+	var reported []error
+	logger := New(store, testLogger(), WithWriteErrorHandler(func(err error) { reported = append(reported, err) }))
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dnszone", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected handler status to pass through, got %d", w.Code)
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected the write error to be reported once, got %d", len(reported))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	var cutoff time.Time
+	store := &mockstore.MockStorage{
+		PruneAuditEntriesFunc: func(_ context.Context, before time.Time) (int64, error) {
+			cutoff = before
+			return 3, nil
+		},
+	}
+
+	l := New(store, testLogger())
+	l.now = func() time.Time { return now }
+	l.prune(context.Background())
+
+	if !cutoff.Equal(now.Add(-retention)) {
+		t.Errorf("unexpected prune cutoff %v", cutoff)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		New(&mockstore.MockStorage{}, testLogger()).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// StreamSchemaVersion is the version of the audit stream record format.
+// Fields may be added within a version; it changes when a field is removed,
+// renamed or changes meaning.
+const StreamSchemaVersion = 1
+
+// streamLogType marks audit stream lines, so they can be told apart from
+// application logs written to the same output.
+const streamLogType = "audit"
+
+// Outcomes recorded in the audit stream.
+const (
+	OutcomeSuccess = "success" // 1xx-3xx
+	OutcomeDenied  = "denied"  // 401 and 403
+	OutcomeFailure = "failure" // any other 4xx or 5xx
+)
+
+// StreamRecord is one line of the audit stream. Every field is always
+// present, so parsers can rely on the schema; unset values are zero.
+type StreamRecord struct {
+	SchemaVersion int    `json:"schema_version"`
+	LogType       string `json:"log_type"`
+	Time          string `json:"time"` // RFC 3339 with nanoseconds, UTC
+	InstanceID    string `json:"instance_id"`
+	RequestID     string `json:"request_id"`
+	TokenID       int64  `json:"token_id"` // 0 for the master key
+	TokenName     string `json:"token_name"`
+	Action        string `json:"action"` // "admin" for admin API requests
+	ZoneID        int64  `json:"zone_id"`
+	RecordType    string `json:"record_type"`
+	Method        string `json:"method"`
+	Path          string `json:"path"`
+	Status        int    `json:"status"`
+	Outcome       string `json:"outcome"`
+}
+
+// stream writes audit entries as JSON lines.
+type stream struct {
+	mu         sync.Mutex
+	w          io.Writer
+	instanceID string
+}
+
+// WithStream also writes every entry to w as one JSON line in the
+// StreamRecord format, for SIEM ingestion. The line is written before the
+// entry is stored, so it is not lost when storage fails.
+func WithStream(w io.Writer, instanceID string) Option {
+	return func(l *Logger) {
+		l.stream = &stream{w: w, instanceID: instanceID}
+	}
+}
+
+// write writes one entry. Lines are written whole, so concurrent requests
+// never interleave.
+func (s *stream) write(e *storage.AuditEntry) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(newStreamRecord(e, s.instanceID)); err != nil { // coverage-ignore: the record always encodes
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+// newStreamRecord converts an audit entry to the stream format.
+func newStreamRecord(e *storage.AuditEntry, instanceID string) StreamRecord {
+	return StreamRecord{
+		SchemaVersion: StreamSchemaVersion,
+		LogType:       streamLogType,
+		Time:          e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		InstanceID:    instanceID,
+		RequestID:     e.RequestID,
+		TokenID:       e.TokenID,
+		TokenName:     e.TokenName,
+		Action:        e.Action,
+		ZoneID:        e.ZoneID,
+		RecordType:    e.RecordType,
+		Method:        e.Method,
+		Path:          e.Path,
+		Status:        e.Status,
+		Outcome:       outcome(e.Status),
+	}
+}
+
+// outcome classifies a response status.
+func outcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return OutcomeDenied
+	case status >= 400:
+		return OutcomeFailure
+	default:
+		return OutcomeSuccess
+	}
+}
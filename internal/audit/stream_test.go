@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestStream_WritesRecords(t *testing.T) {
+	t.Parallel()
+	store, _ := recordingStore()
+	out := &syncBuffer{}
+	l := New(store, testLogger(), WithStream(out, "instance-1"))
+	l.now = func() time.Time { return time.Date(2026, 1, 15, 9, 30, 0, 0, time.FixedZone("CET", 3600)) }
+
+	for _, status := range []int{http.StatusCreated, http.StatusForbidden, http.StatusBadGateway} {
+		handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(status)
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/dnszone/5/records", strings.NewReader(`{"Type":3}`))
+		r = r.WithContext(auth.WithToken(r.Context(), &storage.Token{ID: 7, Name: "acme"}))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var records []StreamRecord
+	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	for scanner.Scan() {
+		var rec StreamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid stream line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d: %s", len(records), out.String())
+	}
+
+	want := StreamRecord{
+		SchemaVersion: StreamSchemaVersion,
+		LogType:       "audit",
+		Time:          "2026-01-15T08:30:00Z",
+		InstanceID:    "instance-1",
+		TokenID:       7,
+		TokenName:     "acme",
+		Action:        string(auth.ActionAddRecord),
+		ZoneID:        5,
+		RecordType:    "TXT",
+		Method:        http.MethodPost,
+		Path:          "/dnszone/5/records",
+		Status:        http.StatusCreated,
+		Outcome:       OutcomeSuccess,
+	}
+	if records[0] != want {
+		t.Errorf("record = %+v, want %+v", records[0], want)
+	}
+	if records[1].Outcome != OutcomeDenied || records[2].Outcome != OutcomeFailure {
+		t.Errorf("unexpected outcomes %q, %q", records[1].Outcome, records[2].Outcome)
+	}
+}
+
+func TestStream_WrittenWhenStoreFails(t *testing.T) {
+	t.Parallel()
+	store := &mockstore.MockStorage{AppendAuditEntryFunc: func(context.Context, *storage.AuditEntry) (*storage.AuditEntry, error) {
+		return nil, errors.New("disk full")
+	}}
+	out := &syncBuffer{}
+	l := New(store, testLogger(), WithStream(out, ""))
+
+	handler := l.AdminMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/tokens", nil))
+
+	if !strings.Contains(out.String(), `"action":"admin"`) {
+		t.Errorf("expected the entry in the stream, got %q", out.String())
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestStream_WriteFailureDoesNotFailRequest(t *testing.T) {
+	t.Parallel()
+	store, entries := recordingStore()
+	l := New(store, testLogger(), WithStream(failingWriter{}, ""))
+
+	w := httptest.NewRecorder()
+	l.AdminMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/tokens/1", nil))
+
+	if w.Code != http.StatusNoContent || len(entries()) != 1 {
+		t.Errorf("expected request to succeed and be stored, got %d with %d entries", w.Code, len(entries()))
+	}
+}
@@ -9,35 +9,112 @@ import (
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // URL patterns for DNS API endpoints (matching bunny.net API paths)
 var (
 	listZonesPattern         = regexp.MustCompile(`^/dnszone/?$`)
 	getZonePattern           = regexp.MustCompile(`^/dnszone/(\d+)/?$`)
+	findZoneByNamePattern    = regexp.MustCompile(`^/dnszone/byname/[^/]+/?$`)
 	updateZonePattern        = regexp.MustCompile(`^/dnszone/(\d+)/?$`)
 	recordsPattern           = regexp.MustCompile(`^/dnszone/(\d+)/records/?$`)
 	updateRecordPattern      = regexp.MustCompile(`^/dnszone/(\d+)/records/(\d+)/?$`)
 	deleteRecordPattern      = regexp.MustCompile(`^/dnszone/(\d+)/records/(\d+)/?$`)
+	recordMetaPattern        = regexp.MustCompile(`^/dnszone/(\d+)/records/(\d+)/meta/?$`)
+	batchRecordsPattern      = regexp.MustCompile(`^/dnszone/(\d+)/records/batch/?$`)
 	checkAvailabilityPattern = regexp.MustCompile(`^/dnszone/checkavailability/?$`)
 	importRecordsPattern     = regexp.MustCompile(`^/dnszone/(\d+)/import/?$`)
 	exportRecordsPattern     = regexp.MustCompile(`^/dnszone/(\d+)/export/?$`)
+	diffRecordsPattern       = regexp.MustCompile(`^/dnszone/(\d+)/diff/?$`)
+	syncRecordsPattern       = regexp.MustCompile(`^/dnszone/(\d+)/sync/?$`)
 	dnssecPattern            = regexp.MustCompile(`^/dnszone/(\d+)/dnssec/?$`)
 	issueCertificatePattern  = regexp.MustCompile(`^/dnszone/(\d+)/certificate/issue/?$`)
 	statisticsPattern        = regexp.MustCompile(`^/dnszone/(\d+)/statistics/?$`)
+	scheduledPattern         = regexp.MustCompile(`^/dnszone/(\d+)/scheduled/?$`)
+	scheduledChangePattern   = regexp.MustCompile(`^/dnszone/(\d+)/scheduled/(\d+)/?$`)
+	statisticsSummaryPattern = regexp.MustCompile(`^/dnszone/statistics/?$`)
 	scanTriggerPattern       = regexp.MustCompile(`^/dnszone/records/scan/?$`)
 	scanResultPattern        = regexp.MustCompile(`^/dnszone/(\d+)/records/scan/?$`)
+	accountStatsPattern      = regexp.MustCompile(`^/statistics/?$`)
+	billingPattern           = regexp.MustCompile(`^/billing(/summary)?/?$`)
+	searchRecordsPattern     = regexp.MustCompile(`^/search/records/?$`)
+	exportZonesPattern       = regexp.MustCompile(`^/export/?$`)
+	myActivityPattern        = regexp.MustCompile(`^/api/my/activity/?$`)
+	routesPattern            = regexp.MustCompile(`^/api/routes/?$`)
+	jobPattern               = regexp.MustCompile(`^/jobs/\d+/?$`)
+	listPullZonesPattern     = regexp.MustCompile(`^/pullzone/?$`)
+	pullZonePattern          = regexp.MustCompile(`^/pullzone/(\d+)/?$`)
+	purgePullZonePattern     = regexp.MustCompile(`^/pullzone/(\d+)/purgeCache/?$`)
+	listStorageZonesPattern  = regexp.MustCompile(`^/storagezone/?$`)
+	storageZonePattern       = regexp.MustCompile(`^/storagezone/(\d+)/?$`)
+	resetPasswordPattern     = regexp.MustCompile(`^/storagezone/(\d+)/resetPassword/?$`)
 )
 
 // ParseRequest extracts action, zone ID, and record type from HTTP request.
 func ParseRequest(r *http.Request) (*Request, error) {
 	path := r.URL.Path
 
+	// GET /statistics - account statistics (admin only)
+	if r.Method == http.MethodGet && accountStatsPattern.MatchString(path) {
+		return &Request{Action: ActionGetAccountStatistics}, nil
+	}
+
+	// GET /billing and /billing/summary - account billing (admin only)
+	if r.Method == http.MethodGet && billingPattern.MatchString(path) {
+		return &Request{Action: ActionGetBilling}, nil
+	}
+
+	// GET /search/records - search records across permitted zones
+	if r.Method == http.MethodGet && searchRecordsPattern.MatchString(path) {
+		return &Request{Action: ActionSearchRecords}, nil
+	}
+
+	// GET /export - multi-zone export archive
+	if r.Method == http.MethodGet && exportZonesPattern.MatchString(path) {
+		return &Request{Action: ActionExportZones}, nil
+	}
+
+	// GET /api/my/activity - the caller's own audit entries
+	if r.Method == http.MethodGet && myActivityPattern.MatchString(path) {
+		return &Request{Action: ActionGetMyActivity}, nil
+	}
+
+	// GET /api/routes - the proxy's route table
+	if r.Method == http.MethodGet && routesPattern.MatchString(path) {
+		return &Request{Action: ActionListRoutes}, nil
+	}
+
+	// GET /jobs/{id} - status of one of the caller's background jobs
+	if r.Method == http.MethodGet && jobPattern.MatchString(path) {
+		return &Request{Action: ActionGetJob}, nil
+	}
+
+	// Pull zone endpoints
+	if strings.HasPrefix(path, "/pullzone") {
+		return parsePullZoneRequest(r.Method, path)
+	}
+
+	// Storage zone endpoints
+	if strings.HasPrefix(path, "/storagezone") {
+		return parseStorageZoneRequest(r.Method, path)
+	}
+
 	// GET /dnszone - list zones
 	if r.Method == http.MethodGet && listZonesPattern.MatchString(path) {
 		return &Request{Action: ActionListZones}, nil
 	}
 
+	// GET /dnszone/statistics - statistics summed across permitted zones
+	if r.Method == http.MethodGet && statisticsSummaryPattern.MatchString(path) {
+		return &Request{Action: ActionGetStatisticsSummary}, nil
+	}
+
+	// GET /dnszone/byname/{domain} - find the zone a hostname belongs to
+	if r.Method == http.MethodGet && findZoneByNamePattern.MatchString(path) {
+		return &Request{Action: ActionFindZoneByName}, nil
+	}
+
 	// GET /dnszone/{id} - get zone
 	if r.Method == http.MethodGet {
 		if matches := getZonePattern.FindStringSubmatch(path); matches != nil {
@@ -69,8 +146,23 @@ func ParseRequest(r *http.Request) (*Request, error) {
 		}
 		return &Request{Action: ActionListRecords, ZoneID: zoneID}, nil
 	}
+
+	// GET /dnszone/{id}/records/{rid} - get record
+	// Record type restrictions are enforced by the handler, which looks up the record.
+	if r.Method == http.MethodGet {
+		if matches := updateRecordPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			if _, err := strconv.ParseInt(matches[2], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid record ID: %w", err)
+			}
+			return &Request{Action: ActionGetRecord, ZoneID: zoneID}, nil
+		}
+	}
 	// POST /dnszone/checkavailability - check zone availability (admin only)
-	// POST /dnszone/{id}/import - import records (admin only)
+	// POST /dnszone/{id}/import - import records
 	if r.Method == http.MethodPost {
 		if matches := importRecordsPattern.FindStringSubmatch(path); matches != nil {
 			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
@@ -80,6 +172,27 @@ func ParseRequest(r *http.Request) (*Request, error) {
 			return &Request{Action: ActionImportRecords, ZoneID: zoneID}, nil
 		}
 	}
+	// POST /dnszone/{id}/diff - compare a zone file with the zone's records
+	if r.Method == http.MethodPost {
+		if matches := diffRecordsPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			return &Request{Action: ActionDiffRecords, ZoneID: zoneID}, nil
+		}
+	}
+	// POST /dnszone/{id}/sync - make the zone's records match a zone file
+	// Per-change actions and record types are enforced by the handler.
+	if r.Method == http.MethodPost {
+		if matches := syncRecordsPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			return &Request{Action: ActionSyncRecords, ZoneID: zoneID}, nil
+		}
+	}
 	if r.Method == http.MethodPost && checkAvailabilityPattern.MatchString(path) {
 		return &Request{Action: ActionCheckAvailability}, nil
 	}
@@ -94,6 +207,17 @@ func ParseRequest(r *http.Request) (*Request, error) {
 		return &Request{Action: ActionCreateZone}, nil
 	}
 
+	// GET /dnszone/{id}/dnssec - DNSSEC status
+	if r.Method == http.MethodGet {
+		if matches := dnssecPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			return &Request{Action: ActionGetDNSSEC, ZoneID: zoneID}, nil
+		}
+	}
+
 	// POST /dnszone/{id}/dnssec - enable DNSSEC (admin only)
 	if r.Method == http.MethodPost {
 		if matches := dnssecPattern.FindStringSubmatch(path); matches != nil {
@@ -115,6 +239,35 @@ func ParseRequest(r *http.Request) (*Request, error) {
 			return &Request{Action: ActionDisableDNSSEC, ZoneID: zoneID}, nil
 		}
 	}
+	// GET /dnszone/{id}/scheduled - list scheduled record changes
+	if r.Method == http.MethodGet {
+		if matches := scheduledPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			return &Request{Action: ActionListScheduledChanges, ZoneID: zoneID}, nil
+		}
+	}
+
+	// GET and DELETE /dnszone/{id}/scheduled/{cid} - get or cancel a scheduled change
+	// Cancelling is checked by the handler against the change's own action.
+	if matches := scheduledChangePattern.FindStringSubmatch(path); matches != nil {
+		zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone ID: %w", err)
+		}
+		if _, err := strconv.ParseInt(matches[2], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid change ID: %w", err)
+		}
+		switch r.Method {
+		case http.MethodGet:
+			return &Request{Action: ActionGetScheduledChange, ZoneID: zoneID}, nil
+		case http.MethodDelete:
+			return &Request{Action: ActionCancelScheduledChange, ZoneID: zoneID}, nil
+		}
+	}
+
 	// POST /dnszone/{id}/certificate/issue - issue wildcard certificate (admin only)
 	if r.Method == http.MethodPost {
 		if matches := issueCertificatePattern.FindStringSubmatch(path); matches != nil {
@@ -139,7 +292,7 @@ func ParseRequest(r *http.Request) (*Request, error) {
 		}
 	}
 
-	// GET /dnszone/{id}/export - export records (admin only)
+	// GET /dnszone/{id}/export - export records
 	if r.Method == http.MethodGet {
 		if matches := exportRecordsPattern.FindStringSubmatch(path); matches != nil {
 			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
@@ -148,7 +301,7 @@ func ParseRequest(r *http.Request) (*Request, error) {
 			}
 			return &Request{Action: ActionExportRecords, ZoneID: zoneID}, nil
 		}
-		// GET /dnszone/{id}/statistics - query statistics (admin only)
+		// GET /dnszone/{id}/statistics - query statistics
 		if r.Method == http.MethodGet {
 			if matches := statisticsPattern.FindStringSubmatch(path); matches != nil {
 				zoneID, err := strconv.ParseInt(matches[1], 10, 64)
@@ -160,6 +313,18 @@ func ParseRequest(r *http.Request) (*Request, error) {
 		}
 	}
 
+	// POST /dnszone/{id}/records/batch - batch record operations
+	// Per-operation actions and record types are enforced by the handler.
+	if r.Method == http.MethodPost {
+		if matches := batchRecordsPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			return &Request{Action: ActionBatchRecords, ZoneID: zoneID}, nil
+		}
+	}
+
 	// POST /dnszone/{id}/records - add record
 	if r.Method == http.MethodPost && recordsPattern.MatchString(path) {
 		matches := recordsPattern.FindStringSubmatch(path)
@@ -248,40 +413,105 @@ func ParseRequest(r *http.Request) (*Request, error) {
 		}
 	}
 
+	// PATCH /dnszone/{id}/records/{rid}/meta - update record metadata
+	// Record type restrictions are enforced by the handler, which looks up the record.
+	if r.Method == http.MethodPatch {
+		if matches := recordMetaPattern.FindStringSubmatch(path); matches != nil {
+			zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid zone ID: %w", err)
+			}
+			if _, err := strconv.ParseInt(matches[2], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid record ID: %w", err)
+			}
+			return &Request{Action: ActionUpdateRecordMeta, ZoneID: zoneID}, nil
+		}
+	}
+
 	return nil, fmt.Errorf("unrecognized endpoint: %s %s", r.Method, path)
 }
 
+// parsePullZoneRequest maps a /pullzone request to its action and pull zone ID.
+func parsePullZoneRequest(method, path string) (*Request, error) {
+	if listPullZonesPattern.MatchString(path) {
+		switch method {
+		case http.MethodGet:
+			return &Request{Action: ActionListPullZones}, nil
+		case http.MethodPost:
+			return &Request{Action: ActionCreatePullZone}, nil
+		}
+	}
+
+	// POST /pullzone/{id}/purgeCache - purge cache
+	if matches := purgePullZonePattern.FindStringSubmatch(path); matches != nil && method == http.MethodPost {
+		zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pull zone ID: %w", err)
+		}
+		return &Request{Action: ActionPurgePullZone, ZoneID: zoneID}, nil
+	}
+
+	// GET, POST and DELETE /pullzone/{id}
+	if matches := pullZonePattern.FindStringSubmatch(path); matches != nil {
+		zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pull zone ID: %w", err)
+		}
+		switch method {
+		case http.MethodGet:
+			return &Request{Action: ActionGetPullZone, ZoneID: zoneID}, nil
+		case http.MethodPost:
+			return &Request{Action: ActionUpdatePullZone, ZoneID: zoneID}, nil
+		case http.MethodDelete:
+			return &Request{Action: ActionDeletePullZone, ZoneID: zoneID}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unrecognized endpoint: %s %s", method, path)
+}
+
+// parseStorageZoneRequest maps a /storagezone request to its action and storage zone ID.
+func parseStorageZoneRequest(method, path string) (*Request, error) {
+	if listStorageZonesPattern.MatchString(path) {
+		switch method {
+		case http.MethodGet:
+			return &Request{Action: ActionListStorageZones}, nil
+		case http.MethodPost:
+			return &Request{Action: ActionCreateStorageZone}, nil
+		}
+	}
+
+	// POST /storagezone/{id}/resetPassword - reset password
+	if matches := resetPasswordPattern.FindStringSubmatch(path); matches != nil && method == http.MethodPost {
+		zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage zone ID: %w", err)
+		}
+		return &Request{Action: ActionResetStorageZonePassword, ZoneID: zoneID}, nil
+	}
+
+	// GET and DELETE /storagezone/{id}
+	if matches := storageZonePattern.FindStringSubmatch(path); matches != nil {
+		zoneID, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage zone ID: %w", err)
+		}
+		switch method {
+		case http.MethodGet:
+			return &Request{Action: ActionGetStorageZone, ZoneID: zoneID}, nil
+		case http.MethodDelete:
+			return &Request{Action: ActionDeleteStorageZone, ZoneID: zoneID}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unrecognized endpoint: %s %s", method, path)
+}
+
 // MapRecordTypeToString converts a bunny.net record type integer to its string name.
 // Record types: 0 = A, 1 = AAAA, 2 = CNAME, 3 = TXT, 4 = MX, 5 = SPF, 6 = Flatten, 7 = PullZone, 8 = SRV, 9 = CAA, 10 = PTR, 11 = Script, 12 = NS
 func MapRecordTypeToString(typeInt int) string {
-	switch typeInt {
-	case 0:
-		return "A"
-	case 1:
-		return "AAAA"
-	case 2:
-		return "CNAME"
-	case 3:
-		return "TXT"
-	case 4:
-		return "MX"
-	case 5:
-		return "SPF"
-	case 6:
-		return "Flatten"
-	case 7:
-		return "PullZone"
-	case 8:
-		return "SRV"
-	case 9:
-		return "CAA"
-	case 10:
-		return "PTR"
-	case 11:
-		return "Script"
-	case 12:
-		return "NS"
-	default:
+	if typeInt < 0 || typeInt >= len(recordTypes) {
 		return "" // Unknown type
 	}
+	return recordTypes[typeInt]
 }
@@ -25,6 +25,151 @@ func TestParseRequest(t *testing.T) {
 			path:       "/dnszone",
 			wantAction: ActionListZones,
 		},
+		{
+			name:       "find zone by name",
+			method:     "GET",
+			path:       "/dnszone/byname/www.example.com",
+			wantAction: ActionFindZoneByName,
+		},
+		{
+			name:       "search records",
+			method:     "GET",
+			path:       "/search/records",
+			wantAction: ActionSearchRecords,
+		},
+		{
+			name:       "export zones",
+			method:     "GET",
+			path:       "/export",
+			wantAction: ActionExportZones,
+		},
+		{
+			name:       "my activity",
+			method:     "GET",
+			path:       "/api/my/activity",
+			wantAction: ActionGetMyActivity,
+		},
+		{
+			name:       "list routes",
+			method:     "GET",
+			path:       "/api/routes",
+			wantAction: ActionListRoutes,
+		},
+		{
+			name:       "get job",
+			method:     "GET",
+			path:       "/jobs/42",
+			wantAction: ActionGetJob,
+		},
+		{
+			name:       "list pull zones",
+			method:     "GET",
+			path:       "/pullzone",
+			wantAction: ActionListPullZones,
+		},
+		{
+			name:       "create pull zone",
+			method:     "POST",
+			path:       "/pullzone",
+			wantAction: ActionCreatePullZone,
+		},
+		{
+			name:       "get pull zone",
+			method:     "GET",
+			path:       "/pullzone/42",
+			wantAction: ActionGetPullZone,
+			wantZoneID: 42,
+		},
+		{
+			name:       "update pull zone",
+			method:     "POST",
+			path:       "/pullzone/42",
+			wantAction: ActionUpdatePullZone,
+			wantZoneID: 42,
+		},
+		{
+			name:       "delete pull zone",
+			method:     "DELETE",
+			path:       "/pullzone/42",
+			wantAction: ActionDeletePullZone,
+			wantZoneID: 42,
+		},
+		{
+			name:       "purge pull zone",
+			method:     "POST",
+			path:       "/pullzone/42/purgeCache",
+			wantAction: ActionPurgePullZone,
+			wantZoneID: 42,
+		},
+		{
+			name:       "list storage zones",
+			method:     "GET",
+			path:       "/storagezone",
+			wantAction: ActionListStorageZones,
+		},
+		{
+			name:       "create storage zone",
+			method:     "POST",
+			path:       "/storagezone",
+			wantAction: ActionCreateStorageZone,
+		},
+		{
+			name:       "get storage zone",
+			method:     "GET",
+			path:       "/storagezone/9",
+			wantAction: ActionGetStorageZone,
+			wantZoneID: 9,
+		},
+		{
+			name:       "delete storage zone",
+			method:     "DELETE",
+			path:       "/storagezone/9",
+			wantAction: ActionDeleteStorageZone,
+			wantZoneID: 9,
+		},
+		{
+			name:       "reset storage zone password",
+			method:     "POST",
+			path:       "/storagezone/9/resetPassword",
+			wantAction: ActionResetStorageZonePassword,
+			wantZoneID: 9,
+		},
+		{
+			name:    "storage zone update is not proxied",
+			method:  "POST",
+			path:    "/storagezone/9",
+			wantErr: true,
+		},
+		{
+			name:    "unknown pull zone endpoint",
+			method:  "GET",
+			path:    "/pullzone/42/purgeCache",
+			wantErr: true,
+		},
+		{
+			name:       "account statistics",
+			method:     "GET",
+			path:       "/statistics",
+			wantAction: ActionGetAccountStatistics,
+		},
+		{
+			name:       "billing",
+			method:     "GET",
+			path:       "/billing",
+			wantAction: ActionGetBilling,
+		},
+		{
+			name:       "billing summary",
+			method:     "GET",
+			path:       "/billing/summary",
+			wantAction: ActionGetBilling,
+		},
+		{
+			name:    "billing write rejected",
+			method:  "POST",
+			path:    "/billing",
+			wantErr: true,
+		},
 		{
 			name:       "list zones with trailing slash",
 			method:     "GET",
@@ -45,6 +190,13 @@ func TestParseRequest(t *testing.T) {
 			wantAction: ActionListRecords,
 			wantZoneID: 456,
 		},
+		{
+			name:       "get record",
+			method:     "GET",
+			path:       "/dnszone/456/records/789",
+			wantAction: ActionGetRecord,
+			wantZoneID: 456,
+		},
 		{
 			name:       "add record",
 			method:     "POST",
@@ -61,6 +213,21 @@ func TestParseRequest(t *testing.T) {
 			wantAction: ActionDeleteRecord,
 			wantZoneID: 123,
 		},
+		{
+			name:       "batch records",
+			method:     "POST",
+			path:       "/dnszone/789/records/batch",
+			body:       `{"Operations":[]}`,
+			wantAction: ActionBatchRecords,
+			wantZoneID: 789,
+		},
+		{
+			name:       "update record metadata",
+			method:     "PATCH",
+			path:       "/dnszone/789/records/456/meta",
+			wantAction: ActionUpdateRecordMeta,
+			wantZoneID: 789,
+		},
 		{
 			name:       "update record",
 			method:     "POST",
@@ -114,6 +281,19 @@ func TestParseRequest(t *testing.T) {
 			wantAction: ActionExportRecords,
 			wantZoneID: 456,
 		},
+		{
+			name:       "get DNSSEC",
+			method:     "GET",
+			path:       "/dnszone/123/dnssec",
+			wantAction: ActionGetDNSSEC,
+			wantZoneID: 123,
+		},
+		{
+			name:       "statistics summary",
+			method:     "GET",
+			path:       "/dnszone/statistics",
+			wantAction: ActionGetStatisticsSummary,
+		},
 		{
 			name:       "enable DNSSEC",
 			method:     "POST",
@@ -128,6 +308,41 @@ func TestParseRequest(t *testing.T) {
 			wantAction: ActionDisableDNSSEC,
 			wantZoneID: 123,
 		},
+		{
+			name:       "diff records",
+			method:     "POST",
+			path:       "/dnszone/123/diff",
+			wantAction: ActionDiffRecords,
+			wantZoneID: 123,
+		},
+		{
+			name:       "sync records",
+			method:     "POST",
+			path:       "/dnszone/123/sync",
+			wantAction: ActionSyncRecords,
+			wantZoneID: 123,
+		},
+		{
+			name:       "list scheduled changes",
+			method:     "GET",
+			path:       "/dnszone/123/scheduled",
+			wantAction: ActionListScheduledChanges,
+			wantZoneID: 123,
+		},
+		{
+			name:       "get scheduled change",
+			method:     "GET",
+			path:       "/dnszone/123/scheduled/7",
+			wantAction: ActionGetScheduledChange,
+			wantZoneID: 123,
+		},
+		{
+			name:       "cancel scheduled change",
+			method:     "DELETE",
+			path:       "/dnszone/123/scheduled/7",
+			wantAction: ActionCancelScheduledChange,
+			wantZoneID: 123,
+		},
 		{
 			name:       "issue certificate",
 			method:     "POST",
@@ -2,9 +2,14 @@
 package auth
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -15,46 +20,268 @@ func HashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// FormatKey returns the API key of a token: its ID and secret joined by a
+// dot. Only the hash of the secret is stored.
+func FormatKey(id int64, secret string) string {
+	return strconv.FormatInt(id, 10) + "." + secret
+}
+
+// ParseKey splits an API key made by FormatKey into the token ID and secret.
+// ok is false for keys without an ID, such as those issued before keys
+// carried one and provisioned secrets, which are looked up by hash instead.
+func ParseKey(key string) (id int64, secret string, ok bool) {
+	idPart, secret, found := strings.Cut(key, ".")
+	if !found || secret == "" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 || strconv.FormatInt(id, 10) != idPart {
+		return 0, "", false
+	}
+	return id, secret, true
+}
+
+// KeyLookup finds tokens by the hash of their key or secret.
+type KeyLookup interface {
+	GetTokenByHash(ctx context.Context, keyHash string) (*storage.Token, error)
+	GetTokenByKey(ctx context.Context, id int64, secretHash string) (*storage.Token, error)
+}
+
+// LookupKey returns the token for an API key: by ID, checking the hash of
+// the secret, for keys made by FormatKey, and by hash otherwise. The hash
+// lookup only finds tokens created with a whole key, such as those issued
+// before keys carried an ID and provisioned secrets, so the secret of a key
+// made by FormatKey is not accepted without its ID.
+// Returns storage.ErrNotFound if no token has the key.
+func LookupKey(ctx context.Context, tokens KeyLookup, key string) (*storage.Token, error) {
+	if id, secret, ok := ParseKey(key); ok {
+		return tokens.GetTokenByKey(ctx, id, HashToken(secret))
+	}
+	return tokens.GetTokenByHash(ctx, HashToken(key))
+}
+
 // Action represents an API operation.
 type Action string
 
 const (
 	// ActionListZones lists all zones accessible to the key.
 	ActionListZones Action = "list_zones"
+	// ActionFindZoneByName resolves a hostname to the zone it belongs to.
+	ActionFindZoneByName Action = "find_zone_by_name"
 	// ActionGetZone gets details for a specific zone.
 	ActionGetZone Action = "get_zone"
 	// ActionListRecords lists records in a zone.
 	ActionListRecords Action = "list_records"
+	// ActionGetRecord gets a single record; it is granted by list_records.
+	ActionGetRecord Action = "get_record"
+	// ActionExportRecords exports a zone file; it is granted by list_records,
+	// and scoped tokens only get the record types they may read.
+	ActionExportRecords Action = "export_records"
+	// ActionDiffRecords compares a submitted zone file with a zone's records
+	// without changing them; it is granted by list_records.
+	ActionDiffRecords Action = "diff_records"
+	// ActionSyncRecords makes a zone's records match a submitted zone file;
+	// each change is checked against add_record, update_record or delete_record.
+	ActionSyncRecords Action = "sync_records"
 	// ActionAddRecord adds a record to a zone.
 	ActionAddRecord Action = "add_record"
 	// ActionUpdateRecord updates a record in a zone.
 	ActionUpdateRecord Action = "update_record"
 	// ActionDeleteRecord deletes a record from a zone.
 	ActionDeleteRecord Action = "delete_record"
+	// ActionBatchRecords applies several record operations to a zone; each
+	// operation is checked against add_record, update_record or delete_record.
+	ActionBatchRecords Action = "batch_records"
+	// ActionUpdateRecordMeta updates proxy-side metadata for a record.
+	ActionUpdateRecordMeta Action = "update_record_meta"
+	// ActionSearchRecords searches records across the key's permitted zones.
+	ActionSearchRecords Action = "search_records"
+	// ActionExportZones exports zone files for several zones as one archive.
+	ActionExportZones Action = "export_zones"
+	// ActionGetMyActivity lists the calling token's own audit entries.
+	ActionGetMyActivity Action = "get_my_activity"
+	// ActionListRoutes lists the proxy's routes and the access each requires.
+	ActionListRoutes Action = "list_routes"
+	// ActionGetJob gets the status of one of the calling token's background jobs.
+	ActionGetJob Action = "get_job"
+	// ActionListScheduledChanges lists a zone's scheduled record changes; it
+	// is granted by list_records.
+	ActionListScheduledChanges Action = "list_scheduled_changes"
+	// ActionGetScheduledChange gets one scheduled record change; it is
+	// granted by list_records.
+	ActionGetScheduledChange Action = "get_scheduled_change"
+	// ActionCancelScheduledChange cancels a scheduled record change; it is
+	// checked against add_record or delete_record, as the change itself was.
+	ActionCancelScheduledChange Action = "cancel_scheduled_change"
 	// ActionCreateZone creates a new DNS zone (admin only).
 	ActionCreateZone Action = "create_zone"
 	// ActionUpdateZone updates zone-level settings (admin only).
 	ActionUpdateZone Action = "update_zone"
 	// ActionCheckAvailability checks DNS zone availability (admin only).
 	ActionCheckAvailability Action = "check_availability"
-	// ActionImportRecords imports DNS records (admin only).
+	// ActionImportRecords imports DNS records from a file; each record is
+	// checked against add_record.
 	ActionImportRecords Action = "import_records"
-	// ActionExportRecords exports DNS records (admin only).
-	ActionExportRecords Action = "export_records"
+	// ActionGetDNSSEC gets a zone's DNSSEC status and DS record; it is granted
+	// by list_records, since the DS record is published in the parent zone.
+	ActionGetDNSSEC Action = "get_dnssec"
 	// ActionEnableDNSSEC enables DNSSEC for a zone (admin only).
 	ActionEnableDNSSEC Action = "enable_dnssec"
 	// ActionDisableDNSSEC disables DNSSEC for a zone (admin only).
 	ActionDisableDNSSEC Action = "disable_dnssec"
 	// ActionIssueCertificate issues a wildcard SSL certificate (admin only).
 	ActionIssueCertificate Action = "issue_certificate"
-	// ActionGetStatistics retrieves DNS query statistics (admin only).
+	// ActionGetStatistics retrieves DNS query statistics for a zone. It is
+	// not part of an access level, so it must be granted by name.
 	ActionGetStatistics Action = "get_statistics"
+	// ActionGetStatisticsSummary sums DNS query statistics across the zones
+	// the key may get statistics for.
+	ActionGetStatisticsSummary Action = "get_statistics_summary"
 	// ActionTriggerDNSScan triggers a DNS record scan (admin only).
 	ActionTriggerDNSScan Action = "trigger_dns_scan"
 	// ActionGetDNSScanResult retrieves DNS scan results (admin only).
 	ActionGetDNSScanResult Action = "get_dns_scan_result"
+	// ActionGetAccountStatistics retrieves account-wide CDN statistics (admin only).
+	ActionGetAccountStatistics Action = "get_account_statistics"
+	// ActionGetBilling retrieves account billing data (admin only).
+	ActionGetBilling Action = "get_billing"
+	// ActionListPullZones lists the pull zones accessible to the key.
+	ActionListPullZones Action = "list_pull_zones"
+	// ActionGetPullZone gets details for a specific pull zone.
+	ActionGetPullZone Action = "get_pull_zone"
+	// ActionUpdatePullZone updates pull zone settings.
+	ActionUpdatePullZone Action = "update_pull_zone"
+	// ActionPurgePullZone purges a pull zone's cache.
+	ActionPurgePullZone Action = "purge_pull_zone"
+	// ActionCreatePullZone creates a new pull zone (admin only).
+	ActionCreatePullZone Action = "create_pull_zone"
+	// ActionDeletePullZone deletes a pull zone (admin only).
+	ActionDeletePullZone Action = "delete_pull_zone"
+	// ActionListStorageZones lists the storage zones accessible to the key.
+	ActionListStorageZones Action = "list_storage_zones"
+	// ActionGetStorageZone gets details for a specific storage zone.
+	ActionGetStorageZone Action = "get_storage_zone"
+	// ActionDeleteStorageZone deletes a storage zone.
+	ActionDeleteStorageZone Action = "delete_storage_zone"
+	// ActionResetStorageZonePassword resets a storage zone's password.
+	ActionResetStorageZonePassword Action = "reset_storage_zone_password"
+	// ActionCreateStorageZone creates a new storage zone (admin only).
+	ActionCreateStorageZone Action = "create_storage_zone"
+)
+
+// adminOnlyActions lists actions that scoped tokens can never perform,
+// regardless of their zone permissions.
+var adminOnlyActions = map[Action]bool{
+	ActionCreateZone:           true,
+	ActionUpdateZone:           true,
+	ActionCheckAvailability:    true,
+	ActionEnableDNSSEC:         true,
+	ActionDisableDNSSEC:        true,
+	ActionIssueCertificate:     true,
+	ActionTriggerDNSScan:       true,
+	ActionGetDNSScanResult:     true,
+	ActionGetAccountStatistics: true,
+	ActionGetBilling:           true,
+	ActionCreatePullZone:       true,
+	ActionDeletePullZone:       true,
+	ActionCreateStorageZone:    true,
+}
+
+// IsAdminOnly reports whether the action is restricted to admin tokens.
+func (a Action) IsAdminOnly() bool {
+	return adminOnlyActions[a]
+}
+
+// unscopedActions lists actions any valid key may perform; the handler
+// restricts results to the caller's permitted zones or own data.
+var unscopedActions = map[Action]bool{
+	ActionListZones:            true,
+	ActionFindZoneByName:       true,
+	ActionSearchRecords:        true,
+	ActionExportZones:          true,
+	ActionGetMyActivity:        true,
+	ActionListRoutes:           true,
+	ActionGetJob:               true,
+	ActionGetStatisticsSummary: true,
+	ActionListPullZones:        true,
+	ActionListStorageZones:     true,
+}
+
+// RequiresZonePermission reports whether a scoped token needs a permission
+// for the target zone to perform the action.
+func (a Action) RequiresZonePermission() bool {
+	return !unscopedActions[a] && !adminOnlyActions[a]
+}
+
+// actionResources maps actions whose ZoneID does not refer to a DNS zone to
+// the resource it refers to.
+var actionResources = map[Action]string{
+	ActionListPullZones:            storage.ResourcePullZone,
+	ActionGetPullZone:              storage.ResourcePullZone,
+	ActionUpdatePullZone:           storage.ResourcePullZone,
+	ActionPurgePullZone:            storage.ResourcePullZone,
+	ActionCreatePullZone:           storage.ResourcePullZone,
+	ActionDeletePullZone:           storage.ResourcePullZone,
+	ActionListStorageZones:         storage.ResourceStorageZone,
+	ActionGetStorageZone:           storage.ResourceStorageZone,
+	ActionDeleteStorageZone:        storage.ResourceStorageZone,
+	ActionResetStorageZonePassword: storage.ResourceStorageZone,
+	ActionCreateStorageZone:        storage.ResourceStorageZone,
+}
+
+// Resource returns the kind of permission (storage.ResourceDNSZone,
+// storage.ResourcePullZone or storage.ResourceStorageZone) that governs the action.
+func (a Action) Resource() string {
+	if r, ok := actionResources[a]; ok {
+		return r
+	}
+	return storage.ResourceDNSZone
+}
+
+// Access levels grant groups of record actions. They may be listed in a
+// permission's AllowedActions alongside individual actions, so a read-only
+// token is just ["read"].
+const (
+	// AccessRead lists records; get_zone and list_zones need no action.
+	AccessRead = "read"
+	// AccessCreate adds records.
+	AccessCreate = "create"
+	// AccessUpdate updates records and their proxy-side metadata.
+	AccessUpdate = "update"
+	// AccessDelete deletes records.
+	AccessDelete = "delete"
 )
 
+// accessLevels maps each access level to the actions it grants.
+var accessLevels = map[string][]Action{
+	AccessRead:   {ActionListRecords},
+	AccessCreate: {ActionAddRecord},
+	AccessUpdate: {ActionUpdateRecord, ActionUpdateRecordMeta},
+	AccessDelete: {ActionDeleteRecord},
+}
+
+// grantedBy maps actions that reveal no more than listing a zone's records
+// to the action that grants them.
+var grantedBy = map[Action]Action{
+	ActionGetRecord:            ActionListRecords,
+	ActionExportRecords:        ActionListRecords,
+	ActionDiffRecords:          ActionListRecords,
+	ActionGetDNSSEC:            ActionListRecords,
+	ActionListScheduledChanges: ActionListRecords,
+	ActionGetScheduledChange:   ActionListRecords,
+}
+
+// actionAllowed reports whether allowed grants the action, either by name or
+// through an access level.
+func actionAllowed(allowed []string, action Action) bool {
+	for _, a := range allowed {
+		if a == string(action) || slices.Contains(accessLevels[a], action) {
+			return true
+		}
+	}
+	return false
+}
+
 // Errors for authentication and authorization failures.
 var (
 	// ErrMissingKey indicates no API key was provided.
@@ -65,6 +292,32 @@ var (
 	ErrForbidden = errors.New("auth: permission denied")
 )
 
+// Reasons a request is denied, reported in DenialError.
+const (
+	ReasonAdminRequired        = "admin_required"          // the action needs an admin token
+	ReasonNoPermission         = "no_permission"           // no permission covers the zone
+	ReasonActionNotAllowed     = "action_not_allowed"      // the zone's permission does not grant the action
+	ReasonRecordTypeNotAllowed = "record_type_not_allowed" // the zone's permission does not grant the record type
+)
+
+// DenialError explains why CheckPermission denied a request. It matches
+// ErrForbidden with errors.Is.
+type DenialError struct {
+	Reason string
+	// PermissionID is the permission that was checked, or 0 if none applied.
+	PermissionID int64
+	Message      string
+}
+
+func (e *DenialError) Error() string {
+	return "auth: permission denied: " + e.Reason
+}
+
+// Is reports whether target is ErrForbidden.
+func (e *DenialError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
 // Request represents a parsed API request.
 type Request struct {
 	Action     Action
@@ -79,98 +332,120 @@ type KeyInfo struct {
 	Permissions []*storage.Permission
 }
 
-// CheckPermission verifies if the key has permission for the request.
+// CheckPermission verifies if the key has permission for the request. A
+// denied request returns a *DenialError.
 func CheckPermission(keyInfo *KeyInfo, req *Request) error {
-	// list_zones: always allowed if key is valid
-	if req.Action == ActionListZones {
+	// list_zones, find_zone_by_name, search_records, export_zones,
+	// get_my_activity, list_routes, get_job, get_statistics_summary,
+	// list_pull_zones and list_storage_zones: always allowed if key is valid; the handler
+	// restricts results to permitted zones or the caller's own entries
+	if unscopedActions[req.Action] {
 		return nil
 	}
 
-	// Find permission for this zone
+	// Find permission for this zone, of the kind the action applies to
+	resource := req.Action.Resource()
 	var zonePerm *storage.Permission
 	for _, p := range keyInfo.Permissions {
-		if p.ZoneID == req.ZoneID {
+		if p.ZoneID == req.ZoneID && permissionResource(p) == resource {
 			zonePerm = p
 			break
 		}
 	}
 
 	if zonePerm == nil {
-		return ErrForbidden
+		return &DenialError{Reason: ReasonNoPermission,
+			Message: fmt.Sprintf("This token has no permission for %s %d.", resource, req.ZoneID)}
 	}
 
-	// get_zone: allowed if any permission exists for zone
-	if req.Action == ActionGetZone {
+	// get_zone, get_pull_zone and get_storage_zone: allowed if any permission
+	// exists for zone
+	// batch_records, import_records and sync_records: the handler checks each
+	// operation or record with its own action
+	// cancel_scheduled_change: the handler checks the change's own action
+	switch req.Action {
+	case ActionGetZone, ActionGetPullZone, ActionGetStorageZone, ActionBatchRecords, ActionImportRecords,
+		ActionSyncRecords, ActionCancelScheduledChange:
 		return nil
 	}
 
 	// Check if action is in allowed actions
-	actionAllowed := false
-	for _, a := range zonePerm.AllowedActions {
-		if a == string(req.Action) {
-			actionAllowed = true
-			break
-		}
+	action := req.Action
+	if granting, ok := grantedBy[action]; ok {
+		action = granting
 	}
-
-	if !actionAllowed {
-		return ErrForbidden
+	if !actionAllowed(zonePerm.AllowedActions, action) {
+		return &DenialError{Reason: ReasonActionNotAllowed, PermissionID: zonePerm.ID,
+			Message: fmt.Sprintf("Permission %d does not allow %s.", zonePerm.ID, req.Action)}
 	}
 
 	// add_record and update_record: also check record type
 	if req.Action == ActionAddRecord || req.Action == ActionUpdateRecord {
-		typeAllowed := false
-		for _, t := range zonePerm.RecordTypes {
-			if t == req.RecordType {
-				typeAllowed = true
-				break
-			}
-		}
-		if !typeAllowed {
-			return ErrForbidden
+		if !recordTypeAllowed(zonePerm.RecordTypes, req.RecordType) {
+			return &DenialError{Reason: ReasonRecordTypeNotAllowed, PermissionID: zonePerm.ID,
+				Message: fmt.Sprintf("Permission %d does not allow %s records.", zonePerm.ID, req.RecordType)}
 		}
 	}
 
 	return nil
 }
 
-// GetPermittedZoneIDs returns the zone IDs that the key has permission for.
+// permissionResource returns the resource a permission applies to; rows
+// written before resources existed are DNS zone permissions.
+func permissionResource(p *storage.Permission) string {
+	if p.Resource == "" {
+		return storage.ResourceDNSZone
+	}
+	return p.Resource
+}
+
+// GetPermittedZoneIDs returns the DNS zone IDs that the key has permission for.
 // If any permission has ZoneID = 0 (all zones), returns nil (meaning "all zones").
 func GetPermittedZoneIDs(keyInfo *KeyInfo) []int64 {
+	return GetPermittedResourceIDs(keyInfo, storage.ResourceDNSZone)
+}
+
+// GetPermittedResourceIDs returns the IDs of the given resource kind that the
+// key has permission for. If any such permission has ZoneID = 0, returns nil
+// (meaning "all").
+func GetPermittedResourceIDs(keyInfo *KeyInfo, resource string) []int64 {
 	if keyInfo == nil {
 		return nil
 	}
 
-	// Check if key has "all zones" permission (ZoneID = 0)
-	for _, perm := range keyInfo.Permissions {
-		if perm.ZoneID == 0 {
-			return nil // nil means "all zones"
-		}
+	if hasAllPermission(keyInfo, resource) {
+		return nil // nil means "all zones"
 	}
 
 	// Collect all specific zone IDs
 	zoneIDs := make([]int64, 0, len(keyInfo.Permissions))
 	for _, perm := range keyInfo.Permissions {
-		zoneIDs = append(zoneIDs, perm.ZoneID)
+		if permissionResource(perm) == resource {
+			zoneIDs = append(zoneIDs, perm.ZoneID)
+		}
 	}
 	return zoneIDs
 }
 
-// HasAllZonesPermission returns true if the key has permission for all zones (ZoneID = 0).
+// HasAllZonesPermission returns true if the key has permission for all DNS zones (ZoneID = 0).
 func HasAllZonesPermission(keyInfo *KeyInfo) bool {
 	if keyInfo == nil {
 		return false
 	}
+	return hasAllPermission(keyInfo, storage.ResourceDNSZone)
+}
 
+// hasAllPermission reports whether the key has a ZoneID = 0 permission for resource.
+func hasAllPermission(keyInfo *KeyInfo, resource string) bool {
 	for _, perm := range keyInfo.Permissions {
-		if perm.ZoneID == 0 {
+		if perm.ZoneID == 0 && permissionResource(perm) == resource {
 			return true
 		}
 	}
 	return false
 }
 
-// findZonePermission finds the permission entry for a zone.
+// findZonePermission finds the permission entry for a DNS zone.
 // First tries exact zone match, then falls back to wildcard (ZoneID=0).
 // Returns nil if no matching permission is found.
 func findZonePermission(keyInfo *KeyInfo, zoneID int64) *storage.Permission {
@@ -179,14 +454,14 @@ func findZonePermission(keyInfo *KeyInfo, zoneID int64) *storage.Permission {
 	}
 	// Try exact zone match first
 	for _, perm := range keyInfo.Permissions {
-		if perm.ZoneID == zoneID {
+		if perm.ZoneID == zoneID && permissionResource(perm) == storage.ResourceDNSZone {
 			return perm
 		}
 	}
 	// Fall back to wildcard (ZoneID=0) if not an exact match
 	if zoneID != 0 {
 		for _, perm := range keyInfo.Permissions {
-			if perm.ZoneID == 0 {
+			if perm.ZoneID == 0 && permissionResource(perm) == storage.ResourceDNSZone {
 				return perm
 			}
 		}
@@ -194,6 +469,12 @@ func findZonePermission(keyInfo *KeyInfo, zoneID int64) *storage.Permission {
 	return nil
 }
 
+// HasZoneAccess returns true if the key has any permission for the zone,
+// either directly or through an all-zones (ZoneID = 0) permission.
+func HasZoneAccess(keyInfo *KeyInfo, zoneID int64) bool {
+	return findZonePermission(keyInfo, zoneID) != nil
+}
+
 // IsRecordTypePermitted checks if a record type is permitted for a zone.
 // Returns true if the type is allowed, or if no RecordTypes restriction exists.
 func IsRecordTypePermitted(keyInfo *KeyInfo, zoneID int64, recordType string) bool {
@@ -211,13 +492,7 @@ func IsRecordTypePermitted(keyInfo *KeyInfo, zoneID int64, recordType string) bo
 		return true
 	}
 
-	// Check if recordType is in the allowed list
-	for _, t := range zonePerm.RecordTypes {
-		if t == recordType {
-			return true
-		}
-	}
-	return false
+	return recordTypeAllowed(zonePerm.RecordTypes, recordType)
 }
 
 // GetPermittedRecordTypes returns the allowed record types for a zone.
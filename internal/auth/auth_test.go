@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
@@ -402,3 +403,219 @@ func TestGetPermittedRecordTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestHasZoneAccess(t *testing.T) {
+	t.Parallel()
+
+	scoped := &KeyInfo{Permissions: []*storage.Permission{{ZoneID: 10}}}
+	wildcard := &KeyInfo{Permissions: []*storage.Permission{{ZoneID: 0}}}
+
+	if !HasZoneAccess(scoped, 10) {
+		t.Error("expected access to permitted zone")
+	}
+	if HasZoneAccess(scoped, 11) {
+		t.Error("expected no access to other zone")
+	}
+	if !HasZoneAccess(wildcard, 99) {
+		t.Error("expected all-zones permission to grant access")
+	}
+	if HasZoneAccess(nil, 10) {
+		t.Error("expected nil key info to have no access")
+	}
+}
+
+func TestCheckPermission_AccessLevels(t *testing.T) {
+	t.Parallel()
+
+	keyInfo := func(actions ...string) *KeyInfo {
+		return &KeyInfo{Permissions: []*storage.Permission{
+			{ZoneID: 1, AllowedActions: actions, RecordTypes: []string{"TXT"}},
+		}}
+	}
+	tests := []struct {
+		name    string
+		allowed []string
+		action  Action
+		want    error
+	}{
+		{"read lists records", []string{AccessRead}, ActionListRecords, nil},
+		{"read gets a record", []string{AccessRead}, ActionGetRecord, nil},
+		{"list_records gets a record", []string{"list_records"}, ActionGetRecord, nil},
+		{"read gets zone", []string{AccessRead}, ActionGetZone, nil},
+		{"read cannot add", []string{AccessRead}, ActionAddRecord, ErrForbidden},
+		{"read cannot update", []string{AccessRead}, ActionUpdateRecord, ErrForbidden},
+		{"read cannot delete", []string{AccessRead}, ActionDeleteRecord, ErrForbidden},
+		{"create adds", []string{AccessCreate}, ActionAddRecord, nil},
+		{"create cannot delete", []string{AccessCreate}, ActionDeleteRecord, ErrForbidden},
+		{"update updates", []string{AccessUpdate}, ActionUpdateRecord, nil},
+		{"update updates meta", []string{AccessUpdate}, ActionUpdateRecordMeta, nil},
+		{"delete deletes", []string{AccessDelete}, ActionDeleteRecord, nil},
+		{"delete cannot list", []string{AccessDelete}, ActionListRecords, ErrForbidden},
+		{"delete cannot get a record", []string{AccessDelete}, ActionGetRecord, ErrForbidden},
+		{"read gets DNSSEC status", []string{AccessRead}, ActionGetDNSSEC, nil},
+		{"get_statistics gets statistics", []string{"get_statistics"}, ActionGetStatistics, nil},
+		{"read cannot get statistics", []string{AccessRead}, ActionGetStatistics, ErrForbidden},
+		{"create cannot get DNSSEC status", []string{AccessCreate}, ActionGetDNSSEC, ErrForbidden},
+		{"mixed with action names", []string{AccessRead, "delete_record"}, ActionDeleteRecord, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckPermission(keyInfo(tt.allowed...), &Request{Action: tt.action, ZoneID: 1, RecordType: "TXT"})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("CheckPermission(%v, %s) = %v, want %v", tt.allowed, tt.action, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPermission_DenialReasons(t *testing.T) {
+	t.Parallel()
+
+	keyInfo := &KeyInfo{Permissions: []*storage.Permission{
+		{ID: 12, ZoneID: 1, AllowedActions: []string{"add_record"}, RecordTypes: []string{"TXT"}},
+	}}
+	tests := []struct {
+		name       string
+		req        *Request
+		wantReason string
+		wantPermID int64
+	}{
+		{"other zone", &Request{Action: ActionListRecords, ZoneID: 2}, ReasonNoPermission, 0},
+		{"action", &Request{Action: ActionDeleteRecord, ZoneID: 1}, ReasonActionNotAllowed, 12},
+		{"record type", &Request{Action: ActionAddRecord, ZoneID: 1, RecordType: "A"}, ReasonRecordTypeNotAllowed, 12},
+	}
+	for _, tt := range tests {
+		var denial *DenialError
+		err := CheckPermission(keyInfo, tt.req)
+		if !errors.As(err, &denial) || !errors.Is(err, ErrForbidden) {
+			t.Fatalf("%s: expected a DenialError, got %v", tt.name, err)
+		}
+		if denial.Reason != tt.wantReason || denial.PermissionID != tt.wantPermID || denial.Message == "" {
+			t.Errorf("%s: unexpected denial %+v", tt.name, denial)
+		}
+	}
+}
+
+func TestActionRequiresZonePermission(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		action Action
+		want   bool
+	}{
+		{ActionListZones, false},
+		{ActionListRoutes, false},
+		{ActionGetMyActivity, false},
+		{ActionGetJob, false},
+		{ActionCreateZone, false},
+		{ActionGetZone, true},
+		{ActionListRecords, true},
+		{ActionAddRecord, true},
+	}
+	for _, tt := range tests {
+		if got := tt.action.RequiresZonePermission(); got != tt.want {
+			t.Errorf("%s.RequiresZonePermission() = %v, want %v", tt.action, got, tt.want)
+		}
+	}
+
+	// Unscoped actions are allowed for a key with no permissions at all.
+	if err := CheckPermission(&KeyInfo{}, &Request{Action: ActionListRoutes}); err != nil {
+		t.Errorf("CheckPermission(list_routes) = %v, want nil", err)
+	}
+}
+
+func TestCheckPermission_PullZones(t *testing.T) {
+	t.Parallel()
+
+	keyInfo := &KeyInfo{
+		Permissions: []*storage.Permission{
+			{ZoneID: 7, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+			{Resource: storage.ResourcePullZone, ZoneID: 42, AllowedActions: []string{"purge_pull_zone"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		req     *Request
+		wantErr bool
+	}{
+		{"list pull zones", &Request{Action: ActionListPullZones}, false},
+		{"get permitted pull zone", &Request{Action: ActionGetPullZone, ZoneID: 42}, false},
+		{"purge permitted pull zone", &Request{Action: ActionPurgePullZone, ZoneID: 42}, false},
+		{"update without action", &Request{Action: ActionUpdatePullZone, ZoneID: 42}, true},
+		{"other pull zone", &Request{Action: ActionGetPullZone, ZoneID: 43}, true},
+		// A DNS zone permission does not grant the pull zone with the same ID.
+		{"pull zone with DNS zone ID", &Request{Action: ActionGetPullZone, ZoneID: 7}, true},
+		// A pull zone permission does not grant the DNS zone with the same ID.
+		{"DNS zone with pull zone ID", &Request{Action: ActionGetZone, ZoneID: 42}, true},
+		{"DNS zone", &Request{Action: ActionListRecords, ZoneID: 7}, false},
+	}
+	for _, tt := range tests {
+		err := CheckPermission(keyInfo, tt.req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckPermission() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if got := GetPermittedZoneIDs(keyInfo); len(got) != 1 || got[0] != 7 {
+		t.Errorf("GetPermittedZoneIDs() = %v, want [7]", got)
+	}
+	if got := GetPermittedResourceIDs(keyInfo, storage.ResourcePullZone); len(got) != 1 || got[0] != 42 {
+		t.Errorf("GetPermittedResourceIDs(pullzone) = %v, want [42]", got)
+	}
+	if HasZoneAccess(keyInfo, 42) {
+		t.Error("HasZoneAccess(42) = true for a pull zone permission")
+	}
+	if ActionPurgePullZone.Resource() != storage.ResourcePullZone || ActionGetZone.Resource() != storage.ResourceDNSZone {
+		t.Error("unexpected Action.Resource() mapping")
+	}
+}
+
+func TestCheckPermission_StorageZones(t *testing.T) {
+	t.Parallel()
+
+	keyInfo := &KeyInfo{
+		Permissions: []*storage.Permission{
+			{Resource: storage.ResourcePullZone, ZoneID: 9, AllowedActions: []string{"purge_pull_zone"}},
+			{Resource: storage.ResourceStorageZone, ZoneID: 4, AllowedActions: []string{"reset_storage_zone_password"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		req     *Request
+		wantErr bool
+	}{
+		{"list storage zones", &Request{Action: ActionListStorageZones}, false},
+		{"get permitted storage zone", &Request{Action: ActionGetStorageZone, ZoneID: 4}, false},
+		{"reset permitted storage zone", &Request{Action: ActionResetStorageZonePassword, ZoneID: 4}, false},
+		{"delete without action", &Request{Action: ActionDeleteStorageZone, ZoneID: 4}, true},
+		{"storage zone with pull zone ID", &Request{Action: ActionGetStorageZone, ZoneID: 9}, true},
+	}
+	for _, tt := range tests {
+		err := CheckPermission(keyInfo, tt.req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: CheckPermission() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if !ActionCreateStorageZone.IsAdminOnly() || ActionDeleteStorageZone.IsAdminOnly() {
+		t.Error("only create_storage_zone should be admin only")
+	}
+	if ActionResetStorageZonePassword.Resource() != storage.ResourceStorageZone {
+		t.Errorf("Resource() = %q, want %q", ActionResetStorageZonePassword.Resource(), storage.ResourceStorageZone)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+	if id, secret, ok := ParseKey(FormatKey(42, "abc.def")); !ok || id != 42 || secret != "abc.def" {
+		t.Errorf("ParseKey(FormatKey(42, %q)) = %d, %q, %v", "abc.def", id, secret, ok)
+	}
+	for _, key := range []string{"0123abcd", "42.", ".abc", "0.abc", "-1.abc", "042.abc", "x1.abc", "99999999999999999999.abc"} {
+		if _, _, ok := ParseKey(key); ok {
+			t.Errorf("ParseKey(%q) ok = true, want false", key)
+		}
+	}
+}
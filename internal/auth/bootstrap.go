@@ -5,6 +5,9 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -17,9 +20,13 @@ const (
 	// Master key authentication is allowed in this state
 	StateUnconfigured BootstrapState = iota
 
-	// StateConfigured means at least one admin token exists
+	// StateConfigured means at least one admin token exists, or once did
 	// Master key authentication is locked out in this state
 	StateConfigured
+
+	// StateExpired means no admin token exists but the bootstrap window has
+	// closed. Master key authentication is locked out until a restart
+	StateExpired
 )
 
 // String returns the string representation of the bootstrap state
@@ -29,6 +36,8 @@ func (s BootstrapState) String() string {
 		return "UNCONFIGURED"
 	case StateConfigured:
 		return "CONFIGURED"
+	case StateExpired:
+		return "EXPIRED"
 	default:
 		return "UNKNOWN"
 	}
@@ -37,33 +46,104 @@ func (s BootstrapState) String() string {
 // BootstrapService manages the bootstrap state machine
 type BootstrapService struct {
 	tokens        storage.TokenStore
-	masterKeyHash string // SHA-256 hash of BUNNY_API_KEY
+	mu            sync.RWMutex
+	masterKeyHash string // SHA-256 hash of BUNNY_API_KEY, replaced by SetMasterKey
+
+	record    storage.BootstrapStore // nil: completion is not persisted
+	window    time.Duration          // 0: no time limit
+	startedAt time.Time
+}
+
+// BootstrapOption configures a BootstrapService.
+type BootstrapOption func(*BootstrapService)
+
+// WithBootstrapRecord persists the end of bootstrap, so the master key stays
+// locked out even if every admin token is later removed.
+func WithBootstrapRecord(record storage.BootstrapStore) BootstrapOption {
+	return func(b *BootstrapService) {
+		b.record = record
+	}
+}
+
+// WithBootstrapWindow limits bootstrap to the given time after the service is
+// created. Once it passes without an admin token, the master key is refused.
+func WithBootstrapWindow(window time.Duration) BootstrapOption {
+	return func(b *BootstrapService) {
+		b.window = window
+	}
 }
 
 // NewBootstrapService creates a new bootstrap service
 // masterKey is the raw BUNNY_API_KEY value
-func NewBootstrapService(tokens storage.TokenStore, masterKey string) *BootstrapService {
-	hash := sha256.Sum256([]byte(masterKey))
-	return &BootstrapService{
+func NewBootstrapService(tokens storage.TokenStore, masterKey string, opts ...BootstrapOption) *BootstrapService {
+	b := &BootstrapService{
 		tokens:        tokens,
-		masterKeyHash: hex.EncodeToString(hash[:]),
+		masterKeyHash: hashMasterKey(masterKey),
+		startedAt:     time.Now(),
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	return b
+}
+
+// SetMasterKey replaces the master key after BUNNY_API_KEY rotates, so the
+// old key stops working as the master key and the new one starts.
+func (b *BootstrapService) SetMasterKey(masterKey string) {
+	hash := hashMasterKey(masterKey)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.masterKeyHash = hash
+}
+
+// hashMasterKey returns the hex SHA-256 hash of a master key.
+func hashMasterKey(masterKey string) string {
+	hash := sha256.Sum256([]byte(masterKey))
+	return hex.EncodeToString(hash[:])
 }
 
 // GetState returns the current bootstrap state
-// Returns StateUnconfigured if no admin tokens exist
-// Returns StateConfigured if at least one admin token exists
+// Returns StateConfigured if bootstrap was recorded as complete or an admin token exists
+// Returns StateExpired if the bootstrap window has closed without an admin token
+// Returns StateUnconfigured otherwise
 func (b *BootstrapService) GetState(ctx context.Context) (BootstrapState, error) {
+	if b.record != nil {
+		_, err := b.record.GetBootstrapCompletedAt(ctx)
+		if err == nil {
+			return StateConfigured, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return StateUnconfigured, err
+		}
+	}
+
 	hasAdmin, err := b.tokens.HasAnyAdminToken(ctx)
 	if err != nil {
 		return StateUnconfigured, err
 	}
 	if hasAdmin {
+		// Databases bootstrapped before completion was recorded
+		if err := b.Complete(ctx); err != nil {
+			return StateConfigured, err
+		}
 		return StateConfigured, nil
 	}
+	if b.window > 0 && time.Since(b.startedAt) > b.window {
+		return StateExpired, nil
+	}
 	return StateUnconfigured, nil
 }
 
+// Complete records that bootstrap is over. Call it once the first admin token
+// is created. Without a bootstrap record it does nothing, and the state
+// follows whether an admin token exists.
+func (b *BootstrapService) Complete(ctx context.Context) error {
+	if b.record == nil {
+		return nil
+	}
+	return b.record.CompleteBootstrap(ctx, time.Now())
+}
+
 // IsMasterKey checks if the provided key matches the bunny.net API key
 //
 // SECURITY: This function MUST use constant-time comparison to prevent timing
@@ -78,12 +158,14 @@ func (b *BootstrapService) GetState(ctx context.Context) (BootstrapState, error)
 func (b *BootstrapService) IsMasterKey(key string) bool {
 	hash := sha256.Sum256([]byte(key))
 	keyHash := hex.EncodeToString(hash[:])
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	// SECURITY-CRITICAL: Must use constant-time comparison (see function comment)
 	return subtle.ConstantTimeCompare([]byte(keyHash), []byte(b.masterKeyHash)) == 1
 }
 
 // CanUseMasterKey returns true only during UNCONFIGURED state
-// Once an admin token exists, master key is locked out
+// Once an admin token exists or the bootstrap window closes, master key is locked out
 func (b *BootstrapService) CanUseMasterKey(ctx context.Context) (bool, error) {
 	state, err := b.GetState(ctx)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
 )
@@ -90,6 +91,96 @@ func TestGetState_Error(t *testing.T) {
 	}
 }
 
+func TestGetState_BootstrapWindow(t *testing.T) {
+	t.Parallel()
+	mock := &mockstore.MockStorage{
+		HasAnyAdminTokenFunc: func(ctx context.Context) (bool, error) {
+			return false, nil
+		},
+	}
+	bs := NewBootstrapService(mock, "test-key", WithBootstrapWindow(time.Minute))
+
+	state, err := bs.GetState(context.Background())
+	if err != nil || state != StateUnconfigured {
+		t.Fatalf("expected StateUnconfigured within the window, got %v, %v", state, err)
+	}
+
+	bs.startedAt = time.Now().Add(-2 * time.Minute)
+	state, err = bs.GetState(context.Background())
+	if err != nil || state != StateExpired {
+		t.Fatalf("expected StateExpired after the window, got %v, %v", state, err)
+	}
+	if canUse, _ := bs.CanUseMasterKey(context.Background()); canUse {
+		t.Error("expected master key to be locked out after the window")
+	}
+}
+
+func TestGetState_RecordedCompletion(t *testing.T) {
+	t.Parallel()
+	mock := &mockstore.MockStorage{
+		HasAnyAdminTokenFunc: func(ctx context.Context) (bool, error) {
+			return false, nil
+		},
+		GetBootstrapCompletedAtFunc: func(ctx context.Context) (time.Time, error) {
+			return time.Now().Add(-time.Hour), nil
+		},
+	}
+	bs := NewBootstrapService(mock, "test-key", WithBootstrapRecord(mock))
+
+	// Removing every admin token does not reopen bootstrap
+	state, err := bs.GetState(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != StateConfigured {
+		t.Errorf("expected StateConfigured, got %v", state)
+	}
+}
+
+func TestGetState_RecordsExistingAdmin(t *testing.T) {
+	t.Parallel()
+	var recorded bool
+	mock := &mockstore.MockStorage{
+		HasAnyAdminTokenFunc: func(ctx context.Context) (bool, error) {
+			return true, nil
+		},
+		CompleteBootstrapFunc: func(ctx context.Context, at time.Time) error {
+			recorded = true
+			return nil
+		},
+	}
+	bs := NewBootstrapService(mock, "test-key", WithBootstrapRecord(mock))
+
+	state, err := bs.GetState(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != StateConfigured {
+		t.Errorf("expected StateConfigured, got %v", state)
+	}
+	if !recorded {
+		t.Error("expected completion to be recorded for an existing admin token")
+	}
+}
+
+func TestGetState_RecordError(t *testing.T) {
+	t.Parallel()
+	expectedErr := errors.New("database error")
+	mock := &mockstore.MockStorage{
+		GetBootstrapCompletedAtFunc: func(ctx context.Context) (time.Time, error) {
+			return time.Time{}, expectedErr
+		},
+	}
+	bs := NewBootstrapService(mock, "test-key", WithBootstrapRecord(mock))
+
+	if _, err := bs.GetState(context.Background()); !errors.Is(err, expectedErr) {
+		t.Errorf("expected error %v, got %v", expectedErr, err)
+	}
+	if canUse, _ := bs.CanUseMasterKey(context.Background()); canUse {
+		t.Error("expected master key to be refused on error")
+	}
+}
+
 func TestIsMasterKey_CorrectKey(t *testing.T) {
 	t.Parallel()
 	masterKey := "my-secret-key"
@@ -134,6 +225,20 @@ func TestIsMasterKey_CaseSensitive(t *testing.T) {
 	}
 }
 
+func TestSetMasterKey(t *testing.T) {
+	t.Parallel()
+	mock := &mockstore.MockStorage{}
+	bs := NewBootstrapService(mock, "old-key")
+
+	bs.SetMasterKey("new-key")
+	if bs.IsMasterKey("old-key") {
+		t.Error("expected the rotated-out key to stop being the master key")
+	}
+	if !bs.IsMasterKey("new-key") {
+		t.Error("expected the new key to be the master key")
+	}
+}
+
 func TestCanUseMasterKey_Unconfigured(t *testing.T) {
 	t.Parallel()
 	mock := &mockstore.MockStorage{
@@ -311,6 +416,15 @@ func TestBootstrapStateString_Configured(t *testing.T) {
 	}
 }
 
+func TestBootstrapStateString_Expired(t *testing.T) {
+	t.Parallel()
+	s := StateExpired
+	expected := "EXPIRED"
+	if s.String() != expected {
+		t.Errorf("expected %s, got %s", expected, s.String())
+	}
+}
+
 func TestBootstrapStateString_Unknown(t *testing.T) {
 	t.Parallel()
 	s := BootstrapState(999)
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// WithCache caches token lookups and the permissions loaded for them for ttl,
+// keyed by the hash of the API key, so authenticating a proxied request does
+// not read storage every time. Changes made by this instance take effect once
+// InvalidateCache is called; changes made by other instances sharing the
+// database show up once entries expire. Expiry and TLS fingerprint pinning
+// are still checked on every request. A non-positive ttl disables the cache.
+func WithCache(ttl time.Duration) Option {
+	return func(m *Authenticator) {
+		if ttl > 0 {
+			m.cache = newTokenCache(ttl)
+		}
+	}
+}
+
+// InvalidateCache drops every cached token and permission set. Call it after
+// changing tokens, their permissions, roles or anything else permissions are
+// resolved from. It does nothing when the cache is disabled.
+func (m *Authenticator) InvalidateCache() {
+	if m.cache != nil {
+		m.cache.invalidate()
+	}
+}
+
+// tokenCache holds authenticated tokens and their permissions by key hash.
+type tokenCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	now func() time.Time
+
+	// generation is bumped on every invalidation, so a lookup that started
+	// before a change does not store its now stale result.
+	generation uint64
+	entries    map[string]cachedToken
+}
+
+type cachedToken struct {
+	token    storage.Token
+	perms    []*storage.Permission
+	storedAt time.Time
+}
+
+func newTokenCache(ttl time.Duration) *tokenCache {
+	return &tokenCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cachedToken),
+	}
+}
+
+// get returns a copy of the cached token for keyHash and its permissions if
+// they have not expired. The permissions are shared and must not be modified.
+func (c *tokenCache) get(keyHash string) (*storage.Token, []*storage.Permission, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[keyHash]
+	if !ok {
+		return nil, nil, false
+	}
+	if c.now().Sub(e.storedAt) >= c.ttl {
+		delete(c.entries, keyHash)
+		return nil, nil, false
+	}
+	token := e.token
+	return &token, e.perms, true
+}
+
+// currentGeneration returns the invalidation generation to pass to a later put.
+func (c *tokenCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put stores a copy of token and its permissions unless the cache was
+// invalidated since gen. Expired entries are dropped along the way, so the
+// cache never holds more than the tokens used within a TTL.
+func (c *tokenCache) put(gen uint64, keyHash string, token *storage.Token, perms []*storage.Permission) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.generation {
+		return
+	}
+	now := c.now()
+	for hash, e := range c.entries {
+		if now.Sub(e.storedAt) >= c.ttl {
+			delete(c.entries, hash)
+		}
+	}
+	c.entries[keyHash] = cachedToken{token: *token, perms: perms, storedAt: now}
+}
+
+// invalidate drops every entry.
+func (c *tokenCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// serveKey sends a request with the given API key through handler and
+// returns the status.
+func serveKey(handler http.Handler, key string) int {
+	req := httptest.NewRequest("GET", "/dnszone", nil)
+	req.Header.Set("AccessKey", key)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuthMiddleware_CacheServesWithoutStorage(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "scoped-token", false, "scoped-key")
+	tokenStore.permissions[token.ID] = []*storage.Permission{
+		{ID: 1, TokenID: 2, ZoneID: 100, AllowedActions: []string{"list_records"}},
+	}
+	m := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"), WithCache(time.Minute))
+
+	var gotPerms []*storage.Permission
+	handler := m.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPerms = PermissionsFromContext(r.Context())
+	}))
+
+	if code := serveKey(handler, "scoped-key"); code != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", code)
+	}
+
+	tokenStore.getByHashErr = errors.New("database locked")
+	tokenStore.getPermsErr = errors.New("database locked")
+	gotPerms = nil
+	if code := serveKey(handler, "scoped-key"); code != http.StatusOK {
+		t.Errorf("cached request: status %d, want 200", code)
+	}
+	if len(gotPerms) != 1 || gotPerms[0].ZoneID != 100 {
+		t.Errorf("cached permissions = %v, want the zone 100 permission", gotPerms)
+	}
+
+	m.InvalidateCache()
+	if code := serveKey(handler, "scoped-key"); code != http.StatusInternalServerError {
+		t.Errorf("request after invalidation: status %d, want 500", code)
+	}
+}
+
+func TestAuthMiddleware_CacheChecksExpiry(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "ci-token", false, "ci-key")
+	token.ExpiresAt = time.Now().Add(time.Hour)
+	m := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"), WithCache(time.Minute))
+	handler := m.Authenticate(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	if code := serveKey(handler, "ci-key"); code != http.StatusOK {
+		t.Fatalf("first request: status %d, want 200", code)
+	}
+
+	// The cached copy expires on its own schedule
+	m.cache.mu.Lock()
+	e := m.cache.entries[token.KeyHash]
+	e.token.ExpiresAt = time.Now().Add(-time.Second)
+	m.cache.entries[token.KeyHash] = e
+	m.cache.mu.Unlock()
+
+	if code := serveKey(handler, "ci-key"); code != http.StatusUnauthorized {
+		t.Errorf("expired cached token: status %d, want 401", code)
+	}
+}
+
+func TestAuthMiddleware_CacheSkipsPendingPin(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "ci-token", false, "ci-key")
+	token.PinTLSFingerprint = true
+	m := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"),
+		WithFingerprints(headerFingerprints("X-Client-Fingerprint")), WithCache(time.Minute))
+	handler := m.Authenticate(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/dnszone", nil)
+	req.Header.Set("AccessKey", "ci-key")
+	req.Header.Set("X-Client-Fingerprint", "aa:bb")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if _, _, ok := m.cache.get(token.KeyHash); ok {
+		t.Error("token waiting for its fingerprint pin was cached")
+	}
+}
+
+func TestTokenCache(t *testing.T) {
+	t.Parallel()
+	c := newTokenCache(time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	c.now = func() time.Time { return now }
+	token := &storage.Token{ID: 1, Name: "ci"}
+
+	c.put(c.currentGeneration(), "hash-1", token, nil)
+	got, _, ok := c.get("hash-1")
+	if !ok || got.ID != 1 {
+		t.Fatalf("get() = %v, %v; want the stored token", got, ok)
+	}
+	got.Name = "changed"
+	if again, _, _ := c.get("hash-1"); again.Name != "ci" {
+		t.Error("get() returned the cached token itself, not a copy")
+	}
+
+	now = now.Add(time.Minute)
+	if _, _, ok := c.get("hash-1"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	// A lookup that started before an invalidation is not stored
+	gen := c.currentGeneration()
+	c.invalidate()
+	c.put(gen, "hash-1", token, nil)
+	if _, _, ok := c.get("hash-1"); ok {
+		t.Error("stale lookup was stored after an invalidation")
+	}
+
+	// Expired entries are dropped when others are stored
+	c.put(c.currentGeneration(), "hash-1", token, nil)
+	now = now.Add(time.Minute)
+	c.put(c.currentGeneration(), "hash-2", token, nil)
+	if len(c.entries) != 1 {
+		t.Errorf("cache holds %d entries, want 1", len(c.entries))
+	}
+}
+
+func TestWithCache_Disabled(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	m := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"), WithCache(0))
+	if m.cache != nil {
+		t.Error("a zero TTL enabled the cache")
+	}
+	m.InvalidateCache() // does nothing
+}
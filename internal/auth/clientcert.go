@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// ClientCertTokens looks up the token a client certificate is mapped to.
+type ClientCertTokens interface {
+	GetTokenByClientCert(ctx context.Context, commonName string) (*storage.Token, error)
+}
+
+// WithClientCertificates authenticates requests without an AccessKey header
+// by their client certificate, as the token its subject common name is mapped
+// to. Only certificates verified against the TLS listener's client CA count,
+// so this has no effect unless TLS_CLIENT_CA is set.
+func WithClientCertificates(tokens ClientCertTokens) Option {
+	return func(m *Authenticator) {
+		m.clientCerts = tokens
+	}
+}
+
+// ClientCertName returns the subject common name of the client certificate
+// a request was made with, or "" if it has none that was verified.
+func ClientCertName(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// clientCertTokens maps common names to tokens.
+type clientCertTokens map[string]*storage.Token
+
+func (c clientCertTokens) GetTokenByClientCert(_ context.Context, commonName string) (*storage.Token, error) {
+	if token, ok := c[commonName]; ok {
+		return token, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+// withVerifiedCert returns req as if made with a verified client certificate.
+func withVerifiedCert(req *http.Request, commonName string) *http.Request {
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{leaf},
+		VerifiedChains:   [][]*x509.Certificate{{leaf}},
+	}
+	return req
+}
+
+func TestClientCertName(t *testing.T) {
+	t.Parallel()
+	plain := httptest.NewRequest(http.MethodGet, "/", nil)
+	if name := ClientCertName(plain); name != "" {
+		t.Errorf("ClientCertName(plain HTTP) = %q, want empty", name)
+	}
+
+	// A certificate the listener did not verify does not count
+	unverified := httptest.NewRequest(http.MethodGet, "/", nil)
+	unverified.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "ci"}}},
+	}
+	if name := ClientCertName(unverified); name != "" {
+		t.Errorf("ClientCertName(unverified) = %q, want empty", name)
+	}
+
+	if name := ClientCertName(withVerifiedCert(httptest.NewRequest(http.MethodGet, "/", nil), "ci")); name != "ci" {
+		t.Errorf("ClientCertName(verified) = %q, want ci", name)
+	}
+}
+
+func TestAuthMiddleware_ClientCertificate(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	scoped := &storage.Token{ID: 2, Name: "billing"}
+	tokenStore.permissions[scoped.ID] = []*storage.Permission{{ID: 1, TokenID: 2, ZoneID: 100}}
+	certs := clientCertTokens{
+		"billing.internal": scoped,
+		"expired.internal": {ID: 3, Name: "old", ExpiresAt: time.Now().Add(-time.Hour)},
+	}
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		commonName string
+		wantStatus int
+	}{
+		{"mapped", []Option{WithClientCertificates(certs)}, "billing.internal", http.StatusOK},
+		{"unmapped", []Option{WithClientCertificates(certs)}, "unknown.internal", http.StatusUnauthorized},
+		{"expired token", []Option{WithClientCertificates(certs)}, "expired.internal", http.StatusUnauthorized},
+		{"disabled", nil, "billing.internal", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var gotToken *storage.Token
+			var gotPerms []*storage.Permission
+			handler := NewAuthenticator(tokenStore, bootstrap, tt.opts...).Authenticate(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					gotToken = TokenFromContext(r.Context())
+					gotPerms = PermissionsFromContext(r.Context())
+				}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, withVerifiedCert(httptest.NewRequest(http.MethodGet, "/dnszone", nil), tt.commonName))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && (gotToken != scoped || len(gotPerms) != 1) {
+				t.Errorf("got token %+v with %d permissions, want billing with 1", gotToken, len(gotPerms))
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_AccessKeyTakesPrecedenceOverClientCert(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	admin := tokenStore.addToken(1, "admin", true, "admin-key")
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+	certs := clientCertTokens{"billing.internal": {ID: 2, Name: "billing"}}
+
+	var gotToken *storage.Token
+	handler := NewAuthenticator(tokenStore, bootstrap, WithClientCertificates(certs)).Authenticate(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotToken = TokenFromContext(r.Context())
+		}))
+
+	req := withVerifiedCert(httptest.NewRequest(http.MethodGet, "/dnszone", nil), "billing.internal")
+	req.Header.Set("AccessKey", "admin-key")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotToken != admin {
+		t.Errorf("got token %+v, want the AccessKey token", gotToken)
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+var (
+	// ErrFingerprintMissing is returned when a pinned token is used without a TLS fingerprint.
+	ErrFingerprintMissing = errors.New("auth: TLS fingerprint missing")
+	// ErrFingerprintMismatch is returned when a pinned token is used from a different TLS client.
+	ErrFingerprintMismatch = errors.New("auth: TLS fingerprint mismatch")
+)
+
+// FingerprintPinner records the TLS fingerprint a token is pinned to.
+type FingerprintPinner interface {
+	PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error)
+}
+
+// FingerprintSource reads the TLS fingerprint of the client that made a request.
+type FingerprintSource struct {
+	// Header names the header carrying the client's JA3/JA4 fingerprint, set
+	// by a TLS terminator in front of the proxy; empty if there is none.
+	Header string
+	// TrustedProxies are the addresses of the TLS terminators that may set
+	// Header. It is ignored on requests from any other address.
+	TrustedProxies []netip.Prefix
+}
+
+// Fingerprint returns the TLS fingerprint of the client that made r, or "" if
+// it is not known. When the proxy terminates TLS itself, it is the SHA-256
+// digest of the client certificate, as "sha256:<hex>": Header could only have
+// been set by the client. Otherwise it is the value of Header on requests
+// from TrustedProxies. Header is removed from every request it is not read
+// from, so handlers and logs after this never see a forged value.
+func (s *FingerprintSource) Fingerprint(r *http.Request) string {
+	if r.TLS != nil {
+		if s.Header != "" {
+			r.Header.Del(s.Header)
+		}
+		if len(r.TLS.PeerCertificates) == 0 {
+			return ""
+		}
+		sum := sha256.Sum256(r.TLS.PeerCertificates[0].Raw)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if s.Header == "" {
+		return ""
+	}
+	if !s.trusted(r.RemoteAddr) {
+		r.Header.Del(s.Header)
+		return ""
+	}
+	return r.Header.Get(s.Header)
+}
+
+// trusted reports whether a connection's remote address is a trusted proxy.
+func (s *FingerprintSource) trusted(remoteAddr string) bool {
+	addrPort, err := netip.ParseAddrPort(remoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range s.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFingerprint enforces TLS fingerprint pinning for a token. fingerprint
+// is the one FingerprintSource reads for the request. The first use of a
+// pinned token records its fingerprint; later uses must present the same one.
+func CheckFingerprint(ctx context.Context, pinner FingerprintPinner, token *storage.Token, fingerprint string) error {
+	if !token.PinTLSFingerprint {
+		return nil
+	}
+	if fingerprint == "" {
+		return ErrFingerprintMissing
+	}
+
+	pinned := token.TLSFingerprint
+	if pinned == "" {
+		var err error
+		pinned, err = pinner.PinTokenFingerprint(ctx, token.ID, fingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to pin TLS fingerprint: %w", err)
+		}
+	}
+	if pinned != fingerprint {
+		return ErrFingerprintMismatch
+	}
+	return nil
+}
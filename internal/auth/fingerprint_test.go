@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+type pinnerFunc func(ctx context.Context, id int64, fingerprint string) (string, error)
+
+func (f pinnerFunc) PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error) {
+	return f(ctx, id, fingerprint)
+}
+
+func TestCheckFingerprint(t *testing.T) {
+	t.Parallel()
+
+	pinFirst := pinnerFunc(func(_ context.Context, _ int64, fp string) (string, error) { return fp, nil })
+	pinnedElsewhere := pinnerFunc(func(context.Context, int64, string) (string, error) { return "other", nil })
+	dbErr := errors.New("database error")
+	failing := pinnerFunc(func(context.Context, int64, string) (string, error) { return "", dbErr })
+
+	tests := []struct {
+		name        string
+		token       storage.Token
+		pinner      FingerprintPinner
+		fingerprint string
+		want        error
+	}{
+		{"not pinned", storage.Token{}, failing, "", nil},
+		{"missing", storage.Token{PinTLSFingerprint: true}, pinFirst, "", ErrFingerprintMissing},
+		{"first use", storage.Token{PinTLSFingerprint: true}, pinFirst, "fp", nil},
+		{"lost race to another client", storage.Token{PinTLSFingerprint: true}, pinnedElsewhere, "fp", ErrFingerprintMismatch},
+		{"matches", storage.Token{PinTLSFingerprint: true, TLSFingerprint: "fp"}, failing, "fp", nil},
+		{"mismatch", storage.Token{PinTLSFingerprint: true, TLSFingerprint: "fp"}, failing, "other", ErrFingerprintMismatch},
+		{"store error", storage.Token{PinTLSFingerprint: true}, failing, "fp", dbErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := CheckFingerprint(context.Background(), tt.pinner, &tt.token, tt.fingerprint)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("CheckFingerprint() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// headerFingerprints reads fingerprints from header, trusting the address
+// httptest requests come from.
+func headerFingerprints(header string) *FingerprintSource {
+	return &FingerprintSource{Header: header, TrustedProxies: []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}}
+}
+
+func TestFingerprintSource(t *testing.T) {
+	t.Parallel()
+	source := headerFingerprints("X-JA4")
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	sum := sha256.Sum256(cert.Raw)
+	certFingerprint := "sha256:" + hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		tls        *tls.ConnectionState
+		want       string
+	}{
+		{"trusted proxy", "192.0.2.7:4711", nil, "t13d_aaa"},
+		{"trusted proxy over IPv6-mapped IPv4", "[::ffff:192.0.2.7]:4711", nil, "t13d_aaa"},
+		{"untrusted client", "198.51.100.9:4711", nil, ""},
+		{"unparsable address", "pipe", nil, ""},
+		{"TLS terminated by the proxy", "192.0.2.7:4711", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, certFingerprint},
+		{"TLS without a client certificate", "192.0.2.7:4711", &tls.ConnectionState{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr, req.TLS = tt.remoteAddr, tt.tls
+			req.Header.Set("X-JA4", "t13d_aaa")
+			if got := source.Fingerprint(req); got != tt.want {
+				t.Errorf("Fingerprint() = %q, want %q", got, tt.want)
+			}
+			// The header stays only where it was trusted
+			if kept := req.Header.Get("X-JA4") != ""; kept != (tt.tls == nil && tt.want != "") {
+				t.Errorf("header kept = %v", kept)
+			}
+		})
+	}
+}
@@ -2,13 +2,14 @@ package auth
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
 
@@ -17,20 +18,67 @@ import (
 type Authenticator struct {
 	tokens    storage.TokenStore
 	bootstrap *BootstrapService
+
+	fingerprints *FingerprintSource // nil: pinning not enforced
+	clientCerts  ClientCertTokens
+	domains      DomainResolver
+	cache        *tokenCache // nil: disabled
+	logger       *slog.Logger
+}
+
+// DomainResolver expands a token's domain permissions into per-zone permissions.
+type DomainResolver interface {
+	Resolve(ctx context.Context, token *storage.Token) ([]*storage.Permission, error)
+}
+
+// WithDomainResolver adds the per-zone permissions resolved from a scoped
+// token's domain permissions to the permissions it loads. They come after the
+// token's own permissions, which therefore take precedence for a zone both cover.
+func WithDomainResolver(r DomainResolver) Option {
+	return func(m *Authenticator) {
+		m.domains = r
+	}
+}
+
+// Option configures an Authenticator.
+type Option func(*Authenticator)
+
+// WithFingerprints enforces TLS fingerprint pinning for tokens that request
+// it, reading the client's fingerprint from s. Without it, pinning is not
+// enforced.
+func WithFingerprints(s *FingerprintSource) Option {
+	return func(m *Authenticator) {
+		m.fingerprints = s
+	}
+}
+
+// WithLogger sets the logger denied requests are logged to. The default is
+// slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Authenticator) {
+		m.logger = logger
+	}
 }
 
 // NewAuthenticator creates a new authentication middleware.
-func NewAuthenticator(tokens storage.TokenStore, bootstrap *BootstrapService) *Authenticator {
-	return &Authenticator{
+func NewAuthenticator(tokens storage.TokenStore, bootstrap *BootstrapService, opts ...Option) *Authenticator {
+	m := &Authenticator{
 		tokens:    tokens,
 		bootstrap: bootstrap,
+		logger:    slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
 }
 
 // Authenticate is middleware that validates the API key and sets authentication context.
 // It checks in order:
 // 1. Master key (only valid during UNCONFIGURED state)
-// 2. Token from the tokens table (SHA256 hash lookup)
+// 2. Token from the tokens table (looked up by ID for <id>.<secret> keys and by SHA256 hash otherwise, cached with WithCache); expired or fingerprint-mismatched tokens are rejected
+// 3. Without an AccessKey header, the token a verified client certificate is
+// mapped to, when client certificates are enabled
 //
 // On success, it sets:
 // - Token in context (nil for master key)
@@ -39,9 +87,20 @@ func NewAuthenticator(tokens storage.TokenStore, bootstrap *BootstrapService) *A
 // - IsAdmin flag
 func (m *Authenticator) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the fingerprint first, so a forged header is removed from
+		// every request
+		var fingerprint string
+		if m.fingerprints != nil {
+			fingerprint = m.fingerprints.Fingerprint(r)
+		}
+
 		// Extract API key from AccessKey header
 		apiKey := extractAccessKey(r)
 		if apiKey == "" {
+			if name := ClientCertName(r); name != "" && m.clientCerts != nil {
+				m.authenticateClientCert(w, r, next, name)
+				return
+			}
 			writeJSONError(w, http.StatusUnauthorized, "missing API key")
 			return
 		}
@@ -64,11 +123,9 @@ func (m *Authenticator) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Try token authentication using SHA256 hash
-		hash := sha256.Sum256([]byte(apiKey))
-		keyHash := hex.EncodeToString(hash[:])
-
-		token, err := m.tokens.GetTokenByHash(ctx, keyHash)
+		// Try token authentication, by ID for <id>.<secret> keys and by
+		// SHA256 hash otherwise
+		token, perms, err := m.lookupToken(ctx, apiKey)
 		if err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
 				writeJSONError(w, http.StatusUnauthorized, "invalid API key")
@@ -77,40 +134,136 @@ func (m *Authenticator) Authenticate(next http.Handler) http.Handler {
 			writeJSONError(w, http.StatusInternalServerError, "internal error")
 			return
 		}
-
-		// Token found - set context
-		ctx = WithToken(ctx, token)
-		ctx = WithMasterKey(ctx, false)
-		ctx = WithAdmin(ctx, token.IsAdmin)
-
-		// Load permissions for scoped tokens
-		if !token.IsAdmin {
-			perms, err := m.loadPermissions(ctx, token.ID)
-			if err != nil {
+		if token.IsExpired(time.Now()) {
+			writeJSONError(w, http.StatusUnauthorized, "token expired")
+			return
+		}
+		if m.fingerprints != nil {
+			err := CheckFingerprint(ctx, m.tokens, token, fingerprint)
+			switch {
+			case errors.Is(err, ErrFingerprintMissing), errors.Is(err, ErrFingerprintMismatch):
+				writeJSONError(w, http.StatusUnauthorized, "TLS fingerprint mismatch")
+				return
+			case err != nil:
 				writeJSONError(w, http.StatusInternalServerError, "internal error")
 				return
 			}
-			ctx = WithPermissions(ctx, perms)
 		}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		serveWithPermissions(w, r, next, token, perms)
 	})
 }
 
+// lookupToken returns the token for an API key and, for scoped tokens, its
+// permissions, from the cache when it is enabled.
+func (m *Authenticator) lookupToken(ctx context.Context, apiKey string) (*storage.Token, []*storage.Permission, error) {
+	var gen uint64
+	keyHash := HashToken(apiKey)
+	if m.cache != nil {
+		if token, perms, ok := m.cache.get(keyHash); ok {
+			return token, perms, nil
+		}
+		gen = m.cache.currentGeneration()
+	}
+
+	token, err := LookupKey(ctx, m.tokens, apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	var perms []*storage.Permission
+	if !token.IsAdmin {
+		if perms, err = m.loadPermissions(ctx, token); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// A token waiting for its TLS fingerprint to be pinned is loaded again,
+	// so the pin is picked up
+	if m.cache != nil && (!token.PinTLSFingerprint || token.TLSFingerprint != "") {
+		m.cache.put(gen, keyHash, token, perms)
+	}
+	return token, perms, nil
+}
+
+// authenticateClientCert serves a request as the token its client
+// certificate's common name is mapped to.
+func (m *Authenticator) authenticateClientCert(w http.ResponseWriter, r *http.Request, next http.Handler, commonName string) {
+	token, err := m.clientCerts.GetTokenByClientCert(r.Context(), commonName)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			writeJSONError(w, http.StatusUnauthorized, "client certificate not mapped to a token")
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if token.IsExpired(time.Now()) {
+		writeJSONError(w, http.StatusUnauthorized, "token expired")
+		return
+	}
+	m.serveToken(w, r, next, token)
+}
+
+// serveToken loads the permissions of an authenticated token and serves the
+// request as it.
+func (m *Authenticator) serveToken(w http.ResponseWriter, r *http.Request, next http.Handler, token *storage.Token) {
+	var perms []*storage.Permission
+	if !token.IsAdmin {
+		var err error
+		if perms, err = m.loadPermissions(r.Context(), token); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+	serveWithPermissions(w, r, next, token, perms)
+}
+
+// serveWithPermissions sets an authenticated token and, for scoped tokens,
+// its permissions on the request context and calls next.
+func serveWithPermissions(w http.ResponseWriter, r *http.Request, next http.Handler, token *storage.Token, perms []*storage.Permission) {
+	ctx := WithToken(r.Context(), token)
+	ctx = WithMasterKey(ctx, false)
+	ctx = WithAdmin(ctx, token.IsAdmin)
+	if !token.IsAdmin {
+		ctx = WithPermissions(ctx, perms)
+	}
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// TokenPermissions loads the current permissions of a token, bypassing the
+// cache. It is used to re-check work a token authorized earlier, such as
+// scheduled changes.
+func (m *Authenticator) TokenPermissions(ctx context.Context, token *storage.Token) ([]*storage.Permission, error) {
+	return m.loadPermissions(ctx, token)
+}
+
 // loadPermissions loads permissions for a token.
-// Uses the PermissionStore interface if available on the tokens store.
-func (m *Authenticator) loadPermissions(ctx context.Context, tokenID int64) ([]*storage.Permission, error) {
+// Uses the PermissionStore interface if available on the tokens store, and
+// adds the permissions resolved from domain permissions.
+func (m *Authenticator) loadPermissions(ctx context.Context, token *storage.Token) ([]*storage.Permission, error) {
 	// Check if the token store also implements GetPermissionsForToken
 	type permissionLoader interface {
 		GetPermissionsForToken(ctx context.Context, tokenID int64) ([]*storage.Permission, error)
 	}
 
+	// No permission loading available - start from an empty slice
+	perms := []*storage.Permission{}
 	if loader, ok := m.tokens.(permissionLoader); ok {
-		return loader.GetPermissionsForToken(ctx, tokenID)
+		loaded, err := loader.GetPermissionsForToken(ctx, token.ID)
+		if err != nil {
+			return nil, err
+		}
+		perms = loaded
 	}
 
-	// No permission loading available - return empty slice
-	return []*storage.Permission{}, nil
+	if m.domains != nil {
+		resolved, err := m.domains.Resolve(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, resolved...)
+	}
+	return perms, nil
 }
 
 // RequireAdmin is middleware that requires admin privileges.
@@ -129,7 +282,8 @@ func (m *Authenticator) RequireAdmin(next http.Handler) http.Handler {
 // CheckPermissions is middleware that validates token permissions for proxy requests.
 // It must be used after Authenticate middleware.
 // Admin tokens and master key bypass permission checks.
-// Scoped tokens are validated against their permissions.
+// Scoped tokens are validated against their permissions; denied requests
+// get a reason code, are logged and are counted by reason.
 func (m *Authenticator) CheckPermissions(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -153,7 +307,8 @@ func (m *Authenticator) CheckPermissions(next http.Handler) http.Handler {
 			return
 		}
 
-		if req.Action == ActionUpdateZone || req.Action == ActionCreateZone || req.Action == ActionCheckAvailability || req.Action == ActionImportRecords || req.Action == ActionExportRecords || req.Action == ActionEnableDNSSEC || req.Action == ActionDisableDNSSEC || req.Action == ActionIssueCertificate || req.Action == ActionGetStatistics || req.Action == ActionTriggerDNSScan || req.Action == ActionGetDNSScanResult {
+		if req.Action.IsAdminOnly() {
+			m.logDenial(r, req, &DenialError{Reason: ReasonAdminRequired})
 			writeJSONErrorWithCode(w, http.StatusForbidden, "admin_required", "This endpoint requires an admin token.")
 			return
 		}
@@ -176,7 +331,10 @@ func (m *Authenticator) CheckPermissions(next http.Handler) http.Handler {
 
 		// Check permissions
 		if err := CheckPermission(keyInfo, req); err != nil {
-			writeJSONError(w, http.StatusForbidden, "permission denied")
+			denial := &DenialError{Reason: ReasonNoPermission}
+			errors.As(err, &denial)
+			m.logDenial(r, req, denial)
+			writeDenial(w, denial)
 			return
 		}
 
@@ -184,6 +342,23 @@ func (m *Authenticator) CheckPermissions(next http.Handler) http.Handler {
 	})
 }
 
+// logDenial logs and counts a denied request.
+func (m *Authenticator) logDenial(r *http.Request, req *Request, denial *DenialError) {
+	metrics.RecordPermissionDenial(denial.Reason)
+	attrs := []any{"reason", denial.Reason, "action", string(req.Action), "zone_id", req.ZoneID,
+		"method", r.Method, "path", r.URL.Path}
+	if token := TokenFromContext(r.Context()); token != nil {
+		attrs = append(attrs, "token_id", token.ID, "token", token.Name)
+	}
+	if denial.PermissionID != 0 {
+		attrs = append(attrs, "permission_id", denial.PermissionID)
+	}
+	if req.RecordType != "" {
+		attrs = append(attrs, "record_type", req.RecordType)
+	}
+	m.logger.Info("request denied", attrs...)
+}
+
 // GetKeyInfo retrieves KeyInfo from request context by reconstructing it from Token and Permissions.
 // Returns nil if no token is present in the context.
 // This is for backward compatibility with code that expects KeyInfo.
@@ -223,6 +398,27 @@ func writeJSONError(w http.ResponseWriter, status int, message string) {
 	}
 }
 
+// deniedResponse is the body of a 403 response for a scoped token.
+type deniedResponse struct {
+	Error        string `json:"error"`
+	Reason       string `json:"reason"`
+	Message      string `json:"message,omitempty"`
+	PermissionID int64  `json:"permission_id,omitempty"`
+}
+
+// writeDenial writes a 403 response explaining a denial.
+func writeDenial(w http.ResponseWriter, denial *DenialError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	//nolint:errcheck // Encoding errors are not critical for error responses
+	json.NewEncoder(w).Encode(deniedResponse{
+		Error:        "permission denied",
+		Reason:       denial.Reason,
+		Message:      denial.Message,
+		PermissionID: denial.PermissionID,
+	})
+}
+
 // writeJSONErrorWithCode writes a JSON error response with code and message.
 func writeJSONErrorWithCode(w http.ResponseWriter, status int, code, message string) {
 	w.Header().Set("Content-Type", "application/json")
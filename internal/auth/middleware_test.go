@@ -7,9 +7,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -21,6 +24,7 @@ import (
 // detailed testing of different error conditions.
 type authTestTokenStore struct {
 	tokens        map[string]*storage.Token // keyed by hash
+	keyTokens     map[string]*storage.Token // tokens for <id>.<secret> keys, keyed by secret hash
 	permissions   map[int64][]*storage.Permission
 	hasAdminToken bool
 	getByHashErr  error
@@ -31,6 +35,7 @@ type authTestTokenStore struct {
 func newAuthTestTokenStore() *authTestTokenStore {
 	return &authTestTokenStore{
 		tokens:      make(map[string]*storage.Token),
+		keyTokens:   make(map[string]*storage.Token),
 		permissions: make(map[int64][]*storage.Permission),
 	}
 }
@@ -46,6 +51,17 @@ func (m *authTestTokenStore) CreateToken(ctx context.Context, name string, isAdm
 	return token, nil
 }
 
+func (m *authTestTokenStore) CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*storage.Token, error) {
+	token := &storage.Token{
+		ID:      int64(len(m.tokens) + len(m.keyTokens) + 1),
+		KeyHash: secretHash,
+		Name:    name,
+		IsAdmin: isAdmin,
+	}
+	m.keyTokens[secretHash] = token
+	return token, nil
+}
+
 func (m *authTestTokenStore) GetTokenByHash(ctx context.Context, keyHash string) (*storage.Token, error) {
 	if m.getByHashErr != nil {
 		return nil, m.getByHashErr
@@ -56,6 +72,19 @@ func (m *authTestTokenStore) GetTokenByHash(ctx context.Context, keyHash string)
 	return nil, storage.ErrNotFound
 }
 
+func (m *authTestTokenStore) GetTokenByKey(ctx context.Context, id int64, secretHash string) (*storage.Token, error) {
+	if m.getByHashErr != nil {
+		return nil, m.getByHashErr
+	}
+	if token, ok := m.tokens[secretHash]; ok && token.ID == id {
+		return token, nil
+	}
+	if token, ok := m.keyTokens[secretHash]; ok && token.ID == id {
+		return token, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
 func (m *authTestTokenStore) GetTokenByID(ctx context.Context, id int64) (*storage.Token, error) {
 	for _, token := range m.tokens {
 		if token.ID == id {
@@ -83,6 +112,53 @@ func (m *authTestTokenStore) DeleteToken(ctx context.Context, id int64) error {
 	return storage.ErrNotFound
 }
 
+func (m *authTestTokenStore) SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token.ExpiresAt = expiresAt
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (m *authTestTokenStore) SetTokenAccount(ctx context.Context, id int64, account string) error {
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token.Account = account
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (m *authTestTokenStore) SetTokenPinning(ctx context.Context, id int64, pin bool) error {
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token.PinTLSFingerprint = pin
+			token.TLSFingerprint = ""
+			return nil
+		}
+	}
+	return storage.ErrNotFound
+}
+
+func (m *authTestTokenStore) PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error) {
+	for _, token := range m.tokens {
+		if token.ID == id {
+			if token.TLSFingerprint == "" {
+				token.TLSFingerprint = fingerprint
+			}
+			return token.TLSFingerprint, nil
+		}
+	}
+	return "", storage.ErrNotFound
+}
+
+func (m *authTestTokenStore) RecordTokenUsage(ctx context.Context, usage []storage.TokenUsage) error {
+	return nil
+}
+
 func (m *authTestTokenStore) HasAnyAdminToken(ctx context.Context) (bool, error) {
 	if m.hasAdminErr != nil {
 		return false, m.hasAdminErr
@@ -441,6 +517,174 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_IDKey(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	tokenStore.addToken(5, "ci-token", true, "ci-secret")
+	middleware := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"))
+
+	var gotID int64
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = TokenFromContext(r.Context()).ID
+	}))
+
+	if code := serveKey(handler, FormatKey(5, "ci-secret")); code != http.StatusOK || gotID != 5 {
+		t.Errorf("status = %d, token %d; want 200 for token 5", code, gotID)
+	}
+	// The secret of token 5 does not work with another ID
+	if code := serveKey(handler, FormatKey(6, "ci-secret")); code != http.StatusUnauthorized {
+		t.Errorf("wrong ID: status = %d, want 401", code)
+	}
+	// Keys without an ID are still looked up by hash
+	if code := serveKey(handler, "ci-secret"); code != http.StatusOK {
+		t.Errorf("key without an ID: status = %d, want 200", code)
+	}
+
+	// The secret of a token issued with an <id>.<secret> key is not a key on its own
+	issued, err := tokenStore.CreateKeyToken(context.Background(), "issued", true, HashToken("issued-secret"))
+	if err != nil {
+		t.Fatalf("CreateKeyToken() error = %v", err)
+	}
+	if code := serveKey(handler, FormatKey(issued.ID, "issued-secret")); code != http.StatusOK || gotID != issued.ID {
+		t.Errorf("issued key: status = %d, token %d; want 200 for token %d", code, gotID, issued.ID)
+	}
+	if code := serveKey(handler, "issued-secret"); code != http.StatusUnauthorized {
+		t.Errorf("issued secret without its ID: status = %d, want 401", code)
+	}
+}
+
+func TestAuthMiddleware_ExpiredToken(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "ci-token", false, "ci-key")
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+	middleware := NewAuthenticator(tokenStore, bootstrap)
+
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	req := httptest.NewRequest("GET", "/dnszone", nil)
+	req.Header.Set("AccessKey", "ci-key")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["error"] != "token expired" {
+		t.Errorf("error = %q, want 'token expired'", resp["error"])
+	}
+}
+
+func TestAuthMiddleware_PinnedFingerprint(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "ci-token", false, "ci-key")
+	token.PinTLSFingerprint = true
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+	middleware := NewAuthenticator(tokenStore, bootstrap, WithFingerprints(headerFingerprints("X-JA4")))
+
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		fingerprint string
+		want        int
+	}{
+		{"first use pins", "t13d1516h2_aaa", http.StatusOK},
+		{"same client", "t13d1516h2_aaa", http.StatusOK},
+		{"different client", "t13d1516h2_bbb", http.StatusUnauthorized},
+		{"no fingerprint", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/dnszone", nil)
+		req.Header.Set("AccessKey", "ci-key")
+		if tt.fingerprint != "" {
+			req.Header.Set("X-JA4", tt.fingerprint)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+	if token.TLSFingerprint != "t13d1516h2_aaa" {
+		t.Errorf("pinned fingerprint = %q, want the first one seen", token.TLSFingerprint)
+	}
+}
+
+func TestAuthMiddleware_FingerprintFromUntrustedClient(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "ci-token", false, "ci-key")
+	token.PinTLSFingerprint = true
+	token.TLSFingerprint = "t13d1516h2_aaa"
+	tokenStore.addToken(3, "other-token", false, "other-key")
+	middleware := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"),
+		WithFingerprints(headerFingerprints("X-JA4")))
+
+	var seen string
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get("X-JA4")
+	}))
+	serve := func(key string) int {
+		req := httptest.NewRequest("GET", "/dnszone", nil)
+		req.RemoteAddr = "198.51.100.9:4711"
+		req.Header.Set("AccessKey", key)
+		req.Header.Set("X-JA4", "t13d1516h2_aaa")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A client cannot claim the pinned fingerprint by setting the header itself
+	if code := serve("ci-key"); code != http.StatusUnauthorized {
+		t.Errorf("pinned token: status = %d, want 401", code)
+	}
+	if code := serve("other-key"); code != http.StatusOK || seen != "" {
+		t.Errorf("status = %d and handler saw %q; want 200 and the header removed", code, seen)
+	}
+}
+
+func TestAuthMiddleware_PinnedFingerprintNotEnforcedWithoutHeader(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "ci-token", false, "ci-key")
+	token.PinTLSFingerprint = true
+	token.TLSFingerprint = "t13d1516h2_aaa"
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+	middleware := NewAuthenticator(tokenStore, bootstrap)
+
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/dnszone", nil)
+	req.Header.Set("AccessKey", "ci-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
 func TestAuthMiddleware_BootstrapServiceError(t *testing.T) {
 	t.Parallel()
 	tokenStore := newAuthTestTokenStore()
@@ -510,6 +754,59 @@ func TestAuthMiddleware_PermissionsLoadError(t *testing.T) {
 	}
 }
 
+// fakeDomainResolver returns fixed permissions for every token.
+type fakeDomainResolver struct {
+	perms []*storage.Permission
+	err   error
+}
+
+func (f *fakeDomainResolver) Resolve(context.Context, *storage.Token) ([]*storage.Permission, error) {
+	return f.perms, f.err
+}
+
+func TestAuthMiddleware_DomainResolver(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	tokenStore.hasAdminToken = true
+	token := tokenStore.addToken(2, "scoped-token", false, "scoped-key")
+	tokenStore.permissions[token.ID] = []*storage.Permission{
+		{ID: 1, TokenID: 2, ZoneID: 100, AllowedActions: []string{"list_records"}},
+	}
+	tokenStore.addToken(1, "admin-token", true, "admin-key")
+	resolver := &fakeDomainResolver{perms: []*storage.Permission{
+		{TokenID: 2, ZoneID: 200, AllowedActions: []string{"list_records"}},
+	}}
+	middleware := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"), WithDomainResolver(resolver))
+
+	var gotPerms []*storage.Permission
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPerms = PermissionsFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(key string) int {
+		req := httptest.NewRequest("GET", "/dnszone", nil)
+		req.Header.Set("AccessKey", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve("scoped-key"); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(gotPerms) != 2 || gotPerms[0].ZoneID != 100 || gotPerms[1].ZoneID != 200 {
+		t.Errorf("PermissionsFromContext() = %+v, want the token's permission then the resolved one", gotPerms)
+	}
+
+	resolver.err = errors.New("resolve error")
+	if code := serve("admin-key"); code != http.StatusOK {
+		t.Errorf("admin status = %d, want 200 without resolving", code)
+	}
+	if code := serve("scoped-key"); code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", code)
+	}
+}
+
 // --- RequireAdmin middleware tests ---
 
 func TestRequireAdmin_AdminUser(t *testing.T) {
@@ -1047,6 +1344,86 @@ func TestCheckPermissions_ValidPermissions(t *testing.T) {
 	}
 }
 
+func TestCheckPermissions_ReadOnlyAccess(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+	authenticator := NewAuthenticator(tokenStore, bootstrap)
+
+	token := &storage.Token{ID: 1, Name: "dashboard", IsAdmin: false}
+	perms := []*storage.Permission{
+		{ZoneID: 123, AllowedActions: []string{AccessRead}, RecordTypes: []string{"TXT"}},
+	}
+
+	handler := authenticator.CheckPermissions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"GET", "/dnszone", "", http.StatusOK},
+		{"GET", "/dnszone/123", "", http.StatusOK},
+		{"GET", "/dnszone/123/records", "", http.StatusOK},
+		{"POST", "/dnszone/123/records", `{"Type":3,"Name":"x","Value":"y"}`, http.StatusForbidden},
+		{"POST", "/dnszone/123/records/9", `{"Type":3,"Name":"x","Value":"y"}`, http.StatusForbidden},
+		{"DELETE", "/dnszone/123/records/9", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		ctx := WithAdmin(req.Context(), false)
+		ctx = WithToken(ctx, token)
+		ctx = WithPermissions(ctx, perms)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req.WithContext(ctx))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestCheckPermissions_DenialReason(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	var logs bytes.Buffer
+	authenticator := NewAuthenticator(tokenStore, NewBootstrapService(tokenStore, "master-key"),
+		WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+
+	handler := authenticator.CheckPermissions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	req := httptest.NewRequest("POST", "/dnszone/123/records", bytes.NewBufferString(`{"Type":4,"Name":"mx","Value":"a"}`))
+	ctx := WithAdmin(req.Context(), false)
+	ctx = WithToken(ctx, &storage.Token{ID: 1, Name: "acme"})
+	ctx = WithPermissions(ctx, []*storage.Permission{
+		{ID: 12, ZoneID: 123, AllowedActions: []string{"add_record"}, RecordTypes: []string{"TXT"}},
+	})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", rec.Code)
+	}
+	var resp deniedResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Error != "permission denied" || resp.Reason != ReasonRecordTypeNotAllowed || resp.PermissionID != 12 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	for _, want := range []string{`"reason":"record_type_not_allowed"`, `"permission_id":12`, `"token":"acme"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log %q does not contain %s", logs.String(), want)
+		}
+	}
+}
+
 func TestCheckPermissions_MissingZonePermission(t *testing.T) {
 	t.Parallel()
 	tokenStore := newAuthTestTokenStore()
@@ -1092,6 +1469,41 @@ func TestCheckPermissions_MissingZonePermission(t *testing.T) {
 	}
 }
 
+func TestCheckPermissions_BatchRecordsNeedsZonePermission(t *testing.T) {
+	t.Parallel()
+	tokenStore := newAuthTestTokenStore()
+	bootstrap := NewBootstrapService(tokenStore, "master-key")
+	authenticator := NewAuthenticator(tokenStore, bootstrap)
+
+	token := &storage.Token{ID: 1, Name: "test-token"}
+	// No record actions: the handler checks each operation
+	perms := []*storage.Permission{{ZoneID: 123, AllowedActions: []string{"list_records"}}}
+
+	handler := authenticator.CheckPermissions(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/dnszone/123/records/batch", http.StatusOK},
+		{"/dnszone/456/records/batch", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest("POST", tt.path, bytes.NewBufferString(`{"Operations":[]}`))
+		ctx := WithAdmin(req.Context(), false)
+		ctx = WithToken(ctx, token)
+		ctx = WithPermissions(ctx, perms)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req.WithContext(ctx))
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
 func TestCheckPermissions_MissingActionPermission(t *testing.T) {
 	t.Parallel()
 	tokenStore := newAuthTestTokenStore()
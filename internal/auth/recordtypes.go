@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recordTypes holds the bunny.net record type names, indexed by their numeric type.
+var recordTypes = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SPF", "Flatten", "PullZone", "SRV", "CAA", "PTR", "Script", "NS"}
+
+// recordTypeAliases maps alternative spellings of bunny-specific types to their
+// canonical name. The bunny.net dashboard labels type 5 "Redirect" while the API
+// calls it SPF, and type 6 is a flattened CNAME.
+var recordTypeAliases = map[string]string{
+	"REDIRECT":       "SPF",
+	"RDR":            "SPF",
+	"CNAMEFLATTEN":   "Flatten",
+	"FLATTENCNAME":   "Flatten",
+	"FLATTENEDCNAME": "Flatten",
+}
+
+// recordTypeKey folds a record type name for lookup: case-insensitive, ignoring
+// spaces, dashes and underscores so "PULL_ZONE" and "Pull Zone" both match PullZone.
+func recordTypeKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(name)))
+}
+
+// canonicalRecordTypes maps folded names and aliases to canonical names.
+var canonicalRecordTypes = func() map[string]string {
+	m := make(map[string]string, len(recordTypes)+len(recordTypeAliases))
+	for _, name := range recordTypes {
+		m[recordTypeKey(name)] = name
+	}
+	for alias, name := range recordTypeAliases {
+		m[alias] = name
+	}
+	return m
+}()
+
+// RecordTypeNames returns the canonical record type names accepted in permissions.
+func RecordTypeNames() []string {
+	return append([]string(nil), recordTypes...)
+}
+
+// NormalizeRecordType returns the canonical name for a record type, matching
+// case-insensitively and accepting aliases such as REDIRECT and CNAME_FLATTEN.
+// Returns false if the type is unknown.
+func NormalizeRecordType(name string) (string, bool) {
+	canonical, ok := canonicalRecordTypes[recordTypeKey(name)]
+	return canonical, ok
+}
+
+// NormalizeRecordTypes canonicalizes a permission's record types, dropping duplicates.
+// Returns an error naming the first unknown type.
+func NormalizeRecordTypes(names []string) ([]string, error) {
+	normalized := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		canonical, ok := NormalizeRecordType(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown record type %q", name)
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			normalized = append(normalized, canonical)
+		}
+	}
+	return normalized, nil
+}
+
+// recordTypeAllowed reports whether recordType is in the allowed list.
+// Both sides are compared by canonical name, so permissions stored as "SCRIPT"
+// match Script records. Unknown types are never allowed.
+func recordTypeAllowed(allowed []string, recordType string) bool {
+	want, ok := NormalizeRecordType(recordType)
+	if !ok {
+		return false
+	}
+	for _, t := range allowed {
+		if canonical, ok := NormalizeRecordType(t); ok && canonical == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestNormalizeRecordType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"TXT", "TXT", true},
+		{"txt", "TXT", true},
+		{" aaaa ", "AAAA", true},
+		{"SCRIPT", "Script", true},
+		{"script", "Script", true},
+		{"PULLZONE", "PullZone", true},
+		{"pull_zone", "PullZone", true},
+		{"Pull Zone", "PullZone", true},
+		{"SPF", "SPF", true},
+		{"REDIRECT", "SPF", true},
+		{"rdr", "SPF", true},
+		{"Flatten", "Flatten", true},
+		{"CNAME_FLATTEN", "Flatten", true},
+		{"flattened-cname", "Flatten", true},
+		{"", "", false},
+		{"WORKER", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeRecordType(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("NormalizeRecordType(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNormalizeRecordTypes(t *testing.T) {
+	t.Parallel()
+
+	got, err := NormalizeRecordTypes([]string{"script", "TXT", "SCRIPT", "redirect"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"Script", "TXT", "SPF"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := NormalizeRecordTypes([]string{"TXT", "WORKER"}); err == nil {
+		t.Error("expected error for unknown record type")
+	}
+}
+
+func TestRecordTypeNames(t *testing.T) {
+	t.Parallel()
+
+	names := RecordTypeNames()
+	if len(names) != 13 || names[11] != "Script" {
+		t.Fatalf("unexpected names: %v", names)
+	}
+	for i, name := range names {
+		if got := MapRecordTypeToString(i); got != name {
+			t.Errorf("MapRecordTypeToString(%d) = %q, want %q", i, got, name)
+		}
+	}
+
+	// The returned slice is a copy
+	names[0] = "changed"
+	if RecordTypeNames()[0] != "A" {
+		t.Error("RecordTypeNames should not expose the underlying slice")
+	}
+}
+
+func TestCheckPermission_BunnySpecificRecordTypes(t *testing.T) {
+	t.Parallel()
+	keyInfo := &KeyInfo{
+		KeyID: 1,
+		Permissions: []*storage.Permission{
+			{ZoneID: 10, AllowedActions: []string{"add_record"}, RecordTypes: []string{"SCRIPT", "redirect"}},
+		},
+	}
+
+	tests := []struct {
+		recordType string
+		wantErr    bool
+	}{
+		{"Script", false},
+		{"SPF", false},
+		{"PullZone", true},
+		{"Flatten", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		err := CheckPermission(keyInfo, &Request{Action: ActionAddRecord, ZoneID: 10, RecordType: tt.recordType})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckPermission(%q) error = %v, wantErr %v", tt.recordType, err, tt.wantErr)
+		}
+		if got := IsRecordTypePermitted(keyInfo, 10, tt.recordType); got == tt.wantErr {
+			t.Errorf("IsRecordTypePermitted(%q) = %v, want %v", tt.recordType, got, !tt.wantErr)
+		}
+	}
+}
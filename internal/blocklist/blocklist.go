@@ -0,0 +1,272 @@
+// Package blocklist rejects requests from blocked client addresses before they
+// reach authentication.
+//
+// Entries are IP addresses or CIDR networks stored in the database, so every
+// instance sharing it enforces them. Temporary bans carry an expiry and stop
+// matching once it passes. Each instance keeps an in-memory copy that is
+// reloaded periodically and immediately after changes made through it.
+package blocklist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// refreshInterval is how often entries added by other instances are picked
+// up and expired entries are pruned.
+const refreshInterval = 30 * time.Second
+
+// ErrInvalidCIDR is returned for an address or network that cannot be parsed.
+var ErrInvalidCIDR = errors.New("invalid IP address or CIDR")
+
+// Store defines the storage operations needed by the blocklist.
+type Store interface {
+	AddBlocklistEntry(ctx context.Context, e *storage.BlocklistEntry) (*storage.BlocklistEntry, error)
+	ListBlocklistEntries(ctx context.Context) ([]*storage.BlocklistEntry, error)
+	DeleteBlocklistEntry(ctx context.Context, id int64) error
+	PruneBlocklistEntries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Option configures a Blocklist.
+type Option func(*Blocklist)
+
+// WithTrustForwardedFor takes the client address from the last
+// X-Forwarded-For entry instead of the connection's remote address. Only
+// enable it behind a load balancer that appends to the header, otherwise
+// clients can choose the address that is checked.
+func WithTrustForwardedFor(trust bool) Option {
+	return func(b *Blocklist) {
+		b.trustForwardedFor.Store(trust)
+	}
+}
+
+// rule is a parsed entry.
+type rule struct {
+	prefix    netip.Prefix
+	expiresAt time.Time
+}
+
+// Blocklist checks client addresses against the stored entries.
+type Blocklist struct {
+	store             Store
+	logger            *slog.Logger
+	trustForwardedFor atomic.Bool
+	now               func() time.Time
+
+	mu    sync.RWMutex
+	rules []rule
+}
+
+// New creates a blocklist. Call Reload or Run to load the stored entries.
+func New(store Store, logger *slog.Logger, opts ...Option) *Blocklist {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	b := &Blocklist{store: store, logger: logger, now: time.Now}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// ParseCIDR parses an IP address or CIDR network into its canonical form.
+// A bare address is a single-host network (/32 or /128) and host bits are
+// cleared, so "203.0.113.9/24" becomes "203.0.113.0/24".
+func ParseCIDR(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidCIDR, s)
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidCIDR, s)
+	}
+	return prefix.Masked(), nil
+}
+
+// Add blocks an address or network. A positive ttl makes it a temporary ban.
+// Returns ErrInvalidCIDR for an unparsable network and storage.ErrDuplicate
+// if the network is already blocked.
+func (b *Blocklist) Add(ctx context.Context, cidr, reason, createdBy string, ttl time.Duration) (*storage.BlocklistEntry, error) {
+	prefix, err := ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &storage.BlocklistEntry{CIDR: prefix.String(), Reason: reason, CreatedBy: createdBy, CreatedAt: b.now()}
+	if ttl > 0 {
+		e.ExpiresAt = e.CreatedAt.Add(ttl)
+	}
+	created, err := b.store.AddBlocklistEntry(ctx, e)
+	if err != nil {
+		return nil, err
+	}
+
+	b.logger.Info("blocklist entry added", "cidr", created.CIDR, "reason", reason,
+		"created_by", createdBy, "ttl", ttl)
+	b.reloadAfterChange(ctx)
+	return created, nil
+}
+
+// Remove unblocks an entry by ID.
+// Returns storage.ErrNotFound if the entry doesn't exist.
+func (b *Blocklist) Remove(ctx context.Context, id int64) error {
+	if err := b.store.DeleteBlocklistEntry(ctx, id); err != nil {
+		return err
+	}
+	b.logger.Info("blocklist entry removed", "id", id)
+	b.reloadAfterChange(ctx)
+	return nil
+}
+
+// List returns the active entries, leaving out expired ones.
+func (b *Blocklist) List(ctx context.Context) ([]*storage.BlocklistEntry, error) {
+	entries, err := b.store.ListBlocklistEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := b.now()
+	active := make([]*storage.BlocklistEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.ExpiresAt.IsZero() || e.ExpiresAt.After(now) {
+			active = append(active, e)
+		}
+	}
+	return active, nil
+}
+
+// Reload replaces the in-memory entries with the stored ones.
+// Entries that fail to parse are logged and skipped.
+func (b *Blocklist) Reload(ctx context.Context) error {
+	entries, err := b.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load blocklist: %w", err)
+	}
+
+	rules := make([]rule, 0, len(entries))
+	for _, e := range entries {
+		prefix, err := ParseCIDR(e.CIDR)
+		if err != nil {
+			b.logger.Warn("skipping invalid blocklist entry", "id", e.ID, "cidr", e.CIDR)
+			continue
+		}
+		rules = append(rules, rule{prefix: prefix, expiresAt: e.ExpiresAt})
+	}
+
+	b.mu.Lock()
+	b.rules = rules
+	b.mu.Unlock()
+
+	metrics.RecordBlocklistEntries(len(rules))
+	return nil
+}
+
+// reloadAfterChange applies a local change right away rather than at the next refresh.
+func (b *Blocklist) reloadAfterChange(ctx context.Context) {
+	if err := b.Reload(ctx); err != nil {
+		b.logger.Error("failed to reload blocklist", "error", err)
+	}
+}
+
+// Blocked reports whether addr matches an active entry.
+func (b *Blocklist) Blocked(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	now := b.now()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, r := range b.rules {
+		if r.prefix.Contains(addr) && (r.expiresAt.IsZero() || r.expiresAt.After(now)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTrustForwardedFor changes whether client addresses are taken from
+// X-Forwarded-For, as WithTrustForwardedFor does, while requests are served.
+func (b *Blocklist) SetTrustForwardedFor(trust bool) {
+	b.trustForwardedFor.Store(trust)
+}
+
+// ClientIP returns the address a request is checked against.
+func (b *Blocklist) ClientIP(r *http.Request) (netip.Addr, bool) {
+	if b.trustForwardedFor.Load() {
+		if header := r.Header.Values("X-Forwarded-For"); len(header) > 0 {
+			hops := strings.Split(header[len(header)-1], ",")
+			if addr, err := netip.ParseAddr(strings.TrimSpace(hops[len(hops)-1])); err == nil {
+				return addr.Unmap(), true
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Middleware rejects requests from blocked addresses with 403 Forbidden.
+// Requests whose address cannot be determined are let through.
+func (b *Blocklist) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := b.ClientIP(r); ok && b.Blocked(addr) {
+			metrics.RecordBlocklistRejection()
+			b.logger.Warn("blocked request", "client_ip", addr.String(), "method", r.Method, "path", r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			//nolint:errcheck
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "access denied"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run reloads the entries and prunes expired ones until ctx is cancelled.
+func (b *Blocklist) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := b.store.PruneBlocklistEntries(ctx, b.now()); err != nil {
+			b.logger.Error("failed to prune blocklist", "error", err)
+		} else if n > 0 {
+			b.logger.Info("pruned expired blocklist entries", "count", n)
+		}
+		if err := b.Reload(ctx); err != nil {
+			b.logger.Error("failed to reload blocklist", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package blocklist
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestBlocklist(t *testing.T, opts ...Option) (*Blocklist, storage.Storage) {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return New(store, testLogger(), opts...), store
+}
+
+func TestParseCIDR(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"203.0.113.7", "203.0.113.7/32", false},
+		{" 203.0.113.9/24 ", "203.0.113.0/24", false},
+		{"2001:db8::1", "2001:db8::1/128", false},
+		{"::ffff:192.0.2.1", "192.0.2.1/32", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"example.com", "", true},
+		{"10.0.0.0/33", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCIDR(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidCIDR) {
+				t.Errorf("ParseCIDR(%q) error = %v, want ErrInvalidCIDR", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParseCIDR(%q) = %s, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestBlocklist_AddRemoveAndExpiry(t *testing.T) {
+	t.Parallel()
+	b, _ := newTestBlocklist(t)
+	ctx := context.Background()
+	now := time.Unix(1_700_000_000, 0)
+	b.now = func() time.Time { return now }
+
+	network, err := b.Add(ctx, "203.0.113.0/24", "scanner", "admin", 0)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := b.Add(ctx, "198.51.100.7", "brute force", "admin", time.Hour); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := b.Add(ctx, "203.0.113.99/24", "", "admin", 0); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate for the same network, got %v", err)
+	}
+	if _, err := b.Add(ctx, "not-an-ip", "", "admin", 0); !errors.Is(err, ErrInvalidCIDR) {
+		t.Errorf("expected ErrInvalidCIDR, got %v", err)
+	}
+
+	for addr, want := range map[string]bool{
+		"203.0.113.50":             true,
+		"198.51.100.7":             true,
+		"::ffff:198.51.100.7":      true,
+		"198.51.100.8":             false,
+		"2001:db8::1":              false,
+		"192.0.2.1":                false,
+		"::ffff:203.0.113.1":       true,
+		"2001:db8:ffff::ffff:ffff": false,
+	} {
+		if got := b.Blocked(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("Blocked(%s) = %v, want %v", addr, got, want)
+		}
+	}
+
+	// The temporary ban lapses without a reload
+	now = now.Add(time.Hour)
+	if b.Blocked(netip.MustParseAddr("198.51.100.7")) {
+		t.Error("expected temporary ban to expire")
+	}
+	if list, _ := b.List(ctx); len(list) != 1 || list[0].ID != network.ID {
+		t.Errorf("expected only the permanent entry to be listed, got %+v", list)
+	}
+
+	if err := b.Remove(ctx, network.ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if b.Blocked(netip.MustParseAddr("203.0.113.50")) {
+		t.Error("expected removed network to be unblocked immediately")
+	}
+	if err := b.Remove(ctx, network.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound removing twice, got %v", err)
+	}
+}
+
+func TestBlocklist_ReloadPicksUpOtherInstances(t *testing.T) {
+	t.Parallel()
+	b, store := newTestBlocklist(t)
+	ctx := context.Background()
+
+	if _, err := store.AddBlocklistEntry(ctx, &storage.BlocklistEntry{CIDR: "192.0.2.0/24"}); err != nil {
+		t.Fatalf("AddBlocklistEntry failed: %v", err)
+	}
+	if b.Blocked(netip.MustParseAddr("192.0.2.1")) {
+		t.Fatal("expected entry not to apply before a reload")
+	}
+	if err := b.Reload(ctx); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if !b.Blocked(netip.MustParseAddr("192.0.2.1")) {
+		t.Error("expected entry to apply after a reload")
+	}
+}
+
+func TestBlocklist_ClientIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		trust     bool
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"remote addr", false, "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"forwarded ignored", false, "192.0.2.1:1234", []string{"203.0.113.7"}, "192.0.2.1"},
+		{"last forwarded hop", true, "10.0.0.1:1234", []string{"198.51.100.1, 203.0.113.7"}, "203.0.113.7"},
+		{"last forwarded header", true, "10.0.0.1:1234", []string{"198.51.100.1", "203.0.113.8"}, "203.0.113.8"},
+		{"invalid forwarded", true, "10.0.0.1:1234", []string{"unknown"}, "10.0.0.1"},
+		{"ipv6 remote", false, "[2001:db8::1]:443", nil, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		b := New(&mockstore.MockStorage{}, testLogger(), WithTrustForwardedFor(tt.trust))
+		r := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+		r.RemoteAddr = tt.remote
+		for _, v := range tt.forwarded {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		got, ok := b.ClientIP(r)
+		if !ok || got.String() != tt.want {
+			t.Errorf("%s: ClientIP() = %s, %v; want %s", tt.name, got, ok, tt.want)
+		}
+	}
+
+	b := New(&mockstore.MockStorage{}, testLogger())
+	r := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+	r.RemoteAddr = "@"
+	if _, ok := b.ClientIP(r); ok {
+		t.Error("expected no address for an unparsable remote address")
+	}
+}
+
+func TestBlocklist_SetTrustForwardedFor(t *testing.T) {
+	t.Parallel()
+	b := New(&mockstore.MockStorage{}, testLogger())
+	r := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+	r.RemoteAddr = "198.51.100.1:1234"
+	r.Header.Set("X-Forwarded-For", "192.0.2.1")
+
+	b.SetTrustForwardedFor(true)
+	if got, _ := b.ClientIP(r); got.String() != "192.0.2.1" {
+		t.Errorf("ClientIP() = %s, want the forwarded address", got)
+	}
+	b.SetTrustForwardedFor(false)
+	if got, _ := b.ClientIP(r); got.String() != "198.51.100.1" {
+		t.Errorf("ClientIP() = %s, want the remote address", got)
+	}
+}
+
+func TestBlocklist_Middleware(t *testing.T) {
+	t.Parallel()
+	b, _ := newTestBlocklist(t)
+	if _, err := b.Add(context.Background(), "192.0.2.1", "abuse", "admin", 0); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	called := 0
+	handler := b.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for remote, want := range map[string]int{
+		"192.0.2.1:5555": http.StatusForbidden,
+		"192.0.2.2:5555": http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", remote, want, w.Code)
+		}
+	}
+	if called != 1 {
+		t.Errorf("expected only the allowed request to reach the handler, got %d calls", called)
+	}
+}
+
+func TestRun_PrunesAndStopsOnCancel(t *testing.T) {
+	t.Parallel()
+
+	pruned := make(chan time.Time, 1)
+	store := &mockstore.MockStorage{
+		PruneBlocklistEntriesFunc: func(_ context.Context, before time.Time) (int64, error) {
+			pruned <- before
+			return 1, nil
+		},
+	}
+	b := New(store, testLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-pruned:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to prune on start")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
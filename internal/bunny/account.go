@@ -0,0 +1,88 @@
+package bunny
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GetStatistics retrieves account-wide CDN statistics.
+// The query parameters (dateFrom, dateTo, pullZone, hourly, ...) are forwarded unchanged.
+// The response is returned as raw JSON because the proxy passes it through without modification.
+func (c *Client) GetStatistics(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, "/statistics", query)
+}
+
+// GetBilling retrieves the account billing details.
+func (c *Client) GetBilling(ctx context.Context) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, "/billing", nil)
+}
+
+// GetBillingSummary retrieves the account billing summary.
+func (c *Client) GetBillingSummary(ctx context.Context) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, "/billing/summary", nil)
+}
+
+// getRawJSON performs an authenticated GET request and returns the response body as raw JSON.
+func (c *Client) getRawJSON(ctx context.Context, path string, query url.Values) (json.RawMessage, error) {
+	return c.doRawJSON(ctx, http.MethodGet, path, query, nil)
+}
+
+// doRawJSON performs an authenticated request with an optional JSON body and
+// returns the response body as raw JSON. A 204 No Content response returns nil.
+func (c *Client) doRawJSON(ctx context.Context, method, path string, query url.Values, payload json.RawMessage) (json.RawMessage, error) {
+	endpoint := c.endpoint(path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if len(payload) > 0 {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s %s: %w", strings.ToLower(method), path, err)
+	}
+	defer func() {
+		//nolint:errcheck
+		resp.Body.Close()
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil, nil
+	case http.StatusOK, http.StatusCreated:
+		if len(body) == 0 {
+			return nil, nil
+		}
+		if !json.Valid(body) {
+			return nil, fmt.Errorf("failed to parse response: invalid JSON")
+		}
+		return json.RawMessage(body), nil
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+
+	return nil, parseError(resp.StatusCode, body)
+}
@@ -0,0 +1,154 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetStatistics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		handler http.HandlerFunc
+		want    string
+		wantErr error
+		anyErr  bool
+	}{
+		{
+			name:  "forwards query parameters",
+			query: url.Values{"dateFrom": {"2025-01-01"}, "hourly": {"true"}},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet {
+					t.Errorf("expected GET, got %s", r.Method)
+				}
+				if r.URL.Path != "/statistics" {
+					t.Errorf("expected /statistics, got %s", r.URL.Path)
+				}
+				if r.Header.Get("AccessKey") != "test-key" {
+					t.Errorf("missing AccessKey header")
+				}
+				if r.URL.Query().Get("dateFrom") != "2025-01-01" || r.URL.Query().Get("hourly") != "true" {
+					t.Errorf("unexpected query: %s", r.URL.RawQuery)
+				}
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"TotalBandwidthUsed":42}`))
+			},
+			want: `{"TotalBandwidthUsed":42}`,
+		},
+		{
+			name: "unauthorized",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantErr: ErrUnauthorized,
+		},
+		{
+			name: "invalid JSON",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`not json`))
+			},
+			anyErr: true,
+		},
+		{
+			name: "server error",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			anyErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			client := NewClient("test-key", WithBaseURL(ts.URL))
+			got, err := client.GetStatistics(context.Background(), tt.query)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if tt.anyErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetBilling(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.URL.Path {
+		case "/billing":
+			w.Write([]byte(`{"Balance":10.5}`))
+		case "/billing/summary":
+			w.Write([]byte(`[{"PullZoneId":1}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", WithBaseURL(ts.URL))
+
+	billing, err := client.GetBilling(context.Background())
+	if err != nil {
+		t.Fatalf("GetBilling failed: %v", err)
+	}
+	if string(billing) != `{"Balance":10.5}` {
+		t.Errorf("unexpected billing body: %s", billing)
+	}
+
+	summary, err := client.GetBillingSummary(context.Background())
+	if err != nil {
+		t.Fatalf("GetBillingSummary failed: %v", err)
+	}
+	if string(summary) != `[{"PullZoneId":1}]` {
+		t.Errorf("unexpected summary body: %s", summary)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", calls.Load())
+	}
+}
+
+func TestGetRawJSON_NotFound(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", WithBaseURL(ts.URL))
+	_, err := client.GetBilling(context.Background())
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
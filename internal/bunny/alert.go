@@ -0,0 +1,56 @@
+package bunny
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// authAlertInterval is the minimum time between upstream.auth_failed events,
+// so a revoked key raises one alert rather than one per request.
+const authAlertInterval = 5 * time.Minute
+
+// Alerter publishes events about the upstream API.
+// It is satisfied by *outbox.Outbox.
+type Alerter interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// WithAlerter publishes an upstream.auth_failed event when bunny.net rejects
+// the API key, at most once every five minutes.
+func WithAlerter(a Alerter) Option {
+	return func(c *Client) {
+		c.alerter = a
+	}
+}
+
+// alertAuthFailure publishes upstream.auth_failed for a request rejected
+// with 401, unless one was published within authAlertInterval.
+func (c *Client) alertAuthFailure(req *http.Request, class string) {
+	if c.alerter == nil {
+		return
+	}
+	now := time.Now()
+	last := c.lastAuthAlert.Load()
+	if last != 0 && now.Sub(time.Unix(0, last)) < authAlertInterval {
+		return
+	}
+	if !c.lastAuthAlert.CompareAndSwap(last, now.UnixNano()) {
+		return // another request is publishing
+	}
+
+	// The event outlives the request, so it must not be canceled with it
+	err := c.alerter.Publish(context.WithoutCancel(req.Context()), webhook.EventUpstreamAuthFailed, map[string]any{
+		"message":        "bunny.net rejected the API key",
+		"status":         http.StatusUnauthorized,
+		"endpoint_class": class,
+		"method":         req.Method,
+		"path":           req.URL.Path,
+	})
+	if err != nil {
+		slog.Default().Error("failed to publish upstream auth failure", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// recordingAlerter records published events.
+type recordingAlerter struct {
+	mu     sync.Mutex
+	events []string
+	data   []map[string]any
+}
+
+func (a *recordingAlerter) Publish(_ context.Context, event string, data any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+	a.data = append(a.data, data.(map[string]any))
+	return nil
+}
+
+func TestAlertAuthFailure(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	alerter := &recordingAlerter{}
+	client := NewClient("revoked", WithBaseURL(server.URL), WithAlerter(alerter))
+
+	for range 3 {
+		if _, err := client.GetZone(context.Background(), 1); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("expected ErrUnauthorized, got %v", err)
+		}
+	}
+	if len(alerter.events) != 1 || alerter.events[0] != webhook.EventUpstreamAuthFailed {
+		t.Fatalf("expected one upstream.auth_failed event, got %v", alerter.events)
+	}
+	if data := alerter.data[0]; data["endpoint_class"] != EndpointZones || data["path"] != "/dnszone/1" {
+		t.Errorf("unexpected event data: %v", data)
+	}
+
+	// Another event is published once the interval has passed
+	client.lastAuthAlert.Store(time.Now().Add(-authAlertInterval).UnixNano())
+	_, _ = client.GetZone(context.Background(), 1)
+	if len(alerter.events) != 2 {
+		t.Errorf("expected a second event after the interval, got %d", len(alerter.events))
+	}
+}
@@ -0,0 +1,254 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+// Endpoint classes. Upstream health is tracked, and circuits are broken,
+// per class, so a failing endpoint does not block unrelated ones.
+const (
+	EndpointZones        = "zones"
+	EndpointRecords      = "records"
+	EndpointDNSSEC       = "dnssec"
+	EndpointCertificates = "certificates"
+	EndpointStatistics   = "statistics" // account and DNS zone statistics
+	EndpointOther        = "other"      // pull zones, storage zones and billing
+)
+
+// EndpointClasses lists every endpoint class in report order.
+var EndpointClasses = []string{EndpointZones, EndpointRecords, EndpointDNSSEC, EndpointCertificates, EndpointStatistics, EndpointOther}
+
+// Endpoint health statuses.
+const (
+	HealthOK          = "ok"          // no failures in the health window
+	HealthDegraded    = "degraded"    // some failures, circuit closed
+	HealthUnavailable = "unavailable" // circuit open
+)
+
+const (
+	// DefaultBreakerCooldown is how long a circuit stays open when a
+	// BreakerPolicy does not set a cooldown.
+	DefaultBreakerCooldown = 30 * time.Second
+
+	// healthWindow is the period over which error rates are reported, kept
+	// as one bucket per minute.
+	healthWindow = 5
+)
+
+// ErrCircuitOpen is returned, without contacting bunny.net, for requests to
+// an endpoint class whose circuit is open.
+var ErrCircuitOpen = errors.New("bunny: circuit open")
+
+// BreakerPolicy controls the per-class circuit breakers.
+type BreakerPolicy struct {
+	// Threshold is the number of consecutive failed requests to a class that
+	// opens its circuit. Zero disables circuit breaking.
+	Threshold int
+
+	// Cooldown is how long an open circuit rejects requests. After it, one
+	// request is let through: success closes the circuit, failure reopens it.
+	Cooldown time.Duration
+}
+
+// WithCircuitBreaker stops sending requests to an endpoint class after
+// repeated failures, so a broken endpoint fails fast instead of tying up
+// clients and retries. A request fails when it ends, after any retries, in
+// a network error or a 5xx response. Health is tracked without this option;
+// only the breaking is enabled by it.
+func WithCircuitBreaker(policy BreakerPolicy) Option {
+	return func(c *Client) {
+		if policy.Cooldown <= 0 {
+			policy.Cooldown = DefaultBreakerCooldown
+		}
+		c.health.policy = policy
+	}
+}
+
+// EndpointHealth reports the recent health of one endpoint class.
+type EndpointHealth struct {
+	Class     string  `json:"class"`
+	Status    string  `json:"status"`
+	Requests  int64   `json:"requests"` // in the last five minutes
+	Failures  int64   `json:"failures"` // in the last five minutes
+	ErrorRate float64 `json:"error_rate"`
+	// LastError describes the most recent failure, if any in the window.
+	LastError string `json:"last_error,omitempty"`
+	// RetryAt is when an open circuit lets a request through again.
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+}
+
+// EndpointHealth reports the health of every endpoint class.
+func (c *Client) EndpointHealth() []EndpointHealth {
+	return c.health.report()
+}
+
+// healthBucket counts requests to a class in one minute.
+type healthBucket struct {
+	minute   int64
+	requests int64
+	failures int64
+}
+
+// classHealth is the health and circuit state of one endpoint class.
+type classHealth struct {
+	buckets     [healthWindow]healthBucket
+	consecutive int
+	openUntil   time.Time
+	probing     bool
+	lastError   string
+}
+
+// endpointHealth tracks every endpoint class.
+type endpointHealth struct {
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu      sync.Mutex
+	classes map[string]*classHealth
+}
+
+func newEndpointHealth() *endpointHealth {
+	h := &endpointHealth{
+		now:     time.Now,
+		classes: make(map[string]*classHealth, len(EndpointClasses)),
+	}
+	for _, class := range EndpointClasses {
+		h.classes[class] = &classHealth{}
+	}
+	return h
+}
+
+// allow reports whether a request to class may be sent. While the circuit is
+// open it returns ErrCircuitOpen; once the cooldown has passed, a single
+// probe request is allowed.
+func (h *endpointHealth) allow(class string) error {
+	if h.policy.Threshold <= 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := h.classes[class]
+	if ch.openUntil.IsZero() {
+		return nil
+	}
+	if h.now().Before(ch.openUntil) || ch.probing {
+		return fmt.Errorf("%w: %s endpoints are failing", ErrCircuitOpen, class)
+	}
+	ch.probing = true
+	return nil
+}
+
+// record counts the outcome of a request to class and updates its circuit.
+func (h *endpointHealth) record(ctx context.Context, class string, resp *http.Response, err error) {
+	var failure string
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Canceled by the caller; says nothing about bunny.net
+		h.mu.Lock()
+		h.classes[class].probing = false
+		h.mu.Unlock()
+		return
+	case err != nil:
+		failure = err.Error()
+	case resp.StatusCode >= http.StatusInternalServerError:
+		failure = resp.Status
+	}
+
+	now := h.now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := h.classes[class]
+	b := &ch.buckets[(now.Unix()/60)%healthWindow]
+	if minute := now.Unix() / 60; b.minute != minute {
+		*b = healthBucket{minute: minute}
+	}
+	b.requests++
+	metrics.RecordUpstreamRequest(class, failure == "")
+
+	if failure == "" {
+		ch.consecutive = 0
+		ch.probing = false
+		if !ch.openUntil.IsZero() {
+			ch.openUntil = time.Time{}
+			metrics.RecordUpstreamCircuitOpen(class, false)
+		}
+		return
+	}
+
+	b.failures++
+	ch.consecutive++
+	ch.lastError = failure
+	if h.policy.Threshold > 0 && (ch.probing || ch.consecutive >= h.policy.Threshold) {
+		ch.probing = false
+		ch.openUntil = now.Add(h.policy.Cooldown)
+		metrics.RecordUpstreamCircuitOpen(class, true)
+	}
+}
+
+// report summarizes every class over the health window.
+func (h *endpointHealth) report() []EndpointHealth {
+	now := h.now()
+	minute := now.Unix() / 60
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	report := make([]EndpointHealth, 0, len(EndpointClasses))
+	for _, class := range EndpointClasses {
+		ch := h.classes[class]
+		eh := EndpointHealth{Class: class, Status: HealthOK}
+		for _, b := range ch.buckets {
+			if age := minute - b.minute; age >= 0 && age < healthWindow {
+				eh.Requests += b.requests
+				eh.Failures += b.failures
+			}
+		}
+		if eh.Requests > 0 {
+			eh.ErrorRate = float64(eh.Failures) / float64(eh.Requests)
+		}
+		if eh.Failures > 0 {
+			eh.Status = HealthDegraded
+			eh.LastError = ch.lastError
+		}
+		if !ch.openUntil.IsZero() {
+			eh.Status = HealthUnavailable
+			retryAt := ch.openUntil
+			eh.RetryAt = &retryAt
+		}
+		report = append(report, eh)
+	}
+	return report
+}
+
+// endpointClass classifies a bunny.net API path. The base URL may add a
+// prefix to the path.
+func endpointClass(path string) string {
+	i := strings.Index(path, "/dnszone")
+	if i < 0 {
+		if strings.HasSuffix(path, "/statistics") {
+			return EndpointStatistics
+		}
+		return EndpointOther
+	}
+	rest := path[i+len("/dnszone"):]
+	switch {
+	case strings.HasSuffix(rest, "/statistics"):
+		return EndpointStatistics
+	case strings.Contains(rest, "/records"), strings.HasSuffix(rest, "/import"), strings.HasSuffix(rest, "/export"):
+		return EndpointRecords
+	case strings.HasSuffix(rest, "/dnssec"):
+		return EndpointDNSSEC
+	case strings.Contains(rest, "/certificate"):
+		return EndpointCertificates
+	default:
+		return EndpointZones
+	}
+}
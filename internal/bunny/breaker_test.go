@@ -0,0 +1,159 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// certificateOutage serves record writes but fails certificate requests
+// while broken is set. certCalls counts certificate requests received.
+func certificateOutage(t *testing.T) (server *httptest.Server, broken *atomic.Bool, certCalls *atomic.Int32) {
+	t.Helper()
+	broken, certCalls = new(atomic.Bool), new(atomic.Int32)
+	broken.Store(true)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/certificate/") {
+			certCalls.Add(1)
+			if broken.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"Id":7}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, broken, certCalls
+}
+
+// healthOf returns the reported health of one endpoint class.
+func healthOf(c *Client, class string) EndpointHealth {
+	for _, h := range c.EndpointHealth() {
+		if h.Class == class {
+			return h
+		}
+	}
+	return EndpointHealth{}
+}
+
+func TestCircuitBreaker_IsolatesClasses(t *testing.T) {
+	t.Parallel()
+	server, broken, certCalls := certificateOutage(t)
+	client := NewClient("key", WithBaseURL(server.URL), WithCircuitBreaker(BreakerPolicy{Threshold: 2, Cooldown: time.Minute}))
+	now := time.Now()
+	client.health.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for range 2 {
+		if err := client.IssueCertificate(ctx, 1, "example.com"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected upstream error, got %v", err)
+		}
+	}
+	if err := client.IssueCertificate(ctx, 1, "example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if certCalls.Load() != 2 {
+		t.Errorf("expected the open circuit to skip bunny.net, got %d calls", certCalls.Load())
+	}
+
+	// Record writes are unaffected
+	if _, err := client.AddRecord(ctx, 1, &AddRecordRequest{Type: 3, Name: "_acme-challenge", Value: "token"}); err != nil {
+		t.Fatalf("AddRecord failed: %v", err)
+	}
+
+	certs := healthOf(client, EndpointCertificates)
+	if certs.Status != HealthUnavailable || certs.Failures != 2 || certs.ErrorRate != 1 || certs.RetryAt == nil || certs.LastError == "" {
+		t.Errorf("unexpected certificates health: %+v", certs)
+	}
+	if records := healthOf(client, EndpointRecords); records.Status != HealthOK || records.Requests != 1 {
+		t.Errorf("unexpected records health: %+v", records)
+	}
+
+	// After the cooldown a failed probe reopens the circuit at once
+	now = now.Add(2 * time.Minute)
+	if err := client.IssueCertificate(ctx, 1, "example.com"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected probe to reach bunny.net, got %v", err)
+	}
+	if err := client.IssueCertificate(ctx, 1, "example.com"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected failed probe to reopen the circuit, got %v", err)
+	}
+
+	// A successful probe closes it
+	now = now.Add(2 * time.Minute)
+	broken.Store(false)
+	for range 2 {
+		if err := client.IssueCertificate(ctx, 1, "example.com"); err != nil {
+			t.Fatalf("expected circuit to close, got %v", err)
+		}
+	}
+	if certs := healthOf(client, EndpointCertificates); certs.Status != HealthDegraded || certs.RetryAt != nil {
+		t.Errorf("expected closed circuit with recent failures, got %+v", certs)
+	}
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	t.Parallel()
+	server, _, certCalls := certificateOutage(t)
+	client := NewClient("key", WithBaseURL(server.URL))
+
+	for range 10 {
+		if err := client.IssueCertificate(context.Background(), 1, "example.com"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatal("circuit opened without WithCircuitBreaker")
+		}
+	}
+	if certCalls.Load() != 10 {
+		t.Errorf("expected every request to reach bunny.net, got %d", certCalls.Load())
+	}
+	if certs := healthOf(client, EndpointCertificates); certs.Status != HealthDegraded || certs.Failures != 10 {
+		t.Errorf("expected failures to be tracked, got %+v", certs)
+	}
+}
+
+func TestCircuitBreaker_IgnoresCanceledRequests(t *testing.T) {
+	t.Parallel()
+	server, _, _ := certificateOutage(t)
+	client := NewClient("key", WithBaseURL(server.URL), WithCircuitBreaker(BreakerPolicy{Threshold: 1}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := client.IssueCertificate(ctx, 1, "example.com"); err == nil {
+		t.Fatal("expected error for canceled context")
+	}
+	if certs := healthOf(client, EndpointCertificates); certs.Status != HealthOK || certs.Requests != 0 {
+		t.Errorf("expected canceled request to be ignored, got %+v", certs)
+	}
+}
+
+func TestEndpointClass(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"/dnszone":                     EndpointZones,
+		"/dnszone/1":                   EndpointZones,
+		"/dnszone/checkavailability":   EndpointZones,
+		"/dnszone/1/statistics":        EndpointStatistics,
+		"/dnszone/1/records":           EndpointRecords,
+		"/dnszone/1/records/2":         EndpointRecords,
+		"/dnszone/records/scan":        EndpointRecords,
+		"/dnszone/1/import":            EndpointRecords,
+		"/dnszone/1/export":            EndpointRecords,
+		"/dnszone/1/dnssec":            EndpointDNSSEC,
+		"/dnszone/1/certificate/issue": EndpointCertificates,
+		"/api/v1/dnszone/1/records":    EndpointRecords,
+		"/pullzone/1":                  EndpointOther,
+		"/statistics":                  EndpointStatistics,
+		"/billing/summary":             EndpointOther,
+	}
+	for path, want := range tests {
+		if got := endpointClass(path); got != want {
+			t.Errorf("endpointClass(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
 const (
@@ -20,8 +23,29 @@ const (
 // Client is an HTTP client for the bunny.net DNS API.
 type Client struct {
 	baseURL    string
-	apiKey     string
+	apiKey     atomic.Pointer[string] // replaced by SetAPIKey when the key rotates
 	httpClient *http.Client
+	userAgent  string
+	contact    string
+
+	// classURLs overrides baseURL per endpoint class.
+	classURLs map[string]string
+
+	// drift reports unknown response fields; nil when schema checks are off.
+	drift *schemaDrift
+
+	// zoneLookups caches FindZoneByDomain results.
+	zoneLookups *zoneLookupCache
+
+	// retry controls retries of transient failures; zero disables them.
+	retry RetryPolicy
+
+	// health tracks upstream failures and circuits per endpoint class.
+	health *endpointHealth
+
+	// alerter receives upstream.auth_failed events; nil disables them.
+	alerter       Alerter
+	lastAuthAlert atomic.Int64 // unix nanoseconds of the last event
 }
 
 // Option configures a Client.
@@ -34,6 +58,51 @@ func WithBaseURL(url string) Option {
 	}
 }
 
+// WithEndpointBaseURLs sends requests for some endpoint classes to other base
+// URLs, such as a regional API or a staging host, keyed by class as returned
+// by ParseEndpointBaseURLs. Other classes use the base URL.
+func WithEndpointBaseURLs(urls map[string]string) Option {
+	return func(c *Client) {
+		c.classURLs = urls
+	}
+}
+
+// ParseEndpointBaseURLs parses "class=url" entries, such as
+// "statistics=https://stats.example.net". Classes are those in
+// EndpointClasses; URLs must be absolute http(s) URLs and lose a trailing slash.
+func ParseEndpointBaseURLs(entries []string) (map[string]string, error) {
+	urls := make(map[string]string, len(entries))
+	for _, e := range entries {
+		class, raw, ok := strings.Cut(e, "=")
+		class, raw = strings.ToLower(strings.TrimSpace(class)), strings.TrimSpace(raw)
+		if !ok || raw == "" {
+			return nil, fmt.Errorf("invalid endpoint override %q: want class=url", e)
+		}
+		if !slices.Contains(EndpointClasses, class) {
+			return nil, fmt.Errorf("invalid endpoint override %q: unknown class %q (want one of %s)",
+				e, class, strings.Join(EndpointClasses, ", "))
+		}
+		if _, dup := urls[class]; dup {
+			return nil, fmt.Errorf("invalid endpoint override %q: class %q is overridden twice", e, class)
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint override %q: URL must be an absolute http or https URL", e)
+		}
+		urls[class] = strings.TrimSuffix(raw, "/")
+	}
+	return urls, nil
+}
+
+// endpoint returns the URL for an API path, using the base URL of the path's
+// endpoint class.
+func (c *Client) endpoint(path string) string {
+	if base, ok := c.classURLs[endpointClass(path)]; ok {
+		return base + path
+	}
+	return c.baseURL + path
+}
+
 // WithHTTPClient sets a custom HTTP client.
 func WithHTTPClient(client *http.Client) Option {
 	return func(c *Client) {
@@ -41,14 +110,31 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithUserAgent sets the User-Agent header sent on every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithContact sets a contact (e.g. an email address) sent in the From header
+// on every request, so bunny.net support can reach the operator.
+func WithContact(contact string) Option {
+	return func(c *Client) {
+		c.contact = contact
+	}
+}
+
 // NewClient creates a new bunny.net API client.
 func NewClient(apiKey string, opts ...Option) *Client {
 	c := &Client{
-		baseURL:    DefaultBaseURL,
-		apiKey:     apiKey,
-		httpClient: http.DefaultClient,
+		baseURL:     DefaultBaseURL,
+		httpClient:  http.DefaultClient,
+		zoneLookups: newZoneLookupCache(),
+		health:      newEndpointHealth(),
 	}
 
+	c.apiKey.Store(&apiKey)
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -56,6 +142,23 @@ func NewClient(apiKey string, opts ...Option) *Client {
 	return c
 }
 
+// SetAPIKey replaces the API key sent with later requests, such as after the
+// key was rotated in a secret store. Requests already sent keep the old key.
+func (c *Client) SetAPIKey(apiKey string) {
+	c.apiKey.Store(&apiKey)
+}
+
+// setHeaders sets the authentication and client identification headers.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("AccessKey", *c.apiKey.Load())
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.contact != "" {
+		req.Header.Set("From", c.contact)
+	}
+}
+
 // ListZones retrieves all DNS zones, optionally filtered.
 // Returns the full paginated response.
 func (c *Client) ListZones(ctx context.Context, opts *ListZonesOptions) (*ListZonesResponse, error) {
@@ -79,7 +182,7 @@ func (c *Client) ListZones(ctx context.Context, opts *ListZonesOptions) (*ListZo
 		query.Set("search", opts.Search)
 	}
 
-	endpoint := c.baseURL + "/dnszone"
+	endpoint := c.endpoint("/dnszone")
 	if len(query) > 0 {
 		endpoint += "?" + query.Encode()
 	}
@@ -90,11 +193,11 @@ func (c *Client) ListZones(ctx context.Context, opts *ListZonesOptions) (*ListZo
 		return nil, err
 	}
 
-	// Set authentication header
-	req.Header.Set("AccessKey", c.apiKey)
+	// Set authentication and identification headers
+	c.setHeaders(req)
 
 	// Execute request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +219,7 @@ func (c *Client) ListZones(ctx context.Context, opts *ListZonesOptions) (*ListZo
 
 	// Decode successful response
 	var result ListZonesResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := c.decode(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -125,16 +228,16 @@ func (c *Client) ListZones(ctx context.Context, opts *ListZonesOptions) (*ListZo
 
 // GetZone retrieves a single DNS zone by ID, including all its records.
 func (c *Client) GetZone(ctx context.Context, id int64) (*Zone, error) {
-	url := fmt.Sprintf("%s/dnszone/%d", c.baseURL, id)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d", id))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +254,7 @@ func (c *Client) GetZone(ctx context.Context, id int64) (*Zone, error) {
 	// Handle specific status codes
 	if resp.StatusCode == http.StatusOK {
 		var zone Zone
-		if err := json.Unmarshal(body, &zone); err != nil {
+		if err := c.decode(body, &zone); err != nil {
 			return nil, fmt.Errorf("failed to decode zone: %w", err)
 		}
 		return &zone, nil
@@ -165,7 +268,10 @@ func (c *Client) GetZone(ctx context.Context, id int64) (*Zone, error) {
 	return nil, parseError(resp.StatusCode, body)
 }
 
-// AddRecordRequest represents the request body for creating a new DNS record.
+// AddRecordRequest represents the request body for creating or updating a
+// DNS record. The optional fields for pull zone, script, monitoring and smart
+// routing records are pointers: nil leaves them out of the request, so an
+// update does not reset settings it does not mention.
 type AddRecordRequest struct {
 	Type     int    `json:"Type"` // 0 = A, 1 = AAAA, 2 = CNAME, 3 = TXT, 4 = MX, 5 = SPF, 6 = Flatten, 7 = PullZone, 8 = SRV, 9 = CAA, 10 = PTR, 11 = Script, 12 = NS
 	Name     string `json:"Name"`
@@ -178,11 +284,22 @@ type AddRecordRequest struct {
 	Tag      string `json:"Tag"`
 	Disabled bool   `json:"Disabled"`
 	Comment  string `json:"Comment"`
+
+	PullZoneID            *int64                  `json:"PullZoneId,omitempty"` // PullZone records
+	ScriptID              *int64                  `json:"ScriptId,omitempty"`   // Script records
+	Accelerated           *bool                   `json:"Accelerated,omitempty"`
+	MonitorType           *int                    `json:"MonitorType,omitempty"` // 0 = None, 1 = Ping, 2 = Http, 3 = Monitor
+	GeolocationLatitude   *float64                `json:"GeolocationLatitude,omitempty"`
+	GeolocationLongitude  *float64                `json:"GeolocationLongitude,omitempty"`
+	LatencyZone           *string                 `json:"LatencyZone,omitempty"`
+	SmartRoutingType      *int                    `json:"SmartRoutingType,omitempty"` // 0 = None, 1 = Latency, 2 = Geolocation
+	EnviromentalVariables []EnvironmentalVariable `json:"EnviromentalVariables,omitempty"`
+	AutoSslIssuance       *bool                   `json:"AutoSslIssuance,omitempty"`
 }
 
 // AddRecord adds a new DNS record to a zone.
 func (c *Client) AddRecord(ctx context.Context, zoneID int64, req *AddRecordRequest) (*Record, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/records", c.baseURL, zoneID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/records", zoneID))
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -194,10 +311,10 @@ func (c *Client) AddRecord(ctx context.Context, zoneID int64, req *AddRecordRequ
 		return nil, err
 	}
 
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -214,7 +331,7 @@ func (c *Client) AddRecord(ctx context.Context, zoneID int64, req *AddRecordRequ
 	// Handle specific status codes
 	if resp.StatusCode == http.StatusCreated {
 		var record Record
-		if err := json.Unmarshal(respBody, &record); err != nil {
+		if err := c.decode(respBody, &record); err != nil {
 			return nil, fmt.Errorf("failed to decode record: %w", err)
 		}
 		return &record, nil
@@ -230,7 +347,7 @@ func (c *Client) AddRecord(ctx context.Context, zoneID int64, req *AddRecordRequ
 
 // UpdateRecord updates an existing DNS record in a zone.
 func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID int64, req *AddRecordRequest) (*Record, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/records/%d", c.baseURL, zoneID, recordID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/records/%d", zoneID, recordID))
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -242,10 +359,10 @@ func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID int64, req *
 		return nil, err
 	}
 
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -262,7 +379,7 @@ func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID int64, req *
 	// Handle specific status codes
 	if resp.StatusCode == http.StatusOK {
 		var record Record
-		if err := json.Unmarshal(respBody, &record); err != nil {
+		if err := c.decode(respBody, &record); err != nil {
 			return nil, fmt.Errorf("failed to decode record: %w", err)
 		}
 		return &record, nil
@@ -283,16 +400,16 @@ func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID int64, req *
 
 // DeleteRecord removes a DNS record from the specified zone.
 func (c *Client) DeleteRecord(ctx context.Context, zoneID, recordID int64) error {
-	url := fmt.Sprintf("%s/dnszone/%d/records/%d", c.baseURL, zoneID, recordID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/records/%d", zoneID, recordID))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -322,7 +439,7 @@ func (c *Client) DeleteRecord(ctx context.Context, zoneID, recordID int64) error
 // CreateZone creates a new DNS zone.
 // POST /dnszone
 func (c *Client) CreateZone(ctx context.Context, domain string) (*Zone, error) {
-	url := fmt.Sprintf("%s/dnszone", c.baseURL)
+	url := c.endpoint("/dnszone")
 
 	req := &CreateZoneRequest{
 		Domain: domain,
@@ -338,10 +455,10 @@ func (c *Client) CreateZone(ctx context.Context, domain string) (*Zone, error) {
 		return nil, err
 	}
 
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -357,8 +474,9 @@ func (c *Client) CreateZone(ctx context.Context, domain string) (*Zone, error) {
 
 	// Handle specific status codes
 	if resp.StatusCode == http.StatusCreated {
+		c.zoneLookups.reset()
 		var zone Zone
-		if err := json.Unmarshal(respBody, &zone); err != nil {
+		if err := c.decode(respBody, &zone); err != nil {
 			return nil, fmt.Errorf("failed to decode zone: %w", err)
 		}
 		return &zone, nil
@@ -371,16 +489,16 @@ func (c *Client) CreateZone(ctx context.Context, domain string) (*Zone, error) {
 // DeleteZone deletes a DNS zone by ID.
 // DELETE /dnszone/{id}
 func (c *Client) DeleteZone(ctx context.Context, id int64) error {
-	url := fmt.Sprintf("%s/dnszone/%d", c.baseURL, id)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d", id))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -396,6 +514,7 @@ func (c *Client) DeleteZone(ctx context.Context, id int64) error {
 
 	// Handle specific status codes
 	if resp.StatusCode == http.StatusNoContent {
+		c.zoneLookups.reset()
 		return nil
 	}
 
@@ -410,7 +529,7 @@ func (c *Client) DeleteZone(ctx context.Context, id int64) error {
 
 // UpdateZone updates zone-level settings.
 func (c *Client) UpdateZone(ctx context.Context, id int64, req *UpdateZoneRequest) (*Zone, error) {
-	url := fmt.Sprintf("%s/dnszone/%d", c.baseURL, id)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d", id))
 
 	body, err := json.Marshal(req)
 	if err != nil {
@@ -421,10 +540,10 @@ func (c *Client) UpdateZone(ctx context.Context, id int64, req *UpdateZoneReques
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update zone: %w", err)
 	}
@@ -440,7 +559,7 @@ func (c *Client) UpdateZone(ctx context.Context, id int64, req *UpdateZoneReques
 
 	if resp.StatusCode == http.StatusOK {
 		var zone Zone
-		if err := json.Unmarshal(respBody, &zone); err != nil {
+		if err := c.decode(respBody, &zone); err != nil {
 			return nil, fmt.Errorf("failed to parse zone: %w", err)
 		}
 		return &zone, nil
@@ -455,7 +574,7 @@ func (c *Client) UpdateZone(ctx context.Context, id int64, req *UpdateZoneReques
 
 // CheckZoneAvailability checks if a domain name is available to be added as a DNS zone.
 func (c *Client) CheckZoneAvailability(ctx context.Context, name string) (*CheckAvailabilityResponse, error) {
-	url := fmt.Sprintf("%s/dnszone/checkavailability", c.baseURL)
+	url := c.endpoint("/dnszone/checkavailability")
 
 	reqBody := &CheckAvailabilityRequest{Name: name}
 	body, err := json.Marshal(reqBody)
@@ -467,10 +586,10 @@ func (c *Client) CheckZoneAvailability(ctx context.Context, name string) (*Check
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check availability: %w", err)
 	}
@@ -486,7 +605,7 @@ func (c *Client) CheckZoneAvailability(ctx context.Context, name string) (*Check
 
 	if resp.StatusCode == http.StatusOK {
 		var result CheckAvailabilityResponse
-		if err := json.Unmarshal(respBody, &result); err != nil {
+		if err := c.decode(respBody, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
@@ -498,18 +617,18 @@ func (c *Client) CheckZoneAvailability(ctx context.Context, name string) (*Check
 // ImportRecords imports DNS records from BIND zone file format.
 // The body is forwarded as-is to the bunny.net API.
 func (c *Client) ImportRecords(ctx context.Context, zoneID int64, body io.Reader, contentType string) (*ImportRecordsResponse, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/import", c.baseURL, zoneID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/import", zoneID))
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	if contentType != "" {
 		httpReq.Header.Set("Content-Type", contentType)
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to import records: %w", err)
 	}
@@ -525,7 +644,7 @@ func (c *Client) ImportRecords(ctx context.Context, zoneID int64, body io.Reader
 
 	if resp.StatusCode == http.StatusOK {
 		var result ImportRecordsResponse
-		if err := json.Unmarshal(respBody, &result); err != nil {
+		if err := c.decode(respBody, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
@@ -541,50 +660,67 @@ func (c *Client) ImportRecords(ctx context.Context, zoneID int64, body io.Reader
 // ExportRecords exports DNS records in BIND zone file format.
 // Returns the raw text response body.
 func (c *Client) ExportRecords(ctx context.Context, zoneID int64) (string, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/export", c.baseURL, zoneID)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	body, err := c.StreamExportRecords(ctx, zoneID)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("AccessKey", c.apiKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to export records: %w", err)
+		return "", err
 	}
 	defer func() {
 		//nolint:errcheck
-		resp.Body.Close()
+		body.Close()
 	}()
 
-	body, err := io.ReadAll(resp.Body)
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response: %w", err)
 	}
+	return string(data), nil
+}
+
+// StreamExportRecords exports DNS records in BIND zone file format without
+// buffering the zone file. The caller must close the returned body.
+func (c *Client) StreamExportRecords(ctx context.Context, zoneID int64) (io.ReadCloser, error) {
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/export", zoneID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(req)
 
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export records: %w", err)
+	}
 	if resp.StatusCode == http.StatusOK {
-		return string(body), nil
+		return resp.Body, nil
 	}
+	defer func() {
+		//nolint:errcheck
+		resp.Body.Close()
+	}()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return "", ErrNotFound
+		return nil, ErrNotFound
 	}
 
-	return "", parseError(resp.StatusCode, body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return nil, parseError(resp.StatusCode, body)
 }
 
 // EnableDNSSEC enables DNSSEC for a DNS zone.
 func (c *Client) EnableDNSSEC(ctx context.Context, zoneID int64) (*DNSSECResponse, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/dnssec", c.baseURL, zoneID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/dnssec", zoneID))
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to enable DNSSEC: %w", err)
 	}
@@ -600,7 +736,7 @@ func (c *Client) EnableDNSSEC(ctx context.Context, zoneID int64) (*DNSSECRespons
 
 	if resp.StatusCode == http.StatusOK {
 		var result DNSSECResponse
-		if err := json.Unmarshal(body, &result); err != nil {
+		if err := c.decode(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
@@ -615,15 +751,15 @@ func (c *Client) EnableDNSSEC(ctx context.Context, zoneID int64) (*DNSSECRespons
 
 // DisableDNSSEC disables DNSSEC for a DNS zone.
 func (c *Client) DisableDNSSEC(ctx context.Context, zoneID int64) (*DNSSECResponse, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/dnssec", c.baseURL, zoneID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/dnssec", zoneID))
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to disable DNSSEC: %w", err)
 	}
@@ -639,7 +775,7 @@ func (c *Client) DisableDNSSEC(ctx context.Context, zoneID int64) (*DNSSECRespon
 
 	if resp.StatusCode == http.StatusOK {
 		var result DNSSECResponse
-		if err := json.Unmarshal(body, &result); err != nil {
+		if err := c.decode(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
@@ -654,7 +790,7 @@ func (c *Client) DisableDNSSEC(ctx context.Context, zoneID int64) (*DNSSECRespon
 
 // IssueCertificate triggers issuance of a wildcard SSL certificate for a zone.
 func (c *Client) IssueCertificate(ctx context.Context, zoneID int64, domain string) error {
-	url := fmt.Sprintf("%s/dnszone/%d/certificate/issue", c.baseURL, zoneID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/certificate/issue", zoneID))
 
 	reqBody := struct {
 		Domain string `json:"Domain"`
@@ -669,10 +805,10 @@ func (c *Client) IssueCertificate(ctx context.Context, zoneID int64, domain stri
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return fmt.Errorf("failed to issue certificate: %w", err)
 	}
@@ -699,7 +835,7 @@ func (c *Client) IssueCertificate(ctx context.Context, zoneID int64, domain stri
 
 // GetZoneStatistics retrieves DNS query statistics for a zone.
 func (c *Client) GetZoneStatistics(ctx context.Context, zoneID int64, dateFrom, dateTo string) (*ZoneStatisticsResponse, error) {
-	endpoint := fmt.Sprintf("%s/dnszone/%d/statistics", c.baseURL, zoneID)
+	endpoint := c.endpoint(fmt.Sprintf("/dnszone/%d/statistics", zoneID))
 
 	// Add query parameters if provided
 	query := url.Values{}
@@ -717,9 +853,9 @@ func (c *Client) GetZoneStatistics(ctx context.Context, zoneID int64, dateFrom,
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get statistics: %w", err)
 	}
@@ -735,7 +871,7 @@ func (c *Client) GetZoneStatistics(ctx context.Context, zoneID int64, dateFrom,
 
 	if resp.StatusCode == http.StatusOK {
 		var result ZoneStatisticsResponse
-		if err := json.Unmarshal(body, &result); err != nil {
+		if err := c.decode(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
@@ -776,7 +912,7 @@ func parseError(statusCode int, body []byte) error {
 
 // TriggerDNSScan triggers a background DNS record scan for a domain.
 func (c *Client) TriggerDNSScan(ctx context.Context, domain string) (*DNSScanResult, error) {
-	url := fmt.Sprintf("%s/dnszone/records/scan", c.baseURL)
+	url := c.endpoint("/dnszone/records/scan")
 
 	reqBody := struct {
 		Domain string `json:"Domain"`
@@ -790,10 +926,10 @@ func (c *Client) TriggerDNSScan(ctx context.Context, domain string) (*DNSScanRes
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	httpReq.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(httpReq)
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to trigger DNS scan: %w", err)
 	}
@@ -809,7 +945,7 @@ func (c *Client) TriggerDNSScan(ctx context.Context, domain string) (*DNSScanRes
 
 	if resp.StatusCode == http.StatusOK {
 		var result DNSScanResult
-		if err := json.Unmarshal(body, &result); err != nil {
+		if err := c.decode(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
@@ -824,15 +960,15 @@ func (c *Client) TriggerDNSScan(ctx context.Context, domain string) (*DNSScanRes
 
 // GetDNSScanResult retrieves the latest DNS record scan result.
 func (c *Client) GetDNSScanResult(ctx context.Context, zoneID int64) (*DNSScanResult, error) {
-	url := fmt.Sprintf("%s/dnszone/%d/records/scan", c.baseURL, zoneID)
+	url := c.endpoint(fmt.Sprintf("/dnszone/%d/records/scan", zoneID))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("AccessKey", c.apiKey)
+	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get scan result: %w", err)
 	}
@@ -848,7 +984,7 @@ func (c *Client) GetDNSScanResult(ctx context.Context, zoneID int64) (*DNSScanRe
 
 	if resp.StatusCode == http.StatusOK {
 		var result DNSScanResult
-		if err := json.Unmarshal(body, &result); err != nil {
+		if err := c.decode(body, &result); err != nil {
 			return nil, fmt.Errorf("failed to parse response: %w", err)
 		}
 		return &result, nil
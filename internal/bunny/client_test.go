@@ -3,12 +3,14 @@ package bunny
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -279,6 +281,58 @@ func TestAddRecord(t *testing.T) {
 		}
 	})
 
+	t.Run("script and smart routing fields", func(t *testing.T) {
+		t.Parallel()
+		server := mockbunny.New()
+		defer server.Close()
+		zoneID := server.AddZone("example.com")
+
+		scriptID := int64(42)
+		smart, monitor := 1, 2
+		lat, lon := 52.37, 4.89
+		zone := "EU"
+		noSSL := false
+		client := NewClient("test-key", WithBaseURL(server.URL()))
+		record, err := client.AddRecord(context.Background(), zoneID, &AddRecordRequest{
+			Type:                  11, // Script
+			Name:                  "edge",
+			Value:                 "script",
+			TTL:                   300,
+			ScriptID:              &scriptID,
+			MonitorType:           &monitor,
+			GeolocationLatitude:   &lat,
+			GeolocationLongitude:  &lon,
+			LatencyZone:           &zone,
+			SmartRoutingType:      &smart,
+			EnviromentalVariables: []EnvironmentalVariable{{Name: "REGION", Value: "eu"}},
+			AutoSslIssuance:       &noSSL,
+		})
+		if err != nil {
+			t.Fatalf("AddRecord failed: %v", err)
+		}
+
+		if record.LinkName != "42" || record.MonitorType != 2 || record.SmartRoutingType != 1 || record.LatencyZone != "EU" ||
+			record.GeolocationLatitude != 52.37 || record.GeolocationLongitude != 4.89 || record.AutoSslIssuance {
+			t.Errorf("fields were not passed through: %+v", record)
+		}
+		if len(record.EnviromentalVariables) != 1 || record.EnviromentalVariables[0] != (EnvironmentalVariable{Name: "REGION", Value: "eu"}) {
+			t.Errorf("EnviromentalVariables = %+v", record.EnviromentalVariables)
+		}
+	})
+
+	t.Run("unset optional fields are omitted", func(t *testing.T) {
+		t.Parallel()
+		body, err := json.Marshal(&AddRecordRequest{Type: 0, Name: "www", Value: "1.2.3.4"})
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		for _, field := range []string{"PullZoneId", "ScriptId", "Accelerated", "SmartRoutingType", "EnviromentalVariables", "AutoSslIssuance"} {
+			if strings.Contains(string(body), field) {
+				t.Errorf("request %s contains unset field %s", body, field)
+			}
+		}
+	})
+
 	t.Run("zone not found error (404)", func(t *testing.T) {
 		t.Parallel()
 		server := mockbunny.New()
@@ -486,6 +540,33 @@ func TestUpdateRecord(t *testing.T) {
 		}
 	})
 
+	t.Run("smart routing fields are kept unless sent", func(t *testing.T) {
+		t.Parallel()
+		server := mockbunny.New()
+		defer server.Close()
+		zoneID := server.AddZoneWithRecords("example.com", []mockbunny.Record{
+			{Type: 0, Name: "www", Value: "1.2.3.4", TTL: 300, SmartRoutingType: 2, GeolocationLatitude: 40.7},
+		})
+		recordID := server.GetZone(zoneID).Records[0].ID
+
+		client := NewClient("test-key", WithBaseURL(server.URL()))
+		pullZoneID := int64(7)
+		accelerated := true
+		if _, err := client.UpdateRecord(context.Background(), zoneID, recordID, &AddRecordRequest{
+			Type: 0, Name: "www", Value: "2.3.4.5", TTL: 300, PullZoneID: &pullZoneID, Accelerated: &accelerated,
+		}); err != nil {
+			t.Fatalf("UpdateRecord failed: %v", err)
+		}
+
+		updated := server.GetZone(zoneID).Records[0]
+		if updated.SmartRoutingType != 2 || updated.GeolocationLatitude != 40.7 {
+			t.Errorf("unsent fields were reset: %+v", updated)
+		}
+		if !updated.Accelerated || updated.LinkName != "7" {
+			t.Errorf("sent fields were not applied: %+v", updated)
+		}
+	})
+
 	t.Run("zone not found error (404)", func(t *testing.T) {
 		t.Parallel()
 		server := mockbunny.New()
@@ -2366,3 +2447,136 @@ func TestHTTPClientTimeout(t *testing.T) {
 		t.Logf("Request correctly timed out after %v with error: %v", duration, err)
 	})
 }
+
+// headerTransport captures the headers of the last request.
+type headerTransport struct {
+	header http.Header
+}
+
+// RoundTrip implements http.RoundTripper for headerTransport.
+func (ht *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ht.header = req.Header.Clone()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"Items":[]}`)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+// TestClientIdentificationHeaders tests the User-Agent and contact headers.
+func TestClientIdentificationHeaders(t *testing.T) {
+	t.Parallel()
+
+	t.Run("configured", func(t *testing.T) {
+		t.Parallel()
+		transport := &headerTransport{}
+		client := NewClient("test-key",
+			WithHTTPClient(&http.Client{Transport: transport}),
+			WithUserAgent("bunny-api-proxy/1.2.3 (+proxy-1)"),
+			WithContact("ops@example.com"),
+		)
+		if _, err := client.ListZones(context.Background(), nil); err != nil {
+			t.Fatalf("ListZones failed: %v", err)
+		}
+		if got := transport.header.Get("User-Agent"); got != "bunny-api-proxy/1.2.3 (+proxy-1)" {
+			t.Errorf("User-Agent = %q", got)
+		}
+		if got := transport.header.Get("From"); got != "ops@example.com" {
+			t.Errorf("From = %q", got)
+		}
+		if got := transport.header.Get("AccessKey"); got != "test-key" {
+			t.Errorf("AccessKey = %q", got)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+		transport := &headerTransport{}
+		client := NewClient("test-key", WithHTTPClient(&http.Client{Transport: transport}))
+		if _, err := client.GetStatistics(context.Background(), nil); err != nil {
+			t.Fatalf("GetStatistics failed: %v", err)
+		}
+		if _, ok := transport.header["From"]; ok {
+			t.Error("From should not be set without a contact")
+		}
+		if _, ok := transport.header["User-Agent"]; ok {
+			t.Error("User-Agent should be left to net/http without configuration")
+		}
+	})
+}
+
+// TestSetAPIKey tests that a rotated key is sent with later requests.
+func TestSetAPIKey(t *testing.T) {
+	t.Parallel()
+	transport := &headerTransport{}
+	client := NewClient("old-key", WithHTTPClient(&http.Client{Transport: transport}))
+
+	client.SetAPIKey("new-key")
+	if _, err := client.ListZones(context.Background(), nil); err != nil {
+		t.Fatalf("ListZones failed: %v", err)
+	}
+	if got := transport.header.Get("AccessKey"); got != "new-key" {
+		t.Errorf("AccessKey = %q, want the rotated key", got)
+	}
+}
+
+func TestEndpointBaseURLs(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var hosts []string
+	record := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hosts = append(hosts, name+" "+r.URL.Path)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		}))
+	}
+	upstream, stats := record("main"), record("stats")
+	defer upstream.Close()
+	defer stats.Close()
+
+	client := NewClient("test-key", WithBaseURL(upstream.URL),
+		WithEndpointBaseURLs(map[string]string{EndpointStatistics: stats.URL + "/v2"}))
+
+	ctx := context.Background()
+	if _, err := client.GetStatistics(ctx, nil); err != nil {
+		t.Fatalf("GetStatistics failed: %v", err)
+	}
+	if _, err := client.GetZoneStatistics(ctx, 1, "", ""); err != nil {
+		t.Fatalf("GetZoneStatistics failed: %v", err)
+	}
+	if _, err := client.GetBilling(ctx); err != nil {
+		t.Fatalf("GetBilling failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"stats /v2/statistics", "stats /v2/dnszone/1/statistics", "main /billing"}
+	if strings.Join(hosts, ", ") != strings.Join(want, ", ") {
+		t.Errorf("requests = %v, want %v", hosts, want)
+	}
+}
+
+func TestParseEndpointBaseURLs(t *testing.T) {
+	t.Parallel()
+
+	urls, err := ParseEndpointBaseURLs([]string{" Statistics = https://stats.example.net/ ", "records=http://localhost:8081"})
+	if err != nil {
+		t.Fatalf("ParseEndpointBaseURLs failed: %v", err)
+	}
+	if len(urls) != 2 || urls[EndpointStatistics] != "https://stats.example.net" || urls[EndpointRecords] != "http://localhost:8081" {
+		t.Errorf("unexpected URLs: %v", urls)
+	}
+
+	for _, entry := range []string{"statistics", "statistics=", "pullzones=https://x", "zones=ftp://x", "zones=/relative"} {
+		if _, err := ParseEndpointBaseURLs([]string{entry}); err == nil {
+			t.Errorf("ParseEndpointBaseURLs(%q) expected an error", entry)
+		}
+	}
+	if _, err := ParseEndpointBaseURLs([]string{"zones=https://a", "zones=https://b"}); err == nil {
+		t.Error("expected an error for a class overridden twice")
+	}
+}
@@ -8,18 +8,27 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sipico/bunny-api-proxy/internal/logging"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
 	"github.com/sipico/bunny-api-proxy/internal/middleware"
 )
 
 // LoggingTransport wraps an http.RoundTripper and logs all HTTP interactions.
-// It redacts sensitive headers like AccessKey and Authorization.
+// It redacts sensitive headers like AccessKey and Authorization, and secrets
+// in TXT record values, and records the count, latency and in-flight number
+// of attempts per endpoint class.
 type LoggingTransport struct {
 	Transport http.RoundTripper
 	Logger    *slog.Logger
 	Prefix    string // e.g., "MOCK" or "REAL"
+
+	// LogBodies, if set, reports whether to log the request and response of
+	// a call in full at INFO level, as is otherwise only done at DEBUG level.
+	LogBodies func(ctx context.Context) bool
 }
 
 // RoundTrip implements http.RoundTripper interface
@@ -29,11 +38,18 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	// Extract request ID from context
 	requestID := middleware.GetRequestID(req.Context())
 
-	isDebug := t.Logger.Enabled(req.Context(), slog.LevelDebug)
+	// Bodies are logged at DEBUG level, or at INFO level while body logging
+	// is switched on for this call
+	bodyLevel := slog.LevelDebug
+	logBodies := t.Logger.Enabled(req.Context(), slog.LevelDebug)
+	if !logBodies && t.LogBodies != nil && t.LogBodies(req.Context()) {
+		bodyLevel = slog.LevelInfo
+		logBodies = true
+	}
 
-	// Only buffer request body if DEBUG logging is enabled
+	// Only buffer request body if bodies are logged
 	var reqBodyBytes []byte
-	if isDebug && req.Body != nil {
+	if logBodies && req.Body != nil {
 		var err error
 		reqBodyBytes, err = io.ReadAll(req.Body)
 		if err != nil {
@@ -44,7 +60,7 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	}
 
 	// DEBUG: Log full request details
-	if isDebug {
+	if logBodies {
 		reqHeaders := make(map[string]string)
 		for k, v := range req.Header {
 			if strings.EqualFold(k, "AccessKey") || strings.EqualFold(k, "Authorization") {
@@ -54,21 +70,25 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 			}
 		}
 
-		t.Logger.Debug("Bunny API request",
+		t.Logger.Log(req.Context(), bodyLevel, "Bunny API request",
 			"request_id", requestID,
 			"prefix", t.Prefix,
 			"method", req.Method,
 			"url", req.URL.String(),
 			"headers", reqHeaders,
-			"body", string(reqBodyBytes),
+			"body", string(logging.RedactSecrets(reqBodyBytes)),
 		)
 	}
 
 	// Execute the request
+	class := endpointClass(req.URL.Path)
+	metrics.RecordUpstreamInFlight(class, 1)
 	resp, err := t.transport().RoundTrip(req)
 	duration := time.Since(start)
+	metrics.RecordUpstreamInFlight(class, -1)
 
 	if err != nil {
+		metrics.RecordUpstreamAttempt(class, req.Method, "error", duration.Seconds())
 		// Log error
 		t.Logger.Error("Bunny API call failed",
 			"request_id", requestID,
@@ -80,10 +100,11 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 		)
 		return nil, err
 	}
+	metrics.RecordUpstreamAttempt(class, req.Method, strconv.Itoa(resp.StatusCode), duration.Seconds())
 
-	// Only buffer response body if DEBUG logging is enabled
+	// Only buffer response body if bodies are logged
 	var respBodyBytes []byte
-	if isDebug {
+	if logBodies {
 		var err error
 		respBodyBytes, err = io.ReadAll(resp.Body)
 		if err != nil {
@@ -104,14 +125,14 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	)
 
 	// DEBUG: Log full response details
-	if isDebug {
-		t.Logger.Debug("Bunny API response",
+	if logBodies {
+		t.Logger.Log(req.Context(), bodyLevel, "Bunny API response",
 			"request_id", requestID,
 			"prefix", t.Prefix,
 			"status_code", resp.StatusCode,
 			"status", resp.Status,
 			"headers", resp.Header,
-			"body", string(respBodyBytes),
+			"body", string(logging.RedactSecrets(respBodyBytes)),
 		)
 	}
 
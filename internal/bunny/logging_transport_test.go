@@ -1064,6 +1064,46 @@ func TestLoggingTransport_DoesNotBufferAtInfoLevel(t *testing.T) {
 	}
 }
 
+// TestLoggingTransport_LogBodiesAtInfoLevel tests that switched-on body
+// logging logs redacted bodies at INFO level.
+func TestLoggingTransport_LogBodiesAtInfoLevel(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	requestBody := `{"Type":3,"Name":"_acme-challenge","Value":"gfj9Xq-Ae2v6d7tD0Wl1u8Xr4mK3bNcJpQ5zYhS9aLk"}`
+	mockTransport := &mockRoundTripper{
+		response: &http.Response{
+			StatusCode: http.StatusBadRequest,
+			Body:       io.NopCloser(strings.NewReader(`{"ErrorKey":"validation_error"}`)),
+			Header:     make(http.Header),
+		},
+	}
+
+	lt := &LoggingTransport{
+		Transport: mockTransport,
+		Logger:    logger,
+		Prefix:    "TEST",
+		LogBodies: func(context.Context) bool { return true },
+	}
+
+	req, err := http.NewRequest("PUT", "https://api.bunny.net/dnszone/1/records", strings.NewReader(requestBody))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if _, err := lt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip failed: %v", err)
+	}
+
+	logs := buf.String()
+	if !strings.Contains(logs, `"msg":"Bunny API request"`) || !strings.Contains(logs, "validation_error") {
+		t.Errorf("expected request and response bodies at INFO level, got: %s", logs)
+	}
+	if strings.Contains(logs, "gfj9Xq") || !strings.Contains(logs, "[REDACTED]") {
+		t.Errorf("expected the TXT value to be redacted, got: %s", logs)
+	}
+}
+
 // TestLoggingTransport_BuffersAtDebugLevel tests that request/response bodies ARE buffered at DEBUG level.
 func TestLoggingTransport_BuffersAtDebugLevel(t *testing.T) {
 	t.Parallel()
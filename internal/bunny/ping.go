@@ -0,0 +1,49 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrUpstreamUnavailable is returned by Ping when the API cannot be reached or
+// answers with a server error.
+var ErrUpstreamUnavailable = errors.New("bunny.net API unavailable")
+
+// Ping checks that the API is reachable with a HEAD request on the base URL.
+// The API key is not sent, so any response below 500 counts as reachable.
+// Errors never include the base URL, so they are safe to show unauthenticated callers.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: invalid base URL", ErrUpstreamUnavailable)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	//nolint:errcheck
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status %d", ErrUpstreamUnavailable, resp.StatusCode)
+	}
+	return nil
+}
+
+// VerifyKey checks that bunny.net accepts the API key by listing one page of
+// zones. It returns ErrUnauthorized if the key is rejected.
+func (c *Client) VerifyKey(ctx context.Context) error {
+	_, err := c.ListZones(ctx, &ListZonesOptions{Page: 1, PerPage: 5})
+	return err
+}
@@ -0,0 +1,84 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"ok", http.StatusOK, false},
+		{"unauthorized still reachable", http.StatusUnauthorized, false},
+		{"not found still reachable", http.StatusNotFound, false},
+		{"server error", http.StatusBadGateway, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					t.Errorf("expected HEAD, got %s", r.Method)
+				}
+				if r.Header.Get("AccessKey") != "" {
+					t.Error("Ping must not send the API key")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewClient("secret-key", WithBaseURL(server.URL)).Ping(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUpstreamUnavailable) {
+				t.Errorf("expected ErrUpstreamUnavailable, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPing_Unreachable(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.NotFoundHandler())
+	baseURL := server.URL
+	server.Close()
+
+	err := NewClient("secret-key", WithBaseURL(baseURL)).Ping(context.Background())
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("expected ErrUpstreamUnavailable, got %v", err)
+	}
+	if strings.Contains(err.Error(), baseURL) {
+		t.Errorf("error should not include the base URL: %v", err)
+	}
+}
+
+func TestVerifyKey(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("AccessKey") != "valid-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"CurrentPage":1,"TotalItems":0,"HasMoreItems":false,"Items":[]}`)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	if err := NewClient("valid-key", WithBaseURL(server.URL)).VerifyKey(context.Background()); err != nil {
+		t.Errorf("VerifyKey() with a valid key = %v", err)
+	}
+	if err := NewClient("rotated-key", WithBaseURL(server.URL)).VerifyKey(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("VerifyKey() with a rejected key = %v, want ErrUnauthorized", err)
+	}
+}
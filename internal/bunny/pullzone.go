@@ -0,0 +1,54 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Pull zone (CDN) endpoints. Bodies and responses are passed through as raw
+// JSON because the proxy forwards them without modification; pull zone
+// models are large and change more often than the DNS ones.
+
+// ListPullZones retrieves pull zones. The query parameters (page, perPage,
+// search, includeCertificate) are forwarded unchanged. The response is a
+// paginated list with Items, CurrentPage, TotalItems and HasMoreItems.
+// GET /pullzone
+func (c *Client) ListPullZones(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, "/pullzone", query)
+}
+
+// GetPullZone retrieves a single pull zone by ID.
+// GET /pullzone/{id}
+func (c *Client) GetPullZone(ctx context.Context, id int64, query url.Values) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, fmt.Sprintf("/pullzone/%d", id), query)
+}
+
+// AddPullZone creates a new pull zone from a PullZoneCreate body.
+// POST /pullzone
+func (c *Client) AddPullZone(ctx context.Context, body json.RawMessage) (json.RawMessage, error) {
+	return c.doRawJSON(ctx, http.MethodPost, "/pullzone", nil, body)
+}
+
+// UpdatePullZone updates a pull zone's settings.
+// POST /pullzone/{id}
+func (c *Client) UpdatePullZone(ctx context.Context, id int64, body json.RawMessage) (json.RawMessage, error) {
+	return c.doRawJSON(ctx, http.MethodPost, fmt.Sprintf("/pullzone/%d", id), nil, body)
+}
+
+// DeletePullZone deletes a pull zone.
+// DELETE /pullzone/{id}
+func (c *Client) DeletePullZone(ctx context.Context, id int64) error {
+	_, err := c.doRawJSON(ctx, http.MethodDelete, fmt.Sprintf("/pullzone/%d", id), nil, nil)
+	return err
+}
+
+// PurgePullZoneCache purges a pull zone's cache. The optional body (e.g.
+// {"CacheTag": "..."}) limits the purge.
+// POST /pullzone/{id}/purgeCache
+func (c *Client) PurgePullZoneCache(ctx context.Context, id int64, body json.RawMessage) error {
+	_, err := c.doRawJSON(ctx, http.MethodPost, fmt.Sprintf("/pullzone/%d/purgeCache", id), nil, body)
+	return err
+}
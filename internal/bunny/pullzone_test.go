@@ -0,0 +1,125 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestPullZoneMethods(t *testing.T) {
+	t.Parallel()
+
+	type call struct {
+		method, path, query, body string
+	}
+	var got call
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = call{r.Method, r.URL.Path, r.URL.RawQuery, string(body)}
+		if r.Header.Get("AccessKey") != "test-key" {
+			t.Errorf("missing AccessKey header")
+		}
+		if len(body) > 0 && r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		switch {
+		case r.Method == http.MethodDelete, r.URL.Path == "/pullzone/7/purgeCache":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/pullzone":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"Id":8}`))
+		default:
+			w.Write([]byte(`{"Id":7}`))
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", WithBaseURL(ts.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		do   func() (json.RawMessage, error)
+		want call
+		resp string
+	}{
+		{
+			name: "list",
+			do:   func() (json.RawMessage, error) { return client.ListPullZones(ctx, url.Values{"search": {"cdn"}}) },
+			want: call{http.MethodGet, "/pullzone", "search=cdn", ""},
+			resp: `{"Id":7}`,
+		},
+		{
+			name: "get",
+			do:   func() (json.RawMessage, error) { return client.GetPullZone(ctx, 7, nil) },
+			want: call{http.MethodGet, "/pullzone/7", "", ""},
+			resp: `{"Id":7}`,
+		},
+		{
+			name: "add",
+			do:   func() (json.RawMessage, error) { return client.AddPullZone(ctx, json.RawMessage(`{"Name":"cdn"}`)) },
+			want: call{http.MethodPost, "/pullzone", "", `{"Name":"cdn"}`},
+			resp: `{"Id":8}`,
+		},
+		{
+			name: "update",
+			do: func() (json.RawMessage, error) {
+				return client.UpdatePullZone(ctx, 7, json.RawMessage(`{"EnableCacheSlice":true}`))
+			},
+			want: call{http.MethodPost, "/pullzone/7", "", `{"EnableCacheSlice":true}`},
+			resp: `{"Id":7}`,
+		},
+		{
+			name: "delete",
+			do:   func() (json.RawMessage, error) { return nil, client.DeletePullZone(ctx, 7) },
+			want: call{http.MethodDelete, "/pullzone/7", "", ""},
+		},
+		{
+			name: "purge",
+			do: func() (json.RawMessage, error) {
+				return nil, client.PurgePullZoneCache(ctx, 7, json.RawMessage(`{"CacheTag":"img"}`))
+			},
+			want: call{http.MethodPost, "/pullzone/7/purgeCache", "", `{"CacheTag":"img"}`},
+		},
+	}
+
+	// Subtests share the server's recorded call, so they run sequentially.
+	for _, tt := range tests {
+		resp, err := tt.do()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: request = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if string(resp) != tt.resp {
+			t.Errorf("%s: response = %s, want %s", tt.name, resp, tt.resp)
+		}
+	}
+}
+
+func TestPullZoneErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pullzone/1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", WithBaseURL(ts.URL))
+	if _, err := client.GetPullZone(context.Background(), 1, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetPullZone() error = %v, want ErrNotFound", err)
+	}
+	if err := client.PurgePullZoneCache(context.Background(), 2, nil); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("PurgePullZoneCache() error = %v, want ErrUnauthorized", err)
+	}
+}
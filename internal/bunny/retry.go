@@ -0,0 +1,178 @@
+package bunny
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+const (
+	// DefaultRetryBaseDelay is the delay before the first retry when a
+	// RetryPolicy does not set one.
+	DefaultRetryBaseDelay = 200 * time.Millisecond
+
+	// DefaultRetryMaxDelay caps each retry delay when a RetryPolicy does not set one.
+	DefaultRetryMaxDelay = 10 * time.Second
+)
+
+// DefaultRetryStatusCodes are the statuses retried when a RetryPolicy does
+// not list any: rate limiting and transient gateway failures.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy controls how the client retries transient upstream failures.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the
+	// first. Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each retry
+	// after it. Delays are jittered by up to half.
+	BaseDelay time.Duration
+
+	// MaxDelay caps each delay. A Retry-After header asking for a longer wait
+	// ends the retries instead.
+	MaxDelay time.Duration
+
+	// StatusCodes are the response statuses that are retried.
+	StatusCodes []int
+}
+
+// WithRetry retries requests that fail with one of the policy's status codes,
+// honoring Retry-After. Network errors are retried for GET and DELETE only,
+// since another method's request may have reached bunny.net. Without this
+// option requests are not retried.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		if policy.BaseDelay <= 0 {
+			policy.BaseDelay = DefaultRetryBaseDelay
+		}
+		if policy.MaxDelay <= 0 {
+			policy.MaxDelay = DefaultRetryMaxDelay
+		}
+		if policy.StatusCodes == nil {
+			policy.StatusCodes = DefaultRetryStatusCodes
+		}
+		c.retry = policy
+	}
+}
+
+// do sends req, retrying transient failures according to the retry policy.
+// Retries are counted in bunny_proxy_upstream_retries_total. The outcome,
+// after retries, is recorded in the health of the request's endpoint class,
+// and requests to a class with an open circuit fail with ErrCircuitOpen.
+// A rejected API key is reported to the alerter.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	class := endpointClass(req.URL.Path)
+	if err := c.health.allow(class); err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	c.health.record(req.Context(), class, resp, err)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		c.alertAuthFailure(req, class)
+	}
+	return resp, err
+}
+
+// send sends req, retrying transient failures.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if attempt >= c.retry.MaxAttempts || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		reason, delay, ok := c.retry.next(req, resp, err, attempt)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			//nolint:errcheck
+			io.Copy(io.Discard, resp.Body)
+			//nolint:errcheck
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil { // coverage-ignore: bytes readers always rewind
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+
+		metrics.RecordUpstreamRetry(reason)
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next decides whether a failed attempt is retried, returning the metric
+// reason and the delay before the next attempt.
+func (p RetryPolicy) next(req *http.Request, resp *http.Response, err error, attempt int) (reason string, delay time.Duration, ok bool) {
+	if err != nil {
+		if req.Context().Err() != nil || (req.Method != http.MethodGet && req.Method != http.MethodDelete) {
+			return "", 0, false
+		}
+		return "error", p.backoff(attempt), true
+	}
+	if !slices.Contains(p.StatusCodes, resp.StatusCode) {
+		return "", 0, false
+	}
+
+	delay = p.backoff(attempt)
+	if after, found := retryAfter(resp.Header.Get("Retry-After")); found {
+		if after > p.MaxDelay {
+			return "", 0, false
+		}
+		delay = max(delay, after)
+	}
+	return strconv.Itoa(resp.StatusCode), delay, true
+}
+
+// backoff returns the jittered delay after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MaxDelay
+	if shift := attempt - 1; shift < 32 && p.BaseDelay<<shift > 0 && p.BaseDelay<<shift < p.MaxDelay {
+		d = p.BaseDelay << shift
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date.
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning early with the context's error if it
+// is canceled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,194 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with status, then answers
+// with okStatus and okBody. bodies returns every request body received.
+func flakyServer(t *testing.T, failures int, status int, retryAfterHeader string, okStatus int, okBody string) (server *httptest.Server, count *atomic.Int32, bodies func() []string) {
+	t.Helper()
+	count = new(atomic.Int32)
+	var mu sync.Mutex
+	var received []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, string(body))
+		mu.Unlock()
+		if int(count.Add(1)) <= failures {
+			if retryAfterHeader != "" {
+				w.Header().Set("Retry-After", retryAfterHeader)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(okStatus)
+		_, _ = io.WriteString(w, okBody)
+	}))
+	t.Cleanup(server.Close)
+	return server, count, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(received)
+	}
+}
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetry_TransientStatusThenSuccess(t *testing.T) {
+	t.Parallel()
+	server, count, bodies := flakyServer(t, 2, http.StatusBadGateway, "", http.StatusCreated, `{"Id":7}`)
+
+	client := NewClient("key", WithBaseURL(server.URL), WithRetry(fastRetry))
+	record, err := client.AddRecord(context.Background(), 1, &AddRecordRequest{Type: 3, Name: "_acme-challenge", Value: "token"})
+	if err != nil {
+		t.Fatalf("AddRecord failed: %v", err)
+	}
+	if record.ID != 7 || count.Load() != 3 {
+		t.Errorf("got record %d after %d attempts, want record 7 after 3", record.ID, count.Load())
+	}
+	for i, body := range bodies() {
+		if !strings.Contains(body, `"Value":"token"`) {
+			t.Errorf("attempt %d sent body %q, want the record", i+1, body)
+		}
+	}
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		policy     RetryPolicy
+		want       int32
+	}{
+		{"attempts exhausted", http.StatusServiceUnavailable, "", fastRetry, 3},
+		{"not retryable", http.StatusBadRequest, "", fastRetry, 1},
+		{"retries disabled", http.StatusBadGateway, "", RetryPolicy{}, 1},
+		{"retry-after too long", http.StatusTooManyRequests, "60", fastRetry, 1},
+		{"custom status codes", http.StatusBadGateway, "", RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, StatusCodes: []int{http.StatusTooManyRequests}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			server, count, _ := flakyServer(t, 10, tt.status, tt.retryAfter, http.StatusOK, `{}`)
+
+			client := NewClient("key", WithBaseURL(server.URL), WithRetry(tt.policy))
+			if _, err := client.GetZone(context.Background(), 1); err == nil {
+				t.Fatal("expected an error")
+			}
+			if n := count.Load(); n != tt.want {
+				t.Errorf("got %d attempts, want %d", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+	server, count, _ := flakyServer(t, 1, http.StatusTooManyRequests, "1", http.StatusOK, `{"Id":1}`)
+
+	client := NewClient("key", WithBaseURL(server.URL), WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}))
+	start := time.Now()
+	if _, err := client.GetZone(context.Background(), 1); err != nil {
+		t.Fatalf("GetZone failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || count.Load() != 2 {
+		t.Errorf("got %d attempts in %v, want 2 attempts at least a second apart", count.Load(), elapsed)
+	}
+}
+
+// errTransport fails every request with a network error.
+type errTransport struct {
+	count atomic.Int32
+}
+
+func (et *errTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	et.count.Add(1)
+	return nil, errors.New("connection reset")
+}
+
+func TestRetry_NetworkErrors(t *testing.T) {
+	t.Parallel()
+
+	transport := &errTransport{}
+	client := NewClient("key", WithHTTPClient(&http.Client{Transport: transport}), WithRetry(fastRetry))
+
+	if _, err := client.GetZone(context.Background(), 1); err == nil {
+		t.Fatal("expected GetZone to fail")
+	}
+	if n := transport.count.Load(); n != 3 {
+		t.Errorf("expected GET to be retried, got %d attempts", n)
+	}
+
+	transport.count.Store(0)
+	if _, err := client.CreateZone(context.Background(), "example.com"); err == nil {
+		t.Fatal("expected CreateZone to fail")
+	}
+	if n := transport.count.Load(); n != 1 {
+		t.Errorf("expected POST not to be retried after a network error, got %d attempts", n)
+	}
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	t.Parallel()
+	server, count, _ := flakyServer(t, 10, http.StatusBadGateway, "", http.StatusOK, `{}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := NewClient("key", WithBaseURL(server.URL), WithRetry(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Minute, MaxDelay: time.Minute}))
+	if _, err := client.GetZone(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("expected 1 attempt, got %d", n)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	t.Parallel()
+
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second, 100: time.Second} {
+		for range 20 {
+			if d := p.backoff(attempt); d < want/2 || d > want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, d, want/2, want)
+			}
+		}
+	}
+}
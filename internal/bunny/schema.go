@@ -0,0 +1,202 @@
+package bunny
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+// SchemaCheck controls how fields in bunny.net responses that the client
+// types do not declare are handled. Without a check they are silently dropped.
+type SchemaCheck string
+
+const (
+	// SchemaCheckOff decodes responses leniently without looking for unknown fields.
+	SchemaCheckOff SchemaCheck = "off"
+	// SchemaCheckLog logs and counts unknown fields but still decodes the response.
+	SchemaCheckLog SchemaCheck = "log"
+	// SchemaCheckStrict logs and counts unknown fields and fails the decode.
+	SchemaCheckStrict SchemaCheck = "strict"
+)
+
+// ErrSchemaDrift is returned in strict mode when a response contains unknown fields.
+var ErrSchemaDrift = errors.New("bunny: response contains unknown fields")
+
+// ParseSchemaCheck parses a schema check mode. An empty string means off.
+func ParseSchemaCheck(s string) (SchemaCheck, error) {
+	switch mode := SchemaCheck(strings.ToLower(s)); mode {
+	case "", SchemaCheckOff:
+		return SchemaCheckOff, nil
+	case SchemaCheckLog, SchemaCheckStrict:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid schema check mode %q: must be off, log or strict", s)
+}
+
+// WithSchemaCheck enables detection of unknown response fields.
+// Each unknown field is logged once per process and counted in
+// bunny_proxy_upstream_unknown_fields_total on every occurrence.
+func WithSchemaCheck(mode SchemaCheck, logger *slog.Logger) Option {
+	return func(c *Client) {
+		if mode == "" || mode == SchemaCheckOff {
+			c.drift = nil
+			return
+		}
+		if logger == nil {
+			logger = slog.Default()
+		}
+		c.drift = &schemaDrift{mode: mode, logger: logger}
+	}
+}
+
+// schemaDrift reports unknown response fields.
+type schemaDrift struct {
+	mode   SchemaCheck
+	logger *slog.Logger
+
+	// logged holds the type.field keys already logged.
+	logged sync.Map
+}
+
+// decode unmarshals a successful response body into v, checking for unknown
+// fields when a schema check is enabled.
+func (c *Client) decode(body []byte, v any) error {
+	if c.drift == nil {
+		return json.Unmarshal(body, v)
+	}
+
+	typeName := reflect.TypeOf(v).Elem().Name()
+	fields := unknownFields(body, reflect.TypeOf(v))
+	for _, field := range fields {
+		metrics.RecordUpstreamUnknownField(typeName, field)
+		if _, seen := c.drift.logged.LoadOrStore(typeName+"."+field, true); !seen {
+			c.drift.logger.Warn("bunny.net response contains a field unknown to the proxy",
+				"type", typeName, "field", field, "mode", string(c.drift.mode))
+		}
+	}
+
+	if c.drift.mode != SchemaCheckStrict {
+		return json.Unmarshal(body, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if len(fields) > 0 {
+			return fmt.Errorf("%w: %s: %s", ErrSchemaDrift, typeName, strings.Join(fields, ", "))
+		}
+		return err
+	}
+	return nil
+}
+
+var unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// unknownFields returns the sorted paths of object keys in data that have no
+// matching field in t, e.g. "Records[].NewField". Keys are matched
+// case-insensitively, as encoding/json does. Invalid JSON yields no fields;
+// the decode itself reports it.
+func unknownFields(data []byte, t reflect.Type) []string {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	found := make(map[string]struct{})
+	walkUnknown(raw, t, "", found)
+
+	fields := make([]string, 0, len(found))
+	for f := range found {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// walkUnknown records unknown keys of raw against t under path.
+func walkUnknown(raw any, t reflect.Type, path string, found map[string]struct{}) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types with custom decoding, such as BunnyTime, define their own shape.
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		known := jsonFields(t)
+		for key, value := range obj {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			ft, ok := known[strings.ToLower(key)]
+			if !ok {
+				found[child] = struct{}{}
+				continue
+			}
+			walkUnknown(value, ft, child, found)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := raw.([]any)
+		if !ok {
+			return
+		}
+		for _, elem := range arr {
+			walkUnknown(elem, t.Elem(), path+"[]", found)
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, value := range obj {
+			walkUnknown(value, t.Elem(), path+".*", found)
+		}
+	}
+}
+
+// jsonFields maps the lower-cased JSON names of t's fields to their types,
+// including fields promoted from embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,130 @@
+package bunny
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const driftZoneJSON = `{
+	"Id": 1,
+	"Domain": "example.com",
+	"DateCreated": "2024-01-01T00:00:00",
+	"ShinyNewSetting": true,
+	"Records": [
+		{"Id": 10, "Type": 0, "Name": "www", "Value": "1.2.3.4", "HealthCheckGroup": "pz"},
+		{"id": 11, "type": 3, "Name": "txt", "Value": "v", "HealthCheckGroup": "", "ShinyRecordFlag": true}
+	]
+}`
+
+func TestParseSchemaCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    SchemaCheck
+		wantErr bool
+	}{
+		{"", SchemaCheckOff, false},
+		{"off", SchemaCheckOff, false},
+		{"LOG", SchemaCheckLog, false},
+		{"strict", SchemaCheckStrict, false},
+		{"panic", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSchemaCheck(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSchemaCheck(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	got := unknownFields([]byte(driftZoneJSON), reflect.TypeOf(&Zone{}))
+	want := []string{"Records[].HealthCheckGroup", "Records[].ShinyRecordFlag", "ShinyNewSetting"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unknownFields() = %v, want %v", got, want)
+	}
+
+	if got := unknownFields([]byte(`{"Id":1,"domain":"example.com"}`), reflect.TypeOf(&Zone{})); len(got) != 0 {
+		t.Errorf("expected case-insensitive match to be known, got %v", got)
+	}
+	if got := unknownFields([]byte(`not json`), reflect.TypeOf(&Zone{})); len(got) != 0 {
+		t.Errorf("expected no fields for invalid JSON, got %v", got)
+	}
+}
+
+func TestSchemaCheck_Modes(t *testing.T) {
+	t.Parallel()
+
+	newClient := func(mode SchemaCheck, logs *bytes.Buffer) *Client {
+		logger := slog.New(slog.NewTextHandler(logs, nil))
+		return NewClient("test-key",
+			WithBaseURL("http://bunny.test"),
+			WithHTTPClient(&http.Client{Transport: &mockTransport{statusCode: http.StatusOK, body: []byte(driftZoneJSON)}}),
+			WithSchemaCheck(mode, logger),
+		)
+	}
+
+	t.Run("off", func(t *testing.T) {
+		t.Parallel()
+		var logs bytes.Buffer
+		zone, err := newClient(SchemaCheckOff, &logs).GetZone(context.Background(), 1)
+		if err != nil || zone.Domain != "example.com" {
+			t.Fatalf("expected lenient decode, got %v, %v", zone, err)
+		}
+		if logs.Len() != 0 {
+			t.Errorf("expected no logs, got %s", logs.String())
+		}
+	})
+
+	t.Run("log", func(t *testing.T) {
+		t.Parallel()
+		var logs bytes.Buffer
+		client := newClient(SchemaCheckLog, &logs)
+		for range 2 {
+			zone, err := client.GetZone(context.Background(), 1)
+			if err != nil || len(zone.Records) != 2 {
+				t.Fatalf("expected decode to succeed, got %v, %v", zone, err)
+			}
+		}
+		if n := strings.Count(logs.String(), "field=ShinyNewSetting"); n != 1 {
+			t.Errorf("expected unknown field to be logged once, got %d in %s", n, logs.String())
+		}
+		if !strings.Contains(logs.String(), "field=Records[].HealthCheckGroup") {
+			t.Errorf("expected nested field to be logged, got %s", logs.String())
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		t.Parallel()
+		var logs bytes.Buffer
+		_, err := newClient(SchemaCheckStrict, &logs).GetZone(context.Background(), 1)
+		if !errors.Is(err, ErrSchemaDrift) {
+			t.Fatalf("expected ErrSchemaDrift, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "ShinyNewSetting") {
+			t.Errorf("expected error to name the unknown field, got %v", err)
+		}
+	})
+
+	t.Run("strict with known fields", func(t *testing.T) {
+		t.Parallel()
+		client := NewClient("test-key",
+			WithBaseURL("http://bunny.test"),
+			WithHTTPClient(&http.Client{Transport: &mockTransport{statusCode: http.StatusOK, body: []byte(`{"Available":true}`)}}),
+			WithSchemaCheck(SchemaCheckStrict, nil),
+		)
+		resp, err := client.CheckZoneAvailability(context.Background(), "example.com")
+		if err != nil || !resp.Available {
+			t.Errorf("expected strict decode to succeed, got %v, %v", resp, err)
+		}
+	})
+}
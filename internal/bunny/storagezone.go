@@ -0,0 +1,46 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Storage zone endpoints. Like pull zones, bodies and responses are passed
+// through as raw JSON.
+
+// ListStorageZones retrieves storage zones. The query parameters (page,
+// perPage, includeDeleted, search) are forwarded unchanged.
+// GET /storagezone
+func (c *Client) ListStorageZones(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, "/storagezone", query)
+}
+
+// GetStorageZone retrieves a single storage zone by ID.
+// GET /storagezone/{id}
+func (c *Client) GetStorageZone(ctx context.Context, id int64) (json.RawMessage, error) {
+	return c.getRawJSON(ctx, fmt.Sprintf("/storagezone/%d", id), nil)
+}
+
+// AddStorageZone creates a new storage zone from a StorageZoneCreate body.
+// POST /storagezone
+func (c *Client) AddStorageZone(ctx context.Context, body json.RawMessage) (json.RawMessage, error) {
+	return c.doRawJSON(ctx, http.MethodPost, "/storagezone", nil, body)
+}
+
+// DeleteStorageZone deletes a storage zone. The query parameters
+// (deleteLinkedPullZones) are forwarded unchanged.
+// DELETE /storagezone/{id}
+func (c *Client) DeleteStorageZone(ctx context.Context, id int64, query url.Values) error {
+	_, err := c.doRawJSON(ctx, http.MethodDelete, fmt.Sprintf("/storagezone/%d", id), query, nil)
+	return err
+}
+
+// ResetStorageZonePassword generates a new password for a storage zone.
+// POST /storagezone/{id}/resetPassword
+func (c *Client) ResetStorageZonePassword(ctx context.Context, id int64) error {
+	_, err := c.doRawJSON(ctx, http.MethodPost, fmt.Sprintf("/storagezone/%d/resetPassword", id), nil, nil)
+	return err
+}
@@ -0,0 +1,109 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestStorageZoneMethods(t *testing.T) {
+	t.Parallel()
+
+	type call struct {
+		method, path, query, body string
+	}
+	var got call
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = call{r.Method, r.URL.Path, r.URL.RawQuery, string(body)}
+		if r.Header.Get("AccessKey") != "test-key" {
+			t.Errorf("missing AccessKey header")
+		}
+		switch {
+		case r.Method == http.MethodDelete, r.URL.Path == "/storagezone/5/resetPassword":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"Id":6}`))
+		default:
+			w.Write([]byte(`{"Id":5}`))
+		}
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", WithBaseURL(ts.URL))
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		do   func() (json.RawMessage, error)
+		want call
+		resp string
+	}{
+		{
+			name: "list",
+			do:   func() (json.RawMessage, error) { return client.ListStorageZones(ctx, url.Values{"page": {"2"}}) },
+			want: call{http.MethodGet, "/storagezone", "page=2", ""},
+			resp: `{"Id":5}`,
+		},
+		{
+			name: "get",
+			do:   func() (json.RawMessage, error) { return client.GetStorageZone(ctx, 5) },
+			want: call{http.MethodGet, "/storagezone/5", "", ""},
+			resp: `{"Id":5}`,
+		},
+		{
+			name: "add",
+			do: func() (json.RawMessage, error) {
+				return client.AddStorageZone(ctx, json.RawMessage(`{"Name":"backups","Region":"DE"}`))
+			},
+			want: call{http.MethodPost, "/storagezone", "", `{"Name":"backups","Region":"DE"}`},
+			resp: `{"Id":6}`,
+		},
+		{
+			name: "delete",
+			do: func() (json.RawMessage, error) {
+				return nil, client.DeleteStorageZone(ctx, 5, url.Values{"deleteLinkedPullZones": {"true"}})
+			},
+			want: call{http.MethodDelete, "/storagezone/5", "deleteLinkedPullZones=true", ""},
+		},
+		{
+			name: "reset password",
+			do:   func() (json.RawMessage, error) { return nil, client.ResetStorageZonePassword(ctx, 5) },
+			want: call{http.MethodPost, "/storagezone/5/resetPassword", "", ""},
+		},
+	}
+
+	// Subtests share the server's recorded call, so they run sequentially.
+	for _, tt := range tests {
+		resp, err := tt.do()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: request = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if string(resp) != tt.resp {
+			t.Errorf("%s: response = %s, want %s", tt.name, resp, tt.resp)
+		}
+	}
+}
+
+func TestStorageZoneErrors(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	client := NewClient("test-key", WithBaseURL(ts.URL))
+	if err := client.ResetStorageZonePassword(context.Background(), 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResetStorageZonePassword() error = %v, want ErrNotFound", err)
+	}
+}
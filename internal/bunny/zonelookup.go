@@ -0,0 +1,128 @@
+package bunny
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// zoneLookupTTL is how long FindZoneByDomain reuses a lookup, including
+	// lookups that found no zone.
+	zoneLookupTTL = time.Minute
+
+	// zoneLookupMaxEntries bounds the lookup cache; it is emptied when full.
+	zoneLookupMaxEntries = 10000
+
+	// zoneLookupPageSize is the page size used to search zones by name.
+	zoneLookupPageSize = 1000
+)
+
+// zoneLookupCache maps domain names to the zone with that exact name, or to
+// nil if there is none.
+type zoneLookupCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]zoneLookupEntry
+}
+
+type zoneLookupEntry struct {
+	zone      *Zone // nil when no zone has the name
+	fetchedAt time.Time
+}
+
+func newZoneLookupCache() *zoneLookupCache {
+	return &zoneLookupCache{
+		now:     time.Now,
+		entries: make(map[string]zoneLookupEntry),
+	}
+}
+
+// get returns the cached lookup for name, if it has not expired.
+func (c *zoneLookupCache) get(name string) (zone *Zone, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[name]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(e.fetchedAt) >= zoneLookupTTL {
+		delete(c.entries, name)
+		return nil, false
+	}
+	return e.zone, true
+}
+
+func (c *zoneLookupCache) put(name string, zone *Zone) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= zoneLookupMaxEntries {
+		clear(c.entries)
+	}
+	c.entries[name] = zoneLookupEntry{zone: zone, fetchedAt: c.now()}
+}
+
+// reset drops every lookup, after a zone is created or deleted.
+func (c *zoneLookupCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// FindZoneByDomain returns the zone a hostname belongs to: the zone named
+// after the hostname or its closest parent domain. A trailing dot and a
+// leading "*." are ignored. Returns ErrNotFound if no zone matches.
+//
+// Lookups are cached for a minute, including misses, and dropped when the
+// client creates or deletes a zone. The returned zone has no records.
+func (c *Client) FindZoneByDomain(ctx context.Context, hostname string) (*Zone, error) {
+	hostname = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(hostname, ".")), "*.")
+	labels := strings.Split(hostname, ".")
+	if len(labels) < 2 {
+		return nil, fmt.Errorf("%w: no zone for %q", ErrNotFound, hostname)
+	}
+
+	// Zones are at least two labels deep, so the top-level domain is never searched
+	for i := 0; i < len(labels)-1; i++ {
+		zone, err := c.zoneNamed(ctx, strings.Join(labels[i:], "."))
+		if err != nil {
+			return nil, err
+		}
+		if zone != nil {
+			found := *zone
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no zone for %q", ErrNotFound, hostname)
+}
+
+// zoneNamed returns the zone named exactly name, or nil if there is none.
+func (c *Client) zoneNamed(ctx context.Context, name string) (*Zone, error) {
+	if zone, ok := c.zoneLookups.get(name); ok {
+		return zone, nil
+	}
+
+	// The search matches parts of names, so page through every match
+	var zone *Zone
+	for page := 1; zone == nil; page++ {
+		result, err := c.ListZones(ctx, &ListZonesOptions{Page: page, PerPage: zoneLookupPageSize, Search: name})
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range result.Items {
+			if strings.EqualFold(z.Domain, name) {
+				z.Records = nil
+				zone = &z
+				break
+			}
+		}
+		if !result.HasMoreItems || len(result.Items) == 0 {
+			break
+		}
+	}
+
+	c.zoneLookups.put(name, zone)
+	return zone, nil
+}
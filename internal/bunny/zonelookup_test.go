@@ -0,0 +1,124 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockbunny"
+)
+
+// countingTransport counts requests sent through it.
+type countingTransport struct {
+	count atomic.Int64
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.count.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFindZoneByDomain(t *testing.T) {
+	t.Parallel()
+	server := mockbunny.New()
+	defer server.Close()
+
+	exampleID := server.AddZone("example.com")
+	subID := server.AddZone("sub.example.com")
+	server.AddZone("myexample.com")
+
+	transport := &countingTransport{}
+	client := NewClient("test-key", WithBaseURL(server.URL()), WithHTTPClient(&http.Client{Transport: transport}))
+	ctx := context.Background()
+
+	tests := []struct {
+		hostname string
+		want     int64
+	}{
+		{"example.com", exampleID},
+		{"www.example.com.", exampleID},
+		{"_acme-challenge.WWW.Example.com", exampleID},
+		{"*.example.com", exampleID},
+		{"a.sub.example.com", subID},
+	}
+	for _, tt := range tests {
+		zone, err := client.FindZoneByDomain(ctx, tt.hostname)
+		if err != nil {
+			t.Fatalf("FindZoneByDomain(%q) failed: %v", tt.hostname, err)
+		}
+		if zone.ID != tt.want || zone.Records != nil {
+			t.Errorf("FindZoneByDomain(%q) = zone %d with %d records, want zone %d without records",
+				tt.hostname, zone.ID, len(zone.Records), tt.want)
+		}
+	}
+
+	for _, hostname := range []string{"example.net", "www.example.org", "localhost", ""} {
+		if _, err := client.FindZoneByDomain(ctx, hostname); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindZoneByDomain(%q) error = %v, want ErrNotFound", hostname, err)
+		}
+	}
+
+	// Repeated lookups, including misses, are served from the cache
+	before := transport.count.Load()
+	if _, err := client.FindZoneByDomain(ctx, "www.example.com"); err != nil {
+		t.Fatalf("cached lookup failed: %v", err)
+	}
+	if _, err := client.FindZoneByDomain(ctx, "example.net"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("cached miss error = %v, want ErrNotFound", err)
+	}
+	if n := transport.count.Load() - before; n != 0 {
+		t.Errorf("expected cached lookups, got %d requests", n)
+	}
+
+	// Creating a zone drops cached misses
+	if _, err := client.CreateZone(ctx, "example.net"); err != nil {
+		t.Fatalf("CreateZone failed: %v", err)
+	}
+	if zone, err := client.FindZoneByDomain(ctx, "www.example.net"); err != nil || zone.Domain != "example.net" {
+		t.Errorf("expected new zone after CreateZone, got %+v, %v", zone, err)
+	}
+}
+
+func TestFindZoneByDomain_Expiry(t *testing.T) {
+	t.Parallel()
+	server := mockbunny.New()
+	defer server.Close()
+	server.AddZone("example.com")
+
+	transport := &countingTransport{}
+	client := NewClient("test-key", WithBaseURL(server.URL()), WithHTTPClient(&http.Client{Transport: transport}))
+	now := time.Now()
+	client.zoneLookups.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.FindZoneByDomain(context.Background(), "example.com"); err != nil {
+			t.Fatalf("FindZoneByDomain failed: %v", err)
+		}
+	}
+	if n := transport.count.Load(); n != 1 {
+		t.Fatalf("expected 1 request, got %d", n)
+	}
+
+	now = now.Add(zoneLookupTTL)
+	if _, err := client.FindZoneByDomain(context.Background(), "example.com"); err != nil {
+		t.Fatalf("FindZoneByDomain failed: %v", err)
+	}
+	if n := transport.count.Load(); n != 2 {
+		t.Errorf("expected expired lookup to refetch, got %d requests", n)
+	}
+}
+
+func TestFindZoneByDomain_UpstreamError(t *testing.T) {
+	t.Parallel()
+	server := mockbunny.New()
+	defer server.Close()
+	server.SetNextError(http.StatusInternalServerError, "boom", 1)
+
+	client := NewClient("test-key", WithBaseURL(server.URL()))
+	if _, err := client.FindZoneByDomain(context.Background(), "example.com"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected upstream error, got %v", err)
+	}
+}
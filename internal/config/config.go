@@ -3,30 +3,890 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration for API-only mode.
 type Config struct {
+	// ConfigFile is a file of NAME=value lines read for variables the
+	// environment does not set. It is read again on SIGHUP, so settings that
+	// can change while running should be kept there.
+	ConfigFile string
+
+	// SecretFiles maps the secret variables read from a file, such as
+	// BUNNY_API_KEY from BUNNY_API_KEY_FILE, to the file's path.
+	SecretFiles map[string]string
+
 	LogLevel          string // debug, info, warn, error
 	ListenAddr        string // Server listen address (e.g., ":8080")
 	DatabasePath      string // SQLite database path
 	BunnyAPIURL       string // Optional: Base URL for bunny.net API (empty = use default)
 	BunnyAPIKey       string // Required: bunny.net API key for master authentication
 	MetricsListenAddr string // Metrics listener address (e.g., "localhost:9090")
+
+	// ShutdownDrain is how long the proxy keeps serving after SIGTERM with
+	// readiness reporting 503, so load balancers stop routing to it before
+	// its listeners close.
+	ShutdownDrain time.Duration
+
+	// BunnyAccounts maps account names to the API keys of further bunny.net
+	// accounts. Tokens assigned to an account send their requests with its
+	// key; other tokens use BunnyAPIKey.
+	BunnyAccounts map[string]string
+
+	// DatabaseBusyTimeout is how long a database connection waits for a lock
+	// before failing with "database is locked". DatabaseMaxReadConnections
+	// is the size of the read-only connection pool used beside the single
+	// writer connection.
+	DatabaseBusyTimeout        time.Duration
+	DatabaseMaxReadConnections int
+
+	// MetricsTokenLabels enables per-token request metrics labelled with the
+	// token name ("name") or a hash of it ("hash"); "off" disables them.
+	// MetricsTokenAllowlist limits labels to the listed token names, and at
+	// most MetricsTokenMaxLabels distinct labels are used. Other tokens share
+	// the label "other".
+	MetricsTokenLabels    string
+	MetricsTokenAllowlist []string
+	MetricsTokenMaxLabels int
+
+	// BunnyAPIURLOverrides are "class=url" entries sending one bunny.net
+	// endpoint class, such as statistics, to another base URL.
+	BunnyAPIURLOverrides []string
+
+	// InstanceID identifies this proxy instance in logs, metrics, /status and
+	// the upstream User-Agent. Empty means an ID generated on first start and
+	// persisted in the database.
+	InstanceID string
+
+	// BunnyUserAgent overrides the User-Agent sent to bunny.net. Empty means
+	// the default "bunny-api-proxy/<version> (+<instance-id>)".
+	BunnyUserAgent string
+
+	// BunnyContact is sent in the From header on upstream requests so
+	// bunny.net support can identify the operator. Optional.
+	BunnyContact string
+
+	// BunnySchemaCheck controls detection of bunny.net response fields the
+	// proxy does not know about: "off", "log" or "strict".
+	BunnySchemaCheck string
+
+	// BunnyRetryMaxAttempts is the number of attempts per upstream request,
+	// including the first; transient failures are retried. 1 disables retries.
+	BunnyRetryMaxAttempts int
+
+	// BunnyRetryBaseDelay is the delay before the first retry, doubled for
+	// each retry after it.
+	BunnyRetryBaseDelay time.Duration
+
+	// BunnyCircuitBreakerThreshold is the number of consecutive failed
+	// requests to one bunny.net endpoint class (zones, records, dnssec,
+	// certificates) that opens its circuit. 0 disables circuit breaking.
+	BunnyCircuitBreakerThreshold int
+
+	// BunnyCircuitBreakerCooldown is how long an open circuit fails requests
+	// before letting one through to test the endpoint.
+	BunnyCircuitBreakerCooldown time.Duration
+
+	// AccountPassthroughEnabled exposes read-only bunny.net account endpoints
+	// (/statistics, /billing) to admin tokens through the proxy.
+	AccountPassthroughEnabled bool
+
+	// WebhookMaxPerToken limits how many webhooks a scoped token may register.
+	// Admin tokens are not limited.
+	WebhookMaxPerToken int
+
+	// DBSizeAlertMB raises a system alert when the database reaches this size
+	// in megabytes. Zero disables the alert.
+	DBSizeAlertMB int
+
+	// Storage soft quotas: the proxy turns read-only when free space on the
+	// database volume drops below DiskMinFreeMB or the database reaches
+	// DBSizeLimitMB. Zero disables either check. DELETE requests are still
+	// accepted while read-only when StorageDegradedAllowDelete is set.
+	DiskMinFreeMB              int
+	DBSizeLimitMB              int
+	StorageDegradedAllowDelete bool
+
+	// SLO targets, as ratios of good events, and the record write latency
+	// above which a write counts against the latency objective.
+	SLOAvailabilityTarget float64
+	SLOLatencyTarget      float64
+	SLOLatencyThreshold   time.Duration
+
+	// Watchdog thresholds: sustained growth above these levels is reported
+	// as a possible leak. Zero disables the check for that resource.
+	WatchdogGoroutineThreshold int
+	WatchdogFDThreshold        int
+	WatchdogDBConnThreshold    int
+
+	// AuthCacheTTL caches token lookups and permissions of proxied requests.
+	// Zero disables the cache.
+	AuthCacheTTL time.Duration
+
+	// ResponseCacheTTL caches upstream zone reads for GET /dnszone and
+	// GET /dnszone/{id}. Zero disables the cache.
+	ResponseCacheTTL time.Duration
+	// ResponseCacheMaxEntries caps the number of cached responses. Zero means no cap.
+	ResponseCacheMaxEntries int
+
+	// CacheWarmupEnabled prefetches the zone list and up to CacheWarmupZones
+	// zones into the response cache on startup, most used zones first.
+	// It has no effect while the response cache is disabled.
+	CacheWarmupEnabled     bool
+	CacheWarmupZones       int
+	CacheWarmupParallelism int
+
+	// BlocklistTrustForwardedFor checks the last X-Forwarded-For address
+	// against the blocklist instead of the connection's remote address.
+	// Only enable it behind a load balancer that sets the header.
+	BlocklistTrustForwardedFor bool
+
+	// HoneypotEnabled serves decoy paths; a client requesting one is
+	// blocklisted for HoneypotBanDuration (zero bans permanently) and a
+	// system alert is published. HoneypotPaths overrides the default decoys.
+	HoneypotEnabled     bool
+	HoneypotPaths       []string
+	HoneypotBanDuration time.Duration
+
+	// HealthCheckUpstream adds a bunny.net API reachability check to /ready.
+	// An unreachable upstream reports "degraded" rather than "down".
+	HealthCheckUpstream bool
+
+	// UpstreamKeyCheckInterval is how often the bunny.net API keys are
+	// verified. A rejected key degrades /ready and raises a system alert.
+	// Zero disables the check.
+	UpstreamKeyCheckInterval time.Duration
+
+	// VaultBunnyAPIKeyPath reads the bunny.net API key from this HashiCorp
+	// Vault secret instead of BunnyAPIKey, authenticating at VaultAddr with
+	// VaultToken or the AppRole VaultRoleID and VaultSecretID. The secret is
+	// read again every VaultRefreshInterval and a rotated key replaces the
+	// one in use; zero reads it only on startup.
+	VaultAddr             string
+	VaultToken            string
+	VaultRoleID           string
+	VaultSecretID         string
+	VaultBunnyAPIKeyPath  string
+	VaultBunnyAPIKeyField string
+	VaultRefreshInterval  time.Duration
+
+	// TLSFingerprintHeader names the header carrying the client's JA3/JA4
+	// TLS fingerprint, set by the TLS terminator in front of the proxy, and
+	// TLSFingerprintTrustedProxies are the addresses of those terminators:
+	// the header is ignored on requests from anywhere else. Empty disables
+	// TLS fingerprint pinning for tokens, except on the TLS listener when it
+	// verifies client certificates.
+	TLSFingerprintHeader         string
+	TLSFingerprintTrustedProxies []netip.Prefix
+
+	// AdminSessionTTL is the longest an admin session exchanged for an admin
+	// token lasts. Zero disables POST /admin/api/sessions.
+	AdminSessionTTL time.Duration
+
+	// AdminCORSAllowedOrigins are the origins browsers may call the admin API
+	// from, or "*" for any; empty disables CORS. Preflight requests are
+	// answered with AdminCORSAllowedMethods and AdminCORSAllowedHeaders, and
+	// AdminCORSAllowCredentials lets browsers send credentials.
+	AdminCORSAllowedOrigins   []string
+	AdminCORSAllowedMethods   []string
+	AdminCORSAllowedHeaders   []string
+	AdminCORSAllowCredentials bool
+
+	// OIDCIssuer lets the admin API accept JWTs from this OpenID Connect
+	// issuer as Authorization: Bearer credentials, alongside admin tokens.
+	// A JWT must be signed with a key from OIDCJWKSURL (discovered from the
+	// issuer when empty), name OIDCAudience in aud, and list one of
+	// OIDCAdminRoles in OIDCRoleClaim. OIDCUsernameClaim names the user in
+	// logs and audit entries. Empty disables OIDC.
+	OIDCIssuer        string
+	OIDCAudience      string
+	OIDCJWKSURL       string
+	OIDCRoleClaim     string
+	OIDCAdminRoles    []string
+	OIDCUsernameClaim string
+
+	// BootstrapWindow is how long after startup the master API key may create
+	// the first admin token. Zero allows it until an admin token exists.
+	BootstrapWindow time.Duration
+
+	// AuditStream also writes audit entries as JSON lines for SIEM ingestion:
+	// "stdout", "stderr" or a file path to append to. Empty disables it.
+	AuditStream string
+
+	// ReplayProtection checks X-Request-Timestamp and X-Request-Nonce on
+	// mutating proxy requests: "off", "optional" (only requests that send
+	// them) or "required".
+	ReplayProtection string
+
+	// ReplayWindow is how far a request timestamp may be from the proxy's clock.
+	ReplayWindow time.Duration
+
+	// IdempotencyTTL is how long the responses to mutating proxy requests
+	// sent with an Idempotency-Key are kept for retries. Zero ignores the header.
+	IdempotencyTTL time.Duration
+
+	// RecordValidation is "strict" to validate records before they are sent
+	// to bunny.net, with TTLs between RecordMinTTL and RecordMaxTTL seconds,
+	// or "off" to leave validation to bunny.net.
+	RecordValidation string
+	RecordMinTTL     int
+	RecordMaxTTL     int
+
+	// ACMEDomains enables the built-in ACME client: a certificate for these
+	// names is obtained and renewed using DNS-01 challenges answered through
+	// the proxy with ACMEAccessKey, and served on TLSListenAddr. Empty
+	// disables ACME.
+	ACMEDomains      []string
+	ACMEDirectoryURL string
+	ACMEEmail        string
+	ACMEAccessKey    string
+	ACMECertDir      string
+	ACMERenewBefore  time.Duration
+	TLSListenAddr    string
+
+	// TLSCertFile and TLSKeyFile serve a certificate from PEM files on
+	// TLSListenAddr instead of one obtained with ACME. The TLS listener is
+	// disabled unless ACME or these are configured.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSClientCA is a PEM bundle of CAs the TLS listener verifies client
+	// certificates against. A verified certificate authenticates as the
+	// token its common name is mapped to when no AccessKey is sent.
+	// TLSClientAuth is "optional" or "required"; required rejects TLS
+	// connections without a verified certificate.
+	TLSClientCA   string
+	TLSClientAuth string
+
+	// ZoneTagRules ("tag=glob" entries matched against zone domains) and
+	// ZoneTagSource (a CSV document at an http(s) URL or file path) tag
+	// upstream zones every ZoneTagSyncInterval, keeping tag permissions
+	// current. Both empty disables the sync.
+	ZoneTagRules        []string
+	ZoneTagSource       string
+	ZoneTagSyncInterval time.Duration
+
+	// DomainPermissionCacheTTL is how long the zone list that domain
+	// permissions are matched against is cached.
+	DomainPermissionCacheTTL time.Duration
+
+	// TokensConfigFile is a JSON file of tokens created and updated at
+	// startup. Empty disables provisioning.
+	TokensConfigFile string
+
+	// DatabaseEncryptionKey, or the contents of DatabaseEncryptionKeyFile,
+	// is a 32-byte key, hex or base64 encoded, that encrypts sensitive
+	// database columns. Empty leaves them in plaintext.
+	DatabaseEncryptionKey     string
+	DatabaseEncryptionKeyFile string
+
+	// Token keys are stored as Argon2id digests with these parameters:
+	// memory in KiB, iterations and parallelism. Zero iterations store
+	// plain SHA-256 hashes instead.
+	TokenHashMemoryKiB   int
+	TokenHashIterations  int
+	TokenHashParallelism int
+
+	// HTTP server timeouts for the main and TLS listeners; zero disables a
+	// timeout. MaxRequestBodyBytes caps request bodies on proxied routes.
+	HTTPReadTimeout       time.Duration
+	HTTPReadHeaderTimeout time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	MaxRequestBodyBytes   int64
+
+	// ImportMaxBodyBytes and ImportTimeout replace the body cap and the read
+	// and write timeouts for POST /dnszone/{zoneID}/import, since large zone
+	// files take longer to upload and import. Zero ImportTimeout keeps the
+	// server timeouts.
+	ImportMaxBodyBytes int64
+	ImportTimeout      time.Duration
+
+	// RecordMaxTXTLength caps TXT values in record writes. ImportMaxRecords
+	// and ImportMaxAsyncRecords cap the records of one import, run at once
+	// or as a job.
+	RecordMaxTXTLength    int
+	ImportMaxRecords      int
+	ImportMaxAsyncRecords int
 }
 
-// Load parses configuration from environment variables.
+// defaultACMEDirectoryURL is the production Let's Encrypt directory.
+const defaultACMEDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+
+// Load parses configuration from environment variables and CONFIG_FILE.
 // All configuration options have sensible defaults for ease of deployment.
 func Load() (*Config, error) {
-	logLevel := os.Getenv("LOG_LEVEL")
-	listenAddr := os.Getenv("LISTEN_ADDR")
-	databasePath := os.Getenv("DATABASE_PATH")
-	bunnyAPIURL := os.Getenv("BUNNY_API_URL")
-	bunnyAPIKey := os.Getenv("BUNNY_API_KEY")
-	metricsListenAddr := os.Getenv("METRICS_LISTEN_ADDR")
+	return load(os.Getenv)
+}
+
+// Defaults returns the configuration used when no environment variables are set.
+func Defaults() *Config {
+	cfg, err := load(func(string) string { return "" })
+	if err != nil { // coverage-ignore: the defaults always parse
+		panic(fmt.Sprintf("config: invalid defaults: %v", err)) // coverage-ignore: the defaults always parse
+	}
+	return cfg
+}
+
+// load parses configuration from variables looked up with getenv.
+func load(getenv func(string) string) (*Config, error) {
+	getenv, configFile, err := withConfigFile(getenv)
+	if err != nil {
+		return nil, err
+	}
+	getenv, secretFiles, err := withSecretFiles(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	logLevel := getenv("LOG_LEVEL")
+	listenAddr := getenv("LISTEN_ADDR")
+	databasePath := getenv("DATABASE_PATH")
+	bunnyAPIURL := getenv("BUNNY_API_URL")
+	bunnyAPIKey := getenv("BUNNY_API_KEY")
+	var bunnyAPIURLOverrides []string
+	for _, entry := range strings.Split(getenv("BUNNY_API_URL_OVERRIDES"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		if class, u, ok := strings.Cut(entry, "="); !ok || strings.TrimSpace(class) == "" || strings.TrimSpace(u) == "" {
+			return nil, fmt.Errorf("invalid BUNNY_API_URL_OVERRIDES entry %q: must be class=url", entry)
+		}
+		bunnyAPIURLOverrides = append(bunnyAPIURLOverrides, entry)
+	}
+	databaseBusyTimeoutMS, err := parseIntEnv(getenv, "DATABASE_BUSY_TIMEOUT_MS", 5000)
+	if err != nil {
+		return nil, err
+	}
+	databaseMaxReadConnections, err := parseIntEnv(getenv, "DATABASE_MAX_READ_CONNECTIONS", 4)
+	if err != nil {
+		return nil, err
+	}
+	if databaseMaxReadConnections == 0 {
+		return nil, fmt.Errorf("invalid DATABASE_MAX_READ_CONNECTIONS \"0\": must be positive")
+	}
+	metricsListenAddr := getenv("METRICS_LISTEN_ADDR")
+
+	metricsTokenLabels := strings.ToLower(getenv("METRICS_TOKEN_LABELS"))
+	switch metricsTokenLabels {
+	case "":
+		metricsTokenLabels = "off"
+	case "off", "name", "hash":
+	default:
+		return nil, fmt.Errorf("invalid METRICS_TOKEN_LABELS %q: must be off, name or hash", getenv("METRICS_TOKEN_LABELS"))
+	}
+	var metricsTokenAllowlist []string
+	for _, name := range strings.Split(getenv("METRICS_TOKEN_ALLOWLIST"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			metricsTokenAllowlist = append(metricsTokenAllowlist, name)
+		}
+	}
+	metricsTokenMaxLabels, err := parseIntEnv(getenv, "METRICS_TOKEN_MAX_LABELS", 100)
+	if err != nil {
+		return nil, err
+	}
+	if metricsTokenMaxLabels == 0 {
+		return nil, fmt.Errorf("invalid METRICS_TOKEN_MAX_LABELS \"0\": must be positive")
+	}
+
+	instanceID := getenv("INSTANCE_ID")
+
+	bunnySchemaCheck := strings.ToLower(getenv("BUNNY_SCHEMA_CHECK"))
+	switch bunnySchemaCheck {
+	case "":
+		bunnySchemaCheck = "off"
+	case "off", "log", "strict":
+	default:
+		return nil, fmt.Errorf("invalid BUNNY_SCHEMA_CHECK %q: must be off, log or strict", getenv("BUNNY_SCHEMA_CHECK"))
+	}
+
+	bunnyRetryMaxAttempts, err := parseIntEnv(getenv, "BUNNY_RETRY_MAX_ATTEMPTS", 3)
+	if err != nil {
+		return nil, err
+	}
+	if bunnyRetryMaxAttempts == 0 {
+		return nil, fmt.Errorf("invalid BUNNY_RETRY_MAX_ATTEMPTS \"0\": must be positive")
+	}
+
+	bunnyRetryBaseDelayMS, err := parseIntEnv(getenv, "BUNNY_RETRY_BASE_DELAY_MS", 200)
+	if err != nil {
+		return nil, err
+	}
+	if bunnyRetryBaseDelayMS == 0 {
+		return nil, fmt.Errorf("invalid BUNNY_RETRY_BASE_DELAY_MS \"0\": must be positive")
+	}
+
+	bunnyBreakerThreshold, err := parseIntEnv(getenv, "BUNNY_CIRCUIT_BREAKER_THRESHOLD", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	bunnyBreakerCooldownSeconds, err := parseIntEnv(getenv, "BUNNY_CIRCUIT_BREAKER_COOLDOWN_SECONDS", 30)
+	if err != nil {
+		return nil, err
+	}
+	if bunnyBreakerCooldownSeconds == 0 {
+		return nil, fmt.Errorf("invalid BUNNY_CIRCUIT_BREAKER_COOLDOWN_SECONDS \"0\": must be positive")
+	}
+
+	accountPassthrough, err := parseBoolEnv(getenv, "ACCOUNT_PASSTHROUGH_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookMaxPerToken, err := parseIntEnv(getenv, "WEBHOOK_MAX_PER_TOKEN", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	dbSizeAlertMB, err := parseIntEnv(getenv, "DB_SIZE_ALERT_MB", 1024)
+	if err != nil {
+		return nil, err
+	}
+
+	diskMinFreeMB, err := parseIntEnv(getenv, "DISK_MIN_FREE_MB", 64)
+	if err != nil {
+		return nil, err
+	}
+
+	dbSizeLimitMB, err := parseIntEnv(getenv, "DB_SIZE_LIMIT_MB", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	storageDegradedAllowDelete, err := parseBoolEnv(getenv, "STORAGE_DEGRADED_ALLOW_DELETE", true)
+	if err != nil {
+		return nil, err
+	}
+
+	sloAvailability, err := parseRatioEnv(getenv, "SLO_AVAILABILITY_TARGET", 0.999)
+	if err != nil {
+		return nil, err
+	}
+
+	sloLatency, err := parseRatioEnv(getenv, "SLO_LATENCY_TARGET", 0.99)
+	if err != nil {
+		return nil, err
+	}
+
+	sloLatencyThresholdMS, err := parseIntEnv(getenv, "SLO_LATENCY_THRESHOLD_MS", 300)
+	if err != nil {
+		return nil, err
+	}
+	if sloLatencyThresholdMS == 0 {
+		return nil, fmt.Errorf("invalid SLO_LATENCY_THRESHOLD_MS \"0\": must be positive")
+	}
+
+	watchdogGoroutines, err := parseIntEnv(getenv, "WATCHDOG_GOROUTINE_THRESHOLD", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	watchdogFDs, err := parseIntEnv(getenv, "WATCHDOG_FD_THRESHOLD", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	watchdogDBConns, err := parseIntEnv(getenv, "WATCHDOG_DB_CONN_THRESHOLD", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	authCacheTTLSeconds, err := parseIntEnv(getenv, "AUTH_CACHE_TTL_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	responseCacheTTLSeconds, err := parseIntEnv(getenv, "RESPONSE_CACHE_TTL_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	responseCacheMaxEntries, err := parseIntEnv(getenv, "RESPONSE_CACHE_MAX_ENTRIES", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheWarmup, err := parseBoolEnv(getenv, "CACHE_WARMUP_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheWarmupZones, err := parseIntEnv(getenv, "CACHE_WARMUP_ZONES", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheWarmupParallelism, err := parseIntEnv(getenv, "CACHE_WARMUP_PARALLELISM", 4)
+	if err != nil {
+		return nil, err
+	}
+	if cacheWarmupParallelism == 0 {
+		return nil, fmt.Errorf("invalid CACHE_WARMUP_PARALLELISM \"0\": must be positive")
+	}
+
+	blocklistTrustForwardedFor, err := parseBoolEnv(getenv, "BLOCKLIST_TRUST_X_FORWARDED_FOR", false)
+	if err != nil {
+		return nil, err
+	}
+
+	honeypotEnabled, err := parseBoolEnv(getenv, "HONEYPOT_ENABLED", false)
+	if err != nil {
+		return nil, err
+	}
+
+	var honeypotPaths []string
+	for _, p := range strings.Split(getenv("HONEYPOT_PATHS"), ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if !strings.HasPrefix(p, "/") || p == "/" {
+			return nil, fmt.Errorf("invalid HONEYPOT_PATHS entry %q: must start with / and not be the root", p)
+		}
+		honeypotPaths = append(honeypotPaths, p)
+	}
+
+	honeypotBanSeconds, err := parseIntEnv(getenv, "HONEYPOT_BAN_SECONDS", 86400)
+	if err != nil {
+		return nil, err
+	}
+
+	healthCheckUpstream, err := parseBoolEnv(getenv, "HEALTH_CHECK_UPSTREAM", false)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamKeyCheckSeconds, err := parseIntEnv(getenv, "UPSTREAM_KEY_CHECK_INTERVAL_SECONDS", 300)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultAddr := strings.TrimSuffix(strings.TrimSpace(getenv("VAULT_ADDR")), "/")
+	if vaultAddr != "" {
+		u, err := url.Parse(vaultAddr)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid VAULT_ADDR %q: must be an absolute http or https URL", vaultAddr)
+		}
+	}
+	vaultBunnyAPIKeyField := strings.TrimSpace(getenv("VAULT_BUNNY_API_KEY_FIELD"))
+	if vaultBunnyAPIKeyField == "" {
+		vaultBunnyAPIKeyField = "api_key"
+	}
+	vaultRefreshSeconds, err := parseIntEnv(getenv, "VAULT_REFRESH_INTERVAL_SECONDS", 300)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownDrainSeconds, err := parseIntEnv(getenv, "SHUTDOWN_DRAIN_SECONDS", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsFingerprintHeader := strings.TrimSpace(getenv("TLS_FINGERPRINT_HEADER"))
+	if strings.ContainsAny(tlsFingerprintHeader, " \t:") {
+		return nil, fmt.Errorf("invalid TLS_FINGERPRINT_HEADER %q: not a header name", tlsFingerprintHeader)
+	}
+	var tlsFingerprintProxies []netip.Prefix
+	for _, entry := range strings.Split(getenv("TLS_FINGERPRINT_TRUSTED_PROXIES"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		prefix, err := parseAddressOrCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS_FINGERPRINT_TRUSTED_PROXIES entry %q: must be an IP address or CIDR", entry)
+		}
+		tlsFingerprintProxies = append(tlsFingerprintProxies, prefix)
+	}
+
+	adminSessionTTLSeconds, err := parseIntEnv(getenv, "ADMIN_SESSION_TTL_SECONDS", 900)
+	if err != nil {
+		return nil, err
+	}
+
+	var adminCORSOrigins []string
+	for _, origin := range strings.Split(getenv("ADMIN_CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin == "" {
+			continue
+		}
+		if origin != "*" {
+			u, err := url.Parse(origin)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+				u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+				return nil, fmt.Errorf("invalid ADMIN_CORS_ALLOWED_ORIGINS entry %q: must be * or scheme://host[:port]", origin)
+			}
+		}
+		adminCORSOrigins = append(adminCORSOrigins, origin)
+	}
+	adminCORSMethods := []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	if raw := getenv("ADMIN_CORS_ALLOWED_METHODS"); raw != "" {
+		adminCORSMethods = nil
+		for _, m := range strings.Split(raw, ",") {
+			if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+				adminCORSMethods = append(adminCORSMethods, m)
+			}
+		}
+	}
+	adminCORSHeaders := []string{"AccessKey", "Content-Type"}
+	if raw := getenv("ADMIN_CORS_ALLOWED_HEADERS"); raw != "" {
+		adminCORSHeaders = nil
+		for _, h := range strings.Split(raw, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				adminCORSHeaders = append(adminCORSHeaders, h)
+			}
+		}
+	}
+	adminCORSCredentials, err := parseBoolEnv(getenv, "ADMIN_CORS_ALLOW_CREDENTIALS", false)
+	if err != nil {
+		return nil, err
+	}
+	if adminCORSCredentials && slices.Contains(adminCORSOrigins, "*") {
+		return nil, fmt.Errorf("ADMIN_CORS_ALLOW_CREDENTIALS cannot be used with ADMIN_CORS_ALLOWED_ORIGINS=*")
+	}
+
+	oidcIssuer := strings.TrimSpace(getenv("OIDC_ISSUER_URL"))
+	oidcJWKSURL := strings.TrimSpace(getenv("OIDC_JWKS_URL"))
+	for name, raw := range map[string]string{"OIDC_ISSUER_URL": oidcIssuer, "OIDC_JWKS_URL": oidcJWKSURL} {
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s %q: must be an absolute http or https URL", name, raw)
+		}
+	}
+	oidcRoleClaim := strings.TrimSpace(getenv("OIDC_ROLE_CLAIM"))
+	if oidcRoleClaim == "" {
+		oidcRoleClaim = "roles"
+	}
+	var oidcAdminRoles []string
+	for _, role := range strings.Split(getenv("OIDC_ADMIN_ROLES"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			oidcAdminRoles = append(oidcAdminRoles, role)
+		}
+	}
+	oidcUsernameClaim := strings.TrimSpace(getenv("OIDC_USERNAME_CLAIM"))
+	if oidcUsernameClaim == "" {
+		oidcUsernameClaim = "email"
+	}
+
+	bootstrapWindowSeconds, err := parseIntEnv(getenv, "BOOTSTRAP_WINDOW_SECONDS", 3600)
+	if err != nil {
+		return nil, err
+	}
+
+	replayProtection := strings.ToLower(getenv("REPLAY_PROTECTION"))
+	switch replayProtection {
+	case "":
+		replayProtection = "off"
+	case "off", "optional", "required":
+	default:
+		return nil, fmt.Errorf("invalid REPLAY_PROTECTION %q: must be off, optional or required", getenv("REPLAY_PROTECTION"))
+	}
+
+	replayWindowSeconds, err := parseIntEnv(getenv, "REPLAY_WINDOW_SECONDS", 300)
+	if err != nil {
+		return nil, err
+	}
+	if replayWindowSeconds == 0 {
+		return nil, fmt.Errorf("invalid REPLAY_WINDOW_SECONDS \"0\": must be positive")
+	}
+
+	idempotencyTTLSeconds, err := parseIntEnv(getenv, "IDEMPOTENCY_TTL_SECONDS", 86400)
+	if err != nil {
+		return nil, err
+	}
+
+	recordValidation := strings.ToLower(getenv("RECORD_VALIDATION"))
+	switch recordValidation {
+	case "":
+		recordValidation = "off"
+	case "off", "strict":
+	default:
+		return nil, fmt.Errorf("invalid RECORD_VALIDATION %q: must be off or strict", getenv("RECORD_VALIDATION"))
+	}
+
+	recordMinTTL, err := parseIntEnv(getenv, "RECORD_MIN_TTL_SECONDS", 15)
+	if err != nil {
+		return nil, err
+	}
+	recordMaxTTL, err := parseIntEnv(getenv, "RECORD_MAX_TTL_SECONDS", 86400)
+	if err != nil {
+		return nil, err
+	}
+	if recordMaxTTL < recordMinTTL || recordMaxTTL > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid RECORD_MAX_TTL_SECONDS \"%d\": must be at least RECORD_MIN_TTL_SECONDS and at most %d", recordMaxTTL, math.MaxInt32)
+	}
+
+	var acmeDomains []string
+	for _, d := range strings.Split(getenv("ACME_DOMAINS"), ",") {
+		if d = strings.ToLower(strings.TrimSpace(d)); d == "" {
+			continue
+		}
+		if strings.ContainsAny(d, " /:") || strings.Contains(d[1:], "*") || (d[0] == '*' && !strings.HasPrefix(d, "*.")) {
+			return nil, fmt.Errorf("invalid ACME_DOMAINS entry %q: not a domain name", d)
+		}
+		acmeDomains = append(acmeDomains, d)
+	}
+
+	acmeRenewBeforeDays, err := parseIntEnv(getenv, "ACME_RENEW_BEFORE_DAYS", 30)
+	if err != nil {
+		return nil, err
+	}
+
+	acmeDirectoryURL := getenv("ACME_DIRECTORY_URL")
+	if acmeDirectoryURL == "" {
+		acmeDirectoryURL = defaultACMEDirectoryURL
+	}
+
+	acmeCertDir := getenv("ACME_CERT_DIR")
+	if acmeCertDir == "" {
+		acmeCertDir = "/data/certs"
+	}
+
+	tlsListenAddr := getenv("TLS_LISTEN_ADDR")
+	if tlsListenAddr == "" {
+		tlsListenAddr = ":8443"
+	}
+
+	tlsClientAuth := strings.ToLower(getenv("TLS_CLIENT_AUTH"))
+	switch tlsClientAuth {
+	case "":
+		tlsClientAuth = "optional"
+	case "optional", "required":
+	default:
+		return nil, fmt.Errorf("invalid TLS_CLIENT_AUTH %q: must be optional or required", getenv("TLS_CLIENT_AUTH"))
+	}
+
+	var zoneTagRules []string
+	for _, rule := range strings.Split(getenv("ZONE_TAG_RULES"), ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		if tag, pattern, ok := strings.Cut(rule, "="); !ok || strings.TrimSpace(tag) == "" || strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("invalid ZONE_TAG_RULES entry %q: must be tag=pattern", rule)
+		}
+		zoneTagRules = append(zoneTagRules, rule)
+	}
+
+	zoneTagSyncSeconds, err := parseIntEnv(getenv, "ZONE_TAG_SYNC_INTERVAL_SECONDS", 300)
+	if err != nil {
+		return nil, err
+	}
+	if zoneTagSyncSeconds == 0 {
+		return nil, fmt.Errorf("invalid ZONE_TAG_SYNC_INTERVAL_SECONDS \"0\": must be positive")
+	}
+
+	domainPermissionCacheSeconds, err := parseIntEnv(getenv, "DOMAIN_PERMISSION_CACHE_SECONDS", 60)
+	if err != nil {
+		return nil, err
+	}
+	if domainPermissionCacheSeconds == 0 {
+		return nil, fmt.Errorf("invalid DOMAIN_PERMISSION_CACHE_SECONDS \"0\": must be positive")
+	}
+
+	tokenHashMemory, err := parseIntEnv(getenv, "TOKEN_HASH_MEMORY_KIB", 19456)
+	if err != nil {
+		return nil, err
+	}
+	tokenHashIterations, err := parseIntEnv(getenv, "TOKEN_HASH_ITERATIONS", 2)
+	if err != nil {
+		return nil, err
+	}
+	tokenHashParallelism, err := parseIntEnv(getenv, "TOKEN_HASH_PARALLELISM", 1)
+	if err != nil {
+		return nil, err
+	}
+	if tokenHashParallelism < 1 || tokenHashParallelism > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid TOKEN_HASH_PARALLELISM \"%d\": must be between 1 and %d", tokenHashParallelism, math.MaxUint8)
+	}
+	if tokenHashMemory < 8*tokenHashParallelism || int64(tokenHashMemory) > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid TOKEN_HASH_MEMORY_KIB \"%d\": must be between 8 times TOKEN_HASH_PARALLELISM and %d", tokenHashMemory, uint32(math.MaxUint32))
+	}
+	if int64(tokenHashIterations) > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid TOKEN_HASH_ITERATIONS \"%d\": must be at most %d", tokenHashIterations, uint32(math.MaxUint32))
+	}
+
+	httpReadTimeoutSeconds, err := parseIntEnv(getenv, "HTTP_READ_TIMEOUT_SECONDS", 15)
+	if err != nil {
+		return nil, err
+	}
+	httpReadHeaderTimeoutSeconds, err := parseIntEnv(getenv, "HTTP_READ_HEADER_TIMEOUT_SECONDS", 10)
+	if err != nil {
+		return nil, err
+	}
+	httpWriteTimeoutSeconds, err := parseIntEnv(getenv, "HTTP_WRITE_TIMEOUT_SECONDS", 15)
+	if err != nil {
+		return nil, err
+	}
+	httpIdleTimeoutSeconds, err := parseIntEnv(getenv, "HTTP_IDLE_TIMEOUT_SECONDS", 60)
+	if err != nil {
+		return nil, err
+	}
+	maxRequestBodyBytes, err := parseIntEnv(getenv, "MAX_REQUEST_BODY_BYTES", 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	if maxRequestBodyBytes == 0 {
+		return nil, fmt.Errorf("invalid MAX_REQUEST_BODY_BYTES \"0\": must be positive")
+	}
+	importMaxBodyBytes, err := parseIntEnv(getenv, "IMPORT_MAX_BODY_BYTES", 10<<20)
+	if err != nil {
+		return nil, err
+	}
+	if importMaxBodyBytes == 0 {
+		return nil, fmt.Errorf("invalid IMPORT_MAX_BODY_BYTES \"0\": must be positive")
+	}
+	importTimeoutSeconds, err := parseIntEnv(getenv, "IMPORT_TIMEOUT_SECONDS", 300)
+	if err != nil {
+		return nil, err
+	}
+	recordMaxTXTLength, err := parseIntEnv(getenv, "RECORD_MAX_TXT_LENGTH", 4096)
+	if err != nil {
+		return nil, err
+	}
+	if recordMaxTXTLength == 0 {
+		return nil, fmt.Errorf("invalid RECORD_MAX_TXT_LENGTH \"0\": must be positive")
+	}
+	importMaxRecords, err := parseIntEnv(getenv, "IMPORT_MAX_RECORDS", 1000)
+	if err != nil {
+		return nil, err
+	}
+	if importMaxRecords == 0 {
+		return nil, fmt.Errorf("invalid IMPORT_MAX_RECORDS \"0\": must be positive")
+	}
+	importMaxAsyncRecords, err := parseIntEnv(getenv, "IMPORT_MAX_ASYNC_RECORDS", 20000)
+	if err != nil {
+		return nil, err
+	}
+	if importMaxAsyncRecords == 0 {
+		return nil, fmt.Errorf("invalid IMPORT_MAX_ASYNC_RECORDS \"0\": must be positive")
+	}
 
 	// Set defaults for optional fields
+	bunnyAccounts := make(map[string]string)
+	for _, entry := range strings.Split(getenv("BUNNY_ACCOUNTS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(entry, "=")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || !validAccountName(name) || key == "" {
+			// The entry holds an API key, so only the name is quoted
+			return nil, fmt.Errorf("invalid BUNNY_ACCOUNTS entry for %q: must be name=apikey, with a name of letters, digits, '-' and '_'", name)
+		}
+		if _, dup := bunnyAccounts[name]; dup {
+			return nil, fmt.Errorf("invalid BUNNY_ACCOUNTS: account %q is listed twice", name)
+		}
+		bunnyAccounts[name] = key
+	}
 	if logLevel == "" {
 		logLevel = "info"
 	}
@@ -44,21 +904,283 @@ func Load() (*Config, error) {
 	}
 
 	cfg := &Config{
-		LogLevel:          logLevel,
-		ListenAddr:        listenAddr,
-		DatabasePath:      databasePath,
-		BunnyAPIURL:       bunnyAPIURL,
-		BunnyAPIKey:       bunnyAPIKey,
-		MetricsListenAddr: metricsListenAddr,
+		ConfigFile:           configFile,
+		SecretFiles:          secretFiles,
+		LogLevel:             logLevel,
+		ListenAddr:           listenAddr,
+		DatabasePath:         databasePath,
+		BunnyAPIURL:          bunnyAPIURL,
+		BunnyAPIURLOverrides: bunnyAPIURLOverrides,
+		BunnyAPIKey:          bunnyAPIKey,
+		BunnyAccounts:        bunnyAccounts,
+		MetricsListenAddr:    metricsListenAddr,
+
+		ShutdownDrain: time.Duration(shutdownDrainSeconds) * time.Second,
+
+		DatabaseBusyTimeout:        time.Duration(databaseBusyTimeoutMS) * time.Millisecond,
+		DatabaseMaxReadConnections: databaseMaxReadConnections,
+
+		MetricsTokenLabels:    metricsTokenLabels,
+		MetricsTokenAllowlist: metricsTokenAllowlist,
+		MetricsTokenMaxLabels: metricsTokenMaxLabels,
+
+		InstanceID:                   instanceID,
+		BunnyUserAgent:               getenv("BUNNY_USER_AGENT"),
+		BunnyContact:                 getenv("BUNNY_CONTACT"),
+		BunnySchemaCheck:             bunnySchemaCheck,
+		BunnyRetryMaxAttempts:        bunnyRetryMaxAttempts,
+		BunnyRetryBaseDelay:          time.Duration(bunnyRetryBaseDelayMS) * time.Millisecond,
+		BunnyCircuitBreakerThreshold: bunnyBreakerThreshold,
+		BunnyCircuitBreakerCooldown:  time.Duration(bunnyBreakerCooldownSeconds) * time.Second,
+		AccountPassthroughEnabled:    accountPassthrough,
+		WebhookMaxPerToken:           webhookMaxPerToken,
+		DBSizeAlertMB:                dbSizeAlertMB,
+		SLOAvailabilityTarget:        sloAvailability,
+		SLOLatencyTarget:             sloLatency,
+		SLOLatencyThreshold:          time.Duration(sloLatencyThresholdMS) * time.Millisecond,
+
+		DiskMinFreeMB:              diskMinFreeMB,
+		DBSizeLimitMB:              dbSizeLimitMB,
+		StorageDegradedAllowDelete: storageDegradedAllowDelete,
+
+		WatchdogGoroutineThreshold: watchdogGoroutines,
+		WatchdogFDThreshold:        watchdogFDs,
+		WatchdogDBConnThreshold:    watchdogDBConns,
+
+		AuthCacheTTL:            time.Duration(authCacheTTLSeconds) * time.Second,
+		ResponseCacheTTL:        time.Duration(responseCacheTTLSeconds) * time.Second,
+		ResponseCacheMaxEntries: responseCacheMaxEntries,
+
+		CacheWarmupEnabled:     cacheWarmup,
+		CacheWarmupZones:       cacheWarmupZones,
+		CacheWarmupParallelism: cacheWarmupParallelism,
+
+		BlocklistTrustForwardedFor: blocklistTrustForwardedFor,
+
+		HoneypotEnabled:     honeypotEnabled,
+		HoneypotPaths:       honeypotPaths,
+		HoneypotBanDuration: time.Duration(honeypotBanSeconds) * time.Second,
+
+		HealthCheckUpstream:      healthCheckUpstream,
+		UpstreamKeyCheckInterval: time.Duration(upstreamKeyCheckSeconds) * time.Second,
+
+		VaultAddr:             vaultAddr,
+		VaultToken:            strings.TrimSpace(getenv("VAULT_TOKEN")),
+		VaultRoleID:           strings.TrimSpace(getenv("VAULT_ROLE_ID")),
+		VaultSecretID:         strings.TrimSpace(getenv("VAULT_SECRET_ID")),
+		VaultBunnyAPIKeyPath:  strings.Trim(strings.TrimSpace(getenv("VAULT_BUNNY_API_KEY_PATH")), "/"),
+		VaultBunnyAPIKeyField: vaultBunnyAPIKeyField,
+		VaultRefreshInterval:  time.Duration(vaultRefreshSeconds) * time.Second,
+
+		TLSFingerprintHeader:         tlsFingerprintHeader,
+		TLSFingerprintTrustedProxies: tlsFingerprintProxies,
+
+		AdminSessionTTL: time.Duration(adminSessionTTLSeconds) * time.Second,
+		BootstrapWindow: time.Duration(bootstrapWindowSeconds) * time.Second,
+
+		AdminCORSAllowedOrigins:   adminCORSOrigins,
+		AdminCORSAllowedMethods:   adminCORSMethods,
+		AdminCORSAllowedHeaders:   adminCORSHeaders,
+		AdminCORSAllowCredentials: adminCORSCredentials,
+
+		OIDCIssuer:        oidcIssuer,
+		OIDCAudience:      strings.TrimSpace(getenv("OIDC_AUDIENCE")),
+		OIDCJWKSURL:       oidcJWKSURL,
+		OIDCRoleClaim:     oidcRoleClaim,
+		OIDCAdminRoles:    oidcAdminRoles,
+		OIDCUsernameClaim: oidcUsernameClaim,
+
+		AuditStream: strings.TrimSpace(getenv("AUDIT_STREAM")),
+
+		ReplayProtection: replayProtection,
+		ReplayWindow:     time.Duration(replayWindowSeconds) * time.Second,
+		IdempotencyTTL:   time.Duration(idempotencyTTLSeconds) * time.Second,
+		RecordValidation: recordValidation,
+		RecordMinTTL:     recordMinTTL,
+		RecordMaxTTL:     recordMaxTTL,
+
+		ACMEDomains:      acmeDomains,
+		ACMEDirectoryURL: acmeDirectoryURL,
+		ACMEEmail:        getenv("ACME_EMAIL"),
+		ACMEAccessKey:    getenv("ACME_ACCESS_KEY"),
+		ACMECertDir:      acmeCertDir,
+		ACMERenewBefore:  time.Duration(acmeRenewBeforeDays) * 24 * time.Hour,
+		TLSListenAddr:    tlsListenAddr,
+
+		TLSCertFile:   strings.TrimSpace(getenv("TLS_CERT_FILE")),
+		TLSKeyFile:    strings.TrimSpace(getenv("TLS_KEY_FILE")),
+		TLSClientCA:   strings.TrimSpace(getenv("TLS_CLIENT_CA")),
+		TLSClientAuth: tlsClientAuth,
+
+		ZoneTagRules:        zoneTagRules,
+		ZoneTagSource:       strings.TrimSpace(getenv("ZONE_TAG_SOURCE")),
+		ZoneTagSyncInterval: time.Duration(zoneTagSyncSeconds) * time.Second,
+
+		DomainPermissionCacheTTL: time.Duration(domainPermissionCacheSeconds) * time.Second,
+
+		TokensConfigFile: strings.TrimSpace(getenv("TOKENS_CONFIG_FILE")),
+
+		DatabaseEncryptionKey:     strings.TrimSpace(getenv("DATABASE_ENCRYPTION_KEY")),
+		DatabaseEncryptionKeyFile: strings.TrimSpace(getenv("DATABASE_ENCRYPTION_KEY_FILE")),
+
+		TokenHashMemoryKiB:   tokenHashMemory,
+		TokenHashIterations:  tokenHashIterations,
+		TokenHashParallelism: tokenHashParallelism,
+
+		HTTPReadTimeout:       time.Duration(httpReadTimeoutSeconds) * time.Second,
+		HTTPReadHeaderTimeout: time.Duration(httpReadHeaderTimeoutSeconds) * time.Second,
+		HTTPWriteTimeout:      time.Duration(httpWriteTimeoutSeconds) * time.Second,
+		HTTPIdleTimeout:       time.Duration(httpIdleTimeoutSeconds) * time.Second,
+		MaxRequestBodyBytes:   int64(maxRequestBodyBytes),
+
+		ImportMaxBodyBytes: int64(importMaxBodyBytes),
+		ImportTimeout:      time.Duration(importTimeoutSeconds) * time.Second,
+
+		RecordMaxTXTLength:    recordMaxTXTLength,
+		ImportMaxRecords:      importMaxRecords,
+		ImportMaxAsyncRecords: importMaxAsyncRecords,
 	}
 
 	return cfg, nil
 }
 
+// validAccountName reports whether name can name a BUNNY_ACCOUNTS account.
+func validAccountName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseBoolEnv reads a boolean environment variable.
+// Returns def if the variable is unset or empty.
+func parseBoolEnv(getenv func(string) string, name string, def bool) (bool, error) {
+	raw := getenv(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be a boolean", name, raw)
+	}
+	return v, nil
+}
+
+// parseIntEnv reads a non-negative integer environment variable.
+// Returns def if the variable is unset or empty.
+func parseIntEnv(getenv func(string) string, name string, def int) (int, error) {
+	raw := getenv(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, raw)
+	}
+	return v, nil
+}
+
+// parseRatioEnv reads a ratio strictly between 0 and 1 from an environment variable.
+// Returns def if the variable is unset or empty.
+func parseRatioEnv(getenv func(string) string, name string, def float64) (float64, error) {
+	raw := getenv(name)
+	if raw == "" {
+		return def, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v <= 0 || v >= 1 {
+		return 0, fmt.Errorf("invalid %s %q: must be a number between 0 and 1 (exclusive)", name, raw)
+	}
+	return v, nil
+}
+
+// parseAddressOrCIDR parses an IP address, as a single-host network, or a
+// CIDR network.
+func parseAddressOrCIDR(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		addr = addr.Unmap()
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
 // Validate checks all configuration constraints.
 func (c *Config) Validate() error {
-	if c.BunnyAPIKey == "" {
+	if c.BunnyAPIKey == "" && c.VaultBunnyAPIKeyPath == "" {
 		return fmt.Errorf("BUNNY_API_KEY environment variable is required")
 	}
+	if c.VaultBunnyAPIKeyPath != "" {
+		if c.BunnyAPIKey != "" {
+			return fmt.Errorf("BUNNY_API_KEY cannot be used with VAULT_BUNNY_API_KEY_PATH")
+		}
+		if c.VaultAddr == "" {
+			return fmt.Errorf("VAULT_ADDR is required when VAULT_BUNNY_API_KEY_PATH is set")
+		}
+		if (c.VaultToken == "") == (c.VaultRoleID == "") {
+			return fmt.Errorf("VAULT_BUNNY_API_KEY_PATH requires either VAULT_TOKEN or VAULT_ROLE_ID")
+		}
+		if c.VaultRoleID != "" && c.VaultSecretID == "" {
+			return fmt.Errorf("VAULT_SECRET_ID is required with VAULT_ROLE_ID")
+		}
+	}
+	if len(c.ACMEDomains) > 0 && c.ACMEAccessKey == "" {
+		return fmt.Errorf("ACME_ACCESS_KEY is required when ACME_DOMAINS is set")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLSCertFile != "" && len(c.ACMEDomains) > 0 {
+		return fmt.Errorf("TLS_CERT_FILE cannot be used with ACME_DOMAINS")
+	}
+	if c.TLSClientCA != "" && !c.TLSEnabled() {
+		return fmt.Errorf("TLS_CLIENT_CA requires TLS_CERT_FILE or ACME_DOMAINS")
+	}
+	if c.TLSClientAuth == "required" && c.TLSClientCA == "" {
+		return fmt.Errorf("TLS_CLIENT_AUTH=required requires TLS_CLIENT_CA")
+	}
+	if c.TLSFingerprintHeader != "" && len(c.TLSFingerprintTrustedProxies) == 0 {
+		return fmt.Errorf("TLS_FINGERPRINT_HEADER requires TLS_FINGERPRINT_TRUSTED_PROXIES")
+	}
+	if c.DatabaseEncryptionKey != "" && c.DatabaseEncryptionKeyFile != "" {
+		return fmt.Errorf("DATABASE_ENCRYPTION_KEY and DATABASE_ENCRYPTION_KEY_FILE cannot be used together")
+	}
+	if c.OIDCIssuer != "" && (c.OIDCAudience == "" || len(c.OIDCAdminRoles) == 0) {
+		return fmt.Errorf("OIDC_ISSUER_URL requires OIDC_AUDIENCE and OIDC_ADMIN_ROLES")
+	}
 	return nil
 }
+
+// BunnyAPIKeyProvider returns the provider of the bunny.net API key, or nil
+// if the key is set with BUNNY_API_KEY.
+func (c *Config) BunnyAPIKeyProvider() SecretProvider {
+	if c.VaultBunnyAPIKeyPath == "" {
+		return nil
+	}
+	return NewVaultProvider(VaultOptions{
+		Addr:     c.VaultAddr,
+		Token:    c.VaultToken,
+		RoleID:   c.VaultRoleID,
+		SecretID: c.VaultSecretID,
+		Path:     c.VaultBunnyAPIKeyPath,
+		Field:    c.VaultBunnyAPIKeyField,
+	})
+}
+
+// TLSEnabled reports whether the TLS listener serves a certificate, from
+// ACME or from files.
+func (c *Config) TLSEnabled() bool {
+	return len(c.ACMEDomains) > 0 || c.TLSCertFile != ""
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"net/netip"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_DefaultValues(t *testing.T) {
@@ -230,6 +234,1129 @@ func TestLoad_MetricsListenAddr(t *testing.T) {
 	}
 }
 
+func TestLoad_AccountPassthroughEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     bool
+		wantErr  bool
+	}{
+		{"not set defaults to false", "", false, false},
+		{"true", "true", true, false},
+		{"one", "1", true, false},
+		{"false", "false", false, false},
+		{"invalid", "yes-please", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACCOUNT_PASSTHROUGH_ENABLED", tt.envValue)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.AccountPassthroughEnabled != tt.want {
+				t.Errorf("AccountPassthroughEnabled = %v, want %v", cfg.AccountPassthroughEnabled, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_WebhookMaxPerToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     int
+		wantErr  bool
+	}{
+		{"not set defaults to 5", "", 5, false},
+		{"custom", "20", 20, false},
+		{"zero", "0", 0, false},
+		{"negative", "-1", 0, true},
+		{"invalid", "many", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WEBHOOK_MAX_PER_TOKEN", tt.envValue)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.WebhookMaxPerToken != tt.want {
+				t.Errorf("WebhookMaxPerToken = %d, want %d", cfg.WebhookMaxPerToken, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_DBSizeAlertMB(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     int
+		wantErr  bool
+	}{
+		{"not set defaults to 1024", "", 1024, false},
+		{"custom", "256", 256, false},
+		{"zero disables", "0", 0, false},
+		{"negative", "-1", 0, true},
+		{"invalid", "big", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_SIZE_ALERT_MB", tt.envValue)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.DBSizeAlertMB != tt.want {
+				t.Errorf("DBSizeAlertMB = %d, want %d", cfg.DBSizeAlertMB, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_StorageQuotas(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("DISK_MIN_FREE_MB", "")
+		t.Setenv("DB_SIZE_LIMIT_MB", "")
+		t.Setenv("STORAGE_DEGRADED_ALLOW_DELETE", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.DiskMinFreeMB != 64 || cfg.DBSizeLimitMB != 0 || !cfg.StorageDegradedAllowDelete {
+			t.Errorf("got min free %d, size limit %d, allow delete %v; want 64, 0, true",
+				cfg.DiskMinFreeMB, cfg.DBSizeLimitMB, cfg.StorageDegradedAllowDelete)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("DISK_MIN_FREE_MB", "0")
+		t.Setenv("DB_SIZE_LIMIT_MB", "2048")
+		t.Setenv("STORAGE_DEGRADED_ALLOW_DELETE", "false")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.DiskMinFreeMB != 0 || cfg.DBSizeLimitMB != 2048 || cfg.StorageDegradedAllowDelete {
+			t.Errorf("got min free %d, size limit %d, allow delete %v; want 0, 2048, false",
+				cfg.DiskMinFreeMB, cfg.DBSizeLimitMB, cfg.StorageDegradedAllowDelete)
+		}
+	})
+
+	for _, env := range []string{"DISK_MIN_FREE_MB", "DB_SIZE_LIMIT_MB", "STORAGE_DEGRADED_ALLOW_DELETE"} {
+		t.Run("invalid "+env, func(t *testing.T) {
+			t.Setenv(env, "lots")
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_BunnyAPIURLOverrides(t *testing.T) {
+	t.Setenv("BUNNY_API_URL_OVERRIDES", "statistics=https://stats.example.net, records=http://localhost:8081")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.BunnyAPIURLOverrides) != 2 || cfg.BunnyAPIURLOverrides[1] != "records=http://localhost:8081" {
+		t.Errorf("unexpected overrides: %v", cfg.BunnyAPIURLOverrides)
+	}
+
+	for _, value := range []string{"statistics", "=https://stats.example.net", "statistics="} {
+		t.Setenv("BUNNY_API_URL_OVERRIDES", value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Load() with BUNNY_API_URL_OVERRIDES=%q expected an error", value)
+		}
+	}
+}
+
+func TestLoad_BunnyAccounts(t *testing.T) {
+	t.Setenv("BUNNY_ACCOUNTS", "client-a=key-a, client_b = key-b")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.BunnyAccounts) != 2 || cfg.BunnyAccounts["client-a"] != "key-a" || cfg.BunnyAccounts["client_b"] != "key-b" {
+		t.Errorf("unexpected accounts: %v", cfg.BunnyAccounts)
+	}
+
+	for _, value := range []string{"client-a", "=s3cr3t", "client-a=", "client a=s3cr3t", "a=s3cr3t,a=s3cr3t"} {
+		t.Setenv("BUNNY_ACCOUNTS", value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Load() with BUNNY_ACCOUNTS=%q expected an error", value)
+		} else if strings.Contains(err.Error(), "s3cr3t") {
+			t.Errorf("Load() error %q leaks the API key", err)
+		}
+	}
+}
+
+func TestLoad_ZoneTags(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("ZONE_TAG_RULES", "")
+		t.Setenv("ZONE_TAG_SOURCE", "")
+		t.Setenv("ZONE_TAG_SYNC_INTERVAL_SECONDS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.ZoneTagRules) != 0 || cfg.ZoneTagSource != "" || cfg.ZoneTagSyncInterval != 5*time.Minute {
+			t.Errorf("got rules %v, source %q, interval %v; want none, empty, 5m",
+				cfg.ZoneTagRules, cfg.ZoneTagSource, cfg.ZoneTagSyncInterval)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("ZONE_TAG_RULES", "prod=*.prod.example.com, staging=*.staging.example.com")
+		t.Setenv("ZONE_TAG_SOURCE", "https://cmdb.example.com/zones.csv")
+		t.Setenv("ZONE_TAG_SYNC_INTERVAL_SECONDS", "60")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(cfg.ZoneTagRules) != 2 || cfg.ZoneTagRules[1] != "staging=*.staging.example.com" {
+			t.Errorf("unexpected rules: %v", cfg.ZoneTagRules)
+		}
+		if cfg.ZoneTagSource != "https://cmdb.example.com/zones.csv" || cfg.ZoneTagSyncInterval != time.Minute {
+			t.Errorf("got source %q, interval %v", cfg.ZoneTagSource, cfg.ZoneTagSyncInterval)
+		}
+	})
+
+	for name, env := range map[string][2]string{
+		"rule without pattern": {"ZONE_TAG_RULES", "prod"},
+		"rule without tag":     {"ZONE_TAG_RULES", "=*.example.com"},
+		"zero interval":        {"ZONE_TAG_SYNC_INTERVAL_SECONDS", "0"},
+		"invalid interval":     {"ZONE_TAG_SYNC_INTERVAL_SECONDS", "often"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_DomainPermissionCache(t *testing.T) {
+	t.Setenv("DOMAIN_PERMISSION_CACHE_SECONDS", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.DomainPermissionCacheTTL != time.Minute {
+		t.Errorf("DomainPermissionCacheTTL = %v, want 1m", cfg.DomainPermissionCacheTTL)
+	}
+
+	t.Setenv("DOMAIN_PERMISSION_CACHE_SECONDS", "15")
+	if cfg, err := Load(); err != nil || cfg.DomainPermissionCacheTTL != 15*time.Second {
+		t.Errorf("Load() = %v, %v; want a 15s TTL", cfg, err)
+	}
+
+	for _, value := range []string{"0", "-1", "soon"} {
+		t.Setenv("DOMAIN_PERMISSION_CACHE_SECONDS", value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Load() with DOMAIN_PERMISSION_CACHE_SECONDS=%q expected an error", value)
+		}
+	}
+}
+
+func TestLoad_DatabasePool(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("DATABASE_BUSY_TIMEOUT_MS", "")
+		t.Setenv("DATABASE_MAX_READ_CONNECTIONS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.DatabaseBusyTimeout != 5*time.Second || cfg.DatabaseMaxReadConnections != 4 {
+			t.Errorf("got busy timeout %v, read connections %d; want 5s, 4",
+				cfg.DatabaseBusyTimeout, cfg.DatabaseMaxReadConnections)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("DATABASE_BUSY_TIMEOUT_MS", "15000")
+		t.Setenv("DATABASE_MAX_READ_CONNECTIONS", "8")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.DatabaseBusyTimeout != 15*time.Second || cfg.DatabaseMaxReadConnections != 8 {
+			t.Errorf("got busy timeout %v, read connections %d; want 15s, 8",
+				cfg.DatabaseBusyTimeout, cfg.DatabaseMaxReadConnections)
+		}
+	})
+
+	for name, env := range map[string][2]string{
+		"negative timeout":    {"DATABASE_BUSY_TIMEOUT_MS", "-1"},
+		"no read connections": {"DATABASE_MAX_READ_CONNECTIONS", "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_ShutdownDrain(t *testing.T) {
+	t.Setenv("SHUTDOWN_DRAIN_SECONDS", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ShutdownDrain != 0 {
+		t.Errorf("ShutdownDrain = %v, want 0", cfg.ShutdownDrain)
+	}
+
+	t.Setenv("SHUTDOWN_DRAIN_SECONDS", "15")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ShutdownDrain != 15*time.Second {
+		t.Errorf("ShutdownDrain = %v, want 15s", cfg.ShutdownDrain)
+	}
+
+	t.Setenv("SHUTDOWN_DRAIN_SECONDS", "-1")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want error")
+	}
+}
+
+func TestLoad_MetricsTokenLabels(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("METRICS_TOKEN_LABELS", "")
+		t.Setenv("METRICS_TOKEN_ALLOWLIST", "")
+		t.Setenv("METRICS_TOKEN_MAX_LABELS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.MetricsTokenLabels != "off" || len(cfg.MetricsTokenAllowlist) != 0 || cfg.MetricsTokenMaxLabels != 100 {
+			t.Errorf("got labels %q, allowlist %v, max %d; want off, none, 100",
+				cfg.MetricsTokenLabels, cfg.MetricsTokenAllowlist, cfg.MetricsTokenMaxLabels)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("METRICS_TOKEN_LABELS", "Hash")
+		t.Setenv("METRICS_TOKEN_ALLOWLIST", "ci, deploy")
+		t.Setenv("METRICS_TOKEN_MAX_LABELS", "20")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.MetricsTokenLabels != "hash" || len(cfg.MetricsTokenAllowlist) != 2 || cfg.MetricsTokenAllowlist[1] != "deploy" || cfg.MetricsTokenMaxLabels != 20 {
+			t.Errorf("got labels %q, allowlist %v, max %d",
+				cfg.MetricsTokenLabels, cfg.MetricsTokenAllowlist, cfg.MetricsTokenMaxLabels)
+		}
+	})
+
+	for name, env := range map[string][2]string{
+		"unknown mode": {"METRICS_TOKEN_LABELS", "id"},
+		"zero limit":   {"METRICS_TOKEN_MAX_LABELS", "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_SLO(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("SLO_AVAILABILITY_TARGET", "")
+		t.Setenv("SLO_LATENCY_TARGET", "")
+		t.Setenv("SLO_LATENCY_THRESHOLD_MS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.SLOAvailabilityTarget != 0.999 || cfg.SLOLatencyTarget != 0.99 || cfg.SLOLatencyThreshold != 300*time.Millisecond {
+			t.Errorf("unexpected SLO defaults: %v %v %v", cfg.SLOAvailabilityTarget, cfg.SLOLatencyTarget, cfg.SLOLatencyThreshold)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("SLO_AVAILABILITY_TARGET", "0.995")
+		t.Setenv("SLO_LATENCY_TARGET", "0.95")
+		t.Setenv("SLO_LATENCY_THRESHOLD_MS", "500")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.SLOAvailabilityTarget != 0.995 || cfg.SLOLatencyTarget != 0.95 || cfg.SLOLatencyThreshold != 500*time.Millisecond {
+			t.Errorf("unexpected SLO config: %v %v %v", cfg.SLOAvailabilityTarget, cfg.SLOLatencyTarget, cfg.SLOLatencyThreshold)
+		}
+	})
+
+	for _, tt := range []struct {
+		name, env, value string
+	}{
+		{"availability of 1", "SLO_AVAILABILITY_TARGET", "1"},
+		{"availability not a number", "SLO_AVAILABILITY_TARGET", "high"},
+		{"latency of 0", "SLO_LATENCY_TARGET", "0"},
+		{"zero threshold", "SLO_LATENCY_THRESHOLD_MS", "0"},
+		{"negative threshold", "SLO_LATENCY_THRESHOLD_MS", "-5"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.env, tt.value)
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_ClientIdentification(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("INSTANCE_ID", "")
+		t.Setenv("BUNNY_USER_AGENT", "")
+		t.Setenv("BUNNY_CONTACT", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.InstanceID != "" {
+			t.Errorf("InstanceID = %q, want empty for a generated ID", cfg.InstanceID)
+		}
+		if cfg.BunnyUserAgent != "" || cfg.BunnyContact != "" {
+			t.Errorf("expected empty user agent and contact, got %q %q", cfg.BunnyUserAgent, cfg.BunnyContact)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("INSTANCE_ID", "proxy-eu-1")
+		t.Setenv("BUNNY_USER_AGENT", "acme-dns/2.0")
+		t.Setenv("BUNNY_CONTACT", "ops@example.com")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.InstanceID != "proxy-eu-1" || cfg.BunnyUserAgent != "acme-dns/2.0" || cfg.BunnyContact != "ops@example.com" {
+			t.Errorf("unexpected identification config: %q %q %q", cfg.InstanceID, cfg.BunnyUserAgent, cfg.BunnyContact)
+		}
+	})
+}
+
+func TestLoad_BunnySchemaCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     string
+		wantErr  bool
+	}{
+		{"not set defaults to off", "", "off", false},
+		{"log", "log", "log", false},
+		{"strict uppercase", "STRICT", "strict", false},
+		{"invalid", "loud", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BUNNY_SCHEMA_CHECK", tt.envValue)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.BunnySchemaCheck != tt.want {
+				t.Errorf("BunnySchemaCheck = %q, want %q", cfg.BunnySchemaCheck, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_BunnyRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts string
+		baseDelay   string
+		wantMax     int
+		wantDelay   time.Duration
+		wantErr     bool
+	}{
+		{"defaults", "", "", 3, 200 * time.Millisecond, false},
+		{"custom", "5", "50", 5, 50 * time.Millisecond, false},
+		{"disabled", "1", "", 1, 200 * time.Millisecond, false},
+		{"zero attempts", "0", "", 0, 0, true},
+		{"zero delay", "", "0", 0, 0, true},
+		{"invalid", "many", "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BUNNY_RETRY_MAX_ATTEMPTS", tt.maxAttempts)
+			t.Setenv("BUNNY_RETRY_BASE_DELAY_MS", tt.baseDelay)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.BunnyRetryMaxAttempts != tt.wantMax || cfg.BunnyRetryBaseDelay != tt.wantDelay {
+				t.Errorf("got %d attempts, %v delay; want %d, %v",
+					cfg.BunnyRetryMaxAttempts, cfg.BunnyRetryBaseDelay, tt.wantMax, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestLoad_BunnyCircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		threshold     string
+		cooldown      string
+		wantThreshold int
+		wantCooldown  time.Duration
+		wantErr       bool
+	}{
+		{"defaults", "", "", 5, 30 * time.Second, false},
+		{"custom", "3", "60", 3, time.Minute, false},
+		{"disabled", "0", "", 0, 30 * time.Second, false},
+		{"zero cooldown", "", "0", 0, 0, true},
+		{"invalid", "-1", "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BUNNY_CIRCUIT_BREAKER_THRESHOLD", tt.threshold)
+			t.Setenv("BUNNY_CIRCUIT_BREAKER_COOLDOWN_SECONDS", tt.cooldown)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.BunnyCircuitBreakerThreshold != tt.wantThreshold || cfg.BunnyCircuitBreakerCooldown != tt.wantCooldown {
+				t.Errorf("got threshold %d, cooldown %v; want %d, %v",
+					cfg.BunnyCircuitBreakerThreshold, cfg.BunnyCircuitBreakerCooldown, tt.wantThreshold, tt.wantCooldown)
+			}
+		})
+	}
+}
+
+func TestLoad_TLSClientAuth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "optional", false},
+		{"Required", "required", false},
+		{"always", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("TLS_CLIENT_AUTH", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.TLSClientAuth != tt.want {
+				t.Errorf("TLSClientAuth = %q, want %q", cfg.TLSClientAuth, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_AdminSessionTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"default", "", 15 * time.Minute, false},
+		{"custom", "300", 5 * time.Minute, false},
+		{"disabled", "0", 0, false},
+		{"negative", "-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_SESSION_TTL_SECONDS", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.AdminSessionTTL != tt.want {
+				t.Errorf("AdminSessionTTL = %v, want %v", cfg.AdminSessionTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_BootstrapWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"default", "", time.Hour, false},
+		{"custom", "600", 10 * time.Minute, false},
+		{"unlimited", "0", 0, false},
+		{"negative", "-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("BOOTSTRAP_WINDOW_SECONDS", tt.value)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.BootstrapWindow != tt.want {
+				t.Errorf("BootstrapWindow = %v, want %v", cfg.BootstrapWindow, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_ReplayProtection(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		window     string
+		wantMode   string
+		wantWindow time.Duration
+		wantErr    bool
+	}{
+		{"defaults", "", "", "off", 5 * time.Minute, false},
+		{"required", "Required", "60", "required", time.Minute, false},
+		{"optional", "optional", "", "optional", 5 * time.Minute, false},
+		{"invalid mode", "always", "", "", 0, true},
+		{"zero window", "required", "0", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REPLAY_PROTECTION", tt.mode)
+			t.Setenv("REPLAY_WINDOW_SECONDS", tt.window)
+
+			cfg, err := Load()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.ReplayProtection != tt.wantMode || cfg.ReplayWindow != tt.wantWindow {
+				t.Errorf("got %q, %v; want %q, %v", cfg.ReplayProtection, cfg.ReplayWindow, tt.wantMode, tt.wantWindow)
+			}
+		})
+	}
+}
+
+func TestLoad_IdempotencyTTL(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 24 * time.Hour, false},
+		{"600", 10 * time.Minute, false},
+		{"0", 0, false},
+		{"-1", 0, true},
+		{"day", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("IDEMPOTENCY_TTL_SECONDS", tt.value)
+		cfg, err := Load()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Load() with IDEMPOTENCY_TTL_SECONDS=%q expected an error", tt.value)
+			}
+			continue
+		}
+		if err != nil || cfg.IdempotencyTTL != tt.want {
+			t.Errorf("Load() with IDEMPOTENCY_TTL_SECONDS=%q = %v, %v; want %v", tt.value, cfg, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_RecordValidation(t *testing.T) {
+	tests := []struct {
+		mode, minTTL, maxTTL string
+		wantMode             string
+		wantMin, wantMax     int
+		wantErr              bool
+	}{
+		{"", "", "", "off", 15, 86400, false},
+		{"STRICT", "60", "3600", "strict", 60, 3600, false},
+		{"strict", "300", "300", "strict", 300, 300, false},
+		{"lenient", "", "", "", 0, 0, true},
+		{"strict", "600", "60", "", 0, 0, true},
+		{"strict", "-1", "", "", 0, 0, true},
+		{"strict", "", "99999999999", "", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("RECORD_VALIDATION", tt.mode)
+		t.Setenv("RECORD_MIN_TTL_SECONDS", tt.minTTL)
+		t.Setenv("RECORD_MAX_TTL_SECONDS", tt.maxTTL)
+		cfg, err := Load()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Load() with %q, %q, %q expected an error", tt.mode, tt.minTTL, tt.maxTTL)
+			}
+			continue
+		}
+		if err != nil || cfg.RecordValidation != tt.wantMode || cfg.RecordMinTTL != tt.wantMin || cfg.RecordMaxTTL != tt.wantMax {
+			t.Errorf("Load() with %q, %q, %q = %v, %v", tt.mode, tt.minTTL, tt.maxTTL, cfg, err)
+		}
+	}
+}
+
+func TestLoad_AuditStream(t *testing.T) {
+	t.Setenv("AUDIT_STREAM", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.AuditStream != "" {
+		t.Errorf("AuditStream = %q, want empty", cfg.AuditStream)
+	}
+
+	t.Setenv("AUDIT_STREAM", " stdout ")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.AuditStream != "stdout" {
+		t.Errorf("AuditStream = %q, want %q", cfg.AuditStream, "stdout")
+	}
+}
+
+func TestLoad_WatchdogThresholds(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("WATCHDOG_GOROUTINE_THRESHOLD", "")
+		t.Setenv("WATCHDOG_FD_THRESHOLD", "")
+		t.Setenv("WATCHDOG_DB_CONN_THRESHOLD", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.WatchdogGoroutineThreshold != 1000 || cfg.WatchdogFDThreshold != 1000 || cfg.WatchdogDBConnThreshold != 10 {
+			t.Errorf("unexpected watchdog defaults: %d %d %d", cfg.WatchdogGoroutineThreshold, cfg.WatchdogFDThreshold, cfg.WatchdogDBConnThreshold)
+		}
+	})
+
+	t.Run("custom and disabled", func(t *testing.T) {
+		t.Setenv("WATCHDOG_GOROUTINE_THRESHOLD", "5000")
+		t.Setenv("WATCHDOG_FD_THRESHOLD", "0")
+		t.Setenv("WATCHDOG_DB_CONN_THRESHOLD", "2")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.WatchdogGoroutineThreshold != 5000 || cfg.WatchdogFDThreshold != 0 || cfg.WatchdogDBConnThreshold != 2 {
+			t.Errorf("unexpected watchdog config: %d %d %d", cfg.WatchdogGoroutineThreshold, cfg.WatchdogFDThreshold, cfg.WatchdogDBConnThreshold)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("WATCHDOG_FD_THRESHOLD", "lots")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
+func TestLoad_TokenHashing(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("TOKEN_HASH_MEMORY_KIB", "")
+		t.Setenv("TOKEN_HASH_ITERATIONS", "")
+		t.Setenv("TOKEN_HASH_PARALLELISM", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.TokenHashMemoryKiB != 19456 || cfg.TokenHashIterations != 2 || cfg.TokenHashParallelism != 1 {
+			t.Errorf("unexpected token hashing defaults: %d %d %d", cfg.TokenHashMemoryKiB, cfg.TokenHashIterations, cfg.TokenHashParallelism)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("TOKEN_HASH_ITERATIONS", "0")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.TokenHashIterations != 0 {
+			t.Errorf("TokenHashIterations = %d, want 0", cfg.TokenHashIterations)
+		}
+	})
+
+	for name, env := range map[string][2]string{
+		"zero parallelism":     {"TOKEN_HASH_PARALLELISM", "0"},
+		"too much parallelism": {"TOKEN_HASH_PARALLELISM", "256"},
+		"too little memory":    {"TOKEN_HASH_MEMORY_KIB", "7"},
+		"negative iterations":  {"TOKEN_HASH_ITERATIONS", "-1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proxy.env")
+	content := "# Settings reloaded on SIGHUP\n\nLOG_LEVEL=debug\nLISTEN_ADDR = \":9000\"\nBUNNY_API_KEY='file-key'\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	t.Run("fills unset variables", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", path)
+		t.Setenv("LOG_LEVEL", "")
+		t.Setenv("LISTEN_ADDR", "")
+		t.Setenv("BUNNY_API_KEY", "env-key")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.ConfigFile != path || cfg.LogLevel != "debug" || cfg.ListenAddr != ":9000" {
+			t.Errorf("unexpected config from file: %q %q %q", cfg.ConfigFile, cfg.LogLevel, cfg.ListenAddr)
+		}
+		// The environment takes precedence
+		if cfg.BunnyAPIKey != "env-key" {
+			t.Errorf("BunnyAPIKey = %q, want the environment's value", cfg.BunnyAPIKey)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.env"))
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		invalid := filepath.Join(t.TempDir(), "invalid.env")
+		if err := os.WriteFile(invalid, []byte("LOG_LEVEL=debug\nnot a setting\n"), 0o600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		t.Setenv("CONFIG_FILE", invalid)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("Load() error = %v, want an error for line 2", err)
+		}
+	})
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "bunny_api_key")
+	accountsPath := filepath.Join(dir, "bunny_accounts")
+	if err := os.WriteFile(keyPath, []byte("file-key\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+	if err := os.WriteFile(accountsPath, []byte("client-a=key-a\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+
+	t.Run("reads files", func(t *testing.T) {
+		t.Setenv("BUNNY_API_KEY", "")
+		t.Setenv("BUNNY_API_KEY_FILE", keyPath)
+		t.Setenv("BUNNY_ACCOUNTS_FILE", accountsPath)
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.BunnyAPIKey != "file-key" || cfg.BunnyAccounts["client-a"] != "key-a" {
+			t.Errorf("unexpected secrets: %q %v", cfg.BunnyAPIKey, cfg.BunnyAccounts)
+		}
+		if cfg.SecretFiles["BUNNY_API_KEY"] != keyPath || cfg.SecretFiles["BUNNY_ACCOUNTS"] != accountsPath {
+			t.Errorf("SecretFiles = %v", cfg.SecretFiles)
+		}
+	})
+
+	t.Run("both set", func(t *testing.T) {
+		t.Setenv("BUNNY_API_KEY", "env-key")
+		t.Setenv("BUNNY_API_KEY_FILE", keyPath)
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+			t.Fatalf("Load() error = %v, want a conflict error", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("BUNNY_API_KEY", "")
+		t.Setenv("ACME_ACCESS_KEY_FILE", filepath.Join(dir, "missing"))
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "ACME_ACCESS_KEY_FILE") {
+			t.Fatalf("Load() error = %v, want an error naming the variable", err)
+		}
+	})
+}
+
+func TestLoad_Vault(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.VaultBunnyAPIKeyField != "api_key" || cfg.VaultRefreshInterval != 5*time.Minute {
+			t.Errorf("unexpected defaults: %q %v", cfg.VaultBunnyAPIKeyField, cfg.VaultRefreshInterval)
+		}
+		if cfg.BunnyAPIKeyProvider() != nil {
+			t.Error("BunnyAPIKeyProvider() should be nil without VAULT_BUNNY_API_KEY_PATH")
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("BUNNY_API_KEY", "")
+		t.Setenv("VAULT_ADDR", "https://vault.example.com:8200/")
+		t.Setenv("VAULT_ROLE_ID", "proxy")
+		t.Setenv("VAULT_SECRET_ID", "secret-id")
+		t.Setenv("VAULT_BUNNY_API_KEY_PATH", "/secret/data/bunny/")
+		t.Setenv("VAULT_BUNNY_API_KEY_FIELD", "key")
+		t.Setenv("VAULT_REFRESH_INTERVAL_SECONDS", "60")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.VaultAddr != "https://vault.example.com:8200" || cfg.VaultBunnyAPIKeyPath != "secret/data/bunny" ||
+			cfg.VaultBunnyAPIKeyField != "key" || cfg.VaultRefreshInterval != time.Minute {
+			t.Errorf("unexpected config: %q %q %q %v", cfg.VaultAddr, cfg.VaultBunnyAPIKeyPath,
+				cfg.VaultBunnyAPIKeyField, cfg.VaultRefreshInterval)
+		}
+		if cfg.BunnyAPIKeyProvider() == nil {
+			t.Error("BunnyAPIKeyProvider() = nil, want the Vault provider")
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", "vault:8200")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
+func TestLoad_ServerLimits(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.HTTPReadTimeout != 15*time.Second || cfg.HTTPReadHeaderTimeout != 10*time.Second ||
+			cfg.HTTPWriteTimeout != 15*time.Second || cfg.HTTPIdleTimeout != 60*time.Second {
+			t.Errorf("unexpected timeouts: %v %v %v %v", cfg.HTTPReadTimeout, cfg.HTTPReadHeaderTimeout, cfg.HTTPWriteTimeout, cfg.HTTPIdleTimeout)
+		}
+		if cfg.MaxRequestBodyBytes != 1<<20 || cfg.ImportMaxBodyBytes != 10<<20 || cfg.ImportTimeout != 5*time.Minute {
+			t.Errorf("unexpected limits: %d %d %v", cfg.MaxRequestBodyBytes, cfg.ImportMaxBodyBytes, cfg.ImportTimeout)
+		}
+		if cfg.RecordMaxTXTLength != 4096 || cfg.ImportMaxRecords != 1000 || cfg.ImportMaxAsyncRecords != 20000 {
+			t.Errorf("unexpected record limits: %d %d %d", cfg.RecordMaxTXTLength, cfg.ImportMaxRecords, cfg.ImportMaxAsyncRecords)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("HTTP_READ_TIMEOUT_SECONDS", "30")
+		t.Setenv("HTTP_READ_HEADER_TIMEOUT_SECONDS", "5")
+		t.Setenv("HTTP_WRITE_TIMEOUT_SECONDS", "0")
+		t.Setenv("HTTP_IDLE_TIMEOUT_SECONDS", "120")
+		t.Setenv("MAX_REQUEST_BODY_BYTES", "65536")
+		t.Setenv("IMPORT_MAX_BODY_BYTES", "1048576")
+		t.Setenv("IMPORT_TIMEOUT_SECONDS", "0")
+		t.Setenv("RECORD_MAX_TXT_LENGTH", "512")
+		t.Setenv("IMPORT_MAX_RECORDS", "100")
+		t.Setenv("IMPORT_MAX_ASYNC_RECORDS", "5000")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.HTTPReadTimeout != 30*time.Second || cfg.HTTPReadHeaderTimeout != 5*time.Second ||
+			cfg.HTTPWriteTimeout != 0 || cfg.HTTPIdleTimeout != 2*time.Minute {
+			t.Errorf("unexpected timeouts: %v %v %v %v", cfg.HTTPReadTimeout, cfg.HTTPReadHeaderTimeout, cfg.HTTPWriteTimeout, cfg.HTTPIdleTimeout)
+		}
+		if cfg.MaxRequestBodyBytes != 65536 || cfg.ImportMaxBodyBytes != 1<<20 || cfg.ImportTimeout != 0 {
+			t.Errorf("unexpected limits: %d %d %v", cfg.MaxRequestBodyBytes, cfg.ImportMaxBodyBytes, cfg.ImportTimeout)
+		}
+		if cfg.RecordMaxTXTLength != 512 || cfg.ImportMaxRecords != 100 || cfg.ImportMaxAsyncRecords != 5000 {
+			t.Errorf("unexpected record limits: %d %d %d", cfg.RecordMaxTXTLength, cfg.ImportMaxRecords, cfg.ImportMaxAsyncRecords)
+		}
+	})
+
+	for name, env := range map[string][2]string{
+		"zero body size":        {"MAX_REQUEST_BODY_BYTES", "0"},
+		"zero import body size": {"IMPORT_MAX_BODY_BYTES", "0"},
+		"zero TXT length":       {"RECORD_MAX_TXT_LENGTH", "0"},
+		"zero import records":   {"IMPORT_MAX_RECORDS", "0"},
+		"negative timeout":      {"HTTP_WRITE_TIMEOUT_SECONDS", "-1"},
+		"invalid timeout":       {"IMPORT_TIMEOUT_SECONDS", "5m"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(env[0], env[1])
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_AuthCache(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		t.Setenv("AUTH_CACHE_TTL_SECONDS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.AuthCacheTTL != 0 {
+			t.Errorf("AuthCacheTTL = %v, want 0", cfg.AuthCacheTTL)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("AUTH_CACHE_TTL_SECONDS", "15")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.AuthCacheTTL != 15*time.Second {
+			t.Errorf("AuthCacheTTL = %v, want 15s", cfg.AuthCacheTTL)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("AUTH_CACHE_TTL_SECONDS", "-1")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
+func TestLoad_ResponseCache(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("RESPONSE_CACHE_TTL_SECONDS", "")
+		t.Setenv("RESPONSE_CACHE_MAX_ENTRIES", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.ResponseCacheTTL != 0 || cfg.ResponseCacheMaxEntries != 1000 {
+			t.Errorf("unexpected response cache defaults: %v %d", cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("RESPONSE_CACHE_TTL_SECONDS", "30")
+		t.Setenv("RESPONSE_CACHE_MAX_ENTRIES", "0")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.ResponseCacheTTL != 30*time.Second || cfg.ResponseCacheMaxEntries != 0 {
+			t.Errorf("unexpected response cache config: %v %d", cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Setenv("RESPONSE_CACHE_TTL_SECONDS", "-5")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
 func TestValidate(t *testing.T) {
 	t.Run("returns error when BunnyAPIKey is empty", func(t *testing.T) {
 		cfg := &Config{
@@ -248,6 +1375,90 @@ func TestValidate(t *testing.T) {
 		}
 	})
 
+	t.Run("returns error when ACME is enabled without an access key", func(t *testing.T) {
+		cfg := &Config{BunnyAPIKey: "valid-api-key", ACMEDomains: []string{"proxy.example.com"}}
+		if err := cfg.Validate(); err == nil {
+			t.Error("Validate() should return error when ACME_ACCESS_KEY is missing")
+		}
+		cfg.ACMEAccessKey = "acme-token"
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("checks Vault settings", func(t *testing.T) {
+		vault := Config{VaultAddr: "https://vault.example.com", VaultBunnyAPIKeyPath: "secret/data/bunny"}
+		tests := []struct {
+			name    string
+			mutate  func(c *Config)
+			wantErr bool
+		}{
+			{"token", func(c *Config) { c.VaultToken = "hvs.token" }, false},
+			{"approle", func(c *Config) { c.VaultRoleID, c.VaultSecretID = "role", "secret" }, false},
+			{"no auth", func(c *Config) {}, true},
+			{"both auth methods", func(c *Config) { c.VaultToken, c.VaultRoleID, c.VaultSecretID = "t", "r", "s" }, true},
+			{"role without secret", func(c *Config) { c.VaultRoleID = "role" }, true},
+			{"no address", func(c *Config) { c.VaultToken, c.VaultAddr = "t", "" }, true},
+			{"with BUNNY_API_KEY", func(c *Config) { c.VaultToken, c.BunnyAPIKey = "t", "key" }, true},
+		}
+		for _, tt := range tests {
+			cfg := vault
+			tt.mutate(&cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		}
+	})
+
+	t.Run("checks TLS listener settings", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			cfg     Config
+			wantErr bool
+		}{
+			{"cert files", Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}, false},
+			{"cert without key", Config{TLSCertFile: "cert.pem"}, true},
+			{"key without cert", Config{TLSKeyFile: "key.pem"}, true},
+			{"cert files with ACME", Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem",
+				ACMEDomains: []string{"proxy.example.com"}, ACMEAccessKey: "acme-token"}, true},
+			{"client CA with cert files", Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem",
+				TLSClientCA: "ca.pem", TLSClientAuth: "required"}, false},
+			{"client CA with ACME", Config{ACMEDomains: []string{"proxy.example.com"}, ACMEAccessKey: "acme-token",
+				TLSClientCA: "ca.pem"}, false},
+			{"client CA without listener", Config{TLSClientCA: "ca.pem"}, true},
+			{"required without client CA", Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem",
+				TLSClientAuth: "required"}, true},
+		}
+		for _, tt := range tests {
+			tt.cfg.BunnyAPIKey = "valid-api-key"
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		}
+	})
+
+	t.Run("requires trusted proxies for the fingerprint header", func(t *testing.T) {
+		cfg := &Config{BunnyAPIKey: "valid-api-key", TLSFingerprintHeader: "X-JA4"}
+		if err := cfg.Validate(); err == nil {
+			t.Error("expected error without TLS_FINGERPRINT_TRUSTED_PROXIES")
+		}
+		cfg.TLSFingerprintTrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+	})
+
+	t.Run("rejects both encryption key settings", func(t *testing.T) {
+		cfg := &Config{
+			BunnyAPIKey:               "valid-api-key",
+			DatabaseEncryptionKey:     "key",
+			DatabaseEncryptionKeyFile: "/run/secrets/key",
+		}
+		if err := cfg.Validate(); err == nil {
+			t.Error("Validate() error = nil, want error")
+		}
+	})
+
 	t.Run("returns nil when BunnyAPIKey is set", func(t *testing.T) {
 		cfg := &Config{
 			LogLevel:     "info",
@@ -262,3 +1473,339 @@ func TestValidate(t *testing.T) {
 		}
 	})
 }
+
+func TestLoad_CacheWarmup(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("CACHE_WARMUP_ENABLED", "")
+		t.Setenv("CACHE_WARMUP_ZONES", "")
+		t.Setenv("CACHE_WARMUP_PARALLELISM", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.CacheWarmupEnabled || cfg.CacheWarmupZones != 20 || cfg.CacheWarmupParallelism != 4 {
+			t.Errorf("unexpected cache warm-up defaults: %v %d %d", cfg.CacheWarmupEnabled, cfg.CacheWarmupZones, cfg.CacheWarmupParallelism)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("CACHE_WARMUP_ENABLED", "true")
+		t.Setenv("CACHE_WARMUP_ZONES", "50")
+		t.Setenv("CACHE_WARMUP_PARALLELISM", "8")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !cfg.CacheWarmupEnabled || cfg.CacheWarmupZones != 50 || cfg.CacheWarmupParallelism != 8 {
+			t.Errorf("unexpected cache warm-up config: %v %d %d", cfg.CacheWarmupEnabled, cfg.CacheWarmupZones, cfg.CacheWarmupParallelism)
+		}
+	})
+
+	t.Run("zero parallelism", func(t *testing.T) {
+		t.Setenv("CACHE_WARMUP_PARALLELISM", "0")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
+func TestLoad_BlocklistTrustForwardedFor(t *testing.T) {
+	t.Setenv("BLOCKLIST_TRUST_X_FORWARDED_FOR", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.BlocklistTrustForwardedFor {
+		t.Error("expected X-Forwarded-For to be untrusted by default")
+	}
+
+	t.Setenv("BLOCKLIST_TRUST_X_FORWARDED_FOR", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.BlocklistTrustForwardedFor {
+		t.Error("expected X-Forwarded-For to be trusted")
+	}
+
+	t.Setenv("BLOCKLIST_TRUST_X_FORWARDED_FOR", "maybe")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want error")
+	}
+}
+
+func TestLoad_Honeypot(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("HONEYPOT_ENABLED", "")
+		t.Setenv("HONEYPOT_PATHS", "")
+		t.Setenv("HONEYPOT_BAN_SECONDS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.HoneypotEnabled || cfg.HoneypotPaths != nil || cfg.HoneypotBanDuration != 24*time.Hour {
+			t.Errorf("unexpected honeypot defaults: %v %v %v", cfg.HoneypotEnabled, cfg.HoneypotPaths, cfg.HoneypotBanDuration)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("HONEYPOT_ENABLED", "true")
+		t.Setenv("HONEYPOT_PATHS", " /admin/api/keys, /.env ,")
+		t.Setenv("HONEYPOT_BAN_SECONDS", "0")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if !cfg.HoneypotEnabled || len(cfg.HoneypotPaths) != 2 || cfg.HoneypotPaths[1] != "/.env" || cfg.HoneypotBanDuration != 0 {
+			t.Errorf("unexpected honeypot config: %v %v %v", cfg.HoneypotEnabled, cfg.HoneypotPaths, cfg.HoneypotBanDuration)
+		}
+	})
+
+	t.Run("invalid path", func(t *testing.T) {
+		t.Setenv("HONEYPOT_PATHS", "admin/keys")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() error = nil, want error")
+		}
+	})
+}
+
+func TestLoad_UpstreamKeyCheckInterval(t *testing.T) {
+	t.Setenv("UPSTREAM_KEY_CHECK_INTERVAL_SECONDS", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.UpstreamKeyCheckInterval != 5*time.Minute {
+		t.Errorf("UpstreamKeyCheckInterval = %v, want 5m", cfg.UpstreamKeyCheckInterval)
+	}
+
+	t.Setenv("UPSTREAM_KEY_CHECK_INTERVAL_SECONDS", "0")
+	if cfg, err = Load(); err != nil || cfg.UpstreamKeyCheckInterval != 0 {
+		t.Errorf("Load() = %v, %v; want the check disabled", cfg.UpstreamKeyCheckInterval, err)
+	}
+
+	t.Setenv("UPSTREAM_KEY_CHECK_INTERVAL_SECONDS", "soon")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want error")
+	}
+}
+
+func TestLoad_HealthCheckUpstream(t *testing.T) {
+	t.Setenv("HEALTH_CHECK_UPSTREAM", "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.HealthCheckUpstream {
+		t.Error("HealthCheckUpstream should default to false")
+	}
+
+	t.Setenv("HEALTH_CHECK_UPSTREAM", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !cfg.HealthCheckUpstream {
+		t.Error("HealthCheckUpstream = false, want true")
+	}
+
+	t.Setenv("HEALTH_CHECK_UPSTREAM", "maybe")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want error")
+	}
+}
+
+func TestLoad_TLSFingerprintHeader(t *testing.T) {
+	t.Setenv("TLS_FINGERPRINT_HEADER", " X-JA4 ")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.TLSFingerprintHeader != "X-JA4" {
+		t.Errorf("TLSFingerprintHeader = %q, want X-JA4", cfg.TLSFingerprintHeader)
+	}
+
+	t.Setenv("TLS_FINGERPRINT_TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.7, 2001:db8::1/64")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := joinPrefixes(cfg.TLSFingerprintTrustedProxies); got != "10.0.0.0/8,192.0.2.7/32,2001:db8::/64" {
+		t.Errorf("TLSFingerprintTrustedProxies = %s", got)
+	}
+
+	t.Setenv("TLS_FINGERPRINT_TRUSTED_PROXIES", "10.0.0.0/8,proxy.internal")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want error for a host name")
+	}
+
+	t.Setenv("TLS_FINGERPRINT_HEADER", "X-JA4: x")
+	if _, err := Load(); err == nil {
+		t.Fatal("Load() error = nil, want error")
+	}
+}
+
+func TestLoad_ACME(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.ACMEDomains) != 0 {
+		t.Errorf("ACMEDomains = %v, want none by default", cfg.ACMEDomains)
+	}
+	if cfg.ACMEDirectoryURL != defaultACMEDirectoryURL || cfg.ACMECertDir != "/data/certs" ||
+		cfg.ACMERenewBefore != 30*24*time.Hour || cfg.TLSListenAddr != ":8443" {
+		t.Errorf("unexpected ACME defaults: %+v", cfg)
+	}
+
+	t.Setenv("ACME_DOMAINS", " Proxy.example.com, *.example.com ,")
+	t.Setenv("ACME_EMAIL", "ops@example.com")
+	t.Setenv("ACME_ACCESS_KEY", "acme-token")
+	t.Setenv("ACME_DIRECTORY_URL", "https://acme-staging-v02.api.letsencrypt.org/directory")
+	t.Setenv("ACME_CERT_DIR", "/var/lib/certs")
+	t.Setenv("ACME_RENEW_BEFORE_DAYS", "20")
+	t.Setenv("TLS_LISTEN_ADDR", ":443")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.ACMEDomains) != 2 || cfg.ACMEDomains[0] != "proxy.example.com" || cfg.ACMEDomains[1] != "*.example.com" {
+		t.Errorf("ACMEDomains = %v", cfg.ACMEDomains)
+	}
+	if cfg.ACMEEmail != "ops@example.com" || cfg.ACMEAccessKey != "acme-token" ||
+		cfg.ACMEDirectoryURL != "https://acme-staging-v02.api.letsencrypt.org/directory" ||
+		cfg.ACMECertDir != "/var/lib/certs" || cfg.ACMERenewBefore != 20*24*time.Hour || cfg.TLSListenAddr != ":443" {
+		t.Errorf("unexpected ACME config: %+v", cfg)
+	}
+
+	for _, bad := range []string{"example.com/path", "a.*.example.com", "*example.com"} {
+		t.Setenv("ACME_DOMAINS", bad)
+		if _, err := Load(); err == nil {
+			t.Errorf("Load() with ACME_DOMAINS=%q error = nil, want error", bad)
+		}
+	}
+
+	t.Setenv("ACME_DOMAINS", "")
+	t.Setenv("ACME_RENEW_BEFORE_DAYS", "soon")
+	if _, err := Load(); err == nil {
+		t.Error("Load() with invalid ACME_RENEW_BEFORE_DAYS error = nil, want error")
+	}
+}
+
+func TestLoad_AdminCORS(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("ADMIN_CORS_ALLOWED_ORIGINS", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.AdminCORSAllowedOrigins != nil || cfg.AdminCORSAllowCredentials {
+			t.Errorf("CORS enabled by default: %v %v", cfg.AdminCORSAllowedOrigins, cfg.AdminCORSAllowCredentials)
+		}
+		if strings.Join(cfg.AdminCORSAllowedMethods, ",") != "GET,POST,PUT,PATCH,DELETE" ||
+			strings.Join(cfg.AdminCORSAllowedHeaders, ",") != "AccessKey,Content-Type" {
+			t.Errorf("unexpected defaults: %v %v", cfg.AdminCORSAllowedMethods, cfg.AdminCORSAllowedHeaders)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("ADMIN_CORS_ALLOWED_ORIGINS", "https://admin.example.com, http://localhost:5173")
+		t.Setenv("ADMIN_CORS_ALLOWED_METHODS", "get, delete")
+		t.Setenv("ADMIN_CORS_ALLOWED_HEADERS", "AccessKey, X-Requested-With")
+		t.Setenv("ADMIN_CORS_ALLOW_CREDENTIALS", "true")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if strings.Join(cfg.AdminCORSAllowedOrigins, ",") != "https://admin.example.com,http://localhost:5173" ||
+			strings.Join(cfg.AdminCORSAllowedMethods, ",") != "GET,DELETE" ||
+			strings.Join(cfg.AdminCORSAllowedHeaders, ",") != "AccessKey,X-Requested-With" || !cfg.AdminCORSAllowCredentials {
+			t.Errorf("unexpected CORS settings: %v %v %v %v", cfg.AdminCORSAllowedOrigins, cfg.AdminCORSAllowedMethods,
+				cfg.AdminCORSAllowedHeaders, cfg.AdminCORSAllowCredentials)
+		}
+	})
+
+	for name, env := range map[string]map[string]string{
+		"path":                 {"ADMIN_CORS_ALLOWED_ORIGINS": "https://admin.example.com/panel"},
+		"no scheme":            {"ADMIN_CORS_ALLOWED_ORIGINS": "admin.example.com"},
+		"credentials with any": {"ADMIN_CORS_ALLOWED_ORIGINS": "*", "ADMIN_CORS_ALLOW_CREDENTIALS": "true"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range env {
+				t.Setenv(k, v)
+			}
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLoad_OIDC(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("OIDC_ISSUER_URL", "")
+		t.Setenv("OIDC_ROLE_CLAIM", "")
+		t.Setenv("OIDC_USERNAME_CLAIM", "")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.OIDCIssuer != "" || cfg.OIDCRoleClaim != "roles" || cfg.OIDCUsernameClaim != "email" {
+			t.Errorf("unexpected defaults: issuer %q, role claim %q, username claim %q",
+				cfg.OIDCIssuer, cfg.OIDCRoleClaim, cfg.OIDCUsernameClaim)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		t.Setenv("OIDC_ISSUER_URL", "https://login.example.com/realms/ops")
+		t.Setenv("OIDC_AUDIENCE", " bunny-api-proxy ")
+		t.Setenv("OIDC_JWKS_URL", "https://login.example.com/keys")
+		t.Setenv("OIDC_ROLE_CLAIM", "realm_access.roles")
+		t.Setenv("OIDC_ADMIN_ROLES", "proxy-admin, dns-admin")
+		t.Setenv("OIDC_USERNAME_CLAIM", "preferred_username")
+
+		cfg, err := Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.OIDCIssuer != "https://login.example.com/realms/ops" || cfg.OIDCAudience != "bunny-api-proxy" ||
+			cfg.OIDCJWKSURL != "https://login.example.com/keys" || cfg.OIDCRoleClaim != "realm_access.roles" ||
+			strings.Join(cfg.OIDCAdminRoles, ",") != "proxy-admin,dns-admin" || cfg.OIDCUsernameClaim != "preferred_username" {
+			t.Errorf("unexpected OIDC settings: %+v", cfg)
+		}
+		cfg.BunnyAPIKey = "valid-api-key"
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+	})
+
+	for name, url := range map[string]string{
+		"OIDC_ISSUER_URL": "login.example.com",
+		"OIDC_JWKS_URL":   "ftp://login.example.com/keys",
+	} {
+		t.Run("invalid "+name, func(t *testing.T) {
+			t.Setenv(name, url)
+			if _, err := Load(); err == nil {
+				t.Fatal("Load() error = nil, want error")
+			}
+		})
+	}
+
+	t.Run("issuer without audience or roles", func(t *testing.T) {
+		for _, cfg := range []*Config{
+			{BunnyAPIKey: "valid-api-key", OIDCIssuer: "https://login.example.com", OIDCAdminRoles: []string{"admin"}},
+			{BunnyAPIKey: "valid-api-key", OIDCIssuer: "https://login.example.com", OIDCAudience: "bunny-api-proxy"},
+		} {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Validate() error = nil for %+v, want error", cfg)
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"maps"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of a secret setting that is set.
+const redacted = "[redacted]"
+
+// Setting is one configuration variable with its effective and default
+// values, both formatted as they would be written in the environment.
+type Setting struct {
+	Name    string
+	Value   string
+	Default string
+	// Secret values are redacted by Describe.
+	Secret bool
+	// NonDefault reports whether the effective value differs from the default.
+	NonDefault bool
+	// Description explains the setting in Markdown.
+	Description string
+}
+
+// setting describes how one environment variable maps onto Config.
+type setting struct {
+	name   string
+	secret bool
+	value  func(c *Config) string
+}
+
+// settings lists every environment variable Load reads, in documentation order.
+var settings = []setting{
+	{"CONFIG_FILE", false, func(c *Config) string { return c.ConfigFile }},
+	{"LOG_LEVEL", false, func(c *Config) string { return c.LogLevel }},
+	{"LISTEN_ADDR", false, func(c *Config) string { return c.ListenAddr }},
+	{"SHUTDOWN_DRAIN_SECONDS", false, func(c *Config) string { return formatDuration(c.ShutdownDrain, time.Second) }},
+	{"DATABASE_PATH", false, func(c *Config) string { return c.DatabasePath }},
+	{"DATABASE_BUSY_TIMEOUT_MS", false, func(c *Config) string { return formatDuration(c.DatabaseBusyTimeout, time.Millisecond) }},
+	{"DATABASE_MAX_READ_CONNECTIONS", false, func(c *Config) string { return strconv.Itoa(c.DatabaseMaxReadConnections) }},
+	{"BUNNY_API_URL", false, func(c *Config) string { return redactURL(c.BunnyAPIURL) }},
+	{"BUNNY_API_URL_OVERRIDES", false, func(c *Config) string { return redactURLOverrides(c.BunnyAPIURLOverrides) }},
+	{"BUNNY_API_KEY", true, func(c *Config) string { return c.BunnyAPIKey }},
+	{"BUNNY_API_KEY_FILE", false, func(c *Config) string { return c.SecretFiles["BUNNY_API_KEY"] }},
+	{"BUNNY_ACCOUNTS", true, func(c *Config) string { return formatAccounts(c.BunnyAccounts) }},
+	{"BUNNY_ACCOUNTS_FILE", false, func(c *Config) string { return c.SecretFiles["BUNNY_ACCOUNTS"] }},
+	{"METRICS_LISTEN_ADDR", false, func(c *Config) string { return c.MetricsListenAddr }},
+	{"METRICS_TOKEN_LABELS", false, func(c *Config) string { return c.MetricsTokenLabels }},
+	{"METRICS_TOKEN_ALLOWLIST", false, func(c *Config) string { return strings.Join(c.MetricsTokenAllowlist, ",") }},
+	{"METRICS_TOKEN_MAX_LABELS", false, func(c *Config) string { return strconv.Itoa(c.MetricsTokenMaxLabels) }},
+	{"INSTANCE_ID", false, func(c *Config) string { return c.InstanceID }},
+	{"BUNNY_USER_AGENT", false, func(c *Config) string { return c.BunnyUserAgent }},
+	{"BUNNY_CONTACT", false, func(c *Config) string { return c.BunnyContact }},
+	{"BUNNY_SCHEMA_CHECK", false, func(c *Config) string { return c.BunnySchemaCheck }},
+	{"BUNNY_RETRY_MAX_ATTEMPTS", false, func(c *Config) string { return strconv.Itoa(c.BunnyRetryMaxAttempts) }},
+	{"BUNNY_RETRY_BASE_DELAY_MS", false, func(c *Config) string { return formatDuration(c.BunnyRetryBaseDelay, time.Millisecond) }},
+	{"BUNNY_CIRCUIT_BREAKER_THRESHOLD", false, func(c *Config) string { return strconv.Itoa(c.BunnyCircuitBreakerThreshold) }},
+	{"BUNNY_CIRCUIT_BREAKER_COOLDOWN_SECONDS", false, func(c *Config) string { return formatDuration(c.BunnyCircuitBreakerCooldown, time.Second) }},
+	{"ACCOUNT_PASSTHROUGH_ENABLED", false, func(c *Config) string { return strconv.FormatBool(c.AccountPassthroughEnabled) }},
+	{"WEBHOOK_MAX_PER_TOKEN", false, func(c *Config) string { return strconv.Itoa(c.WebhookMaxPerToken) }},
+	{"DB_SIZE_ALERT_MB", false, func(c *Config) string { return strconv.Itoa(c.DBSizeAlertMB) }},
+	{"DISK_MIN_FREE_MB", false, func(c *Config) string { return strconv.Itoa(c.DiskMinFreeMB) }},
+	{"DB_SIZE_LIMIT_MB", false, func(c *Config) string { return strconv.Itoa(c.DBSizeLimitMB) }},
+	{"STORAGE_DEGRADED_ALLOW_DELETE", false, func(c *Config) string { return strconv.FormatBool(c.StorageDegradedAllowDelete) }},
+	{"SLO_AVAILABILITY_TARGET", false, func(c *Config) string { return formatFloat(c.SLOAvailabilityTarget) }},
+	{"SLO_LATENCY_TARGET", false, func(c *Config) string { return formatFloat(c.SLOLatencyTarget) }},
+	{"SLO_LATENCY_THRESHOLD_MS", false, func(c *Config) string { return formatDuration(c.SLOLatencyThreshold, time.Millisecond) }},
+	{"WATCHDOG_GOROUTINE_THRESHOLD", false, func(c *Config) string { return strconv.Itoa(c.WatchdogGoroutineThreshold) }},
+	{"WATCHDOG_FD_THRESHOLD", false, func(c *Config) string { return strconv.Itoa(c.WatchdogFDThreshold) }},
+	{"WATCHDOG_DB_CONN_THRESHOLD", false, func(c *Config) string { return strconv.Itoa(c.WatchdogDBConnThreshold) }},
+	{"AUTH_CACHE_TTL_SECONDS", false, func(c *Config) string { return formatDuration(c.AuthCacheTTL, time.Second) }},
+	{"RESPONSE_CACHE_TTL_SECONDS", false, func(c *Config) string { return formatDuration(c.ResponseCacheTTL, time.Second) }},
+	{"RESPONSE_CACHE_MAX_ENTRIES", false, func(c *Config) string { return strconv.Itoa(c.ResponseCacheMaxEntries) }},
+	{"CACHE_WARMUP_ENABLED", false, func(c *Config) string { return strconv.FormatBool(c.CacheWarmupEnabled) }},
+	{"CACHE_WARMUP_ZONES", false, func(c *Config) string { return strconv.Itoa(c.CacheWarmupZones) }},
+	{"CACHE_WARMUP_PARALLELISM", false, func(c *Config) string { return strconv.Itoa(c.CacheWarmupParallelism) }},
+	{"BLOCKLIST_TRUST_X_FORWARDED_FOR", false, func(c *Config) string { return strconv.FormatBool(c.BlocklistTrustForwardedFor) }},
+	{"HONEYPOT_ENABLED", false, func(c *Config) string { return strconv.FormatBool(c.HoneypotEnabled) }},
+	{"HONEYPOT_PATHS", false, func(c *Config) string { return strings.Join(c.HoneypotPaths, ",") }},
+	{"HONEYPOT_BAN_SECONDS", false, func(c *Config) string { return formatDuration(c.HoneypotBanDuration, time.Second) }},
+	{"HEALTH_CHECK_UPSTREAM", false, func(c *Config) string { return strconv.FormatBool(c.HealthCheckUpstream) }},
+	{"UPSTREAM_KEY_CHECK_INTERVAL_SECONDS", false, func(c *Config) string { return formatDuration(c.UpstreamKeyCheckInterval, time.Second) }},
+	{"VAULT_ADDR", false, func(c *Config) string { return redactURL(c.VaultAddr) }},
+	{"VAULT_TOKEN", true, func(c *Config) string { return c.VaultToken }},
+	{"VAULT_TOKEN_FILE", false, func(c *Config) string { return c.SecretFiles["VAULT_TOKEN"] }},
+	{"VAULT_ROLE_ID", false, func(c *Config) string { return c.VaultRoleID }},
+	{"VAULT_SECRET_ID", true, func(c *Config) string { return c.VaultSecretID }},
+	{"VAULT_SECRET_ID_FILE", false, func(c *Config) string { return c.SecretFiles["VAULT_SECRET_ID"] }},
+	{"VAULT_BUNNY_API_KEY_PATH", false, func(c *Config) string { return c.VaultBunnyAPIKeyPath }},
+	{"VAULT_BUNNY_API_KEY_FIELD", false, func(c *Config) string { return c.VaultBunnyAPIKeyField }},
+	{"VAULT_REFRESH_INTERVAL_SECONDS", false, func(c *Config) string { return formatDuration(c.VaultRefreshInterval, time.Second) }},
+	{"TLS_FINGERPRINT_HEADER", false, func(c *Config) string { return c.TLSFingerprintHeader }},
+	{"TLS_FINGERPRINT_TRUSTED_PROXIES", false, func(c *Config) string { return joinPrefixes(c.TLSFingerprintTrustedProxies) }},
+	{"ADMIN_SESSION_TTL_SECONDS", false, func(c *Config) string { return formatDuration(c.AdminSessionTTL, time.Second) }},
+	{"ADMIN_CORS_ALLOWED_ORIGINS", false, func(c *Config) string { return strings.Join(c.AdminCORSAllowedOrigins, ",") }},
+	{"ADMIN_CORS_ALLOWED_METHODS", false, func(c *Config) string { return strings.Join(c.AdminCORSAllowedMethods, ",") }},
+	{"ADMIN_CORS_ALLOWED_HEADERS", false, func(c *Config) string { return strings.Join(c.AdminCORSAllowedHeaders, ",") }},
+	{"ADMIN_CORS_ALLOW_CREDENTIALS", false, func(c *Config) string { return strconv.FormatBool(c.AdminCORSAllowCredentials) }},
+	{"OIDC_ISSUER_URL", false, func(c *Config) string { return c.OIDCIssuer }},
+	{"OIDC_AUDIENCE", false, func(c *Config) string { return c.OIDCAudience }},
+	{"OIDC_JWKS_URL", false, func(c *Config) string { return c.OIDCJWKSURL }},
+	{"OIDC_ROLE_CLAIM", false, func(c *Config) string { return c.OIDCRoleClaim }},
+	{"OIDC_ADMIN_ROLES", false, func(c *Config) string { return strings.Join(c.OIDCAdminRoles, ",") }},
+	{"OIDC_USERNAME_CLAIM", false, func(c *Config) string { return c.OIDCUsernameClaim }},
+	{"BOOTSTRAP_WINDOW_SECONDS", false, func(c *Config) string { return formatDuration(c.BootstrapWindow, time.Second) }},
+	{"AUDIT_STREAM", false, func(c *Config) string { return c.AuditStream }},
+	{"REPLAY_PROTECTION", false, func(c *Config) string { return c.ReplayProtection }},
+	{"REPLAY_WINDOW_SECONDS", false, func(c *Config) string { return formatDuration(c.ReplayWindow, time.Second) }},
+	{"IDEMPOTENCY_TTL_SECONDS", false, func(c *Config) string { return formatDuration(c.IdempotencyTTL, time.Second) }},
+	{"RECORD_VALIDATION", false, func(c *Config) string { return c.RecordValidation }},
+	{"RECORD_MIN_TTL_SECONDS", false, func(c *Config) string { return strconv.Itoa(c.RecordMinTTL) }},
+	{"RECORD_MAX_TTL_SECONDS", false, func(c *Config) string { return strconv.Itoa(c.RecordMaxTTL) }},
+	{"ACME_DOMAINS", false, func(c *Config) string { return strings.Join(c.ACMEDomains, ",") }},
+	{"ACME_ACCESS_KEY", true, func(c *Config) string { return c.ACMEAccessKey }},
+	{"ACME_ACCESS_KEY_FILE", false, func(c *Config) string { return c.SecretFiles["ACME_ACCESS_KEY"] }},
+	{"ACME_EMAIL", false, func(c *Config) string { return c.ACMEEmail }},
+	{"ACME_DIRECTORY_URL", false, func(c *Config) string { return redactURL(c.ACMEDirectoryURL) }},
+	{"ACME_CERT_DIR", false, func(c *Config) string { return c.ACMECertDir }},
+	{"ACME_RENEW_BEFORE_DAYS", false, func(c *Config) string { return formatDuration(c.ACMERenewBefore, 24*time.Hour) }},
+	{"TLS_LISTEN_ADDR", false, func(c *Config) string { return c.TLSListenAddr }},
+	{"TLS_CERT_FILE", false, func(c *Config) string { return c.TLSCertFile }},
+	{"TLS_KEY_FILE", false, func(c *Config) string { return c.TLSKeyFile }},
+	{"TLS_CLIENT_CA", false, func(c *Config) string { return c.TLSClientCA }},
+	{"TLS_CLIENT_AUTH", false, func(c *Config) string { return c.TLSClientAuth }},
+	{"ZONE_TAG_RULES", false, func(c *Config) string { return strings.Join(c.ZoneTagRules, ",") }},
+	{"ZONE_TAG_SOURCE", false, func(c *Config) string { return redactURL(c.ZoneTagSource) }},
+	{"ZONE_TAG_SYNC_INTERVAL_SECONDS", false, func(c *Config) string { return formatDuration(c.ZoneTagSyncInterval, time.Second) }},
+	{"DOMAIN_PERMISSION_CACHE_SECONDS", false, func(c *Config) string { return formatDuration(c.DomainPermissionCacheTTL, time.Second) }},
+	{"TOKENS_CONFIG_FILE", false, func(c *Config) string { return c.TokensConfigFile }},
+	{"DATABASE_ENCRYPTION_KEY", true, func(c *Config) string { return c.DatabaseEncryptionKey }},
+	{"DATABASE_ENCRYPTION_KEY_FILE", false, func(c *Config) string { return c.DatabaseEncryptionKeyFile }},
+	{"TOKEN_HASH_MEMORY_KIB", false, func(c *Config) string { return strconv.Itoa(c.TokenHashMemoryKiB) }},
+	{"TOKEN_HASH_ITERATIONS", false, func(c *Config) string { return strconv.Itoa(c.TokenHashIterations) }},
+	{"TOKEN_HASH_PARALLELISM", false, func(c *Config) string { return strconv.Itoa(c.TokenHashParallelism) }},
+	{"HTTP_READ_TIMEOUT_SECONDS", false, func(c *Config) string { return formatDuration(c.HTTPReadTimeout, time.Second) }},
+	{"HTTP_READ_HEADER_TIMEOUT_SECONDS", false, func(c *Config) string { return formatDuration(c.HTTPReadHeaderTimeout, time.Second) }},
+	{"HTTP_WRITE_TIMEOUT_SECONDS", false, func(c *Config) string { return formatDuration(c.HTTPWriteTimeout, time.Second) }},
+	{"HTTP_IDLE_TIMEOUT_SECONDS", false, func(c *Config) string { return formatDuration(c.HTTPIdleTimeout, time.Second) }},
+	{"MAX_REQUEST_BODY_BYTES", false, func(c *Config) string { return strconv.FormatInt(c.MaxRequestBodyBytes, 10) }},
+	{"IMPORT_MAX_BODY_BYTES", false, func(c *Config) string { return strconv.FormatInt(c.ImportMaxBodyBytes, 10) }},
+	{"IMPORT_TIMEOUT_SECONDS", false, func(c *Config) string { return formatDuration(c.ImportTimeout, time.Second) }},
+	{"RECORD_MAX_TXT_LENGTH", false, func(c *Config) string { return strconv.Itoa(c.RecordMaxTXTLength) }},
+	{"IMPORT_MAX_RECORDS", false, func(c *Config) string { return strconv.Itoa(c.ImportMaxRecords) }},
+	{"IMPORT_MAX_ASYNC_RECORDS", false, func(c *Config) string { return strconv.Itoa(c.ImportMaxAsyncRecords) }},
+}
+
+// Describe lists every setting with its effective and default values, for
+// support and audit reviews. Secrets are redacted, as are passwords in URLs,
+// but NonDefault still reports whether a secret was changed.
+func (c *Config) Describe() []Setting {
+	defaults := Defaults()
+	out := make([]Setting, 0, len(settings))
+	for _, s := range settings {
+		value, def := s.value(c), s.value(defaults)
+		setting := Setting{Name: s.name, Value: value, Default: def, Secret: s.secret, NonDefault: value != def,
+			Description: descriptions[s.name]}
+		if s.secret {
+			setting.Value, setting.Default = redact(value), redact(def)
+		}
+		out = append(out, setting)
+	}
+	return out
+}
+
+// redact hides a secret value, keeping whether it is set.
+func redact(v string) string {
+	if v == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL hides the password and query string of a URL, which may carry
+// credentials. Values that are not URLs, such as file paths, are returned as is.
+func redactURL(v string) string {
+	u, err := url.Parse(v)
+	if err != nil || u.Host == "" {
+		return v
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = redacted
+	}
+	return u.Redacted()
+}
+
+// redactURLOverrides redacts the URL of each "class=url" entry.
+func redactURLOverrides(entries []string) string {
+	redactedEntries := make([]string, 0, len(entries))
+	for _, e := range entries {
+		class, u, _ := strings.Cut(e, "=")
+		redactedEntries = append(redactedEntries, class+"="+redactURL(strings.TrimSpace(u)))
+	}
+	return strings.Join(redactedEntries, ",")
+}
+
+// formatAccounts formats accounts as sorted "name=apikey" entries.
+func formatAccounts(accounts map[string]string) string {
+	entries := make([]string, 0, len(accounts))
+	for _, name := range slices.Sorted(maps.Keys(accounts)) {
+		entries = append(entries, name+"="+accounts[name])
+	}
+	return strings.Join(entries, ",")
+}
+
+// joinPrefixes formats networks as a comma-separated list.
+func joinPrefixes(prefixes []netip.Prefix) string {
+	entries := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		entries = append(entries, prefix.String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// formatFloat formats a ratio the way it would be written in the environment.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatDuration formats d as a whole number of units.
+func formatDuration(d, unit time.Duration) string {
+	return strconv.FormatInt(int64(d/unit), 10)
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readConfigFile reads a file of NAME=value lines, as used by CONFIG_FILE.
+// Blank lines and lines starting with # are ignored, and a value may be
+// enclosed in single or double quotes.
+func readConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path) //nolint:gosec // the path is chosen by the operator
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_FILE: %w", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid CONFIG_FILE line %d: must be NAME=value", n)
+		}
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_FILE: %w", err)
+	}
+	return values, nil
+}
+
+// withConfigFile returns a lookup that falls back to the values in the
+// CONFIG_FILE named by getenv for variables the environment does not set.
+func withConfigFile(getenv func(string) string) (func(string) string, string, error) {
+	path := strings.TrimSpace(getenv("CONFIG_FILE"))
+	if path == "" {
+		return getenv, "", nil
+	}
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return func(name string) string {
+		if v := getenv(name); v != "" {
+			return v
+		}
+		return values[name]
+	}, path, nil
+}
+
+// secretVariables are the secrets that can also be read from the file named
+// by NAME_FILE, so Docker and Kubernetes secrets can be mounted as files
+// instead of appearing in the environment. DATABASE_ENCRYPTION_KEY_FILE is
+// read when the database is opened.
+var secretVariables = []string{"BUNNY_API_KEY", "BUNNY_ACCOUNTS", "ACME_ACCESS_KEY", "VAULT_TOKEN", "VAULT_SECRET_ID"}
+
+// withSecretFiles returns a lookup that reads each secret variable from the
+// file named by its _FILE variable, and the files read keyed by variable
+// name. Surrounding whitespace, such as a trailing newline, is removed.
+func withSecretFiles(getenv func(string) string) (func(string) string, map[string]string, error) {
+	files := make(map[string]string)
+	values := make(map[string]string)
+	for _, name := range secretVariables {
+		path := strings.TrimSpace(getenv(name + "_FILE"))
+		if path == "" {
+			continue
+		}
+		if getenv(name) != "" {
+			return nil, nil, fmt.Errorf("%s and %s_FILE cannot be used together", name, name)
+		}
+		data, err := os.ReadFile(path) //nolint:gosec // the path is chosen by the operator
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s_FILE: %w", name, err)
+		}
+		files[name] = path
+		values[name] = strings.TrimSpace(string(data))
+	}
+	if len(files) == 0 {
+		return getenv, files, nil
+	}
+	return func(name string) string {
+		if v, ok := values[name]; ok {
+			return v
+		}
+		return getenv(name)
+	}, files, nil
+}
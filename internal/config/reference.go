@@ -0,0 +1,117 @@
+package config
+
+// descriptions documents every setting for the operator documentation served
+// at /admin/docs. Values are Markdown.
+var descriptions = map[string]string{
+	"CONFIG_FILE":                            "File of `NAME=value` lines read for variables the environment does not set, and read again on `SIGHUP`",
+	"LOG_LEVEL":                              "Default log level",
+	"LISTEN_ADDR":                            "Address and port to listen on",
+	"SHUTDOWN_DRAIN_SECONDS":                 "How long the proxy keeps serving after `SIGTERM` while `/ready` reports 503, so load balancers stop routing to it before it closes its listeners",
+	"DATABASE_PATH":                          "SQLite database file path",
+	"DATABASE_BUSY_TIMEOUT_MS":               "How long a database connection waits for a lock held by another connection or process before failing with `database is locked`",
+	"DATABASE_MAX_READ_CONNECTIONS":          "Read-only SQLite connections used beside the single writer connection",
+	"BUNNY_API_URL":                          "bunny.net API URL (for testing/mocking)",
+	"BUNNY_API_URL_OVERRIDES":                "Comma-separated `class=url` entries sending one endpoint class (`zones`, `records`, `dnssec`, `certificates`, `statistics` or `other`) to another base URL, for regional endpoints or staged rollouts",
+	"BUNNY_API_KEY":                          "bunny.net master API key, used to proxy requests and for bootstrap authentication",
+	"BUNNY_API_KEY_FILE":                     "File containing `BUNNY_API_KEY`, such as a Docker or Kubernetes secret",
+	"BUNNY_ACCOUNTS":                         "Comma-separated `name=apikey` entries for further bunny.net accounts; tokens assigned to an account send their requests with its key",
+	"BUNNY_ACCOUNTS_FILE":                    "File containing `BUNNY_ACCOUNTS`, such as a Docker or Kubernetes secret",
+	"METRICS_LISTEN_ADDR":                    "Internal-only listener for `/metrics`; do not expose it to the public internet",
+	"METRICS_TOKEN_LABELS":                   "Per-token request metrics: `off`, `name` (label by token name) or `hash` (label by a short hash of the name)",
+	"METRICS_TOKEN_ALLOWLIST":                "Comma-separated token names given their own per-token metric label; other tokens share `other`",
+	"METRICS_TOKEN_MAX_LABELS":               "Most distinct per-token metric labels; further tokens share `other`",
+	"INSTANCE_ID":                            "Identifies this instance in logs (`instance_id`), the `bunny_proxy_info` metric, `/status` and the upstream User-Agent",
+	"BUNNY_USER_AGENT":                       "User-Agent sent to bunny.net",
+	"BUNNY_CONTACT":                          "Contact (e.g. an email address) sent to bunny.net in the `From` header so their support can identify your traffic",
+	"BUNNY_SCHEMA_CHECK":                     "Detect bunny.net response fields the proxy does not know about: `off`, `log` (log once per field and count in `bunny_proxy_upstream_unknown_fields_total`) or `strict` (also fail the request)",
+	"BUNNY_RETRY_MAX_ATTEMPTS":               "Attempts per bunny.net request, including the first. 429, 502, 503 and 504 responses are retried with exponential backoff, honoring `Retry-After`; `1` disables retries",
+	"BUNNY_RETRY_BASE_DELAY_MS":              "Delay before the first retry, doubled for each retry after it (jittered, at most 10 seconds)",
+	"BUNNY_CIRCUIT_BREAKER_THRESHOLD":        "Consecutive failed bunny.net calls to one endpoint class that open its circuit; `0` disables circuit breaking",
+	"BUNNY_CIRCUIT_BREAKER_COOLDOWN_SECONDS": "How long an open circuit fails requests before testing the endpoint again",
+	"ACCOUNT_PASSTHROUGH_ENABLED":            "Expose read-only `/statistics` and `/billing` to admin tokens",
+	"WEBHOOK_MAX_PER_TOKEN":                  "Maximum webhooks a scoped token may register (0 disables scoped webhooks)",
+	"DB_SIZE_ALERT_MB":                       "Send a `system.alert` webhook when the database reaches this size in MB (0 disables)",
+	"DISK_MIN_FREE_MB":                       "Turn read-only when free space on the database volume drops below this many MB (0 disables)",
+	"DB_SIZE_LIMIT_MB":                       "Turn read-only when the database reaches this size in MB (0 disables)",
+	"STORAGE_DEGRADED_ALLOW_DELETE":          "Accept `DELETE` requests while read-only, so data can be removed",
+	"SLO_AVAILABILITY_TARGET":                "Availability objective as a ratio of non-5xx proxied requests",
+	"SLO_LATENCY_TARGET":                     "Ratio of record writes that must complete within the latency threshold",
+	"SLO_LATENCY_THRESHOLD_MS":               "Record write latency threshold in milliseconds",
+	"WATCHDOG_GOROUTINE_THRESHOLD":           "Goroutine count above which sustained growth is reported as a possible leak (0 disables)",
+	"WATCHDOG_FD_THRESHOLD":                  "Open file descriptor count above which sustained growth is reported (0 disables; Linux only)",
+	"WATCHDOG_DB_CONN_THRESHOLD":             "Database connection count above which sustained growth is reported (0 disables)",
+	"AUTH_CACHE_TTL_SECONDS":                 "Cache proxy token lookups and permissions for this long; admin API changes drop the cache (0 disables)",
+	"RESPONSE_CACHE_TTL_SECONDS":             "Cache upstream responses for `GET /dnszone` and `GET /dnszone/{id}` for this long (0 disables)",
+	"RESPONSE_CACHE_MAX_ENTRIES":             "Maximum number of cached responses; the oldest is evicted first (0 means no cap)",
+	"CACHE_WARMUP_ENABLED":                   "Prefetch the zone list and zones into the response cache on startup (needs `RESPONSE_CACHE_TTL_SECONDS`)",
+	"CACHE_WARMUP_ZONES":                     "Maximum number of zones to prefetch; zones changed most in the last 24 hours come first",
+	"CACHE_WARMUP_PARALLELISM":               "Maximum number of zones fetched at once during warm-up",
+	"BLOCKLIST_TRUST_X_FORWARDED_FOR":        "Check the last `X-Forwarded-For` address against the blocklist instead of the remote address; only enable behind a load balancer that sets it",
+	"HONEYPOT_ENABLED":                       "Serve decoy paths that blocklist the requesting client and send a `system.alert`",
+	"HONEYPOT_PATHS":                         "Comma-separated decoy paths replacing the defaults",
+	"HONEYPOT_BAN_SECONDS":                   "How long a client that requested a decoy stays blocklisted (0 = permanently)",
+	"HEALTH_CHECK_UPSTREAM":                  "Include bunny.net API reachability in `/ready` (unreachable reports `degraded`)",
+	"UPSTREAM_KEY_CHECK_INTERVAL_SECONDS":    "How often bunny.net is asked to accept each API key; a rejected key degrades `/ready` and raises an alert (`0` disables)",
+	"VAULT_ADDR":                             "HashiCorp Vault server the bunny.net API key is read from, such as `https://vault.example.com:8200`",
+	"VAULT_TOKEN":                            "Vault token used to read `VAULT_BUNNY_API_KEY_PATH`; renewed while it is renewable",
+	"VAULT_TOKEN_FILE":                       "File containing `VAULT_TOKEN`, such as a Docker or Kubernetes secret",
+	"VAULT_ROLE_ID":                          "AppRole role ID used to log in to Vault instead of `VAULT_TOKEN`",
+	"VAULT_SECRET_ID":                        "AppRole secret ID used with `VAULT_ROLE_ID`",
+	"VAULT_SECRET_ID_FILE":                   "File containing `VAULT_SECRET_ID`, such as a Docker or Kubernetes secret",
+	"VAULT_BUNNY_API_KEY_PATH":               "Vault API path of the secret holding the bunny.net API key, such as `secret/data/bunny` for KV version 2; replaces `BUNNY_API_KEY`",
+	"VAULT_BUNNY_API_KEY_FIELD":              "Field of the Vault secret holding the API key",
+	"VAULT_REFRESH_INTERVAL_SECONDS":         "How often the API key is read from Vault again; a rotated key replaces the one in use without a restart (`0` reads it only on startup)",
+	"TLS_FINGERPRINT_HEADER":                 "Header carrying the client's JA3/JA4 TLS fingerprint, set by your TLS terminator; enables fingerprint pinning for tokens",
+	"TLS_FINGERPRINT_TRUSTED_PROXIES":        "Comma-separated IP addresses or CIDRs of the TLS terminators allowed to set `TLS_FINGERPRINT_HEADER`; required with it, and the header is removed from requests from any other address",
+	"ADMIN_SESSION_TTL_SECONDS":              "Longest lifetime of an admin session; `0` disables `POST /admin/api/sessions`",
+	"ADMIN_CORS_ALLOWED_ORIGINS":             "Comma-separated origins (`scheme://host[:port]`, or `*`) browsers may call `/admin/api/*` from, such as a separately hosted admin panel; empty disables CORS",
+	"ADMIN_CORS_ALLOWED_METHODS":             "Comma-separated methods allowed in cross-origin admin API requests",
+	"ADMIN_CORS_ALLOWED_HEADERS":             "Comma-separated request headers allowed in cross-origin admin API requests",
+	"ADMIN_CORS_ALLOW_CREDENTIALS":           "Let browsers send cookies and other credentials with cross-origin admin API requests; not allowed with the `*` origin",
+	"OIDC_ISSUER_URL":                        "OpenID Connect issuer whose JWTs the admin API accepts as `Authorization: Bearer` credentials; empty disables OIDC",
+	"OIDC_AUDIENCE":                          "Audience the JWTs must name in `aud`; required with `OIDC_ISSUER_URL`",
+	"OIDC_JWKS_URL":                          "URL of the issuer's signing keys; empty discovers it from the issuer",
+	"OIDC_ROLE_CLAIM":                        "Claim listing the user's roles or groups; a dotted path such as `realm_access.roles` reads a nested claim",
+	"OIDC_ADMIN_ROLES":                       "Comma-separated roles in `OIDC_ROLE_CLAIM` that make a user an admin; required with `OIDC_ISSUER_URL`",
+	"OIDC_USERNAME_CLAIM":                    "Claim naming the user in logs and audit entries, falling back to `sub`",
+	"BOOTSTRAP_WINDOW_SECONDS":               "How long after startup the bunny.net API key may create the first admin token; `0` allows it until one exists",
+	"AUDIT_STREAM":                           "Also write every audit entry as a JSON line to `stdout`, `stderr` or a file path, for SIEM ingestion",
+	"REPLAY_PROTECTION":                      "Check `X-Request-Timestamp` and `X-Request-Nonce` on mutating proxy requests: `off`, `optional` (only requests that send them) or `required`",
+	"REPLAY_WINDOW_SECONDS":                  "How far a request timestamp may be from the proxy's clock",
+	"IDEMPOTENCY_TTL_SECONDS":                "How long responses to mutating proxy requests sent with an `Idempotency-Key` are kept for retries (0 ignores the header)",
+	"RECORD_VALIDATION":                      "Validate records before sending them to bunny.net: `off` or `strict` (addresses, host names, TTLs and CAA syntax, rejected with field errors)",
+	"RECORD_MIN_TTL_SECONDS":                 "Lowest record TTL accepted with `RECORD_VALIDATION=strict`",
+	"RECORD_MAX_TTL_SECONDS":                 "Highest record TTL accepted with `RECORD_VALIDATION=strict`",
+	"ACME_DOMAINS":                           "Comma-separated names for a certificate obtained and renewed by the built-in ACME client and served on `TLS_LISTEN_ADDR`",
+	"ACME_ACCESS_KEY":                        "Token the ACME client uses to create and delete `_acme-challenge` TXT records through the proxy (required with `ACME_DOMAINS`)",
+	"ACME_ACCESS_KEY_FILE":                   "File containing `ACME_ACCESS_KEY`, such as a Docker or Kubernetes secret",
+	"ACME_EMAIL":                             "Contact address registered with the CA for expiry notices",
+	"ACME_DIRECTORY_URL":                     "ACME directory of the CA",
+	"ACME_CERT_DIR":                          "Directory for the ACME account key, certificate and certificate key",
+	"ACME_RENEW_BEFORE_DAYS":                 "Renew the certificate this many days before it expires",
+	"TLS_LISTEN_ADDR":                        "HTTPS listener address, used when `ACME_DOMAINS` or `TLS_CERT_FILE` is set",
+	"TLS_CERT_FILE":                          "PEM certificate (chain) served on `TLS_LISTEN_ADDR` instead of an ACME certificate; requires `TLS_KEY_FILE`",
+	"TLS_KEY_FILE":                           "PEM private key for `TLS_CERT_FILE`",
+	"TLS_CLIENT_CA":                          "PEM bundle of CAs that client certificates on the HTTPS listener are verified against; enables client certificate authentication",
+	"TLS_CLIENT_AUTH":                        "`optional` accepts TLS connections without a client certificate; `required` rejects them",
+	"ZONE_TAG_RULES":                         "Comma-separated `tag=pattern` rules tagging zones by domain",
+	"ZONE_TAG_SOURCE":                        "URL or file path of a CSV document of zone tags",
+	"ZONE_TAG_SYNC_INTERVAL_SECONDS":         "How often zones are re-tagged",
+	"DOMAIN_PERMISSION_CACHE_SECONDS":        "How long the zone list that domain permissions are matched against is cached",
+	"TOKENS_CONFIG_FILE":                     "JSON file of tokens created and updated at startup",
+	"DATABASE_ENCRYPTION_KEY":                "32-byte key, hex or base64 encoded, that encrypts secrets such as webhook signing secrets in the database",
+	"DATABASE_ENCRYPTION_KEY_FILE":           "File containing `DATABASE_ENCRYPTION_KEY`, such as a Docker or Kubernetes secret",
+	"TOKEN_HASH_MEMORY_KIB":                  "Argon2id memory cost, in KiB, of the digests token keys are stored as",
+	"TOKEN_HASH_ITERATIONS":                  "Argon2id iterations of the digests token keys are stored as (0 stores plain SHA-256 hashes)",
+	"TOKEN_HASH_PARALLELISM":                 "Argon2id parallelism of the digests token keys are stored as",
+	"HTTP_READ_TIMEOUT_SECONDS":              "Longest time to read a request, including its body (0 disables the timeout)",
+	"HTTP_READ_HEADER_TIMEOUT_SECONDS":       "Longest time to read request headers (0 uses `HTTP_READ_TIMEOUT_SECONDS`)",
+	"HTTP_WRITE_TIMEOUT_SECONDS":             "Longest time from reading request headers to finishing the response (0 disables the timeout)",
+	"HTTP_IDLE_TIMEOUT_SECONDS":              "How long an idle keep-alive connection is kept open (0 uses `HTTP_READ_TIMEOUT_SECONDS`)",
+	"MAX_REQUEST_BODY_BYTES":                 "Largest request body accepted on proxied routes",
+	"IMPORT_MAX_BODY_BYTES":                  "Largest zone file accepted by `POST /dnszone/{zoneID}/import`, instead of `MAX_REQUEST_BODY_BYTES`",
+	"IMPORT_TIMEOUT_SECONDS":                 "Read and write timeout of `POST /dnszone/{zoneID}/import`, replacing the server timeouts (0 keeps them)",
+	"RECORD_MAX_TXT_LENGTH":                  "Longest TXT record value accepted, in bytes",
+	"IMPORT_MAX_RECORDS":                     "Most records one import may create, and changes one sync may apply",
+	"IMPORT_MAX_ASYNC_RECORDS":               "Most records one import with `async=true` may create",
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SecretProvider fetches a secret kept outside the configuration, such as
+// the bunny.net API key in Vault, so it can be rotated while the proxy runs.
+type SecretProvider interface {
+	// Fetch returns the current value of the secret.
+	Fetch(ctx context.Context) (string, error)
+}
+
+// WatchSecret fetches the secret called name from provider on the interval
+// until ctx is cancelled, and calls apply with the new value whenever it
+// differs from current. A failed fetch is logged and the value in use is
+// kept, so an unreachable secret store does not interrupt proxying.
+func WatchSecret(ctx context.Context, name string, provider SecretProvider, current string, interval time.Duration,
+	apply func(string), logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		value, err := provider.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("failed to fetch secret; keeping the current value", "secret", name, "error", err)
+			}
+			continue
+		}
+		if value == "" || value == current {
+			continue
+		}
+		apply(value)
+		current = value
+		logger.Info("secret rotated", "secret", name)
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// vaultRequestTimeout bounds each request to Vault.
+const vaultRequestTimeout = 10 * time.Second
+
+// errVaultForbidden is returned when Vault refuses the token, such as after
+// it expired or was revoked.
+var errVaultForbidden = errors.New("vault refused the token")
+
+// VaultOptions configures a VaultProvider.
+type VaultOptions struct {
+	// Addr is the Vault server, such as https://vault.example.com:8200.
+	Addr string
+
+	// Token authenticates with a Vault token. Otherwise RoleID and
+	// SecretID log in with AppRole.
+	Token    string
+	RoleID   string
+	SecretID string
+
+	// Path is the API path of the secret below /v1, such as
+	// secret/data/bunny for a KV version 2 engine mounted at secret.
+	Path string
+	// Field is the key of the secret within the Vault secret.
+	Field string
+
+	// HTTPClient sends the requests; nil uses a client with a timeout.
+	HTTPClient *http.Client
+}
+
+// VaultProvider reads a secret from a HashiCorp Vault KV engine. Its token is
+// renewed once half of its TTL has passed; AppRole logins are repeated when
+// the token can no longer be renewed.
+type VaultProvider struct {
+	opts VaultOptions
+	now  func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	renewable bool
+	renewAt   time.Time // zero: the token does not expire
+}
+
+// NewVaultProvider creates a provider for one secret field.
+func NewVaultProvider(opts VaultOptions) *VaultProvider {
+	opts.Addr = strings.TrimSuffix(opts.Addr, "/")
+	opts.Path = strings.Trim(opts.Path, "/")
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: vaultRequestTimeout}
+	}
+	return &VaultProvider{opts: opts, now: time.Now}
+}
+
+// vaultAuth is the token part of a login or renewal response.
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// Fetch reads the secret field, logging in or renewing the token first when
+// it is due.
+func (v *VaultProvider) Fetch(ctx context.Context) (string, error) {
+	token, err := v.currentToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	value, err := v.read(ctx, token)
+	if errors.Is(err, errVaultForbidden) && v.opts.RoleID != "" {
+		// The token was revoked early; an AppRole login gets a new one
+		v.mu.Lock()
+		v.token = ""
+		v.mu.Unlock()
+		if token, err = v.currentToken(ctx); err != nil {
+			return "", err
+		}
+		value, err = v.read(ctx, token)
+	}
+	return value, err
+}
+
+// currentToken returns a usable token, logging in or renewing as needed.
+func (v *VaultProvider) currentToken(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.token != "" && (v.renewAt.IsZero() || v.now().Before(v.renewAt)) {
+		return v.token, nil
+	}
+	if v.token != "" && v.renewable {
+		var auth struct {
+			Auth vaultAuth `json:"auth"`
+		}
+		err := v.do(ctx, http.MethodPost, "auth/token/renew-self", v.token, nil, &auth)
+		if err == nil {
+			v.setToken(v.token, auth.Auth.LeaseDuration, auth.Auth.Renewable)
+			return v.token, nil
+		}
+		if v.opts.RoleID == "" {
+			return "", fmt.Errorf("failed to renew vault token: %w", err)
+		}
+	}
+
+	if v.opts.RoleID == "" {
+		var lookup struct {
+			Data struct {
+				TTL       int64 `json:"ttl"`
+				Renewable bool  `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := v.do(ctx, http.MethodGet, "auth/token/lookup-self", v.opts.Token, nil, &lookup); err != nil {
+			return "", fmt.Errorf("failed to look up vault token: %w", err)
+		}
+		v.setToken(v.opts.Token, lookup.Data.TTL, lookup.Data.Renewable)
+		return v.token, nil
+	}
+
+	var login struct {
+		Auth vaultAuth `json:"auth"`
+	}
+	body := map[string]string{"role_id": v.opts.RoleID, "secret_id": v.opts.SecretID}
+	if err := v.do(ctx, http.MethodPost, "auth/approle/login", "", body, &login); err != nil {
+		return "", fmt.Errorf("vault approle login failed: %w", err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", errors.New("vault approle login returned no token")
+	}
+	v.setToken(login.Auth.ClientToken, login.Auth.LeaseDuration, login.Auth.Renewable)
+	return v.token, nil
+}
+
+// setToken records a token and when it is next due for renewal. AppRole
+// tokens that cannot be renewed are replaced by a new login at that time.
+func (v *VaultProvider) setToken(token string, ttlSeconds int64, renewable bool) {
+	v.token, v.renewable = token, renewable
+	v.renewAt = time.Time{}
+	if ttlSeconds > 0 && (renewable || v.opts.RoleID != "") {
+		v.renewAt = v.now().Add(time.Duration(ttlSeconds) * time.Second / 2)
+	}
+}
+
+// read reads the secret field with token. Both KV version 1 and version 2
+// responses are understood.
+func (v *VaultProvider) read(ctx context.Context, token string) (string, error) {
+	var secret struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := v.do(ctx, http.MethodGet, v.opts.Path, token, nil, &secret); err != nil {
+		return "", fmt.Errorf("failed to read vault secret %s: %w", v.opts.Path, err)
+	}
+	fields := secret.Data
+	if raw, ok := fields["data"]; ok && fields["metadata"] != nil {
+		// KV version 2 nests the fields beside the version metadata
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return "", fmt.Errorf("failed to read vault secret %s: %w", v.opts.Path, err)
+		}
+	}
+	var value string
+	if err := json.Unmarshal(fields[v.opts.Field], &value); err != nil || value == "" {
+		return "", fmt.Errorf("vault secret %s has no string field %q", v.opts.Path, v.opts.Field)
+	}
+	return value, nil
+}
+
+// do sends one request to the Vault API and decodes the JSON response into out.
+func (v *VaultProvider) do(ctx context.Context, method, path, token string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, v.opts.Addr+"/v1/"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return errVaultForbidden
+	case resp.StatusCode != http.StatusOK:
+		// Vault error bodies never contain the secret or the token
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&vaultErr) //nolint:errcheck
+		return fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVault serves AppRole logins, token lookups and renewals, and one KV
+// version 2 secret.
+type fakeVault struct {
+	mu       sync.Mutex
+	key      string
+	tokens   map[string]bool
+	logins   int
+	renewals int
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token := r.Header.Get("X-Vault-Token")
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["secret_id"] != "secret-id" {
+			http.Error(w, `{"errors":["invalid secret id"]}`, http.StatusBadRequest)
+			return
+		}
+		f.logins++
+		token := fmt.Sprintf("approle-token-%d", f.logins)
+		f.tokens[token] = true
+		writeJSON(w, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 60, "renewable": true}})
+		return
+	case "/v1/auth/token/lookup-self":
+		if !f.tokens[token] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		writeJSON(w, map[string]any{"data": map[string]any{"ttl": 0, "renewable": false}})
+		return
+	case "/v1/auth/token/renew-self":
+		if !f.tokens[token] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		f.renewals++
+		writeJSON(w, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 60, "renewable": true}})
+		return
+	case "/v1/secret/data/bunny":
+		if !f.tokens[token] {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		writeJSON(w, map[string]any{"data": map[string]any{
+			"data":     map[string]any{"api_key": f.key},
+			"metadata": map[string]any{"version": 3},
+		}})
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// counts returns the number of logins and renewals so far.
+func (f *fakeVault) counts() (logins, renewals int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins, f.renewals
+}
+
+// update replaces the secret and, if tokens is not nil, the valid tokens.
+func (f *fakeVault) update(key string, tokens map[string]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.key = key
+	if tokens != nil {
+		f.tokens = tokens
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v) //nolint:errcheck
+}
+
+func TestVaultProvider_Token(t *testing.T) {
+	t.Parallel()
+	vault := &fakeVault{key: "bunny-key", tokens: map[string]bool{"static-token": true}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	p := NewVaultProvider(VaultOptions{Addr: server.URL + "/", Token: "static-token", Path: "secret/data/bunny", Field: "api_key"})
+	got, err := p.Fetch(context.Background())
+	if err != nil || got != "bunny-key" {
+		t.Fatalf("Fetch() = %q, %v", got, err)
+	}
+
+	p = NewVaultProvider(VaultOptions{Addr: server.URL, Token: "static-token", Path: "secret/data/bunny", Field: "missing"})
+	if _, err := p.Fetch(context.Background()); err == nil {
+		t.Error("expected an error for a missing field")
+	}
+	p = NewVaultProvider(VaultOptions{Addr: server.URL, Token: "revoked", Path: "secret/data/bunny", Field: "api_key"})
+	if _, err := p.Fetch(context.Background()); err == nil {
+		t.Error("expected an error for a refused token")
+	}
+}
+
+func TestVaultProvider_AppRole(t *testing.T) {
+	t.Parallel()
+	vault := &fakeVault{key: "bunny-key", tokens: map[string]bool{}}
+	server := httptest.NewServer(vault)
+	defer server.Close()
+
+	now := time.Now()
+	p := NewVaultProvider(VaultOptions{Addr: server.URL, RoleID: "proxy", SecretID: "secret-id", Path: "secret/data/bunny", Field: "api_key"})
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if got, err := p.Fetch(ctx); err != nil || got != "bunny-key" {
+		t.Fatalf("Fetch() = %q, %v", got, err)
+	}
+	if logins, renewals := vault.counts(); logins != 1 || renewals != 0 {
+		t.Fatalf("expected one login, got %d logins and %d renewals", logins, renewals)
+	}
+
+	// Past half of the 60 second TTL the token is renewed
+	now = now.Add(31 * time.Second)
+	vault.update("rotated-key", nil)
+	if got, err := p.Fetch(ctx); err != nil || got != "rotated-key" {
+		t.Fatalf("Fetch() = %q, %v", got, err)
+	}
+	if logins, renewals := vault.counts(); logins != 1 || renewals != 1 {
+		t.Errorf("expected a renewal, got %d logins and %d renewals", logins, renewals)
+	}
+
+	// A revoked token is replaced by a new login
+	vault.update("rotated-key", map[string]bool{})
+	if got, err := p.Fetch(ctx); err != nil || got != "rotated-key" {
+		t.Fatalf("Fetch() = %q, %v", got, err)
+	}
+	if logins, _ := vault.counts(); logins != 2 {
+		t.Errorf("expected a second login, got %d", logins)
+	}
+}
+
+// sequenceProvider returns its values in turn, then repeats the last one.
+type sequenceProvider struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (p *sequenceProvider) Fetch(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	v := p.values[0]
+	if len(p.values) > 1 {
+		p.values = p.values[1:]
+	}
+	return v, nil
+}
+
+func TestWatchSecret(t *testing.T) {
+	t.Parallel()
+	provider := &sequenceProvider{values: []string{"old-key", "", "new-key"}}
+	applied := make(chan string, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		WatchSecret(ctx, "BUNNY_API_KEY", provider, "old-key", time.Millisecond, func(v string) { applied <- v }, nil)
+		close(done)
+	}()
+
+	select {
+	case got := <-applied:
+		if got != "new-key" {
+			t.Errorf("applied %q, want new-key", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("rotated secret was not applied")
+	}
+	cancel()
+	<-done
+	if len(applied) != 0 {
+		t.Errorf("unchanged values were applied: %d", len(applied))
+	}
+}
@@ -0,0 +1,280 @@
+// Package diskguard switches the proxy to a read-only degraded mode when the
+// database volume runs out of space, instead of letting every write fail.
+//
+// The guard enters degraded mode when free space on the volume drops below a
+// minimum, when the database reaches its soft size limit, or as soon as a
+// write is reported to have failed because the disk is full. While degraded,
+// Middleware rejects mutating requests with 503 Service Unavailable; reads keep
+// working. Run leaves degraded mode once space is available again.
+package diskguard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+const (
+	// interval is how often free space and the database size are checked.
+	interval = 30 * time.Second
+
+	// recoveryMargin is the free space required to leave degraded mode after
+	// a disk-full write failure when no minimum is configured.
+	recoveryMargin = 16 << 20
+
+	// retryAfterSeconds is sent with rejected writes.
+	retryAfterSeconds = "60"
+
+	// alertStorageDegraded is the alert name for entering degraded mode.
+	alertStorageDegraded = "storage_degraded"
+)
+
+// Reasons for degraded mode.
+const (
+	// ReasonDiskFull means a write failed because the volume is full.
+	ReasonDiskFull = "disk_full"
+	// ReasonLowDiskSpace means free space is below the configured minimum.
+	ReasonLowDiskSpace = "low_disk_space"
+	// ReasonDBSizeLimit means the database reached its soft size limit.
+	ReasonDBSizeLimit = "db_size_limit"
+)
+
+// errUnsupported is returned by freeSpace on platforms without statfs.
+var errUnsupported = errors.New("free space not available on this platform")
+
+// Store defines the storage operations needed by the guard.
+type Store interface {
+	DBStats(ctx context.Context) (*storage.DBStats, error)
+}
+
+// Alerter delivers operational alerts. It is satisfied by *outbox.Outbox.
+type Alerter interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// Option configures a Guard.
+type Option func(*Guard)
+
+// WithMinFreeSpace enters degraded mode when free space on the database
+// volume drops below bytes. Zero disables the check.
+func WithMinFreeSpace(bytes int64) Option {
+	return func(g *Guard) {
+		g.minFree = bytes
+	}
+}
+
+// WithDBSizeLimit enters degraded mode when the database reaches bytes.
+// Zero disables the limit.
+func WithDBSizeLimit(bytes int64) Option {
+	return func(g *Guard) {
+		g.dbLimit = bytes
+	}
+}
+
+// WithAlerter publishes a system alert when the guard enters degraded mode.
+// The alert is best effort: it is stored in the outbox, which may itself
+// fail to write when the disk is full.
+func WithAlerter(alerter Alerter) Option {
+	return func(g *Guard) {
+		g.alerter = alerter
+	}
+}
+
+// Guard tracks whether storage is healthy enough to accept writes.
+type Guard struct {
+	store   Store
+	dir     string
+	logger  *slog.Logger
+	minFree int64
+	dbLimit int64
+	alerter Alerter
+
+	// freeSpace reports the bytes available in a directory; replaced in tests.
+	freeSpace func(dir string) (int64, error)
+	now       func() time.Time
+
+	mu     sync.Mutex
+	reason string
+	since  time.Time
+}
+
+// New creates a guard for a database stored in dir. An empty dir (an
+// in-memory database) disables the free space check.
+func New(store Store, dir string, logger *slog.Logger, opts ...Option) *Guard {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	g := &Guard{
+		store:     store,
+		dir:       dir,
+		logger:    logger,
+		freeSpace: freeSpace,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Degraded reports whether the proxy is in read-only degraded mode.
+func (g *Guard) Degraded() bool {
+	return g.Reason() != ""
+}
+
+// Reason returns why the proxy is degraded, or "" when it is not.
+func (g *Guard) Reason() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.reason
+}
+
+// ReportError enters degraded mode if err is a disk-full write failure.
+// Other errors are ignored, so callers can pass every write error.
+func (g *Guard) ReportError(err error) {
+	if err == nil || !storage.IsDiskFull(err) {
+		return
+	}
+	g.setReason(context.Background(), ReasonDiskFull)
+}
+
+// Check returns an error while degraded, for use as a readiness check.
+func (g *Guard) Check(context.Context) error {
+	if reason := g.Reason(); reason != "" {
+		return fmt.Errorf("read-only: %s", reason)
+	}
+	return nil
+}
+
+// Run checks storage until ctx is cancelled.
+func (g *Guard) Run(ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		g.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check samples free space and the database size, entering degraded mode
+// when either is over its limit and leaving it once both are back in range.
+func (g *Guard) check(ctx context.Context) {
+	free, freeKnown := int64(0), false
+	if g.dir != "" {
+		n, err := g.freeSpace(g.dir)
+		switch {
+		case err == nil:
+			free, freeKnown = n, true
+			metrics.RecordDiskFree(free)
+		case !errors.Is(err, errUnsupported):
+			g.logger.Error("failed to read free disk space", "error", err)
+		}
+	}
+
+	reason := ""
+	if freeKnown && g.minFree > 0 && free < g.minFree {
+		reason = ReasonLowDiskSpace
+	}
+	if reason == "" && g.dbLimit > 0 {
+		stats, err := g.store.DBStats(ctx)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				g.logger.Error("failed to read database stats", "error", err)
+			}
+		case stats.FileSize >= g.dbLimit:
+			reason = ReasonDBSizeLimit
+		}
+	}
+
+	// A disk-full failure only clears once there is room again. Without a
+	// free space reading, the next check clears it; another failure
+	// re-enters degraded mode straight away.
+	if reason == "" && g.Reason() == ReasonDiskFull && freeKnown && free < max(g.minFree, recoveryMargin) {
+		return
+	}
+	g.setReason(ctx, reason)
+}
+
+// setReason records the degraded state, logging and alerting on changes.
+func (g *Guard) setReason(ctx context.Context, reason string) {
+	g.mu.Lock()
+	prev := g.reason
+	if reason == prev {
+		g.mu.Unlock()
+		return
+	}
+	g.reason = reason
+	var degradedFor time.Duration
+	if reason != "" && prev == "" {
+		g.since = g.now()
+	} else if reason == "" {
+		degradedFor = g.now().Sub(g.since)
+	}
+	g.mu.Unlock()
+
+	metrics.RecordStorageDegraded(reason != "")
+	if reason == "" {
+		g.logger.Info("storage recovered, accepting writes again", "previous_reason", prev, "degraded_for", degradedFor.String())
+		return
+	}
+	g.logger.Error("storage degraded, switching to read-only mode", "reason", reason)
+	if prev != "" || g.alerter == nil {
+		return
+	}
+	err := g.alerter.Publish(ctx, webhook.EventSystemAlert, map[string]any{
+		"alert":   alertStorageDegraded,
+		"message": "storage degraded, the proxy is read-only",
+		"reason":  reason,
+	})
+	if err != nil {
+		g.logger.Error("failed to publish storage degraded alert", "error", err)
+	}
+}
+
+// Middleware rejects mutating requests with 503 while degraded. GET, HEAD
+// and OPTIONS requests always pass. When allowDelete is set, DELETE requests
+// also pass, so operators can remove data to free space.
+func (g *Guard) Middleware(allowDelete bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reason := g.Reason()
+			if reason == "" || readOnlyMethod(r.Method) || (allowDelete && r.Method == http.MethodDelete) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			metrics.RecordStorageDegradedRejection()
+			g.logger.Warn("rejected write while storage is degraded", "reason", reason, "method", r.Method, "path", r.URL.Path)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", retryAfterSeconds)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			//nolint:errcheck
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"error":   "storage_degraded",
+				"message": "The proxy is read-only because its storage is full (" + reason + "). Retry later.",
+			})
+		})
+	}
+}
+
+// readOnlyMethod reports whether a request method never writes.
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,195 @@
+package diskguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type recordingAlerter struct {
+	events []map[string]any
+}
+
+func (a *recordingAlerter) Publish(_ context.Context, event string, data any) error {
+	if event != webhook.EventSystemAlert {
+		return errors.New("unexpected event " + event)
+	}
+	a.events = append(a.events, data.(map[string]any))
+	return nil
+}
+
+// newTestGuard returns a guard whose free space and database size are read
+// from the returned pointers.
+func newTestGuard(opts ...Option) (*Guard, *int64, *int64) {
+	free, size := int64(1<<30), int64(1<<20)
+	store := &mockstore.MockStorage{DBStatsFunc: func(context.Context) (*storage.DBStats, error) {
+		return &storage.DBStats{FileSize: size}, nil
+	}}
+	g := New(store, "/data", testLogger(), opts...)
+	g.freeSpace = func(string) (int64, error) { return free, nil }
+	return g, &free, &size
+}
+
+func TestCheck_LowDiskSpace(t *testing.T) {
+	t.Parallel()
+
+	alerter := &recordingAlerter{}
+	g, free, _ := newTestGuard(WithMinFreeSpace(64<<20), WithAlerter(alerter))
+	ctx := context.Background()
+
+	g.check(ctx)
+	if g.Degraded() {
+		t.Fatal("expected healthy guard with plenty of free space")
+	}
+
+	*free = 1 << 20
+	g.check(ctx)
+	g.check(ctx)
+	if g.Reason() != ReasonLowDiskSpace {
+		t.Fatalf("Reason() = %q, want %q", g.Reason(), ReasonLowDiskSpace)
+	}
+	if len(alerter.events) != 1 {
+		t.Fatalf("expected a single alert, got %d", len(alerter.events))
+	}
+	if e := alerter.events[0]; e["alert"] != alertStorageDegraded || e["reason"] != ReasonLowDiskSpace {
+		t.Errorf("unexpected alert data: %v", e)
+	}
+
+	*free = 1 << 30
+	g.check(ctx)
+	if g.Degraded() {
+		t.Error("expected guard to recover once space is free again")
+	}
+}
+
+func TestCheck_DBSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	g, _, size := newTestGuard(WithDBSizeLimit(8 << 20))
+	ctx := context.Background()
+
+	g.check(ctx)
+	if g.Degraded() {
+		t.Fatal("expected healthy guard below the size limit")
+	}
+
+	*size = 8 << 20
+	g.check(ctx)
+	if g.Reason() != ReasonDBSizeLimit {
+		t.Fatalf("Reason() = %q, want %q", g.Reason(), ReasonDBSizeLimit)
+	}
+
+	*size = 4 << 20
+	g.check(ctx)
+	if g.Degraded() {
+		t.Error("expected guard to recover once the database shrank")
+	}
+}
+
+func TestReportError_DiskFull(t *testing.T) {
+	t.Parallel()
+
+	g, free, _ := newTestGuard()
+	ctx := context.Background()
+
+	g.ReportError(nil)
+	g.ReportError(errors.New("constraint failed"))
+	if g.Degraded() {
+		t.Fatal("expected unrelated errors to be ignored")
+	}
+
+	g.ReportError(fmt.Errorf("append audit entry: %w", syscall.ENOSPC))
+	if g.Reason() != ReasonDiskFull {
+		t.Fatalf("Reason() = %q, want %q", g.Reason(), ReasonDiskFull)
+	}
+	if err := g.Check(ctx); err == nil {
+		t.Error("Check() = nil while degraded, want error")
+	}
+
+	// Still too little room to leave degraded mode
+	*free = 1 << 20
+	g.check(ctx)
+	if g.Reason() != ReasonDiskFull {
+		t.Fatalf("Reason() = %q after check with little space, want %q", g.Reason(), ReasonDiskFull)
+	}
+
+	*free = 1 << 30
+	g.check(ctx)
+	if g.Degraded() {
+		t.Error("expected guard to recover once space is free again")
+	}
+	if err := g.Check(ctx); err != nil {
+		t.Errorf("Check() = %v after recovery, want nil", err)
+	}
+}
+
+func TestCheck_NoDirectory(t *testing.T) {
+	t.Parallel()
+
+	g := New(&mockstore.MockStorage{}, "", testLogger(), WithMinFreeSpace(1<<40))
+	g.freeSpace = func(string) (int64, error) {
+		t.Error("free space must not be read without a directory")
+		return 0, nil
+	}
+	g.check(context.Background())
+	if g.Degraded() {
+		t.Error("expected in-memory database to never be low on space")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		degraded    bool
+		allowDelete bool
+		method      string
+		want        int
+	}{
+		{"healthy write", false, false, http.MethodPost, http.StatusNoContent},
+		{"degraded read", true, false, http.MethodGet, http.StatusNoContent},
+		{"degraded head", true, false, http.MethodHead, http.StatusNoContent},
+		{"degraded write", true, false, http.MethodPost, http.StatusServiceUnavailable},
+		{"degraded delete", true, false, http.MethodDelete, http.StatusServiceUnavailable},
+		{"degraded delete allowed", true, true, http.MethodDelete, http.StatusNoContent},
+		{"degraded update with delete allowed", true, true, http.MethodPut, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			g, _, _ := newTestGuard()
+			if tt.degraded {
+				g.ReportError(syscall.ENOSPC)
+			}
+			next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			rec := httptest.NewRecorder()
+			g.Middleware(tt.allowDelete)(next).ServeHTTP(rec, httptest.NewRequest(tt.method, "/dnszone/1/records", nil))
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+				t.Error("expected Retry-After header on rejected write")
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+
+package diskguard
+
+// freeSpace is not implemented on this platform; only the database size
+// limit and disk-full write failures are detected.
+func freeSpace(string) (int64, error) {
+	return 0, errUnsupported
+}
@@ -0,0 +1,107 @@
+// Package docs assembles the operator documentation served at /admin/docs.
+//
+// A hand-written guide is embedded in the binary and followed by reference
+// sections generated from the configuration settings and the routers, so the
+// documentation always describes the running version.
+package docs
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/sipico/bunny-api-proxy/internal/config"
+)
+
+//go:embed operator.md
+var guide string
+
+// Route is one documented API route.
+type Route struct {
+	Method      string
+	Path        string
+	Access      string // empty if not known
+	Description string // empty if not known
+}
+
+// Page is the operator documentation for one running proxy.
+type Page struct {
+	Version     string
+	AdminRoutes []Route
+	ProxyRoutes []Route
+}
+
+// Markdown returns the documentation as Markdown: the embedded guide, then
+// the configuration and API references.
+func (p Page) Markdown() string {
+	var b strings.Builder
+	b.WriteString(guide)
+	if p.Version != "" {
+		fmt.Fprintf(&b, "\nThis documentation describes version `%s`.\n", p.Version)
+	}
+
+	b.WriteString("\n## Configuration Reference\n\n")
+	b.WriteString("Every setting is an environment variable. Defaults are shown as they would be written in the environment; `GET /admin/api/config` shows the values in effect.\n\n")
+	b.WriteString("| Variable | Default | Description |\n|---|---|---|\n")
+	for _, s := range config.Defaults().Describe() {
+		def := "-"
+		if s.Default != "" {
+			def = "`" + s.Default + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", s.Name, def, tableCell(s.Description))
+	}
+
+	b.WriteString("\n## Admin API Reference\n\n")
+	b.WriteString("Routes under `/admin/api` need an admin token unless noted in the guide above; `/admin/health`, `/admin/ready` and `/admin/docs` are public.\n\n")
+	writeRoutes(&b, p.AdminRoutes, false)
+
+	b.WriteString("\n## Proxy API Reference\n\n")
+	b.WriteString("Access is the weakest token that can call a route: `any` valid token, a token with a permission for the `zone` granting the action, or an `admin` token.\n\n")
+	writeRoutes(&b, p.ProxyRoutes, true)
+
+	return b.String()
+}
+
+// HTML returns the documentation as a standalone HTML document.
+func (p Page) HTML() string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>Bunny API Proxy %s</title>\n", html.EscapeString(p.Version))
+	b.WriteString("<style>" + stylesheet + "</style>\n</head>\n<body>\n")
+	b.WriteString(Render(p.Markdown()))
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// writeRoutes writes a route table, with access and description columns
+// when detailed.
+func writeRoutes(b *strings.Builder, routes []Route, detailed bool) {
+	if len(routes) == 0 {
+		b.WriteString("No routes are enabled.\n")
+		return
+	}
+	if detailed {
+		b.WriteString("| Method | Path | Access | Description |\n|---|---|---|---|\n")
+	} else {
+		b.WriteString("| Method | Path |\n|---|---|\n")
+	}
+	for _, r := range routes {
+		if detailed {
+			fmt.Fprintf(b, "| %s | `%s` | %s | %s |\n", r.Method, r.Path, r.Access, tableCell(r.Description))
+		} else {
+			fmt.Fprintf(b, "| %s | `%s` |\n", r.Method, r.Path)
+		}
+	}
+}
+
+// tableCell keeps text on one table row.
+func tableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// stylesheet keeps the page readable without external assets.
+const stylesheet = `body{font-family:system-ui,sans-serif;max-width:60rem;margin:2rem auto;padding:0 1rem;line-height:1.5;color:#222}` +
+	`code,pre{font-family:ui-monospace,monospace;background:#f4f4f4;border-radius:3px}code{padding:0 .2em}pre{padding:.75rem;overflow-x:auto}` +
+	`table{border-collapse:collapse;width:100%;font-size:.9rem}th,td{border:1px solid #ddd;padding:.3rem .5rem;text-align:left;vertical-align:top}` +
+	`th{background:#f4f4f4}h2{border-bottom:1px solid #ddd;margin-top:2rem}`
@@ -0,0 +1,44 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPage_Markdown(t *testing.T) {
+	t.Parallel()
+	page := Page{
+		Version:     "2026.01.2",
+		AdminRoutes: []Route{{Method: "GET", Path: "/admin/api/tokens"}},
+		ProxyRoutes: []Route{{Method: "GET", Path: "/dnszone", Access: "any", Description: "List zones"}},
+	}
+	md := page.Markdown()
+
+	for _, want := range []string{
+		"# Bunny API Proxy Operator Guide",
+		"version `2026.01.2`",
+		"| `LISTEN_ADDR` | `:8080` | Address and port to listen on |",
+		"| `BUNNY_API_KEY` | - |",
+		"| GET | `/admin/api/tokens` |",
+		"| GET | `/dnszone` | any | List zones |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q", want)
+		}
+	}
+}
+
+func TestPage_HTML(t *testing.T) {
+	t.Parallel()
+	doc := Page{Version: "<dev>"}.HTML()
+
+	if !strings.HasPrefix(doc, "<!DOCTYPE html>") || !strings.Contains(doc, "<title>Bunny API Proxy &lt;dev&gt;</title>") {
+		t.Errorf("unexpected document head: %.200s", doc)
+	}
+	if !strings.Contains(doc, `<h2 id="configuration-reference">`) {
+		t.Error("expected the configuration reference to be rendered")
+	}
+	if !strings.Contains(doc, "No routes are enabled.") {
+		t.Error("expected empty route tables to be noted")
+	}
+}
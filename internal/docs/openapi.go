@@ -0,0 +1,167 @@
+package docs
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// openAPIDocument is the subset of an OpenAPI 3 document the proxy describes.
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Tags       []openAPITag                            `json:"tags"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+type openAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type openAPIOperation struct {
+	Tags        []string                   `json:"tags"`
+	Summary     string                     `json:"summary,omitempty"`
+	OperationID string                     `json:"operationId"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	// Access is the weakest token that can call a proxy route.
+	Access string `json:"x-access,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required"`
+	Schema   openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Type string `json:"type"`
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+type openAPIComponents struct {
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in"`
+	Name string `json:"name"`
+}
+
+// securityScheme names the AccessKey header scheme in the document.
+const securityScheme = "AccessKey"
+
+var (
+	// pathParam matches a chi path parameter, with an optional pattern.
+	pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+	// nonOperationID matches characters left out of operation IDs.
+	nonOperationID = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// OpenAPI returns an OpenAPI 3 description of the admin and proxy routes as
+// JSON. Every route is listed with its path parameters and whether it needs
+// a token; request and response bodies are described in docs/API.md.
+func (p Page) OpenAPI() ([]byte, error) {
+	version := p.Version
+	if version == "" {
+		version = "dev"
+	}
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "Bunny API Proxy",
+			Version:     version,
+			Description: "Scoped access to the bunny.net API. Send a token in the `AccessKey` header.",
+		},
+		Tags: []openAPITag{
+			{Name: "admin", Description: "Token, permission and proxy management"},
+			{Name: "proxy", Description: "bunny.net API routes, checked against the token's permissions"},
+		},
+		Paths: make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{SecuritySchemes: map[string]openAPISecurityScheme{
+			securityScheme: {Type: "apiKey", In: "header", Name: "AccessKey"},
+		}},
+		Security: []map[string][]string{{securityScheme: {}}},
+	}
+	for _, r := range p.AdminRoutes {
+		op := addOperation(doc.Paths, r, "admin")
+		// Only /admin/api needs a token
+		if op != nil && !strings.HasPrefix(r.Path, "/admin/api/") {
+			op.Security = []map[string][]string{{}}
+		}
+	}
+	for _, r := range p.ProxyRoutes {
+		if op := addOperation(doc.Paths, r, "proxy"); op != nil {
+			op.Access = r.Access
+		}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// addOperation adds the operation for a route to paths and returns it, or nil
+// for routes OpenAPI cannot describe.
+func addOperation(paths map[string]map[string]*openAPIOperation, r Route, tag string) *openAPIOperation {
+	method := strings.ToLower(r.Method)
+	// Catch-all routes from mounted routers have no OpenAPI form
+	if strings.Contains(r.Path, "*") {
+		return nil
+	}
+
+	op := &openAPIOperation{
+		Tags:        []string{tag},
+		Summary:     r.Description,
+		OperationID: method + strings.TrimRight(nonOperationID.ReplaceAllString(r.Path, "_"), "_"),
+		Responses: map[string]openAPIResponse{
+			"default": {Description: "See docs/API.md for the response of this route"},
+		},
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(r.Path, -1) {
+		op.Parameters = append(op.Parameters, openAPIParameter{Name: m[1], In: "path", Required: true, Schema: openAPISchema{Type: "string"}})
+	}
+
+	path := pathParam.ReplaceAllString(r.Path, "{$1}")
+	if paths[path] == nil {
+		paths[path] = make(map[string]*openAPIOperation)
+	}
+	paths[path][method] = op
+	return op
+}
+
+// swaggerUIAssets is where the Swagger UI page loads its script and styles
+// from; the proxy does not bundle them.
+const swaggerUIAssets = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14"
+
+// SwaggerUICSP is the Content-Security-Policy for the page from SwaggerUI.
+const SwaggerUICSP = "default-src 'none'; script-src 'unsafe-inline' https://cdn.jsdelivr.net; " +
+	"style-src 'unsafe-inline' https://cdn.jsdelivr.net; img-src data: https://cdn.jsdelivr.net; connect-src 'self'"
+
+// SwaggerUI returns an HTML page that renders the OpenAPI document at specURL
+// with Swagger UI.
+func SwaggerUI(title, specURL string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	b.WriteString("<link rel=\"stylesheet\" href=\"" + swaggerUIAssets + "/swagger-ui.css\">\n</head>\n<body>\n")
+	b.WriteString("<div id=\"swagger-ui\"></div>\n")
+	b.WriteString("<script src=\"" + swaggerUIAssets + "/swagger-ui-bundle.js\"></script>\n")
+	specJSON, _ := json.Marshal(specURL) //nolint:errcheck // marshaling a string cannot fail
+	b.WriteString("<script>SwaggerUIBundle({url: " + string(specJSON) + ", dom_id: \"#swagger-ui\"});</script>\n")
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
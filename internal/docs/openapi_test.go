@@ -0,0 +1,71 @@
+package docs
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPage_OpenAPI(t *testing.T) {
+	t.Parallel()
+	page := Page{
+		Version: "2026.01.2",
+		AdminRoutes: []Route{
+			{Method: "GET", Path: "/admin/health"},
+			{Method: "DELETE", Path: "/admin/api/tokens/{id}"},
+			{Method: "GET", Path: "/admin/*"},
+		},
+		ProxyRoutes: []Route{
+			{Method: "GET", Path: "/dnszone/{zoneID:[0-9]+}", Access: "zone", Description: "Get a zone"},
+		},
+	}
+	spec, err := page.OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() error: %v", err)
+	}
+
+	var doc openAPIDocument
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatalf("OpenAPI() is not valid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" || doc.Info.Version != "2026.01.2" {
+		t.Errorf("unexpected header: openapi %q, version %q", doc.OpenAPI, doc.Info.Version)
+	}
+	if len(doc.Paths) != 3 {
+		t.Errorf("expected 3 paths without the catch-all, got %d", len(doc.Paths))
+	}
+
+	if op := doc.Paths["/admin/health"]["get"]; op == nil || len(op.Security) != 1 || len(op.Security[0]) != 0 {
+		t.Errorf("/admin/health should not need a token: %+v", op)
+	}
+	del := doc.Paths["/admin/api/tokens/{id}"]["delete"]
+	if del == nil || del.Security != nil || del.OperationID != "delete_admin_api_tokens_id" {
+		t.Errorf("unexpected token delete operation: %+v", del)
+	}
+	if del != nil && (len(del.Parameters) != 1 || del.Parameters[0].Name != "id" || !del.Parameters[0].Required) {
+		t.Errorf("unexpected parameters: %+v", del.Parameters)
+	}
+
+	zone := doc.Paths["/dnszone/{zoneID}"]["get"]
+	if zone == nil || zone.Access != "zone" || zone.Summary != "Get a zone" || zone.Tags[0] != "proxy" {
+		t.Errorf("unexpected proxy operation: %+v", zone)
+	}
+	if !strings.Contains(string(spec), `"x-access": "zone"`) {
+		t.Error("expected the access level as x-access")
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	t.Parallel()
+	page := SwaggerUI("<API>", `spec.json"</script>`)
+
+	if !strings.Contains(page, "<title>&lt;API&gt;</title>") {
+		t.Error("expected the title to be escaped")
+	}
+	if strings.Contains(page, `spec.json"</script>`) {
+		t.Error("expected the spec URL to be escaped in the script")
+	}
+	if !strings.Contains(page, swaggerUIAssets+"/swagger-ui-bundle.js") {
+		t.Error("expected the Swagger UI bundle to be loaded")
+	}
+}
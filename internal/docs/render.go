@@ -0,0 +1,162 @@
+package docs
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Render converts the Markdown subset used by the documentation to HTML:
+// ATX headings, paragraphs, fenced code blocks, bullet and numbered lists,
+// pipe tables, and inline code, bold text and links.
+func Render(markdown string) string {
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case strings.HasPrefix(trimmed, "```"):
+			b.WriteString("<pre><code>")
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				b.WriteString(html.EscapeString(lines[i]) + "\n")
+			}
+			b.WriteString("</code></pre>\n")
+			i++ // closing fence
+
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level > 6 {
+				level = 6
+			}
+			text := strings.TrimSpace(trimmed[level:])
+			tag := "h" + string(rune('0'+level))
+			b.WriteString("<" + tag + " id=\"" + anchor(text) + "\">" + inline(text) + "</" + tag + ">\n")
+			i++
+
+		case strings.HasPrefix(trimmed, "|"):
+			var rows []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, strings.TrimSpace(lines[i]))
+			}
+			renderTable(&b, rows)
+
+		case isListItem(trimmed):
+			tag := "ul"
+			if orderedItem.MatchString(trimmed) {
+				tag = "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && isListItem(strings.TrimSpace(lines[i])); i++ {
+				b.WriteString("<li>" + inline(listItemText(strings.TrimSpace(lines[i]))) + "</li>\n")
+			}
+			b.WriteString("</" + tag + ">\n")
+
+		default:
+			var para []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if t == "" || strings.HasPrefix(t, "#") || strings.HasPrefix(t, "```") || strings.HasPrefix(t, "|") || isListItem(t) {
+					break
+				}
+				para = append(para, t)
+			}
+			b.WriteString("<p>" + inline(strings.Join(para, " ")) + "</p>\n")
+		}
+	}
+	return b.String()
+}
+
+var (
+	orderedItem = regexp.MustCompile(`^\d+\. `)
+	boldText    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	linkText    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	nonAnchor   = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// isListItem reports whether a line starts a bullet or numbered list item.
+func isListItem(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || orderedItem.MatchString(line)
+}
+
+// listItemText strips the list marker from a list item.
+func listItemText(line string) string {
+	if loc := orderedItem.FindStringIndex(line); loc != nil {
+		return line[loc[1]:]
+	}
+	return line[2:]
+}
+
+// renderTable writes a pipe table. The second row is the header separator.
+func renderTable(b *strings.Builder, rows []string) {
+	b.WriteString("<table>\n")
+	for i, row := range rows {
+		if i == 1 && strings.Trim(row, "|-: ") == "" {
+			continue
+		}
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+		}
+		b.WriteString("<tr>")
+		for _, c := range splitRow(row) {
+			b.WriteString("<" + cell + ">" + inline(c) + "</" + cell + ">")
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// splitRow splits a table row on unescaped pipes.
+func splitRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// inline escapes text and renders code spans, bold text and links. Text
+// inside code spans is left as is.
+func inline(text string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "`") {
+		escaped := html.EscapeString(part)
+		if i%2 == 1 {
+			b.WriteString("<code>" + escaped + "</code>")
+			continue
+		}
+		escaped = boldText.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = linkText.ReplaceAllStringFunc(escaped, func(m string) string {
+			sub := linkText.FindStringSubmatch(m)
+			href := sub[2]
+			if !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "https://") && !strings.HasPrefix(href, "/") {
+				return sub[1]
+			}
+			return "<a href=\"" + href + "\">" + sub[1] + "</a>"
+		})
+		b.WriteString(escaped)
+	}
+	return b.String()
+}
+
+// anchor returns the fragment identifier for a heading.
+func anchor(heading string) string {
+	return strings.Trim(nonAnchor.ReplaceAllString(strings.ToLower(heading), "-"), "-")
+}
@@ -0,0 +1,35 @@
+package docs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{"heading", "## Health and Status", `<h2 id="health-and-status">Health and Status</h2>`},
+		{"paragraph", "One line\nand the next.", "<p>One line and the next.</p>"},
+		{"inline", "Use **admin** tokens, `<id>` and [docs](https://docs.bunny.net).",
+			`<p>Use <strong>admin</strong> tokens, <code>&lt;id&gt;</code> and <a href="https://docs.bunny.net">docs</a>.</p>`},
+		{"unsafe link", "[click](javascript:void)", "<p>click</p>"},
+		{"code inside code span", "`**not bold**`", "<p><code>**not bold**</code></p>"},
+		{"code block", "```\n<script>\n```", "<pre><code>&lt;script&gt;\n</code></pre>"},
+		{"bullets", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>"},
+		{"numbered", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>"},
+		{"table", "| A | B |\n|---|---|\n| `x` | y \\| z |",
+			"<table>\n<tr><th>A</th><th>B</th></tr>\n<tr><td><code>x</code></td><td>y | z</td></tr>\n</table>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := strings.TrimSpace(Render(tt.markdown)); got != tt.want {
+				t.Errorf("Render(%q) =\n%s\nwant\n%s", tt.markdown, got, tt.want)
+			}
+		})
+	}
+}
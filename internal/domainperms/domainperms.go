@@ -0,0 +1,207 @@
+// Package domainperms resolves domain permissions, which grant a token a DNS
+// zone permission on every zone whose domain matches a pattern such as
+// "*.customer1.example.com". Resolve expands a token's domain permissions
+// into ordinary per-zone permissions when it authenticates, matching them
+// against the upstream zone list. The list is cached per account, so zones
+// added upstream are covered once the cache refreshes, without editing tokens.
+package domainperms
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// DefaultCacheTTL is how long a zone list is used before it is listed again.
+	DefaultCacheTTL = time.Minute
+
+	// zoneListPageSize is the page size used to list all zones.
+	zoneListPageSize = 1000
+)
+
+// patternRegexp matches a lowercase domain of at least two labels,
+// optionally preceded by "*.".
+var patternRegexp = regexp.MustCompile(`^(\*\.)?[a-z0-9_-]+(\.[a-z0-9_-]+)+$`)
+
+// NormalizePattern lowercases a pattern and drops a trailing dot, and
+// reports whether the result is valid: a domain such as "example.com", or
+// "*." followed by a domain, such as "*.customer1.example.com".
+func NormalizePattern(pattern string) (string, bool) {
+	pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+	return pattern, len(pattern) <= 253 && patternRegexp.MatchString(pattern)
+}
+
+// Match reports whether a zone's domain matches a normalized pattern. A plain
+// domain matches only itself; "*.example.com" matches every zone below
+// example.com, such as a.example.com and b.a.example.com, but not example.com.
+func Match(pattern, domain string) bool {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return len(domain) > len(suffix) && strings.HasSuffix(domain, suffix)
+	}
+	return domain == pattern
+}
+
+// Store lists the domain permissions of a token.
+type Store interface {
+	ListDomainPermissions(ctx context.Context, tokenID int64) ([]*storage.DomainPermission, error)
+}
+
+// ZoneLister lists upstream DNS zones. It is satisfied by *bunny.Client.
+type ZoneLister interface {
+	ListZones(ctx context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error)
+}
+
+// Option configures a Resolver.
+type Option func(*Resolver)
+
+// WithCacheTTL sets how long a zone list is cached.
+func WithCacheTTL(d time.Duration) Option {
+	return func(r *Resolver) {
+		if d > 0 {
+			r.ttl = d
+		}
+	}
+}
+
+// WithAccounts resolves the domain permissions of tokens assigned to a named
+// upstream account against that account's zones.
+func WithAccounts(accounts map[string]ZoneLister) Option {
+	return func(r *Resolver) {
+		r.accounts = accounts
+	}
+}
+
+// zone is the part of an upstream zone patterns are matched against.
+type zone struct {
+	id     int64
+	domain string
+}
+
+// zoneList is a cached zone list of one account.
+type zoneList struct {
+	zones    []zone
+	listedAt time.Time
+}
+
+// Resolver expands domain permissions into per-zone permissions.
+type Resolver struct {
+	store    Store
+	zones    ZoneLister
+	accounts map[string]ZoneLister
+	logger   *slog.Logger
+	ttl      time.Duration
+	now      func() time.Time
+
+	// mu guards cache and serializes listing, so concurrent requests wait
+	// for one listing instead of each listing the zones.
+	mu    sync.Mutex
+	cache map[string]*zoneList
+}
+
+// New creates a resolver that matches domain permissions against the zones
+// listed by zones, the default account.
+func New(store Store, zones ZoneLister, logger *slog.Logger, opts ...Option) *Resolver {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	r := &Resolver{
+		store:  store,
+		zones:  zones,
+		logger: logger,
+		ttl:    DefaultCacheTTL,
+		now:    time.Now,
+		cache:  make(map[string]*zoneList),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve returns a DNS zone permission for every zone in the token's account
+// matched by one of its domain permissions. It only lists zones for tokens
+// that have domain permissions.
+func (r *Resolver) Resolve(ctx context.Context, token *storage.Token) ([]*storage.Permission, error) {
+	domainPerms, err := r.store.ListDomainPermissions(ctx, token.ID)
+	if err != nil || len(domainPerms) == 0 {
+		return nil, err
+	}
+
+	zones := r.zoneList(ctx, token.Account)
+	var perms []*storage.Permission
+	for _, p := range domainPerms {
+		for _, z := range zones {
+			if Match(p.Pattern, z.domain) {
+				perms = append(perms, &storage.Permission{
+					TokenID:        token.ID,
+					Resource:       storage.ResourceDNSZone,
+					ZoneID:         z.id,
+					AllowedActions: p.AllowedActions,
+					RecordTypes:    p.RecordTypes,
+				})
+			}
+		}
+	}
+	return perms, nil
+}
+
+// zoneList returns the zones of an account, listing them again once the
+// cached list is older than the TTL. If listing fails the previous list is
+// kept until the TTL passes again; without one, no zones match and the next
+// request tries again.
+func (r *Resolver) zoneList(ctx context.Context, account string) []zone {
+	lister := r.zones
+	if account != "" {
+		lister = r.accounts[account]
+	}
+	if lister == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cached := r.cache[account]
+	now := r.now()
+	if cached != nil && now.Sub(cached.listedAt) < r.ttl {
+		return cached.zones
+	}
+
+	// Other requests share the list, so a client hanging up must not abort it
+	zones, err := listAllZones(context.WithoutCancel(ctx), lister)
+	if err != nil {
+		r.logger.Warn("failed to list zones for domain permissions", "account", account, "error", err)
+		if cached == nil {
+			return nil
+		}
+		cached.listedAt = now
+		return cached.zones
+	}
+	r.cache[account] = &zoneList{zones: zones, listedAt: now}
+	return zones
+}
+
+// listAllZones pages through every zone in an account.
+func listAllZones(ctx context.Context, lister ZoneLister) ([]zone, error) {
+	var zones []zone
+	for page := 1; ; page++ {
+		result, err := lister.ListZones(ctx, &bunny.ListZonesOptions{Page: page, PerPage: zoneListPageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, z := range result.Items {
+			zones = append(zones, zone{id: z.ID, domain: z.Domain})
+		}
+		if !result.HasMoreItems || len(result.Items) == 0 {
+			return zones, nil
+		}
+	}
+}
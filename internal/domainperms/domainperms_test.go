@@ -0,0 +1,187 @@
+package domainperms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeZones serves zones one per page to exercise paging, counting listings.
+type fakeZones struct {
+	zones    []bunny.Zone
+	err      error
+	listings int
+}
+
+func (f *fakeZones) ListZones(_ context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+	if opts.Page == 1 {
+		f.listings++
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	i := opts.Page - 1
+	if i >= len(f.zones) {
+		return &bunny.ListZonesResponse{CurrentPage: opts.Page}, nil
+	}
+	return &bunny.ListZonesResponse{
+		CurrentPage:  opts.Page,
+		HasMoreItems: i+1 < len(f.zones),
+		Items:        []bunny.Zone{f.zones[i]},
+	}, nil
+}
+
+// fakeStore returns fixed domain permissions for every token.
+type fakeStore struct {
+	perms []*storage.DomainPermission
+	err   error
+}
+
+func (f *fakeStore) ListDomainPermissions(context.Context, int64) ([]*storage.DomainPermission, error) {
+	return f.perms, f.err
+}
+
+func TestNormalizePattern(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{
+		"Example.COM.":             "example.com",
+		" *.customer1.example.com": "*.customer1.example.com",
+		"_acme.example.com":        "_acme.example.com",
+	} {
+		if got, ok := NormalizePattern(in); !ok || got != want {
+			t.Errorf("NormalizePattern(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "com", "*", "*.com", "a.*.example.com", "**.example.com", "exa mple.com", "example..com"} {
+		if got, ok := NormalizePattern(in); ok {
+			t.Errorf("NormalizePattern(%q) = %q, want invalid", in, got)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern, domain string
+		want            bool
+	}{
+		{"*.customer1.example.com", "a.customer1.example.com", true},
+		{"*.customer1.example.com", "B.A.Customer1.Example.com.", true},
+		{"*.customer1.example.com", "customer1.example.com", false},
+		{"*.customer1.example.com", "xcustomer1.example.com", false},
+		{"customer1.example.com", "customer1.example.com", true},
+		{"customer1.example.com", "a.customer1.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.domain); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1_700_000_000, 0)
+	upstream := &fakeZones{zones: []bunny.Zone{
+		{ID: 1, Domain: "customer1.example.com"},
+		{ID: 2, Domain: "a.customer1.example.com"},
+		{ID: 3, Domain: "customer2.example.com"},
+	}}
+	other := &fakeZones{zones: []bunny.Zone{{ID: 9, Domain: "b.customer1.example.com"}}}
+	store := &fakeStore{perms: []*storage.DomainPermission{
+		{Pattern: "*.customer1.example.com", AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+	}}
+	r := New(store, upstream, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithCacheTTL(time.Minute), WithAccounts(map[string]ZoneLister{"other": other}))
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	zoneIDs := func(token *storage.Token) []int64 {
+		t.Helper()
+		perms, err := r.Resolve(ctx, token)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		var ids []int64
+		for _, p := range perms {
+			if p.TokenID != token.ID || p.Resource != storage.ResourceDNSZone || !slices.Equal(p.RecordTypes, []string{"TXT"}) {
+				t.Errorf("unexpected permission: %+v", p)
+			}
+			ids = append(ids, p.ZoneID)
+		}
+		return ids
+	}
+
+	token := &storage.Token{ID: 5}
+	if got := zoneIDs(token); !slices.Equal(got, []int64{2}) {
+		t.Errorf("resolved zones = %v, want [2]", got)
+	}
+
+	// New zones are picked up once the cached list expires
+	upstream.zones = append(upstream.zones, bunny.Zone{ID: 4, Domain: "b.customer1.example.com"})
+	if got := zoneIDs(token); !slices.Equal(got, []int64{2}) || upstream.listings != 1 {
+		t.Errorf("resolved zones = %v after %d listings, want the cached [2]", got, upstream.listings)
+	}
+	now = now.Add(time.Minute)
+	if got := zoneIDs(token); !slices.Equal(got, []int64{2, 4}) {
+		t.Errorf("resolved zones = %v, want [2 4]", got)
+	}
+
+	// A failed listing keeps the previous list
+	upstream.err = errors.New("upstream down")
+	now = now.Add(time.Minute)
+	if got := zoneIDs(token); !slices.Equal(got, []int64{2, 4}) {
+		t.Errorf("resolved zones = %v, want the stale [2 4]", got)
+	}
+
+	// Tokens of another account match that account's zones
+	if got := zoneIDs(&storage.Token{ID: 5, Account: "other"}); !slices.Equal(got, []int64{9}) {
+		t.Errorf("resolved zones = %v, want [9]", got)
+	}
+	if got := zoneIDs(&storage.Token{ID: 5, Account: "removed"}); len(got) != 0 {
+		t.Errorf("resolved zones = %v for an unknown account, want none", got)
+	}
+
+	// Tokens without domain permissions never list zones
+	store.perms = nil
+	listings := other.listings
+	r.cache = make(map[string]*zoneList)
+	if got := zoneIDs(&storage.Token{ID: 6, Account: "other"}); len(got) != 0 || other.listings != listings {
+		t.Errorf("resolved zones = %v after %d listings, want none", got, other.listings-listings)
+	}
+
+	store.err = errors.New("db down")
+	if _, err := r.Resolve(ctx, token); err == nil {
+		t.Error("expected the store error")
+	}
+}
+
+func TestResolveRetriesWithoutAList(t *testing.T) {
+	t.Parallel()
+	upstream := &fakeZones{err: errors.New("upstream down")}
+	store := &fakeStore{perms: []*storage.DomainPermission{{Pattern: "example.com"}}}
+	r := New(store, upstream, nil)
+	ctx := context.Background()
+
+	for range 2 {
+		if perms, err := r.Resolve(ctx, &storage.Token{ID: 1}); err != nil || len(perms) != 0 {
+			t.Fatalf("Resolve() = %v, %v; want no permissions", perms, err)
+		}
+	}
+	if upstream.listings != 2 {
+		t.Errorf("expected a listing per request until one succeeds, got %d", upstream.listings)
+	}
+
+	upstream.err = nil
+	upstream.zones = []bunny.Zone{{ID: 7, Domain: "example.com"}}
+	if perms, err := r.Resolve(ctx, &storage.Token{ID: 1}); err != nil || len(perms) != 1 || perms[0].ZoneID != 7 {
+		t.Errorf("Resolve() = %v, %v; want zone 7", perms, err)
+	}
+}
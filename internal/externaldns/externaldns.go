@@ -0,0 +1,343 @@
+// Package externaldns implements the Kubernetes external-dns webhook provider
+// API on top of the proxy, so external-dns can manage records with a scoped
+// token instead of a bunny.net account key.
+package externaldns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// MediaType is the content type of webhook provider requests and responses.
+const MediaType = "application/external.dns.webhook+json;version=1"
+
+// maxBody caps the size of request bodies.
+const maxBody = 4 << 20
+
+// Endpoint is a DNS name with its record type and targets, as external-dns
+// describes records.
+type Endpoint struct {
+	DNSName          string            `json:"dnsName"`
+	Targets          []string          `json:"targets"`
+	RecordType       string            `json:"recordType"`
+	SetIdentifier    string            `json:"setIdentifier,omitempty"`
+	RecordTTL        int64             `json:"recordTTL,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	ProviderSpecific []ProviderProp    `json:"providerSpecific,omitempty"`
+}
+
+// ProviderProp is a provider-specific endpoint property. None are used.
+type ProviderProp struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Changes is the body of an apply request.
+type Changes struct {
+	Create    []*Endpoint `json:"Create"`
+	UpdateOld []*Endpoint `json:"UpdateOld"`
+	UpdateNew []*Endpoint `json:"UpdateNew"`
+	Delete    []*Endpoint `json:"Delete"`
+}
+
+// DomainFilter tells external-dns which domains the provider manages.
+type DomainFilter struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// errInvalidChange marks apply requests that cannot be carried out.
+var errInvalidChange = errors.New("invalid change")
+
+// NewRouter serves the external-dns webhook provider API:
+//
+//	GET  /                 domain filter negotiation
+//	GET  /records          current records
+//	POST /adjustendpoints  drop endpoints the provider cannot manage
+//	POST /records          apply changes (also served at POST /apply)
+//
+// Callers authenticate with their own proxy key, in the AccessKey header or
+// as the Basic auth password, which external-dns sends when the key is in
+// the webhook URL's user info. Record requests are sent through proxy with
+// that key, so only the zones and record types the key permits are listed
+// and changed, and the audit log and change events apply as usual.
+func NewRouter(proxy http.Handler, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	h := &handler{proxy: proxy, logger: logger}
+	r := chi.NewRouter()
+	r.Get("/", h.withClient(h.negotiate))
+	r.Get("/records", h.withClient(h.listRecords))
+	r.Post("/records", h.withClient(h.applyChanges))
+	r.Post("/apply", h.withClient(h.applyChanges))
+	r.Post("/adjustendpoints", h.adjustEndpoints)
+	return r
+}
+
+type handler struct {
+	proxy  http.Handler
+	logger *slog.Logger
+}
+
+// withClient authenticates a request and passes a proxy client for the
+// caller's key to next.
+func (h *handler) withClient(next func(http.ResponseWriter, *http.Request, *proxyClient)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("AccessKey")
+		if key == "" {
+			_, key, _ = r.BasicAuth()
+		}
+		if key == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="bunny-api-proxy"`)
+			writeError(w, http.StatusUnauthorized, "missing API key")
+			return
+		}
+		// Drop this router's routing state so the proxy router routes the
+		// client's requests from the top.
+		ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+		next(w, r.WithContext(ctx), &proxyClient{handler: h.proxy, accessKey: key})
+	}
+}
+
+// negotiate returns the domains of the zones the key can list.
+// GET /
+func (h *handler) negotiate(w http.ResponseWriter, r *http.Request, c *proxyClient) {
+	zones, err := c.zones(r.Context())
+	if err != nil {
+		h.fail(w, "negotiate", err)
+		return
+	}
+	filter := DomainFilter{Include: make([]string, 0, len(zones))}
+	for _, z := range zones {
+		filter.Include = append(filter.Include, strings.ToLower(z.Domain))
+	}
+	slices.Sort(filter.Include)
+	writeJSON(w, http.StatusOK, filter)
+}
+
+// listRecords returns the records of every zone the key can list, limited
+// to the record types the key may see.
+// GET /records
+func (h *handler) listRecords(w http.ResponseWriter, r *http.Request, c *proxyClient) {
+	zones, err := c.zones(r.Context())
+	if err != nil {
+		h.fail(w, "records", err)
+		return
+	}
+	out := make([]*Endpoint, 0)
+	for i := range zones {
+		records, err := c.records(r.Context(), zones[i].ID)
+		var reqErr *RequestError
+		if errors.As(err, &reqErr) && reqErr.Status == http.StatusForbidden {
+			// Listed but not readable, e.g. a key that may only add records
+			continue
+		}
+		if err != nil {
+			h.fail(w, "records", err)
+			return
+		}
+		out = append(out, endpoints(&zones[i], records)...)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// adjustEndpoints normalizes names and drops endpoints with record types the
+// provider does not manage, so external-dns does not plan changes for them.
+// POST /adjustendpoints
+func (h *handler) adjustEndpoints(w http.ResponseWriter, r *http.Request) {
+	var in []*Endpoint
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	out := make([]*Endpoint, 0, len(in))
+	for _, ep := range in {
+		if _, ok := recordTypes[ep.RecordType]; !ok {
+			continue
+		}
+		ep.DNSName = normalizeName(ep.DNSName)
+		out = append(out, ep)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// applyChanges deletes, updates and creates records. Creating a record that
+// already exists, or deleting one that does not, succeeds, so a retried
+// request does not fail halfway through.
+// POST /records
+func (h *handler) applyChanges(w http.ResponseWriter, r *http.Request, c *proxyClient) {
+	var changes Changes
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&changes); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	zones, err := c.zones(r.Context())
+	if err != nil {
+		h.fail(w, "apply", err)
+		return
+	}
+
+	a := &applier{client: c, zones: zoneIndex(zones), records: make(map[int64][]bunny.Record), logger: h.logger}
+	if err := a.apply(r.Context(), &changes); err != nil {
+		h.fail(w, "apply", err)
+		return
+	}
+	h.logger.Info("externaldns: changes applied", "create", len(changes.Create),
+		"update", len(changes.UpdateNew), "delete", len(changes.Delete))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applier carries out a set of changes, caching each zone's records until
+// they are changed.
+type applier struct {
+	client  *proxyClient
+	zones   zoneIndex
+	records map[int64][]bunny.Record
+	logger  *slog.Logger
+}
+
+func (a *applier) apply(ctx context.Context, changes *Changes) error {
+	for _, ep := range changes.Delete {
+		if err := a.reconcile(ctx, ep, ep.Targets, nil); err != nil {
+			return err
+		}
+	}
+	for _, ep := range changes.UpdateNew {
+		var owned []string
+		for _, old := range changes.UpdateOld {
+			if normalizeName(old.DNSName) == normalizeName(ep.DNSName) && old.RecordType == ep.RecordType && old.SetIdentifier == ep.SetIdentifier {
+				owned = old.Targets
+			}
+		}
+		if err := a.reconcile(ctx, ep, owned, ep.Targets); err != nil {
+			return err
+		}
+	}
+	for _, ep := range changes.Create {
+		if err := a.reconcile(ctx, ep, nil, ep.Targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcile deletes the records for targets in remove that are not in keep,
+// and adds or updates the records for the targets in keep.
+func (a *applier) reconcile(ctx context.Context, ep *Endpoint, remove, keep []string) error {
+	code, ok := recordTypes[ep.RecordType]
+	if !ok {
+		return fmt.Errorf("%w: record type %q is not supported", errInvalidChange, ep.RecordType)
+	}
+	dnsName := normalizeName(ep.DNSName)
+	zone, name, ok := a.zones.find(dnsName)
+	if !ok {
+		if len(keep) == 0 {
+			a.logger.Warn("externaldns: skipping delete outside the key's zones", "name", dnsName)
+			return nil
+		}
+		return fmt.Errorf("%w: no zone found for %s", errInvalidChange, dnsName)
+	}
+
+	records, err := a.zoneRecords(ctx, zone.ID)
+	if err != nil {
+		return err
+	}
+	for _, tgt := range remove {
+		if slices.Contains(keep, tgt) {
+			continue
+		}
+		for i := range records {
+			if !matches(&records[i], name, code, tgt) {
+				continue
+			}
+			if err := a.client.deleteRecord(ctx, zone.ID, records[i].ID); err != nil {
+				return err
+			}
+			delete(a.records, zone.ID)
+		}
+	}
+	for _, tgt := range keep {
+		req, err := recordRequest(code, name, tgt, ep.RecordTTL)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", errInvalidChange, dnsName, err)
+		}
+		i := slices.IndexFunc(records, func(rec bunny.Record) bool { return matches(&rec, name, code, tgt) })
+		switch {
+		case i < 0:
+			err = a.client.addRecord(ctx, zone.ID, req)
+		case ep.RecordTTL > 0 && int64(records[i].TTL) != ep.RecordTTL:
+			err = a.client.updateRecord(ctx, zone.ID, records[i].ID, req)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		delete(a.records, zone.ID)
+	}
+	return nil
+}
+
+// zoneRecords returns a zone's records, fetching them when they are not
+// cached.
+func (a *applier) zoneRecords(ctx context.Context, zoneID int64) ([]bunny.Record, error) {
+	if records, ok := a.records[zoneID]; ok {
+		return records, nil
+	}
+	records, err := a.client.records(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	a.records[zoneID] = records
+	return records, nil
+}
+
+// fail logs a failed request and writes the matching error response.
+// Authentication and permission failures keep their status; anything else
+// from the proxy is reported as a bad gateway.
+func (h *handler) fail(w http.ResponseWriter, op string, err error) {
+	status, message := http.StatusBadGateway, "failed to update records"
+	var reqErr *RequestError
+	switch {
+	case errors.Is(err, errInvalidChange):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.As(err, &reqErr):
+		switch reqErr.Status {
+		case http.StatusUnauthorized:
+			status, message = http.StatusUnauthorized, "invalid API key"
+		case http.StatusForbidden:
+			status, message = http.StatusForbidden, "permission denied"
+		case http.StatusServiceUnavailable:
+			status, message = http.StatusServiceUnavailable, "service unavailable"
+		}
+	}
+	h.logger.Warn("externaldns: request failed", "op", op, "status", status, "error", err)
+	writeError(w, status, message)
+}
+
+// writeJSON writes a webhook provider response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", MediaType)
+	w.Header().Set("Vary", "Content-Type")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,214 @@
+package externaldns
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/proxy"
+	"github.com/sipico/bunny-api-proxy/internal/replay"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockbunny"
+)
+
+// newTestRouter returns the webhook router in front of a proxy backed by
+// mockbunny, and a scoped token allowed to manage A, CNAME and TXT records in
+// the first zone only.
+func newTestRouter(t *testing.T, zoneDomains ...string) (http.Handler, *mockbunny.Server, map[string]int64, string) {
+	t.Helper()
+	server := mockbunny.New()
+	t.Cleanup(server.Close)
+	zones := make(map[string]int64)
+	for _, d := range zoneDomains {
+		zones[d] = server.AddZone(d)
+	}
+
+	db, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() }) //nolint:errcheck
+
+	const key = "external-dns-key"
+	hash := sha256.Sum256([]byte(key))
+	token, err := db.CreateToken(context.Background(), "external-dns", false, hex.EncodeToString(hash[:]))
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	_, err = db.AddPermissionForToken(context.Background(), token.ID, &storage.Permission{
+		ZoneID:         zones[zoneDomains[0]],
+		AllowedActions: []string{"list_records", "add_record", "update_record", "delete_record"},
+		RecordTypes:    []string{"A", "CNAME", "TXT"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add permission: %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := proxy.NewHandler(bunny.NewClient("test", bunny.WithBaseURL(server.URL())), logger)
+	authenticator := auth.NewAuthenticator(db, auth.NewBootstrapService(db, "master-key"))
+	guard, err := replay.New(db, replay.ModeRequired, logger)
+	if err != nil {
+		t.Fatalf("failed to create replay guard: %v", err)
+	}
+	chain := func(next http.Handler) http.Handler {
+		return authenticator.Authenticate(guard.Middleware(authenticator.CheckPermissions(next)))
+	}
+	return NewRouter(proxy.NewRouter(handler, chain, logger), logger), server, zones, key
+}
+
+// call sends a webhook request authenticated with key as the Basic auth
+// password, as external-dns does.
+func call(t *testing.T, router http.Handler, key, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", MediaType)
+	if key != "" {
+		req.SetBasicAuth("external-dns", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+	router, _, _, key := newTestRouter(t, "example.com", "other.com")
+
+	w := call(t, router, key, http.MethodGet, "/", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != MediaType {
+		t.Errorf("Content-Type = %q, want %q", got, MediaType)
+	}
+	var filter DomainFilter
+	if err := json.Unmarshal(w.Body.Bytes(), &filter); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(filter.Include) != 1 || filter.Include[0] != "example.com" {
+		t.Errorf("include = %v, want only the permitted zone", filter.Include)
+	}
+}
+
+func TestMissingKey(t *testing.T) {
+	t.Parallel()
+	router, _, _, _ := newTestRouter(t, "example.com")
+
+	w := call(t, router, "", http.MethodGet, "/records", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("expected a WWW-Authenticate challenge")
+	}
+
+	w = call(t, router, "wrong-key", http.MethodGet, "/records", "")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d for an invalid key, want 401", w.Code)
+	}
+}
+
+func TestApplyAndListRecords(t *testing.T) {
+	t.Parallel()
+	router, server, zones, key := newTestRouter(t, "example.com")
+
+	changes := `{"Create": [
+		{"dnsName": "www.example.com", "targets": ["192.0.2.1", "192.0.2.2"], "recordType": "A", "recordTTL": 60},
+		{"dnsName": "a-www.example.com", "targets": ["\"heritage=external-dns\""], "recordType": "TXT"}
+	]}`
+	if w := call(t, router, key, http.MethodPost, "/records", changes); w.Code != http.StatusNoContent {
+		t.Fatalf("apply status = %d, want 204: %s", w.Code, w.Body.String())
+	}
+	// Creating existing records again is harmless
+	if w := call(t, router, key, http.MethodPost, "/records", changes); w.Code != http.StatusNoContent {
+		t.Fatalf("repeated apply status = %d, want 204: %s", w.Code, w.Body.String())
+	}
+	if n := len(server.GetZone(zones["example.com"]).Records); n != 3 {
+		t.Fatalf("expected 3 records, got %d", n)
+	}
+
+	w := call(t, router, key, http.MethodGet, "/records", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("records status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var eps []*Endpoint
+	if err := json.Unmarshal(w.Body.Bytes(), &eps); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var www *Endpoint
+	for _, ep := range eps {
+		if ep.DNSName == "www.example.com" && ep.RecordType == "A" {
+			www = ep
+		}
+	}
+	if www == nil || len(www.Targets) != 2 || www.RecordTTL != 60 {
+		t.Fatalf("unexpected endpoints %+v", eps)
+	}
+
+	// Replace one target and change the TTL, then delete the TXT record
+	changes = `{
+		"UpdateOld": [{"dnsName": "www.example.com", "targets": ["192.0.2.1", "192.0.2.2"], "recordType": "A", "recordTTL": 60}],
+		"UpdateNew": [{"dnsName": "www.example.com", "targets": ["192.0.2.1", "192.0.2.3"], "recordType": "A", "recordTTL": 120}],
+		"Delete": [{"dnsName": "a-www.example.com", "targets": ["\"heritage=external-dns\""], "recordType": "TXT"}]
+	}`
+	if w := call(t, router, key, http.MethodPost, "/apply", changes); w.Code != http.StatusNoContent {
+		t.Fatalf("apply status = %d, want 204: %s", w.Code, w.Body.String())
+	}
+	records := server.GetZone(zones["example.com"]).Records
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %+v", records)
+	}
+	for _, rec := range records {
+		if rec.Type != 0 || rec.TTL != 120 || (rec.Value != "192.0.2.1" && rec.Value != "192.0.2.3") {
+			t.Errorf("unexpected record %+v", rec)
+		}
+	}
+}
+
+func TestApplyPermissionsApply(t *testing.T) {
+	t.Parallel()
+	router, _, _, key := newTestRouter(t, "example.com", "other.com")
+
+	// The token has no permission for other.com, so the zone is invisible
+	changes := `{"Create": [{"dnsName": "www.other.com", "targets": ["192.0.2.1"], "recordType": "A"}]}`
+	if w := call(t, router, key, http.MethodPost, "/records", changes); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d for a zone outside the token's permissions, want 400", w.Code)
+	}
+
+	// MX is not among the token's record types
+	changes = `{"Create": [{"dnsName": "example.com", "targets": ["10 mail.example.com"], "recordType": "MX"}]}`
+	if w := call(t, router, key, http.MethodPost, "/records", changes); w.Code != http.StatusForbidden {
+		t.Errorf("status = %d for a record type outside the token's permissions, want 403", w.Code)
+	}
+}
+
+func TestAdjustEndpoints(t *testing.T) {
+	t.Parallel()
+	router, _, _, _ := newTestRouter(t, "example.com")
+
+	body := `[
+		{"dnsName": "WWW.Example.com.", "targets": ["192.0.2.1"], "recordType": "A"},
+		{"dnsName": "example.com", "targets": ["0 issue \"letsencrypt.org\""], "recordType": "CAA"}
+	]`
+	w := call(t, router, "", http.MethodPost, "/adjustendpoints", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var eps []*Endpoint
+	if err := json.Unmarshal(w.Body.Bytes(), &eps); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(eps) != 1 || eps[0].DNSName != "www.example.com" {
+		t.Errorf("unexpected endpoints %+v", eps)
+	}
+}
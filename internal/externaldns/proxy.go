@@ -0,0 +1,137 @@
+package externaldns
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/replay"
+)
+
+// zonesPerPage is the page size used to list the caller's zones.
+const zonesPerPage = 1000
+
+// RequestError is returned when the proxy answers a record request with an
+// error status, for example because the access key lacks a permission.
+type RequestError struct {
+	Method string
+	Path   string
+	Status int
+	Body   string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s %s: status %d: %s", e.Method, e.Path, e.Status, e.Body)
+}
+
+// proxyClient sends requests through the proxy's own handler, authenticated
+// with the caller's access key, so permissions, the audit log and change
+// events apply as if the caller had used the record endpoints.
+type proxyClient struct {
+	handler   http.Handler
+	accessKey string
+}
+
+// zones returns every zone the key can list.
+func (c *proxyClient) zones(ctx context.Context) ([]bunny.Zone, error) {
+	var zones []bunny.Zone
+	for page := 1; ; page++ {
+		var resp bunny.ListZonesResponse
+		path := fmt.Sprintf("/dnszone?page=%d&perPage=%d", page, zonesPerPage)
+		if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+		zones = append(zones, resp.Items...)
+		if !resp.HasMoreItems || len(resp.Items) == 0 {
+			return zones, nil
+		}
+	}
+}
+
+// records returns the records of a zone that the key may see.
+func (c *proxyClient) records(ctx context.Context, zoneID int64) ([]bunny.Record, error) {
+	var records []bunny.Record
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/dnszone/%d/records", zoneID), nil, &records)
+	return records, err
+}
+
+func (c *proxyClient) addRecord(ctx context.Context, zoneID int64, rec *bunny.AddRecordRequest) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/dnszone/%d/records", zoneID), rec, nil)
+}
+
+func (c *proxyClient) updateRecord(ctx context.Context, zoneID, recordID int64, rec *bunny.AddRecordRequest) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/dnszone/%d/records/%d", zoneID, recordID), rec, nil)
+}
+
+func (c *proxyClient) deleteRecord(ctx context.Context, zoneID, recordID int64) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/dnszone/%d/records/%d", zoneID, recordID), nil, nil)
+}
+
+// do serves one request through the proxy handler and decodes the JSON
+// response into out.
+func (c *proxyClient) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("AccessKey", c.accessKey)
+	// Each request is new, so it passes replay protection when it is required
+	req.Header.Set(replay.TimestampHeader, strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set(replay.NonceHeader, rand.Text())
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	w := &responseBuffer{header: make(http.Header)}
+	c.handler.ServeHTTP(w, req)
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.status >= 300 {
+		return &RequestError{Method: method, Path: path, Status: w.status, Body: strings.TrimSpace(w.body.String())}
+	}
+	if out != nil {
+		if err := json.Unmarshal(w.body.Bytes(), out); err != nil {
+			return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// responseBuffer is an in-memory http.ResponseWriter.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header { return b.header }
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
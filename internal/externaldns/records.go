@@ -0,0 +1,177 @@
+package externaldns
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// defaultTTL is used for endpoints without a TTL.
+const defaultTTL = 300
+
+// recordTypes maps the external-dns record types the provider manages to
+// bunny.net's type codes.
+var recordTypes = map[string]int{
+	"A":     0,
+	"AAAA":  1,
+	"CNAME": 2,
+	"TXT":   3,
+	"MX":    4,
+	"SRV":   8,
+	"NS":    12,
+}
+
+// typeName returns the external-dns name of a bunny.net record type, or ""
+// for types the provider does not manage.
+func typeName(code int) string {
+	for name, c := range recordTypes {
+		if c == code {
+			return name
+		}
+	}
+	return ""
+}
+
+// zoneIndex finds the zone a DNS name belongs to.
+type zoneIndex []bunny.Zone
+
+// find returns the most specific zone containing name and the name relative
+// to it ("" for the apex).
+func (zones zoneIndex) find(name string) (*bunny.Zone, string, bool) {
+	var best *bunny.Zone
+	for i := range zones {
+		domain := strings.ToLower(zones[i].Domain)
+		if name != domain && !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+		if best == nil || len(domain) > len(best.Domain) {
+			best = &zones[i]
+		}
+	}
+	if best == nil {
+		return nil, "", false
+	}
+	return best, strings.TrimSuffix(strings.TrimSuffix(name, strings.ToLower(best.Domain)), "."), true
+}
+
+// normalizeName lowercases a DNS name and drops its trailing dot.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// fqdn returns the DNS name of a record in zone.
+func fqdn(zone *bunny.Zone, name string) string {
+	domain := strings.ToLower(zone.Domain)
+	if name == "" {
+		return domain
+	}
+	return strings.ToLower(name) + "." + domain
+}
+
+// target returns the external-dns form of a record's value: MX records are
+// "<priority> <host>" and SRV records "<priority> <weight> <port> <host>".
+func target(rec *bunny.Record) string {
+	switch rec.Type {
+	case recordTypes["MX"]:
+		return fmt.Sprintf("%d %s", rec.Priority, rec.Value)
+	case recordTypes["SRV"]:
+		return fmt.Sprintf("%d %d %d %s", rec.Priority, rec.Weight, rec.Port, rec.Value)
+	}
+	return rec.Value
+}
+
+// recordRequest returns the record for one target of an endpoint.
+func recordRequest(code int, name, target string, ttl int64) (*bunny.AddRecordRequest, error) {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	req := &bunny.AddRecordRequest{Type: code, Name: name, Value: target, TTL: int32(min(ttl, 1<<31-1))} //nolint:gosec // clamped above
+	switch code {
+	case recordTypes["CNAME"], recordTypes["NS"]:
+		req.Value = strings.TrimSuffix(target, ".")
+	case recordTypes["MX"]:
+		fields := strings.Fields(target)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid MX target %q: want \"<priority> <host>\"", target)
+		}
+		prio, err := parseUint16(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid MX target %q: %w", target, err)
+		}
+		req.Priority, req.Value = prio, strings.TrimSuffix(fields[1], ".")
+	case recordTypes["SRV"]:
+		fields := strings.Fields(target)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("invalid SRV target %q: want \"<priority> <weight> <port> <host>\"", target)
+		}
+		var nums [3]int32
+		for i := range nums {
+			n, err := parseUint16(fields[i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid SRV target %q: %w", target, err)
+			}
+			nums[i] = n
+		}
+		req.Priority, req.Weight, req.Port = nums[0], nums[1], nums[2]
+		req.Value = strings.TrimSuffix(fields[3], ".")
+	}
+	return req, nil
+}
+
+// parseUint16 parses a priority, weight or port.
+func parseUint16(s string) (int32, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number from 0 to 65535", s)
+	}
+	return int32(n), nil
+}
+
+// endpoints groups a zone's records into one endpoint per name and type,
+// skipping types the provider does not manage.
+func endpoints(zone *bunny.Zone, records []bunny.Record) []*Endpoint {
+	var out []*Endpoint
+	byKey := make(map[string]*Endpoint)
+	for i := range records {
+		rec := &records[i]
+		recordType := typeName(rec.Type)
+		if recordType == "" {
+			continue
+		}
+		name := fqdn(zone, rec.Name)
+		key := name + " " + recordType
+		ep, ok := byKey[key]
+		if !ok {
+			ep = &Endpoint{DNSName: name, RecordType: recordType, RecordTTL: int64(rec.TTL)}
+			byKey[key] = ep
+			out = append(out, ep)
+		}
+		ep.Targets = append(ep.Targets, target(rec))
+	}
+	for _, ep := range out {
+		slices.Sort(ep.Targets)
+	}
+	return out
+}
+
+// matches reports whether rec is the record for target of an endpoint with
+// the given name relative to the zone and type. Host names are compared
+// case-insensitively.
+func matches(rec *bunny.Record, name string, code int, tgt string) bool {
+	if rec.Type != code || !strings.EqualFold(rec.Name, name) {
+		return false
+	}
+	switch code {
+	case recordTypes["TXT"], recordTypes["A"], recordTypes["AAAA"]:
+		return target(rec) == tgt
+	}
+	want, err := recordRequest(code, name, tgt, 0)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(rec.Value, want.Value) && rec.Priority == want.Priority &&
+		rec.Weight == want.Weight && rec.Port == want.Port
+}
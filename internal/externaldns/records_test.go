@@ -0,0 +1,85 @@
+package externaldns
+
+import (
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+func TestZoneIndexFind(t *testing.T) {
+	t.Parallel()
+	zones := zoneIndex{{ID: 1, Domain: "example.com"}, {ID: 2, Domain: "Sub.Example.com"}}
+
+	tests := []struct {
+		name   string
+		zoneID int64
+		rel    string
+		ok     bool
+	}{
+		{"example.com", 1, "", true},
+		{"www.example.com", 1, "www", true},
+		{"sub.example.com", 2, "", true},
+		{"a.b.sub.example.com", 2, "a.b", true},
+		{"notexample.com", 0, "", false},
+	}
+	for _, tt := range tests {
+		zone, rel, ok := zones.find(tt.name)
+		if ok != tt.ok {
+			t.Errorf("find(%q) ok = %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && (zone.ID != tt.zoneID || rel != tt.rel) {
+			t.Errorf("find(%q) = %d %q, want %d %q", tt.name, zone.ID, rel, tt.zoneID, tt.rel)
+		}
+	}
+}
+
+func TestRecordRequest(t *testing.T) {
+	t.Parallel()
+	req, err := recordRequest(recordTypes["SRV"], "_sip._tcp", "10 20 5060 sip.example.com.", 0)
+	if err != nil {
+		t.Fatalf("recordRequest failed: %v", err)
+	}
+	if req.Priority != 10 || req.Weight != 20 || req.Port != 5060 || req.Value != "sip.example.com" || req.TTL != defaultTTL {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	req, err = recordRequest(recordTypes["MX"], "", "10 mail.example.com", 3600)
+	if err != nil {
+		t.Fatalf("recordRequest failed: %v", err)
+	}
+	if req.Priority != 10 || req.Value != "mail.example.com" || req.TTL != 3600 {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	for _, tgt := range []string{"mail.example.com", "70000 mail.example.com"} {
+		if _, err := recordRequest(recordTypes["MX"], "", tgt, 0); err == nil {
+			t.Errorf("expected invalid MX target %q to be rejected", tgt)
+		}
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	t.Parallel()
+	zone := &bunny.Zone{ID: 1, Domain: "example.com"}
+	records := []bunny.Record{
+		{Type: recordTypes["A"], Name: "www", Value: "192.0.2.2", TTL: 60},
+		{Type: recordTypes["A"], Name: "www", Value: "192.0.2.1", TTL: 60},
+		{Type: recordTypes["MX"], Name: "", Value: "mail.example.com", Priority: 10, TTL: 300},
+		{Type: 9, Name: "", Value: "0 issue \"letsencrypt.org\""},
+	}
+
+	eps := endpoints(zone, records)
+	if len(eps) != 2 {
+		t.Fatalf("expected 2 endpoints, got %+v", eps)
+	}
+	if eps[0].DNSName != "www.example.com" || eps[0].RecordType != "A" || eps[0].Targets[0] != "192.0.2.1" || len(eps[0].Targets) != 2 {
+		t.Errorf("unexpected endpoint %+v", eps[0])
+	}
+	if eps[1].DNSName != "example.com" || eps[1].Targets[0] != "10 mail.example.com" {
+		t.Errorf("unexpected endpoint %+v", eps[1])
+	}
+	if !matches(&records[2], "", recordTypes["MX"], "10 Mail.example.com.") {
+		t.Error("expected MX target to match case-insensitively and without the trailing dot")
+	}
+}
@@ -0,0 +1,243 @@
+// Package freeze rejects mutating proxy requests to zones during a change
+// freeze, so automation cannot touch DNS while an incident is handled.
+//
+// Freeze windows are stored in the database, so every instance sharing it
+// enforces them. A window may start later and may end at a set time or last
+// until it is lifted. Each instance keeps an in-memory copy that is reloaded
+// periodically and immediately after changes made through it.
+package freeze
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// OverrideHeader lets an admin token or the master key change a frozen
+	// zone when set to "true". Scoped tokens cannot override a freeze.
+	OverrideHeader = "X-Freeze-Override"
+
+	// refreshInterval is how often freezes added by other instances are
+	// picked up and ended freezes are pruned.
+	refreshInterval = 30 * time.Second
+)
+
+// ErrInvalidWindow is returned for a freeze that ends before it starts or
+// has already ended.
+var ErrInvalidWindow = errors.New("invalid freeze window")
+
+// Store defines the storage operations needed by the schedule.
+type Store interface {
+	AddZoneFreeze(ctx context.Context, f *storage.ZoneFreeze) (*storage.ZoneFreeze, error)
+	ListZoneFreezes(ctx context.Context) ([]*storage.ZoneFreeze, error)
+	DeleteZoneFreeze(ctx context.Context, id int64) error
+	PruneZoneFreezes(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Schedule checks requests against the stored freeze windows.
+type Schedule struct {
+	store  Store
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	freezes []*storage.ZoneFreeze
+}
+
+// New creates a freeze schedule. Call Reload or Run to load the stored freezes.
+func New(store Store, logger *slog.Logger) *Schedule {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Schedule{store: store, logger: logger, now: time.Now}
+}
+
+// Add freezes a zone from startsAt, or now if it is zero, until endsAt, or
+// until the freeze is lifted if endsAt is zero. Returns ErrInvalidWindow if
+// the window ends before it starts or has already ended.
+func (s *Schedule) Add(ctx context.Context, zoneID int64, reason, createdBy string, startsAt, endsAt time.Time) (*storage.ZoneFreeze, error) {
+	now := s.now()
+	if startsAt.IsZero() {
+		startsAt = now
+	}
+	if !endsAt.IsZero() && (!endsAt.After(startsAt) || !endsAt.After(now)) {
+		return nil, fmt.Errorf("%w: it must end after it starts and in the future", ErrInvalidWindow)
+	}
+
+	created, err := s.store.AddZoneFreeze(ctx, &storage.ZoneFreeze{
+		ZoneID:    zoneID,
+		Reason:    reason,
+		CreatedBy: createdBy,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("zone freeze added", "id", created.ID, "zone_id", zoneID, "reason", reason,
+		"created_by", createdBy, "starts_at", created.StartsAt, "ends_at", created.EndsAt)
+	s.reloadAfterChange(ctx)
+	return created, nil
+}
+
+// Remove lifts a freeze by ID.
+// Returns storage.ErrNotFound if the freeze doesn't exist.
+func (s *Schedule) Remove(ctx context.Context, id int64) error {
+	if err := s.store.DeleteZoneFreeze(ctx, id); err != nil {
+		return err
+	}
+	s.logger.Info("zone freeze lifted", "id", id)
+	s.reloadAfterChange(ctx)
+	return nil
+}
+
+// List returns the current and upcoming freezes, leaving out ended ones.
+func (s *Schedule) List(ctx context.Context) ([]*storage.ZoneFreeze, error) {
+	freezes, err := s.store.ListZoneFreezes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	pending := make([]*storage.ZoneFreeze, 0, len(freezes))
+	for _, f := range freezes {
+		if f.EndsAt.IsZero() || f.EndsAt.After(now) {
+			pending = append(pending, f)
+		}
+	}
+	return pending, nil
+}
+
+// Reload replaces the in-memory freezes with the stored ones.
+func (s *Schedule) Reload(ctx context.Context) error {
+	freezes, err := s.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load zone freezes: %w", err)
+	}
+
+	s.mu.Lock()
+	s.freezes = freezes
+	s.mu.Unlock()
+	return nil
+}
+
+// reloadAfterChange applies a local change right away rather than at the next refresh.
+func (s *Schedule) reloadAfterChange(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		s.logger.Error("failed to reload zone freezes", "error", err)
+	}
+}
+
+// Active returns the freeze in effect for a zone, or nil if the zone is not
+// frozen. Of several overlapping freezes, the one ending last is returned.
+func (s *Schedule) Active(zoneID int64) *storage.ZoneFreeze {
+	now := s.now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var active *storage.ZoneFreeze
+	for _, f := range s.freezes {
+		if f.ZoneID != zoneID || f.StartsAt.After(now) || (!f.EndsAt.IsZero() && !f.EndsAt.After(now)) {
+			continue
+		}
+		if active == nil || f.EndsAt.IsZero() || (!active.EndsAt.IsZero() && f.EndsAt.After(active.EndsAt)) {
+			active = f
+		}
+	}
+	return active
+}
+
+// Middleware rejects mutating requests to a frozen zone with 423 Locked.
+// Admin tokens and the master key may send OverrideHeader to make the change
+// anyway. It must run after authentication.
+func (s *Schedule) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Cancelling a scheduled change and diffing a zone file leave the zone
+		// as it is
+		req, err := auth.ParseRequest(r)
+		if err != nil || req.ZoneID == 0 || req.Action.Resource() != storage.ResourceDNSZone ||
+			req.Action == auth.ActionCancelScheduledChange || req.Action == auth.ActionDiffRecords {
+			next.ServeHTTP(w, r)
+			return
+		}
+		f := s.Active(req.ZoneID)
+		if f == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if auth.IsAdminFromContext(r.Context()) && r.Header.Get(OverrideHeader) == "true" {
+			metrics.RecordFrozenRequest("overridden")
+			s.logger.Warn("zone freeze overridden", "zone_id", req.ZoneID, "freeze_id", f.ID,
+				"method", r.Method, "path", r.URL.Path)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		metrics.RecordFrozenRequest("rejected")
+		s.logger.Info("rejected request to frozen zone", "zone_id", req.ZoneID, "freeze_id", f.ID,
+			"method", r.Method, "path", r.URL.Path)
+
+		message := fmt.Sprintf("Zone %d is frozen until the freeze is lifted", req.ZoneID)
+		if !f.EndsAt.IsZero() {
+			message = fmt.Sprintf("Zone %d is frozen until %s", req.ZoneID, f.EndsAt.UTC().Format(time.RFC3339))
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(f.EndsAt.Sub(s.now()).Seconds())+1, 10))
+		}
+		if f.Reason != "" {
+			message += ": " + f.Reason
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusLocked)
+		//nolint:errcheck
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error":     "zone_frozen",
+			"message":   message,
+			"freeze_id": f.ID,
+		})
+	})
+}
+
+// Run reloads the freezes and prunes ended ones until ctx is cancelled.
+func (s *Schedule) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.store.PruneZoneFreezes(ctx, s.now()); err != nil {
+			s.logger.Error("failed to prune zone freezes", "error", err)
+		} else if n > 0 {
+			s.logger.Info("pruned ended zone freezes", "count", n)
+		}
+		if err := s.Reload(ctx); err != nil {
+			s.logger.Error("failed to reload zone freezes", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readOnlyMethod reports whether a request method never writes.
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
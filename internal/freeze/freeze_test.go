@@ -0,0 +1,177 @@
+package freeze
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestSchedule returns a schedule on an in-memory database, with a clock
+// the test can move.
+func newTestSchedule(t *testing.T) (*Schedule, *time.Time) {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	s := New(store, testLogger())
+	now := time.Unix(1_700_000_000, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestSchedule_AddRemoveAndWindows(t *testing.T) {
+	t.Parallel()
+	s, now := newTestSchedule(t)
+	ctx := context.Background()
+
+	open, err := s.Add(ctx, 1, "incident 42", "admin", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := s.Add(ctx, 2, "", "admin", now.Add(time.Hour), now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	for _, w := range [][2]time.Time{
+		{now.Add(time.Hour), now.Add(time.Minute)},
+		{now.Add(-2 * time.Hour), now.Add(-time.Hour)},
+	} {
+		if _, err := s.Add(ctx, 3, "", "admin", w[0], w[1]); !errors.Is(err, ErrInvalidWindow) {
+			t.Errorf("Add(%v, %v) error = %v, want ErrInvalidWindow", w[0], w[1], err)
+		}
+	}
+
+	if f := s.Active(1); f == nil || f.ID != open.ID {
+		t.Errorf("Active(1) = %+v, want the open-ended freeze", f)
+	}
+	if f := s.Active(2); f != nil {
+		t.Errorf("Active(2) before the window = %+v, want nil", f)
+	}
+
+	*now = now.Add(90 * time.Minute)
+	if f := s.Active(2); f == nil {
+		t.Error("zone 2 is not frozen during its window")
+	}
+	*now = now.Add(time.Hour)
+	if f := s.Active(2); f != nil {
+		t.Errorf("Active(2) after the window = %+v, want nil", f)
+	}
+	if freezes, err := s.List(ctx); err != nil || len(freezes) != 1 {
+		t.Errorf("List() = %+v, %v; want only the open-ended freeze", freezes, err)
+	}
+
+	if err := s.Remove(ctx, open.ID); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if f := s.Active(1); f != nil {
+		t.Errorf("Active(1) after lifting = %+v, want nil", f)
+	}
+	if err := s.Remove(ctx, open.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Remove again error = %v, want ErrNotFound", err)
+	}
+}
+
+// serve sends a request through the schedule's middleware, as an admin if
+// admin is set.
+func serve(s *Schedule, method, path string, admin bool, override string) (*httptest.ResponseRecorder, bool) {
+	called := false
+	handler := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(method, path, strings.NewReader(`{"Type":3,"Name":"x","Value":"y"}`))
+	req = req.WithContext(auth.WithAdmin(req.Context(), admin))
+	if override != "" {
+		req.Header.Set(OverrideHeader, override)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w, called
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	s, now := newTestSchedule(t)
+	ctx := context.Background()
+	if _, err := s.Add(ctx, 123, "incident 42", "admin", time.Time{}, now.Add(time.Hour)); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		admin      bool
+		override   string
+		wantCalled bool
+	}{
+		{"add record", http.MethodPost, "/dnszone/123/records", false, "", false},
+		{"delete record", http.MethodDelete, "/dnszone/123/records/7", false, "", false},
+		{"batch", http.MethodPost, "/dnszone/123/records/batch", false, "", false},
+		{"read", http.MethodGet, "/dnszone/123/records", false, "", true},
+		{"other zone", http.MethodPost, "/dnszone/124/records", false, "", true},
+		{"cancel scheduled change", http.MethodDelete, "/dnszone/123/scheduled/5", false, "", true},
+		{"diff", http.MethodPost, "/dnszone/123/diff", false, "", true},
+		{"sync", http.MethodPost, "/dnszone/123/sync", false, "", false},
+		{"pull zone with the same ID", http.MethodPost, "/pullzone/123/purgeCache", false, "", true},
+		{"scoped override", http.MethodPost, "/dnszone/123/records", false, "true", false},
+		{"admin without override", http.MethodPost, "/dnszone/123/records", true, "", false},
+		{"admin override", http.MethodPost, "/dnszone/123/records", true, "true", true},
+	}
+	for _, tt := range tests {
+		w, called := serve(s, tt.method, tt.path, tt.admin, tt.override)
+		if called != tt.wantCalled {
+			t.Errorf("%s: handler called = %v, want %v", tt.name, called, tt.wantCalled)
+		}
+		if !called && w.Code != http.StatusLocked {
+			t.Errorf("%s: status %d, want 423", tt.name, w.Code)
+		}
+	}
+
+	w, _ := serve(s, http.MethodPost, "/dnszone/123/records", false, "")
+	if body := w.Body.String(); !strings.Contains(body, `"zone_frozen"`) || !strings.Contains(body, "incident 42") {
+		t.Errorf("unexpected body: %s", body)
+	}
+	if got := w.Header().Get("Retry-After"); got != "3601" {
+		t.Errorf("Retry-After = %q, want 3601", got)
+	}
+}
+
+func TestRun_PrunesAndReloads(t *testing.T) {
+	t.Parallel()
+	var pruned bool
+	store := &mockstore.MockStorage{
+		PruneZoneFreezesFunc: func(context.Context, time.Time) (int64, error) {
+			pruned = true
+			return 1, nil
+		},
+		ListZoneFreezesFunc: func(context.Context) ([]*storage.ZoneFreeze, error) {
+			return []*storage.ZoneFreeze{{ID: 1, ZoneID: 9, StartsAt: time.Unix(0, 0)}}, nil
+		},
+	}
+	s := New(store, testLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	if !pruned || s.Active(9) == nil {
+		t.Errorf("Run did not prune and reload: pruned %v, active %+v", pruned, s.Active(9))
+	}
+}
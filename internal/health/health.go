@@ -0,0 +1,179 @@
+// Package health reports readiness as a tri-state built from dependency checks.
+//
+// A failing critical check (the database) marks the service down; a failing
+// non-critical check (the bunny.net API) only degrades it. Degraded still
+// answers 200, so orchestrators keep routing to a proxy whose upstream is
+// having trouble, while the body tells them why. Check errors are shown to
+// unauthenticated callers and must not contain secrets.
+//
+// Liveness is reported separately by Live, which checks nothing, so a slow
+// or broken dependency never gets a healthy process restarted.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds a single check.
+const checkTimeout = 5 * time.Second
+
+// Status is the outcome of a check or of all checks together.
+type Status string
+
+// Statuses, from best to worst.
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Check is one dependency check.
+type Check struct {
+	Name string
+	// Critical checks mark the service down when they fail; others degrade it.
+	Critical bool
+	// CacheFor reuses a result for this long, so frequent probes do not
+	// hammer the dependency. Zero runs the check on every request.
+	CacheFor time.Duration
+	Run      func(ctx context.Context) error
+	// RunDetail may be set instead of Run for checks that also report a
+	// short state, such as "configured", shown as the result's detail.
+	RunDetail func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    Status `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status  Status            `json:"status"`
+	Version string            `json:"version,omitempty"`
+	Checks  map[string]Result `json:"checks,omitempty"`
+}
+
+type cachedResult struct {
+	result Result
+	at     time.Time
+}
+
+// Checker runs dependency checks.
+type Checker struct {
+	checks  []Check
+	version string
+	now     func() time.Time
+
+	mu     sync.Mutex
+	cached map[string]cachedResult
+}
+
+// New creates a checker for the given checks.
+func New(checks ...Check) *Checker {
+	return &Checker{
+		checks: checks,
+		now:    time.Now,
+		cached: make(map[string]cachedResult),
+	}
+}
+
+// SetVersion includes the service version in reports.
+func (c *Checker) SetVersion(version string) {
+	c.version = version
+}
+
+// Check runs all checks concurrently and combines their results.
+func (c *Checker) Check(ctx context.Context) Report {
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Version: c.version, Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		r := results[i]
+		report.Checks[check.Name] = r
+		switch {
+		case r.Status == StatusOK:
+		case check.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run runs one check, or returns its cached result if still fresh.
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	if check.CacheFor > 0 {
+		c.mu.Lock()
+		cached, ok := c.cached[check.Name]
+		c.mu.Unlock()
+		if ok && c.now().Sub(cached.at) < check.CacheFor {
+			return cached.result
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := c.now()
+	var detail string
+	var err error
+	if check.RunDetail != nil {
+		detail, err = check.RunDetail(ctx)
+	} else {
+		err = check.Run(ctx)
+	}
+	result := Result{Status: StatusOK, Detail: detail, LatencyMS: c.now().Sub(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	if check.CacheFor > 0 {
+		c.mu.Lock()
+		c.cached[check.Name] = cachedResult{result: result, at: c.now()}
+		c.mu.Unlock()
+	}
+	return result
+}
+
+// ServeHTTP writes the report as JSON, with 503 Service Unavailable when down.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == StatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	//nolint:errcheck // Response write errors are unrecoverable
+	json.NewEncoder(w).Encode(report)
+}
+
+// Live returns a liveness handler: it always reports ok with the version,
+// without running any checks.
+func Live(version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		//nolint:errcheck // Response write errors are unrecoverable
+		json.NewEncoder(w).Encode(Report{Status: StatusOK, Version: version})
+	}
+}
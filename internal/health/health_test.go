@@ -0,0 +1,168 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func okCheck(name string, critical bool) Check {
+	return Check{Name: name, Critical: critical, Run: func(context.Context) error { return nil }}
+}
+
+func failCheck(name string, critical bool) Check {
+	return Check{Name: name, Critical: critical, Run: func(context.Context) error { return errors.New(name + " unavailable") }}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		checks []Check
+		want   Status
+	}{
+		{"no checks", nil, StatusOK},
+		{"all ok", []Check{okCheck("database", true), okCheck("upstream", false)}, StatusOK},
+		{"non-critical failing", []Check{okCheck("database", true), failCheck("upstream", false)}, StatusDegraded},
+		{"critical failing", []Check{failCheck("database", true), okCheck("upstream", false)}, StatusDown},
+		{"both failing", []Check{failCheck("upstream", false), failCheck("database", true)}, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			report := New(tt.checks...).Check(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("Status = %q, want %q", report.Status, tt.want)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("got %d check results, want %d", len(report.Checks), len(tt.checks))
+			}
+		})
+	}
+}
+
+func TestCheckResultError(t *testing.T) {
+	t.Parallel()
+
+	report := New(failCheck("upstream", false)).Check(context.Background())
+	got := report.Checks["upstream"]
+	if got.Status != StatusDown || got.Error != "upstream unavailable" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+}
+
+func TestCheckCaching(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	c := New(Check{
+		Name:     "upstream",
+		CacheFor: time.Minute,
+		Run: func(context.Context) error {
+			calls.Add(1)
+			return nil
+		},
+	})
+	now := time.Unix(1_700_000_000, 0)
+	c.now = func() time.Time { return now }
+
+	c.Check(context.Background())
+	c.Check(context.Background())
+	if got := calls.Load(); got != 1 {
+		t.Errorf("check ran %d times within CacheFor, want 1", got)
+	}
+
+	now = now.Add(time.Minute)
+	c.Check(context.Background())
+	if got := calls.Load(); got != 2 {
+		t.Errorf("check ran %d times after CacheFor, want 2", got)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	t.Parallel()
+
+	c := New(Check{
+		Name: "slow",
+		Run: func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("no deadline")
+			}
+			return nil
+		},
+	})
+	if got := c.Check(context.Background()).Status; got != StatusOK {
+		t.Errorf("Status = %q, want ok (check should get a deadline)", got)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		checks   []Check
+		wantCode int
+		want     Status
+	}{
+		{[]Check{okCheck("database", true)}, http.StatusOK, StatusOK},
+		{[]Check{okCheck("database", true), failCheck("upstream", false)}, http.StatusOK, StatusDegraded},
+		{[]Check{failCheck("database", true)}, http.StatusServiceUnavailable, StatusDown},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		New(tt.checks...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: status code = %d, want %d", tt.want, w.Code, tt.wantCode)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		var report Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode report: %v", err)
+		}
+		if report.Status != tt.want {
+			t.Errorf("Status = %q, want %q", report.Status, tt.want)
+		}
+	}
+}
+
+func TestCheckDetail(t *testing.T) {
+	t.Parallel()
+
+	c := New(Check{Name: "bootstrap", RunDetail: func(context.Context) (string, error) { return "configured", nil }})
+	c.SetVersion("1.2.3")
+	report := c.Check(context.Background())
+	if report.Version != "1.2.3" {
+		t.Errorf("Version = %q, want 1.2.3", report.Version)
+	}
+	if got := report.Checks["bootstrap"]; got.Status != StatusOK || got.Detail != "configured" {
+		t.Errorf("unexpected result: %+v", got)
+	}
+
+	c = New(Check{Name: "bootstrap", RunDetail: func(context.Context) (string, error) { return "", errors.New("state unavailable") }})
+	if got := c.Check(context.Background()); got.Status != StatusDegraded || got.Checks["bootstrap"].Error != "state unavailable" {
+		t.Errorf("unexpected report: %+v", got)
+	}
+}
+
+func TestLive(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	Live("1.2.3")(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status code = %d, want 200", w.Code)
+	}
+	if body := w.Body.String(); body != `{"status":"ok","version":"1.2.3"}`+"\n" {
+		t.Errorf("body = %s", body)
+	}
+}
@@ -0,0 +1,176 @@
+// Package honeypot serves decoy paths that no legitimate client requests.
+//
+// The decoys look like admin endpoints and files that scanners probe for on
+// internet-exposed services. A request for one answers 404 like any unknown
+// path, bans the client address through the blocklist and publishes a system
+// alert, so scanning is caught before it finds anything real.
+package honeypot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+const (
+	// DefaultBanDuration is how long a client that hit a decoy stays blocked.
+	DefaultBanDuration = 24 * time.Hour
+
+	// createdBy is recorded as the creator of blocklist entries added here.
+	createdBy = "honeypot"
+
+	// alertHoneypotHit is the alert name for a decoy path being requested.
+	alertHoneypotHit = "honeypot_hit"
+)
+
+// DefaultPaths are the decoy paths used when none are configured. None of
+// them is served by the proxy.
+var DefaultPaths = []string{
+	"/admin/api/keys",
+	"/admin/api/users",
+	"/admin/api/backup",
+	"/admin/api/debug/vars",
+	"/admin/.env",
+	"/.env",
+	"/.git/config",
+	"/wp-login.php",
+	"/phpmyadmin",
+	"/server-status",
+}
+
+// Blocker bans client addresses. It is satisfied by *blocklist.Blocklist.
+type Blocker interface {
+	ClientIP(r *http.Request) (netip.Addr, bool)
+	Add(ctx context.Context, cidr, reason, createdBy string, ttl time.Duration) (*storage.BlocklistEntry, error)
+}
+
+// Alerter delivers operational alerts. It is satisfied by *outbox.Outbox.
+type Alerter interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// Option configures a Honeypot.
+type Option func(*Honeypot)
+
+// WithAlerter publishes a system alert when a client is banned.
+func WithAlerter(alerter Alerter) Option {
+	return func(h *Honeypot) {
+		h.alerter = alerter
+	}
+}
+
+// WithBanDuration sets how long a client stays blocked. Zero bans permanently.
+func WithBanDuration(d time.Duration) Option {
+	return func(h *Honeypot) {
+		h.banDuration = d
+	}
+}
+
+// Honeypot intercepts requests for decoy paths.
+type Honeypot struct {
+	paths       map[string]bool
+	blocker     Blocker
+	alerter     Alerter
+	banDuration time.Duration
+	logger      *slog.Logger
+}
+
+// New creates a honeypot for the given decoy paths, or DefaultPaths if none
+// are given. Paths must start with "/"; a trailing slash is ignored.
+func New(paths []string, blocker Blocker, logger *slog.Logger, opts ...Option) (*Honeypot, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if len(paths) == 0 {
+		paths = DefaultPaths
+	}
+
+	h := &Honeypot{
+		paths:       make(map[string]bool, len(paths)),
+		blocker:     blocker,
+		banDuration: DefaultBanDuration,
+		logger:      logger,
+	}
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") || p == "/" {
+			return nil, fmt.Errorf("invalid honeypot path %q: must start with / and not be the root", p)
+		}
+		h.paths[normalize(p)] = true
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
+}
+
+// normalize drops a trailing slash so "/phpmyadmin/" matches "/phpmyadmin".
+func normalize(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// Middleware answers requests for decoy paths with 404 Not Found and bans the
+// client. Other requests are passed on. It must run after the blocklist
+// middleware, so a banned client is rejected there and alerts are not repeated.
+func (h *Honeypot) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.paths[normalize(r.URL.Path)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		metrics.RecordHoneypotHit()
+		h.trip(context.WithoutCancel(r.Context()), r)
+		http.NotFound(w, r)
+	})
+}
+
+// trip bans the client that requested a decoy and reports it.
+func (h *Honeypot) trip(ctx context.Context, r *http.Request) {
+	addr, ok := h.blocker.ClientIP(r)
+	if !ok {
+		h.logger.Warn("honeypot hit from unknown address", "method", r.Method, "path", r.URL.Path)
+		return
+	}
+	h.logger.Warn("honeypot hit", "client_ip", addr.String(), "method", r.Method, "path", r.URL.Path,
+		"user_agent", r.UserAgent())
+
+	entry, err := h.blocker.Add(ctx, addr.String(), "honeypot: "+r.Method+" "+r.URL.Path, createdBy, h.banDuration)
+	if err != nil {
+		// A concurrent hit from the same client already banned it
+		if !errors.Is(err, storage.ErrDuplicate) {
+			h.logger.Error("failed to blocklist honeypot client", "error", err, "client_ip", addr.String())
+		}
+		return
+	}
+
+	if h.alerter == nil {
+		return
+	}
+	data := map[string]any{
+		"alert":      alertHoneypotHit,
+		"message":    fmt.Sprintf("%s requested honeypot path %s and was blocklisted", addr, r.URL.Path),
+		"client_ip":  addr.String(),
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"user_agent": r.UserAgent(),
+		"entry_id":   entry.ID,
+	}
+	if !entry.ExpiresAt.IsZero() {
+		data["expires_at"] = entry.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if err := h.alerter.Publish(ctx, webhook.EventSystemAlert, data); err != nil {
+		h.logger.Error("failed to publish honeypot alert", "error", err)
+	}
+}
@@ -0,0 +1,141 @@
+package honeypot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/blocklist"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type fakeAlerter struct {
+	mu     sync.Mutex
+	events []string
+	data   []map[string]any
+}
+
+func (a *fakeAlerter) Publish(_ context.Context, event string, data any) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+	a.data = append(a.data, data.(map[string]any))
+	return nil
+}
+
+func newTestBlocklist(t *testing.T) *blocklist.Blocklist {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return blocklist.New(store, testLogger())
+}
+
+func TestNew_InvalidPath(t *testing.T) {
+	t.Parallel()
+	for _, p := range []string{"admin/keys", "/", ""} {
+		if _, err := New([]string{p}, nil, testLogger()); err == nil {
+			t.Errorf("expected error for path %q", p)
+		}
+	}
+}
+
+func TestMiddleware_BansAndAlerts(t *testing.T) {
+	t.Parallel()
+	bl := newTestBlocklist(t)
+	alerter := &fakeAlerter{}
+	h, err := New(nil, bl, testLogger(), WithAlerter(alerter), WithBanDuration(time.Hour))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	called := 0
+	handler := bl.Middleware(h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		called++
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	// Ordinary requests pass through
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dnszone", nil))
+	if w.Code != http.StatusOK || called != 1 {
+		t.Fatalf("expected request to reach the handler, got %d", w.Code)
+	}
+
+	// A decoy looks like any unknown path
+	r := httptest.NewRequest(http.MethodGet, "/.env/", nil)
+	r.Header.Set("User-Agent", "scanner/1.0")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound || called != 1 {
+		t.Fatalf("expected 404 without reaching the handler, got %d", w.Code)
+	}
+	if !bl.Blocked(netip.MustParseAddr("192.0.2.1")) {
+		t.Fatal("expected client to be blocklisted")
+	}
+	entries, _ := bl.List(context.Background())
+	if len(entries) != 1 || entries[0].CreatedBy != "honeypot" || entries[0].Reason != "honeypot: GET /.env/" || entries[0].ExpiresAt.IsZero() {
+		t.Errorf("unexpected blocklist entries: %+v", entries)
+	}
+
+	if len(alerter.events) != 1 || alerter.events[0] != webhook.EventSystemAlert {
+		t.Fatalf("expected one system alert, got %v", alerter.events)
+	}
+	if d := alerter.data[0]; d["alert"] != alertHoneypotHit || d["client_ip"] != "192.0.2.1" || d["user_agent"] != "scanner/1.0" {
+		t.Errorf("unexpected alert data: %v", d)
+	}
+	if _, err := json.Marshal(alerter.data[0]); err != nil {
+		t.Errorf("alert data must be JSON-encodable: %v", err)
+	}
+
+	// The banned client is now rejected by the blocklist, even on real paths
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dnszone", nil))
+	if w.Code != http.StatusForbidden || called != 1 {
+		t.Errorf("expected banned client to be rejected, got %d", w.Code)
+	}
+}
+
+type failingBlocker struct {
+	err error
+}
+
+func (b failingBlocker) ClientIP(*http.Request) (netip.Addr, bool) {
+	return netip.MustParseAddr("198.51.100.7"), true
+}
+
+func (b failingBlocker) Add(context.Context, string, string, string, time.Duration) (*storage.BlocklistEntry, error) {
+	return nil, b.err
+}
+
+func TestMiddleware_NoAlertWhenNotBanned(t *testing.T) {
+	t.Parallel()
+	for _, err := range []error{storage.ErrDuplicate, errors.New("database is locked")} {
+		alerter := &fakeAlerter{}
+		h, _ := New([]string{"/admin/api/keys"}, failingBlocker{err: err}, testLogger(), WithAlerter(alerter))
+
+		w := httptest.NewRecorder()
+		h.Middleware(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/api/keys", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%v: expected 404, got %d", err, w.Code)
+		}
+		if len(alerter.events) != 0 {
+			t.Errorf("%v: expected no alert, got %v", err, alerter.events)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Package idempotency replays the stored response to a retried mutating
+// request.
+//
+// Clients send a unique value in the Idempotency-Key header. The first
+// request with a key runs as usual and its response is stored; a request
+// repeating the key within the TTL gets the stored response instead of
+// running again, so a client retrying after a network timeout does not, for
+// example, create a second TXT record. Keys are scoped to the token and
+// recorded in storage, so a retry is recognized by any instance sharing the
+// database.
+//
+// A key may only be reused for the same request: the same method, path and
+// body. Responses with a 5xx status are not stored, so a request that failed
+// upstream can be retried with its key.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// Header carries the client's key for a request.
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader is set to "true" on stored responses sent for a retry.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultTTL is how long a key and its response are kept.
+	DefaultTTL = 24 * time.Hour
+
+	// pruneInterval is how often expired keys are deleted.
+	pruneInterval = time.Minute
+
+	// maxStoredBody is the largest response body stored for a key. A request
+	// with a larger response releases its key instead.
+	maxStoredBody = 1 << 20
+)
+
+// validKey limits keys to 1-255 printable ASCII characters without spaces,
+// enough for a UUID or any random string.
+var validKey = regexp.MustCompile(`^[\x21-\x7e]{1,255}$`)
+
+// errBusy reports a key that was released and claimed again while it was
+// being looked up.
+var errBusy = errors.New("idempotency key is busy")
+
+// Store defines the storage operations needed by the guard.
+type Store interface {
+	ClaimIdempotencyKey(ctx context.Context, tokenID int64, key, fingerprint string, at time.Time) error
+	GetIdempotentResponse(ctx context.Context, tokenID int64, key string) (*storage.IdempotentResponse, error)
+	CompleteIdempotencyKey(ctx context.Context, tokenID int64, key string, status int, contentType string, body []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, tokenID int64, key string) error
+	PruneIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Option configures a Guard.
+type Option func(*Guard)
+
+// WithTTL sets how long keys and their responses are kept. Zero disables
+// the guard.
+func WithTTL(d time.Duration) Option {
+	return func(g *Guard) {
+		g.ttl = d
+	}
+}
+
+// WithWriteErrorHandler calls fn with every error from recording a key,
+// so storage failures can be detected.
+func WithWriteErrorHandler(fn func(error)) Option {
+	return func(g *Guard) {
+		g.onWriteError = fn
+	}
+}
+
+// Guard stores the responses to requests sent with an Idempotency-Key and
+// replays them for retries.
+type Guard struct {
+	store        Store
+	logger       *slog.Logger
+	ttl          time.Duration
+	now          func() time.Time
+	onWriteError func(error)
+}
+
+// New creates an idempotency guard keeping keys for DefaultTTL unless
+// WithTTL says otherwise.
+func New(store Store, logger *slog.Logger, opts ...Option) *Guard {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	g := &Guard{
+		store:  store,
+		logger: logger,
+		ttl:    DefaultTTL,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Enabled reports whether keys are honored.
+func (g *Guard) Enabled() bool {
+	return g.ttl > 0
+}
+
+// Middleware honors the Idempotency-Key header on mutating requests. A retry
+// of a completed request gets the stored response, with ReplayedHeader set.
+// A retry while the first request is still running gets 409 Conflict, and a
+// key reused for a different request gets 422 Unprocessable Entity. Requests
+// without the header and reads are passed through. It must run after
+// authentication; keys are scoped to the token, and to token ID 0 for the
+// master key.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	if !g.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || readOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validKey.MatchString(key) {
+			metrics.RecordIdempotentRequest("invalid")
+			writeError(w, http.StatusBadRequest, "invalid_idempotency_key",
+				Header+" must be 1-255 printable characters without spaces.")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "request body could not be read")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var tokenID int64
+		if token := auth.TokenFromContext(r.Context()); token != nil {
+			tokenID = token.ID
+		}
+		fingerprint := requestFingerprint(r, body)
+
+		stored, err := g.claim(r.Context(), tokenID, key, fingerprint)
+		switch {
+		case errors.Is(err, errBusy):
+			metrics.RecordIdempotentRequest("in_progress")
+			writeError(w, http.StatusConflict, "idempotency_key_in_use",
+				"A request with this "+Header+" is still in progress.")
+			return
+		case err != nil:
+			metrics.RecordIdempotentRequest("error")
+			g.logger.Error("failed to claim idempotency key", "error", err)
+			g.reportWriteError(err)
+			writeError(w, http.StatusInternalServerError, "internal_error", "internal error")
+			return
+		case stored == nil:
+			metrics.RecordIdempotentRequest("new")
+			g.serveFirst(w, r, next, tokenID, key)
+			return
+		}
+
+		if stored.Fingerprint != fingerprint {
+			metrics.RecordIdempotentRequest("mismatch")
+			g.logger.Warn("idempotency key reused for a different request", "method", r.Method, "path", r.URL.Path)
+			writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused",
+				Header+" was already used for a different request.")
+			return
+		}
+		if stored.Status == 0 {
+			metrics.RecordIdempotentRequest("in_progress")
+			writeError(w, http.StatusConflict, "idempotency_key_in_use",
+				"A request with this "+Header+" is still in progress.")
+			return
+		}
+
+		metrics.RecordIdempotentRequest("replayed")
+		g.logger.Debug("replayed idempotent response", "method", r.Method, "path", r.URL.Path, "status", stored.Status)
+		if stored.ContentType != "" {
+			w.Header().Set("Content-Type", stored.ContentType)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(stored.Status)
+		_, _ = w.Write(stored.Body) //nolint:errcheck
+	})
+}
+
+// claim records a new key, returning nil, or returns the request already
+// recorded for it. A key older than the TTL that was not pruned yet is
+// claimed again.
+func (g *Guard) claim(ctx context.Context, tokenID int64, key, fingerprint string) (*storage.IdempotentResponse, error) {
+	now := g.now()
+	for range 2 {
+		err := g.store.ClaimIdempotencyKey(ctx, tokenID, key, fingerprint, now)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, storage.ErrDuplicate) {
+			return nil, err
+		}
+
+		stored, err := g.store.GetIdempotentResponse(ctx, tokenID, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue // released since the claim failed
+		}
+		if err != nil {
+			return nil, err
+		}
+		if now.Sub(stored.CreatedAt) < g.ttl {
+			return stored, nil
+		}
+		if err := g.store.ReleaseIdempotencyKey(ctx, tokenID, key); err != nil {
+			return nil, err
+		}
+	}
+	return nil, errBusy
+}
+
+// serveFirst runs the first request with a key and stores its response.
+// The key is released if the response is not stored, including when the
+// handler panics, so the request can be retried.
+func (g *Guard) serveFirst(w http.ResponseWriter, r *http.Request, next http.Handler, tokenID int64, key string) {
+	// Store the outcome even if the client goes away
+	ctx := context.WithoutCancel(r.Context())
+	rec := &responseRecorder{ResponseWriter: w}
+	stored := false
+	defer func() {
+		if !stored {
+			if err := g.store.ReleaseIdempotencyKey(ctx, tokenID, key); err != nil {
+				g.logger.Error("failed to release idempotency key", "error", err)
+				g.reportWriteError(err)
+			}
+		}
+	}()
+
+	next.ServeHTTP(rec, r)
+
+	status := rec.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status >= http.StatusInternalServerError || rec.overflow {
+		return
+	}
+	if err := g.store.CompleteIdempotencyKey(ctx, tokenID, key, status, w.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+		g.logger.Error("failed to store idempotent response", "error", err)
+		g.reportWriteError(err)
+		return
+	}
+	stored = true
+}
+
+// reportWriteError passes err to the write error handler, if there is one.
+func (g *Guard) reportWriteError(err error) {
+	if g.onWriteError != nil {
+		g.onWriteError(err)
+	}
+}
+
+// Run prunes expired keys until ctx is canceled.
+func (g *Guard) Run(ctx context.Context) {
+	if !g.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.prune(ctx)
+		}
+	}
+}
+
+// prune deletes keys claimed more than a TTL ago.
+func (g *Guard) prune(ctx context.Context) {
+	n, err := g.store.PruneIdempotencyKeys(ctx, g.now().Add(-g.ttl))
+	if err != nil {
+		g.logger.Error("failed to prune idempotency keys", "error", err)
+		return
+	}
+	if n > 0 {
+		g.logger.Debug("pruned idempotency keys", "count", n)
+	}
+}
+
+// requestFingerprint identifies a request by its method, path, query and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxStoredBody {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// readOnlyMethod reports whether a request method never writes.
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// writeError writes a JSON error response with code and message.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":   code,
+		"message": message,
+	})
+}
@@ -0,0 +1,232 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestStore(t *testing.T) *storage.SQLiteStorage {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// countingHandler answers with status and counts the requests it serves.
+type countingHandler struct {
+	status int
+	calls  int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `,"body":"` + string(body) + `"}`))
+}
+
+// serve sends a request through handler as the given token.
+func serve(handler http.Handler, method, path string, tokenID int64, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	if tokenID != 0 {
+		req = req.WithContext(auth.WithToken(req.Context(), &storage.Token{ID: tokenID}))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	t.Parallel()
+	g := New(newTestStore(t), testLogger())
+	next := &countingHandler{status: http.StatusCreated}
+	handler := g.Middleware(next)
+
+	first := serve(handler, http.MethodPut, "/dnszone/1/records", 5, "key-1", "txt")
+	if first.Code != http.StatusCreated || first.Header().Get(ReplayedHeader) != "" {
+		t.Fatalf("first request: status %d, replayed %q", first.Code, first.Header().Get(ReplayedHeader))
+	}
+
+	retry := serve(handler, http.MethodPut, "/dnszone/1/records", 5, "key-1", "txt")
+	if retry.Code != http.StatusCreated || retry.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("retry: status %d, replayed %q", retry.Code, retry.Header().Get(ReplayedHeader))
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("retry body = %q (%s), want %q", retry.Body.String(), retry.Header().Get("Content-Type"), first.Body.String())
+	}
+	if next.calls != 1 {
+		t.Errorf("handler ran %d times, want 1", next.calls)
+	}
+
+	// Keys are per token, and requests without a key always run
+	serve(handler, http.MethodPut, "/dnszone/1/records", 6, "key-1", "txt")
+	serve(handler, http.MethodPut, "/dnszone/1/records", 5, "", "txt")
+	serve(handler, http.MethodGet, "/dnszone/1", 5, "key-1", "")
+	if next.calls != 4 {
+		t.Errorf("handler ran %d times, want 4", next.calls)
+	}
+
+	// A key reused for another request is rejected
+	for _, req := range [][3]string{
+		{http.MethodPut, "/dnszone/1/records", "other"},
+		{http.MethodPut, "/dnszone/2/records", "txt"},
+		{http.MethodDelete, "/dnszone/1/records", "txt"},
+	} {
+		if w := serve(handler, req[0], req[1], 5, "key-1", req[2]); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s %s with body %q: status %d, want 422", req[0], req[1], req[2], w.Code)
+		}
+	}
+}
+
+func TestMiddleware_ServerErrorsAreNotStored(t *testing.T) {
+	t.Parallel()
+	g := New(newTestStore(t), testLogger())
+	next := &countingHandler{status: http.StatusBadGateway}
+	handler := g.Middleware(next)
+
+	serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", "")
+	next.status = http.StatusNoContent
+	if w := serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", ""); w.Code != http.StatusNoContent {
+		t.Errorf("retry after a server error: status %d, want 204", w.Code)
+	}
+	if w := serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", ""); w.Header().Get(ReplayedHeader) != "true" {
+		t.Error("successful retry was not stored")
+	}
+	if next.calls != 2 {
+		t.Errorf("handler ran %d times, want 2", next.calls)
+	}
+}
+
+func TestMiddleware_InProgress(t *testing.T) {
+	t.Parallel()
+	g := New(newTestStore(t), testLogger())
+	var retry *httptest.ResponseRecorder
+	var handler http.Handler
+	handler = g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if retry == nil {
+			retry = serve(handler, http.MethodDelete, "/dnszone/1/records/2", 5, "key-1", "")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve(handler, http.MethodDelete, "/dnszone/1/records/2", 5, "key-1", "")
+	if retry.Code != http.StatusConflict {
+		t.Errorf("retry during the first request: status %d, want 409", retry.Code)
+	}
+}
+
+func TestMiddleware_ReleasesKeyOnPanic(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	g := New(store, testLogger())
+	handler := g.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { _ = recover() }()
+		serve(handler, http.MethodDelete, "/dnszone/1", 5, "key-1", "")
+	}()
+	if _, err := store.GetIdempotentResponse(context.Background(), 5, "key-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("key after panic: %v, want ErrNotFound", err)
+	}
+}
+
+func TestMiddleware_ExpiredKeyRunsAgain(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	now := time.Unix(1_700_000_000, 0)
+	g := New(store, testLogger(), WithTTL(time.Hour))
+	g.now = func() time.Time { return now }
+	next := &countingHandler{status: http.StatusOK}
+	handler := g.Middleware(next)
+
+	serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", "")
+	now = now.Add(time.Hour)
+	if w := serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", ""); w.Header().Get(ReplayedHeader) != "" {
+		t.Error("expired key was replayed")
+	}
+	if next.calls != 2 {
+		t.Errorf("handler ran %d times, want 2", next.calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	g.prune(context.Background())
+	if _, err := store.GetIdempotentResponse(context.Background(), 5, "key-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("key after prune: %v, want ErrNotFound", err)
+	}
+}
+
+func TestMiddleware_InvalidKey(t *testing.T) {
+	t.Parallel()
+	g := New(newTestStore(t), testLogger())
+	next := &countingHandler{status: http.StatusOK}
+	handler := g.Middleware(next)
+
+	for _, key := range []string{"has space", strings.Repeat("k", 256), "café"} {
+		if w := serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, key, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("key %q: status %d, want 400", key, w.Code)
+		}
+	}
+	if next.calls != 0 {
+		t.Errorf("handler ran %d times, want 0", next.calls)
+	}
+}
+
+func TestMiddleware_StorageError(t *testing.T) {
+	t.Parallel()
+	var reported error
+	store := &mockstore.MockStorage{
+		ClaimIdempotencyKeyFunc: func(context.Context, int64, string, string, time.Time) error {
+			return errors.New("disk full")
+		},
+	}
+	g := New(store, testLogger(), WithWriteErrorHandler(func(err error) { reported = err }))
+	next := &countingHandler{status: http.StatusOK}
+
+	if w := serve(g.Middleware(next), http.MethodPost, "/dnszone/1/records/2", 5, "key-1", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", w.Code)
+	}
+	if reported == nil || next.calls != 0 {
+		t.Errorf("reported %v after %d calls, want the error and no calls", reported, next.calls)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	t.Parallel()
+	g := New(nil, testLogger(), WithTTL(0))
+	if g.Enabled() {
+		t.Error("guard with a zero TTL is enabled")
+	}
+	next := &countingHandler{status: http.StatusOK}
+	handler := g.Middleware(next)
+	serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", "")
+	serve(handler, http.MethodPost, "/dnszone/1/records/2", 5, "key-1", "")
+	if next.calls != 2 {
+		t.Errorf("handler ran %d times, want 2", next.calls)
+	}
+	g.Run(context.Background()) // returns at once
+}
@@ -0,0 +1,163 @@
+// Package instance gives each proxy process an identity that survives
+// restarts and registers it in the database, so replicas sharing storage can
+// be told apart.
+//
+// Unless configured explicitly, the ID is a UUID generated on the first start
+// on a host and reused by later starts with the same hostname. Run keeps the
+// registration fresh with a heartbeat and removes long-gone instances.
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// heartbeatInterval is how often an instance refreshes its registration.
+	heartbeatInterval = 30 * time.Second
+
+	// staleAfter is how long an instance may miss heartbeats before it is
+	// reported inactive.
+	staleAfter = 3 * heartbeatInterval
+
+	// retention is how long an inactive instance stays registered.
+	retention = 7 * 24 * time.Hour
+)
+
+// Store defines the storage operations needed by the instance registry.
+type Store interface {
+	GetInstanceByHostname(ctx context.Context, hostname string) (*storage.Instance, error)
+	UpsertInstance(ctx context.Context, inst *storage.Instance) error
+	TouchInstance(ctx context.Context, id string, at time.Time) error
+	ListInstances(ctx context.Context) ([]*storage.Instance, error)
+	PruneInstances(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Resolve returns the ID for this process. A configured ID is used as is;
+// otherwise the ID last registered from hostname is reused, or a new one is
+// generated on the first start.
+func Resolve(ctx context.Context, store Store, configured, hostname string) (string, error) {
+	if configured != "" {
+		return configured, nil
+	}
+	if hostname != "" {
+		inst, err := store.GetInstanceByHostname(ctx, hostname)
+		if err == nil {
+			return inst.ID, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return "", fmt.Errorf("failed to look up instance ID: %w", err)
+		}
+	}
+	return uuid.NewString(), nil
+}
+
+// Status describes a registered instance.
+type Status struct {
+	storage.Instance
+
+	// Active is false once the instance has missed several heartbeats.
+	Active bool
+	// Current marks the instance serving the request.
+	Current bool
+}
+
+// Registry registers this process and lists the others.
+type Registry struct {
+	store  Store
+	logger *slog.Logger
+	self   storage.Instance
+	now    func() time.Time
+}
+
+// NewRegistry creates a registry for the process described by self.
+// self.ID is normally the result of Resolve.
+func NewRegistry(store Store, self storage.Instance, logger *slog.Logger) *Registry {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Registry{
+		store:  store,
+		logger: logger,
+		self:   self,
+		now:    time.Now,
+	}
+}
+
+// ID returns this process's instance ID.
+func (r *Registry) ID() string {
+	return r.self.ID
+}
+
+// Run registers this process and sends heartbeats until ctx is cancelled.
+func (r *Registry) Run(ctx context.Context) {
+	r.register(ctx)
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.heartbeat(ctx)
+		}
+	}
+}
+
+// register records this process as started now.
+func (r *Registry) register(ctx context.Context) {
+	inst := r.self
+	inst.StartedAt = r.now()
+	inst.LastSeenAt = inst.StartedAt
+	if err := r.store.UpsertInstance(ctx, &inst); err != nil {
+		r.logger.Error("failed to register instance", "error", err)
+		return
+	}
+	r.self.StartedAt = inst.StartedAt
+}
+
+// heartbeat refreshes the registration and prunes instances gone for longer
+// than the retention period.
+func (r *Registry) heartbeat(ctx context.Context) {
+	now := r.now()
+	err := r.store.TouchInstance(ctx, r.self.ID, now)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && r.self.StartedAt.IsZero()) {
+		// Pruned by another replica, or the first registration failed
+		r.register(ctx)
+	} else if err != nil {
+		r.logger.Error("failed to send instance heartbeat", "error", err)
+	}
+
+	if n, err := r.store.PruneInstances(ctx, now.Add(-retention)); err != nil {
+		r.logger.Error("failed to prune instances", "error", err)
+	} else if n > 0 {
+		r.logger.Info("pruned inactive instances", "count", n)
+	}
+}
+
+// List returns every registered instance, most recently seen first.
+func (r *Registry) List(ctx context.Context) ([]Status, error) {
+	instances, err := r.store.ListInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := r.now().Add(-staleAfter)
+	statuses := make([]Status, 0, len(instances))
+	for _, inst := range instances {
+		statuses = append(statuses, Status{
+			Instance: *inst,
+			Active:   !inst.LastSeenAt.Before(cutoff),
+			Current:  inst.ID == r.self.ID,
+		})
+	}
+	return statuses, nil
+}
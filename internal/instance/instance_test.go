@@ -0,0 +1,149 @@
+package instance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	known := &mockstore.MockStorage{GetInstanceByHostnameFunc: func(_ context.Context, hostname string) (*storage.Instance, error) {
+		return &storage.Instance{ID: "id-for-" + hostname}, nil
+	}}
+	unknown := &mockstore.MockStorage{}
+	failing := &mockstore.MockStorage{GetInstanceByHostnameFunc: func(context.Context, string) (*storage.Instance, error) {
+		return nil, errors.New("db down")
+	}}
+
+	if id, err := Resolve(ctx, failing, "proxy-eu-1", "host"); err != nil || id != "proxy-eu-1" {
+		t.Errorf("expected configured ID to win, got %q, %v", id, err)
+	}
+	if id, err := Resolve(ctx, known, "", "host"); err != nil || id != "id-for-host" {
+		t.Errorf("expected ID registered for host, got %q, %v", id, err)
+	}
+	id, err := Resolve(ctx, unknown, "", "host")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		t.Errorf("expected generated UUID, got %q", id)
+	}
+	if _, err := Resolve(ctx, failing, "", "host"); err == nil {
+		t.Error("expected lookup error to be returned")
+	}
+}
+
+func TestRegistry_Heartbeat(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var upserts []storage.Instance
+	var touched time.Time
+	var prunedBefore time.Time
+	touchErr := error(nil)
+	store := &mockstore.MockStorage{
+		UpsertInstanceFunc: func(_ context.Context, inst *storage.Instance) error {
+			upserts = append(upserts, *inst)
+			return nil
+		},
+		TouchInstanceFunc: func(_ context.Context, id string, at time.Time) error {
+			if id != "abc" {
+				t.Errorf("expected own ID to be touched, got %q", id)
+			}
+			touched = at
+			return touchErr
+		},
+		PruneInstancesFunc: func(_ context.Context, before time.Time) (int64, error) {
+			prunedBefore = before
+			return 0, nil
+		},
+	}
+	now := time.Unix(1_700_000_000, 0)
+	r := NewRegistry(store, storage.Instance{ID: "abc", Hostname: "host", Version: "1.0.0"}, testLogger())
+	r.now = func() time.Time { return now }
+
+	r.register(ctx)
+	if len(upserts) != 1 || upserts[0].Version != "1.0.0" || !upserts[0].StartedAt.Equal(now) {
+		t.Fatalf("unexpected registration: %+v", upserts)
+	}
+
+	now = now.Add(heartbeatInterval)
+	r.heartbeat(ctx)
+	if !touched.Equal(now) || len(upserts) != 1 {
+		t.Errorf("expected a touch without re-registering, got touched=%v upserts=%d", touched, len(upserts))
+	}
+	if !prunedBefore.Equal(now.Add(-retention)) {
+		t.Errorf("expected prune cutoff %v, got %v", now.Add(-retention), prunedBefore)
+	}
+
+	// Re-register after another replica pruned this instance
+	touchErr = storage.ErrNotFound
+	r.heartbeat(ctx)
+	if len(upserts) != 2 || !upserts[1].StartedAt.Equal(now) {
+		t.Errorf("expected re-registration, got %+v", upserts)
+	}
+}
+
+func TestRegistry_List(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	store := &mockstore.MockStorage{ListInstancesFunc: func(context.Context) ([]*storage.Instance, error) {
+		return []*storage.Instance{
+			{ID: "abc", LastSeenAt: now.Add(-heartbeatInterval)},
+			{ID: "def", LastSeenAt: now.Add(-staleAfter - time.Second)},
+		}, nil
+	}}
+	r := NewRegistry(store, storage.Instance{ID: "abc"}, nil)
+	r.now = func() time.Time { return now }
+
+	got, err := r.List(context.Background())
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(got) != 2 || !got[0].Active || !got[0].Current || got[1].Active || got[1].Current {
+		t.Errorf("unexpected statuses: %+v", got)
+	}
+	if r.ID() != "abc" {
+		t.Errorf("ID() = %q, want abc", r.ID())
+	}
+
+	failing := NewRegistry(&mockstore.MockStorage{ListInstancesFunc: func(context.Context) ([]*storage.Instance, error) {
+		return nil, errors.New("db down")
+	}}, storage.Instance{ID: "abc"}, nil)
+	if _, err := failing.List(context.Background()); err == nil {
+		t.Error("expected list error to be returned")
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		NewRegistry(&mockstore.MockStorage{}, storage.Instance{ID: "abc"}, testLogger()).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
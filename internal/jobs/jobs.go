@@ -0,0 +1,252 @@
+// Package jobs runs long-running operations, such as large zone imports, in
+// the background so the request that starts them can return at once.
+//
+// A job is recorded in storage when it is submitted and its status, progress
+// and result are updated as it runs, so clients can poll it. Work itself is
+// held in memory: jobs still queued or running when the process stops are
+// marked as failed by the next Run.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// DefaultQueueSize is how many jobs may wait for the worker.
+	DefaultQueueSize = 16
+
+	// DefaultRetention is how long finished jobs are kept.
+	DefaultRetention = 24 * time.Hour
+
+	// pruneInterval is how often finished jobs are pruned.
+	pruneInterval = 10 * time.Minute
+
+	// progressInterval is the least time between progress updates in storage.
+	progressInterval = time.Second
+)
+
+// ErrQueueFull is returned by Submit when too many jobs are waiting.
+var ErrQueueFull = errors.New("job queue is full")
+
+// Store defines the storage operations needed by the runner.
+type Store interface {
+	CreateJob(ctx context.Context, j *storage.Job) (*storage.Job, error)
+	GetJob(ctx context.Context, id int64) (*storage.Job, error)
+	StartJob(ctx context.Context, id int64, at time.Time) error
+	UpdateJobProgress(ctx context.Context, id int64, processed int) error
+	FinishJob(ctx context.Context, id int64, status string, processed int, result []byte, errMsg string, at time.Time) error
+	FailUnfinishedJobs(ctx context.Context, before time.Time, message string) (int64, error)
+	PruneJobs(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Work performs a job. It calls progress with the number of items processed
+// so far and returns the job's result, which is stored as JSON. A job whose
+// Work returns an error fails, keeping any result returned with the error.
+// ctx is cancelled when the runner stops, after the stop grace period.
+type Work func(ctx context.Context, progress func(processed int)) (result any, err error)
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithQueueSize sets how many jobs may wait for the worker.
+func WithQueueSize(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.queue = make(chan queued, n)
+		}
+	}
+}
+
+// WithRetention sets how long finished jobs are kept.
+func WithRetention(d time.Duration) Option {
+	return func(r *Runner) {
+		if d > 0 {
+			r.retention = d
+		}
+	}
+}
+
+// WithStopGrace lets a job that is running when the runner stops continue
+// for up to d before its context is cancelled, so a graceful shutdown does
+// not leave it half done. The default is no grace.
+func WithStopGrace(d time.Duration) Option {
+	return func(r *Runner) {
+		if d > 0 {
+			r.stopGrace = d
+		}
+	}
+}
+
+// queued is a submitted job waiting for the worker.
+type queued struct {
+	id   int64
+	work Work
+}
+
+// Runner runs submitted jobs one at a time.
+type Runner struct {
+	store     Store
+	logger    *slog.Logger
+	queue     chan queued
+	retention time.Duration
+	stopGrace time.Duration
+	started   time.Time
+	now       func() time.Time
+}
+
+// New creates a runner. Jobs are run once Run is called.
+func New(store Store, logger *slog.Logger, opts ...Option) *Runner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	r := &Runner{
+		store:     store,
+		logger:    logger,
+		queue:     make(chan queued, DefaultQueueSize),
+		retention: DefaultRetention,
+		started:   time.Now(),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Submit records job as queued and schedules work for it. Kind, ZoneID,
+// TokenID and Total are taken from job. It returns ErrQueueFull, after
+// recording the job as failed, when too many jobs are waiting.
+func (r *Runner) Submit(ctx context.Context, job *storage.Job, work Work) (*storage.Job, error) {
+	created, err := r.store.CreateJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r.queue <- queued{id: created.ID, work: work}:
+		return created, nil
+	default:
+		if err := r.store.FinishJob(ctx, created.ID, storage.JobStatusFailed, 0, nil, ErrQueueFull.Error(), r.now()); err != nil {
+			r.logger.Error("failed to record rejected job", "job_id", created.ID, "error", err)
+		}
+		return nil, ErrQueueFull
+	}
+}
+
+// Get returns a job by ID.
+// Returns storage.ErrNotFound if the job does not exist.
+func (r *Runner) Get(ctx context.Context, id int64) (*storage.Job, error) {
+	return r.store.GetJob(ctx, id)
+}
+
+// Run marks jobs left unfinished by a previous process as failed, then runs
+// submitted jobs and prunes finished ones until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) {
+	if n, err := r.store.FailUnfinishedJobs(ctx, r.started, "interrupted by a restart"); err != nil {
+		r.logger.Error("failed to mark interrupted jobs", "error", err)
+	} else if n > 0 {
+		r.logger.Warn("marked jobs interrupted by a restart as failed", "count", n)
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case q := <-r.queue:
+			r.run(ctx, q)
+		case <-ticker.C:
+			r.prune(ctx)
+		}
+	}
+}
+
+// run performs one job and records its outcome.
+func (r *Runner) run(ctx context.Context, q queued) {
+	// The outcome is recorded even when ctx was cancelled during the job
+	store := context.WithoutCancel(ctx)
+	if err := r.store.StartJob(store, q.id, r.now()); err != nil {
+		r.logger.Error("failed to start job", "job_id", q.id, "error", err)
+		return
+	}
+
+	processed, lastUpdate := 0, r.now()
+	progress := func(n int) {
+		processed = n
+		if r.now().Sub(lastUpdate) < progressInterval {
+			return
+		}
+		lastUpdate = r.now()
+		if err := r.store.UpdateJobProgress(store, q.id, n); err != nil {
+			r.logger.Warn("failed to update job progress", "job_id", q.id, "error", err)
+		}
+	}
+
+	workCtx, cancel := r.workContext(ctx)
+	defer cancel()
+	start := r.now()
+	result, workErr := q.work(workCtx, progress)
+
+	status, errMsg := storage.JobStatusSucceeded, ""
+	if workErr != nil {
+		status, errMsg = storage.JobStatusFailed, workErr.Error()
+	}
+	var body []byte
+	if result != nil {
+		var err error
+		if body, err = json.Marshal(result); err != nil {
+			status, errMsg, body = storage.JobStatusFailed, "failed to encode result", nil
+			r.logger.Error("failed to encode job result", "job_id", q.id, "error", err)
+		}
+	}
+	if err := r.store.FinishJob(store, q.id, status, processed, body, errMsg, r.now()); err != nil {
+		r.logger.Error("failed to record job outcome", "job_id", q.id, "error", err)
+		return
+	}
+	r.logger.Info("job finished", "job_id", q.id, "status", status, "processed", processed,
+		"duration", r.now().Sub(start))
+}
+
+// workContext returns the context a job runs with: ctx, or, with a stop
+// grace period, a context cancelled that long after ctx.
+func (r *Runner) workContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.stopGrace == 0 {
+		return context.WithCancel(ctx)
+	}
+	workCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(ctx, func() {
+		timer := time.NewTimer(r.stopGrace)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			r.logger.Warn("cancelling job still running after the stop grace period", "grace", r.stopGrace)
+			cancel()
+		case <-workCtx.Done():
+		}
+	})
+	return workCtx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// prune deletes jobs that finished more than the retention period ago.
+func (r *Runner) prune(ctx context.Context) {
+	n, err := r.store.PruneJobs(ctx, r.now().Add(-r.retention))
+	if err != nil {
+		r.logger.Error("failed to prune jobs", "error", err)
+		return
+	}
+	if n > 0 {
+		r.logger.Debug("pruned jobs", "count", n)
+	}
+}
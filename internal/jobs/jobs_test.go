@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu     sync.Mutex
+	jobs   map[int64]*storage.Job
+	nextID int64
+}
+
+func newMemStore() *memStore {
+	return &memStore{jobs: make(map[int64]*storage.Job)}
+}
+
+func (m *memStore) CreateJob(_ context.Context, j *storage.Job) (*storage.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	job := *j
+	job.ID, job.Status = m.nextID, storage.JobStatusQueued
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	m.jobs[job.ID] = &job
+	created := job
+	return &created, nil
+}
+
+func (m *memStore) GetJob(_ context.Context, id int64) (*storage.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	job := *j
+	return &job, nil
+}
+
+func (m *memStore) StartJob(_ context.Context, id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok || j.Status != storage.JobStatusQueued {
+		return storage.ErrNotFound
+	}
+	j.Status, j.StartedAt = storage.JobStatusRunning, at
+	return nil
+}
+
+func (m *memStore) UpdateJobProgress(_ context.Context, id int64, processed int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok || j.Status != storage.JobStatusRunning {
+		return storage.ErrNotFound
+	}
+	j.Processed = processed
+	return nil
+}
+
+func (m *memStore) FinishJob(_ context.Context, id int64, status string, processed int, result []byte, errMsg string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok || (j.Status != storage.JobStatusQueued && j.Status != storage.JobStatusRunning) {
+		return storage.ErrNotFound
+	}
+	j.Status, j.Processed, j.Result, j.Error, j.FinishedAt = status, processed, result, errMsg, at
+	return nil
+}
+
+func (m *memStore) FailUnfinishedJobs(_ context.Context, before time.Time, message string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, j := range m.jobs {
+		if (j.Status == storage.JobStatusQueued || j.Status == storage.JobStatusRunning) && j.CreatedAt.Before(before) {
+			j.Status, j.Error = storage.JobStatusFailed, message
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memStore) PruneJobs(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// waitFor polls a job until it has finished.
+func waitFor(t *testing.T, r *Runner, id int64) *storage.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := r.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if job.Status == storage.JobStatusSucceeded || job.Status == storage.JobStatusFailed {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %d did not finish", id)
+	return nil
+}
+
+func newTestRunner(t *testing.T, store Store, opts ...Option) *Runner {
+	t.Helper()
+	r := New(store, slog.New(slog.NewTextHandler(io.Discard, nil)), opts...)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return r
+}
+
+func TestRunner_Succeeds(t *testing.T) {
+	t.Parallel()
+	r := newTestRunner(t, newMemStore())
+
+	job, err := r.Submit(context.Background(), &storage.Job{Kind: storage.JobKindImport, ZoneID: 7, Total: 3},
+		func(_ context.Context, progress func(int)) (any, error) {
+			for i := 1; i <= 3; i++ {
+				progress(i)
+			}
+			return map[string]int{"Created": 3}, nil
+		})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if job.Status != storage.JobStatusQueued || job.ZoneID != 7 {
+		t.Errorf("unexpected submitted job %+v", job)
+	}
+
+	got := waitFor(t, r, job.ID)
+	if got.Status != storage.JobStatusSucceeded || got.Processed != 3 || string(got.Result) != `{"Created":3}` {
+		t.Errorf("unexpected finished job %+v", got)
+	}
+}
+
+func TestRunner_Fails(t *testing.T) {
+	t.Parallel()
+	r := newTestRunner(t, newMemStore())
+
+	job, err := r.Submit(context.Background(), &storage.Job{Kind: storage.JobKindImport},
+		func(_ context.Context, progress func(int)) (any, error) {
+			progress(1)
+			return map[string]int{"Created": 1}, errors.New("upstream unavailable")
+		})
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	got := waitFor(t, r, job.ID)
+	if got.Status != storage.JobStatusFailed || got.Error != "upstream unavailable" || string(got.Result) != `{"Created":1}` {
+		t.Errorf("unexpected failed job %+v", got)
+	}
+}
+
+func TestRunner_QueueFull(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	// Not running, so the queue fills up
+	r := New(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithQueueSize(1))
+	work := func(context.Context, func(int)) (any, error) { return nil, nil }
+
+	if _, err := r.Submit(context.Background(), &storage.Job{Kind: storage.JobKindImport}, work); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if _, err := r.Submit(context.Background(), &storage.Job{Kind: storage.JobKindImport}, work); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+	if job, _ := store.GetJob(context.Background(), 2); job == nil || job.Status != storage.JobStatusFailed {
+		t.Errorf("expected the rejected job to be recorded as failed, got %+v", job)
+	}
+}
+
+func TestRunner_FailsInterruptedJobs(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	old, _ := store.CreateJob(context.Background(), &storage.Job{Kind: storage.JobKindImport, CreatedAt: time.Now().Add(-time.Hour)})
+
+	r := newTestRunner(t, store)
+	got := waitFor(t, r, old.ID)
+	if got.Status != storage.JobStatusFailed || got.Error == "" {
+		t.Errorf("expected the interrupted job to have failed, got %+v", got)
+	}
+}
+
+func TestRunner_StopGrace(t *testing.T) {
+	t.Parallel()
+
+	run := func(grace time.Duration, release <-chan struct{}) (*memStore, int64) {
+		store := newMemStore()
+		r := New(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithStopGrace(grace))
+		started := make(chan struct{})
+		job, err := r.Submit(context.Background(), &storage.Job{Kind: storage.JobKindImport},
+			func(ctx context.Context, progress func(int)) (any, error) {
+				close(started)
+				select {
+				case <-release:
+					progress(1)
+					return nil, nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			})
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			r.Run(ctx)
+		}()
+		<-started
+		cancel()
+		<-done
+		return store, job.ID
+	}
+
+	// The running job finishes within the grace period
+	release := make(chan struct{})
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	store, id := run(time.Minute, release)
+	if job, _ := store.GetJob(context.Background(), id); job.Status != storage.JobStatusSucceeded || job.Processed != 1 {
+		t.Errorf("expected the job to finish after the runner stopped, got %+v", job)
+	}
+
+	// It is cancelled once the grace period has passed
+	store, id = run(20*time.Millisecond, make(chan struct{}))
+	if job, _ := store.GetJob(context.Background(), id); job.Status != storage.JobStatusFailed || job.Error != context.Canceled.Error() {
+		t.Errorf("expected the job to be cancelled after the grace period, got %+v", job)
+	}
+}
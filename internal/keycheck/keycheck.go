@@ -0,0 +1,175 @@
+// Package keycheck periodically verifies the bunny.net API keys the proxy
+// uses, so a key that was rotated or revoked in bunny.net shows up in /ready,
+// in metrics and as a system alert instead of as 502s in client logs.
+//
+// bunny.net does not report when a key expires, so a key is only known to be
+// bad once bunny.net rejects it. Checks that fail for other reasons, such as
+// bunny.net being unreachable, leave the key's last known state unchanged;
+// the upstream readiness check and circuit breakers cover those.
+package keycheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+const (
+	// checkTimeout bounds the check of one key.
+	checkTimeout = 30 * time.Second
+
+	// alertKeyRejected is the alert name for a key bunny.net rejected.
+	alertKeyRejected = "upstream_key_rejected"
+)
+
+// Verifier checks one account's API key. It is satisfied by *bunny.Client.
+type Verifier interface {
+	VerifyKey(ctx context.Context) error
+}
+
+// Alerter delivers operational alerts. It is satisfied by *outbox.Outbox.
+type Alerter interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// Option configures a Checker.
+type Option func(*Checker)
+
+// WithAlerter publishes a system alert when bunny.net rejects a key.
+func WithAlerter(alerter Alerter) Option {
+	return func(c *Checker) {
+		c.alerter = alerter
+	}
+}
+
+// Checker verifies API keys on an interval.
+type Checker struct {
+	accounts map[string]Verifier
+	interval time.Duration
+	logger   *slog.Logger
+	alerter  Alerter
+
+	mu       sync.Mutex
+	rejected map[string]bool
+}
+
+// New creates a checker for the given accounts, keyed by account name with
+// "" for the default account.
+func New(accounts map[string]Verifier, interval time.Duration, logger *slog.Logger, opts ...Option) *Checker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	c := &Checker{
+		accounts: accounts,
+		interval: interval,
+		logger:   logger,
+		rejected: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run checks every key at once and then on the interval until ctx is
+// cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll checks every key once.
+func (c *Checker) checkAll(ctx context.Context) {
+	for name, v := range c.accounts {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := v.VerifyKey(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		switch {
+		case err == nil:
+			c.observe(ctx, name, false)
+		case errors.Is(err, bunny.ErrUnauthorized):
+			c.observe(ctx, name, true)
+		default:
+			c.logger.Debug("upstream key check failed", "account", name, "error", err)
+		}
+	}
+}
+
+// observe records a key's state, reporting when it changes.
+func (c *Checker) observe(ctx context.Context, name string, rejected bool) {
+	metrics.RecordUpstreamKeyValid(name, !rejected)
+
+	c.mu.Lock()
+	changed := c.rejected[name] != rejected
+	c.rejected[name] = rejected
+	c.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if !rejected {
+		c.logger.Info("bunny.net accepts the API key again", "account", name)
+		return
+	}
+	c.logger.Error("bunny.net rejected the API key; it may have been rotated or revoked", "account", name)
+	if c.alerter == nil {
+		return
+	}
+	err := c.alerter.Publish(ctx, webhook.EventSystemAlert, map[string]any{
+		"alert":   alertKeyRejected,
+		"message": fmt.Sprintf("bunny.net rejected the API key of %s", accountLabel(name)),
+		"account": name,
+	})
+	if err != nil {
+		c.logger.Error("failed to publish upstream key alert", "error", err)
+	}
+}
+
+// Check returns an error naming the accounts whose key bunny.net rejected at
+// the last check, for use as a readiness check. It does not call bunny.net.
+func (c *Checker) Check(context.Context) error {
+	c.mu.Lock()
+	var rejected []string
+	for name, r := range c.rejected {
+		if r {
+			rejected = append(rejected, accountLabel(name))
+		}
+	}
+	c.mu.Unlock()
+
+	if len(rejected) == 0 {
+		return nil
+	}
+	slices.Sort(rejected)
+	return fmt.Errorf("bunny.net rejected the API key of %s", strings.Join(rejected, ", "))
+}
+
+// accountLabel names an account in messages.
+func accountLabel(name string) string {
+	if name == "" {
+		return "the default account"
+	}
+	return "account " + name
+}
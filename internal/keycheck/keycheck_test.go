@@ -0,0 +1,94 @@
+package keycheck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type recordingAlerter struct {
+	events []map[string]any
+}
+
+func (a *recordingAlerter) Publish(_ context.Context, event string, data any) error {
+	if event != webhook.EventSystemAlert {
+		return errors.New("unexpected event " + event)
+	}
+	a.events = append(a.events, data.(map[string]any))
+	return nil
+}
+
+// fakeVerifier returns err from every check.
+type fakeVerifier struct {
+	err error
+}
+
+func (f *fakeVerifier) VerifyKey(context.Context) error {
+	return f.err
+}
+
+func TestChecker(t *testing.T) {
+	t.Parallel()
+	def, clientA := &fakeVerifier{}, &fakeVerifier{}
+	alerter := &recordingAlerter{}
+	c := New(map[string]Verifier{"": def, "client-a": clientA}, time.Minute, testLogger(), WithAlerter(alerter))
+	ctx := context.Background()
+
+	c.checkAll(ctx)
+	if err := c.Check(ctx); err != nil || len(alerter.events) != 0 {
+		t.Fatalf("expected valid keys, got %v with alerts %v", err, alerter.events)
+	}
+
+	// A rejected key fails the check and alerts once
+	clientA.err = bunny.ErrUnauthorized
+	def.err = bunny.ErrUnauthorized
+	c.checkAll(ctx)
+	c.checkAll(ctx)
+	err := c.Check(ctx)
+	if err == nil || err.Error() != "bunny.net rejected the API key of account client-a, the default account" {
+		t.Errorf("unexpected check error %v", err)
+	}
+	if len(alerter.events) != 2 || alerter.events[0]["alert"] != alertKeyRejected {
+		t.Errorf("expected one alert per account, got %v", alerter.events)
+	}
+
+	// Other failures keep the last known state
+	def.err = errors.New("connection refused")
+	clientA.err = nil
+	c.checkAll(ctx)
+	if err := c.Check(ctx); err == nil || strings.Contains(err.Error(), "client-a") {
+		t.Errorf("expected only the default account to stay rejected, got %v", err)
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	t.Parallel()
+	c := New(map[string]Verifier{"": &fakeVerifier{err: bunny.ErrUnauthorized}}, time.Hour, testLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for c.Check(ctx) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("key was not checked on start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// BodySwitch turns on logging of upstream request and response bodies for
+// a limited time without lowering the log level, for every caller or for
+// one token. The switch is per process; it is not shared between instances.
+type BodySwitch struct {
+	mu      sync.RWMutex
+	until   time.Time
+	tokenID int64
+	now     func() time.Time
+}
+
+// NewBodySwitch creates a body logging switch that is off.
+func NewBodySwitch() *BodySwitch {
+	return &BodySwitch{now: time.Now}
+}
+
+// Enable turns body logging on for d, limited to requests by tokenID unless
+// it is 0, and returns when it turns off again.
+func (s *BodySwitch) Enable(d time.Duration, tokenID int64) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.until = s.now().Add(d)
+	s.tokenID = tokenID
+	return s.until
+}
+
+// Disable turns body logging off.
+func (s *BodySwitch) Disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.until = time.Time{}
+	s.tokenID = 0
+}
+
+// Status returns when body logging turns off and the token it is limited
+// to. until is zero when body logging is off.
+func (s *BodySwitch) Status() (until time.Time, tokenID int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.now().Before(s.until) {
+		return time.Time{}, 0
+	}
+	return s.until, s.tokenID
+}
+
+// Enabled reports whether bodies of a request by tokenID are logged. The
+// master key and unauthenticated requests pass 0.
+func (s *BodySwitch) Enabled(tokenID int64) bool {
+	until, only := s.Status()
+	return !until.IsZero() && (only == 0 || only == tokenID)
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// bunnyRecordTypeTXT is the bunny.net record type number of TXT records.
+const bunnyRecordTypeTXT = 3
+
+// secretPatterns match secrets that DNS TXT records commonly carry: ACME
+// challenge digests, verification codes and other long random tokens, and
+// key=value pairs naming a token or secret.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)[a-z0-9_-]*(verification|token|secret|key|password)[a-z0-9_-]*=[^\s";]+`),
+	regexp.MustCompile(`[A-Za-z0-9_+/-]{32,}={0,2}`),
+}
+
+// RedactSecrets masks secrets in a bunny.net request or response body for
+// logging. In JSON bodies only the Value of TXT records is checked, and the
+// parts matching a secret pattern are replaced with "[REDACTED]". Other
+// bodies, such as zone file imports, are checked as a whole.
+func RedactSecrets(body []byte) []byte {
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return redactText(body)
+	}
+	if !redactTXTValues(data) {
+		return body
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(data); err != nil {
+		return redactText(body)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactTXTValues redacts secrets in the Value of every TXT record in a
+// decoded JSON document and reports whether it changed anything.
+func redactTXTValues(value any) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]any:
+		if isTXTRecord(v) {
+			if s, ok := v["Value"].(string); ok {
+				if redacted := string(redactText([]byte(s))); redacted != s {
+					v["Value"] = redacted
+					changed = true
+				}
+			}
+		}
+		for _, val := range v {
+			changed = redactTXTValues(val) || changed
+		}
+	case []any:
+		for _, item := range v {
+			changed = redactTXTValues(item) || changed
+		}
+	}
+	return changed
+}
+
+// isTXTRecord reports whether a JSON object is a bunny.net TXT record, whose
+// Type is a number, or a proxy request naming the type as a string.
+func isTXTRecord(obj map[string]any) bool {
+	switch t := obj["Type"].(type) {
+	case float64:
+		return t == bunnyRecordTypeTXT
+	case string:
+		return t == "TXT" || t == "txt"
+	}
+	return false
+}
+
+// redactText replaces every secret pattern match in text.
+func redactText(text []byte) []byte {
+	for _, re := range secretPatterns {
+		text = re.ReplaceAll(text, []byte("[REDACTED]"))
+	}
+	return text
+}
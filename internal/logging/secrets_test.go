@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"ACME challenge",
+			`{"Type":3,"Name":"_acme-challenge","Value":"gfj9Xq-Ae2v6d7tD0Wl1u8Xr4mK3bNcJpQ5zYhS9aLk"}`,
+			`{"Name":"_acme-challenge","Type":3,"Value":"[REDACTED]"}`},
+		{"verification code",
+			`{"Type":"TXT","Value":"google-site-verification=abc123"}`,
+			`{"Type":"TXT","Value":"[REDACTED]"}`},
+		{"records in a zone",
+			`{"Id":1,"Records":[{"Type":3,"Value":"v=spf1 -all"},{"Type":3,"Value":"api_token=s3cr3t"}]}`,
+			`{"Id":1,"Records":[{"Type":3,"Value":"v=spf1 -all"},{"Type":3,"Value":"[REDACTED]"}]}`},
+		{"non-TXT records are kept",
+			`{"Type":2,"Value":"a-very-long-hostname-component-that-is-not-a-secret"}`,
+			`{"Type":2,"Value":"a-very-long-hostname-component-that-is-not-a-secret"}`},
+		{"unchanged JSON keeps its formatting",
+			`{"Domain": "example.com"}`,
+			`{"Domain": "example.com"}`},
+		{"zone file",
+			"_acme-challenge 300 IN TXT \"gfj9Xq-Ae2v6d7tD0Wl1u8Xr4mK3bNcJpQ5zYhS9aLk\"\n",
+			"_acme-challenge 300 IN TXT \"[REDACTED]\"\n"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(RedactSecrets([]byte(tt.body))); got != tt.expected {
+				t.Errorf("RedactSecrets() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBodySwitch(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := NewBodySwitch()
+	s.now = func() time.Time { return now }
+
+	if s.Enabled(0) {
+		t.Error("new switch is on")
+	}
+
+	until := s.Enable(time.Minute, 5)
+	if !until.Equal(now.Add(time.Minute)) {
+		t.Errorf("Enable() = %v, want a minute from now", until)
+	}
+	if !s.Enabled(5) || s.Enabled(6) || s.Enabled(0) {
+		t.Error("switch limited to token 5 matched the wrong tokens")
+	}
+
+	s.Enable(time.Minute, 0)
+	if !s.Enabled(6) || !s.Enabled(0) {
+		t.Error("switch for every token did not match")
+	}
+
+	now = now.Add(time.Minute)
+	if s.Enabled(6) {
+		t.Error("switch still on after expiry")
+	}
+	if until, tokenID := s.Status(); !until.IsZero() || tokenID != 0 {
+		t.Errorf("Status() after expiry = %v, %d", until, tokenID)
+	}
+
+	s.Enable(time.Minute, 0)
+	s.Disable()
+	if s.Enabled(0) {
+		t.Error("switch on after Disable")
+	}
+}
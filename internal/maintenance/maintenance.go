@@ -0,0 +1,144 @@
+// Package maintenance runs periodic database housekeeping: incremental
+// vacuuming, database size metrics, and an alert when the database grows past
+// a configured size.
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+const (
+	// interval is how often the worker vacuums and samples the database size.
+	interval = 10 * time.Minute
+
+	// vacuumPages bounds the pages reclaimed per run, so a large backlog is
+	// returned gradually instead of holding the write lock for long.
+	vacuumPages = 2000
+
+	// alertDBSize is the alert name for the database size threshold.
+	alertDBSize = "db_size"
+)
+
+// Store defines the storage operations needed by the worker.
+type Store interface {
+	DBStats(ctx context.Context) (*storage.DBStats, error)
+	IncrementalVacuum(ctx context.Context, pages int) error
+}
+
+// Alerter delivers operational alerts. It is satisfied by *outbox.Outbox.
+type Alerter interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// Option configures a Worker.
+type Option func(*Worker)
+
+// WithSizeAlert raises an alert when the database reaches threshold bytes.
+// A threshold of zero disables the alert.
+func WithSizeAlert(threshold int64, alerter Alerter) Option {
+	return func(w *Worker) {
+		w.threshold = threshold
+		w.alerter = alerter
+	}
+}
+
+// Worker vacuums the database and tracks its size.
+type Worker struct {
+	store     Store
+	logger    *slog.Logger
+	threshold int64
+	alerter   Alerter
+
+	// alerting is set while the database is over the threshold, so the
+	// alert fires once per crossing rather than on every run.
+	alerting bool
+}
+
+// New creates a maintenance worker.
+func New(store Store, logger *slog.Logger, opts ...Option) *Worker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	w := &Worker{
+		store:  store,
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run performs maintenance until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce vacuums free pages, then records the database size and checks the threshold.
+func (w *Worker) runOnce(ctx context.Context) {
+	if err := w.store.IncrementalVacuum(ctx, vacuumPages); err != nil && ctx.Err() == nil {
+		w.logger.Error("incremental vacuum failed", "error", err)
+	}
+
+	stats, err := w.store.DBStats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Error("failed to read database stats", "error", err)
+		}
+		return
+	}
+	metrics.RecordDBStats(stats.FileSize, stats.PageCount, stats.FreelistCount)
+	w.logger.Debug("database stats", "size_bytes", stats.FileSize, "pages", stats.PageCount, "free_pages", stats.FreelistCount)
+
+	w.checkThreshold(ctx, stats)
+}
+
+// checkThreshold alerts when the database first reaches the threshold and
+// re-arms once it drops back below it.
+func (w *Worker) checkThreshold(ctx context.Context, stats *storage.DBStats) {
+	if w.threshold <= 0 {
+		return
+	}
+	if stats.FileSize < w.threshold {
+		if w.alerting {
+			w.logger.Info("database size back below alert threshold", "size_bytes", stats.FileSize, "threshold_bytes", w.threshold)
+		}
+		w.alerting = false
+		return
+	}
+	if w.alerting {
+		return
+	}
+	w.alerting = true
+
+	w.logger.Warn("database size reached alert threshold", "size_bytes", stats.FileSize, "threshold_bytes", w.threshold)
+	if w.alerter == nil {
+		return
+	}
+	err := w.alerter.Publish(ctx, webhook.EventSystemAlert, map[string]any{
+		"alert":           alertDBSize,
+		"message":         "database size reached the alert threshold",
+		"size_bytes":      stats.FileSize,
+		"threshold_bytes": w.threshold,
+	})
+	if err != nil {
+		w.logger.Error("failed to publish database size alert", "error", err)
+	}
+}
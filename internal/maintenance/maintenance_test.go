@@ -0,0 +1,125 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type recordingAlerter struct {
+	events []map[string]any
+}
+
+func (a *recordingAlerter) Publish(_ context.Context, event string, data any) error {
+	if event != webhook.EventSystemAlert {
+		return errors.New("unexpected event " + event)
+	}
+	a.events = append(a.events, data.(map[string]any))
+	return nil
+}
+
+func TestRunOnce_VacuumsAndAlertsOncePerCrossing(t *testing.T) {
+	t.Parallel()
+
+	var vacuumed []int
+	size := int64(500)
+	store := &mockstore.MockStorage{
+		IncrementalVacuumFunc: func(_ context.Context, pages int) error {
+			vacuumed = append(vacuumed, pages)
+			return nil
+		},
+		DBStatsFunc: func(context.Context) (*storage.DBStats, error) {
+			return &storage.DBStats{FileSize: size}, nil
+		},
+	}
+	alerter := &recordingAlerter{}
+	w := New(store, testLogger(), WithSizeAlert(1000, alerter))
+	ctx := context.Background()
+
+	w.runOnce(ctx)
+	if len(vacuumed) != 1 || vacuumed[0] != vacuumPages {
+		t.Errorf("expected one bounded vacuum, got %v", vacuumed)
+	}
+	if len(alerter.events) != 0 {
+		t.Fatalf("expected no alert below threshold, got %v", alerter.events)
+	}
+
+	size = 1500
+	w.runOnce(ctx)
+	w.runOnce(ctx)
+	if len(alerter.events) != 1 {
+		t.Fatalf("expected a single alert while over threshold, got %d", len(alerter.events))
+	}
+	if e := alerter.events[0]; e["alert"] != alertDBSize || e["size_bytes"] != int64(1500) || e["threshold_bytes"] != int64(1000) {
+		t.Errorf("unexpected alert data: %v", e)
+	}
+
+	// Dropping below re-arms the alert
+	size = 900
+	w.runOnce(ctx)
+	size = 1200
+	w.runOnce(ctx)
+	if len(alerter.events) != 2 {
+		t.Errorf("expected alert to fire again after re-arming, got %d", len(alerter.events))
+	}
+}
+
+func TestRunOnce_ThresholdDisabled(t *testing.T) {
+	t.Parallel()
+
+	alerter := &recordingAlerter{}
+	store := &mockstore.MockStorage{
+		DBStatsFunc: func(context.Context) (*storage.DBStats, error) {
+			return &storage.DBStats{FileSize: 1 << 40}, nil
+		},
+	}
+	New(store, testLogger(), WithSizeAlert(0, alerter)).runOnce(context.Background())
+	if len(alerter.events) != 0 {
+		t.Errorf("expected no alert with threshold disabled, got %v", alerter.events)
+	}
+}
+
+func TestRunOnce_StatsErrorSkipsThreshold(t *testing.T) {
+	t.Parallel()
+
+	alerter := &recordingAlerter{}
+	store := &mockstore.MockStorage{
+		IncrementalVacuumFunc: func(context.Context, int) error { return errors.New("locked") },
+		DBStatsFunc: func(context.Context) (*storage.DBStats, error) {
+			return nil, errors.New("io error")
+		},
+	}
+	New(store, testLogger(), WithSizeAlert(1, alerter)).runOnce(context.Background())
+	if len(alerter.events) != 0 {
+		t.Errorf("expected no alert without stats, got %v", alerter.events)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		New(&mockstore.MockStorage{}, testLogger()).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
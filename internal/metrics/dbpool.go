@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbPoolStats returns the statistics of each database connection pool, keyed
+// by pool name. It is nil until SetDBPoolStats is called.
+var dbPoolStats atomic.Pointer[func() map[string]sql.DBStats]
+
+// SetDBPoolStats sets the source of the database connection pool metrics,
+// which are read from it on every scrape.
+func SetDBPoolStats(stats func() map[string]sql.DBStats) {
+	dbPoolStats.Store(&stats)
+}
+
+// dbPoolCollector exports database/sql pool statistics. Waits count callers
+// that found every connection busy, the main sign of lock contention.
+type dbPoolCollector struct {
+	connections *prometheus.Desc
+	maxOpen     *prometheus.Desc
+	waits       *prometheus.Desc
+	waitSeconds *prometheus.Desc
+}
+
+func newDBPoolCollector() *dbPoolCollector {
+	return &dbPoolCollector{
+		connections: prometheus.NewDesc("bunny_proxy_db_connections",
+			"Number of database connections by pool and state", []string{"pool", "state"}, nil),
+		maxOpen: prometheus.NewDesc("bunny_proxy_db_max_open_connections",
+			"Maximum number of open database connections by pool", []string{"pool"}, nil),
+		waits: prometheus.NewDesc("bunny_proxy_db_connection_waits_total",
+			"Total number of times a query waited for a free database connection", []string{"pool"}, nil),
+		waitSeconds: prometheus.NewDesc("bunny_proxy_db_connection_wait_seconds_total",
+			"Total time spent waiting for a free database connection", []string{"pool"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.maxOpen
+	ch <- c.waits
+	ch <- c.waitSeconds
+}
+
+// Collect implements prometheus.Collector.
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := dbPoolStats.Load()
+	if stats == nil {
+		return
+	}
+	for pool, s := range (*stats)() {
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.InUse), pool, "in_use")
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(s.Idle), pool, "idle")
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(s.MaxOpenConnections), pool)
+		ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(s.WaitCount), pool)
+		ch <- prometheus.MustNewConstMetric(c.waitSeconds, prometheus.CounterValue, s.WaitDuration.Seconds(), pool)
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDBPoolCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(newDBPoolCollector()); err != nil {
+		t.Fatalf("Register() failed: %v", err)
+	}
+
+	SetDBPoolStats(func() map[string]sql.DBStats {
+		return map[string]sql.DBStats{
+			"write": {MaxOpenConnections: 1, InUse: 1, WaitCount: 3, WaitDuration: 1500 * time.Millisecond},
+			"read":  {MaxOpenConnections: 4, Idle: 2},
+		}
+	})
+	t.Cleanup(func() { dbPoolStats.Store(nil) })
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() failed: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			key := mf.GetName()
+			for _, l := range m.GetLabel() {
+				key += "," + l.GetValue()
+			}
+			if c := m.GetCounter(); c != nil {
+				values[key] = c.GetValue()
+			} else {
+				values[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	for key, want := range map[string]float64{
+		"bunny_proxy_db_connections,write,in_use":            1,
+		"bunny_proxy_db_connections,read,idle":               2,
+		"bunny_proxy_db_max_open_connections,read":           4,
+		"bunny_proxy_db_connection_waits_total,write":        3,
+		"bunny_proxy_db_connection_wait_seconds_total,write": 1.5,
+		"bunny_proxy_db_connection_wait_seconds_total,read":  0,
+	} {
+		if got, ok := values[key]; !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", key, got, ok, want)
+		}
+	}
+}
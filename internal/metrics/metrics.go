@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"sync/atomic"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,6 +19,59 @@ var (
 	requestsTotal     atomic.Pointer[prometheus.CounterVec]
 	requestDuration   atomic.Pointer[prometheus.HistogramVec]
 	authFailuresTotal atomic.Pointer[prometheus.CounterVec]
+	info              atomic.Pointer[prometheus.GaugeVec]
+
+	// Per-token request metrics, updated by TokenMetrics.Middleware
+	tokenRequestsTotal atomic.Pointer[prometheus.CounterVec]
+	tokenDenialsTotal  atomic.Pointer[prometheus.CounterVec]
+
+	// Permission denials by reason, updated by the permission middleware
+	permissionDenialsTotal atomic.Pointer[prometheus.CounterVec]
+
+	// Database size gauges, updated by the maintenance worker
+	dbSizeBytes     atomic.Pointer[prometheus.Gauge]
+	dbPageCount     atomic.Pointer[prometheus.Gauge]
+	dbFreelistPages atomic.Pointer[prometheus.Gauge]
+
+	// SLO metrics, updated by the SLO tracker
+	sliEventsTotal atomic.Pointer[prometheus.CounterVec]
+	sloTarget      atomic.Pointer[prometheus.GaugeVec]
+	sloBurnRate    atomic.Pointer[prometheus.GaugeVec]
+
+	// Upstream schema drift counter, updated by the bunny client
+	upstreamUnknownFieldsTotal atomic.Pointer[prometheus.CounterVec]
+	upstreamRetriesTotal       atomic.Pointer[prometheus.CounterVec]
+	upstreamRequestsTotal      atomic.Pointer[prometheus.CounterVec]
+	upstreamCircuitOpen        atomic.Pointer[prometheus.GaugeVec]
+	upstreamKeyValid           atomic.Pointer[prometheus.GaugeVec]
+	upstreamAttemptsTotal      atomic.Pointer[prometheus.CounterVec]
+	upstreamAttemptDuration    atomic.Pointer[prometheus.HistogramVec]
+	upstreamInFlight           atomic.Pointer[prometheus.GaugeVec]
+	responseCacheLookupsTotal  atomic.Pointer[prometheus.CounterVec]
+
+	// Blocklist metrics, updated by the blocklist middleware
+	blocklistRejectionsTotal atomic.Pointer[prometheus.Counter]
+	blocklistEntries         atomic.Pointer[prometheus.Gauge]
+
+	// Replay protection rejections, updated by the replay middleware
+	replayRejectionsTotal atomic.Pointer[prometheus.CounterVec]
+
+	// Idempotency-Key requests, updated by the idempotency middleware
+	idempotentRequestsTotal atomic.Pointer[prometheus.CounterVec]
+
+	// Requests to frozen zones, updated by the zone freeze middleware
+	frozenRequestsTotal atomic.Pointer[prometheus.CounterVec]
+
+	// Requests over a size limit, updated by the proxy handler
+	requestLimitRejectionsTotal atomic.Pointer[prometheus.CounterVec]
+
+	// Honeypot hits, updated by the honeypot middleware
+	honeypotHitsTotal atomic.Pointer[prometheus.Counter]
+
+	// Storage degradation, updated by the disk guard
+	storageDegraded           atomic.Pointer[prometheus.Gauge]
+	diskFreeBytes             atomic.Pointer[prometheus.Gauge]
+	storageDegradedRejections atomic.Pointer[prometheus.Counter]
 )
 
 // Init initializes all Prometheus metrics and registers them with the provided registry.
@@ -66,26 +120,396 @@ func Init(reg prometheus.Registerer) error {
 		return fmt.Errorf("failed to register authFailuresTotal: %w", err)
 	}
 
+	// Per-token counters: which token's requests succeed or are denied
+	tokenRequestsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "token_requests_total",
+			Help:      "Total number of proxy requests by token label and status code",
+		},
+		[]string{"token", "status"},
+	)
+	if err := reg.Register(tokenRequestsTotalVec); err != nil {
+		return fmt.Errorf("failed to register tokenRequestsTotal: %w", err)
+	}
+
+	tokenDenialsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "token_denials_total",
+			Help:      "Total number of proxy requests denied with 401 or 403, by token label",
+		},
+		[]string{"token"},
+	)
+	if err := reg.Register(tokenDenialsTotalVec); err != nil {
+		return fmt.Errorf("failed to register tokenDenialsTotal: %w", err)
+	}
+
+	permissionDenialsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "permission_denials_total",
+			Help:      "Total number of proxy requests denied by token permissions, by reason (admin_required, no_permission, action_not_allowed, record_type_not_allowed)",
+		},
+		[]string{"reason"},
+	)
+	if err := reg.Register(permissionDenialsTotalVec); err != nil {
+		return fmt.Errorf("failed to register permissionDenialsTotal: %w", err)
+	}
+
 	// Info gauge: static metric with constant label values for build info
 	infoGaugeVec := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "bunny",
 			Subsystem: "proxy",
 			Name:      "info",
-			Help:      "Proxy version and build information",
+			Help:      "Proxy version and instance identification",
 		},
-		[]string{"version"},
+		[]string{"version", "instance_id"},
 	)
-	infoGaugeInstance := infoGaugeVec.WithLabelValues("1.0.0")
 	if err := reg.Register(infoGaugeVec); err != nil {
 		return fmt.Errorf("failed to register infoGauge: %w", err)
 	}
-	infoGaugeInstance.Set(1)
+	infoGaugeVec.WithLabelValues("unknown", "").Set(1)
+
+	// Database size gauges: track growth of the SQLite file on the data volume
+	dbGauges := make([]prometheus.Gauge, 3)
+	for i, opts := range []prometheus.GaugeOpts{
+		{Name: "db_size_bytes", Help: "Size of the SQLite database on disk, including the write-ahead log"},
+		{Name: "db_page_count", Help: "Number of pages in the SQLite database"},
+		{Name: "db_freelist_pages", Help: "Number of unused pages awaiting incremental vacuum"},
+	} {
+		opts.Namespace = "bunny"
+		opts.Subsystem = "proxy"
+		dbGauges[i] = prometheus.NewGauge(opts)
+		if err := reg.Register(dbGauges[i]); err != nil {
+			return fmt.Errorf("failed to register %s: %w", opts.Name, err)
+		}
+	}
+
+	// SLI events counter: good and bad events per service level indicator
+	sliEventsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "sli_events_total",
+			Help:      "Total number of events counted towards each service level indicator, by result",
+		},
+		[]string{"sli", "result"},
+	)
+	if err := reg.Register(sliEventsTotalVec); err != nil {
+		return fmt.Errorf("failed to register sliEventsTotal: %w", err)
+	}
+
+	// SLO target gauge: the configured objective for each indicator
+	sloTargetVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "slo_target",
+			Help:      "Configured service level objective as a ratio of good events",
+		},
+		[]string{"sli"},
+	)
+	if err := reg.Register(sloTargetVec); err != nil {
+		return fmt.Errorf("failed to register sloTarget: %w", err)
+	}
+
+	// SLO burn rate gauge: error budget consumption rate per evaluation window
+	sloBurnRateVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "slo_burn_rate",
+			Help:      "Error budget burn rate over the evaluation window (1 = budget spent exactly over the SLO period)",
+		},
+		[]string{"sli", "window"},
+	)
+	if err := reg.Register(sloBurnRateVec); err != nil {
+		return fmt.Errorf("failed to register sloBurnRate: %w", err)
+	}
+
+	// Upstream unknown fields counter: bunny.net response fields the proxy does not know about
+	upstreamUnknownFieldsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_unknown_fields_total",
+			Help:      "Total number of bunny.net response fields not declared by the proxy's types, by response type and field path",
+		},
+		[]string{"type", "field"},
+	)
+	if err := reg.Register(upstreamUnknownFieldsTotalVec); err != nil {
+		return fmt.Errorf("failed to register upstreamUnknownFieldsTotal: %w", err)
+	}
+
+	// Upstream retries: bunny.net requests retried after a transient failure
+	upstreamRetriesTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_retries_total",
+			Help:      "Total number of bunny.net requests retried, by reason (the HTTP status code, or error for network failures)",
+		},
+		[]string{"reason"},
+	)
+	if err := reg.Register(upstreamRetriesTotalVec); err != nil {
+		return fmt.Errorf("failed to register upstreamRetriesTotal: %w", err)
+	}
+
+	// Upstream requests and circuits per endpoint class, updated by the bunny client
+	upstreamRequestsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_requests_total",
+			Help:      "Total number of bunny.net requests after retries, by endpoint class and result (success, failure)",
+		},
+		[]string{"class", "result"},
+	)
+	if err := reg.Register(upstreamRequestsTotalVec); err != nil {
+		return fmt.Errorf("failed to register upstreamRequestsTotal: %w", err)
+	}
+
+	upstreamCircuitOpenVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_circuit_open",
+			Help:      "Whether the circuit breaker for a bunny.net endpoint class is open (1) or closed (0)",
+		},
+		[]string{"class"},
+	)
+	if err := reg.Register(upstreamCircuitOpenVec); err != nil {
+		return fmt.Errorf("failed to register upstreamCircuitOpen: %w", err)
+	}
+
+	// Upstream API key checks, updated by the key checker
+	upstreamKeyValidVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_key_valid",
+			Help:      "Whether bunny.net accepted the account's API key at the last check (1) or rejected it (0); the default account has an empty label",
+		},
+		[]string{"account"},
+	)
+	if err := reg.Register(upstreamKeyValidVec); err != nil {
+		return fmt.Errorf("failed to register upstreamKeyValid: %w", err)
+	}
+
+	// Upstream HTTP attempts, updated by bunny.LoggingTransport. Unlike
+	// upstream_requests_total, every retry attempt is counted and timed.
+	upstreamAttemptsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_attempts_total",
+			Help:      "Total number of HTTP attempts sent to bunny.net, by endpoint class, method and status code (error for network failures)",
+		},
+		[]string{"class", "method", "status"},
+	)
+	if err := reg.Register(upstreamAttemptsTotalVec); err != nil {
+		return fmt.Errorf("failed to register upstreamAttemptsTotal: %w", err)
+	}
+
+	upstreamAttemptDurationVec := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Latency of HTTP attempts sent to bunny.net in seconds, until response headers arrive",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"class", "method", "status"},
+	)
+	if err := reg.Register(upstreamAttemptDurationVec); err != nil {
+		return fmt.Errorf("failed to register upstreamAttemptDuration: %w", err)
+	}
+
+	upstreamInFlightVec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "upstream_in_flight_requests",
+			Help:      "Number of HTTP attempts to bunny.net awaiting a response, by endpoint class",
+		},
+		[]string{"class"},
+	)
+	if err := reg.Register(upstreamInFlightVec); err != nil {
+		return fmt.Errorf("failed to register upstreamInFlight: %w", err)
+	}
+
+	responseCacheLookupsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "response_cache_lookups_total",
+			Help:      "Total number of response cache lookups for upstream reads, by kind (zone, zone_list) and result (hit, miss)",
+		},
+		[]string{"kind", "result"},
+	)
+	if err := reg.Register(responseCacheLookupsTotalVec); err != nil {
+		return fmt.Errorf("failed to register responseCacheLookupsTotal: %w", err)
+	}
+
+	// Blocklist: rejected requests and currently active entries
+	blocklistRejections := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "bunny",
+		Subsystem: "proxy",
+		Name:      "blocklist_rejections_total",
+		Help:      "Total number of requests rejected because the client address is blocklisted",
+	})
+	if err := reg.Register(blocklistRejections); err != nil {
+		return fmt.Errorf("failed to register blocklistRejectionsTotal: %w", err)
+	}
+	blocklistEntriesGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "bunny",
+		Subsystem: "proxy",
+		Name:      "blocklist_entries",
+		Help:      "Number of active blocklist entries, including temporary bans",
+	})
+	if err := reg.Register(blocklistEntriesGauge); err != nil {
+		return fmt.Errorf("failed to register blocklistEntries: %w", err)
+	}
+
+	// Replay protection: mutating requests rejected for their timestamp or nonce
+	replayRejectionsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "replay_rejections_total",
+			Help:      "Total number of requests rejected by replay protection, by reason (missing, invalid, stale, duplicate, error)",
+		},
+		[]string{"reason"},
+	)
+	if err := reg.Register(replayRejectionsTotalVec); err != nil {
+		return fmt.Errorf("failed to register replayRejectionsTotal: %w", err)
+	}
+
+	// Idempotency keys: mutating requests sent with an Idempotency-Key
+	idempotentRequestsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "idempotent_requests_total",
+			Help:      "Total number of requests sent with an Idempotency-Key, by outcome (new, replayed, in_progress, mismatch, invalid, error)",
+		},
+		[]string{"outcome"},
+	)
+	if err := reg.Register(idempotentRequestsTotalVec); err != nil {
+		return fmt.Errorf("failed to register idempotentRequestsTotal: %w", err)
+	}
+
+	// Zone freezes: mutating requests to a zone during a change freeze
+	frozenRequestsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "frozen_zone_requests_total",
+			Help:      "Total number of mutating requests to a zone during a change freeze, by outcome (rejected, overridden)",
+		},
+		[]string{"outcome"},
+	)
+	if err := reg.Register(frozenRequestsTotalVec); err != nil {
+		return fmt.Errorf("failed to register frozenRequestsTotal: %w", err)
+	}
+
+	// Request limits: bodies, TXT values and imports over their limits
+	requestLimitRejectionsTotalVec := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "bunny",
+			Subsystem: "proxy",
+			Name:      "request_limit_rejections_total",
+			Help:      "Total number of requests rejected for exceeding a size limit, by limit (body, txt_length, import_records)",
+		},
+		[]string{"limit"},
+	)
+	if err := reg.Register(requestLimitRejectionsTotalVec); err != nil {
+		return fmt.Errorf("failed to register requestLimitRejectionsTotal: %w", err)
+	}
+
+	// Honeypot: requests for decoy paths
+	honeypotHits := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "bunny",
+		Subsystem: "proxy",
+		Name:      "honeypot_hits_total",
+		Help:      "Total number of requests for honeypot decoy paths",
+	})
+	if err := reg.Register(honeypotHits); err != nil {
+		return fmt.Errorf("failed to register honeypotHitsTotal: %w", err)
+	}
+
+	// Storage degradation: read-only mode, free space and rejected writes
+	storageDegradedGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "bunny",
+		Subsystem: "proxy",
+		Name:      "storage_degraded",
+		Help:      "1 while the proxy is read-only because the database volume is full or over its limits",
+	})
+	if err := reg.Register(storageDegradedGauge); err != nil {
+		return fmt.Errorf("failed to register storageDegraded: %w", err)
+	}
+	diskFreeGauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "bunny",
+		Subsystem: "proxy",
+		Name:      "disk_free_bytes",
+		Help:      "Free space available to the proxy on the database volume",
+	})
+	if err := reg.Register(diskFreeGauge); err != nil {
+		return fmt.Errorf("failed to register diskFreeBytes: %w", err)
+	}
+	storageDegradedRejectionsCounter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "bunny",
+		Subsystem: "proxy",
+		Name:      "storage_degraded_rejections_total",
+		Help:      "Total number of write requests rejected while storage is degraded",
+	})
+	if err := reg.Register(storageDegradedRejectionsCounter); err != nil {
+		return fmt.Errorf("failed to register storageDegradedRejections: %w", err)
+	}
+
+	// Database connection pool usage and waits, read from SetDBPoolStats
+	if err := reg.Register(newDBPoolCollector()); err != nil {
+		return fmt.Errorf("failed to register dbPoolCollector: %w", err)
+	}
 
 	// Store metrics in atomics for lock-free access in record functions
 	requestsTotal.Store(requestsTotalVec)
 	requestDuration.Store(requestDurationVec)
 	authFailuresTotal.Store(authFailuresTotalVec)
+	info.Store(infoGaugeVec)
+	tokenRequestsTotal.Store(tokenRequestsTotalVec)
+	tokenDenialsTotal.Store(tokenDenialsTotalVec)
+	permissionDenialsTotal.Store(permissionDenialsTotalVec)
+	dbSizeBytes.Store(&dbGauges[0])
+	dbPageCount.Store(&dbGauges[1])
+	dbFreelistPages.Store(&dbGauges[2])
+	sliEventsTotal.Store(sliEventsTotalVec)
+	sloTarget.Store(sloTargetVec)
+	sloBurnRate.Store(sloBurnRateVec)
+	upstreamUnknownFieldsTotal.Store(upstreamUnknownFieldsTotalVec)
+	upstreamRetriesTotal.Store(upstreamRetriesTotalVec)
+	upstreamRequestsTotal.Store(upstreamRequestsTotalVec)
+	upstreamCircuitOpen.Store(upstreamCircuitOpenVec)
+	upstreamKeyValid.Store(upstreamKeyValidVec)
+	upstreamAttemptsTotal.Store(upstreamAttemptsTotalVec)
+	upstreamAttemptDuration.Store(upstreamAttemptDurationVec)
+	upstreamInFlight.Store(upstreamInFlightVec)
+	responseCacheLookupsTotal.Store(responseCacheLookupsTotalVec)
+	blocklistRejectionsTotal.Store(&blocklistRejections)
+	blocklistEntries.Store(&blocklistEntriesGauge)
+	replayRejectionsTotal.Store(replayRejectionsTotalVec)
+	idempotentRequestsTotal.Store(idempotentRequestsTotalVec)
+	frozenRequestsTotal.Store(frozenRequestsTotalVec)
+	requestLimitRejectionsTotal.Store(requestLimitRejectionsTotalVec)
+	honeypotHitsTotal.Store(&honeypotHits)
+	storageDegraded.Store(&storageDegradedGauge)
+	diskFreeBytes.Store(&diskFreeGauge)
+	storageDegradedRejections.Store(&storageDegradedRejectionsCounter)
 
 	return nil
 }
@@ -117,6 +541,135 @@ func RecordAuthFailure(reason string) {
 	}
 }
 
+// RecordTokenRequest counts a proxy request under a token label, and counts
+// it as a denial if statusCode is 401 or 403.
+func RecordTokenRequest(token string, statusCode int) {
+	if counter := tokenRequestsTotal.Load(); counter != nil {
+		counter.WithLabelValues(token, strconv.Itoa(statusCode)).Inc()
+	}
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		if counter := tokenDenialsTotal.Load(); counter != nil {
+			counter.WithLabelValues(token).Inc()
+		}
+	}
+}
+
+// SetInfo sets the version and instance ID reported by bunny_proxy_info,
+// replacing any previous values.
+func SetInfo(version, instanceID string) {
+	if gauge := info.Load(); gauge != nil {
+		gauge.Reset()
+		gauge.WithLabelValues(version, instanceID).Set(1)
+	}
+}
+
+// RecordDBStats sets the database size gauges.
+func RecordDBStats(sizeBytes, pageCount, freelistPages int64) {
+	if g := dbSizeBytes.Load(); g != nil {
+		(*g).Set(float64(sizeBytes))
+	}
+	if g := dbPageCount.Load(); g != nil {
+		(*g).Set(float64(pageCount))
+	}
+	if g := dbFreelistPages.Load(); g != nil {
+		(*g).Set(float64(freelistPages))
+	}
+}
+
+// RecordSLIEvent counts one event for the given service level indicator.
+func RecordSLIEvent(sli string, good bool) {
+	if counter := sliEventsTotal.Load(); counter != nil {
+		result := "bad"
+		if good {
+			result = "good"
+		}
+		counter.WithLabelValues(sli, result).Inc()
+	}
+}
+
+// RecordSLOTarget sets the configured objective for the given indicator.
+func RecordSLOTarget(sli string, target float64) {
+	if gauge := sloTarget.Load(); gauge != nil {
+		gauge.WithLabelValues(sli).Set(target)
+	}
+}
+
+// RecordSLOBurnRate sets the burn rate for the given indicator and window (e.g. "5m").
+func RecordSLOBurnRate(sli, window string, rate float64) {
+	if gauge := sloBurnRate.Load(); gauge != nil {
+		gauge.WithLabelValues(sli, window).Set(rate)
+	}
+}
+
+// RecordUpstreamUnknownField counts an unknown field in a bunny.net response.
+// typeName is the Go response type (e.g. "Zone") and field its JSON path (e.g. "Records[].NewField").
+func RecordUpstreamUnknownField(typeName, field string) {
+	if counter := upstreamUnknownFieldsTotal.Load(); counter != nil {
+		counter.WithLabelValues(typeName, field).Inc()
+	}
+}
+
+// RecordUpstreamRetry counts a retried bunny.net request. reason is the
+// status code of the failed attempt, or "error" for network failures.
+func RecordUpstreamRetry(reason string) {
+	if counter := upstreamRetriesTotal.Load(); counter != nil {
+		counter.WithLabelValues(reason).Inc()
+	}
+}
+
+// RecordUpstreamRequest counts a bunny.net request to an endpoint class,
+// after any retries.
+func RecordUpstreamRequest(class string, success bool) {
+	if counter := upstreamRequestsTotal.Load(); counter != nil {
+		result := "failure"
+		if success {
+			result = "success"
+		}
+		counter.WithLabelValues(class, result).Inc()
+	}
+}
+
+// RecordUpstreamKeyValid records whether bunny.net accepted an account's API key.
+func RecordUpstreamKeyValid(account string, valid bool) {
+	if g := upstreamKeyValid.Load(); g != nil {
+		v := 0.0
+		if valid {
+			v = 1
+		}
+		g.WithLabelValues(account).Set(v)
+	}
+}
+
+// RecordUpstreamCircuitOpen sets whether an endpoint class's circuit is open.
+func RecordUpstreamCircuitOpen(class string, open bool) {
+	if g := upstreamCircuitOpen.Load(); g != nil {
+		v := 0.0
+		if open {
+			v = 1
+		}
+		g.WithLabelValues(class).Set(v)
+	}
+}
+
+// RecordUpstreamAttempt counts and times one HTTP attempt sent to bunny.net.
+// status is the response status code, or "error" when no response arrived.
+func RecordUpstreamAttempt(class, method, status string, durationSeconds float64) {
+	if counter := upstreamAttemptsTotal.Load(); counter != nil {
+		counter.WithLabelValues(class, method, status).Inc()
+	}
+	if histogram := upstreamAttemptDuration.Load(); histogram != nil {
+		histogram.WithLabelValues(class, method, status).Observe(durationSeconds)
+	}
+}
+
+// RecordUpstreamInFlight adds delta to the number of in-flight attempts to
+// an endpoint class: 1 when an attempt starts and -1 when it finishes.
+func RecordUpstreamInFlight(class string, delta float64) {
+	if g := upstreamInFlight.Load(); g != nil {
+		g.WithLabelValues(class).Add(delta)
+	}
+}
+
 // Handler returns an HTTP handler for Prometheus metrics in text format.
 // This handler should be registered at /metrics endpoint.
 func Handler() http.Handler {
@@ -141,3 +694,95 @@ func GetMetricsText(reg prometheus.Gatherer) (string, error) {
 
 	return string(body), nil
 }
+
+// RecordResponseCacheLookup counts a response cache lookup of the given kind.
+func RecordResponseCacheLookup(kind string, hit bool) {
+	if counter := responseCacheLookupsTotal.Load(); counter != nil {
+		result := "miss"
+		if hit {
+			result = "hit"
+		}
+		counter.WithLabelValues(kind, result).Inc()
+	}
+}
+
+// RecordBlocklistRejection counts a request rejected by the blocklist.
+func RecordBlocklistRejection() {
+	if counter := blocklistRejectionsTotal.Load(); counter != nil {
+		(*counter).Inc()
+	}
+}
+
+// RecordBlocklistEntries sets the number of active blocklist entries.
+func RecordBlocklistEntries(n int) {
+	if g := blocklistEntries.Load(); g != nil {
+		(*g).Set(float64(n))
+	}
+}
+
+// RecordPermissionDenial counts a request denied by token permissions.
+func RecordPermissionDenial(reason string) {
+	if counter := permissionDenialsTotal.Load(); counter != nil {
+		counter.WithLabelValues(reason).Inc()
+	}
+}
+
+// RecordReplayRejection counts a request rejected by replay protection.
+func RecordReplayRejection(reason string) {
+	if counter := replayRejectionsTotal.Load(); counter != nil {
+		counter.WithLabelValues(reason).Inc()
+	}
+}
+
+// RecordIdempotentRequest counts a request sent with an Idempotency-Key.
+func RecordIdempotentRequest(outcome string) {
+	if counter := idempotentRequestsTotal.Load(); counter != nil {
+		counter.WithLabelValues(outcome).Inc()
+	}
+}
+
+// RecordFrozenRequest counts a mutating request to a zone during a change freeze.
+func RecordFrozenRequest(outcome string) {
+	if counter := frozenRequestsTotal.Load(); counter != nil {
+		counter.WithLabelValues(outcome).Inc()
+	}
+}
+
+// RecordRequestLimitRejection counts a request rejected for exceeding a size limit.
+func RecordRequestLimitRejection(limit string) {
+	if counter := requestLimitRejectionsTotal.Load(); counter != nil {
+		counter.WithLabelValues(limit).Inc()
+	}
+}
+
+// RecordHoneypotHit counts a request for a honeypot decoy path.
+func RecordHoneypotHit() {
+	if counter := honeypotHitsTotal.Load(); counter != nil {
+		(*counter).Inc()
+	}
+}
+
+// RecordStorageDegraded sets whether the proxy is in read-only degraded mode.
+func RecordStorageDegraded(degraded bool) {
+	if g := storageDegraded.Load(); g != nil {
+		v := 0.0
+		if degraded {
+			v = 1
+		}
+		(*g).Set(v)
+	}
+}
+
+// RecordDiskFree sets the free space on the database volume.
+func RecordDiskFree(bytes int64) {
+	if g := diskFreeBytes.Load(); g != nil {
+		(*g).Set(float64(bytes))
+	}
+}
+
+// RecordStorageDegradedRejection counts a write rejected in degraded mode.
+func RecordStorageDegradedRejection() {
+	if counter := storageDegradedRejections.Load(); counter != nil {
+		(*counter).Inc()
+	}
+}
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"database/sql"
 	"strings"
 	"testing"
 
@@ -22,6 +23,33 @@ func TestInitSucceeds(t *testing.T) {
 	RecordRequest("GET", "/dnszone", "200")
 	RecordRequestDuration("GET", "/dnszone", "200", 0.05)
 	RecordAuthFailure("invalid_key")
+	RecordTokenRequest("ci", 403)
+	RecordDBStats(8192, 2, 1)
+	RecordSLIEvent("availability", true)
+	RecordSLOTarget("availability", 0.999)
+	RecordSLOBurnRate("availability", "5m", 2)
+	RecordUpstreamUnknownField("Zone", "NewField")
+	RecordUpstreamRetry("502")
+	RecordUpstreamRequest("records", true)
+	RecordUpstreamCircuitOpen("certificates", true)
+	RecordUpstreamKeyValid("", true)
+	RecordUpstreamAttempt("zones", "GET", "200", 0.2)
+	RecordUpstreamInFlight("zones", 1)
+	RecordResponseCacheLookup("zone", true)
+	RecordBlocklistRejection()
+	RecordBlocklistEntries(2)
+	RecordPermissionDenial("record_type_not_allowed")
+	RecordReplayRejection("duplicate")
+	RecordIdempotentRequest("replayed")
+	RecordFrozenRequest("rejected")
+	RecordRequestLimitRejection("txt_length")
+	RecordHoneypotHit()
+	RecordStorageDegraded(true)
+	RecordDiskFree(1 << 20)
+	RecordStorageDegradedRejection()
+	SetDBPoolStats(func() map[string]sql.DBStats {
+		return map[string]sql.DBStats{"write": {MaxOpenConnections: 1}}
+	})
 
 	// Verify metrics were registered
 	metrics, err := reg.Gather()
@@ -45,6 +73,37 @@ func TestInitSucceeds(t *testing.T) {
 		"bunny_proxy_request_duration_seconds",
 		"bunny_proxy_auth_failures_total",
 		"bunny_proxy_info",
+		"bunny_proxy_token_requests_total",
+		"bunny_proxy_token_denials_total",
+		"bunny_proxy_db_size_bytes",
+		"bunny_proxy_db_page_count",
+		"bunny_proxy_db_freelist_pages",
+		"bunny_proxy_sli_events_total",
+		"bunny_proxy_slo_target",
+		"bunny_proxy_slo_burn_rate",
+		"bunny_proxy_upstream_unknown_fields_total",
+		"bunny_proxy_upstream_retries_total",
+		"bunny_proxy_upstream_requests_total",
+		"bunny_proxy_upstream_circuit_open",
+		"bunny_proxy_upstream_key_valid",
+		"bunny_proxy_upstream_attempts_total",
+		"bunny_proxy_upstream_request_duration_seconds",
+		"bunny_proxy_upstream_in_flight_requests",
+		"bunny_proxy_response_cache_lookups_total",
+		"bunny_proxy_blocklist_rejections_total",
+		"bunny_proxy_blocklist_entries",
+		"bunny_proxy_permission_denials_total",
+		"bunny_proxy_replay_rejections_total",
+		"bunny_proxy_frozen_zone_requests_total",
+		"bunny_proxy_request_limit_rejections_total",
+		"bunny_proxy_honeypot_hits_total",
+		"bunny_proxy_storage_degraded",
+		"bunny_proxy_disk_free_bytes",
+		"bunny_proxy_storage_degraded_rejections_total",
+		"bunny_proxy_db_connections",
+		"bunny_proxy_db_max_open_connections",
+		"bunny_proxy_db_connection_waits_total",
+		"bunny_proxy_db_connection_wait_seconds_total",
 	}
 
 	foundCount := 0
@@ -73,6 +132,30 @@ func TestRecordFunctionsDoNotPanic(t *testing.T) {
 	RecordRequest("GET", "/test", "200")
 	RecordRequestDuration("GET", "/test", "200", 0.1)
 	RecordAuthFailure("test_reason")
+	RecordTokenRequest("ci", 200)
+	RecordDBStats(1, 1, 0)
+	RecordSLIEvent("availability", false)
+	RecordSLOTarget("availability", 0.99)
+	RecordSLOBurnRate("availability", "1h", 0)
+	RecordUpstreamUnknownField("Record", "Foo")
+	RecordUpstreamRetry("error")
+	RecordUpstreamRequest("zones", false)
+	RecordUpstreamCircuitOpen("zones", false)
+	RecordUpstreamKeyValid("client-a", false)
+	RecordUpstreamAttempt("records", "POST", "error", 1)
+	RecordUpstreamInFlight("records", -1)
+	RecordResponseCacheLookup("zone_list", false)
+	RecordBlocklistRejection()
+	RecordBlocklistEntries(0)
+	RecordPermissionDenial("no_permission")
+	RecordReplayRejection("stale")
+	RecordIdempotentRequest("new")
+	RecordFrozenRequest("overridden")
+	RecordRequestLimitRejection("body")
+	RecordHoneypotHit()
+	RecordStorageDegraded(false)
+	RecordDiskFree(0)
+	RecordStorageDegradedRejection()
 }
 
 // TestHandlerReturnsHTTPHandler verifies that Handler() returns a valid HTTP handler
@@ -197,3 +280,25 @@ func TestInitRegistrationErrors(t *testing.T) {
 		t.Fatal("expected error on duplicate registration, got nil")
 	}
 }
+
+// TestSetInfo verifies the info gauge reports the configured version and instance ID
+func TestSetInfo(t *testing.T) {
+	// Don't run in parallel - calls Init() which modifies global state
+	reg := prometheus.NewRegistry()
+	if err := Init(reg); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	SetInfo("2026.01.2", "proxy-1")
+
+	text, err := GetMetricsText(reg)
+	if err != nil {
+		t.Fatalf("GetMetricsText() failed: %v", err)
+	}
+	if !strings.Contains(text, `bunny_proxy_info{instance_id="proxy-1",version="2026.01.2"} 1`) {
+		t.Errorf("expected info with version and instance ID, got:\n%s", text)
+	}
+	if strings.Contains(text, `version="unknown"`) {
+		t.Error("expected placeholder info series to be replaced")
+	}
+}
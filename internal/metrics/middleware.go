@@ -35,6 +35,11 @@ func (r *statusRecorder) Write(b []byte) (int, error) {
 	return r.ResponseWriter.Write(b)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // Middleware returns an HTTP middleware that records Prometheus metrics for each request.
 // It tracks:
 // - Request count by method, path, and status code
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+)
+
+// Token label modes for TokenMetrics.
+const (
+	TokenLabelsOff  = "off"
+	TokenLabelsName = "name"
+	TokenLabelsHash = "hash"
+)
+
+// otherTokenLabel is shared by tokens that do not get a label of their own.
+const otherTokenLabel = "other"
+
+// TokenMetrics counts proxy requests and denials per token. Labels are the
+// token name or a hash of it; tokens outside the allowlist, or seen after
+// the label limit is reached, share the label "other", so the number of
+// series stays bounded however many tokens exist.
+type TokenMetrics struct {
+	mode      string
+	allowlist map[string]bool
+	maxLabels int
+	tokenName func(r *http.Request) string
+
+	mu     sync.Mutex
+	labels map[string]bool
+}
+
+// NewTokenMetrics creates per-token metrics. mode is TokenLabelsOff,
+// TokenLabelsName or TokenLabelsHash. tokenName returns the name of the
+// request's authenticated token, or "" if there is none.
+func NewTokenMetrics(mode string, allowlist []string, maxLabels int, tokenName func(r *http.Request) string) *TokenMetrics {
+	m := &TokenMetrics{
+		mode:      mode,
+		maxLabels: maxLabels,
+		tokenName: tokenName,
+		labels:    make(map[string]bool),
+	}
+	if len(allowlist) > 0 {
+		m.allowlist = make(map[string]bool, len(allowlist))
+		for _, name := range allowlist {
+			m.allowlist[name] = true
+		}
+	}
+	return m
+}
+
+// Middleware counts each request under its token's label once the response
+// is written. It must run after authentication; requests without a token,
+// such as those using the master key, are not counted.
+func (m *TokenMetrics) Middleware(next http.Handler) http.Handler {
+	if m.mode == TokenLabelsOff || m.mode == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := m.tokenName(r)
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		RecordTokenRequest(m.label(name), recorder.statusCode)
+	})
+}
+
+// label returns the metric label for a token name.
+func (m *TokenMetrics) label(name string) string {
+	if m.allowlist != nil && !m.allowlist[name] {
+		return otherTokenLabel
+	}
+	label := name
+	if m.mode == TokenLabelsHash {
+		sum := sha256.Sum256([]byte(name))
+		label = hex.EncodeToString(sum[:4])
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.labels[label] {
+		return label
+	}
+	if len(m.labels) >= m.maxLabels {
+		return otherTokenLabel
+	}
+	m.labels[label] = true
+	return label
+}
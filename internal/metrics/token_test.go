@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTokenMetricsLabel(t *testing.T) {
+	t.Parallel()
+
+	byName := NewTokenMetrics(TokenLabelsName, nil, 2, nil)
+	if got := byName.label("ci"); got != "ci" {
+		t.Errorf("label(ci) = %q, want ci", got)
+	}
+	if got := byName.label("deploy"); got != "deploy" {
+		t.Errorf("label(deploy) = %q, want deploy", got)
+	}
+	// The limit is reached; known labels are kept and new ones share "other"
+	if got := byName.label("backup"); got != otherTokenLabel {
+		t.Errorf("label(backup) = %q, want other", got)
+	}
+	if got := byName.label("ci"); got != "ci" {
+		t.Errorf("label(ci) again = %q, want ci", got)
+	}
+
+	allowed := NewTokenMetrics(TokenLabelsName, []string{"ci"}, 100, nil)
+	if got := allowed.label("deploy"); got != otherTokenLabel {
+		t.Errorf("label(deploy) outside allowlist = %q, want other", got)
+	}
+
+	hashed := NewTokenMetrics(TokenLabelsHash, nil, 100, nil)
+	got := hashed.label("ci")
+	if len(got) != 8 || got == "ci" || got != hashed.label("ci") {
+		t.Errorf("hashed label = %q, want a stable 8 character hash", got)
+	}
+}
+
+func TestTokenMetricsMiddleware(t *testing.T) {
+	t.Parallel()
+
+	denied := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	calls := 0
+	tokenName := func(r *http.Request) string {
+		calls++
+		return r.Header.Get("X-Token")
+	}
+
+	m := NewTokenMetrics(TokenLabelsName, nil, 100, tokenName)
+	for _, token := range []string{"ci", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+		req.Header.Set("X-Token", token)
+		w := httptest.NewRecorder()
+		m.Middleware(denied).ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", w.Code)
+		}
+	}
+	if calls != 2 || !m.labels["ci"] || len(m.labels) != 1 {
+		t.Errorf("labels = %v after %d calls, want only ci", m.labels, calls)
+	}
+
+	off := NewTokenMetrics(TokenLabelsOff, nil, 100, tokenName)
+	w := httptest.NewRecorder()
+	off.Middleware(denied).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dnszone", nil))
+	if calls != 2 {
+		t.Error("disabled token metrics looked up the token")
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	t.Parallel()
+	cfg := CORSConfig{
+		AllowedOrigins: []string{"https://admin.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"AccessKey", "Content-Type"},
+	}
+	var called bool
+	handler := CORS(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		called = false
+		req := httptest.NewRequest(method, "/api/tokens", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "https://admin.example.com", false)
+	if !called || rec.Header().Get("Access-Control-Allow-Origin") != "https://admin.example.com" {
+		t.Errorf("allowed origin: called %v, headers %v", called, rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("credentials allowed without AllowCredentials")
+	}
+
+	rec = serve(http.MethodOptions, "https://admin.example.com", true)
+	if called || rec.Code != http.StatusNoContent {
+		t.Errorf("preflight: called %v, status %d; want it answered with 204", called, rec.Code)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("Access-Control-Allow-Methods = %q", got)
+	}
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "AccessKey, Content-Type" {
+		t.Errorf("Access-Control-Allow-Headers = %q", got)
+	}
+
+	for _, origin := range []string{"https://evil.example.com", ""} {
+		rec = serve(http.MethodOptions, origin, true)
+		if !called || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("origin %q: called %v, headers %v; want it passed on without CORS headers", origin, called, rec.Header())
+		}
+	}
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	t.Parallel()
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	tests := []struct {
+		credentials bool
+		want        string
+	}{
+		{false, "*"},
+		{true, "https://spa.example.com"},
+	}
+	for _, tt := range tests {
+		handler := CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: tt.credentials})(next)
+		req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+		req.Header.Set("Origin", "https://spa.example.com")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("credentials %v: Access-Control-Allow-Origin = %q, want %q", tt.credentials, got, tt.want)
+		}
+	}
+}
@@ -160,3 +160,8 @@ func (r *responseRecorder) Write(b []byte) (int, error) {
 	r.body.Write(b) // Capture for logging
 	return r.ResponseWriter.Write(b)
 }
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,252 @@
+// Package outbox provides reliable, at-least-once event emission.
+//
+// Events are first persisted to the outbox table, then a background worker
+// drains them to the configured sinks (webhooks today). Events published while
+// a request is served are held in the request's Pending and written in the
+// same transaction as its audit entry, so a request is never audited without
+// its events or the reverse. An event stays pending
+// until every sink accepts it, so events survive crashes and restarts. Because
+// a retry re-sends to all sinks, receivers must deduplicate on the event ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// pollInterval is how often the worker looks for due events.
+	pollInterval = 2 * time.Second
+
+	// batchSize is the maximum number of events handled per poll.
+	batchSize = 50
+
+	// maxAttempts is the number of delivery attempts before an event is marked dead.
+	maxAttempts = 10
+
+	// baseBackoff is the delay after the first failed attempt; it doubles per attempt.
+	baseBackoff = 5 * time.Second
+
+	// maxBackoff caps the delay between attempts.
+	maxBackoff = time.Hour
+
+	// retention is how long delivered events are kept before pruning.
+	retention = 24 * time.Hour
+
+	// pruneInterval is how often delivered events are pruned.
+	pruneInterval = time.Hour
+)
+
+// Store defines the storage operations needed by the outbox.
+type Store interface {
+	EnqueueOutboxEvent(ctx context.Context, e *storage.OutboxEvent) (*storage.OutboxEvent, error)
+	ListDueOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*storage.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, id int64, at time.Time) error
+	MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error
+	PruneOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Event is an outbox event handed to sinks.
+type Event struct {
+	ID        string
+	Name      string
+	Data      json.RawMessage
+	CreatedAt time.Time
+}
+
+// Sink receives events drained from the outbox.
+// Send must return an error if the event was not fully accepted, so it is retried.
+type Sink interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// Outbox persists events and delivers them to sinks in the background.
+type Outbox struct {
+	store     Store
+	sinks     []Sink
+	logger    *slog.Logger
+	now       func() time.Time
+	lastPrune time.Time
+}
+
+// New creates an outbox that drains to the given sinks.
+func New(store Store, logger *slog.Logger, sinks ...Sink) *Outbox {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Outbox{
+		store:  store,
+		sinks:  sinks,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// pendingKey is the context key of a request's Pending.
+type pendingKey struct{}
+
+// Pending holds the events published while a request is served until they
+// are written with its audit entry.
+type Pending struct {
+	mu     sync.Mutex
+	events []*storage.OutboxEvent
+	taken  bool
+}
+
+// WithPending returns a context in which Publish holds events in the
+// returned Pending instead of enqueueing them.
+func WithPending(ctx context.Context) (context.Context, *Pending) {
+	p := &Pending{}
+	return context.WithValue(ctx, pendingKey{}, p), p
+}
+
+// Take returns the events held so far. Events published afterwards, such as
+// by work the request started in the background, are enqueued directly.
+func (p *Pending) Take() []*storage.OutboxEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.taken = true
+	return p.events
+}
+
+// hold adds an event unless Take was called, and reports whether it did.
+func (p *Pending) hold(e *storage.OutboxEvent) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.taken {
+		return false
+	}
+	p.events = append(p.events, e)
+	return true
+}
+
+// Publish persists an event for delivery. data is JSON-encoded. If ctx comes
+// from WithPending, the event is held until it is written with the request's
+// audit entry.
+func (o *Outbox) Publish(ctx context.Context, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	e := &storage.OutboxEvent{
+		EventID:       uuid.NewString(),
+		Event:         event,
+		Payload:       payload,
+		NextAttemptAt: o.now(),
+	}
+	if p, ok := ctx.Value(pendingKey{}).(*Pending); ok && p.hold(e) {
+		return nil
+	}
+	_, err = o.store.EnqueueOutboxEvent(ctx, e)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue event: %w", err)
+	}
+
+	return nil
+}
+
+// Run drains the outbox until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		o.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain delivers one batch of due events and prunes old delivered events.
+func (o *Outbox) drain(ctx context.Context) {
+	now := o.now()
+
+	events, err := o.store.ListDueOutboxEvents(ctx, now, batchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			o.logger.Error("failed to list outbox events", "error", err)
+		}
+		return
+	}
+
+	for _, e := range events {
+		if ctx.Err() != nil {
+			return
+		}
+		o.deliver(ctx, e)
+	}
+
+	if now.Sub(o.lastPrune) >= pruneInterval {
+		o.lastPrune = now
+		if n, err := o.store.PruneOutboxEvents(ctx, now.Add(-retention)); err != nil {
+			o.logger.Error("failed to prune outbox", "error", err)
+		} else if n > 0 {
+			o.logger.Debug("pruned outbox events", "count", n)
+		}
+	}
+}
+
+// deliver sends a single event to every sink and records the outcome.
+func (o *Outbox) deliver(ctx context.Context, e *storage.OutboxEvent) {
+	event := Event{
+		ID:        e.EventID,
+		Name:      e.Event,
+		Data:      json.RawMessage(e.Payload),
+		CreatedAt: e.CreatedAt,
+	}
+
+	var errs []error
+	for _, sink := range o.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		attempt := e.Attempts + 1
+		dead := attempt >= maxAttempts
+		next := o.now().Add(backoff(attempt))
+		if markErr := o.store.MarkOutboxEventFailed(ctx, e.ID, err.Error(), next, dead); markErr != nil {
+			o.logger.Error("failed to record outbox failure", "error", markErr, "event_id", e.EventID)
+			return
+		}
+		if dead {
+			o.logger.Error("outbox event gave up after max attempts", "event_id", e.EventID, "event", e.Event,
+				"attempts", attempt, "error", err)
+		} else {
+			o.logger.Warn("outbox event delivery failed", "event_id", e.EventID, "event", e.Event,
+				"attempt", attempt, "next_attempt", next, "error", err)
+		}
+		return
+	}
+
+	if err := o.store.MarkOutboxEventDelivered(ctx, e.ID, o.now()); err != nil {
+		// The event will be redelivered; receivers deduplicate on event ID.
+		o.logger.Error("failed to mark outbox event delivered", "error", err, "event_id", e.EventID)
+	}
+}
+
+// backoff returns the delay before the given retry attempt (1-based).
+func backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
@@ -0,0 +1,244 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestStore(t *testing.T) *storage.SQLiteStorage {
+	t.Helper()
+	s, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// recordingSink records events and fails while failing is set.
+type recordingSink struct {
+	mu      sync.Mutex
+	events  []Event
+	failing bool
+}
+
+func (s *recordingSink) Send(_ context.Context, e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+	if s.failing {
+		return errors.New("sink unavailable")
+	}
+	return nil
+}
+
+func (s *recordingSink) received() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event(nil), s.events...)
+}
+
+func TestPublishAndDrain(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	sink := &recordingSink{}
+	o := New(store, testLogger(), sink)
+	ctx := context.Background()
+
+	if err := o.Publish(ctx, "record.created", map[string]any{"zone_id": 1}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	o.drain(ctx)
+
+	got := sink.received()
+	if len(got) != 1 || got[0].Name != "record.created" || string(got[0].Data) != `{"zone_id":1}` || got[0].ID == "" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+
+	// Delivered events are not sent again
+	o.drain(ctx)
+	if len(sink.received()) != 1 {
+		t.Errorf("expected event to be delivered once, got %d", len(sink.received()))
+	}
+}
+
+func TestPublish_HoldsPendingEvents(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	o := New(store, testLogger())
+	ctx, pending := WithPending(context.Background())
+
+	if err := o.Publish(ctx, "record.created", map[string]any{"zone_id": 1}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	due, err := store.ListDueOutboxEvents(context.Background(), time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutboxEvents() error = %v", err)
+	}
+	if len(due) != 0 {
+		t.Fatalf("held event should not be enqueued, got %d", len(due))
+	}
+
+	held := pending.Take()
+	if len(held) != 1 || held[0].Event != "record.created" || string(held[0].Payload) != `{"zone_id":1}` || held[0].EventID == "" {
+		t.Fatalf("unexpected held events: %+v", held)
+	}
+
+	// Events published after Take are enqueued directly
+	if err := o.Publish(ctx, "record.deleted", map[string]any{"zone_id": 1}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	due, err = store.ListDueOutboxEvents(context.Background(), time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutboxEvents() error = %v", err)
+	}
+	if len(due) != 1 || due[0].Event != "record.deleted" {
+		t.Fatalf("unexpected due events: %+v", due)
+	}
+	if len(pending.Take()) != 1 {
+		t.Error("Take() should not gain events after it was called")
+	}
+}
+
+func TestDrain_RetriesWithStableEventID(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	sink := &recordingSink{failing: true}
+	o := New(store, testLogger(), sink)
+	ctx := context.Background()
+
+	now := time.Now()
+	o.now = func() time.Time { return now }
+
+	if err := o.Publish(ctx, "zone.deleted", nil); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	o.drain(ctx)
+
+	// Not due again until the backoff has elapsed
+	o.drain(ctx)
+	if len(sink.received()) != 1 {
+		t.Fatalf("expected 1 attempt before backoff elapsed, got %d", len(sink.received()))
+	}
+
+	sink.mu.Lock()
+	sink.failing = false
+	sink.mu.Unlock()
+	now = now.Add(baseBackoff)
+	o.drain(ctx)
+
+	got := sink.received()
+	if len(got) != 2 {
+		t.Fatalf("expected retry after backoff, got %d attempts", len(got))
+	}
+	if got[0].ID != got[1].ID {
+		t.Errorf("event ID changed across retries: %s vs %s", got[0].ID, got[1].ID)
+	}
+}
+
+func TestDrain_SurvivesRestart(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	// Published by one process that never drained...
+	if err := New(store, testLogger()).Publish(ctx, "record.deleted", nil); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	// ...and delivered by the next one.
+	sink := &recordingSink{}
+	New(store, testLogger(), sink).drain(ctx)
+	if len(sink.received()) != 1 {
+		t.Errorf("expected pending event to be delivered after restart, got %d", len(sink.received()))
+	}
+}
+
+func TestDrain_MarksDeadAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	var dead bool
+	store := &mockstore.MockStorage{
+		ListDueOutboxEventsFunc: func(context.Context, time.Time, int) ([]*storage.OutboxEvent, error) {
+			return []*storage.OutboxEvent{{ID: 1, EventID: "e", Event: "x", Payload: []byte("null"), Attempts: maxAttempts - 1}}, nil
+		},
+		MarkOutboxEventFailedFunc: func(_ context.Context, _ int64, _ string, _ time.Time, d bool) error {
+			dead = d
+			return nil
+		},
+	}
+
+	New(store, testLogger(), &recordingSink{failing: true}).drain(context.Background())
+	if !dead {
+		t.Error("expected event to be marked dead on final attempt")
+	}
+}
+
+func TestDrain_Prunes(t *testing.T) {
+	t.Parallel()
+
+	var pruneCalls int
+	store := &mockstore.MockStorage{
+		PruneOutboxEventsFunc: func(context.Context, time.Time) (int64, error) {
+			pruneCalls++
+			return 0, nil
+		},
+	}
+
+	o := New(store, testLogger())
+	o.drain(context.Background())
+	o.drain(context.Background())
+	if pruneCalls != 1 {
+		t.Errorf("expected prune once per interval, got %d", pruneCalls)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		New(newTestStore(t), testLogger()).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{20, maxBackoff},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+// Package provision creates and updates tokens from a declarative config file
+// at startup, so deployments can keep their tokens in version control instead
+// of calling the admin API after every fresh deploy.
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// minSecretLength is the shortest token secret a config file may set.
+const minSecretLength = 32
+
+// File is a tokens config file.
+type File struct {
+	// Prune deletes tokens that an earlier run provisioned and the file no
+	// longer lists. Tokens created through the admin API are never pruned.
+	Prune  bool        `json:"prune"`
+	Tokens []TokenSpec `json:"tokens"`
+}
+
+// TokenSpec is a token the file provisions. Its secret is read from the
+// environment variable SecretEnv or the file SecretFile, never from the
+// config file itself, and identifies the token between runs.
+type TokenSpec struct {
+	Name        string           `json:"name"`
+	IsAdmin     bool             `json:"is_admin"`
+	SecretEnv   string           `json:"secret_env,omitempty"`
+	SecretFile  string           `json:"secret_file,omitempty"`
+	Permissions []PermissionSpec `json:"permissions,omitempty"`
+	// Roles are role names; the roles must exist.
+	Roles []string `json:"roles,omitempty"`
+
+	secret string
+}
+
+// PermissionSpec is a permission of a scoped token, as accepted by
+// POST /admin/api/tokens/{id}/permissions.
+type PermissionSpec struct {
+	Resource       string   `json:"resource,omitempty"`
+	ZoneID         int64    `json:"zone_id"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types,omitempty"`
+}
+
+// Load reads and validates a tokens config file, resolving secrets with
+// getenv and from files.
+func Load(path string, getenv func(string) string) (*File, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is operator configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens config: %w", err)
+	}
+
+	var f File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("invalid tokens config %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(f.Tokens))
+	secrets := make(map[string]string, len(f.Tokens))
+	for i := range f.Tokens {
+		t := &f.Tokens[i]
+		t.Name = strings.TrimSpace(t.Name)
+		if t.Name == "" {
+			return nil, fmt.Errorf("invalid tokens config: token %d has no name", i+1)
+		}
+		if names[t.Name] {
+			return nil, fmt.Errorf("invalid tokens config: token %q is listed twice", t.Name)
+		}
+		names[t.Name] = true
+
+		if err := t.resolveSecret(getenv); err != nil {
+			return nil, fmt.Errorf("invalid tokens config: token %q: %w", t.Name, err)
+		}
+		if other, ok := secrets[t.secret]; ok {
+			return nil, fmt.Errorf("invalid tokens config: tokens %q and %q have the same secret", other, t.Name)
+		}
+		secrets[t.secret] = t.Name
+
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("invalid tokens config: token %q: %w", t.Name, err)
+		}
+	}
+	return &f, nil
+}
+
+// resolveSecret reads the token's secret from its environment variable or file.
+func (t *TokenSpec) resolveSecret(getenv func(string) string) error {
+	switch {
+	case t.SecretEnv != "" && t.SecretFile != "":
+		return errors.New("set only one of secret_env and secret_file")
+	case t.SecretEnv != "":
+		t.secret = strings.TrimSpace(getenv(t.SecretEnv))
+		if t.secret == "" {
+			return fmt.Errorf("environment variable %s is not set", t.SecretEnv)
+		}
+	case t.SecretFile != "":
+		data, err := os.ReadFile(t.SecretFile)
+		if err != nil {
+			return fmt.Errorf("failed to read secret_file: %w", err)
+		}
+		t.secret = strings.TrimSpace(string(data))
+	default:
+		return errors.New("secret_env or secret_file is required")
+	}
+	if len(t.secret) < minSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", minSecretLength)
+	}
+	// Such a key would be looked up as the token with that ID
+	if _, _, ok := auth.ParseKey(t.secret); ok {
+		return errors.New("secret must not have the <id>.<secret> form of generated keys")
+	}
+	return nil
+}
+
+// validate checks the token's permissions and normalizes them.
+func (t *TokenSpec) validate() error {
+	if t.IsAdmin {
+		if len(t.Permissions) > 0 || len(t.Roles) > 0 {
+			return errors.New("admin tokens cannot have permissions or roles")
+		}
+		return nil
+	}
+	if len(t.Permissions) == 0 && len(t.Roles) == 0 {
+		return errors.New("scoped tokens need at least one permission or role")
+	}
+	for i := range t.Permissions {
+		p := &t.Permissions[i]
+		if p.Resource == "" {
+			p.Resource = storage.ResourceDNSZone
+		}
+		if !storage.IsValidResource(p.Resource) {
+			return fmt.Errorf("permission %d: invalid resource %q", i+1, p.Resource)
+		}
+		if p.ZoneID <= 0 {
+			return fmt.Errorf("permission %d: zone_id must be greater than 0", i+1)
+		}
+		if len(p.AllowedActions) == 0 {
+			return fmt.Errorf("permission %d: at least one action is required", i+1)
+		}
+		if p.Resource == storage.ResourceDNSZone && len(p.RecordTypes) == 0 {
+			return fmt.Errorf("permission %d: at least one record type is required", i+1)
+		}
+		for j, rt := range p.RecordTypes {
+			p.RecordTypes[j] = strings.ToUpper(strings.TrimSpace(rt))
+		}
+	}
+	return nil
+}
+
+// Store defines the storage operations needed to reconcile tokens.
+type Store interface {
+	CreateToken(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error)
+	GetTokenByHash(ctx context.Context, keyHash string) (*storage.Token, error)
+	GetTokenByID(ctx context.Context, id int64) (*storage.Token, error)
+	DeleteToken(ctx context.Context, id int64) error
+	CountAdminTokens(ctx context.Context) (int, error)
+	AddPermissionForToken(ctx context.Context, tokenID int64, perm *storage.Permission) (*storage.Permission, error)
+	RemovePermissionForToken(ctx context.Context, tokenID, permID int64) error
+	GetPermissionsForToken(ctx context.Context, tokenID int64) ([]*storage.Permission, error)
+	ListRoles(ctx context.Context) ([]*storage.Role, error)
+	ListTokenRoles(ctx context.Context, tokenID int64) ([]*storage.Role, error)
+	AssignRole(ctx context.Context, tokenID, roleID int64) error
+	UnassignRole(ctx context.Context, tokenID, roleID int64) error
+	MarkTokenProvisioned(ctx context.Context, tokenID int64) error
+	ListProvisionedTokenIDs(ctx context.Context) ([]int64, error)
+	CompleteBootstrap(ctx context.Context, at time.Time) error
+}
+
+// Result counts what a reconcile changed.
+type Result struct {
+	Created   int // tokens created, including replaced ones
+	Updated   int // tokens whose permissions or roles changed
+	Unchanged int
+	Pruned    int
+}
+
+// Reconcile makes the store match the file. A token is found by the hash of
+// its secret. Missing tokens are created, and existing ones get exactly the
+// permissions and roles the file lists; a token whose name or admin flag
+// changed is replaced. Permissions added by zone tags are left alone. With
+// Prune, provisioned tokens the file no longer lists are deleted, except the
+// last admin token.
+//
+// Reconciling is idempotent, so every instance sharing a database can run it
+// at startup.
+func Reconcile(ctx context.Context, store Store, f *File, logger *slog.Logger) (Result, error) {
+	var res Result
+
+	roleIDs := make(map[string]int64)
+	roles, err := store.ListRoles(ctx)
+	if err != nil {
+		return res, err
+	}
+	for _, r := range roles {
+		roleIDs[r.Name] = r.ID
+	}
+
+	keep := make(map[int64]bool, len(f.Tokens))
+	createdAdmin := false
+	for i := range f.Tokens {
+		spec := &f.Tokens[i]
+		token, created, err := ensureToken(ctx, store, spec, logger)
+		if err != nil {
+			return res, fmt.Errorf("token %q: %w", spec.Name, err)
+		}
+		keep[token.ID] = true
+		if err := store.MarkTokenProvisioned(ctx, token.ID); err != nil {
+			return res, fmt.Errorf("token %q: %w", spec.Name, err)
+		}
+
+		changed, err := syncPermissions(ctx, store, token.ID, spec.Permissions)
+		if err != nil {
+			return res, fmt.Errorf("token %q: %w", spec.Name, err)
+		}
+		rolesChanged, err := syncRoles(ctx, store, token.ID, spec.Roles, roleIDs)
+		if err != nil {
+			return res, fmt.Errorf("token %q: %w", spec.Name, err)
+		}
+
+		switch {
+		case created:
+			res.Created++
+			createdAdmin = createdAdmin || spec.IsAdmin
+			logger.Info("provisioned token created", "id", token.ID, "name", spec.Name, "is_admin", spec.IsAdmin)
+		case changed || rolesChanged:
+			res.Updated++
+			logger.Info("provisioned token updated", "id", token.ID, "name", spec.Name)
+		default:
+			res.Unchanged++
+		}
+	}
+
+	// A provisioned admin token ends bootstrap, as one created through the API does
+	if createdAdmin {
+		if err := store.CompleteBootstrap(ctx, time.Now()); err != nil {
+			return res, err
+		}
+	}
+
+	if f.Prune {
+		n, err := prune(ctx, store, keep, logger)
+		res.Pruned = n
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// ensureToken returns the token with spec's secret, creating it if it does
+// not exist or replacing it if its name or admin flag differ.
+func ensureToken(ctx context.Context, store Store, spec *TokenSpec, logger *slog.Logger) (*storage.Token, bool, error) {
+	keyHash := auth.HashToken(spec.secret)
+	token, err := store.GetTokenByHash(ctx, keyHash)
+	switch {
+	case err == nil && token.Name == spec.Name && token.IsAdmin == spec.IsAdmin:
+		return token, false, nil
+	case err == nil:
+		logger.Info("replacing provisioned token whose name or admin flag changed", "id", token.ID, "name", spec.Name)
+		if err := store.DeleteToken(ctx, token.ID); err != nil {
+			return nil, false, err
+		}
+	case !errors.Is(err, storage.ErrNotFound):
+		return nil, false, err
+	}
+
+	token, err = store.CreateToken(ctx, spec.Name, spec.IsAdmin, keyHash)
+	if errors.Is(err, storage.ErrDuplicate) {
+		// Another instance created it first
+		token, err = store.GetTokenByHash(ctx, keyHash)
+		return token, false, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return token, true, nil
+}
+
+// permissionKey identifies a permission by what it grants.
+func permissionKey(resource string, zoneID int64, actions, recordTypes []string) string {
+	if resource == "" {
+		resource = storage.ResourceDNSZone
+	}
+	actions, recordTypes = slices.Clone(actions), slices.Clone(recordTypes)
+	slices.Sort(actions)
+	slices.Sort(recordTypes)
+	return fmt.Sprintf("%s/%d/%s/%s", resource, zoneID, strings.Join(actions, ","), strings.Join(recordTypes, ","))
+}
+
+// syncPermissions gives a token exactly the wanted direct permissions and
+// reports whether anything changed.
+func syncPermissions(ctx context.Context, store Store, tokenID int64, want []PermissionSpec) (bool, error) {
+	current, err := store.GetPermissionsForToken(ctx, tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	wanted := make(map[string]PermissionSpec, len(want))
+	for _, p := range want {
+		wanted[permissionKey(p.Resource, p.ZoneID, p.AllowedActions, p.RecordTypes)] = p
+	}
+
+	changed := false
+	for _, p := range current {
+		if p.RoleID != 0 || p.TagPermissionID != 0 {
+			continue
+		}
+		key := permissionKey(p.Resource, p.ZoneID, p.AllowedActions, p.RecordTypes)
+		if _, ok := wanted[key]; ok {
+			delete(wanted, key)
+			continue
+		}
+		if err := store.RemovePermissionForToken(ctx, tokenID, p.ID); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+
+	for _, p := range want {
+		key := permissionKey(p.Resource, p.ZoneID, p.AllowedActions, p.RecordTypes)
+		if _, ok := wanted[key]; !ok {
+			continue
+		}
+		delete(wanted, key)
+		_, err := store.AddPermissionForToken(ctx, tokenID, &storage.Permission{
+			Resource:       p.Resource,
+			ZoneID:         p.ZoneID,
+			AllowedActions: p.AllowedActions,
+			RecordTypes:    p.RecordTypes,
+		})
+		if err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// syncRoles gives a token exactly the named roles and reports whether
+// anything changed.
+func syncRoles(ctx context.Context, store Store, tokenID int64, names []string, roleIDs map[string]int64) (bool, error) {
+	want := make(map[int64]bool, len(names))
+	for _, name := range names {
+		id, ok := roleIDs[name]
+		if !ok {
+			return false, fmt.Errorf("role %q does not exist", name)
+		}
+		want[id] = true
+	}
+
+	current, err := store.ListTokenRoles(ctx, tokenID)
+	if err != nil {
+		return false, err
+	}
+	changed := false
+	for _, r := range current {
+		if want[r.ID] {
+			delete(want, r.ID)
+			continue
+		}
+		if err := store.UnassignRole(ctx, tokenID, r.ID); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	for id := range want {
+		if err := store.AssignRole(ctx, tokenID, id); err != nil && !errors.Is(err, storage.ErrDuplicate) {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// prune deletes provisioned tokens not in keep and returns how many it deleted.
+func prune(ctx context.Context, store Store, keep map[int64]bool, logger *slog.Logger) (int, error) {
+	ids, err := store.ListProvisionedTokenIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, id := range ids {
+		if keep[id] {
+			continue
+		}
+		token, err := store.GetTokenByID(ctx, id)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return pruned, err
+		}
+		if token.IsAdmin {
+			admins, err := store.CountAdminTokens(ctx)
+			if err != nil {
+				return pruned, err
+			}
+			if admins <= 1 {
+				logger.Warn("not pruning the last admin token", "id", id, "name", token.Name)
+				continue
+			}
+		}
+		if err := store.DeleteToken(ctx, id); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return pruned, err
+		}
+		pruned++
+		logger.Info("provisioned token pruned", "id", id, "name", token.Name)
+	}
+	return pruned, nil
+}
@@ -0,0 +1,238 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	adminSecret = "admin-secret-0123456789abcdef0123456789"
+	ciSecret    = "ci-secret-0123456789abcdef0123456789abcd"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestStore(t *testing.T) *storage.SQLiteStorage {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func mustLoad(t *testing.T, content string) *File {
+	t.Helper()
+	f, err := Load(writeConfig(t, content), env(map[string]string{"ADMIN_SECRET": adminSecret, "CI_SECRET": ciSecret}))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return f
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte(ciSecret+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Load(writeConfig(t, `{"tokens": [
+		{"name": "ci", "secret_file": "`+secretFile+`",
+		 "permissions": [{"zone_id": 1, "allowed_actions": ["list_records"], "record_types": ["txt"]}]}
+	]}`), env(nil))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if f.Tokens[0].secret != ciSecret {
+		t.Errorf("secret = %q, want trimmed file contents", f.Tokens[0].secret)
+	}
+	p := f.Tokens[0].Permissions[0]
+	if p.Resource != storage.ResourceDNSZone || p.RecordTypes[0] != "TXT" {
+		t.Errorf("permission = %+v, want normalized resource and record types", p)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config  string
+		wantErr string
+	}{
+		"unknown field":   {`{"tokens": [], "prun": true}`, "unknown field"},
+		"no name":         {`{"tokens": [{"secret_env": "ADMIN_SECRET", "is_admin": true}]}`, "has no name"},
+		"duplicate name":  {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET", "is_admin": true}, {"name": "a", "secret_env": "CI_SECRET", "is_admin": true}]}`, "listed twice"},
+		"no secret":       {`{"tokens": [{"name": "a", "is_admin": true}]}`, "secret_env or secret_file is required"},
+		"both secrets":    {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET", "secret_file": "/x", "is_admin": true}]}`, "only one"},
+		"unset env":       {`{"tokens": [{"name": "a", "secret_env": "MISSING", "is_admin": true}]}`, "MISSING is not set"},
+		"short secret":    {`{"tokens": [{"name": "a", "secret_env": "SHORT", "is_admin": true}]}`, "at least 32"},
+		"id secret":       {`{"tokens": [{"name": "a", "secret_env": "ID_SECRET", "is_admin": true}]}`, "<id>.<secret>"},
+		"shared secret":   {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET", "is_admin": true}, {"name": "b", "secret_env": "ADMIN_SECRET", "is_admin": true}]}`, "same secret"},
+		"admin with role": {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET", "is_admin": true, "roles": ["ops"]}]}`, "cannot have permissions"},
+		"empty scoped":    {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET"}]}`, "at least one permission or role"},
+		"zero zone":       {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET", "permissions": [{"allowed_actions": ["list_records"], "record_types": ["A"]}]}]}`, "zone_id"},
+		"bad resource":    {`{"tokens": [{"name": "a", "secret_env": "ADMIN_SECRET", "permissions": [{"resource": "cdn", "zone_id": 1, "allowed_actions": ["list_records"]}]}]}`, "invalid resource"},
+	}
+	getenv := env(map[string]string{"ADMIN_SECRET": adminSecret, "CI_SECRET": ciSecret, "SHORT": "short",
+		"ID_SECRET": "42.0123456789abcdef0123456789abcdef"})
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Load(writeConfig(t, tt.config), getenv)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	if _, err := store.CreateRole(ctx, &storage.Role{Name: "ops"}); err != nil {
+		t.Fatalf("CreateRole failed: %v", err)
+	}
+	// Tokens created through the API are never pruned
+	manual, err := store.CreateToken(ctx, "manual", false, "manual-hash")
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	f := mustLoad(t, `{"prune": true, "tokens": [
+		{"name": "admin", "is_admin": true, "secret_env": "ADMIN_SECRET"},
+		{"name": "ci", "secret_env": "CI_SECRET", "roles": ["ops"],
+		 "permissions": [{"zone_id": 1, "allowed_actions": ["list_records", "add_record"], "record_types": ["TXT"]}]}
+	]}`)
+	res, err := Reconcile(ctx, store, f, testLogger())
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if res.Created != 2 || res.Updated != 0 || res.Pruned != 0 {
+		t.Errorf("first Reconcile = %+v, want 2 created", res)
+	}
+	if at, err := store.GetBootstrapCompletedAt(ctx); err != nil || at.IsZero() {
+		t.Errorf("bootstrap not completed after provisioning an admin token: %v, %v", at, err)
+	}
+
+	ci, err := store.GetTokenByHash(ctx, auth.HashToken(ciSecret))
+	if err != nil {
+		t.Fatalf("ci token not created: %v", err)
+	}
+	roles, err := store.ListTokenRoles(ctx, ci.ID)
+	if err != nil || len(roles) != 1 || roles[0].Name != "ops" {
+		t.Errorf("ci roles = %v, %v, want [ops]", roles, err)
+	}
+
+	// Running again with the same file changes nothing
+	res, err = Reconcile(ctx, store, f, testLogger())
+	if err != nil {
+		t.Fatalf("second Reconcile failed: %v", err)
+	}
+	if res.Unchanged != 2 || res.Created != 0 || res.Updated != 0 {
+		t.Errorf("second Reconcile = %+v, want 2 unchanged", res)
+	}
+
+	// Changing the permission replaces the old one and drops the role;
+	// removing the admin token from the file keeps it as the last admin
+	f = mustLoad(t, `{"prune": true, "tokens": [
+		{"name": "ci", "secret_env": "CI_SECRET",
+		 "permissions": [{"zone_id": 2, "allowed_actions": ["list_records"], "record_types": ["A"]}]}
+	]}`)
+	res, err = Reconcile(ctx, store, f, testLogger())
+	if err != nil {
+		t.Fatalf("third Reconcile failed: %v", err)
+	}
+	if res.Updated != 1 || res.Pruned != 0 {
+		t.Errorf("third Reconcile = %+v, want 1 updated and the last admin kept", res)
+	}
+	perms, err := store.GetPermissionsForToken(ctx, ci.ID)
+	if err != nil || len(perms) != 1 || perms[0].ZoneID != 2 {
+		t.Errorf("ci permissions = %+v, %v, want only zone 2", perms, err)
+	}
+	if _, err := store.GetTokenByHash(ctx, auth.HashToken(adminSecret)); err != nil {
+		t.Errorf("last admin token was pruned: %v", err)
+	}
+
+	// An empty file prunes the scoped token but not the manual one
+	res, err = Reconcile(ctx, store, mustLoad(t, `{"prune": true, "tokens": []}`), testLogger())
+	if err != nil {
+		t.Fatalf("fourth Reconcile failed: %v", err)
+	}
+	if res.Pruned != 1 {
+		t.Errorf("fourth Reconcile = %+v, want 1 pruned", res)
+	}
+	if _, err := store.GetTokenByID(ctx, ci.ID); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("ci token not pruned: %v", err)
+	}
+	if _, err := store.GetTokenByID(ctx, manual.ID); err != nil {
+		t.Errorf("manual token was pruned: %v", err)
+	}
+}
+
+func TestReconcile_ReplacesChangedToken(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	f := mustLoad(t, `{"tokens": [{"name": "ci", "secret_env": "CI_SECRET",
+		"permissions": [{"zone_id": 1, "allowed_actions": ["list_records"], "record_types": ["A"]}]}]}`)
+	if _, err := Reconcile(ctx, store, f, testLogger()); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	before, err := store.GetTokenByHash(ctx, auth.HashToken(ciSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Tokens[0].Name = "deploy"
+	res, err := Reconcile(ctx, store, f, testLogger())
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	after, err := store.GetTokenByHash(ctx, auth.HashToken(ciSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Created != 1 || after.ID == before.ID || after.Name != "deploy" {
+		t.Errorf("Reconcile = %+v, token = %+v, want a replaced token named deploy", res, after)
+	}
+}
+
+func TestReconcile_UnknownRole(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+
+	f := mustLoad(t, `{"tokens": [{"name": "ci", "secret_env": "CI_SECRET", "roles": ["missing"]}]}`)
+	_, err := Reconcile(context.Background(), store, f, testLogger())
+	if err == nil || !strings.Contains(err.Error(), `role "missing" does not exist`) {
+		t.Errorf("Reconcile error = %v, want unknown role", err)
+	}
+}
@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+)
+
+// SetAccounts registers the clients of further bunny.net accounts by name.
+// Requests from tokens assigned to one of them are sent with its client;
+// all other requests use the client given to NewHandler.
+// Must be called before SetResponseCache and NewRouter.
+func (h *Handler) SetAccounts(clients map[string]BunnyClient) {
+	h.accounts = clients
+}
+
+// upstream returns the client for the account of the token in ctx.
+func (h *Handler) upstream(ctx context.Context) BunnyClient {
+	if token := auth.TokenFromContext(ctx); token != nil && token.Account != "" {
+		if client, ok := h.accounts[token.Account]; ok {
+			return client
+		}
+	}
+	return h.client
+}
+
+// accountOf returns the configured account of the token in ctx, or "" for
+// the default account.
+func (h *Handler) accountOf(ctx context.Context) string {
+	if token := auth.TokenFromContext(ctx); token != nil {
+		if _, ok := h.accounts[token.Account]; ok {
+			return token.Account
+		}
+	}
+	return ""
+}
+
+// clientFor returns the client of an account, "" being the default one.
+// It reports false if the account is not configured.
+func (h *Handler) clientFor(account string) (BunnyClient, bool) {
+	if account == "" {
+		return h.client, true
+	}
+	client, ok := h.accounts[account]
+	return client, ok
+}
+
+// uncached returns client without the response cache in front of it.
+func uncached(client BunnyClient) BunnyClient {
+	if cached, isCached := client.(*cachingClient); isCached {
+		return cached.BunnyClient
+	}
+	return client
+}
+
+// requireAccount rejects requests from tokens assigned to an account that is
+// no longer configured, instead of sending them to the default account.
+func (h *Handler) requireAccount(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := auth.TokenFromContext(r.Context()); token != nil && token.Account != "" {
+			if _, ok := h.accounts[token.Account]; !ok {
+				h.logger.Warn("token assigned to an unconfigured account",
+					"token", token.Name, "account", token.Account)
+				writeError(w, http.StatusServiceUnavailable, "upstream account not configured")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
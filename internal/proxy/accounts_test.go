@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// accountTestClient returns a mock whose zone list names the account.
+func accountTestClient(name string) *mockBunnyClient {
+	return &mockBunnyClient{
+		listZonesFunc: func(context.Context, *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+			return &bunny.ListZonesResponse{Items: []bunny.Zone{{ID: 1, Domain: name + ".example"}}, TotalItems: 1}, nil
+		},
+	}
+}
+
+func TestRouter_RoutesTokensToTheirAccount(t *testing.T) {
+	t.Parallel()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := NewHandler(accountTestClient("default"), logger)
+	h.SetAccounts(map[string]BunnyClient{"client-a": accountTestClient("client-a")})
+	h.SetResponseCache(time.Minute, 0)
+
+	for account, want := range map[string]struct {
+		status int
+		domain string
+	}{
+		"":         {http.StatusOK, "default.example"},
+		"client-a": {http.StatusOK, "client-a.example"},
+		"removed":  {http.StatusServiceUnavailable, ""},
+	} {
+		token := &storage.Token{ID: 1, Name: "ci", IsAdmin: true, Account: account}
+		withToken := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx := auth.WithAdmin(auth.WithToken(r.Context(), token), true)
+				ctx = auth.WithPermissions(ctx, []*storage.Permission{{ZoneID: 0, AllowedActions: []string{"list_zones"}}})
+				next.ServeHTTP(w, r.WithContext(ctx))
+			})
+		}
+
+		w := httptest.NewRecorder()
+		NewRouter(h, withToken, logger).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dnszone", nil))
+		if w.Code != want.status {
+			t.Errorf("account %q: expected %d, got %d: %s", account, want.status, w.Code, w.Body.String())
+			continue
+		}
+		if want.domain != "" && !strings.Contains(w.Body.String(), want.domain) {
+			t.Errorf("account %q: expected zones of %s, got %s", account, want.domain, w.Body.String())
+		}
+	}
+}
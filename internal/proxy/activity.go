@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// Limits for GET /api/my/activity.
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 500
+)
+
+// ActivityStore defines the storage operations needed to list a token's own audit entries.
+type ActivityStore interface {
+	ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]*storage.AuditEntry, error)
+}
+
+// SetActivityStore enables GET /api/my/activity. Must be called before NewRouter.
+func (h *Handler) SetActivityStore(store ActivityStore) {
+	h.activity = store
+}
+
+// ActivityEntry is one of the calling token's audit entries.
+type ActivityEntry struct {
+	ID         int64  `json:"Id"`
+	Action     string `json:"Action,omitempty"`
+	ZoneID     int64  `json:"ZoneId,omitempty"`
+	RecordType string `json:"RecordType,omitempty"`
+	Method     string `json:"Method"`
+	Path       string `json:"Path"`
+	Status     int    `json:"Status"`
+	RequestID  string `json:"RequestId,omitempty"`
+	CreatedAt  string `json:"CreatedAt"`
+}
+
+// ActivityResponse is the response body for GET /api/my/activity.
+type ActivityResponse struct {
+	Items      []ActivityEntry `json:"Items"`
+	TotalItems int             `json:"TotalItems"`
+}
+
+// HandleListMyActivity returns the calling token's own recent audit entries, newest first.
+// GET /api/my/activity?limit=50
+// Only the caller's entries are ever returned. The master key has no token and no activity.
+func (h *Handler) HandleListMyActivity(w http.ResponseWriter, r *http.Request) {
+	token := auth.TokenFromContext(r.Context())
+	if token == nil {
+		writeError(w, http.StatusBadRequest, "activity is only available for token-authenticated requests")
+		return
+	}
+
+	limit := defaultActivityLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxActivityLimit {
+			writeError(w, http.StatusBadRequest, "invalid limit parameter")
+			return
+		}
+		limit = n
+	}
+
+	entries, err := h.activity.ListAuditEntries(r.Context(), storage.AuditFilter{TokenID: token.ID, Limit: limit})
+	if err != nil {
+		h.logger.Error("failed to list activity", "error", err, "token_id", token.ID)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	resp := ActivityResponse{Items: make([]ActivityEntry, 0, len(entries))}
+	for _, e := range entries {
+		resp.Items = append(resp.Items, ActivityEntry{
+			ID:         e.ID,
+			Action:     e.Action,
+			ZoneID:     e.ZoneID,
+			RecordType: e.RecordType,
+			Method:     e.Method,
+			Path:       e.Path,
+			Status:     e.Status,
+			RequestID:  e.RequestID,
+			CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	resp.TotalItems = len(resp.Items)
+
+	writeJSON(w, http.StatusOK, resp)
+}
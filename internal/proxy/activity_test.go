@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func activityRequest(path string, token *storage.Token) *http.Request {
+	r := newTestRequest(http.MethodGet, path, nil, nil)
+	if token != nil {
+		r = r.WithContext(auth.WithToken(r.Context(), token))
+	}
+	return r
+}
+
+func TestHandleListMyActivity_OnlyOwnEntries(t *testing.T) {
+	t.Parallel()
+	store := newMemoryStorage(t)
+	ctx := context.Background()
+	for _, e := range []*storage.AuditEntry{
+		{TokenID: 1, Action: "list_zones", Method: "GET", Path: "/dnszone", Status: 200},
+		{TokenID: 2, Action: "list_zones", Method: "GET", Path: "/dnszone", Status: 200},
+		{TokenID: 1, Action: "add_record", ZoneID: 5, RecordType: "TXT", Method: "POST", Path: "/dnszone/5/records", Status: 403},
+	} {
+		if _, err := store.AppendAuditEntry(ctx, e); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+
+	h := NewHandler(&mockBunnyClient{}, nil)
+	h.SetActivityStore(store)
+
+	w := httptest.NewRecorder()
+	h.HandleListMyActivity(w, activityRequest("/api/my/activity", &storage.Token{ID: 1, Name: "acme"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp ActivityResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.TotalItems != 2 || resp.Items[0].Action != "add_record" || resp.Items[0].Status != 403 || resp.Items[1].Action != "list_zones" {
+		t.Errorf("expected token 1 entries newest first, got %+v", resp.Items)
+	}
+	if _, err := time.Parse(time.RFC3339, resp.Items[0].CreatedAt); err != nil {
+		t.Errorf("CreatedAt should be RFC3339, got %q", resp.Items[0].CreatedAt)
+	}
+}
+
+func TestHandleListMyActivity_Limit(t *testing.T) {
+	t.Parallel()
+	var gotFilter storage.AuditFilter
+	h := NewHandler(&mockBunnyClient{}, nil)
+	h.SetActivityStore(&mockstore.MockStorage{
+		ListAuditEntriesFunc: func(_ context.Context, f storage.AuditFilter) ([]*storage.AuditEntry, error) {
+			gotFilter = f
+			return nil, nil
+		},
+	})
+	token := &storage.Token{ID: 9}
+
+	w := httptest.NewRecorder()
+	h.HandleListMyActivity(w, activityRequest("/api/my/activity", token))
+	if gotFilter.TokenID != 9 || gotFilter.Limit != defaultActivityLimit {
+		t.Errorf("unexpected default filter: %+v", gotFilter)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleListMyActivity(w, activityRequest("/api/my/activity?limit=5", token))
+	if gotFilter.Limit != 5 {
+		t.Errorf("expected limit 5, got %d", gotFilter.Limit)
+	}
+
+	for _, limit := range []string{"0", "501", "x"} {
+		w = httptest.NewRecorder()
+		h.HandleListMyActivity(w, activityRequest("/api/my/activity?limit="+limit, token))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s: expected 400, got %d", limit, w.Code)
+		}
+	}
+}
+
+func TestHandleListMyActivity_Errors(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(&mockBunnyClient{}, nil)
+	h.SetActivityStore(&mockstore.MockStorage{
+		ListAuditEntriesFunc: func(context.Context, storage.AuditFilter) ([]*storage.AuditEntry, error) {
+			return nil, errors.New("db down")
+		},
+	})
+
+	w := httptest.NewRecorder()
+	h.HandleListMyActivity(w, activityRequest("/api/my/activity", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleListMyActivity(w, activityRequest("/api/my/activity", &storage.Token{ID: 1}))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 on storage error, got %d", w.Code)
+	}
+}
+
+func TestRouter_ActivityRouteOnlyWhenEnabled(t *testing.T) {
+	t.Parallel()
+	passthrough := func(next http.Handler) http.Handler { return next }
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := NewHandler(&mockBunnyClient{}, nil)
+	w := httptest.NewRecorder()
+	NewRouter(h, passthrough, logger).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/my/activity", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 when disabled, got %d", w.Code)
+	}
+
+	h.SetActivityStore(&mockstore.MockStorage{})
+	w = httptest.NewRecorder()
+	NewRouter(h, passthrough, logger).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/my/activity", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected route to be registered, got %d", w.Code)
+	}
+}
@@ -0,0 +1,348 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// maxBatchOperations limits the operations in one batch request.
+const maxBatchOperations = 100
+
+// Batch operation actions.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation is one record operation in a batch.
+// create needs Record; update needs RecordId and Record; delete needs RecordId.
+type BatchOperation struct {
+	Action   string                  `json:"Action"`
+	RecordID int64                   `json:"RecordId,omitempty"`
+	Record   *bunny.AddRecordRequest `json:"Record,omitempty"`
+}
+
+// BatchRecordsRequest is the request body for POST /dnszone/{zoneID}/records/batch.
+type BatchRecordsRequest struct {
+	Operations []BatchOperation `json:"Operations"`
+}
+
+// BatchResult is the outcome of one applied operation.
+type BatchResult struct {
+	Action   string        `json:"Action"`
+	RecordID int64         `json:"RecordId"`
+	Record   *bunny.Record `json:"Record,omitempty"`
+}
+
+// BatchRecordsResponse is the response body for POST /dnszone/{zoneID}/records/batch.
+// On failure, Error and FailedOperation describe the operation that failed and
+// RolledBack reports whether every earlier operation was undone.
+type BatchRecordsResponse struct {
+	Results         []BatchResult `json:"Results,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	FailedOperation *int          `json:"FailedOperation,omitempty"`
+	RolledBack      bool          `json:"RolledBack,omitempty"`
+	RollbackErrors  []string      `json:"RollbackErrors,omitempty"`
+}
+
+// appliedOperation records what is needed to undo an applied operation.
+type appliedOperation struct {
+	op       BatchOperation
+	result   BatchResult
+	previous bunny.Record // the record before an update or delete
+}
+
+// HandleBatchRecords applies several record operations to one zone.
+// POST /dnszone/{zoneID}/records/batch
+//
+// Every operation is validated and permission-checked against its own action
+// (add_record, update_record, delete_record) and record types before any
+// change is made. Operations are then applied in order; if one fails, the
+// ones already applied are undone in reverse order. bunny.net has no
+// transactions, so a deleted record that is restored gets a new ID.
+func (h *Handler) HandleBatchRecords(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	var req BatchRecordsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if err := validateBatch(req.Operations); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for i, op := range req.Operations {
+		if op.Record == nil {
+			continue
+		}
+		h.applyTTLPolicy(zoneID, op.Record, op.Action == BatchUpdate)
+		if errs := h.checkRecord(op.Record, op.Action == BatchUpdate); len(errs) > 0 {
+			writeInvalidRecord(w, fmt.Sprintf("operation %d: invalid record", i), errs)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	keyInfo := auth.GetKeyInfo(ctx)
+	scoped := keyInfo != nil && !auth.IsAdminFromContext(ctx)
+
+	// Updates and deletes need the current records, for permission checks and rollback
+	existing := make(map[int64]bunny.Record)
+	if batchReferencesRecords(req.Operations) {
+		zone, err := h.upstream(ctx).GetZone(ctx, zoneID)
+		if err != nil {
+			handleBunnyError(w, err)
+			return
+		}
+		records := zone.Records
+		if scoped {
+			records = filterRecordsByPermission(records, keyInfo, zoneID)
+		}
+		for _, rec := range records {
+			existing[rec.ID] = rec
+		}
+	}
+
+	protections, err := h.protections(ctx, zoneID)
+	if err != nil {
+		h.logger.Error("failed to list protected records", "zone_id", zoneID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to check protected records")
+		return
+	}
+
+	for i, op := range req.Operations {
+		var before *bunny.Record
+		if op.Action != BatchCreate {
+			rec, ok := existing[op.RecordID]
+			if !ok {
+				writeError(w, http.StatusNotFound, fmt.Sprintf("operation %d: record not found", i))
+				return
+			}
+			before = &rec
+		}
+		if p := protectingOperation(protections, op, before); p != nil {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("operation %d: %s", i, protectedMessage(p)))
+			return
+		}
+		if scoped && auth.CheckPermission(keyInfo, batchPermissionRequest(zoneID, op)) != nil {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("operation %d: permission denied", i))
+			return
+		}
+	}
+
+	applied := make([]appliedOperation, 0, len(req.Operations))
+	for i, op := range req.Operations {
+		a, err := h.applyBatchOperation(ctx, zoneID, op, existing)
+		if err != nil {
+			h.failBatch(w, r, zoneID, i, err, applied)
+			return
+		}
+		applied = append(applied, a)
+	}
+
+	h.logger.Info("batch records", "zone_id", zoneID, "operations", len(applied), "token", tokenName(r))
+	h.zones.invalidate(zoneID)
+
+	resp := BatchRecordsResponse{Results: make([]BatchResult, 0, len(applied))}
+	for _, a := range applied {
+		resp.Results = append(resp.Results, a.result)
+		h.publishBatchEvent(r, zoneID, a)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateBatch checks the shape of the operations without calling upstream.
+func validateBatch(ops []BatchOperation) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("at least one operation is required")
+	}
+	if len(ops) > maxBatchOperations {
+		return fmt.Errorf("at most %d operations are allowed", maxBatchOperations)
+	}
+
+	seen := make(map[int64]bool)
+	for i, op := range ops {
+		switch op.Action {
+		case BatchCreate:
+			if op.Record == nil || op.RecordID != 0 {
+				return fmt.Errorf("operation %d: create needs Record and no RecordId", i)
+			}
+			continue
+		case BatchUpdate:
+			if op.Record == nil || op.RecordID <= 0 {
+				return fmt.Errorf("operation %d: update needs RecordId and Record", i)
+			}
+		case BatchDelete:
+			if op.Record != nil || op.RecordID <= 0 {
+				return fmt.Errorf("operation %d: delete needs RecordId and no Record", i)
+			}
+		default:
+			return fmt.Errorf("operation %d: unknown action %q", i, op.Action)
+		}
+		if seen[op.RecordID] {
+			return fmt.Errorf("operation %d: record %d is changed more than once", i, op.RecordID)
+		}
+		seen[op.RecordID] = true
+	}
+	return nil
+}
+
+// batchReferencesRecords reports whether any operation targets an existing record.
+func batchReferencesRecords(ops []BatchOperation) bool {
+	for _, op := range ops {
+		if op.Action != BatchCreate {
+			return true
+		}
+	}
+	return false
+}
+
+// batchPermissionRequest maps an operation to the request its single-record
+// endpoint would be checked as.
+func batchPermissionRequest(zoneID int64, op BatchOperation) *auth.Request {
+	switch op.Action {
+	case BatchCreate:
+		return &auth.Request{Action: auth.ActionAddRecord, ZoneID: zoneID, RecordType: auth.MapRecordTypeToString(op.Record.Type)}
+	case BatchUpdate:
+		return &auth.Request{Action: auth.ActionUpdateRecord, ZoneID: zoneID, RecordType: auth.MapRecordTypeToString(op.Record.Type)}
+	default:
+		return &auth.Request{Action: auth.ActionDeleteRecord, ZoneID: zoneID}
+	}
+}
+
+// applyBatchOperation applies one operation upstream.
+func (h *Handler) applyBatchOperation(ctx context.Context, zoneID int64, op BatchOperation, existing map[int64]bunny.Record) (appliedOperation, error) {
+	a := appliedOperation{op: op, result: BatchResult{Action: op.Action, RecordID: op.RecordID}}
+	switch op.Action {
+	case BatchCreate:
+		record, err := h.upstream(ctx).AddRecord(ctx, zoneID, op.Record)
+		if err != nil {
+			return a, err
+		}
+		a.result.Record = record
+		if record != nil {
+			a.result.RecordID = record.ID
+		}
+	case BatchUpdate:
+		a.previous = existing[op.RecordID]
+		record, err := h.upstream(ctx).UpdateRecord(ctx, zoneID, op.RecordID, op.Record)
+		if err != nil {
+			return a, err
+		}
+		a.result.Record = record
+	case BatchDelete:
+		a.previous = existing[op.RecordID]
+		if err := h.upstream(ctx).DeleteRecord(ctx, zoneID, op.RecordID); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// failBatch undoes the applied operations in reverse order and writes the failure.
+func (h *Handler) failBatch(w http.ResponseWriter, r *http.Request, zoneID int64, failed int, cause error, applied []appliedOperation) {
+	// Undo even if the client went away; a half-applied batch is worse
+	ctx := context.WithoutCancel(r.Context())
+
+	var rollbackErrors []string
+	for i := len(applied) - 1; i >= 0; i-- {
+		if err := h.undoBatchOperation(ctx, zoneID, applied[i]); err != nil {
+			h.logger.Error("batch rollback failed", "zone_id", zoneID, "operation", i, "error", err)
+			rollbackErrors = append(rollbackErrors, fmt.Sprintf("operation %d: %v", i, err))
+		}
+	}
+	if len(applied) > 0 {
+		h.zones.invalidate(zoneID)
+	}
+
+	status, message := bunnyErrorStatus(cause)
+	h.logger.Warn("batch records failed", "zone_id", zoneID, "operation", failed, "error", cause,
+		"applied", len(applied), "rollback_errors", len(rollbackErrors), "token", tokenName(r))
+
+	writeJSON(w, status, BatchRecordsResponse{
+		Error:           fmt.Sprintf("operation %d: %s", failed, message),
+		FailedOperation: &failed,
+		RolledBack:      len(rollbackErrors) == 0,
+		RollbackErrors:  rollbackErrors,
+	})
+}
+
+// undoBatchOperation reverts one applied operation.
+func (h *Handler) undoBatchOperation(ctx context.Context, zoneID int64, a appliedOperation) error {
+	switch a.op.Action {
+	case BatchCreate:
+		return h.upstream(ctx).DeleteRecord(ctx, zoneID, a.result.RecordID)
+	case BatchUpdate:
+		_, err := h.upstream(ctx).UpdateRecord(ctx, zoneID, a.op.RecordID, recordToRequest(a.previous))
+		return err
+	default:
+		_, err := h.upstream(ctx).AddRecord(ctx, zoneID, recordToRequest(a.previous))
+		return err
+	}
+}
+
+// recordToRequest converts a record into the request that recreates it.
+// bunny.net only reports the name of a linked pull zone or script, not its
+// ID, so those links are left as they are.
+func recordToRequest(rec bunny.Record) *bunny.AddRecordRequest {
+	return &bunny.AddRecordRequest{
+		Type:                  rec.Type,
+		Name:                  rec.Name,
+		Value:                 rec.Value,
+		TTL:                   rec.TTL,
+		Priority:              rec.Priority,
+		Weight:                rec.Weight,
+		Port:                  rec.Port,
+		Flags:                 rec.Flags,
+		Tag:                   rec.Tag,
+		Disabled:              rec.Disabled,
+		Comment:               rec.Comment,
+		Accelerated:           &rec.Accelerated,
+		MonitorType:           &rec.MonitorType,
+		GeolocationLatitude:   &rec.GeolocationLatitude,
+		GeolocationLongitude:  &rec.GeolocationLongitude,
+		LatencyZone:           &rec.LatencyZone,
+		SmartRoutingType:      &rec.SmartRoutingType,
+		EnviromentalVariables: rec.EnviromentalVariables,
+		AutoSslIssuance:       &rec.AutoSslIssuance,
+	}
+}
+
+// publishBatchEvent records the change and emits the same change event as
+// the single-record endpoint.
+func (h *Handler) publishBatchEvent(r *http.Request, zoneID int64, a appliedOperation) {
+	switch a.op.Action {
+	case BatchCreate:
+		h.recordChange(r.Context(), zoneID, a.result.RecordID, storage.RecordChangeCreate, nil, a.result.Record, h.authorOf(r))
+		h.publish(r.Context(), webhook.EventRecordCreated, map[string]any{
+			"zone_id": zoneID, "record": a.result.Record, "actor": tokenName(r),
+		})
+	case BatchUpdate:
+		h.recordChange(r.Context(), zoneID, a.op.RecordID, storage.RecordChangeUpdate, &a.previous,
+			updatedRecord(a.result.Record, a.op.Record), h.authorOf(r))
+		h.publish(r.Context(), webhook.EventRecordUpdated, map[string]any{
+			"zone_id": zoneID, "record_id": a.op.RecordID, "record": a.op.Record, "actor": tokenName(r),
+		})
+	case BatchDelete:
+		h.forgetRecordMeta(r.Context(), zoneID, a.op.RecordID)
+		h.recordChange(r.Context(), zoneID, a.op.RecordID, storage.RecordChangeDelete, &a.previous, nil, h.authorOf(r))
+		h.publish(r.Context(), webhook.EventRecordDeleted, map[string]any{
+			"zone_id": zoneID, "record_id": a.op.RecordID, "actor": tokenName(r),
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// newBatchRequest creates a batch request for zone 123, scoped to keyInfo if non-nil.
+func newBatchRequest(body string, keyInfo *auth.KeyInfo) *http.Request {
+	params := map[string]string{"zoneID": "123"}
+	if keyInfo == nil {
+		return newTestRequest(http.MethodPost, "/dnszone/123/records/batch", strings.NewReader(body), params)
+	}
+	r := newTestRequestWithKeyInfo("/dnszone/123/records/batch", params, keyInfo)
+	r.Method = http.MethodPost
+	r.Body = io.NopCloser(strings.NewReader(body))
+	return r
+}
+
+func batchZone() *bunny.Zone {
+	return &bunny.Zone{ID: 123, Records: []bunny.Record{
+		{ID: 1, Type: 3, Name: "_acme-challenge", Value: "old", TTL: 60},
+		{ID: 2, Type: 0, Name: "www", Value: "1.2.3.4", TTL: 300},
+	}}
+}
+
+func txtOnlyKey() *auth.KeyInfo {
+	return &auth.KeyInfo{KeyID: 1, KeyName: "acme", Permissions: []*storage.Permission{
+		{ZoneID: 123, AllowedActions: []string{"add_record", "update_record", "delete_record"}, RecordTypes: []string{"TXT"}},
+	}}
+}
+
+func TestValidateBatch(t *testing.T) {
+	t.Parallel()
+
+	rec := &bunny.AddRecordRequest{Type: 3, Name: "a"}
+	tooMany := make([]BatchOperation, maxBatchOperations+1)
+	for i := range tooMany {
+		tooMany[i] = BatchOperation{Action: BatchCreate, Record: rec}
+	}
+
+	tests := []struct {
+		name    string
+		ops     []BatchOperation
+		wantErr bool
+	}{
+		{"valid", []BatchOperation{{Action: BatchCreate, Record: rec}, {Action: BatchUpdate, RecordID: 1, Record: rec}, {Action: BatchDelete, RecordID: 2}}, false},
+		{"empty", nil, true},
+		{"too many", tooMany, true},
+		{"create with id", []BatchOperation{{Action: BatchCreate, RecordID: 1, Record: rec}}, true},
+		{"update without record", []BatchOperation{{Action: BatchUpdate, RecordID: 1}}, true},
+		{"delete without id", []BatchOperation{{Action: BatchDelete}}, true},
+		{"unknown action", []BatchOperation{{Action: "upsert", Record: rec}}, true},
+		{"duplicate record", []BatchOperation{{Action: BatchUpdate, RecordID: 1, Record: rec}, {Action: BatchDelete, RecordID: 1}}, true},
+	}
+	for _, tt := range tests {
+		if err := validateBatch(tt.ops); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateBatch() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHandleBatchRecords_Success(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) { return batchZone(), nil },
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			calls = append(calls, "add "+req.Value)
+			return &bunny.Record{ID: 10 + int64(len(calls)), Type: req.Type, Name: req.Name, Value: req.Value}, nil
+		},
+		updateRecordFunc: func(_ context.Context, _ int64, id int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			calls = append(calls, "update "+req.Value)
+			return &bunny.Record{ID: id, Type: req.Type, Value: req.Value}, nil
+		},
+		deleteRecordFunc: func(context.Context, int64, int64) error {
+			calls = append(calls, "delete")
+			return nil
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w := httptest.NewRecorder()
+
+	body := `{"Operations":[
+		{"Action":"create","Record":{"Type":3,"Name":"_acme-challenge","Value":"t1"}},
+		{"Action":"create","Record":{"Type":3,"Name":"_acme-challenge","Value":"t2"}},
+		{"Action":"update","RecordId":1,"Record":{"Type":3,"Name":"_acme-challenge","Value":"new"}},
+		{"Action":"delete","RecordId":2}
+	]}`
+	handler.HandleBatchRecords(w, newBatchRequest(body, nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if strings.Join(calls, ",") != "add t1,add t2,update new,delete" {
+		t.Errorf("unexpected upstream calls: %v", calls)
+	}
+
+	var resp BatchRecordsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Results) != 4 || resp.Results[0].RecordID != 11 || resp.Results[3].RecordID != 2 {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+}
+
+func TestHandleBatchRecords_PermissionPerOperation(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) { return batchZone(), nil },
+		addRecordFunc: func(context.Context, int64, *bunny.AddRecordRequest) (*bunny.Record, error) {
+			t.Error("no operation should be applied when one is denied")
+			return nil, nil
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Run("denied record type", func(t *testing.T) {
+		t.Parallel()
+		w := httptest.NewRecorder()
+		body := `{"Operations":[
+			{"Action":"create","Record":{"Type":3,"Name":"_acme-challenge","Value":"t1"}},
+			{"Action":"create","Record":{"Type":0,"Name":"www2","Value":"1.2.3.4"}}
+		]}`
+		handler.HandleBatchRecords(w, newBatchRequest(body, txtOnlyKey()))
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "operation 1") {
+			t.Errorf("expected 403 for operation 1, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("hidden record", func(t *testing.T) {
+		t.Parallel()
+		w := httptest.NewRecorder()
+		body := `{"Operations":[{"Action":"delete","RecordId":2}]}`
+		handler.HandleBatchRecords(w, newBatchRequest(body, txtOnlyKey()))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for a record of a denied type, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestHandleBatchRecords_RollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	var undo []string
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) { return batchZone(), nil },
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			if req.Value == "fail" {
+				return nil, &bunny.APIError{StatusCode: http.StatusBadRequest, Message: "invalid value"}
+			}
+			return &bunny.Record{ID: 50, Type: req.Type, Value: req.Value}, nil
+		},
+		updateRecordFunc: func(_ context.Context, _ int64, id int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			if req.Value == "old" {
+				undo = append(undo, "restore 1")
+			}
+			return &bunny.Record{ID: id, Value: req.Value}, nil
+		},
+		deleteRecordFunc: func(_ context.Context, _ int64, id int64) error {
+			if id == 50 {
+				undo = append(undo, "delete 50")
+			}
+			return nil
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w := httptest.NewRecorder()
+
+	body := `{"Operations":[
+		{"Action":"create","Record":{"Type":3,"Name":"_acme-challenge","Value":"t1"}},
+		{"Action":"update","RecordId":1,"Record":{"Type":3,"Name":"_acme-challenge","Value":"new"}},
+		{"Action":"create","Record":{"Type":3,"Name":"_acme-challenge","Value":"fail"}}
+	]}`
+	handler.HandleBatchRecords(w, newBatchRequest(body, nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	var resp BatchRecordsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.FailedOperation == nil || *resp.FailedOperation != 2 || !resp.RolledBack {
+		t.Errorf("unexpected failure response: %s", w.Body.String())
+	}
+	if strings.Join(undo, ",") != "restore 1,delete 50" {
+		t.Errorf("expected rollback in reverse order, got %v", undo)
+	}
+}
+
+func TestHandleBatchRecords_RollbackErrorsReported(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) { return batchZone(), nil },
+		deleteRecordFunc: func(_ context.Context, _ int64, id int64) error {
+			if id == 2 {
+				return bunny.ErrNotFound
+			}
+			return nil
+		},
+		addRecordFunc: func(context.Context, int64, *bunny.AddRecordRequest) (*bunny.Record, error) {
+			return nil, errors.New("connection reset")
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w := httptest.NewRecorder()
+
+	body := `{"Operations":[{"Action":"delete","RecordId":1},{"Action":"delete","RecordId":2}]}`
+	handler.HandleBatchRecords(w, newBatchRequest(body, nil))
+
+	var resp BatchRecordsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusNotFound || resp.RolledBack || len(resp.RollbackErrors) != 1 {
+		t.Errorf("expected failed rollback to be reported, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleBatchRecords_InvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(&mockBunnyClient{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for _, body := range []string{`{invalid`, `{"Operations":[]}`, `{"Operations":[{"Action":"delete"}]}`} {
+		w := httptest.NewRecorder()
+		handler.HandleBatchRecords(w, newBatchRequest(body, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
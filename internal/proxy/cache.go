@@ -0,0 +1,347 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+// Response cache kinds, used in metrics labels.
+const (
+	cacheKindZone     = "zone"
+	cacheKindZoneList = "zone_list"
+)
+
+// SetResponseCache caches upstream zone reads (ListZones and GetZone) for ttl,
+// keeping at most maxEntries responses; maxEntries <= 0 means no cap.
+// Every mutation made through the proxy drops the affected zone and all
+// cached zone lists. Changes made outside the proxy show up once entries
+// expire. Each account has its own cache, so SetAccounts must be called
+// first. A non-positive ttl leaves caching disabled.
+func (h *Handler) SetResponseCache(ttl time.Duration, maxEntries int) {
+	if ttl <= 0 {
+		return
+	}
+	h.client = &cachingClient{BunnyClient: h.client, cache: newResponseCache(ttl, maxEntries)}
+	for name, client := range h.accounts {
+		h.accounts[name] = &cachingClient{BunnyClient: client, cache: newResponseCache(ttl, maxEntries)}
+	}
+}
+
+// WarmResponseCache prefetches the unfiltered zone list and up to maxZones
+// zones into the response cache, fetching at most parallelism zones at once.
+// hotZones are fetched first and the rest are taken from the zone list in
+// order. Failures are logged and skipped. It returns the number of zones
+// cached and does nothing when the cache is disabled.
+func (h *Handler) WarmResponseCache(ctx context.Context, hotZones []int64, maxZones, parallelism int) int {
+	c, ok := h.client.(*cachingClient)
+	if !ok {
+		return 0
+	}
+	start := time.Now()
+
+	// Same key as GET /dnszone without query parameters
+	list, err := c.ListZones(ctx, &bunny.ListZonesOptions{})
+	if err != nil {
+		h.logger.Warn("cache warm-up: failed to list zones", "error", err)
+		list = &bunny.ListZonesResponse{}
+	}
+
+	zoneIDs := make([]int64, 0, maxZones)
+	seen := make(map[int64]bool)
+	add := func(id int64) {
+		if len(zoneIDs) < maxZones && !seen[id] {
+			seen[id] = true
+			zoneIDs = append(zoneIDs, id)
+		}
+	}
+	for _, id := range hotZones {
+		add(id)
+	}
+	for _, zone := range list.Items {
+		add(zone.ID)
+	}
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	warmed := 0
+	for _, id := range zoneIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if _, err := c.GetZone(ctx, id); err != nil {
+				h.logger.Warn("cache warm-up: failed to fetch zone", "zone_id", id, "error", err)
+				return
+			}
+			mu.Lock()
+			warmed++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	h.logger.Info("cache warm-up complete", "zones", warmed, "failed", len(zoneIDs)-warmed,
+		"duration", time.Since(start))
+	return warmed
+}
+
+// responseCache holds upstream zone and zone list responses.
+type responseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	// generation is bumped on every invalidation, so a read that started
+	// before a mutation does not store its now stale result.
+	generation uint64
+	zones      map[int64]cacheEntry[bunny.Zone]
+	lists      map[bunny.ListZonesOptions]cacheEntry[bunny.ListZonesResponse]
+}
+
+type cacheEntry[T any] struct {
+	value    T
+	storedAt time.Time
+}
+
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	return &responseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		zones:      make(map[int64]cacheEntry[bunny.Zone]),
+		lists:      make(map[bunny.ListZonesOptions]cacheEntry[bunny.ListZonesResponse]),
+	}
+}
+
+// getZone returns a copy of a cached zone if it has not expired.
+func (c *responseCache) getZone(id int64) (*bunny.Zone, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.zones[id]
+	if ok && c.now().Sub(e.storedAt) >= c.ttl {
+		delete(c.zones, id)
+		ok = false
+	}
+	metrics.RecordResponseCacheLookup(cacheKindZone, ok)
+	if !ok {
+		return nil, false
+	}
+	zone := e.value
+	zone.Records = slices.Clone(zone.Records)
+	return &zone, true
+}
+
+// getList returns a copy of a cached zone list if it has not expired.
+func (c *responseCache) getList(key bunny.ListZonesOptions) (*bunny.ListZonesResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.lists[key]
+	if ok && c.now().Sub(e.storedAt) >= c.ttl {
+		delete(c.lists, key)
+		ok = false
+	}
+	metrics.RecordResponseCacheLookup(cacheKindZoneList, ok)
+	if !ok {
+		return nil, false
+	}
+	list := e.value
+	list.Items = slices.Clone(list.Items)
+	return &list, true
+}
+
+// currentGeneration returns the invalidation generation to pass to a later put.
+func (c *responseCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// putZone stores a copy of zone unless the cache was invalidated since gen.
+func (c *responseCache) putZone(gen uint64, zone *bunny.Zone) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.generation {
+		return
+	}
+	if _, ok := c.zones[zone.ID]; !ok {
+		c.makeRoom()
+	}
+	value := *zone
+	value.Records = slices.Clone(zone.Records)
+	c.zones[zone.ID] = cacheEntry[bunny.Zone]{value: value, storedAt: c.now()}
+}
+
+// putList stores a copy of list unless the cache was invalidated since gen.
+func (c *responseCache) putList(gen uint64, key bunny.ListZonesOptions, list *bunny.ListZonesResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.generation {
+		return
+	}
+	if _, ok := c.lists[key]; !ok {
+		c.makeRoom()
+	}
+	value := *list
+	value.Items = slices.Clone(list.Items)
+	c.lists[key] = cacheEntry[bunny.ListZonesResponse]{value: value, storedAt: c.now()}
+}
+
+// makeRoom evicts the oldest entry when the cache is full. c.mu must be held.
+func (c *responseCache) makeRoom() {
+	if c.maxEntries <= 0 || len(c.zones)+len(c.lists) < c.maxEntries {
+		return
+	}
+
+	var oldest time.Time
+	oldestZone, oldestList, found := int64(0), bunny.ListZonesOptions{}, ""
+	for id, e := range c.zones {
+		if found == "" || e.storedAt.Before(oldest) {
+			oldest, oldestZone, found = e.storedAt, id, cacheKindZone
+		}
+	}
+	for key, e := range c.lists {
+		if found == "" || e.storedAt.Before(oldest) {
+			oldest, oldestList, found = e.storedAt, key, cacheKindZoneList
+		}
+	}
+
+	switch found {
+	case cacheKindZone:
+		delete(c.zones, oldestZone)
+	case cacheKindZoneList:
+		delete(c.lists, oldestList)
+	}
+}
+
+// invalidateZone drops a zone and every zone list, which embed zones.
+func (c *responseCache) invalidateZone(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.zones, id)
+	clear(c.lists)
+}
+
+// invalidateLists drops every zone list.
+func (c *responseCache) invalidateLists() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.lists)
+}
+
+// cachingClient serves zone reads from a responseCache and invalidates it on
+// every mutation, whether or not the upstream call succeeded.
+type cachingClient struct {
+	BunnyClient
+	cache *responseCache
+}
+
+// ListZones returns a cached zone list or fetches it.
+func (c *cachingClient) ListZones(ctx context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+	var key bunny.ListZonesOptions
+	if opts != nil {
+		key = *opts
+	}
+	if list, ok := c.cache.getList(key); ok {
+		return list, nil
+	}
+
+	gen := c.cache.currentGeneration()
+	list, err := c.BunnyClient.ListZones(ctx, opts)
+	if err == nil && list != nil {
+		c.cache.putList(gen, key, list)
+	}
+	return list, err
+}
+
+// GetZone returns a cached zone or fetches it.
+func (c *cachingClient) GetZone(ctx context.Context, id int64) (*bunny.Zone, error) {
+	if zone, ok := c.cache.getZone(id); ok {
+		return zone, nil
+	}
+
+	gen := c.cache.currentGeneration()
+	zone, err := c.BunnyClient.GetZone(ctx, id)
+	if err == nil && zone != nil {
+		c.cache.putZone(gen, zone)
+	}
+	return zone, err
+}
+
+// CreateZone creates a zone and drops cached zone lists.
+func (c *cachingClient) CreateZone(ctx context.Context, domain string) (*bunny.Zone, error) {
+	defer c.cache.invalidateLists()
+	return c.BunnyClient.CreateZone(ctx, domain)
+}
+
+// DeleteZone deletes a zone and drops it from the cache.
+func (c *cachingClient) DeleteZone(ctx context.Context, id int64) error {
+	defer c.cache.invalidateZone(id)
+	return c.BunnyClient.DeleteZone(ctx, id)
+}
+
+// UpdateZone updates a zone and drops it from the cache.
+func (c *cachingClient) UpdateZone(ctx context.Context, id int64, req *bunny.UpdateZoneRequest) (*bunny.Zone, error) {
+	defer c.cache.invalidateZone(id)
+	return c.BunnyClient.UpdateZone(ctx, id, req)
+}
+
+// ImportRecords imports records and drops the zone from the cache.
+func (c *cachingClient) ImportRecords(ctx context.Context, zoneID int64, body io.Reader, contentType string) (*bunny.ImportRecordsResponse, error) {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.ImportRecords(ctx, zoneID, body, contentType)
+}
+
+// EnableDNSSEC enables DNSSEC and drops the zone from the cache.
+func (c *cachingClient) EnableDNSSEC(ctx context.Context, zoneID int64) (*bunny.DNSSECResponse, error) {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.EnableDNSSEC(ctx, zoneID)
+}
+
+// DisableDNSSEC disables DNSSEC and drops the zone from the cache.
+func (c *cachingClient) DisableDNSSEC(ctx context.Context, zoneID int64) (*bunny.DNSSECResponse, error) {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.DisableDNSSEC(ctx, zoneID)
+}
+
+// IssueCertificate issues a certificate and drops the zone from the cache.
+func (c *cachingClient) IssueCertificate(ctx context.Context, zoneID int64, domain string) error {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.IssueCertificate(ctx, zoneID, domain)
+}
+
+// AddRecord adds a record and drops the zone from the cache.
+func (c *cachingClient) AddRecord(ctx context.Context, zoneID int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.AddRecord(ctx, zoneID, req)
+}
+
+// UpdateRecord updates a record and drops the zone from the cache.
+func (c *cachingClient) UpdateRecord(ctx context.Context, zoneID, recordID int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.UpdateRecord(ctx, zoneID, recordID, req)
+}
+
+// DeleteRecord deletes a record and drops the zone from the cache.
+func (c *cachingClient) DeleteRecord(ctx context.Context, zoneID, recordID int64) error {
+	defer c.cache.invalidateZone(zoneID)
+	return c.BunnyClient.DeleteRecord(ctx, zoneID, recordID)
+}
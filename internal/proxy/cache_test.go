@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+type fakeCacheClock struct{ t time.Time }
+
+func (c *fakeCacheClock) now() time.Time { return c.t }
+
+// newCachingTestClient returns a caching client around a mock that counts upstream reads.
+func newCachingTestClient(maxEntries int) (*cachingClient, *fakeCacheClock, map[string]int) {
+	calls := make(map[string]int)
+	mock := &mockBunnyClient{
+		listZonesFunc: func(_ context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+			calls["list"]++
+			return &bunny.ListZonesResponse{Items: []bunny.Zone{{ID: 1, Domain: "example.com"}}, TotalItems: 1}, nil
+		},
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			calls["get"]++
+			if id == 404 {
+				return nil, bunny.ErrNotFound
+			}
+			return &bunny.Zone{ID: id, Records: []bunny.Record{{ID: 10, Type: 3}, {ID: 11, Type: 0}}}, nil
+		},
+	}
+	clock := &fakeCacheClock{t: time.Unix(1_700_000_000, 0)}
+	cache := newResponseCache(time.Minute, maxEntries)
+	cache.now = clock.now
+	return &cachingClient{BunnyClient: mock, cache: cache}, clock, calls
+}
+
+func TestCachingClient_ReadsAreCachedUntilExpiry(t *testing.T) {
+	t.Parallel()
+	c, clock, calls := newCachingTestClient(0)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := c.GetZone(ctx, 1); err != nil {
+			t.Fatalf("GetZone failed: %v", err)
+		}
+		if _, err := c.ListZones(ctx, &bunny.ListZonesOptions{Page: 1}); err != nil {
+			t.Fatalf("ListZones failed: %v", err)
+		}
+	}
+	if calls["get"] != 1 || calls["list"] != 1 {
+		t.Errorf("expected one upstream call each, got %v", calls)
+	}
+
+	// Lists are keyed by query
+	if _, err := c.ListZones(ctx, &bunny.ListZonesOptions{Page: 2}); err != nil {
+		t.Fatalf("ListZones failed: %v", err)
+	}
+	if calls["list"] != 2 {
+		t.Errorf("expected a different query to miss, got %d list calls", calls["list"])
+	}
+
+	clock.t = clock.t.Add(time.Minute)
+	if _, err := c.GetZone(ctx, 1); err != nil {
+		t.Fatalf("GetZone failed: %v", err)
+	}
+	if calls["get"] != 2 {
+		t.Errorf("expected expired entry to be refetched, got %d get calls", calls["get"])
+	}
+}
+
+func TestCachingClient_ErrorsAreNotCached(t *testing.T) {
+	t.Parallel()
+	c, _, calls := newCachingTestClient(0)
+
+	for range 2 {
+		if _, err := c.GetZone(context.Background(), 404); !errors.Is(err, bunny.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if calls["get"] != 2 {
+		t.Errorf("expected errors to be refetched, got %d get calls", calls["get"])
+	}
+}
+
+func TestCachingClient_ReturnsCopies(t *testing.T) {
+	t.Parallel()
+	c, _, _ := newCachingTestClient(0)
+	ctx := context.Background()
+
+	zone, _ := c.GetZone(ctx, 1)
+	zone.Records[0].Value = "mutated"
+	zone.Records = zone.Records[:1]
+	list, _ := c.ListZones(ctx, nil)
+	list.Items = nil
+
+	again, _ := c.GetZone(ctx, 1)
+	if len(again.Records) != 2 || again.Records[0].Value != "" {
+		t.Errorf("expected cached zone to be unaffected by callers, got %+v", again.Records)
+	}
+	if list, _ := c.ListZones(ctx, nil); len(list.Items) != 1 {
+		t.Errorf("expected cached list to be unaffected by callers, got %+v", list.Items)
+	}
+}
+
+func TestCachingClient_MutationsInvalidate(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	mutations := map[string]func(c *cachingClient){
+		"add record":        func(c *cachingClient) { _, _ = c.AddRecord(ctx, 1, &bunny.AddRecordRequest{}) },
+		"update record":     func(c *cachingClient) { _, _ = c.UpdateRecord(ctx, 1, 10, &bunny.AddRecordRequest{}) },
+		"delete record":     func(c *cachingClient) { _ = c.DeleteRecord(ctx, 1, 10) },
+		"delete zone":       func(c *cachingClient) { _ = c.DeleteZone(ctx, 1) },
+		"update zone":       func(c *cachingClient) { _, _ = c.UpdateZone(ctx, 1, &bunny.UpdateZoneRequest{}) },
+		"import":            func(c *cachingClient) { _, _ = c.ImportRecords(ctx, 1, nil, "") },
+		"enable dnssec":     func(c *cachingClient) { _, _ = c.EnableDNSSEC(ctx, 1) },
+		"disable dnssec":    func(c *cachingClient) { _, _ = c.DisableDNSSEC(ctx, 1) },
+		"issue certificate": func(c *cachingClient) { _ = c.IssueCertificate(ctx, 1, "example.com") },
+	}
+	for name, mutate := range mutations {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c, _, calls := newCachingTestClient(0)
+			_, _ = c.GetZone(ctx, 1)
+			_, _ = c.GetZone(ctx, 2)
+			_, _ = c.ListZones(ctx, nil)
+
+			mutate(c)
+
+			_, _ = c.GetZone(ctx, 1)
+			_, _ = c.GetZone(ctx, 2)
+			_, _ = c.ListZones(ctx, nil)
+			if calls["get"] != 3 || calls["list"] != 2 {
+				t.Errorf("expected zone 1 and lists to be refetched and zone 2 kept, got %v", calls)
+			}
+		})
+	}
+
+	t.Run("create zone", func(t *testing.T) {
+		t.Parallel()
+		c, _, calls := newCachingTestClient(0)
+		_, _ = c.GetZone(ctx, 1)
+		_, _ = c.ListZones(ctx, nil)
+
+		_, _ = c.CreateZone(ctx, "new.example.com")
+
+		_, _ = c.GetZone(ctx, 1)
+		_, _ = c.ListZones(ctx, nil)
+		if calls["get"] != 1 || calls["list"] != 2 {
+			t.Errorf("expected only lists to be refetched, got %v", calls)
+		}
+	})
+}
+
+func TestCachingClient_StaleReadIsNotStored(t *testing.T) {
+	t.Parallel()
+	c, _, calls := newCachingTestClient(0)
+	ctx := context.Background()
+
+	// A mutation lands while the read is in flight
+	gen := c.cache.currentGeneration()
+	zone, _ := c.BunnyClient.GetZone(ctx, 1)
+	_ = c.DeleteRecord(ctx, 1, 10)
+	c.cache.putZone(gen, zone)
+
+	_, _ = c.GetZone(ctx, 1)
+	if calls["get"] != 2 {
+		t.Errorf("expected read started before the mutation to be discarded, got %d get calls", calls["get"])
+	}
+}
+
+func TestResponseCache_MaxEntries(t *testing.T) {
+	t.Parallel()
+	c, clock, calls := newCachingTestClient(2)
+	ctx := context.Background()
+
+	_, _ = c.GetZone(ctx, 1)
+	clock.t = clock.t.Add(time.Second)
+	_, _ = c.GetZone(ctx, 2)
+	clock.t = clock.t.Add(time.Second)
+	_, _ = c.ListZones(ctx, nil) // evicts zone 1
+
+	if n := len(c.cache.zones) + len(c.cache.lists); n != 2 {
+		t.Fatalf("expected cache to hold 2 entries, got %d", n)
+	}
+	_, _ = c.GetZone(ctx, 2)
+	_, _ = c.GetZone(ctx, 1)
+	if calls["get"] != 3 {
+		t.Errorf("expected only the oldest entry to be evicted, got %d get calls", calls["get"])
+	}
+}
+
+func TestSetResponseCache(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := &mockBunnyClient{getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+		calls++
+		return &bunny.Zone{ID: id}, nil
+	}}
+
+	disabled := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	disabled.SetResponseCache(0, 100)
+	if _, ok := disabled.client.(*cachingClient); ok {
+		t.Error("expected a zero TTL to leave caching disabled")
+	}
+
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler.SetResponseCache(time.Minute, 100)
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.HandleGetZone(w, newTestRequest(http.MethodGet, "/dnszone/123", nil, map[string]string{"zoneID": "123"}))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the second GET to be served from cache, got %d upstream calls", calls)
+	}
+}
+
+func TestWarmResponseCache(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	fetched := make(map[int64]int)
+	client := &mockBunnyClient{
+		listZonesFunc: func(context.Context, *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+			return &bunny.ListZonesResponse{Items: []bunny.Zone{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}, TotalItems: 4}, nil
+		},
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			fetched[id]++
+			if id == 2 {
+				return nil, errors.New("upstream unavailable")
+			}
+			return &bunny.Zone{ID: id}, nil
+		},
+	}
+
+	disabled := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if n := disabled.WarmResponseCache(context.Background(), nil, 10, 2); n != 0 || len(fetched) != 0 {
+		t.Fatalf("expected no warm-up without a cache, got %d zones", n)
+	}
+
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler.SetResponseCache(time.Minute, 100)
+
+	// Hot zone 9 comes first, then the list fills up to the limit
+	n := handler.WarmResponseCache(context.Background(), []int64{9, 1}, 3, 2)
+	if n != 2 {
+		t.Errorf("expected 2 zones warmed, got %d", n)
+	}
+	if len(fetched) != 3 || fetched[9] != 1 || fetched[1] != 1 || fetched[2] != 1 {
+		t.Errorf("unexpected upstream fetches: %v", fetched)
+	}
+
+	cache := handler.client.(*cachingClient).cache
+	if _, ok := cache.lists[bunny.ListZonesOptions{}]; !ok {
+		t.Error("expected the zone list to be cached")
+	}
+	if _, ok := cache.zones[9]; !ok {
+		t.Error("expected hot zone 9 to be cached")
+	}
+	if _, ok := cache.zones[2]; ok {
+		t.Error("expected failed zone 2 not to be cached")
+	}
+}
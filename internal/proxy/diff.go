@@ -0,0 +1,287 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// diffTypes are the record types a diff compares: those a zone file can
+// express. Live records of other types, such as pull zone or script records,
+// are left out.
+var diffTypes = map[int]bool{
+	recordTypeA: true, recordTypeAAAA: true, recordTypeCNAME: true, recordTypeTXT: true, recordTypeMX: true,
+	recordTypeSRV: true, recordTypeCAA: true, recordTypePTR: true, recordTypeNS: true,
+}
+
+// DiffRecordsRequest is the JSON body of POST /dnszone/{zoneID}/diff. It has
+// the shape of a format=json export, so an export can be edited and diffed.
+type DiffRecordsRequest struct {
+	Records []bunny.AddRecordRequest `json:"Records"`
+}
+
+// RecordDiff is one change needed to make a zone match a submitted file. Its
+// Action, RecordId and Record can be sent to POST /dnszone/{zoneID}/records/batch
+// as they are.
+type RecordDiff struct {
+	BatchOperation
+	Line   int           `json:"Line,omitempty"`   // the file line, or position in Records, of a create or update
+	Before *bunny.Record `json:"Before,omitempty"` // the live record an update or delete changes
+}
+
+// ZoneDiffResponse is the response body of POST /dnszone/{zoneID}/diff.
+// Operations lists deletes, then updates, then creates. If the file has
+// invalid or denied lines, Error and Lines describe them instead.
+type ZoneDiffResponse struct {
+	Operations []RecordDiff       `json:"Operations"`
+	Creates    int                `json:"Creates"`
+	Updates    int                `json:"Updates"`
+	Deletes    int                `json:"Deletes"`
+	Unchanged  int                `json:"Unchanged"`
+	Error      string             `json:"error,omitempty"`
+	Lines      []ImportLineResult `json:"Lines,omitempty"`
+}
+
+// HandleDiffRecords compares a zone file with a zone's live records and
+// returns the creates, updates and deletes that would make the zone match
+// it, without applying anything.
+// POST /dnszone/{zoneID}/diff
+//
+// Content-Type application/json is read as a DiffRecordsRequest and anything
+// else as a BIND zone file. Records are checked as an import checks them,
+// after the zone's TTL policy. Tokens restricted to some record types may
+// only submit, and are only compared on, records of those types.
+func (h *Handler) HandleDiffRecords(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	resp, ok := h.diffZone(w, r, zoneID)
+	if !ok {
+		return
+	}
+
+	h.logger.Info("diff records", "zone_id", zoneID, "creates", resp.Creates, "updates", resp.Updates,
+		"deletes", resp.Deletes, "unchanged", resp.Unchanged)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// diffZone compares the zone file in the body of r with the zone's live
+// records. It writes an error response and returns false if the file cannot
+// be read or has invalid or denied lines, or the zone cannot be fetched.
+func (h *Handler) diffZone(w http.ResponseWriter, r *http.Request, zoneID int64) (*ZoneDiffResponse, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(w, err)
+		return nil, false
+	}
+
+	// The diff is against live state, not a cached copy
+	ctx := r.Context()
+	zone, err := uncached(h.upstream(ctx)).GetZone(ctx, zoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return nil, false
+	}
+
+	var entries []importEntry
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var req DiffRecordsRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return nil, false
+		}
+		entries = jsonDiffEntries(req.Records)
+	} else {
+		entries = parseBINDImport(string(body), zone.Domain)
+	}
+
+	keyInfo := auth.GetKeyInfo(ctx)
+	if auth.IsAdminFromContext(ctx) {
+		keyInfo = nil
+	}
+	var (
+		resp    ZoneDiffResponse
+		desired []importEntry
+		denied  bool
+	)
+	for _, e := range entries {
+		if e.record == nil {
+			if e.status == ImportInvalid {
+				resp.Lines = append(resp.Lines, ImportLineResult{Line: e.line, Status: e.status, Error: e.message})
+			}
+			continue
+		}
+		typeName := auth.MapRecordTypeToString(e.record.Type)
+		h.applyTTLPolicy(zoneID, e.record, false)
+		if errs := h.checkRecord(e.record, false); len(errs) > 0 {
+			resp.Lines = append(resp.Lines, ImportLineResult{Line: e.line, Status: ImportInvalid, Type: typeName,
+				Name: e.record.Name, Error: errs[0].Field + ": " + errs[0].Message})
+		} else if keyInfo != nil && !auth.IsRecordTypePermitted(keyInfo, zoneID, typeName) {
+			resp.Lines = append(resp.Lines, ImportLineResult{Line: e.line, Status: ImportDenied, Type: typeName,
+				Name: e.record.Name, Error: "permission denied"})
+			denied = true
+		} else {
+			desired = append(desired, e)
+		}
+	}
+	if len(resp.Lines) > 0 {
+		status := http.StatusBadRequest
+		resp.Error = fmt.Sprintf("%d invalid lines", len(resp.Lines))
+		if denied {
+			status = http.StatusForbidden
+			resp.Error = fmt.Sprintf("%d invalid or denied lines", len(resp.Lines))
+		}
+		resp.Operations = []RecordDiff{}
+		writeJSON(w, status, resp)
+		return nil, false
+	}
+
+	live := make([]bunny.Record, 0, len(zone.Records))
+	for _, rec := range filterRecordsByPermission(zone.Records, keyInfo, zoneID) {
+		// bunny.net manages the apex NS records
+		if diffTypes[rec.Type] && !(rec.Type == recordTypeNS && normalizeRecordName(rec.Name) == "@") {
+			live = append(live, rec)
+		}
+	}
+	resp.Operations, resp.Unchanged = diffRecords(live, desired)
+	for _, op := range resp.Operations {
+		switch op.Action {
+		case BatchCreate:
+			resp.Creates++
+		case BatchUpdate:
+			resp.Updates++
+		case BatchDelete:
+			resp.Deletes++
+		}
+	}
+	return &resp, true
+}
+
+// jsonDiffEntries converts the records of a DiffRecordsRequest to entries
+// numbered by their position. Only the fields a zone file sets are kept, and
+// the apex may be named "@" or "".
+func jsonDiffEntries(records []bunny.AddRecordRequest) []importEntry {
+	entries := make([]importEntry, 0, len(records))
+	for i, r := range records {
+		if !diffTypes[r.Type] {
+			entries = append(entries, invalidEntry(i+1, "record type %d is not supported", r.Type))
+			continue
+		}
+		rec := &bunny.AddRecordRequest{
+			Type: r.Type, Name: strings.TrimSuffix(r.Name, "."), Value: r.Value, TTL: r.TTL,
+			Priority: r.Priority, Weight: r.Weight, Port: r.Port, Flags: r.Flags, Tag: r.Tag,
+		}
+		if rec.Name == "@" || rec.Name == "" {
+			rec.Name = apexName(rec.Type)
+		}
+		entries = append(entries, importEntry{line: i + 1, record: rec})
+	}
+	return entries
+}
+
+// diffRecords returns the operations that turn live into desired, and how
+// many records already match. Records are paired by type and name: first
+// those with the same value, which are unchanged or updated, then the rest in
+// order as updates. Names are compared as normalizeRecordName writes them, so
+// "@" and "" both pair with the apex. Unpaired desired records are created and
+// unpaired live records deleted.
+func diffRecords(live []bunny.Record, desired []importEntry) ([]RecordDiff, int) {
+	key := func(recordType int, name string) string {
+		return strconv.Itoa(recordType) + " " + normalizeRecordName(name)
+	}
+	byKey := make(map[string][]int)
+	for i, rec := range live {
+		k := key(rec.Type, rec.Name)
+		byKey[k] = append(byKey[k], i)
+	}
+	paired := make([]bool, len(live))
+	pair := func(k string, match func(bunny.Record) bool) *bunny.Record {
+		for _, i := range byKey[k] {
+			if !paired[i] && match(live[i]) {
+				paired[i] = true
+				return &live[i]
+			}
+		}
+		return nil
+	}
+
+	var updates, creates []RecordDiff
+	unchanged := 0
+	var unmatched []importEntry
+	for _, e := range desired {
+		rec := e.record
+		before := pair(key(rec.Type, rec.Name), func(l bunny.Record) bool { return sameRecordValue(rec.Type, l.Value, rec.Value) })
+		switch {
+		case before == nil:
+			unmatched = append(unmatched, e)
+		case sameRecordFields(*before, rec):
+			unchanged++
+		default:
+			updates = append(updates, updateDiff(e, before))
+		}
+	}
+	for _, e := range unmatched {
+		if before := pair(key(e.record.Type, e.record.Name), func(bunny.Record) bool { return true }); before != nil {
+			updates = append(updates, updateDiff(e, before))
+			continue
+		}
+		creates = append(creates, RecordDiff{BatchOperation: BatchOperation{Action: BatchCreate, Record: e.record}, Line: e.line})
+	}
+
+	ops := make([]RecordDiff, 0, len(live)+len(creates))
+	for i := range live {
+		if !paired[i] {
+			ops = append(ops, RecordDiff{BatchOperation: BatchOperation{Action: BatchDelete, RecordID: live[i].ID}, Before: &live[i]})
+		}
+	}
+	ops = append(ops, updates...)
+	return append(ops, creates...), unchanged
+}
+
+// updateDiff returns the update of before to a desired record. A zone file
+// cannot disable or comment records, so the update keeps those of before.
+func updateDiff(e importEntry, before *bunny.Record) RecordDiff {
+	e.record.Disabled, e.record.Comment = before.Disabled, before.Comment
+	return RecordDiff{
+		BatchOperation: BatchOperation{Action: BatchUpdate, RecordID: before.ID, Record: e.record},
+		Line:           e.line,
+		Before:         before,
+	}
+}
+
+// sameRecordValue reports whether two values of a record type are equal:
+// addresses by value, TXT and CAA values exactly, and names ignoring case and
+// a trailing dot.
+func sameRecordValue(recordType int, a, b string) bool {
+	switch recordType {
+	case recordTypeA, recordTypeAAAA:
+		x, errX := netip.ParseAddr(a)
+		y, errY := netip.ParseAddr(b)
+		if errX == nil && errY == nil {
+			return x == y
+		}
+		return a == b
+	case recordTypeTXT, recordTypeCAA:
+		return a == b
+	}
+	return normalizeRecordValue(a) == normalizeRecordValue(b)
+}
+
+// sameRecordFields reports whether a live record has the fields a zone file
+// sets besides its value.
+func sameRecordFields(live bunny.Record, rec *bunny.AddRecordRequest) bool {
+	return live.TTL == rec.TTL && live.Priority == rec.Priority && live.Weight == rec.Weight &&
+		live.Port == rec.Port && live.Flags == rec.Flags && strings.EqualFold(live.Tag, rec.Tag)
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestHandleDiffRecords(t *testing.T) {
+	t.Parallel()
+	client := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: id, Domain: "example.com", Records: []bunny.Record{
+				{ID: 1, Type: recordTypeA, Name: "", Value: "192.0.2.1", TTL: 300},
+				{ID: 2, Type: recordTypeA, Name: "www", Value: "192.0.2.2", TTL: 300},
+				{ID: 3, Type: recordTypeTXT, Name: "", Value: "v=spf1 -all", TTL: 300},
+				{ID: 4, Type: recordTypeMX, Name: "", Value: "mail.example.com", TTL: 3600, Priority: 10},
+				{ID: 5, Type: recordTypeCNAME, Name: "old", Value: "example.com", TTL: 300},
+				{ID: 6, Type: recordTypeNS, Name: "", Value: "kiki.bunny.net", TTL: 300},
+			}}, nil
+		},
+	}
+	h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	diff := func(contentType, body string, keyInfo *auth.KeyInfo) (*httptest.ResponseRecorder, ZoneDiffResponse) {
+		t.Helper()
+		params := map[string]string{"zoneID": "1"}
+		r := newTestRequest(http.MethodPost, "/dnszone/1/diff", strings.NewReader(body), params)
+		if keyInfo != nil {
+			r = newTestRequestWithKeyInfo("/dnszone/1/diff", params, keyInfo)
+			r.Method = http.MethodPost
+			r.Body = io.NopCloser(strings.NewReader(body))
+		}
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		h.HandleDiffRecords(w, r)
+		var resp ZoneDiffResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v: %s", err, w.Body.String())
+		}
+		return w, resp
+	}
+
+	zoneFile := strings.Join([]string{
+		"@ 300 IN A 192.0.2.1",
+		"WWW 600 IN A 192.0.2.2",
+		`@ 300 IN TXT "v=spf1 include:_spf.example.net -all"`,
+		"@ 3600 IN MX 10 mail.example.com.",
+		"api 300 IN A 192.0.2.3",
+	}, "\n")
+	w, resp := diff("text/plain", zoneFile, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Creates != 1 || resp.Updates != 2 || resp.Deletes != 1 || resp.Unchanged != 2 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+	want := []struct {
+		action   string
+		recordID int64
+	}{{BatchDelete, 5}, {BatchUpdate, 2}, {BatchUpdate, 3}, {BatchCreate, 0}}
+	if len(resp.Operations) != len(want) {
+		t.Fatalf("expected %d operations, got %+v", len(want), resp.Operations)
+	}
+	for i, op := range resp.Operations {
+		if op.Action != want[i].action || op.RecordID != want[i].recordID {
+			t.Errorf("operation %d: expected %s %d, got %s %d", i, want[i].action, want[i].recordID, op.Action, op.RecordID)
+		}
+	}
+	if op := resp.Operations[1]; op.Record == nil || op.Record.TTL != 600 || op.Before == nil || op.Before.TTL != 300 || op.Line != 2 {
+		t.Errorf("unexpected TTL update: %+v", op)
+	}
+
+	// A JSON body matching the zone has nothing to change
+	_, resp = diff("application/json", `{"Records":[
+		{"Type":0,"Name":"@","Value":"192.0.2.1","Ttl":300},
+		{"Type":0,"Name":"www","Value":"192.0.2.2","Ttl":300},
+		{"Type":3,"Name":"","Value":"v=spf1 -all","Ttl":300},
+		{"Type":4,"Name":"","Value":"mail.example.com.","Ttl":3600,"Priority":10},
+		{"Type":2,"Name":"old","Value":"example.com","Ttl":300}]}`, nil)
+	if len(resp.Operations) != 0 || resp.Unchanged != 5 {
+		t.Errorf("expected no operations, got %+v", resp)
+	}
+
+	w, resp = diff("text/plain", "www 300 IN A not-an-address\n", nil)
+	if w.Code != http.StatusBadRequest || len(resp.Lines) != 1 || resp.Lines[0].Line != 1 || len(resp.Operations) != 0 {
+		t.Errorf("expected 400 for an invalid line, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Scoped tokens only see, and may only submit, their record types
+	txtOnly := &auth.KeyInfo{KeyID: 1, KeyName: "acme", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+	}}
+	w, resp = diff("text/plain", `@ 300 IN TXT "v=spf1 -all"`+"\n", txtOnly)
+	if w.Code != http.StatusOK || len(resp.Operations) != 0 || resp.Unchanged != 1 {
+		t.Errorf("expected the TXT record unchanged, got %d: %s", w.Code, w.Body.String())
+	}
+	w, resp = diff("text/plain", "www 300 IN A 192.0.2.2\n", txtOnly)
+	if w.Code != http.StatusForbidden || len(resp.Lines) != 1 || resp.Lines[0].Status != ImportDenied {
+		t.Errorf("expected 403 for an A record, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,109 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// dnssecKey identifies a zone in one bunny.net account.
+type dnssecKey struct {
+	account string
+	zoneID  int64
+}
+
+// dnssecCache keeps the DNSSEC keys bunny.net returned when DNSSEC was
+// enabled through the proxy. bunny.net has no endpoint that reads them
+// without enabling DNSSEC, and zones only report whether it is enabled.
+type dnssecCache struct {
+	mu   sync.Mutex
+	keys map[dnssecKey]bunny.DNSSECResponse
+}
+
+func newDNSSECCache() *dnssecCache {
+	return &dnssecCache{keys: make(map[dnssecKey]bunny.DNSSECResponse)}
+}
+
+func (c *dnssecCache) get(key dnssecKey) (bunny.DNSSECResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.keys[key]
+	return r, ok
+}
+
+// put stores the result of enabling or disabling DNSSEC; a disabled zone's
+// keys are dropped.
+func (c *dnssecCache) put(key dnssecKey, r *bunny.DNSSECResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.Enabled {
+		c.keys[key] = *r
+	} else {
+		delete(c.keys, key)
+	}
+}
+
+func (c *dnssecCache) drop(key dnssecKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.keys, key)
+}
+
+// dnssecKeyFor returns the cache key of a zone in the caller's account.
+func (h *Handler) dnssecKeyFor(ctx context.Context, zoneID int64) dnssecKey {
+	return dnssecKey{account: h.accountOf(ctx), zoneID: zoneID}
+}
+
+// dnssecStatus is the response of GET /dnszone/{zoneID}/dnssec.
+type dnssecStatus struct {
+	bunny.DNSSECResponse
+	// KeysKnown is false when DNSSEC is enabled but was not enabled through
+	// the proxy since it started, so the DS record and key are unknown.
+	KeysKnown bool `json:"KeysKnown"`
+}
+
+// HandleGetDNSSEC returns whether DNSSEC is enabled for a zone and, if it was
+// enabled through the proxy, the DS record to publish at the registrar.
+// GET /dnszone/{zoneID}/dnssec
+// Granted by list_records, so registrar automation needs no admin token.
+func (h *Handler) HandleGetDNSSEC(w http.ResponseWriter, r *http.Request) {
+	zoneIDStr := chi.URLParam(r, "zoneID")
+	if zoneIDStr == "" {
+		writeError(w, http.StatusBadRequest, "missing zone ID")
+		return
+	}
+
+	zoneID, err := strconv.ParseInt(zoneIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	zone, err := h.upstream(r.Context()).GetZone(r.Context(), zoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	key := h.dnssecKeyFor(r.Context(), zoneID)
+	status := dnssecStatus{DNSSECResponse: bunny.DNSSECResponse{Enabled: zone.DnsSecEnabled}, KeysKnown: true}
+	if zone.DnsSecEnabled {
+		cached, ok := h.dnssec.get(key)
+		if ok {
+			status.DNSSECResponse = cached
+		} else {
+			status.KeysKnown = false
+		}
+	} else {
+		// Disabled outside the proxy
+		h.dnssec.drop(key)
+	}
+
+	h.logger.Info("get DNSSEC", "zone_id", zoneID, "enabled", zone.DnsSecEnabled)
+
+	writeJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+func TestHandleGetDNSSEC(t *testing.T) {
+	t.Parallel()
+	var enabled atomic.Bool
+	mockClient := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			if id != 1 {
+				return nil, bunny.ErrNotFound
+			}
+			return &bunny.Zone{ID: id, Domain: "example.com", DnsSecEnabled: enabled.Load()}, nil
+		},
+		enableDNSSECFunc: func(context.Context, int64) (*bunny.DNSSECResponse, error) {
+			enabled.Store(true)
+			return &bunny.DNSSECResponse{Enabled: true, DsRecord: "example.com. 3600 IN DS 12345 13 2 AABB", Digest: "AABB", KeyTag: 12345}, nil
+		},
+	}
+	handler := NewHandler(mockClient, slog.Default())
+
+	r := chi.NewRouter()
+	r.Get("/dnszone/{zoneID}/dnssec", handler.HandleGetDNSSEC)
+	r.Post("/dnszone/{zoneID}/dnssec", handler.HandleEnableDNSSEC)
+
+	get := func(path string) (int, dnssecStatus) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var status dnssecStatus
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+		}
+		return w.Code, status
+	}
+
+	if code, status := get("/dnszone/1/dnssec"); code != http.StatusOK || status.Enabled || !status.KeysKnown {
+		t.Errorf("expected DNSSEC disabled, got %d %+v", code, status)
+	}
+
+	// Enabled outside the proxy: the keys are unknown
+	enabled.Store(true)
+	if code, status := get("/dnszone/1/dnssec"); code != http.StatusOK || !status.Enabled || status.KeysKnown {
+		t.Errorf("expected unknown keys, got %d %+v", code, status)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/dnszone/1/dnssec", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("enable failed with %d", w.Code)
+	}
+	if code, status := get("/dnszone/1/dnssec"); code != http.StatusOK || !status.KeysKnown || status.Digest != "AABB" || status.KeyTag != 12345 {
+		t.Errorf("expected the DS record, got %d %+v", code, status)
+	}
+
+	// Disabled outside the proxy: the cached keys are dropped
+	enabled.Store(false)
+	if _, status := get("/dnszone/1/dnssec"); status.Enabled || status.DsRecord != "" {
+		t.Errorf("expected DNSSEC disabled, got %+v", status)
+	}
+	enabled.Store(true)
+	if _, status := get("/dnszone/1/dnssec"); status.KeysKnown {
+		t.Errorf("expected the keys to be forgotten, got %+v", status)
+	}
+
+	if code, _ := get("/dnszone/2/dnssec"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown zone, got %d", code)
+	}
+	if code, _ := get("/dnszone/abc/dnssec"); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid zone ID, got %d", code)
+	}
+}
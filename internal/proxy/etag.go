@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// preconditionLocks serializes conditional writes per zone, so two clients
+// sending If-Match for the same zone cannot both pass the check before
+// either write lands. Zones share a fixed set of locks.
+var preconditionLocks [64]sync.Mutex
+
+// zoneLock returns the precondition lock of a zone.
+func zoneLock(zoneID int64) *sync.Mutex {
+	return &preconditionLocks[uint64(zoneID)%uint64(len(preconditionLocks))] //nolint:gosec // zone IDs are positive
+}
+
+// etag returns a strong entity tag for the JSON encoding of v.
+func etag(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// zoneETag returns the entity tag of a zone as the caller sees it, with the
+// records the caller may not see removed, so it changes whenever the zone
+// settings or any visible record change.
+func zoneETag(zone *bunny.Zone) string {
+	return etag(zone)
+}
+
+// recordETag returns the entity tag of a single record.
+func recordETag(rec *bunny.Record) string {
+	return etag(rec)
+}
+
+// ifMatch reports whether an If-Match header value matches current, using
+// strong comparison: weak tags never match and "*" matches any current
+// representation. Nothing matches a resource that does not exist.
+func ifMatch(header, current string) bool {
+	if current == "" {
+		return false
+	}
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch enforces the If-Match header of a zone write, or of a record
+// write when recordID is not zero. Without the header it returns a no-op
+// unlock and true. Otherwise it locks the zone, fetches it from bunny.net
+// bypassing the response cache, and compares the entity tag the caller got
+// from GET. On a mismatch, or when the record no longer exists, it writes
+// 412 Precondition Failed and returns false; on success the caller must call
+// unlock after its write.
+func (h *Handler) checkIfMatch(w http.ResponseWriter, r *http.Request, zoneID, recordID int64) (unlock func(), ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return func() {}, true
+	}
+
+	mu := zoneLock(zoneID)
+	mu.Lock()
+
+	zone, err := uncached(h.upstream(r.Context())).GetZone(r.Context(), zoneID)
+	if err != nil {
+		mu.Unlock()
+		handleBunnyError(w, err)
+		return nil, false
+	}
+	zone.Records = filterRecordsByPermission(zone.Records, auth.GetKeyInfo(r.Context()), zoneID)
+
+	current := zoneETag(zone)
+	if recordID != 0 {
+		current = ""
+		if i := slices.IndexFunc(zone.Records, func(rec bunny.Record) bool { return rec.ID == recordID }); i >= 0 {
+			current = recordETag(&zone.Records[i])
+		}
+	}
+	if !ifMatch(header, current) {
+		mu.Unlock()
+		if current != "" {
+			w.Header().Set("ETag", current)
+		}
+		h.logger.Info("precondition failed", "zone_id", zoneID, "record_id", recordID)
+		writeError(w, http.StatusPreconditionFailed, "resource was modified; fetch it again and retry")
+		return nil, false
+	}
+	return mu.Unlock, true
+}
@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// newETagTestHandler returns a handler whose zone 123 has one record with
+// the given value, and a pointer to that value to simulate a concurrent
+// change. It also reports whether a write reached bunny.net.
+func newETagTestHandler() (*Handler, *string, *bool) {
+	value, written := "192.0.2.1", false
+	client := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			if id != 123 {
+				return nil, bunny.ErrNotFound
+			}
+			return &bunny.Zone{ID: 123, Domain: "example.com", Records: []bunny.Record{
+				{ID: 1, Type: 0, Name: "www", Value: value},
+			}}, nil
+		},
+		updateRecordFunc: func(context.Context, int64, int64, *bunny.AddRecordRequest) (*bunny.Record, error) {
+			written = true
+			return nil, nil
+		},
+		deleteRecordFunc: func(context.Context, int64, int64) error {
+			written = true
+			return nil
+		},
+		deleteZoneFunc: func(context.Context, int64) error {
+			written = true
+			return nil
+		},
+	}
+	return NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil))), &value, &written
+}
+
+func TestETag_GetResponses(t *testing.T) {
+	t.Parallel()
+	handler, value, _ := newETagTestHandler()
+	params := map[string]string{"zoneID": "123", "recordID": "1"}
+
+	w := httptest.NewRecorder()
+	handler.HandleGetRecord(w, newTestRequest(http.MethodGet, "/dnszone/123/records/1", nil, params))
+	recordTag := w.Header().Get("ETag")
+	if !strings.HasPrefix(recordTag, `"`) || !strings.HasSuffix(recordTag, `"`) {
+		t.Fatalf("expected a strong ETag on the record, got %q", recordTag)
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleGetZone(w, newTestRequest(http.MethodGet, "/dnszone/123", nil, params))
+	zoneTag := w.Header().Get("ETag")
+	if zoneTag == "" || zoneTag == recordTag {
+		t.Fatalf("expected a distinct zone ETag, got %q", zoneTag)
+	}
+
+	*value = "192.0.2.2"
+	w = httptest.NewRecorder()
+	handler.HandleGetZone(w, newTestRequest(http.MethodGet, "/dnszone/123", nil, params))
+	if w.Header().Get("ETag") == zoneTag {
+		t.Error("expected the zone ETag to change with its records")
+	}
+}
+
+func TestETag_IfMatch(t *testing.T) {
+	t.Parallel()
+	params := map[string]string{"zoneID": "123", "recordID": "1"}
+	body := `{"Type": 0, "Name": "www", "Value": "192.0.2.3"}`
+
+	tests := []struct {
+		name       string
+		ifMatch    func(current string) string
+		change     bool
+		wantStatus int
+		wantWrite  bool
+	}{
+		{"no header", func(string) string { return "" }, true, http.StatusNoContent, true},
+		{"current tag", func(tag string) string { return tag }, false, http.StatusNoContent, true},
+		{"one of several", func(tag string) string { return `"other", ` + tag }, false, http.StatusNoContent, true},
+		{"wildcard", func(string) string { return "*" }, true, http.StatusNoContent, true},
+		{"modified since", func(tag string) string { return tag }, true, http.StatusPreconditionFailed, false},
+		{"weak tag", func(tag string) string { return "W/" + tag }, false, http.StatusPreconditionFailed, false},
+	}
+	for _, tt := range tests {
+		for _, method := range []string{http.MethodPost, http.MethodDelete} {
+			handler, value, written := newETagTestHandler()
+			w := httptest.NewRecorder()
+			handler.HandleGetRecord(w, newTestRequest(http.MethodGet, "/dnszone/123/records/1", nil, params))
+			tag := w.Header().Get("ETag")
+			if tt.change {
+				*value = "192.0.2.2"
+			}
+
+			r := newTestRequest(method, "/dnszone/123/records/1", strings.NewReader(body), params)
+			if h := tt.ifMatch(tag); h != "" {
+				r.Header.Set("If-Match", h)
+			}
+			w = httptest.NewRecorder()
+			if method == http.MethodPost {
+				handler.HandleUpdateRecord(w, r)
+			} else {
+				handler.HandleDeleteRecord(w, r)
+			}
+
+			if w.Code != tt.wantStatus || *written != tt.wantWrite {
+				t.Errorf("%s %s: status %d, written %v; want %d, %v: %s", tt.name, method, w.Code, *written,
+					tt.wantStatus, tt.wantWrite, w.Body.String())
+			}
+			if w.Code == http.StatusPreconditionFailed && tt.change && w.Header().Get("ETag") == tag {
+				t.Errorf("%s %s: expected the current ETag with the 412 response", tt.name, method)
+			}
+		}
+	}
+}
+
+func TestETag_IfMatchMissingRecord(t *testing.T) {
+	t.Parallel()
+	handler, _, written := newETagTestHandler()
+	r := newTestRequest(http.MethodDelete, "/dnszone/123/records/9", nil, map[string]string{"zoneID": "123", "recordID": "9"})
+	r.Header.Set("If-Match", "*")
+	w := httptest.NewRecorder()
+	handler.HandleDeleteRecord(w, r)
+
+	if w.Code != http.StatusPreconditionFailed || *written {
+		t.Errorf("status %d, written %v; want 412 without a write", w.Code, *written)
+	}
+}
+
+func TestETag_IfMatchZone(t *testing.T) {
+	t.Parallel()
+	handler, _, written := newETagTestHandler()
+	params := map[string]string{"zoneID": "123"}
+
+	r := newTestRequest(http.MethodDelete, "/dnszone/123", nil, params)
+	r.Header.Set("If-Match", `"stale"`)
+	w := httptest.NewRecorder()
+	handler.HandleDeleteZone(w, r)
+	if w.Code != http.StatusPreconditionFailed || *written {
+		t.Fatalf("status %d, written %v; want 412 without a write", w.Code, *written)
+	}
+
+	r = newTestRequest(http.MethodDelete, "/dnszone/123", nil, params)
+	r.Header.Set("If-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	handler.HandleDeleteZone(w, r)
+	if w.Code != http.StatusNoContent || !*written {
+		t.Errorf("status %d, written %v; want 204 with the current ETag", w.Code, *written)
+	}
+}
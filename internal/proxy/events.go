@@ -0,0 +1,26 @@
+package proxy
+
+import "context"
+
+// EventPublisher persists change events for asynchronous delivery.
+// It is satisfied by *outbox.Outbox.
+type EventPublisher interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// SetEventPublisher enables change events for successful DNS mutations.
+func (h *Handler) SetEventPublisher(p EventPublisher) {
+	h.events = p
+}
+
+// publish emits a change event if a publisher is configured.
+// Failures are logged and never fail the request: the change has already
+// been applied upstream.
+func (h *Handler) publish(ctx context.Context, event string, data map[string]any) {
+	if h.events == nil {
+		return
+	}
+	if err := h.events.Publish(ctx, event, data); err != nil {
+		h.logger.Error("failed to publish event", "event", event, "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+type publishedEvent struct {
+	name string
+	data map[string]any
+}
+
+// fakePublisher records published events and optionally fails.
+type fakePublisher struct {
+	events []publishedEvent
+	err    error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, event string, data any) error {
+	p.events = append(p.events, publishedEvent{name: event, data: data.(map[string]any)})
+	return p.err
+}
+
+func TestHandler_PublishesMutationEvents(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		createZoneFunc: func(_ context.Context, domain string) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: 1, Domain: domain}, nil
+		},
+		updateZoneFunc: func(_ context.Context, id int64, _ *bunny.UpdateZoneRequest) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: id, Domain: "example.com"}, nil
+		},
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			return &bunny.Record{ID: 7, Type: req.Type, Name: req.Name}, nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		handle    func(*Handler) http.HandlerFunc
+		method    string
+		body      string
+		params    map[string]string
+		wantEvent string
+	}{
+		{"create zone", func(h *Handler) http.HandlerFunc { return h.HandleCreateZone }, http.MethodPost,
+			`{"Domain":"example.com"}`, nil, webhook.EventZoneCreated},
+		{"update zone", func(h *Handler) http.HandlerFunc { return h.HandleUpdateZone }, http.MethodPost,
+			`{}`, map[string]string{"zoneID": "1"}, webhook.EventZoneUpdated},
+		{"delete zone", func(h *Handler) http.HandlerFunc { return h.HandleDeleteZone }, http.MethodDelete,
+			"", map[string]string{"zoneID": "1"}, webhook.EventZoneDeleted},
+		{"add record", func(h *Handler) http.HandlerFunc { return h.HandleAddRecord }, http.MethodPut,
+			`{"Type":0,"Name":"www"}`, map[string]string{"zoneID": "1"}, webhook.EventRecordCreated},
+		{"update record", func(h *Handler) http.HandlerFunc { return h.HandleUpdateRecord }, http.MethodPost,
+			`{"Type":0,"Name":"www"}`, map[string]string{"zoneID": "1", "recordID": "7"}, webhook.EventRecordUpdated},
+		{"delete record", func(h *Handler) http.HandlerFunc { return h.HandleDeleteRecord }, http.MethodDelete,
+			"", map[string]string{"zoneID": "1", "recordID": "7"}, webhook.EventRecordDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pub := &fakePublisher{}
+			h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			h.SetEventPublisher(pub)
+
+			r := newTestRequest(tt.method, "/", strings.NewReader(tt.body), tt.params)
+			r = r.WithContext(auth.WithToken(r.Context(), &storage.Token{Name: "ci"}))
+			w := httptest.NewRecorder()
+			tt.handle(h)(w, r)
+
+			if w.Code >= 300 {
+				t.Fatalf("unexpected status %d: %s", w.Code, w.Body.String())
+			}
+			if len(pub.events) != 1 || pub.events[0].name != tt.wantEvent {
+				t.Fatalf("expected one %s event, got %+v", tt.wantEvent, pub.events)
+			}
+			if pub.events[0].data["actor"] != "ci" || pub.events[0].data["zone_id"] != int64(1) {
+				t.Errorf("unexpected event data: %+v", pub.events[0].data)
+			}
+		})
+	}
+}
+
+func TestHandler_NoEventOnFailure(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		deleteZoneFunc: func(context.Context, int64) error {
+			return bunny.ErrNotFound
+		},
+	}
+	pub := &fakePublisher{}
+	h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetEventPublisher(pub)
+
+	w := httptest.NewRecorder()
+	h.HandleDeleteZone(w, newTestRequest(http.MethodDelete, "/dnszone/1", nil, map[string]string{"zoneID": "1"}))
+
+	if len(pub.events) != 0 {
+		t.Errorf("expected no events for failed mutation, got %+v", pub.events)
+	}
+}
+
+func TestHandler_PublishErrorDoesNotFailRequest(t *testing.T) {
+	t.Parallel()
+
+	pub := &fakePublisher{err: errors.New("db locked")}
+	h := NewHandler(&mockBunnyClient{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetEventPublisher(pub)
+
+	w := httptest.NewRecorder()
+	h.HandleDeleteZone(w, newTestRequest(http.MethodDelete, "/dnszone/1", nil, map[string]string{"zoneID": "1"}))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204 despite publish error, got %d", w.Code)
+	}
+	if len(pub.events) != 1 {
+		t.Errorf("expected publish to be attempted, got %d", len(pub.events))
+	}
+}
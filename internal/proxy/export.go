@@ -0,0 +1,253 @@
+package proxy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// exportFailuresFile is added to an export archive listing zones that could not be exported.
+const exportFailuresFile = "FAILED_ZONES.txt"
+
+// Formats of GET /dnszone/{zoneID}/export.
+const (
+	exportFormatBIND = "bind"
+	exportFormatJSON = "json"
+	exportFormatCSV  = "csv"
+)
+
+// RecordExport is a zone's records exported with format=json.
+type RecordExport struct {
+	ZoneID  int64          `json:"ZoneId"`
+	Domain  string         `json:"Domain"`
+	Records []bunny.Record `json:"Records"`
+}
+
+// writeStructuredExport writes a zone's records as a RecordExport, or as CSV
+// with the columns POST /dnszone/{zoneID}/import reads, so the file can be
+// imported again. Only records of permitted types are included; nil permits
+// every type.
+func (h *Handler) writeStructuredExport(w http.ResponseWriter, r *http.Request, zoneID int64, format string, permitted map[string]bool) {
+	zone, err := h.upstream(r.Context()).GetZone(r.Context(), zoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+	records := make([]bunny.Record, 0, len(zone.Records))
+	for _, rec := range zone.Records {
+		if permitted == nil || permitted[auth.MapRecordTypeToString(rec.Type)] {
+			records = append(records, rec)
+		}
+	}
+
+	if format == exportFormatJSON {
+		writeJSON(w, http.StatusOK, RecordExport{ZoneID: zone.ID, Domain: zone.Domain, Records: records})
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		err = cw.Write(csvColumns)
+		for i := 0; err == nil && i < len(records); i++ {
+			err = cw.Write(csvRecord(records[i]))
+		}
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+		// The status is sent, so a failure part way can only be logged
+		if err != nil {
+			h.logger.Warn("export records: write interrupted", "zone_id", zoneID, "format", format, "error", err)
+			return
+		}
+	}
+
+	h.logger.Info("export records", "zone_id", zoneID, "format", format, "records", len(records))
+}
+
+// csvRecord returns a record as a row of csvColumns, naming the apex "@".
+func csvRecord(rec bunny.Record) []string {
+	name := rec.Name
+	if name == "" {
+		name = "@"
+	}
+	fields := map[string]string{
+		"type":     auth.MapRecordTypeToString(rec.Type),
+		"name":     name,
+		"value":    rec.Value,
+		"ttl":      strconv.Itoa(int(rec.TTL)),
+		"priority": strconv.Itoa(int(rec.Priority)),
+		"weight":   strconv.Itoa(int(rec.Weight)),
+		"port":     strconv.Itoa(int(rec.Port)),
+		"flags":    strconv.Itoa(rec.Flags),
+		"tag":      rec.Tag,
+		"disabled": strconv.FormatBool(rec.Disabled),
+		"comment":  rec.Comment,
+	}
+	row := make([]string, len(csvColumns))
+	for i, column := range csvColumns {
+		row[i] = fields[column]
+	}
+	return row
+}
+
+// HandleExportZones streams a tar.gz archive with one BIND zone file per zone.
+// GET /export?zones=1,2,3
+// Without zones, every zone the caller may export is included. Scoped tokens may
+// only export zones they can read without a record type restriction, since zone
+// files contain every record. Zones that fail to export mid-stream are listed in
+// FAILED_ZONES.txt at the end of the archive.
+func (h *Handler) HandleExportZones(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("tag") != "" {
+		writeError(w, http.StatusBadRequest, "tag filtering is not supported")
+		return
+	}
+
+	requested, err := parseZoneIDList(r.URL.Query().Get("zones"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zones parameter")
+		return
+	}
+
+	for _, id := range requested {
+		if !canExportZone(r, id) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("permission denied for zone %d", id))
+			return
+		}
+	}
+
+	all, err := h.listAllZones(r.Context())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	zones, missing := selectZones(all, requested, func(id int64) bool { return canExportZone(r, id) })
+	if missing != 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("zone %d not found", missing))
+		return
+	}
+
+	filename := fmt.Sprintf("dns-export-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	var failed []string
+	for _, zone := range zones {
+		content, err := h.upstream(r.Context()).ExportRecords(r.Context(), zone.ID)
+		if err == nil {
+			err = writeTarFile(tw, exportFileName(zone), content)
+		}
+		if err != nil {
+			h.logger.Warn("export: failed to export zone", "zone_id", zone.ID, "error", err)
+			failed = append(failed, fmt.Sprintf("%d %s: %v", zone.ID, zone.Domain, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		if err := writeTarFile(tw, exportFailuresFile, strings.Join(failed, "\n")+"\n"); err != nil {
+			h.logger.Error("export: failed to write failure list", "error", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		h.logger.Error("export: failed to finish archive", "error", err)
+	}
+	if err := gz.Close(); err != nil {
+		h.logger.Error("export: failed to finish archive", "error", err)
+	}
+
+	h.logger.Info("export zones", "zones", len(zones), "failed", len(failed))
+}
+
+// canExportZone reports whether the caller may export a full zone file.
+func canExportZone(r *http.Request, zoneID int64) bool {
+	if auth.IsAdminFromContext(r.Context()) {
+		return true
+	}
+	keyInfo := auth.GetKeyInfo(r.Context())
+	return auth.HasZoneAccess(keyInfo, zoneID) && auth.GetPermittedRecordTypes(keyInfo, zoneID) == nil
+}
+
+// selectZones returns the requested zones, or every zone allowed reports
+// true for if none were requested, sorted by ID. If a requested zone does not
+// exist, its ID is returned as missing.
+func selectZones(all []bunny.Zone, requested []int64, allowed func(int64) bool) (zones []bunny.Zone, missing int64) {
+	byID := make(map[int64]bunny.Zone, len(all))
+	for _, zone := range all {
+		byID[zone.ID] = zone
+	}
+
+	if len(requested) > 0 {
+		for _, id := range requested {
+			zone, ok := byID[id]
+			if !ok {
+				return nil, id
+			}
+			zones = append(zones, zone)
+		}
+	} else {
+		for _, zone := range all {
+			if allowed(zone.ID) {
+				zones = append(zones, zone)
+			}
+		}
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+	return zones, 0
+}
+
+// parseZoneIDList parses a comma-separated list of zone IDs, dropping duplicates.
+func parseZoneIDList(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	seen := make(map[int64]bool)
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid zone ID %q", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// exportFileName returns the archive entry name for a zone file.
+func exportFileName(zone bunny.Zone) string {
+	if zone.Domain == "" || strings.ContainsAny(zone.Domain, `/\`) {
+		return fmt.Sprintf("zone-%d.zone", zone.ID)
+	}
+	return zone.Domain + ".zone"
+}
+
+// writeTarFile adds a regular file to a tar archive.
+func writeTarFile(tw *tar.Writer, name, content string) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write([]byte(content))
+	return err
+}
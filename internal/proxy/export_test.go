@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// newExportHandler returns a handler over zones 1-3; exporting zone 3 fails.
+func newExportHandler() *Handler {
+	return NewHandler(&mockBunnyClient{
+		listZonesFunc: func(context.Context, *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+			return &bunny.ListZonesResponse{Items: []bunny.Zone{
+				{ID: 2, Domain: "b.com"}, {ID: 1, Domain: "a.com"}, {ID: 3, Domain: "c.com"},
+			}}, nil
+		},
+		exportRecordsFunc: func(_ context.Context, id int64) (string, error) {
+			if id == 3 {
+				return "", errors.New("upstream unavailable")
+			}
+			return fmt.Sprintf("; zone %d\n", id), nil
+		},
+	}, nil)
+}
+
+// readExport returns the archive entries of an export response, keyed by name.
+func readExport(t *testing.T, body io.Reader) (names []string, files map[string]string) {
+	t.Helper()
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		t.Fatalf("invalid gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files = make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names, files
+		}
+		if err != nil {
+			t.Fatalf("invalid tar: %v", err)
+		}
+		data, _ := io.ReadAll(tr)
+		names = append(names, hdr.Name)
+		files[hdr.Name] = string(data)
+	}
+}
+
+func adminRequest(path string) *http.Request {
+	r := newTestRequest(http.MethodGet, path, nil, nil)
+	return r.WithContext(auth.WithAdmin(r.Context(), true))
+}
+
+func TestHandleExportZones_Admin(t *testing.T) {
+	t.Parallel()
+	h := newExportHandler()
+
+	w := httptest.NewRecorder()
+	h.HandleExportZones(w, adminRequest("/export"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/gzip" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+
+	names, files := readExport(t, w.Body)
+	want := []string{"a.com.zone", "b.com.zone", exportFailuresFile}
+	if len(names) != len(want) {
+		t.Fatalf("expected entries %v, got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("entry %d = %q, want %q", i, names[i], want[i])
+		}
+	}
+	if files["a.com.zone"] != "; zone 1\n" {
+		t.Errorf("unexpected zone file: %q", files["a.com.zone"])
+	}
+	if files[exportFailuresFile] == "" {
+		t.Error("expected failed zone to be listed")
+	}
+}
+
+func TestHandleExportZones_SelectedZones(t *testing.T) {
+	t.Parallel()
+	h := newExportHandler()
+
+	w := httptest.NewRecorder()
+	h.HandleExportZones(w, adminRequest("/export?zones=2,2"))
+	names, _ := readExport(t, w.Body)
+	if len(names) != 1 || names[0] != "b.com.zone" {
+		t.Errorf("expected only b.com.zone, got %v", names)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleExportZones(w, adminRequest("/export?zones=9"))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown zone, got %d", w.Code)
+	}
+}
+
+func TestHandleExportZones_ScopedToken(t *testing.T) {
+	t.Parallel()
+	h := newExportHandler()
+	keyInfo := &auth.KeyInfo{KeyID: 1, Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"list_records"}},
+		{ZoneID: 2, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+	}}
+
+	w := httptest.NewRecorder()
+	h.HandleExportZones(w, newTestRequestWithKeyInfo("/export", nil, keyInfo))
+	names, _ := readExport(t, w.Body)
+	if len(names) != 1 || names[0] != "a.com.zone" {
+		t.Errorf("expected only the unrestricted zone, got %v", names)
+	}
+
+	// Record type restricted and unpermitted zones are rejected when requested explicitly
+	for _, zones := range []string{"2", "3"} {
+		w = httptest.NewRecorder()
+		h.HandleExportZones(w, newTestRequestWithKeyInfo("/export?zones="+zones, nil, keyInfo))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("zones=%s: expected 403, got %d", zones, w.Code)
+		}
+	}
+}
+
+func TestHandleExportZones_InvalidParams(t *testing.T) {
+	t.Parallel()
+	h := newExportHandler()
+
+	for _, path := range []string{"/export?zones=1,x", "/export?zones=-1", "/export?tag=prod"} {
+		w := httptest.NewRecorder()
+		h.HandleExportZones(w, adminRequest(path))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", path, w.Code)
+		}
+	}
+}
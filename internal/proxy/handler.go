@@ -5,14 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sipico/bunny-api-proxy/internal/auth"
 	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
 )
 
 // BunnyClient defines the bunny.net API operations needed by the proxy.
@@ -27,6 +34,9 @@ type BunnyClient interface {
 	// GetZone retrieves a single zone by ID, including all records.
 	GetZone(ctx context.Context, id int64) (*bunny.Zone, error)
 
+	// FindZoneByDomain returns the zone a hostname belongs to, without records.
+	FindZoneByDomain(ctx context.Context, hostname string) (*bunny.Zone, error)
+
 	// DeleteZone deletes a DNS zone by ID.
 	DeleteZone(ctx context.Context, id int64) error
 	// UpdateZone updates zone-level settings.
@@ -39,6 +49,9 @@ type BunnyClient interface {
 
 	// ExportRecords exports DNS records in BIND zone file format.
 	ExportRecords(ctx context.Context, zoneID int64) (string, error)
+	// StreamExportRecords exports DNS records in BIND zone file format as a
+	// stream the caller must close.
+	StreamExportRecords(ctx context.Context, zoneID int64) (io.ReadCloser, error)
 	// EnableDNSSEC enables DNSSEC for a DNS zone.
 	EnableDNSSEC(ctx context.Context, zoneID int64) (*bunny.DNSSECResponse, error)
 	// DisableDNSSEC disables DNSSEC for a DNS zone.
@@ -60,12 +73,97 @@ type BunnyClient interface {
 
 	// DeleteRecord removes a DNS record from the specified zone.
 	DeleteRecord(ctx context.Context, zoneID, recordID int64) error
+
+	// GetStatistics retrieves account-wide CDN statistics as raw JSON.
+	GetStatistics(ctx context.Context, query url.Values) (json.RawMessage, error)
+	// GetBilling retrieves account billing details as raw JSON.
+	GetBilling(ctx context.Context) (json.RawMessage, error)
+	// GetBillingSummary retrieves the account billing summary as raw JSON.
+	GetBillingSummary(ctx context.Context) (json.RawMessage, error)
+
+	// ListPullZones retrieves pull zones as raw JSON.
+	ListPullZones(ctx context.Context, query url.Values) (json.RawMessage, error)
+	// GetPullZone retrieves a single pull zone as raw JSON.
+	GetPullZone(ctx context.Context, id int64, query url.Values) (json.RawMessage, error)
+	// AddPullZone creates a pull zone from a raw JSON body.
+	AddPullZone(ctx context.Context, body json.RawMessage) (json.RawMessage, error)
+	// UpdatePullZone updates a pull zone from a raw JSON body.
+	UpdatePullZone(ctx context.Context, id int64, body json.RawMessage) (json.RawMessage, error)
+	// DeletePullZone deletes a pull zone.
+	DeletePullZone(ctx context.Context, id int64) error
+	// PurgePullZoneCache purges a pull zone's cache.
+	PurgePullZoneCache(ctx context.Context, id int64, body json.RawMessage) error
+
+	// ListStorageZones retrieves storage zones as raw JSON.
+	ListStorageZones(ctx context.Context, query url.Values) (json.RawMessage, error)
+	// GetStorageZone retrieves a single storage zone as raw JSON.
+	GetStorageZone(ctx context.Context, id int64) (json.RawMessage, error)
+	// AddStorageZone creates a storage zone from a raw JSON body.
+	AddStorageZone(ctx context.Context, body json.RawMessage) (json.RawMessage, error)
+	// DeleteStorageZone deletes a storage zone.
+	DeleteStorageZone(ctx context.Context, id int64, query url.Values) error
+	// ResetStorageZonePassword generates a new storage zone password.
+	ResetStorageZonePassword(ctx context.Context, id int64) error
 }
 
 // Handler handles proxy requests to bunny.net API.
 type Handler struct {
 	client BunnyClient
 	logger *slog.Logger
+
+	// accounts holds the clients of further bunny.net accounts by name.
+	accounts map[string]BunnyClient
+
+	// accountPassthrough enables the read-only /statistics and /billing routes.
+	accountPassthrough bool
+
+	// events receives change events for successful mutations; nil disables them.
+	events EventPublisher
+
+	// meta stores proxy-side record annotations; nil disables them.
+	meta RecordMetaStore
+
+	// history stores snapshots of record mutations; nil disables them.
+	history RecordHistoryStore
+
+	// zones caches zones fetched by record searches.
+	zones *zoneCache
+
+	// dnssec keeps the DNSSEC keys returned when DNSSEC was enabled.
+	dnssec *dnssecCache
+
+	// activity lists the caller's own audit entries; nil disables /api/my/activity.
+	activity ActivityStore
+
+	// jobs runs asynchronous imports; nil disables them and /jobs/{jobID}.
+	jobs JobRunner
+
+	// schedule stores record changes to be made later; nil disables them.
+	schedule ScheduleStore
+
+	// routeInfo is the route table served by /api/routes; set by NewRouter.
+	routeInfo []RouteInfo
+
+	// strictRecords validates records before they are sent upstream, with
+	// TTLs bounded by minTTL and maxTTL.
+	strictRecords  bool
+	minTTL, maxTTL int32
+
+	// ttlPolicy defaults and clamps record TTLs per zone; nil leaves them as sent.
+	ttlPolicy TTLPolicy
+
+	// protected lists records scoped tokens may not change; nil disables protection.
+	protected ProtectedRecordStore
+
+	// maxBody caps request bodies; imports are capped at importMaxBody and
+	// get importTimeout to read the file and respond, if set.
+	maxBody, importMaxBody int64
+	importTimeout          time.Duration
+
+	// maxTXTLength caps TXT values; imports create at most importMaxRecords
+	// records, or importMaxAsyncRecords as a job.
+	maxTXTLength                            int
+	importMaxRecords, importMaxAsyncRecords int
 }
 
 // NewHandler creates a new proxy handler.
@@ -77,9 +175,24 @@ func NewHandler(client BunnyClient, logger *slog.Logger) *Handler {
 	return &Handler{
 		client: client,
 		logger: logger,
+		zones:  newZoneCache(searchCacheTTL),
+		dnssec: newDNSSECCache(),
+
+		maxBody:       defaultMaxBodySize,
+		importMaxBody: defaultMaxBodySize,
+
+		maxTXTLength:          DefaultMaxTXTLength,
+		importMaxRecords:      DefaultImportMaxRecords,
+		importMaxAsyncRecords: DefaultImportMaxAsyncRecords,
 	}
 }
 
+// SetAccountPassthrough enables or disables the read-only account endpoints
+// (/statistics, /billing, /billing/summary). Must be called before NewRouter.
+func (h *Handler) SetAccountPassthrough(enabled bool) {
+	h.accountPassthrough = enabled
+}
+
 // writeJSON writes a JSON response with the given status code.
 func writeJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -101,24 +214,32 @@ func writeError(w http.ResponseWriter, status int, message string) {
 // handleBunnyError maps bunny.net client errors to appropriate HTTP responses.
 // It logs errors to help with debugging upstream issues.
 func handleBunnyError(w http.ResponseWriter, err error) {
+	status, message := bunnyErrorStatus(err)
+	writeError(w, status, message)
+}
+
+// bunnyErrorStatus maps a bunny.net client error to an HTTP status and message.
+func bunnyErrorStatus(err error) (int, string) {
 	switch {
 	case errors.Is(err, bunny.ErrNotFound):
-		writeError(w, http.StatusNotFound, "resource not found")
+		return http.StatusNotFound, "resource not found"
 	case errors.Is(err, bunny.ErrUnauthorized):
 		// Master key issue - proxy's bunny.net credentials are invalid
 		slog.Default().Error("upstream authentication failed", "error", err)
-		writeError(w, http.StatusBadGateway, "upstream authentication failed")
+		return http.StatusBadGateway, "upstream authentication failed"
+	case errors.Is(err, bunny.ErrCircuitOpen):
+		// bunny.net keeps failing for this kind of request; fail fast until it recovers
+		return http.StatusServiceUnavailable, "upstream temporarily unavailable"
 	default:
 		// Check if it's a structured APIError with a specific status code
 		var apiErr *bunny.APIError
 		if errors.As(err, &apiErr) {
 			// Forward the APIError status code (e.g., 400 for validation errors)
-			writeError(w, apiErr.StatusCode, apiErr.Message)
-			return
+			return apiErr.StatusCode, apiErr.Message
 		}
 		// Generic errors (network, parsing, etc.) - log for debugging
 		slog.Default().Error("bunny.net API error", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal server error")
+		return http.StatusInternalServerError, "internal server error"
 	}
 }
 
@@ -134,7 +255,9 @@ func filterRecordsByPermission(records []bunny.Record, keyInfo *auth.KeyInfo, zo
 	}
 	typeSet := make(map[string]bool, len(permittedTypes))
 	for _, t := range permittedTypes {
-		typeSet[t] = true
+		if name, ok := auth.NormalizeRecordType(t); ok {
+			typeSet[name] = true
+		}
 	}
 	filtered := make([]bunny.Record, 0, len(records))
 	for _, record := range records {
@@ -145,6 +268,63 @@ func filterRecordsByPermission(records []bunny.Record, keyInfo *auth.KeyInfo, zo
 	return filtered
 }
 
+// recordFilter selects records by the type, name and value query parameters
+// of a record listing. Empty fields match every record.
+type recordFilter struct {
+	recordType string // canonical type name
+	name       string // normalized with normalizeRecordName
+	value      string // normalized with normalizeRecordValue
+}
+
+// parseRecordFilter reads a record filter from query parameters. Types are
+// matched by name, case-insensitively; names and values are compared
+// case-insensitively, ignoring a trailing dot, and "@" names the apex.
+func parseRecordFilter(q url.Values) (recordFilter, error) {
+	var f recordFilter
+	if t := q.Get("type"); t != "" {
+		canonical, ok := auth.NormalizeRecordType(t)
+		if !ok {
+			return f, fmt.Errorf("unknown record type %q", t)
+		}
+		f.recordType = canonical
+	}
+	if name := q.Get("name"); name != "" {
+		f.name = normalizeRecordName(name)
+	}
+	f.value = normalizeRecordValue(q.Get("value"))
+	return f, nil
+}
+
+// apply returns the records matching the filter.
+func (f recordFilter) apply(records []bunny.Record) []bunny.Record {
+	if f == (recordFilter{}) {
+		return records
+	}
+	matched := make([]bunny.Record, 0, len(records))
+	for _, record := range records {
+		if f.recordType != "" && auth.MapRecordTypeToString(record.Type) != f.recordType {
+			continue
+		}
+		if f.name != "" && normalizeRecordName(record.Name) != f.name {
+			continue
+		}
+		if f.value != "" && normalizeRecordValue(record.Value) != f.value {
+			continue
+		}
+		matched = append(matched, record)
+	}
+	return matched
+}
+
+// normalizeRecordName normalizes a record name for comparison, writing the
+// apex as "@".
+func normalizeRecordName(name string) string {
+	if name = normalizeRecordValue(name); name == "" {
+		return "@"
+	}
+	return name
+}
+
 // HandleListZones lists all DNS zones with optional filtering.
 func (h *Handler) HandleListZones(w http.ResponseWriter, r *http.Request) {
 	opts := &bunny.ListZonesOptions{}
@@ -173,7 +353,7 @@ func (h *Handler) HandleListZones(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call client to list zones
-	result, err := h.client.ListZones(r.Context(), opts)
+	result, err := h.upstream(r.Context()).ListZones(r.Context(), opts)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -215,7 +395,7 @@ func (h *Handler) HandleCreateZone(w http.ResponseWriter, r *http.Request) {
 		Domain string `json:"Domain"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
 		return
 	}
 
@@ -225,7 +405,7 @@ func (h *Handler) HandleCreateZone(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create zone via bunny client
-	zone, err := h.client.CreateZone(r.Context(), req.Domain)
+	zone, err := h.upstream(r.Context()).CreateZone(r.Context(), req.Domain)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -233,6 +413,9 @@ func (h *Handler) HandleCreateZone(w http.ResponseWriter, r *http.Request) {
 
 	// Log the request
 	h.logger.Info("create zone", "domain", req.Domain, "zoneID", zone.ID)
+	h.publish(r.Context(), webhook.EventZoneCreated, map[string]any{
+		"zone_id": zone.ID, "domain": zone.Domain, "actor": tokenName(r),
+	})
 
 	// Return successful response
 	writeJSON(w, http.StatusCreated, zone)
@@ -253,7 +436,7 @@ func (h *Handler) HandleGetZone(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call client to get zone
-	zone, err := h.client.GetZone(r.Context(), zoneID)
+	zone, err := h.upstream(r.Context()).GetZone(r.Context(), zoneID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -265,11 +448,52 @@ func (h *Handler) HandleGetZone(w http.ResponseWriter, r *http.Request) {
 
 	// Log the request
 	h.logger.Info("get zone", "zone_id", zoneID)
+	w.Header().Set("ETag", zoneETag(zone))
 
-	// Return successful response
+	// Return successful response, with record metadata when enabled
+	if h.meta != nil {
+		writeJSON(w, http.StatusOK, zoneWithMeta{Zone: zone, Records: h.attachRecordMeta(r.Context(), zoneID, zone.Records)})
+		return
+	}
 	writeJSON(w, http.StatusOK, zone)
 }
 
+// ZoneLookupResponse is the response body for GET /dnszone/byname/{domain}.
+// RecordName is the hostname relative to the zone, empty for the apex.
+type ZoneLookupResponse struct {
+	ZoneID     int64  `json:"ZoneId"`
+	Domain     string `json:"Domain"`
+	RecordName string `json:"RecordName"`
+}
+
+// HandleFindZoneByName resolves a hostname to the zone it belongs to.
+// GET /dnszone/byname/{domain}
+// Scoped keys only see zones they have a permission for; other zones are
+// reported as not found.
+func (h *Handler) HandleFindZoneByName(w http.ResponseWriter, r *http.Request) {
+	hostname := strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(chi.URLParam(r, "domain"), ".")), "*.")
+	if hostname == "" || len(hostname) > 253 || strings.ContainsAny(hostname, " /\\") {
+		writeError(w, http.StatusBadRequest, "invalid domain")
+		return
+	}
+
+	zone, err := h.upstream(r.Context()).FindZoneByDomain(r.Context(), hostname)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	if !auth.IsAdminFromContext(r.Context()) && !auth.HasZoneAccess(auth.GetKeyInfo(r.Context()), zone.ID) {
+		writeError(w, http.StatusNotFound, "resource not found")
+		return
+	}
+
+	h.logger.Info("find zone by name", "domain", hostname, "zone_id", zone.ID)
+
+	recordName := strings.TrimSuffix(strings.TrimSuffix(hostname, strings.ToLower(zone.Domain)), ".")
+	writeJSON(w, http.StatusOK, ZoneLookupResponse{ZoneID: zone.ID, Domain: zone.Domain, RecordName: recordName})
+}
+
 // HandleDeleteZone deletes a DNS zone by ID.
 // DELETE /dnszone/{zoneID}
 func (h *Handler) HandleDeleteZone(w http.ResponseWriter, r *http.Request) {
@@ -285,14 +509,25 @@ func (h *Handler) HandleDeleteZone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	unlock, ok := h.checkIfMatch(w, r, zoneID, 0)
+	if !ok {
+		return
+	}
+	defer unlock()
+
 	// Delete zone via bunny client
-	if err := h.client.DeleteZone(r.Context(), zoneID); err != nil {
+	if err := h.upstream(r.Context()).DeleteZone(r.Context(), zoneID); err != nil {
 		handleBunnyError(w, err)
 		return
 	}
 
 	// Log the request
 	h.logger.Info("delete zone", "zone_id", zoneID)
+	h.zones.invalidate(zoneID)
+	h.forgetZoneRecordMeta(r.Context(), zoneID)
+	h.publish(r.Context(), webhook.EventZoneDeleted, map[string]any{
+		"zone_id": zoneID, "actor": tokenName(r),
+	})
 
 	// Return successful response (204 No Content)
 	w.WriteHeader(http.StatusNoContent)
@@ -316,17 +551,26 @@ func (h *Handler) HandleUpdateZone(w http.ResponseWriter, r *http.Request) {
 
 	var req bunny.UpdateZoneRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
+		return
+	}
+
+	unlock, ok := h.checkIfMatch(w, r, zoneID, 0)
+	if !ok {
 		return
 	}
+	defer unlock()
 
-	zone, err := h.client.UpdateZone(r.Context(), zoneID, &req)
+	zone, err := h.upstream(r.Context()).UpdateZone(r.Context(), zoneID, &req)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
 	}
 
 	h.logger.Info("update zone", "zone_id", zoneID)
+	h.publish(r.Context(), webhook.EventZoneUpdated, map[string]any{
+		"zone_id": zoneID, "domain": zone.Domain, "actor": tokenName(r),
+	})
 	writeJSON(w, http.StatusOK, zone)
 }
 
@@ -338,7 +582,7 @@ func (h *Handler) HandleCheckAvailability(w http.ResponseWriter, r *http.Request
 		Name string `json:"Name"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
 		return
 	}
 
@@ -347,7 +591,7 @@ func (h *Handler) HandleCheckAvailability(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	result, err := h.client.CheckZoneAvailability(r.Context(), req.Name)
+	result, err := h.upstream(r.Context()).CheckZoneAvailability(r.Context(), req.Name)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -358,10 +602,11 @@ func (h *Handler) HandleCheckAvailability(w http.ResponseWriter, r *http.Request
 	writeJSON(w, http.StatusOK, result)
 }
 
-// HandleImportRecords imports DNS records from BIND zone file format.
-// POST /dnszone/{zoneID}/import
-// Admin only — bulk import operation.
-func (h *Handler) HandleImportRecords(w http.ResponseWriter, r *http.Request) {
+// HandleExportRecords streams a zone's records in BIND zone file format, or
+// converts them to JSON or CSV with format=json or format=csv.
+// GET /dnszone/{zoneID}/export?format=bind|json|csv&type=TXT
+// Tokens restricted to some record types only get records of those types.
+func (h *Handler) HandleExportRecords(w http.ResponseWriter, r *http.Request) {
 	zoneIDStr := chi.URLParam(r, "zoneID")
 	if zoneIDStr == "" {
 		writeError(w, http.StatusBadRequest, "missing zone ID")
@@ -374,45 +619,70 @@ func (h *Handler) HandleImportRecords(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.client.ImportRecords(r.Context(), zoneID, r.Body, r.Header.Get("Content-Type"))
-	if err != nil {
-		handleBunnyError(w, err)
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatBIND
+	}
+	if format != exportFormatBIND && format != exportFormatJSON && format != exportFormatCSV {
+		writeError(w, http.StatusBadRequest, "invalid format; use bind, json or csv")
 		return
 	}
+	var recordType string
+	if t := r.URL.Query().Get("type"); t != "" {
+		var ok bool
+		if recordType, ok = auth.NormalizeRecordType(t); !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown record type %q", t))
+			return
+		}
+	}
 
-	h.logger.Info("import records", "zone_id", zoneID, "created", result.Created, "failed", result.Failed, "skipped", result.Skipped)
-
-	writeJSON(w, http.StatusOK, result)
-}
-
-// HandleExportRecords exports DNS records in BIND zone file format.
-// GET /dnszone/{zoneID}/export
-// Admin only — exports all records as raw text.
-func (h *Handler) HandleExportRecords(w http.ResponseWriter, r *http.Request) {
-	zoneIDStr := chi.URLParam(r, "zoneID")
-	if zoneIDStr == "" {
-		writeError(w, http.StatusBadRequest, "missing zone ID")
-		return
+	var permitted map[string]bool
+	keyInfo := auth.GetKeyInfo(r.Context())
+	if auth.IsAdminFromContext(r.Context()) {
+		keyInfo = nil
+	}
+	if types := auth.GetPermittedRecordTypes(keyInfo, zoneID); types != nil {
+		permitted = make(map[string]bool, len(types))
+		for _, t := range types {
+			if name, ok := auth.NormalizeRecordType(t); ok {
+				permitted[name] = true
+			}
+		}
+	}
+	if recordType != "" {
+		permitted = map[string]bool{recordType: permitted == nil || permitted[recordType]}
 	}
 
-	zoneID, err := strconv.ParseInt(zoneIDStr, 10, 64)
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid zone ID")
+	if format != exportFormatBIND {
+		h.writeStructuredExport(w, r, zoneID, format, permitted)
 		return
 	}
 
-	result, err := h.client.ExportRecords(r.Context(), zoneID)
+	body, err := h.upstream(r.Context()).StreamExportRecords(r.Context(), zoneID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
 	}
-
-	h.logger.Info("export records", "zone_id", zoneID)
+	defer func() {
+		//nolint:errcheck
+		body.Close()
+	}()
 
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	//nolint:errcheck
-	w.Write([]byte(result))
+
+	// The status is sent, so a failure part way can only be logged
+	if permitted == nil {
+		_, err = io.Copy(w, body)
+	} else {
+		err = filterZoneFile(w, body, permitted)
+	}
+	if err != nil {
+		h.logger.Warn("export records: stream interrupted", "zone_id", zoneID, "error", err)
+		return
+	}
+
+	h.logger.Info("export records", "zone_id", zoneID, "filtered", permitted != nil)
 }
 
 // HandleEnableDNSSEC enables DNSSEC for a DNS zone.
@@ -431,11 +701,12 @@ func (h *Handler) HandleEnableDNSSEC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.client.EnableDNSSEC(r.Context(), zoneID)
+	result, err := h.upstream(r.Context()).EnableDNSSEC(r.Context(), zoneID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
 	}
+	h.dnssec.put(h.dnssecKeyFor(r.Context(), zoneID), result)
 
 	h.logger.Info("enable DNSSEC", "zone_id", zoneID)
 
@@ -458,11 +729,12 @@ func (h *Handler) HandleDisableDNSSEC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.client.DisableDNSSEC(r.Context(), zoneID)
+	result, err := h.upstream(r.Context()).DisableDNSSEC(r.Context(), zoneID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
 	}
+	h.dnssec.put(h.dnssecKeyFor(r.Context(), zoneID), result)
 
 	h.logger.Info("disable DNSSEC", "zone_id", zoneID)
 
@@ -489,11 +761,11 @@ func (h *Handler) HandleIssueCertificate(w http.ResponseWriter, r *http.Request)
 		Domain string `json:"Domain"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
 		return
 	}
 
-	if err := h.client.IssueCertificate(r.Context(), zoneID, req.Domain); err != nil {
+	if err := h.upstream(r.Context()).IssueCertificate(r.Context(), zoneID, req.Domain); err != nil {
 		handleBunnyError(w, err)
 		return
 	}
@@ -505,7 +777,7 @@ func (h *Handler) HandleIssueCertificate(w http.ResponseWriter, r *http.Request)
 
 // HandleGetStatistics retrieves DNS query statistics for a zone.
 // GET /dnszone/{zoneID}/statistics
-// Admin only — statistics are outside the record-level permission model.
+// Scoped tokens need the get_statistics action for the zone.
 func (h *Handler) HandleGetStatistics(w http.ResponseWriter, r *http.Request) {
 	zoneIDStr := chi.URLParam(r, "zoneID")
 	if zoneIDStr == "" {
@@ -519,10 +791,18 @@ func (h *Handler) HandleGetStatistics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// CheckPermissions checks this too; checking here keeps the statistics of
+	// other zones closed if the route is ever mounted without it
+	if keyInfo := auth.GetKeyInfo(r.Context()); keyInfo != nil && !auth.IsAdminFromContext(r.Context()) &&
+		auth.CheckPermission(keyInfo, &auth.Request{Action: auth.ActionGetStatistics, ZoneID: zoneID}) != nil {
+		writeError(w, http.StatusForbidden, fmt.Sprintf("permission denied for zone %d", zoneID))
+		return
+	}
+
 	dateFrom := r.URL.Query().Get("dateFrom")
 	dateTo := r.URL.Query().Get("dateTo")
 
-	result, err := h.client.GetZoneStatistics(r.Context(), zoneID, dateFrom, dateTo)
+	result, err := h.upstream(r.Context()).GetZoneStatistics(r.Context(), zoneID, dateFrom, dateTo)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -541,7 +821,7 @@ func (h *Handler) HandleTriggerScan(w http.ResponseWriter, r *http.Request) {
 		Domain string `json:"Domain"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
 		return
 	}
 
@@ -550,7 +830,7 @@ func (h *Handler) HandleTriggerScan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.client.TriggerDNSScan(r.Context(), req.Domain)
+	result, err := h.upstream(r.Context()).TriggerDNSScan(r.Context(), req.Domain)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -577,7 +857,7 @@ func (h *Handler) HandleGetScanResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.client.GetDNSScanResult(r.Context(), zoneID)
+	result, err := h.upstream(r.Context()).GetDNSScanResult(r.Context(), zoneID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -588,7 +868,8 @@ func (h *Handler) HandleGetScanResult(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
-// HandleListRecords lists all DNS records for a zone.
+// HandleListRecords lists the DNS records of a zone, optionally filtered by
+// the type, name and value query parameters.
 func (h *Handler) HandleListRecords(w http.ResponseWriter, r *http.Request) {
 	zoneIDStr := chi.URLParam(r, "zoneID")
 	if zoneIDStr == "" {
@@ -602,24 +883,74 @@ func (h *Handler) HandleListRecords(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Call client to get zone (which includes records)
-	zone, err := h.client.GetZone(r.Context(), zoneID)
+	filter, err := parseRecordFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Call client to get zone (which includes records); bunny.net cannot
+	// filter a zone's records, so filters are applied here
+	zone, err := h.upstream(r.Context()).GetZone(r.Context(), zoneID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
 	}
 
-	// Filter records by record type if scoped key
+	// Filter records by record type if scoped key, then by the query
 	keyInfo := auth.GetKeyInfo(r.Context())
-	zone.Records = filterRecordsByPermission(zone.Records, keyInfo, zoneID)
+	zone.Records = filter.apply(filterRecordsByPermission(zone.Records, keyInfo, zoneID))
 
 	// Log the request
-	h.logger.Info("list records", "zone_id", zoneID)
+	h.logger.Info("list records", "zone_id", zoneID, "records", len(zone.Records))
 
-	// Return only the records array
+	// Return only the records array, with record metadata when enabled
+	if h.meta != nil {
+		writeJSON(w, http.StatusOK, h.attachRecordMeta(r.Context(), zoneID, zone.Records))
+		return
+	}
 	writeJSON(w, http.StatusOK, zone.Records)
 }
 
+// HandleGetRecord returns a single DNS record. bunny.net has no endpoint for
+// one record, so the zone is fetched and the record picked from it. Records
+// of types the token may not see are reported as not found.
+func (h *Handler) HandleGetRecord(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	recordID, err := strconv.ParseInt(chi.URLParam(r, "recordID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid record ID")
+		return
+	}
+
+	zone, err := h.upstream(r.Context()).GetZone(r.Context(), zoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	records := filterRecordsByPermission(zone.Records, auth.GetKeyInfo(r.Context()), zoneID)
+	i := slices.IndexFunc(records, func(rec bunny.Record) bool { return rec.ID == recordID })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "record not found")
+		return
+	}
+
+	h.logger.Info("get record", "zone_id", zoneID, "record_id", recordID)
+	w.Header().Set("ETag", recordETag(&records[i]))
+
+	if h.meta != nil {
+		writeJSON(w, http.StatusOK, h.attachRecordMeta(r.Context(), zoneID, records[i:i+1])[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, records[i])
+}
+
 // HandleAddRecord creates a new DNS record in the specified zone.
 func (h *Handler) HandleAddRecord(w http.ResponseWriter, r *http.Request) {
 	zoneIDStr := chi.URLParam(r, "zoneID")
@@ -637,12 +968,24 @@ func (h *Handler) HandleAddRecord(w http.ResponseWriter, r *http.Request) {
 	// Decode request body
 	var req bunny.AddRecordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
+		return
+	}
+	h.applyTTLPolicy(zoneID, &req, false)
+	if errs := h.checkRecord(&req, false); len(errs) > 0 {
+		writeInvalidRecord(w, "invalid record", errs)
+		return
+	}
+	if r.URL.Query().Has("scheduleAt") {
+		h.scheduleChange(w, r, &storage.ScheduledChange{ZoneID: zoneID, Action: storage.RecordChangeCreate}, &req)
+		return
+	}
+	if !h.checkProtectedCreate(w, r, zoneID, &req) {
 		return
 	}
 
 	// Call client to add record
-	record, err := h.client.AddRecord(r.Context(), zoneID, &req)
+	record, err := h.upstream(r.Context()).AddRecord(r.Context(), zoneID, &req)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -650,6 +993,13 @@ func (h *Handler) HandleAddRecord(w http.ResponseWriter, r *http.Request) {
 
 	// Log the request
 	h.logger.Info("add record", "zone_id", zoneID, "type", req.Type, "name", req.Name)
+	h.zones.invalidate(zoneID)
+	if record != nil {
+		h.recordChange(r.Context(), zoneID, record.ID, storage.RecordChangeCreate, nil, record, h.authorOf(r))
+	}
+	h.publish(r.Context(), webhook.EventRecordCreated, map[string]any{
+		"zone_id": zoneID, "record": record, "actor": tokenName(r),
+	})
 
 	// Return 201 Created with the record
 	writeJSON(w, http.StatusCreated, record)
@@ -684,13 +1034,29 @@ func (h *Handler) HandleUpdateRecord(w http.ResponseWriter, r *http.Request) {
 	// Decode request body
 	var req bunny.AddRecordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid request body")
+		writeBodyError(w, err)
 		return
 	}
+	h.applyTTLPolicy(zoneID, &req, true)
+	if errs := h.checkRecord(&req, true); len(errs) > 0 {
+		writeInvalidRecord(w, "invalid record", errs)
+		return
+	}
+
+	unlock, ok := h.checkIfMatch(w, r, zoneID, recordID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !h.checkProtected(w, r, zoneID, recordID, &req) {
+		return
+	}
+	before := h.recordBefore(r.Context(), h.upstream(r.Context()), zoneID, recordID)
 
-	// Call client to update record — validation is delegated to the backend
-	// (bunny.net API has nuanced validation rules per record type)
-	record, err := h.client.UpdateRecord(r.Context(), zoneID, recordID, &req)
+	// Call client to update record — unless strict validation is enabled,
+	// validation is delegated to the backend (bunny.net API has nuanced
+	// validation rules per record type)
+	record, err := h.upstream(r.Context()).UpdateRecord(r.Context(), zoneID, recordID, &req)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -698,6 +1064,11 @@ func (h *Handler) HandleUpdateRecord(w http.ResponseWriter, r *http.Request) {
 
 	// Log the request
 	h.logger.Info("update record", "zone_id", zoneID, "record_id", recordID, "type", req.Type, "name", req.Name)
+	h.zones.invalidate(zoneID)
+	h.recordChange(r.Context(), zoneID, recordID, storage.RecordChangeUpdate, before, updatedRecord(record, &req), h.authorOf(r))
+	h.publish(r.Context(), webhook.EventRecordUpdated, map[string]any{
+		"zone_id": zoneID, "record_id": recordID, "record": req, "actor": tokenName(r),
+	})
 
 	// If record is nil (204 No Content from backend), return 204
 	if record == nil {
@@ -734,9 +1105,23 @@ func (h *Handler) HandleDeleteRecord(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid record ID")
 		return
 	}
+	if r.URL.Query().Has("scheduleAt") {
+		h.scheduleChange(w, r, &storage.ScheduledChange{ZoneID: zoneID, Action: storage.RecordChangeDelete, RecordID: recordID}, nil)
+		return
+	}
+
+	unlock, ok := h.checkIfMatch(w, r, zoneID, recordID)
+	if !ok {
+		return
+	}
+	defer unlock()
+	if !h.checkProtected(w, r, zoneID, recordID, nil) {
+		return
+	}
+	before := h.recordBefore(r.Context(), h.upstream(r.Context()), zoneID, recordID)
 
 	// Call client to delete record
-	err = h.client.DeleteRecord(r.Context(), zoneID, recordID)
+	err = h.upstream(r.Context()).DeleteRecord(r.Context(), zoneID, recordID)
 	if err != nil {
 		handleBunnyError(w, err)
 		return
@@ -744,7 +1129,78 @@ func (h *Handler) HandleDeleteRecord(w http.ResponseWriter, r *http.Request) {
 
 	// Log the request
 	h.logger.Info("delete record", "zone_id", zoneID, "record_id", recordID)
+	h.zones.invalidate(zoneID)
+	h.forgetRecordMeta(r.Context(), zoneID, recordID)
+	h.recordChange(r.Context(), zoneID, recordID, storage.RecordChangeDelete, before, nil, h.authorOf(r))
+	h.publish(r.Context(), webhook.EventRecordDeleted, map[string]any{
+		"zone_id": zoneID, "record_id": recordID, "actor": tokenName(r),
+	})
 
 	// Return 204 No Content
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// writeRawJSON writes an already-encoded JSON body with a 200 status.
+func writeRawJSON(w http.ResponseWriter, body json.RawMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	//nolint:errcheck
+	w.Write(body)
+}
+
+// HandleGetAccountStatistics passes through account-wide CDN statistics.
+// GET /statistics
+// Admin only — account data is outside the zone permission model.
+func (h *Handler) HandleGetAccountStatistics(w http.ResponseWriter, r *http.Request) {
+	result, err := h.upstream(r.Context()).GetStatistics(r.Context(), r.URL.Query())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("get account statistics", "token", tokenName(r), "query", r.URL.RawQuery)
+
+	writeRawJSON(w, result)
+}
+
+// HandleGetBilling passes through account billing details.
+// GET /billing
+// Admin only — account data is outside the zone permission model.
+func (h *Handler) HandleGetBilling(w http.ResponseWriter, r *http.Request) {
+	result, err := h.upstream(r.Context()).GetBilling(r.Context())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("get billing", "token", tokenName(r))
+
+	writeRawJSON(w, result)
+}
+
+// HandleGetBillingSummary passes through the account billing summary.
+// GET /billing/summary
+// Admin only — account data is outside the zone permission model.
+func (h *Handler) HandleGetBillingSummary(w http.ResponseWriter, r *http.Request) {
+	result, err := h.upstream(r.Context()).GetBillingSummary(r.Context())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("get billing summary", "token", tokenName(r))
+
+	writeRawJSON(w, result)
+}
+
+// tokenName returns the authenticated token name for audit logging.
+// Returns "master_key" for bootstrap master key requests.
+func tokenName(r *http.Request) string {
+	if token := auth.TokenFromContext(r.Context()); token != nil {
+		return token.Name
+	}
+	if auth.IsMasterKeyFromContext(r.Context()) {
+		return "master_key"
+	}
+	return ""
+}
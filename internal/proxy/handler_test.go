@@ -9,6 +9,8 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
@@ -22,6 +24,7 @@ type mockBunnyClient struct {
 	listZonesFunc             func(context.Context, *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error)
 	createZoneFunc            func(context.Context, string) (*bunny.Zone, error)
 	getZoneFunc               func(context.Context, int64) (*bunny.Zone, error)
+	findZoneByDomainFunc      func(context.Context, string) (*bunny.Zone, error)
 	deleteZoneFunc            func(context.Context, int64) error
 	updateZoneFunc            func(context.Context, int64, *bunny.UpdateZoneRequest) (*bunny.Zone, error)
 	addRecordFunc             func(context.Context, int64, *bunny.AddRecordRequest) (*bunny.Record, error)
@@ -36,6 +39,20 @@ type mockBunnyClient struct {
 	getZoneStatisticsFunc     func(context.Context, int64, string, string) (*bunny.ZoneStatisticsResponse, error)
 	triggerDNSScanFunc        func(context.Context, string) (*bunny.DNSScanResult, error)
 	getDNSScanResultFunc      func(context.Context, int64) (*bunny.DNSScanResult, error)
+	getStatisticsFunc         func(context.Context, url.Values) (json.RawMessage, error)
+	getBillingFunc            func(context.Context) (json.RawMessage, error)
+	getBillingSummaryFunc     func(context.Context) (json.RawMessage, error)
+	listPullZonesFunc         func(context.Context, url.Values) (json.RawMessage, error)
+	getPullZoneFunc           func(context.Context, int64, url.Values) (json.RawMessage, error)
+	addPullZoneFunc           func(context.Context, json.RawMessage) (json.RawMessage, error)
+	updatePullZoneFunc        func(context.Context, int64, json.RawMessage) (json.RawMessage, error)
+	deletePullZoneFunc        func(context.Context, int64) error
+	purgePullZoneCacheFunc    func(context.Context, int64, json.RawMessage) error
+	listStorageZonesFunc      func(context.Context, url.Values) (json.RawMessage, error)
+	getStorageZoneFunc        func(context.Context, int64) (json.RawMessage, error)
+	addStorageZoneFunc        func(context.Context, json.RawMessage) (json.RawMessage, error)
+	deleteStorageZoneFunc     func(context.Context, int64, url.Values) error
+	resetStorageZonePassFunc  func(context.Context, int64) error
 }
 
 func (m *mockBunnyClient) ListZones(ctx context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
@@ -59,6 +76,13 @@ func (m *mockBunnyClient) GetZone(ctx context.Context, id int64) (*bunny.Zone, e
 	return nil, nil
 }
 
+func (m *mockBunnyClient) FindZoneByDomain(ctx context.Context, hostname string) (*bunny.Zone, error) {
+	if m.findZoneByDomainFunc != nil {
+		return m.findZoneByDomainFunc(ctx, hostname)
+	}
+	return nil, bunny.ErrNotFound
+}
+
 func (m *mockBunnyClient) DeleteZone(ctx context.Context, id int64) error {
 	if m.deleteZoneFunc != nil {
 		return m.deleteZoneFunc(ctx, id)
@@ -115,6 +139,14 @@ func (m *mockBunnyClient) ExportRecords(ctx context.Context, zoneID int64) (stri
 	return "", nil
 }
 
+func (m *mockBunnyClient) StreamExportRecords(ctx context.Context, zoneID int64) (io.ReadCloser, error) {
+	content, err := m.ExportRecords(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
 func (m *mockBunnyClient) EnableDNSSEC(ctx context.Context, zoneID int64) (*bunny.DNSSECResponse, error) {
 	if m.enableDNSSECFunc != nil {
 		return m.enableDNSSECFunc(ctx, zoneID)
@@ -187,6 +219,104 @@ func (m *mockBunnyClient) GetDNSScanResult(ctx context.Context, zoneID int64) (*
 	return nil, nil
 }
 
+func (m *mockBunnyClient) GetStatistics(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	if m.getStatisticsFunc != nil {
+		return m.getStatisticsFunc(ctx, query)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) GetBilling(ctx context.Context) (json.RawMessage, error) {
+	if m.getBillingFunc != nil {
+		return m.getBillingFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) GetBillingSummary(ctx context.Context) (json.RawMessage, error) {
+	if m.getBillingSummaryFunc != nil {
+		return m.getBillingSummaryFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) ListPullZones(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	if m.listPullZonesFunc != nil {
+		return m.listPullZonesFunc(ctx, query)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) GetPullZone(ctx context.Context, id int64, query url.Values) (json.RawMessage, error) {
+	if m.getPullZoneFunc != nil {
+		return m.getPullZoneFunc(ctx, id, query)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) AddPullZone(ctx context.Context, body json.RawMessage) (json.RawMessage, error) {
+	if m.addPullZoneFunc != nil {
+		return m.addPullZoneFunc(ctx, body)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) UpdatePullZone(ctx context.Context, id int64, body json.RawMessage) (json.RawMessage, error) {
+	if m.updatePullZoneFunc != nil {
+		return m.updatePullZoneFunc(ctx, id, body)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) DeletePullZone(ctx context.Context, id int64) error {
+	if m.deletePullZoneFunc != nil {
+		return m.deletePullZoneFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *mockBunnyClient) PurgePullZoneCache(ctx context.Context, id int64, body json.RawMessage) error {
+	if m.purgePullZoneCacheFunc != nil {
+		return m.purgePullZoneCacheFunc(ctx, id, body)
+	}
+	return nil
+}
+
+func (m *mockBunnyClient) ListStorageZones(ctx context.Context, query url.Values) (json.RawMessage, error) {
+	if m.listStorageZonesFunc != nil {
+		return m.listStorageZonesFunc(ctx, query)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) GetStorageZone(ctx context.Context, id int64) (json.RawMessage, error) {
+	if m.getStorageZoneFunc != nil {
+		return m.getStorageZoneFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) AddStorageZone(ctx context.Context, body json.RawMessage) (json.RawMessage, error) {
+	if m.addStorageZoneFunc != nil {
+		return m.addStorageZoneFunc(ctx, body)
+	}
+	return nil, nil
+}
+
+func (m *mockBunnyClient) DeleteStorageZone(ctx context.Context, id int64, query url.Values) error {
+	if m.deleteStorageZoneFunc != nil {
+		return m.deleteStorageZoneFunc(ctx, id, query)
+	}
+	return nil
+}
+
+func (m *mockBunnyClient) ResetStorageZonePassword(ctx context.Context, id int64) error {
+	if m.resetStorageZonePassFunc != nil {
+		return m.resetStorageZonePassFunc(ctx, id)
+	}
+	return nil
+}
+
 func TestNewHandler_WithLogger(t *testing.T) {
 	t.Parallel()
 	logger := slog.New(slog.NewTextHandler(nil, nil))
@@ -341,6 +471,17 @@ func TestHandleBunnyError_Unauthorized(t *testing.T) {
 	}
 }
 
+// TestHandleBunnyError_CircuitOpen tests that an open circuit maps to 503
+func TestHandleBunnyError_CircuitOpen(t *testing.T) {
+	t.Parallel()
+	w := httptest.NewRecorder()
+	handleBunnyError(w, fmt.Errorf("%w: certificates endpoints are failing", bunny.ErrCircuitOpen))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
 // TestHandleBunnyError_GenericError tests generic error mapping
 func TestHandleBunnyError_GenericError(t *testing.T) {
 	t.Parallel()
@@ -707,6 +848,66 @@ func TestHandleGetZone_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandleFindZoneByName(t *testing.T) {
+	t.Parallel()
+	client := &mockBunnyClient{
+		findZoneByDomainFunc: func(ctx context.Context, hostname string) (*bunny.Zone, error) {
+			if strings.HasSuffix(hostname, "example.com") {
+				return &bunny.Zone{ID: 123, Domain: "Example.com"}, nil
+			}
+			return nil, bunny.ErrNotFound
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	permitted := &auth.KeyInfo{KeyID: 1, Permissions: []*storage.Permission{{ZoneID: 123, AllowedActions: []string{"read"}}}}
+	other := &auth.KeyInfo{KeyID: 2, Permissions: []*storage.Permission{{ZoneID: 456, AllowedActions: []string{"read"}}}}
+
+	tests := []struct {
+		name           string
+		domain         string
+		keyInfo        *auth.KeyInfo
+		wantStatus     int
+		wantRecordName string
+	}{
+		{"admin", "_acme-challenge.www.example.com.", nil, http.StatusOK, "_acme-challenge.www"},
+		{"apex", "example.com", permitted, http.StatusOK, ""},
+		{"permitted zone", "www.example.com", permitted, http.StatusOK, "www"},
+		{"zone without permission", "www.example.com", other, http.StatusNotFound, ""},
+		{"unknown zone", "www.example.net", nil, http.StatusNotFound, ""},
+		{"invalid domain", "a b", nil, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			params := map[string]string{"domain": tt.domain}
+			var r *http.Request
+			if tt.keyInfo != nil {
+				r = newTestRequestWithKeyInfo("/dnszone/byname/x", params, tt.keyInfo)
+			} else {
+				r = newTestRequest(http.MethodGet, "/dnszone/byname/x", nil, params)
+				r = r.WithContext(auth.WithAdmin(r.Context(), true))
+			}
+			w := httptest.NewRecorder()
+			handler.HandleFindZoneByName(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp ZoneLookupResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to unmarshal response: %v", err)
+			}
+			if resp.ZoneID != 123 || resp.Domain != "Example.com" || resp.RecordName != tt.wantRecordName {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
 // TestHandleDeleteZone_Success tests successful zone deletion
 func TestHandleDeleteZone_Success(t *testing.T) {
 	t.Parallel()
@@ -1838,6 +2039,59 @@ func TestHandleListRecords_FiltersRecordTypes(t *testing.T) {
 	}
 }
 
+func TestHandleListRecords_QueryFilters(t *testing.T) {
+	t.Parallel()
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: 123, Domain: "example.com", Records: []bunny.Record{
+				{ID: 1, Type: 3, Name: "_acme-challenge", Value: "token-a"}, // TXT
+				{ID: 2, Type: 3, Name: "_acme-challenge", Value: "token-b"}, // TXT
+				{ID: 3, Type: 0, Name: "www", Value: "192.0.2.1"},           // A
+				{ID: 4, Type: 3, Name: "", Value: "v=spf1 -all"},            // TXT
+			}}, nil
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		query   string
+		wantIDs []int64
+	}{
+		{"", []int64{1, 2, 3, 4}},
+		{"type=txt", []int64{1, 2, 4}},
+		{"type=TXT&name=_ACME-challenge.", []int64{1, 2}},
+		{"name=_acme-challenge&value=token-b", []int64{2}},
+		{"name=@", []int64{4}},
+		{"value=192.0.2.1", []int64{3}},
+		{"type=A&value=token-a", []int64{}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.HandleListRecords(w, newTestRequest(http.MethodGet, "/dnszone/123/records?"+tt.query, nil, map[string]string{"zoneID": "123"}))
+		if w.Code != http.StatusOK {
+			t.Errorf("%q: status %d: %s", tt.query, w.Code, w.Body.String())
+			continue
+		}
+		var records []bunny.Record
+		if err := json.Unmarshal(w.Body.Bytes(), &records); err != nil {
+			t.Fatalf("%q: failed to unmarshal response: %v", tt.query, err)
+		}
+		ids := make([]int64, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+			t.Errorf("%q: got records %v, want %v", tt.query, ids, tt.wantIDs)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.HandleListRecords(w, newTestRequest(http.MethodGet, "/dnszone/123/records?type=BOGUS", nil, map[string]string{"zoneID": "123"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown type: status %d, want 400", w.Code)
+	}
+}
+
 // TestHandleListRecords_EmptyAfterFilter tests filtering that results in empty records.
 func TestHandleListRecords_EmptyAfterFilter(t *testing.T) {
 	t.Parallel()
@@ -1936,6 +2190,64 @@ func TestHandleListRecords_MissingZoneID(t *testing.T) {
 	}
 }
 
+func TestHandleGetRecord(t *testing.T) {
+	t.Parallel()
+	client := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			if id != 123 {
+				return nil, bunny.ErrNotFound
+			}
+			return &bunny.Zone{ID: 123, Domain: "example.com", Records: []bunny.Record{
+				{ID: 1, Type: 3, Name: "_acme-challenge", Value: "token"}, // TXT
+				{ID: 2, Type: 0, Name: "www", Value: "192.0.2.1"},         // A
+			}}, nil
+		},
+	}
+	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	txtOnly := &auth.KeyInfo{KeyID: 1, KeyName: "acme", Permissions: []*storage.Permission{
+		{ID: 1, TokenID: 1, ZoneID: 123, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+	}}
+
+	tests := []struct {
+		name       string
+		zoneID     string
+		recordID   string
+		keyInfo    *auth.KeyInfo
+		wantStatus int
+	}{
+		{"found", "123", "2", nil, http.StatusOK},
+		{"permitted type", "123", "1", txtOnly, http.StatusOK},
+		{"type not permitted", "123", "2", txtOnly, http.StatusNotFound},
+		{"no such record", "123", "9", nil, http.StatusNotFound},
+		{"no such zone", "999", "1", nil, http.StatusNotFound},
+		{"invalid record ID", "123", "abc", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		params := map[string]string{"zoneID": tt.zoneID, "recordID": tt.recordID}
+		path := "/dnszone/" + tt.zoneID + "/records/" + tt.recordID
+		r := newTestRequest(http.MethodGet, path, nil, params)
+		if tt.keyInfo != nil {
+			r = newTestRequestWithKeyInfo(path, params, tt.keyInfo)
+		}
+		w := httptest.NewRecorder()
+		handler.HandleGetRecord(w, r)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.wantStatus, w.Body.String())
+			continue
+		}
+		if w.Code == http.StatusOK {
+			var record bunny.Record
+			if err := json.Unmarshal(w.Body.Bytes(), &record); err != nil {
+				t.Fatalf("%s: failed to unmarshal response: %v", tt.name, err)
+			}
+			if fmt.Sprint(record.ID) != tt.recordID {
+				t.Errorf("%s: got record %d, want %s", tt.name, record.ID, tt.recordID)
+			}
+		}
+	}
+}
+
 // TestHandleAddRecord_MissingZoneID tests handling of missing zone ID parameter
 func TestHandleAddRecord_MissingZoneID(t *testing.T) {
 	t.Parallel()
@@ -2123,101 +2435,6 @@ func TestHandleCheckAvailability_ClientError(t *testing.T) {
 	}
 }
 
-// TestHandleImportRecords_Success tests successful record import
-func TestHandleImportRecords_Success(t *testing.T) {
-	t.Parallel()
-	client := &mockBunnyClient{
-		importRecordsFunc: func(ctx context.Context, zoneID int64, body io.Reader, contentType string) (*bunny.ImportRecordsResponse, error) {
-			if zoneID != 123 {
-				t.Errorf("expected zoneID 123, got %d", zoneID)
-			}
-			return &bunny.ImportRecordsResponse{
-				TotalRecordsParsed: 4,
-				Created:            3,
-				Failed:             1,
-				Skipped:            0,
-			}, nil
-		},
-	}
-
-	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
-	w := httptest.NewRecorder()
-	body := bytes.NewBufferString("example.com. 300 IN A 1.2.3.4\nexample.com. 300 IN TXT \"test\"")
-	r := newTestRequest(http.MethodPost, "/dnszone/123/import", body, map[string]string{"zoneID": "123"})
-
-	handler.HandleImportRecords(w, r)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
-	}
-
-	var result bunny.ImportRecordsResponse
-	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
-		t.Fatalf("failed to unmarshal response: %v", err)
-	}
-
-	if result.Created != 3 {
-		t.Errorf("expected 3 created records, got %d", result.Created)
-	}
-}
-
-// TestHandleImportRecords_InvalidZoneID tests invalid zone ID
-func TestHandleImportRecords_InvalidZoneID(t *testing.T) {
-	t.Parallel()
-	client := &mockBunnyClient{}
-	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
-	w := httptest.NewRecorder()
-	r := newTestRequest(http.MethodPost, "/dnszone/abc/import", nil, map[string]string{"zoneID": "abc"})
-
-	handler.HandleImportRecords(w, r)
-
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
-	}
-}
-
-// TestHandleImportRecords_NotFound tests zone not found
-func TestHandleImportRecords_NotFound(t *testing.T) {
-	t.Parallel()
-	client := &mockBunnyClient{
-		importRecordsFunc: func(ctx context.Context, zoneID int64, body io.Reader, contentType string) (*bunny.ImportRecordsResponse, error) {
-			return nil, bunny.ErrNotFound
-		},
-	}
-
-	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
-	w := httptest.NewRecorder()
-	body := bytes.NewBufferString("example.com. 300 IN A 1.2.3.4")
-	r := newTestRequest(http.MethodPost, "/dnszone/999/import", body, map[string]string{"zoneID": "999"})
-
-	handler.HandleImportRecords(w, r)
-
-	if w.Code != http.StatusNotFound {
-		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
-	}
-}
-
-// TestHandleImportRecords_ClientError tests client error handling
-func TestHandleImportRecords_ClientError(t *testing.T) {
-	t.Parallel()
-	client := &mockBunnyClient{
-		importRecordsFunc: func(ctx context.Context, zoneID int64, body io.Reader, contentType string) (*bunny.ImportRecordsResponse, error) {
-			return nil, fmt.Errorf("network error")
-		},
-	}
-
-	handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
-	w := httptest.NewRecorder()
-	body := bytes.NewBufferString("example.com. 300 IN A 1.2.3.4")
-	r := newTestRequest(http.MethodPost, "/dnszone/123/import", body, map[string]string{"zoneID": "123"})
-
-	handler.HandleImportRecords(w, r)
-
-	if w.Code != http.StatusInternalServerError {
-		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
-	}
-}
-
 func TestHandleExportRecords_Success(t *testing.T) {
 	t.Parallel()
 	mockClient := &mockBunnyClient{
@@ -2249,6 +2466,29 @@ func TestHandleExportRecords_Success(t *testing.T) {
 	}
 }
 
+func TestHandleExportRecords_FiltersRecordTypes(t *testing.T) {
+	t.Parallel()
+	mockClient := &mockBunnyClient{
+		exportRecordsFunc: func(context.Context, int64) (string, error) {
+			return ";; Zone: example.com\n@ 300 IN A 192.168.1.1\n_acme-challenge 60 IN TXT \"token\"\n", nil
+		},
+	}
+	handler := NewHandler(mockClient, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	keyInfo := &auth.KeyInfo{KeyID: 1, KeyName: "acme", Permissions: []*storage.Permission{
+		{ID: 1, TokenID: 1, ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"txt"}},
+	}}
+
+	w := httptest.NewRecorder()
+	handler.HandleExportRecords(w, newTestRequestWithKeyInfo("/dnszone/1/export", map[string]string{"zoneID": "1"}, keyInfo))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if body := w.Body.String(); body != "_acme-challenge 60 IN TXT \"token\"\n" {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
 func TestHandleExportRecords_InvalidZoneID(t *testing.T) {
 	t.Parallel()
 	handler := NewHandler(&mockBunnyClient{}, slog.Default())
@@ -2307,6 +2547,77 @@ func TestHandleExportRecords_BunnyError(t *testing.T) {
 	}
 }
 
+func TestHandleExportRecords_StructuredFormats(t *testing.T) {
+	t.Parallel()
+	mockClient := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: id, Domain: "example.com", Records: []bunny.Record{
+				{ID: 1, Type: 0, Name: "", Value: "192.0.2.1", TTL: 300},
+				{ID: 2, Type: 3, Name: "_acme-challenge", Value: `say "hi", twice`, TTL: 60, Comment: "acme"},
+				{ID: 3, Type: 4, Name: "", Value: "mail.example.com", TTL: 3600, Priority: 10},
+			}}, nil
+		},
+		exportRecordsFunc: func(context.Context, int64) (string, error) {
+			return "@ 300 IN A 192.0.2.1\n_acme-challenge 60 IN TXT \"token\"\n", nil
+		},
+	}
+	handler := NewHandler(mockClient, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	export := func(query string, keyInfo *auth.KeyInfo) *httptest.ResponseRecorder {
+		r := newTestRequest(http.MethodGet, "/dnszone/1/export?"+query, nil, map[string]string{"zoneID": "1"})
+		if keyInfo != nil {
+			r = newTestRequestWithKeyInfo("/dnszone/1/export?"+query, map[string]string{"zoneID": "1"}, keyInfo)
+		}
+		w := httptest.NewRecorder()
+		handler.HandleExportRecords(w, r)
+		return w
+	}
+
+	w := export("format=json", nil)
+	var exported RecordExport
+	if err := json.NewDecoder(w.Body).Decode(&exported); err != nil {
+		t.Fatalf("failed to decode JSON export: %v", err)
+	}
+	if exported.ZoneID != 1 || exported.Domain != "example.com" || len(exported.Records) != 3 {
+		t.Errorf("unexpected JSON export: %+v", exported)
+	}
+
+	w = export("format=csv&type=mx", nil)
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+	want := "type,name,value,ttl,priority,weight,port,flags,tag,disabled,comment\nMX,@,mail.example.com,3600,10,0,0,0,,false,\n"
+	if w.Body.String() != want {
+		t.Errorf("unexpected CSV export:\n%s", w.Body.String())
+	}
+
+	// A CSV export can be imported again
+	txtOnly := &auth.KeyInfo{KeyID: 1, KeyName: "acme", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+	}}
+	w = export("format=csv", txtOnly)
+	entries, err := parseCSVImport(w.Body.String())
+	if err != nil || len(entries) != 1 || entries[0].record == nil || entries[0].record.Value != `say "hi", twice` {
+		t.Errorf("unexpected re-imported records %+v, %v from:\n%s", entries, err, w.Body.String())
+	}
+
+	// A scoped token asking for a type it may not read gets nothing
+	w = export("format=json&type=A", txtOnly)
+	if err := json.NewDecoder(w.Body).Decode(&exported); err != nil || len(exported.Records) != 0 {
+		t.Errorf("expected no records, got %+v, %v", exported.Records, err)
+	}
+
+	// The type filter applies to zone files too
+	if w := export("type=TXT", nil); w.Body.String() != "_acme-challenge 60 IN TXT \"token\"\n" {
+		t.Errorf("unexpected zone file: %q", w.Body.String())
+	}
+
+	for _, query := range []string{"format=yaml", "type=BOGUS"} {
+		if w := export(query, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
 func TestHandleEnableDNSSEC_Success(t *testing.T) {
 	t.Parallel()
 	mockClient := &mockBunnyClient{
@@ -2865,3 +3176,78 @@ func TestHandleGetScanResult_BunnyError(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
 	}
 }
+
+func TestHandleGetAccountStatistics_Success(t *testing.T) {
+	t.Parallel()
+	var gotQuery url.Values
+	mockClient := &mockBunnyClient{
+		getStatisticsFunc: func(_ context.Context, query url.Values) (json.RawMessage, error) {
+			gotQuery = query
+			return json.RawMessage(`{"TotalBandwidthUsed":42}`), nil
+		},
+	}
+	handler := NewHandler(mockClient, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/statistics?dateFrom=2025-01-01&pullZone=7", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetAccountStatistics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected Content-Type application/json, got %s", ct)
+	}
+	if w.Body.String() != `{"TotalBandwidthUsed":42}` {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+	if gotQuery.Get("dateFrom") != "2025-01-01" || gotQuery.Get("pullZone") != "7" {
+		t.Errorf("query not forwarded: %v", gotQuery)
+	}
+}
+
+func TestHandleGetAccountStatistics_BunnyError(t *testing.T) {
+	t.Parallel()
+	mockClient := &mockBunnyClient{
+		getStatisticsFunc: func(_ context.Context, _ url.Values) (json.RawMessage, error) {
+			return nil, bunny.ErrUnauthorized
+		},
+	}
+	handler := NewHandler(mockClient, slog.Default())
+
+	req := httptest.NewRequest(http.MethodGet, "/statistics", nil)
+	w := httptest.NewRecorder()
+	handler.HandleGetAccountStatistics(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status %d, got %d", http.StatusBadGateway, w.Code)
+	}
+}
+
+func TestHandleGetBilling(t *testing.T) {
+	t.Parallel()
+	mockClient := &mockBunnyClient{
+		getBillingFunc: func(_ context.Context) (json.RawMessage, error) {
+			return json.RawMessage(`{"Balance":10.5}`), nil
+		},
+		getBillingSummaryFunc: func(_ context.Context) (json.RawMessage, error) {
+			return nil, fmt.Errorf("connection failed")
+		},
+	}
+	handler := NewHandler(mockClient, slog.Default())
+
+	w := httptest.NewRecorder()
+	handler.HandleGetBilling(w, httptest.NewRequest(http.MethodGet, "/billing", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("billing: expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Body.String() != `{"Balance":10.5}` {
+		t.Errorf("billing: unexpected body: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.HandleGetBillingSummary(w, httptest.NewRequest(http.MethodGet, "/billing/summary", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("summary: expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// RecordHistoryStore persists snapshots of record mutations.
+type RecordHistoryStore interface {
+	AddRecordChange(ctx context.Context, c *storage.RecordChange) (*storage.RecordChange, error)
+	GetRecordChange(ctx context.Context, id int64) (*storage.RecordChange, error)
+}
+
+// SetRecordHistoryStore enables record change history. Updates and deletes
+// then fetch the record before changing it, to keep a snapshot of it.
+func (h *Handler) SetRecordHistoryStore(store RecordHistoryStore) {
+	h.history = store
+}
+
+// changeAuthor identifies who made a record change, and in which account.
+type changeAuthor struct {
+	name    string // token name
+	account string // upstream account, "" for the default one
+}
+
+// authorOf returns the author of the changes r makes.
+func (h *Handler) authorOf(r *http.Request) changeAuthor {
+	return changeAuthor{name: tokenName(r), account: h.accountOf(r.Context())}
+}
+
+// recordBefore returns a record as it is before an update or delete through
+// client, or nil if history is disabled or the record cannot be fetched.
+func (h *Handler) recordBefore(ctx context.Context, client BunnyClient, zoneID, recordID int64) *bunny.Record {
+	if h.history == nil {
+		return nil
+	}
+	zone, err := uncached(client).GetZone(ctx, zoneID)
+	if err != nil {
+		h.logger.Warn("failed to fetch record for history", "zone_id", zoneID, "record_id", recordID, "error", err)
+		return nil
+	}
+	i := slices.IndexFunc(zone.Records, func(rec bunny.Record) bool { return rec.ID == recordID })
+	if i < 0 {
+		return nil
+	}
+	return &zone.Records[i]
+}
+
+// recordChange stores a snapshot of a record mutation if history is enabled.
+// before and after are a *bunny.Record or *bunny.AddRecordRequest, or nil
+// when there is no record. Failures are logged and never fail the request:
+// the change has already been applied upstream.
+func (h *Handler) recordChange(ctx context.Context, zoneID, recordID int64, action string, before, after any, author changeAuthor) {
+	if h.history == nil {
+		return
+	}
+	if _, err := h.saveRecordChange(ctx, zoneID, recordID, action, before, after, author); err != nil {
+		h.logger.Error("failed to record change", "zone_id", zoneID, "record_id", recordID, "action", action, "error", err)
+	}
+}
+
+// saveRecordChange encodes and stores a record change.
+func (h *Handler) saveRecordChange(ctx context.Context, zoneID, recordID int64, action string, before, after any, author changeAuthor) (*storage.RecordChange, error) {
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+	return h.history.AddRecordChange(context.WithoutCancel(ctx), &storage.RecordChange{
+		ZoneID: zoneID, RecordID: recordID, Action: action, Before: beforeJSON, After: afterJSON,
+		Actor: author.name, Account: author.account,
+	})
+}
+
+// snapshot encodes a record for the change history; nil records encode to nil.
+func snapshot(v any) ([]byte, error) {
+	switch rec := v.(type) {
+	case nil:
+		return nil, nil
+	case *bunny.Record:
+		if rec == nil {
+			return nil, nil
+		}
+	case *bunny.AddRecordRequest:
+		if rec == nil {
+			return nil, nil
+		}
+	}
+	return json.Marshal(v)
+}
+
+// updatedRecord returns the record bunny.net returned for an update, or the
+// request when it returned none.
+func updatedRecord(record *bunny.Record, req *bunny.AddRecordRequest) any {
+	if record != nil {
+		return record
+	}
+	return req
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeHistory collects record changes in memory.
+type fakeHistory struct {
+	mu      sync.Mutex
+	changes []*storage.RecordChange
+}
+
+func (f *fakeHistory) AddRecordChange(_ context.Context, c *storage.RecordChange) (*storage.RecordChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c.ID = int64(len(f.changes) + 1)
+	f.changes = append(f.changes, c)
+	return c, nil
+}
+
+func (f *fakeHistory) GetRecordChange(_ context.Context, id int64) (*storage.RecordChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id < 1 || id > int64(len(f.changes)) {
+		return nil, storage.ErrNotFound
+	}
+	return f.changes[id-1], nil
+}
+
+func TestRecordHistory(t *testing.T) {
+	t.Parallel()
+	records := []bunny.Record{{ID: 7, Type: 3, Name: "_acme", Value: "old"}}
+	mockClient := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: id, Records: records}, nil
+		},
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			return &bunny.Record{ID: 8, Type: req.Type, Name: req.Name, Value: req.Value}, nil
+		},
+		updateRecordFunc: func(context.Context, int64, int64, *bunny.AddRecordRequest) (*bunny.Record, error) {
+			return nil, nil // bunny.net answers updates with 204 No Content
+		},
+		deleteRecordFunc: func(context.Context, int64, int64) error { return nil },
+	}
+	history := &fakeHistory{}
+	handler := NewHandler(mockClient, slog.Default())
+	handler.SetRecordHistoryStore(history)
+
+	r := chi.NewRouter()
+	r.Post("/dnszone/{zoneID}/records", handler.HandleAddRecord)
+	r.Post("/dnszone/{zoneID}/records/{recordID}", handler.HandleUpdateRecord)
+	r.Delete("/dnszone/{zoneID}/records/{recordID}", handler.HandleDeleteRecord)
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPost, "/dnszone/1/records", `{"Type":3,"Name":"www","Value":"new"}`},
+		{http.MethodPost, "/dnszone/1/records/7", `{"Type":3,"Name":"_acme","Value":"updated"}`},
+		{http.MethodDelete, "/dnszone/1/records/7", ""},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(req.method, req.path, bytes.NewBufferString(req.body)))
+		if w.Code >= 300 {
+			t.Fatalf("%s %s failed with %d: %s", req.method, req.path, w.Code, w.Body.String())
+		}
+	}
+
+	if len(history.changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(history.changes))
+	}
+	created, updated, deleted := history.changes[0], history.changes[1], history.changes[2]
+	if created.Action != storage.RecordChangeCreate || created.RecordID != 8 || created.Before != nil ||
+		!bytes.Contains(created.After, []byte(`"Value":"new"`)) {
+		t.Errorf("unexpected create: %+v", created)
+	}
+	if updated.Action != storage.RecordChangeUpdate || updated.RecordID != 7 ||
+		!bytes.Contains(updated.Before, []byte(`"Value":"old"`)) || !bytes.Contains(updated.After, []byte(`"Value":"updated"`)) {
+		t.Errorf("unexpected update: %s -> %s", updated.Before, updated.After)
+	}
+	if deleted.Action != storage.RecordChangeDelete || deleted.After != nil || !bytes.Contains(deleted.Before, []byte(`"Value":"old"`)) {
+		t.Errorf("unexpected delete: %+v", deleted)
+	}
+}
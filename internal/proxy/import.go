@@ -0,0 +1,253 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/jobs"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// Import line statuses.
+const (
+	ImportCreated = "created" // the record was created
+	ImportFailed  = "failed"  // bunny.net rejected the record
+	ImportSkipped = "skipped" // the line is a record bunny.net manages itself
+	ImportInvalid = "invalid" // the line could not be parsed or validated
+	ImportDenied  = "denied"  // the key may not create the record
+	ImportValid   = "valid"   // the record was not created because other lines were rejected
+)
+
+// ImportLineResult is the outcome of one record line of an import file.
+type ImportLineResult struct {
+	Line     int    `json:"Line"`
+	Status   string `json:"Status"`
+	Type     string `json:"Type,omitempty"`
+	Name     string `json:"Name,omitempty"`
+	RecordID int64  `json:"RecordId,omitempty"`
+	Error    string `json:"Error,omitempty"`
+}
+
+// ImportRecordsResponse is the response body for POST /dnszone/{zoneID}/import.
+// The counts match bunny.net's import response; Results has one entry per
+// record line, in file order.
+type ImportRecordsResponse struct {
+	TotalRecordsParsed int                `json:"TotalRecordsParsed"`
+	Created            int                `json:"Created"`
+	Failed             int                `json:"Failed"`
+	Skipped            int                `json:"Skipped"`
+	Results            []ImportLineResult `json:"Results"`
+	Error              string             `json:"error,omitempty"`
+}
+
+// HandleImportRecords imports DNS records from a BIND zone file or CSV.
+// POST /dnszone/{zoneID}/import
+//
+// The file is parsed by the proxy, not passed through: Content-Type text/csv
+// is read as CSV and anything else as a BIND zone file. Every record is
+// checked as POST /dnszone/{zoneID}/records would be, including the key's
+// record types. If any line is invalid or denied, nothing is imported.
+// Otherwise the records are created one by one; records bunny.net rejects are
+// reported as failed without stopping the import.
+//
+// With ?async=true, the file is checked as usual but the records are created
+// by a background job: the response is 202 Accepted with the job, and
+// GET /jobs/{jobID} reports its progress and, once finished, the import
+// response.
+func (h *Handler) HandleImportRecords(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	async := false
+	if s := r.URL.Query().Get("async"); s != "" {
+		if async, err = strconv.ParseBool(s); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid async parameter")
+			return
+		}
+	}
+	if async && h.jobs == nil {
+		writeError(w, http.StatusBadRequest, "asynchronous imports are not enabled")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		metrics.RecordRequestLimitRejection(limitBody)
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("zone file is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	zone, err := h.upstream(ctx).GetZone(ctx, zoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	format := "bind"
+	var entries []importEntry
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		format = "csv"
+		entries, err = parseCSVImport(string(body))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else {
+		entries = parseBINDImport(string(body), zone.Domain)
+	}
+	if len(entries) == 0 {
+		writeError(w, http.StatusBadRequest, "no records found")
+		return
+	}
+
+	keyInfo := auth.GetKeyInfo(ctx)
+	scoped := keyInfo != nil && !auth.IsAdminFromContext(ctx)
+	protections, err := h.protections(ctx, zoneID)
+	if err != nil {
+		h.logger.Error("failed to list protected records", "zone_id", zoneID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to check protected records")
+		return
+	}
+
+	resp := ImportRecordsResponse{Results: make([]ImportLineResult, 0, len(entries))}
+	var invalid, denied int
+	for _, e := range entries {
+		res := ImportLineResult{Line: e.line, Status: e.status, Error: e.message}
+		if e.record != nil {
+			resp.TotalRecordsParsed++
+			res.Status, res.Type, res.Name = ImportValid, auth.MapRecordTypeToString(e.record.Type), e.record.Name
+			req := &auth.Request{Action: auth.ActionAddRecord, ZoneID: zoneID, RecordType: res.Type}
+			h.applyTTLPolicy(zoneID, e.record, false)
+			if errs := h.checkRecord(e.record, false); len(errs) > 0 {
+				res.Status, res.Error = ImportInvalid, errs[0].Field+": "+errs[0].Message
+			} else if scoped && auth.CheckPermission(keyInfo, req) != nil {
+				res.Status, res.Error = ImportDenied, "permission denied"
+			} else if p := protecting(protections, requestedRecord(e.record, e.record.Type)); p != nil {
+				res.Status, res.Error = ImportDenied, protectedMessage(p)
+			}
+		}
+		switch res.Status {
+		case ImportInvalid:
+			invalid++
+		case ImportDenied:
+			denied++
+		case ImportSkipped:
+			resp.Skipped++
+		}
+		resp.Results = append(resp.Results, res)
+	}
+
+	limit := h.importMaxRecords
+	if async {
+		limit = h.importMaxAsyncRecords
+	}
+	if resp.TotalRecordsParsed > limit {
+		metrics.RecordRequestLimitRejection(limitImportRecords)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("the file has %d records; at most %d can be imported at once", resp.TotalRecordsParsed, limit))
+		return
+	}
+	if invalid > 0 {
+		resp.Error = fmt.Sprintf("%d invalid lines; nothing was imported", invalid)
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+	if denied > 0 {
+		resp.Error = fmt.Sprintf("permission denied for %d records; nothing was imported", denied)
+		writeJSON(w, http.StatusForbidden, resp)
+		return
+	}
+
+	client, author := h.upstream(ctx), h.authorOf(r)
+	if async {
+		job, err := h.jobs.Submit(ctx, &storage.Job{
+			Kind:    storage.JobKindImport,
+			ZoneID:  zoneID,
+			TokenID: jobTokenID(r),
+			Total:   resp.TotalRecordsParsed,
+		}, func(ctx context.Context, progress func(int)) (any, error) {
+			err := h.createImportedRecords(ctx, client, zoneID, entries, &resp, author, format, progress)
+			return resp, err
+		})
+		if errors.Is(err, jobs.ErrQueueFull) {
+			writeError(w, http.StatusServiceUnavailable, "too many imports are waiting; retry later")
+			return
+		}
+		if err != nil {
+			h.logger.Error("failed to start import job", "zone_id", zoneID, "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to start import job")
+			return
+		}
+		h.logger.Info("import records started", "zone_id", zoneID, "format", format, "job_id", job.ID,
+			"records", resp.TotalRecordsParsed, "token", author.name)
+		w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.ID))
+		writeJSON(w, http.StatusAccepted, newJobResponse(job))
+		return
+	}
+
+	// The request context is only cancelled when the client has gone away
+	_ = h.createImportedRecords(ctx, client, zoneID, entries, &resp, author, format, func(int) {})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// createImportedRecords creates the records of an import in file order,
+// updating resp and calling progress with the number of records handled.
+// Records bunny.net rejects are marked failed. If ctx is cancelled, the
+// remaining records are left as valid and ctx's error is returned.
+func (h *Handler) createImportedRecords(ctx context.Context, client BunnyClient, zoneID int64, entries []importEntry,
+	resp *ImportRecordsResponse, author changeAuthor, format string, progress func(int)) error {
+	handled := 0
+	for i, e := range entries {
+		if e.record == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			resp.Error = "import interrupted; the remaining records were not created"
+			break
+		}
+		res := &resp.Results[i]
+		record, err := client.AddRecord(ctx, zoneID, e.record)
+		handled++
+		if err != nil {
+			_, message := bunnyErrorStatus(err)
+			res.Status, res.Error = ImportFailed, message
+			resp.Failed++
+			progress(handled)
+			continue
+		}
+		res.Status = ImportCreated
+		resp.Created++
+		if record != nil {
+			res.RecordID = record.ID
+			h.recordChange(ctx, zoneID, record.ID, storage.RecordChangeCreate, nil, record, author)
+		}
+		h.publish(ctx, webhook.EventRecordCreated, map[string]any{
+			"zone_id": zoneID, "record": record, "actor": author.name,
+		})
+		progress(handled)
+	}
+
+	h.logger.Info("import records", "zone_id", zoneID, "format", format, "created", resp.Created,
+		"failed", resp.Failed, "skipped", resp.Skipped, "token", author.name)
+	if resp.Created > 0 {
+		h.zones.invalidate(zoneID)
+	}
+	return ctx.Err()
+}
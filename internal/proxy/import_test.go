@@ -0,0 +1,220 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// newImportRequest creates an import request for zone 123, scoped to keyInfo if non-nil.
+func newImportRequest(body, contentType string, keyInfo *auth.KeyInfo) *http.Request {
+	params := map[string]string{"zoneID": "123"}
+	var r *http.Request
+	if keyInfo == nil {
+		r = newTestRequest(http.MethodPost, "/dnszone/123/import", strings.NewReader(body), params)
+	} else {
+		r = newTestRequestWithKeyInfo("/dnszone/123/import", params, keyInfo)
+		r.Method = http.MethodPost
+		r.Body = io.NopCloser(strings.NewReader(body))
+	}
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
+
+func importClient(t *testing.T, added *[]bunny.AddRecordRequest) *mockBunnyClient {
+	return &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: 123, Domain: "example.com"}, nil
+		},
+		addRecordFunc: func(_ context.Context, zoneID int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			if added == nil {
+				t.Error("no record should be created")
+				return nil, nil
+			}
+			if req.Value == "reject" {
+				return nil, &bunny.APIError{StatusCode: http.StatusBadRequest, Message: "invalid record"}
+			}
+			*added = append(*added, *req)
+			return &bunny.Record{ID: int64(100 + len(*added)), Type: req.Type, Name: req.Name, Value: req.Value}, nil
+		},
+	}
+}
+
+func decodeImportResponse(t *testing.T, w *httptest.ResponseRecorder) ImportRecordsResponse {
+	t.Helper()
+	var resp ImportRecordsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp
+}
+
+func TestHandleImportRecords_BIND(t *testing.T) {
+	t.Parallel()
+
+	var added []bunny.AddRecordRequest
+	handler := NewHandler(importClient(t, &added), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w := httptest.NewRecorder()
+
+	body := "$TTL 300\n@ IN SOA ns1.bunny.net. admin.example.com. 1 3600 600 604800 300\nwww IN A 192.0.2.1\n@ TXT \"reject\"\n"
+	handler.HandleImportRecords(w, newImportRequest(body, "text/plain", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	resp := decodeImportResponse(t, w)
+	if resp.TotalRecordsParsed != 2 || resp.Created != 1 || resp.Failed != 1 || resp.Skipped != 1 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+	if len(added) != 1 || added[0].Name != "www" || added[0].Value != "192.0.2.1" {
+		t.Errorf("unexpected records created: %+v", added)
+	}
+
+	want := []ImportLineResult{
+		{Line: 2, Status: ImportSkipped, Error: "SOA records are managed by bunny.net"},
+		{Line: 3, Status: ImportCreated, Type: "A", Name: "www", RecordID: 101},
+		{Line: 4, Status: ImportFailed, Type: "TXT", Name: "@", Error: "invalid record"},
+	}
+	if fmt.Sprint(resp.Results) != fmt.Sprint(want) {
+		t.Errorf("results = %+v, want %+v", resp.Results, want)
+	}
+}
+
+func TestHandleImportRecords_CSV(t *testing.T) {
+	t.Parallel()
+
+	var added []bunny.AddRecordRequest
+	handler := NewHandler(importClient(t, &added), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w := httptest.NewRecorder()
+
+	body := "type,name,value,ttl\nTXT,_acme-challenge,token,60\nA,@,192.0.2.1,\n"
+	handler.HandleImportRecords(w, newImportRequest(body, "text/csv; charset=utf-8", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if resp := decodeImportResponse(t, w); resp.Created != 2 {
+		t.Errorf("expected 2 created records, got %+v", resp)
+	}
+	if len(added) != 2 || added[0].TTL != 60 || added[1].TTL != defaultImportTTL {
+		t.Errorf("unexpected records created: %+v", added)
+	}
+}
+
+func TestHandleImportRecords_RejectsWholeFile(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(importClient(t, nil), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	txtOnly := &auth.KeyInfo{KeyID: 1, KeyName: "acme", Permissions: []*storage.Permission{
+		{ZoneID: 123, AllowedActions: []string{"add_record"}, RecordTypes: []string{"TXT"}},
+	}}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		keyInfo     *auth.KeyInfo
+		wantStatus  int
+		wantLine    ImportLineResult
+	}{
+		{
+			name:        "invalid line",
+			body:        "www 300 IN A 192.0.2.1\nbad 300 IN A not-an-ip\n",
+			contentType: "text/dns",
+			wantStatus:  http.StatusBadRequest,
+			wantLine:    ImportLineResult{Line: 2, Status: ImportInvalid, Error: `A record: invalid IPv4 address "not-an-ip"`},
+		},
+		{
+			name:        "denied record type",
+			body:        "type,name,value\nTXT,_acme-challenge,token\nA,www,192.0.2.1\n",
+			contentType: "text/csv",
+			keyInfo:     txtOnly,
+			wantStatus:  http.StatusForbidden,
+			wantLine:    ImportLineResult{Line: 3, Status: ImportDenied, Type: "A", Name: "www", Error: "permission denied"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			handler.HandleImportRecords(w, newImportRequest(tt.body, tt.contentType, tt.keyInfo))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+			resp := decodeImportResponse(t, w)
+			if resp.Created != 0 || !strings.Contains(resp.Error, "nothing was imported") {
+				t.Errorf("unexpected response: %+v", resp)
+			}
+			if len(resp.Results) != 2 || resp.Results[0].Status != ImportValid || resp.Results[1] != tt.wantLine {
+				t.Errorf("unexpected results: %+v", resp.Results)
+			}
+		})
+	}
+}
+
+func TestHandleImportRecords_InvalidRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		zoneID      string
+		body        string
+		contentType string
+		getZoneErr  error
+		wantStatus  int
+	}{
+		{"invalid zone ID", "abc", "www 300 IN A 192.0.2.1", "", nil, http.StatusBadRequest},
+		{"zone not found", "123", "www 300 IN A 192.0.2.1", "", bunny.ErrNotFound, http.StatusNotFound},
+		{"client error", "123", "www 300 IN A 192.0.2.1", "", fmt.Errorf("network error"), http.StatusInternalServerError},
+		{"empty file", "123", "; nothing here\n", "", nil, http.StatusBadRequest},
+		{"bad CSV header", "123", "kind,name,value\n", "text/csv", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client := &mockBunnyClient{
+				getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) {
+					if tt.getZoneErr != nil {
+						return nil, tt.getZoneErr
+					}
+					return &bunny.Zone{ID: 123, Domain: "example.com"}, nil
+				},
+			}
+			handler := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			w := httptest.NewRecorder()
+			r := newTestRequest(http.MethodPost, "/dnszone/"+tt.zoneID+"/import", strings.NewReader(tt.body), map[string]string{"zoneID": tt.zoneID})
+			r.Header.Set("Content-Type", tt.contentType)
+
+			handler.HandleImportRecords(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleImportRecords_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(importClient(t, nil), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler.SetRequestLimits(defaultMaxBodySize, 16, 0)
+	w := httptest.NewRecorder()
+	handler.limitRequest(http.HandlerFunc(handler.HandleImportRecords)).
+		ServeHTTP(w, newImportRequest("www 300 IN A 192.0.2.1\n", "text/plain", nil))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+}
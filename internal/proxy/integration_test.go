@@ -10,6 +10,8 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -336,8 +338,8 @@ func TestIntegration_CheckAvailability_ExistingZone(t *testing.T) {
 	}
 }
 
-// TestIntegration_ImportRecords_AdminOnly tests that ImportRecords requires admin token
-func TestIntegration_ImportRecords_AdminOnly(t *testing.T) {
+// TestIntegration_ImportRecords tests that ImportRecords needs a token allowed to add records to the zone
+func TestIntegration_ImportRecords(t *testing.T) {
 	t.Parallel()
 
 	mockServer := mockbunny.New()
@@ -387,7 +389,7 @@ func TestIntegration_ImportRecords_AdminOnly(t *testing.T) {
 	}
 
 	// Verify response
-	var result bunny.ImportRecordsResponse
+	var result ImportRecordsResponse
 	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
@@ -395,7 +397,7 @@ func TestIntegration_ImportRecords_AdminOnly(t *testing.T) {
 		t.Errorf("expected 2 created records, got %d", result.Created)
 	}
 
-	// Test 2: Non-admin token should fail with 403
+	// Test 2: Token without permissions for the zone should fail with 403
 	req = httptest.NewRequest("POST", fmt.Sprintf("/dnszone/%d/import", zoneID), bytes.NewReader([]byte(importBody)))
 	req.Header.Set("AccessKey", "non-admin-key")
 	w = httptest.NewRecorder()
@@ -403,7 +405,7 @@ func TestIntegration_ImportRecords_AdminOnly(t *testing.T) {
 	proxyRouter.ServeHTTP(w, req)
 
 	if w.Code != http.StatusForbidden {
-		t.Errorf("expected status 403 with non-admin token, got %d (body: %s)", w.Code, w.Body.String())
+		t.Errorf("expected status 403 without permissions, got %d (body: %s)", w.Code, w.Body.String())
 	}
 
 	// Test 3: Invalid token should fail with 401
@@ -418,13 +420,14 @@ func TestIntegration_ImportRecords_AdminOnly(t *testing.T) {
 	}
 }
 
-func TestIntegration_ExportRecords_AdminOnly(t *testing.T) {
+func TestIntegration_ExportRecords_Permissions(t *testing.T) {
 	t.Parallel()
 	mockServer := mockbunny.New()
 	defer mockServer.Close()
 
 	zoneID := mockServer.AddZoneWithRecords("example.com", []mockbunny.Record{
 		{Type: 0, Name: "@", Value: "192.168.1.1", TTL: 300},
+		{Type: 3, Name: "_acme-challenge", Value: "token", TTL: 60},
 	})
 
 	// Create storage with admin and scoped tokens
@@ -440,28 +443,43 @@ func TestIntegration_ExportRecords_AdminOnly(t *testing.T) {
 		t.Fatalf("failed to create admin token: %v", err)
 	}
 
-	// Create scoped token
-	scopedToken := "scoped-export-test-token"
-	scopedHash := sha256.Sum256([]byte(scopedToken))
-	_, err = db.CreateToken(context.Background(), "scoped-export", false, hex.EncodeToString(scopedHash[:]))
+	// Create a scoped token without permissions, and one that may read TXT records
+	_, err = db.CreateToken(context.Background(), "scoped-export", false, hashTokenForTest("scoped-export-test-token"))
 	if err != nil {
 		t.Fatalf("failed to create scoped token: %v", err)
 	}
+	txtToken, err := db.CreateToken(context.Background(), "txt-export", false, hashTokenForTest("txt-export-test-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+	_, err = db.AddPermissionForToken(context.Background(), txtToken.ID, &storage.Permission{
+		ZoneID:         zoneID,
+		AllowedActions: []string{"read"},
+		RecordTypes:    []string{"TXT"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add permission: %v", err)
+	}
 
 	client := bunny.NewClient("test-key", bunny.WithBaseURL(mockServer.URL()))
 	handler := NewHandler(client, testLogger())
 	bootstrapService := auth.NewBootstrapService(db, "master-key")
 	authenticator := auth.NewAuthenticator(db, bootstrapService)
-	router := NewRouter(handler, authenticator.Authenticate, testLogger())
+	authMiddleware := func(next http.Handler) http.Handler {
+		return authenticator.Authenticate(authenticator.CheckPermissions(next))
+	}
+	router := NewRouter(handler, authMiddleware, testLogger())
 
 	tests := []struct {
 		name       string
 		token      string
 		wantStatus int
+		wantA      bool
 	}{
-		{"admin token succeeds", "admin-export-test-token", http.StatusOK},
-		{"scoped token gets 403", scopedToken, http.StatusForbidden},
-		{"invalid token gets 401", "invalid-token", http.StatusUnauthorized},
+		{"admin token gets every record", "admin-export-test-token", http.StatusOK, true},
+		{"TXT token gets TXT records", "txt-export-test-token", http.StatusOK, false},
+		{"token without permission gets 403", "scoped-export-test-token", http.StatusForbidden, false},
+		{"invalid token gets 401", "invalid-token", http.StatusUnauthorized, false},
 	}
 
 	for _, tt := range tests {
@@ -472,7 +490,17 @@ func TestIntegration_ExportRecords_AdminOnly(t *testing.T) {
 			router.ServeHTTP(w, req)
 
 			if w.Code != tt.wantStatus {
-				t.Errorf("expected status %d, got %d (body: %s)", tt.wantStatus, w.Code, w.Body.String())
+				t.Fatalf("expected status %d, got %d (body: %s)", tt.wantStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			body := w.Body.String()
+			if !strings.Contains(body, "_acme-challenge") {
+				t.Errorf("export is missing the TXT record: %q", body)
+			}
+			if strings.Contains(body, "192.168.1.1") != tt.wantA {
+				t.Errorf("export contains A record = %v, want %v: %q", !tt.wantA, tt.wantA, body)
 			}
 		})
 	}
@@ -644,56 +672,86 @@ func TestIntegration_IssueCertificate_AdminOnly(t *testing.T) {
 	}
 }
 
-func TestIntegration_GetStatistics_AdminOnly(t *testing.T) {
+// TestIntegration_GetStatistics_Permissions tests that zone statistics need
+// an admin token or a scoped token granted get_statistics on the zone.
+func TestIntegration_GetStatistics_Permissions(t *testing.T) {
 	t.Parallel()
 	mockServer := mockbunny.New()
 	defer mockServer.Close()
 
 	zoneID := mockServer.AddZone("example.com")
+	otherZoneID := mockServer.AddZone("example.org")
 
 	db, err := storage.New(":memory:")
 	if err != nil {
 		t.Fatalf("failed to create storage: %v", err)
 	}
 
-	adminHash := hashTokenForTest("admin-stats-token")
-	_, err = db.CreateToken(context.Background(), "admin-stats", true, adminHash)
+	_, err = db.CreateToken(context.Background(), "admin-stats", true, hashTokenForTest("admin-stats-token"))
 	if err != nil {
 		t.Fatalf("failed to create admin token: %v", err)
 	}
 
-	scopedToken := "scoped-stats-token"
-	scopedHash := sha256.Sum256([]byte(scopedToken))
-	_, err = db.CreateToken(context.Background(), "scoped-stats", false, hex.EncodeToString(scopedHash[:]))
+	// A scoped token without permissions, one granted statistics for the zone
+	// and one that may only read its records
+	_, err = db.CreateToken(context.Background(), "scoped-stats", false, hashTokenForTest("scoped-stats-token"))
 	if err != nil {
 		t.Fatalf("failed to create scoped token: %v", err)
 	}
+	for name, actions := range map[string][]string{
+		"stats-token": {"get_statistics"},
+		"read-token":  {"read"},
+	} {
+		token, err := db.CreateToken(context.Background(), name, false, hashTokenForTest(name))
+		if err != nil {
+			t.Fatalf("failed to create scoped token: %v", err)
+		}
+		_, err = db.AddPermissionForToken(context.Background(), token.ID, &storage.Permission{
+			ZoneID:         zoneID,
+			AllowedActions: actions,
+			RecordTypes:    []string{"A", "TXT"},
+		})
+		if err != nil {
+			t.Fatalf("failed to add permission: %v", err)
+		}
+	}
 
 	client := bunny.NewClient("test-key", bunny.WithBaseURL(mockServer.URL()))
 	handler := NewHandler(client, testLogger())
 	bootstrapService := auth.NewBootstrapService(db, "master-key")
 	authenticator := auth.NewAuthenticator(db, bootstrapService)
-	router := NewRouter(handler, authenticator.Authenticate, testLogger())
+	authMiddleware := func(next http.Handler) http.Handler {
+		return authenticator.Authenticate(authenticator.CheckPermissions(next))
+	}
+	router := NewRouter(handler, authMiddleware, testLogger())
+	// Without CheckPermissions, the handler's own check must still hold
+	authOnlyRouter := NewRouter(handler, authenticator.Authenticate, testLogger())
 
 	tests := []struct {
 		name       string
 		token      string
+		zoneID     int64
 		wantStatus int
 	}{
-		{"admin token succeeds", "admin-stats-token", http.StatusOK},
-		{"scoped token gets 403", scopedToken, http.StatusForbidden},
-		{"invalid token gets 401", "invalid-token", http.StatusUnauthorized},
+		{"admin token succeeds", "admin-stats-token", zoneID, http.StatusOK},
+		{"scoped token with get_statistics succeeds", "stats-token", zoneID, http.StatusOK},
+		{"scoped token with get_statistics on another zone gets 403", "stats-token", otherZoneID, http.StatusForbidden},
+		{"scoped token with only read gets 403", "read-token", zoneID, http.StatusForbidden},
+		{"scoped token without permissions gets 403", "scoped-stats-token", zoneID, http.StatusForbidden},
+		{"invalid token gets 401", "invalid-token", zoneID, http.StatusUnauthorized},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/dnszone/%d/statistics", zoneID), nil)
-			req.Header.Set("AccessKey", tt.token)
-			w := httptest.NewRecorder()
-			router.ServeHTTP(w, req)
-
-			if w.Code != tt.wantStatus {
-				t.Errorf("expected status %d, got %d (body: %s)", tt.wantStatus, w.Code, w.Body.String())
+			for name, r := range map[string]http.Handler{"full": router, "authenticate only": authOnlyRouter} {
+				req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/dnszone/%d/statistics", tt.zoneID), nil)
+				req.Header.Set("AccessKey", tt.token)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if w.Code != tt.wantStatus {
+					t.Errorf("%s router: expected status %d, got %d (body: %s)", name, tt.wantStatus, w.Code, w.Body.String())
+				}
 			}
 		})
 	}
@@ -1022,3 +1080,145 @@ func TestIntegration_FailureInjection_Latency(t *testing.T) {
 		t.Errorf("expected valid zone response, got error: %v", err)
 	}
 }
+
+func TestIntegration_AccountPassthrough(t *testing.T) {
+	t.Parallel()
+
+	db := newMemoryStorage(t)
+	_, err := db.CreateToken(context.Background(), "admin-account", true, hashTokenForTest("admin-account-token"))
+	if err != nil {
+		t.Fatalf("failed to create admin token: %v", err)
+	}
+	_, err = db.CreateToken(context.Background(), "scoped-account", false, hashTokenForTest("scoped-account-token"))
+	if err != nil {
+		t.Fatalf("failed to create scoped token: %v", err)
+	}
+
+	client := &mockBunnyClient{
+		getStatisticsFunc: func(_ context.Context, _ url.Values) (json.RawMessage, error) {
+			return json.RawMessage(`{}`), nil
+		},
+		getBillingFunc: func(_ context.Context) (json.RawMessage, error) {
+			return json.RawMessage(`{}`), nil
+		},
+		getBillingSummaryFunc: func(_ context.Context) (json.RawMessage, error) {
+			return json.RawMessage(`[]`), nil
+		},
+	}
+	authenticator := auth.NewAuthenticator(db, auth.NewBootstrapService(db, "master-key"))
+
+	tests := []struct {
+		name       string
+		enabled    bool
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"disabled returns 404", false, "/statistics", "admin-account-token", http.StatusNotFound},
+		{"admin gets statistics", true, "/statistics?hourly=true", "admin-account-token", http.StatusOK},
+		{"admin gets billing", true, "/billing", "admin-account-token", http.StatusOK},
+		{"admin gets billing summary", true, "/billing/summary", "admin-account-token", http.StatusOK},
+		{"scoped token gets 403", true, "/statistics", "scoped-account-token", http.StatusForbidden},
+		{"scoped token gets 403 on billing", true, "/billing", "scoped-account-token", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(client, testLogger())
+			handler.SetAccountPassthrough(tt.enabled)
+			router := NewRouter(handler, authenticator.Authenticate, testLogger())
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("AccessKey", tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d (body: %s)", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestIntegration_BunnySpecificRecordTypes tests that Script, PullZone, Redirect and
+// flattened CNAME records are classified for permissions, whatever case or alias
+// the permission uses for them.
+func TestIntegration_BunnySpecificRecordTypes(t *testing.T) {
+	t.Parallel()
+
+	mockServer := mockbunny.New()
+	defer mockServer.Close()
+
+	zoneID := mockServer.AddZoneWithRecords("example.com", []mockbunny.Record{
+		{Type: 3, Name: "txt", Value: "hello"},
+		{Type: 5, Name: "redirect", Value: "https://example.org"},
+		{Type: 6, Name: "flat", Value: "target.example.org"},
+		{Type: 7, Name: "cdn", Value: "pullzone"},
+		{Type: 11, Name: "edge", Value: "script"},
+	})
+
+	db := newMemoryStorage(t)
+	token, err := db.CreateToken(context.Background(), "edge-scripts", false, hashTokenForTest("edge-token"))
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	_, err = db.AddPermissionForToken(context.Background(), token.ID, &storage.Permission{
+		ZoneID:         zoneID,
+		AllowedActions: []string{"list_records", "add_record"},
+		RecordTypes:    []string{"SCRIPT", "pull_zone"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add permission: %v", err)
+	}
+
+	bunnyClient := bunny.NewClient("test-api-key", bunny.WithBaseURL(mockServer.URL()))
+	authenticator := auth.NewAuthenticator(db, auth.NewBootstrapService(db, "master-key"))
+	authMiddleware := func(next http.Handler) http.Handler {
+		return authenticator.Authenticate(authenticator.CheckPermissions(next))
+	}
+	router := NewRouter(NewHandler(bunnyClient, testLogger()), authMiddleware, testLogger())
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("AccessKey", "edge-token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Listing only returns the permitted bunny-specific types
+	w := do(http.MethodGet, fmt.Sprintf("/dnszone/%d", zoneID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d (body: %s)", w.Code, w.Body.String())
+	}
+	var zone bunny.Zone
+	if err := json.Unmarshal(w.Body.Bytes(), &zone); err != nil {
+		t.Fatalf("failed to decode zone: %v", err)
+	}
+	gotTypes := make(map[int]bool)
+	for _, r := range zone.Records {
+		gotTypes[r.Type] = true
+	}
+	if len(zone.Records) != 2 || !gotTypes[7] || !gotTypes[11] {
+		t.Errorf("expected only PullZone and Script records, got %+v", zone.Records)
+	}
+
+	tests := []struct {
+		name       string
+		recordType int
+		wantStatus int
+	}{
+		{"script allowed", 11, http.StatusCreated},
+		{"pull zone allowed", 7, http.StatusCreated},
+		{"redirect forbidden", 5, http.StatusForbidden},
+		{"flattened cname forbidden", 6, http.StatusForbidden},
+		{"unknown type forbidden", 42, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		body := fmt.Sprintf(`{"Type":%d,"Name":"new","Value":"v"}`, tt.recordType)
+		w := do(http.MethodPost, fmt.Sprintf("/dnszone/%d/records", zoneID), body)
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d (body: %s)", tt.name, tt.wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
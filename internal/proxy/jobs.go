@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/jobs"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// JobRunner runs long operations in the background. It is satisfied by
+// *jobs.Runner.
+type JobRunner interface {
+	Submit(ctx context.Context, job *storage.Job, work jobs.Work) (*storage.Job, error)
+	Get(ctx context.Context, id int64) (*storage.Job, error)
+}
+
+// SetJobRunner enables asynchronous imports (POST .../import?async=true) and
+// GET /jobs/{jobID}. Must be called before NewRouter.
+func (h *Handler) SetJobRunner(runner JobRunner) {
+	h.jobs = runner
+}
+
+// JobResponse is the response body for GET /jobs/{jobID} and for requests
+// that start a job. Result is the response the operation would have returned
+// synchronously, once the job has finished.
+type JobResponse struct {
+	ID         int64           `json:"Id"`
+	Kind       string          `json:"Kind"`
+	ZoneID     int64           `json:"ZoneId,omitempty"`
+	Status     string          `json:"Status"`
+	Total      int             `json:"Total"`
+	Processed  int             `json:"Processed"`
+	Result     json.RawMessage `json:"Result,omitempty"`
+	Error      string          `json:"Error,omitempty"`
+	CreatedAt  string          `json:"CreatedAt"`
+	StartedAt  string          `json:"StartedAt,omitempty"`
+	FinishedAt string          `json:"FinishedAt,omitempty"`
+}
+
+// newJobResponse converts a stored job.
+func newJobResponse(j *storage.Job) JobResponse {
+	resp := JobResponse{
+		ID:        j.ID,
+		Kind:      j.Kind,
+		ZoneID:    j.ZoneID,
+		Status:    j.Status,
+		Total:     j.Total,
+		Processed: j.Processed,
+		Result:    j.Result,
+		Error:     j.Error,
+		CreatedAt: j.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !j.StartedAt.IsZero() {
+		resp.StartedAt = j.StartedAt.UTC().Format(time.RFC3339)
+	}
+	if !j.FinishedAt.IsZero() {
+		resp.FinishedAt = j.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// HandleGetJob returns the status and progress of a background job.
+// GET /jobs/{jobID}
+// Jobs are visible to the token that started them and to admins; other
+// jobs are reported as not found.
+func (h *Handler) HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "jobID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	job, err := h.jobs.Get(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && !ownsJob(r, job)) {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to get job", "job_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newJobResponse(job))
+}
+
+// ownsJob reports whether the caller may see a job.
+func ownsJob(r *http.Request, job *storage.Job) bool {
+	return auth.IsAdminFromContext(r.Context()) || job.TokenID == jobTokenID(r)
+}
+
+// jobTokenID returns the ID recorded as a job's owner: the caller's token,
+// or 0 for the master key.
+func jobTokenID(r *http.Request) int64 {
+	if token := auth.TokenFromContext(r.Context()); token != nil {
+		return token.ID
+	}
+	return 0
+}
@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/jobs"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// syncJobRunner runs work as soon as it is submitted and keeps jobs in memory.
+type syncJobRunner struct {
+	jobs map[int64]*storage.Job
+	err  error
+}
+
+func (s *syncJobRunner) Submit(ctx context.Context, job *storage.Job, work jobs.Work) (*storage.Job, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	queued := *job
+	queued.ID, queued.Status = int64(len(s.jobs)+1), storage.JobStatusQueued
+	done := queued
+	result, err := work(ctx, func(n int) { done.Processed = n })
+	done.Status = storage.JobStatusSucceeded
+	if err != nil {
+		done.Status, done.Error = storage.JobStatusFailed, err.Error()
+	}
+	done.Result, _ = json.Marshal(result) //nolint:errcheck
+	s.jobs[done.ID] = &done
+	return &queued, nil
+}
+
+func (s *syncJobRunner) Get(_ context.Context, id int64) (*storage.Job, error) {
+	if j, ok := s.jobs[id]; ok {
+		return j, nil
+	}
+	return nil, storage.ErrNotFound
+}
+
+func TestHandleImportRecords_Async(t *testing.T) {
+	t.Parallel()
+
+	var added []bunny.AddRecordRequest
+	handler := NewHandler(importClient(t, &added), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	runner := &syncJobRunner{jobs: make(map[int64]*storage.Job)}
+	handler.SetJobRunner(runner)
+	owner := &auth.KeyInfo{KeyID: 5, KeyName: "importer", Permissions: []*storage.Permission{
+		{ZoneID: 123, AllowedActions: []string{"import_records", "add_record"}, RecordTypes: []string{"A", "TXT"}},
+	}}
+
+	r := newImportRequest("www 300 IN A 192.0.2.1\n@ 300 IN TXT \"reject\"\n", "text/plain", owner)
+	r.URL.RawQuery = "async=true"
+	w := httptest.NewRecorder()
+	handler.HandleImportRecords(w, r)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var started JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &started); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if started.Status != storage.JobStatusQueued || started.Kind != storage.JobKindImport || started.Total != 2 ||
+		w.Header().Get("Location") != "/jobs/1" {
+		t.Errorf("unexpected job %+v, Location %q", started, w.Header().Get("Location"))
+	}
+
+	getJob := func(keyInfo *auth.KeyInfo) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.HandleGetJob(w, newTestRequestWithKeyInfo("/jobs/1", map[string]string{"jobID": "1"}, keyInfo))
+		return w
+	}
+
+	w = getJob(owner)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var job JobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	var result ImportRecordsResponse
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		t.Fatalf("failed to unmarshal job result: %v", err)
+	}
+	if job.Status != storage.JobStatusSucceeded || job.Processed != 2 || result.Created != 1 || result.Failed != 1 {
+		t.Errorf("unexpected finished job %+v with result %+v", job, result)
+	}
+	if len(added) != 1 {
+		t.Errorf("expected 1 record created, got %+v", added)
+	}
+
+	// Other tokens cannot see the job
+	if w := getJob(&auth.KeyInfo{KeyID: 6, KeyName: "other"}); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for another token, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleImportRecords_AsyncErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		runner     JobRunner
+		query      string
+		wantStatus int
+	}{
+		{"not enabled", nil, "async=true", http.StatusBadRequest},
+		{"invalid parameter", &syncJobRunner{jobs: map[int64]*storage.Job{}}, "async=maybe", http.StatusBadRequest},
+		{"queue full", &syncJobRunner{err: jobs.ErrQueueFull}, "async=1", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler := NewHandler(importClient(t, nil), slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.runner != nil {
+				handler.SetJobRunner(tt.runner)
+			}
+			r := newImportRequest("www 300 IN A 192.0.2.1\n", "text/plain", nil)
+			r.URL.RawQuery = tt.query
+			w := httptest.NewRecorder()
+			handler.HandleImportRecords(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleGetJob_Invalid(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(&mockBunnyClient{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler.SetJobRunner(&syncJobRunner{jobs: make(map[int64]*storage.Job)})
+
+	for id, want := range map[string]int{"abc": http.StatusBadRequest, "9": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		handler.HandleGetJob(w, newTestRequest(http.MethodGet, "/jobs/"+id, strings.NewReader(""), map[string]string{"jobID": id}))
+		if w.Code != want {
+			t.Errorf("job %s: expected status %d, got %d", id, want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+// defaultMaxBodySize is the request body limit used until SetRequestLimits
+// is called.
+const defaultMaxBodySize = 1 << 20
+
+// Record limits used until SetRecordLimits is called. Asynchronous imports
+// run in the background, so they are not bound by the server's write timeout
+// and may be larger.
+const (
+	DefaultMaxTXTLength          = 4096
+	DefaultImportMaxRecords      = 1000
+	DefaultImportMaxAsyncRecords = 20000
+)
+
+// Limits counted in bunny_proxy_request_limit_rejections_total.
+const (
+	limitBody          = "body"
+	limitTXTLength     = "txt_length"
+	limitImportRecords = "import_records"
+)
+
+// importPath matches POST /dnszone/{zoneID}/import.
+var importPath = regexp.MustCompile(`^/dnszone/\d+/import/?$`)
+
+// SetRequestLimits sets the largest request body proxied routes accept and,
+// for zone imports, their own body limit and a timeout that replaces the
+// server's read and write timeouts. Zero importTimeout keeps the server
+// timeouts. Must be called before NewRouter.
+func (h *Handler) SetRequestLimits(maxBody, importMaxBody int64, importTimeout time.Duration) {
+	h.maxBody, h.importMaxBody, h.importTimeout = maxBody, importMaxBody, importTimeout
+}
+
+// SetRecordLimits sets the longest TXT value accepted in record writes, and
+// the most records one import may create, synchronously or with async=true.
+// Records over a limit are rejected before anything is sent to bunny.net.
+// Must be called before NewRouter.
+func (h *Handler) SetRecordLimits(maxTXTLength, importMaxRecords, importMaxAsyncRecords int) {
+	h.maxTXTLength = maxTXTLength
+	h.importMaxRecords, h.importMaxAsyncRecords = importMaxRecords, importMaxAsyncRecords
+}
+
+// limitRequest caps the request body; reading past the limit fails with
+// *http.MaxBytesError.
+func (h *Handler) limitRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := h.maxBody
+		if r.Method == http.MethodPost && importPath.MatchString(r.URL.Path) {
+			limit = h.importMaxBody
+			if h.importTimeout > 0 {
+				deadline := time.Now().Add(h.importTimeout)
+				rc := http.NewResponseController(w)
+				if err := errors.Join(rc.SetReadDeadline(deadline), rc.SetWriteDeadline(deadline)); err != nil {
+					h.logger.Warn("failed to extend import deadlines", "error", err)
+				}
+			}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeBodyError responds to a request body that could not be read or
+// decoded: 413 if it is over the body limit, 400 otherwise.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		metrics.RecordRequestLimitRejection(limitBody)
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, "invalid request body")
+}
@@ -0,0 +1,107 @@
+package proxy
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitRequest_BodySize(t *testing.T) {
+	t.Parallel()
+
+	h := NewHandler(&mockBunnyClient{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRequestLimits(10, 100, 0)
+	limited := h.limitRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		size       int
+		wantStatus int
+	}{
+		{"small body", http.MethodPost, "/dnszone/1/records", 10, http.StatusOK},
+		{"large body", http.MethodPost, "/dnszone/1/records", 11, http.StatusRequestEntityTooLarge},
+		{"large import", http.MethodPost, "/dnszone/1/import", 100, http.StatusOK},
+		{"import over its limit", http.MethodPost, "/dnszone/1/import", 101, http.StatusRequestEntityTooLarge},
+		{"import path with another method", http.MethodPut, "/dnszone/1/import", 11, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			w := httptest.NewRecorder()
+			limited.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(strings.Repeat("x", tt.size))))
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestLimitRequest_ImportTimeout(t *testing.T) {
+	t.Parallel()
+
+	h := NewHandler(&mockBunnyClient{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRequestLimits(defaultMaxBodySize, defaultMaxBodySize, 5*time.Second)
+	server := httptest.NewUnstartedServer(h.limitRequest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		_, _ = io.WriteString(w, "done") //nolint:errcheck
+	})))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	for path, wantOK := range map[string]bool{"/dnszone/1/import": true, "/dnszone/1/records": false} {
+		resp, err := server.Client().Post(server.URL+path, "text/plain", strings.NewReader("www 300 IN A 192.0.2.1"))
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close() //nolint:errcheck
+		}
+		if ok := err == nil; ok != wantOK {
+			t.Errorf("%s: expected success %v, got error %v", path, wantOK, err)
+		}
+	}
+}
+
+func TestRecordLimits(t *testing.T) {
+	t.Parallel()
+
+	h := NewHandler(importClient(t, nil), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRequestLimits(128, defaultMaxBodySize, 0)
+	h.SetRecordLimits(8, 2, 3)
+	send := func(handler http.HandlerFunc, r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.limitRequest(handler).ServeHTTP(w, r)
+		return w
+	}
+	addRecord := func(body string) *http.Request {
+		return newTestRequest(http.MethodPost, "/dnszone/123/records", strings.NewReader(body), map[string]string{"zoneID": "123"})
+	}
+
+	w := send(h.HandleAddRecord, addRecord(`{"Type":3,"Name":"_acme","Value":"123456789"}`))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "longer than the 8 allowed") {
+		t.Errorf("expected 400 for a long TXT value, got %d: %s", w.Code, w.Body.String())
+	}
+	w = send(h.HandleAddRecord, addRecord(`{"Type":3,"Name":"_acme","Value":"`+strings.Repeat("x", 128)+`"}`))
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "larger than 128 bytes") {
+		t.Errorf("expected 413 for a large body, got %d: %s", w.Code, w.Body.String())
+	}
+	w = send(h.HandleBatchRecords, newBatchRequest(`{"Operations":[{"Action":"create","Record":{"Type":3,"Value":"123456789"}}]}`, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a long TXT value in a batch, got %d: %s", w.Code, w.Body.String())
+	}
+
+	zone := "a 300 IN A 192.0.2.1\nb 300 IN A 192.0.2.2\nc 300 IN A 192.0.2.3\n"
+	w = send(h.HandleImportRecords, newImportRequest(zone, "text/plain", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "the file has 3 records; at most 2") {
+		t.Errorf("expected 400 for too many records, got %d: %s", w.Code, w.Body.String())
+	}
+}
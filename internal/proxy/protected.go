@@ -0,0 +1,146 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// ProtectedRecordStore lists the record names scoped tokens may not change.
+type ProtectedRecordStore interface {
+	ListProtectedRecords(ctx context.Context, zoneID int64) ([]*storage.ProtectedRecord, error)
+}
+
+// SetProtectedRecordStore stops scoped tokens from changing the records
+// protected in s, even when their permissions would allow it: they may not
+// update or delete a protected record, create one, or rename another record
+// into a protected name. Admin tokens and the master key are not affected.
+// Must be called before NewRouter.
+func (h *Handler) SetProtectedRecordStore(s ProtectedRecordStore) {
+	h.protected = s
+}
+
+// protections returns the protected records of a zone that apply to the
+// caller: none for admins, the master key or when protection is disabled.
+func (h *Handler) protections(ctx context.Context, zoneID int64) ([]*storage.ProtectedRecord, error) {
+	if h.protected == nil || auth.GetKeyInfo(ctx) == nil || auth.IsAdminFromContext(ctx) {
+		return nil, nil
+	}
+	return h.protected.ListProtectedRecords(ctx, zoneID)
+}
+
+// checkProtected writes 403 Forbidden and returns false if the caller may not
+// change a record because it is protected, or because req would make it a
+// protected record. req is nil for a delete.
+func (h *Handler) checkProtected(w http.ResponseWriter, r *http.Request, zoneID, recordID int64, req *bunny.AddRecordRequest) bool {
+	p, err := h.protectedRecord(r.Context(), h.upstream(r.Context()), zoneID, recordID, req)
+	if err != nil {
+		handleBunnyError(w, err)
+		return false
+	}
+	if p != nil {
+		h.logger.Warn("rejected change to protected record", "zone_id", zoneID, "record_id", recordID,
+			"protection_id", p.ID, "token", tokenName(r))
+		writeError(w, http.StatusForbidden, protectedMessage(p))
+		return false
+	}
+	return true
+}
+
+// checkProtectedCreate writes 403 Forbidden and returns false if the caller
+// may not create req because its name and type are protected.
+func (h *Handler) checkProtectedCreate(w http.ResponseWriter, r *http.Request, zoneID int64, req *bunny.AddRecordRequest) bool {
+	protections, err := h.protections(r.Context(), zoneID)
+	if err != nil {
+		h.logger.Error("failed to list protected records", "zone_id", zoneID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to check protected records")
+		return false
+	}
+	if p := protecting(protections, requestedRecord(req, req.Type)); p != nil {
+		h.logger.Warn("rejected protected record create", "zone_id", zoneID, "name", req.Name,
+			"protection_id", p.ID, "token", tokenName(r))
+		writeError(w, http.StatusForbidden, protectedMessage(p))
+		return false
+	}
+	return true
+}
+
+// protectedRecord returns the protection that stops the caller from changing
+// a record, or from updating it with req, or nil. req is nil for a delete.
+// The record is only fetched when the zone has protected records; a missing
+// record is left for bunny.net to report.
+func (h *Handler) protectedRecord(ctx context.Context, client BunnyClient, zoneID, recordID int64, req *bunny.AddRecordRequest) (*storage.ProtectedRecord, error) {
+	protections, err := h.protections(ctx, zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list protected records: %w", err)
+	}
+	if len(protections) == 0 {
+		return nil, nil
+	}
+
+	zone, err := uncached(client).GetZone(ctx, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(zone.Records, func(rec bunny.Record) bool { return rec.ID == recordID })
+	if i < 0 {
+		return nil, nil
+	}
+	if p := protecting(protections, &zone.Records[i]); p != nil || req == nil {
+		return p, nil
+	}
+	return protecting(protections, requestedRecord(req, zone.Records[i].Type)), nil
+}
+
+// protectingOperation returns the protection that stops a batch operation, or
+// nil: that of the record it changes, or of the record it creates or updates
+// it to. before is the record changed, nil for a create.
+func protectingOperation(protections []*storage.ProtectedRecord, op BatchOperation, before *bunny.Record) *storage.ProtectedRecord {
+	if before != nil {
+		if p := protecting(protections, before); p != nil {
+			return p
+		}
+	}
+	if op.Record == nil {
+		return nil
+	}
+	recordType := op.Record.Type
+	if before != nil {
+		recordType = before.Type
+	}
+	return protecting(protections, requestedRecord(op.Record, recordType))
+}
+
+// requestedRecord returns the record req would create or update a record to,
+// for matching against protections. bunny.net keeps the type of a record on
+// update, so recordType is the current type for an update.
+func requestedRecord(req *bunny.AddRecordRequest, recordType int) *bunny.Record {
+	return &bunny.Record{Name: req.Name, Type: recordType}
+}
+
+// protecting returns the protection covering rec, or nil.
+func protecting(protections []*storage.ProtectedRecord, rec *bunny.Record) *storage.ProtectedRecord {
+	for _, p := range protections {
+		if normalizeRecordName(rec.Name) == normalizeRecordName(p.Name) && (p.RecordType == "" || p.RecordType == auth.MapRecordTypeToString(rec.Type)) {
+			return p
+		}
+	}
+	return nil
+}
+
+// protectedMessage describes a protection in an error, naming the apex "@".
+func protectedMessage(p *storage.ProtectedRecord) string {
+	name := p.Name
+	if name == "" {
+		name = "@"
+	}
+	if p.RecordType == "" {
+		return fmt.Sprintf("record %s is protected", name)
+	}
+	return fmt.Sprintf("record %s %s is protected", name, p.RecordType)
+}
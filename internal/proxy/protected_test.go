@@ -0,0 +1,186 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeProtections is a ProtectedRecordStore holding the protections of every zone.
+type fakeProtections []*storage.ProtectedRecord
+
+func (f fakeProtections) ListProtectedRecords(_ context.Context, zoneID int64) ([]*storage.ProtectedRecord, error) {
+	var protections []*storage.ProtectedRecord
+	for _, p := range f {
+		if p.ZoneID == zoneID {
+			protections = append(protections, p)
+		}
+	}
+	return protections, nil
+}
+
+func TestProtectedRecords(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{records: []bunny.Record{
+		{ID: 1, Type: 4, Name: "", Value: "mail.example.com"},
+		{ID: 2, Type: 3, Name: "", Value: "v=spf1 -all"},
+		{ID: 3, Type: 0, Name: "WWW", Value: "192.0.2.1"},
+		{ID: 4, Type: 0, Name: "app", Value: "192.0.2.2"},
+	}}
+	h := NewHandler(zone.client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetProtectedRecordStore(fakeProtections{
+		{ID: 1, ZoneID: 1, Name: "", RecordType: "MX"},
+		{ID: 2, ZoneID: 1, Name: "www"},
+	})
+	scoped := &auth.KeyInfo{KeyID: 2, KeyName: "deploy", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"update_record", "delete_record", "batch_records"}},
+	}}
+
+	send := func(method string, recordID string, body string, keyInfo *auth.KeyInfo) *httptest.ResponseRecorder {
+		t.Helper()
+		params := map[string]string{"zoneID": "1", "recordID": recordID}
+		r := newTestRequestWithKeyInfo("/dnszone/1/records/"+recordID, params, keyInfo)
+		r.Method = method
+		r.Body = io.NopCloser(strings.NewReader(body))
+		w := httptest.NewRecorder()
+		if method == http.MethodDelete {
+			h.HandleDeleteRecord(w, r)
+		} else {
+			h.HandleUpdateRecord(w, r)
+		}
+		return w
+	}
+
+	w := send(http.MethodDelete, "1", "", scoped)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "record @ MX is protected") {
+		t.Errorf("expected 403 deleting the apex MX, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "3", `{"Type":0,"Value":"192.0.2.9"}`, scoped); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 updating www of any type, got %d: %s", w.Code, w.Body.String())
+	}
+	if zone.get(1) == nil || zone.get(3).Value != "192.0.2.1" {
+		t.Error("a protected record was changed")
+	}
+
+	// Another type at the apex and other names are not protected
+	if w := send(http.MethodDelete, "2", "", scoped); w.Code != http.StatusNoContent {
+		t.Errorf("expected 204 deleting the apex TXT, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "4", `{"Type":0,"Value":"192.0.2.9"}`, scoped); w.Code >= 300 {
+		t.Errorf("expected app to be updated, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A batch is rejected as a whole
+	r := newTestRequestWithKeyInfo("/dnszone/1/records/batch", map[string]string{"zoneID": "1"}, scoped)
+	r.Method = http.MethodPost
+	r.Body = io.NopCloser(strings.NewReader(`{"Operations":[{"Action":"delete","RecordId":4},{"Action":"delete","RecordId":1}]}`))
+	w = httptest.NewRecorder()
+	h.HandleBatchRecords(w, r)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "operation 1") {
+		t.Errorf("expected 403 for operation 1, got %d: %s", w.Code, w.Body.String())
+	}
+	if zone.get(4) == nil {
+		t.Error("the batch was partly applied")
+	}
+
+	// Admin tokens are not affected
+	r = newTestRequest(http.MethodDelete, "/dnszone/1/records/1", nil, map[string]string{"zoneID": "1", "recordID": "1"})
+	r = r.WithContext(auth.WithAdmin(auth.WithToken(r.Context(), &storage.Token{ID: 1, Name: "admin", IsAdmin: true}), true))
+	w = httptest.NewRecorder()
+	h.HandleDeleteRecord(w, r)
+	if w.Code != http.StatusNoContent || zone.get(1) != nil {
+		t.Errorf("expected an admin to delete the apex MX, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProtectedRecords_CreateAndRename(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{nextID: 10, records: []bunny.Record{
+		{ID: 1, Type: 4, Name: "", Value: "mail.example.com"},
+		{ID: 2, Type: 0, Name: "app", Value: "192.0.2.2", TTL: 300},
+		{ID: 3, Type: 4, Name: "backup", Value: "mx2.example.com"},
+	}}
+	h := NewHandler(zone.client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetProtectedRecordStore(fakeProtections{
+		{ID: 1, ZoneID: 1, Name: "", RecordType: "MX"},
+		{ID: 2, ZoneID: 1, Name: "www"},
+	})
+	scoped := &auth.KeyInfo{KeyID: 2, KeyName: "deploy", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"add_record", "update_record", "batch_records"}, RecordTypes: []string{"A", "MX"}},
+	}}
+
+	send := func(path string, params map[string]string, body string, handle http.HandlerFunc) *httptest.ResponseRecorder {
+		t.Helper()
+		r := newTestRequestWithKeyInfo(path, params, scoped)
+		r.Method = http.MethodPost
+		r.Body = io.NopCloser(strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handle(w, r)
+		return w
+	}
+	add := func(body string) *httptest.ResponseRecorder {
+		return send("/dnszone/1/records", map[string]string{"zoneID": "1"}, body, h.HandleAddRecord)
+	}
+	update := func(recordID, body string) *httptest.ResponseRecorder {
+		return send("/dnszone/1/records/"+recordID, map[string]string{"zoneID": "1", "recordID": recordID}, body, h.HandleUpdateRecord)
+	}
+
+	if w := add(`{"Type":4,"Name":"@","Value":"evil.example.net","Priority":5}`); w.Code != http.StatusForbidden ||
+		!strings.Contains(w.Body.String(), "record @ MX is protected") {
+		t.Errorf("expected 403 adding an apex MX, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := add(`{"Type":5,"Name":"WWW.","Value":"v=spf1 -all"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 adding www of any type, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := update("2", `{"Type":0,"Name":"www","Value":"192.0.2.2"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 renaming app to www, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := update("3", `{"Type":4,"Name":"","Value":"mx2.example.com"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 moving an MX record to the apex, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(zone.records) != 3 || zone.get(2).Name != "app" || zone.get(3).Name != "backup" {
+		t.Errorf("a protected name was taken: %+v", zone.records)
+	}
+
+	batch := func(body string) *httptest.ResponseRecorder {
+		return send("/dnszone/1/records/batch", map[string]string{"zoneID": "1"}, body, h.HandleBatchRecords)
+	}
+	if w := batch(`{"Operations":[{"Action":"create","Record":{"Type":0,"Name":"api","Value":"192.0.2.3"}},` +
+		`{"Action":"create","Record":{"Type":0,"Name":"www","Value":"192.0.2.4"}}]}`); w.Code != http.StatusForbidden ||
+		!strings.Contains(w.Body.String(), "operation 1") {
+		t.Errorf("expected 403 for the create in operation 1, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := batch(`{"Operations":[{"Action":"update","RecordId":2,"Record":{"Type":0,"Name":"www","Value":"192.0.2.2"}}]}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a batch rename to www, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A sync by a token for A records compares only those
+	r := newTestRequestWithKeyInfo("/dnszone/1/sync", map[string]string{"zoneID": "1"}, &auth.KeyInfo{KeyID: 3, KeyName: "sync",
+		Permissions: []*storage.Permission{{ZoneID: 1, AllowedActions: []string{"add_record"}, RecordTypes: []string{"A"}}}})
+	r.Method = http.MethodPost
+	r.Body = io.NopCloser(strings.NewReader("app 300 IN A 192.0.2.2\nwww 300 IN A 192.0.2.5\n"))
+	w := httptest.NewRecorder()
+	h.HandleSyncRecords(w, r)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "record www is protected") {
+		t.Errorf("expected 403 for a sync creating www, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(zone.records) != 3 || zone.get(2).Name != "app" {
+		t.Errorf("a batch or sync took a protected name: %+v", zone.records)
+	}
+
+	// Other names and types are not protected
+	if w := add(`{"Type":3,"Name":"@","Value":"v=spf1 -all"}`); w.Code != http.StatusCreated {
+		t.Errorf("expected 201 adding an apex TXT, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := update("2", `{"Type":0,"Name":"api","Value":"192.0.2.2"}`); w.Code >= 300 || zone.get(2).Name != "api" {
+		t.Errorf("expected app to be renamed to api, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,222 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// rawZoneList is a paginated pull or storage zone list, with items kept raw
+// so fields the proxy doesn't model pass through unchanged.
+type rawZoneList struct {
+	Items        []json.RawMessage `json:"Items"`
+	CurrentPage  int               `json:"CurrentPage"`
+	TotalItems   int               `json:"TotalItems"`
+	HasMoreItems bool              `json:"HasMoreItems"`
+}
+
+// HandleListPullZones lists pull zones.
+// GET /pullzone
+// Scoped keys only see pull zones they have a permission for.
+func (h *Handler) HandleListPullZones(w http.ResponseWriter, r *http.Request) {
+	result, err := h.upstream(r.Context()).ListPullZones(r.Context(), r.URL.Query())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("list pull zones", "token", tokenName(r), "query", r.URL.RawQuery)
+
+	h.writeScopedZoneList(w, r, result, storage.ResourcePullZone)
+}
+
+// writeScopedZoneList writes a raw zone list, filtered to the zones of the
+// given resource kind the caller has a permission for. The list may be
+// paginated or a plain array. Admins and all-zones permissions see it unchanged.
+func (h *Handler) writeScopedZoneList(w http.ResponseWriter, r *http.Request, result json.RawMessage, resource string) {
+	keyInfo := auth.GetKeyInfo(r.Context())
+	if keyInfo == nil || auth.IsAdminFromContext(r.Context()) {
+		writeRawJSON(w, result)
+		return
+	}
+	permittedIDs := auth.GetPermittedResourceIDs(keyInfo, resource)
+	if permittedIDs == nil {
+		writeRawJSON(w, result)
+		return
+	}
+
+	idSet := make(map[int64]bool, len(permittedIDs))
+	for _, id := range permittedIDs {
+		idSet[id] = true
+	}
+	filter := func(items []json.RawMessage) []json.RawMessage {
+		filtered := make([]json.RawMessage, 0)
+		for _, item := range items {
+			var zone struct {
+				ID int64 `json:"Id"`
+			}
+			if err := json.Unmarshal(item, &zone); err == nil && idSet[zone.ID] {
+				filtered = append(filtered, item)
+			}
+		}
+		return filtered
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(result, &items); err == nil {
+		writeJSON(w, http.StatusOK, filter(items))
+		return
+	}
+
+	var list rawZoneList
+	if err := json.Unmarshal(result, &list); err != nil {
+		h.logger.Error("failed to parse zone list", "resource", resource, "error", err)
+		writeError(w, http.StatusBadGateway, "invalid upstream response")
+		return
+	}
+	list.Items = filter(list.Items)
+	list.TotalItems = len(list.Items)
+	list.HasMoreItems = false
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// HandleCreatePullZone creates a pull zone.
+// POST /pullzone
+// Admin only — a new pull zone has no permissions yet.
+func (h *Handler) HandleCreatePullZone(w http.ResponseWriter, r *http.Request) {
+	body, ok := readJSONBody(w, r, true)
+	if !ok {
+		return
+	}
+
+	result, err := h.upstream(r.Context()).AddPullZone(r.Context(), body)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("create pull zone", "token", tokenName(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	//nolint:errcheck
+	w.Write(result)
+}
+
+// HandleGetPullZone retrieves a single pull zone.
+// GET /pullzone/{pullZoneID}
+func (h *Handler) HandleGetPullZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pullZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.upstream(r.Context()).GetPullZone(r.Context(), id, r.URL.Query())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("get pull zone", "token", tokenName(r), "pull_zone_id", id)
+
+	writeRawJSON(w, result)
+}
+
+// HandleUpdatePullZone updates a pull zone's settings.
+// POST /pullzone/{pullZoneID}
+func (h *Handler) HandleUpdatePullZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pullZoneID(w, r)
+	if !ok {
+		return
+	}
+	body, ok := readJSONBody(w, r, true)
+	if !ok {
+		return
+	}
+
+	result, err := h.upstream(r.Context()).UpdatePullZone(r.Context(), id, body)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("update pull zone", "token", tokenName(r), "pull_zone_id", id)
+
+	writeRawJSON(w, result)
+}
+
+// HandleDeletePullZone deletes a pull zone.
+// DELETE /pullzone/{pullZoneID}
+// Admin only.
+func (h *Handler) HandleDeletePullZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := pullZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.upstream(r.Context()).DeletePullZone(r.Context(), id); err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("delete pull zone", "token", tokenName(r), "pull_zone_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandlePurgePullZoneCache purges a pull zone's cache.
+// POST /pullzone/{pullZoneID}/purgeCache
+// The optional body (e.g. {"CacheTag": "..."}) is forwarded unchanged.
+func (h *Handler) HandlePurgePullZoneCache(w http.ResponseWriter, r *http.Request) {
+	id, ok := pullZoneID(w, r)
+	if !ok {
+		return
+	}
+	body, ok := readJSONBody(w, r, false)
+	if !ok {
+		return
+	}
+
+	if err := h.upstream(r.Context()).PurgePullZoneCache(r.Context(), id, body); err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("purge pull zone cache", "token", tokenName(r), "pull_zone_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pullZoneID parses the pull zone ID URL parameter, writing a 400 on failure.
+func pullZoneID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "pullZoneID"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid pull zone ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// readJSONBody reads the request body and checks that it is JSON, writing a
+// 400, or 413 for a body over the limit, on failure. An empty body is rejected only when required is set.
+func readJSONBody(w http.ResponseWriter, r *http.Request, required bool) (json.RawMessage, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeBodyError(w, err)
+		return nil, false
+	}
+	if len(body) == 0 && !required {
+		return nil, true
+	}
+	if !json.Valid(body) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+	return json.RawMessage(body), true
+}
@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const testPullZoneList = `{"Items":[{"Id":1,"Name":"a","OriginUrl":"https://a"},{"Id":2,"Name":"b"},{"Id":3,"Name":"c"}],"CurrentPage":1,"TotalItems":3,"HasMoreItems":true}`
+
+func newPullZoneTestHandler(client *mockBunnyClient) *Handler {
+	return NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestHandleListPullZones_FiltersToPermittedPullZones(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		listPullZonesFunc: func(_ context.Context, query url.Values) (json.RawMessage, error) {
+			if query.Get("search") != "cdn" {
+				t.Errorf("expected search query to be forwarded, got %v", query)
+			}
+			return json.RawMessage(testPullZoneList), nil
+		},
+	}
+	keyInfo := &auth.KeyInfo{
+		KeyID:   1,
+		KeyName: "cdn-key",
+		Permissions: []*storage.Permission{
+			{ZoneID: 2, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+			{Resource: storage.ResourcePullZone, ZoneID: 1, AllowedActions: []string{"purge_pull_zone"}},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	newPullZoneTestHandler(client).HandleListPullZones(w, newTestRequestWithKeyInfo("/pullzone?search=cdn", nil, keyInfo))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list rawZoneList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	// Zone 2 is only permitted as a DNS zone, so it is filtered out.
+	if len(list.Items) != 1 || list.TotalItems != 1 || list.HasMoreItems {
+		t.Fatalf("expected only pull zone 1, got %+v", list)
+	}
+	// Fields the proxy does not model pass through.
+	if !strings.Contains(string(list.Items[0]), `"OriginUrl":"https://a"`) {
+		t.Errorf("item not passed through unchanged: %s", list.Items[0])
+	}
+}
+
+func TestHandleListPullZones_Admin(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		listPullZonesFunc: func(context.Context, url.Values) (json.RawMessage, error) {
+			return json.RawMessage(testPullZoneList), nil
+		},
+	}
+	r := newTestRequestWithKeyInfo("/pullzone", nil, &auth.KeyInfo{KeyID: 1, KeyName: "admin"})
+	r = r.WithContext(auth.WithAdmin(r.Context(), true))
+
+	w := httptest.NewRecorder()
+	newPullZoneTestHandler(client).HandleListPullZones(w, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != testPullZoneList {
+		t.Errorf("expected unfiltered list, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleListPullZones_InvalidUpstream(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		listPullZonesFunc: func(context.Context, url.Values) (json.RawMessage, error) {
+			return json.RawMessage(`"unexpected"`), nil
+		},
+	}
+	keyInfo := &auth.KeyInfo{KeyID: 1, Permissions: []*storage.Permission{{Resource: storage.ResourcePullZone, ZoneID: 1}}}
+
+	w := httptest.NewRecorder()
+	newPullZoneTestHandler(client).HandleListPullZones(w, newTestRequestWithKeyInfo("/pullzone", nil, keyInfo))
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", w.Code)
+	}
+}
+
+func TestHandleGetPullZone(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		getPullZoneFunc: func(_ context.Context, id int64, _ url.Values) (json.RawMessage, error) {
+			if id == 404 {
+				return nil, bunny.ErrNotFound
+			}
+			return json.RawMessage(`{"Id":7}`), nil
+		},
+	}
+	h := newPullZoneTestHandler(client)
+
+	tests := []struct {
+		name     string
+		id       string
+		wantCode int
+	}{
+		{"found", "7", http.StatusOK},
+		{"not found", "404", http.StatusNotFound},
+		{"invalid ID", "abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.HandleGetPullZone(w, newTestRequest(http.MethodGet, "/pullzone/"+tt.id, nil, map[string]string{"pullZoneID": tt.id}))
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.wantCode, w.Code)
+		}
+	}
+}
+
+func TestHandleCreateAndUpdatePullZone(t *testing.T) {
+	t.Parallel()
+
+	var gotBody string
+	var gotID int64
+	client := &mockBunnyClient{
+		addPullZoneFunc: func(_ context.Context, body json.RawMessage) (json.RawMessage, error) {
+			gotBody = string(body)
+			return json.RawMessage(`{"Id":9}`), nil
+		},
+		updatePullZoneFunc: func(_ context.Context, id int64, body json.RawMessage) (json.RawMessage, error) {
+			gotID, gotBody = id, string(body)
+			return json.RawMessage(`{"Id":7}`), nil
+		},
+	}
+	h := newPullZoneTestHandler(client)
+
+	w := httptest.NewRecorder()
+	h.HandleCreatePullZone(w, newTestRequest(http.MethodPost, "/pullzone", strings.NewReader(`{"Name":"cdn"}`), nil))
+	if w.Code != http.StatusCreated || w.Body.String() != `{"Id":9}` || gotBody != `{"Name":"cdn"}` {
+		t.Errorf("create: got %d %s, body forwarded %s", w.Code, w.Body.String(), gotBody)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleUpdatePullZone(w, newTestRequest(http.MethodPost, "/pullzone/7", strings.NewReader(`{"EnableLogging":true}`), map[string]string{"pullZoneID": "7"}))
+	if w.Code != http.StatusOK || gotID != 7 || gotBody != `{"EnableLogging":true}` {
+		t.Errorf("update: got %d, id %d, body forwarded %s", w.Code, gotID, gotBody)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleUpdatePullZone(w, newTestRequest(http.MethodPost, "/pullzone/7", strings.NewReader(`not json`), map[string]string{"pullZoneID": "7"}))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("update with invalid body: expected status 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleCreatePullZone(w, newTestRequest(http.MethodPost, "/pullzone", nil, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("create without body: expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandleDeleteAndPurgePullZone(t *testing.T) {
+	t.Parallel()
+
+	var deleted, purged int64
+	var purgeBody json.RawMessage
+	client := &mockBunnyClient{
+		deletePullZoneFunc: func(_ context.Context, id int64) error {
+			deleted = id
+			return nil
+		},
+		purgePullZoneCacheFunc: func(_ context.Context, id int64, body json.RawMessage) error {
+			purged, purgeBody = id, body
+			return nil
+		},
+	}
+	h := newPullZoneTestHandler(client)
+	params := map[string]string{"pullZoneID": "7"}
+
+	w := httptest.NewRecorder()
+	h.HandleDeletePullZone(w, newTestRequest(http.MethodDelete, "/pullzone/7", nil, params))
+	if w.Code != http.StatusNoContent || deleted != 7 {
+		t.Errorf("delete: got %d, deleted %d", w.Code, deleted)
+	}
+
+	// The purge body is optional.
+	w = httptest.NewRecorder()
+	h.HandlePurgePullZoneCache(w, newTestRequest(http.MethodPost, "/pullzone/7/purgeCache", nil, params))
+	if w.Code != http.StatusNoContent || purged != 7 || purgeBody != nil {
+		t.Errorf("purge: got %d, purged %d, body %s", w.Code, purged, purgeBody)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandlePurgePullZoneCache(w, newTestRequest(http.MethodPost, "/pullzone/7/purgeCache", strings.NewReader(`{"CacheTag":"img"}`), params))
+	if w.Code != http.StatusNoContent || string(purgeBody) != `{"CacheTag":"img"}` {
+		t.Errorf("purge with tag: got %d, body %s", w.Code, purgeBody)
+	}
+}
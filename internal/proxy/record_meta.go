@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// Limits on record metadata, to keep annotations small.
+const (
+	maxRecordMetaKeys     = 32
+	maxRecordMetaKeyLen   = 64
+	maxRecordMetaValueLen = 1024
+)
+
+// RecordMetaStore defines the storage operations for proxy-side record metadata.
+type RecordMetaStore interface {
+	GetRecordMeta(ctx context.Context, zoneID, recordID int64) (*storage.RecordMeta, error)
+	ListRecordMeta(ctx context.Context, zoneID int64) ([]*storage.RecordMeta, error)
+	SetRecordMeta(ctx context.Context, m *storage.RecordMeta) (*storage.RecordMeta, error)
+	DeleteRecordMeta(ctx context.Context, zoneID, recordID int64) error
+	DeleteZoneRecordMeta(ctx context.Context, zoneID int64) error
+}
+
+// SetRecordMetaStore enables record metadata: the PATCH .../meta endpoint and
+// the ProxyMeta field on proxied records. Must be called before NewRouter.
+func (h *Handler) SetRecordMetaStore(store RecordMetaStore) {
+	h.meta = store
+}
+
+// recordWithMeta is a bunny.net record with its proxy-side metadata attached.
+type recordWithMeta struct {
+	bunny.Record
+	ProxyMeta map[string]string `json:"ProxyMeta,omitempty"`
+}
+
+// zoneWithMeta is a bunny.net zone whose records carry proxy-side metadata.
+type zoneWithMeta struct {
+	*bunny.Zone
+	Records []recordWithMeta `json:"Records"`
+}
+
+// RecordMetaResponse is returned by PATCH /dnszone/{zoneID}/records/{recordID}/meta.
+type RecordMetaResponse struct {
+	ZoneID    int64             `json:"ZoneId"`
+	RecordID  int64             `json:"RecordId"`
+	Meta      map[string]string `json:"Meta"`
+	UpdatedBy string            `json:"UpdatedBy,omitempty"`
+	UpdatedAt string            `json:"UpdatedAt,omitempty"`
+}
+
+// HandleUpdateRecordMeta merges metadata annotations into a record.
+// PATCH /dnszone/{zoneID}/records/{recordID}/meta
+// Body: {"owner": "team-a", "ticket": "OPS-123", "purpose": null}
+// String values are set; null removes a key. The record must exist and be
+// visible to the token (record type restrictions apply).
+func (h *Handler) HandleUpdateRecordMeta(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	recordID, err := strconv.ParseInt(chi.URLParam(r, "recordID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid record ID")
+		return
+	}
+
+	var patch map[string]*string
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Only annotate records that exist and that this token can see
+	zone, err := h.upstream(ctx).GetZone(ctx, zoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+	if !hasRecord(filterRecordsByPermission(zone.Records, auth.GetKeyInfo(ctx), zoneID), recordID) {
+		writeError(w, http.StatusNotFound, "record not found")
+		return
+	}
+
+	meta := make(map[string]string)
+	existing, err := h.meta.GetRecordMeta(ctx, zoneID, recordID)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Error("failed to get record metadata", "error", err, "zone_id", zoneID, "record_id", recordID)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if existing != nil {
+		meta = existing.Meta
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(meta, key)
+			continue
+		}
+		meta[key] = *value
+	}
+	if err := validateRecordMeta(meta); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	saved, err := h.meta.SetRecordMeta(ctx, &storage.RecordMeta{
+		ZoneID:    zoneID,
+		RecordID:  recordID,
+		Meta:      meta,
+		UpdatedBy: tokenName(r),
+	})
+	if err != nil {
+		h.logger.Error("failed to set record metadata", "error", err, "zone_id", zoneID, "record_id", recordID)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	h.logger.Info("update record metadata", "zone_id", zoneID, "record_id", recordID, "token", tokenName(r))
+
+	resp := RecordMetaResponse{
+		ZoneID:    zoneID,
+		RecordID:  recordID,
+		Meta:      saved.Meta,
+		UpdatedBy: saved.UpdatedBy,
+	}
+	if resp.Meta == nil {
+		resp.Meta = map[string]string{}
+	}
+	if !saved.UpdatedAt.IsZero() {
+		resp.UpdatedAt = saved.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validateRecordMeta enforces the metadata size limits.
+func validateRecordMeta(meta map[string]string) error {
+	if len(meta) > maxRecordMetaKeys {
+		return fmt.Errorf("too many metadata keys (max %d)", maxRecordMetaKeys)
+	}
+	for key, value := range meta {
+		if key == "" || len(key) > maxRecordMetaKeyLen {
+			return fmt.Errorf("metadata keys must be 1-%d characters", maxRecordMetaKeyLen)
+		}
+		if len(value) > maxRecordMetaValueLen {
+			return fmt.Errorf("metadata value for %q exceeds %d characters", key, maxRecordMetaValueLen)
+		}
+	}
+	return nil
+}
+
+// hasRecord reports whether records contains a record with the given ID.
+func hasRecord(records []bunny.Record, recordID int64) bool {
+	for _, record := range records {
+		if record.ID == recordID {
+			return true
+		}
+	}
+	return false
+}
+
+// attachRecordMeta pairs records with their stored metadata.
+// Metadata lookup failures are logged and the records are returned without it,
+// so annotations never block reads of DNS data.
+func (h *Handler) attachRecordMeta(ctx context.Context, zoneID int64, records []bunny.Record) []recordWithMeta {
+	result := make([]recordWithMeta, len(records))
+	for i, record := range records {
+		result[i].Record = record
+	}
+
+	metas, err := h.meta.ListRecordMeta(ctx, zoneID)
+	if err != nil {
+		h.logger.Error("failed to list record metadata", "error", err, "zone_id", zoneID)
+		return result
+	}
+
+	byRecord := make(map[int64]map[string]string, len(metas))
+	for _, m := range metas {
+		byRecord[m.RecordID] = m.Meta
+	}
+	for i := range result {
+		result[i].ProxyMeta = byRecord[result[i].ID]
+	}
+	return result
+}
+
+// forgetRecordMeta removes metadata for a deleted record, logging failures.
+func (h *Handler) forgetRecordMeta(ctx context.Context, zoneID, recordID int64) {
+	if h.meta == nil {
+		return
+	}
+	if err := h.meta.DeleteRecordMeta(ctx, zoneID, recordID); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		h.logger.Error("failed to delete record metadata", "error", err, "zone_id", zoneID, "record_id", recordID)
+	}
+}
+
+// forgetZoneRecordMeta removes metadata for all records of a deleted zone, logging failures.
+func (h *Handler) forgetZoneRecordMeta(ctx context.Context, zoneID int64) {
+	if h.meta == nil {
+		return
+	}
+	if err := h.meta.DeleteZoneRecordMeta(ctx, zoneID); err != nil {
+		h.logger.Error("failed to delete zone record metadata", "error", err, "zone_id", zoneID)
+	}
+}
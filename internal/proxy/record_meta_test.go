@@ -0,0 +1,254 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+// newRecordMetaHandler returns a handler backed by zone 1 with an A record (10) and a TXT record (11).
+func newRecordMetaHandler(t *testing.T) (*Handler, storage.Storage) {
+	t.Helper()
+
+	store := newMemoryStorage(t)
+	t.Cleanup(func() { _ = store.Close() })
+
+	client := &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			if id != 1 {
+				return nil, bunny.ErrNotFound
+			}
+			return &bunny.Zone{ID: 1, Domain: "example.com", Records: []bunny.Record{
+				{ID: 10, Type: 0, Name: "www"},
+				{ID: 11, Type: 3, Name: "_acme-challenge"},
+			}}, nil
+		},
+	}
+
+	h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRecordMetaStore(store)
+	return h, store
+}
+
+// patchMeta calls HandleUpdateRecordMeta; withAuth, if set, adds auth state to the request context.
+func patchMeta(h *Handler, withAuth func(context.Context) context.Context, zoneID, recordID, body string) *httptest.ResponseRecorder {
+	r := newTestRequest(http.MethodPatch, "/dnszone/"+zoneID+"/records/"+recordID+"/meta",
+		strings.NewReader(body), map[string]string{"zoneID": zoneID, "recordID": recordID})
+	if withAuth != nil {
+		r = r.WithContext(withAuth(r.Context()))
+	}
+	w := httptest.NewRecorder()
+	h.HandleUpdateRecordMeta(w, r)
+	return w
+}
+
+func TestHandleUpdateRecordMeta_MergePatch(t *testing.T) {
+	t.Parallel()
+	h, _ := newRecordMetaHandler(t)
+
+	w := patchMeta(h, nil, "1", "10", `{"owner":"team-a","ticket":"OPS-1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = patchMeta(h, nil, "1", "10", `{"ticket":null,"purpose":"web frontend"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp RecordMetaResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.ZoneID != 1 || resp.RecordID != 10 || resp.UpdatedAt == "" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(resp.Meta) != 2 || resp.Meta["owner"] != "team-a" || resp.Meta["purpose"] != "web frontend" {
+		t.Errorf("unexpected merged metadata: %v", resp.Meta)
+	}
+}
+
+func TestHandleUpdateRecordMeta_Errors(t *testing.T) {
+	t.Parallel()
+	h, _ := newRecordMetaHandler(t)
+
+	tests := []struct {
+		name     string
+		zoneID   string
+		recordID string
+		body     string
+		wantCode int
+	}{
+		{"invalid zone ID", "x", "10", `{}`, http.StatusBadRequest},
+		{"invalid record ID", "1", "x", `{}`, http.StatusBadRequest},
+		{"invalid body", "1", "10", `[`, http.StatusBadRequest},
+		{"null body", "1", "10", `null`, http.StatusBadRequest},
+		{"unknown zone", "2", "10", `{"owner":"x"}`, http.StatusNotFound},
+		{"unknown record", "1", "99", `{"owner":"x"}`, http.StatusNotFound},
+		{"empty key", "1", "10", `{"":"x"}`, http.StatusBadRequest},
+		{"long value", "1", "10", `{"owner":"` + strings.Repeat("x", maxRecordMetaValueLen+1) + `"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if w := patchMeta(h, nil, tt.zoneID, tt.recordID, tt.body); w.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleUpdateRecordMeta_RecordTypeRestriction(t *testing.T) {
+	t.Parallel()
+	h, _ := newRecordMetaHandler(t)
+
+	withAuth := func(ctx context.Context) context.Context {
+		ctx = auth.WithToken(ctx, &storage.Token{ID: 5, Name: "acme"})
+		return auth.WithPermissions(ctx, []*storage.Permission{
+			{ZoneID: 1, AllowedActions: []string{string(auth.ActionUpdateRecordMeta)}, RecordTypes: []string{"TXT"}},
+		})
+	}
+
+	if w := patchMeta(h, withAuth, "1", "10", `{"owner":"x"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for record type outside permissions, got %d", w.Code)
+	}
+	w := patchMeta(h, withAuth, "1", "11", `{"owner":"x"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for permitted record type, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp RecordMetaResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	if resp.UpdatedBy != "acme" {
+		t.Errorf("expected UpdatedBy to be the token name, got %q", resp.UpdatedBy)
+	}
+}
+
+func TestRecordMeta_ReturnedWithRecords(t *testing.T) {
+	t.Parallel()
+	h, store := newRecordMetaHandler(t)
+
+	if _, err := store.SetRecordMeta(context.Background(), &storage.RecordMeta{
+		ZoneID: 1, RecordID: 11, Meta: map[string]string{"owner": "certbot"},
+	}); err != nil {
+		t.Fatalf("SetRecordMeta failed: %v", err)
+	}
+
+	// List records
+	w := httptest.NewRecorder()
+	h.HandleListRecords(w, newTestRequest(http.MethodGet, "/dnszone/1/records", nil, map[string]string{"zoneID": "1"}))
+	var records []struct {
+		ID        int64             `json:"Id"`
+		Name      string            `json:"Name"`
+		ProxyMeta map[string]string `json:"ProxyMeta"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&records); err != nil {
+		t.Fatalf("failed to decode records: %v", err)
+	}
+	if len(records) != 2 || records[0].ProxyMeta != nil || records[1].ProxyMeta["owner"] != "certbot" || records[1].Name != "_acme-challenge" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+
+	// Get zone
+	w = httptest.NewRecorder()
+	h.HandleGetZone(w, newTestRequest(http.MethodGet, "/dnszone/1", nil, map[string]string{"zoneID": "1"}))
+	if strings.Count(w.Body.String(), `"Records"`) != 1 {
+		t.Errorf("expected a single Records field, got %s", w.Body.String())
+	}
+	var zone struct {
+		Domain  string `json:"Domain"`
+		Records []struct {
+			ProxyMeta map[string]string `json:"ProxyMeta"`
+		} `json:"Records"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&zone); err != nil {
+		t.Fatalf("failed to decode zone: %v", err)
+	}
+	if zone.Domain != "example.com" || len(zone.Records) != 2 || zone.Records[1].ProxyMeta["owner"] != "certbot" {
+		t.Errorf("unexpected zone: %+v", zone)
+	}
+}
+
+func TestRecordMeta_ListFailureDoesNotBlockReads(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: 1, Records: []bunny.Record{{ID: 10}}}, nil
+		},
+	}
+	h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRecordMetaStore(&mockstore.MockStorage{
+		ListRecordMetaFunc: func(context.Context, int64) ([]*storage.RecordMeta, error) {
+			return nil, errors.New("db locked")
+		},
+	})
+
+	w := httptest.NewRecorder()
+	h.HandleListRecords(w, newTestRequest(http.MethodGet, "/dnszone/1/records", nil, map[string]string{"zoneID": "1"}))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 despite metadata failure, got %d", w.Code)
+	}
+}
+
+func TestRecordMeta_ClearedOnDelete(t *testing.T) {
+	t.Parallel()
+	h, store := newRecordMetaHandler(t)
+	ctx := context.Background()
+
+	for _, recordID := range []int64{10, 11} {
+		if _, err := store.SetRecordMeta(ctx, &storage.RecordMeta{ZoneID: 1, RecordID: recordID, Meta: map[string]string{"k": "v"}}); err != nil {
+			t.Fatalf("SetRecordMeta failed: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.HandleDeleteRecord(w, newTestRequest(http.MethodDelete, "/dnszone/1/records/10", nil, map[string]string{"zoneID": "1", "recordID": "10"}))
+	if _, err := store.GetRecordMeta(ctx, 1, 10); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected record metadata to be removed, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleDeleteZone(w, newTestRequest(http.MethodDelete, "/dnszone/1", nil, map[string]string{"zoneID": "1"}))
+	if metas, _ := store.ListRecordMeta(ctx, 1); len(metas) != 0 {
+		t.Errorf("expected zone metadata to be removed, got %+v", metas)
+	}
+}
+
+func TestRecordMeta_RouteOnlyWhenEnabled(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) {
+			return &bunny.Zone{ID: 1, Records: []bunny.Record{{ID: 2}}}, nil
+		},
+	}
+	noAuth := func(next http.Handler) http.Handler { return next }
+
+	for _, enabled := range []bool{false, true} {
+		h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		if enabled {
+			h.SetRecordMetaStore(&mockstore.MockStorage{})
+		}
+		router := NewRouter(h, noAuth, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/dnszone/1/records/2/meta", strings.NewReader(`{"owner":"x"}`)))
+		if enabled && w.Code != http.StatusOK {
+			t.Errorf("expected 200 when enabled, got %d: %s", w.Code, w.Body.String())
+		}
+		if !enabled && w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("expected route to be absent when disabled, got %d", w.Code)
+		}
+	}
+}
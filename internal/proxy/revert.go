@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+var (
+	// ErrRevertConflict is returned when a change cannot be reverted, such as
+	// when the record was changed again since.
+	ErrRevertConflict = errors.New("change cannot be reverted")
+
+	// ErrRevertUpstream is returned when bunny.net fails a revert.
+	ErrRevertUpstream = errors.New("upstream request failed")
+)
+
+// conflictFields are the record fields compared to tell whether a record is
+// still as a change left it. The others are set or normalized by bunny.net.
+var conflictFields = []struct {
+	key string
+	get func(*bunny.Record) any
+}{
+	{"Type", func(r *bunny.Record) any { return r.Type }},
+	{"Name", func(r *bunny.Record) any { return r.Name }},
+	{"Value", func(r *bunny.Record) any { return r.Value }},
+	{"Ttl", func(r *bunny.Record) any { return r.TTL }},
+	{"Priority", func(r *bunny.Record) any { return r.Priority }},
+	{"Weight", func(r *bunny.Record) any { return r.Weight }},
+	{"Port", func(r *bunny.Record) any { return r.Port }},
+	{"Flags", func(r *bunny.Record) any { return r.Flags }},
+	{"Tag", func(r *bunny.Record) any { return r.Tag }},
+	{"Disabled", func(r *bunny.Record) any { return r.Disabled }},
+}
+
+// matchesSnapshot reports whether rec has the values of a change snapshot.
+// Fields the snapshot leaves out, as an update request may, match anything.
+func matchesSnapshot(rec *bunny.Record, snap []byte) bool {
+	var want bunny.Record
+	var keys map[string]json.RawMessage
+	if json.Unmarshal(snap, &want) != nil || json.Unmarshal(snap, &keys) != nil {
+		return false
+	}
+	for _, f := range conflictFields {
+		if _, ok := keys[f.key]; ok && f.get(rec) != f.get(&want) {
+			return false
+		}
+	}
+	return true
+}
+
+// RevertChange undoes a recorded record change by applying its inverse in
+// the account it was made in: a created record is deleted, an updated record
+// gets its previous values back and a deleted record is created again, with
+// a new ID. The record must still be as the change left it, and a deleted
+// record must not have been recreated; otherwise nothing is changed and
+// ErrRevertConflict is returned. The revert is recorded as a change by actor
+// and returned. Returns storage.ErrNotFound if the change does not exist.
+func (h *Handler) RevertChange(ctx context.Context, changeID int64, actor string) (*storage.RecordChange, error) {
+	if h.history == nil {
+		return nil, errors.New("record history is not enabled")
+	}
+	change, err := h.history.GetRecordChange(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	client, ok := h.clientFor(change.Account)
+	if !ok {
+		return nil, fmt.Errorf("account %q is not configured", change.Account)
+	}
+
+	// Serialize with conditional writes to the zone
+	mu := zoneLock(change.ZoneID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	zone, err := uncached(client).GetZone(ctx, change.ZoneID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRevertUpstream, err)
+	}
+	var current *bunny.Record
+	if i := slices.IndexFunc(zone.Records, func(rec bunny.Record) bool { return rec.ID == change.RecordID }); i >= 0 {
+		current = &zone.Records[i]
+	}
+
+	var before bunny.Record
+	if change.Action != storage.RecordChangeCreate {
+		if change.Before == nil {
+			return nil, fmt.Errorf("%w: the record was not captured before the change", ErrRevertConflict)
+		}
+		if err := json.Unmarshal(change.Before, &before); err != nil {
+			return nil, fmt.Errorf("failed to decode record snapshot: %w", err)
+		}
+	}
+
+	// The inverse change, recorded once it is applied
+	zoneID := change.ZoneID
+	revert := &storage.RecordChange{ZoneID: zoneID, RecordID: change.RecordID, Actor: actor, Account: change.Account}
+	var revertBefore, revertAfter any
+	switch change.Action {
+	case storage.RecordChangeCreate:
+		if current == nil || !matchesSnapshot(current, change.After) {
+			return nil, fmt.Errorf("%w: record %d was changed or deleted since", ErrRevertConflict, change.RecordID)
+		}
+		if err := client.DeleteRecord(ctx, zoneID, change.RecordID); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRevertUpstream, err)
+		}
+		h.forgetRecordMeta(ctx, zoneID, change.RecordID)
+		revert.Action, revertBefore = storage.RecordChangeDelete, current
+		h.publish(ctx, webhook.EventRecordDeleted, map[string]any{
+			"zone_id": zoneID, "record_id": change.RecordID, "actor": actor,
+		})
+
+	case storage.RecordChangeUpdate:
+		if current == nil || !matchesSnapshot(current, change.After) {
+			return nil, fmt.Errorf("%w: record %d was changed or deleted since", ErrRevertConflict, change.RecordID)
+		}
+		req := recordToRequest(before)
+		record, err := client.UpdateRecord(ctx, zoneID, change.RecordID, req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRevertUpstream, err)
+		}
+		revert.Action, revertBefore, revertAfter = storage.RecordChangeUpdate, current, updatedRecord(record, req)
+		h.publish(ctx, webhook.EventRecordUpdated, map[string]any{
+			"zone_id": zoneID, "record_id": change.RecordID, "record": req, "actor": actor,
+		})
+
+	case storage.RecordChangeDelete:
+		if current != nil || slices.ContainsFunc(zone.Records, func(rec bunny.Record) bool { return matchesSnapshot(&rec, change.Before) }) {
+			return nil, fmt.Errorf("%w: the deleted record exists again", ErrRevertConflict)
+		}
+		record, err := client.AddRecord(ctx, zoneID, recordToRequest(before))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRevertUpstream, err)
+		}
+		revert.Action, revertAfter = storage.RecordChangeCreate, record
+		revert.RecordID = 0
+		if record != nil {
+			revert.RecordID = record.ID
+		}
+		h.publish(ctx, webhook.EventRecordCreated, map[string]any{
+			"zone_id": zoneID, "record": record, "actor": actor,
+		})
+
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrRevertConflict, change.Action)
+	}
+	h.zones.invalidate(zoneID)
+
+	h.logger.Info("revert record change", "change_id", changeID, "zone_id", zoneID, "record_id", change.RecordID,
+		"action", change.Action, "actor", actor)
+	saved, err := h.saveRecordChange(ctx, zoneID, revert.RecordID, revert.Action, revertBefore, revertAfter,
+		changeAuthor{name: actor, account: change.Account})
+	if err != nil {
+		// The revert was applied; only its history entry is missing
+		h.logger.Error("failed to record change", "zone_id", zoneID, "record_id", revert.RecordID, "action", revert.Action, "error", err)
+		return revert, nil
+	}
+	return saved, nil
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// recordZone is a zone held in memory by a mock bunny.net client.
+type recordZone struct {
+	mu      sync.Mutex
+	records []bunny.Record
+	nextID  int64
+}
+
+func (z *recordZone) client() *mockBunnyClient {
+	return &mockBunnyClient{
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			z.mu.Lock()
+			defer z.mu.Unlock()
+			return &bunny.Zone{ID: id, Records: slices.Clone(z.records)}, nil
+		},
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			z.mu.Lock()
+			defer z.mu.Unlock()
+			z.nextID++
+			rec := bunny.Record{ID: z.nextID, Type: req.Type, Name: req.Name, Value: req.Value, TTL: req.TTL}
+			z.records = append(z.records, rec)
+			return &rec, nil
+		},
+		updateRecordFunc: func(_ context.Context, _, id int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			z.mu.Lock()
+			defer z.mu.Unlock()
+			for i := range z.records {
+				if z.records[i].ID == id {
+					z.records[i].Name, z.records[i].Value, z.records[i].TTL = req.Name, req.Value, req.TTL
+					return nil, nil
+				}
+			}
+			return nil, bunny.ErrNotFound
+		},
+		deleteRecordFunc: func(_ context.Context, _, id int64) error {
+			z.mu.Lock()
+			defer z.mu.Unlock()
+			z.records = slices.DeleteFunc(z.records, func(rec bunny.Record) bool { return rec.ID == id })
+			return nil
+		},
+	}
+}
+
+func (z *recordZone) get(id int64) *bunny.Record {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	for _, rec := range z.records {
+		if rec.ID == id {
+			return &rec
+		}
+	}
+	return nil
+}
+
+func TestRevertChange(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{nextID: 10}
+	history := &fakeHistory{}
+	h := NewHandler(zone.client(), slog.Default())
+	h.SetRecordHistoryStore(history)
+	ctx := context.Background()
+	author := changeAuthor{name: "ci"}
+
+	// A create, an update and a delete, as the handlers record them
+	created, _ := zone.client().AddRecord(ctx, 1, &bunny.AddRecordRequest{Type: 3, Name: "a", Value: "v1", TTL: 300})
+	h.recordChange(ctx, 1, created.ID, storage.RecordChangeCreate, nil, created, author)
+	old := *created
+	updateReq := &bunny.AddRecordRequest{Type: 3, Name: "a", Value: "v2", TTL: 300}
+	_, _ = zone.client().UpdateRecord(ctx, 1, created.ID, updateReq)
+	h.recordChange(ctx, 1, created.ID, storage.RecordChangeUpdate, &old, updateReq, author)
+
+	// Reverting the update restores v1
+	revert, err := h.RevertChange(ctx, 2, "admin")
+	if err != nil {
+		t.Fatalf("RevertChange(update) failed: %v", err)
+	}
+	if rec := zone.get(created.ID); rec == nil || rec.Value != "v1" {
+		t.Errorf("expected v1 restored, got %+v", rec)
+	}
+	if revert.Action != storage.RecordChangeUpdate || revert.Actor != "admin" || revert.ID != 3 {
+		t.Errorf("unexpected revert change: %+v", revert)
+	}
+
+	// The record no longer matches the update: reverting it again conflicts
+	if _, err := h.RevertChange(ctx, 2, "admin"); !errors.Is(err, ErrRevertConflict) {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+
+	// A revert can itself be reverted
+	if _, err := h.RevertChange(ctx, 3, "admin"); err != nil {
+		t.Fatalf("RevertChange(revert) failed: %v", err)
+	}
+	current := zone.get(created.ID)
+	if current == nil || current.Value != "v2" {
+		t.Fatalf("expected v2 back, got %+v", current)
+	}
+
+	// The create cannot be reverted once the record is deleted; the delete can
+	// be, and recreates the record
+	h.recordChange(ctx, 1, created.ID, storage.RecordChangeDelete, current, nil, author)
+	_ = zone.client().DeleteRecord(ctx, 1, created.ID)
+	if _, err := h.RevertChange(ctx, 1, "admin"); !errors.Is(err, ErrRevertConflict) {
+		t.Errorf("expected a conflict reverting the create of a deleted record, got %v", err)
+	}
+	deleteID := int64(len(history.changes))
+	revert, err = h.RevertChange(ctx, deleteID, "admin")
+	if err != nil {
+		t.Fatalf("RevertChange(delete) failed: %v", err)
+	}
+	if rec := zone.get(revert.RecordID); revert.Action != storage.RecordChangeCreate || rec == nil || rec.Value != "v2" {
+		t.Errorf("expected the record recreated, got %+v and %+v", revert, rec)
+	}
+	if _, err := h.RevertChange(ctx, deleteID, "admin"); !errors.Is(err, ErrRevertConflict) {
+		t.Errorf("expected a conflict restoring a record twice, got %v", err)
+	}
+
+	if _, err := h.RevertChange(ctx, 99, "admin"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
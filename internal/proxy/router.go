@@ -6,9 +6,102 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
 	"github.com/sipico/bunny-api-proxy/internal/middleware"
 )
 
+// route is one proxy endpoint. The same table registers handlers and
+// backs GET /api/routes, so the published access rules cannot drift from
+// what the router enforces.
+type route struct {
+	method      string
+	pattern     string
+	handler     http.HandlerFunc
+	adminOnly   bool        // wrapped in requireAdmin
+	action      auth.Action // action checked by auth.CheckPermissions; empty if unrecognized
+	description string
+}
+
+// routes returns the proxy's routes, including optional ones that are enabled.
+func (h *Handler) routes() []route {
+	rs := []route{
+		{http.MethodGet, "/dnszone", h.HandleListZones, false, auth.ActionListZones, "List zones"},
+		{http.MethodPost, "/dnszone", h.HandleCreateZone, false, auth.ActionCreateZone, "Create a zone"},
+		{http.MethodGet, "/dnszone/byname/{domain}", h.HandleFindZoneByName, false, auth.ActionFindZoneByName, "Find the zone a hostname belongs to"},
+		{http.MethodPost, "/dnszone/checkavailability", h.HandleCheckAvailability, true, auth.ActionCheckAvailability, "Check whether a domain can be added"},
+		{http.MethodPost, "/dnszone/{zoneID}/import", h.HandleImportRecords, false, auth.ActionImportRecords, "Import records from a BIND zone file or CSV"},
+		{http.MethodGet, "/dnszone/{zoneID}/export", h.HandleExportRecords, false, auth.ActionExportRecords, "Export records as a BIND zone file, JSON or CSV"},
+		{http.MethodPost, "/dnszone/{zoneID}/diff", h.HandleDiffRecords, false, auth.ActionDiffRecords, "Compare a zone file with the zone's records"},
+		{http.MethodPost, "/dnszone/{zoneID}/sync", h.HandleSyncRecords, false, auth.ActionSyncRecords, "Make the zone's records match a zone file"},
+		{http.MethodGet, "/dnszone/{zoneID}/dnssec", h.HandleGetDNSSEC, false, auth.ActionGetDNSSEC, "Get DNSSEC status and the DS record"},
+		{http.MethodPost, "/dnszone/{zoneID}/dnssec", h.HandleEnableDNSSEC, true, auth.ActionEnableDNSSEC, "Enable DNSSEC"},
+		{http.MethodDelete, "/dnszone/{zoneID}/dnssec", h.HandleDisableDNSSEC, true, auth.ActionDisableDNSSEC, "Disable DNSSEC"},
+		{http.MethodPost, "/dnszone/{zoneID}/certificate/issue", h.HandleIssueCertificate, true, auth.ActionIssueCertificate, "Issue a wildcard certificate"},
+		{http.MethodGet, "/dnszone/statistics", h.HandleGetStatisticsSummary, false, auth.ActionGetStatisticsSummary, "Sum DNS query statistics across permitted zones"},
+		{http.MethodGet, "/dnszone/{zoneID}/statistics", h.HandleGetStatistics, false, auth.ActionGetStatistics, "Get DNS query statistics"},
+		{http.MethodPost, "/dnszone/records/scan", h.HandleTriggerScan, true, auth.ActionTriggerDNSScan, "Trigger a DNS record scan"},
+		{http.MethodPost, "/dnszone/{zoneID}", h.HandleUpdateZone, true, auth.ActionUpdateZone, "Update zone settings"},
+		{http.MethodGet, "/dnszone/{zoneID}", h.HandleGetZone, false, auth.ActionGetZone, "Get a zone and its records"},
+		{http.MethodDelete, "/dnszone/{zoneID}", h.HandleDeleteZone, false, "", "Delete a zone"},
+		{http.MethodGet, "/dnszone/{zoneID}/records/scan", h.HandleGetScanResult, true, auth.ActionGetDNSScanResult, "Get the latest DNS scan result"},
+		{http.MethodGet, "/dnszone/{zoneID}/records", h.HandleListRecords, false, auth.ActionListRecords, "List records"},
+		{http.MethodPost, "/dnszone/{zoneID}/records", h.HandleAddRecord, false, auth.ActionAddRecord, "Add a record"},
+		{http.MethodPost, "/dnszone/{zoneID}/records/batch", h.HandleBatchRecords, false, auth.ActionBatchRecords, "Apply several record operations"},
+		{http.MethodGet, "/dnszone/{zoneID}/records/{recordID}", h.HandleGetRecord, false, auth.ActionGetRecord, "Get a record"},
+		{http.MethodPost, "/dnszone/{zoneID}/records/{recordID}", h.HandleUpdateRecord, false, auth.ActionUpdateRecord, "Update a record"},
+		{http.MethodDelete, "/dnszone/{zoneID}/records/{recordID}", h.HandleDeleteRecord, false, auth.ActionDeleteRecord, "Delete a record"},
+		{http.MethodGet, "/search/records", h.HandleSearchRecords, false, auth.ActionSearchRecords, "Search records across permitted zones"},
+		{http.MethodGet, "/export", h.HandleExportZones, false, auth.ActionExportZones, "Export permitted zones as one archive"},
+		{http.MethodGet, "/api/routes", h.HandleListRoutes, false, auth.ActionListRoutes, "List routes and the access each requires"},
+		{http.MethodGet, "/pullzone", h.HandleListPullZones, false, auth.ActionListPullZones, "List pull zones"},
+		{http.MethodPost, "/pullzone", h.HandleCreatePullZone, true, auth.ActionCreatePullZone, "Create a pull zone"},
+		{http.MethodGet, "/pullzone/{pullZoneID}", h.HandleGetPullZone, false, auth.ActionGetPullZone, "Get a pull zone"},
+		{http.MethodPost, "/pullzone/{pullZoneID}", h.HandleUpdatePullZone, false, auth.ActionUpdatePullZone, "Update pull zone settings"},
+		{http.MethodDelete, "/pullzone/{pullZoneID}", h.HandleDeletePullZone, true, auth.ActionDeletePullZone, "Delete a pull zone"},
+		{http.MethodPost, "/pullzone/{pullZoneID}/purgeCache", h.HandlePurgePullZoneCache, false, auth.ActionPurgePullZone, "Purge a pull zone's cache"},
+		{http.MethodGet, "/storagezone", h.HandleListStorageZones, false, auth.ActionListStorageZones, "List storage zones"},
+		{http.MethodPost, "/storagezone", h.HandleCreateStorageZone, true, auth.ActionCreateStorageZone, "Create a storage zone"},
+		{http.MethodGet, "/storagezone/{storageZoneID}", h.HandleGetStorageZone, false, auth.ActionGetStorageZone, "Get a storage zone"},
+		{http.MethodDelete, "/storagezone/{storageZoneID}", h.HandleDeleteStorageZone, false, auth.ActionDeleteStorageZone, "Delete a storage zone"},
+		{http.MethodPost, "/storagezone/{storageZoneID}/resetPassword", h.HandleResetStorageZonePassword, false, auth.ActionResetStorageZonePassword, "Reset a storage zone's password"},
+	}
+
+	// Optional proxy-side record metadata
+	if h.meta != nil {
+		rs = append(rs, route{http.MethodPatch, "/dnszone/{zoneID}/records/{recordID}/meta", h.HandleUpdateRecordMeta, false, auth.ActionUpdateRecordMeta, "Update proxy-side record metadata"})
+	}
+
+	// Optional self-service view of the caller's own audit entries
+	if h.activity != nil {
+		rs = append(rs, route{http.MethodGet, "/api/my/activity", h.HandleListMyActivity, false, auth.ActionGetMyActivity, "List the caller's own audit entries"})
+	}
+
+	// Optional background jobs for long imports
+	if h.jobs != nil {
+		rs = append(rs, route{http.MethodGet, "/jobs/{jobID}", h.HandleGetJob, false, auth.ActionGetJob, "Get the status of a background job"})
+	}
+
+	// Optional record changes made at a later time
+	if h.schedule != nil {
+		rs = append(rs,
+			route{http.MethodGet, "/dnszone/{zoneID}/scheduled", h.HandleListScheduledChanges, false, auth.ActionListScheduledChanges, "List scheduled record changes"},
+			route{http.MethodGet, "/dnszone/{zoneID}/scheduled/{changeID}", h.HandleGetScheduledChange, false, auth.ActionGetScheduledChange, "Get a scheduled record change"},
+			route{http.MethodDelete, "/dnszone/{zoneID}/scheduled/{changeID}", h.HandleCancelScheduledChange, false, auth.ActionCancelScheduledChange, "Cancel a scheduled record change"},
+		)
+	}
+
+	// Optional read-only account endpoints for dashboards
+	if h.accountPassthrough {
+		rs = append(rs,
+			route{http.MethodGet, "/statistics", h.HandleGetAccountStatistics, true, auth.ActionGetAccountStatistics, "Get account-wide CDN statistics"},
+			route{http.MethodGet, "/billing", h.HandleGetBilling, true, auth.ActionGetBilling, "Get account billing"},
+			route{http.MethodGet, "/billing/summary", h.HandleGetBillingSummary, true, auth.ActionGetBilling, "Get account billing summary"},
+		)
+	}
+
+	return rs
+}
+
 // NewRouter creates a Chi router with all proxy endpoints.
 // The authMiddleware parameter should be auth.Middleware(validator).
 // The logger parameter is used for debug logging of HTTP requests/responses.
@@ -18,28 +111,20 @@ func NewRouter(handler *Handler, authMiddleware func(http.Handler) http.Handler,
 	// Apply middlewares in order
 	r.Use(middleware.RequestID)                // Add request ID first
 	r.Use(middleware.HTTPLogging(logger, nil)) // Log with no allowlist (DNS API has no secrets)
-	r.Use(middleware.MaxBodySize(1 << 20))     // 1MB limit
+	r.Use(handler.limitRequest)                // Body size limit, longer deadlines for imports
 	r.Use(authMiddleware)                      // Auth after logging
+	r.Use(handler.requireAccount)              // Token's upstream account must exist
 
 	// Wire handler methods to routes
-	r.Get("/dnszone", handler.HandleListZones)
-	r.Post("/dnszone", handler.HandleCreateZone)
-	r.With(requireAdmin).Post("/dnszone/checkavailability", handler.HandleCheckAvailability)
-	r.With(requireAdmin).Post("/dnszone/{zoneID}/import", handler.HandleImportRecords)
-	r.With(requireAdmin).Get("/dnszone/{zoneID}/export", handler.HandleExportRecords)
-	r.With(requireAdmin).Post("/dnszone/{zoneID}/dnssec", handler.HandleEnableDNSSEC)
-	r.With(requireAdmin).Delete("/dnszone/{zoneID}/dnssec", handler.HandleDisableDNSSEC)
-	r.With(requireAdmin).Post("/dnszone/{zoneID}/certificate/issue", handler.HandleIssueCertificate)
-	r.With(requireAdmin).Get("/dnszone/{zoneID}/statistics", handler.HandleGetStatistics)
-	r.With(requireAdmin).Post("/dnszone/records/scan", handler.HandleTriggerScan)
-	r.With(requireAdmin).Post("/dnszone/{zoneID}", handler.HandleUpdateZone)
-	r.Get("/dnszone/{zoneID}", handler.HandleGetZone)
-	r.Delete("/dnszone/{zoneID}", handler.HandleDeleteZone)
-	r.With(requireAdmin).Get("/dnszone/{zoneID}/records/scan", handler.HandleGetScanResult)
-	r.Get("/dnszone/{zoneID}/records", handler.HandleListRecords)
-	r.Post("/dnszone/{zoneID}/records", handler.HandleAddRecord)
-	r.Post("/dnszone/{zoneID}/records/{recordID}", handler.HandleUpdateRecord)
-	r.Delete("/dnszone/{zoneID}/records/{recordID}", handler.HandleDeleteRecord)
+	rs := handler.routes()
+	handler.routeInfo = describeRoutes(rs)
+	for _, rt := range rs {
+		if rt.adminOnly {
+			r.With(requireAdmin).MethodFunc(rt.method, rt.pattern, rt.handler)
+		} else {
+			r.MethodFunc(rt.method, rt.pattern, rt.handler)
+		}
+	}
 
 	return r
 }
@@ -0,0 +1,69 @@
+package proxy
+
+import "net/http"
+
+// Access levels reported by GET /api/routes.
+const (
+	// RouteAccessAdmin routes require an admin token or the master key.
+	RouteAccessAdmin = "admin"
+	// RouteAccessZone routes require a permission for the target zone that grants Action.
+	RouteAccessZone = "zone"
+	// RouteAccessAny routes are open to any valid key; results are limited to what it may see.
+	RouteAccessAny = "any"
+)
+
+// RouteInfo describes one proxy route and what it takes to call it.
+type RouteInfo struct {
+	Method      string `json:"Method"`
+	Path        string `json:"Path"`
+	Access      string `json:"Access"`
+	Action      string `json:"Action,omitempty"`
+	Description string `json:"Description"`
+}
+
+// RoutesResponse is the response body for GET /api/routes.
+type RoutesResponse struct {
+	Items []RouteInfo `json:"Items"`
+}
+
+// describeRoutes derives the published route table from the router's routes.
+func describeRoutes(rs []route) []RouteInfo {
+	infos := make([]RouteInfo, 0, len(rs))
+	for _, rt := range rs {
+		infos = append(infos, RouteInfo{
+			Method:      rt.method,
+			Path:        rt.pattern,
+			Access:      routeAccess(rt),
+			Action:      string(rt.action),
+			Description: rt.description,
+		})
+	}
+	return infos
+}
+
+// routeAccess reports the weakest key that can call the route. Routes whose
+// action auth.ParseRequest does not recognize are rejected for scoped tokens,
+// so they are admin-only in practice.
+func routeAccess(rt route) string {
+	switch {
+	case rt.adminOnly, rt.action == "", rt.action.IsAdminOnly():
+		return RouteAccessAdmin
+	case rt.action.RequiresZonePermission():
+		return RouteAccessZone
+	default:
+		return RouteAccessAny
+	}
+}
+
+// Routes returns the route table served by GET /api/routes.
+// It is empty until NewRouter has been called.
+func (h *Handler) Routes() []RouteInfo {
+	return h.routeInfo
+}
+
+// HandleListRoutes returns the proxy's route table with the access each route requires.
+// GET /api/routes
+// Only routes enabled in this deployment are listed.
+func (h *Handler) HandleListRoutes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, RoutesResponse{Items: h.routeInfo})
+}
@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+// newRoutesTestHandler returns a handler with every optional route enabled.
+func newRoutesTestHandler() *Handler {
+	h := NewHandler(&mockBunnyClient{}, nil)
+	h.SetActivityStore(&mockstore.MockStorage{})
+	h.SetRecordMetaStore(&mockstore.MockStorage{})
+	h.SetAccountPassthrough(true)
+	return h
+}
+
+func TestHandleListRoutes(t *testing.T) {
+	t.Parallel()
+	passthrough := func(next http.Handler) http.Handler { return next }
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	w := httptest.NewRecorder()
+	NewRouter(NewHandler(&mockBunnyClient{}, nil), passthrough, logger).
+		ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/routes", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp RoutesResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	got := make(map[string]RouteInfo)
+	for _, ri := range resp.Items {
+		got[ri.Method+" "+ri.Path] = ri
+	}
+
+	tests := []struct {
+		route  string
+		access string
+		action auth.Action
+	}{
+		{"GET /dnszone", RouteAccessAny, auth.ActionListZones},
+		{"POST /dnszone", RouteAccessAdmin, auth.ActionCreateZone},
+		{"GET /dnszone/{zoneID}", RouteAccessZone, auth.ActionGetZone},
+		{"DELETE /dnszone/{zoneID}", RouteAccessAdmin, ""},
+		{"POST /dnszone/{zoneID}/records", RouteAccessZone, auth.ActionAddRecord},
+		{"POST /dnszone/{zoneID}/import", RouteAccessZone, auth.ActionImportRecords},
+		{"GET /api/routes", RouteAccessAny, auth.ActionListRoutes},
+	}
+	for _, tt := range tests {
+		ri, ok := got[tt.route]
+		if !ok {
+			t.Errorf("route %s not listed", tt.route)
+			continue
+		}
+		if ri.Access != tt.access || ri.Action != string(tt.action) {
+			t.Errorf("route %s: access=%q action=%q, want %q %q", tt.route, ri.Access, ri.Action, tt.access, tt.action)
+		}
+		if ri.Description == "" {
+			t.Errorf("route %s has no description", tt.route)
+		}
+	}
+
+	// Disabled optional routes are not advertised
+	for _, route := range []string{"GET /api/my/activity", "GET /billing", "PATCH /dnszone/{zoneID}/records/{recordID}/meta"} {
+		if _, ok := got[route]; ok {
+			t.Errorf("disabled route %s should not be listed", route)
+		}
+	}
+}
+
+func TestRoutes_MatchRegisteredRoutes(t *testing.T) {
+	t.Parallel()
+	passthrough := func(next http.Handler) http.Handler { return next }
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	h := newRoutesTestHandler()
+	router, ok := NewRouter(h, passthrough, logger).(chi.Routes)
+	if !ok {
+		t.Fatal("router does not implement chi.Routes")
+	}
+
+	registered := make(map[string]bool)
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("chi.Walk: %v", err)
+	}
+
+	listed := make(map[string]bool)
+	for _, ri := range h.routeInfo {
+		key := ri.Method + " " + ri.Path
+		listed[key] = true
+		if !registered[key] {
+			t.Errorf("listed route %s is not registered", key)
+		}
+	}
+	for key := range registered {
+		if !listed[key] {
+			t.Errorf("registered route %s is not listed", key)
+		}
+	}
+}
+
+func TestRoutes_ActionsMatchParseRequest(t *testing.T) {
+	t.Parallel()
+
+	for _, rt := range newRoutesTestHandler().routes() {
+		path := strings.NewReplacer("{zoneID}", "1", "{recordID}", "2", "{pullZoneID}", "3", "{storageZoneID}", "4", "{domain}", "www.example.com").Replace(rt.pattern)
+		req := httptest.NewRequest(rt.method, path, strings.NewReader(`{"Type":0}`))
+		parsed, err := auth.ParseRequest(req)
+		if rt.action == "" {
+			if err == nil {
+				t.Errorf("%s %s: ParseRequest = %s, route lists no action", rt.method, rt.pattern, parsed.Action)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: ParseRequest error: %v", rt.method, rt.pattern, err)
+			continue
+		}
+		if parsed.Action != rt.action {
+			t.Errorf("%s %s: ParseRequest = %s, route lists %s", rt.method, rt.pattern, parsed.Action, rt.action)
+		}
+	}
+}
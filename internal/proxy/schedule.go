@@ -0,0 +1,367 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// maxScheduleAhead bounds how far ahead a record change can be scheduled.
+const maxScheduleAhead = 366 * 24 * time.Hour
+
+// ScheduleStore persists record changes to be made at a later time. The
+// changes are made by a schedule.Runner, which calls RunScheduledChange.
+type ScheduleStore interface {
+	CreateScheduledChange(ctx context.Context, c *storage.ScheduledChange) (*storage.ScheduledChange, error)
+	GetScheduledChange(ctx context.Context, id int64) (*storage.ScheduledChange, error)
+	ListScheduledChanges(ctx context.Context, zoneID int64, status string) ([]*storage.ScheduledChange, error)
+	CancelScheduledChange(ctx context.Context, id int64, at time.Time) error
+}
+
+// SetScheduleStore enables scheduled record changes: ?scheduleAt= on record
+// creates and deletes, and the /dnszone/{zoneID}/scheduled routes. Must be
+// called before NewRouter.
+func (h *Handler) SetScheduleStore(store ScheduleStore) {
+	h.schedule = store
+}
+
+// ScheduledChangeResponse is the response body for scheduled record changes.
+// Record is the record to create; RecordId is the record to delete, or the
+// record a create made once it has run.
+type ScheduledChangeResponse struct {
+	ID         int64           `json:"Id"`
+	ZoneID     int64           `json:"ZoneId"`
+	Action     string          `json:"Action"`
+	RecordID   int64           `json:"RecordId,omitempty"`
+	Record     json.RawMessage `json:"Record,omitempty"`
+	Status     string          `json:"Status"`
+	Error      string          `json:"Error,omitempty"`
+	RunAt      string          `json:"RunAt"`
+	CreatedAt  string          `json:"CreatedAt"`
+	FinishedAt string          `json:"FinishedAt,omitempty"`
+}
+
+// newScheduledChangeResponse converts a stored scheduled change.
+func newScheduledChangeResponse(c *storage.ScheduledChange) ScheduledChangeResponse {
+	resp := ScheduledChangeResponse{
+		ID:        c.ID,
+		ZoneID:    c.ZoneID,
+		Action:    c.Action,
+		RecordID:  c.RecordID,
+		Record:    c.Request,
+		Status:    c.Status,
+		Error:     c.Error,
+		RunAt:     c.RunAt.UTC().Format(time.RFC3339),
+		CreatedAt: c.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !c.FinishedAt.IsZero() {
+		resp.FinishedAt = c.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	return resp
+}
+
+// parseScheduleAt parses the scheduleAt parameter, an RFC 3339 time that
+// must be in the future and at most maxScheduleAhead away.
+func parseScheduleAt(s string, now time.Time) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("invalid scheduleAt parameter: expected an RFC 3339 time")
+	}
+	if !at.After(now) {
+		return time.Time{}, errors.New("scheduleAt must be in the future")
+	}
+	if at.Sub(now) > maxScheduleAhead {
+		return time.Time{}, fmt.Errorf("scheduleAt must be within %d days", int(maxScheduleAhead/(24*time.Hour)))
+	}
+	return at, nil
+}
+
+// scheduleChange records a record create or delete requested with
+// ?scheduleAt= instead of making it, and responds 202 Accepted with the
+// scheduled change. record is the record to create, nil for a delete. The
+// caller's permissions were checked as for the change itself.
+func (h *Handler) scheduleChange(w http.ResponseWriter, r *http.Request, change *storage.ScheduledChange, record *bunny.AddRecordRequest) {
+	if h.schedule == nil {
+		writeError(w, http.StatusBadRequest, "scheduled changes are not enabled")
+		return
+	}
+	runAt, err := parseScheduleAt(r.URL.Query().Get("scheduleAt"), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	zone, err := uncached(h.upstream(ctx)).GetZone(ctx, change.ZoneID)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+	protections, err := h.protections(ctx, change.ZoneID)
+	if err != nil {
+		h.logger.Error("failed to list protected records", "zone_id", change.ZoneID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to check protected records")
+		return
+	}
+	if change.Action == storage.RecordChangeDelete {
+		i := slices.IndexFunc(zone.Records, func(rec bunny.Record) bool { return rec.ID == change.RecordID })
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "record not found")
+			return
+		}
+		if p := protecting(protections, &zone.Records[i]); p != nil {
+			writeError(w, http.StatusForbidden, protectedMessage(p))
+			return
+		}
+	}
+	if record != nil {
+		if p := protecting(protections, requestedRecord(record, record.Type)); p != nil {
+			writeError(w, http.StatusForbidden, protectedMessage(p))
+			return
+		}
+	}
+	if record != nil {
+		if change.Request, err = json.Marshal(record); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to encode record")
+			return
+		}
+	}
+
+	change.RunAt = runAt
+	change.TokenID = jobTokenID(r)
+	change.Actor = tokenName(r)
+	change.Account = h.accountOf(ctx)
+	scheduled, err := h.schedule.CreateScheduledChange(ctx, change)
+	if err != nil {
+		h.logger.Error("failed to schedule record change", "zone_id", change.ZoneID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to schedule change")
+		return
+	}
+
+	h.logger.Info("schedule record change", "zone_id", scheduled.ZoneID, "change_id", scheduled.ID,
+		"action", scheduled.Action, "record_id", scheduled.RecordID, "run_at", scheduled.RunAt, "token", scheduled.Actor)
+	w.Header().Set("Location", fmt.Sprintf("/dnszone/%d/scheduled/%d", scheduled.ZoneID, scheduled.ID))
+	writeJSON(w, http.StatusAccepted, newScheduledChangeResponse(scheduled))
+}
+
+// HandleListScheduledChanges lists a zone's scheduled record changes in the
+// order they run.
+// GET /dnszone/{zoneID}/scheduled?status=pending
+// Granted by list_records. Scoped tokens limited to some record types only
+// see creates of those types.
+func (h *Handler) HandleListScheduledChanges(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", storage.ScheduledChangePending, storage.ScheduledChangeRunning, storage.ScheduledChangeSucceeded,
+		storage.ScheduledChangeFailed, storage.ScheduledChangeCancelled:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid status parameter")
+		return
+	}
+
+	changes, err := h.schedule.ListScheduledChanges(r.Context(), zoneID, status)
+	if err != nil {
+		h.logger.Error("failed to list scheduled changes", "zone_id", zoneID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to list scheduled changes")
+		return
+	}
+
+	resp := make([]ScheduledChangeResponse, 0, len(changes))
+	for _, c := range changes {
+		if canSeeScheduledChange(r, c) {
+			resp = append(resp, newScheduledChangeResponse(c))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleGetScheduledChange returns one scheduled record change.
+// GET /dnszone/{zoneID}/scheduled/{changeID}
+// Granted by list_records.
+func (h *Handler) HandleGetScheduledChange(w http.ResponseWriter, r *http.Request) {
+	change, ok := h.scheduledChangeFromPath(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newScheduledChangeResponse(change))
+}
+
+// HandleCancelScheduledChange cancels a pending scheduled record change.
+// DELETE /dnszone/{zoneID}/scheduled/{changeID}
+// Scoped tokens need the action the change makes: add_record for the
+// record's type, or delete_record. Changes that are no longer pending
+// cannot be cancelled.
+func (h *Handler) HandleCancelScheduledChange(w http.ResponseWriter, r *http.Request) {
+	change, ok := h.scheduledChangeFromPath(w, r)
+	if !ok {
+		return
+	}
+	if keyInfo := auth.GetKeyInfo(r.Context()); keyInfo != nil && !auth.IsAdminFromContext(r.Context()) {
+		req := &auth.Request{Action: auth.ActionDeleteRecord, ZoneID: change.ZoneID}
+		if change.Action == storage.RecordChangeCreate {
+			req.Action, req.RecordType = auth.ActionAddRecord, scheduledRecordType(change)
+		}
+		if err := auth.CheckPermission(keyInfo, req); err != nil {
+			writeError(w, http.StatusForbidden, "permission denied")
+			return
+		}
+	}
+
+	if change.Status != storage.ScheduledChangePending {
+		writeError(w, http.StatusConflict, fmt.Sprintf("scheduled change is %s and can no longer be cancelled", change.Status))
+		return
+	}
+	err := h.schedule.CancelScheduledChange(r.Context(), change.ID, time.Now())
+	if errors.Is(err, storage.ErrNotFound) {
+		// It started running or was cancelled since it was read
+		writeError(w, http.StatusConflict, "scheduled change is no longer pending and can no longer be cancelled")
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to cancel scheduled change", "change_id", change.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to cancel scheduled change")
+		return
+	}
+
+	h.logger.Info("cancel scheduled record change", "zone_id", change.ZoneID, "change_id", change.ID, "token", tokenName(r))
+
+	change.Status, change.FinishedAt = storage.ScheduledChangeCancelled, time.Now()
+	writeJSON(w, http.StatusOK, newScheduledChangeResponse(change))
+}
+
+// scheduledChangeFromPath loads the scheduled change named by the path,
+// writing an error response if it is not found in the zone or the caller
+// may not see it.
+func (h *Handler) scheduledChangeFromPath(w http.ResponseWriter, r *http.Request) (*storage.ScheduledChange, bool) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return nil, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "changeID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid change ID")
+		return nil, false
+	}
+
+	change, err := h.schedule.GetScheduledChange(r.Context(), id)
+	if errors.Is(err, storage.ErrNotFound) || (err == nil && (change.ZoneID != zoneID || !canSeeScheduledChange(r, change))) {
+		writeError(w, http.StatusNotFound, "scheduled change not found")
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error("failed to get scheduled change", "change_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to get scheduled change")
+		return nil, false
+	}
+	return change, true
+}
+
+// canSeeScheduledChange reports whether the caller may see a scheduled
+// change: scoped tokens limited to some record types only see creates of
+// those types, as they only see records of those types.
+func canSeeScheduledChange(r *http.Request, c *storage.ScheduledChange) bool {
+	keyInfo := auth.GetKeyInfo(r.Context())
+	if c.Action != storage.RecordChangeCreate || keyInfo == nil || auth.IsAdminFromContext(r.Context()) {
+		return true
+	}
+	return auth.IsRecordTypePermitted(keyInfo, c.ZoneID, scheduledRecordType(c))
+}
+
+// scheduledRecordType returns the type name of the record a scheduled
+// create makes.
+func scheduledRecordType(c *storage.ScheduledChange) string {
+	var rec struct {
+		Type int `json:"Type"`
+	}
+	_ = json.Unmarshal(c.Request, &rec) //nolint:errcheck // stored by scheduleChange
+	return auth.MapRecordTypeToString(rec.Type)
+}
+
+// RunScheduledChange makes a scheduled record change in the account it was
+// scheduled in, recording it in the change history and publishing its event
+// as if its author had made it then. It returns the ID of the record a
+// create made. It is called by schedule.Runner once the change is due.
+func (h *Handler) RunScheduledChange(ctx context.Context, c *storage.ScheduledChange) (int64, error) {
+	client, ok := h.clientFor(c.Account)
+	if !ok {
+		return 0, fmt.Errorf("account %q is not configured", c.Account)
+	}
+	author := changeAuthor{name: c.Actor, account: c.Account}
+
+	// Serialize with conditional writes to the zone
+	mu := zoneLock(c.ZoneID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch c.Action {
+	case storage.RecordChangeCreate:
+		var req bunny.AddRecordRequest
+		if err := json.Unmarshal(c.Request, &req); err != nil {
+			return 0, fmt.Errorf("failed to decode record: %w", err)
+		}
+		// The name may have been protected since the change was scheduled
+		protections, err := h.protections(ctx, c.ZoneID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list protected records: %w", err)
+		}
+		if p := protecting(protections, requestedRecord(&req, req.Type)); p != nil {
+			return 0, errors.New(protectedMessage(p))
+		}
+		record, err := client.AddRecord(ctx, c.ZoneID, &req)
+		if err != nil {
+			return 0, err
+		}
+		h.logger.Info("scheduled add record", "zone_id", c.ZoneID, "change_id", c.ID, "type", req.Type, "name", req.Name)
+		h.zones.invalidate(c.ZoneID)
+		var recordID int64
+		if record != nil {
+			recordID = record.ID
+			h.recordChange(ctx, c.ZoneID, record.ID, storage.RecordChangeCreate, nil, record, author)
+		}
+		h.publish(ctx, webhook.EventRecordCreated, map[string]any{
+			"zone_id": c.ZoneID, "record": record, "actor": c.Actor,
+		})
+		return recordID, nil
+
+	case storage.RecordChangeDelete:
+		// The record may have been protected since the change was scheduled
+		p, err := h.protectedRecord(ctx, client, c.ZoneID, c.RecordID, nil)
+		if err != nil {
+			return 0, err
+		}
+		if p != nil {
+			return 0, errors.New(protectedMessage(p))
+		}
+		before := h.recordBefore(ctx, client, c.ZoneID, c.RecordID)
+		if err := client.DeleteRecord(ctx, c.ZoneID, c.RecordID); err != nil {
+			return 0, err
+		}
+		h.logger.Info("scheduled delete record", "zone_id", c.ZoneID, "change_id", c.ID, "record_id", c.RecordID)
+		h.zones.invalidate(c.ZoneID)
+		h.forgetRecordMeta(ctx, c.ZoneID, c.RecordID)
+		h.recordChange(ctx, c.ZoneID, c.RecordID, storage.RecordChangeDelete, before, nil, author)
+		h.publish(ctx, webhook.EventRecordDeleted, map[string]any{
+			"zone_id": c.ZoneID, "record_id": c.RecordID, "actor": c.Actor,
+		})
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unknown scheduled change action %q", c.Action)
+}
@@ -0,0 +1,287 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeSchedule is an in-memory ScheduleStore.
+type fakeSchedule struct {
+	mu      sync.Mutex
+	changes []*storage.ScheduledChange
+}
+
+func (f *fakeSchedule) CreateScheduledChange(_ context.Context, c *storage.ScheduledChange) (*storage.ScheduledChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	change := *c
+	change.ID, change.Status, change.CreatedAt = int64(len(f.changes)+1), storage.ScheduledChangePending, time.Now()
+	f.changes = append(f.changes, &change)
+	created := change
+	return &created, nil
+}
+
+func (f *fakeSchedule) GetScheduledChange(_ context.Context, id int64) (*storage.ScheduledChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id < 1 || id > int64(len(f.changes)) {
+		return nil, storage.ErrNotFound
+	}
+	change := *f.changes[id-1]
+	return &change, nil
+}
+
+func (f *fakeSchedule) ListScheduledChanges(_ context.Context, zoneID int64, status string) ([]*storage.ScheduledChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	changes := make([]*storage.ScheduledChange, 0)
+	for _, c := range f.changes {
+		if c.ZoneID == zoneID && (status == "" || c.Status == status) {
+			change := *c
+			changes = append(changes, &change)
+		}
+	}
+	return changes, nil
+}
+
+func (f *fakeSchedule) CancelScheduledChange(_ context.Context, id int64, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id < 1 || id > int64(len(f.changes)) || f.changes[id-1].Status != storage.ScheduledChangePending {
+		return storage.ErrNotFound
+	}
+	f.changes[id-1].Status, f.changes[id-1].FinishedAt = storage.ScheduledChangeCancelled, at
+	return nil
+}
+
+// newScheduleRouter routes the record and scheduled change endpoints to h,
+// with the caller set by withCaller.
+func newScheduleRouter(h *Handler, withCaller func(context.Context) context.Context) http.Handler {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(withCaller(req.Context())))
+		})
+	})
+	r.Post("/dnszone/{zoneID}/records", h.HandleAddRecord)
+	r.Delete("/dnszone/{zoneID}/records/{recordID}", h.HandleDeleteRecord)
+	r.Get("/dnszone/{zoneID}/scheduled", h.HandleListScheduledChanges)
+	r.Get("/dnszone/{zoneID}/scheduled/{changeID}", h.HandleGetScheduledChange)
+	r.Delete("/dnszone/{zoneID}/scheduled/{changeID}", h.HandleCancelScheduledChange)
+	return r
+}
+
+func TestScheduleRecordChanges(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{records: []bunny.Record{{ID: 5, Type: 0, Name: "www", Value: "192.0.2.1"}}, nextID: 10}
+	schedule := &fakeSchedule{}
+	h := NewHandler(zone.client(), slog.Default())
+	h.SetScheduleStore(schedule)
+	router := newScheduleRouter(h, func(ctx context.Context) context.Context {
+		return auth.WithAdmin(auth.WithToken(ctx, &storage.Token{ID: 4, Name: "deploy", IsAdmin: true}), true)
+	})
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	at := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	// A scheduled create is recorded, not made
+	w := send(http.MethodPost, "/dnszone/1/records?scheduleAt="+at, `{"Type":3,"Name":"_acme","Value":"token"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var created ScheduledChangeResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if created.Status != storage.ScheduledChangePending || created.RunAt != at || created.Action != storage.RecordChangeCreate ||
+		!strings.Contains(string(created.Record), `"_acme"`) {
+		t.Errorf("unexpected scheduled change %+v", created)
+	}
+	if loc := w.Header().Get("Location"); loc != "/dnszone/1/scheduled/1" {
+		t.Errorf("unexpected Location %q", loc)
+	}
+	if zone.get(11) != nil {
+		t.Error("the record was created before it was due")
+	}
+	if c := schedule.changes[0]; c.TokenID != 4 || c.Actor != "deploy" || c.ZoneID != 1 {
+		t.Errorf("unexpected stored change %+v", c)
+	}
+
+	// A scheduled delete must name an existing record
+	if w := send(http.MethodDelete, "/dnszone/1/records/5?scheduleAt="+at, ""); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if zone.get(5) == nil {
+		t.Error("the record was deleted before it was due")
+	}
+	if w := send(http.MethodDelete, "/dnszone/1/records/6?scheduleAt="+at, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown record, got %d", w.Code)
+	}
+
+	for name, scheduleAt := range map[string]string{
+		"not a time": "tomorrow",
+		"past":       time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+		"too far":    time.Now().Add(2 * maxScheduleAhead).UTC().Format(time.RFC3339),
+	} {
+		if w := send(http.MethodPost, "/dnszone/1/records?scheduleAt="+scheduleAt, `{"Type":0,"Name":"a","Value":"192.0.2.2"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+
+	w = send(http.MethodGet, "/dnszone/1/scheduled?status=pending", "")
+	var list []ScheduledChangeResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode list: %v", err)
+	}
+	if len(list) != 2 || list[1].Action != storage.RecordChangeDelete || list[1].RecordID != 5 {
+		t.Errorf("unexpected list %+v", list)
+	}
+	if w := send(http.MethodGet, "/dnszone/1/scheduled?status=done", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid status, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/dnszone/1/scheduled/2", ""); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/dnszone/2/scheduled/2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for another zone's change, got %d", w.Code)
+	}
+
+	w = send(http.MethodDelete, "/dnszone/1/scheduled/2", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cancelled"`) {
+		t.Fatalf("expected the change to be cancelled, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodDelete, "/dnszone/1/scheduled/2", ""); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 cancelling twice, got %d", w.Code)
+	}
+
+	// Without a store, scheduling is rejected rather than done at once
+	h = NewHandler(zone.client(), slog.Default())
+	router = newScheduleRouter(h, func(ctx context.Context) context.Context { return ctx })
+	if w := send(http.MethodPost, "/dnszone/1/records?scheduleAt="+at, `{"Type":0,"Name":"a","Value":"192.0.2.2"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a store, got %d", w.Code)
+	}
+	if zone.get(11) != nil {
+		t.Error("the record was created")
+	}
+}
+
+func TestScheduledChanges_ScopedToken(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{}
+	schedule := &fakeSchedule{}
+	h := NewHandler(zone.client(), slog.Default())
+	h.SetScheduleStore(schedule)
+	ctx := context.Background()
+	at := time.Now().Add(time.Hour)
+	txt, _ := schedule.CreateScheduledChange(ctx, &storage.ScheduledChange{ZoneID: 1, Action: storage.RecordChangeCreate,
+		Request: []byte(`{"Type":3,"Name":"_acme","Value":"token"}`), RunAt: at})
+	del, _ := schedule.CreateScheduledChange(ctx, &storage.ScheduledChange{ZoneID: 1, Action: storage.RecordChangeDelete,
+		RecordID: 5, RunAt: at})
+
+	// May read records and create A records, but not delete
+	router := newScheduleRouter(h, func(ctx context.Context) context.Context {
+		ctx = auth.WithToken(ctx, &storage.Token{ID: 2, Name: "scoped"})
+		return auth.WithPermissions(ctx, []*storage.Permission{
+			{ZoneID: 1, AllowedActions: []string{"read", "create"}, RecordTypes: []string{"A"}},
+		})
+	})
+	send := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := send(http.MethodGet, "/dnszone/1/scheduled")
+	var list []ScheduledChangeResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("failed to decode list: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != del.ID {
+		t.Errorf("expected only the delete, got %+v", list)
+	}
+	if w := send(http.MethodGet, "/dnszone/1/scheduled/1"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a TXT create, got %d", w.Code)
+	}
+	if w := send(http.MethodDelete, "/dnszone/1/scheduled/2"); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 cancelling a delete, got %d", w.Code)
+	}
+	if got, _ := schedule.GetScheduledChange(ctx, txt.ID); got.Status != storage.ScheduledChangePending {
+		t.Errorf("expected the TXT create to stay pending, got %s", got.Status)
+	}
+}
+
+func TestRunScheduledChange(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{records: []bunny.Record{{ID: 5, Type: 0, Name: "www", Value: "192.0.2.1"}}, nextID: 10}
+	history := &fakeHistory{}
+	h := NewHandler(zone.client(), slog.Default())
+	h.SetRecordHistoryStore(history)
+	ctx := context.Background()
+
+	recordID, err := h.RunScheduledChange(ctx, &storage.ScheduledChange{ID: 1, ZoneID: 1, Action: storage.RecordChangeCreate,
+		Request: []byte(`{"Type":0,"Name":"new","Value":"192.0.2.9"}`), Actor: "deploy"})
+	if err != nil || recordID != 11 || zone.get(11) == nil || zone.get(11).Name != "new" {
+		t.Fatalf("create: record %d, %v", recordID, err)
+	}
+	if _, err := h.RunScheduledChange(ctx, &storage.ScheduledChange{ID: 2, ZoneID: 1, Action: storage.RecordChangeDelete,
+		RecordID: 5, Actor: "deploy"}); err != nil || zone.get(5) != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// Both are recorded as changes by the token that scheduled them
+	if len(history.changes) != 2 || history.changes[0].Actor != "deploy" || history.changes[1].Before == nil {
+		t.Errorf("unexpected history %+v", history.changes)
+	}
+
+	if _, err := h.RunScheduledChange(ctx, &storage.ScheduledChange{ID: 3, ZoneID: 1, Action: storage.RecordChangeDelete,
+		RecordID: 5, Account: "missing"}); err == nil {
+		t.Error("expected an error for an unconfigured account")
+	}
+}
+
+func TestScheduledChanges_Protected(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{nextID: 10}
+	h := NewHandler(zone.client(), slog.Default())
+	h.SetScheduleStore(&fakeSchedule{})
+	h.SetProtectedRecordStore(fakeProtections{{ID: 1, ZoneID: 1, Name: "www"}})
+	withScoped := func(ctx context.Context) context.Context {
+		ctx = auth.WithToken(ctx, &storage.Token{ID: 2, Name: "scoped"})
+		return auth.WithPermissions(ctx, []*storage.Permission{
+			{ZoneID: 1, AllowedActions: []string{"create"}, RecordTypes: []string{"A"}},
+		})
+	}
+
+	router := newScheduleRouter(h, withScoped)
+	at := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/dnszone/1/records?scheduleAt="+at,
+		strings.NewReader(`{"Type":0,"Name":"www","Value":"192.0.2.9"}`)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 scheduling a protected create, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The name may be protected after the create was scheduled
+	_, err := h.RunScheduledChange(withScoped(context.Background()), &storage.ScheduledChange{ID: 1, ZoneID: 1,
+		Action: storage.RecordChangeCreate, Request: []byte(`{"Type":0,"Name":"www","Value":"192.0.2.9"}`), Actor: "scoped"})
+	if err == nil || !strings.Contains(err.Error(), "record www is protected") || len(zone.records) != 0 {
+		t.Errorf("expected the protected create to fail, got %v and %+v", err, zone.records)
+	}
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+const (
+	// searchConcurrency bounds the number of zones fetched in parallel by a search.
+	searchConcurrency = 4
+
+	// searchCacheTTL is how long fetched zones are reused by subsequent searches.
+	searchCacheTTL = 30 * time.Second
+
+	// zoneListPageSize is the page size used to enumerate every zone in the account.
+	zoneListPageSize = 1000
+)
+
+// SearchResult is a record matching a search, with its zone context.
+type SearchResult struct {
+	ZoneID int64        `json:"ZoneId"`
+	Domain string       `json:"Domain"`
+	Record bunny.Record `json:"Record"`
+}
+
+// SearchRecordsResponse is the response body for GET /search/records.
+// FailedZones lists zones that could not be fetched; results are then partial.
+type SearchRecordsResponse struct {
+	Items       []SearchResult `json:"Items"`
+	TotalItems  int            `json:"TotalItems"`
+	FailedZones []int64        `json:"FailedZones,omitempty"`
+}
+
+// zoneCache keeps recently fetched zones so repeated searches don't refetch
+// every zone. Entries are dropped when the proxy changes a zone.
+type zoneCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[int64]zoneCacheEntry
+}
+
+type zoneCacheEntry struct {
+	zone      *bunny.Zone
+	fetchedAt time.Time
+}
+
+func newZoneCache(ttl time.Duration) *zoneCache {
+	return &zoneCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[int64]zoneCacheEntry),
+	}
+}
+
+// get returns a cached zone if it has not expired.
+func (c *zoneCache) get(id int64) (*bunny.Zone, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(e.fetchedAt) >= c.ttl {
+		delete(c.entries, id)
+		return nil, false
+	}
+	return e.zone, true
+}
+
+func (c *zoneCache) put(zone *bunny.Zone) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[zone.ID] = zoneCacheEntry{zone: zone, fetchedAt: c.now()}
+}
+
+// invalidate drops a zone so the next search refetches it.
+func (c *zoneCache) invalidate(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, id)
+}
+
+// HandleSearchRecords finds records whose value matches across every zone the caller can read.
+// GET /search/records?value=1.2.3.4
+// Values are compared case-insensitively, ignoring a trailing dot.
+func (h *Handler) HandleSearchRecords(w http.ResponseWriter, r *http.Request) {
+	value := normalizeRecordValue(r.URL.Query().Get("value"))
+	if value == "" {
+		writeError(w, http.StatusBadRequest, "missing value parameter")
+		return
+	}
+
+	zoneIDs, err := h.searchableZoneIDs(r)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	zones, failed := h.fetchZones(r.Context(), zoneIDs)
+
+	keyInfo := auth.GetKeyInfo(r.Context())
+	if auth.IsAdminFromContext(r.Context()) {
+		keyInfo = nil
+	}
+
+	resp := SearchRecordsResponse{Items: make([]SearchResult, 0), FailedZones: failed}
+	for _, zone := range zones {
+		for _, record := range filterRecordsByPermission(zone.Records, keyInfo, zone.ID) {
+			if normalizeRecordValue(record.Value) == value {
+				resp.Items = append(resp.Items, SearchResult{ZoneID: zone.ID, Domain: zone.Domain, Record: record})
+			}
+		}
+	}
+	resp.TotalItems = len(resp.Items)
+
+	h.logger.Info("search records", "zones", len(zoneIDs), "matches", resp.TotalItems, "failed_zones", len(failed))
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// searchableZoneIDs returns the zones the caller may read. Admins and tokens with an
+// all-zones permission search every zone in the account.
+func (h *Handler) searchableZoneIDs(r *http.Request) ([]int64, error) {
+	keyInfo := auth.GetKeyInfo(r.Context())
+	if !auth.IsAdminFromContext(r.Context()) && !auth.HasAllZonesPermission(keyInfo) {
+		return auth.GetPermittedZoneIDs(keyInfo), nil
+	}
+
+	zones, err := h.listAllZones(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(zones))
+	for _, zone := range zones {
+		ids = append(ids, zone.ID)
+	}
+	return ids, nil
+}
+
+// listAllZones pages through every zone in the account.
+func (h *Handler) listAllZones(ctx context.Context) ([]bunny.Zone, error) {
+	var zones []bunny.Zone
+	for page := 1; ; page++ {
+		result, err := h.upstream(ctx).ListZones(ctx, &bunny.ListZonesOptions{Page: page, PerPage: zoneListPageSize})
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, result.Items...)
+		if !result.HasMoreItems || len(result.Items) == 0 {
+			return zones, nil
+		}
+	}
+}
+
+// fetchZones fetches zones with bounded concurrency, using the zone cache.
+// Zones that fail to load are returned in failed, sorted by ID.
+func (h *Handler) fetchZones(ctx context.Context, ids []int64) (zones []*bunny.Zone, failed []int64) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, searchConcurrency)
+
+	for _, id := range ids {
+		if zone, ok := h.zones.get(id); ok {
+			mu.Lock()
+			zones = append(zones, zone)
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id int64) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			zone, err := h.upstream(ctx).GetZone(ctx, id)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil || zone == nil {
+				h.logger.Warn("search: failed to fetch zone", "zone_id", id, "error", err)
+				failed = append(failed, id)
+				return
+			}
+			h.zones.put(zone)
+			zones = append(zones, zone)
+		}(id)
+	}
+	wg.Wait()
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+	sort.Slice(failed, func(i, j int) bool { return failed[i] < failed[j] })
+	return zones, failed
+}
+
+// normalizeRecordValue lowercases a record value and strips a trailing dot,
+// so "Host.Example.com." matches "host.example.com".
+func normalizeRecordValue(v string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v)), ".")
+}
@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// newSearchHandler returns a handler over three zones; zone 3 fails to load.
+func newSearchHandler(getCalls *atomic.Int32) *Handler {
+	zones := map[int64]*bunny.Zone{
+		1: {ID: 1, Domain: "a.com", Records: []bunny.Record{
+			{ID: 10, Type: 0, Name: "www", Value: "1.2.3.4"},
+			{ID: 11, Type: 3, Name: "txt", Value: "1.2.3.4"},
+		}},
+		2: {ID: 2, Domain: "b.com", Records: []bunny.Record{
+			{ID: 20, Type: 0, Name: "api", Value: "1.2.3.4"},
+			{ID: 21, Type: 2, Name: "alias", Value: "Target.Example.com."},
+		}},
+	}
+	return NewHandler(&mockBunnyClient{
+		listZonesFunc: func(_ context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+			return &bunny.ListZonesResponse{Items: []bunny.Zone{{ID: 1}, {ID: 2}, {ID: 3}}}, nil
+		},
+		getZoneFunc: func(_ context.Context, id int64) (*bunny.Zone, error) {
+			if getCalls != nil {
+				getCalls.Add(1)
+			}
+			if z, ok := zones[id]; ok {
+				return z, nil
+			}
+			return nil, errors.New("upstream unavailable")
+		},
+	}, nil)
+}
+
+func searchRecords(h *Handler, r *http.Request) (*httptest.ResponseRecorder, SearchRecordsResponse) {
+	w := httptest.NewRecorder()
+	h.HandleSearchRecords(w, r)
+	var resp SearchRecordsResponse
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	return w, resp
+}
+
+func TestHandleSearchRecords_Admin(t *testing.T) {
+	t.Parallel()
+	h := newSearchHandler(nil)
+
+	r := newTestRequest(http.MethodGet, "/search/records?value=1.2.3.4", nil, nil)
+	r = r.WithContext(auth.WithAdmin(r.Context(), true))
+
+	w, resp := searchRecords(h, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if resp.TotalItems != 3 || len(resp.Items) != 3 {
+		t.Fatalf("expected 3 matches, got %+v", resp)
+	}
+	if resp.Items[2].ZoneID != 2 || resp.Items[2].Domain != "b.com" || resp.Items[2].Record.ID != 20 {
+		t.Errorf("unexpected zone context: %+v", resp.Items[2])
+	}
+	if len(resp.FailedZones) != 1 || resp.FailedZones[0] != 3 {
+		t.Errorf("expected zone 3 reported as failed, got %v", resp.FailedZones)
+	}
+}
+
+func TestHandleSearchRecords_ScopedToken(t *testing.T) {
+	t.Parallel()
+	h := newSearchHandler(nil)
+
+	r := newTestRequestWithKeyInfo("/search/records?value=1.2.3.4", nil, &auth.KeyInfo{
+		KeyID: 1,
+		Permissions: []*storage.Permission{
+			{ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"A"}},
+		},
+	})
+
+	_, resp := searchRecords(h, r)
+	if len(resp.Items) != 1 || resp.Items[0].Record.ID != 10 {
+		t.Errorf("expected only the permitted A record in zone 1, got %+v", resp.Items)
+	}
+	if len(resp.FailedZones) != 0 {
+		t.Errorf("expected no failed zones, got %v", resp.FailedZones)
+	}
+}
+
+func TestHandleSearchRecords_NormalizesValue(t *testing.T) {
+	t.Parallel()
+	h := newSearchHandler(nil)
+
+	r := newTestRequest(http.MethodGet, "/search/records?value=target.example.com", nil, nil)
+	r = r.WithContext(auth.WithAdmin(r.Context(), true))
+
+	_, resp := searchRecords(h, r)
+	if len(resp.Items) != 1 || resp.Items[0].Record.ID != 21 {
+		t.Errorf("expected case- and dot-insensitive match, got %+v", resp.Items)
+	}
+}
+
+func TestHandleSearchRecords_MissingValue(t *testing.T) {
+	t.Parallel()
+	h := newSearchHandler(nil)
+
+	w := httptest.NewRecorder()
+	h.HandleSearchRecords(w, newTestRequest(http.MethodGet, "/search/records", nil, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandleSearchRecords_CachesZones(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	h := newSearchHandler(&calls)
+
+	search := func() {
+		r := newTestRequest(http.MethodGet, "/search/records?value=1.2.3.4", nil, nil)
+		searchRecords(h, r.WithContext(auth.WithAdmin(r.Context(), true)))
+	}
+
+	search()
+	search()
+	// Zones 1 and 2 are cached; zone 3 failed and is retried
+	if got := calls.Load(); got != 4 {
+		t.Errorf("expected 4 upstream fetches, got %d", got)
+	}
+
+	h.zones.invalidate(1)
+	search()
+	if got := calls.Load(); got != 6 {
+		t.Errorf("expected invalidated zone to be refetched, got %d fetches", got)
+	}
+}
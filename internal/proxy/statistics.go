@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// statisticsParallelism bounds the zones whose statistics are fetched at once.
+const statisticsParallelism = 4
+
+// statisticsSummary is the response of GET /dnszone/statistics: the charts of
+// every included zone summed per date and query type, and each zone's total.
+type statisticsSummary struct {
+	bunny.ZoneStatisticsResponse
+	Zones []zoneQueryTotal `json:"Zones"`
+}
+
+// zoneQueryTotal is one zone's share of a statistics summary.
+type zoneQueryTotal struct {
+	ZoneID             int64  `json:"ZoneId"`
+	Domain             string `json:"Domain"`
+	TotalQueriesServed int64  `json:"TotalQueriesServed"`
+}
+
+// HandleGetStatisticsSummary sums DNS query statistics across zones.
+// GET /dnszone/statistics?zones=1,2,3&dateFrom=...&dateTo=...
+// Without zones, every zone the caller may get statistics for is included.
+// Scoped tokens need the get_statistics action for each zone. If statistics
+// cannot be fetched for one zone, the request fails rather than returning a
+// sum that silently leaves it out.
+func (h *Handler) HandleGetStatisticsSummary(w http.ResponseWriter, r *http.Request) {
+	requested, err := parseZoneIDList(r.URL.Query().Get("zones"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zones parameter")
+		return
+	}
+	for _, id := range requested {
+		if !canGetStatistics(r, id) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("permission denied for zone %d", id))
+			return
+		}
+	}
+
+	all, err := h.listAllZones(r.Context())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+	zones, missing := selectZones(all, requested, func(id int64) bool { return canGetStatistics(r, id) })
+	if missing != 0 {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("zone %d not found", missing))
+		return
+	}
+
+	dateFrom := r.URL.Query().Get("dateFrom")
+	dateTo := r.URL.Query().Get("dateTo")
+	results := make([]*bunny.ZoneStatisticsResponse, len(zones))
+	errs := make([]error, len(zones))
+	sem := make(chan struct{}, statisticsParallelism)
+	var wg sync.WaitGroup
+	for i, zone := range zones {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i], errs[i] = h.upstream(r.Context()).GetZoneStatistics(r.Context(), zone.ID, dateFrom, dateTo)
+		}()
+	}
+	wg.Wait()
+
+	summary := statisticsSummary{
+		ZoneStatisticsResponse: bunny.ZoneStatisticsResponse{
+			QueriesServedChart:       make(map[string]int64),
+			NormalQueriesServedChart: make(map[string]int64),
+			SmartQueriesServedChart:  make(map[string]int64),
+			QueriesByTypeChart:       make(map[string]int64),
+		},
+		Zones: make([]zoneQueryTotal, 0, len(zones)),
+	}
+	for i, zone := range zones {
+		if errs[i] != nil {
+			h.logger.Warn("statistics summary: failed to get zone statistics", "zone_id", zone.ID, "error", errs[i])
+			handleBunnyError(w, errs[i])
+			return
+		}
+		addStatistics(&summary.ZoneStatisticsResponse, results[i])
+		summary.Zones = append(summary.Zones, zoneQueryTotal{
+			ZoneID: zone.ID, Domain: zone.Domain, TotalQueriesServed: results[i].TotalQueriesServed,
+		})
+	}
+
+	h.logger.Info("get statistics summary", "zones", len(zones))
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// canGetStatistics reports whether the caller may get a zone's statistics.
+func canGetStatistics(r *http.Request, zoneID int64) bool {
+	if auth.IsAdminFromContext(r.Context()) {
+		return true
+	}
+	keyInfo := auth.GetKeyInfo(r.Context())
+	if keyInfo == nil {
+		return false
+	}
+	return auth.CheckPermission(keyInfo, &auth.Request{Action: auth.ActionGetStatistics, ZoneID: zoneID}) == nil
+}
+
+// addStatistics adds one zone's statistics to a running sum.
+func addStatistics(sum, zone *bunny.ZoneStatisticsResponse) {
+	sum.TotalQueriesServed += zone.TotalQueriesServed
+	for k, v := range zone.QueriesServedChart {
+		sum.QueriesServedChart[k] += v
+	}
+	for k, v := range zone.NormalQueriesServedChart {
+		sum.NormalQueriesServedChart[k] += v
+	}
+	for k, v := range zone.SmartQueriesServedChart {
+		sum.SmartQueriesServedChart[k] += v
+	}
+	for k, v := range zone.QueriesByTypeChart {
+		sum.QueriesByTypeChart[k] += v
+	}
+}
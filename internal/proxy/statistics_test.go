@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// newStatisticsHandler returns a handler over zones 1-3, where zone N served
+// N queries on one day; fetching zone 3's statistics fails.
+func newStatisticsHandler() *Handler {
+	return NewHandler(&mockBunnyClient{
+		listZonesFunc: func(context.Context, *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+			return &bunny.ListZonesResponse{Items: []bunny.Zone{
+				{ID: 2, Domain: "b.com"}, {ID: 1, Domain: "a.com"}, {ID: 3, Domain: "c.com"},
+			}}, nil
+		},
+		getZoneStatisticsFunc: func(_ context.Context, id int64, _, _ string) (*bunny.ZoneStatisticsResponse, error) {
+			if id == 3 {
+				return nil, errors.New("upstream unavailable")
+			}
+			return &bunny.ZoneStatisticsResponse{
+				TotalQueriesServed: id,
+				QueriesServedChart: map[string]int64{"2026-10-01T00:00:00Z": id},
+				QueriesByTypeChart: map[string]int64{"A": id},
+			}, nil
+		},
+	}, nil)
+}
+
+func TestHandleGetStatisticsSummary(t *testing.T) {
+	t.Parallel()
+	h := newStatisticsHandler()
+
+	w := httptest.NewRecorder()
+	h.HandleGetStatisticsSummary(w, adminRequest("/dnszone/statistics?zones=2,1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary statisticsSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if summary.TotalQueriesServed != 3 || summary.QueriesServedChart["2026-10-01T00:00:00Z"] != 3 ||
+		summary.QueriesByTypeChart["A"] != 3 {
+		t.Errorf("unexpected sums: %+v", summary.ZoneStatisticsResponse)
+	}
+	if len(summary.Zones) != 2 || summary.Zones[0].ZoneID != 1 || summary.Zones[1].TotalQueriesServed != 2 {
+		t.Errorf("unexpected zones: %+v", summary.Zones)
+	}
+
+	// A zone whose statistics fail fails the request
+	w = httptest.NewRecorder()
+	h.HandleGetStatisticsSummary(w, adminRequest("/dnszone/statistics"))
+	if w.Code == http.StatusOK {
+		t.Error("expected an error when a zone's statistics fail")
+	}
+
+	for path, want := range map[string]int{
+		"/dnszone/statistics?zones=9":   http.StatusNotFound,
+		"/dnszone/statistics?zones=abc": http.StatusBadRequest,
+	} {
+		w = httptest.NewRecorder()
+		h.HandleGetStatisticsSummary(w, adminRequest(path))
+		if w.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+}
+
+func TestHandleGetStatisticsSummary_ScopedToken(t *testing.T) {
+	t.Parallel()
+	h := newStatisticsHandler()
+	keyInfo := &auth.KeyInfo{KeyID: 1, Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"get_statistics"}},
+		{ZoneID: 2, AllowedActions: []string{"read"}},
+	}}
+
+	w := httptest.NewRecorder()
+	h.HandleGetStatisticsSummary(w, newTestRequestWithKeyInfo("/dnszone/statistics", nil, keyInfo))
+	var summary statisticsSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(summary.Zones) != 1 || summary.Zones[0].ZoneID != 1 || summary.TotalQueriesServed != 1 {
+		t.Errorf("expected only zone 1, got %+v", summary)
+	}
+
+	// Zones without get_statistics are rejected when requested explicitly
+	for _, zones := range []string{"2", "3"} {
+		w = httptest.NewRecorder()
+		h.HandleGetStatisticsSummary(w, newTestRequestWithKeyInfo("/dnszone/statistics?zones="+zones, nil, keyInfo))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("zones=%s: expected 403, got %d", zones, w.Code)
+		}
+	}
+}
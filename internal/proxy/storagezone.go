@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// HandleListStorageZones lists storage zones.
+// GET /storagezone
+// Scoped keys only see storage zones they have a permission for.
+func (h *Handler) HandleListStorageZones(w http.ResponseWriter, r *http.Request) {
+	result, err := h.upstream(r.Context()).ListStorageZones(r.Context(), r.URL.Query())
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("list storage zones", "token", tokenName(r), "query", r.URL.RawQuery)
+
+	h.writeScopedZoneList(w, r, result, storage.ResourceStorageZone)
+}
+
+// HandleCreateStorageZone creates a storage zone.
+// POST /storagezone
+// Admin only — a new storage zone has no permissions yet.
+func (h *Handler) HandleCreateStorageZone(w http.ResponseWriter, r *http.Request) {
+	body, ok := readJSONBody(w, r, true)
+	if !ok {
+		return
+	}
+
+	result, err := h.upstream(r.Context()).AddStorageZone(r.Context(), body)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("create storage zone", "token", tokenName(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	//nolint:errcheck
+	w.Write(result)
+}
+
+// HandleGetStorageZone retrieves a single storage zone.
+// GET /storagezone/{storageZoneID}
+func (h *Handler) HandleGetStorageZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := storageZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.upstream(r.Context()).GetStorageZone(r.Context(), id)
+	if err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("get storage zone", "token", tokenName(r), "storage_zone_id", id)
+
+	writeRawJSON(w, result)
+}
+
+// HandleDeleteStorageZone deletes a storage zone.
+// DELETE /storagezone/{storageZoneID}
+func (h *Handler) HandleDeleteStorageZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := storageZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.upstream(r.Context()).DeleteStorageZone(r.Context(), id, r.URL.Query()); err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("delete storage zone", "token", tokenName(r), "storage_zone_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleResetStorageZonePassword generates a new storage zone password.
+// POST /storagezone/{storageZoneID}/resetPassword
+func (h *Handler) HandleResetStorageZonePassword(w http.ResponseWriter, r *http.Request) {
+	id, ok := storageZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.upstream(r.Context()).ResetStorageZonePassword(r.Context(), id); err != nil {
+		handleBunnyError(w, err)
+		return
+	}
+
+	h.logger.Info("reset storage zone password", "token", tokenName(r), "storage_zone_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// storageZoneID parses the storage zone ID URL parameter, writing a 400 on failure.
+func storageZoneID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "storageZoneID"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "invalid storage zone ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestHandleListStorageZones_FiltersPlainArray(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		listStorageZonesFunc: func(context.Context, url.Values) (json.RawMessage, error) {
+			return json.RawMessage(`[{"Id":4,"Name":"backups","Password":"secret"},{"Id":5,"Name":"media"}]`), nil
+		},
+	}
+	keyInfo := &auth.KeyInfo{
+		KeyID:   1,
+		KeyName: "backup-key",
+		Permissions: []*storage.Permission{
+			{Resource: storage.ResourcePullZone, ZoneID: 5, AllowedActions: []string{"purge_pull_zone"}},
+			{Resource: storage.ResourceStorageZone, ZoneID: 4, AllowedActions: []string{"reset_storage_zone_password"}},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	newPullZoneTestHandler(client).HandleListStorageZones(w, newTestRequestWithKeyInfo("/storagezone", nil, keyInfo))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	// Zone 5 is only permitted as a pull zone, so it is filtered out.
+	if len(items) != 1 || items[0]["Id"] != float64(4) {
+		t.Errorf("expected only storage zone 4, got %v", items)
+	}
+}
+
+func TestHandleGetStorageZone(t *testing.T) {
+	t.Parallel()
+
+	client := &mockBunnyClient{
+		getStorageZoneFunc: func(_ context.Context, id int64) (json.RawMessage, error) {
+			if id != 4 {
+				return nil, bunny.ErrNotFound
+			}
+			return json.RawMessage(`{"Id":4}`), nil
+		},
+	}
+	h := newPullZoneTestHandler(client)
+
+	tests := []struct {
+		id       string
+		wantCode int
+	}{
+		{"4", http.StatusOK},
+		{"9", http.StatusNotFound},
+		{"0", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.HandleGetStorageZone(w, newTestRequest(http.MethodGet, "/storagezone/"+tt.id, nil, map[string]string{"storageZoneID": tt.id}))
+		if w.Code != tt.wantCode {
+			t.Errorf("id %s: expected status %d, got %d", tt.id, tt.wantCode, w.Code)
+		}
+	}
+}
+
+func TestHandleStorageZoneMutations(t *testing.T) {
+	t.Parallel()
+
+	var created string
+	var deleted, reset int64
+	var deleteQuery url.Values
+	client := &mockBunnyClient{
+		addStorageZoneFunc: func(_ context.Context, body json.RawMessage) (json.RawMessage, error) {
+			created = string(body)
+			return json.RawMessage(`{"Id":6}`), nil
+		},
+		deleteStorageZoneFunc: func(_ context.Context, id int64, query url.Values) error {
+			deleted, deleteQuery = id, query
+			return nil
+		},
+		resetStorageZonePassFunc: func(_ context.Context, id int64) error {
+			reset = id
+			return nil
+		},
+	}
+	h := newPullZoneTestHandler(client)
+	params := map[string]string{"storageZoneID": "4"}
+
+	w := httptest.NewRecorder()
+	h.HandleCreateStorageZone(w, newTestRequest(http.MethodPost, "/storagezone", strings.NewReader(`{"Name":"backups"}`), nil))
+	if w.Code != http.StatusCreated || created != `{"Name":"backups"}` {
+		t.Errorf("create: got %d, body forwarded %s", w.Code, created)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleDeleteStorageZone(w, newTestRequest(http.MethodDelete, "/storagezone/4?deleteLinkedPullZones=true", nil, params))
+	if w.Code != http.StatusNoContent || deleted != 4 || deleteQuery.Get("deleteLinkedPullZones") != "true" {
+		t.Errorf("delete: got %d, deleted %d, query %v", w.Code, deleted, deleteQuery)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleResetStorageZonePassword(w, newTestRequest(http.MethodPost, "/storagezone/4/resetPassword", nil, params))
+	if w.Code != http.StatusNoContent || reset != 4 {
+		t.Errorf("reset password: got %d, reset %d", w.Code, reset)
+	}
+}
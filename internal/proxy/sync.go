@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// SyncRecordsResponse is the response body of POST /dnszone/{zoneID}/sync:
+// the changes found, as a diff reports them, and the outcome of each change
+// in the order of Operations. Results is left out on a dry run.
+type SyncRecordsResponse struct {
+	ZoneDiffResponse
+	DryRun  bool          `json:"DryRun"`
+	Results []BatchResult `json:"Results,omitempty"`
+}
+
+// HandleSyncRecords makes a zone's records match a zone file, applying only
+// the creates, updates and deletes a diff finds.
+// POST /dnszone/{zoneID}/sync
+//
+// The file is read and compared as HandleDiffRecords does, and every change
+// is then checked as a batch operation is, against its own action and the
+// zone's protected records, before any is applied. Changes are applied in
+// order and undone if one fails. With ?dry_run=true the checked changes are
+// returned without applying them.
+func (h *Handler) HandleSyncRecords(w http.ResponseWriter, r *http.Request) {
+	zoneID, err := strconv.ParseInt(chi.URLParam(r, "zoneID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid zone ID")
+		return
+	}
+
+	dryRun := false
+	if s := r.URL.Query().Get("dry_run"); s != "" {
+		if dryRun, err = strconv.ParseBool(s); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid dry_run parameter")
+			return
+		}
+	}
+
+	diff, ok := h.diffZone(w, r, zoneID)
+	if !ok {
+		return
+	}
+	// An empty file would delete every record the caller can see
+	if diff.Creates+diff.Updates+diff.Unchanged == 0 {
+		writeError(w, http.StatusBadRequest, "no records found")
+		return
+	}
+	if len(diff.Operations) > h.importMaxRecords {
+		metrics.RecordRequestLimitRejection(limitImportRecords)
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("the sync needs %d changes; at most %d can be applied at once",
+			len(diff.Operations), h.importMaxRecords))
+		return
+	}
+
+	ctx := r.Context()
+	keyInfo := auth.GetKeyInfo(ctx)
+	scoped := keyInfo != nil && !auth.IsAdminFromContext(ctx)
+
+	var protections []*storage.ProtectedRecord
+	if len(diff.Operations) > 0 {
+		if protections, err = h.protections(ctx, zoneID); err != nil {
+			h.logger.Error("failed to list protected records", "zone_id", zoneID, "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to check protected records")
+			return
+		}
+	}
+
+	existing := make(map[int64]bunny.Record)
+	for i, op := range diff.Operations {
+		if p := protectingOperation(protections, op.BatchOperation, op.Before); p != nil {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("operation %d: %s", i, protectedMessage(p)))
+			return
+		}
+		if op.Before != nil {
+			existing[op.RecordID] = *op.Before
+		}
+		if scoped && auth.CheckPermission(keyInfo, batchPermissionRequest(zoneID, op.BatchOperation)) != nil {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("operation %d: permission denied", i))
+			return
+		}
+	}
+
+	resp := SyncRecordsResponse{ZoneDiffResponse: *diff, DryRun: dryRun}
+	if dryRun || len(diff.Operations) == 0 {
+		h.logger.Info("sync records", "zone_id", zoneID, "dry_run", dryRun, "creates", diff.Creates,
+			"updates", diff.Updates, "deletes", diff.Deletes, "token", tokenName(r))
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	applied := make([]appliedOperation, 0, len(diff.Operations))
+	for i, op := range diff.Operations {
+		a, err := h.applyBatchOperation(ctx, zoneID, op.BatchOperation, existing)
+		if err != nil {
+			h.failBatch(w, r, zoneID, i, err, applied)
+			return
+		}
+		applied = append(applied, a)
+	}
+
+	h.logger.Info("sync records", "zone_id", zoneID, "dry_run", false, "creates", diff.Creates,
+		"updates", diff.Updates, "deletes", diff.Deletes, "token", tokenName(r))
+	h.zones.invalidate(zoneID)
+
+	resp.Results = make([]BatchResult, 0, len(applied))
+	for _, a := range applied {
+		resp.Results = append(resp.Results, a.result)
+		h.publishBatchEvent(r, zoneID, a)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
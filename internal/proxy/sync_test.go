@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func TestHandleSyncRecords(t *testing.T) {
+	t.Parallel()
+	zone := &recordZone{nextID: 10, records: []bunny.Record{
+		{ID: 1, Type: recordTypeA, Name: "www", Value: "192.0.2.1", TTL: 300},
+		{ID: 2, Type: recordTypeA, Name: "app", Value: "192.0.2.2", TTL: 300},
+		{ID: 3, Type: recordTypeTXT, Name: "old", Value: "stale", TTL: 300},
+		{ID: 4, Type: recordTypeMX, Name: "", Value: "mail.example.com", TTL: 3600, Priority: 10},
+	}}
+	h := NewHandler(zone.client(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetProtectedRecordStore(fakeProtections{{ID: 1, ZoneID: 1, Name: "", RecordType: "MX"}})
+	sync := func(query, body string, keyInfo *auth.KeyInfo) (*httptest.ResponseRecorder, SyncRecordsResponse) {
+		t.Helper()
+		params := map[string]string{"zoneID": "1"}
+		r := newTestRequest(http.MethodPost, "/dnszone/1/sync?"+query, strings.NewReader(body), params)
+		if keyInfo != nil {
+			r = newTestRequestWithKeyInfo("/dnszone/1/sync?"+query, params, keyInfo)
+			r.Method = http.MethodPost
+			r.Body = io.NopCloser(strings.NewReader(body))
+		}
+		w := httptest.NewRecorder()
+		h.HandleSyncRecords(w, r)
+		var resp SyncRecordsResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp) //nolint:errcheck
+		return w, resp
+	}
+
+	zoneFile := "www 300 IN A 192.0.2.1\napp 300 IN A 192.0.2.9\napi 300 IN A 192.0.2.3\n"
+	scoped := &auth.KeyInfo{KeyID: 2, KeyName: "deploy", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"add_record", "update_record", "delete_record"}, RecordTypes: []string{"A"}},
+	}}
+
+	// The protected MX record is not the token's type, so it is neither compared nor deleted
+	w, resp := sync("dry_run=true", zoneFile, scoped)
+	if w.Code != http.StatusOK || !resp.DryRun || resp.Creates != 1 || resp.Updates != 1 || resp.Deletes != 0 || resp.Results != nil {
+		t.Fatalf("unexpected dry run %d: %s", w.Code, w.Body.String())
+	}
+	if zone.get(2).Value != "192.0.2.2" || len(zone.records) != 4 {
+		t.Errorf("dry run changed the zone: %+v", zone.records)
+	}
+
+	w, resp = sync("", zoneFile, scoped)
+	if w.Code != http.StatusOK || resp.DryRun || len(resp.Results) != 2 {
+		t.Fatalf("unexpected sync %d: %s", w.Code, w.Body.String())
+	}
+	if zone.get(2).Value != "192.0.2.9" || zone.get(11) == nil || zone.get(11).Name != "api" || zone.get(3) == nil {
+		t.Errorf("unexpected records after sync: %+v", zone.records)
+	}
+
+	// Syncing again has nothing to change
+	if w, resp = sync("", zoneFile, scoped); w.Code != http.StatusOK || len(resp.Operations) != 0 || resp.Unchanged != 3 {
+		t.Errorf("expected no changes, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Without a type restriction the stale TXT and the protected MX record are deleted
+	noDelete := &auth.KeyInfo{KeyID: 3, KeyName: "writer", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"add_record", "update_record"}},
+	}}
+	if w, _ := sync("dry_run=true", zoneFile, noDelete); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 deleting without delete_record, got %d: %s", w.Code, w.Body.String())
+	}
+	unrestricted := &auth.KeyInfo{KeyID: 4, KeyName: "sync", Permissions: []*storage.Permission{
+		{ZoneID: 1, AllowedActions: []string{"add_record", "update_record", "delete_record"}},
+	}}
+	if w, _ := sync("", zoneFile, unrestricted); w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "protected") {
+		t.Errorf("expected 403 deleting a protected record, got %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name, query, body string
+		want              int
+	}{
+		{"empty file", "", "; nothing\n", http.StatusBadRequest},
+		{"invalid dry_run", "dry_run=maybe", zoneFile, http.StatusBadRequest},
+		{"invalid line", "", "www 300 IN A nowhere\n", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w, _ := sync(tt.query, tt.body, nil); w.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if zone.get(4) == nil {
+		t.Error("the protected record was deleted")
+	}
+}
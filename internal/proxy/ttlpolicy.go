@@ -0,0 +1,30 @@
+package proxy
+
+import "github.com/sipico/bunny-api-proxy/internal/bunny"
+
+// TTLPolicy defaults and clamps the TTLs of records written through the
+// proxy. It is satisfied by *ttlpolicy.Policies.
+type TTLPolicy interface {
+	// TTL returns the TTL to write for a record in a zone, given the TTL the
+	// client sent; 0 means the client did not set one.
+	TTL(zoneID int64, ttl int32) int32
+}
+
+// SetTTLPolicy applies p to records created and updated through the record,
+// batch and import endpoints, before strict validation. Must be called before
+// NewRouter.
+func (h *Handler) SetTTLPolicy(p TTLPolicy) {
+	h.ttlPolicy = p
+}
+
+// applyTTLPolicy adjusts a record's TTL to the zone's policy. An update
+// without a TTL keeps the record's current one, so it is left alone.
+func (h *Handler) applyTTLPolicy(zoneID int64, rec *bunny.AddRecordRequest, update bool) {
+	if h.ttlPolicy == nil || (update && rec.TTL == 0) {
+		return
+	}
+	if ttl := h.ttlPolicy.TTL(zoneID, rec.TTL); ttl != rec.TTL {
+		h.logger.Info("TTL adjusted by policy", "zone_id", zoneID, "name", rec.Name, "requested", rec.TTL, "ttl", ttl)
+		rec.TTL = ttl
+	}
+}
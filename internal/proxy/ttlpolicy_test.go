@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// boundedTTLs defaults TTLs to 300 and clamps them to 60-86400 in zone 123.
+type boundedTTLs struct{}
+
+func (boundedTTLs) TTL(zoneID int64, ttl int32) int32 {
+	switch {
+	case zoneID != 123:
+		return ttl
+	case ttl == 0:
+		return 300
+	}
+	return min(max(ttl, 60), 86400)
+}
+
+func TestTTLPolicy_AppliedToRecordWrites(t *testing.T) {
+	t.Parallel()
+	var sent []int32
+	record := func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+		sent = append(sent, req.TTL)
+		return &bunny.Record{ID: 1, Type: req.Type, TTL: req.TTL}, nil
+	}
+	h := NewHandler(&mockBunnyClient{
+		addRecordFunc: record,
+		updateRecordFunc: func(ctx context.Context, zoneID, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			return record(ctx, zoneID, req)
+		},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetTTLPolicy(boundedTTLs{})
+
+	for _, tc := range []struct {
+		zoneID, body string
+		update       bool
+	}{
+		{"123", `{"Type":3,"Name":"_acme-challenge","Value":"t","Ttl":30}`, false},
+		{"123", `{"Type":3,"Name":"_acme-challenge","Value":"t"}`, false},
+		{"123", `{"Type":3,"Value":"t","Ttl":100000}`, true},
+		// An update without a TTL keeps the record's own
+		{"123", `{"Type":3,"Value":"t"}`, true},
+		{"456", `{"Type":3,"Name":"_acme-challenge","Value":"t","Ttl":30}`, false},
+	} {
+		w := httptest.NewRecorder()
+		params := map[string]string{"zoneID": tc.zoneID, "recordID": "1"}
+		if tc.update {
+			h.HandleUpdateRecord(w, newTestRequest(http.MethodPost, "/dnszone/"+tc.zoneID+"/records/1", strings.NewReader(tc.body), params))
+		} else {
+			h.HandleAddRecord(w, newTestRequest(http.MethodPost, "/dnszone/"+tc.zoneID+"/records", strings.NewReader(tc.body), params))
+		}
+		if w.Code >= 300 {
+			t.Fatalf("%s: status %d: %s", tc.body, w.Code, w.Body.String())
+		}
+	}
+
+	want := []int32{60, 300, 86400, 0, 30}
+	if len(sent) != len(want) {
+		t.Fatalf("sent TTLs %v, want %v", sent, want)
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("sent TTLs %v, want %v", sent, want)
+			break
+		}
+	}
+}
+
+func TestTTLPolicy_AppliedBeforeStrictValidation(t *testing.T) {
+	t.Parallel()
+	h := strictHandler(&mockBunnyClient{
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			return &bunny.Record{ID: 1, TTL: req.TTL}, nil
+		},
+	})
+	h.SetTTLPolicy(boundedTTLs{})
+
+	// A TTL strict validation would reject is raised to the policy's minimum first
+	w := httptest.NewRecorder()
+	h.HandleAddRecord(w, newTestRequest(http.MethodPost, "/dnszone/123/records",
+		strings.NewReader(`{"Type":3,"Name":"a","Value":"t","Ttl":1}`), map[string]string{"zoneID": "123"}))
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"Ttl":60`) {
+		t.Errorf("status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTTLPolicy_AppliedToBatchesAndImports(t *testing.T) {
+	t.Parallel()
+	var added []bunny.AddRecordRequest
+	h := strictHandler(importClient(t, &added))
+	h.SetTTLPolicy(boundedTTLs{})
+
+	w := httptest.NewRecorder()
+	h.HandleImportRecords(w, newImportRequest("Type,Name,Value,TTL\nTXT,_acme-challenge,token,1\n", "text/csv", nil))
+	if len(added) != 1 || added[0].TTL != 60 {
+		t.Errorf("expected the record to be imported with TTL 60, got %+v: %s", added, w.Body.String())
+	}
+
+	var batched []int32
+	h = strictHandler(&mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) { return batchZone(), nil },
+		addRecordFunc: func(_ context.Context, _ int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			batched = append(batched, req.TTL)
+			return &bunny.Record{ID: 1, TTL: req.TTL}, nil
+		},
+	})
+	h.SetTTLPolicy(boundedTTLs{})
+	w = httptest.NewRecorder()
+	h.HandleBatchRecords(w, newBatchRequest(`{"Operations":[{"Action":"create","Record":{"Type":3,"Name":"a","Value":"t","Ttl":1}}]}`, nil))
+	if len(batched) != 1 || batched[0] != 60 {
+		t.Errorf("expected the record to be created with TTL 60, got %v: %d %s", batched, w.Code, w.Body.String())
+	}
+}
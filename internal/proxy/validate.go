@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+// Default TTL bounds of strict record validation, in seconds.
+const (
+	DefaultMinRecordTTL = 15
+	DefaultMaxRecordTTL = 86400
+)
+
+// SetRecordValidation enables strict validation of records before they are
+// sent to bunny.net: addresses, host names, TTLs within [minTTL, maxTTL] and
+// CAA syntax. Invalid records are rejected with 400 Bad Request and a list of
+// field errors instead of bunny.net's own messages.
+func (h *Handler) SetRecordValidation(strict bool, minTTL, maxTTL int32) {
+	h.strictRecords = strict
+	h.minTTL, h.maxTTL = minTTL, maxTTL
+}
+
+// FieldError describes one invalid field of a record.
+type FieldError struct {
+	Field   string `json:"Field"`
+	Message string `json:"Message"`
+}
+
+// RecordValidationResponse is the 400 response for a record that failed
+// strict validation.
+type RecordValidationResponse struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"Fields"`
+}
+
+// checkRecord validates a record when strict validation is enabled. Updates
+// may leave fields at their zero value, which bunny.net accepts as a partial
+// update, so those are only checked for new records. The TXT length limit is
+// checked either way.
+func (h *Handler) checkRecord(rec *bunny.AddRecordRequest, update bool) []FieldError {
+	if rec.Type == recordTypeTXT && h.maxTXTLength > 0 && len(rec.Value) > h.maxTXTLength {
+		metrics.RecordRequestLimitRejection(limitTXTLength)
+		return []FieldError{{Field: "Value", Message: fmt.Sprintf("TXT value is %d bytes, longer than the %d allowed", len(rec.Value), h.maxTXTLength)}}
+	}
+	if !h.strictRecords {
+		return nil
+	}
+
+	var errs []FieldError
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	typeName := auth.MapRecordTypeToString(rec.Type)
+	if typeName == "" {
+		fail("Type", "unknown record type %d", rec.Type)
+		return errs
+	}
+	if rec.Name != "" && rec.Name != "@" && !validRecordName(rec.Name) {
+		fail("Name", "invalid record name %q; use a name relative to the zone, or \"@\" or an empty name for the apex", rec.Name)
+	}
+	if !update || rec.TTL != 0 {
+		if rec.TTL < h.minTTL || rec.TTL > h.maxTTL {
+			fail("Ttl", "TTL %d is outside %d-%d seconds", rec.TTL, h.minTTL, h.maxTTL)
+		}
+	}
+
+	if rec.Value == "" {
+		if !update {
+			fail("Value", "a value is required")
+		}
+		return errs
+	}
+	switch rec.Type {
+	case recordTypeA, recordTypeAAAA:
+		if err := checkAddress(rec.Type, rec.Value); err != nil {
+			fail("Value", "%v", err)
+		}
+	case recordTypeCNAME, recordTypeMX, recordTypeNS, recordTypePTR:
+		if !validHostName(rec.Value) {
+			fail("Value", "invalid host name %q for a %s record", rec.Value, typeName)
+		}
+	case recordTypeCAA:
+		errs = append(errs, checkCAA(rec)...)
+	}
+
+	if rec.Type == recordTypeMX || rec.Type == recordTypeSRV {
+		if rec.Priority < 0 || rec.Priority > 65535 {
+			fail("Priority", "priority %d is outside 0-65535", rec.Priority)
+		}
+	}
+	if rec.Type == recordTypeSRV {
+		if rec.Weight < 0 || rec.Weight > 65535 {
+			fail("Weight", "weight %d is outside 0-65535", rec.Weight)
+		}
+		if rec.Port < 0 || rec.Port > 65535 {
+			fail("Port", "port %d is outside 0-65535", rec.Port)
+		}
+	}
+	return errs
+}
+
+// checkCAA validates a CAA record's flags, tag and value (RFC 8659).
+func checkCAA(rec *bunny.AddRecordRequest) []FieldError {
+	var errs []FieldError
+	if rec.Flags < 0 || rec.Flags > 255 {
+		errs = append(errs, FieldError{Field: "Flags", Message: fmt.Sprintf("flags %d are outside 0-255", rec.Flags)})
+	}
+
+	switch strings.ToLower(rec.Tag) {
+	case "issue", "issuewild", "issuemail":
+		// An issuer domain, optionally followed by "; key=value" parameters;
+		// ";" alone forbids issuance
+		issuer, params, _ := strings.Cut(rec.Value, ";")
+		if issuer = strings.TrimSpace(issuer); issuer != "" && !validHostName(issuer) {
+			errs = append(errs, FieldError{Field: "Value", Message: fmt.Sprintf("invalid issuer domain %q", issuer)})
+		}
+		for _, p := range strings.Split(params, ";") {
+			if p = strings.TrimSpace(p); p == "" {
+				continue
+			}
+			if key, _, ok := strings.Cut(p, "="); !ok || !isAlnum(strings.TrimSpace(key)) {
+				errs = append(errs, FieldError{Field: "Value", Message: fmt.Sprintf("invalid CAA parameter %q; use key=value", p)})
+			}
+		}
+	case "iodef":
+		u, err := url.Parse(rec.Value)
+		if err != nil || (u.Scheme != "mailto" && u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, FieldError{Field: "Value", Message: fmt.Sprintf("invalid iodef URL %q; use a mailto:, http: or https: URL", rec.Value)})
+		}
+	default:
+		errs = append(errs, FieldError{Field: "Tag", Message: fmt.Sprintf("unknown CAA tag %q; use issue, issuewild, issuemail or iodef", rec.Tag)})
+	}
+	return errs
+}
+
+// validRecordName reports whether name is a record name relative to the zone:
+// dot-separated labels, with "*" allowed as the first label.
+func validRecordName(name string) bool {
+	if len(name) > 253 {
+		return false
+	}
+	for i, label := range strings.Split(name, ".") {
+		if label == "*" && i == 0 {
+			continue
+		}
+		if !validLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// validHostName reports whether name is a fully qualified host name of at
+// least two labels, with or without the trailing dot.
+func validHostName(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	labels := strings.Split(name, ".")
+	if len(name) > 253 || len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if !validLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// validLabel reports whether a label has 1-63 letters, digits, '-' or '_'
+// and does not start or end with '-'.
+func validLabel(label string) bool {
+	if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(label); i++ {
+		if c := label[i]; c != '-' && c != '_' && !isAlnum(label[i:i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeInvalidRecord writes the 400 response for a record that failed validation.
+func writeInvalidRecord(w http.ResponseWriter, message string, errs []FieldError) {
+	writeJSON(w, http.StatusBadRequest, RecordValidationResponse{Error: message, Fields: errs})
+}
@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+func strictHandler(client BunnyClient) *Handler {
+	h := NewHandler(client, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h.SetRecordValidation(true, DefaultMinRecordTTL, DefaultMaxRecordTTL)
+	return h
+}
+
+func TestCheckRecord(t *testing.T) {
+	t.Parallel()
+	h := strictHandler(&mockBunnyClient{})
+
+	tests := []struct {
+		name      string
+		rec       bunny.AddRecordRequest
+		update    bool
+		wantField string // first invalid field, or "" if valid
+	}{
+		{"A", bunny.AddRecordRequest{Type: recordTypeA, Name: "www", Value: "192.0.2.1", TTL: 300}, false, ""},
+		{"A apex", bunny.AddRecordRequest{Type: recordTypeA, Value: "192.0.2.1", TTL: 300}, false, ""},
+		{"A apex as @", bunny.AddRecordRequest{Type: recordTypeA, Name: "@", Value: "192.0.2.1", TTL: 300}, false, ""},
+		{"A with IPv6", bunny.AddRecordRequest{Type: recordTypeA, Name: "www", Value: "2001:db8::1", TTL: 300}, false, "Value"},
+		{"AAAA", bunny.AddRecordRequest{Type: recordTypeAAAA, Name: "www", Value: "2001:db8::1", TTL: 300}, false, ""},
+		{"AAAA with IPv4", bunny.AddRecordRequest{Type: recordTypeAAAA, Name: "www", Value: "192.0.2.1", TTL: 300}, false, "Value"},
+		{"CNAME", bunny.AddRecordRequest{Type: recordTypeCNAME, Name: "*.cdn", Value: "cdn.example.net.", TTL: 300}, false, ""},
+		{"CNAME single label", bunny.AddRecordRequest{Type: recordTypeCNAME, Name: "cdn", Value: "localhost", TTL: 300}, false, "Value"},
+		{"MX", bunny.AddRecordRequest{Type: recordTypeMX, Value: "mail.example.com", TTL: 300, Priority: 10}, false, ""},
+		{"MX bad host", bunny.AddRecordRequest{Type: recordTypeMX, Value: "-mail.example.com", TTL: 300}, false, "Value"},
+		{"MX bad priority", bunny.AddRecordRequest{Type: recordTypeMX, Value: "mail.example.com", TTL: 300, Priority: 70000}, false, "Priority"},
+		{"SRV bad port", bunny.AddRecordRequest{Type: recordTypeSRV, Name: "_sip._tcp", Value: "sip.example.com", TTL: 300, Port: -1}, false, "Port"},
+		{"TXT", bunny.AddRecordRequest{Type: recordTypeTXT, Name: "_acme-challenge", Value: "token", TTL: 60}, false, ""},
+		{"bad name", bunny.AddRecordRequest{Type: recordTypeTXT, Name: "a..b", Value: "token", TTL: 60}, false, "Name"},
+		{"wildcard not first", bunny.AddRecordRequest{Type: recordTypeTXT, Name: "a.*", Value: "token", TTL: 60}, false, "Name"},
+		{"TTL too low", bunny.AddRecordRequest{Type: recordTypeTXT, Name: "a", Value: "token", TTL: 5}, false, "Ttl"},
+		{"TTL too high", bunny.AddRecordRequest{Type: recordTypeTXT, Name: "a", Value: "token", TTL: 90000}, false, "Ttl"},
+		{"missing value", bunny.AddRecordRequest{Type: recordTypeTXT, Name: "a", TTL: 60}, false, "Value"},
+		{"unknown type", bunny.AddRecordRequest{Type: 99, Name: "a", Value: "x", TTL: 60}, false, "Type"},
+		{"partial update", bunny.AddRecordRequest{Type: recordTypeA, Name: "www"}, true, ""},
+		{"update with bad value", bunny.AddRecordRequest{Type: recordTypeA, Value: "not-an-ip"}, true, "Value"},
+		{"CAA issue", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "letsencrypt.org; validationmethods=dns-01", Tag: "issue", TTL: 300}, false, ""},
+		{"CAA forbid issuance", bunny.AddRecordRequest{Type: recordTypeCAA, Value: ";", Tag: "issuewild", TTL: 300}, false, ""},
+		{"CAA bad issuer", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "letsencrypt", Tag: "issue", TTL: 300}, false, "Value"},
+		{"CAA bad parameter", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "letsencrypt.org; dns-01", Tag: "issue", TTL: 300}, false, "Value"},
+		{"CAA iodef", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "mailto:security@example.com", Tag: "iodef", TTL: 300}, false, ""},
+		{"CAA bad iodef", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "ftp://example.com", Tag: "iodef", TTL: 300}, false, "Value"},
+		{"CAA unknown tag", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "letsencrypt.org", Tag: "issuer", TTL: 300}, false, "Tag"},
+		{"CAA bad flags", bunny.AddRecordRequest{Type: recordTypeCAA, Value: "letsencrypt.org", Tag: "issue", Flags: 256, TTL: 300}, false, "Flags"},
+	}
+
+	for _, tt := range tests {
+		errs := h.checkRecord(&tt.rec, tt.update)
+		var got string
+		if len(errs) > 0 {
+			got = errs[0].Field
+		}
+		if got != tt.wantField {
+			t.Errorf("%s: checkRecord() = %+v, want first field %q", tt.name, errs, tt.wantField)
+		}
+	}
+}
+
+func TestCheckRecord_Disabled(t *testing.T) {
+	t.Parallel()
+	h := NewHandler(&mockBunnyClient{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if errs := h.checkRecord(&bunny.AddRecordRequest{Type: recordTypeA, Value: "bogus"}, false); errs != nil {
+		t.Errorf("checkRecord() without strict validation = %+v, want nil", errs)
+	}
+}
+
+func TestHandleAddRecord_StrictValidation(t *testing.T) {
+	t.Parallel()
+	handler := strictHandler(&mockBunnyClient{
+		addRecordFunc: func(context.Context, int64, *bunny.AddRecordRequest) (*bunny.Record, error) {
+			t.Error("an invalid record was sent upstream")
+			return nil, nil
+		},
+	})
+
+	body := `{"Type":0,"Name":"www","Value":"192.0.2","Ttl":1}`
+	w := httptest.NewRecorder()
+	handler.HandleAddRecord(w, newTestRequest(http.MethodPost, "/dnszone/123/records", strings.NewReader(body), map[string]string{"zoneID": "123"}))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	var resp RecordValidationResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if resp.Error != "invalid record" || len(resp.Fields) != 2 || resp.Fields[0].Field != "Ttl" || resp.Fields[1].Field != "Value" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestHandleUpdateRecord_StrictValidation(t *testing.T) {
+	t.Parallel()
+	var updates int
+	handler := strictHandler(&mockBunnyClient{
+		updateRecordFunc: func(_ context.Context, _, recordID int64, req *bunny.AddRecordRequest) (*bunny.Record, error) {
+			updates++
+			return &bunny.Record{ID: recordID, Type: req.Type, Value: req.Value}, nil
+		},
+	})
+	params := map[string]string{"zoneID": "123", "recordID": "456"}
+
+	for body, want := range map[string]int{
+		`{"Type":2,"Value":"target"}`:  http.StatusBadRequest,
+		`{"Type":2,"Comment":"moved"}`: http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		handler.HandleUpdateRecord(w, newTestRequest(http.MethodPost, "/dnszone/123/records/456", strings.NewReader(body), params))
+		if w.Code != want {
+			t.Errorf("update %s: status %d, want %d: %s", body, w.Code, want, w.Body.String())
+		}
+	}
+	if updates != 1 {
+		t.Errorf("%d updates sent upstream, want 1", updates)
+	}
+}
+
+func TestHandleBatchRecords_StrictValidation(t *testing.T) {
+	t.Parallel()
+	handler := strictHandler(&mockBunnyClient{
+		getZoneFunc: func(context.Context, int64) (*bunny.Zone, error) {
+			t.Error("a batch with an invalid record was processed")
+			return batchZone(), nil
+		},
+	})
+
+	body := `{"Operations":[{"Action":"create","Record":{"Type":3,"Name":"_acme-challenge","Value":"t","Ttl":60}},` +
+		`{"Action":"create","Record":{"Type":1,"Name":"www","Value":"192.0.2.1","Ttl":60}}]}`
+	w := httptest.NewRecorder()
+	handler.HandleBatchRecords(w, newBatchRequest(body, nil))
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "operation 1: invalid record") {
+		t.Errorf("status %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleImportRecords_StrictValidation(t *testing.T) {
+	t.Parallel()
+	handler := strictHandler(importClient(t, nil))
+
+	body := "Type,Name,Value,TTL\nTXT,_acme-challenge,token,60\nCNAME,cdn,localhost,60\n"
+	w := httptest.NewRecorder()
+	handler.HandleImportRecords(w, newImportRequest(body, "text/csv", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	resp := decodeImportResponse(t, w)
+	if len(resp.Results) != 2 || resp.Results[0].Status != ImportValid || resp.Results[1].Status != ImportInvalid ||
+		!strings.HasPrefix(resp.Results[1].Error, "Value: ") {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+}
@@ -0,0 +1,683 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// maxZoneFileLine is the longest zone file line filterZoneFile accepts.
+const maxZoneFileLine = 1 << 20
+
+// importEntry is one record line of an import file: the record to create, or
+// why the line is skipped or invalid.
+type importEntry struct {
+	line    int
+	record  *bunny.AddRecordRequest // nil for skipped and invalid lines
+	status  string                  // ImportSkipped or ImportInvalid when record is nil
+	message string
+}
+
+func invalidEntry(line int, format string, args ...any) importEntry {
+	return importEntry{line: line, status: ImportInvalid, message: fmt.Sprintf(format, args...)}
+}
+
+// Numeric bunny.net record types, indexed like auth.RecordTypeNames.
+const (
+	recordTypeA     = 0
+	recordTypeAAAA  = 1
+	recordTypeCNAME = 2
+	recordTypeTXT   = 3
+	recordTypeMX    = 4
+	recordTypeSRV   = 8
+	recordTypeCAA   = 9
+	recordTypePTR   = 10
+	recordTypeNS    = 12
+)
+
+// zoneToken is a token of a zone file entry. Quoted tokens are character
+// strings and never names, numbers or types.
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// parseBINDImport parses an RFC 1035 zone file for the zone with the given
+// domain. It supports $ORIGIN and $TTL, comments, parentheses spanning lines,
+// omitted owners, and TTL and class in either order. Names are made relative
+// to the zone, with the apex named as apexName does; records outside it are
+// invalid. SOA records and NS records at the apex are skipped, since
+// bunny.net manages them.
+func parseBINDImport(data, domain string) []importEntry {
+	p := &zoneParser{
+		zone:   strings.ToLower(strings.TrimSuffix(domain, ".")) + ".",
+		ttl:    -1,
+		origin: strings.TrimSuffix(domain, ".") + ".",
+	}
+
+	var (
+		entries  []importEntry
+		pending  []zoneToken
+		depth    int
+		start    int
+		indented bool
+	)
+	for i, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if len(pending) == 0 && depth == 0 {
+			start = i + 1
+			indented = strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		}
+		tokens, newDepth, err := tokenizeZoneLine(line, depth)
+		if err != nil {
+			entries = append(entries, invalidEntry(start, "%v", err))
+			pending, depth = nil, 0
+			continue
+		}
+		pending, depth = append(pending, tokens...), newDepth
+		if depth > 0 || len(pending) == 0 {
+			continue
+		}
+		if e, ok := p.entry(start, indented, pending); ok {
+			entries = append(entries, e)
+		}
+		pending = nil
+	}
+	if depth > 0 {
+		entries = append(entries, invalidEntry(start, "unclosed parenthesis"))
+	}
+	return entries
+}
+
+// filterZoneFile copies a zone file from src to dst one entry at a time,
+// keeping directives, blank lines, the SOA record and records whose type is
+// in permitted (canonical names). Comment lines are dropped, since bunny.net
+// may use them for record comments, and so are entries that cannot be parsed.
+func filterZoneFile(dst io.Writer, src io.Reader, permitted map[string]bool) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxZoneFileLine)
+
+	var (
+		lines  []string
+		tokens []zoneToken
+		depth  int
+	)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		lineTokens, newDepth, err := tokenizeZoneLine(line, depth)
+		if err != nil {
+			lines, tokens, depth = nil, nil, 0
+			continue
+		}
+		lines, tokens, depth = append(lines, line), append(tokens, lineTokens...), newDepth
+		if depth > 0 {
+			continue
+		}
+
+		if keepZoneEntry(lines, tokens, permitted) {
+			for _, l := range lines {
+				if _, err := io.WriteString(dst, l+"\n"); err != nil {
+					return err
+				}
+			}
+		}
+		lines, tokens = nil, nil
+	}
+	return scanner.Err()
+}
+
+// keepZoneEntry reports whether filterZoneFile keeps one logical entry.
+func keepZoneEntry(lines []string, tokens []zoneToken, permitted map[string]bool) bool {
+	if len(tokens) == 0 {
+		return len(lines) == 1 && strings.TrimSpace(lines[0]) == ""
+	}
+	indented := strings.HasPrefix(lines[0], " ") || strings.HasPrefix(lines[0], "\t")
+	if !indented && !tokens[0].quoted && strings.HasPrefix(tokens[0].text, "$") {
+		return true
+	}
+
+	if !indented {
+		tokens = tokens[1:]
+	}
+	for len(tokens) > 0 && !tokens[0].quoted {
+		if _, ok := parseZoneTTL(tokens[0].text); !ok && !isZoneClass(tokens[0].text) {
+			break
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 || tokens[0].quoted {
+		return false
+	}
+	if strings.EqualFold(tokens[0].text, "SOA") {
+		return true
+	}
+	canonical, ok := auth.NormalizeRecordType(tokens[0].text)
+	return ok && permitted[canonical]
+}
+
+// tokenizeZoneLine splits one line of a zone file into tokens, dropping the
+// comment. depth is the parenthesis nesting at the start of the line; the
+// nesting at its end is returned.
+func tokenizeZoneLine(line string, depth int) ([]zoneToken, int, error) {
+	var tokens []zoneToken
+	var cur strings.Builder
+	inToken := false
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, zoneToken{text: cur.String()})
+			cur.Reset()
+			inToken = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ';':
+			flush()
+			return tokens, depth, nil
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '(':
+			flush()
+			depth++
+		case c == ')':
+			flush()
+			if depth == 0 {
+				return nil, 0, errors.New("unbalanced parenthesis")
+			}
+			depth--
+		case c == '"':
+			flush()
+			text, n, err := readQuoted(line[i+1:])
+			if err != nil {
+				return nil, 0, err
+			}
+			tokens = append(tokens, zoneToken{text: text, quoted: true})
+			i += n + 1
+		case c == '\\' && i+1 < len(line):
+			cur.WriteByte(c)
+			cur.WriteByte(line[i+1])
+			inToken = true
+			i++
+		default:
+			cur.WriteByte(c)
+			inToken = true
+		}
+	}
+	flush()
+	return tokens, depth, nil
+}
+
+// readQuoted reads a character string up to its closing quote, resolving
+// \X and \DDD escapes. It returns the string and the bytes consumed,
+// including the closing quote.
+func readQuoted(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\\':
+			if i+3 < len(s) && isDigits(s[i+1:i+4]) {
+				n, _ := strconv.Atoi(s[i+1 : i+4])
+				if n > 255 {
+					return "", 0, fmt.Errorf("invalid escape \\%s", s[i+1:i+4])
+				}
+				b.WriteByte(byte(n))
+				i += 3
+			} else if i+1 < len(s) {
+				b.WriteByte(s[i+1])
+				i++
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, errors.New("unterminated quoted string")
+}
+
+// zoneParser holds the state carried between zone file entries.
+type zoneParser struct {
+	zone   string // lower-case absolute zone name
+	origin string // absolute name relative names are completed with
+	ttl    int32  // $TTL, or -1 if unset
+	owner  string // owner of the previous record, used when one is omitted
+}
+
+// entry parses one logical entry. Directives return false.
+func (p *zoneParser) entry(line int, indented bool, tokens []zoneToken) (importEntry, bool) {
+	if !indented && !tokens[0].quoted && strings.HasPrefix(tokens[0].text, "$") {
+		if err := p.directive(tokens); err != nil {
+			return invalidEntry(line, "%v", err), true
+		}
+		return importEntry{}, false
+	}
+
+	if !indented {
+		owner, err := p.absolute(tokens[0])
+		if err != nil {
+			return invalidEntry(line, "%v", err), true
+		}
+		p.owner = owner
+		tokens = tokens[1:]
+	} else if p.owner == "" {
+		return invalidEntry(line, "no owner name"), true
+	}
+
+	ttl := int32(-1)
+	for len(tokens) > 0 && !tokens[0].quoted {
+		if t, ok := parseZoneTTL(tokens[0].text); ok && ttl < 0 {
+			ttl = t
+		} else if isZoneClass(tokens[0].text) {
+			if !strings.EqualFold(tokens[0].text, "IN") {
+				return invalidEntry(line, "class %s is not supported; only IN", strings.ToUpper(tokens[0].text)), true
+			}
+		} else {
+			break
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 || tokens[0].quoted {
+		return invalidEntry(line, "missing record type"), true
+	}
+	rrType, rdata := strings.ToUpper(tokens[0].text), tokens[1:]
+
+	if ttl < 0 {
+		ttl = p.ttl
+	}
+	if ttl < 0 {
+		return invalidEntry(line, "no TTL; set one on the record or with $TTL"), true
+	}
+
+	name, ok := p.relative(p.owner)
+	if !ok {
+		return invalidEntry(line, "name %s is outside zone %s", p.owner, p.zone), true
+	}
+
+	switch {
+	case rrType == "SOA":
+		return importEntry{line: line, status: ImportSkipped, message: "SOA records are managed by bunny.net"}, true
+	case rrType == "NS" && name == "":
+		return importEntry{line: line, status: ImportSkipped, message: "apex NS records are managed by bunny.net"}, true
+	}
+
+	rec := &bunny.AddRecordRequest{Name: name, TTL: ttl}
+	if err := p.rdata(rec, rrType, rdata); err != nil {
+		return invalidEntry(line, "%s record: %v", rrType, err), true
+	}
+	if rec.Name == "" {
+		rec.Name = apexName(rec.Type)
+	}
+	return importEntry{line: line, record: rec}, true
+}
+
+// directive applies $ORIGIN or $TTL.
+func (p *zoneParser) directive(tokens []zoneToken) error {
+	name := strings.ToUpper(tokens[0].text)
+	switch name {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return errors.New("$ORIGIN needs one domain name")
+		}
+		origin, err := p.absolute(tokens[1])
+		if err != nil {
+			return err
+		}
+		p.origin = origin
+	case "$TTL":
+		if len(tokens) != 2 {
+			return errors.New("$TTL needs one TTL")
+		}
+		ttl, ok := parseZoneTTL(tokens[1].text)
+		if !ok || tokens[1].quoted {
+			return fmt.Errorf("invalid TTL %q", tokens[1].text)
+		}
+		p.ttl = ttl
+	default:
+		return fmt.Errorf("%s is not supported", name)
+	}
+	return nil
+}
+
+// rdata validates a record's data and fills in its type and fields.
+func (p *zoneParser) rdata(rec *bunny.AddRecordRequest, rrType string, rdata []zoneToken) error {
+	want := map[string]int{"A": 1, "AAAA": 1, "CNAME": 1, "PTR": 1, "NS": 1, "MX": 2, "SRV": 4, "CAA": 3}
+	if n, ok := want[rrType]; ok && len(rdata) != n {
+		return fmt.Errorf("expected %d fields, got %d", n, len(rdata))
+	}
+
+	var err error
+	switch rrType {
+	case "A", "AAAA":
+		rec.Type = recordTypeA
+		if rrType == "AAAA" {
+			rec.Type = recordTypeAAAA
+		}
+		rec.Value = rdata[0].text
+		return checkAddress(rec.Type, rec.Value)
+	case "CNAME", "PTR", "NS":
+		rec.Type = map[string]int{"CNAME": recordTypeCNAME, "PTR": recordTypePTR, "NS": recordTypeNS}[rrType]
+		rec.Value, err = p.target(rdata[0])
+		return err
+	case "TXT":
+		rec.Type = recordTypeTXT
+		if len(rdata) == 0 {
+			return errors.New("missing text")
+		}
+		var b strings.Builder
+		for _, t := range rdata {
+			b.WriteString(t.text)
+		}
+		rec.Value = b.String()
+		return nil
+	case "MX":
+		rec.Type = recordTypeMX
+		if rec.Priority, err = parseUint16(rdata[0], "preference"); err != nil {
+			return err
+		}
+		rec.Value, err = p.target(rdata[1])
+		return err
+	case "SRV":
+		rec.Type = recordTypeSRV
+		if rec.Priority, err = parseUint16(rdata[0], "priority"); err != nil {
+			return err
+		}
+		if rec.Weight, err = parseUint16(rdata[1], "weight"); err != nil {
+			return err
+		}
+		if rec.Port, err = parseUint16(rdata[2], "port"); err != nil {
+			return err
+		}
+		rec.Value, err = p.target(rdata[3])
+		return err
+	case "CAA":
+		rec.Type = recordTypeCAA
+		flags, err := strconv.ParseUint(rdata[0].text, 10, 8)
+		if err != nil || rdata[0].quoted {
+			return fmt.Errorf("invalid flags %q", rdata[0].text)
+		}
+		rec.Flags = int(flags)
+		if rdata[1].quoted || !isAlnum(rdata[1].text) {
+			return fmt.Errorf("invalid tag %q", rdata[1].text)
+		}
+		rec.Tag = strings.ToLower(rdata[1].text)
+		rec.Value = rdata[2].text
+		return nil
+	default:
+		return errors.New("type is not supported")
+	}
+}
+
+// absolute returns the absolute form of a name, completing relative names
+// with the current origin.
+func (p *zoneParser) absolute(t zoneToken) (string, error) {
+	name := t.text
+	if t.quoted || name == "" || strings.Contains(name, "..") || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid domain name %q", name)
+	}
+	switch {
+	case name == "@":
+		return p.origin, nil
+	case strings.HasSuffix(name, "."):
+		return name, nil
+	default:
+		return name + "." + p.origin, nil
+	}
+}
+
+// apexName returns the name bunny.net accepts for a record of the given type
+// at the zone apex: empty for MX and CAA records, "@" for the other types.
+func apexName(recordType int) string {
+	if recordType == recordTypeMX || recordType == recordTypeCAA {
+		return ""
+	}
+	return "@"
+}
+
+// relative returns an absolute name relative to the zone, "" for the apex.
+func (p *zoneParser) relative(name string) (string, bool) {
+	name = strings.ToLower(name)
+	if name == p.zone {
+		return "", true
+	}
+	if rel, ok := strings.CutSuffix(name, "."+p.zone); ok {
+		return rel, true
+	}
+	return "", false
+}
+
+// target returns the host name a record points to, without the trailing dot.
+func (p *zoneParser) target(t zoneToken) (string, error) {
+	name, err := p.absolute(t)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(name, "."), nil
+}
+
+// parseZoneTTL parses a TTL in seconds or with BIND units, such as 1h30m.
+func parseZoneTTL(s string) (int32, bool) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, false
+	}
+	if isDigits(s) {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		return int32(n), true
+	}
+
+	units := map[byte]int64{'s': 1, 'm': 60, 'h': 3600, 'd': 86400, 'w': 604800}
+	var total, n int64
+	digits := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= '0' && c <= '9' {
+			n = n*10 + int64(c-'0')
+			digits = true
+			if n > 1<<31 {
+				return 0, false
+			}
+			continue
+		}
+		unit, ok := units[c|0x20]
+		if !ok || !digits {
+			return 0, false
+		}
+		total += n * unit
+		n, digits = 0, false
+	}
+	if digits || total > 1<<31-1 {
+		return 0, false
+	}
+	return int32(total), true
+}
+
+// isZoneClass reports whether a token is a DNS class.
+func isZoneClass(s string) bool {
+	switch strings.ToUpper(s) {
+	case "IN", "CH", "HS", "CS", "ANY":
+		return true
+	}
+	return false
+}
+
+func parseUint16(t zoneToken, field string) (int32, error) {
+	n, err := strconv.ParseUint(t.text, 10, 16)
+	if err != nil || t.quoted {
+		return 0, fmt.Errorf("invalid %s %q", field, t.text)
+	}
+	return int32(n), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		if (c < 'a' || c > 'z') && (s[i] < '0' || s[i] > '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// checkAddress rejects A and AAAA values that are not an address of that family.
+func checkAddress(recordType int, value string) error {
+	addr, err := netip.ParseAddr(value)
+	switch {
+	case recordType == recordTypeA && (err != nil || !addr.Is4()):
+		return fmt.Errorf("invalid IPv4 address %q", value)
+	case recordType == recordTypeAAAA && (err != nil || !addr.Is6() || addr.Is4In6()):
+		return fmt.Errorf("invalid IPv6 address %q", value)
+	}
+	return nil
+}
+
+// defaultImportTTL is the TTL of CSV records without one.
+const defaultImportTTL = 300
+
+// csvColumns are the columns a CSV import may have, named like the fields of
+// POST /dnszone/{zoneID}/records. Type, Name and Value are required.
+var csvColumns = []string{"type", "name", "value", "ttl", "priority", "weight", "port", "flags", "tag", "disabled", "comment"}
+
+// parseCSVImport parses CSV records. The first row names the columns, in any
+// order and case. Types are record type names, such as A or TXT; "@" or an
+// empty name is the apex; a missing TTL is defaultImportTTL. Rows with the
+// wrong number of fields are invalid; a malformed file or header is an error.
+func parseCSVImport(data string) ([]importEntry, error) {
+	r := csv.NewReader(strings.NewReader(data))
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing CSV header row")
+		}
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, fmt.Errorf("unknown CSV column %q", header[i])
+		}
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("duplicate CSV column %q", header[i])
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"type", "name", "value"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	var entries []importEntry
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+			entries = append(entries, invalidEntry(parseErr.StartLine, "expected %d fields, got %d", len(header), len(row)))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		entries = append(entries, csvEntry(line, row, columns))
+	}
+}
+
+// csvEntry converts one CSV row to a record.
+func csvEntry(line int, row []string, columns map[string]int) importEntry {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	number := func(name string, bits int) (int64, error) {
+		s := field(name)
+		if s == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseUint(s, 10, bits)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, s)
+		}
+		return int64(n), nil
+	}
+
+	typeName, ok := auth.NormalizeRecordType(field("type"))
+	if !ok {
+		return invalidEntry(line, "unknown record type %q", field("type"))
+	}
+	rec := &bunny.AddRecordRequest{
+		Type:    slices.Index(auth.RecordTypeNames(), typeName),
+		Name:    field("name"),
+		Value:   field("value"),
+		Tag:     field("tag"),
+		Comment: field("comment"),
+	}
+	if rec.Name == "@" || rec.Name == "" {
+		rec.Name = apexName(rec.Type)
+	}
+	if rec.Value == "" {
+		return invalidEntry(line, "missing value")
+	}
+	if err := checkAddress(rec.Type, rec.Value); err != nil {
+		return invalidEntry(line, "%v", err)
+	}
+
+	fields := []struct {
+		name string
+		bits int
+		set  func(int64)
+	}{
+		{"ttl", 31, func(n int64) { rec.TTL = int32(n) }},
+		{"priority", 16, func(n int64) { rec.Priority = int32(n) }},
+		{"weight", 16, func(n int64) { rec.Weight = int32(n) }},
+		{"port", 16, func(n int64) { rec.Port = int32(n) }},
+		{"flags", 8, func(n int64) { rec.Flags = int(n) }},
+	}
+	for _, f := range fields {
+		n, err := number(f.name, f.bits)
+		if err != nil {
+			return invalidEntry(line, "%v", err)
+		}
+		f.set(n)
+	}
+	if field("ttl") == "" {
+		rec.TTL = defaultImportTTL
+	}
+	if s := field("disabled"); s != "" {
+		disabled, err := strconv.ParseBool(s)
+		if err != nil {
+			return invalidEntry(line, "invalid disabled %q", s)
+		}
+		rec.Disabled = disabled
+	}
+	return importEntry{line: line, record: rec}
+}
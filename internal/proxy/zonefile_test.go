@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+func TestParseBINDImport(t *testing.T) {
+	t.Parallel()
+
+	zone := `$ORIGIN example.com.
+$TTL 1h
+@       IN SOA ns1.bunny.net. admin.example.com. (
+            2024010101 ; serial
+            3600 600 604800 300 )
+@       IN NS  kiki.bunny.net.
+@       300 IN A 192.0.2.1
+        IN AAAA 2001:db8::1
+www     CNAME example.com.
+mail.example.com. 600 IN MX 10 mx.example.com.
+_sip._tcp IN SRV 10 20 5060 sip
+@       TXT "v=spf1 -all" " ~all" ; comment
+@       CAA 0 issue "letsencrypt.org"
+sub     NS ns.other.net.
+@       MX 20 mx.example.com.
+`
+	entries := parseBINDImport(zone, "Example.com")
+
+	want := []importEntry{
+		{line: 3, status: ImportSkipped},
+		{line: 6, status: ImportSkipped},
+		{line: 7, record: &bunny.AddRecordRequest{Type: recordTypeA, Name: "@", Value: "192.0.2.1", TTL: 300}},
+		{line: 8, record: &bunny.AddRecordRequest{Type: recordTypeAAAA, Name: "@", Value: "2001:db8::1", TTL: 3600}},
+		{line: 9, record: &bunny.AddRecordRequest{Type: recordTypeCNAME, Name: "www", Value: "example.com", TTL: 3600}},
+		{line: 10, record: &bunny.AddRecordRequest{Type: recordTypeMX, Name: "mail", Value: "mx.example.com", TTL: 600, Priority: 10}},
+		{line: 11, record: &bunny.AddRecordRequest{Type: recordTypeSRV, Name: "_sip._tcp", Value: "sip.example.com", TTL: 3600, Priority: 10, Weight: 20, Port: 5060}},
+		{line: 12, record: &bunny.AddRecordRequest{Type: recordTypeTXT, Name: "@", Value: "v=spf1 -all ~all", TTL: 3600}},
+		{line: 13, record: &bunny.AddRecordRequest{Type: recordTypeCAA, Name: "", Value: "letsencrypt.org", TTL: 3600, Tag: "issue"}},
+		{line: 14, record: &bunny.AddRecordRequest{Type: recordTypeNS, Name: "sub", Value: "ns.other.net", TTL: 3600}},
+		// bunny.net only accepts an empty apex name for MX and CAA records
+		{line: 15, record: &bunny.AddRecordRequest{Type: recordTypeMX, Name: "", Value: "mx.example.com", TTL: 3600, Priority: 20}},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i, w := range want {
+		got := entries[i]
+		if got.line != w.line || got.status != w.status {
+			t.Errorf("entry %d: got line %d status %q (%s), want line %d status %q", i, got.line, got.status, got.message, w.line, w.status)
+			continue
+		}
+		if (got.record == nil) != (w.record == nil) || (w.record != nil && !reflect.DeepEqual(*got.record, *w.record)) {
+			t.Errorf("entry %d: got record %+v, want %+v", i, got.record, w.record)
+		}
+	}
+}
+
+func TestFilterZoneFile(t *testing.T) {
+	t.Parallel()
+
+	zone := `;; Zone: example.com
+$ORIGIN example.com.
+$TTL 300
+
+@       IN SOA ns1.bunny.net. admin.example.com. (
+            2024010101 3600 600 604800 300 )
+@       300 IN A 192.0.2.1
+; internal host
+intranet 300 IN A 10.0.0.1
+_acme-challenge 60 IN TXT "token" ; renewed daily
+        IN TXT ( "second"
+                 "token" )
+www     CNAME example.com.
+`
+	var out strings.Builder
+	if err := filterZoneFile(&out, strings.NewReader(zone), map[string]bool{"TXT": true}); err != nil {
+		t.Fatalf("filterZoneFile() error = %v", err)
+	}
+
+	want := `$ORIGIN example.com.
+$TTL 300
+
+@       IN SOA ns1.bunny.net. admin.example.com. (
+            2024010101 3600 600 604800 300 )
+_acme-challenge 60 IN TXT "token" ; renewed daily
+        IN TXT ( "second"
+                 "token" )
+`
+	if out.String() != want {
+		t.Errorf("filterZoneFile() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestParseBINDImport_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		zone    string
+		message string
+	}{
+		{"no ttl", "www IN A 192.0.2.1", "no TTL"},
+		{"bad address", "www 300 IN A 2001:db8::1", "invalid IPv4 address"},
+		{"outside zone", "www.example.org. 300 IN A 192.0.2.1", "outside zone"},
+		{"unsupported type", "www 300 IN HINFO cpu os", "type is not supported"},
+		{"wrong field count", "www 300 IN MX mx.example.com.", "expected 2 fields"},
+		{"bad class", "www 300 CH A 192.0.2.1", "only IN"},
+		{"no owner", "  300 IN A 192.0.2.1", "no owner name"},
+		{"unterminated string", `www 300 IN TXT "open`, "unterminated"},
+		{"unclosed parenthesis", "www 300 IN MX ( 10\n mx.example.com.", "unclosed parenthesis"},
+		{"bad directive", "$INCLUDE other.zone", "not supported"},
+	}
+	for _, tt := range tests {
+		entries := parseBINDImport(tt.zone, "example.com")
+		if len(entries) != 1 || entries[0].status != ImportInvalid || !strings.Contains(entries[0].message, tt.message) {
+			t.Errorf("%s: expected one invalid entry mentioning %q, got %+v", tt.name, tt.message, entries)
+		}
+	}
+}
+
+func TestParseZoneTTL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want int32
+		ok   bool
+	}{
+		{"300", 300, true},
+		{"1h30m", 5400, true},
+		{"1W", 604800, true},
+		{"1d2", 0, false},
+		{"h", 0, false},
+		{"IN", 0, false},
+		{"4294967296", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseZoneTTL(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseZoneTTL(%q) = %d, %v; want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseCSVImport(t *testing.T) {
+	t.Parallel()
+
+	data := `Type,Name,Value,TTL,Priority
+A,@,192.0.2.1,,
+# comment
+MX,mail,mx.example.com,600,10
+txt,_acme-challenge,"a,b",60,
+HINFO,www,x,,
+AAAA,www,192.0.2.1,,
+A,www
+`
+	entries, err := parseCSVImport(data)
+	if err != nil {
+		t.Fatalf("parseCSVImport() error = %v", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("expected 6 entries, got %d: %+v", len(entries), entries)
+	}
+
+	want := []bunny.AddRecordRequest{
+		{Type: recordTypeA, Name: "@", Value: "192.0.2.1", TTL: defaultImportTTL},
+		{Type: recordTypeMX, Name: "mail", Value: "mx.example.com", TTL: 600, Priority: 10},
+		{Type: recordTypeTXT, Name: "_acme-challenge", Value: "a,b", TTL: 60},
+	}
+	for i, w := range want {
+		if entries[i].record == nil || !reflect.DeepEqual(*entries[i].record, w) {
+			t.Errorf("entry %d: got %+v (%s), want %+v", i, entries[i].record, entries[i].message, w)
+		}
+	}
+	if entries[1].line != 4 {
+		t.Errorf("expected MX on line 4, got %d", entries[1].line)
+	}
+	for i, msg := range map[int]string{3: "unknown record type", 4: "invalid IPv6 address", 5: "expected 5 fields"} {
+		if entries[i].status != ImportInvalid || !strings.Contains(entries[i].message, msg) {
+			t.Errorf("entry %d: expected invalid %q, got %+v", i, msg, entries[i])
+		}
+	}
+}
+
+func TestParseCSVImport_Header(t *testing.T) {
+	t.Parallel()
+
+	for _, data := range []string{"", "Type,Name\nA,www", "Type,Name,Value,Color", "Type,Name,Value,type"} {
+		if _, err := parseCSVImport(data); err == nil {
+			t.Errorf("parseCSVImport(%q) expected an error", data)
+		}
+	}
+}
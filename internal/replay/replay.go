@@ -0,0 +1,243 @@
+// Package replay rejects replayed mutating requests.
+//
+// Clients send the time of the request in X-Request-Timestamp (unix seconds)
+// and a unique value in X-Request-Nonce. A request is rejected when its
+// timestamp is outside the window around the proxy's clock, or when the same
+// token already used its nonce. Nonces are recorded in storage, so a replay is
+// caught by any instance sharing the database, and pruned once their
+// timestamps can no longer pass the window check.
+//
+// The headers are not signed, so this stops requests that are captured and
+// re-sent as they are, for example from logs or by a misbehaving
+// intermediary; it does not stop a client that can rewrite headers.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// TimestampHeader carries the request time in unix seconds.
+	TimestampHeader = "X-Request-Timestamp"
+
+	// NonceHeader carries a value the token has not used before.
+	NonceHeader = "X-Request-Nonce"
+
+	// DefaultWindow is how far a request timestamp may be from the proxy's clock.
+	DefaultWindow = 5 * time.Minute
+
+	// pruneInterval is how often nonces outside the window are deleted.
+	pruneInterval = time.Minute
+)
+
+// Modes accepted by New.
+const (
+	// ModeOff disables replay protection.
+	ModeOff = "off"
+
+	// ModeOptional checks requests that send the headers and lets others through.
+	ModeOptional = "optional"
+
+	// ModeRequired rejects mutating requests without the headers.
+	ModeRequired = "required"
+)
+
+// validNonce limits nonces to 16-128 URL-safe characters, enough for a UUID
+// or a random base64url string.
+var validNonce = regexp.MustCompile(`^[A-Za-z0-9_-]{16,128}$`)
+
+// Store defines the storage operations needed by the guard.
+type Store interface {
+	RecordNonce(ctx context.Context, tokenID int64, nonce string, at time.Time) error
+	PruneNonces(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Option configures a Guard.
+type Option func(*Guard)
+
+// WithWindow sets how far a request timestamp may be from the proxy's clock.
+func WithWindow(d time.Duration) Option {
+	return func(g *Guard) {
+		g.window = d
+	}
+}
+
+// WithWriteErrorHandler calls fn with every error from recording a nonce,
+// so storage failures can be detected.
+func WithWriteErrorHandler(fn func(error)) Option {
+	return func(g *Guard) {
+		g.onWriteError = fn
+	}
+}
+
+// Guard validates request timestamps and nonces.
+type Guard struct {
+	store        Store
+	logger       *slog.Logger
+	mode         string
+	window       time.Duration
+	now          func() time.Time
+	onWriteError func(error)
+}
+
+// New creates a replay guard in the given mode: ModeOff, ModeOptional or ModeRequired.
+func New(store Store, mode string, logger *slog.Logger, opts ...Option) (*Guard, error) {
+	switch mode {
+	case ModeOff, ModeOptional, ModeRequired:
+	default:
+		return nil, fmt.Errorf("invalid replay protection mode %q: must be off, optional or required", mode)
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	g := &Guard{
+		store:  store,
+		logger: logger,
+		mode:   mode,
+		window: DefaultWindow,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, nil
+}
+
+// Enabled reports whether requests are checked.
+func (g *Guard) Enabled() bool {
+	return g.mode != ModeOff
+}
+
+// Middleware rejects mutating requests with a missing, malformed or stale
+// timestamp or a reused nonce with 401 Unauthorized. Reads are never checked.
+// It must run after authentication; nonces are scoped to the token, and to
+// token ID 0 for the master key.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	if !g.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		timestamp, nonce := r.Header.Get(TimestampHeader), r.Header.Get(NonceHeader)
+		if timestamp == "" && nonce == "" && g.mode == ModeOptional {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reason, message := g.check(r.Context(), timestamp, nonce)
+		if reason == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		metrics.RecordReplayRejection(reason)
+		if reason == "error" {
+			writeError(w, http.StatusInternalServerError, "internal_error", message)
+			return
+		}
+		g.logger.Warn("rejected replayed request", "reason", reason, "method", r.Method, "path", r.URL.Path)
+		writeError(w, http.StatusUnauthorized, "replay_rejected", message)
+	})
+}
+
+// check validates a request's timestamp and records its nonce. It returns an
+// empty reason for a valid request, or the rejection reason and message.
+func (g *Guard) check(ctx context.Context, timestamp, nonce string) (reason, message string) {
+	if timestamp == "" || nonce == "" {
+		return "missing", "Mutating requests must send " + TimestampHeader + " and " + NonceHeader + "."
+	}
+	if !validNonce.MatchString(nonce) {
+		return "invalid", NonceHeader + " must be 16-128 letters, digits, '-' or '_'."
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "invalid", TimestampHeader + " must be a unix time in seconds."
+	}
+
+	now := g.now()
+	if skew := now.Sub(time.Unix(seconds, 0)).Abs(); skew > g.window {
+		return "stale", fmt.Sprintf("%s is more than %d seconds from the proxy's clock.", TimestampHeader, int(g.window.Seconds()))
+	}
+
+	var tokenID int64
+	if token := auth.TokenFromContext(ctx); token != nil {
+		tokenID = token.ID
+	}
+	if err := g.store.RecordNonce(ctx, tokenID, nonce, now); err != nil {
+		if errors.Is(err, storage.ErrDuplicate) {
+			return "duplicate", NonceHeader + " was already used."
+		}
+		g.logger.Error("failed to record nonce", "error", err)
+		if g.onWriteError != nil {
+			g.onWriteError(err)
+		}
+		return "error", "internal error"
+	}
+	return "", ""
+}
+
+// Run prunes nonces that can no longer be replayed until ctx is canceled.
+// A nonce must be kept for two windows: its timestamp may be a full window
+// ahead of the clock when it is first seen.
+func (g *Guard) Run(ctx context.Context) {
+	if !g.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.prune(ctx)
+		}
+	}
+}
+
+// prune deletes nonces seen more than two windows ago.
+func (g *Guard) prune(ctx context.Context) {
+	n, err := g.store.PruneNonces(ctx, g.now().Add(-2*g.window))
+	if err != nil {
+		g.logger.Error("failed to prune nonces", "error", err)
+		return
+	}
+	if n > 0 {
+		g.logger.Debug("pruned nonces", "count", n)
+	}
+}
+
+// readOnlyMethod reports whether a request method never writes.
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// writeError writes a JSON error response with code and message.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"error":   code,
+		"message": message,
+	})
+}
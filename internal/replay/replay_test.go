@@ -0,0 +1,183 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestStore(t *testing.T) *storage.SQLiteStorage {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// serve sends a request through the guard as the given token, returning the status.
+func serve(g *Guard, method string, tokenID int64, timestamp, nonce string) int {
+	handler := g.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(method, "/dnszone/1/records", nil)
+	if timestamp != "" {
+		req.Header.Set(TimestampHeader, timestamp)
+	}
+	if nonce != "" {
+		req.Header.Set(NonceHeader, nonce)
+	}
+	if tokenID != 0 {
+		req = req.WithContext(auth.WithToken(req.Context(), &storage.Token{ID: tokenID}))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestNew_InvalidMode(t *testing.T) {
+	t.Parallel()
+	if _, err := New(nil, "strict", testLogger()); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
+
+func TestMiddleware_Required(t *testing.T) {
+	t.Parallel()
+	g, err := New(newTestStore(t), ModeRequired, testLogger(), WithWindow(time.Minute))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	now := time.Now()
+	g.now = func() time.Time { return now }
+	ts := strconv.FormatInt(now.Unix(), 10)
+	nonce := "0b8e6a52-3c1f-4d0e-9f4a-1c2d3e4f5a6b"
+
+	tests := []struct {
+		name      string
+		method    string
+		tokenID   int64
+		timestamp string
+		nonce     string
+		want      int
+	}{
+		{"reads are not checked", http.MethodGet, 1, "", "", http.StatusOK},
+		{"missing headers", http.MethodPut, 1, "", "", http.StatusUnauthorized},
+		{"missing nonce", http.MethodPut, 1, ts, "", http.StatusUnauthorized},
+		{"short nonce", http.MethodPut, 1, ts, "abc", http.StatusUnauthorized},
+		{"invalid timestamp", http.MethodPut, 1, "yesterday", nonce, http.StatusUnauthorized},
+		{"stale timestamp", http.MethodPut, 1, strconv.FormatInt(now.Add(-2*time.Minute).Unix(), 10), nonce, http.StatusUnauthorized},
+		{"future timestamp", http.MethodPut, 1, strconv.FormatInt(now.Add(2*time.Minute).Unix(), 10), nonce, http.StatusUnauthorized},
+		{"valid", http.MethodPut, 1, ts, nonce, http.StatusOK},
+		{"replayed", http.MethodPut, 1, ts, nonce, http.StatusUnauthorized},
+		{"same nonce, other token", http.MethodDelete, 2, ts, nonce, http.StatusOK},
+		{"same nonce, master key", http.MethodPost, 0, ts, nonce, http.StatusOK},
+		{"replayed by master key", http.MethodPost, 0, ts, nonce, http.StatusUnauthorized},
+	}
+	// Cases run in order: later ones depend on the nonces recorded by earlier ones
+	for _, tt := range tests {
+		if got := serve(g, tt.method, tt.tokenID, tt.timestamp, tt.nonce); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMiddleware_Optional(t *testing.T) {
+	t.Parallel()
+	g, err := New(newTestStore(t), ModeOptional, testLogger())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := "optional-mode-nonce-1"
+
+	if got := serve(g, http.MethodPost, 1, "", ""); got != http.StatusOK {
+		t.Errorf("expected requests without headers to pass, got %d", got)
+	}
+	if got := serve(g, http.MethodPost, 1, ts, ""); got != http.StatusUnauthorized {
+		t.Errorf("expected partial headers to be rejected, got %d", got)
+	}
+	if got := serve(g, http.MethodPost, 1, ts, nonce); got != http.StatusOK {
+		t.Errorf("expected valid headers to pass, got %d", got)
+	}
+	if got := serve(g, http.MethodPost, 1, ts, nonce); got != http.StatusUnauthorized {
+		t.Errorf("expected replay to be rejected, got %d", got)
+	}
+}
+
+func TestMiddleware_Off(t *testing.T) {
+	t.Parallel()
+	g, err := New(nil, ModeOff, testLogger())
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if g.Enabled() {
+		t.Error("expected guard to be disabled")
+	}
+	if got := serve(g, http.MethodPost, 1, "", ""); got != http.StatusOK {
+		t.Errorf("expected request to pass, got %d", got)
+	}
+	g.Run(context.Background()) // returns at once when disabled
+}
+
+func TestMiddleware_StoreError(t *testing.T) {
+	t.Parallel()
+	var reported error
+	store := &mockstore.MockStorage{RecordNonceFunc: func(context.Context, int64, string, time.Time) error {
+		return errors.New("disk full")
+	}}
+	g, err := New(store, ModeRequired, testLogger(), WithWriteErrorHandler(func(err error) { reported = err }))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if got := serve(g, http.MethodPost, 1, strconv.FormatInt(time.Now().Unix(), 10), "store-error-nonce-1"); got != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", got)
+	}
+	if reported == nil {
+		t.Error("expected the write error to be reported")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	g, err := New(store, ModeRequired, testLogger(), WithWindow(time.Minute))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	g.now = func() time.Time { return now }
+
+	if err := store.RecordNonce(ctx, 1, "expired-nonce-0001", now.Add(-3*time.Minute)); err != nil {
+		t.Fatalf("RecordNonce failed: %v", err)
+	}
+	if err := store.RecordNonce(ctx, 1, "recent-nonce-00001", now.Add(-90*time.Second)); err != nil {
+		t.Fatalf("RecordNonce failed: %v", err)
+	}
+	g.prune(ctx)
+
+	// The expired nonce is gone; the recent one is kept for two windows
+	if err := store.RecordNonce(ctx, 1, "expired-nonce-0001", now); err != nil {
+		t.Errorf("expected expired nonce to be pruned, got %v", err)
+	}
+	if err := store.RecordNonce(ctx, 1, "recent-nonce-00001", now); !errors.Is(err, storage.ErrDuplicate) {
+		t.Errorf("expected recent nonce to be kept, got %v", err)
+	}
+}
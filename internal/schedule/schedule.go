@@ -0,0 +1,262 @@
+// Package schedule makes record changes that clients asked the proxy to make
+// at a later time, such as a DNS cutover at 2am.
+//
+// Scheduled changes are recorded in storage, so they survive restarts. The
+// runner polls for changes that are due and hands each one to an Executor,
+// which makes it upstream. A change is made at most once: it is marked as
+// running before it is made, and changes left running by a process that
+// stopped are marked as failed by the next Run rather than retried.
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// DefaultPollInterval is how often the runner looks for changes that are
+	// due, and so the most a change can run late.
+	DefaultPollInterval = 15 * time.Second
+
+	// DefaultRetention is how long finished and cancelled changes are kept.
+	DefaultRetention = 7 * 24 * time.Hour
+
+	// pruneInterval is how often finished changes are pruned.
+	pruneInterval = time.Hour
+)
+
+// Store defines the storage operations needed by the runner.
+type Store interface {
+	ListDueScheduledChanges(ctx context.Context, at time.Time) ([]*storage.ScheduledChange, error)
+	StartScheduledChange(ctx context.Context, id int64, at time.Time) error
+	FinishScheduledChange(ctx context.Context, id int64, status string, recordID int64, errMsg string, at time.Time) error
+	FailUnfinishedScheduledChanges(ctx context.Context, before time.Time, message string) (int64, error)
+	PruneScheduledChanges(ctx context.Context, before time.Time) (int64, error)
+	GetTokenByID(ctx context.Context, id int64) (*storage.Token, error)
+}
+
+// Executor makes a scheduled change upstream. It is satisfied by
+// *proxy.Handler.
+type Executor interface {
+	// RunScheduledChange makes the change and returns the ID of the record
+	// a create made.
+	RunScheduledChange(ctx context.Context, c *storage.ScheduledChange) (recordID int64, err error)
+}
+
+// PermissionLoader loads the current permissions of a token. It is
+// satisfied by *auth.Authenticator.
+type PermissionLoader interface {
+	TokenPermissions(ctx context.Context, token *storage.Token) ([]*storage.Permission, error)
+}
+
+// FreezeChecker reports the active freeze of a zone. It is satisfied by
+// *freeze.Schedule.
+type FreezeChecker interface {
+	Active(zoneID int64) *storage.ZoneFreeze
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithPollInterval sets how often the runner looks for changes that are due.
+func WithPollInterval(d time.Duration) Option {
+	return func(r *Runner) {
+		if d > 0 {
+			r.interval = d
+		}
+	}
+}
+
+// WithRetention sets how long finished and cancelled changes are kept.
+func WithRetention(d time.Duration) Option {
+	return func(r *Runner) {
+		if d > 0 {
+			r.retention = d
+		}
+	}
+}
+
+// WithFreezes fails changes to zones that are frozen when the changes are due.
+func WithFreezes(f FreezeChecker) Option {
+	return func(r *Runner) {
+		r.freezes = f
+	}
+}
+
+// Runner makes scheduled changes once they are due, one at a time.
+type Runner struct {
+	store     Store
+	exec      Executor
+	perms     PermissionLoader
+	freezes   FreezeChecker
+	logger    *slog.Logger
+	interval  time.Duration
+	retention time.Duration
+	started   time.Time
+	now       func() time.Time
+}
+
+// New creates a runner. Changes are made once Run is called, as the token
+// that scheduled them with the permissions perms loads for it then.
+func New(store Store, exec Executor, perms PermissionLoader, logger *slog.Logger, opts ...Option) *Runner {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	r := &Runner{
+		store:     store,
+		exec:      exec,
+		perms:     perms,
+		logger:    logger,
+		interval:  DefaultPollInterval,
+		retention: DefaultRetention,
+		started:   time.Now(),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run marks changes left running by a previous process as failed, then makes
+// changes as they become due and prunes finished ones until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) {
+	if n, err := r.store.FailUnfinishedScheduledChanges(ctx, r.started, "interrupted by a restart"); err != nil {
+		r.logger.Error("failed to mark interrupted scheduled changes", "error", err)
+	} else if n > 0 {
+		r.logger.Warn("marked scheduled changes interrupted by a restart as failed", "count", n)
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	lastPrune := r.now()
+
+	// Changes that came due while the proxy was down run at once
+	r.runDue(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.runDue(ctx)
+			if r.now().Sub(lastPrune) >= pruneInterval {
+				lastPrune = r.now()
+				r.prune(ctx)
+			}
+		}
+	}
+}
+
+// runDue makes every change that is due.
+func (r *Runner) runDue(ctx context.Context) {
+	due, err := r.store.ListDueScheduledChanges(ctx, r.now())
+	if err != nil {
+		r.logger.Error("failed to list due scheduled changes", "error", err)
+		return
+	}
+	for _, c := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		r.run(ctx, c)
+	}
+}
+
+// run makes one change and records its outcome.
+func (r *Runner) run(ctx context.Context, c *storage.ScheduledChange) {
+	// Another instance may have claimed the change, or it was just cancelled
+	if err := r.store.StartScheduledChange(ctx, c.ID, r.now()); err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			r.logger.Error("failed to start scheduled change", "change_id", c.ID, "error", err)
+		}
+		return
+	}
+
+	// The outcome is recorded even when ctx was cancelled during the change
+	store := context.WithoutCancel(ctx)
+	status, errMsg := storage.ScheduledChangeSucceeded, ""
+	recordID, err := r.execute(ctx, c)
+	if err != nil {
+		status, errMsg = storage.ScheduledChangeFailed, err.Error()
+	}
+	if err := r.store.FinishScheduledChange(store, c.ID, status, recordID, errMsg, r.now()); err != nil {
+		r.logger.Error("failed to record scheduled change outcome", "change_id", c.ID, "error", err)
+		return
+	}
+	r.logger.Info("scheduled change finished", "change_id", c.ID, "zone_id", c.ZoneID, "action", c.Action,
+		"status", status, "error", errMsg, "late", r.now().Sub(c.RunAt).Round(time.Second))
+}
+
+// execute makes a change unless its zone is frozen, or the token that
+// scheduled it has since been deleted, has expired or no longer has the
+// permission to make it, since the change was authorized with its
+// permissions. The change is made as that token, so checks that depend on
+// the caller, such as protected records, still apply.
+func (r *Runner) execute(ctx context.Context, c *storage.ScheduledChange) (int64, error) {
+	if r.freezes != nil {
+		if f := r.freezes.Active(c.ZoneID); f != nil {
+			return 0, fmt.Errorf("zone %d is frozen (freeze %d)", c.ZoneID, f.ID)
+		}
+	}
+	if c.TokenID != 0 {
+		token, err := r.store.GetTokenByID(ctx, c.TokenID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return 0, errors.New("the token that scheduled the change was deleted")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !token.ExpiresAt.IsZero() && !r.now().Before(token.ExpiresAt) {
+			return 0, errors.New("the token that scheduled the change has expired")
+		}
+		ctx = auth.WithAdmin(auth.WithToken(ctx, token), token.IsAdmin)
+		if !token.IsAdmin {
+			perms, err := r.perms.TokenPermissions(ctx, token)
+			if err != nil {
+				return 0, fmt.Errorf("failed to load permissions: %w", err)
+			}
+			keyInfo := &auth.KeyInfo{KeyID: token.ID, KeyName: token.Name, Permissions: perms}
+			var denial *auth.DenialError
+			if err := auth.CheckPermission(keyInfo, permissionRequest(c)); errors.As(err, &denial) {
+				return 0, fmt.Errorf("the token that scheduled the change is no longer permitted to make it: %s", denial.Message)
+			} else if err != nil {
+				return 0, err
+			}
+			ctx = auth.WithPermissions(ctx, perms)
+		}
+	}
+	return r.exec.RunScheduledChange(ctx, c)
+}
+
+// permissionRequest returns the permission a change needs: add_record for
+// the type of the record a create makes, or delete_record.
+func permissionRequest(c *storage.ScheduledChange) *auth.Request {
+	if c.Action != storage.RecordChangeCreate {
+		return &auth.Request{Action: auth.ActionDeleteRecord, ZoneID: c.ZoneID}
+	}
+	var rec struct {
+		Type int `json:"Type"`
+	}
+	_ = json.Unmarshal(c.Request, &rec) //nolint:errcheck // validated when the change was scheduled
+	return &auth.Request{Action: auth.ActionAddRecord, ZoneID: c.ZoneID, RecordType: auth.MapRecordTypeToString(rec.Type)}
+}
+
+// prune deletes changes that finished more than the retention period ago.
+func (r *Runner) prune(ctx context.Context) {
+	n, err := r.store.PruneScheduledChanges(ctx, r.now().Add(-r.retention))
+	if err != nil {
+		r.logger.Error("failed to prune scheduled changes", "error", err)
+		return
+	}
+	if n > 0 {
+		r.logger.Debug("pruned scheduled changes", "count", n)
+	}
+}
@@ -0,0 +1,288 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu      sync.Mutex
+	changes map[int64]*storage.ScheduledChange
+	tokens  map[int64]*storage.Token
+	perms   map[int64][]*storage.Permission
+	nextID  int64
+}
+
+func newMemStore() *memStore {
+	return &memStore{changes: make(map[int64]*storage.ScheduledChange), tokens: make(map[int64]*storage.Token),
+		perms: make(map[int64][]*storage.Permission)}
+}
+
+// add records a pending change and returns its ID.
+func (m *memStore) add(c storage.ScheduledChange) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	c.ID = m.nextID
+	if c.Status == "" {
+		c.Status = storage.ScheduledChangePending
+	}
+	m.changes[c.ID] = &c
+	return c.ID
+}
+
+// get returns a copy of a change.
+func (m *memStore) get(id int64) storage.ScheduledChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *m.changes[id]
+}
+
+func (m *memStore) ListDueScheduledChanges(_ context.Context, at time.Time) ([]*storage.ScheduledChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []*storage.ScheduledChange
+	for _, c := range m.changes {
+		if c.Status == storage.ScheduledChangePending && !c.RunAt.After(at) {
+			change := *c
+			due = append(due, &change)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	return due, nil
+}
+
+func (m *memStore) StartScheduledChange(_ context.Context, id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.changes[id]
+	if !ok || c.Status != storage.ScheduledChangePending {
+		return storage.ErrNotFound
+	}
+	c.Status, c.StartedAt = storage.ScheduledChangeRunning, at
+	return nil
+}
+
+func (m *memStore) FinishScheduledChange(_ context.Context, id int64, status string, recordID int64, errMsg string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.changes[id]
+	if !ok || c.Status != storage.ScheduledChangeRunning {
+		return storage.ErrNotFound
+	}
+	c.Status, c.Error, c.FinishedAt = status, errMsg, at
+	if recordID > 0 {
+		c.RecordID = recordID
+	}
+	return nil
+}
+
+func (m *memStore) FailUnfinishedScheduledChanges(_ context.Context, before time.Time, message string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for _, c := range m.changes {
+		if c.Status == storage.ScheduledChangeRunning && c.StartedAt.Before(before) {
+			c.Status, c.Error = storage.ScheduledChangeFailed, message
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memStore) PruneScheduledChanges(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *memStore) GetTokenByID(_ context.Context, id int64) (*storage.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.tokens[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return t, nil
+}
+
+// setPermissions replaces the permissions of a token.
+func (m *memStore) setPermissions(tokenID int64, perms ...*storage.Permission) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.perms[tokenID] = perms
+}
+
+func (m *memStore) TokenPermissions(_ context.Context, token *storage.Token) ([]*storage.Permission, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.perms[token.ID], nil
+}
+
+// fakeExecutor records the changes it makes; deletes fail.
+type fakeExecutor struct {
+	mu  sync.Mutex
+	ran []int64
+}
+
+func (e *fakeExecutor) RunScheduledChange(_ context.Context, c *storage.ScheduledChange) (int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ran = append(e.ran, c.ID)
+	if c.Action == storage.RecordChangeDelete {
+		return 0, errors.New("record not found")
+	}
+	return 100 + c.ID, nil
+}
+
+func (e *fakeExecutor) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.ran)
+}
+
+// frozenZones freezes the zones it holds.
+type frozenZones map[int64]bool
+
+func (f frozenZones) Active(zoneID int64) *storage.ZoneFreeze {
+	if f[zoneID] {
+		return &storage.ZoneFreeze{ID: 1, ZoneID: zoneID}
+	}
+	return nil
+}
+
+func TestRunner_RunDue(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	exec := &fakeExecutor{}
+	r := New(store, exec, store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithFreezes(frozenZones{5: true}))
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	store.tokens[1] = &storage.Token{ID: 1}
+	store.setPermissions(1, &storage.Permission{AllowedActions: []string{"add_record", "delete_record"}, RecordTypes: []string{"A"}})
+	store.tokens[2] = &storage.Token{ID: 2, ExpiresAt: now.Add(-time.Minute)}
+	create := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, TokenID: 1, RunAt: now.Add(-time.Minute)})
+	master := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, RunAt: now})
+	del := store.add(storage.ScheduledChange{Action: storage.RecordChangeDelete, RecordID: 9, TokenID: 1, RunAt: now})
+	deleted := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, TokenID: 3, RunAt: now})
+	expired := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, TokenID: 2, RunAt: now})
+	frozen := store.add(storage.ScheduledChange{ZoneID: 5, Action: storage.RecordChangeCreate, RunAt: now})
+	later := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, RunAt: now.Add(time.Second)})
+
+	r.runDue(context.Background())
+
+	for id, want := range map[int64]struct {
+		status   string
+		recordID int64
+	}{
+		create:  {storage.ScheduledChangeSucceeded, 100 + create},
+		master:  {storage.ScheduledChangeSucceeded, 100 + master},
+		del:     {storage.ScheduledChangeFailed, 9},
+		deleted: {storage.ScheduledChangeFailed, 0},
+		expired: {storage.ScheduledChangeFailed, 0},
+		frozen:  {storage.ScheduledChangeFailed, 0},
+		later:   {storage.ScheduledChangePending, 0},
+	} {
+		got := store.get(id)
+		if got.Status != want.status || got.RecordID != want.recordID {
+			t.Errorf("change %d = %s with record %d, want %s with record %d", id, got.Status, got.RecordID, want.status, want.recordID)
+		}
+		if (got.Status == storage.ScheduledChangeFailed) != (got.Error != "") {
+			t.Errorf("change %d: unexpected error %q", id, got.Error)
+		}
+	}
+	// Changes to frozen zones and of deleted and expired tokens are not made
+	if exec.count() != 3 {
+		t.Errorf("expected 3 changes to be made, got %d", exec.count())
+	}
+
+	// Finished changes are not made again
+	r.runDue(context.Background())
+	if exec.count() != 3 {
+		t.Errorf("expected no more changes, got %d", exec.count())
+	}
+}
+
+func TestRunner_RevokedPermissions(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	exec := &fakeExecutor{}
+	r := New(store, exec, store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	store.tokens[1] = &storage.Token{ID: 1}
+	store.tokens[2] = &storage.Token{ID: 2, IsAdmin: true}
+	store.setPermissions(1, &storage.Permission{ZoneID: 7, AllowedActions: []string{"add_record", "delete_record"},
+		RecordTypes: []string{"A", "TXT"}})
+	a := store.add(storage.ScheduledChange{ZoneID: 7, Action: storage.RecordChangeCreate, TokenID: 1,
+		Request: []byte(`{"Type":0,"Name":"www","Value":"192.0.2.1"}`), RunAt: now})
+	txt := store.add(storage.ScheduledChange{ZoneID: 7, Action: storage.RecordChangeCreate, TokenID: 1,
+		Request: []byte(`{"Type":3,"Name":"www","Value":"v=spf1 -all"}`), RunAt: now})
+	del := store.add(storage.ScheduledChange{ZoneID: 7, Action: storage.RecordChangeDelete, RecordID: 9, TokenID: 1, RunAt: now})
+	admin := store.add(storage.ScheduledChange{ZoneID: 8, Action: storage.RecordChangeCreate, TokenID: 2,
+		Request: []byte(`{"Type":3,"Name":"www","Value":"v=spf1 -all"}`), RunAt: now})
+
+	// The token loses TXT records and delete_record after scheduling
+	store.setPermissions(1, &storage.Permission{ID: 5, ZoneID: 7, AllowedActions: []string{"add_record"}, RecordTypes: []string{"A"}})
+
+	r.runDue(context.Background())
+
+	for id, wantStatus := range map[int64]string{
+		a:     storage.ScheduledChangeSucceeded,
+		txt:   storage.ScheduledChangeFailed,
+		del:   storage.ScheduledChangeFailed,
+		admin: storage.ScheduledChangeSucceeded,
+	} {
+		if got := store.get(id); got.Status != wantStatus {
+			t.Errorf("change %d = %s (%q), want %s", id, got.Status, got.Error, wantStatus)
+		}
+	}
+	if got := store.get(txt).Error; got != "the token that scheduled the change is no longer permitted to make it: Permission 5 does not allow TXT records." {
+		t.Errorf("unexpected error for the TXT change: %q", got)
+	}
+	if exec.count() != 2 {
+		t.Errorf("expected 2 changes to be made, got %d", exec.count())
+	}
+}
+
+func TestRunner_FailsInterruptedChanges(t *testing.T) {
+	t.Parallel()
+	store := newMemStore()
+	exec := &fakeExecutor{}
+	interrupted := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, Status: storage.ScheduledChangeRunning,
+		RunAt: time.Now().Add(-time.Hour), StartedAt: time.Now().Add(-time.Hour)})
+	due := store.add(storage.ScheduledChange{Action: storage.RecordChangeCreate, RunAt: time.Now().Add(-time.Hour)})
+
+	r := New(store, exec, store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithPollInterval(time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for store.get(due).Status != storage.ScheduledChangeSucceeded && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := store.get(due); got.Status != storage.ScheduledChangeSucceeded {
+		t.Errorf("expected the overdue change to run, got %+v", got)
+	}
+	if got := store.get(interrupted); got.Status != storage.ScheduledChangeFailed || got.Error == "" {
+		t.Errorf("expected the interrupted change to have failed, got %+v", got)
+	}
+	if exec.count() != 1 {
+		t.Errorf("expected only the overdue change to be made, got %d", exec.count())
+	}
+}
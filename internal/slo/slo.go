@@ -0,0 +1,325 @@
+// Package slo tracks service level indicators for proxied requests and
+// evaluates error budget burn rates against configured objectives.
+//
+// Two indicators are tracked:
+//   - availability: every proxied request; 5xx responses are bad.
+//   - record_write_latency: record create, update and delete requests that did
+//     not fail with a 5xx; requests slower than the latency threshold are bad.
+//
+// Events are kept in per-minute buckets covering the longest evaluation window,
+// so burn rates can be computed in-process without a Prometheus server.
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/metrics"
+)
+
+// Indicator names, used in metrics labels and /status.
+const (
+	SLIAvailability       = "availability"
+	SLIRecordWriteLatency = "record_write_latency"
+)
+
+const (
+	// evalInterval is how often burn rate gauges are refreshed.
+	evalInterval = 30 * time.Second
+
+	// bucketCount covers the longest window with one bucket per minute.
+	bucketCount = 6 * 60
+
+	// Multiwindow burn rate thresholds for a 30 day SLO period: a fast burn
+	// spends 2% of the budget in an hour, a slow burn 5% in six hours.
+	fastBurnThreshold = 14.4
+	slowBurnThreshold = 6
+)
+
+// Evaluation windows, shortest first.
+var windows = [...]struct {
+	name    string
+	minutes int64
+}{
+	{"5m", 5},
+	{"30m", 30},
+	{"1h", 60},
+	{"6h", 360},
+}
+
+// recordWritePath matches record create, update and delete paths.
+var recordWritePath = regexp.MustCompile(`^/dnszone/\d+/records(/\d+)?$`)
+
+// Objectives are the configured service level objectives.
+type Objectives struct {
+	// Availability is the target ratio of non-5xx proxied requests, e.g. 0.999.
+	Availability float64
+	// Latency is the target ratio of record writes faster than LatencyThreshold.
+	Latency float64
+	// LatencyThreshold is the duration above which a record write is bad.
+	LatencyThreshold time.Duration
+}
+
+// sli indexes indicators within a bucket.
+type sli int
+
+const (
+	availability sli = iota
+	recordWriteLatency
+	sliCount
+)
+
+var sliNames = [sliCount]string{SLIAvailability, SLIRecordWriteLatency}
+
+// bucket holds event counts for one minute.
+type bucket struct {
+	minute int64
+	total  [sliCount]int64
+	bad    [sliCount]int64
+}
+
+// Tracker records SLI events and evaluates burn rates.
+type Tracker struct {
+	objectives Objectives
+	instanceID string
+	upstream   func() []bunny.EndpointHealth
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets [bucketCount]bucket
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithInstanceID reports the instance ID in /status, so responses from
+// different replicas behind a load balancer can be told apart.
+func WithInstanceID(id string) Option {
+	return func(t *Tracker) {
+		t.instanceID = id
+	}
+}
+
+// WithUpstreamHealth reports the health of each bunny.net endpoint class in
+// /status, as returned by fn.
+func WithUpstreamHealth(fn func() []bunny.EndpointHealth) Option {
+	return func(t *Tracker) {
+		t.upstream = fn
+	}
+}
+
+// New creates a tracker for the given objectives.
+func New(objectives Objectives, opts ...Option) *Tracker {
+	metrics.RecordSLOTarget(SLIAvailability, objectives.Availability)
+	metrics.RecordSLOTarget(SLIRecordWriteLatency, objectives.Latency)
+	t := &Tracker{
+		objectives: objectives,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Middleware records SLI events for every request passing through it.
+// It should wrap the proxy router only, so health checks and admin
+// requests do not count towards the objectives.
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := t.now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		t.observe(r, rec.status, t.now().Sub(start))
+	})
+}
+
+// observe records the events for one completed request.
+func (t *Tracker) observe(r *http.Request, status int, elapsed time.Duration) {
+	failed := status >= http.StatusInternalServerError
+	t.record(availability, !failed)
+
+	if !failed && isRecordWrite(r) {
+		t.record(recordWriteLatency, elapsed <= t.objectives.LatencyThreshold)
+	}
+}
+
+// isRecordWrite reports whether r creates, updates or deletes a record.
+func isRecordWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodDelete:
+		return recordWritePath.MatchString(r.URL.Path)
+	}
+	return false
+}
+
+// record counts one event in the current minute's bucket.
+func (t *Tracker) record(s sli, good bool) {
+	metrics.RecordSLIEvent(sliNames[s], good)
+
+	minute := t.now().Unix() / 60
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := &t.buckets[minute%bucketCount]
+	if b.minute != minute {
+		*b = bucket{minute: minute}
+	}
+	b.total[s]++
+	if !good {
+		b.bad[s]++
+	}
+}
+
+// Status is the response body for GET /status.
+type Status struct {
+	// Status is "ok", or "burning" when any objective is alerting.
+	Status     string            `json:"status"`
+	InstanceID string            `json:"instance_id,omitempty"`
+	Objectives []ObjectiveStatus `json:"objectives"`
+	// Upstream reports bunny.net health per endpoint class. It does not
+	// affect Status.
+	Upstream []bunny.EndpointHealth `json:"upstream,omitempty"`
+}
+
+// ObjectiveStatus reports burn rates for one indicator.
+type ObjectiveStatus struct {
+	SLI     string         `json:"sli"`
+	Target  float64        `json:"target"`
+	Windows []WindowStatus `json:"windows"`
+	// FastBurn and SlowBurn follow the multiwindow alerting rules: both the
+	// long and the short window must exceed the threshold.
+	FastBurn bool `json:"fast_burn"`
+	SlowBurn bool `json:"slow_burn"`
+}
+
+// WindowStatus reports event counts and the burn rate over one window.
+type WindowStatus struct {
+	Window   string  `json:"window"`
+	Total    int64   `json:"total"`
+	Bad      int64   `json:"bad"`
+	BurnRate float64 `json:"burn_rate"`
+}
+
+// Evaluate computes burn rates for every indicator and window.
+func (t *Tracker) Evaluate() Status {
+	minute := t.now().Unix() / 60
+
+	var total, bad [len(windows)][sliCount]int64
+	t.mu.Lock()
+	for i := range t.buckets {
+		b := &t.buckets[i]
+		age := minute - b.minute
+		for w, win := range windows {
+			if age < 0 || age >= win.minutes {
+				continue
+			}
+			for s := range sliCount {
+				total[w][s] += b.total[s]
+				bad[w][s] += b.bad[s]
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	status := Status{Status: "ok", InstanceID: t.instanceID}
+	targets := [sliCount]float64{t.objectives.Availability, t.objectives.Latency}
+	for s := range sliCount {
+		obj := ObjectiveStatus{SLI: sliNames[s], Target: targets[s]}
+		rates := make(map[string]float64, len(windows))
+		for w, win := range windows {
+			rate := burnRate(total[w][s], bad[w][s], targets[s])
+			rates[win.name] = rate
+			obj.Windows = append(obj.Windows, WindowStatus{
+				Window:   win.name,
+				Total:    total[w][s],
+				Bad:      bad[w][s],
+				BurnRate: rate,
+			})
+		}
+		obj.FastBurn = rates["1h"] >= fastBurnThreshold && rates["5m"] >= fastBurnThreshold
+		obj.SlowBurn = rates["6h"] >= slowBurnThreshold && rates["30m"] >= slowBurnThreshold
+		if obj.FastBurn || obj.SlowBurn {
+			status.Status = "burning"
+		}
+		status.Objectives = append(status.Objectives, obj)
+	}
+	if t.upstream != nil {
+		status.Upstream = t.upstream()
+	}
+	return status
+}
+
+// burnRate is the observed error ratio divided by the error budget.
+func burnRate(total, bad int64, target float64) float64 {
+	if total == 0 || target >= 1 {
+		return 0
+	}
+	return (float64(bad) / float64(total)) / (1 - target)
+}
+
+// Run refreshes the burn rate gauges until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(evalInterval)
+	defer ticker.Stop()
+
+	for {
+		t.publish()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish exports the current burn rates as metrics.
+func (t *Tracker) publish() {
+	for _, obj := range t.Evaluate().Objectives {
+		for _, w := range obj.Windows {
+			metrics.RecordSLOBurnRate(obj.SLI, w.Window, w.BurnRate)
+		}
+	}
+}
+
+// HandleStatus reports the objectives and current burn rates.
+// GET /status
+// Always returns 200 so a burning budget does not fail liveness checks.
+func (t *Tracker) HandleStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	//nolint:errcheck // Response write errors are unrecoverable
+	json.NewEncoder(w).Encode(t.Evaluate())
+}
+
+// statusRecorder captures the response status code.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader captures the first status code written.
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write marks the header as written with the default status.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,208 @@
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+)
+
+// fakeClock is a manually advanced clock for the tracker.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+func newTestTracker() (*Tracker, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+	tr := New(Objectives{Availability: 0.99, Latency: 0.9, LatencyThreshold: 300 * time.Millisecond})
+	tr.now = clock.now
+	return tr, clock
+}
+
+func objective(t *testing.T, s Status, name string) ObjectiveStatus {
+	t.Helper()
+	for _, o := range s.Objectives {
+		if o.SLI == name {
+			return o
+		}
+	}
+	t.Fatalf("objective %s not found in %+v", name, s)
+	return ObjectiveStatus{}
+}
+
+func window(t *testing.T, o ObjectiveStatus, name string) WindowStatus {
+	t.Helper()
+	for _, w := range o.Windows {
+		if w.Window == name {
+			return w
+		}
+	}
+	t.Fatalf("window %s not found in %+v", name, o)
+	return WindowStatus{}
+}
+
+func TestMiddleware_ClassifiesRequests(t *testing.T) {
+	t.Parallel()
+	tr, clock := newTestTracker()
+
+	handler := tr.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("mode") {
+		case "slow":
+			clock.advance(time.Second)
+		case "fail":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/dnszone", nil),                        // availability only
+		httptest.NewRequest(http.MethodPost, "/dnszone/1/records", nil),             // fast write
+		httptest.NewRequest(http.MethodPost, "/dnszone/1/records/2?mode=slow", nil), // slow write
+		httptest.NewRequest(http.MethodDelete, "/dnszone/1/records/2?mode=fail", nil),
+		httptest.NewRequest(http.MethodPatch, "/dnszone/1/records/2/meta?mode=slow", nil), // not a record write
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	s := tr.Evaluate()
+	avail := window(t, objective(t, s, SLIAvailability), "5m")
+	if avail.Total != 5 || avail.Bad != 1 {
+		t.Errorf("availability: expected 5 total / 1 bad, got %+v", avail)
+	}
+	latency := window(t, objective(t, s, SLIRecordWriteLatency), "5m")
+	if latency.Total != 2 || latency.Bad != 1 {
+		t.Errorf("latency: expected 2 total / 1 bad (5xx excluded), got %+v", latency)
+	}
+}
+
+func TestEvaluate_BurnRatesAndWindows(t *testing.T) {
+	t.Parallel()
+	tr, clock := newTestTracker()
+
+	// An old failure only visible in the longer windows
+	tr.record(availability, false)
+	clock.advance(45 * time.Minute)
+	for range 99 {
+		tr.record(availability, true)
+	}
+	tr.record(availability, false)
+
+	obj := objective(t, tr.Evaluate(), SLIAvailability)
+	if obj.Target != 0.99 {
+		t.Errorf("expected target 0.99, got %v", obj.Target)
+	}
+	short := window(t, obj, "5m")
+	if short.Total != 100 || short.Bad != 1 || math.Abs(short.BurnRate-1) > 1e-9 {
+		t.Errorf("5m: expected 1%% errors and burn rate 1, got %+v", short)
+	}
+	long := window(t, obj, "1h")
+	if long.Total != 101 || long.Bad != 2 {
+		t.Errorf("1h: expected old failure to be included, got %+v", long)
+	}
+
+	// Everything ages out of the 6h window
+	clock.advance(7 * time.Hour)
+	if w := window(t, objective(t, tr.Evaluate(), SLIAvailability), "6h"); w.Total != 0 || w.BurnRate != 0 {
+		t.Errorf("expected empty 6h window after ageing out, got %+v", w)
+	}
+}
+
+func TestEvaluate_FastBurn(t *testing.T) {
+	t.Parallel()
+	tr, _ := newTestTracker()
+
+	for range 50 {
+		tr.record(availability, true)
+		tr.record(availability, false)
+	}
+
+	s := tr.Evaluate()
+	if s.Status != "burning" {
+		t.Errorf("expected burning status, got %q", s.Status)
+	}
+	obj := objective(t, s, SLIAvailability)
+	if !obj.FastBurn || !obj.SlowBurn {
+		t.Errorf("expected fast and slow burn at 50x, got %+v", obj)
+	}
+	if objective(t, s, SLIRecordWriteLatency).FastBurn {
+		t.Error("latency objective should not be burning without events")
+	}
+}
+
+func TestHandleStatus(t *testing.T) {
+	t.Parallel()
+	tr, _ := newTestTracker()
+	WithInstanceID("proxy-0")(tr)
+	tr.record(recordWriteLatency, true)
+
+	w := httptest.NewRecorder()
+	tr.HandleStatus(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var s Status
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if s.Status != "ok" || s.InstanceID != "proxy-0" || len(s.Objectives) != 2 || len(s.Objectives[1].Windows) != len(windows) {
+		t.Errorf("unexpected status: %+v", s)
+	}
+}
+
+func TestHandleStatus_Upstream(t *testing.T) {
+	t.Parallel()
+	tr, _ := newTestTracker()
+	WithUpstreamHealth(func() []bunny.EndpointHealth {
+		return []bunny.EndpointHealth{{Class: bunny.EndpointCertificates, Status: bunny.HealthUnavailable}}
+	})(tr)
+
+	w := httptest.NewRecorder()
+	tr.HandleStatus(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var s Status
+	if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if s.Status != "ok" || len(s.Upstream) != 1 || s.Upstream[0].Status != bunny.HealthUnavailable {
+		t.Errorf("unexpected status: %+v", s)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+	tr, _ := newTestTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		tr.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
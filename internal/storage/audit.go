@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultAuditListLimit caps ListAuditEntries when the filter sets no limit.
+const defaultAuditListLimit = 100
+
+// AppendAuditEntry records an audit entry.
+// CreatedAt defaults to now and is stored with second precision.
+func (s *SQLiteStorage) AppendAuditEntry(ctx context.Context, e *AuditEntry) (*AuditEntry, error) {
+	return appendAuditEntry(ctx, s.db, e)
+}
+
+// AppendAuditEntryWithEvents records an audit entry and enqueues the outbox
+// events of the request it describes in one transaction, so either both are
+// stored or neither is.
+func (s *SQLiteStorage) AppendAuditEntryWithEvents(ctx context.Context, e *AuditEntry, events []*OutboxEvent) (*AuditEntry, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin audit transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := appendAuditEntry(ctx, tx, e); err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if _, err := enqueueOutboxEvent(ctx, tx, event); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit audit entry: %w", err)
+	}
+	return e, nil
+}
+
+// appendAuditEntry inserts an audit entry using the given executor.
+func appendAuditEntry(ctx context.Context, exec execer, e *AuditEntry) (*AuditEntry, error) {
+	if e.Method == "" || e.Path == "" {
+		return nil, fmt.Errorf("audit entry requires method and path")
+	}
+
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := exec.ExecContext(ctx,
+		`INSERT INTO audit_log (token_id, token_name, action, zone_id, record_type, method, path, status, request_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.TokenID, e.TokenName, e.Action, e.ZoneID, e.RecordType, e.Method, e.Path, e.Status, e.RequestID, createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	e.ID = id
+	e.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	return e, nil
+}
+
+// ListAuditEntries returns entries matching the filter, newest first.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListAuditEntries(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditListLimit
+	}
+
+	var conds []string
+	var args []any
+	where := func(cond string, arg any) {
+		conds = append(conds, cond)
+		args = append(args, arg)
+	}
+	if filter.TokenID != 0 {
+		where("token_id = ?", filter.TokenID)
+	}
+	if filter.ZoneID != 0 {
+		where("zone_id = ?", filter.ZoneID)
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.RecordType != "" {
+		where("record_type = ?", filter.RecordType)
+	}
+	if filter.MinStatus != 0 {
+		where("status >= ?", filter.MinStatus)
+	}
+	if filter.MaxStatus != 0 {
+		where("status <= ?", filter.MaxStatus)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= ?", filter.Since.Unix())
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < ?", filter.Until.Unix())
+	}
+	if filter.BeforeID != 0 {
+		where("id < ?", filter.BeforeID)
+	}
+
+	query := `SELECT id, token_id, token_name, action, zone_id, record_type, method, path, status, request_id, created_at
+		FROM audit_log`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	entries := make([]*AuditEntry, 0)
+	for rows.Next() {
+		var e AuditEntry
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &e.TokenID, &e.TokenName, &e.Action, &e.ZoneID, &e.RecordType,
+			&e.Method, &e.Path, &e.Status, &e.RequestID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit row: %w", err)
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// PruneAuditEntries deletes entries created before the cutoff.
+func (s *SQLiteStorage) PruneAuditEntries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM audit_log WHERE created_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune audit log: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// ListHotZones returns the IDs of the zones with the most audit entries since
+// the cutoff, busiest first, up to limit.
+func (s *SQLiteStorage) ListHotZones(ctx context.Context, since time.Time, limit int) ([]int64, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		`SELECT zone_id FROM audit_log WHERE zone_id != 0 AND created_at >= ?
+			GROUP BY zone_id ORDER BY COUNT(*) DESC, zone_id ASC LIMIT ?`,
+		since.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hot zones: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	zoneIDs := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan hot zone row: %w", err)
+		}
+		zoneIDs = append(zoneIDs, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating hot zones: %w", err)
+	}
+
+	return zoneIDs, nil
+}
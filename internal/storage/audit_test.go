@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+	now := time.Now()
+
+	entries := []*AuditEntry{
+		{TokenID: 1, TokenName: "acme", Action: "add_record", ZoneID: 5, RecordType: "TXT", Method: "POST", Path: "/dnszone/5/records", Status: 201, CreatedAt: now.Add(-48 * time.Hour)},
+		{TokenID: 2, TokenName: "other", Action: "list_zones", Method: "GET", Path: "/dnszone", Status: 200},
+		{TokenID: 1, TokenName: "acme", Action: "delete_record", ZoneID: 5, Method: "DELETE", Path: "/dnszone/5/records/9", Status: 403, RequestID: "req-1"},
+	}
+	for _, e := range entries {
+		if _, err := s.AppendAuditEntry(ctx, e); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+	if _, err := s.AppendAuditEntry(ctx, &AuditEntry{TokenID: 1}); err == nil {
+		t.Error("expected error for entry without method and path")
+	}
+
+	got, err := s.ListAuditEntries(ctx, AuditFilter{TokenID: 1})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Action != "delete_record" || got[0].Status != 403 || got[0].RequestID != "req-1" || got[1].RecordType != "TXT" {
+		t.Fatalf("expected token 1 entries newest first, got %+v", got)
+	}
+
+	if got, _ := s.ListAuditEntries(ctx, AuditFilter{Limit: 1}); len(got) != 1 || got[0].TokenID != 1 {
+		t.Errorf("expected only the newest entry, got %+v", got)
+	}
+	if got, _ := s.ListAuditEntries(ctx, AuditFilter{}); len(got) != 3 {
+		t.Errorf("expected all entries, got %d", len(got))
+	}
+
+	for _, zoneID := range []int64{7, 7, 5} {
+		if _, err := s.AppendAuditEntry(ctx, &AuditEntry{ZoneID: zoneID, Method: "GET", Path: "/dnszone"}); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+	hot, err := s.ListHotZones(ctx, now.Add(-time.Hour), 10)
+	if err != nil {
+		t.Fatalf("ListHotZones failed: %v", err)
+	}
+	// Zone 5 has two recent entries and one older than the cutoff; requests without a zone are skipped
+	if len(hot) != 2 || hot[0] != 5 || hot[1] != 7 {
+		t.Errorf("expected zones 5 then 7, got %v", hot)
+	}
+	if hot, _ := s.ListHotZones(ctx, now.Add(-time.Hour), 1); len(hot) != 1 {
+		t.Errorf("expected limit to apply, got %v", hot)
+	}
+
+	pruned, err := s.PruneAuditEntries(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("PruneAuditEntries failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("expected 1 pruned entry, got %d", pruned)
+	}
+}
+
+func TestListAuditEntriesFilter(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+	base := time.Unix(1_700_000_000, 0)
+
+	entries := []*AuditEntry{
+		{TokenID: 1, Action: "add_record", ZoneID: 5, RecordType: "TXT", Method: "POST", Path: "/dnszone/5/records", Status: 201, CreatedAt: base},
+		{TokenID: 1, Action: "add_record", ZoneID: 6, RecordType: "A", Method: "POST", Path: "/dnszone/6/records", Status: 403, CreatedAt: base.Add(time.Minute)},
+		{TokenID: 2, Action: "delete_record", ZoneID: 5, Method: "DELETE", Path: "/dnszone/5/records/9", Status: 204, CreatedAt: base.Add(2 * time.Minute)},
+		{TokenID: 2, Action: "admin", Method: "POST", Path: "/admin/api/tokens", Status: 500, CreatedAt: base.Add(3 * time.Minute)},
+	}
+	for _, e := range entries {
+		if _, err := s.AppendAuditEntry(ctx, e); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		want   []int64
+	}{
+		{"zone", AuditFilter{ZoneID: 5}, []int64{3, 1}},
+		{"action", AuditFilter{Action: "add_record"}, []int64{2, 1}},
+		{"record type", AuditFilter{RecordType: "TXT"}, []int64{1}},
+		{"failures", AuditFilter{MinStatus: 400}, []int64{4, 2}},
+		{"successes", AuditFilter{MaxStatus: 399}, []int64{3, 1}},
+		{"time range", AuditFilter{Since: base.Add(time.Minute), Until: base.Add(3 * time.Minute)}, []int64{3, 2}},
+		{"combined", AuditFilter{TokenID: 1, ZoneID: 6}, []int64{2}},
+		{"before id", AuditFilter{BeforeID: 3, Limit: 1}, []int64{2}},
+	}
+	for _, tt := range tests {
+		got, err := s.ListAuditEntries(ctx, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: ListAuditEntries failed: %v", tt.name, err)
+		}
+		ids := make([]int64, 0, len(got))
+		for _, e := range got {
+			ids = append(ids, e.ID)
+		}
+		if !slices.Equal(ids, tt.want) {
+			t.Errorf("%s: expected entries %v, got %v", tt.name, tt.want, ids)
+		}
+	}
+}
+
+func TestAppendAuditEntryWithEvents(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	entry := &AuditEntry{TokenID: 1, Method: "POST", Path: "/dnszone/5/records", Status: 201}
+	events := []*OutboxEvent{{EventID: "e1", Event: "record.created"}, {EventID: "e2", Event: "record.created"}}
+	if _, err := s.AppendAuditEntryWithEvents(ctx, entry, events); err != nil {
+		t.Fatalf("AppendAuditEntryWithEvents failed: %v", err)
+	}
+	if entry.ID == 0 || events[0].ID == 0 || events[1].ID == 0 {
+		t.Errorf("expected IDs to be set, got entry %d and events %d, %d", entry.ID, events[0].ID, events[1].ID)
+	}
+
+	// The second event is a duplicate, so neither the entry nor the first
+	// event is stored
+	_, err = s.AppendAuditEntryWithEvents(ctx, &AuditEntry{TokenID: 1, Method: "DELETE", Path: "/dnszone/5/records/9"},
+		[]*OutboxEvent{{EventID: "e3", Event: "record.deleted"}, {EventID: "e1", Event: "record.deleted"}})
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got %v", err)
+	}
+
+	entries, err := s.ListAuditEntries(ctx, AuditFilter{})
+	if err != nil {
+		t.Fatalf("ListAuditEntries failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Method != "POST" {
+		t.Errorf("expected only the first entry, got %+v", entries)
+	}
+	due, err := s.ListDueOutboxEvents(ctx, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutboxEvents failed: %v", err)
+	}
+	var ids []string
+	for _, e := range due {
+		ids = append(ids, e.EventID)
+	}
+	if !slices.Equal(ids, []string{"e1", "e2"}) {
+		t.Errorf("expected only the first request's events, got %v", ids)
+	}
+}
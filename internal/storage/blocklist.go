@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddBlocklistEntry adds a blocked network.
+// CreatedAt defaults to now; times are stored with second precision.
+// Returns ErrDuplicate if the network is already blocked.
+func (s *SQLiteStorage) AddBlocklistEntry(ctx context.Context, e *BlocklistEntry) (*BlocklistEntry, error) {
+	if e.CIDR == "" {
+		return nil, fmt.Errorf("blocklist entry requires a CIDR")
+	}
+
+	createdAt := e.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	var expiresAt int64
+	if !e.ExpiresAt.IsZero() {
+		expiresAt = e.ExpiresAt.Unix()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO blocklist (cidr, reason, created_by, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		e.CIDR, e.Reason, e.CreatedBy, createdAt.Unix(), expiresAt)
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to add blocklist entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	entry := *e
+	entry.ID = id
+	entry.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	if expiresAt != 0 {
+		entry.ExpiresAt = time.Unix(expiresAt, 0)
+	}
+	return &entry, nil
+}
+
+// ListBlocklistEntries returns all entries, including expired ones not yet pruned, in creation order.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListBlocklistEntries(ctx context.Context) ([]*BlocklistEntry, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT id, cidr, reason, created_by, created_at, expires_at FROM blocklist ORDER BY id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocklist: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	entries := make([]*BlocklistEntry, 0)
+	for rows.Next() {
+		var e BlocklistEntry
+		var createdAt, expiresAt int64
+		if err := rows.Scan(&e.ID, &e.CIDR, &e.Reason, &e.CreatedBy, &createdAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blocklist row: %w", err)
+		}
+		e.CreatedAt = time.Unix(createdAt, 0)
+		e.ExpiresAt = unixOrZero(expiresAt)
+		entries = append(entries, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blocklist: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteBlocklistEntry removes an entry by ID.
+// Returns ErrNotFound if the entry doesn't exist.
+func (s *SQLiteStorage) DeleteBlocklistEntry(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM blocklist WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete blocklist entry: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PruneBlocklistEntries deletes temporary entries that expired before the cutoff
+// and returns how many were removed. Permanent entries are never pruned.
+func (s *SQLiteStorage) PruneBlocklistEntries(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM blocklist WHERE expires_at != 0 AND expires_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune blocklist: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBlocklist(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := s.AddBlocklistEntry(ctx, &BlocklistEntry{}); err == nil {
+		t.Error("expected error for entry without CIDR")
+	}
+
+	permanent, err := s.AddBlocklistEntry(ctx, &BlocklistEntry{CIDR: "203.0.113.0/24", Reason: "scanner", CreatedBy: "admin"})
+	if err != nil {
+		t.Fatalf("AddBlocklistEntry failed: %v", err)
+	}
+	if permanent.ID == 0 || permanent.CreatedAt.IsZero() || !permanent.ExpiresAt.IsZero() {
+		t.Errorf("unexpected permanent entry: %+v", permanent)
+	}
+	if _, err := s.AddBlocklistEntry(ctx, &BlocklistEntry{CIDR: "203.0.113.0/24"}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate for an already blocked network, got %v", err)
+	}
+
+	expired, err := s.AddBlocklistEntry(ctx, &BlocklistEntry{CIDR: "198.51.100.7/32", ExpiresAt: now.Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("AddBlocklistEntry failed: %v", err)
+	}
+	if _, err := s.AddBlocklistEntry(ctx, &BlocklistEntry{CIDR: "2001:db8::/32", ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("AddBlocklistEntry failed: %v", err)
+	}
+
+	list, err := s.ListBlocklistEntries(ctx)
+	if err != nil {
+		t.Fatalf("ListBlocklistEntries failed: %v", err)
+	}
+	if len(list) != 3 || list[0].Reason != "scanner" || list[1].ExpiresAt.Unix() != expired.ExpiresAt.Unix() {
+		t.Fatalf("unexpected entries: %+v", list)
+	}
+
+	n, err := s.PruneBlocklistEntries(ctx, now)
+	if err != nil || n != 1 {
+		t.Fatalf("expected one expired entry pruned, got %d, %v", n, err)
+	}
+
+	if err := s.DeleteBlocklistEntry(ctx, permanent.ID); err != nil {
+		t.Fatalf("DeleteBlocklistEntry failed: %v", err)
+	}
+	if err := s.DeleteBlocklistEntry(ctx, permanent.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	list, _ = s.ListBlocklistEntries(ctx)
+	if len(list) != 1 || list[0].CIDR != "2001:db8::/32" {
+		t.Errorf("expected only the active temporary entry to remain, got %+v", list)
+	}
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CompleteBootstrap records that bootstrap completed at the given time.
+// Later calls keep the first time.
+func (s *SQLiteStorage) CompleteBootstrap(ctx context.Context, at time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO bootstrap (id, completed_at) VALUES (1, ?)", at.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record bootstrap completion: %w", err)
+	}
+	return nil
+}
+
+// GetBootstrapCompletedAt returns when bootstrap completed.
+// Returns ErrNotFound if it has not.
+func (s *SQLiteStorage) GetBootstrapCompletedAt(ctx context.Context) (time.Time, error) {
+	var completedAt int64
+	err := s.readDB.QueryRowContext(ctx, "SELECT completed_at FROM bootstrap WHERE id = 1").Scan(&completedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to get bootstrap state: %w", err)
+	}
+	return time.Unix(completedAt, 0), nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SetTokenClientCert maps client certificates with the given subject common
+// name to a token, replacing any name the token was mapped to before.
+// Returns ErrNotFound if the token doesn't exist and ErrDuplicate if the name
+// is mapped to another token.
+func (s *SQLiteStorage) SetTokenClientCert(ctx context.Context, tokenID int64, commonName string) error {
+	if commonName == "" {
+		return fmt.Errorf("common name must not be empty")
+	}
+
+	var count int
+	if err := s.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM tokens WHERE id = ?", tokenID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to get token: %w", err)
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO token_client_certs (token_id, common_name, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(token_id) DO UPDATE SET common_name = excluded.common_name, created_at = excluded.created_at`,
+		tokenID, commonName, time.Now().Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to set token client certificate: %w", err)
+	}
+	return nil
+}
+
+// GetTokenClientCert returns the client certificate common name a token is mapped to.
+// Returns ErrNotFound if the token has no mapping.
+func (s *SQLiteStorage) GetTokenClientCert(ctx context.Context, tokenID int64) (string, error) {
+	var commonName string
+	err := s.readDB.QueryRowContext(ctx,
+		"SELECT common_name FROM token_client_certs WHERE token_id = ?", tokenID).Scan(&commonName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to get token client certificate: %w", err)
+	}
+	return commonName, nil
+}
+
+// GetTokenByClientCert returns the token client certificates with the given
+// common name are mapped to.
+// Returns ErrNotFound if no token is mapped to the name.
+func (s *SQLiteStorage) GetTokenByClientCert(ctx context.Context, commonName string) (*Token, error) {
+	t, err := scanToken(s.readDB.QueryRowContext(ctx,
+		"SELECT "+tokenColumns+" FROM tokens WHERE id = (SELECT token_id FROM token_client_certs WHERE common_name = ?)",
+		commonName))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get token by client certificate: %w", err)
+	}
+	return t, nil
+}
+
+// DeleteTokenClientCert removes a token's client certificate mapping.
+// Returns ErrNotFound if the token has no mapping.
+func (s *SQLiteStorage) DeleteTokenClientCert(ctx context.Context, tokenID int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM token_client_certs WHERE token_id = ?", tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to delete token client certificate: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -1,23 +1,106 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
+)
+
+// Connection pool defaults. The busy timeout is how long a connection waits
+// for a lock held by another connection or process before failing with
+// "database is locked".
+const (
+	DefaultBusyTimeout        = 5 * time.Second
+	DefaultMaxReadConnections = 4
 )
 
 // SQLiteStorage implements the Storage interface using SQLite.
+//
+// Writes go through db, which holds a single connection so that writers in
+// this process queue in the pool instead of contending for the SQLite write
+// lock. Reads go through readDB, a pool of read-only connections that WAL
+// mode lets run alongside the writer. For in-memory databases both are the
+// same pool, since every connection would otherwise see its own database.
+//
+// With an encryption key, dataKey encrypts the columns in encryptedColumns.
+// With token hashing, hasher turns token key hashes into Argon2id digests.
 type SQLiteStorage struct {
-	db *sql.DB
+	db      *sql.DB
+	readDB  *sql.DB
+	path    string
+	dataKey []byte
+	hasher  *tokenHasher // nil: plain SHA-256 hashes
+}
+
+// Option configures the database connections opened by New.
+type Option func(*options)
+
+type options struct {
+	busyTimeout        time.Duration
+	maxReadConnections int
+	encryptionKey      []byte
+	tokenHashing       *TokenHashParams
+	// skipEncryption opens the database without loading the data key, for
+	// checks that never read encrypted columns
+	skipEncryption bool
+}
+
+// WithBusyTimeout sets how long a connection waits for a locked database
+// before failing. Default: 5s.
+func WithBusyTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.busyTimeout = d
+	}
+}
+
+// WithMaxReadConnections sets the size of the read-only connection pool.
+// Default: 4.
+func WithMaxReadConnections(n int) Option {
+	return func(o *options) {
+		o.maxReadConnections = n
+	}
 }
 
 // New creates a new SQLiteStorage instance.
 // The dbPath is the file path for the SQLite database (or ":memory:" for tests).
-func New(dbPath string) (*SQLiteStorage, error) {
-	// Open database connection
-	db, err := sql.Open("sqlite", dbPath)
+func New(dbPath string, opts ...Option) (*SQLiteStorage, error) {
+	o := options{
+		busyTimeout:        DefaultBusyTimeout,
+		maxReadConnections: DefaultMaxReadConnections,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.busyTimeout < 0 {
+		return nil, fmt.Errorf("busy timeout must not be negative, got %s", o.busyTimeout)
+	}
+	if o.maxReadConnections < 1 {
+		return nil, fmt.Errorf("max read connections must be at least 1, got %d", o.maxReadConnections)
+	}
+	if o.encryptionKey != nil && len(o.encryptionKey) != 32 {
+		return nil, ErrInvalidKey
+	}
+	var hasher *tokenHasher
+	if o.tokenHashing != nil {
+		var err error
+		if hasher, err = newTokenHasher(*o.tokenHashing); err != nil {
+			return nil, err
+		}
+	}
+
+	// Open the writer. Pragmas in the DSN are applied to every connection
+	// the pool opens, so they survive a connection being replaced. Write
+	// transactions take the write lock up front (BEGIN IMMEDIATE), where the
+	// busy timeout applies, instead of failing when a read lock is upgraded.
+	db, err := sql.Open("sqlite", dsn(dbPath, o.busyTimeout, "_txlock=immediate", "_pragma=foreign_keys(1)"))
 	if err != nil { // coverage-ignore: sql.Open only fails for unknown driver names
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	// modernc.org/sqlite requires a single writer connection for in-process
+	// file databases to avoid "database is locked" errors
+	db.SetMaxOpenConns(1)
 
 	// Initialize schema
 	if err := InitSchema(db); err != nil {
@@ -25,47 +108,100 @@ func New(dbPath string) (*SQLiteStorage, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
-	// Enable WAL mode for better concurrent access support
+	// Enable WAL mode so readers do not block the writer. The journal mode is
+	// stored in the database file, so setting it once covers the read pool.
 	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil { // coverage-ignore: pragma fails only on corrupted DB
 		_ = db.Close() //nolint:errcheck
 		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
 	}
 
-	// Set busy timeout to wait for locks instead of failing immediately (5 seconds)
-	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil { // coverage-ignore: pragma fails only on corrupted DB
+	// Let freed pages be reclaimed by incremental vacuum (see IncrementalVacuum)
+	if err := enableIncrementalVacuum(db); err != nil { // coverage-ignore: vacuum fails only on corrupted DB or full disk
 		_ = db.Close() //nolint:errcheck
-		return nil, fmt.Errorf("failed to set busy timeout: %w", err)
+		return nil, err
 	}
 
-	// Disable memory-mapped I/O to prevent SIGBUS on Docker bind mounts
-	// and resource-constrained CI runners. modernc.org/sqlite (pure Go) uses mmap
-	// internally; under pressure, mmap'd regions can become invalid.
-	// Trade-off: minor read performance cost, eliminates SIGBUS-prone codepath.
-	if _, err := db.Exec("PRAGMA mmap_size = 0"); err != nil { // coverage-ignore: pragma fails only on corrupted DB
-		_ = db.Close() //nolint:errcheck
-		return nil, fmt.Errorf("failed to disable mmap: %w", err)
+	// Load or create the data key and encrypt secrets stored before it existed
+	var dataKey []byte
+	if !o.skipEncryption {
+		dataKey, err = setupEncryption(context.Background(), db, o.encryptionKey)
+		if err != nil {
+			_ = db.Close() //nolint:errcheck
+			return nil, err
+		}
 	}
 
-	// Configure connection pool for concurrent access
-	// modernc.org/sqlite requires single connection for in-process file databases
-	// to avoid "database is locked" errors
-	db.SetMaxOpenConns(1)
+	// Hash tokens stored before token hashing was enabled
+	if hasher != nil {
+		if err := hashStoredTokens(context.Background(), db, hasher); err != nil {
+			_ = db.Close() //nolint:errcheck
+			return nil, err
+		}
+	}
 
-	// Enable foreign key constraints
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil { // coverage-ignore: pragma fails only on corrupted DB
-		_ = db.Close() //nolint:errcheck
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
+	readDB := db
+	if !isMemoryPath(dbPath) {
+		readDB, err = sql.Open("sqlite", dsn(dbPath, o.busyTimeout, "_pragma=query_only(1)"))
+		if err != nil { // coverage-ignore: sql.Open only fails for unknown driver names
+			_ = db.Close() //nolint:errcheck
+			return nil, fmt.Errorf("failed to open read pool: %w", err)
+		}
+		readDB.SetMaxOpenConns(o.maxReadConnections)
+		readDB.SetMaxIdleConns(o.maxReadConnections)
 	}
 
 	return &SQLiteStorage{
-		db: db,
+		db:      db,
+		readDB:  readDB,
+		path:    dbPath,
+		dataKey: dataKey,
+		hasher:  hasher,
 	}, nil
 }
 
-// Close closes the database connection.
+// dsn builds a modernc.org/sqlite data source name with the pragmas every
+// connection needs plus extra query parameters. mmap is disabled to prevent
+// SIGBUS on Docker bind mounts and resource-constrained CI runners, where
+// mmap'd regions can become invalid under pressure.
+func dsn(dbPath string, busyTimeout time.Duration, extra ...string) string {
+	params := append([]string{
+		fmt.Sprintf("_pragma=busy_timeout(%d)", busyTimeout.Milliseconds()),
+		"_pragma=mmap_size(0)",
+	}, extra...)
+
+	sep := "?"
+	if strings.Contains(dbPath, "?") {
+		sep = "&"
+	}
+	return dbPath + sep + strings.Join(params, "&")
+}
+
+// isMemoryPath reports whether dbPath names an in-memory database.
+func isMemoryPath(dbPath string) bool {
+	return dbPath == ":memory:" || strings.Contains(dbPath, "mode=memory")
+}
+
+// PoolStats returns statistics of the writer and reader connection pools,
+// keyed by "write" and "read". In-memory databases only have a writer.
+func (s *SQLiteStorage) PoolStats() map[string]sql.DBStats {
+	stats := map[string]sql.DBStats{"write": s.db.Stats()}
+	if s.readDB != s.db {
+		stats["read"] = s.readDB.Stats()
+	}
+	return stats
+}
+
+// Close closes the database connections.
 func (s *SQLiteStorage) Close() error {
-	if s.db != nil {
-		return s.db.Close()
+	if s.db == nil {
+		return nil
+	}
+	var readErr error
+	if s.readDB != nil && s.readDB != s.db {
+		readErr = s.readDB.Close()
+	}
+	if err := s.db.Close(); err != nil {
+		return err
 	}
-	return nil
+	return readErr
 }
@@ -1,9 +1,11 @@
 package storage
 
 import (
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -199,3 +201,91 @@ func TestNewDisablesMmapSize(t *testing.T) {
 		t.Errorf("expected mmap_size 0, got %d", mmapSize)
 	}
 }
+
+// TestNewFileDatabaseUsesReadPool tests that file databases get a separate
+// read-only pool that sees committed writes and uses the configured pragmas.
+func TestNewFileDatabaseUsesReadPool(t *testing.T) {
+	t.Parallel()
+	storage, err := New(t.TempDir()+"/test.db", WithBusyTimeout(250*time.Millisecond), WithMaxReadConnections(2))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	if storage.readDB == storage.db {
+		t.Fatal("file database should have a separate read pool")
+	}
+	if got := storage.readDB.Stats().MaxOpenConnections; got != 2 {
+		t.Errorf("expected 2 read connections, got %d", got)
+	}
+
+	var journalMode string
+	if err := storage.readDB.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil {
+		t.Fatalf("failed to check journal mode: %v", err)
+	}
+	if journalMode != "wal" {
+		t.Errorf("expected journal mode 'wal', got %s", journalMode)
+	}
+	for _, db := range []*sql.DB{storage.db, storage.readDB} {
+		var busyTimeout int
+		if err := db.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			t.Fatalf("failed to check busy timeout: %v", err)
+		}
+		if busyTimeout != 250 {
+			t.Errorf("expected busy timeout 250, got %d", busyTimeout)
+		}
+	}
+
+	if _, err := storage.readDB.Exec("DELETE FROM tokens"); err == nil {
+		t.Error("read pool should reject writes")
+	}
+
+	ctx := context.Background()
+	created, err := storage.CreateToken(ctx, "reader", false, "hash")
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	if _, err := storage.GetTokenByID(ctx, created.ID); err != nil {
+		t.Errorf("read pool does not see committed write: %v", err)
+	}
+
+	stats := storage.PoolStats()
+	if _, ok := stats["read"]; !ok {
+		t.Error("PoolStats is missing the read pool")
+	}
+	if got := stats["write"].MaxOpenConnections; got != 1 {
+		t.Errorf("expected 1 write connection, got %d", got)
+	}
+}
+
+// TestNewMemoryDatabaseSharesPool tests that in-memory databases read and
+// write through the same connection.
+func TestNewMemoryDatabaseSharesPool(t *testing.T) {
+	t.Parallel()
+	storage, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = storage.Close() }()
+
+	if storage.readDB != storage.db {
+		t.Error("in-memory database should not have a separate read pool")
+	}
+	if stats := storage.PoolStats(); len(stats) != 1 {
+		t.Errorf("expected only the write pool, got %v", stats)
+	}
+}
+
+// TestNewRejectsInvalidOptions tests that New() validates pool options.
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	t.Parallel()
+	for name, opt := range map[string]Option{
+		"negative busy timeout": WithBusyTimeout(-time.Second),
+		"no read connections":   WithMaxReadConnections(0),
+	} {
+		if storage, err := New(":memory:", opt); err == nil {
+			t.Errorf("%s: expected error", name)
+			_ = storage.Close()
+		}
+	}
+}
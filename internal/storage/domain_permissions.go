@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddDomainPermission grants a token a permission on every zone whose domain
+// matches a pattern. The pattern is stored as given; callers validate it.
+func (s *SQLiteStorage) AddDomainPermission(ctx context.Context, p *DomainPermission) (*DomainPermission, error) {
+	if p.Pattern == "" {
+		return nil, fmt.Errorf("pattern cannot be empty")
+	}
+	if len(p.AllowedActions) == 0 {
+		return nil, fmt.Errorf("allowed actions cannot be empty")
+	}
+	if len(p.RecordTypes) == 0 {
+		return nil, fmt.Errorf("record types cannot be empty")
+	}
+
+	allowedActionsJSON, err := marshalStringArray(p.AllowedActions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal allowed actions: %w", err)
+	}
+	recordTypesJSON, err := marshalStringArray(p.RecordTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal record types: %w", err)
+	}
+
+	createdAt := p.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"INSERT INTO domain_permissions (token_id, pattern, allowed_actions, record_types, created_at) VALUES (?, ?, ?, ?, ?)",
+		p.TokenID, p.Pattern, string(allowedActionsJSON), string(recordTypesJSON), createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert domain permission: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	created := *p
+	created.ID = id
+	created.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	return &created, nil
+}
+
+// ListDomainPermissions returns a token's domain permissions in creation order.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListDomainPermissions(ctx context.Context, tokenID int64) ([]*DomainPermission, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT id, token_id, pattern, allowed_actions, record_types, created_at FROM domain_permissions WHERE token_id = ? ORDER BY id ASC",
+		tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query domain permissions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	perms := make([]*DomainPermission, 0)
+	for rows.Next() {
+		var p DomainPermission
+		var allowedActionsJSON, recordTypesJSON string
+		var createdAt int64
+		if err := rows.Scan(&p.ID, &p.TokenID, &p.Pattern, &allowedActionsJSON, &recordTypesJSON, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan domain permission row: %w", err)
+		}
+		if err := unmarshalStringArray(allowedActionsJSON, &p.AllowedActions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed actions: %w", err)
+		}
+		if err := unmarshalStringArray(recordTypesJSON, &p.RecordTypes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal record types: %w", err)
+		}
+		p.CreatedAt = time.Unix(createdAt, 0)
+		perms = append(perms, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating domain permissions: %w", err)
+	}
+	return perms, nil
+}
+
+// DeleteDomainPermission deletes a token's domain permission. Returns
+// ErrNotFound if it doesn't exist or doesn't belong to the token.
+func (s *SQLiteStorage) DeleteDomainPermission(ctx context.Context, tokenID, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM domain_permissions WHERE id = ? AND token_id = ?", id, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain permission: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
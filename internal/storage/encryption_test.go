@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEncryptionKey(t *testing.T) {
+	t.Parallel()
+	key := bytes.Repeat([]byte{0xab}, 32)
+	for _, s := range []string{hex.EncodeToString(key), base64.StdEncoding.EncodeToString(key) + "\n"} {
+		got, err := ParseEncryptionKey(s)
+		if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("ParseEncryptionKey(%q) = %x, %v", s, got, err)
+		}
+	}
+	for _, s := range []string{"", "not a key", hex.EncodeToString(key[:16])} {
+		if _, err := ParseEncryptionKey(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("ParseEncryptionKey(%q) error = %v, want ErrInvalidKey", s, err)
+		}
+	}
+}
+
+func TestEncryptedColumns(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "proxy.db")
+	key := bytes.Repeat([]byte{1}, 32)
+
+	// A webhook written before encryption was enabled
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	old, err := s.CreateWebhook(ctx, &Webhook{URL: "https://example.com/old", Secret: "old-secret", Enabled: true})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	_ = s.Close()
+
+	s, err = New(path, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("New with key failed: %v", err)
+	}
+	hook, err := s.CreateWebhook(ctx, &Webhook{URL: "https://example.com/new", Secret: "new-secret", Enabled: true})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if hook.Secret != "new-secret" {
+		t.Errorf("secret = %q, want it decrypted", hook.Secret)
+	}
+	if got, err := s.GetWebhook(ctx, old.ID); err != nil || got.Secret != "old-secret" {
+		t.Errorf("GetWebhook(old) = %+v, %v", got, err)
+	}
+
+	rows, err := s.db.Query("SELECT secret FROM webhooks")
+	if err != nil {
+		t.Fatalf("failed to read secrets: %v", err)
+	}
+	for rows.Next() {
+		var stored string
+		if err := rows.Scan(&stored); err != nil {
+			t.Fatalf("failed to scan secret: %v", err)
+		}
+		if !strings.HasPrefix(stored, encryptedPrefix) || strings.Contains(stored, "secret") {
+			t.Errorf("secret stored as %q, want it encrypted", stored)
+		}
+	}
+	_ = rows.Close()
+	_ = s.Close()
+
+	if _, err := New(path); !errors.Is(err, ErrEncryptionKeyRequired) {
+		t.Errorf("New without key error = %v, want ErrEncryptionKeyRequired", err)
+	}
+	if _, err := New(path, WithEncryptionKey(bytes.Repeat([]byte{2}, 32))); !errors.Is(err, ErrDecryption) {
+		t.Errorf("New with wrong key error = %v, want ErrDecryption", err)
+	}
+	if _, err := New(path, WithEncryptionKey([]byte("short"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("New with short key error = %v, want ErrInvalidKey", err)
+	}
+
+	s, err = New(path, WithEncryptionKey(key))
+	if err != nil {
+		t.Fatalf("reopening with the key failed: %v", err)
+	}
+	defer s.Close()
+	hooks, err := s.ListWebhooks(ctx)
+	if err != nil || len(hooks) != 2 || hooks[1].Secret != "new-secret" {
+		t.Errorf("ListWebhooks() = %v, %v", hooks, err)
+	}
+}
@@ -1,6 +1,12 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"syscall"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
 
 var (
 	// ErrInvalidKey is returned when an encryption key is not 32 bytes.
@@ -9,9 +15,38 @@ var (
 	// ErrDecryption is returned when decryption fails due to wrong key or corrupted data.
 	ErrDecryption = errors.New("decryption failed: wrong key or corrupted data")
 
+	// ErrEncryptionKeyRequired is returned when a database with encrypted
+	// columns is opened without an encryption key.
+	ErrEncryptionKeyRequired = errors.New("database has encrypted columns but no encryption key is set")
+
 	// ErrDuplicate is returned when attempting to create a resource that already exists.
 	ErrDuplicate = errors.New("resource already exists")
 
 	// ErrNotFound is returned when a requested resource does not exist.
 	ErrNotFound = errors.New("resource not found")
 )
+
+// isConstraintViolation reports whether err is a SQLite constraint violation
+// such as a UNIQUE constraint failure.
+func isConstraintViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// Check for UNIQUE constraint (extended error code 2067)
+		// or base constraint error code 19
+		return sqliteErr.Code() == 2067 || (sqliteErr.Code()&0xFF) == sqlite3.SQLITE_CONSTRAINT
+	}
+	return false
+}
+
+// IsDiskFull reports whether err is a write failure caused by the database's
+// volume running out of space.
+func IsDiskFull(err error) bool {
+	if errors.Is(err, syscall.ENOSPC) {
+		return true
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return (sqliteErr.Code() & 0xFF) == sqlite3.SQLITE_FULL
+	}
+	return false
+}
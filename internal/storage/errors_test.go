@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestIsDiskFull(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	// Cap the database at its current size so the next growing write fails
+	// the same way a full volume does.
+	if _, err := s.db.ExecContext(ctx, "PRAGMA max_page_count = 1"); err != nil {
+		t.Fatalf("failed to cap page count: %v", err)
+	}
+	_, err = s.CreateToken(ctx, strings.Repeat("x", 8192), false, "hash")
+	if err == nil {
+		t.Fatal("expected write to fail once the database is full")
+	}
+	if !IsDiskFull(err) {
+		t.Errorf("IsDiskFull(%v) = false, want true", err)
+	}
+
+	if !IsDiskFull(fmt.Errorf("write wal: %w", syscall.ENOSPC)) {
+		t.Error("IsDiskFull(ENOSPC) = false, want true")
+	}
+	for _, err := range []error{nil, errors.New("boom"), ErrNotFound} {
+		if IsDiskFull(err) {
+			t.Errorf("IsDiskFull(%v) = true, want false", err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ClaimIdempotencyKey records that a token's request with a key is in
+// progress; times are stored with second precision.
+// Returns ErrDuplicate if the token already used the key.
+func (s *SQLiteStorage) ClaimIdempotencyKey(ctx context.Context, tokenID int64, key, fingerprint string, at time.Time) error {
+	if key == "" {
+		return fmt.Errorf("idempotency key must not be empty")
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO idempotency_keys (token_id, key, fingerprint, created_at) VALUES (?, ?, ?, ?)",
+		tokenID, key, fingerprint, at.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return nil
+}
+
+// GetIdempotentResponse returns the request recorded for a token's key.
+// Returns ErrNotFound if the key is not recorded.
+func (s *SQLiteStorage) GetIdempotentResponse(ctx context.Context, tokenID int64, key string) (*IdempotentResponse, error) {
+	resp := &IdempotentResponse{TokenID: tokenID, Key: key}
+	var createdAt int64
+	err := s.readDB.QueryRowContext(ctx,
+		"SELECT fingerprint, status, content_type, body, created_at FROM idempotency_keys WHERE token_id = ? AND key = ?",
+		tokenID, key).Scan(&resp.Fingerprint, &resp.Status, &resp.ContentType, &resp.Body, &createdAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	resp.CreatedAt = time.Unix(createdAt, 0)
+	return resp, nil
+}
+
+// CompleteIdempotencyKey stores the response to a claimed key's request.
+// Returns ErrNotFound if the key is not recorded.
+func (s *SQLiteStorage) CompleteIdempotencyKey(ctx context.Context, tokenID int64, key string, status int, contentType string, body []byte) error {
+	if body == nil {
+		body = []byte{}
+	}
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE token_id = ? AND key = ?",
+		status, contentType, body, tokenID, key)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a key, so it can be claimed again.
+func (s *SQLiteStorage) ReleaseIdempotencyKey(ctx context.Context, tokenID int64, key string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE token_id = ? AND key = ?", tokenID, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PruneIdempotencyKeys deletes keys claimed before the cutoff and returns how many were removed.
+func (s *SQLiteStorage) PruneIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune idempotency keys: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
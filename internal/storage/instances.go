@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetInstanceByHostname retrieves the most recently seen instance on a host.
+// Returns ErrNotFound if no instance has registered from that host.
+func (s *SQLiteStorage) GetInstanceByHostname(ctx context.Context, hostname string) (*Instance, error) {
+	row := s.readDB.QueryRowContext(ctx,
+		`SELECT id, hostname, version, started_at, last_seen_at FROM instances
+			WHERE hostname = ? ORDER BY last_seen_at DESC LIMIT 1`, hostname)
+
+	inst, err := scanInstance(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get instance: %w", err)
+	}
+	return inst, nil
+}
+
+// UpsertInstance registers an instance, replacing any existing row with the same ID.
+// StartedAt and LastSeenAt default to now and are stored with second precision.
+func (s *SQLiteStorage) UpsertInstance(ctx context.Context, inst *Instance) error {
+	if inst.ID == "" {
+		return fmt.Errorf("instance requires an ID")
+	}
+
+	now := time.Now()
+	startedAt, lastSeenAt := inst.StartedAt, inst.LastSeenAt
+	if startedAt.IsZero() {
+		startedAt = now
+	}
+	if lastSeenAt.IsZero() {
+		lastSeenAt = now
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO instances (id, hostname, version, started_at, last_seen_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				hostname = excluded.hostname, version = excluded.version,
+				started_at = excluded.started_at, last_seen_at = excluded.last_seen_at`,
+		inst.ID, inst.Hostname, inst.Version, startedAt.Unix(), lastSeenAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to upsert instance: %w", err)
+	}
+
+	inst.StartedAt = time.Unix(startedAt.Unix(), 0)
+	inst.LastSeenAt = time.Unix(lastSeenAt.Unix(), 0)
+	return nil
+}
+
+// TouchInstance updates an instance's last seen time.
+// Returns ErrNotFound if the instance is not registered.
+func (s *SQLiteStorage) TouchInstance(ctx context.Context, id string, at time.Time) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE instances SET last_seen_at = ? WHERE id = ?", at.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to touch instance: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListInstances returns all registered instances, most recently seen first.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListInstances(ctx context.Context) ([]*Instance, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT id, hostname, version, started_at, last_seen_at FROM instances ORDER BY last_seen_at DESC, id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query instances: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	instances := make([]*Instance, 0)
+	for rows.Next() {
+		inst, err := scanInstance(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan instance row: %w", err)
+		}
+		instances = append(instances, inst)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating instances: %w", err)
+	}
+
+	return instances, nil
+}
+
+// PruneInstances deletes instances last seen before the cutoff and returns how many were removed.
+func (s *SQLiteStorage) PruneInstances(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM instances WHERE last_seen_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune instances: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// scanInstance scans an instance from a row.
+func scanInstance(row rowScanner) (*Instance, error) {
+	var inst Instance
+	var startedAt, lastSeenAt int64
+	if err := row.Scan(&inst.ID, &inst.Hostname, &inst.Version, &startedAt, &lastSeenAt); err != nil {
+		return nil, err
+	}
+	inst.StartedAt = time.Unix(startedAt, 0)
+	inst.LastSeenAt = time.Unix(lastSeenAt, 0)
+	return &inst, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInstances(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := s.GetInstanceByHostname(ctx, "host-a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for unknown host, got %v", err)
+	}
+	if err := s.UpsertInstance(ctx, &Instance{Hostname: "host-a"}); err == nil {
+		t.Error("expected error for instance without ID")
+	}
+
+	instances := []*Instance{
+		{ID: "old", Hostname: "host-a", Version: "1.0.0", LastSeenAt: now.Add(-48 * time.Hour)},
+		{ID: "new", Hostname: "host-a", Version: "1.1.0"},
+		{ID: "other", Hostname: "host-b", Version: "1.1.0", LastSeenAt: now.Add(-time.Minute)},
+	}
+	for _, inst := range instances {
+		if err := s.UpsertInstance(ctx, inst); err != nil {
+			t.Fatalf("UpsertInstance failed: %v", err)
+		}
+	}
+
+	got, err := s.GetInstanceByHostname(ctx, "host-a")
+	if err != nil || got.ID != "new" || got.Version != "1.1.0" {
+		t.Fatalf("expected most recently seen instance on host-a, got %+v, %v", got, err)
+	}
+
+	// Re-registering replaces the row
+	if err := s.UpsertInstance(ctx, &Instance{ID: "other", Hostname: "host-b", Version: "1.2.0"}); err != nil {
+		t.Fatalf("UpsertInstance failed: %v", err)
+	}
+
+	if err := s.TouchInstance(ctx, "old", now.Add(-30*time.Hour)); err != nil {
+		t.Fatalf("TouchInstance failed: %v", err)
+	}
+	if err := s.TouchInstance(ctx, "missing", now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound touching unknown instance, got %v", err)
+	}
+
+	list, err := s.ListInstances(ctx)
+	if err != nil {
+		t.Fatalf("ListInstances failed: %v", err)
+	}
+	if len(list) != 3 || list[2].ID != "old" || list[2].LastSeenAt.Unix() != now.Add(-30*time.Hour).Unix() {
+		t.Fatalf("expected three instances with the stale one last, got %+v", list)
+	}
+	for _, inst := range list {
+		if inst.ID == "other" && inst.Version != "1.2.0" {
+			t.Errorf("expected upsert to update version, got %q", inst.Version)
+		}
+	}
+
+	n, err := s.PruneInstances(ctx, now.Add(-24*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("expected one pruned instance, got %d, %v", n, err)
+	}
+	if list, _ := s.ListInstances(ctx); len(list) != 2 {
+		t.Errorf("expected two instances after pruning, got %d", len(list))
+	}
+}
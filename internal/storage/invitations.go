@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CreateInvitation stores an invitation under the hash of its code.
+// CreatedAt defaults to now; times are stored with second precision.
+// Returns ErrDuplicate if the hash is already in use.
+func (s *SQLiteStorage) CreateInvitation(ctx context.Context, inv *Invitation, inviteHash string) (*Invitation, error) {
+	if inviteHash == "" {
+		return nil, fmt.Errorf("invitation hash must not be empty")
+	}
+	name := strings.TrimSpace(inv.TokenName)
+	if name == "" {
+		return nil, fmt.Errorf("token name cannot be empty")
+	}
+
+	roleIDs := inv.RoleIDs
+	if roleIDs == nil {
+		roleIDs = []int64{}
+	}
+	roleIDsJSON, err := json.Marshal(roleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal role IDs: %w", err)
+	}
+
+	createdAt := inv.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO invitations (invite_hash, token_name, role_ids, token_ttl_seconds, created_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		inviteHash, name, string(roleIDsJSON), int64(inv.TokenTTL/time.Second), inv.CreatedBy,
+		createdAt.Unix(), inv.ExpiresAt.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	created := *inv
+	created.ID = id
+	created.TokenName = name
+	created.RoleIDs = roleIDs
+	created.TokenTTL = inv.TokenTTL.Truncate(time.Second)
+	created.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	created.ExpiresAt = time.Unix(inv.ExpiresAt.Unix(), 0)
+	created.ClaimedAt = time.Time{}
+	created.TokenID = 0
+	return &created, nil
+}
+
+// GetInvitationByHash returns the invitation with the given code hash,
+// including a claimed or expired one.
+// Returns ErrNotFound if there is none.
+func (s *SQLiteStorage) GetInvitationByHash(ctx context.Context, inviteHash string) (*Invitation, error) {
+	inv, err := scanInvitation(s.readDB.QueryRowContext(ctx,
+		"SELECT "+invitationColumns+" FROM invitations WHERE invite_hash = ?", inviteHash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// ListInvitations returns all invitations, newest first.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListInvitations(ctx context.Context) ([]*Invitation, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT "+invitationColumns+" FROM invitations ORDER BY id DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	invitations := make([]*Invitation, 0)
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation row: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// DeleteInvitation revokes an invitation. The token it minted, if any, is kept.
+// Returns ErrNotFound if the invitation doesn't exist.
+func (s *SQLiteStorage) DeleteInvitation(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM invitations WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+	if n, err := rowsAffected(result); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimInvitation records that an unclaimed invitation minted a token. The
+// check and the update are one statement, so two concurrent claims cannot
+// both succeed. Returns ErrNotFound if the invitation doesn't exist, was
+// already claimed or expired before now.
+func (s *SQLiteStorage) ClaimInvitation(ctx context.Context, id, tokenID int64, now time.Time) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE invitations SET claimed_at = ?, token_id = ?
+		WHERE id = ? AND claimed_at = 0 AND expires_at > ?`,
+		now.Unix(), tokenID, id, now.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to claim invitation: %w", err)
+	}
+	if n, err := rowsAffected(result); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// invitationColumns lists the columns read by scanInvitation, in order.
+const invitationColumns = "id, token_name, role_ids, token_ttl_seconds, created_by, created_at, expires_at, claimed_at, token_id"
+
+// scanInvitation scans an invitation row and decodes its role IDs.
+func scanInvitation(row rowScanner) (*Invitation, error) {
+	var inv Invitation
+	var roleIDsJSON string
+	var ttlSeconds, createdAt, expiresAt, claimedAt int64
+	var tokenID sql.NullInt64
+
+	if err := row.Scan(&inv.ID, &inv.TokenName, &roleIDsJSON, &ttlSeconds, &inv.CreatedBy,
+		&createdAt, &expiresAt, &claimedAt, &tokenID); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(roleIDsJSON), &inv.RoleIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal role IDs: %w", err)
+	}
+
+	inv.TokenTTL = time.Duration(ttlSeconds) * time.Second
+	inv.CreatedAt = time.Unix(createdAt, 0)
+	inv.ExpiresAt = time.Unix(expiresAt, 0)
+	if claimedAt != 0 {
+		inv.ClaimedAt = time.Unix(claimedAt, 0)
+	}
+	inv.TokenID = tokenID.Int64
+	return &inv, nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreateJob records a queued job; times are stored with second precision.
+// CreatedAt defaults to now.
+func (s *SQLiteStorage) CreateJob(ctx context.Context, j *Job) (*Job, error) {
+	if j.Kind == "" {
+		return nil, fmt.Errorf("job requires a kind")
+	}
+
+	createdAt := j.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO jobs (kind, zone_id, token_id, status, total, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		j.Kind, j.ZoneID, j.TokenID, JobStatusQueued, j.Total, createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	return &Job{
+		ID:        id,
+		Kind:      j.Kind,
+		ZoneID:    j.ZoneID,
+		TokenID:   j.TokenID,
+		Status:    JobStatusQueued,
+		Total:     j.Total,
+		CreatedAt: time.Unix(createdAt.Unix(), 0),
+	}, nil
+}
+
+// GetJob retrieves a job by ID.
+// Returns ErrNotFound if the job does not exist.
+func (s *SQLiteStorage) GetJob(ctx context.Context, id int64) (*Job, error) {
+	j := &Job{ID: id}
+	var result string
+	var createdAt, startedAt, finishedAt int64
+	err := s.readDB.QueryRowContext(ctx,
+		`SELECT kind, zone_id, token_id, status, total, processed, result, error, created_at, started_at, finished_at
+			FROM jobs WHERE id = ?`, id).
+		Scan(&j.Kind, &j.ZoneID, &j.TokenID, &j.Status, &j.Total, &j.Processed, &result, &j.Error,
+			&createdAt, &startedAt, &finishedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if result != "" {
+		j.Result = []byte(result)
+	}
+	j.CreatedAt = time.Unix(createdAt, 0)
+	j.StartedAt = unixOrZero(startedAt)
+	j.FinishedAt = unixOrZero(finishedAt)
+	return j, nil
+}
+
+// StartJob marks a queued job as running.
+// Returns ErrNotFound if the job does not exist or is not queued.
+func (s *SQLiteStorage) StartJob(ctx context.Context, id int64, at time.Time) error {
+	return s.updateJob(ctx, "start",
+		"UPDATE jobs SET status = ?, started_at = ? WHERE id = ? AND status = ?",
+		JobStatusRunning, at.Unix(), id, JobStatusQueued)
+}
+
+// UpdateJobProgress records how many items a running job has processed.
+// Returns ErrNotFound if the job does not exist or is not running.
+func (s *SQLiteStorage) UpdateJobProgress(ctx context.Context, id int64, processed int) error {
+	return s.updateJob(ctx, "update progress of",
+		"UPDATE jobs SET processed = ? WHERE id = ? AND status = ?",
+		processed, id, JobStatusRunning)
+}
+
+// FinishJob records the outcome of a queued or running job. status must be
+// JobStatusSucceeded or JobStatusFailed.
+// Returns ErrNotFound if the job does not exist or has already finished.
+func (s *SQLiteStorage) FinishJob(ctx context.Context, id int64, status string, processed int, result []byte, errMsg string, at time.Time) error {
+	if status != JobStatusSucceeded && status != JobStatusFailed {
+		return fmt.Errorf("invalid job status %q", status)
+	}
+	return s.updateJob(ctx, "finish",
+		`UPDATE jobs SET status = ?, processed = ?, result = ?, error = ?, finished_at = ?
+			WHERE id = ? AND status IN (?, ?)`,
+		status, processed, string(result), errMsg, at.Unix(), id, JobStatusQueued, JobStatusRunning)
+}
+
+// updateJob runs an update of one job and reports ErrNotFound when it
+// matched no row.
+func (s *SQLiteStorage) updateJob(ctx context.Context, op, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s job: %w", op, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FailUnfinishedJobs marks jobs created before the cutoff that are still
+// queued or running as failed with the given message, and returns how many
+// were marked. It is used at startup for jobs whose worker has stopped.
+func (s *SQLiteStorage) FailUnfinishedJobs(ctx context.Context, before time.Time, message string) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE jobs SET status = ?, error = ?, finished_at = ? WHERE status IN (?, ?) AND created_at < ?",
+		JobStatusFailed, message, time.Now().Unix(), JobStatusQueued, JobStatusRunning, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail unfinished jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneJobs deletes jobs that finished before the cutoff and returns how many were removed.
+func (s *SQLiteStorage) PruneJobs(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM jobs WHERE finished_at > 0 AND finished_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
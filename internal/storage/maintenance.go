@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// autoVacuumIncremental is the PRAGMA auto_vacuum value for incremental mode.
+const autoVacuumIncremental = 2
+
+// enableIncrementalVacuum switches the database to incremental auto-vacuum.
+// Existing databases only pick up the mode after a full VACUUM, which runs once.
+func enableIncrementalVacuum(db *sql.DB) error {
+	plan, err := planIncrementalVacuum(db)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range plan {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to enable incremental vacuum: %w", err)
+		}
+	}
+	return nil
+}
+
+// planIncrementalVacuum returns the statements enableIncrementalVacuum would
+// run: none once the mode is set, otherwise a mode change and a full VACUUM.
+func planIncrementalVacuum(db *sql.DB) ([]string, error) {
+	var mode int
+	if err := db.QueryRow("PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return nil, fmt.Errorf("failed to read auto_vacuum mode: %w", err)
+	}
+	if mode == autoVacuumIncremental {
+		return nil, nil
+	}
+	return []string{"PRAGMA auto_vacuum = INCREMENTAL", "VACUUM"}, nil
+}
+
+// DBStats returns the database size and page usage.
+// FileSize is the size of the database file plus its write-ahead log; for
+// in-memory databases it is the page count times the page size.
+func (s *SQLiteStorage) DBStats(ctx context.Context) (*DBStats, error) {
+	var stats DBStats
+	for _, p := range []struct {
+		pragma string
+		dest   *int64
+	}{
+		{"page_size", &stats.PageSize},
+		{"page_count", &stats.PageCount},
+		{"freelist_count", &stats.FreelistCount},
+	} {
+		if err := s.db.QueryRowContext(ctx, "PRAGMA "+p.pragma).Scan(p.dest); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p.pragma, err)
+		}
+	}
+
+	stats.FileSize = stats.PageSize * stats.PageCount
+	if s.path != "" && s.path != ":memory:" {
+		size, err := fileSize(s.path)
+		if err != nil {
+			return nil, err
+		}
+		walSize, err := fileSize(s.path + "-wal")
+		if err != nil {
+			return nil, err
+		}
+		stats.FileSize = size + walSize
+	}
+
+	return &stats, nil
+}
+
+// IncrementalVacuum returns up to pages free pages to the filesystem.
+// A non-positive pages value reclaims all free pages.
+func (s *SQLiteStorage) IncrementalVacuum(ctx context.Context, pages int) error {
+	if pages < 0 {
+		pages = 0
+	}
+	// The pragma frees one page per result row, so the rows must be drained.
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("PRAGMA incremental_vacuum(%d)", pages))
+	if err != nil {
+		return fmt.Errorf("failed to run incremental vacuum: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to run incremental vacuum: %w", err)
+	}
+	return nil
+}
+
+// OpenConnections returns the number of established database connections
+// across the writer and reader pools,
+// both in use and idle.
+func (s *SQLiteStorage) OpenConnections() int {
+	n := 0
+	for _, stats := range s.PoolStats() {
+		n += stats.OpenConnections
+	}
+	return n
+}
+
+// fileSize returns the size of a file, or 0 if it does not exist.
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNew_EnablesIncrementalVacuum(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(filepath.Join(t.TempDir(), "proxy.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	var mode int
+	if err := s.db.QueryRow("PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		t.Fatalf("failed to read auto_vacuum: %v", err)
+	}
+	if mode != autoVacuumIncremental {
+		t.Errorf("expected incremental auto_vacuum, got %d", mode)
+	}
+}
+
+func TestDBStatsAndIncrementalVacuum(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(filepath.Join(t.TempDir(), "proxy.db"))
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	// Grow the database, then free the pages
+	big := strings.Repeat("x", 4096)
+	for i := 0; i < 50; i++ {
+		if _, err := s.AppendAuditEntry(ctx, &AuditEntry{Method: "GET", Path: big}); err != nil {
+			t.Fatalf("AppendAuditEntry failed: %v", err)
+		}
+	}
+	if _, err := s.db.Exec("DELETE FROM audit_log"); err != nil {
+		t.Fatalf("failed to delete rows: %v", err)
+	}
+
+	before, err := s.DBStats(ctx)
+	if err != nil {
+		t.Fatalf("DBStats failed: %v", err)
+	}
+	if before.PageSize <= 0 || before.PageCount <= 0 || before.FileSize <= 0 {
+		t.Fatalf("unexpected stats: %+v", before)
+	}
+	if before.FreelistCount == 0 {
+		t.Fatalf("expected free pages after delete, got %+v", before)
+	}
+
+	if err := s.IncrementalVacuum(ctx, 0); err != nil {
+		t.Fatalf("IncrementalVacuum failed: %v", err)
+	}
+	after, err := s.DBStats(ctx)
+	if err != nil {
+		t.Fatalf("DBStats failed: %v", err)
+	}
+	if after.FreelistCount != 0 || after.PageCount >= before.PageCount {
+		t.Errorf("expected free pages to be reclaimed, before %+v after %+v", before, after)
+	}
+}
+
+func TestDBStats_InMemory(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	stats, err := s.DBStats(context.Background())
+	if err != nil {
+		t.Fatalf("DBStats failed: %v", err)
+	}
+	if stats.FileSize != stats.PageSize*stats.PageCount {
+		t.Errorf("expected in-memory size from pages, got %+v", stats)
+	}
+}
+
+func TestOpenConnections(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if n := s.OpenConnections(); n != 1 {
+		t.Errorf("expected the single pooled connection, got %d", n)
+	}
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// MigrationReport is the outcome of VerifyMigration.
+type MigrationReport struct {
+	// Applied lists the statements that ran against the copy.
+	Applied []string
+	// Problems lists integrity and foreign key problems found afterwards.
+	// Empty means the migrated copy is sound.
+	Problems []string
+	// RowCounts is the number of rows in each table after migrating.
+	RowCounts map[string]int64
+}
+
+// openReadOnly opens an existing database without creating or modifying it.
+func openReadOnly(dbPath string) (*sql.DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	u := url.URL{Scheme: "file", Path: dbPath, RawQuery: "mode=ro"}
+	db, err := sql.Open("sqlite", u.String())
+	if err != nil { // coverage-ignore: sql.Open only fails for unknown driver names
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+// PlanMigration returns the SQL that opening the database at dbPath with this
+// version would run, in order. The database is opened read-only and left
+// untouched. An empty plan means the database is up to date.
+func PlanMigration(ctx context.Context, dbPath string) ([]string, error) {
+	db, err := openReadOnly(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close() //nolint:errcheck
+
+	return planMigration(ctx, db)
+}
+
+// planMigration lists the statements New would run against db.
+func planMigration(ctx context.Context, db *sql.DB) ([]string, error) {
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	plan, err := PlanSchema(db)
+	if err != nil {
+		return nil, err
+	}
+	vacuum, err := planIncrementalVacuum(db)
+	if err != nil {
+		return nil, err
+	}
+	return append(plan, vacuum...), nil
+}
+
+// VerifyMigration copies the database at dbPath to a temporary file, migrates
+// the copy and checks the result. The original is opened read-only, so this is
+// safe to run against a snapshot, or a live database before an upgrade.
+func VerifyMigration(ctx context.Context, dbPath string) (*MigrationReport, error) {
+	src, err := openReadOnly(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close() //nolint:errcheck
+
+	plan, err := planMigration(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "bunny-api-proxy-verify-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck
+
+	copyPath := filepath.Join(dir, "proxy.db")
+	if _, err := src.ExecContext(ctx, "VACUUM INTO ?", copyPath); err != nil {
+		return nil, fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	migrated, err := New(copyPath, func(o *options) { o.skipEncryption = true })
+	if err != nil {
+		return nil, fmt.Errorf("migration failed: %w", err)
+	}
+	defer migrated.Close() //nolint:errcheck
+
+	report := &MigrationReport{Applied: plan, RowCounts: make(map[string]int64)}
+
+	remaining, err := PlanSchema(migrated.db)
+	if err != nil {
+		return nil, err
+	}
+	for _, stmt := range remaining {
+		report.Problems = append(report.Problems, "not applied: "+stmt)
+	}
+
+	problems, err := checkIntegrity(ctx, migrated.db)
+	if err != nil {
+		return nil, err
+	}
+	report.Problems = append(report.Problems, problems...)
+
+	if err := countRows(ctx, migrated.db, report.RowCounts); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkIntegrity runs SQLite's integrity and foreign key checks.
+func checkIntegrity(ctx context.Context, db *sql.DB) ([]string, error) {
+	var problems []string
+
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %w", err)
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			_ = rows.Close() //nolint:errcheck
+			return nil, fmt.Errorf("failed to check integrity: %w", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, "integrity: "+msg)
+		}
+	}
+	_ = rows.Close() //nolint:errcheck
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check integrity: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkID int64
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+		}
+		problems = append(problems, fmt.Sprintf("foreign key: %s row %d references a missing %s row", table, rowID.Int64, parent))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+
+	return problems, nil
+}
+
+// countRows records the number of rows in each table.
+func countRows(ctx context.Context, db *sql.DB, counts map[string]int64) error {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close() //nolint:errcheck
+			return fmt.Errorf("failed to inspect schema: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	_ = rows.Close() //nolint:errcheck
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect schema: %w", err)
+	}
+
+	for _, name := range tables {
+		var n int64
+		// name comes from sqlite_master, not user input
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+name+`"`).Scan(&n); err != nil {
+			return fmt.Errorf("failed to count %s rows: %w", name, err)
+		}
+		counts[name] = n
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// createOldDatabase writes a database with the tokens table as it was before
+// token expiry, and nothing else.
+func createOldDatabase(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`CREATE TABLE tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key_hash TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("failed to create old tokens table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO tokens (key_hash, name) VALUES ('hash', 'old')"); err != nil {
+		t.Fatalf("failed to insert token: %v", err)
+	}
+	return path
+}
+
+func TestPlanSchema(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	plan, err := PlanSchema(db)
+	if err != nil {
+		t.Fatalf("PlanSchema failed: %v", err)
+	}
+	want := []string{migrationsTable}
+	for _, m := range migrations {
+		want = append(want, m.Up...)
+	}
+	if !slices.Equal(plan, want) {
+		t.Errorf("expected an empty database to need the migrations table and every migration, got %d of %d statements", len(plan), len(want))
+	}
+
+	if err := InitSchema(db); err != nil {
+		t.Fatalf("InitSchema failed: %v", err)
+	}
+	if plan, err = PlanSchema(db); err != nil || len(plan) != 0 {
+		t.Errorf("expected no statements after InitSchema, got %v, %v", plan, err)
+	}
+}
+
+func TestSchemaObjectName(t *testing.T) {
+	t.Parallel()
+	seen := make(map[string]bool)
+	for _, stmt := range schemaStatements {
+		name := schemaObjectName(stmt)
+		if name == "" || strings.ContainsAny(name, "( ") || seen[name] {
+			t.Errorf("bad or duplicate object name %q for %s", name, stmt)
+		}
+		seen[name] = true
+	}
+}
+
+func TestPlanMigration(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := createOldDatabase(t)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read database: %v", err)
+	}
+
+	plan, err := PlanMigration(ctx, path)
+	if err != nil {
+		t.Fatalf("PlanMigration failed: %v", err)
+	}
+	joined := strings.Join(plan, "\n")
+	for _, want := range []string{
+		"CREATE TABLE IF NOT EXISTS permissions",
+		"ALTER TABLE tokens ADD COLUMN expires_at",
+		"ALTER TABLE tokens ADD COLUMN tls_fingerprint",
+		"VACUUM",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("plan is missing %q", want)
+		}
+	}
+	if strings.Contains(joined, "CREATE TABLE IF NOT EXISTS tokens") {
+		t.Error("plan should not recreate the existing tokens table")
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read database: %v", err)
+	}
+	if !slices.Equal(before, after) {
+		t.Error("PlanMigration modified the database")
+	}
+
+	// An up-to-date database needs nothing
+	current := filepath.Join(t.TempDir(), "current.db")
+	s, err := New(current)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	_ = s.Close()
+	if plan, err := PlanMigration(ctx, current); err != nil || len(plan) != 0 {
+		t.Errorf("expected an empty plan, got %v, %v", plan, err)
+	}
+
+	if _, err := PlanMigration(ctx, filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("expected an error for a missing database")
+	}
+}
+
+func TestVerifyMigration(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := createOldDatabase(t)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read database: %v", err)
+	}
+
+	report, err := VerifyMigration(ctx, path)
+	if err != nil {
+		t.Fatalf("VerifyMigration failed: %v", err)
+	}
+	if len(report.Applied) == 0 {
+		t.Error("expected statements to be applied to the copy")
+	}
+	if len(report.Problems) != 0 {
+		t.Errorf("expected no problems, got %v", report.Problems)
+	}
+	if report.RowCounts["tokens"] != 1 || report.RowCounts["permissions"] != 0 {
+		t.Errorf("unexpected row counts: %v", report.RowCounts)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read database: %v", err)
+	}
+	if !slices.Equal(before, after) {
+		t.Error("VerifyMigration modified the original database")
+	}
+}
+
+func TestVerifyMigrationReportsForeignKeyProblems(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "proxy.db")
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// A permission for a token that no longer exists
+	if _, err := s.db.Exec("PRAGMA foreign_keys = OFF"); err != nil {
+		t.Fatalf("failed to disable foreign keys: %v", err)
+	}
+	if _, err := s.db.Exec("INSERT INTO permissions (token_id, zone_id, allowed_actions, record_types) VALUES (42, 1, '[]', '[]')"); err != nil {
+		t.Fatalf("failed to insert orphan permission: %v", err)
+	}
+	_ = s.Close()
+
+	report, err := VerifyMigration(context.Background(), path)
+	if err != nil {
+		t.Fatalf("VerifyMigration failed: %v", err)
+	}
+	if len(report.Problems) != 1 || !strings.Contains(report.Problems[0], "permissions") {
+		t.Errorf("expected one foreign key problem, got %v", report.Problems)
+	}
+}
@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// baselineVersion is the first versioned migration. It holds the schema as it
+// was when migrations were introduced; earlier versions were never recorded.
+const baselineVersion = 19
+
+// migrationsTable records the migrations applied to a database.
+const migrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at INTEGER NOT NULL
+)`
+
+// ErrSchemaTooNew is returned when a database was migrated by a newer version
+// of the proxy than the one opening it.
+var ErrSchemaTooNew = errors.New("database schema is newer than this version supports")
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration is one versioned schema change, read from
+// migrations/NNNN_name.up.sql and the matching .down.sql, which reverts it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// migrations lists every migration in version order.
+var migrations = mustLoadMigrations(migrationFiles)
+
+// mustLoadMigrations parses the embedded migration files. The files are part
+// of the binary, so a malformed one is a programming error.
+func mustLoadMigrations(fsys fs.FS) []Migration {
+	m, err := loadMigrations(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// loadMigrations reads NNNN_name.up.sql and NNNN_name.down.sql pairs.
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil { // coverage-ignore: the pattern is constant and valid
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, name := range names {
+		base := path.Base(name)
+		stem, direction, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: name must end in .up.sql or .down.sql", base)
+		}
+		num, label, ok := strings.Cut(stem, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || version <= 0 || label == "" {
+			return nil, fmt.Errorf("migration %s: name must start with a version number and a name", base)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil { // coverage-ignore: the file was just listed
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		} else if m.Name != label {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, label)
+		}
+		if direction == "up" {
+			m.Up = splitStatements(string(data))
+		} else {
+			m.Down = splitStatements(string(data))
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil || m.Down == nil {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+	slices.SortFunc(result, func(a, b Migration) int { return a.Version - b.Version })
+	return result, nil
+}
+
+// splitStatements splits an SQL script into statements, dropping comment
+// lines. A statement ends with a semicolon at the end of a line.
+func splitStatements(script string) []string {
+	statements := []string{}
+	var current []string
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current = append(current, strings.TrimRight(line, " \t\r"))
+		if strings.HasSuffix(trimmed, ";") {
+			stmt := strings.Join(current, "\n")
+			statements = append(statements, strings.TrimSuffix(stmt, ";"))
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, strings.Join(current, "\n"))
+	}
+	return statements
+}
+
+// baselineMigration returns the migration holding the baseline schema.
+func baselineMigration() Migration {
+	return migrations[0]
+}
+
+// appliedVersion returns the newest migration recorded in db, or 0 if none is.
+func appliedVersion(db *sql.DB) (int, error) {
+	exists, err := schemaObjectExists(db, "schema_migrations")
+	if err != nil || !exists {
+		return 0, err
+	}
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// applyMigration runs a migration's statements and records it, atomically.
+func applyMigration(db *sql.DB, m Migration, statements []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.Version, m.Name, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit()
+}
+
+// revertMigration runs a migration's down statements and removes its record,
+// atomically.
+func revertMigration(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin reverting %d_%s: %w", m.Version, m.Name, err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, stmt := range m.Down {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("reverting %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+		return fmt.Errorf("failed to unrecord migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit()
+}
+
+// checkedVersion returns the newest migration recorded in db, failing with
+// ErrSchemaTooNew if it is not one of ms.
+func checkedVersion(db *sql.DB, ms []Migration) (int, error) {
+	applied, err := appliedVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if latest := ms[len(ms)-1].Version; applied > latest {
+		return 0, fmt.Errorf("%w: database is at version %d, this version supports up to %d; run \"migrate down\" with the newer version first",
+			ErrSchemaTooNew, applied, latest)
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the migrations of the database at dbPath newer than
+// target, newest first, and returns the ones it reverted. Run it with the
+// version that applied them before downgrading the proxy. target is a
+// migration version, at least the baseline, or 0 to drop every table.
+func MigrateDown(ctx context.Context, dbPath string, target int) ([]Migration, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db, err := sql.Open("sqlite", dsn(dbPath, DefaultBusyTimeout, "_txlock=immediate"))
+	if err != nil { // coverage-ignore: sql.Open only fails for unknown driver names
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close() //nolint:errcheck
+	db.SetMaxOpenConns(1)
+
+	return migrateDown(ctx, db, migrations, target)
+}
+
+// migrateDown reverts the migrations in ms newer than target.
+func migrateDown(ctx context.Context, db *sql.DB, ms []Migration, target int) ([]Migration, error) {
+	if target != 0 && target < baselineVersion {
+		return nil, fmt.Errorf("invalid target version %d: versions before %d were never recorded; use %d, or 0 for an empty database",
+			target, baselineVersion, baselineVersion)
+	}
+	current, err := checkedVersion(db, ms)
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, errors.New("database has no migration history; start the proxy once to record it")
+	}
+
+	var reverted []Migration
+	for _, m := range slices.Backward(ms) {
+		if m.Version <= target || m.Version > current {
+			continue
+		}
+		if err := revertMigration(ctx, db, m); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, m)
+	}
+	return reverted, nil
+}
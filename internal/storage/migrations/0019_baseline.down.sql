@@ -0,0 +1,22 @@
+-- Drops every table of the baseline schema, children before parents.
+DROP TABLE IF EXISTS provisioned_tokens;
+DROP TABLE IF EXISTS invitations;
+DROP TABLE IF EXISTS token_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS bootstrap;
+DROP TABLE IF EXISTS token_client_certs;
+DROP TABLE IF EXISTS admin_sessions;
+DROP TABLE IF EXISTS request_nonces;
+DROP TABLE IF EXISTS zone_tags;
+DROP TABLE IF EXISTS tag_permissions;
+DROP TABLE IF EXISTS blocklist;
+DROP TABLE IF EXISTS instances;
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS record_meta;
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS tokens;
+DROP TABLE IF EXISTS config;
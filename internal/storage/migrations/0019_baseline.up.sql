@@ -0,0 +1,282 @@
+-- Schema version 19, the schema when versioned migrations were introduced.
+-- Databases created before then are brought up to it table by table, column
+-- by column, and recorded as being at this version.
+
+-- config table: stores master API key hash and configuration
+CREATE TABLE IF NOT EXISTS config (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	master_api_key_hash TEXT NOT NULL
+);
+
+-- tokens table: unified table for both admin tokens and scoped keys
+CREATE TABLE IF NOT EXISTS tokens (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	key_hash TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	expires_at INTEGER NOT NULL DEFAULT 0,
+	pin_tls_fingerprint BOOLEAN NOT NULL DEFAULT FALSE,
+	tls_fingerprint TEXT NOT NULL DEFAULT '',
+	last_used_at INTEGER NOT NULL DEFAULT 0,
+	use_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- Index on key_hash for fast lookups
+CREATE INDEX IF NOT EXISTS idx_tokens_key_hash ON tokens(key_hash);
+
+-- permissions table: stores permissions for each token
+CREATE TABLE IF NOT EXISTS permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_id INTEGER NOT NULL,
+	zone_id INTEGER NOT NULL,
+	resource TEXT NOT NULL DEFAULT 'dnszone',
+	allowed_actions TEXT NOT NULL,
+	record_types TEXT NOT NULL,
+	tag_permission_id INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
+
+-- Index on token_id for fast lookups
+CREATE INDEX IF NOT EXISTS idx_permissions_token_id ON permissions(token_id);
+
+-- webhooks table: registered webhook endpoints.
+-- token_id is NULL for admin-managed webhooks; scoped tokens own theirs.
+CREATE TABLE IF NOT EXISTS webhooks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	token_id INTEGER,
+	zone_ids TEXT NOT NULL DEFAULT '[]',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
+
+-- Index on token_id for per-token webhook quotas
+CREATE INDEX IF NOT EXISTS idx_webhooks_token_id ON webhooks(token_id);
+
+-- webhook_deliveries table: bounded history of delivery attempts per webhook
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL,
+	event_id TEXT NOT NULL,
+	event TEXT NOT NULL,
+	schema_version INTEGER NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	success BOOLEAN NOT NULL DEFAULT FALSE,
+	error TEXT NOT NULL DEFAULT '',
+	duration_ms INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+-- Index on webhook_id for delivery history lookups
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+
+-- outbox table: events awaiting delivery to webhooks and other sinks.
+-- Times used for scheduling are unix seconds so they compare correctly in SQL.
+CREATE TABLE IF NOT EXISTS outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id TEXT NOT NULL UNIQUE,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	delivered_at INTEGER,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for the worker's due-event scan
+CREATE INDEX IF NOT EXISTS idx_outbox_status_next_attempt ON outbox(status, next_attempt_at);
+
+-- record_meta table: proxy-side annotations for bunny.net DNS records
+CREATE TABLE IF NOT EXISTS record_meta (
+	zone_id INTEGER NOT NULL,
+	record_id INTEGER NOT NULL,
+	meta TEXT NOT NULL,
+	updated_by TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (zone_id, record_id)
+);
+
+-- audit_log table: one row per authenticated proxy or admin API request.
+-- token_id is 0 for the master key; rows outlive deleted tokens on purpose.
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_id INTEGER NOT NULL DEFAULT 0,
+	token_name TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL DEFAULT '',
+	zone_id INTEGER NOT NULL DEFAULT 0,
+	record_type TEXT NOT NULL DEFAULT '',
+	method TEXT NOT NULL,
+	path TEXT NOT NULL,
+	status INTEGER NOT NULL,
+	request_id TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL
+);
+
+-- Index for per-token activity lookups, newest first
+CREATE INDEX IF NOT EXISTS idx_audit_log_token_id ON audit_log(token_id, id);
+
+-- Index for retention pruning
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+
+-- Index for per-zone audit lookups, newest first
+CREATE INDEX IF NOT EXISTS idx_audit_log_zone_id ON audit_log(zone_id, id);
+
+-- instances table: proxy processes sharing this database.
+-- Times are unix seconds; last_seen_at is refreshed by a heartbeat.
+CREATE TABLE IF NOT EXISTS instances (
+	id TEXT PRIMARY KEY,
+	hostname TEXT NOT NULL DEFAULT '',
+	version TEXT NOT NULL DEFAULT '',
+	started_at INTEGER NOT NULL,
+	last_seen_at INTEGER NOT NULL
+);
+
+-- Index for reusing a host's ID across restarts
+CREATE INDEX IF NOT EXISTS idx_instances_hostname ON instances(hostname);
+
+-- blocklist table: client networks rejected before authentication.
+-- cidr is the normalized prefix; expires_at is unix seconds, 0 for permanent bans.
+CREATE TABLE IF NOT EXISTS blocklist (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	cidr TEXT NOT NULL UNIQUE,
+	reason TEXT NOT NULL DEFAULT '',
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL DEFAULT 0
+);
+
+-- tag_permissions table: permissions granted on every zone with a tag.
+-- Tag sync materializes them as permissions rows with tag_permission_id set.
+CREATE TABLE IF NOT EXISTS tag_permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_id INTEGER NOT NULL,
+	tag TEXT NOT NULL,
+	allowed_actions TEXT NOT NULL,
+	record_types TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
+
+-- zone_tags table: tags derived for upstream DNS zones, replaced on every sync
+CREATE TABLE IF NOT EXISTS zone_tags (
+	zone_id INTEGER NOT NULL,
+	tag TEXT NOT NULL,
+	domain TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (zone_id, tag)
+);
+
+-- Index for finding the zones with a tag
+CREATE INDEX IF NOT EXISTS idx_zone_tags_tag ON zone_tags(tag);
+
+-- request_nonces table: nonces of replay-protected requests, per token.
+-- token_id is 0 for the master key; seen_at is unix seconds.
+CREATE TABLE IF NOT EXISTS request_nonces (
+	token_id INTEGER NOT NULL,
+	nonce TEXT NOT NULL,
+	seen_at INTEGER NOT NULL,
+	PRIMARY KEY (token_id, nonce)
+);
+
+-- Index for pruning nonces older than the replay window
+CREATE INDEX IF NOT EXISTS idx_request_nonces_seen_at ON request_nonces(seen_at);
+
+-- admin_sessions table: short-lived credentials exchanged for admin tokens,
+-- bound to the client that created them. Times are unix seconds.
+CREATE TABLE IF NOT EXISTS admin_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_id INTEGER NOT NULL,
+	session_hash TEXT NOT NULL UNIQUE,
+	client_ip TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
+
+-- Index for pruning expired sessions
+CREATE INDEX IF NOT EXISTS idx_admin_sessions_expires_at ON admin_sessions(expires_at);
+
+-- token_client_certs table: maps verified client certificates, by subject
+-- common name, to the token they authenticate as. One name per token.
+CREATE TABLE IF NOT EXISTS token_client_certs (
+	token_id INTEGER PRIMARY KEY,
+	common_name TEXT NOT NULL UNIQUE,
+	created_at INTEGER NOT NULL,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
+
+-- bootstrap table: records when the first admin token was created, after
+-- which the master API key stays locked out for good. At most one row.
+CREATE TABLE IF NOT EXISTS bootstrap (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	completed_at INTEGER NOT NULL
+);
+
+-- roles table: named permission sets shared by tokens
+CREATE TABLE IF NOT EXISTS roles (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	description TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL
+);
+
+-- role_permissions table: the permissions a role grants, shaped like permissions
+CREATE TABLE IF NOT EXISTS role_permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	role_id INTEGER NOT NULL,
+	resource TEXT NOT NULL DEFAULT 'dnszone',
+	zone_id INTEGER NOT NULL,
+	allowed_actions TEXT NOT NULL,
+	record_types TEXT NOT NULL,
+	FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- Index on role_id for loading a role's permissions
+CREATE INDEX IF NOT EXISTS idx_role_permissions_role_id ON role_permissions(role_id);
+
+-- token_roles table: the roles each token holds. Tokens get a role's
+-- current permissions when they are loaded, so role changes apply at once.
+CREATE TABLE IF NOT EXISTS token_roles (
+	token_id INTEGER NOT NULL,
+	role_id INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (token_id, role_id),
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE,
+	FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- Index for counting and removing a role's tokens
+CREATE INDEX IF NOT EXISTS idx_token_roles_role_id ON token_roles(role_id);
+
+-- invitations table: one-time links that mint a scoped token with the
+-- given roles. Only the hash of the invitation code is stored. claimed_at
+-- is unix seconds, 0 until claimed; token_id is the token it minted.
+CREATE TABLE IF NOT EXISTS invitations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	invite_hash TEXT NOT NULL UNIQUE,
+	token_name TEXT NOT NULL,
+	role_ids TEXT NOT NULL,
+	token_ttl_seconds INTEGER NOT NULL DEFAULT 0,
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL,
+	claimed_at INTEGER NOT NULL DEFAULT 0,
+	token_id INTEGER,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE SET NULL
+);
+
+-- provisioned_tokens table: tokens created from TOKENS_CONFIG_FILE, the
+-- only ones pruning may delete
+CREATE TABLE IF NOT EXISTS provisioned_tokens (
+	token_id INTEGER PRIMARY KEY,
+	created_at INTEGER NOT NULL,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Values encrypted with the dropped data key can no longer be read. Only
+-- revert this on a database that was never given an encryption key.
+DROP TABLE IF EXISTS encryption_keys;
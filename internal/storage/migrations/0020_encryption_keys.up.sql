@@ -0,0 +1,8 @@
+-- encryption_keys table: the data key that encrypts sensitive columns, itself
+-- encrypted with the key from DATABASE_ENCRYPTION_KEY. At most one row; no
+-- row means the database stores those columns in plaintext.
+CREATE TABLE IF NOT EXISTS encryption_keys (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	wrapped_key TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
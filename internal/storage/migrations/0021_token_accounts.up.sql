@@ -0,0 +1,4 @@
+-- tokens.account names the upstream bunny.net account, configured with
+-- BUNNY_ACCOUNTS, that the token's requests are sent to. Empty means the
+-- account of BUNNY_API_KEY.
+ALTER TABLE tokens ADD COLUMN account TEXT NOT NULL DEFAULT '';
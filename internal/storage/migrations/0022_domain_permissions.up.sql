@@ -0,0 +1,14 @@
+-- domain_permissions table: permissions granted on every zone whose domain
+-- matches a pattern, such as "*.customer1.example.com". They are resolved
+-- against the upstream zone list when a token authenticates.
+CREATE TABLE IF NOT EXISTS domain_permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_id INTEGER NOT NULL,
+	pattern TEXT NOT NULL,
+	allowed_actions TEXT NOT NULL,
+	record_types TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_permissions_token_id ON domain_permissions(token_id);
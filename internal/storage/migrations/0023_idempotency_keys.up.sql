@@ -0,0 +1,16 @@
+-- idempotency_keys table: responses to mutating proxy requests sent with an
+-- Idempotency-Key, per token, replayed for retries. token_id is 0 for the
+-- master key; status is 0 while the first request is in progress.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	token_id INTEGER NOT NULL,
+	key TEXT NOT NULL,
+	fingerprint TEXT NOT NULL,
+	status INTEGER NOT NULL DEFAULT 0,
+	content_type TEXT NOT NULL DEFAULT '',
+	body BLOB,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (token_id, key)
+);
+
+-- Index for pruning keys older than the TTL
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
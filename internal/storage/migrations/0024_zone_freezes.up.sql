@@ -0,0 +1,14 @@
+-- zone_freezes table: windows during which mutating proxy requests to a zone
+-- are rejected, e.g. while an incident is handled. ends_at is 0 for a freeze
+-- that lasts until it is lifted.
+CREATE TABLE IF NOT EXISTS zone_freezes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	zone_id INTEGER NOT NULL,
+	reason TEXT NOT NULL DEFAULT '',
+	created_by TEXT NOT NULL DEFAULT '',
+	starts_at INTEGER NOT NULL,
+	ends_at INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_zone_freezes_zone_id ON zone_freezes(zone_id);
@@ -0,0 +1,4 @@
+-- Tokens whose key_hash holds an Argon2id digest cannot be found by older
+-- versions, so they must be recreated after migrating down.
+DROP INDEX IF EXISTS idx_tokens_key_lookup;
+ALTER TABLE tokens DROP COLUMN key_lookup;
@@ -0,0 +1,6 @@
+-- tokens.key_lookup: the first characters of the SHA-256 key hash, used to
+-- find a token when key_hash holds an Argon2id digest instead of the hash.
+-- It narrows a lookup to a few candidates without revealing the hash.
+ALTER TABLE tokens ADD COLUMN key_lookup TEXT NOT NULL DEFAULT '';
+UPDATE tokens SET key_lookup = substr(key_hash, 1, 8);
+CREATE INDEX IF NOT EXISTS idx_tokens_key_lookup ON tokens(key_lookup);
@@ -0,0 +1,21 @@
+-- jobs table: long-running proxy operations, such as asynchronous zone
+-- imports, run by a background worker and polled by the token that started
+-- them. token_id is 0 for the master key; result holds the operation's JSON
+-- response once it has finished. Times are Unix seconds, 0 when unset.
+CREATE TABLE IF NOT EXISTS jobs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	kind TEXT NOT NULL,
+	zone_id INTEGER NOT NULL DEFAULT 0,
+	token_id INTEGER NOT NULL DEFAULT 0,
+	status TEXT NOT NULL,
+	total INTEGER NOT NULL DEFAULT 0,
+	processed INTEGER NOT NULL DEFAULT 0,
+	result TEXT NOT NULL DEFAULT '',
+	error TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	started_at INTEGER NOT NULL DEFAULT 0,
+	finished_at INTEGER NOT NULL DEFAULT 0
+);
+
+-- Index for pruning finished jobs
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs(finished_at);
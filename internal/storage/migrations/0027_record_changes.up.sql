@@ -0,0 +1,17 @@
+-- record_changes table: before and after snapshots of every DNS record
+-- mutation made through the proxy. before_record and after_record hold the
+-- record as JSON, '' when there is none (before a create, after a delete).
+-- actor is the name of the token that made the change.
+CREATE TABLE IF NOT EXISTS record_changes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	zone_id INTEGER NOT NULL,
+	record_id INTEGER NOT NULL DEFAULT 0,
+	action TEXT NOT NULL,
+	before_record TEXT NOT NULL DEFAULT '',
+	after_record TEXT NOT NULL DEFAULT '',
+	actor TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL
+);
+
+-- Index for a zone's history, newest first
+CREATE INDEX IF NOT EXISTS idx_record_changes_zone_id ON record_changes(zone_id, id);
@@ -0,0 +1 @@
+ALTER TABLE record_changes DROP COLUMN account;
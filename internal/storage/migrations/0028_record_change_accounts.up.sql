@@ -0,0 +1,4 @@
+-- record_changes.account names the upstream bunny.net account the change was
+-- made in, so it can be reverted there. Empty means the account of
+-- BUNNY_API_KEY.
+ALTER TABLE record_changes ADD COLUMN account TEXT NOT NULL DEFAULT '';
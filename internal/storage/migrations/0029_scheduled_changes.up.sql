@@ -0,0 +1,27 @@
+-- scheduled_changes table: record creates and deletes clients asked the
+-- proxy to make at a later time. request holds the record to create as
+-- JSON, '' for a delete; record_id is the record to delete, or the record a
+-- create made once it has run. token_id is 0 for the master key. Times are
+-- Unix seconds, 0 when unset.
+CREATE TABLE IF NOT EXISTS scheduled_changes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	zone_id INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	record_id INTEGER NOT NULL DEFAULT 0,
+	request TEXT NOT NULL DEFAULT '',
+	token_id INTEGER NOT NULL DEFAULT 0,
+	actor TEXT NOT NULL DEFAULT '',
+	account TEXT NOT NULL DEFAULT '',
+	run_at INTEGER NOT NULL,
+	status TEXT NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	started_at INTEGER NOT NULL DEFAULT 0,
+	finished_at INTEGER NOT NULL DEFAULT 0
+);
+
+-- Index for finding changes that are due
+CREATE INDEX IF NOT EXISTS idx_scheduled_changes_status_run_at ON scheduled_changes(status, run_at);
+
+-- Index for a zone's scheduled changes
+CREATE INDEX IF NOT EXISTS idx_scheduled_changes_zone_id ON scheduled_changes(zone_id, run_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ttl_policies;
@@ -0,0 +1,11 @@
+-- ttl_policies table: bounds and defaults for the TTLs of records written
+-- through the proxy. zone_id 0 holds the global policy; a zone's own policy
+-- replaces it. A bound or default of 0 is not set.
+CREATE TABLE IF NOT EXISTS ttl_policies (
+	zone_id INTEGER PRIMARY KEY,
+	min_ttl INTEGER NOT NULL DEFAULT 0,
+	max_ttl INTEGER NOT NULL DEFAULT 0,
+	default_ttl INTEGER NOT NULL DEFAULT 0,
+	updated_by TEXT NOT NULL DEFAULT '',
+	updated_at INTEGER NOT NULL
+);
@@ -0,0 +1,13 @@
+-- protected_records table: record names in a zone that scoped tokens may not
+-- update or delete. name is relative to the zone, lowercase, and empty for the
+-- apex; record_type is a canonical type name, or empty for every type.
+CREATE TABLE IF NOT EXISTS protected_records (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	zone_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	record_type TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL,
+	UNIQUE (zone_id, name, record_type)
+);
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrationsAreConsistent(t *testing.T) {
+	t.Parallel()
+	if migrations[0].Version != baselineVersion {
+		t.Errorf("first migration is %d, want the baseline %d", migrations[0].Version, baselineVersion)
+	}
+	if last := migrations[len(migrations)-1].Version; last != SchemaVersion {
+		t.Errorf("newest migration is %d, but SchemaVersion is %d", last, SchemaVersion)
+	}
+	for i, m := range migrations {
+		if len(m.Up) == 0 || len(m.Down) == 0 {
+			t.Errorf("migration %d_%s has no statements", m.Version, m.Name)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d is out of order", m.Version)
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsMalformedFiles(t *testing.T) {
+	t.Parallel()
+	file := &fstest.MapFile{Data: []byte("SELECT 1;\n")}
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down":  {"migrations/0020_x.up.sql": file},
+		"bad direction": {"migrations/0020_x.sideways.sql": file},
+		"no version":    {"migrations/x.up.sql": file, "migrations/x.down.sql": file},
+		"two names":     {"migrations/0020_x.up.sql": file, "migrations/0020_y.down.sql": file},
+		"zero version":  {"migrations/0000_x.up.sql": file, "migrations/0000_x.down.sql": file},
+		"missing name":  {"migrations/0020_.up.sql": file, "migrations/0020_.down.sql": file},
+	} {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	t.Parallel()
+	got := splitStatements("-- comment;\nCREATE TABLE a (\n\tid INTEGER\n);\n\nDROP TABLE b;\nSELECT 1")
+	want := []string{"CREATE TABLE a (\n\tid INTEGER\n)", "DROP TABLE b", "SELECT 1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("splitStatements() = %q, want %q", got, want)
+	}
+}
+
+// recordedVersions returns the versions in schema_migrations.
+func recordedVersions(t *testing.T, db *sql.DB) []int {
+	t.Helper()
+	rows, err := db.Query("SELECT version FROM schema_migrations ORDER BY version")
+	if err != nil {
+		t.Fatalf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("failed to scan version: %v", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func TestInitSchemaRecordsBaseline(t *testing.T) {
+	t.Parallel()
+	s, err := New(createOldDatabase(t))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Close()
+
+	if got := recordedVersions(t, s.db); len(got) != len(migrations) || got[0] != baselineVersion {
+		t.Errorf("recorded versions = %v, want every migration from the baseline", got)
+	}
+	var expiresAt int64
+	if err := s.db.QueryRow("SELECT expires_at FROM tokens WHERE name = 'old'").Scan(&expiresAt); err != nil {
+		t.Errorf("pre-migration database was not brought up to the baseline: %v", err)
+	}
+}
+
+func TestInitSchemaRejectsNewerDatabase(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "proxy.db")
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if _, err := s.db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'future', 0)", SchemaVersion+1); err != nil {
+		t.Fatalf("failed to record future migration: %v", err)
+	}
+	_ = s.Close()
+
+	if _, err := New(path); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("New() error = %v, want ErrSchemaTooNew", err)
+	}
+	if _, err := MigrateDown(context.Background(), path, baselineVersion); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("MigrateDown() error = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	next := Migration{
+		Version: SchemaVersion + 1,
+		Name:    "notes",
+		Up:      []string{"CREATE TABLE notes (id INTEGER PRIMARY KEY)", "ALTER TABLE tokens ADD COLUMN note TEXT NOT NULL DEFAULT ''"},
+		Down:    []string{"ALTER TABLE tokens DROP COLUMN note", "DROP TABLE notes"},
+	}
+	ms := append(slices.Clone(migrations), next)
+
+	if err := migrateUp(db, migrations); err != nil {
+		t.Fatalf("migrateUp failed: %v", err)
+	}
+	plan, err := planSchema(db, ms)
+	if err != nil || !slices.Equal(plan, next.Up) {
+		t.Errorf("planSchema() = %v, %v; want the pending migration", plan, err)
+	}
+	for range 2 {
+		if err := migrateUp(db, ms); err != nil {
+			t.Fatalf("migrateUp failed: %v", err)
+		}
+	}
+	var want []int
+	for _, m := range ms {
+		want = append(want, m.Version)
+	}
+	if got := recordedVersions(t, db); !slices.Equal(got, want) {
+		t.Errorf("recorded versions = %v, want %v", got, want)
+	}
+
+	if _, err := migrateDown(ctx, db, ms, 5); err == nil {
+		t.Error("expected an error for a target before the baseline")
+	}
+	reverted, err := migrateDown(ctx, db, ms, baselineVersion)
+	if err != nil || len(reverted) != len(ms)-1 || reverted[0].Version != next.Version {
+		t.Fatalf("migrateDown() = %v, %v", reverted, err)
+	}
+	if exists, _ := schemaObjectExists(db, "notes"); exists {
+		t.Error("notes table still exists after migrating down")
+	}
+	if exists, _ := schemaObjectExists(db, "tokens"); !exists {
+		t.Error("baseline tables were dropped")
+	}
+
+	if _, err := migrateDown(ctx, db, ms, 0); err != nil {
+		t.Fatalf("migrateDown to 0 failed: %v", err)
+	}
+	if exists, _ := schemaObjectExists(db, "tokens"); exists {
+		t.Error("tokens table still exists after reverting the baseline")
+	}
+	if got := recordedVersions(t, db); len(got) != 0 {
+		t.Errorf("recorded versions after reverting everything = %v", got)
+	}
+	if _, err := migrateDown(ctx, db, ms, 0); err == nil {
+		t.Error("expected an error for a database without migration history")
+	}
+
+	// Reverted databases migrate up again from scratch
+	if err := migrateUp(db, ms); err != nil {
+		t.Fatalf("migrateUp after reverting failed: %v", err)
+	}
+}
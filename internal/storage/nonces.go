@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RecordNonce records a nonce used by a token; times are stored with second precision.
+// Returns ErrDuplicate if the token already used the nonce.
+func (s *SQLiteStorage) RecordNonce(ctx context.Context, tokenID int64, nonce string, at time.Time) error {
+	if nonce == "" {
+		return fmt.Errorf("nonce must not be empty")
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO request_nonces (token_id, nonce, seen_at) VALUES (?, ?, ?)",
+		tokenID, nonce, at.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to record nonce: %w", err)
+	}
+	return nil
+}
+
+// PruneNonces deletes nonces seen before the cutoff and returns how many were removed.
+func (s *SQLiteStorage) PruneNonces(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM request_nonces WHERE seen_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune nonces: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx, so outbox events can be
+// written inside the same transaction as the records that produced them.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// EnqueueOutboxEvent persists an event for delivery.
+// Returns ErrDuplicate if an event with the same EventID already exists.
+func (s *SQLiteStorage) EnqueueOutboxEvent(ctx context.Context, e *OutboxEvent) (*OutboxEvent, error) {
+	return enqueueOutboxEvent(ctx, s.db, e)
+}
+
+// enqueueOutboxEvent inserts an outbox event using the given executor.
+// Callers writing other records in a transaction should pass the transaction
+// so the event is committed (or rolled back) atomically with them.
+func enqueueOutboxEvent(ctx context.Context, exec execer, e *OutboxEvent) (*OutboxEvent, error) {
+	if e.EventID == "" {
+		return nil, fmt.Errorf("event ID cannot be empty")
+	}
+	if e.Event == "" {
+		return nil, fmt.Errorf("event name cannot be empty")
+	}
+
+	payload := e.Payload
+	if len(payload) == 0 {
+		payload = []byte("null")
+	}
+	nextAttempt := e.NextAttemptAt
+	if nextAttempt.IsZero() {
+		nextAttempt = time.Now()
+	}
+
+	result, err := exec.ExecContext(ctx,
+		"INSERT INTO outbox (event_id, event, payload, status, next_attempt_at) VALUES (?, ?, ?, ?, ?)",
+		e.EventID, e.Event, string(payload), OutboxStatusPending, nextAttempt.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to enqueue outbox event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	e.ID = id
+	e.Payload = payload
+	e.Status = OutboxStatusPending
+	e.NextAttemptAt = time.Unix(nextAttempt.Unix(), 0)
+	return e, nil
+}
+
+// ListDueOutboxEvents returns pending events whose next attempt is at or before now, oldest first.
+// Returns empty slice if none are due.
+func (s *SQLiteStorage) ListDueOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		`SELECT id, event_id, event, payload, status, attempts, next_attempt_at, last_error, created_at
+			FROM outbox WHERE status = ? AND next_attempt_at <= ? ORDER BY id ASC LIMIT ?`,
+		OutboxStatusPending, now.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	events := make([]*OutboxEvent, 0)
+	for rows.Next() {
+		var e OutboxEvent
+		var payload string
+		var nextAttempt int64
+		if err := rows.Scan(&e.ID, &e.EventID, &e.Event, &payload, &e.Status, &e.Attempts,
+			&nextAttempt, &e.LastError, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+		e.Payload = []byte(payload)
+		e.NextAttemptAt = time.Unix(nextAttempt, 0)
+		events = append(events, &e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating outbox rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkOutboxEventDelivered marks an event as delivered.
+// Returns ErrNotFound if the event doesn't exist.
+func (s *SQLiteStorage) MarkOutboxEventDelivered(ctx context.Context, id int64, at time.Time) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE outbox SET status = ?, attempts = attempts + 1, last_error = '', delivered_at = ? WHERE id = ?",
+		OutboxStatusDelivered, at.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event delivered: %w", err)
+	}
+	return requireRowsAffected(result)
+}
+
+// MarkOutboxEventFailed records a failed attempt and schedules the next one.
+// When dead is true the event is moved out of the pending state for good.
+// Returns ErrNotFound if the event doesn't exist.
+func (s *SQLiteStorage) MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	status := OutboxStatusPending
+	if dead {
+		status = OutboxStatusDead
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE outbox SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?",
+		status, lastError, nextAttemptAt.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return requireRowsAffected(result)
+}
+
+// PruneOutboxEvents deletes delivered events delivered before the cutoff.
+// Pending and dead events are kept for inspection.
+func (s *SQLiteStorage) PruneOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM outbox WHERE status = ? AND delivered_at < ?",
+		OutboxStatusDelivered, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// requireRowsAffected returns ErrNotFound if the statement affected no rows.
+func requireRowsAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEnqueueOutboxEvent(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	e, err := s.EnqueueOutboxEvent(ctx, &OutboxEvent{EventID: "evt-1", Event: "record.created", Payload: []byte(`{"a":1}`)})
+	if err != nil {
+		t.Fatalf("EnqueueOutboxEvent failed: %v", err)
+	}
+	if e.ID <= 0 || e.Status != OutboxStatusPending {
+		t.Errorf("unexpected event: %+v", e)
+	}
+
+	if _, err := s.EnqueueOutboxEvent(ctx, &OutboxEvent{EventID: "evt-1", Event: "record.created"}); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("expected ErrDuplicate, got %v", err)
+	}
+	if _, err := s.EnqueueOutboxEvent(ctx, &OutboxEvent{Event: "record.created"}); err == nil {
+		t.Error("expected error for empty event ID")
+	}
+	if _, err := s.EnqueueOutboxEvent(ctx, &OutboxEvent{EventID: "evt-2"}); err == nil {
+		t.Error("expected error for empty event name")
+	}
+}
+
+func TestEnqueueOutboxEvent_RolledBackWithTransaction(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	if _, err := enqueueOutboxEvent(ctx, tx, &OutboxEvent{EventID: "evt-tx", Event: "record.created"}); err != nil {
+		t.Fatalf("enqueueOutboxEvent failed: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	due, err := s.ListDueOutboxEvents(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("ListDueOutboxEvents failed: %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("expected rolled back event to be absent, got %d", len(due))
+	}
+}
+
+func TestOutboxLifecycle(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+	now := time.Now()
+
+	first, _ := s.EnqueueOutboxEvent(ctx, &OutboxEvent{EventID: "a", Event: "zone.created", NextAttemptAt: now})
+	second, _ := s.EnqueueOutboxEvent(ctx, &OutboxEvent{EventID: "b", Event: "zone.deleted", NextAttemptAt: now.Add(time.Hour)})
+
+	due, err := s.ListDueOutboxEvents(ctx, now, 10)
+	if err != nil {
+		t.Fatalf("ListDueOutboxEvents failed: %v", err)
+	}
+	if len(due) != 1 || due[0].EventID != "a" || string(due[0].Payload) != "null" {
+		t.Fatalf("expected only event a to be due, got %+v", due)
+	}
+
+	// Failed attempt reschedules
+	if err := s.MarkOutboxEventFailed(ctx, first.ID, "receiver down", now.Add(time.Minute), false); err != nil {
+		t.Fatalf("MarkOutboxEventFailed failed: %v", err)
+	}
+	due, _ = s.ListDueOutboxEvents(ctx, now.Add(2*time.Minute), 10)
+	if len(due) != 1 || due[0].Attempts != 1 || due[0].LastError != "receiver down" {
+		t.Fatalf("expected rescheduled event with 1 attempt, got %+v", due)
+	}
+
+	// Delivered events are no longer due and can be pruned
+	if err := s.MarkOutboxEventDelivered(ctx, first.ID, now); err != nil {
+		t.Fatalf("MarkOutboxEventDelivered failed: %v", err)
+	}
+	// Dead events are never due again and are kept
+	if err := s.MarkOutboxEventFailed(ctx, second.ID, "gave up", now, true); err != nil {
+		t.Fatalf("MarkOutboxEventFailed failed: %v", err)
+	}
+	due, _ = s.ListDueOutboxEvents(ctx, now.Add(2*time.Hour), 10)
+	if len(due) != 0 {
+		t.Errorf("expected no due events, got %+v", due)
+	}
+
+	pruned, err := s.PruneOutboxEvents(ctx, now.Add(time.Second))
+	if err != nil {
+		t.Fatalf("PruneOutboxEvents failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("expected 1 pruned event, got %d", pruned)
+	}
+
+	var remaining int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM outbox").Scan(&remaining); err != nil {
+		t.Fatalf("failed to count outbox: %v", err)
+	}
+	if remaining != 1 {
+		t.Errorf("expected dead event to remain, got %d rows", remaining)
+	}
+
+	if err := s.MarkOutboxEventDelivered(ctx, 999, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.MarkOutboxEventFailed(ctx, 999, "", now, false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
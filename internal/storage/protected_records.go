@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddProtectedRecord protects a record name and type in a zone.
+// CreatedAt defaults to now; times are stored with second precision.
+// Returns ErrDuplicate if it is already protected.
+func (s *SQLiteStorage) AddProtectedRecord(ctx context.Context, p *ProtectedRecord) (*ProtectedRecord, error) {
+	if p.ZoneID <= 0 {
+		return nil, fmt.Errorf("protected record requires a zone ID")
+	}
+
+	createdAt := p.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO protected_records (zone_id, name, record_type, reason, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		p.ZoneID, p.Name, p.RecordType, p.Reason, p.CreatedBy, createdAt.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to add protected record: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	protected := *p
+	protected.ID = id
+	protected.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	return &protected, nil
+}
+
+// ListProtectedRecords returns the protected records of a zone, or of every
+// zone for zone 0, in creation order.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListProtectedRecords(ctx context.Context, zoneID int64) ([]*ProtectedRecord, error) {
+	query := "SELECT id, zone_id, name, record_type, reason, created_by, created_at FROM protected_records"
+	var args []any
+	if zoneID != 0 {
+		query += " WHERE zone_id = ?"
+		args = append(args, zoneID)
+	}
+	rows, err := s.readDB.QueryContext(ctx, query+" ORDER BY id ASC", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query protected records: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	protected := make([]*ProtectedRecord, 0)
+	for rows.Next() {
+		var p ProtectedRecord
+		var createdAt int64
+		if err := rows.Scan(&p.ID, &p.ZoneID, &p.Name, &p.RecordType, &p.Reason, &p.CreatedBy, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan protected record row: %w", err)
+		}
+		p.CreatedAt = time.Unix(createdAt, 0)
+		protected = append(protected, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating protected records: %w", err)
+	}
+
+	return protected, nil
+}
+
+// DeleteProtectedRecord removes a protection by ID.
+// Returns ErrNotFound if it doesn't exist.
+func (s *SQLiteStorage) DeleteProtectedRecord(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM protected_records WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete protected record: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MarkTokenProvisioned records that a token is managed by the tokens config
+// file. Marking a token twice is not an error.
+func (s *SQLiteStorage) MarkTokenProvisioned(ctx context.Context, tokenID int64) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO provisioned_tokens (token_id, created_at) VALUES (?, ?)",
+		tokenID, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to mark token provisioned: %w", err)
+	}
+	return nil
+}
+
+// ListProvisionedTokenIDs returns the IDs of provisioned tokens that still
+// exist, in ascending order. Returns empty slice if there are none.
+func (s *SQLiteStorage) ListProvisionedTokenIDs(ctx context.Context) ([]int64, error) {
+	rows, err := s.readDB.QueryContext(ctx, "SELECT token_id FROM provisioned_tokens ORDER BY token_id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provisioned tokens: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan provisioned token row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating provisioned tokens: %w", err)
+	}
+	return ids, nil
+}
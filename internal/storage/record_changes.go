@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultRecordChangeListLimit caps ListRecordChanges when the filter sets no limit.
+const defaultRecordChangeListLimit = 100
+
+// AddRecordChange records a record mutation.
+// CreatedAt defaults to now and is stored with second precision.
+func (s *SQLiteStorage) AddRecordChange(ctx context.Context, c *RecordChange) (*RecordChange, error) {
+	if c.ZoneID <= 0 || c.Action == "" {
+		return nil, fmt.Errorf("record change requires a zone ID and action")
+	}
+
+	createdAt := c.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO record_changes (zone_id, record_id, action, before_record, after_record, actor, account, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ZoneID, c.RecordID, c.Action, string(c.Before), string(c.After), c.Actor, c.Account, createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to add record change: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	change := *c
+	change.ID = id
+	change.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	return &change, nil
+}
+
+// ListRecordChanges returns a zone's record changes matching the filter, newest first.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListRecordChanges(ctx context.Context, zoneID int64, filter RecordChangeFilter) ([]*RecordChange, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultRecordChangeListLimit
+	}
+
+	conds := []string{"zone_id = ?"}
+	args := []any{zoneID}
+	if filter.RecordID != 0 {
+		conds = append(conds, "record_id = ?")
+		args = append(args, filter.RecordID)
+	}
+	if filter.BeforeID != 0 {
+		conds = append(conds, "id < ?")
+		args = append(args, filter.BeforeID)
+	}
+	args = append(args, limit)
+
+	rows, err := s.readDB.QueryContext(ctx,
+		`SELECT `+recordChangeColumns+` FROM record_changes
+			WHERE `+strings.Join(conds, " AND ")+` ORDER BY id DESC LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record changes: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	changes := make([]*RecordChange, 0)
+	for rows.Next() {
+		c, err := scanRecordChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record change row: %w", err)
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating record changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetRecordChange retrieves a record change by ID.
+// Returns ErrNotFound if the change does not exist.
+func (s *SQLiteStorage) GetRecordChange(ctx context.Context, id int64) (*RecordChange, error) {
+	row := s.readDB.QueryRowContext(ctx, `SELECT `+recordChangeColumns+` FROM record_changes WHERE id = ?`, id)
+	c, err := scanRecordChange(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get record change: %w", err)
+	}
+	return c, nil
+}
+
+// recordChangeColumns are the record_changes columns read by scanRecordChange.
+const recordChangeColumns = "id, zone_id, record_id, action, before_record, after_record, actor, account, created_at"
+
+// scanRecordChange scans a record_changes row.
+func scanRecordChange(row rowScanner) (*RecordChange, error) {
+	var c RecordChange
+	var before, after string
+	var createdAt int64
+	if err := row.Scan(&c.ID, &c.ZoneID, &c.RecordID, &c.Action, &before, &after, &c.Actor, &c.Account, &createdAt); err != nil {
+		return nil, err
+	}
+	if before != "" {
+		c.Before = []byte(before)
+	}
+	if after != "" {
+		c.After = []byte(after)
+	}
+	c.CreatedAt = time.Unix(createdAt, 0)
+	return &c, nil
+}
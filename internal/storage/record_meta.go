@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// GetRecordMeta retrieves metadata for a record.
+// Returns ErrNotFound if the record has no metadata.
+func (s *SQLiteStorage) GetRecordMeta(ctx context.Context, zoneID, recordID int64) (*RecordMeta, error) {
+	row := s.readDB.QueryRowContext(ctx,
+		"SELECT zone_id, record_id, meta, updated_by, updated_at FROM record_meta WHERE zone_id = ? AND record_id = ?",
+		zoneID, recordID)
+
+	m, err := scanRecordMeta(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get record metadata: %w", err)
+	}
+
+	return m, nil
+}
+
+// ListRecordMeta retrieves metadata for all annotated records in a zone, ordered by record ID.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListRecordMeta(ctx context.Context, zoneID int64) ([]*RecordMeta, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT zone_id, record_id, meta, updated_by, updated_at FROM record_meta WHERE zone_id = ? ORDER BY record_id ASC",
+		zoneID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query record metadata: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	metas := make([]*RecordMeta, 0)
+	for rows.Next() {
+		m, err := scanRecordMeta(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan record metadata row: %w", err)
+		}
+		metas = append(metas, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating record metadata: %w", err)
+	}
+
+	return metas, nil
+}
+
+// SetRecordMeta replaces the metadata for a record.
+// An empty Meta map removes the record's metadata and returns m unchanged.
+func (s *SQLiteStorage) SetRecordMeta(ctx context.Context, m *RecordMeta) (*RecordMeta, error) {
+	if len(m.Meta) == 0 {
+		if err := s.DeleteRecordMeta(ctx, m.ZoneID, m.RecordID); err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	metaJSON, err := json.Marshal(m.Meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal record metadata: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO record_meta (zone_id, record_id, meta, updated_by, updated_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT (zone_id, record_id) DO UPDATE SET
+				meta = excluded.meta, updated_by = excluded.updated_by, updated_at = excluded.updated_at`,
+		m.ZoneID, m.RecordID, string(metaJSON), m.UpdatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set record metadata: %w", err)
+	}
+
+	return s.GetRecordMeta(ctx, m.ZoneID, m.RecordID)
+}
+
+// DeleteRecordMeta removes metadata for a record.
+// Returns ErrNotFound if the record has no metadata.
+func (s *SQLiteStorage) DeleteRecordMeta(ctx context.Context, zoneID, recordID int64) error {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM record_meta WHERE zone_id = ? AND record_id = ?", zoneID, recordID)
+	if err != nil {
+		return fmt.Errorf("failed to delete record metadata: %w", err)
+	}
+	return requireRowsAffected(result)
+}
+
+// DeleteZoneRecordMeta removes metadata for all records in a zone.
+func (s *SQLiteStorage) DeleteZoneRecordMeta(ctx context.Context, zoneID int64) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM record_meta WHERE zone_id = ?", zoneID); err != nil {
+		return fmt.Errorf("failed to delete zone record metadata: %w", err)
+	}
+	return nil
+}
+
+// scanRecordMeta scans a record_meta row and decodes its metadata map.
+func scanRecordMeta(row rowScanner) (*RecordMeta, error) {
+	var m RecordMeta
+	var metaJSON string
+
+	if err := row.Scan(&m.ZoneID, &m.RecordID, &metaJSON, &m.UpdatedBy, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(metaJSON), &m.Meta); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal record metadata: %w", err)
+	}
+
+	return &m, nil
+}
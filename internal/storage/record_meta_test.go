@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRecordMetaLifecycle(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	if _, err := s.GetRecordMeta(ctx, 1, 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	m, err := s.SetRecordMeta(ctx, &RecordMeta{ZoneID: 1, RecordID: 10, Meta: map[string]string{"owner": "team-a"}, UpdatedBy: "ci"})
+	if err != nil {
+		t.Fatalf("SetRecordMeta failed: %v", err)
+	}
+	if m.Meta["owner"] != "team-a" || m.UpdatedBy != "ci" || m.UpdatedAt.IsZero() {
+		t.Errorf("unexpected metadata: %+v", m)
+	}
+
+	// Set replaces the whole map
+	m, err = s.SetRecordMeta(ctx, &RecordMeta{ZoneID: 1, RecordID: 10, Meta: map[string]string{"ticket": "OPS-1"}, UpdatedBy: "admin"})
+	if err != nil {
+		t.Fatalf("SetRecordMeta failed: %v", err)
+	}
+	if len(m.Meta) != 1 || m.Meta["ticket"] != "OPS-1" || m.UpdatedBy != "admin" {
+		t.Errorf("expected replaced metadata, got %+v", m)
+	}
+
+	if _, err := s.SetRecordMeta(ctx, &RecordMeta{ZoneID: 1, RecordID: 11, Meta: map[string]string{"purpose": "mail"}}); err != nil {
+		t.Fatalf("SetRecordMeta failed: %v", err)
+	}
+	if _, err := s.SetRecordMeta(ctx, &RecordMeta{ZoneID: 2, RecordID: 10, Meta: map[string]string{"purpose": "other zone"}}); err != nil {
+		t.Fatalf("SetRecordMeta failed: %v", err)
+	}
+
+	list, err := s.ListRecordMeta(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListRecordMeta failed: %v", err)
+	}
+	if len(list) != 2 || list[0].RecordID != 10 || list[1].RecordID != 11 {
+		t.Errorf("unexpected zone metadata: %+v", list)
+	}
+
+	// Empty map removes the metadata
+	if _, err := s.SetRecordMeta(ctx, &RecordMeta{ZoneID: 1, RecordID: 11}); err != nil {
+		t.Fatalf("SetRecordMeta with empty map failed: %v", err)
+	}
+	if _, err := s.GetRecordMeta(ctx, 1, 11); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected metadata to be removed, got %v", err)
+	}
+
+	if err := s.DeleteRecordMeta(ctx, 1, 10); err != nil {
+		t.Fatalf("DeleteRecordMeta failed: %v", err)
+	}
+	if err := s.DeleteRecordMeta(ctx, 1, 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.DeleteZoneRecordMeta(ctx, 2); err != nil {
+		t.Fatalf("DeleteZoneRecordMeta failed: %v", err)
+	}
+	list, _ = s.ListRecordMeta(ctx, 2)
+	if len(list) != 0 {
+		t.Errorf("expected zone metadata to be removed, got %+v", list)
+	}
+}
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CreateRole creates a role with its permissions in one transaction.
+// Returns ErrDuplicate if a role with the same name exists.
+func (s *SQLiteStorage) CreateRole(ctx context.Context, role *Role) (*Role, error) {
+	name := strings.TrimSpace(role.Name)
+	if name == "" {
+		return nil, fmt.Errorf("role name cannot be empty")
+	}
+
+	createdAt := role.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin role transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO roles (name, description, created_at) VALUES (?, ?, ?)",
+		name, role.Description, createdAt.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to insert role: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	created := &Role{
+		ID:          id,
+		Name:        name,
+		Description: role.Description,
+		Permissions: make([]*Permission, 0, len(role.Permissions)),
+		CreatedAt:   time.Unix(createdAt.Unix(), 0),
+	}
+	for _, p := range role.Permissions {
+		perm, err := addRolePermission(ctx, tx, id, p)
+		if err != nil {
+			return nil, err
+		}
+		created.Permissions = append(created.Permissions, perm)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit role: %w", err)
+	}
+	return created, nil
+}
+
+// GetRole returns a role with its permissions.
+// Returns ErrNotFound if the role doesn't exist.
+func (s *SQLiteStorage) GetRole(ctx context.Context, id int64) (*Role, error) {
+	roles, err := s.queryRoles(ctx, "WHERE r.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, ErrNotFound
+	}
+	return roles[0], nil
+}
+
+// ListRoles returns all roles with their permissions, ordered by name.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListRoles(ctx context.Context) ([]*Role, error) {
+	return s.queryRoles(ctx, "")
+}
+
+// ListTokenRoles returns the roles a token holds, ordered by name.
+// Returns empty slice if it holds none.
+func (s *SQLiteStorage) ListTokenRoles(ctx context.Context, tokenID int64) ([]*Role, error) {
+	return s.queryRoles(ctx, "WHERE r.id IN (SELECT role_id FROM token_roles WHERE token_id = ?)", tokenID)
+}
+
+// DeleteRole deletes a role. Tokens holding it lose its permissions.
+// Returns ErrNotFound if the role doesn't exist.
+func (s *SQLiteStorage) DeleteRole(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM roles WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if n, err := rowsAffected(result); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddRolePermission adds a permission to a role, granting it to every token
+// holding the role. Returns ErrNotFound if the role doesn't exist.
+func (s *SQLiteStorage) AddRolePermission(ctx context.Context, roleID int64, perm *Permission) (*Permission, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin role permission transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM roles WHERE id = ?", roleID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrNotFound
+	}
+
+	created, err := addRolePermission(ctx, tx, roleID, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit role permission: %w", err)
+	}
+	return created, nil
+}
+
+// RemoveRolePermission removes a permission from a role.
+// Returns ErrNotFound if the permission doesn't exist or belongs to another role.
+func (s *SQLiteStorage) RemoveRolePermission(ctx context.Context, roleID, permID int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM role_permissions WHERE id = ? AND role_id = ?", permID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete role permission: %w", err)
+	}
+	if n, err := rowsAffected(result); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AssignRole gives a token a role. Returns ErrNotFound if the token or role
+// doesn't exist and ErrDuplicate if the token already holds the role.
+func (s *SQLiteStorage) AssignRole(ctx context.Context, tokenID, roleID int64) error {
+	var tokens, roles int
+	err := s.readDB.QueryRowContext(ctx,
+		"SELECT (SELECT COUNT(*) FROM tokens WHERE id = ?), (SELECT COUNT(*) FROM roles WHERE id = ?)",
+		tokenID, roleID).Scan(&tokens, &roles)
+	if err != nil {
+		return fmt.Errorf("failed to get token and role: %w", err)
+	}
+	if tokens == 0 || roles == 0 {
+		return ErrNotFound
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO token_roles (token_id, role_id, created_at) VALUES (?, ?, ?)",
+		tokenID, roleID, time.Now().Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to assign role: %w", err)
+	}
+	return nil
+}
+
+// UnassignRole takes a role away from a token.
+// Returns ErrNotFound if the token doesn't hold the role.
+func (s *SQLiteStorage) UnassignRole(ctx context.Context, tokenID, roleID int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM token_roles WHERE token_id = ? AND role_id = ?", tokenID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to unassign role: %w", err)
+	}
+	if n, err := rowsAffected(result); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// queryRoles returns the roles matching a WHERE clause over roles r, ordered
+// by name, with their permissions and token counts.
+func (s *SQLiteStorage) queryRoles(ctx context.Context, where string, args ...any) ([]*Role, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		`SELECT r.id, r.name, r.description, r.created_at,
+			(SELECT COUNT(*) FROM token_roles tr WHERE tr.role_id = r.id)
+		FROM roles r `+where+` ORDER BY r.name ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	roles := make([]*Role, 0)
+	byID := make(map[int64]*Role)
+	for rows.Next() {
+		var r Role
+		var createdAt int64
+		if err := rows.Scan(&r.ID, &r.Name, &r.Description, &createdAt, &r.TokenCount); err != nil {
+			return nil, fmt.Errorf("failed to scan role row: %w", err)
+		}
+		r.CreatedAt = time.Unix(createdAt, 0)
+		r.Permissions = make([]*Permission, 0)
+		roles = append(roles, &r)
+		byID[r.ID] = &r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating roles: %w", err)
+	}
+	if len(roles) == 0 {
+		return roles, nil
+	}
+
+	permRows, err := s.readDB.QueryContext(ctx,
+		`SELECT id, role_id, resource, zone_id, allowed_actions, record_types
+		FROM role_permissions WHERE role_id IN (SELECT r.id FROM roles r `+where+`) ORDER BY id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+	defer permRows.Close() //nolint:errcheck
+
+	for permRows.Next() {
+		var p Permission
+		var allowedActionsJSON, recordTypesJSON string
+		if err := permRows.Scan(&p.ID, &p.RoleID, &p.Resource, &p.ZoneID, &allowedActionsJSON, &recordTypesJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission row: %w", err)
+		}
+		if err := unmarshalStringArray(allowedActionsJSON, &p.AllowedActions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed actions: %w", err)
+		}
+		if err := unmarshalStringArray(recordTypesJSON, &p.RecordTypes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal record types: %w", err)
+		}
+		if r := byID[p.RoleID]; r != nil {
+			r.Permissions = append(r.Permissions, &p)
+		}
+	}
+	if err := permRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating role permissions: %w", err)
+	}
+	return roles, nil
+}
+
+// addRolePermission inserts a role permission within a transaction.
+func addRolePermission(ctx context.Context, tx *sql.Tx, roleID int64, perm *Permission) (*Permission, error) {
+	allowedActionsJSON, recordTypesJSON, err := encodePermission(perm)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO role_permissions (role_id, resource, zone_id, allowed_actions, record_types) VALUES (?, ?, ?, ?, ?)",
+		roleID, perm.Resource, perm.ZoneID, string(allowedActionsJSON), string(recordTypesJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert role permission: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	created := *perm
+	created.ID = id
+	created.TokenID = 0
+	created.RoleID = roleID
+	return &created, nil
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreateScheduledChange records a pending change; times are stored with
+// second precision. CreatedAt defaults to now.
+func (s *SQLiteStorage) CreateScheduledChange(ctx context.Context, c *ScheduledChange) (*ScheduledChange, error) {
+	if c.ZoneID <= 0 || c.RunAt.IsZero() {
+		return nil, fmt.Errorf("scheduled change requires a zone ID and run time")
+	}
+	switch c.Action {
+	case RecordChangeCreate:
+		if len(c.Request) == 0 {
+			return nil, fmt.Errorf("scheduled create requires a record")
+		}
+	case RecordChangeDelete:
+		if c.RecordID <= 0 {
+			return nil, fmt.Errorf("scheduled delete requires a record ID")
+		}
+	default:
+		return nil, fmt.Errorf("invalid scheduled change action %q", c.Action)
+	}
+
+	createdAt := c.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO scheduled_changes (zone_id, action, record_id, request, token_id, actor, account, run_at, status, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.ZoneID, c.Action, c.RecordID, string(c.Request), c.TokenID, c.Actor, c.Account,
+		c.RunAt.Unix(), ScheduledChangePending, createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled change: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	return &ScheduledChange{
+		ID:        id,
+		ZoneID:    c.ZoneID,
+		Action:    c.Action,
+		RecordID:  c.RecordID,
+		Request:   c.Request,
+		TokenID:   c.TokenID,
+		Actor:     c.Actor,
+		Account:   c.Account,
+		RunAt:     time.Unix(c.RunAt.Unix(), 0),
+		Status:    ScheduledChangePending,
+		CreatedAt: time.Unix(createdAt.Unix(), 0),
+	}, nil
+}
+
+// GetScheduledChange retrieves a scheduled change by ID.
+// Returns ErrNotFound if the change does not exist.
+func (s *SQLiteStorage) GetScheduledChange(ctx context.Context, id int64) (*ScheduledChange, error) {
+	row := s.readDB.QueryRowContext(ctx,
+		`SELECT `+scheduledChangeColumns+` FROM scheduled_changes WHERE id = ?`, id)
+	c, err := scanScheduledChange(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get scheduled change: %w", err)
+	}
+	return c, nil
+}
+
+// ListScheduledChanges returns a zone's scheduled changes with the given
+// status, or all of them if status is empty, in the order they run.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListScheduledChanges(ctx context.Context, zoneID int64, status string) ([]*ScheduledChange, error) {
+	query := `SELECT ` + scheduledChangeColumns + ` FROM scheduled_changes WHERE zone_id = ?`
+	args := []any{zoneID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	return s.queryScheduledChanges(ctx, query+` ORDER BY run_at, id`, args...)
+}
+
+// ListDueScheduledChanges returns pending changes due at or before at, in
+// the order they run.
+func (s *SQLiteStorage) ListDueScheduledChanges(ctx context.Context, at time.Time) ([]*ScheduledChange, error) {
+	return s.queryScheduledChanges(ctx,
+		`SELECT `+scheduledChangeColumns+` FROM scheduled_changes
+			WHERE status = ? AND run_at <= ? ORDER BY run_at, id`,
+		ScheduledChangePending, at.Unix())
+}
+
+// queryScheduledChanges runs a query selecting scheduledChangeColumns.
+func (s *SQLiteStorage) queryScheduledChanges(ctx context.Context, query string, args ...any) ([]*ScheduledChange, error) {
+	rows, err := s.readDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled changes: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	changes := make([]*ScheduledChange, 0)
+	for rows.Next() {
+		c, err := scanScheduledChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled change row: %w", err)
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scheduled changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// StartScheduledChange marks a pending change as running.
+// Returns ErrNotFound if the change does not exist or is not pending.
+func (s *SQLiteStorage) StartScheduledChange(ctx context.Context, id int64, at time.Time) error {
+	return s.updateScheduledChange(ctx, "start",
+		"UPDATE scheduled_changes SET status = ?, started_at = ? WHERE id = ? AND status = ?",
+		ScheduledChangeRunning, at.Unix(), id, ScheduledChangePending)
+}
+
+// FinishScheduledChange records the outcome of a running change. status
+// must be ScheduledChangeSucceeded or ScheduledChangeFailed; recordID is the
+// record a create made, and 0 leaves the stored record ID unchanged.
+// Returns ErrNotFound if the change does not exist or is not running.
+func (s *SQLiteStorage) FinishScheduledChange(ctx context.Context, id int64, status string, recordID int64, errMsg string, at time.Time) error {
+	if status != ScheduledChangeSucceeded && status != ScheduledChangeFailed {
+		return fmt.Errorf("invalid scheduled change status %q", status)
+	}
+	return s.updateScheduledChange(ctx, "finish",
+		`UPDATE scheduled_changes SET status = ?, record_id = CASE WHEN ? > 0 THEN ? ELSE record_id END,
+			error = ?, finished_at = ? WHERE id = ? AND status = ?`,
+		status, recordID, recordID, errMsg, at.Unix(), id, ScheduledChangeRunning)
+}
+
+// CancelScheduledChange marks a pending change as cancelled.
+// Returns ErrNotFound if the change does not exist or is not pending.
+func (s *SQLiteStorage) CancelScheduledChange(ctx context.Context, id int64, at time.Time) error {
+	return s.updateScheduledChange(ctx, "cancel",
+		"UPDATE scheduled_changes SET status = ?, finished_at = ? WHERE id = ? AND status = ?",
+		ScheduledChangeCancelled, at.Unix(), id, ScheduledChangePending)
+}
+
+// updateScheduledChange runs an update of one scheduled change and reports
+// ErrNotFound when it matched no row.
+func (s *SQLiteStorage) updateScheduledChange(ctx context.Context, op, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s scheduled change: %w", op, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FailUnfinishedScheduledChanges marks changes that started running before
+// the cutoff and never finished as failed with the given message, and
+// returns how many were marked. It is used at startup for changes whose
+// worker stopped while making them.
+func (s *SQLiteStorage) FailUnfinishedScheduledChanges(ctx context.Context, before time.Time, message string) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE scheduled_changes SET status = ?, error = ?, finished_at = ? WHERE status = ? AND started_at < ?",
+		ScheduledChangeFailed, message, time.Now().Unix(), ScheduledChangeRunning, before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail unfinished scheduled changes: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneScheduledChanges deletes changes that finished or were cancelled
+// before the cutoff and returns how many were removed.
+func (s *SQLiteStorage) PruneScheduledChanges(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM scheduled_changes WHERE finished_at > 0 AND finished_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune scheduled changes: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scheduledChangeColumns are the scheduled_changes columns read by scanScheduledChange.
+const scheduledChangeColumns = "id, zone_id, action, record_id, request, token_id, actor, account, run_at, status, error, created_at, started_at, finished_at"
+
+// scanScheduledChange scans a scheduled_changes row.
+func scanScheduledChange(row rowScanner) (*ScheduledChange, error) {
+	var c ScheduledChange
+	var request string
+	var runAt, createdAt, startedAt, finishedAt int64
+	if err := row.Scan(&c.ID, &c.ZoneID, &c.Action, &c.RecordID, &request, &c.TokenID, &c.Actor, &c.Account,
+		&runAt, &c.Status, &c.Error, &createdAt, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	if request != "" {
+		c.Request = []byte(request)
+	}
+	c.RunAt = time.Unix(runAt, 0)
+	c.CreatedAt = time.Unix(createdAt, 0)
+	c.StartedAt = unixOrZero(startedAt)
+	c.FinishedAt = unixOrZero(finishedAt)
+	return &c, nil
+}
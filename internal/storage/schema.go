@@ -3,69 +3,170 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
-// SchemaVersion is the current version of the database schema.
-// Update this when making schema changes.
-const SchemaVersion = 2
+// SchemaVersion is the current version of the database schema, the version
+// of the newest migration in migrations/. Add a migration to change it.
+const SchemaVersion = 31
 
-// InitSchema creates all required tables and indexes.
-// This is idempotent - safe to call multiple times.
+// schemaStatements creates all tables and indexes of the baseline schema.
+// Each creates one named object, which PlanSchema uses to tell whether it
+// already exists in a database that predates versioned migrations.
+var schemaStatements = baselineMigration().Up
+
+// addedColumns are columns added to tables after they were first released.
+// CREATE TABLE IF NOT EXISTS leaves existing tables alone, so these are added
+// separately. New tables get them from schemaStatements.
+var addedColumns = []struct{ table, column, definition string }{
+	// tokens.expires_at: unix seconds, 0 for tokens that never expire
+	{"tokens", "expires_at", "INTEGER NOT NULL DEFAULT 0"},
+	// tokens.tls_fingerprint: pinned on first use, '' until then
+	{"tokens", "pin_tls_fingerprint", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	{"tokens", "tls_fingerprint", "TEXT NOT NULL DEFAULT ''"},
+	// tokens.last_used_at: unix seconds, 0 for tokens never used
+	{"tokens", "last_used_at", "INTEGER NOT NULL DEFAULT 0"},
+	{"tokens", "use_count", "INTEGER NOT NULL DEFAULT 0"},
+	// permissions.resource: what zone_id refers to; existing rows are DNS zones
+	{"permissions", "resource", "TEXT NOT NULL DEFAULT 'dnszone'"},
+	// permissions.tag_permission_id: 0 for permissions added directly
+	{"permissions", "tag_permission_id", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// InitSchema creates or upgrades the schema by applying every pending
+// migration, each in its own transaction. A database that predates
+// versioned migrations is first brought up to the baseline table by table
+// and recorded as being at it. This is idempotent - safe to call multiple
+// times. Returns ErrSchemaTooNew if a newer version migrated the database.
 func InitSchema(db *sql.DB) error {
+	return migrateUp(db, migrations)
+}
+
+// migrateUp applies the migrations in ms that db has not recorded yet.
+func migrateUp(db *sql.DB, ms []Migration) error {
 	// Enable foreign key constraints
 	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		return fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
-
-	// Execute all DDL statements
-	ddlStatements := []string{
-		// config table: stores master API key hash and configuration
-		`CREATE TABLE IF NOT EXISTS config (
-			id INTEGER PRIMARY KEY CHECK (id = 1),
-			master_api_key_hash TEXT NOT NULL
-		)`,
-
-		// tokens table: unified table for both admin tokens and scoped keys
-		`CREATE TABLE IF NOT EXISTS tokens (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			key_hash TEXT NOT NULL UNIQUE,
-			name TEXT NOT NULL,
-			is_admin BOOLEAN NOT NULL DEFAULT FALSE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// Index on key_hash for fast lookups
-		`CREATE INDEX IF NOT EXISTS idx_tokens_key_hash ON tokens(key_hash)`,
-
-		// permissions table: stores permissions for each token
-		`CREATE TABLE IF NOT EXISTS permissions (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			token_id INTEGER NOT NULL,
-			zone_id INTEGER NOT NULL,
-			allowed_actions TEXT NOT NULL,
-			record_types TEXT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (token_id) REFERENCES tokens(id) ON DELETE CASCADE
-		)`,
-
-		// Index on token_id for fast lookups
-		`CREATE INDEX IF NOT EXISTS idx_permissions_token_id ON permissions(token_id)`,
+	if _, err := db.Exec(migrationsTable); err != nil {
+		return fmt.Errorf("failed to execute DDL: %w", err)
 	}
 
-	// Execute each DDL statement
-	for _, stmt := range ddlStatements {
-		if _, err := db.Exec(stmt); err != nil {
-			return fmt.Errorf("failed to execute DDL: %w", err)
+	applied, err := checkedVersion(db, ms)
+	if err != nil {
+		return err
+	}
+	if applied < baselineVersion {
+		plan, err := planBaseline(db)
+		if err != nil {
+			return err
+		}
+		if err := applyMigration(db, ms[0], plan); err != nil {
+			return err
+		}
+	}
+	for _, m := range ms {
+		if m.Version > max(applied, baselineVersion) {
+			if err := applyMigration(db, m, m.Up); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
-// MigrateSchema checks current schema version and applies migrations.
-// For MVP, we only have v2. Future versions will add migration logic.
+// PlanSchema returns the statements InitSchema would run against db, in
+// order: the migrations table if it is missing, the baseline tables,
+// indexes and columns a pre-migration database lacks, then the statements
+// of each pending migration. It does not modify the database.
+func PlanSchema(db *sql.DB) ([]string, error) {
+	return planSchema(db, migrations)
+}
+
+// planSchema lists the statements migrateUp would run.
+func planSchema(db *sql.DB, ms []Migration) ([]string, error) {
+	var plan []string
+	exists, err := schemaObjectExists(db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		plan = append(plan, migrationsTable)
+	}
+
+	applied, err := checkedVersion(db, ms)
+	if err != nil {
+		return nil, err
+	}
+	if applied < baselineVersion {
+		baseline, err := planBaseline(db)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, baseline...)
+	}
+	for _, m := range ms {
+		if m.Version > max(applied, baselineVersion) {
+			plan = append(plan, m.Up...)
+		}
+	}
+
+	return plan, nil
+}
+
+// planBaseline returns the statements that bring db up to the baseline
+// schema: tables and indexes that don't exist yet, then missing columns.
+func planBaseline(db *sql.DB) ([]string, error) {
+	var plan []string
+	created := make(map[string]bool)
+	for _, stmt := range schemaStatements {
+		name := schemaObjectName(stmt)
+		exists, err := schemaObjectExists(db, name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			plan = append(plan, stmt)
+			created[name] = true
+		}
+	}
+
+	for _, c := range addedColumns {
+		if created[c.table] {
+			continue
+		}
+		var count int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.column).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s table: %w", c.table, err)
+		}
+		if count == 0 {
+			plan = append(plan, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition))
+		}
+	}
+
+	return plan, nil
+}
+
+// schemaObjectName returns the table or index a schema statement creates.
+func schemaObjectName(stmt string) string {
+	fields := strings.Fields(stmt)
+	// CREATE TABLE|INDEX IF NOT EXISTS <name> ...
+	name, _, _ := strings.Cut(fields[5], "(")
+	return name
+}
+
+// schemaObjectExists reports whether a table or index exists.
+func schemaObjectExists(db *sql.DB, name string) (bool, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = ?", name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	return count > 0, nil
+}
+
+// MigrateSchema applies pending migrations. It is the same as InitSchema.
 func MigrateSchema(db *sql.DB) error {
-	// For MVP, simply initialize the schema
-	// Future versions can add version tracking and incremental migrations here
 	return InitSchema(db)
 }
@@ -23,7 +23,7 @@ func TestInitSchema(t *testing.T) {
 	}
 
 	// Verify all tables exist
-	tables := []string{"config", "tokens", "permissions"}
+	tables := []string{"config", "tokens", "permissions", "webhooks", "webhook_deliveries", "outbox", "record_meta", "audit_log"}
 	for _, table := range tables {
 		query := "SELECT name FROM sqlite_master WHERE type='table' AND name=?"
 		var name string
@@ -36,6 +36,9 @@ func TestInitSchema(t *testing.T) {
 	indexes := []string{
 		"idx_tokens_key_hash",
 		"idx_permissions_token_id",
+		"idx_webhooks_token_id",
+		"idx_webhook_deliveries_webhook_id",
+		"idx_outbox_status_next_attempt",
 	}
 	for _, idx := range indexes {
 		query := "SELECT name FROM sqlite_master WHERE type='index' AND name=?"
@@ -255,7 +258,7 @@ func TestTokensTableStructure(t *testing.T) {
 	}
 
 	// Verify required columns exist
-	requiredColumns := []string{"id", "key_hash", "name", "is_admin", "created_at"}
+	requiredColumns := []string{"id", "key_hash", "name", "is_admin", "created_at", "expires_at", "pin_tls_fingerprint", "tls_fingerprint", "last_used_at", "use_count"}
 	for _, col := range requiredColumns {
 		if !columns[col] {
 			t.Errorf("tokens table missing column: %s", col)
@@ -263,6 +266,55 @@ func TestTokensTableStructure(t *testing.T) {
 	}
 }
 
+// TestInitSchemaAddsTokenExpiry verifies databases created before token expiry gain the column.
+func TestInitSchemaAddsTokenExpiry(t *testing.T) {
+	t.Parallel()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// The tokens table as it was before expires_at existed
+	if _, err := db.Exec(`CREATE TABLE tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key_hash TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("failed to create old tokens table: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO tokens (key_hash, name) VALUES ('hash', 'old')"); err != nil {
+		t.Fatalf("failed to insert token: %v", err)
+	}
+
+	// Running twice must be a no-op the second time
+	for range 2 {
+		if err := InitSchema(db); err != nil {
+			t.Fatalf("InitSchema failed: %v", err)
+		}
+	}
+
+	var expiresAt, lastUsedAt, useCount int64
+	var pin bool
+	var fingerprint string
+	err = db.QueryRow("SELECT expires_at, pin_tls_fingerprint, tls_fingerprint, last_used_at, use_count FROM tokens WHERE name = 'old'").
+		Scan(&expiresAt, &pin, &fingerprint, &lastUsedAt, &useCount)
+	if err != nil {
+		t.Fatalf("failed to read added columns: %v", err)
+	}
+	if expiresAt != 0 {
+		t.Errorf("expected existing token to never expire, got %d", expiresAt)
+	}
+	if pin || fingerprint != "" {
+		t.Errorf("expected existing token not to be pinned, got %v %q", pin, fingerprint)
+	}
+	if lastUsedAt != 0 || useCount != 0 {
+		t.Errorf("expected existing token to be unused, got %d %d", lastUsedAt, useCount)
+	}
+}
+
 // TestPermissionsTableStructure verifies the permissions table has correct schema.
 func TestPermissionsTableStructure(t *testing.T) {
 	t.Parallel()
@@ -299,7 +351,7 @@ func TestPermissionsTableStructure(t *testing.T) {
 	}
 
 	// Verify required columns exist
-	requiredColumns := []string{"id", "token_id", "zone_id", "allowed_actions", "record_types", "created_at"}
+	requiredColumns := []string{"id", "token_id", "resource", "zone_id", "allowed_actions", "record_types", "tag_permission_id", "created_at"}
 	for _, col := range requiredColumns {
 		if !columns[col] {
 			t.Errorf("permissions table missing column: %s", col)
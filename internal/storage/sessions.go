@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreateAdminSession stores a session for a token under the hash of its credential.
+// CreatedAt defaults to now; times are stored with second precision.
+// Returns ErrDuplicate if the hash is already in use.
+func (s *SQLiteStorage) CreateAdminSession(ctx context.Context, sess *AdminSession, sessionHash string) (*AdminSession, error) {
+	if sessionHash == "" {
+		return nil, fmt.Errorf("session hash must not be empty")
+	}
+
+	createdAt := sess.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO admin_sessions (token_id, session_hash, client_ip, user_agent, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		sess.TokenID, sessionHash, sess.ClientIP, sess.UserAgent, createdAt.Unix(), sess.ExpiresAt.Unix())
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("failed to create admin session: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	created := *sess
+	created.ID = id
+	created.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	created.ExpiresAt = time.Unix(sess.ExpiresAt.Unix(), 0)
+	return &created, nil
+}
+
+// GetAdminSessionByHash returns the session with the given credential hash,
+// including an expired one not yet pruned.
+// Returns ErrNotFound if there is none.
+func (s *SQLiteStorage) GetAdminSessionByHash(ctx context.Context, sessionHash string) (*AdminSession, error) {
+	var sess AdminSession
+	var createdAt, expiresAt int64
+	err := s.readDB.QueryRowContext(ctx,
+		`SELECT id, token_id, client_ip, user_agent, created_at, expires_at
+		FROM admin_sessions WHERE session_hash = ?`, sessionHash).
+		Scan(&sess.ID, &sess.TokenID, &sess.ClientIP, &sess.UserAgent, &createdAt, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get admin session: %w", err)
+	}
+	sess.CreatedAt = time.Unix(createdAt, 0)
+	sess.ExpiresAt = time.Unix(expiresAt, 0)
+	return &sess, nil
+}
+
+// DeleteAdminSession removes a session by ID.
+// Returns ErrNotFound if the session doesn't exist.
+func (s *SQLiteStorage) DeleteAdminSession(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM admin_sessions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete admin session: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PruneAdminSessions deletes sessions that expired before the cutoff and
+// returns how many were removed.
+func (s *SQLiteStorage) PruneAdminSessions(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM admin_sessions WHERE expires_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune admin sessions: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -12,6 +12,7 @@ package storage
 
 import (
 	"context"
+	"time"
 )
 
 // TokenStore defines the interface for token-related operations (admin and scoped tokens).
@@ -22,11 +23,21 @@ type TokenStore interface {
 	// Returns ErrDuplicate if a token with this hash already exists.
 	CreateToken(ctx context.Context, name string, isAdmin bool, keyHash string) (*Token, error)
 
+	// CreateKeyToken creates a new token for a key of the form <id>.<secret>
+	// with the hash of its secret. GetTokenByHash does not find such tokens.
+	// Returns ErrDuplicate if a token with this hash already exists.
+	CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*Token, error)
+
 	// GetTokenByHash retrieves a token by its hash.
 	// This is used during authentication to look up the token.
 	// Returns ErrNotFound if the hash doesn't exist.
 	GetTokenByHash(ctx context.Context, keyHash string) (*Token, error)
 
+	// GetTokenByKey retrieves a token by ID if secretHash, the hash of the
+	// secret in its key, matches the stored hash.
+	// Returns ErrNotFound if the token doesn't exist or the hash doesn't match.
+	GetTokenByKey(ctx context.Context, id int64, secretHash string) (*Token, error)
+
 	// GetTokenByID retrieves a token by ID.
 	// This is used in the admin UI to view token details.
 	// Returns ErrNotFound if the token doesn't exist.
@@ -41,11 +52,514 @@ type TokenStore interface {
 	// Returns ErrNotFound if the token doesn't exist.
 	DeleteToken(ctx context.Context, id int64) error
 
+	// SetTokenExpiry sets when a token stops working; a zero time means never.
+	// Returns ErrNotFound if the token doesn't exist.
+	SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error
+
+	// SetTokenPinning enables or disables TLS fingerprint pinning for a token
+	// and forgets any pinned fingerprint. Returns ErrNotFound if the token doesn't exist.
+	SetTokenPinning(ctx context.Context, id int64, pin bool) error
+
+	// SetTokenAccount sets the upstream bunny.net account a token's requests
+	// are sent to; empty means the default account.
+	// Returns ErrNotFound if the token doesn't exist.
+	SetTokenAccount(ctx context.Context, id int64, account string) error
+
+	// PinTokenFingerprint records the fingerprint if none is pinned yet and
+	// returns the pinned fingerprint. Returns ErrNotFound if the token doesn't exist.
+	PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error)
+
+	// RecordTokenUsage adds batched use counts and last used times to tokens.
+	// Tokens that no longer exist are skipped.
+	RecordTokenUsage(ctx context.Context, usage []TokenUsage) error
+
 	// HasAnyAdminToken checks if there are any admin tokens.
 	// Returns true if at least one admin token exists.
 	HasAnyAdminToken(ctx context.Context) (bool, error)
 }
 
+// WebhookStore defines the interface for webhook registrations and delivery history.
+type WebhookStore interface {
+	// CreateWebhook registers a new webhook endpoint.
+	// Returns the webhook with its ID populated.
+	CreateWebhook(ctx context.Context, hook *Webhook) (*Webhook, error)
+
+	// GetWebhook retrieves a webhook by ID.
+	// Returns ErrNotFound if the webhook doesn't exist.
+	GetWebhook(ctx context.Context, id int64) (*Webhook, error)
+
+	// ListWebhooks retrieves all webhooks in creation order.
+	// Returns empty slice if no webhooks exist (not an error).
+	ListWebhooks(ctx context.Context) ([]*Webhook, error)
+
+	// CountWebhooksByToken returns the number of webhooks owned by a token.
+	CountWebhooksByToken(ctx context.Context, tokenID int64) (int, error)
+
+	// DeleteWebhook deletes a webhook by ID.
+	// Also cascades delete its delivery history.
+	// Returns ErrNotFound if the webhook doesn't exist.
+	DeleteWebhook(ctx context.Context, id int64) error
+
+	// RecordWebhookDelivery stores a delivery attempt.
+	// Older attempts beyond the per-webhook history limit are pruned.
+	RecordWebhookDelivery(ctx context.Context, d *WebhookDelivery) (*WebhookDelivery, error)
+
+	// ListWebhookDeliveries retrieves the most recent delivery attempts for a webhook, newest first.
+	// Returns empty slice if there are none (not an error).
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]*WebhookDelivery, error)
+}
+
+// OutboxStore defines the interface for the transactional event outbox.
+type OutboxStore interface {
+	// EnqueueOutboxEvent persists an event for delivery.
+	// Returns ErrDuplicate if an event with the same EventID already exists.
+	EnqueueOutboxEvent(ctx context.Context, e *OutboxEvent) (*OutboxEvent, error)
+
+	// ListDueOutboxEvents returns pending events whose next attempt is at or before now, oldest first.
+	ListDueOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*OutboxEvent, error)
+
+	// MarkOutboxEventDelivered marks an event as delivered.
+	MarkOutboxEventDelivered(ctx context.Context, id int64, at time.Time) error
+
+	// MarkOutboxEventFailed records a failed attempt and schedules the next one.
+	// When dead is true the event is not retried again.
+	MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error
+
+	// PruneOutboxEvents deletes delivered events delivered before the cutoff.
+	// Returns the number of events removed.
+	PruneOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AuditStore defines operations for the request audit log.
+type AuditStore interface {
+	// AppendAuditEntry records an audit entry.
+	AppendAuditEntry(ctx context.Context, e *AuditEntry) (*AuditEntry, error)
+
+	// AppendAuditEntryWithEvents records an audit entry and enqueues outbox
+	// events in one transaction.
+	AppendAuditEntryWithEvents(ctx context.Context, e *AuditEntry, events []*OutboxEvent) (*AuditEntry, error)
+
+	// ListAuditEntries returns entries matching the filter, newest first.
+	// Returns empty slice if there are none (not an error).
+	ListAuditEntries(ctx context.Context, filter AuditFilter) ([]*AuditEntry, error)
+
+	// PruneAuditEntries deletes entries created before the cutoff and returns how many were removed.
+	PruneAuditEntries(ctx context.Context, before time.Time) (int64, error)
+
+	// ListHotZones returns the zones with the most entries since the cutoff, busiest first.
+	// Returns empty slice if there are none (not an error).
+	ListHotZones(ctx context.Context, since time.Time, limit int) ([]int64, error)
+}
+
+// MaintenanceStore defines database housekeeping operations.
+type MaintenanceStore interface {
+	// DBStats returns the database size and page usage.
+	DBStats(ctx context.Context) (*DBStats, error)
+
+	// IncrementalVacuum returns up to pages free pages to the filesystem.
+	// A non-positive pages value reclaims all free pages.
+	IncrementalVacuum(ctx context.Context, pages int) error
+
+	// OpenConnections returns the number of established database connections.
+	OpenConnections() int
+}
+
+// InstanceStore defines the registry of proxy instances sharing the database.
+type InstanceStore interface {
+	// GetInstanceByHostname retrieves the most recently seen instance on a host.
+	// Returns ErrNotFound if no instance has registered from that host.
+	GetInstanceByHostname(ctx context.Context, hostname string) (*Instance, error)
+
+	// UpsertInstance registers an instance, replacing any existing row with the same ID.
+	UpsertInstance(ctx context.Context, inst *Instance) error
+
+	// TouchInstance updates an instance's last seen time.
+	// Returns ErrNotFound if the instance is not registered.
+	TouchInstance(ctx context.Context, id string, at time.Time) error
+
+	// ListInstances returns all registered instances, most recently seen first.
+	// Returns empty slice if there are none (not an error).
+	ListInstances(ctx context.Context) ([]*Instance, error)
+
+	// PruneInstances deletes instances last seen before the cutoff and returns how many were removed.
+	PruneInstances(ctx context.Context, before time.Time) (int64, error)
+}
+
+// BlocklistStore defines the client IP blocklist.
+type BlocklistStore interface {
+	// AddBlocklistEntry adds a blocked network.
+	// Returns ErrDuplicate if the network is already blocked.
+	AddBlocklistEntry(ctx context.Context, e *BlocklistEntry) (*BlocklistEntry, error)
+
+	// ListBlocklistEntries returns all entries, including expired ones not yet pruned, in creation order.
+	// Returns empty slice if there are none (not an error).
+	ListBlocklistEntries(ctx context.Context) ([]*BlocklistEntry, error)
+
+	// DeleteBlocklistEntry removes an entry by ID.
+	// Returns ErrNotFound if the entry doesn't exist.
+	DeleteBlocklistEntry(ctx context.Context, id int64) error
+
+	// PruneBlocklistEntries deletes temporary entries that expired before the cutoff
+	// and returns how many were removed.
+	PruneBlocklistEntries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ZoneFreezeStore defines change freeze windows for zones.
+type ZoneFreezeStore interface {
+	// AddZoneFreeze adds a freeze window.
+	AddZoneFreeze(ctx context.Context, f *ZoneFreeze) (*ZoneFreeze, error)
+
+	// ListZoneFreezes returns all freezes, including ended ones not yet pruned, in creation order.
+	// Returns empty slice if there are none (not an error).
+	ListZoneFreezes(ctx context.Context) ([]*ZoneFreeze, error)
+
+	// DeleteZoneFreeze removes a freeze by ID, lifting it.
+	// Returns ErrNotFound if the freeze doesn't exist.
+	DeleteZoneFreeze(ctx context.Context, id int64) error
+
+	// PruneZoneFreezes deletes freezes that ended before the cutoff
+	// and returns how many were removed.
+	PruneZoneFreezes(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TTLPolicyStore defines the global and per-zone record TTL policies.
+type TTLPolicyStore interface {
+	// SetTTLPolicy creates or replaces the policy of a zone, or the global
+	// policy for zone 0.
+	SetTTLPolicy(ctx context.Context, p *TTLPolicy) (*TTLPolicy, error)
+
+	// ListTTLPolicies returns all policies, the global one first, then by zone ID.
+	// Returns empty slice if there are none (not an error).
+	ListTTLPolicies(ctx context.Context) ([]*TTLPolicy, error)
+
+	// DeleteTTLPolicy removes the policy of a zone, or the global policy for zone 0.
+	// Returns ErrNotFound if there is none.
+	DeleteTTLPolicy(ctx context.Context, zoneID int64) error
+}
+
+// ProtectedRecordStore defines the record names scoped tokens may not change.
+type ProtectedRecordStore interface {
+	// AddProtectedRecord protects a record name and type in a zone.
+	// Returns ErrDuplicate if it is already protected.
+	AddProtectedRecord(ctx context.Context, p *ProtectedRecord) (*ProtectedRecord, error)
+
+	// ListProtectedRecords returns the protected records of a zone, or of
+	// every zone for zone 0, in creation order.
+	// Returns empty slice if there are none (not an error).
+	ListProtectedRecords(ctx context.Context, zoneID int64) ([]*ProtectedRecord, error)
+
+	// DeleteProtectedRecord removes a protection by ID.
+	// Returns ErrNotFound if it doesn't exist.
+	DeleteProtectedRecord(ctx context.Context, id int64) error
+}
+
+// AdminSessionStore defines short-lived admin session credentials.
+type AdminSessionStore interface {
+	// CreateAdminSession stores a session under the hash of its credential.
+	CreateAdminSession(ctx context.Context, sess *AdminSession, sessionHash string) (*AdminSession, error)
+
+	// GetAdminSessionByHash returns the session with the given credential hash.
+	// Returns ErrNotFound if there is none.
+	GetAdminSessionByHash(ctx context.Context, sessionHash string) (*AdminSession, error)
+
+	// DeleteAdminSession removes a session by ID.
+	DeleteAdminSession(ctx context.Context, id int64) error
+
+	// PruneAdminSessions deletes sessions that expired before the cutoff.
+	PruneAdminSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ClientCertStore defines the mapping of client certificates to tokens.
+type ClientCertStore interface {
+	// SetTokenClientCert maps client certificates with the given common name to a token.
+	// Returns ErrNotFound if the token doesn't exist and ErrDuplicate if the
+	// name is mapped to another token.
+	SetTokenClientCert(ctx context.Context, tokenID int64, commonName string) error
+
+	// GetTokenClientCert returns the common name a token is mapped to.
+	// Returns ErrNotFound if the token has no mapping.
+	GetTokenClientCert(ctx context.Context, tokenID int64) (string, error)
+
+	// GetTokenByClientCert returns the token a common name is mapped to.
+	// Returns ErrNotFound if no token is mapped to the name.
+	GetTokenByClientCert(ctx context.Context, commonName string) (*Token, error)
+
+	// DeleteTokenClientCert removes a token's mapping.
+	// Returns ErrNotFound if the token has no mapping.
+	DeleteTokenClientCert(ctx context.Context, tokenID int64) error
+}
+
+// BootstrapStore records the end of bootstrap, when the master API key
+// stops being accepted.
+type BootstrapStore interface {
+	// CompleteBootstrap records that bootstrap completed. Later calls keep
+	// the first time.
+	CompleteBootstrap(ctx context.Context, at time.Time) error
+
+	// GetBootstrapCompletedAt returns when bootstrap completed.
+	// Returns ErrNotFound if it has not.
+	GetBootstrapCompletedAt(ctx context.Context) (time.Time, error)
+}
+
+// RoleStore defines roles: named permission sets that tokens hold instead of
+// copies of the same permissions. GetPermissionsForToken includes the
+// permissions of a token's roles.
+type RoleStore interface {
+	// CreateRole creates a role with its permissions.
+	// Returns ErrDuplicate if a role with the same name exists.
+	CreateRole(ctx context.Context, role *Role) (*Role, error)
+
+	// GetRole returns a role with its permissions.
+	// Returns ErrNotFound if the role doesn't exist.
+	GetRole(ctx context.Context, id int64) (*Role, error)
+
+	// ListRoles returns all roles with their permissions, ordered by name.
+	// Returns empty slice if there are none (not an error).
+	ListRoles(ctx context.Context) ([]*Role, error)
+
+	// ListTokenRoles returns the roles a token holds, ordered by name.
+	// Returns empty slice if it holds none (not an error).
+	ListTokenRoles(ctx context.Context, tokenID int64) ([]*Role, error)
+
+	// DeleteRole deletes a role; tokens holding it lose its permissions.
+	// Returns ErrNotFound if the role doesn't exist.
+	DeleteRole(ctx context.Context, id int64) error
+
+	// AddRolePermission adds a permission to a role.
+	// Returns ErrNotFound if the role doesn't exist.
+	AddRolePermission(ctx context.Context, roleID int64, perm *Permission) (*Permission, error)
+
+	// RemoveRolePermission removes a permission from a role.
+	// Returns ErrNotFound if it doesn't exist or belongs to another role.
+	RemoveRolePermission(ctx context.Context, roleID, permID int64) error
+
+	// AssignRole gives a token a role. Returns ErrNotFound if the token or
+	// role doesn't exist and ErrDuplicate if the token already holds it.
+	AssignRole(ctx context.Context, tokenID, roleID int64) error
+
+	// UnassignRole takes a role away from a token.
+	// Returns ErrNotFound if the token doesn't hold the role.
+	UnassignRole(ctx context.Context, tokenID, roleID int64) error
+}
+
+// InvitationStore defines invitations: one-time, time-limited links that mint
+// a scoped token for whoever claims them.
+type InvitationStore interface {
+	// CreateInvitation stores an invitation under the hash of its code.
+	// CreatedAt defaults to now. Returns ErrDuplicate if the hash is in use.
+	CreateInvitation(ctx context.Context, inv *Invitation, inviteHash string) (*Invitation, error)
+
+	// GetInvitationByHash returns the invitation with the given code hash,
+	// including a claimed or expired one. Returns ErrNotFound if there is none.
+	GetInvitationByHash(ctx context.Context, inviteHash string) (*Invitation, error)
+
+	// ListInvitations returns all invitations, newest first.
+	ListInvitations(ctx context.Context) ([]*Invitation, error)
+
+	// DeleteInvitation revokes an invitation. Returns ErrNotFound if it doesn't exist.
+	DeleteInvitation(ctx context.Context, id int64) error
+
+	// ClaimInvitation records that an unclaimed invitation minted a token.
+	// Returns ErrNotFound if it doesn't exist, was already claimed or
+	// expired before now, so each invitation is claimed at most once.
+	ClaimInvitation(ctx context.Context, id, tokenID int64, now time.Time) error
+}
+
+// ProvisionStore records which tokens were created from a tokens config file,
+// so reconciling the file never touches tokens created through the admin API.
+type ProvisionStore interface {
+	// MarkTokenProvisioned records that a token is managed by the config
+	// file. Marking a token twice is not an error.
+	MarkTokenProvisioned(ctx context.Context, tokenID int64) error
+
+	// ListProvisionedTokenIDs returns the IDs of provisioned tokens that
+	// still exist, in ascending order.
+	ListProvisionedTokenIDs(ctx context.Context) ([]int64, error)
+}
+
+// NonceStore defines the nonces seen on replay-protected requests.
+type NonceStore interface {
+	// RecordNonce records a nonce used by a token.
+	// Returns ErrDuplicate if the token already used the nonce.
+	RecordNonce(ctx context.Context, tokenID int64, nonce string, at time.Time) error
+
+	// PruneNonces deletes nonces seen before the cutoff and returns how many were removed.
+	PruneNonces(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdempotencyStore defines the responses kept for requests sent with an
+// Idempotency-Key. Keys are scoped to the token, and token ID 0 is the master key.
+type IdempotencyStore interface {
+	// ClaimIdempotencyKey records that a token's request with a key is in progress.
+	// Returns ErrDuplicate if the token already used the key.
+	ClaimIdempotencyKey(ctx context.Context, tokenID int64, key, fingerprint string, at time.Time) error
+
+	// GetIdempotentResponse returns the request recorded for a token's key.
+	// Returns ErrNotFound if the key is not recorded.
+	GetIdempotentResponse(ctx context.Context, tokenID int64, key string) (*IdempotentResponse, error)
+
+	// CompleteIdempotencyKey stores the response to a claimed key's request.
+	// Returns ErrNotFound if the key is not recorded.
+	CompleteIdempotencyKey(ctx context.Context, tokenID int64, key string, status int, contentType string, body []byte) error
+
+	// ReleaseIdempotencyKey forgets a key, so it can be claimed again.
+	// Releasing a key that is not recorded is not an error.
+	ReleaseIdempotencyKey(ctx context.Context, tokenID int64, key string) error
+
+	// PruneIdempotencyKeys deletes keys claimed before the cutoff and returns how many were removed.
+	PruneIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+}
+
+// JobStore defines long-running jobs run by a background worker. Jobs are
+// queued, then running, then succeeded or failed.
+type JobStore interface {
+	// CreateJob records a queued job. CreatedAt defaults to now.
+	CreateJob(ctx context.Context, j *Job) (*Job, error)
+
+	// GetJob retrieves a job by ID.
+	// Returns ErrNotFound if the job does not exist.
+	GetJob(ctx context.Context, id int64) (*Job, error)
+
+	// StartJob marks a queued job as running.
+	// Returns ErrNotFound if the job does not exist or is not queued.
+	StartJob(ctx context.Context, id int64, at time.Time) error
+
+	// UpdateJobProgress records how many items a running job has processed.
+	// Returns ErrNotFound if the job does not exist or is not running.
+	UpdateJobProgress(ctx context.Context, id int64, processed int) error
+
+	// FinishJob records the outcome of a queued or running job.
+	// Returns ErrNotFound if the job does not exist or has already finished.
+	FinishJob(ctx context.Context, id int64, status string, processed int, result []byte, errMsg string, at time.Time) error
+
+	// FailUnfinishedJobs marks queued and running jobs created before the
+	// cutoff as failed, and returns how many were marked.
+	FailUnfinishedJobs(ctx context.Context, before time.Time, message string) (int64, error)
+
+	// PruneJobs deletes jobs that finished before the cutoff and returns how many were removed.
+	PruneJobs(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ZoneTagStore defines zone tags and the tag permissions bound to them.
+type ZoneTagStore interface {
+	// ReplaceZoneTags replaces all zone tags.
+	ReplaceZoneTags(ctx context.Context, tags []ZoneTag) error
+
+	// ListZoneTags returns all zone tags ordered by tag, then zone ID.
+	// Returns empty slice if there are none (not an error).
+	ListZoneTags(ctx context.Context) ([]*ZoneTag, error)
+
+	// AddTagPermission grants a token a DNS zone permission on every zone with a tag.
+	AddTagPermission(ctx context.Context, p *TagPermission) (*TagPermission, error)
+
+	// ListTagPermissions returns a token's tag permissions in creation order.
+	// Returns empty slice if there are none (not an error).
+	ListTagPermissions(ctx context.Context, tokenID int64) ([]*TagPermission, error)
+
+	// DeleteTagPermission deletes a token's tag permission and the permissions it created.
+	// Returns ErrNotFound if it doesn't exist or belongs to another token.
+	DeleteTagPermission(ctx context.Context, tokenID, id int64) error
+
+	// SyncTagPermissions adds and removes permissions created by tag
+	// permissions to match the current zone tags, and returns how many
+	// were added and removed. Permissions added directly are not touched.
+	SyncTagPermissions(ctx context.Context) (added, removed int64, err error)
+}
+
+// DomainPermissionStore defines permissions granted by zone domain pattern.
+type DomainPermissionStore interface {
+	// AddDomainPermission grants a token a DNS zone permission on every zone
+	// whose domain matches a pattern.
+	AddDomainPermission(ctx context.Context, p *DomainPermission) (*DomainPermission, error)
+
+	// ListDomainPermissions returns a token's domain permissions in creation order.
+	// Returns empty slice if there are none (not an error).
+	ListDomainPermissions(ctx context.Context, tokenID int64) ([]*DomainPermission, error)
+
+	// DeleteDomainPermission deletes a token's domain permission.
+	// Returns ErrNotFound if it doesn't exist or belongs to another token.
+	DeleteDomainPermission(ctx context.Context, tokenID, id int64) error
+}
+
+// RecordMetaStore defines the interface for proxy-side record metadata.
+type RecordMetaStore interface {
+	// GetRecordMeta retrieves metadata for a record.
+	// Returns ErrNotFound if the record has no metadata.
+	GetRecordMeta(ctx context.Context, zoneID, recordID int64) (*RecordMeta, error)
+
+	// ListRecordMeta retrieves metadata for all annotated records in a zone.
+	// Returns empty slice if there are none (not an error).
+	ListRecordMeta(ctx context.Context, zoneID int64) ([]*RecordMeta, error)
+
+	// SetRecordMeta replaces the metadata for a record.
+	// An empty Meta map removes the record's metadata.
+	SetRecordMeta(ctx context.Context, m *RecordMeta) (*RecordMeta, error)
+
+	// DeleteRecordMeta removes metadata for a record.
+	// Returns ErrNotFound if the record has no metadata.
+	DeleteRecordMeta(ctx context.Context, zoneID, recordID int64) error
+
+	// DeleteZoneRecordMeta removes metadata for all records in a zone.
+	DeleteZoneRecordMeta(ctx context.Context, zoneID int64) error
+}
+
+// RecordChangeStore defines the history of DNS record mutations made through the proxy.
+type RecordChangeStore interface {
+	// AddRecordChange records a record mutation. CreatedAt defaults to now.
+	AddRecordChange(ctx context.Context, c *RecordChange) (*RecordChange, error)
+
+	// ListRecordChanges returns a zone's record changes matching the filter, newest first.
+	// Returns empty slice if there are none (not an error).
+	ListRecordChanges(ctx context.Context, zoneID int64, filter RecordChangeFilter) ([]*RecordChange, error)
+
+	// GetRecordChange retrieves a record change by ID.
+	// Returns ErrNotFound if the change does not exist.
+	GetRecordChange(ctx context.Context, id int64) (*RecordChange, error)
+}
+
+// ScheduledChangeStore defines record changes to be made at a later time.
+// Changes are pending, then running, then succeeded or failed, unless they
+// are cancelled while pending.
+type ScheduledChangeStore interface {
+	// CreateScheduledChange records a pending change. CreatedAt defaults to now.
+	CreateScheduledChange(ctx context.Context, c *ScheduledChange) (*ScheduledChange, error)
+
+	// GetScheduledChange retrieves a scheduled change by ID.
+	// Returns ErrNotFound if the change does not exist.
+	GetScheduledChange(ctx context.Context, id int64) (*ScheduledChange, error)
+
+	// ListScheduledChanges returns a zone's scheduled changes with the given
+	// status, or all of them if status is empty, in the order they run.
+	// Returns empty slice if there are none (not an error).
+	ListScheduledChanges(ctx context.Context, zoneID int64, status string) ([]*ScheduledChange, error)
+
+	// ListDueScheduledChanges returns pending changes due at or before at,
+	// in the order they run.
+	ListDueScheduledChanges(ctx context.Context, at time.Time) ([]*ScheduledChange, error)
+
+	// StartScheduledChange marks a pending change as running.
+	// Returns ErrNotFound if the change does not exist or is not pending.
+	StartScheduledChange(ctx context.Context, id int64, at time.Time) error
+
+	// FinishScheduledChange records the outcome of a running change; recordID
+	// is the record a create made.
+	// Returns ErrNotFound if the change does not exist or is not running.
+	FinishScheduledChange(ctx context.Context, id int64, status string, recordID int64, errMsg string, at time.Time) error
+
+	// CancelScheduledChange marks a pending change as cancelled.
+	// Returns ErrNotFound if the change does not exist or is not pending.
+	CancelScheduledChange(ctx context.Context, id int64, at time.Time) error
+
+	// FailUnfinishedScheduledChanges marks changes that started running
+	// before the cutoff and never finished as failed, and returns how many
+	// were marked.
+	FailUnfinishedScheduledChanges(ctx context.Context, before time.Time, message string) (int64, error)
+
+	// PruneScheduledChanges deletes changes that finished or were cancelled
+	// before the cutoff and returns how many were removed.
+	PruneScheduledChanges(ctx context.Context, before time.Time) (int64, error)
+}
+
 // Storage defines the interface for SQLite persistence operations.
 type Storage interface {
 	// Health checks
@@ -60,6 +574,75 @@ type Storage interface {
 	// TokenStore is embedded to include all token-related operations
 	TokenStore
 
+	// WebhookStore is embedded to include webhook registrations and delivery history
+	WebhookStore
+
+	// OutboxStore is embedded to include the reliable event outbox
+	OutboxStore
+
+	// RecordMetaStore is embedded to include proxy-side record annotations
+	RecordMetaStore
+
+	// RecordChangeStore is embedded to include the history of record mutations
+	RecordChangeStore
+
+	// ScheduledChangeStore is embedded to include record changes made at a later time
+	ScheduledChangeStore
+
+	// AuditStore is embedded to include the request audit log
+	AuditStore
+
+	// MaintenanceStore is embedded to include database size stats and vacuuming
+	MaintenanceStore
+
+	// InstanceStore is embedded to include the registry of proxy instances
+	InstanceStore
+
+	// BlocklistStore is embedded to include the client IP blocklist
+	BlocklistStore
+
+	// ZoneFreezeStore is embedded to include zone change freezes
+	ZoneFreezeStore
+
+	// TTLPolicyStore is embedded to include record TTL policies
+	TTLPolicyStore
+
+	// ProtectedRecordStore is embedded to include protected record names
+	ProtectedRecordStore
+
+	// ZoneTagStore is embedded to include zone tags and tag permissions
+	ZoneTagStore
+
+	// DomainPermissionStore is embedded to include permissions by domain pattern
+	DomainPermissionStore
+
+	// NonceStore is embedded to include the replay protection nonce cache
+	NonceStore
+
+	// IdempotencyStore is embedded to include idempotency keys and their responses
+	IdempotencyStore
+
+	// JobStore is embedded to include background jobs
+	JobStore
+
+	// AdminSessionStore is embedded to include admin session credentials
+	AdminSessionStore
+
+	// ClientCertStore is embedded to include client certificate mappings
+	ClientCertStore
+
+	// BootstrapStore is embedded to include the bootstrap completion record
+	BootstrapStore
+
+	// RoleStore is embedded to include roles and the tokens holding them
+	RoleStore
+
+	// InvitationStore is embedded to include invitation links
+	InvitationStore
+
+	// ProvisionStore is embedded to include the record of provisioned tokens
+	ProvisionStore
+
 	// Unified permission operations
 	AddPermissionForToken(ctx context.Context, tokenID int64, perm *Permission) (*Permission, error)
 	RemovePermission(ctx context.Context, permID int64) error
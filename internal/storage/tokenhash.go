@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Token key hashes are the SHA-256 hashes of API keys computed by callers.
+// With token hashing, key_hash stores an Argon2id digest of that hash
+// instead, so a copy of the database does not reveal anything that can be
+// checked against guessed keys without paying for Argon2id. key_lookup keeps
+// the first keyLookupLen characters of the hash to find the candidates; it is
+// empty for tokens with <id>.<secret> keys, which are found by ID.
+const (
+	keyLookupLen   = 8
+	argon2idPrefix = "$argon2id$"
+	argon2SaltLen  = 16
+	argon2KeyLen   = 32
+
+	// maxVerifiedHashes caps the memo of verified key hashes.
+	maxVerifiedHashes = 10000
+)
+
+// TokenHashParams are the Argon2id parameters used to hash token keys.
+type TokenHashParams struct {
+	// Memory is the memory cost in KiB.
+	Memory uint32
+	// Iterations is the number of passes over the memory.
+	Iterations uint32
+	// Parallelism is the number of lanes.
+	Parallelism uint8
+}
+
+// DefaultTokenHashParams are the Argon2id parameters recommended by OWASP:
+// 19 MiB of memory, 2 iterations and 1 lane.
+var DefaultTokenHashParams = TokenHashParams{Memory: 19 * 1024, Iterations: 2, Parallelism: 1}
+
+// WithTokenHashing stores token key hashes as Argon2id digests with the given
+// parameters. Tokens stored as plain SHA-256 hashes are converted when the
+// database is opened, and tokens hashed with other parameters are rehashed
+// the next time they are looked up. Default: plain SHA-256 hashes.
+func WithTokenHashing(params TokenHashParams) Option {
+	return func(o *options) {
+		o.tokenHashing = &params
+	}
+}
+
+// tokenHasher turns token key hashes into Argon2id digests and verifies them.
+type tokenHasher struct {
+	params TokenHashParams
+
+	// verified remembers the digest each key hash last matched, so a token
+	// in use costs one Argon2id computation per process, not per lookup.
+	mu       sync.Mutex
+	verified map[string]string
+}
+
+func newTokenHasher(params TokenHashParams) (*tokenHasher, error) {
+	if params.Memory < 8*uint32(params.Parallelism) || params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, fmt.Errorf("invalid token hash parameters: memory %d KiB, iterations %d, parallelism %d",
+			params.Memory, params.Iterations, params.Parallelism)
+	}
+	return &tokenHasher{params: params, verified: make(map[string]string)}, nil
+}
+
+// hash returns a new Argon2id digest of keyHash with a random salt.
+func (h *tokenHasher) hash(keyHash string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil { // coverage-ignore: crypto/rand does not fail on supported platforms
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	p := h.params
+	key := argon2.IDKey([]byte(keyHash), salt, p.Iterations, p.Memory, p.Parallelism, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// matches reports whether stored, a plain hash or an Argon2id digest, is the
+// stored form of keyHash. h may be nil when token hashing is off; digests
+// are verified with the parameters they were made with either way.
+func (h *tokenHasher) matches(stored, keyHash string) bool {
+	if !strings.HasPrefix(stored, argon2idPrefix) {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(keyHash)) == 1
+	}
+	if h != nil && h.wasVerified(keyHash, stored) {
+		return true
+	}
+
+	params, salt, key, err := parseArgon2id(stored)
+	if err != nil {
+		return false
+	}
+	got := argon2.IDKey([]byte(keyHash), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key))) //nolint:gosec // at most a few dozen bytes
+	if subtle.ConstantTimeCompare(got, key) != 1 {
+		return false
+	}
+	if h != nil {
+		h.remember(keyHash, stored)
+	}
+	return true
+}
+
+// needsRehash reports whether stored is not an Argon2id digest made with the
+// current parameters.
+func (h *tokenHasher) needsRehash(stored string) bool {
+	params, _, _, err := parseArgon2id(stored)
+	return err != nil || params != h.params
+}
+
+func (h *tokenHasher) wasVerified(keyHash, stored string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	digest, ok := h.verified[keyHash]
+	return ok && subtle.ConstantTimeCompare([]byte(digest), []byte(stored)) == 1
+}
+
+func (h *tokenHasher) remember(keyHash, stored string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.verified) >= maxVerifiedHashes {
+		clear(h.verified)
+	}
+	h.verified[keyHash] = stored
+}
+
+// parseArgon2id decodes a digest of the form
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+func parseArgon2id(digest string) (TokenHashParams, []byte, []byte, error) {
+	var p TokenHashParams
+	parts := strings.Split(digest, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errors.New("not an argon2id digest")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 parameters %q: %w", parts[3], err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errors.New("invalid argon2 key")
+	}
+	return p, salt, key, nil
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx, so a token can be looked
+// up inside the transaction that creates one.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// keyLookup returns the lookup prefix of a key hash.
+func keyLookup(keyHash string) string {
+	if len(keyHash) > keyLookupLen {
+		return keyHash[:keyLookupLen]
+	}
+	return keyHash
+}
+
+// findTokenByHash returns the token whose stored hash matches keyHash among
+// those sharing its lookup prefix, or ErrNotFound.
+func (s *SQLiteStorage) findTokenByHash(ctx context.Context, q querier, keyHash string) (*Token, error) {
+	rows, err := q.QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM tokens WHERE key_lookup = ?", keyLookup(keyHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token by hash: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var candidates []*Token
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		candidates = append(candidates, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get token by hash: %w", err)
+	}
+	// Verify outside the query so Argon2id does not hold a connection
+	_ = rows.Close()
+
+	for _, t := range candidates {
+		if s.hasher.matches(t.KeyHash, keyHash) {
+			return t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// rehashToken replaces the stored hash of a token with a digest made with the
+// current parameters, unless another lookup replaced it first.
+func (s *SQLiteStorage) rehashToken(ctx context.Context, t *Token, keyHash string) error {
+	digest, err := s.hasher.hash(keyHash)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx,
+		"UPDATE tokens SET key_hash = ? WHERE id = ? AND key_hash = ?", digest, t.ID, t.KeyHash); err != nil {
+		return fmt.Errorf("failed to rehash token: %w", err)
+	}
+	s.hasher.remember(keyHash, digest)
+	return nil
+}
+
+// hashStoredTokens converts tokens stored as plain SHA-256 hashes to Argon2id
+// digests. The plain hash is exactly what the digest is made from, so unlike
+// a parameter change this does not have to wait for the token to be used.
+func hashStoredTokens(ctx context.Context, db *sql.DB, h *tokenHasher) error {
+	rows, err := db.QueryContext(ctx, "SELECT id, key_hash FROM tokens WHERE key_hash NOT LIKE '$argon2id$%'")
+	if err != nil {
+		return fmt.Errorf("failed to read token hashes: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	plain := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var keyHash string
+		if err := rows.Scan(&id, &keyHash); err != nil {
+			return fmt.Errorf("failed to scan token hash: %w", err)
+		}
+		plain[id] = keyHash
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read token hashes: %w", err)
+	}
+	// The writer has a single connection, which the updates need
+	_ = rows.Close()
+
+	for id, keyHash := range plain {
+		digest, err := h.hash(keyHash)
+		if err != nil {
+			return err
+		}
+		if _, err := db.ExecContext(ctx,
+			"UPDATE tokens SET key_hash = ? WHERE id = ? AND key_hash = ?", digest, id, keyHash); err != nil {
+			return fmt.Errorf("failed to hash token %d: %w", id, err)
+		}
+	}
+	return nil
+}
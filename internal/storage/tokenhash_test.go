@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cheapHashParams keep Argon2id fast in tests.
+var cheapHashParams = TokenHashParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// storedKeyHash returns the key_hash column of a token.
+func storedKeyHash(t *testing.T, s *SQLiteStorage, id int64) string {
+	t.Helper()
+	var stored string
+	if err := s.db.QueryRow("SELECT key_hash FROM tokens WHERE id = ?", id).Scan(&stored); err != nil {
+		t.Fatalf("failed to read key_hash: %v", err)
+	}
+	return stored
+}
+
+func TestTokenHashing(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	s, err := New(":memory:", WithTokenHashing(cheapHashParams))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Close()
+
+	keyHash := strings.Repeat("ab", 32)
+	created, err := s.CreateToken(ctx, "ci", false, keyHash)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if stored := storedKeyHash(t, s, created.ID); !strings.HasPrefix(stored, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("stored hash = %q, want an Argon2id digest", stored)
+	}
+
+	got, err := s.GetTokenByHash(ctx, keyHash)
+	if err != nil || got.ID != created.ID || got.KeyHash != keyHash {
+		t.Fatalf("GetTokenByHash() = %+v, %v", got, err)
+	}
+	if got, err := s.GetTokenByKey(ctx, created.ID, keyHash); err != nil || got.KeyHash != keyHash {
+		t.Fatalf("GetTokenByKey() = %+v, %v", got, err)
+	}
+	if _, err := s.GetTokenByKey(ctx, created.ID, keyHash[:60]+"cdcd"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTokenByKey(other) error = %v, want ErrNotFound", err)
+	}
+	// Same lookup prefix, different hash
+	if _, err := s.GetTokenByHash(ctx, keyHash[:60]+"cdcd"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTokenByHash(other) error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.CreateToken(ctx, "other", true, keyHash); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("CreateToken(duplicate) error = %v, want ErrDuplicate", err)
+	}
+}
+
+func TestTokenHashing_UpgradesStoredHashes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "proxy.db")
+	keyHash := strings.Repeat("01", 32)
+
+	// A token stored as a plain hash is converted when hashing is enabled
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	token, err := s.CreateToken(ctx, "ci", false, keyHash)
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	_ = s.Close()
+
+	s, err = New(path, WithTokenHashing(cheapHashParams))
+	if err != nil {
+		t.Fatalf("New with hashing failed: %v", err)
+	}
+	first := storedKeyHash(t, s, token.ID)
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("stored hash = %q, want it converted", first)
+	}
+	_ = s.Close()
+
+	// A digest made with other parameters is rehashed on its next lookup
+	s, err = New(path, WithTokenHashing(TokenHashParams{Memory: 128, Iterations: 2, Parallelism: 1}))
+	if err != nil {
+		t.Fatalf("New with new parameters failed: %v", err)
+	}
+	if stored := storedKeyHash(t, s, token.ID); stored != first {
+		t.Errorf("digest was changed before the token was used: %q", stored)
+	}
+	if _, err := s.GetTokenByHash(ctx, keyHash); err != nil {
+		t.Fatalf("GetTokenByHash failed: %v", err)
+	}
+	if stored := storedKeyHash(t, s, token.ID); !strings.HasPrefix(stored, "$argon2id$v=19$m=128,t=2,p=1$") {
+		t.Errorf("stored hash = %q, want it rehashed", stored)
+	}
+	_ = s.Close()
+
+	// Digests are still verified with hashing turned off
+	s, err = New(path)
+	if err != nil {
+		t.Fatalf("New without hashing failed: %v", err)
+	}
+	defer s.Close()
+	if got, err := s.GetTokenByHash(ctx, keyHash); err != nil || got.ID != token.ID {
+		t.Errorf("GetTokenByHash() = %+v, %v", got, err)
+	}
+}
+
+func TestTokenHashing_InvalidParams(t *testing.T) {
+	t.Parallel()
+	for _, p := range []TokenHashParams{
+		{Memory: 64, Iterations: 0, Parallelism: 1},
+		{Memory: 64, Iterations: 1, Parallelism: 0},
+		{Memory: 7, Iterations: 1, Parallelism: 1},
+	} {
+		if _, err := New(":memory:", WithTokenHashing(p)); err == nil {
+			t.Errorf("New(%+v) error = nil, want error", p)
+		}
+	}
+}
+
+func TestParseArgon2id(t *testing.T) {
+	t.Parallel()
+	h, err := newTokenHasher(cheapHashParams)
+	if err != nil {
+		t.Fatalf("newTokenHasher failed: %v", err)
+	}
+	digest, err := h.hash("key-hash")
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if p, salt, key, err := parseArgon2id(digest); err != nil || p != cheapHashParams || len(salt) != argon2SaltLen || len(key) != argon2KeyLen {
+		t.Errorf("parseArgon2id(%q) = %+v, %d, %d, %v", digest, p, len(salt), len(key), err)
+	}
+
+	for _, bad := range []string{
+		"plain-hash",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+	} {
+		if _, _, _, err := parseArgon2id(bad); err == nil {
+			t.Errorf("parseArgon2id(%q) error = nil, want error", bad)
+		}
+		if h.matches(bad, "key-hash") {
+			t.Errorf("matches(%q) = true", bad)
+		}
+	}
+}
@@ -6,30 +6,59 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-
-	"modernc.org/sqlite"
-	sqlite3 "modernc.org/sqlite/lib"
+	"time"
 )
 
-// CreateToken creates a new token (admin or scoped) with bcrypt hash.
+// CreateToken creates a new token (admin or scoped) from the SHA-256 hash of
+// its key, stored as an Argon2id digest when token hashing is enabled.
 // Returns the new token and any error.
 // Returns ErrDuplicate if a token with this hash already exists.
 func (s *SQLiteStorage) CreateToken(ctx context.Context, name string, isAdmin bool, keyHash string) (*Token, error) {
-	// Insert into tokens table
-	result, err := s.db.ExecContext(ctx,
-		"INSERT INTO tokens (key_hash, name, is_admin) VALUES (?, ?, ?)",
-		keyHash, name, isAdmin)
+	return s.createToken(ctx, name, isAdmin, keyHash, keyLookup(keyHash))
+}
+
+// CreateKeyToken creates a new token for a key of the form <id>.<secret>
+// from the SHA-256 hash of its secret. It is stored without a key_lookup,
+// so only GetTokenByKey finds it and the secret alone is not a valid key.
+// Returns ErrDuplicate if a token with this hash already exists.
+func (s *SQLiteStorage) CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*Token, error) {
+	return s.createToken(ctx, name, isAdmin, secretHash, "")
+}
+
+// createToken inserts a token with the given key_lookup, empty for tokens
+// GetTokenByHash must not find.
+func (s *SQLiteStorage) createToken(ctx context.Context, name string, isAdmin bool, keyHash, lookup string) (*Token, error) {
+	stored := keyHash
+	if s.hasher != nil {
+		digest, err := s.hasher.hash(keyHash)
+		if err != nil {
+			return nil, err
+		}
+		stored = digest
+	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		// Check if this is a UNIQUE constraint violation
-		// The extended error code for UNIQUE constraint is 2067
-		var sqliteErr *sqlite.Error
-		if errors.As(err, &sqliteErr) {
-			// Check for UNIQUE constraint (extended error code 2067)
-			// or base constraint error code 19
-			if sqliteErr.Code() == 2067 || (sqliteErr.Code()&0xFF) == sqlite3.SQLITE_CONSTRAINT {
-				return nil, ErrDuplicate
-			}
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// Digests are salted, so the UNIQUE constraint on key_hash cannot tell
+	// that a token with this hash exists
+	_, err = s.findTokenByHash(ctx, tx, keyHash)
+	if err == nil {
+		return nil, ErrDuplicate
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO tokens (key_hash, key_lookup, name, is_admin) VALUES (?, ?, ?, ?)",
+		stored, lookup, name, isAdmin)
+	if err != nil {
+		if isConstraintViolation(err) {
+			return nil, ErrDuplicate
 		}
 		return nil, fmt.Errorf("failed to create token: %w", err)
 	}
@@ -39,6 +68,12 @@ func (s *SQLiteStorage) CreateToken(ctx context.Context, name string, isAdmin bo
 	if err != nil {
 		return nil, fmt.Errorf("failed to get insert ID: %w", err)
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit token: %w", err)
+	}
+	if s.hasher != nil {
+		s.hasher.remember(keyHash, stored)
+	}
 
 	// Return the created token
 	return &Token{
@@ -51,51 +86,62 @@ func (s *SQLiteStorage) CreateToken(ctx context.Context, name string, isAdmin bo
 
 // GetTokenByHash retrieves a token by its hash.
 // This is used during authentication to look up the token.
+// With token hashing, a token stored as a plain hash or hashed with other
+// parameters is rehashed with the current ones.
 // Returns ErrNotFound if the hash doesn't exist.
 func (s *SQLiteStorage) GetTokenByHash(ctx context.Context, keyHash string) (*Token, error) {
-	var t Token
-
-	err := s.db.QueryRowContext(ctx,
-		"SELECT id, key_hash, name, is_admin, created_at FROM tokens WHERE key_hash = ?",
-		keyHash).
-		Scan(&t.ID, &t.KeyHash, &t.Name, &t.IsAdmin, &t.CreatedAt)
-
+	t, err := s.findTokenByHash(ctx, s.readDB, keyHash)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("failed to get token by hash: %w", err)
+		return nil, err
+	}
+	if s.hasher != nil && s.hasher.needsRehash(t.KeyHash) {
+		// The old hash keeps working, so a failure is retried on the next lookup
+		_ = s.rehashToken(ctx, t, keyHash) //nolint:errcheck
 	}
+	t.KeyHash = keyHash
+	return t, nil
+}
 
-	return &t, nil
+// GetTokenByKey retrieves a token by ID and checks that secretHash, the
+// SHA-256 hash of the secret in its key, matches the stored hash.
+// With token hashing, the stored hash is rehashed as in GetTokenByHash.
+// Returns ErrNotFound if the token doesn't exist or the hash doesn't match.
+func (s *SQLiteStorage) GetTokenByKey(ctx context.Context, id int64, secretHash string) (*Token, error) {
+	t, err := s.GetTokenByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.hasher.matches(t.KeyHash, secretHash) {
+		return nil, ErrNotFound
+	}
+	if s.hasher != nil && s.hasher.needsRehash(t.KeyHash) {
+		// The old hash keeps working, so a failure is retried on the next lookup
+		_ = s.rehashToken(ctx, t, secretHash) //nolint:errcheck
+	}
+	t.KeyHash = secretHash
+	return t, nil
 }
 
 // GetTokenByID retrieves a token by ID.
 // This is used in the admin UI to view token details.
 // Returns ErrNotFound if the token doesn't exist.
 func (s *SQLiteStorage) GetTokenByID(ctx context.Context, id int64) (*Token, error) {
-	var t Token
-
-	err := s.db.QueryRowContext(ctx,
-		"SELECT id, key_hash, name, is_admin, created_at FROM tokens WHERE id = ?",
-		id).
-		Scan(&t.ID, &t.KeyHash, &t.Name, &t.IsAdmin, &t.CreatedAt)
-
+	t, err := scanToken(s.readDB.QueryRowContext(ctx,
+		"SELECT "+tokenColumns+" FROM tokens WHERE id = ?", id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to get token by ID: %w", err)
 	}
-
-	return &t, nil
+	return t, nil
 }
 
 // ListTokens returns all tokens (for admin UI).
 // Returns empty slice if no tokens exist.
 func (s *SQLiteStorage) ListTokens(ctx context.Context) ([]*Token, error) {
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, key_hash, name, is_admin, created_at FROM tokens ORDER BY created_at DESC, id DESC")
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM tokens ORDER BY created_at DESC, id DESC")
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tokens: %w", err)
@@ -105,12 +151,11 @@ func (s *SQLiteStorage) ListTokens(ctx context.Context) ([]*Token, error) {
 	var tokens []*Token
 
 	for rows.Next() {
-		var t Token
-		err := rows.Scan(&t.ID, &t.KeyHash, &t.Name, &t.IsAdmin, &t.CreatedAt)
+		t, err := scanToken(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan token row: %w", err)
 		}
-		tokens = append(tokens, &t)
+		tokens = append(tokens, t)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -150,12 +195,149 @@ func (s *SQLiteStorage) DeleteToken(ctx context.Context, id int64) error {
 	return nil
 }
 
+// SetTokenExpiry sets when a token stops working; a zero time means never.
+// Returns ErrNotFound if the token doesn't exist.
+func (s *SQLiteStorage) SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	var at int64
+	if !expiresAt.IsZero() {
+		at = expiresAt.Unix()
+	}
+
+	result, err := s.db.ExecContext(ctx, "UPDATE tokens SET expires_at = ? WHERE id = ?", at, id)
+	if err != nil {
+		return fmt.Errorf("failed to set token expiry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SetTokenPinning enables or disables TLS fingerprint pinning for a token.
+// Any pinned fingerprint is forgotten, so the next use pins a new one.
+// Returns ErrNotFound if the token doesn't exist.
+func (s *SQLiteStorage) SetTokenPinning(ctx context.Context, id int64, pin bool) error {
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE tokens SET pin_tls_fingerprint = ?, tls_fingerprint = '' WHERE id = ?", pin, id)
+	if err != nil {
+		return fmt.Errorf("failed to set token pinning: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SetTokenAccount sets the upstream account a token's requests are sent to;
+// empty means the default account. Returns ErrNotFound if the token doesn't exist.
+func (s *SQLiteStorage) SetTokenAccount(ctx context.Context, id int64, account string) error {
+	result, err := s.db.ExecContext(ctx, "UPDATE tokens SET account = ? WHERE id = ?", account, id)
+	if err != nil {
+		return fmt.Errorf("failed to set token account: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// PinTokenFingerprint records the fingerprint for a token that has none pinned
+// yet and returns the pinned fingerprint. Concurrent first uses agree on one.
+// Returns ErrNotFound if the token doesn't exist.
+func (s *SQLiteStorage) PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error) {
+	var pinned string
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE tokens SET tls_fingerprint = CASE WHEN tls_fingerprint = '' THEN ? ELSE tls_fingerprint END
+		WHERE id = ? RETURNING tls_fingerprint`,
+		fingerprint, id).Scan(&pinned)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to pin token fingerprint: %w", err)
+	}
+	return pinned, nil
+}
+
+// RecordTokenUsage adds batched use counts to tokens and advances their last
+// used time. All updates are applied in one transaction. Tokens deleted since
+// the uses were counted are skipped.
+func (s *SQLiteStorage) RecordTokenUsage(ctx context.Context, usage []TokenUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin token usage transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	for _, u := range usage {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE tokens SET use_count = use_count + ?, last_used_at = MAX(last_used_at, ?) WHERE id = ?",
+			u.Count, u.LastUsedAt.Unix(), u.TokenID); err != nil {
+			return fmt.Errorf("failed to record token usage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit token usage: %w", err)
+	}
+	return nil
+}
+
+// tokenColumns lists the columns read by scanToken, in order.
+const tokenColumns = "id, key_hash, name, is_admin, created_at, expires_at, pin_tls_fingerprint, tls_fingerprint, last_used_at, use_count, account"
+
+// scanToken reads a token row selected with tokenColumns.
+func scanToken(row rowScanner) (*Token, error) {
+	var t Token
+	var expiresAt, lastUsedAt int64
+	if err := row.Scan(&t.ID, &t.KeyHash, &t.Name, &t.IsAdmin, &t.CreatedAt, &expiresAt,
+		&t.PinTLSFingerprint, &t.TLSFingerprint, &lastUsedAt, &t.UseCount, &t.Account); err != nil {
+		return nil, err
+	}
+	t.ExpiresAt = unixOrZero(expiresAt)
+	t.LastUsedAt = unixOrZero(lastUsedAt)
+	return &t, nil
+}
+
+// unixOrZero converts unix seconds to a time, with 0 meaning the zero time.
+func unixOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
 // HasAnyAdminToken checks if there are any admin tokens.
 // Returns true if at least one admin token exists.
 func (s *SQLiteStorage) HasAnyAdminToken(ctx context.Context) (bool, error) {
 	var count int64
 
-	err := s.db.QueryRowContext(ctx,
+	err := s.readDB.QueryRowContext(ctx,
 		"SELECT COUNT(*) FROM tokens WHERE is_admin = TRUE").
 		Scan(&count)
 
@@ -170,7 +352,7 @@ func (s *SQLiteStorage) HasAnyAdminToken(ctx context.Context) (bool, error) {
 func (s *SQLiteStorage) CountAdminTokens(ctx context.Context) (int, error) {
 	var count int
 
-	err := s.db.QueryRowContext(ctx,
+	err := s.readDB.QueryRowContext(ctx,
 		"SELECT COUNT(*) FROM tokens WHERE is_admin = TRUE").
 		Scan(&count)
 
@@ -183,34 +365,18 @@ func (s *SQLiteStorage) CountAdminTokens(ctx context.Context) (int, error) {
 
 // AddPermissionForToken creates a new permission for a token.
 // The perm.AllowedActions and perm.RecordTypes are JSON-encoded for storage.
+// An empty perm.Resource is stored as ResourceDNSZone.
 // Returns the new permission and any error.
 func (s *SQLiteStorage) AddPermissionForToken(ctx context.Context, tokenID int64, perm *Permission) (*Permission, error) {
-	// Validate input
-	if perm.ZoneID <= 0 {
-		return nil, fmt.Errorf("invalid zone ID: must be greater than 0")
-	}
-	if len(perm.AllowedActions) == 0 {
-		return nil, fmt.Errorf("allowed actions cannot be empty")
-	}
-	if len(perm.RecordTypes) == 0 {
-		return nil, fmt.Errorf("record types cannot be empty")
-	}
-
-	// JSON-encode arrays
-	allowedActionsJSON, err := marshalStringArray(perm.AllowedActions)
+	allowedActionsJSON, recordTypesJSON, err := encodePermission(perm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal allowed actions: %w", err)
-	}
-
-	recordTypesJSON, err := marshalStringArray(perm.RecordTypes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal record types: %w", err)
+		return nil, err
 	}
 
 	// Insert into database
 	result, err := s.db.ExecContext(ctx,
-		"INSERT INTO permissions (token_id, zone_id, allowed_actions, record_types) VALUES (?, ?, ?, ?)",
-		tokenID, perm.ZoneID, string(allowedActionsJSON), string(recordTypesJSON))
+		"INSERT INTO permissions (token_id, resource, zone_id, allowed_actions, record_types, tag_permission_id) VALUES (?, ?, ?, ?, ?, ?)",
+		tokenID, perm.Resource, perm.ZoneID, string(allowedActionsJSON), string(recordTypesJSON), perm.TagPermissionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert permission: %w", err)
 	}
@@ -269,13 +435,19 @@ func (s *SQLiteStorage) RemovePermissionForToken(ctx context.Context, tokenID, p
 	return nil
 }
 
-// GetPermissionsForToken retrieves all permissions for a token.
+// GetPermissionsForToken retrieves all permissions for a token: its own, in
+// creation order, then those of its roles by role and creation order.
 // Returns empty slice if no permissions exist (not an error).
 // The AllowedActions and RecordTypes are JSON-decoded.
 func (s *SQLiteStorage) GetPermissionsForToken(ctx context.Context, tokenID int64) ([]*Permission, error) {
-	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, token_id, zone_id, allowed_actions, record_types FROM permissions WHERE token_id = ? ORDER BY id ASC",
-		tokenID)
+	rows, err := s.readDB.QueryContext(ctx,
+		`SELECT id, token_id, resource, zone_id, allowed_actions, record_types, tag_permission_id, 0 AS role_id
+		FROM permissions WHERE token_id = ?
+		UNION ALL
+		SELECT rp.id, tr.token_id, rp.resource, rp.zone_id, rp.allowed_actions, rp.record_types, 0, rp.role_id
+		FROM role_permissions rp JOIN token_roles tr ON tr.role_id = rp.role_id WHERE tr.token_id = ?
+		ORDER BY role_id ASC, id ASC`,
+		tokenID, tokenID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query permissions: %w", err)
 	}
@@ -286,7 +458,7 @@ func (s *SQLiteStorage) GetPermissionsForToken(ctx context.Context, tokenID int6
 		var p Permission
 		var allowedActionsJSON, recordTypesJSON string
 
-		if err := rows.Scan(&p.ID, &p.TokenID, &p.ZoneID, &allowedActionsJSON, &recordTypesJSON); err != nil {
+		if err := rows.Scan(&p.ID, &p.TokenID, &p.Resource, &p.ZoneID, &allowedActionsJSON, &recordTypesJSON, &p.TagPermissionID, &p.RoleID); err != nil {
 			return nil, fmt.Errorf("failed to scan permission row: %w", err)
 		}
 
@@ -314,6 +486,38 @@ func (s *SQLiteStorage) GetPermissionsForToken(ctx context.Context, tokenID int6
 	return permissions, nil
 }
 
+// encodePermission validates a token or role permission and returns its
+// JSON-encoded actions and record types. An empty perm.Resource is set to
+// ResourceDNSZone.
+func encodePermission(perm *Permission) (allowedActions, recordTypes []byte, err error) {
+	if perm.Resource == "" {
+		perm.Resource = ResourceDNSZone
+	}
+
+	// Validate input
+	if !IsValidResource(perm.Resource) {
+		return nil, nil, fmt.Errorf("invalid resource %q", perm.Resource)
+	}
+	if perm.ZoneID <= 0 {
+		return nil, nil, fmt.Errorf("invalid zone ID: must be greater than 0")
+	}
+	if len(perm.AllowedActions) == 0 {
+		return nil, nil, fmt.Errorf("allowed actions cannot be empty")
+	}
+	if len(perm.RecordTypes) == 0 && perm.Resource == ResourceDNSZone {
+		return nil, nil, fmt.Errorf("record types cannot be empty")
+	}
+
+	// JSON-encode arrays
+	if allowedActions, err = marshalStringArray(perm.AllowedActions); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal allowed actions: %w", err)
+	}
+	if recordTypes, err = marshalStringArray(perm.RecordTypes); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal record types: %w", err)
+	}
+	return allowedActions, recordTypes, nil
+}
+
 // marshalStringArray is a helper to marshal a string array to JSON.
 func marshalStringArray(arr []string) ([]byte, error) {
 	return json.Marshal(arr)
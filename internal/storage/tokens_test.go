@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"strings"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -138,6 +139,40 @@ func TestGetTokenByHash(t *testing.T) {
 	}
 }
 
+// TestCreateKeyToken verifies that a token for an <id>.<secret> key is found
+// by ID and secret hash, but not by the hash alone.
+func TestCreateKeyToken(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	hash := hashToken("issued-secret")
+	created, err := s.CreateKeyToken(ctx, "issued", false, hash)
+	if err != nil {
+		t.Fatalf("CreateKeyToken failed: %v", err)
+	}
+
+	got, err := s.GetTokenByKey(ctx, created.ID, hash)
+	if err != nil {
+		t.Fatalf("failed to get token by key: %v", err)
+	}
+	if got.ID != created.ID || got.Name != "issued" || got.IsAdmin {
+		t.Errorf("unexpected token: %+v", got)
+	}
+
+	if _, err := s.GetTokenByHash(ctx, hash); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound looking up the secret hash, got: %v", err)
+	}
+	if _, err := s.CreateToken(ctx, "other", false, hash); err != ErrDuplicate {
+		t.Errorf("expected ErrDuplicate for the same hash, got: %v", err)
+	}
+}
+
 // TestGetTokenByHashNotFound verifies ErrNotFound for non-existent hash.
 func TestGetTokenByHashNotFound(t *testing.T) {
 	t.Parallel()
@@ -342,6 +377,173 @@ func TestDeleteTokenNotFound(t *testing.T) {
 	}
 }
 
+// TestSetTokenExpiry verifies that token expiry is stored and returned by every lookup.
+func TestSetTokenExpiry(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	token, err := s.CreateToken(ctx, "ci", false, hashToken("ci-token"))
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if got, _ := s.GetTokenByID(ctx, token.ID); !got.ExpiresAt.IsZero() {
+		t.Errorf("expected new token to never expire, got %v", got.ExpiresAt)
+	}
+
+	deadline := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := s.SetTokenExpiry(ctx, token.ID, deadline); err != nil {
+		t.Fatalf("SetTokenExpiry failed: %v", err)
+	}
+
+	byID, _ := s.GetTokenByID(ctx, token.ID)
+	byHash, _ := s.GetTokenByHash(ctx, hashToken("ci-token"))
+	list, _ := s.ListTokens(ctx)
+	for _, got := range []*Token{byID, byHash, list[0]} {
+		if !got.ExpiresAt.Equal(deadline) {
+			t.Errorf("expected expiry %v, got %v", deadline, got.ExpiresAt)
+		}
+	}
+
+	// A zero time clears the expiry
+	if err := s.SetTokenExpiry(ctx, token.ID, time.Time{}); err != nil {
+		t.Fatalf("SetTokenExpiry failed: %v", err)
+	}
+	if got, _ := s.GetTokenByID(ctx, token.ID); !got.ExpiresAt.IsZero() {
+		t.Errorf("expected expiry to be cleared, got %v", got.ExpiresAt)
+	}
+
+	if err := s.SetTokenExpiry(ctx, 999, deadline); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+// TestTokenFingerprintPinning verifies pinning on first use and reset.
+func TestTokenFingerprintPinning(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	token, err := s.CreateToken(ctx, "ci", false, hashToken("ci-token"))
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if err := s.SetTokenPinning(ctx, token.ID, true); err != nil {
+		t.Fatalf("SetTokenPinning failed: %v", err)
+	}
+
+	// The first fingerprint wins; later ones do not replace it
+	for _, fp := range []string{"fp-a", "fp-b"} {
+		pinned, err := s.PinTokenFingerprint(ctx, token.ID, fp)
+		if err != nil {
+			t.Fatalf("PinTokenFingerprint failed: %v", err)
+		}
+		if pinned != "fp-a" {
+			t.Errorf("PinTokenFingerprint(%q) = %q, want fp-a", fp, pinned)
+		}
+	}
+
+	byID, _ := s.GetTokenByID(ctx, token.ID)
+	byHash, _ := s.GetTokenByHash(ctx, hashToken("ci-token"))
+	list, _ := s.ListTokens(ctx)
+	for _, got := range []*Token{byID, byHash, list[0]} {
+		if !got.PinTLSFingerprint || got.TLSFingerprint != "fp-a" {
+			t.Errorf("expected pinned fp-a, got %v %q", got.PinTLSFingerprint, got.TLSFingerprint)
+		}
+	}
+
+	// Re-enabling forgets the pinned fingerprint
+	if err := s.SetTokenPinning(ctx, token.ID, true); err != nil {
+		t.Fatalf("SetTokenPinning failed: %v", err)
+	}
+	if pinned, _ := s.PinTokenFingerprint(ctx, token.ID, "fp-b"); pinned != "fp-b" {
+		t.Errorf("expected fp-b after reset, got %q", pinned)
+	}
+
+	if err := s.SetTokenPinning(ctx, 999, true); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+	if _, err := s.PinTokenFingerprint(ctx, 999, "fp"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+}
+
+// TestRecordTokenUsage verifies batched use counts and last used times.
+func TestRecordTokenUsage(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	token, err := s.CreateToken(ctx, "ci", false, hashToken("ci-token"))
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+	if !token.LastUsedAt.IsZero() || token.UseCount != 0 {
+		t.Errorf("new token should be unused, got %v %d", token.LastUsedAt, token.UseCount)
+	}
+
+	later := time.Unix(1700000600, 0)
+	earlier := time.Unix(1700000000, 0)
+	batches := [][]TokenUsage{
+		{{TokenID: token.ID, Count: 3, LastUsedAt: later}},
+		// An older batch adds its count but does not move the last used time back
+		{{TokenID: token.ID, Count: 2, LastUsedAt: earlier}, {TokenID: 999, Count: 1, LastUsedAt: later}},
+		nil,
+	}
+	for _, batch := range batches {
+		if err := s.RecordTokenUsage(ctx, batch); err != nil {
+			t.Fatalf("RecordTokenUsage failed: %v", err)
+		}
+	}
+
+	byID, _ := s.GetTokenByID(ctx, token.ID)
+	byHash, _ := s.GetTokenByHash(ctx, hashToken("ci-token"))
+	list, _ := s.ListTokens(ctx)
+	for _, got := range []*Token{byID, byHash, list[0]} {
+		if got.UseCount != 5 || !got.LastUsedAt.Equal(later) {
+			t.Errorf("expected 5 uses last at %v, got %d at %v", later, got.UseCount, got.LastUsedAt)
+		}
+	}
+}
+
+// TestTokenIsExpired verifies the expiry check.
+func TestTokenIsExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		want      bool
+	}{
+		{"never", time.Time{}, false},
+		{"future", now.Add(time.Minute), false},
+		{"now", now, true},
+		{"past", now.Add(-time.Minute), true},
+	}
+	for _, tt := range tests {
+		token := &Token{ExpiresAt: tt.expiresAt}
+		if got := token.IsExpired(now); got != tt.want {
+			t.Errorf("%s: IsExpired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 // TestHasAnyAdminToken verifies the admin token check.
 func TestHasAnyAdminToken(t *testing.T) {
 	t.Parallel()
@@ -949,6 +1151,63 @@ func TestAddPermissionInvalidZoneID(t *testing.T) {
 	}
 }
 
+// TestAddPermissionResource verifies the resource default and that pull zone
+// permissions do not require record types.
+func TestAddPermissionResource(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	ctx := context.Background()
+
+	token, err := s.CreateToken(ctx, "test-token", false, hashToken("test-key"))
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	if _, err := s.AddPermissionForToken(ctx, token.ID, &Permission{
+		ZoneID:         1,
+		AllowedActions: []string{"list_records"},
+		RecordTypes:    []string{"TXT"},
+	}); err != nil {
+		t.Fatalf("failed to add DNS permission: %v", err)
+	}
+	if _, err := s.AddPermissionForToken(ctx, token.ID, &Permission{
+		Resource:       ResourcePullZone,
+		ZoneID:         2,
+		AllowedActions: []string{"purge_pull_zone"},
+	}); err != nil {
+		t.Fatalf("failed to add pull zone permission: %v", err)
+	}
+
+	perms, err := s.GetPermissionsForToken(ctx, token.ID)
+	if err != nil {
+		t.Fatalf("failed to get permissions: %v", err)
+	}
+	if len(perms) != 2 {
+		t.Fatalf("expected 2 permissions, got %d", len(perms))
+	}
+	if perms[0].Resource != ResourceDNSZone {
+		t.Errorf("expected default resource %q, got %q", ResourceDNSZone, perms[0].Resource)
+	}
+	if perms[1].Resource != ResourcePullZone || perms[1].ZoneID != 2 {
+		t.Errorf("unexpected pull zone permission: %+v", perms[1])
+	}
+
+	_, err = s.AddPermissionForToken(ctx, token.ID, &Permission{
+		Resource:       "bogus",
+		ZoneID:         3,
+		AllowedActions: []string{"list_records"},
+		RecordTypes:    []string{"TXT"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid resource") {
+		t.Errorf("expected invalid resource error, got %v", err)
+	}
+}
+
 // TestAddPermissionEmptyActions tests validation of AllowedActions in AddPermissionForToken.
 func TestAddPermissionEmptyActions(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetTTLPolicy creates or replaces the policy of a zone, or the global policy
+// for zone 0. UpdatedAt defaults to now and is stored with second precision.
+func (s *SQLiteStorage) SetTTLPolicy(ctx context.Context, p *TTLPolicy) (*TTLPolicy, error) {
+	if p.ZoneID < 0 {
+		return nil, fmt.Errorf("invalid zone ID %d", p.ZoneID)
+	}
+	if p.MinTTL < 0 || p.MaxTTL < 0 || p.DefaultTTL < 0 {
+		return nil, fmt.Errorf("TTL policy values must not be negative")
+	}
+
+	updatedAt := p.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO ttl_policies (zone_id, min_ttl, max_ttl, default_ttl, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (zone_id) DO UPDATE SET
+			min_ttl = excluded.min_ttl,
+			max_ttl = excluded.max_ttl,
+			default_ttl = excluded.default_ttl,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at`,
+		p.ZoneID, p.MinTTL, p.MaxTTL, p.DefaultTTL, p.UpdatedBy, updatedAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to set TTL policy: %w", err)
+	}
+
+	policy := *p
+	policy.UpdatedAt = time.Unix(updatedAt.Unix(), 0)
+	return &policy, nil
+}
+
+// ListTTLPolicies returns all policies, the global one first, then by zone ID.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListTTLPolicies(ctx context.Context) ([]*TTLPolicy, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT zone_id, min_ttl, max_ttl, default_ttl, updated_by, updated_at FROM ttl_policies ORDER BY zone_id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query TTL policies: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	policies := make([]*TTLPolicy, 0)
+	for rows.Next() {
+		var p TTLPolicy
+		var updatedAt int64
+		if err := rows.Scan(&p.ZoneID, &p.MinTTL, &p.MaxTTL, &p.DefaultTTL, &p.UpdatedBy, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan TTL policy row: %w", err)
+		}
+		p.UpdatedAt = time.Unix(updatedAt, 0)
+		policies = append(policies, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating TTL policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// DeleteTTLPolicy removes the policy of a zone, or the global policy for zone 0.
+// Returns ErrNotFound if there is none.
+func (s *SQLiteStorage) DeleteTTLPolicy(ctx context.Context, zoneID int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM ttl_policies WHERE zone_id = ?", zoneID)
+	if err != nil {
+		return fmt.Errorf("failed to delete TTL policy: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -4,19 +4,400 @@ import "time"
 
 // Token represents a unified token for admin or scoped access.
 type Token struct {
-	ID        int64
+	ID int64
+	// KeyHash is the SHA-256 hash of the key (of its secret, for keys of the
+	// form <id>.<secret>), as given to CreateToken, GetTokenByHash and
+	// GetTokenByKey. Tokens read any other way carry the stored form, an
+	// Argon2id digest of it when token hashing is enabled.
 	KeyHash   string
 	Name      string
 	IsAdmin   bool
 	CreatedAt time.Time
+	ExpiresAt time.Time // zero for tokens that never expire
+
+	// PinTLSFingerprint binds the token to the TLS client fingerprint seen on
+	// first use. TLSFingerprint is empty until then.
+	PinTLSFingerprint bool
+	TLSFingerprint    string
+
+	// Account names the upstream bunny.net account the token's requests are
+	// sent to; empty means the default account.
+	Account string
+
+	// LastUsedAt is when the token last authenticated a request (zero if never)
+	// and UseCount how many requests it has authenticated. Both are updated in
+	// batches, so they can lag behind by the tracker's flush interval.
+	LastUsedAt time.Time
+	UseCount   int64
+}
+
+// TokenUsage is a batch of uses of one token, added by RecordTokenUsage.
+type TokenUsage struct {
+	TokenID    int64
+	Count      int64
+	LastUsedAt time.Time
+}
+
+// IsExpired reports whether the token has expired at the given time.
+func (t *Token) IsExpired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
 }
 
 // Permission represents access rules for a token.
 type Permission struct {
-	ID             int64
-	TokenID        int64
+	ID      int64
+	TokenID int64
+	// Resource is the kind of bunny.net object ZoneID refers to. Empty means
+	// ResourceDNSZone.
+	Resource       string
 	ZoneID         int64
 	AllowedActions []string // e.g., ["list_records", "add_record", "delete_record"]
-	RecordTypes    []string // e.g., ["TXT", "A", "AAAA"]
+	RecordTypes    []string // e.g., ["TXT", "A", "AAAA"]; DNS zones only
+	// TagPermissionID is the tag permission that created this permission,
+	// or 0 for permissions added directly. Tag sync adds and removes these.
+	TagPermissionID int64
+	// RoleID is the role that grants this permission, or 0 for the token's
+	// own permissions. ID is then the role permission's ID.
+	RoleID    int64
+	CreatedAt time.Time
+}
+
+// Role is a named set of permissions. Tokens holding a role get its current
+// permissions in addition to their own.
+type Role struct {
+	ID          int64
+	Name        string
+	Description string
+	Permissions []*Permission // TokenID is 0 and RoleID is ID
+	TokenCount  int           // tokens holding the role
+	CreatedAt   time.Time
+}
+
+// TagPermission grants a token a DNS zone permission on every zone carrying
+// Tag. It is materialized as one Permission per tagged zone.
+type TagPermission struct {
+	ID             int64
+	TokenID        int64
+	Tag            string
+	AllowedActions []string
+	RecordTypes    []string
+	CreatedAt      time.Time
+}
+
+// DomainPermission grants a token a DNS zone permission on every zone whose
+// domain matches Pattern: a domain such as "example.com", or "*." and a
+// domain, such as "*.customer1.example.com", for every zone below it.
+type DomainPermission struct {
+	ID             int64
+	TokenID        int64
+	Pattern        string
+	AllowedActions []string
+	RecordTypes    []string
 	CreatedAt      time.Time
 }
+
+// IdempotentResponse is the stored response to a mutating request sent with
+// an Idempotency-Key. Fingerprint identifies the request the key was first
+// used for; Status is 0 while that request is still in progress.
+type IdempotentResponse struct {
+	TokenID     int64
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// ZoneTag labels a DNS zone with a tag. Zone tags are derived from naming
+// rules or an external source and replaced wholesale on every sync.
+type ZoneTag struct {
+	ZoneID int64
+	Domain string
+	Tag    string
+}
+
+// Resources a permission can grant access to.
+const (
+	ResourceDNSZone     = "dnszone"
+	ResourcePullZone    = "pullzone"
+	ResourceStorageZone = "storagezone"
+)
+
+// IsValidResource reports whether r names a known permission resource.
+func IsValidResource(r string) bool {
+	return r == ResourceDNSZone || r == ResourcePullZone || r == ResourceStorageZone
+}
+
+// Webhook represents a registered webhook endpoint.
+// Secret is needed to sign outgoing payloads, so it is stored reversibly:
+// encrypted when the storage has an encryption key, otherwise in plaintext.
+type Webhook struct {
+	ID        int64
+	URL       string
+	Secret    string
+	Events    []string // subscribed event names; empty means all events
+	Enabled   bool
+	CreatedAt time.Time
+
+	// TokenID is the scoped token that owns this webhook; 0 for admin-managed webhooks.
+	TokenID int64
+
+	// ZoneIDs limits delivery to events for these zones; empty means all zones.
+	ZoneIDs []int64
+}
+
+// WebhookDelivery records a single delivery attempt to a webhook.
+type WebhookDelivery struct {
+	ID            int64
+	WebhookID     int64
+	EventID       string
+	Event         string
+	SchemaVersion int
+	StatusCode    int    // 0 if no HTTP response was received
+	Success       bool   // true for 2xx responses
+	Error         string // transport error or truncated response body
+	DurationMS    int64
+	CreatedAt     time.Time
+}
+
+// Outbox event statuses.
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusDelivered = "delivered"
+	OutboxStatusDead      = "dead"
+)
+
+// DBStats describes the size of the database.
+type DBStats struct {
+	PageSize      int64
+	PageCount     int64
+	FreelistCount int64 // unused pages that a vacuum can reclaim
+	FileSize      int64 // bytes on disk, including the write-ahead log
+}
+
+// AuditEntry records one authenticated proxy or admin API request.
+type AuditEntry struct {
+	ID         int64
+	TokenID    int64 // 0 for the master key
+	TokenName  string
+	Action     string // auth.Action, empty if the request could not be parsed
+	ZoneID     int64
+	RecordType string
+	Method     string
+	Path       string
+	Status     int
+	RequestID  string
+	CreatedAt  time.Time
+}
+
+// AuditFilter selects audit entries. Zero values match everything.
+type AuditFilter struct {
+	TokenID    int64
+	ZoneID     int64
+	Action     string
+	RecordType string
+	MinStatus  int       // inclusive
+	MaxStatus  int       // inclusive
+	Since      time.Time // inclusive
+	Until      time.Time // exclusive
+	BeforeID   int64     // only entries with a smaller ID, for paging through results
+	Limit      int
+}
+
+// Instance is a proxy process registered in the database.
+type Instance struct {
+	ID         string
+	Hostname   string
+	Version    string
+	StartedAt  time.Time
+	LastSeenAt time.Time
+}
+
+// BlocklistEntry is a client IP address or network whose requests are rejected.
+type BlocklistEntry struct {
+	ID        int64
+	CIDR      string // normalized prefix, e.g. "203.0.113.7/32"
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time // zero for a permanent ban
+}
+
+// ZoneFreeze is a window during which mutating proxy requests to a zone are
+// rejected.
+type ZoneFreeze struct {
+	ID        int64
+	ZoneID    int64
+	Reason    string
+	CreatedBy string
+	StartsAt  time.Time
+	EndsAt    time.Time // zero for a freeze that lasts until it is lifted
+	CreatedAt time.Time
+}
+
+// TTLPolicy bounds and defaults the TTLs of records written through the
+// proxy, in seconds. ZoneID is 0 for the global policy. A bound or default of
+// 0 is not set.
+type TTLPolicy struct {
+	ZoneID     int64
+	MinTTL     int32
+	MaxTTL     int32
+	DefaultTTL int32
+	UpdatedBy  string
+	UpdatedAt  time.Time
+}
+
+// ProtectedRecord marks records in a zone that scoped tokens may not update
+// or delete, even when their permissions would allow it.
+type ProtectedRecord struct {
+	ID         int64
+	ZoneID     int64
+	Name       string // relative to the zone, lowercase; empty for the apex
+	RecordType string // canonical type name; empty for every type
+	Reason     string
+	CreatedBy  string
+	CreatedAt  time.Time
+}
+
+// Invitation is a one-time link that mints a scoped token holding RoleIDs
+// when claimed, so the token's secret is only ever seen by the claimant.
+type Invitation struct {
+	ID        int64
+	TokenName string
+	RoleIDs   []int64
+	// TokenTTL is how long the minted token lasts; zero means it never expires.
+	TokenTTL  time.Duration
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	// ClaimedAt is zero until the invitation is claimed.
+	ClaimedAt time.Time
+	// TokenID is the minted token, or 0 if unclaimed or the token was deleted.
+	TokenID int64
+}
+
+// AdminSession is a short-lived credential exchanged for an admin token.
+// It is only accepted from the client address and user agent that created it.
+type AdminSession struct {
+	ID        int64
+	TokenID   int64
+	ClientIP  string
+	UserAgent string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// OutboxEvent is an event persisted for reliable, at-least-once delivery.
+// It stays pending until every sink has accepted it or it exhausts its attempts.
+type OutboxEvent struct {
+	ID            int64
+	EventID       string // stable across retries so receivers can deduplicate
+	Event         string
+	Payload       []byte // JSON-encoded event data
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
+
+// RecordMeta holds free-form annotations (owner, ticket, purpose, ...) for a DNS record.
+// bunny.net has no record comments, so these are stored proxy-side only.
+type RecordMeta struct {
+	ZoneID    int64
+	RecordID  int64
+	Meta      map[string]string
+	UpdatedBy string // token name that last changed the metadata
+	UpdatedAt time.Time
+}
+
+// Job is a long-running operation run by a background worker, such as an
+// asynchronous zone import. Result holds the operation's JSON response once
+// it has finished.
+type Job struct {
+	ID         int64
+	Kind       string
+	ZoneID     int64
+	TokenID    int64 // 0 for the master key
+	Status     string
+	Total      int // items to process, 0 if unknown
+	Processed  int
+	Result     []byte
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Job kinds.
+const (
+	JobKindImport = "import"
+)
+
+// Job statuses.
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// RecordChange is a snapshot of one DNS record mutation made through the
+// proxy. Before and After hold the record as JSON; Before is nil for a
+// create and After is nil for a delete.
+type RecordChange struct {
+	ID        int64
+	ZoneID    int64
+	RecordID  int64
+	Action    string
+	Before    []byte
+	After     []byte
+	Actor     string // token name that made the change
+	Account   string // upstream bunny.net account, '' for the default one
+	CreatedAt time.Time
+}
+
+// Record change actions.
+const (
+	RecordChangeCreate = "create"
+	RecordChangeUpdate = "update"
+	RecordChangeDelete = "delete"
+)
+
+// RecordChangeFilter selects a zone's record changes. Zero values match everything.
+type RecordChangeFilter struct {
+	RecordID int64
+	BeforeID int64 // only changes with a smaller ID, for paging through results
+	Limit    int
+}
+
+// ScheduledChange is a record create or delete a client asked the proxy to
+// make at a later time. Request holds the record to create as JSON; RecordID
+// is the record to delete, or the record a create made once it has run.
+type ScheduledChange struct {
+	ID         int64
+	ZoneID     int64
+	Action     string // RecordChangeCreate or RecordChangeDelete
+	RecordID   int64
+	Request    []byte
+	TokenID    int64  // 0 for the master key
+	Actor      string // token name that scheduled the change
+	Account    string // upstream bunny.net account, '' for the default one
+	RunAt      time.Time
+	Status     string
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Scheduled change statuses. Changes are pending until they are due, then
+// running, then succeeded or failed; pending changes can be cancelled.
+const (
+	ScheduledChangePending   = "pending"
+	ScheduledChangeRunning   = "running"
+	ScheduledChangeSucceeded = "succeeded"
+	ScheduledChangeFailed    = "failed"
+	ScheduledChangeCancelled = "cancelled"
+)
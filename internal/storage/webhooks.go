@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// webhookDeliveryHistoryLimit is the number of delivery attempts kept per webhook.
+// Older attempts are pruned when a new one is recorded.
+const webhookDeliveryHistoryLimit = 100
+
+// CreateWebhook registers a new webhook endpoint.
+// The hook.Events and hook.ZoneIDs slices are JSON-encoded for storage.
+// Returns the webhook with its ID populated.
+func (s *SQLiteStorage) CreateWebhook(ctx context.Context, hook *Webhook) (*Webhook, error) {
+	if hook.URL == "" {
+		return nil, fmt.Errorf("webhook URL cannot be empty")
+	}
+	if hook.Secret == "" {
+		return nil, fmt.Errorf("webhook secret cannot be empty")
+	}
+
+	events := hook.Events
+	if events == nil {
+		events = []string{}
+	}
+	eventsJSON, err := marshalStringArray(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	zoneIDs := hook.ZoneIDs
+	if zoneIDs == nil {
+		zoneIDs = []int64{}
+	}
+	zoneIDsJSON, err := json.Marshal(zoneIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal zone IDs: %w", err)
+	}
+
+	tokenID := sql.NullInt64{Int64: hook.TokenID, Valid: hook.TokenID != 0}
+
+	secret, err := s.sealSecret(hook.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"INSERT INTO webhooks (url, secret, events, enabled, token_id, zone_ids) VALUES (?, ?, ?, ?, ?, ?)",
+		hook.URL, secret, string(eventsJSON), hook.Enabled, tokenID, string(zoneIDsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	return s.GetWebhook(ctx, id)
+}
+
+// GetWebhook retrieves a webhook by ID.
+// Returns ErrNotFound if the webhook doesn't exist.
+func (s *SQLiteStorage) GetWebhook(ctx context.Context, id int64) (*Webhook, error) {
+	row := s.readDB.QueryRowContext(ctx,
+		"SELECT "+webhookColumns+" FROM webhooks WHERE id = ?",
+		id)
+
+	hook, err := s.scanWebhook(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return hook, nil
+}
+
+// ListWebhooks retrieves all webhooks in creation order.
+// Returns empty slice if no webhooks exist.
+func (s *SQLiteStorage) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT "+webhookColumns+" FROM webhooks ORDER BY id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	hooks := make([]*Webhook, 0)
+	for rows.Next() {
+		hook, err := s.scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook row: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+// CountWebhooksByToken returns the number of webhooks owned by a token.
+func (s *SQLiteStorage) CountWebhooksByToken(ctx context.Context, tokenID int64) (int, error) {
+	var count int
+	err := s.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM webhooks WHERE token_id = ?", tokenID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteWebhook deletes a webhook by ID.
+// Returns ErrNotFound if the webhook doesn't exist.
+// Cascades to delivery history via foreign key constraint.
+func (s *SQLiteStorage) DeleteWebhook(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// RecordWebhookDelivery stores a delivery attempt and prunes history
+// beyond webhookDeliveryHistoryLimit for the same webhook.
+func (s *SQLiteStorage) RecordWebhookDelivery(ctx context.Context, d *WebhookDelivery) (*WebhookDelivery, error) {
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO webhook_deliveries
+			(webhook_id, event_id, event, schema_version, status_code, success, error, duration_ms)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		d.WebhookID, d.EventID, d.Event, d.SchemaVersion, d.StatusCode, d.Success, d.Error, d.DurationMS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	// Keep only the most recent attempts for this webhook
+	_, err = s.db.ExecContext(ctx,
+		`DELETE FROM webhook_deliveries WHERE webhook_id = ? AND id NOT IN (
+			SELECT id FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?
+		)`,
+		d.WebhookID, d.WebhookID, webhookDeliveryHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune webhook deliveries: %w", err)
+	}
+
+	d.ID = id
+	return d, nil
+}
+
+// ListWebhookDeliveries retrieves the most recent delivery attempts for a webhook, newest first.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]*WebhookDelivery, error) {
+	if limit <= 0 || limit > webhookDeliveryHistoryLimit {
+		limit = webhookDeliveryHistoryLimit
+	}
+
+	rows, err := s.readDB.QueryContext(ctx,
+		`SELECT id, webhook_id, event_id, event, schema_version, status_code, success, error, duration_ms, created_at
+			FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?`,
+		webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		var d WebhookDelivery
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &d.SchemaVersion,
+			&d.StatusCode, &d.Success, &d.Error, &d.DurationMS, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// webhookColumns lists the columns read by scanWebhook, in order.
+const webhookColumns = "id, url, secret, events, enabled, token_id, zone_ids, created_at"
+
+// scanWebhook scans a webhook row, decrypts its secret and decodes its
+// events and zone arrays.
+func (s *SQLiteStorage) scanWebhook(row rowScanner) (*Webhook, error) {
+	var hook Webhook
+	var eventsJSON, zoneIDsJSON string
+	var tokenID sql.NullInt64
+
+	if err := row.Scan(&hook.ID, &hook.URL, &hook.Secret, &eventsJSON, &hook.Enabled,
+		&tokenID, &zoneIDsJSON, &hook.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalStringArray(eventsJSON, &hook.Events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal events: %w", err)
+	}
+	if err := json.Unmarshal([]byte(zoneIDsJSON), &hook.ZoneIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal zone IDs: %w", err)
+	}
+	hook.TokenID = tokenID.Int64
+
+	secret, err := s.openSecret(hook.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	hook.Secret = secret
+
+	return &hook, nil
+}
@@ -0,0 +1,234 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// newWebhookTestStorage creates an in-memory storage with a single registered webhook.
+func newWebhookTestStorage(t *testing.T) (*SQLiteStorage, *Webhook) {
+	t.Helper()
+
+	s, err := New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	hook, err := s.CreateWebhook(context.Background(), &Webhook{
+		URL:     "https://example.com/hook",
+		Secret:  "whsec_test",
+		Events:  []string{"record.created"},
+		Enabled: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+
+	return s, hook
+}
+
+func TestCreateWebhook(t *testing.T) {
+	t.Parallel()
+	s, hook := newWebhookTestStorage(t)
+
+	if hook.ID <= 0 {
+		t.Errorf("expected positive ID, got %d", hook.ID)
+	}
+	if hook.URL != "https://example.com/hook" || hook.Secret != "whsec_test" || !hook.Enabled {
+		t.Errorf("unexpected webhook: %+v", hook)
+	}
+	if len(hook.Events) != 1 || hook.Events[0] != "record.created" {
+		t.Errorf("unexpected events: %v", hook.Events)
+	}
+	if hook.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+
+	// Nil events are stored as an empty list
+	all, err := s.CreateWebhook(context.Background(), &Webhook{URL: "https://example.com/all", Secret: "x"})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if all.Events == nil || len(all.Events) != 0 {
+		t.Errorf("expected empty events, got %v", all.Events)
+	}
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	t.Parallel()
+	s, _ := newWebhookTestStorage(t)
+
+	if _, err := s.CreateWebhook(context.Background(), &Webhook{Secret: "x"}); err == nil {
+		t.Error("expected error for empty URL")
+	}
+	if _, err := s.CreateWebhook(context.Background(), &Webhook{URL: "https://example.com"}); err == nil {
+		t.Error("expected error for empty secret")
+	}
+}
+
+func TestGetWebhookNotFound(t *testing.T) {
+	t.Parallel()
+	s, _ := newWebhookTestStorage(t)
+
+	_, err := s.GetWebhook(context.Background(), 999)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListWebhooks(t *testing.T) {
+	t.Parallel()
+	s, hook := newWebhookTestStorage(t)
+
+	hooks, err := s.ListWebhooks(context.Background())
+	if err != nil {
+		t.Fatalf("ListWebhooks failed: %v", err)
+	}
+	if len(hooks) != 1 || hooks[0].ID != hook.ID {
+		t.Errorf("unexpected webhooks: %+v", hooks)
+	}
+}
+
+func TestWebhookTokenOwnership(t *testing.T) {
+	t.Parallel()
+	s, admin := newWebhookTestStorage(t)
+	ctx := context.Background()
+
+	if admin.TokenID != 0 || admin.ZoneIDs == nil || len(admin.ZoneIDs) != 0 {
+		t.Errorf("expected unowned webhook with no zone filter, got %+v", admin)
+	}
+
+	token, err := s.CreateToken(ctx, "team", false, "hash-team")
+	if err != nil {
+		t.Fatalf("CreateToken failed: %v", err)
+	}
+
+	owned, err := s.CreateWebhook(ctx, &Webhook{URL: "https://team.example.com", Secret: "x", TokenID: token.ID, ZoneIDs: []int64{10, 20}})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if owned.TokenID != token.ID || len(owned.ZoneIDs) != 2 || owned.ZoneIDs[1] != 20 {
+		t.Errorf("unexpected owned webhook: %+v", owned)
+	}
+
+	count, err := s.CountWebhooksByToken(ctx, token.ID)
+	if err != nil || count != 1 {
+		t.Errorf("CountWebhooksByToken = %d, %v; want 1", count, err)
+	}
+
+	// Deleting the token removes its webhooks
+	if err := s.DeleteToken(ctx, token.ID); err != nil {
+		t.Fatalf("DeleteToken failed: %v", err)
+	}
+	if _, err := s.GetWebhook(ctx, owned.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected owned webhook to be deleted with its token, got %v", err)
+	}
+	if _, err := s.GetWebhook(ctx, admin.ID); err != nil {
+		t.Errorf("expected admin webhook to remain, got %v", err)
+	}
+}
+
+func TestDeleteWebhookCascadesDeliveries(t *testing.T) {
+	t.Parallel()
+	s, hook := newWebhookTestStorage(t)
+	ctx := context.Background()
+
+	if _, err := s.RecordWebhookDelivery(ctx, &WebhookDelivery{WebhookID: hook.ID, EventID: "e1", Event: "webhook.test", SchemaVersion: 1}); err != nil {
+		t.Fatalf("RecordWebhookDelivery failed: %v", err)
+	}
+
+	if err := s.DeleteWebhook(ctx, hook.ID); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = ?", hook.ID).Scan(&count); err != nil {
+		t.Fatalf("failed to count deliveries: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected deliveries to be cascaded, got %d", count)
+	}
+
+	if err := s.DeleteWebhook(ctx, hook.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound on second delete, got %v", err)
+	}
+}
+
+func TestRecordWebhookDelivery(t *testing.T) {
+	t.Parallel()
+	s, hook := newWebhookTestStorage(t)
+	ctx := context.Background()
+
+	d, err := s.RecordWebhookDelivery(ctx, &WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventID:       "evt-1",
+		Event:         "webhook.test",
+		SchemaVersion: 1,
+		StatusCode:    500,
+		Error:         "boom",
+		DurationMS:    12,
+	})
+	if err != nil {
+		t.Fatalf("RecordWebhookDelivery failed: %v", err)
+	}
+	if d.ID <= 0 {
+		t.Errorf("expected positive ID, got %d", d.ID)
+	}
+
+	deliveries, err := s.ListWebhookDeliveries(ctx, hook.ID, 10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+	got := deliveries[0]
+	if got.EventID != "evt-1" || got.StatusCode != 500 || got.Success || got.Error != "boom" || got.DurationMS != 12 {
+		t.Errorf("unexpected delivery: %+v", got)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+}
+
+func TestRecordWebhookDeliveryPrunesHistory(t *testing.T) {
+	t.Parallel()
+	s, hook := newWebhookTestStorage(t)
+	ctx := context.Background()
+
+	total := webhookDeliveryHistoryLimit + 5
+	for i := 0; i < total; i++ {
+		_, err := s.RecordWebhookDelivery(ctx, &WebhookDelivery{
+			WebhookID:     hook.ID,
+			EventID:       fmt.Sprintf("evt-%d", i),
+			Event:         "webhook.test",
+			SchemaVersion: 1,
+			Success:       true,
+		})
+		if err != nil {
+			t.Fatalf("RecordWebhookDelivery %d failed: %v", i, err)
+		}
+	}
+
+	deliveries, err := s.ListWebhookDeliveries(ctx, hook.ID, 0)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(deliveries) != webhookDeliveryHistoryLimit {
+		t.Fatalf("expected %d deliveries, got %d", webhookDeliveryHistoryLimit, len(deliveries))
+	}
+	if deliveries[0].EventID != fmt.Sprintf("evt-%d", total-1) {
+		t.Errorf("expected newest first, got %s", deliveries[0].EventID)
+	}
+
+	limited, err := s.ListWebhookDeliveries(ctx, hook.ID, 3)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(limited) != 3 {
+		t.Errorf("expected 3 deliveries, got %d", len(limited))
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AddZoneFreeze adds a freeze window.
+// StartsAt and CreatedAt default to now; times are stored with second precision.
+func (s *SQLiteStorage) AddZoneFreeze(ctx context.Context, f *ZoneFreeze) (*ZoneFreeze, error) {
+	if f.ZoneID <= 0 {
+		return nil, fmt.Errorf("zone freeze requires a zone ID")
+	}
+
+	createdAt := f.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	startsAt := f.StartsAt
+	if startsAt.IsZero() {
+		startsAt = createdAt
+	}
+	var endsAt int64
+	if !f.EndsAt.IsZero() {
+		endsAt = f.EndsAt.Unix()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO zone_freezes (zone_id, reason, created_by, starts_at, ends_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		f.ZoneID, f.Reason, f.CreatedBy, startsAt.Unix(), endsAt, createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to add zone freeze: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insert ID: %w", err)
+	}
+
+	freeze := *f
+	freeze.ID = id
+	freeze.StartsAt = time.Unix(startsAt.Unix(), 0)
+	freeze.EndsAt = unixOrZero(endsAt)
+	freeze.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	return &freeze, nil
+}
+
+// ListZoneFreezes returns all freezes, including ended ones not yet pruned, in creation order.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListZoneFreezes(ctx context.Context) ([]*ZoneFreeze, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT id, zone_id, reason, created_by, starts_at, ends_at, created_at FROM zone_freezes ORDER BY id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zone freezes: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	freezes := make([]*ZoneFreeze, 0)
+	for rows.Next() {
+		var f ZoneFreeze
+		var startsAt, endsAt, createdAt int64
+		if err := rows.Scan(&f.ID, &f.ZoneID, &f.Reason, &f.CreatedBy, &startsAt, &endsAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan zone freeze row: %w", err)
+		}
+		f.StartsAt = time.Unix(startsAt, 0)
+		f.EndsAt = unixOrZero(endsAt)
+		f.CreatedAt = time.Unix(createdAt, 0)
+		freezes = append(freezes, &f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating zone freezes: %w", err)
+	}
+
+	return freezes, nil
+}
+
+// DeleteZoneFreeze removes a freeze by ID.
+// Returns ErrNotFound if the freeze doesn't exist.
+func (s *SQLiteStorage) DeleteZoneFreeze(ctx context.Context, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM zone_freezes WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete zone freeze: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PruneZoneFreezes deletes freezes that ended before the cutoff and returns
+// how many were removed. Freezes without an end are never pruned.
+func (s *SQLiteStorage) PruneZoneFreezes(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM zone_freezes WHERE ends_at != 0 AND ends_at < ?", before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune zone freezes: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReplaceZoneTags replaces all zone tags with tags in one transaction.
+// Duplicate zone and tag pairs are stored once.
+func (s *SQLiteStorage) ReplaceZoneTags(ctx context.Context, tags []ZoneTag) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin zone tags transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM zone_tags"); err != nil {
+		return fmt.Errorf("failed to clear zone tags: %w", err)
+	}
+	for _, t := range tags {
+		if t.ZoneID <= 0 || t.Tag == "" {
+			return fmt.Errorf("invalid zone tag %d %q", t.ZoneID, t.Tag)
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO zone_tags (zone_id, tag, domain) VALUES (?, ?, ?)",
+			t.ZoneID, t.Tag, t.Domain); err != nil {
+			return fmt.Errorf("failed to insert zone tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit zone tags: %w", err)
+	}
+	return nil
+}
+
+// ListZoneTags returns all zone tags ordered by tag, then zone ID.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListZoneTags(ctx context.Context) ([]*ZoneTag, error) {
+	rows, err := s.readDB.QueryContext(ctx, "SELECT zone_id, tag, domain FROM zone_tags ORDER BY tag ASC, zone_id ASC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query zone tags: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	tags := make([]*ZoneTag, 0)
+	for rows.Next() {
+		var t ZoneTag
+		if err := rows.Scan(&t.ZoneID, &t.Tag, &t.Domain); err != nil {
+			return nil, fmt.Errorf("failed to scan zone tag row: %w", err)
+		}
+		tags = append(tags, &t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating zone tags: %w", err)
+	}
+	return tags, nil
+}
+
+// AddTagPermission grants a token a permission on every zone with a tag.
+// Permissions for already tagged zones are only created by SyncTagPermissions.
+func (s *SQLiteStorage) AddTagPermission(ctx context.Context, p *TagPermission) (*TagPermission, error) {
+	if p.Tag == "" {
+		return nil, fmt.Errorf("tag cannot be empty")
+	}
+	if len(p.AllowedActions) == 0 {
+		return nil, fmt.Errorf("allowed actions cannot be empty")
+	}
+	if len(p.RecordTypes) == 0 {
+		return nil, fmt.Errorf("record types cannot be empty")
+	}
+
+	allowedActionsJSON, err := marshalStringArray(p.AllowedActions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal allowed actions: %w", err)
+	}
+	recordTypesJSON, err := marshalStringArray(p.RecordTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal record types: %w", err)
+	}
+
+	createdAt := p.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"INSERT INTO tag_permissions (token_id, tag, allowed_actions, record_types, created_at) VALUES (?, ?, ?, ?, ?)",
+		p.TokenID, p.Tag, string(allowedActionsJSON), string(recordTypesJSON), createdAt.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert tag permission: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	created := *p
+	created.ID = id
+	created.CreatedAt = time.Unix(createdAt.Unix(), 0)
+	return &created, nil
+}
+
+// ListTagPermissions returns a token's tag permissions in creation order.
+// Returns empty slice if there are none.
+func (s *SQLiteStorage) ListTagPermissions(ctx context.Context, tokenID int64) ([]*TagPermission, error) {
+	rows, err := s.readDB.QueryContext(ctx,
+		"SELECT id, token_id, tag, allowed_actions, record_types, created_at FROM tag_permissions WHERE token_id = ? ORDER BY id ASC",
+		tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag permissions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	perms := make([]*TagPermission, 0)
+	for rows.Next() {
+		var p TagPermission
+		var allowedActionsJSON, recordTypesJSON string
+		var createdAt int64
+		if err := rows.Scan(&p.ID, &p.TokenID, &p.Tag, &allowedActionsJSON, &recordTypesJSON, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag permission row: %w", err)
+		}
+		if err := unmarshalStringArray(allowedActionsJSON, &p.AllowedActions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal allowed actions: %w", err)
+		}
+		if err := unmarshalStringArray(recordTypesJSON, &p.RecordTypes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal record types: %w", err)
+		}
+		p.CreatedAt = time.Unix(createdAt, 0)
+		perms = append(perms, &p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag permissions: %w", err)
+	}
+	return perms, nil
+}
+
+// DeleteTagPermission deletes a token's tag permission and the permissions
+// it created. Returns ErrNotFound if the tag permission doesn't exist or
+// doesn't belong to the token.
+func (s *SQLiteStorage) DeleteTagPermission(ctx context.Context, tokenID, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin tag permission transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM tag_permissions WHERE id = ? AND token_id = ?", id, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag permission: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM permissions WHERE tag_permission_id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete tag permission's permissions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tag permission deletion: %w", err)
+	}
+	return nil
+}
+
+// SyncTagPermissions brings the permissions created by tag permissions in
+// line with the current zone tags: zones that gained a tag get a permission,
+// and permissions for zones that lost it are removed. Permissions added
+// directly are never touched. Returns how many permissions were added and removed.
+func (s *SQLiteStorage) SyncTagPermissions(ctx context.Context) (added, removed int64, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin tag sync transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM permissions
+		WHERE tag_permission_id != 0 AND NOT EXISTS (
+			SELECT 1 FROM tag_permissions tp JOIN zone_tags zt ON zt.tag = tp.tag
+			WHERE tp.id = permissions.tag_permission_id AND zt.zone_id = permissions.zone_id
+		)`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to remove stale tag permissions: %w", err)
+	}
+	if removed, err = rowsAffected(result); err != nil {
+		return 0, 0, err
+	}
+
+	result, err = tx.ExecContext(ctx, `INSERT INTO permissions (token_id, resource, zone_id, allowed_actions, record_types, tag_permission_id)
+		SELECT tp.token_id, ?, zt.zone_id, tp.allowed_actions, tp.record_types, tp.id
+		FROM tag_permissions tp JOIN zone_tags zt ON zt.tag = tp.tag
+		WHERE NOT EXISTS (
+			SELECT 1 FROM permissions p WHERE p.tag_permission_id = tp.id AND p.zone_id = zt.zone_id
+		)
+		ORDER BY tp.id, zt.zone_id`, ResourceDNSZone)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to add tag permissions: %w", err)
+	}
+	if added, err = rowsAffected(result); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit tag sync: %w", err)
+	}
+	return added, removed, nil
+}
+
+// rowsAffected returns the number of rows a statement changed.
+func rowsAffected(result sql.Result) (int64, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -23,9 +23,13 @@ type CreateRecordRequest struct {
 
 // StateResponse is the response for GET /admin/state
 type StateResponse struct {
-	Zones        []Zone `json:"zones"`
-	NextZoneID   int64  `json:"nextZoneId"`
-	NextRecordID int64  `json:"nextRecordId"`
+	Zones             []Zone        `json:"zones"`
+	NextZoneID        int64         `json:"nextZoneId"`
+	NextRecordID      int64         `json:"nextRecordId"`
+	PullZones         []PullZone    `json:"pullZones,omitempty"`
+	StorageZones      []StorageZone `json:"storageZones,omitempty"`
+	NextPullZoneID    int64         `json:"nextPullZoneId,omitempty"`
+	NextStorageZoneID int64         `json:"nextStorageZoneId,omitempty"`
 }
 
 // handleAdminCreateZone handles POST /admin/zones
@@ -93,7 +97,8 @@ func (s *Server) handleAdminCreateRecord(w http.ResponseWriter, r *http.Request)
 }
 
 // handleAdminReset handles DELETE /admin/reset
-// Clears all zones and records, resetting ID counters, scan state, and failure injection state
+// Clears all zones, records, pull zones and storage zones, resetting ID
+// counters, scan state, and failure injection state
 func (s *Server) handleAdminReset(w http.ResponseWriter, r *http.Request) {
 	s.state.mu.Lock()
 	defer s.state.mu.Unlock()
@@ -102,6 +107,12 @@ func (s *Server) handleAdminReset(w http.ResponseWriter, r *http.Request) {
 	s.state.nextRecordID = 1
 	s.state.scanTriggered = make(map[int64]bool)
 	s.state.scanCallCount = make(map[int64]int)
+	s.state.pullZones = make(map[int64]*PullZone)
+	s.state.storageZones = make(map[int64]*StorageZone)
+	s.state.nextPullZoneID = 1
+	s.state.nextStorageZoneID = 1
+	s.state.nextHostnameID = 1
+	s.state.purges = make(map[int64][]string)
 	// Clear failure injection state with proper initialization
 	s.state.failureInjection = FailureInjection{
 		rateLimitAfter: -1,
@@ -113,25 +124,8 @@ func (s *Server) handleAdminReset(w http.ResponseWriter, r *http.Request) {
 // Returns the full server state for debugging
 func (s *Server) handleAdminState(w http.ResponseWriter, r *http.Request) {
 	s.state.mu.RLock()
-	zones := make([]Zone, 0, len(s.state.zones))
-	for _, z := range s.state.zones {
-		// Deep copy the zone including its Records slice
-		zoneCopy := *z
-		if z.Records != nil {
-			zoneCopy.Records = make([]Record, len(z.Records))
-			copy(zoneCopy.Records, z.Records)
-		}
-		zones = append(zones, zoneCopy)
-	}
-	nextZoneID := s.state.nextZoneID
-	nextRecordID := s.state.nextRecordID
+	resp := s.copyState()
 	s.state.mu.RUnlock()
 
-	resp := StateResponse{
-		Zones:        zones,
-		NextZoneID:   nextZoneID,
-		NextRecordID: nextRecordID,
-	}
-
 	writeJSON(w, http.StatusOK, resp)
 }
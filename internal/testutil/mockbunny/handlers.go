@@ -171,6 +171,17 @@ type AddRecordRequest struct {
 	Tag      string `json:"Tag"`
 	Disabled bool   `json:"Disabled"`
 	Comment  string `json:"Comment"`
+
+	PullZoneID            *int64        `json:"PullZoneId"`
+	ScriptID              *int64        `json:"ScriptId"`
+	Accelerated           *bool         `json:"Accelerated"`
+	MonitorType           *int          `json:"MonitorType"`
+	GeolocationLatitude   *float64      `json:"GeolocationLatitude"`
+	GeolocationLongitude  *float64      `json:"GeolocationLongitude"`
+	LatencyZone           *string       `json:"LatencyZone"`
+	SmartRoutingType      *int          `json:"SmartRoutingType"`
+	EnviromentalVariables []interface{} `json:"EnviromentalVariables"`
+	AutoSslIssuance       *bool         `json:"AutoSslIssuance"`
 }
 
 // handleUpdateRecord handles POST /dnszone/{zoneId}/records/{id} to update an existing DNS record.
@@ -225,6 +236,7 @@ func (s *Server) handleUpdateRecord(w http.ResponseWriter, r *http.Request) {
 			zone.Records[i].Tag = req.Tag
 			zone.Records[i].Disabled = req.Disabled
 			zone.Records[i].Comment = req.Comment
+			applyRecordOptions(&zone.Records[i], addRecordRequestInput(req))
 
 			// Update zone's DateModified
 			zone.DateModified = MockBunnyTime{Time: time.Now().UTC()}
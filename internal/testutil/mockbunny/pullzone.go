@@ -0,0 +1,320 @@
+package mockbunny
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListPullZonesResponse is a paginated response for the List Pull Zones endpoint.
+type ListPullZonesResponse struct {
+	Items        []PullZone `json:"Items"`
+	CurrentPage  int        `json:"CurrentPage"`
+	TotalItems   int        `json:"TotalItems"`
+	HasMoreItems bool       `json:"HasMoreItems"`
+}
+
+// AddPullZone adds a pull zone with sensible defaults and returns its ID.
+// This method is thread-safe and commonly used for test setup.
+func (s *Server) AddPullZone(name, originURL string) int64 {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	zone := s.newPullZone(name)
+	zone.OriginURL = originURL
+	s.state.pullZones[zone.ID] = zone
+	return zone.ID
+}
+
+// GetPullZone returns a copy of a pull zone by ID, or nil if not found.
+// This method is thread-safe.
+func (s *Server) GetPullZone(id int64) *PullZone {
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	zone, ok := s.state.pullZones[id]
+	if !ok {
+		return nil
+	}
+	zoneCopy := copyPullZone(*zone)
+	return &zoneCopy
+}
+
+// PurgedCacheTags returns the cache tags of a pull zone's purge requests in
+// order, with "" for a purge of the whole cache. This method is thread-safe.
+func (s *Server) PurgedCacheTags(id int64) []string {
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	return slices.Clone(s.state.purges[id])
+}
+
+// newPullZone creates a pull zone with the defaults of the real API, taking
+// the next ID. Caller must hold the state lock.
+func (s *Server) newPullZone(name string) *PullZone {
+	zone := &PullZone{
+		ID:          s.state.nextPullZoneID,
+		Name:        name,
+		Enabled:     true,
+		CnameDomain: name + ".b-cdn.net",
+		Hostnames: []Hostname{{
+			ID:               s.state.nextHostnameID,
+			Value:            name + ".b-cdn.net",
+			IsSystemHostname: true,
+			HasCertificate:   true,
+		}},
+		AllowedReferrers:  []string{},
+		BlockedReferrers:  []string{},
+		BlockedIps:        []string{},
+		BlockedCountries:  []string{},
+		EnableGeoZoneUS:   true,
+		EnableGeoZoneEU:   true,
+		EnableGeoZoneASIA: true,
+		EnableGeoZoneSA:   true,
+		EnableGeoZoneAF:   true,
+		ZoneSecurityKey:   randomKey(),
+		EnableAutoSSL:     true,
+	}
+	s.state.nextPullZoneID++
+	s.state.nextHostnameID++
+	return zone
+}
+
+// copyPullZone returns a copy of z with its own slices.
+func copyPullZone(z PullZone) PullZone {
+	z.Hostnames = slices.Clone(z.Hostnames)
+	z.AllowedReferrers = slices.Clone(z.AllowedReferrers)
+	z.BlockedReferrers = slices.Clone(z.BlockedReferrers)
+	z.BlockedIps = slices.Clone(z.BlockedIps)
+	z.BlockedCountries = slices.Clone(z.BlockedCountries)
+	return z
+}
+
+// randomKey returns a random key in the UUID format bunny.net uses for
+// security keys and passwords.
+func randomKey() string {
+	b := make([]byte, 16)
+	//nolint:errcheck // crypto/rand.Read does not fail
+	rand.Read(b)
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// handleListPullZones handles GET /pullzone requests.
+// Supports page, perPage and search (on the name) like GET /dnszone.
+func (s *Server) handleListPullZones(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pagination(r)
+	search := r.URL.Query().Get("search")
+
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	zones := make([]PullZone, 0, len(s.state.pullZones))
+	for _, zone := range s.state.pullZones {
+		if search == "" || strings.Contains(zone.Name, search) {
+			zones = append(zones, copyPullZone(*zone))
+		}
+	}
+	slices.SortFunc(zones, func(a, b PullZone) int { return cmp.Compare(a.ID, b.ID) })
+
+	items, hasMore := paginate(zones, page, perPage)
+	writeJSON(w, http.StatusOK, ListPullZonesResponse{
+		Items:        items,
+		CurrentPage:  page,
+		TotalItems:   len(zones),
+		HasMoreItems: hasMore,
+	})
+}
+
+// handleCreatePullZone handles POST /pullzone requests.
+// Name is required and must be unique. A StorageZoneId links the pull zone
+// to an existing storage zone as its origin.
+func (s *Server) handleCreatePullZone(w http.ResponseWriter, r *http.Request) {
+	var req PullZone
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_JSON", "", "Invalid request body")
+		return
+	}
+	if req.Name == "" {
+		s.writeError(w, http.StatusBadRequest, "pullzone.validation", "Name", "The Name field is required.")
+		return
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	for _, zone := range s.state.pullZones {
+		if strings.EqualFold(zone.Name, req.Name) {
+			s.writeError(w, http.StatusBadRequest, "pullzone.name_taken", "Name", "The pull zone name is already taken.")
+			return
+		}
+	}
+	if req.StorageZoneID != 0 {
+		if sz, ok := s.state.storageZones[req.StorageZoneID]; !ok || sz.Deleted {
+			s.writeError(w, http.StatusBadRequest, "pullzone.storagezone_not_found", "StorageZoneId", "The storage zone was not found.")
+			return
+		}
+	}
+
+	zone := s.newPullZone(req.Name)
+	zone.OriginURL = req.OriginURL
+	zone.OriginHostHeader = req.OriginHostHeader
+	zone.OriginType = req.OriginType
+	zone.StorageZoneID = req.StorageZoneID
+	zone.Type = req.Type
+	if req.StorageZoneID != 0 {
+		zone.OriginType = 2
+	}
+	s.state.pullZones[zone.ID] = zone
+
+	writeJSON(w, http.StatusCreated, zone)
+}
+
+// handleGetPullZone handles GET /pullzone/{id} requests.
+func (s *Server) handleGetPullZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pullZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	zone, exists := s.state.pullZones[id]
+	if !exists {
+		s.writePullZoneNotFound(w)
+		return
+	}
+	writeJSON(w, http.StatusOK, zone)
+}
+
+// handleUpdatePullZone handles POST /pullzone/{id} requests.
+// Only the settings present in the body change. The ID, name and hostnames
+// cannot be changed this way, as in the real API.
+func (s *Server) handleUpdatePullZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pullZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	zone, exists := s.state.pullZones[id]
+	if !exists {
+		s.writePullZoneNotFound(w)
+		return
+	}
+
+	updated := copyPullZone(*zone)
+	if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_JSON", "", "Invalid request body")
+		return
+	}
+	if updated.StorageZoneID != zone.StorageZoneID && updated.StorageZoneID != 0 {
+		if sz, ok := s.state.storageZones[updated.StorageZoneID]; !ok || sz.Deleted {
+			s.writeError(w, http.StatusBadRequest, "pullzone.storagezone_not_found", "StorageZoneId", "The storage zone was not found.")
+			return
+		}
+	}
+	updated.ID, updated.Name, updated.Hostnames, updated.CnameDomain = zone.ID, zone.Name, zone.Hostnames, zone.CnameDomain
+	*zone = updated
+
+	writeJSON(w, http.StatusOK, zone)
+}
+
+// handleDeletePullZone handles DELETE /pullzone/{id} requests.
+func (s *Server) handleDeletePullZone(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pullZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	if _, exists := s.state.pullZones[id]; !exists {
+		s.writePullZoneNotFound(w)
+		return
+	}
+	delete(s.state.pullZones, id)
+	delete(s.state.purges, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePurgePullZoneCache handles POST /pullzone/{id}/purgeCache requests.
+// An optional body {"CacheTag": "..."} limits the purge to a tag. Purges are
+// recorded for PurgedCacheTags.
+func (s *Server) handlePurgePullZoneCache(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.pullZoneID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CacheTag string `json:"CacheTag"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeError(w, http.StatusBadRequest, "INVALID_JSON", "", "Invalid request body")
+			return
+		}
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	if _, exists := s.state.pullZones[id]; !exists {
+		s.writePullZoneNotFound(w)
+		return
+	}
+	s.state.purges[id] = append(s.state.purges[id], req.CacheTag)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pullZoneID parses the {id} path parameter, writing a 400 response and
+// returning false if it is invalid.
+func (s *Server) pullZoneID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_ID", "id", "Invalid pull zone ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) writePullZoneNotFound(w http.ResponseWriter) {
+	s.writeError(w, http.StatusNotFound, "pullzone.not_found", "Id", "The requested pull zone was not found")
+}
+
+// pagination returns the page and perPage query parameters, with the
+// defaults and bounds of GET /dnszone.
+func pagination(r *http.Request) (page, perPage int) {
+	page, perPage = 1, 1000
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if pp, err := strconv.Atoi(r.URL.Query().Get("perPage")); err == nil && pp >= 5 && pp <= 1000 {
+		perPage = pp
+	}
+	return page, perPage
+}
+
+// paginate returns one page of items and whether more follow.
+func paginate[T any](items []T, page, perPage int) ([]T, bool) {
+	start := (page - 1) * perPage
+	if start >= len(items) {
+		return []T{}, false
+	}
+	end := min(start+perPage, len(items))
+	return items[start:end], end < len(items)
+}
@@ -0,0 +1,95 @@
+package mockbunny
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// doJSON sends a request to the mock and decodes a JSON response into out,
+// if given, returning the status code.
+func doJSON(t *testing.T, s *Server, method, path, body string, out any) int {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, s.URL()+path, reader)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("failed to decode %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestPullZoneEndpoints(t *testing.T) {
+	t.Parallel()
+	s := New()
+	defer s.Close()
+
+	var created PullZone
+	if code := doJSON(t, s, http.MethodPost, "/pullzone", `{"Name":"cdn","OriginUrl":"https://origin.example.com"}`, &created); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if created.ID == 0 || created.OriginURL != "https://origin.example.com" || !created.Enabled ||
+		len(created.Hostnames) != 1 || created.Hostnames[0].Value != "cdn.b-cdn.net" || created.ZoneSecurityKey == "" {
+		t.Errorf("unexpected pull zone: %+v", created)
+	}
+	if code := doJSON(t, s, http.MethodPost, "/pullzone", `{"Name":"CDN"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a taken name, got %d", code)
+	}
+	if code := doJSON(t, s, http.MethodPost, "/pullzone", `{"OriginUrl":"https://origin.example.com"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a name, got %d", code)
+	}
+	s.AddPullZone("assets", "https://assets.example.com")
+
+	var list ListPullZonesResponse
+	doJSON(t, s, http.MethodGet, "/pullzone?search=ass", "", &list)
+	if list.TotalItems != 1 || list.Items[0].Name != "assets" {
+		t.Errorf("unexpected search result: %+v", list)
+	}
+
+	// Only the settings in the body change
+	var updated PullZone
+	path := "/pullzone/" + strconv.FormatInt(created.ID, 10)
+	if code := doJSON(t, s, http.MethodPost, path, `{"BlockedIps":["192.0.2.1"],"Name":"renamed"}`, &updated); code != http.StatusOK {
+		t.Fatalf("expected 200 updating, got %d", code)
+	}
+	if updated.Name != "cdn" || updated.OriginURL != "https://origin.example.com" || len(updated.BlockedIps) != 1 {
+		t.Errorf("unexpected updated pull zone: %+v", updated)
+	}
+
+	for _, body := range []string{"", `{"CacheTag":"images"}`} {
+		if code := doJSON(t, s, http.MethodPost, path+"/purgeCache", body, nil); code != http.StatusNoContent {
+			t.Errorf("expected 204 purging, got %d", code)
+		}
+	}
+	if tags := s.PurgedCacheTags(created.ID); len(tags) != 2 || tags[0] != "" || tags[1] != "images" {
+		t.Errorf("unexpected purges: %q", tags)
+	}
+
+	if code := doJSON(t, s, http.MethodDelete, path, "", nil); code != http.StatusNoContent {
+		t.Errorf("expected 204 deleting, got %d", code)
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		if code := doJSON(t, s, method, path, "", nil); code != http.StatusNotFound {
+			t.Errorf("%s after delete: expected 404, got %d", method, code)
+		}
+	}
+	if code := doJSON(t, s, http.MethodGet, "/pullzone/abc", "", nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid ID, got %d", code)
+	}
+}
@@ -1,10 +1,13 @@
 package mockbunny
 
 import (
+	"log"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -18,6 +21,10 @@ type Server struct {
 	router chi.Router
 	logger *slog.Logger
 	apiKey string // Expected API key for authentication
+
+	snapshots map[string]StateResponse // saved by Snapshot, guarded by state.mu
+	stateFile string                   // JSON file the state is persisted to, if set
+	saveMu    sync.Mutex               // orders writes of the state file
 }
 
 // New creates a new mock bunny.net server for testing.
@@ -25,6 +32,8 @@ type Server struct {
 // The state is initialized with empty zones and auto-incrementing IDs.
 // If DEBUG environment variable is set to "true", HTTP request/response logging is enabled.
 // If BUNNY_API_KEY is set, API key authentication is required for DNS API endpoints.
+// If MOCKBUNNY_STATE_FILE is set, the state and snapshots are loaded from that
+// JSON file and saved to it after every request that may change them.
 func New() *Server {
 	state := NewState()
 
@@ -47,11 +56,13 @@ func New() *Server {
 	ts := httptest.NewServer(r)
 
 	server := &Server{
-		Server: ts,
-		state:  state,
-		router: r,
-		logger: logger,
-		apiKey: apiKey,
+		Server:    ts,
+		state:     state,
+		router:    r,
+		logger:    logger,
+		apiKey:    apiKey,
+		snapshots: make(map[string]StateResponse),
+		stateFile: os.Getenv("MOCKBUNNY_STATE_FILE"),
 	}
 
 	// Apply logging middleware if logger present
@@ -65,6 +76,14 @@ func New() *Server {
 	// Apply failure injection middleware
 	r.Use(FailureInjectionMiddleware(state))
 
+	// Persist the state, if a state file is configured
+	if server.stateFile != "" {
+		if err := server.loadStateFile(); err != nil {
+			log.Printf("mockbunny: failed to load state from %s: %v", server.stateFile, err)
+		}
+		r.Use(server.persistMiddleware)
+	}
+
 	// Wire up DNS API handlers with authentication (if API key is configured)
 	r.Group(func(r chi.Router) {
 		if apiKey != "" {
@@ -87,6 +106,17 @@ func New() *Server {
 		r.Put("/dnszone/{zoneId}/records", server.handleAddRecord)
 		r.Post("/dnszone/{zoneId}/records/{id}", server.handleUpdateRecord)
 		r.Delete("/dnszone/{zoneId}/records/{id}", server.handleDeleteRecord)
+		r.Get("/pullzone", server.handleListPullZones)
+		r.Post("/pullzone", server.handleCreatePullZone)
+		r.Get("/pullzone/{id}", server.handleGetPullZone)
+		r.Post("/pullzone/{id}", server.handleUpdatePullZone)
+		r.Delete("/pullzone/{id}", server.handleDeletePullZone)
+		r.Post("/pullzone/{id}/purgeCache", server.handlePurgePullZoneCache)
+		r.Get("/storagezone", server.handleListStorageZones)
+		r.Post("/storagezone", server.handleCreateStorageZone)
+		r.Get("/storagezone/{id}", server.handleGetStorageZone)
+		r.Delete("/storagezone/{id}", server.handleDeleteStorageZone)
+		r.Post("/storagezone/{id}/resetPassword", server.handleResetStorageZonePassword)
 	})
 
 	// Admin endpoints for test seeding (no authentication required)
@@ -95,6 +125,8 @@ func New() *Server {
 		r.Post("/zones/{zoneId}/records", server.handleAdminCreateRecord)
 		r.Delete("/reset", server.handleAdminReset)
 		r.Get("/state", server.handleAdminState)
+		r.Post("/snapshot", server.handleAdminSnapshot)
+		r.Post("/restore", server.handleAdminRestore)
 	})
 
 	return server
@@ -284,7 +316,7 @@ func (s *Server) SetMalformedResponse(count int) {
 // This ensures records created via different API paths have identical structure.
 // Caller must hold the state lock if accessing/modifying state concurrently.
 func (s *Server) newRecord(req addRecordRequestInput) Record {
-	return Record{
+	record := Record{
 		ID:                    s.state.nextRecordID,
 		Type:                  req.Type,
 		Name:                  req.Name,
@@ -307,6 +339,8 @@ func (s *Server) newRecord(req addRecordRequestInput) Record {
 		AutoSslIssuance:       true,
 		AccelerationStatus:    0,
 	}
+	applyRecordOptions(&record, req)
+	return record
 }
 
 // addRecordRequestInput contains the fields common to both admin and DNS API record creation requests.
@@ -322,4 +356,52 @@ type addRecordRequestInput struct {
 	Tag      string
 	Disabled bool
 	Comment  string
+
+	// Optional fields, left unchanged when nil
+	PullZoneID            *int64
+	ScriptID              *int64
+	Accelerated           *bool
+	MonitorType           *int
+	GeolocationLatitude   *float64
+	GeolocationLongitude  *float64
+	LatencyZone           *string
+	SmartRoutingType      *int
+	EnviromentalVariables []interface{}
+	AutoSslIssuance       *bool
+}
+
+// applyRecordOptions copies the optional fields that were set in req onto
+// record. The real API reports a linked pull zone or script by name in
+// LinkName; the mock uses its ID instead.
+func applyRecordOptions(record *Record, req addRecordRequestInput) {
+	if req.PullZoneID != nil {
+		record.LinkName = strconv.FormatInt(*req.PullZoneID, 10)
+	}
+	if req.ScriptID != nil {
+		record.LinkName = strconv.FormatInt(*req.ScriptID, 10)
+	}
+	if req.Accelerated != nil {
+		record.Accelerated = *req.Accelerated
+	}
+	if req.MonitorType != nil {
+		record.MonitorType = *req.MonitorType
+	}
+	if req.GeolocationLatitude != nil {
+		record.GeolocationLatitude = *req.GeolocationLatitude
+	}
+	if req.GeolocationLongitude != nil {
+		record.GeolocationLongitude = *req.GeolocationLongitude
+	}
+	if req.LatencyZone != nil {
+		record.LatencyZone = req.LatencyZone
+	}
+	if req.SmartRoutingType != nil {
+		record.SmartRoutingType = *req.SmartRoutingType
+	}
+	if req.EnviromentalVariables != nil {
+		record.EnviromentalVariables = req.EnviromentalVariables
+	}
+	if req.AutoSslIssuance != nil {
+		record.AutoSslIssuance = *req.AutoSslIssuance
+	}
 }
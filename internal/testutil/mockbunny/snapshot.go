@@ -0,0 +1,266 @@
+package mockbunny
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// defaultSnapshot names the snapshot used when none is given.
+const defaultSnapshot = "default"
+
+// SnapshotResponse is the response for POST /admin/snapshot and POST /admin/restore.
+type SnapshotResponse struct {
+	Name    string `json:"name,omitempty"`
+	Zones   int    `json:"zones"`
+	Records int    `json:"records"`
+}
+
+// stateFile is the JSON written to MOCKBUNNY_STATE_FILE: the current state
+// and the saved snapshots.
+type stateFile struct {
+	State     StateResponse            `json:"state"`
+	Snapshots map[string]StateResponse `json:"snapshots,omitempty"`
+}
+
+// Snapshot saves a copy of the DNS, pull and storage zones and ID counters
+// under name, replacing any earlier snapshot with that name. This method is
+// thread-safe.
+func (s *Server) Snapshot(name string) StateResponse {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	snap := s.copyState()
+	s.snapshots[name] = snap
+	return snap
+}
+
+// Restore replaces the state with the snapshot saved under name, and clears
+// scan and failure injection state as a reset does. Returns false if there is
+// no such snapshot. This method is thread-safe.
+func (s *Server) Restore(name string) bool {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	snap, ok := s.snapshots[name]
+	if ok {
+		s.restoreState(snap)
+	}
+	return ok
+}
+
+// copyState returns a copy of the DNS, pull and storage zones, ordered by
+// ID, and the ID counters.
+// Caller must hold the state lock.
+func (s *Server) copyState() StateResponse {
+	zones := make([]Zone, 0, len(s.state.zones))
+	for _, z := range s.state.zones {
+		zones = append(zones, copyZone(*z))
+	}
+	slices.SortFunc(zones, func(a, b Zone) int { return cmp.Compare(a.ID, b.ID) })
+	pullZones := make([]PullZone, 0, len(s.state.pullZones))
+	for _, z := range s.state.pullZones {
+		pullZones = append(pullZones, copyPullZone(*z))
+	}
+	slices.SortFunc(pullZones, func(a, b PullZone) int { return cmp.Compare(a.ID, b.ID) })
+	storageZones := make([]StorageZone, 0, len(s.state.storageZones))
+	for _, z := range s.state.storageZones {
+		zoneCopy := *z
+		zoneCopy.ReplicationRegions = slices.Clone(z.ReplicationRegions)
+		zoneCopy.PullZones = nil
+		storageZones = append(storageZones, zoneCopy)
+	}
+	slices.SortFunc(storageZones, func(a, b StorageZone) int { return cmp.Compare(a.ID, b.ID) })
+	return StateResponse{
+		Zones:             zones,
+		NextZoneID:        s.state.nextZoneID,
+		NextRecordID:      s.state.nextRecordID,
+		PullZones:         pullZones,
+		StorageZones:      storageZones,
+		NextPullZoneID:    s.state.nextPullZoneID,
+		NextStorageZoneID: s.state.nextStorageZoneID,
+	}
+}
+
+// restoreState replaces the state with a copy of snap. ID counters missing
+// from snap continue after its highest IDs. Caller must hold the state lock.
+func (s *Server) restoreState(snap StateResponse) {
+	s.state.zones = make(map[int64]*Zone, len(snap.Zones))
+	s.state.nextZoneID = max(snap.NextZoneID, 1)
+	s.state.nextRecordID = max(snap.NextRecordID, 1)
+	for _, z := range snap.Zones {
+		zone := copyZone(z)
+		s.state.zones[zone.ID] = &zone
+		s.state.nextZoneID = max(s.state.nextZoneID, zone.ID+1)
+		for _, r := range zone.Records {
+			s.state.nextRecordID = max(s.state.nextRecordID, r.ID+1)
+		}
+	}
+	s.state.pullZones = make(map[int64]*PullZone, len(snap.PullZones))
+	s.state.nextPullZoneID = max(snap.NextPullZoneID, 1)
+	s.state.nextHostnameID = 1
+	for _, z := range snap.PullZones {
+		zone := copyPullZone(z)
+		s.state.pullZones[zone.ID] = &zone
+		s.state.nextPullZoneID = max(s.state.nextPullZoneID, zone.ID+1)
+		for _, h := range zone.Hostnames {
+			s.state.nextHostnameID = max(s.state.nextHostnameID, h.ID+1)
+		}
+	}
+	s.state.storageZones = make(map[int64]*StorageZone, len(snap.StorageZones))
+	s.state.nextStorageZoneID = max(snap.NextStorageZoneID, 1)
+	for _, z := range snap.StorageZones {
+		zone := z
+		zone.ReplicationRegions = slices.Clone(z.ReplicationRegions)
+		zone.PullZones = nil
+		s.state.storageZones[zone.ID] = &zone
+		s.state.nextStorageZoneID = max(s.state.nextStorageZoneID, zone.ID+1)
+	}
+	s.state.purges = make(map[int64][]string)
+	s.state.scanTriggered = make(map[int64]bool)
+	s.state.scanCallCount = make(map[int64]int)
+	s.state.failureInjection = FailureInjection{
+		rateLimitAfter: -1,
+	}
+}
+
+// copyZone returns a copy of z with its own Records slice.
+func copyZone(z Zone) Zone {
+	if z.Records != nil {
+		z.Records = slices.Clone(z.Records)
+	} else {
+		z.Records = []Record{}
+	}
+	return z
+}
+
+// handleAdminSnapshot handles POST /admin/snapshot?name=...
+// Saves the current zones and ID counters under name ("default" if not given)
+func (s *Server) handleAdminSnapshot(w http.ResponseWriter, r *http.Request) {
+	name := snapshotName(r)
+	snap := s.Snapshot(name)
+	writeJSON(w, http.StatusOK, snapshotResponse(name, snap))
+}
+
+// handleAdminRestore handles POST /admin/restore?name=...
+// Restores the snapshot saved under name ("default" if not given). A request
+// body with the shape of GET /admin/state is restored instead, so fixtures
+// can be loaded from files.
+func (s *Server) handleAdminRestore(w http.ResponseWriter, r *http.Request) {
+	var snap StateResponse
+	err := json.NewDecoder(r.Body).Decode(&snap)
+	if err != nil && !errors.Is(err, io.EOF) {
+		s.writeError(w, http.StatusBadRequest, "INVALID_JSON", "", "Invalid request body")
+		return
+	}
+	if err == nil {
+		s.state.mu.Lock()
+		s.restoreState(snap)
+		s.state.mu.Unlock()
+		writeJSON(w, http.StatusOK, snapshotResponse("", snap))
+		return
+	}
+
+	name := snapshotName(r)
+	s.state.mu.Lock()
+	snap, ok := s.snapshots[name]
+	if ok {
+		s.restoreState(snap)
+	}
+	s.state.mu.Unlock()
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "SNAPSHOT_NOT_FOUND", "name", "Snapshot not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshotResponse(name, snap))
+}
+
+func snapshotName(r *http.Request) string {
+	if name := r.URL.Query().Get("name"); name != "" {
+		return name
+	}
+	return defaultSnapshot
+}
+
+func snapshotResponse(name string, snap StateResponse) SnapshotResponse {
+	resp := SnapshotResponse{Name: name, Zones: len(snap.Zones)}
+	for _, z := range snap.Zones {
+		resp.Records += len(z.Records)
+	}
+	return resp
+}
+
+// loadStateFile restores the state and snapshots saved in the state file.
+// A missing file leaves the server empty.
+func (s *Server) loadStateFile() error {
+	data, err := os.ReadFile(s.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("invalid state file %s: %w", s.stateFile, err)
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+	s.restoreState(f.State)
+	for name, snap := range f.Snapshots {
+		s.snapshots[name] = snap
+	}
+	return nil
+}
+
+// saveStateFile writes the state and snapshots to the state file, replacing
+// it atomically.
+func (s *Server) saveStateFile() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.state.mu.RLock()
+	data, err := json.MarshalIndent(stateFile{State: s.copyState(), Snapshots: s.snapshots}, "", "  ")
+	s.state.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.stateFile), ".mockbunny-state-*")
+	if err != nil {
+		return err
+	}
+	//nolint:errcheck // Removing the temporary file fails once it is renamed
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		//nolint:errcheck
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.stateFile)
+}
+
+// persistMiddleware saves the state file after every request that may have
+// changed the state.
+func (s *Server) persistMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return
+		}
+		if err := s.saveStateFile(); err != nil {
+			log.Printf("mockbunny: failed to save state to %s: %v", s.stateFile, err)
+		}
+	})
+}
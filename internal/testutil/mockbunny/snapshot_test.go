@@ -0,0 +1,103 @@
+package mockbunny
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func postAdmin(t *testing.T, s *Server, path, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(s.URL()+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", path, err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestAdminSnapshotRestore(t *testing.T) {
+	t.Parallel()
+	s := New()
+	defer s.Close()
+
+	zoneID := s.AddZoneWithRecords("fixture.com", []Record{{Type: 0, Name: "www", Value: "192.0.2.1", TTL: 300}})
+	resp := postAdmin(t, s, "/admin/snapshot?name=fixtures", "")
+	var snap SnapshotResponse
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		t.Fatalf("failed to decode snapshot: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || snap.Name != "fixtures" || snap.Zones != 1 || snap.Records != 1 {
+		t.Fatalf("unexpected snapshot %d: %+v", resp.StatusCode, snap)
+	}
+
+	s.AddZone("scratch.com")
+	s.SetRateLimit(0)
+	if resp := postAdmin(t, s, "/admin/restore?name=fixtures", ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 restoring, got %d", resp.StatusCode)
+	}
+	state := s.GetState()
+	if len(state) != 1 || len(state[zoneID].Records) != 1 || state[zoneID].Domain != "fixture.com" {
+		t.Errorf("unexpected state after restore: %+v", state)
+	}
+	// Restoring clears failure injection, and IDs continue from the snapshot
+	list, err := http.Get(s.URL() + "/dnszone")
+	if err != nil {
+		t.Fatalf("failed to list zones: %v", err)
+	}
+	_ = list.Body.Close()
+	if list.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 listing zones after restore, got %d", list.StatusCode)
+	}
+	if id := s.AddZone("next.com"); id != zoneID+1 {
+		t.Errorf("expected the next zone ID %d, got %d", zoneID+1, id)
+	}
+
+	// Restoring twice starts from the same fixtures
+	postAdmin(t, s, "/admin/restore?name=fixtures", "")
+	if state := s.GetState(); len(state) != 1 {
+		t.Errorf("expected 1 zone after restoring again, got %d", len(state))
+	}
+
+	if resp := postAdmin(t, s, "/admin/restore?name=missing", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown snapshot, got %d", resp.StatusCode)
+	}
+	if resp := postAdmin(t, s, "/admin/restore", "{"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid JSON, got %d", resp.StatusCode)
+	}
+
+	// A body with the shape of GET /admin/state is restored as a fixture
+	resp = postAdmin(t, s, "/admin/restore", `{"zones":[{"Id":7,"Domain":"file.com","Records":[{"Id":40,"Type":3,"Name":"txt","Value":"v"}]}]}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 restoring a body, got %d", resp.StatusCode)
+	}
+	if zone := s.GetZone(7); zone == nil || len(zone.Records) != 1 {
+		t.Errorf("unexpected zone after restoring a body: %+v", zone)
+	}
+	if id := s.AddZone("next.com"); id != 8 {
+		t.Errorf("expected zone IDs to continue after the body's, got %d", id)
+	}
+}
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv("MOCKBUNNY_STATE_FILE", path)
+
+	s := New()
+	postAdmin(t, s, "/admin/zones", `{"domain": "persist.com"}`)
+	postAdmin(t, s, "/admin/snapshot", "")
+	postAdmin(t, s, "/admin/zones", `{"domain": "later.com"}`)
+	s.Close()
+
+	restarted := New()
+	defer restarted.Close()
+	if state := restarted.GetState(); len(state) != 2 {
+		t.Fatalf("expected 2 zones after restarting, got %+v", state)
+	}
+	postAdmin(t, restarted, "/admin/restore", "")
+	if state := restarted.GetState(); len(state) != 1 || state[1].Domain != "persist.com" {
+		t.Errorf("expected the saved snapshot after restarting, got %+v", state)
+	}
+}
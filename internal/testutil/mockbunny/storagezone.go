@@ -0,0 +1,222 @@
+package mockbunny
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListStorageZonesResponse is a paginated response for the List Storage Zones endpoint.
+type ListStorageZonesResponse struct {
+	Items        []StorageZone `json:"Items"`
+	CurrentPage  int           `json:"CurrentPage"`
+	TotalItems   int           `json:"TotalItems"`
+	HasMoreItems bool          `json:"HasMoreItems"`
+}
+
+// AddStorageZone adds a storage zone in a region, such as "DE", and returns
+// its ID. This method is thread-safe and commonly used for test setup.
+func (s *Server) AddStorageZone(name, region string) int64 {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	zone := s.newStorageZone(name, region)
+	s.state.storageZones[zone.ID] = zone
+	return zone.ID
+}
+
+// GetStorageZone returns a copy of a storage zone by ID, including deleted
+// ones, or nil if not found. This method is thread-safe.
+func (s *Server) GetStorageZone(id int64) *StorageZone {
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	zone, ok := s.state.storageZones[id]
+	if !ok {
+		return nil
+	}
+	zoneCopy := s.storageZoneWithPullZones(zone)
+	return &zoneCopy
+}
+
+// newStorageZone creates a storage zone with the defaults of the real API,
+// taking the next ID. Caller must hold the state lock.
+func (s *Server) newStorageZone(name, region string) *StorageZone {
+	hostname := "storage.bunnycdn.com"
+	if region != "DE" {
+		hostname = strings.ToLower(region) + ".storage.bunnycdn.com"
+	}
+	zone := &StorageZone{
+		ID:                 s.state.nextStorageZoneID,
+		UserID:             "mock-user",
+		Name:               name,
+		Password:           randomKey(),
+		ReadOnlyPassword:   randomKey(),
+		DateModified:       MockBunnyTime{Time: time.Now().UTC()},
+		Region:             region,
+		ReplicationRegions: []string{},
+		StorageHostname:    hostname,
+	}
+	s.state.nextStorageZoneID++
+	return zone
+}
+
+// storageZoneWithPullZones returns a copy of zone listing the pull zones
+// linked to it. Caller must hold the state lock.
+func (s *Server) storageZoneWithPullZones(zone *StorageZone) StorageZone {
+	zoneCopy := *zone
+	zoneCopy.ReplicationRegions = slices.Clone(zone.ReplicationRegions)
+	zoneCopy.PullZones = []PullZone{}
+	for _, pz := range s.state.pullZones {
+		if pz.StorageZoneID == zone.ID {
+			zoneCopy.PullZones = append(zoneCopy.PullZones, copyPullZone(*pz))
+		}
+	}
+	slices.SortFunc(zoneCopy.PullZones, func(a, b PullZone) int { return cmp.Compare(a.ID, b.ID) })
+	return zoneCopy
+}
+
+// handleListStorageZones handles GET /storagezone requests.
+// Supports page, perPage and search (on the name) like GET /dnszone; deleted
+// zones are only listed with includeDeleted=true.
+func (s *Server) handleListStorageZones(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pagination(r)
+	search := r.URL.Query().Get("search")
+	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("includeDeleted")) //nolint:errcheck // invalid means false
+
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	zones := make([]StorageZone, 0, len(s.state.storageZones))
+	for _, zone := range s.state.storageZones {
+		if (includeDeleted || !zone.Deleted) && (search == "" || strings.Contains(zone.Name, search)) {
+			zones = append(zones, s.storageZoneWithPullZones(zone))
+		}
+	}
+	slices.SortFunc(zones, func(a, b StorageZone) int { return cmp.Compare(a.ID, b.ID) })
+
+	items, hasMore := paginate(zones, page, perPage)
+	writeJSON(w, http.StatusOK, ListStorageZonesResponse{
+		Items:        items,
+		CurrentPage:  page,
+		TotalItems:   len(zones),
+		HasMoreItems: hasMore,
+	})
+}
+
+// handleCreateStorageZone handles POST /storagezone requests.
+// Name and Region are required, and the name must not be used by another
+// storage zone that is not deleted.
+func (s *Server) handleCreateStorageZone(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name               string   `json:"Name"`
+		Region             string   `json:"Region"`
+		ReplicationRegions []string `json:"ReplicationRegions"`
+		ZoneTier           int      `json:"ZoneTier"`
+		StorageZoneType    int      `json:"StorageZoneType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_JSON", "", "Invalid request body")
+		return
+	}
+	if req.Name == "" || req.Region == "" {
+		s.writeError(w, http.StatusBadRequest, "storagezone.validation", "Name,Region", "The Name and Region fields are required.")
+		return
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	for _, zone := range s.state.storageZones {
+		if !zone.Deleted && strings.EqualFold(zone.Name, req.Name) {
+			s.writeError(w, http.StatusBadRequest, "storagezone.name_taken", "Name", "The storage zone name is already taken.")
+			return
+		}
+	}
+
+	zone := s.newStorageZone(req.Name, strings.ToUpper(req.Region))
+	if req.ReplicationRegions != nil {
+		zone.ReplicationRegions = req.ReplicationRegions
+	}
+	zone.ZoneTier = req.ZoneTier
+	zone.StorageZoneType = req.StorageZoneType
+	s.state.storageZones[zone.ID] = zone
+
+	writeJSON(w, http.StatusCreated, s.storageZoneWithPullZones(zone))
+}
+
+// handleGetStorageZone handles GET /storagezone/{id} requests.
+// Deleted zones are not found.
+func (s *Server) handleGetStorageZone(w http.ResponseWriter, r *http.Request) {
+	s.state.mu.RLock()
+	defer s.state.mu.RUnlock()
+
+	zone, ok := s.liveStorageZone(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.storageZoneWithPullZones(zone))
+}
+
+// handleDeleteStorageZone handles DELETE /storagezone/{id} requests.
+// The zone is marked deleted, as in the real API. With
+// deleteLinkedPullZones=true the pull zones linked to it are deleted too.
+func (s *Server) handleDeleteStorageZone(w http.ResponseWriter, r *http.Request) {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	zone, ok := s.liveStorageZone(w, r)
+	if !ok {
+		return
+	}
+	zone.Deleted = true
+	zone.DateModified = MockBunnyTime{Time: time.Now().UTC()}
+	if deleteLinked, _ := strconv.ParseBool(r.URL.Query().Get("deleteLinkedPullZones")); deleteLinked { //nolint:errcheck // invalid means false
+		for id, pz := range s.state.pullZones {
+			if pz.StorageZoneID == zone.ID {
+				delete(s.state.pullZones, id)
+				delete(s.state.purges, id)
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleResetStorageZonePassword handles POST /storagezone/{id}/resetPassword requests.
+func (s *Server) handleResetStorageZonePassword(w http.ResponseWriter, r *http.Request) {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	zone, ok := s.liveStorageZone(w, r)
+	if !ok {
+		return
+	}
+	zone.Password = randomKey()
+	zone.DateModified = MockBunnyTime{Time: time.Now().UTC()}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// liveStorageZone returns the storage zone named by the {id} path parameter,
+// writing a 400 or 404 response and returning false if it is invalid, unknown
+// or deleted. Caller must hold the state lock.
+func (s *Server) liveStorageZone(w http.ResponseWriter, r *http.Request) (*StorageZone, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "INVALID_ID", "id", "Invalid storage zone ID")
+		return nil, false
+	}
+	zone, ok := s.state.storageZones[id]
+	if !ok || zone.Deleted {
+		s.writeError(w, http.StatusNotFound, "storagezone.not_found", "Id", "The requested storage zone was not found")
+		return nil, false
+	}
+	return zone, true
+}
@@ -0,0 +1,70 @@
+package mockbunny
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestStorageZoneEndpoints(t *testing.T) {
+	t.Parallel()
+	s := New()
+	defer s.Close()
+
+	if code := doJSON(t, s, http.MethodPost, "/storagezone", `{"Name":"files"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a region, got %d", code)
+	}
+	var created StorageZone
+	if code := doJSON(t, s, http.MethodPost, "/storagezone", `{"Name":"files","Region":"ny"}`, &created); code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if created.Region != "NY" || created.StorageHostname != "ny.storage.bunnycdn.com" || created.Password == "" {
+		t.Errorf("unexpected storage zone: %+v", created)
+	}
+	path := "/storagezone/" + strconv.FormatInt(created.ID, 10)
+
+	if code := doJSON(t, s, http.MethodPost, path+"/resetPassword", "", nil); code != http.StatusNoContent {
+		t.Errorf("expected 204 resetting the password, got %d", code)
+	}
+	if zone := s.GetStorageZone(created.ID); zone.Password == created.Password {
+		t.Error("expected the password to change")
+	}
+
+	// Linked pull zones are listed on the storage zone
+	var linked PullZone
+	doJSON(t, s, http.MethodPost, "/pullzone", `{"Name":"files-cdn","StorageZoneId":`+strconv.FormatInt(created.ID, 10)+`}`, &linked)
+	if linked.OriginType != 2 {
+		t.Errorf("expected a storage zone origin, got %d", linked.OriginType)
+	}
+	if code := doJSON(t, s, http.MethodPost, "/pullzone", `{"Name":"other","StorageZoneId":999}`, nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown storage zone, got %d", code)
+	}
+	var got StorageZone
+	doJSON(t, s, http.MethodGet, path, "", &got)
+	if len(got.PullZones) != 1 || got.PullZones[0].ID != linked.ID {
+		t.Errorf("expected the linked pull zone, got %+v", got.PullZones)
+	}
+
+	// Deleted zones are hidden unless asked for, and their name can be reused
+	if code := doJSON(t, s, http.MethodDelete, path+"?deleteLinkedPullZones=true", "", nil); code != http.StatusNoContent {
+		t.Fatalf("expected 204 deleting, got %d", code)
+	}
+	if s.GetPullZone(linked.ID) != nil {
+		t.Error("expected the linked pull zone to be deleted")
+	}
+	if code := doJSON(t, s, http.MethodGet, path, "", nil); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a deleted zone, got %d", code)
+	}
+	var list ListStorageZonesResponse
+	doJSON(t, s, http.MethodGet, "/storagezone", "", &list)
+	if list.TotalItems != 0 {
+		t.Errorf("expected no storage zones, got %+v", list.Items)
+	}
+	doJSON(t, s, http.MethodGet, "/storagezone?includeDeleted=true", "", &list)
+	if list.TotalItems != 1 || !list.Items[0].Deleted {
+		t.Errorf("expected the deleted zone, got %+v", list.Items)
+	}
+	if code := doJSON(t, s, http.MethodPost, "/storagezone", `{"Name":"files","Region":"DE"}`, nil); code != http.StatusCreated {
+		t.Errorf("expected 201 reusing a deleted zone's name, got %d", code)
+	}
+}
@@ -191,6 +191,79 @@ type ZoneShortTime struct {
 	CertificateKeyType       int                `json:"CertificateKeyType"` // 0 = Ecdsa, 1 = Rsa
 }
 
+// PullZone represents a CDN pull zone. The real model has well over a hundred
+// settings; the mock keeps the ones the proxy and its tests use.
+type PullZone struct {
+	ID                         int64      `json:"Id"`
+	Name                       string     `json:"Name"`
+	OriginURL                  string     `json:"OriginUrl"`
+	OriginHostHeader           string     `json:"OriginHostHeader"`
+	OriginType                 int        `json:"OriginType"` // 0 = OriginUrl, 1 = DnsAccelerate, 2 = StorageZone, 3 = LoadBalancer, 4 = EdgeScript, 5 = MagicContainers, 6 = PushZone
+	Enabled                    bool       `json:"Enabled"`
+	Suspended                  bool       `json:"Suspended"`
+	Hostnames                  []Hostname `json:"Hostnames"`
+	StorageZoneID              int64      `json:"StorageZoneId"`
+	EdgeScriptID               int64      `json:"EdgeScriptId"`
+	Type                       int        `json:"Type"` // 0 = Premium, 1 = Volume
+	CnameDomain                string     `json:"CnameDomain"`
+	AllowedReferrers           []string   `json:"AllowedReferrers"`
+	BlockedReferrers           []string   `json:"BlockedReferrers"`
+	BlockedIps                 []string   `json:"BlockedIps"`
+	BlockedCountries           []string   `json:"BlockedCountries"`
+	EnableGeoZoneUS            bool       `json:"EnableGeoZoneUS"`
+	EnableGeoZoneEU            bool       `json:"EnableGeoZoneEU"`
+	EnableGeoZoneASIA          bool       `json:"EnableGeoZoneASIA"`
+	EnableGeoZoneSA            bool       `json:"EnableGeoZoneSA"`
+	EnableGeoZoneAF            bool       `json:"EnableGeoZoneAF"`
+	ZoneSecurityEnabled        bool       `json:"ZoneSecurityEnabled"`
+	ZoneSecurityKey            string     `json:"ZoneSecurityKey"`
+	IgnoreQueryStrings         bool       `json:"IgnoreQueryStrings"`
+	DisableCookies             bool       `json:"DisableCookies"`
+	AddHostHeader              bool       `json:"AddHostHeader"`
+	VerifyOriginSSL            bool       `json:"VerifyOriginSSL"`
+	EnableOriginShield         bool       `json:"EnableOriginShield"`
+	EnableLogging              bool       `json:"EnableLogging"`
+	EnableAutoSSL              bool       `json:"EnableAutoSSL"`
+	CacheControlMaxAgeOverride int64      `json:"CacheControlMaxAgeOverride"`
+	MonthlyBandwidthLimit      int64      `json:"MonthlyBandwidthLimit"`
+	MonthlyBandwidthUsed       int64      `json:"MonthlyBandwidthUsed"`
+	MonthlyCharges             float64    `json:"MonthlyCharges"`
+}
+
+// Hostname is a hostname served by a pull zone.
+type Hostname struct {
+	ID               int64  `json:"Id"`
+	Value            string `json:"Value"`
+	ForceSSL         bool   `json:"ForceSSL"`
+	IsSystemHostname bool   `json:"IsSystemHostname"`
+	HasCertificate   bool   `json:"HasCertificate"`
+}
+
+// StorageZone represents an edge storage zone. PullZones is filled in from
+// the pull zones linked to it when the zone is returned.
+type StorageZone struct {
+	ID                          int64         `json:"Id"`
+	UserID                      string        `json:"UserId"`
+	Name                        string        `json:"Name"`
+	Password                    string        `json:"Password"`
+	ReadOnlyPassword            string        `json:"ReadOnlyPassword"`
+	DateModified                MockBunnyTime `json:"DateModified"`
+	Deleted                     bool          `json:"Deleted"`
+	StorageUsed                 int64         `json:"StorageUsed"`
+	FilesStored                 int64         `json:"FilesStored"`
+	Region                      string        `json:"Region"`
+	ReplicationRegions          []string      `json:"ReplicationRegions"`
+	PullZones                   []PullZone    `json:"PullZones"`
+	Rewrite404To200             bool          `json:"Rewrite404To200"`
+	Custom404FilePath           string        `json:"Custom404FilePath"`
+	StorageHostname             string        `json:"StorageHostname"`
+	ZoneTier                    int           `json:"ZoneTier"` // 0 = Standard, 1 = Edge
+	ReplicationChangeInProgress bool          `json:"ReplicationChangeInProgress"`
+	PriceOverride               float64       `json:"PriceOverride"`
+	Discount                    int           `json:"Discount"`
+	StorageZoneType             int           `json:"StorageZoneType"`
+}
+
 // FailureInjection holds state for simulating failure modes.
 type FailureInjection struct {
 	// Error injection
@@ -224,6 +297,13 @@ type State struct {
 	scanTriggered    map[int64]bool   // tracks which zones have had a scan triggered
 	scanCallCount    map[int64]int    // tracks how many times scan result has been polled per zone
 	failureInjection FailureInjection // holds failure injection state
+
+	pullZones         map[int64]*PullZone
+	storageZones      map[int64]*StorageZone
+	nextPullZoneID    int64
+	nextStorageZoneID int64
+	nextHostnameID    int64
+	purges            map[int64][]string // cache tags purged per pull zone, "" for a full purge
 }
 
 // NewState creates a new State instance for the mock server.
@@ -237,6 +317,12 @@ func NewState() *State {
 		failureInjection: FailureInjection{
 			rateLimitAfter: -1, // disabled by default
 		},
+		pullZones:         make(map[int64]*PullZone),
+		storageZones:      make(map[int64]*StorageZone),
+		nextPullZoneID:    1,
+		nextStorageZoneID: 1,
+		nextHostnameID:    1,
+		purges:            make(map[int64][]string),
 	}
 	_ = &s.mu // Mutex will be used by state management methods
 	return s
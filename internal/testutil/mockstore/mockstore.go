@@ -6,6 +6,7 @@ package mockstore
 
 import (
 	"context"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -15,12 +16,19 @@ import (
 // If a function field is nil, the method returns a sensible default value.
 type MockStorage struct {
 	// Token operations (storage.TokenStore interface)
-	CreateTokenFunc      func(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error)
-	GetTokenByHashFunc   func(ctx context.Context, keyHash string) (*storage.Token, error)
-	GetTokenByIDFunc     func(ctx context.Context, id int64) (*storage.Token, error)
-	ListTokensFunc       func(ctx context.Context) ([]*storage.Token, error)
-	DeleteTokenFunc      func(ctx context.Context, id int64) error
-	HasAnyAdminTokenFunc func(ctx context.Context) (bool, error)
+	CreateTokenFunc         func(ctx context.Context, name string, isAdmin bool, keyHash string) (*storage.Token, error)
+	CreateKeyTokenFunc      func(ctx context.Context, name string, isAdmin bool, secretHash string) (*storage.Token, error)
+	GetTokenByHashFunc      func(ctx context.Context, keyHash string) (*storage.Token, error)
+	GetTokenByKeyFunc       func(ctx context.Context, id int64, secretHash string) (*storage.Token, error)
+	GetTokenByIDFunc        func(ctx context.Context, id int64) (*storage.Token, error)
+	ListTokensFunc          func(ctx context.Context) ([]*storage.Token, error)
+	DeleteTokenFunc         func(ctx context.Context, id int64) error
+	SetTokenExpiryFunc      func(ctx context.Context, id int64, expiresAt time.Time) error
+	SetTokenPinningFunc     func(ctx context.Context, id int64, pin bool) error
+	SetTokenAccountFunc     func(ctx context.Context, id int64, account string) error
+	PinTokenFingerprintFunc func(ctx context.Context, id int64, fingerprint string) (string, error)
+	RecordTokenUsageFunc    func(ctx context.Context, usage []storage.TokenUsage) error
+	HasAnyAdminTokenFunc    func(ctx context.Context) (bool, error)
 
 	// Unified token operations
 	CountAdminTokensFunc         func(ctx context.Context) (int, error)
@@ -29,6 +37,157 @@ type MockStorage struct {
 	RemovePermissionForTokenFunc func(ctx context.Context, tokenID, permID int64) error
 	GetPermissionsForTokenFunc   func(ctx context.Context, tokenID int64) ([]*storage.Permission, error)
 
+	// Webhook operations (storage.WebhookStore interface)
+	CreateWebhookFunc         func(ctx context.Context, hook *storage.Webhook) (*storage.Webhook, error)
+	GetWebhookFunc            func(ctx context.Context, id int64) (*storage.Webhook, error)
+	ListWebhooksFunc          func(ctx context.Context) ([]*storage.Webhook, error)
+	CountWebhooksByTokenFunc  func(ctx context.Context, tokenID int64) (int, error)
+	DeleteWebhookFunc         func(ctx context.Context, id int64) error
+	RecordWebhookDeliveryFunc func(ctx context.Context, d *storage.WebhookDelivery) (*storage.WebhookDelivery, error)
+	ListWebhookDeliveriesFunc func(ctx context.Context, webhookID int64, limit int) ([]*storage.WebhookDelivery, error)
+
+	// Outbox operations (storage.OutboxStore interface)
+	EnqueueOutboxEventFunc       func(ctx context.Context, e *storage.OutboxEvent) (*storage.OutboxEvent, error)
+	ListDueOutboxEventsFunc      func(ctx context.Context, now time.Time, limit int) ([]*storage.OutboxEvent, error)
+	MarkOutboxEventDeliveredFunc func(ctx context.Context, id int64, at time.Time) error
+	MarkOutboxEventFailedFunc    func(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error
+	PruneOutboxEventsFunc        func(ctx context.Context, before time.Time) (int64, error)
+
+	// Record metadata operations (storage.RecordMetaStore interface)
+	GetRecordMetaFunc        func(ctx context.Context, zoneID, recordID int64) (*storage.RecordMeta, error)
+	ListRecordMetaFunc       func(ctx context.Context, zoneID int64) ([]*storage.RecordMeta, error)
+	SetRecordMetaFunc        func(ctx context.Context, m *storage.RecordMeta) (*storage.RecordMeta, error)
+	DeleteRecordMetaFunc     func(ctx context.Context, zoneID, recordID int64) error
+	DeleteZoneRecordMetaFunc func(ctx context.Context, zoneID int64) error
+
+	// Record change operations (storage.RecordChangeStore interface)
+	AddRecordChangeFunc   func(ctx context.Context, c *storage.RecordChange) (*storage.RecordChange, error)
+	ListRecordChangesFunc func(ctx context.Context, zoneID int64, filter storage.RecordChangeFilter) ([]*storage.RecordChange, error)
+	GetRecordChangeFunc   func(ctx context.Context, id int64) (*storage.RecordChange, error)
+
+	// Scheduled change operations (storage.ScheduledChangeStore interface)
+	CreateScheduledChangeFunc          func(ctx context.Context, c *storage.ScheduledChange) (*storage.ScheduledChange, error)
+	GetScheduledChangeFunc             func(ctx context.Context, id int64) (*storage.ScheduledChange, error)
+	ListScheduledChangesFunc           func(ctx context.Context, zoneID int64, status string) ([]*storage.ScheduledChange, error)
+	ListDueScheduledChangesFunc        func(ctx context.Context, at time.Time) ([]*storage.ScheduledChange, error)
+	StartScheduledChangeFunc           func(ctx context.Context, id int64, at time.Time) error
+	FinishScheduledChangeFunc          func(ctx context.Context, id int64, status string, recordID int64, errMsg string, at time.Time) error
+	CancelScheduledChangeFunc          func(ctx context.Context, id int64, at time.Time) error
+	FailUnfinishedScheduledChangesFunc func(ctx context.Context, before time.Time, message string) (int64, error)
+	PruneScheduledChangesFunc          func(ctx context.Context, before time.Time) (int64, error)
+
+	// Audit log operations (storage.AuditStore interface)
+	AppendAuditEntryFunc           func(ctx context.Context, e *storage.AuditEntry) (*storage.AuditEntry, error)
+	AppendAuditEntryWithEventsFunc func(ctx context.Context, e *storage.AuditEntry, events []*storage.OutboxEvent) (*storage.AuditEntry, error)
+	ListAuditEntriesFunc           func(ctx context.Context, filter storage.AuditFilter) ([]*storage.AuditEntry, error)
+	PruneAuditEntriesFunc          func(ctx context.Context, before time.Time) (int64, error)
+	ListHotZonesFunc               func(ctx context.Context, since time.Time, limit int) ([]int64, error)
+
+	// Maintenance operations (storage.MaintenanceStore interface)
+	DBStatsFunc           func(ctx context.Context) (*storage.DBStats, error)
+	IncrementalVacuumFunc func(ctx context.Context, pages int) error
+	OpenConnectionsFunc   func() int
+
+	// Instance registry operations (storage.InstanceStore interface)
+	GetInstanceByHostnameFunc func(ctx context.Context, hostname string) (*storage.Instance, error)
+	UpsertInstanceFunc        func(ctx context.Context, inst *storage.Instance) error
+	TouchInstanceFunc         func(ctx context.Context, id string, at time.Time) error
+	ListInstancesFunc         func(ctx context.Context) ([]*storage.Instance, error)
+	PruneInstancesFunc        func(ctx context.Context, before time.Time) (int64, error)
+
+	// Blocklist operations (storage.BlocklistStore interface)
+	AddBlocklistEntryFunc     func(ctx context.Context, e *storage.BlocklistEntry) (*storage.BlocklistEntry, error)
+	ListBlocklistEntriesFunc  func(ctx context.Context) ([]*storage.BlocklistEntry, error)
+	DeleteBlocklistEntryFunc  func(ctx context.Context, id int64) error
+	PruneBlocklistEntriesFunc func(ctx context.Context, before time.Time) (int64, error)
+
+	// Zone freeze operations (storage.ZoneFreezeStore interface)
+	AddZoneFreezeFunc    func(ctx context.Context, f *storage.ZoneFreeze) (*storage.ZoneFreeze, error)
+	ListZoneFreezesFunc  func(ctx context.Context) ([]*storage.ZoneFreeze, error)
+	DeleteZoneFreezeFunc func(ctx context.Context, id int64) error
+	PruneZoneFreezesFunc func(ctx context.Context, before time.Time) (int64, error)
+
+	// TTL policy operations (storage.TTLPolicyStore interface)
+	SetTTLPolicyFunc    func(ctx context.Context, p *storage.TTLPolicy) (*storage.TTLPolicy, error)
+	ListTTLPoliciesFunc func(ctx context.Context) ([]*storage.TTLPolicy, error)
+	DeleteTTLPolicyFunc func(ctx context.Context, zoneID int64) error
+
+	// Protected record operations (storage.ProtectedRecordStore interface)
+	AddProtectedRecordFunc    func(ctx context.Context, p *storage.ProtectedRecord) (*storage.ProtectedRecord, error)
+	ListProtectedRecordsFunc  func(ctx context.Context, zoneID int64) ([]*storage.ProtectedRecord, error)
+	DeleteProtectedRecordFunc func(ctx context.Context, id int64) error
+
+	// Zone tag operations (storage.ZoneTagStore interface)
+	ReplaceZoneTagsFunc     func(ctx context.Context, tags []storage.ZoneTag) error
+	ListZoneTagsFunc        func(ctx context.Context) ([]*storage.ZoneTag, error)
+	AddTagPermissionFunc    func(ctx context.Context, p *storage.TagPermission) (*storage.TagPermission, error)
+	ListTagPermissionsFunc  func(ctx context.Context, tokenID int64) ([]*storage.TagPermission, error)
+	DeleteTagPermissionFunc func(ctx context.Context, tokenID, id int64) error
+	SyncTagPermissionsFunc  func(ctx context.Context) (int64, int64, error)
+
+	// Domain permission operations (storage.DomainPermissionStore interface)
+	AddDomainPermissionFunc    func(ctx context.Context, p *storage.DomainPermission) (*storage.DomainPermission, error)
+	ListDomainPermissionsFunc  func(ctx context.Context, tokenID int64) ([]*storage.DomainPermission, error)
+	DeleteDomainPermissionFunc func(ctx context.Context, tokenID, id int64) error
+
+	// Nonce operations (storage.NonceStore interface)
+	RecordNonceFunc func(ctx context.Context, tokenID int64, nonce string, at time.Time) error
+	PruneNoncesFunc func(ctx context.Context, before time.Time) (int64, error)
+
+	// Idempotency key operations (storage.IdempotencyStore interface)
+	ClaimIdempotencyKeyFunc    func(ctx context.Context, tokenID int64, key, fingerprint string, at time.Time) error
+	GetIdempotentResponseFunc  func(ctx context.Context, tokenID int64, key string) (*storage.IdempotentResponse, error)
+	CompleteIdempotencyKeyFunc func(ctx context.Context, tokenID int64, key string, status int, contentType string, body []byte) error
+	ReleaseIdempotencyKeyFunc  func(ctx context.Context, tokenID int64, key string) error
+	PruneIdempotencyKeysFunc   func(ctx context.Context, before time.Time) (int64, error)
+
+	// Job operations (storage.JobStore interface)
+	CreateJobFunc          func(ctx context.Context, j *storage.Job) (*storage.Job, error)
+	GetJobFunc             func(ctx context.Context, id int64) (*storage.Job, error)
+	StartJobFunc           func(ctx context.Context, id int64, at time.Time) error
+	UpdateJobProgressFunc  func(ctx context.Context, id int64, processed int) error
+	FinishJobFunc          func(ctx context.Context, id int64, status string, processed int, result []byte, errMsg string, at time.Time) error
+	FailUnfinishedJobsFunc func(ctx context.Context, before time.Time, message string) (int64, error)
+	PruneJobsFunc          func(ctx context.Context, before time.Time) (int64, error)
+
+	// Admin session operations (storage.AdminSessionStore interface)
+	CreateAdminSessionFunc    func(ctx context.Context, sess *storage.AdminSession, sessionHash string) (*storage.AdminSession, error)
+	GetAdminSessionByHashFunc func(ctx context.Context, sessionHash string) (*storage.AdminSession, error)
+	DeleteAdminSessionFunc    func(ctx context.Context, id int64) error
+	PruneAdminSessionsFunc    func(ctx context.Context, before time.Time) (int64, error)
+
+	// Client certificate operations (storage.ClientCertStore interface)
+	SetTokenClientCertFunc    func(ctx context.Context, tokenID int64, commonName string) error
+	GetTokenClientCertFunc    func(ctx context.Context, tokenID int64) (string, error)
+	GetTokenByClientCertFunc  func(ctx context.Context, commonName string) (*storage.Token, error)
+	DeleteTokenClientCertFunc func(ctx context.Context, tokenID int64) error
+
+	// Bootstrap operations (storage.BootstrapStore interface)
+	CompleteBootstrapFunc       func(ctx context.Context, at time.Time) error
+	GetBootstrapCompletedAtFunc func(ctx context.Context) (time.Time, error)
+
+	// Role operations (storage.RoleStore interface)
+	CreateRoleFunc           func(ctx context.Context, role *storage.Role) (*storage.Role, error)
+	GetRoleFunc              func(ctx context.Context, id int64) (*storage.Role, error)
+	ListRolesFunc            func(ctx context.Context) ([]*storage.Role, error)
+	ListTokenRolesFunc       func(ctx context.Context, tokenID int64) ([]*storage.Role, error)
+	DeleteRoleFunc           func(ctx context.Context, id int64) error
+	AddRolePermissionFunc    func(ctx context.Context, roleID int64, perm *storage.Permission) (*storage.Permission, error)
+	RemoveRolePermissionFunc func(ctx context.Context, roleID, permID int64) error
+	AssignRoleFunc           func(ctx context.Context, tokenID, roleID int64) error
+	UnassignRoleFunc         func(ctx context.Context, tokenID, roleID int64) error
+
+	// Invitation operations (storage.InvitationStore interface)
+	CreateInvitationFunc    func(ctx context.Context, inv *storage.Invitation, inviteHash string) (*storage.Invitation, error)
+	GetInvitationByHashFunc func(ctx context.Context, inviteHash string) (*storage.Invitation, error)
+	ListInvitationsFunc     func(ctx context.Context) ([]*storage.Invitation, error)
+	DeleteInvitationFunc    func(ctx context.Context, id int64) error
+	ClaimInvitationFunc     func(ctx context.Context, id, tokenID int64, now time.Time) error
+
+	// Provisioning operations (storage.ProvisionStore interface)
+	MarkTokenProvisionedFunc    func(ctx context.Context, tokenID int64) error
+	ListProvisionedTokenIDsFunc func(ctx context.Context) ([]int64, error)
+
 	// Lifecycle
 	PingFunc  func(ctx context.Context) error
 	CloseFunc func() error
@@ -42,6 +201,14 @@ func (m *MockStorage) CreateToken(ctx context.Context, name string, isAdmin bool
 	return &storage.Token{ID: 1, Name: name, IsAdmin: isAdmin, KeyHash: keyHash}, nil
 }
 
+// CreateKeyToken creates a new token for an <id>.<secret> key.
+func (m *MockStorage) CreateKeyToken(ctx context.Context, name string, isAdmin bool, secretHash string) (*storage.Token, error) {
+	if m.CreateKeyTokenFunc != nil {
+		return m.CreateKeyTokenFunc(ctx, name, isAdmin, secretHash)
+	}
+	return &storage.Token{ID: 1, Name: name, IsAdmin: isAdmin, KeyHash: secretHash}, nil
+}
+
 // GetTokenByHash retrieves a token by its hash.
 func (m *MockStorage) GetTokenByHash(ctx context.Context, keyHash string) (*storage.Token, error) {
 	if m.GetTokenByHashFunc != nil {
@@ -50,6 +217,14 @@ func (m *MockStorage) GetTokenByHash(ctx context.Context, keyHash string) (*stor
 	return nil, storage.ErrNotFound
 }
 
+// GetTokenByKey retrieves a token by ID and the hash of its key's secret.
+func (m *MockStorage) GetTokenByKey(ctx context.Context, id int64, secretHash string) (*storage.Token, error) {
+	if m.GetTokenByKeyFunc != nil {
+		return m.GetTokenByKeyFunc(ctx, id, secretHash)
+	}
+	return nil, storage.ErrNotFound
+}
+
 // GetTokenByID retrieves a token by ID.
 func (m *MockStorage) GetTokenByID(ctx context.Context, id int64) (*storage.Token, error) {
 	if m.GetTokenByIDFunc != nil {
@@ -74,6 +249,47 @@ func (m *MockStorage) DeleteToken(ctx context.Context, id int64) error {
 	return nil
 }
 
+// SetTokenExpiry sets when a token stops working.
+func (m *MockStorage) SetTokenExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	if m.SetTokenExpiryFunc != nil {
+		return m.SetTokenExpiryFunc(ctx, id, expiresAt)
+	}
+	return nil
+}
+
+// SetTokenPinning enables or disables TLS fingerprint pinning for a token.
+func (m *MockStorage) SetTokenPinning(ctx context.Context, id int64, pin bool) error {
+	if m.SetTokenPinningFunc != nil {
+		return m.SetTokenPinningFunc(ctx, id, pin)
+	}
+	return nil
+}
+
+// SetTokenAccount sets the upstream account a token's requests are sent to.
+func (m *MockStorage) SetTokenAccount(ctx context.Context, id int64, account string) error {
+	if m.SetTokenAccountFunc != nil {
+		return m.SetTokenAccountFunc(ctx, id, account)
+	}
+	return nil
+}
+
+// PinTokenFingerprint records a token's TLS fingerprint if none is pinned yet.
+// By default the given fingerprint is reported as pinned.
+func (m *MockStorage) PinTokenFingerprint(ctx context.Context, id int64, fingerprint string) (string, error) {
+	if m.PinTokenFingerprintFunc != nil {
+		return m.PinTokenFingerprintFunc(ctx, id, fingerprint)
+	}
+	return fingerprint, nil
+}
+
+// RecordTokenUsage adds batched use counts to tokens.
+func (m *MockStorage) RecordTokenUsage(ctx context.Context, usage []storage.TokenUsage) error {
+	if m.RecordTokenUsageFunc != nil {
+		return m.RecordTokenUsageFunc(ctx, usage)
+	}
+	return nil
+}
+
 // HasAnyAdminToken checks if there are any admin tokens.
 func (m *MockStorage) HasAnyAdminToken(ctx context.Context) (bool, error) {
 	if m.HasAnyAdminTokenFunc != nil {
@@ -124,6 +340,850 @@ func (m *MockStorage) GetPermissionsForToken(ctx context.Context, tokenID int64)
 	return []*storage.Permission{}, nil
 }
 
+// CreateWebhook registers a new webhook endpoint.
+func (m *MockStorage) CreateWebhook(ctx context.Context, hook *storage.Webhook) (*storage.Webhook, error) {
+	if m.CreateWebhookFunc != nil {
+		return m.CreateWebhookFunc(ctx, hook)
+	}
+	hook.ID = 1
+	return hook, nil
+}
+
+// GetWebhook retrieves a webhook by ID.
+func (m *MockStorage) GetWebhook(ctx context.Context, id int64) (*storage.Webhook, error) {
+	if m.GetWebhookFunc != nil {
+		return m.GetWebhookFunc(ctx, id)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// ListWebhooks retrieves all webhooks.
+func (m *MockStorage) ListWebhooks(ctx context.Context) ([]*storage.Webhook, error) {
+	if m.ListWebhooksFunc != nil {
+		return m.ListWebhooksFunc(ctx)
+	}
+	return []*storage.Webhook{}, nil
+}
+
+// CountWebhooksByToken returns the number of webhooks owned by a token.
+func (m *MockStorage) CountWebhooksByToken(ctx context.Context, tokenID int64) (int, error) {
+	if m.CountWebhooksByTokenFunc != nil {
+		return m.CountWebhooksByTokenFunc(ctx, tokenID)
+	}
+	return 0, nil
+}
+
+// DeleteWebhook deletes a webhook by ID.
+func (m *MockStorage) DeleteWebhook(ctx context.Context, id int64) error {
+	if m.DeleteWebhookFunc != nil {
+		return m.DeleteWebhookFunc(ctx, id)
+	}
+	return nil
+}
+
+// RecordWebhookDelivery stores a delivery attempt.
+func (m *MockStorage) RecordWebhookDelivery(ctx context.Context, d *storage.WebhookDelivery) (*storage.WebhookDelivery, error) {
+	if m.RecordWebhookDeliveryFunc != nil {
+		return m.RecordWebhookDeliveryFunc(ctx, d)
+	}
+	d.ID = 1
+	return d, nil
+}
+
+// ListWebhookDeliveries retrieves delivery attempts for a webhook.
+func (m *MockStorage) ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]*storage.WebhookDelivery, error) {
+	if m.ListWebhookDeliveriesFunc != nil {
+		return m.ListWebhookDeliveriesFunc(ctx, webhookID, limit)
+	}
+	return []*storage.WebhookDelivery{}, nil
+}
+
+// EnqueueOutboxEvent persists an event for delivery.
+func (m *MockStorage) EnqueueOutboxEvent(ctx context.Context, e *storage.OutboxEvent) (*storage.OutboxEvent, error) {
+	if m.EnqueueOutboxEventFunc != nil {
+		return m.EnqueueOutboxEventFunc(ctx, e)
+	}
+	e.ID = 1
+	e.Status = storage.OutboxStatusPending
+	return e, nil
+}
+
+// ListDueOutboxEvents returns pending events due for delivery.
+func (m *MockStorage) ListDueOutboxEvents(ctx context.Context, now time.Time, limit int) ([]*storage.OutboxEvent, error) {
+	if m.ListDueOutboxEventsFunc != nil {
+		return m.ListDueOutboxEventsFunc(ctx, now, limit)
+	}
+	return []*storage.OutboxEvent{}, nil
+}
+
+// MarkOutboxEventDelivered marks an event as delivered.
+func (m *MockStorage) MarkOutboxEventDelivered(ctx context.Context, id int64, at time.Time) error {
+	if m.MarkOutboxEventDeliveredFunc != nil {
+		return m.MarkOutboxEventDeliveredFunc(ctx, id, at)
+	}
+	return nil
+}
+
+// MarkOutboxEventFailed records a failed delivery attempt.
+func (m *MockStorage) MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	if m.MarkOutboxEventFailedFunc != nil {
+		return m.MarkOutboxEventFailedFunc(ctx, id, lastError, nextAttemptAt, dead)
+	}
+	return nil
+}
+
+// PruneOutboxEvents deletes old delivered events.
+func (m *MockStorage) PruneOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneOutboxEventsFunc != nil {
+		return m.PruneOutboxEventsFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// GetRecordMeta retrieves metadata for a record.
+func (m *MockStorage) GetRecordMeta(ctx context.Context, zoneID, recordID int64) (*storage.RecordMeta, error) {
+	if m.GetRecordMetaFunc != nil {
+		return m.GetRecordMetaFunc(ctx, zoneID, recordID)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// ListRecordMeta retrieves metadata for all annotated records in a zone.
+func (m *MockStorage) ListRecordMeta(ctx context.Context, zoneID int64) ([]*storage.RecordMeta, error) {
+	if m.ListRecordMetaFunc != nil {
+		return m.ListRecordMetaFunc(ctx, zoneID)
+	}
+	return []*storage.RecordMeta{}, nil
+}
+
+// SetRecordMeta replaces the metadata for a record.
+func (m *MockStorage) SetRecordMeta(ctx context.Context, meta *storage.RecordMeta) (*storage.RecordMeta, error) {
+	if m.SetRecordMetaFunc != nil {
+		return m.SetRecordMetaFunc(ctx, meta)
+	}
+	return meta, nil
+}
+
+// DeleteRecordMeta removes metadata for a record.
+func (m *MockStorage) DeleteRecordMeta(ctx context.Context, zoneID, recordID int64) error {
+	if m.DeleteRecordMetaFunc != nil {
+		return m.DeleteRecordMetaFunc(ctx, zoneID, recordID)
+	}
+	return nil
+}
+
+// DeleteZoneRecordMeta removes metadata for all records in a zone.
+func (m *MockStorage) DeleteZoneRecordMeta(ctx context.Context, zoneID int64) error {
+	if m.DeleteZoneRecordMetaFunc != nil {
+		return m.DeleteZoneRecordMetaFunc(ctx, zoneID)
+	}
+	return nil
+}
+
+// AddRecordChange records a record mutation.
+func (m *MockStorage) AddRecordChange(ctx context.Context, c *storage.RecordChange) (*storage.RecordChange, error) {
+	if m.AddRecordChangeFunc != nil {
+		return m.AddRecordChangeFunc(ctx, c)
+	}
+	return c, nil
+}
+
+// ListRecordChanges returns a zone's record changes matching the filter.
+func (m *MockStorage) ListRecordChanges(ctx context.Context, zoneID int64, filter storage.RecordChangeFilter) ([]*storage.RecordChange, error) {
+	if m.ListRecordChangesFunc != nil {
+		return m.ListRecordChangesFunc(ctx, zoneID, filter)
+	}
+	return []*storage.RecordChange{}, nil
+}
+
+// GetRecordChange retrieves a record change by ID.
+func (m *MockStorage) GetRecordChange(ctx context.Context, id int64) (*storage.RecordChange, error) {
+	if m.GetRecordChangeFunc != nil {
+		return m.GetRecordChangeFunc(ctx, id)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// CreateScheduledChange records a pending change.
+func (m *MockStorage) CreateScheduledChange(ctx context.Context, c *storage.ScheduledChange) (*storage.ScheduledChange, error) {
+	if m.CreateScheduledChangeFunc != nil {
+		return m.CreateScheduledChangeFunc(ctx, c)
+	}
+	return c, nil
+}
+
+// GetScheduledChange retrieves a scheduled change by ID.
+func (m *MockStorage) GetScheduledChange(ctx context.Context, id int64) (*storage.ScheduledChange, error) {
+	if m.GetScheduledChangeFunc != nil {
+		return m.GetScheduledChangeFunc(ctx, id)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// ListScheduledChanges returns a zone's scheduled changes with a status.
+func (m *MockStorage) ListScheduledChanges(ctx context.Context, zoneID int64, status string) ([]*storage.ScheduledChange, error) {
+	if m.ListScheduledChangesFunc != nil {
+		return m.ListScheduledChangesFunc(ctx, zoneID, status)
+	}
+	return []*storage.ScheduledChange{}, nil
+}
+
+// ListDueScheduledChanges returns pending changes that are due.
+func (m *MockStorage) ListDueScheduledChanges(ctx context.Context, at time.Time) ([]*storage.ScheduledChange, error) {
+	if m.ListDueScheduledChangesFunc != nil {
+		return m.ListDueScheduledChangesFunc(ctx, at)
+	}
+	return []*storage.ScheduledChange{}, nil
+}
+
+// StartScheduledChange marks a pending change as running.
+func (m *MockStorage) StartScheduledChange(ctx context.Context, id int64, at time.Time) error {
+	if m.StartScheduledChangeFunc != nil {
+		return m.StartScheduledChangeFunc(ctx, id, at)
+	}
+	return nil
+}
+
+// FinishScheduledChange records the outcome of a running change.
+func (m *MockStorage) FinishScheduledChange(ctx context.Context, id int64, status string, recordID int64, errMsg string, at time.Time) error {
+	if m.FinishScheduledChangeFunc != nil {
+		return m.FinishScheduledChangeFunc(ctx, id, status, recordID, errMsg, at)
+	}
+	return nil
+}
+
+// CancelScheduledChange marks a pending change as cancelled.
+func (m *MockStorage) CancelScheduledChange(ctx context.Context, id int64, at time.Time) error {
+	if m.CancelScheduledChangeFunc != nil {
+		return m.CancelScheduledChangeFunc(ctx, id, at)
+	}
+	return nil
+}
+
+// FailUnfinishedScheduledChanges marks interrupted changes as failed.
+func (m *MockStorage) FailUnfinishedScheduledChanges(ctx context.Context, before time.Time, message string) (int64, error) {
+	if m.FailUnfinishedScheduledChangesFunc != nil {
+		return m.FailUnfinishedScheduledChangesFunc(ctx, before, message)
+	}
+	return 0, nil
+}
+
+// PruneScheduledChanges deletes changes that finished before the cutoff.
+func (m *MockStorage) PruneScheduledChanges(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneScheduledChangesFunc != nil {
+		return m.PruneScheduledChangesFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// AppendAuditEntry records an audit entry.
+func (m *MockStorage) AppendAuditEntry(ctx context.Context, e *storage.AuditEntry) (*storage.AuditEntry, error) {
+	if m.AppendAuditEntryFunc != nil {
+		return m.AppendAuditEntryFunc(ctx, e)
+	}
+	return e, nil
+}
+
+// AppendAuditEntryWithEvents records an audit entry and enqueues outbox events.
+func (m *MockStorage) AppendAuditEntryWithEvents(ctx context.Context, e *storage.AuditEntry, events []*storage.OutboxEvent) (*storage.AuditEntry, error) {
+	if m.AppendAuditEntryWithEventsFunc != nil {
+		return m.AppendAuditEntryWithEventsFunc(ctx, e, events)
+	}
+	return e, nil
+}
+
+// ListAuditEntries returns audit entries matching the filter.
+func (m *MockStorage) ListAuditEntries(ctx context.Context, filter storage.AuditFilter) ([]*storage.AuditEntry, error) {
+	if m.ListAuditEntriesFunc != nil {
+		return m.ListAuditEntriesFunc(ctx, filter)
+	}
+	return []*storage.AuditEntry{}, nil
+}
+
+// PruneAuditEntries deletes audit entries created before the cutoff.
+func (m *MockStorage) PruneAuditEntries(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneAuditEntriesFunc != nil {
+		return m.PruneAuditEntriesFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// ListHotZones returns the most audited zones since the cutoff.
+func (m *MockStorage) ListHotZones(ctx context.Context, since time.Time, limit int) ([]int64, error) {
+	if m.ListHotZonesFunc != nil {
+		return m.ListHotZonesFunc(ctx, since, limit)
+	}
+	return []int64{}, nil
+}
+
+// DBStats returns the database size and page usage.
+func (m *MockStorage) DBStats(ctx context.Context) (*storage.DBStats, error) {
+	if m.DBStatsFunc != nil {
+		return m.DBStatsFunc(ctx)
+	}
+	return &storage.DBStats{}, nil
+}
+
+// IncrementalVacuum returns free pages to the filesystem.
+func (m *MockStorage) IncrementalVacuum(ctx context.Context, pages int) error {
+	if m.IncrementalVacuumFunc != nil {
+		return m.IncrementalVacuumFunc(ctx, pages)
+	}
+	return nil
+}
+
+// OpenConnections returns the number of established database connections.
+func (m *MockStorage) OpenConnections() int {
+	if m.OpenConnectionsFunc != nil {
+		return m.OpenConnectionsFunc()
+	}
+	return 0
+}
+
+// GetInstanceByHostname retrieves the most recently seen instance on a host.
+func (m *MockStorage) GetInstanceByHostname(ctx context.Context, hostname string) (*storage.Instance, error) {
+	if m.GetInstanceByHostnameFunc != nil {
+		return m.GetInstanceByHostnameFunc(ctx, hostname)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// UpsertInstance registers an instance.
+func (m *MockStorage) UpsertInstance(ctx context.Context, inst *storage.Instance) error {
+	if m.UpsertInstanceFunc != nil {
+		return m.UpsertInstanceFunc(ctx, inst)
+	}
+	return nil
+}
+
+// TouchInstance updates an instance's last seen time.
+func (m *MockStorage) TouchInstance(ctx context.Context, id string, at time.Time) error {
+	if m.TouchInstanceFunc != nil {
+		return m.TouchInstanceFunc(ctx, id, at)
+	}
+	return nil
+}
+
+// ListInstances returns all registered instances.
+func (m *MockStorage) ListInstances(ctx context.Context) ([]*storage.Instance, error) {
+	if m.ListInstancesFunc != nil {
+		return m.ListInstancesFunc(ctx)
+	}
+	return []*storage.Instance{}, nil
+}
+
+// PruneInstances deletes instances last seen before the cutoff.
+func (m *MockStorage) PruneInstances(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneInstancesFunc != nil {
+		return m.PruneInstancesFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// AddBlocklistEntry adds a blocked network.
+func (m *MockStorage) AddBlocklistEntry(ctx context.Context, e *storage.BlocklistEntry) (*storage.BlocklistEntry, error) {
+	if m.AddBlocklistEntryFunc != nil {
+		return m.AddBlocklistEntryFunc(ctx, e)
+	}
+	return e, nil
+}
+
+// ListBlocklistEntries returns all blocklist entries.
+func (m *MockStorage) ListBlocklistEntries(ctx context.Context) ([]*storage.BlocklistEntry, error) {
+	if m.ListBlocklistEntriesFunc != nil {
+		return m.ListBlocklistEntriesFunc(ctx)
+	}
+	return []*storage.BlocklistEntry{}, nil
+}
+
+// DeleteBlocklistEntry removes a blocklist entry.
+func (m *MockStorage) DeleteBlocklistEntry(ctx context.Context, id int64) error {
+	if m.DeleteBlocklistEntryFunc != nil {
+		return m.DeleteBlocklistEntryFunc(ctx, id)
+	}
+	return nil
+}
+
+// PruneBlocklistEntries deletes expired blocklist entries.
+func (m *MockStorage) PruneBlocklistEntries(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneBlocklistEntriesFunc != nil {
+		return m.PruneBlocklistEntriesFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// AddZoneFreeze adds a zone freeze.
+func (m *MockStorage) AddZoneFreeze(ctx context.Context, f *storage.ZoneFreeze) (*storage.ZoneFreeze, error) {
+	if m.AddZoneFreezeFunc != nil {
+		return m.AddZoneFreezeFunc(ctx, f)
+	}
+	return f, nil
+}
+
+// ListZoneFreezes returns all zone freezes.
+func (m *MockStorage) ListZoneFreezes(ctx context.Context) ([]*storage.ZoneFreeze, error) {
+	if m.ListZoneFreezesFunc != nil {
+		return m.ListZoneFreezesFunc(ctx)
+	}
+	return []*storage.ZoneFreeze{}, nil
+}
+
+// DeleteZoneFreeze removes a zone freeze.
+func (m *MockStorage) DeleteZoneFreeze(ctx context.Context, id int64) error {
+	if m.DeleteZoneFreezeFunc != nil {
+		return m.DeleteZoneFreezeFunc(ctx, id)
+	}
+	return nil
+}
+
+// PruneZoneFreezes deletes ended zone freezes.
+func (m *MockStorage) PruneZoneFreezes(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneZoneFreezesFunc != nil {
+		return m.PruneZoneFreezesFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// SetTTLPolicy creates or replaces a TTL policy.
+func (m *MockStorage) SetTTLPolicy(ctx context.Context, p *storage.TTLPolicy) (*storage.TTLPolicy, error) {
+	if m.SetTTLPolicyFunc != nil {
+		return m.SetTTLPolicyFunc(ctx, p)
+	}
+	return p, nil
+}
+
+// ListTTLPolicies returns all TTL policies.
+func (m *MockStorage) ListTTLPolicies(ctx context.Context) ([]*storage.TTLPolicy, error) {
+	if m.ListTTLPoliciesFunc != nil {
+		return m.ListTTLPoliciesFunc(ctx)
+	}
+	return []*storage.TTLPolicy{}, nil
+}
+
+// DeleteTTLPolicy removes a TTL policy.
+func (m *MockStorage) DeleteTTLPolicy(ctx context.Context, zoneID int64) error {
+	if m.DeleteTTLPolicyFunc != nil {
+		return m.DeleteTTLPolicyFunc(ctx, zoneID)
+	}
+	return nil
+}
+
+// AddProtectedRecord protects a record name.
+func (m *MockStorage) AddProtectedRecord(ctx context.Context, p *storage.ProtectedRecord) (*storage.ProtectedRecord, error) {
+	if m.AddProtectedRecordFunc != nil {
+		return m.AddProtectedRecordFunc(ctx, p)
+	}
+	return p, nil
+}
+
+// ListProtectedRecords returns protected record names.
+func (m *MockStorage) ListProtectedRecords(ctx context.Context, zoneID int64) ([]*storage.ProtectedRecord, error) {
+	if m.ListProtectedRecordsFunc != nil {
+		return m.ListProtectedRecordsFunc(ctx, zoneID)
+	}
+	return []*storage.ProtectedRecord{}, nil
+}
+
+// DeleteProtectedRecord removes a protected record name.
+func (m *MockStorage) DeleteProtectedRecord(ctx context.Context, id int64) error {
+	if m.DeleteProtectedRecordFunc != nil {
+		return m.DeleteProtectedRecordFunc(ctx, id)
+	}
+	return nil
+}
+
+// ReplaceZoneTags replaces all zone tags.
+func (m *MockStorage) ReplaceZoneTags(ctx context.Context, tags []storage.ZoneTag) error {
+	if m.ReplaceZoneTagsFunc != nil {
+		return m.ReplaceZoneTagsFunc(ctx, tags)
+	}
+	return nil
+}
+
+// ListZoneTags returns all zone tags.
+func (m *MockStorage) ListZoneTags(ctx context.Context) ([]*storage.ZoneTag, error) {
+	if m.ListZoneTagsFunc != nil {
+		return m.ListZoneTagsFunc(ctx)
+	}
+	return []*storage.ZoneTag{}, nil
+}
+
+// AddTagPermission grants a token a permission on every zone with a tag.
+func (m *MockStorage) AddTagPermission(ctx context.Context, p *storage.TagPermission) (*storage.TagPermission, error) {
+	if m.AddTagPermissionFunc != nil {
+		return m.AddTagPermissionFunc(ctx, p)
+	}
+	return p, nil
+}
+
+// ListTagPermissions returns a token's tag permissions.
+func (m *MockStorage) ListTagPermissions(ctx context.Context, tokenID int64) ([]*storage.TagPermission, error) {
+	if m.ListTagPermissionsFunc != nil {
+		return m.ListTagPermissionsFunc(ctx, tokenID)
+	}
+	return []*storage.TagPermission{}, nil
+}
+
+// DeleteTagPermission deletes a token's tag permission.
+func (m *MockStorage) DeleteTagPermission(ctx context.Context, tokenID, id int64) error {
+	if m.DeleteTagPermissionFunc != nil {
+		return m.DeleteTagPermissionFunc(ctx, tokenID, id)
+	}
+	return nil
+}
+
+// SyncTagPermissions brings tag-created permissions in line with zone tags.
+func (m *MockStorage) SyncTagPermissions(ctx context.Context) (int64, int64, error) {
+	if m.SyncTagPermissionsFunc != nil {
+		return m.SyncTagPermissionsFunc(ctx)
+	}
+	return 0, 0, nil
+}
+
+// AddDomainPermission grants a token a permission on zones matching a pattern.
+func (m *MockStorage) AddDomainPermission(ctx context.Context, p *storage.DomainPermission) (*storage.DomainPermission, error) {
+	if m.AddDomainPermissionFunc != nil {
+		return m.AddDomainPermissionFunc(ctx, p)
+	}
+	return p, nil
+}
+
+// ListDomainPermissions returns a token's domain permissions.
+func (m *MockStorage) ListDomainPermissions(ctx context.Context, tokenID int64) ([]*storage.DomainPermission, error) {
+	if m.ListDomainPermissionsFunc != nil {
+		return m.ListDomainPermissionsFunc(ctx, tokenID)
+	}
+	return []*storage.DomainPermission{}, nil
+}
+
+// DeleteDomainPermission deletes a token's domain permission.
+func (m *MockStorage) DeleteDomainPermission(ctx context.Context, tokenID, id int64) error {
+	if m.DeleteDomainPermissionFunc != nil {
+		return m.DeleteDomainPermissionFunc(ctx, tokenID, id)
+	}
+	return nil
+}
+
+// RecordNonce records a nonce used by a token.
+func (m *MockStorage) RecordNonce(ctx context.Context, tokenID int64, nonce string, at time.Time) error {
+	if m.RecordNonceFunc != nil {
+		return m.RecordNonceFunc(ctx, tokenID, nonce, at)
+	}
+	return nil
+}
+
+// PruneNonces deletes nonces seen before the cutoff.
+func (m *MockStorage) PruneNonces(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneNoncesFunc != nil {
+		return m.PruneNoncesFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// ClaimIdempotencyKey records that a token's request with a key is in progress.
+func (m *MockStorage) ClaimIdempotencyKey(ctx context.Context, tokenID int64, key, fingerprint string, at time.Time) error {
+	if m.ClaimIdempotencyKeyFunc != nil {
+		return m.ClaimIdempotencyKeyFunc(ctx, tokenID, key, fingerprint, at)
+	}
+	return nil
+}
+
+// GetIdempotentResponse returns the request recorded for a token's key.
+func (m *MockStorage) GetIdempotentResponse(ctx context.Context, tokenID int64, key string) (*storage.IdempotentResponse, error) {
+	if m.GetIdempotentResponseFunc != nil {
+		return m.GetIdempotentResponseFunc(ctx, tokenID, key)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// CompleteIdempotencyKey stores the response to a claimed key's request.
+func (m *MockStorage) CompleteIdempotencyKey(ctx context.Context, tokenID int64, key string, status int, contentType string, body []byte) error {
+	if m.CompleteIdempotencyKeyFunc != nil {
+		return m.CompleteIdempotencyKeyFunc(ctx, tokenID, key, status, contentType, body)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a key.
+func (m *MockStorage) ReleaseIdempotencyKey(ctx context.Context, tokenID int64, key string) error {
+	if m.ReleaseIdempotencyKeyFunc != nil {
+		return m.ReleaseIdempotencyKeyFunc(ctx, tokenID, key)
+	}
+	return nil
+}
+
+// PruneIdempotencyKeys deletes keys claimed before the cutoff.
+func (m *MockStorage) PruneIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneIdempotencyKeysFunc != nil {
+		return m.PruneIdempotencyKeysFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// CreateJob records a queued job.
+func (m *MockStorage) CreateJob(ctx context.Context, j *storage.Job) (*storage.Job, error) {
+	if m.CreateJobFunc != nil {
+		return m.CreateJobFunc(ctx, j)
+	}
+	return j, nil
+}
+
+// GetJob retrieves a job by ID.
+func (m *MockStorage) GetJob(ctx context.Context, id int64) (*storage.Job, error) {
+	if m.GetJobFunc != nil {
+		return m.GetJobFunc(ctx, id)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// StartJob marks a queued job as running.
+func (m *MockStorage) StartJob(ctx context.Context, id int64, at time.Time) error {
+	if m.StartJobFunc != nil {
+		return m.StartJobFunc(ctx, id, at)
+	}
+	return nil
+}
+
+// UpdateJobProgress records a running job's progress.
+func (m *MockStorage) UpdateJobProgress(ctx context.Context, id int64, processed int) error {
+	if m.UpdateJobProgressFunc != nil {
+		return m.UpdateJobProgressFunc(ctx, id, processed)
+	}
+	return nil
+}
+
+// FinishJob records the outcome of a job.
+func (m *MockStorage) FinishJob(ctx context.Context, id int64, status string, processed int, result []byte, errMsg string, at time.Time) error {
+	if m.FinishJobFunc != nil {
+		return m.FinishJobFunc(ctx, id, status, processed, result, errMsg, at)
+	}
+	return nil
+}
+
+// FailUnfinishedJobs marks unfinished jobs created before the cutoff as failed.
+func (m *MockStorage) FailUnfinishedJobs(ctx context.Context, before time.Time, message string) (int64, error) {
+	if m.FailUnfinishedJobsFunc != nil {
+		return m.FailUnfinishedJobsFunc(ctx, before, message)
+	}
+	return 0, nil
+}
+
+// PruneJobs deletes jobs that finished before the cutoff.
+func (m *MockStorage) PruneJobs(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneJobsFunc != nil {
+		return m.PruneJobsFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// CreateAdminSession stores an admin session.
+func (m *MockStorage) CreateAdminSession(ctx context.Context, sess *storage.AdminSession, sessionHash string) (*storage.AdminSession, error) {
+	if m.CreateAdminSessionFunc != nil {
+		return m.CreateAdminSessionFunc(ctx, sess, sessionHash)
+	}
+	return sess, nil
+}
+
+// GetAdminSessionByHash returns the admin session with the given credential hash.
+func (m *MockStorage) GetAdminSessionByHash(ctx context.Context, sessionHash string) (*storage.AdminSession, error) {
+	if m.GetAdminSessionByHashFunc != nil {
+		return m.GetAdminSessionByHashFunc(ctx, sessionHash)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// DeleteAdminSession removes an admin session.
+func (m *MockStorage) DeleteAdminSession(ctx context.Context, id int64) error {
+	if m.DeleteAdminSessionFunc != nil {
+		return m.DeleteAdminSessionFunc(ctx, id)
+	}
+	return nil
+}
+
+// PruneAdminSessions deletes expired admin sessions.
+func (m *MockStorage) PruneAdminSessions(ctx context.Context, before time.Time) (int64, error) {
+	if m.PruneAdminSessionsFunc != nil {
+		return m.PruneAdminSessionsFunc(ctx, before)
+	}
+	return 0, nil
+}
+
+// SetTokenClientCert maps a client certificate common name to a token.
+func (m *MockStorage) SetTokenClientCert(ctx context.Context, tokenID int64, commonName string) error {
+	if m.SetTokenClientCertFunc != nil {
+		return m.SetTokenClientCertFunc(ctx, tokenID, commonName)
+	}
+	return nil
+}
+
+// GetTokenClientCert returns the common name a token is mapped to.
+func (m *MockStorage) GetTokenClientCert(ctx context.Context, tokenID int64) (string, error) {
+	if m.GetTokenClientCertFunc != nil {
+		return m.GetTokenClientCertFunc(ctx, tokenID)
+	}
+	return "", storage.ErrNotFound
+}
+
+// GetTokenByClientCert returns the token a common name is mapped to.
+func (m *MockStorage) GetTokenByClientCert(ctx context.Context, commonName string) (*storage.Token, error) {
+	if m.GetTokenByClientCertFunc != nil {
+		return m.GetTokenByClientCertFunc(ctx, commonName)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// DeleteTokenClientCert removes a token's client certificate mapping.
+func (m *MockStorage) DeleteTokenClientCert(ctx context.Context, tokenID int64) error {
+	if m.DeleteTokenClientCertFunc != nil {
+		return m.DeleteTokenClientCertFunc(ctx, tokenID)
+	}
+	return nil
+}
+
+// CompleteBootstrap records that bootstrap completed.
+func (m *MockStorage) CompleteBootstrap(ctx context.Context, at time.Time) error {
+	if m.CompleteBootstrapFunc != nil {
+		return m.CompleteBootstrapFunc(ctx, at)
+	}
+	return nil
+}
+
+// GetBootstrapCompletedAt returns when bootstrap completed.
+func (m *MockStorage) GetBootstrapCompletedAt(ctx context.Context) (time.Time, error) {
+	if m.GetBootstrapCompletedAtFunc != nil {
+		return m.GetBootstrapCompletedAtFunc(ctx)
+	}
+	return time.Time{}, storage.ErrNotFound
+}
+
+// CreateRole creates a role with its permissions.
+func (m *MockStorage) CreateRole(ctx context.Context, role *storage.Role) (*storage.Role, error) {
+	if m.CreateRoleFunc != nil {
+		return m.CreateRoleFunc(ctx, role)
+	}
+	return role, nil
+}
+
+// GetRole returns a role with its permissions.
+func (m *MockStorage) GetRole(ctx context.Context, id int64) (*storage.Role, error) {
+	if m.GetRoleFunc != nil {
+		return m.GetRoleFunc(ctx, id)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// ListRoles returns all roles.
+func (m *MockStorage) ListRoles(ctx context.Context) ([]*storage.Role, error) {
+	if m.ListRolesFunc != nil {
+		return m.ListRolesFunc(ctx)
+	}
+	return []*storage.Role{}, nil
+}
+
+// ListTokenRoles returns the roles a token holds.
+func (m *MockStorage) ListTokenRoles(ctx context.Context, tokenID int64) ([]*storage.Role, error) {
+	if m.ListTokenRolesFunc != nil {
+		return m.ListTokenRolesFunc(ctx, tokenID)
+	}
+	return []*storage.Role{}, nil
+}
+
+// DeleteRole deletes a role.
+func (m *MockStorage) DeleteRole(ctx context.Context, id int64) error {
+	if m.DeleteRoleFunc != nil {
+		return m.DeleteRoleFunc(ctx, id)
+	}
+	return nil
+}
+
+// AddRolePermission adds a permission to a role.
+func (m *MockStorage) AddRolePermission(ctx context.Context, roleID int64, perm *storage.Permission) (*storage.Permission, error) {
+	if m.AddRolePermissionFunc != nil {
+		return m.AddRolePermissionFunc(ctx, roleID, perm)
+	}
+	return perm, nil
+}
+
+// RemoveRolePermission removes a permission from a role.
+func (m *MockStorage) RemoveRolePermission(ctx context.Context, roleID, permID int64) error {
+	if m.RemoveRolePermissionFunc != nil {
+		return m.RemoveRolePermissionFunc(ctx, roleID, permID)
+	}
+	return nil
+}
+
+// AssignRole gives a token a role.
+func (m *MockStorage) AssignRole(ctx context.Context, tokenID, roleID int64) error {
+	if m.AssignRoleFunc != nil {
+		return m.AssignRoleFunc(ctx, tokenID, roleID)
+	}
+	return nil
+}
+
+// UnassignRole takes a role away from a token.
+func (m *MockStorage) UnassignRole(ctx context.Context, tokenID, roleID int64) error {
+	if m.UnassignRoleFunc != nil {
+		return m.UnassignRoleFunc(ctx, tokenID, roleID)
+	}
+	return nil
+}
+
+// CreateInvitation stores an invitation.
+func (m *MockStorage) CreateInvitation(ctx context.Context, inv *storage.Invitation, inviteHash string) (*storage.Invitation, error) {
+	if m.CreateInvitationFunc != nil {
+		return m.CreateInvitationFunc(ctx, inv, inviteHash)
+	}
+	return inv, nil
+}
+
+// GetInvitationByHash returns the invitation with the given code hash.
+func (m *MockStorage) GetInvitationByHash(ctx context.Context, inviteHash string) (*storage.Invitation, error) {
+	if m.GetInvitationByHashFunc != nil {
+		return m.GetInvitationByHashFunc(ctx, inviteHash)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// ListInvitations returns all invitations.
+func (m *MockStorage) ListInvitations(ctx context.Context) ([]*storage.Invitation, error) {
+	if m.ListInvitationsFunc != nil {
+		return m.ListInvitationsFunc(ctx)
+	}
+	return []*storage.Invitation{}, nil
+}
+
+// DeleteInvitation revokes an invitation.
+func (m *MockStorage) DeleteInvitation(ctx context.Context, id int64) error {
+	if m.DeleteInvitationFunc != nil {
+		return m.DeleteInvitationFunc(ctx, id)
+	}
+	return nil
+}
+
+// ClaimInvitation records that an invitation minted a token.
+func (m *MockStorage) ClaimInvitation(ctx context.Context, id, tokenID int64, now time.Time) error {
+	if m.ClaimInvitationFunc != nil {
+		return m.ClaimInvitationFunc(ctx, id, tokenID, now)
+	}
+	return nil
+}
+
+// MarkTokenProvisioned records that a token is managed by the config file.
+func (m *MockStorage) MarkTokenProvisioned(ctx context.Context, tokenID int64) error {
+	if m.MarkTokenProvisionedFunc != nil {
+		return m.MarkTokenProvisionedFunc(ctx, tokenID)
+	}
+	return nil
+}
+
+// ListProvisionedTokenIDs returns the IDs of provisioned tokens.
+func (m *MockStorage) ListProvisionedTokenIDs(ctx context.Context) ([]int64, error) {
+	if m.ListProvisionedTokenIDsFunc != nil {
+		return m.ListProvisionedTokenIDsFunc(ctx)
+	}
+	return []int64{}, nil
+}
+
 // Ping verifies database connectivity with a lightweight query.
 func (m *MockStorage) Ping(ctx context.Context) error {
 	if m.PingFunc != nil {
@@ -2,7 +2,9 @@ package mockstore
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/sipico/bunny-api-proxy/internal/storage"
 )
@@ -43,6 +45,12 @@ func TestMockStorage_DefaultBehavior(t *testing.T) {
 		t.Errorf("GetTokenByHash default should return ErrNotFound, got %v", err)
 	}
 
+	// Test GetTokenByKey default
+	_, err = mock.GetTokenByKey(ctx, 1, "hash")
+	if err != storage.ErrNotFound {
+		t.Errorf("GetTokenByKey default should return ErrNotFound, got %v", err)
+	}
+
 	// Test ListTokens default
 	tokens, err := mock.ListTokens(ctx)
 	if err != nil {
@@ -115,6 +123,29 @@ func TestMockStorage_UnifiedTokenMethods(t *testing.T) {
 		t.Errorf("DeleteToken default should not error, got %v", err)
 	}
 
+	// Test SetTokenExpiry default
+	if err := mock.SetTokenExpiry(ctx, 123, time.Now()); err != nil {
+		t.Errorf("SetTokenExpiry default should not error, got %v", err)
+	}
+
+	// Test SetTokenAccount default
+	if err := mock.SetTokenAccount(ctx, 123, "acme"); err != nil {
+		t.Errorf("SetTokenAccount default should not error, got %v", err)
+	}
+
+	// Test SetTokenPinning and PinTokenFingerprint defaults
+	if err := mock.SetTokenPinning(ctx, 123, true); err != nil {
+		t.Errorf("SetTokenPinning default should not error, got %v", err)
+	}
+	if pinned, err := mock.PinTokenFingerprint(ctx, 123, "fp"); err != nil || pinned != "fp" {
+		t.Errorf("PinTokenFingerprint default = %q, %v; want fp, nil", pinned, err)
+	}
+
+	// Test RecordTokenUsage default
+	if err := mock.RecordTokenUsage(ctx, []storage.TokenUsage{{TokenID: 123, Count: 1}}); err != nil {
+		t.Errorf("RecordTokenUsage default should not error, got %v", err)
+	}
+
 	// Test CountAdminTokens default
 	count, err := mock.CountAdminTokens(ctx)
 	if err != nil {
@@ -167,6 +198,178 @@ func TestMockStorage_PermissionMethods(t *testing.T) {
 	}
 }
 
+// TestMockStorage_WebhookMethods verifies webhook methods work correctly.
+func TestMockStorage_WebhookMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	hook, err := mock.CreateWebhook(ctx, &storage.Webhook{URL: "https://example.com"})
+	if err != nil || hook.ID != 1 {
+		t.Errorf("CreateWebhook default should set ID, got %+v, %v", hook, err)
+	}
+
+	if _, err := mock.GetWebhook(ctx, 1); err != storage.ErrNotFound {
+		t.Errorf("GetWebhook default should return ErrNotFound, got %v", err)
+	}
+
+	hooks, err := mock.ListWebhooks(ctx)
+	if err != nil || hooks == nil || len(hooks) != 0 {
+		t.Errorf("ListWebhooks default should return empty slice, got %v, %v", hooks, err)
+	}
+
+	if n, err := mock.CountWebhooksByToken(ctx, 1); n != 0 || err != nil {
+		t.Errorf("CountWebhooksByToken default should return 0, got %d, %v", n, err)
+	}
+
+	if err := mock.DeleteWebhook(ctx, 1); err != nil {
+		t.Errorf("DeleteWebhook default should not error, got %v", err)
+	}
+
+	d, err := mock.RecordWebhookDelivery(ctx, &storage.WebhookDelivery{WebhookID: 1})
+	if err != nil || d.ID != 1 {
+		t.Errorf("RecordWebhookDelivery default should set ID, got %+v, %v", d, err)
+	}
+
+	deliveries, err := mock.ListWebhookDeliveries(ctx, 1, 10)
+	if err != nil || deliveries == nil || len(deliveries) != 0 {
+		t.Errorf("ListWebhookDeliveries default should return empty slice, got %v, %v", deliveries, err)
+	}
+}
+
+// TestMockStorage_OutboxMethods verifies outbox methods work correctly.
+func TestMockStorage_OutboxMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	e, err := mock.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{EventID: "e", Event: "x"})
+	if err != nil || e.ID != 1 || e.Status != storage.OutboxStatusPending {
+		t.Errorf("EnqueueOutboxEvent default should set ID and status, got %+v, %v", e, err)
+	}
+
+	due, err := mock.ListDueOutboxEvents(ctx, time.Now(), 10)
+	if err != nil || due == nil || len(due) != 0 {
+		t.Errorf("ListDueOutboxEvents default should return empty slice, got %v, %v", due, err)
+	}
+
+	if err := mock.MarkOutboxEventDelivered(ctx, 1, time.Now()); err != nil {
+		t.Errorf("MarkOutboxEventDelivered default should not error, got %v", err)
+	}
+	if err := mock.MarkOutboxEventFailed(ctx, 1, "x", time.Now(), false); err != nil {
+		t.Errorf("MarkOutboxEventFailed default should not error, got %v", err)
+	}
+	if n, err := mock.PruneOutboxEvents(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("PruneOutboxEvents default should return 0, got %d, %v", n, err)
+	}
+}
+
+// TestMockStorage_RecordMetaMethods verifies record metadata methods work correctly.
+func TestMockStorage_RecordMetaMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	if _, err := mock.GetRecordMeta(ctx, 1, 2); err != storage.ErrNotFound {
+		t.Errorf("GetRecordMeta default should return ErrNotFound, got %v", err)
+	}
+	list, err := mock.ListRecordMeta(ctx, 1)
+	if err != nil || list == nil || len(list) != 0 {
+		t.Errorf("ListRecordMeta default should return empty slice, got %v, %v", list, err)
+	}
+	in := &storage.RecordMeta{ZoneID: 1, RecordID: 2, Meta: map[string]string{"owner": "x"}}
+	if got, err := mock.SetRecordMeta(ctx, in); err != nil || got != in {
+		t.Errorf("SetRecordMeta default should return input, got %+v, %v", got, err)
+	}
+	if err := mock.DeleteRecordMeta(ctx, 1, 2); err != nil {
+		t.Errorf("DeleteRecordMeta default should not error, got %v", err)
+	}
+	if err := mock.DeleteZoneRecordMeta(ctx, 1); err != nil {
+		t.Errorf("DeleteZoneRecordMeta default should not error, got %v", err)
+	}
+}
+
+func TestMockStorage_AuditMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	in := &storage.AuditEntry{Method: "GET", Path: "/dnszone"}
+	if got, err := mock.AppendAuditEntry(ctx, in); err != nil || got != in {
+		t.Errorf("AppendAuditEntry default should return input, got %+v, %v", got, err)
+	}
+	if got, err := mock.AppendAuditEntryWithEvents(ctx, in, nil); err != nil || got != in {
+		t.Errorf("AppendAuditEntryWithEvents default should return input, got %+v, %v", got, err)
+	}
+	list, err := mock.ListAuditEntries(ctx, storage.AuditFilter{TokenID: 1})
+	if err != nil || list == nil || len(list) != 0 {
+		t.Errorf("ListAuditEntries default should return empty slice, got %v, %v", list, err)
+	}
+	if n, err := mock.PruneAuditEntries(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("PruneAuditEntries default should return 0, got %d, %v", n, err)
+	}
+	if zones, err := mock.ListHotZones(ctx, time.Now(), 10); err != nil || zones == nil || len(zones) != 0 {
+		t.Errorf("ListHotZones default should return empty slice, got %v, %v", zones, err)
+	}
+}
+
+func TestMockStorage_MaintenanceMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	if stats, err := mock.DBStats(ctx); err != nil || stats == nil {
+		t.Errorf("DBStats default should return empty stats, got %v, %v", stats, err)
+	}
+	if err := mock.IncrementalVacuum(ctx, 10); err != nil {
+		t.Errorf("IncrementalVacuum default should not error, got %v", err)
+	}
+	if n := mock.OpenConnections(); n != 0 {
+		t.Errorf("OpenConnections default should return 0, got %d", n)
+	}
+}
+
+func TestMockStorage_InstanceMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	if _, err := mock.GetInstanceByHostname(ctx, "host"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("GetInstanceByHostname default should return ErrNotFound, got %v", err)
+	}
+	if err := mock.UpsertInstance(ctx, &storage.Instance{ID: "a"}); err != nil {
+		t.Errorf("UpsertInstance default should not error, got %v", err)
+	}
+	if err := mock.TouchInstance(ctx, "a", time.Now()); err != nil {
+		t.Errorf("TouchInstance default should not error, got %v", err)
+	}
+	if list, err := mock.ListInstances(ctx); err != nil || list == nil || len(list) != 0 {
+		t.Errorf("ListInstances default should return empty slice, got %v, %v", list, err)
+	}
+	if n, err := mock.PruneInstances(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("PruneInstances default should return 0, got %d, %v", n, err)
+	}
+}
+
+func TestMockStorage_BlocklistMethods(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	mock := &MockStorage{}
+
+	if e, err := mock.AddBlocklistEntry(ctx, &storage.BlocklistEntry{CIDR: "192.0.2.1/32"}); err != nil || e.CIDR != "192.0.2.1/32" {
+		t.Errorf("AddBlocklistEntry default should return the entry, got %v, %v", e, err)
+	}
+	if list, err := mock.ListBlocklistEntries(ctx); err != nil || list == nil || len(list) != 0 {
+		t.Errorf("ListBlocklistEntries default should return empty slice, got %v, %v", list, err)
+	}
+	if err := mock.DeleteBlocklistEntry(ctx, 1); err != nil {
+		t.Errorf("DeleteBlocklistEntry default should not error, got %v", err)
+	}
+	if n, err := mock.PruneBlocklistEntries(ctx, time.Now()); err != nil || n != 0 {
+		t.Errorf("PruneBlocklistEntries default should return 0, got %d, %v", n, err)
+	}
+}
+
 // TestMockStorage_LifecycleMethods verifies lifecycle methods work correctly.
 func TestMockStorage_LifecycleMethods(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,101 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testAudit(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AppendAndFilter": func(t *testing.T, s storage.Storage) {
+			entries, err := s.ListAuditEntries(ctx, storage.AuditFilter{})
+			must(t, "ListAuditEntries", err)
+			if entries == nil || len(entries) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", entries)
+			}
+
+			if _, err := s.AppendAuditEntry(ctx, &storage.AuditEntry{Path: "/x"}); err == nil {
+				t.Error("expected error for missing method")
+			}
+
+			base := now().Add(-time.Hour)
+			var ids []int64
+			for i, e := range []storage.AuditEntry{
+				{TokenID: 1, ZoneID: 10, Action: "list_records", Status: 200},
+				{TokenID: 2, ZoneID: 10, Action: "add_record", RecordType: "TXT", Status: 201},
+				{TokenID: 1, ZoneID: 20, Action: "delete_record", RecordType: "A", Status: 403},
+			} {
+				e.Method, e.Path, e.CreatedAt = "GET", "/dnszone", base.Add(time.Duration(i)*time.Minute)
+				appended, err := s.AppendAuditEntry(ctx, &e)
+				must(t, "AppendAuditEntry", err)
+				ids = append(ids, appended.ID)
+			}
+
+			tests := []struct {
+				name   string
+				filter storage.AuditFilter
+				want   []int64
+			}{
+				{"all newest first", storage.AuditFilter{}, []int64{ids[2], ids[1], ids[0]}},
+				{"token", storage.AuditFilter{TokenID: 1}, []int64{ids[2], ids[0]}},
+				{"zone", storage.AuditFilter{ZoneID: 10}, []int64{ids[1], ids[0]}},
+				{"action", storage.AuditFilter{Action: "add_record"}, []int64{ids[1]}},
+				{"record type", storage.AuditFilter{RecordType: "A"}, []int64{ids[2]}},
+				{"status range", storage.AuditFilter{MinStatus: 200, MaxStatus: 299}, []int64{ids[1], ids[0]}},
+				{"time range", storage.AuditFilter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, []int64{ids[1]}},
+				{"paging", storage.AuditFilter{BeforeID: ids[2], Limit: 1}, []int64{ids[1]}},
+			}
+			for _, tt := range tests {
+				entries, err := s.ListAuditEntries(ctx, tt.filter)
+				must(t, "ListAuditEntries "+tt.name, err)
+				if len(entries) != len(tt.want) {
+					t.Errorf("%s: got %d entries, want %d", tt.name, len(entries), len(tt.want))
+					continue
+				}
+				for i, e := range entries {
+					if e.ID != tt.want[i] {
+						t.Errorf("%s: entry %d has ID %d, want %d", tt.name, i, e.ID, tt.want[i])
+					}
+				}
+			}
+		},
+		"PruneAndHotZones": func(t *testing.T, s storage.Storage) {
+			old := now().Add(-48 * time.Hour)
+			recent := now().Add(-time.Minute)
+			for _, e := range []storage.AuditEntry{
+				{ZoneID: 10, CreatedAt: old},
+				{ZoneID: 10, CreatedAt: old},
+				{ZoneID: 20, CreatedAt: recent},
+				{ZoneID: 30, CreatedAt: recent},
+				{ZoneID: 30, CreatedAt: recent},
+				{ZoneID: 0, CreatedAt: recent},
+			} {
+				e.Method, e.Path = "GET", "/dnszone"
+				_, err := s.AppendAuditEntry(ctx, &e)
+				must(t, "AppendAuditEntry", err)
+			}
+
+			zones, err := s.ListHotZones(ctx, now().Add(-time.Hour), 10)
+			must(t, "ListHotZones", err)
+			if len(zones) != 2 || zones[0] != 30 || zones[1] != 20 {
+				t.Errorf("expected hot zones [30 20], got %v", zones)
+			}
+
+			n, err := s.PruneAuditEntries(ctx, now().Add(-24*time.Hour))
+			must(t, "PruneAuditEntries", err)
+			if n != 2 {
+				t.Errorf("PruneAuditEntries = %d, want 2", n)
+			}
+			entries, err := s.ListAuditEntries(ctx, storage.AuditFilter{})
+			must(t, "ListAuditEntries", err)
+			if len(entries) != 4 {
+				t.Errorf("expected 4 entries after prune, got %d", len(entries))
+			}
+		},
+	})
+}
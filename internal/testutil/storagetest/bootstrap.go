@@ -0,0 +1,31 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testBootstrap(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"NotCompleted": func(t *testing.T, s storage.Storage) {
+			_, err := s.GetBootstrapCompletedAt(ctx)
+			wantErr(t, "GetBootstrapCompletedAt", err, storage.ErrNotFound)
+		},
+		"KeepsFirstCompletion": func(t *testing.T, s storage.Storage) {
+			first := now()
+			must(t, "CompleteBootstrap", s.CompleteBootstrap(ctx, first))
+			must(t, "CompleteBootstrap again", s.CompleteBootstrap(ctx, first.Add(time.Hour)))
+
+			got, err := s.GetBootstrapCompletedAt(ctx)
+			must(t, "GetBootstrapCompletedAt", err)
+			if !got.Equal(first) {
+				t.Errorf("GetBootstrapCompletedAt = %v, want %v", got, first)
+			}
+		},
+	})
+}
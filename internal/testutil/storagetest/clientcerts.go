@@ -0,0 +1,72 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testClientCerts(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"SetAndLookup": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "billing")
+			must(t, "SetTokenClientCert", s.SetTokenClientCert(ctx, token.ID, "billing.internal"))
+
+			name, err := s.GetTokenClientCert(ctx, token.ID)
+			must(t, "GetTokenClientCert", err)
+			if name != "billing.internal" {
+				t.Errorf("GetTokenClientCert = %q, want billing.internal", name)
+			}
+			got, err := s.GetTokenByClientCert(ctx, "billing.internal")
+			must(t, "GetTokenByClientCert", err)
+			if got.ID != token.ID || got.Name != "billing" {
+				t.Errorf("GetTokenByClientCert = %+v, want token %d", got, token.ID)
+			}
+
+			// Setting a new name replaces the old one
+			must(t, "SetTokenClientCert replace", s.SetTokenClientCert(ctx, token.ID, "billing-v2.internal"))
+			_, err = s.GetTokenByClientCert(ctx, "billing.internal")
+			wantErr(t, "GetTokenByClientCert old name", err, storage.ErrNotFound)
+		},
+		"Errors": func(t *testing.T, s storage.Storage) {
+			a := createToken(t, s, "a")
+			b := createToken(t, s, "b")
+			must(t, "SetTokenClientCert", s.SetTokenClientCert(ctx, a.ID, "shared"))
+
+			if err := s.SetTokenClientCert(ctx, a.ID, ""); err == nil {
+				t.Error("expected error for empty common name")
+			}
+			wantErr(t, "SetTokenClientCert taken", s.SetTokenClientCert(ctx, b.ID, "shared"), storage.ErrDuplicate)
+			wantErr(t, "SetTokenClientCert missing token", s.SetTokenClientCert(ctx, 999, "other"), storage.ErrNotFound)
+
+			_, err := s.GetTokenClientCert(ctx, b.ID)
+			wantErr(t, "GetTokenClientCert unmapped", err, storage.ErrNotFound)
+			_, err = s.GetTokenByClientCert(ctx, "unknown")
+			wantErr(t, "GetTokenByClientCert unknown", err, storage.ErrNotFound)
+		},
+		"Delete": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			must(t, "SetTokenClientCert", s.SetTokenClientCert(ctx, token.ID, "ci.internal"))
+
+			must(t, "DeleteTokenClientCert", s.DeleteTokenClientCert(ctx, token.ID))
+			wantErr(t, "DeleteTokenClientCert again", s.DeleteTokenClientCert(ctx, token.ID), storage.ErrNotFound)
+			_, err := s.GetTokenByClientCert(ctx, "ci.internal")
+			wantErr(t, "GetTokenByClientCert deleted", err, storage.ErrNotFound)
+		},
+		"DeletedWithToken": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			must(t, "SetTokenClientCert", s.SetTokenClientCert(ctx, token.ID, "ci.internal"))
+
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+			_, err := s.GetTokenByClientCert(ctx, "ci.internal")
+			wantErr(t, "GetTokenByClientCert after token delete", err, storage.ErrNotFound)
+
+			// The name is free for another token
+			other := createToken(t, s, "other")
+			must(t, "SetTokenClientCert reuse", s.SetTokenClientCert(ctx, other.ID, "ci.internal"))
+		},
+	})
+}
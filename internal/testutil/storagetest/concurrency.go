@@ -0,0 +1,163 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// workers is the number of goroutines each concurrency test starts.
+const workers = 16
+
+// parallel runs fn once per worker concurrently and collects the errors.
+func parallel(fn func(i int) error) []error {
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+func testConcurrency(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"DistinctCreates": func(t *testing.T, s storage.Storage) {
+			errs := parallel(func(i int) error {
+				token, err := s.CreateToken(ctx, fmt.Sprintf("t%d", i), false, fmt.Sprintf("hash-%d", i))
+				if err != nil {
+					return err
+				}
+				_, err = s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+					ZoneID: int64(i + 1), AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+				})
+				return err
+			})
+			for i, err := range errs {
+				must(t, fmt.Sprintf("worker %d", i), err)
+			}
+
+			tokens, err := s.ListTokens(ctx)
+			must(t, "ListTokens", err)
+			if len(tokens) != workers {
+				t.Fatalf("expected %d tokens, got %d", workers, len(tokens))
+			}
+			seen := make(map[int64]bool)
+			for _, token := range tokens {
+				if seen[token.ID] {
+					t.Errorf("token ID %d assigned twice", token.ID)
+				}
+				seen[token.ID] = true
+				perms, err := s.GetPermissionsForToken(ctx, token.ID)
+				must(t, "GetPermissionsForToken", err)
+				if len(perms) != 1 {
+					t.Errorf("token %d has %d permissions, want 1", token.ID, len(perms))
+				}
+			}
+		},
+		"DuplicateCreateHasOneWinner": func(t *testing.T, s storage.Storage) {
+			errs := parallel(func(i int) error {
+				_, err := s.CreateToken(ctx, fmt.Sprintf("t%d", i), false, "same-hash")
+				return err
+			})
+			wins := 0
+			for _, err := range errs {
+				switch {
+				case err == nil:
+					wins++
+				case !errors.Is(err, storage.ErrDuplicate):
+					t.Errorf("losers should get ErrDuplicate, got %v", err)
+				}
+			}
+			if wins != 1 {
+				t.Errorf("expected exactly one successful create, got %d", wins)
+			}
+		},
+		"FirstFingerprintWins": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			must(t, "SetTokenPinning", s.SetTokenPinning(ctx, token.ID, true))
+
+			var mu sync.Mutex
+			pinned := make(map[string]int)
+			errs := parallel(func(i int) error {
+				fp, err := s.PinTokenFingerprint(ctx, token.ID, fmt.Sprintf("fp-%d", i))
+				mu.Lock()
+				pinned[fp]++
+				mu.Unlock()
+				return err
+			})
+			for i, err := range errs {
+				must(t, fmt.Sprintf("worker %d", i), err)
+			}
+			if len(pinned) != 1 {
+				t.Errorf("concurrent first uses should agree on one fingerprint, got %v", pinned)
+			}
+		},
+		"OutboxAttemptsAreNotLost": func(t *testing.T, s storage.Storage) {
+			e, err := s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{EventID: "e1", Event: "record.created"})
+			must(t, "EnqueueOutboxEvent", err)
+
+			errs := parallel(func(i int) error {
+				return s.MarkOutboxEventFailed(ctx, e.ID, fmt.Sprintf("attempt %d", i), time.Unix(0, 0), false)
+			})
+			for i, err := range errs {
+				must(t, fmt.Sprintf("worker %d", i), err)
+			}
+
+			due, err := s.ListDueOutboxEvents(ctx, time.Now(), 1)
+			must(t, "ListDueOutboxEvents", err)
+			if len(due) != 1 || due[0].Attempts != workers {
+				t.Errorf("expected %d recorded attempts, got %+v", workers, due)
+			}
+		},
+		"UsageCountsAreNotLost": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			errs := parallel(func(int) error {
+				return s.RecordTokenUsage(ctx, []storage.TokenUsage{{TokenID: token.ID, Count: 1, LastUsedAt: now()}})
+			})
+			for i, err := range errs {
+				must(t, fmt.Sprintf("worker %d", i), err)
+			}
+
+			got, err := s.GetTokenByID(ctx, token.ID)
+			must(t, "GetTokenByID", err)
+			if got.UseCount != workers {
+				t.Errorf("expected %d uses, got %d", workers, got.UseCount)
+			}
+		},
+		"ReadsDuringWrites": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			errs := parallel(func(i int) error {
+				if i%2 == 0 {
+					_, err := s.AppendAuditEntry(ctx, &storage.AuditEntry{TokenID: token.ID, Method: "GET", Path: "/dnszone"})
+					return err
+				}
+				if _, err := s.GetTokenByHash(ctx, token.KeyHash); err != nil {
+					return err
+				}
+				_, err := s.ListAuditEntries(ctx, storage.AuditFilter{TokenID: token.ID})
+				return err
+			})
+			for i, err := range errs {
+				must(t, fmt.Sprintf("worker %d", i), err)
+			}
+
+			entries, err := s.ListAuditEntries(ctx, storage.AuditFilter{TokenID: token.ID})
+			must(t, "ListAuditEntries", err)
+			if len(entries) != workers/2 {
+				t.Errorf("expected %d audit entries, got %d", workers/2, len(entries))
+			}
+		},
+	})
+}
@@ -0,0 +1,59 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testDomainPermissions(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AddListDelete": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			other := createToken(t, s, "other")
+
+			if _, err := s.AddDomainPermission(ctx, &storage.DomainPermission{TokenID: token.ID, AllowedActions: []string{"list_records"}, RecordTypes: []string{"A"}}); err == nil {
+				t.Error("expected error for missing pattern")
+			}
+			p, err := s.AddDomainPermission(ctx, &storage.DomainPermission{
+				TokenID: token.ID, Pattern: "*.customer1.example.com", AllowedActions: []string{"list_records"}, RecordTypes: []string{"A", "TXT"},
+			})
+			must(t, "AddDomainPermission", err)
+			if p.ID <= 0 || p.CreatedAt.IsZero() {
+				t.Errorf("unexpected domain permission: %+v", p)
+			}
+
+			perms, err := s.ListDomainPermissions(ctx, token.ID)
+			must(t, "ListDomainPermissions", err)
+			if len(perms) != 1 || perms[0].Pattern != "*.customer1.example.com" || len(perms[0].RecordTypes) != 2 {
+				t.Fatalf("unexpected domain permissions: %+v", perms)
+			}
+			perms, err = s.ListDomainPermissions(ctx, other.ID)
+			must(t, "ListDomainPermissions", err)
+			if perms == nil || len(perms) != 0 {
+				t.Errorf("expected empty non-nil slice for another token, got %#v", perms)
+			}
+
+			wantErr(t, "DeleteDomainPermission", s.DeleteDomainPermission(ctx, other.ID, p.ID), storage.ErrNotFound)
+			must(t, "DeleteDomainPermission", s.DeleteDomainPermission(ctx, token.ID, p.ID))
+			wantErr(t, "DeleteDomainPermission", s.DeleteDomainPermission(ctx, token.ID, p.ID), storage.ErrNotFound)
+		},
+		"DeletedWithToken": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			_, err := s.AddDomainPermission(ctx, &storage.DomainPermission{
+				TokenID: token.ID, Pattern: "example.com", AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+			})
+			must(t, "AddDomainPermission", err)
+
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+			perms, err := s.ListDomainPermissions(ctx, token.ID)
+			must(t, "ListDomainPermissions", err)
+			if len(perms) != 0 {
+				t.Errorf("expected domain permissions to be deleted with the token, got %+v", perms)
+			}
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testIdempotencyKeys(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"ClaimAndComplete": func(t *testing.T, s storage.Storage) {
+			at := now()
+			if err := s.ClaimIdempotencyKey(ctx, 1, "", "fp", at); err == nil {
+				t.Error("expected error for empty key")
+			}
+
+			must(t, "ClaimIdempotencyKey", s.ClaimIdempotencyKey(ctx, 1, "abc", "fp", at))
+			wantErr(t, "ClaimIdempotencyKey duplicate", s.ClaimIdempotencyKey(ctx, 1, "abc", "other", at), storage.ErrDuplicate)
+
+			// Keys are scoped to the token, so another token may reuse one
+			must(t, "ClaimIdempotencyKey other token", s.ClaimIdempotencyKey(ctx, 2, "abc", "fp", at))
+			must(t, "ClaimIdempotencyKey master key", s.ClaimIdempotencyKey(ctx, 0, "abc", "fp", at))
+
+			got, err := s.GetIdempotentResponse(ctx, 1, "abc")
+			must(t, "GetIdempotentResponse", err)
+			if got.Fingerprint != "fp" || got.Status != 0 || !got.CreatedAt.Equal(at) {
+				t.Errorf("in-progress key = %+v, want fingerprint fp, status 0", got)
+			}
+
+			must(t, "CompleteIdempotencyKey", s.CompleteIdempotencyKey(ctx, 1, "abc", 201, "application/json", []byte(`{"Id":7}`)))
+			got, err = s.GetIdempotentResponse(ctx, 1, "abc")
+			must(t, "GetIdempotentResponse", err)
+			if got.Status != 201 || got.ContentType != "application/json" || string(got.Body) != `{"Id":7}` {
+				t.Errorf("completed key = %+v", got)
+			}
+
+			must(t, "CompleteIdempotencyKey empty body", s.CompleteIdempotencyKey(ctx, 2, "abc", 204, "", nil))
+			if got, err := s.GetIdempotentResponse(ctx, 2, "abc"); err != nil || got.Status != 204 || len(got.Body) != 0 {
+				t.Errorf("GetIdempotentResponse = %+v, %v; want 204 without a body", got, err)
+			}
+
+			_, err = s.GetIdempotentResponse(ctx, 1, "missing")
+			wantErr(t, "GetIdempotentResponse missing", err, storage.ErrNotFound)
+			wantErr(t, "CompleteIdempotencyKey missing", s.CompleteIdempotencyKey(ctx, 1, "missing", 200, "", nil), storage.ErrNotFound)
+		},
+		"Release": func(t *testing.T, s storage.Storage) {
+			must(t, "ClaimIdempotencyKey", s.ClaimIdempotencyKey(ctx, 1, "abc", "fp", now()))
+			must(t, "ReleaseIdempotencyKey", s.ReleaseIdempotencyKey(ctx, 1, "abc"))
+			must(t, "ReleaseIdempotencyKey missing", s.ReleaseIdempotencyKey(ctx, 1, "abc"))
+			must(t, "ClaimIdempotencyKey after release", s.ClaimIdempotencyKey(ctx, 1, "abc", "fp", now()))
+		},
+		"Prune": func(t *testing.T, s storage.Storage) {
+			must(t, "ClaimIdempotencyKey", s.ClaimIdempotencyKey(ctx, 1, "old", "fp", now().Add(-time.Hour)))
+			must(t, "ClaimIdempotencyKey", s.ClaimIdempotencyKey(ctx, 1, "new", "fp", now()))
+
+			n, err := s.PruneIdempotencyKeys(ctx, now().Add(-time.Minute))
+			must(t, "PruneIdempotencyKeys", err)
+			if n != 1 {
+				t.Errorf("PruneIdempotencyKeys = %d, want 1", n)
+			}
+
+			// A pruned key can be claimed again; a kept one cannot
+			must(t, "ClaimIdempotencyKey after prune", s.ClaimIdempotencyKey(ctx, 1, "old", "fp", now()))
+			wantErr(t, "ClaimIdempotencyKey kept", s.ClaimIdempotencyKey(ctx, 1, "new", "fp", now()), storage.ErrDuplicate)
+		},
+	})
+}
@@ -0,0 +1,160 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testMaintenance(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"StatsAndVacuum": func(t *testing.T, s storage.Storage) {
+			must(t, "Ping", s.Ping(ctx))
+
+			stats, err := s.DBStats(ctx)
+			must(t, "DBStats", err)
+			if stats == nil {
+				t.Fatal("DBStats returned nil stats")
+			}
+			if stats.PageSize < 0 || stats.PageCount < 0 || stats.FreelistCount < 0 || stats.FileSize < 0 {
+				t.Errorf("stats should not be negative: %+v", stats)
+			}
+
+			must(t, "IncrementalVacuum", s.IncrementalVacuum(ctx, 0))
+			must(t, "IncrementalVacuum", s.IncrementalVacuum(ctx, 10))
+			if n := s.OpenConnections(); n < 0 {
+				t.Errorf("OpenConnections = %d, want >= 0", n)
+			}
+		},
+	})
+}
+
+func testInstances(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"UpsertAndGet": func(t *testing.T, s storage.Storage) {
+			_, err := s.GetInstanceByHostname(ctx, "web-1")
+			wantErr(t, "GetInstanceByHostname", err, storage.ErrNotFound)
+			if err := s.UpsertInstance(ctx, &storage.Instance{Hostname: "web-1"}); err == nil {
+				t.Error("expected error for missing instance ID")
+			}
+
+			started := now().Add(-time.Hour)
+			inst := &storage.Instance{ID: "a", Hostname: "web-1", Version: "1.0.0", StartedAt: started, LastSeenAt: started}
+			must(t, "UpsertInstance", s.UpsertInstance(ctx, inst))
+
+			// Upserting the same ID replaces the row
+			inst.Version = "1.1.0"
+			inst.LastSeenAt = now()
+			must(t, "UpsertInstance", s.UpsertInstance(ctx, inst))
+
+			got, err := s.GetInstanceByHostname(ctx, "web-1")
+			must(t, "GetInstanceByHostname", err)
+			if got.ID != "a" || got.Version != "1.1.0" || !got.StartedAt.Equal(started) || !got.LastSeenAt.Equal(inst.LastSeenAt) {
+				t.Errorf("unexpected instance: %+v", got)
+			}
+
+			list, err := s.ListInstances(ctx)
+			must(t, "ListInstances", err)
+			if len(list) != 1 {
+				t.Errorf("upsert should not duplicate instances, got %d", len(list))
+			}
+		},
+		"TouchListPrune": func(t *testing.T, s storage.Storage) {
+			list, err := s.ListInstances(ctx)
+			must(t, "ListInstances", err)
+			if list == nil || len(list) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", list)
+			}
+			wantErr(t, "TouchInstance", s.TouchInstance(ctx, "missing", time.Now()), storage.ErrNotFound)
+
+			old := now().Add(-time.Hour)
+			for _, id := range []string{"a", "b"} {
+				must(t, "UpsertInstance", s.UpsertInstance(ctx, &storage.Instance{
+					ID: id, Hostname: "web-" + id, StartedAt: old, LastSeenAt: old,
+				}))
+			}
+			must(t, "TouchInstance", s.TouchInstance(ctx, "b", now()))
+
+			list, err = s.ListInstances(ctx)
+			must(t, "ListInstances", err)
+			if len(list) != 2 || list[0].ID != "b" || list[1].ID != "a" {
+				t.Fatalf("expected most recently seen first, got %+v", list)
+			}
+
+			n, err := s.PruneInstances(ctx, now().Add(-time.Minute))
+			must(t, "PruneInstances", err)
+			if n != 1 {
+				t.Errorf("PruneInstances = %d, want 1", n)
+			}
+			_, err = s.GetInstanceByHostname(ctx, "web-a")
+			wantErr(t, "GetInstanceByHostname after prune", err, storage.ErrNotFound)
+		},
+	})
+}
+
+func testBlocklist(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AddAndDelete": func(t *testing.T, s storage.Storage) {
+			entries, err := s.ListBlocklistEntries(ctx)
+			must(t, "ListBlocklistEntries", err)
+			if entries == nil || len(entries) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", entries)
+			}
+			if _, err := s.AddBlocklistEntry(ctx, &storage.BlocklistEntry{Reason: "x"}); err == nil {
+				t.Error("expected error for missing CIDR")
+			}
+
+			a, err := s.AddBlocklistEntry(ctx, &storage.BlocklistEntry{CIDR: "203.0.113.7/32", Reason: "abuse", CreatedBy: "admin"})
+			must(t, "AddBlocklistEntry", err)
+			if a.ID <= 0 || a.CreatedAt.IsZero() || !a.ExpiresAt.IsZero() {
+				t.Errorf("unexpected entry: %+v", a)
+			}
+			_, err = s.AddBlocklistEntry(ctx, &storage.BlocklistEntry{CIDR: "203.0.113.7/32"})
+			wantErr(t, "AddBlocklistEntry duplicate", err, storage.ErrDuplicate)
+
+			b, err := s.AddBlocklistEntry(ctx, &storage.BlocklistEntry{CIDR: "198.51.100.0/24"})
+			must(t, "AddBlocklistEntry", err)
+
+			entries, err = s.ListBlocklistEntries(ctx)
+			must(t, "ListBlocklistEntries", err)
+			if len(entries) != 2 || entries[0].ID != a.ID || entries[1].ID != b.ID {
+				t.Fatalf("expected entries in creation order, got %+v", entries)
+			}
+			if entries[0].Reason != "abuse" || entries[0].CreatedBy != "admin" {
+				t.Errorf("entry fields not preserved: %+v", entries[0])
+			}
+
+			must(t, "DeleteBlocklistEntry", s.DeleteBlocklistEntry(ctx, a.ID))
+			wantErr(t, "DeleteBlocklistEntry again", s.DeleteBlocklistEntry(ctx, a.ID), storage.ErrNotFound)
+		},
+		"PruneKeepsPermanent": func(t *testing.T, s storage.Storage) {
+			for _, e := range []*storage.BlocklistEntry{
+				{CIDR: "192.0.2.1/32"},
+				{CIDR: "192.0.2.2/32", ExpiresAt: now().Add(-time.Hour)},
+				{CIDR: "192.0.2.3/32", ExpiresAt: now().Add(time.Hour)},
+			} {
+				_, err := s.AddBlocklistEntry(ctx, e)
+				must(t, "AddBlocklistEntry", err)
+			}
+
+			n, err := s.PruneBlocklistEntries(ctx, now())
+			must(t, "PruneBlocklistEntries", err)
+			if n != 1 {
+				t.Errorf("PruneBlocklistEntries = %d, want 1", n)
+			}
+			entries, err := s.ListBlocklistEntries(ctx)
+			must(t, "ListBlocklistEntries", err)
+			if len(entries) != 2 || entries[0].CIDR != "192.0.2.1/32" || entries[1].CIDR != "192.0.2.3/32" {
+				t.Errorf("expected the permanent and unexpired entries to remain, got %+v", entries)
+			}
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testInvitations(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	invite := func(expiresAt time.Time) *storage.Invitation {
+		return &storage.Invitation{
+			TokenName: "contractor",
+			RoleIDs:   []int64{3, 1},
+			TokenTTL:  24 * time.Hour,
+			CreatedBy: "admin",
+			CreatedAt: now(),
+			ExpiresAt: expiresAt,
+		}
+	}
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"CreateAndGet": func(t *testing.T, s storage.Storage) {
+			expiresAt := now().Add(time.Hour)
+			created, err := s.CreateInvitation(ctx, invite(expiresAt), "hash-1")
+			must(t, "CreateInvitation", err)
+			if created.ID == 0 {
+				t.Fatal("expected invitation ID")
+			}
+
+			got, err := s.GetInvitationByHash(ctx, "hash-1")
+			must(t, "GetInvitationByHash", err)
+			if got.ID != created.ID || got.TokenName != "contractor" || got.CreatedBy != "admin" || got.TokenTTL != 24*time.Hour {
+				t.Errorf("GetInvitationByHash = %+v", got)
+			}
+			if len(got.RoleIDs) != 2 || got.RoleIDs[0] != 3 || got.RoleIDs[1] != 1 {
+				t.Errorf("RoleIDs = %v, want [3 1]", got.RoleIDs)
+			}
+			if !got.ExpiresAt.Equal(expiresAt) || !got.ClaimedAt.IsZero() || got.TokenID != 0 {
+				t.Errorf("unexpected times or token: %+v", got)
+			}
+
+			_, err = s.CreateInvitation(ctx, invite(expiresAt), "hash-1")
+			wantErr(t, "CreateInvitation duplicate", err, storage.ErrDuplicate)
+			_, err = s.GetInvitationByHash(ctx, "missing")
+			wantErr(t, "GetInvitationByHash missing", err, storage.ErrNotFound)
+		},
+		"ClaimOnce": func(t *testing.T, s storage.Storage) {
+			inv, err := s.CreateInvitation(ctx, invite(now().Add(time.Hour)), "hash-1")
+			must(t, "CreateInvitation", err)
+			token := createToken(t, s, "contractor")
+
+			must(t, "ClaimInvitation", s.ClaimInvitation(ctx, inv.ID, token.ID, now()))
+			wantErr(t, "ClaimInvitation again", s.ClaimInvitation(ctx, inv.ID, token.ID, now()), storage.ErrNotFound)
+
+			got, err := s.GetInvitationByHash(ctx, "hash-1")
+			must(t, "GetInvitationByHash", err)
+			if got.ClaimedAt.IsZero() || got.TokenID != token.ID {
+				t.Errorf("claimed invitation = %+v", got)
+			}
+
+			// Deleting the token keeps the invitation as a record of the claim
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+			got, err = s.GetInvitationByHash(ctx, "hash-1")
+			must(t, "GetInvitationByHash", err)
+			if got.ClaimedAt.IsZero() || got.TokenID != 0 {
+				t.Errorf("invitation after token deletion = %+v", got)
+			}
+		},
+		"ExpiredCannotBeClaimed": func(t *testing.T, s storage.Storage) {
+			inv, err := s.CreateInvitation(ctx, invite(now().Add(-time.Second)), "hash-1")
+			must(t, "CreateInvitation", err)
+			token := createToken(t, s, "contractor")
+			wantErr(t, "ClaimInvitation expired", s.ClaimInvitation(ctx, inv.ID, token.ID, now()), storage.ErrNotFound)
+		},
+		"ListAndDelete": func(t *testing.T, s storage.Storage) {
+			first, err := s.CreateInvitation(ctx, invite(now().Add(time.Hour)), "hash-1")
+			must(t, "CreateInvitation", err)
+			second, err := s.CreateInvitation(ctx, invite(now().Add(time.Hour)), "hash-2")
+			must(t, "CreateInvitation", err)
+
+			list, err := s.ListInvitations(ctx)
+			must(t, "ListInvitations", err)
+			if len(list) != 2 || list[0].ID != second.ID || list[1].ID != first.ID {
+				t.Errorf("ListInvitations = %+v, want newest first", list)
+			}
+
+			must(t, "DeleteInvitation", s.DeleteInvitation(ctx, first.ID))
+			wantErr(t, "DeleteInvitation again", s.DeleteInvitation(ctx, first.ID), storage.ErrNotFound)
+			_, err = s.GetInvitationByHash(ctx, "hash-1")
+			wantErr(t, "GetInvitationByHash deleted", err, storage.ErrNotFound)
+			wantErr(t, "ClaimInvitation deleted", s.ClaimInvitation(ctx, first.ID, 1, now()), storage.ErrNotFound)
+		},
+	})
+}
@@ -0,0 +1,114 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testJobs(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"Lifecycle": func(t *testing.T, s storage.Storage) {
+			at := now()
+			if _, err := s.CreateJob(ctx, &storage.Job{}); err == nil {
+				t.Error("expected error for a job without a kind")
+			}
+			_, err := s.GetJob(ctx, 1)
+			wantErr(t, "GetJob missing", err, storage.ErrNotFound)
+
+			job, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport, ZoneID: 7, TokenID: 3, Total: 100, CreatedAt: at})
+			must(t, "CreateJob", err)
+			if job.ID == 0 || job.Status != storage.JobStatusQueued || !job.CreatedAt.Equal(at) {
+				t.Fatalf("created job = %+v", job)
+			}
+
+			wantErr(t, "UpdateJobProgress queued", s.UpdateJobProgress(ctx, job.ID, 10), storage.ErrNotFound)
+			must(t, "StartJob", s.StartJob(ctx, job.ID, at))
+			wantErr(t, "StartJob running", s.StartJob(ctx, job.ID, at), storage.ErrNotFound)
+			must(t, "UpdateJobProgress", s.UpdateJobProgress(ctx, job.ID, 40))
+
+			got, err := s.GetJob(ctx, job.ID)
+			must(t, "GetJob", err)
+			if got.Status != storage.JobStatusRunning || got.Processed != 40 || got.ZoneID != 7 || got.TokenID != 3 ||
+				!got.StartedAt.Equal(at) || !got.FinishedAt.IsZero() || got.Result != nil {
+				t.Errorf("running job = %+v", got)
+			}
+
+			must(t, "FinishJob", s.FinishJob(ctx, job.ID, storage.JobStatusSucceeded, 100, []byte(`{"Created":100}`), "", at))
+			wantErr(t, "FinishJob finished", s.FinishJob(ctx, job.ID, storage.JobStatusFailed, 0, nil, "again", at), storage.ErrNotFound)
+			if err := s.FinishJob(ctx, job.ID, storage.JobStatusRunning, 0, nil, "", at); err == nil {
+				t.Error("expected error for an invalid final status")
+			}
+
+			got, err = s.GetJob(ctx, job.ID)
+			must(t, "GetJob", err)
+			if got.Status != storage.JobStatusSucceeded || got.Processed != 100 || string(got.Result) != `{"Created":100}` ||
+				!got.FinishedAt.Equal(at) {
+				t.Errorf("finished job = %+v", got)
+			}
+		},
+		"FailQueued": func(t *testing.T, s storage.Storage) {
+			job, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport})
+			must(t, "CreateJob", err)
+			must(t, "FinishJob", s.FinishJob(ctx, job.ID, storage.JobStatusFailed, 0, nil, "queue full", now()))
+			got, err := s.GetJob(ctx, job.ID)
+			must(t, "GetJob", err)
+			if got.Status != storage.JobStatusFailed || got.Error != "queue full" || !got.StartedAt.IsZero() {
+				t.Errorf("failed job = %+v", got)
+			}
+		},
+		"FailUnfinished": func(t *testing.T, s storage.Storage) {
+			at := now()
+			old, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport, CreatedAt: at.Add(-time.Minute)})
+			must(t, "CreateJob", err)
+			must(t, "StartJob", s.StartJob(ctx, old.ID, at))
+			done, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport, CreatedAt: at.Add(-time.Minute)})
+			must(t, "CreateJob", err)
+			must(t, "FinishJob", s.FinishJob(ctx, done.ID, storage.JobStatusSucceeded, 0, nil, "", at))
+			current, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport, CreatedAt: at})
+			must(t, "CreateJob", err)
+
+			n, err := s.FailUnfinishedJobs(ctx, at, "interrupted")
+			must(t, "FailUnfinishedJobs", err)
+			if n != 1 {
+				t.Errorf("FailUnfinishedJobs = %d, want 1", n)
+			}
+			if got, err := s.GetJob(ctx, old.ID); err != nil || got.Status != storage.JobStatusFailed || got.Error != "interrupted" {
+				t.Errorf("old job = %+v, %v; want failed", got, err)
+			}
+			if got, err := s.GetJob(ctx, done.ID); err != nil || got.Status != storage.JobStatusSucceeded {
+				t.Errorf("finished job = %+v, %v; want it unchanged", got, err)
+			}
+			if got, err := s.GetJob(ctx, current.ID); err != nil || got.Status != storage.JobStatusQueued {
+				t.Errorf("current job = %+v, %v; want it still queued", got, err)
+			}
+		},
+		"Prune": func(t *testing.T, s storage.Storage) {
+			old, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport})
+			must(t, "CreateJob", err)
+			must(t, "FinishJob", s.FinishJob(ctx, old.ID, storage.JobStatusSucceeded, 0, nil, "", now().Add(-time.Hour)))
+			recent, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport})
+			must(t, "CreateJob", err)
+			must(t, "FinishJob", s.FinishJob(ctx, recent.ID, storage.JobStatusSucceeded, 0, nil, "", now()))
+			queued, err := s.CreateJob(ctx, &storage.Job{Kind: storage.JobKindImport, CreatedAt: now().Add(-time.Hour)})
+			must(t, "CreateJob", err)
+
+			n, err := s.PruneJobs(ctx, now().Add(-time.Minute))
+			must(t, "PruneJobs", err)
+			if n != 1 {
+				t.Errorf("PruneJobs = %d, want 1", n)
+			}
+			_, err = s.GetJob(ctx, old.ID)
+			wantErr(t, "GetJob pruned", err, storage.ErrNotFound)
+			for _, id := range []int64{recent.ID, queued.ID} {
+				if _, err := s.GetJob(ctx, id); err != nil {
+					t.Errorf("GetJob(%d) after prune: %v", id, err)
+				}
+			}
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testNonces(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"RecordRejectsDuplicates": func(t *testing.T, s storage.Storage) {
+			if err := s.RecordNonce(ctx, 1, "", now()); err == nil {
+				t.Error("expected error for empty nonce")
+			}
+
+			must(t, "RecordNonce", s.RecordNonce(ctx, 1, "abc", now()))
+			wantErr(t, "RecordNonce duplicate", s.RecordNonce(ctx, 1, "abc", now()), storage.ErrDuplicate)
+
+			// Nonces are scoped to the token, so another token may reuse one
+			must(t, "RecordNonce other token", s.RecordNonce(ctx, 2, "abc", now()))
+			must(t, "RecordNonce master key", s.RecordNonce(ctx, 0, "abc", now()))
+		},
+		"Prune": func(t *testing.T, s storage.Storage) {
+			must(t, "RecordNonce", s.RecordNonce(ctx, 1, "old", now().Add(-time.Hour)))
+			must(t, "RecordNonce", s.RecordNonce(ctx, 1, "new", now()))
+
+			n, err := s.PruneNonces(ctx, now().Add(-time.Minute))
+			must(t, "PruneNonces", err)
+			if n != 1 {
+				t.Errorf("PruneNonces = %d, want 1", n)
+			}
+
+			// A pruned nonce can be used again; a kept one cannot
+			must(t, "RecordNonce after prune", s.RecordNonce(ctx, 1, "old", now()))
+			wantErr(t, "RecordNonce kept", s.RecordNonce(ctx, 1, "new", now()), storage.ErrDuplicate)
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testProtectedRecords(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AddListAndDelete": func(t *testing.T, s storage.Storage) {
+			at := now()
+			protected, err := s.ListProtectedRecords(ctx, 0)
+			must(t, "ListProtectedRecords", err)
+			if protected == nil || len(protected) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", protected)
+			}
+			if _, err := s.AddProtectedRecord(ctx, &storage.ProtectedRecord{Name: "www"}); err == nil {
+				t.Error("expected error for missing zone ID")
+			}
+
+			apex, err := s.AddProtectedRecord(ctx, &storage.ProtectedRecord{ZoneID: 7, RecordType: "MX",
+				Reason: "mail", CreatedBy: "admin", CreatedAt: at})
+			must(t, "AddProtectedRecord", err)
+			if apex.ID <= 0 || !apex.CreatedAt.Equal(at) {
+				t.Errorf("unexpected protected record: %+v", apex)
+			}
+			www, err := s.AddProtectedRecord(ctx, &storage.ProtectedRecord{ZoneID: 7, Name: "www"})
+			must(t, "AddProtectedRecord", err)
+			other, err := s.AddProtectedRecord(ctx, &storage.ProtectedRecord{ZoneID: 8, RecordType: "MX"})
+			must(t, "AddProtectedRecord", err)
+
+			_, err = s.AddProtectedRecord(ctx, &storage.ProtectedRecord{ZoneID: 7, RecordType: "MX"})
+			wantErr(t, "AddProtectedRecord duplicate", err, storage.ErrDuplicate)
+
+			protected, err = s.ListProtectedRecords(ctx, 7)
+			must(t, "ListProtectedRecords", err)
+			if len(protected) != 2 || protected[0].ID != apex.ID || protected[1].ID != www.ID {
+				t.Fatalf("expected zone 7's records in creation order, got %+v", protected)
+			}
+			if p := protected[0]; p.Name != "" || p.RecordType != "MX" || p.Reason != "mail" || p.CreatedBy != "admin" {
+				t.Errorf("protected record fields not preserved: %+v", p)
+			}
+			all, err := s.ListProtectedRecords(ctx, 0)
+			must(t, "ListProtectedRecords all", err)
+			if len(all) != 3 || all[2].ID != other.ID {
+				t.Errorf("expected every zone's records, got %+v", all)
+			}
+
+			must(t, "DeleteProtectedRecord", s.DeleteProtectedRecord(ctx, apex.ID))
+			wantErr(t, "DeleteProtectedRecord again", s.DeleteProtectedRecord(ctx, apex.ID), storage.ErrNotFound)
+		},
+	})
+}
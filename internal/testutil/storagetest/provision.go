@@ -0,0 +1,46 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testProvisionedTokens(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"MarkAndList": func(t *testing.T, s storage.Storage) {
+			ids, err := s.ListProvisionedTokenIDs(ctx)
+			must(t, "ListProvisionedTokenIDs", err)
+			if len(ids) != 0 {
+				t.Fatalf("ListProvisionedTokenIDs = %v, want none", ids)
+			}
+
+			second := createToken(t, s, "second")
+			first := createToken(t, s, "first")
+			createToken(t, s, "manual")
+			must(t, "MarkTokenProvisioned", s.MarkTokenProvisioned(ctx, first.ID))
+			must(t, "MarkTokenProvisioned", s.MarkTokenProvisioned(ctx, second.ID))
+			must(t, "MarkTokenProvisioned again", s.MarkTokenProvisioned(ctx, first.ID))
+
+			ids, err = s.ListProvisionedTokenIDs(ctx)
+			must(t, "ListProvisionedTokenIDs", err)
+			if len(ids) != 2 || ids[0] != second.ID || ids[1] != first.ID {
+				t.Errorf("ListProvisionedTokenIDs = %v, want [%d %d]", ids, second.ID, first.ID)
+			}
+		},
+		"DeletedTokensAreForgotten": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			must(t, "MarkTokenProvisioned", s.MarkTokenProvisioned(ctx, token.ID))
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+
+			ids, err := s.ListProvisionedTokenIDs(ctx)
+			must(t, "ListProvisionedTokenIDs", err)
+			if len(ids) != 0 {
+				t.Errorf("ListProvisionedTokenIDs = %v, want none", ids)
+			}
+		},
+	})
+}
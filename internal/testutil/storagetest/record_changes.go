@@ -0,0 +1,84 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testRecordChanges(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AddAndList": func(t *testing.T, s storage.Storage) {
+			changes, err := s.ListRecordChanges(ctx, 10, storage.RecordChangeFilter{})
+			must(t, "ListRecordChanges", err)
+			if changes == nil || len(changes) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", changes)
+			}
+
+			if _, err := s.AddRecordChange(ctx, &storage.RecordChange{Action: storage.RecordChangeCreate}); err == nil {
+				t.Error("expected error for a change without a zone ID")
+			}
+
+			at := now()
+			var ids []int64
+			for _, c := range []storage.RecordChange{
+				{ZoneID: 10, RecordID: 1, Action: storage.RecordChangeCreate, After: []byte(`{"Value":"a"}`), Actor: "ci", Account: "eu"},
+				{ZoneID: 10, RecordID: 1, Action: storage.RecordChangeUpdate, Before: []byte(`{"Value":"a"}`), After: []byte(`{"Value":"b"}`)},
+				{ZoneID: 20, RecordID: 2, Action: storage.RecordChangeCreate, After: []byte(`{}`)},
+				{ZoneID: 10, RecordID: 3, Action: storage.RecordChangeDelete, Before: []byte(`{"Value":"c"}`), CreatedAt: at},
+			} {
+				added, err := s.AddRecordChange(ctx, &c)
+				must(t, "AddRecordChange", err)
+				ids = append(ids, added.ID)
+			}
+
+			tests := []struct {
+				name   string
+				filter storage.RecordChangeFilter
+				want   []int64
+			}{
+				{"zone newest first", storage.RecordChangeFilter{}, []int64{ids[3], ids[1], ids[0]}},
+				{"record", storage.RecordChangeFilter{RecordID: 1}, []int64{ids[1], ids[0]}},
+				{"paging", storage.RecordChangeFilter{BeforeID: ids[3], Limit: 1}, []int64{ids[1]}},
+			}
+			for _, tt := range tests {
+				changes, err := s.ListRecordChanges(ctx, 10, tt.filter)
+				must(t, "ListRecordChanges "+tt.name, err)
+				if len(changes) != len(tt.want) {
+					t.Errorf("%s: got %d changes, want %d", tt.name, len(changes), len(tt.want))
+					continue
+				}
+				for i, c := range changes {
+					if c.ID != tt.want[i] {
+						t.Errorf("%s: change %d has ID %d, want %d", tt.name, i, c.ID, tt.want[i])
+					}
+				}
+			}
+
+			changes, err = s.ListRecordChanges(ctx, 10, storage.RecordChangeFilter{})
+			must(t, "ListRecordChanges", err)
+			deleted, updated, created := changes[0], changes[1], changes[2]
+			if deleted.Action != storage.RecordChangeDelete || string(deleted.Before) != `{"Value":"c"}` ||
+				deleted.After != nil || !deleted.CreatedAt.Equal(at) {
+				t.Errorf("delete = %+v", deleted)
+			}
+			if string(updated.Before) != `{"Value":"a"}` || string(updated.After) != `{"Value":"b"}` {
+				t.Errorf("update = %+v", updated)
+			}
+			if created.Before != nil || created.Actor != "ci" || created.Account != "eu" || created.RecordID != 1 {
+				t.Errorf("create = %+v", created)
+			}
+
+			got, err := s.GetRecordChange(ctx, updated.ID)
+			must(t, "GetRecordChange", err)
+			if got.ID != updated.ID || string(got.Before) != `{"Value":"a"}` || !got.CreatedAt.Equal(updated.CreatedAt) {
+				t.Errorf("GetRecordChange = %+v", got)
+			}
+			_, err = s.GetRecordChange(ctx, ids[3]+100)
+			wantErr(t, "GetRecordChange missing", err, storage.ErrNotFound)
+		},
+	})
+}
@@ -0,0 +1,83 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testRecordMeta(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"SetAndGet": func(t *testing.T, s storage.Storage) {
+			_, err := s.GetRecordMeta(ctx, 1, 100)
+			wantErr(t, "GetRecordMeta", err, storage.ErrNotFound)
+
+			_, err = s.SetRecordMeta(ctx, &storage.RecordMeta{
+				ZoneID: 1, RecordID: 100, Meta: map[string]string{"owner": "team-a"}, UpdatedBy: "ci",
+			})
+			must(t, "SetRecordMeta", err)
+
+			// Setting again replaces rather than merges
+			_, err = s.SetRecordMeta(ctx, &storage.RecordMeta{
+				ZoneID: 1, RecordID: 100, Meta: map[string]string{"ticket": "OPS-1"}, UpdatedBy: "admin",
+			})
+			must(t, "SetRecordMeta", err)
+
+			got, err := s.GetRecordMeta(ctx, 1, 100)
+			must(t, "GetRecordMeta", err)
+			if len(got.Meta) != 1 || got.Meta["ticket"] != "OPS-1" || got.UpdatedBy != "admin" {
+				t.Errorf("unexpected metadata: %+v", got)
+			}
+		},
+		"EmptyMetaRemoves": func(t *testing.T, s storage.Storage) {
+			_, err := s.SetRecordMeta(ctx, &storage.RecordMeta{ZoneID: 1, RecordID: 100, Meta: map[string]string{"a": "b"}})
+			must(t, "SetRecordMeta", err)
+			_, err = s.SetRecordMeta(ctx, &storage.RecordMeta{ZoneID: 1, RecordID: 100})
+			must(t, "SetRecordMeta with empty meta", err)
+			_, err = s.GetRecordMeta(ctx, 1, 100)
+			wantErr(t, "GetRecordMeta", err, storage.ErrNotFound)
+
+			// Clearing metadata that doesn't exist is not an error
+			_, err = s.SetRecordMeta(ctx, &storage.RecordMeta{ZoneID: 1, RecordID: 100})
+			must(t, "SetRecordMeta with empty meta", err)
+		},
+		"ListAndDelete": func(t *testing.T, s storage.Storage) {
+			metas, err := s.ListRecordMeta(ctx, 1)
+			must(t, "ListRecordMeta", err)
+			if metas == nil || len(metas) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", metas)
+			}
+
+			for _, m := range []*storage.RecordMeta{
+				{ZoneID: 1, RecordID: 200, Meta: map[string]string{"k": "v"}},
+				{ZoneID: 1, RecordID: 100, Meta: map[string]string{"k": "v"}},
+				{ZoneID: 2, RecordID: 100, Meta: map[string]string{"k": "v"}},
+			} {
+				_, err := s.SetRecordMeta(ctx, m)
+				must(t, "SetRecordMeta", err)
+			}
+
+			metas, err = s.ListRecordMeta(ctx, 1)
+			must(t, "ListRecordMeta", err)
+			if len(metas) != 2 || metas[0].RecordID != 100 || metas[1].RecordID != 200 {
+				t.Fatalf("expected records 100 and 200 of zone 1, got %+v", metas)
+			}
+
+			must(t, "DeleteRecordMeta", s.DeleteRecordMeta(ctx, 1, 100))
+			wantErr(t, "DeleteRecordMeta again", s.DeleteRecordMeta(ctx, 1, 100), storage.ErrNotFound)
+
+			must(t, "DeleteZoneRecordMeta", s.DeleteZoneRecordMeta(ctx, 1))
+			metas, err = s.ListRecordMeta(ctx, 1)
+			must(t, "ListRecordMeta", err)
+			if len(metas) != 0 {
+				t.Errorf("zone 1 should have no metadata, got %+v", metas)
+			}
+			if _, err := s.GetRecordMeta(ctx, 2, 100); err != nil {
+				t.Errorf("other zones should be untouched: %v", err)
+			}
+		},
+	})
+}
@@ -0,0 +1,151 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testRoles(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	acme := func() *storage.Role {
+		return &storage.Role{Name: "acme", Description: "ACME DNS-01", Permissions: []*storage.Permission{
+			{ZoneID: 100, AllowedActions: []string{"add_record", "delete_record"}, RecordTypes: []string{"TXT"}},
+		}}
+	}
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"CreateAndGet": func(t *testing.T, s storage.Storage) {
+			created, err := s.CreateRole(ctx, acme())
+			must(t, "CreateRole", err)
+			if created.ID == 0 || len(created.Permissions) != 1 || created.Permissions[0].RoleID != created.ID {
+				t.Fatalf("CreateRole = %+v", created)
+			}
+			if created.Permissions[0].Resource != storage.ResourceDNSZone {
+				t.Errorf("Resource = %q, want %q", created.Permissions[0].Resource, storage.ResourceDNSZone)
+			}
+
+			got, err := s.GetRole(ctx, created.ID)
+			must(t, "GetRole", err)
+			if got.Name != "acme" || got.Description != "ACME DNS-01" || len(got.Permissions) != 1 || got.Permissions[0].ZoneID != 100 {
+				t.Errorf("GetRole = %+v", got)
+			}
+
+			_, err = s.CreateRole(ctx, acme())
+			wantErr(t, "CreateRole duplicate", err, storage.ErrDuplicate)
+			_, err = s.GetRole(ctx, 999)
+			wantErr(t, "GetRole missing", err, storage.ErrNotFound)
+			if _, err := s.CreateRole(ctx, &storage.Role{Name: " "}); err == nil {
+				t.Error("expected error for empty role name")
+			}
+		},
+		"InvalidPermissionCreatesNothing": func(t *testing.T, s storage.Storage) {
+			role := acme()
+			role.Permissions = append(role.Permissions, &storage.Permission{ZoneID: 0, AllowedActions: []string{"list_records"}})
+			if _, err := s.CreateRole(ctx, role); err == nil {
+				t.Fatal("expected error for invalid permission")
+			}
+			roles, err := s.ListRoles(ctx)
+			must(t, "ListRoles", err)
+			if len(roles) != 0 {
+				t.Errorf("ListRoles = %d roles, want 0", len(roles))
+			}
+		},
+		"ListOrderedByName": func(t *testing.T, s storage.Storage) {
+			for _, name := range []string{"monitoring", "acme"} {
+				_, err := s.CreateRole(ctx, &storage.Role{Name: name})
+				must(t, "CreateRole", err)
+			}
+			roles, err := s.ListRoles(ctx)
+			must(t, "ListRoles", err)
+			if len(roles) != 2 || roles[0].Name != "acme" || roles[1].Name != "monitoring" {
+				t.Errorf("ListRoles = %+v", roles)
+			}
+			if roles[0].Permissions == nil {
+				t.Error("Permissions should be an empty slice, not nil")
+			}
+		},
+		"TokensGetRolePermissions": func(t *testing.T, s storage.Storage) {
+			role, err := s.CreateRole(ctx, acme())
+			must(t, "CreateRole", err)
+			token := createToken(t, s, "ci")
+			_, err = s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+				ZoneID: 200, AllowedActions: []string{"list_records"}, RecordTypes: []string{"A"},
+			})
+			must(t, "AddPermissionForToken", err)
+			must(t, "AssignRole", s.AssignRole(ctx, token.ID, role.ID))
+			wantErr(t, "AssignRole again", s.AssignRole(ctx, token.ID, role.ID), storage.ErrDuplicate)
+
+			perms, err := s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 2 || perms[0].ZoneID != 200 || perms[0].RoleID != 0 ||
+				perms[1].ZoneID != 100 || perms[1].RoleID != role.ID || perms[1].TokenID != token.ID {
+				t.Fatalf("GetPermissionsForToken = %+v", perms)
+			}
+
+			// Changes to the role apply to its tokens at once
+			added, err := s.AddRolePermission(ctx, role.ID, &storage.Permission{
+				Resource: storage.ResourcePullZone, ZoneID: 300, AllowedActions: []string{"purge_pull_zone"},
+			})
+			must(t, "AddRolePermission", err)
+			perms, err = s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 3 || perms[2].ZoneID != 300 {
+				t.Fatalf("GetPermissionsForToken after AddRolePermission = %+v", perms)
+			}
+
+			must(t, "RemoveRolePermission", s.RemoveRolePermission(ctx, role.ID, added.ID))
+			wantErr(t, "RemoveRolePermission again", s.RemoveRolePermission(ctx, role.ID, added.ID), storage.ErrNotFound)
+
+			roles, err := s.ListTokenRoles(ctx, token.ID)
+			must(t, "ListTokenRoles", err)
+			if len(roles) != 1 || roles[0].ID != role.ID || roles[0].TokenCount != 1 {
+				t.Errorf("ListTokenRoles = %+v", roles)
+			}
+
+			must(t, "UnassignRole", s.UnassignRole(ctx, token.ID, role.ID))
+			wantErr(t, "UnassignRole again", s.UnassignRole(ctx, token.ID, role.ID), storage.ErrNotFound)
+			perms, err = s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 1 || perms[0].RoleID != 0 {
+				t.Errorf("GetPermissionsForToken after UnassignRole = %+v", perms)
+			}
+		},
+		"AssignErrors": func(t *testing.T, s storage.Storage) {
+			role, err := s.CreateRole(ctx, acme())
+			must(t, "CreateRole", err)
+			token := createToken(t, s, "ci")
+			wantErr(t, "AssignRole missing token", s.AssignRole(ctx, 999, role.ID), storage.ErrNotFound)
+			wantErr(t, "AssignRole missing role", s.AssignRole(ctx, token.ID, 999), storage.ErrNotFound)
+			_, err = s.AddRolePermission(ctx, 999, &storage.Permission{ZoneID: 1, AllowedActions: []string{"get_zone"}, RecordTypes: []string{"A"}})
+			wantErr(t, "AddRolePermission missing role", err, storage.ErrNotFound)
+		},
+		"DeleteCascades": func(t *testing.T, s storage.Storage) {
+			role, err := s.CreateRole(ctx, acme())
+			must(t, "CreateRole", err)
+			token := createToken(t, s, "ci")
+			must(t, "AssignRole", s.AssignRole(ctx, token.ID, role.ID))
+
+			must(t, "DeleteRole", s.DeleteRole(ctx, role.ID))
+			wantErr(t, "DeleteRole again", s.DeleteRole(ctx, role.ID), storage.ErrNotFound)
+			perms, err := s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 0 {
+				t.Errorf("GetPermissionsForToken after DeleteRole = %+v", perms)
+			}
+
+			// Deleting a token releases its roles
+			role, err = s.CreateRole(ctx, acme())
+			must(t, "CreateRole", err)
+			must(t, "AssignRole", s.AssignRole(ctx, token.ID, role.ID))
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+			got, err := s.GetRole(ctx, role.ID)
+			must(t, "GetRole", err)
+			if got.TokenCount != 0 {
+				t.Errorf("TokenCount after DeleteToken = %d, want 0", got.TokenCount)
+			}
+		},
+	})
+}
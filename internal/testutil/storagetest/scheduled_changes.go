@@ -0,0 +1,192 @@
+package storagetest
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testScheduledChanges(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	create := func(zoneID int64, runAt time.Time) *storage.ScheduledChange {
+		return &storage.ScheduledChange{ZoneID: zoneID, Action: storage.RecordChangeCreate,
+			Request: []byte(`{"Type":0,"Name":"www","Value":"192.0.2.1"}`), RunAt: runAt}
+	}
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"Validation": func(t *testing.T, s storage.Storage) {
+			for name, c := range map[string]*storage.ScheduledChange{
+				"no zone":        {Action: storage.RecordChangeCreate, Request: []byte(`{}`), RunAt: now()},
+				"no run time":    {ZoneID: 1, Action: storage.RecordChangeCreate, Request: []byte(`{}`)},
+				"no record":      {ZoneID: 1, Action: storage.RecordChangeCreate, RunAt: now()},
+				"no record ID":   {ZoneID: 1, Action: storage.RecordChangeDelete, RunAt: now()},
+				"update":         {ZoneID: 1, Action: storage.RecordChangeUpdate, RecordID: 2, RunAt: now()},
+				"unknown action": {ZoneID: 1, Action: "rename", RecordID: 2, RunAt: now()},
+			} {
+				if _, err := s.CreateScheduledChange(ctx, c); err == nil {
+					t.Errorf("%s: expected error", name)
+				}
+			}
+			_, err := s.GetScheduledChange(ctx, 1)
+			wantErr(t, "GetScheduledChange missing", err, storage.ErrNotFound)
+		},
+		"Lifecycle": func(t *testing.T, s storage.Storage) {
+			at := now()
+			c := create(7, at.Add(time.Hour))
+			c.TokenID, c.Actor, c.Account, c.CreatedAt = 3, "deploy", "eu", at
+			created, err := s.CreateScheduledChange(ctx, c)
+			must(t, "CreateScheduledChange", err)
+			if created.ID == 0 || created.Status != storage.ScheduledChangePending || !created.RunAt.Equal(at.Add(time.Hour)) {
+				t.Fatalf("created change = %+v", created)
+			}
+
+			got, err := s.GetScheduledChange(ctx, created.ID)
+			must(t, "GetScheduledChange", err)
+			if got.ZoneID != 7 || got.Action != storage.RecordChangeCreate || string(got.Request) != string(c.Request) ||
+				got.TokenID != 3 || got.Actor != "deploy" || got.Account != "eu" || !got.CreatedAt.Equal(at) ||
+				!got.StartedAt.IsZero() || !got.FinishedAt.IsZero() {
+				t.Errorf("stored change = %+v", got)
+			}
+
+			wantErr(t, "FinishScheduledChange pending",
+				s.FinishScheduledChange(ctx, created.ID, storage.ScheduledChangeSucceeded, 0, "", at), storage.ErrNotFound)
+			must(t, "StartScheduledChange", s.StartScheduledChange(ctx, created.ID, at))
+			wantErr(t, "StartScheduledChange running", s.StartScheduledChange(ctx, created.ID, at), storage.ErrNotFound)
+			wantErr(t, "CancelScheduledChange running", s.CancelScheduledChange(ctx, created.ID, at), storage.ErrNotFound)
+			if err := s.FinishScheduledChange(ctx, created.ID, storage.ScheduledChangeCancelled, 0, "", at); err == nil {
+				t.Error("expected error for an invalid final status")
+			}
+			must(t, "FinishScheduledChange", s.FinishScheduledChange(ctx, created.ID, storage.ScheduledChangeSucceeded, 42, "", at))
+
+			got, err = s.GetScheduledChange(ctx, created.ID)
+			must(t, "GetScheduledChange", err)
+			if got.Status != storage.ScheduledChangeSucceeded || got.RecordID != 42 || !got.StartedAt.Equal(at) ||
+				!got.FinishedAt.Equal(at) {
+				t.Errorf("finished change = %+v", got)
+			}
+
+			// A failed delete keeps the record it was to delete
+			del, err := s.CreateScheduledChange(ctx, &storage.ScheduledChange{ZoneID: 7, Action: storage.RecordChangeDelete,
+				RecordID: 9, RunAt: at})
+			must(t, "CreateScheduledChange", err)
+			must(t, "StartScheduledChange", s.StartScheduledChange(ctx, del.ID, at))
+			must(t, "FinishScheduledChange", s.FinishScheduledChange(ctx, del.ID, storage.ScheduledChangeFailed, 0, "not found", at))
+			got, err = s.GetScheduledChange(ctx, del.ID)
+			must(t, "GetScheduledChange", err)
+			if got.Status != storage.ScheduledChangeFailed || got.RecordID != 9 || got.Error != "not found" || got.Request != nil {
+				t.Errorf("failed change = %+v", got)
+			}
+		},
+		"Cancel": func(t *testing.T, s storage.Storage) {
+			at := now()
+			c, err := s.CreateScheduledChange(ctx, create(1, at))
+			must(t, "CreateScheduledChange", err)
+			must(t, "CancelScheduledChange", s.CancelScheduledChange(ctx, c.ID, at))
+			wantErr(t, "CancelScheduledChange twice", s.CancelScheduledChange(ctx, c.ID, at), storage.ErrNotFound)
+			wantErr(t, "StartScheduledChange cancelled", s.StartScheduledChange(ctx, c.ID, at), storage.ErrNotFound)
+			wantErr(t, "CancelScheduledChange missing", s.CancelScheduledChange(ctx, c.ID+1, at), storage.ErrNotFound)
+
+			got, err := s.GetScheduledChange(ctx, c.ID)
+			must(t, "GetScheduledChange", err)
+			if got.Status != storage.ScheduledChangeCancelled || !got.FinishedAt.Equal(at) {
+				t.Errorf("cancelled change = %+v", got)
+			}
+		},
+		"List": func(t *testing.T, s storage.Storage) {
+			at := now()
+			late, err := s.CreateScheduledChange(ctx, create(1, at.Add(time.Hour)))
+			must(t, "CreateScheduledChange", err)
+			early, err := s.CreateScheduledChange(ctx, create(1, at.Add(-time.Minute)))
+			must(t, "CreateScheduledChange", err)
+			due, err := s.CreateScheduledChange(ctx, create(1, at))
+			must(t, "CreateScheduledChange", err)
+			other, err := s.CreateScheduledChange(ctx, create(2, at.Add(-time.Hour)))
+			must(t, "CreateScheduledChange", err)
+			must(t, "CancelScheduledChange", s.CancelScheduledChange(ctx, early.ID, at))
+
+			ids := func(changes []*storage.ScheduledChange) []int64 {
+				out := make([]int64, 0, len(changes))
+				for _, c := range changes {
+					out = append(out, c.ID)
+				}
+				return out
+			}
+
+			all, err := s.ListScheduledChanges(ctx, 1, "")
+			must(t, "ListScheduledChanges", err)
+			if got := ids(all); !slices.Equal(got, []int64{early.ID, due.ID, late.ID}) {
+				t.Errorf("zone 1 changes = %v, want them in run order", got)
+			}
+			pending, err := s.ListScheduledChanges(ctx, 1, storage.ScheduledChangePending)
+			must(t, "ListScheduledChanges", err)
+			if got := ids(pending); !slices.Equal(got, []int64{due.ID, late.ID}) {
+				t.Errorf("pending zone 1 changes = %v", got)
+			}
+			none, err := s.ListScheduledChanges(ctx, 3, "")
+			must(t, "ListScheduledChanges", err)
+			if none == nil || len(none) != 0 {
+				t.Errorf("expected an empty list, got %v", none)
+			}
+
+			dueNow, err := s.ListDueScheduledChanges(ctx, at)
+			must(t, "ListDueScheduledChanges", err)
+			if got := ids(dueNow); !slices.Equal(got, []int64{other.ID, due.ID}) {
+				t.Errorf("due changes = %v", got)
+			}
+		},
+		"FailUnfinished": func(t *testing.T, s storage.Storage) {
+			at := now()
+			old, err := s.CreateScheduledChange(ctx, create(1, at))
+			must(t, "CreateScheduledChange", err)
+			must(t, "StartScheduledChange", s.StartScheduledChange(ctx, old.ID, at.Add(-time.Minute)))
+			current, err := s.CreateScheduledChange(ctx, create(1, at))
+			must(t, "CreateScheduledChange", err)
+			must(t, "StartScheduledChange", s.StartScheduledChange(ctx, current.ID, at))
+			pending, err := s.CreateScheduledChange(ctx, create(1, at.Add(-time.Hour)))
+			must(t, "CreateScheduledChange", err)
+
+			n, err := s.FailUnfinishedScheduledChanges(ctx, at, "interrupted")
+			must(t, "FailUnfinishedScheduledChanges", err)
+			if n != 1 {
+				t.Errorf("FailUnfinishedScheduledChanges = %d, want 1", n)
+			}
+			for id, want := range map[int64]string{
+				old.ID:     storage.ScheduledChangeFailed,
+				current.ID: storage.ScheduledChangeRunning,
+				pending.ID: storage.ScheduledChangePending,
+			} {
+				if got, err := s.GetScheduledChange(ctx, id); err != nil || got.Status != want {
+					t.Errorf("change %d = %+v, %v; want %s", id, got, err, want)
+				}
+			}
+		},
+		"Prune": func(t *testing.T, s storage.Storage) {
+			old, err := s.CreateScheduledChange(ctx, create(1, now()))
+			must(t, "CreateScheduledChange", err)
+			must(t, "CancelScheduledChange", s.CancelScheduledChange(ctx, old.ID, now().Add(-time.Hour)))
+			recent, err := s.CreateScheduledChange(ctx, create(1, now()))
+			must(t, "CreateScheduledChange", err)
+			must(t, "StartScheduledChange", s.StartScheduledChange(ctx, recent.ID, now()))
+			must(t, "FinishScheduledChange", s.FinishScheduledChange(ctx, recent.ID, storage.ScheduledChangeSucceeded, 5, "", now()))
+			pending, err := s.CreateScheduledChange(ctx, create(1, now().Add(-time.Hour)))
+			must(t, "CreateScheduledChange", err)
+
+			n, err := s.PruneScheduledChanges(ctx, now().Add(-time.Minute))
+			must(t, "PruneScheduledChanges", err)
+			if n != 1 {
+				t.Errorf("PruneScheduledChanges = %d, want 1", n)
+			}
+			_, err = s.GetScheduledChange(ctx, old.ID)
+			wantErr(t, "GetScheduledChange pruned", err, storage.ErrNotFound)
+			for _, id := range []int64{recent.ID, pending.ID} {
+				if _, err := s.GetScheduledChange(ctx, id); err != nil {
+					t.Errorf("GetScheduledChange(%d) after prune: %v", id, err)
+				}
+			}
+		},
+	})
+}
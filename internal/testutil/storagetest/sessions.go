@@ -0,0 +1,77 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testAdminSessions(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"CreateAndGet": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ops")
+			expires := now().Add(15 * time.Minute)
+
+			created, err := s.CreateAdminSession(ctx, &storage.AdminSession{
+				TokenID: token.ID, ClientIP: "192.0.2.1", UserAgent: "bunnyctl/1.0", ExpiresAt: expires,
+			}, "hash-a")
+			must(t, "CreateAdminSession", err)
+			if created.ID == 0 || created.CreatedAt.IsZero() {
+				t.Errorf("expected ID and CreatedAt to be set, got %+v", created)
+			}
+
+			got, err := s.GetAdminSessionByHash(ctx, "hash-a")
+			must(t, "GetAdminSessionByHash", err)
+			if got.ID != created.ID || got.TokenID != token.ID || got.ClientIP != "192.0.2.1" ||
+				got.UserAgent != "bunnyctl/1.0" || !got.ExpiresAt.Equal(expires) {
+				t.Errorf("GetAdminSessionByHash = %+v, want %+v", got, created)
+			}
+
+			_, err = s.CreateAdminSession(ctx, &storage.AdminSession{TokenID: token.ID, ExpiresAt: expires}, "hash-a")
+			wantErr(t, "CreateAdminSession duplicate", err, storage.ErrDuplicate)
+
+			_, err = s.GetAdminSessionByHash(ctx, "missing")
+			wantErr(t, "GetAdminSessionByHash missing", err, storage.ErrNotFound)
+		},
+		"Delete": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ops")
+			created, err := s.CreateAdminSession(ctx, &storage.AdminSession{TokenID: token.ID, ExpiresAt: now().Add(time.Hour)}, "hash-a")
+			must(t, "CreateAdminSession", err)
+
+			must(t, "DeleteAdminSession", s.DeleteAdminSession(ctx, created.ID))
+			wantErr(t, "DeleteAdminSession again", s.DeleteAdminSession(ctx, created.ID), storage.ErrNotFound)
+			_, err = s.GetAdminSessionByHash(ctx, "hash-a")
+			wantErr(t, "GetAdminSessionByHash deleted", err, storage.ErrNotFound)
+		},
+		"DeletedWithToken": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ops")
+			_, err := s.CreateAdminSession(ctx, &storage.AdminSession{TokenID: token.ID, ExpiresAt: now().Add(time.Hour)}, "hash-a")
+			must(t, "CreateAdminSession", err)
+
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+			_, err = s.GetAdminSessionByHash(ctx, "hash-a")
+			wantErr(t, "GetAdminSessionByHash after token delete", err, storage.ErrNotFound)
+		},
+		"Prune": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ops")
+			_, err := s.CreateAdminSession(ctx, &storage.AdminSession{TokenID: token.ID, ExpiresAt: now().Add(-time.Hour)}, "hash-old")
+			must(t, "CreateAdminSession", err)
+			_, err = s.CreateAdminSession(ctx, &storage.AdminSession{TokenID: token.ID, ExpiresAt: now().Add(time.Hour)}, "hash-new")
+			must(t, "CreateAdminSession", err)
+
+			n, err := s.PruneAdminSessions(ctx, now())
+			must(t, "PruneAdminSessions", err)
+			if n != 1 {
+				t.Errorf("PruneAdminSessions = %d, want 1", n)
+			}
+			_, err = s.GetAdminSessionByHash(ctx, "hash-old")
+			wantErr(t, "GetAdminSessionByHash pruned", err, storage.ErrNotFound)
+			_, err = s.GetAdminSessionByHash(ctx, "hash-new")
+			must(t, "GetAdminSessionByHash kept", err)
+		},
+	})
+}
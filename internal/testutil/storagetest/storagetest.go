@@ -0,0 +1,128 @@
+// Package storagetest provides a conformance suite for storage.Storage implementations.
+//
+// A backend proves it honours the contract documented on the storage interfaces
+// (sentinel errors, ordering, cascades, and behaviour under concurrent use) by
+// calling Run from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.Storage {
+//			return newBackend(t)
+//		})
+//	}
+//
+// Times are compared with second precision, matching what the SQLite backend stores.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// Factory returns a new, empty Storage. It is called once per subtest and the
+// suite closes the returned Storage when the subtest finishes.
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the full conformance suite against storages created by newStorage.
+// Subtests run in parallel, each with its own Storage.
+func Run(t *testing.T, newStorage Factory) {
+	t.Helper()
+
+	groups := []struct {
+		name string
+		run  func(t *testing.T, newStorage Factory)
+	}{
+		{"Tokens", testTokens},
+		{"Permissions", testPermissions},
+		{"Webhooks", testWebhooks},
+		{"Outbox", testOutbox},
+		{"RecordMeta", testRecordMeta},
+		{"RecordChanges", testRecordChanges},
+		{"ScheduledChanges", testScheduledChanges},
+		{"Audit", testAudit},
+		{"Maintenance", testMaintenance},
+		{"Instances", testInstances},
+		{"Blocklist", testBlocklist},
+		{"ZoneFreezes", testZoneFreezes},
+		{"TTLPolicies", testTTLPolicies},
+		{"ProtectedRecords", testProtectedRecords},
+		{"ZoneTags", testZoneTags},
+		{"DomainPermissions", testDomainPermissions},
+		{"Nonces", testNonces},
+		{"IdempotencyKeys", testIdempotencyKeys},
+		{"Jobs", testJobs},
+		{"AdminSessions", testAdminSessions},
+		{"ClientCerts", testClientCerts},
+		{"Bootstrap", testBootstrap},
+		{"Roles", testRoles},
+		{"Invitations", testInvitations},
+		{"ProvisionedTokens", testProvisionedTokens},
+		{"Concurrency", testConcurrency},
+	}
+	for _, g := range groups {
+		t.Run(g.name, func(t *testing.T) {
+			t.Parallel()
+			g.run(t, newStorage)
+		})
+	}
+}
+
+// open creates a Storage for one test and closes it when the test ends.
+func open(t *testing.T, newStorage Factory) storage.Storage {
+	t.Helper()
+	s := newStorage(t)
+	if s == nil {
+		t.Fatal("factory returned nil storage")
+	}
+	t.Cleanup(func() {
+		if err := s.Close(); err != nil {
+			t.Errorf("Close failed: %v", err)
+		}
+	})
+	return s
+}
+
+// subtests runs each case in parallel against its own Storage.
+func subtests(t *testing.T, newStorage Factory, cases map[string]func(t *testing.T, s storage.Storage)) {
+	t.Helper()
+	for name, fn := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			fn(t, open(t, newStorage))
+		})
+	}
+}
+
+// wantErr fails the test unless err matches target.
+func wantErr(t *testing.T, op string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("%s: expected %v, got %v", op, target, err)
+	}
+}
+
+// must fails the test if err is not nil.
+func must(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s failed: %v", op, err)
+	}
+}
+
+// now returns the current time truncated to the second. Subtests that
+// compare a stored time call it once and reuse the value, as the clock may
+// pass a second boundary between calls.
+func now() time.Time {
+	return time.Unix(time.Now().Unix(), 0)
+}
+
+// createToken creates a scoped token with a hash derived from name.
+func createToken(t *testing.T, s storage.Storage, name string) *storage.Token {
+	t.Helper()
+	token, err := s.CreateToken(context.Background(), name, false, "hash-"+name)
+	must(t, "CreateToken", err)
+	return token
+}
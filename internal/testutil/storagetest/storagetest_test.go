@@ -0,0 +1,33 @@
+package storagetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// TestSQLiteInMemory runs the conformance suite against in-memory SQLite databases.
+func TestSQLiteInMemory(t *testing.T) {
+	t.Parallel()
+	Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.New(":memory:")
+		if err != nil {
+			t.Fatalf("failed to create storage: %v", err)
+		}
+		return s
+	})
+}
+
+// TestSQLiteFile runs the conformance suite against file-backed SQLite databases,
+// which exercises WAL mode and locking across concurrent writers.
+func TestSQLiteFile(t *testing.T) {
+	t.Parallel()
+	Run(t, func(t *testing.T) storage.Storage {
+		s, err := storage.New(filepath.Join(t.TempDir(), "proxy.db"))
+		if err != nil {
+			t.Fatalf("failed to create storage: %v", err)
+		}
+		return s
+	})
+}
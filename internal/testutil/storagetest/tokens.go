@@ -0,0 +1,335 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testTokens(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"CreateAndGet": func(t *testing.T, s storage.Storage) {
+			created, err := s.CreateToken(ctx, "ci", false, "hash-ci")
+			must(t, "CreateToken", err)
+			if created.ID <= 0 {
+				t.Fatalf("expected positive ID, got %d", created.ID)
+			}
+			if created.Name != "ci" || created.IsAdmin || created.KeyHash != "hash-ci" {
+				t.Errorf("unexpected token: %+v", created)
+			}
+
+			byHash, err := s.GetTokenByHash(ctx, "hash-ci")
+			must(t, "GetTokenByHash", err)
+			byID, err := s.GetTokenByID(ctx, created.ID)
+			must(t, "GetTokenByID", err)
+			byKey, err := s.GetTokenByKey(ctx, created.ID, "hash-ci")
+			must(t, "GetTokenByKey", err)
+			_, err = s.GetTokenByKey(ctx, created.ID, "hash-other")
+			wantErr(t, "GetTokenByKey with another hash", err, storage.ErrNotFound)
+			for _, got := range []*storage.Token{byHash, byID, byKey} {
+				if got.ID != created.ID || got.Name != "ci" || got.KeyHash != "hash-ci" || got.IsAdmin {
+					t.Errorf("unexpected token: %+v", got)
+				}
+				if !got.ExpiresAt.IsZero() || got.PinTLSFingerprint || got.TLSFingerprint != "" || got.Account != "" {
+					t.Errorf("new token should not expire, pin or use another account: %+v", got)
+				}
+			}
+		},
+		"KeyToken": func(t *testing.T, s storage.Storage) {
+			created, err := s.CreateKeyToken(ctx, "ci", true, "hash-ci")
+			must(t, "CreateKeyToken", err)
+			if created.ID <= 0 || created.Name != "ci" || !created.IsAdmin || created.KeyHash != "hash-ci" {
+				t.Errorf("unexpected token: %+v", created)
+			}
+			byKey, err := s.GetTokenByKey(ctx, created.ID, "hash-ci")
+			must(t, "GetTokenByKey", err)
+			if byKey.ID != created.ID {
+				t.Errorf("GetTokenByKey returned token %d, want %d", byKey.ID, created.ID)
+			}
+			// Only the whole <id>.<secret> key finds the token
+			_, err = s.GetTokenByHash(ctx, "hash-ci")
+			wantErr(t, "GetTokenByHash", err, storage.ErrNotFound)
+		},
+		"DuplicateHash": func(t *testing.T, s storage.Storage) {
+			createToken(t, s, "ci")
+			_, err := s.CreateToken(ctx, "other", true, "hash-ci")
+			wantErr(t, "CreateToken", err, storage.ErrDuplicate)
+
+			tokens, err := s.ListTokens(ctx)
+			must(t, "ListTokens", err)
+			if len(tokens) != 1 {
+				t.Errorf("failed create should not leave a token behind, got %d tokens", len(tokens))
+			}
+			if ok, err := s.HasAnyAdminToken(ctx); err != nil || ok {
+				t.Errorf("HasAnyAdminToken = %v, %v; want false", ok, err)
+			}
+		},
+		"NotFound": func(t *testing.T, s storage.Storage) {
+			_, err := s.GetTokenByHash(ctx, "missing")
+			wantErr(t, "GetTokenByHash", err, storage.ErrNotFound)
+			_, err = s.GetTokenByID(ctx, 999)
+			wantErr(t, "GetTokenByID", err, storage.ErrNotFound)
+			_, err = s.GetTokenByKey(ctx, 999, "hash-ci")
+			wantErr(t, "GetTokenByKey", err, storage.ErrNotFound)
+			wantErr(t, "DeleteToken", s.DeleteToken(ctx, 999), storage.ErrNotFound)
+			wantErr(t, "SetTokenExpiry", s.SetTokenExpiry(ctx, 999, time.Now()), storage.ErrNotFound)
+			wantErr(t, "SetTokenPinning", s.SetTokenPinning(ctx, 999, true), storage.ErrNotFound)
+			wantErr(t, "SetTokenAccount", s.SetTokenAccount(ctx, 999, "acme"), storage.ErrNotFound)
+			_, err = s.PinTokenFingerprint(ctx, 999, "fp")
+			wantErr(t, "PinTokenFingerprint", err, storage.ErrNotFound)
+		},
+		"ListAndDelete": func(t *testing.T, s storage.Storage) {
+			tokens, err := s.ListTokens(ctx)
+			must(t, "ListTokens", err)
+			if tokens == nil || len(tokens) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", tokens)
+			}
+
+			a := createToken(t, s, "a")
+			b := createToken(t, s, "b")
+			tokens, err = s.ListTokens(ctx)
+			must(t, "ListTokens", err)
+			if len(tokens) != 2 {
+				t.Fatalf("expected 2 tokens, got %d", len(tokens))
+			}
+
+			must(t, "DeleteToken", s.DeleteToken(ctx, a.ID))
+			_, err = s.GetTokenByID(ctx, a.ID)
+			wantErr(t, "GetTokenByID after delete", err, storage.ErrNotFound)
+			tokens, err = s.ListTokens(ctx)
+			must(t, "ListTokens", err)
+			if len(tokens) != 1 || tokens[0].ID != b.ID {
+				t.Errorf("expected only token %d, got %+v", b.ID, tokens)
+			}
+		},
+		"AdminCounts": func(t *testing.T, s storage.Storage) {
+			if ok, err := s.HasAnyAdminToken(ctx); err != nil || ok {
+				t.Fatalf("HasAnyAdminToken = %v, %v; want false", ok, err)
+			}
+			createToken(t, s, "scoped")
+			for i, hash := range []string{"admin-1", "admin-2"} {
+				_, err := s.CreateToken(ctx, hash, true, hash)
+				must(t, "CreateToken", err)
+				n, err := s.CountAdminTokens(ctx)
+				must(t, "CountAdminTokens", err)
+				if n != i+1 {
+					t.Errorf("CountAdminTokens = %d, want %d", n, i+1)
+				}
+			}
+			if ok, err := s.HasAnyAdminToken(ctx); err != nil || !ok {
+				t.Errorf("HasAnyAdminToken = %v, %v; want true", ok, err)
+			}
+		},
+		"Expiry": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			expires := now().Add(time.Hour)
+			must(t, "SetTokenExpiry", s.SetTokenExpiry(ctx, token.ID, expires))
+			got, err := s.GetTokenByID(ctx, token.ID)
+			must(t, "GetTokenByID", err)
+			if !got.ExpiresAt.Equal(expires) {
+				t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, expires)
+			}
+
+			must(t, "SetTokenExpiry", s.SetTokenExpiry(ctx, token.ID, time.Time{}))
+			got, err = s.GetTokenByID(ctx, token.ID)
+			must(t, "GetTokenByID", err)
+			if !got.ExpiresAt.IsZero() {
+				t.Errorf("zero expiry should clear ExpiresAt, got %v", got.ExpiresAt)
+			}
+		},
+		"Usage": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			if !token.LastUsedAt.IsZero() || token.UseCount != 0 {
+				t.Errorf("new token should be unused: %+v", token)
+			}
+			must(t, "RecordTokenUsage with no usage", s.RecordTokenUsage(ctx, nil))
+
+			last := now()
+			must(t, "RecordTokenUsage", s.RecordTokenUsage(ctx, []storage.TokenUsage{
+				{TokenID: token.ID, Count: 3, LastUsedAt: last},
+				{TokenID: 999, Count: 1, LastUsedAt: last},
+			}))
+			must(t, "RecordTokenUsage", s.RecordTokenUsage(ctx, []storage.TokenUsage{
+				{TokenID: token.ID, Count: 2, LastUsedAt: last.Add(-time.Hour)},
+			}))
+
+			got, err := s.GetTokenByID(ctx, token.ID)
+			must(t, "GetTokenByID", err)
+			if got.UseCount != 5 || !got.LastUsedAt.Equal(last) {
+				t.Errorf("expected 5 uses last at %v, got %d at %v", last, got.UseCount, got.LastUsedAt)
+			}
+		},
+		"Account": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			must(t, "SetTokenAccount", s.SetTokenAccount(ctx, token.ID, "acme"))
+
+			got, err := s.GetTokenByHash(ctx, token.KeyHash)
+			must(t, "GetTokenByHash", err)
+			if got.Account != "acme" {
+				t.Errorf("expected account acme, got %q", got.Account)
+			}
+
+			must(t, "SetTokenAccount", s.SetTokenAccount(ctx, token.ID, ""))
+			tokens, err := s.ListTokens(ctx)
+			must(t, "ListTokens", err)
+			if len(tokens) != 1 || tokens[0].Account != "" {
+				t.Errorf("expected the default account after clearing it, got %+v", tokens)
+			}
+		},
+		"FingerprintPinning": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			must(t, "SetTokenPinning", s.SetTokenPinning(ctx, token.ID, true))
+
+			pinned, err := s.PinTokenFingerprint(ctx, token.ID, "first")
+			must(t, "PinTokenFingerprint", err)
+			if pinned != "first" {
+				t.Errorf("first use should pin %q, got %q", "first", pinned)
+			}
+			pinned, err = s.PinTokenFingerprint(ctx, token.ID, "second")
+			must(t, "PinTokenFingerprint", err)
+			if pinned != "first" {
+				t.Errorf("later use should keep %q, got %q", "first", pinned)
+			}
+
+			got, err := s.GetTokenByHash(ctx, token.KeyHash)
+			must(t, "GetTokenByHash", err)
+			if !got.PinTLSFingerprint || got.TLSFingerprint != "first" {
+				t.Errorf("unexpected pinning state: %+v", got)
+			}
+
+			// Re-enabling pinning forgets the old fingerprint
+			must(t, "SetTokenPinning", s.SetTokenPinning(ctx, token.ID, true))
+			pinned, err = s.PinTokenFingerprint(ctx, token.ID, "second")
+			must(t, "PinTokenFingerprint", err)
+			if pinned != "second" {
+				t.Errorf("reset should allow a new pin, got %q", pinned)
+			}
+
+			must(t, "SetTokenPinning", s.SetTokenPinning(ctx, token.ID, false))
+			got, err = s.GetTokenByID(ctx, token.ID)
+			must(t, "GetTokenByID", err)
+			if got.PinTLSFingerprint || got.TLSFingerprint != "" {
+				t.Errorf("disabling pinning should clear state: %+v", got)
+			}
+		},
+	})
+}
+
+func testPermissions(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AddAndList": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			perms, err := s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if perms == nil || len(perms) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", perms)
+			}
+
+			for _, zoneID := range []int64{10, 20} {
+				perm, err := s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+					ZoneID:         zoneID,
+					AllowedActions: []string{"list_records", "add_record"},
+					RecordTypes:    []string{"TXT"},
+				})
+				must(t, "AddPermissionForToken", err)
+				if perm.ID <= 0 || perm.TokenID != token.ID {
+					t.Errorf("unexpected permission: %+v", perm)
+				}
+			}
+
+			perms, err = s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 2 || perms[0].ZoneID != 10 || perms[1].ZoneID != 20 {
+				t.Fatalf("expected permissions for zones 10 and 20 in order, got %+v", perms)
+			}
+			if len(perms[0].AllowedActions) != 2 || perms[0].AllowedActions[1] != "add_record" {
+				t.Errorf("AllowedActions not preserved: %v", perms[0].AllowedActions)
+			}
+			if len(perms[0].RecordTypes) != 1 || perms[0].RecordTypes[0] != "TXT" {
+				t.Errorf("RecordTypes not preserved: %v", perms[0].RecordTypes)
+			}
+			if perms[0].Resource != storage.ResourceDNSZone {
+				t.Errorf("expected default resource %q, got %q", storage.ResourceDNSZone, perms[0].Resource)
+			}
+		},
+		"Resource": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "cdn")
+			_, err := s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+				Resource:       storage.ResourcePullZone,
+				ZoneID:         30,
+				AllowedActions: []string{"purge_pull_zone"},
+			})
+			must(t, "AddPermissionForToken", err)
+			_, err = s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+				Resource:       storage.ResourceStorageZone,
+				ZoneID:         40,
+				AllowedActions: []string{"get_storage_zone"},
+			})
+			must(t, "AddPermissionForToken", err)
+
+			perms, err := s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 2 || perms[0].Resource != storage.ResourcePullZone || perms[0].ZoneID != 30 ||
+				perms[1].Resource != storage.ResourceStorageZone || perms[1].ZoneID != 40 {
+				t.Fatalf("resource permissions not preserved: %+v", perms)
+			}
+		},
+		"RejectsInvalid": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			invalid := []*storage.Permission{
+				{ZoneID: 0, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+				{ZoneID: 1, RecordTypes: []string{"TXT"}},
+				{ZoneID: 1, AllowedActions: []string{"list_records"}},
+				{Resource: "bogus", ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"}},
+			}
+			for _, perm := range invalid {
+				if _, err := s.AddPermissionForToken(ctx, token.ID, perm); err == nil {
+					t.Errorf("expected error for %+v", perm)
+				}
+			}
+			_, err := s.AddPermissionForToken(ctx, 999, &storage.Permission{
+				ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+			})
+			if err == nil {
+				t.Error("expected error for a permission on a missing token")
+			}
+		},
+		"Remove": func(t *testing.T, s storage.Storage) {
+			a := createToken(t, s, "a")
+			b := createToken(t, s, "b")
+			perm, err := s.AddPermissionForToken(ctx, a.ID, &storage.Permission{
+				ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+			})
+			must(t, "AddPermissionForToken", err)
+
+			wantErr(t, "RemovePermissionForToken with wrong token",
+				s.RemovePermissionForToken(ctx, b.ID, perm.ID), storage.ErrNotFound)
+			must(t, "RemovePermissionForToken", s.RemovePermissionForToken(ctx, a.ID, perm.ID))
+			wantErr(t, "RemovePermission", s.RemovePermission(ctx, perm.ID), storage.ErrNotFound)
+		},
+		"CascadeOnTokenDelete": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			_, err := s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+				ZoneID: 1, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+			})
+			must(t, "AddPermissionForToken", err)
+			hook, err := s.CreateWebhook(ctx, &storage.Webhook{URL: "https://example.com/hook", Secret: "s", TokenID: token.ID})
+			must(t, "CreateWebhook", err)
+
+			must(t, "DeleteToken", s.DeleteToken(ctx, token.ID))
+			perms, err := s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(perms) != 0 {
+				t.Errorf("permissions should be deleted with the token, got %d", len(perms))
+			}
+			_, err = s.GetWebhook(ctx, hook.ID)
+			wantErr(t, "GetWebhook after token delete", err, storage.ErrNotFound)
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testTTLPolicies(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"SetAndList": func(t *testing.T, s storage.Storage) {
+			at := now()
+			policies, err := s.ListTTLPolicies(ctx)
+			must(t, "ListTTLPolicies", err)
+			if policies == nil || len(policies) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", policies)
+			}
+			if _, err := s.SetTTLPolicy(ctx, &storage.TTLPolicy{ZoneID: -1}); err == nil {
+				t.Error("expected error for a negative zone ID")
+			}
+			if _, err := s.SetTTLPolicy(ctx, &storage.TTLPolicy{ZoneID: 1, MinTTL: -5}); err == nil {
+				t.Error("expected error for a negative TTL")
+			}
+
+			zone, err := s.SetTTLPolicy(ctx, &storage.TTLPolicy{ZoneID: 7, MinTTL: 60, UpdatedBy: "admin", UpdatedAt: at})
+			must(t, "SetTTLPolicy", err)
+			if zone.ZoneID != 7 || zone.MinTTL != 60 || !zone.UpdatedAt.Equal(at) {
+				t.Errorf("unexpected policy: %+v", zone)
+			}
+			_, err = s.SetTTLPolicy(ctx, &storage.TTLPolicy{MinTTL: 30, MaxTTL: 86400, DefaultTTL: 300})
+			must(t, "SetTTLPolicy global", err)
+
+			// Setting a policy again replaces it
+			_, err = s.SetTTLPolicy(ctx, &storage.TTLPolicy{ZoneID: 7, MaxTTL: 3600, UpdatedBy: "ops", UpdatedAt: at})
+			must(t, "SetTTLPolicy again", err)
+
+			policies, err = s.ListTTLPolicies(ctx)
+			must(t, "ListTTLPolicies", err)
+			if len(policies) != 2 || policies[0].ZoneID != 0 || policies[1].ZoneID != 7 {
+				t.Fatalf("expected the global policy first, got %+v", policies)
+			}
+			if g := policies[0]; g.MinTTL != 30 || g.MaxTTL != 86400 || g.DefaultTTL != 300 || g.UpdatedAt.IsZero() {
+				t.Errorf("global policy not preserved: %+v", g)
+			}
+			if z := policies[1]; z.MinTTL != 0 || z.MaxTTL != 3600 || z.UpdatedBy != "ops" {
+				t.Errorf("zone policy not replaced: %+v", z)
+			}
+		},
+		"Delete": func(t *testing.T, s storage.Storage) {
+			_, err := s.SetTTLPolicy(ctx, &storage.TTLPolicy{ZoneID: 7, MinTTL: 60})
+			must(t, "SetTTLPolicy", err)
+
+			must(t, "DeleteTTLPolicy", s.DeleteTTLPolicy(ctx, 7))
+			wantErr(t, "DeleteTTLPolicy again", s.DeleteTTLPolicy(ctx, 7), storage.ErrNotFound)
+			wantErr(t, "DeleteTTLPolicy global", s.DeleteTTLPolicy(ctx, 0), storage.ErrNotFound)
+
+			policies, err := s.ListTTLPolicies(ctx)
+			must(t, "ListTTLPolicies", err)
+			if len(policies) != 0 {
+				t.Errorf("expected no policies, got %+v", policies)
+			}
+		},
+	})
+}
@@ -0,0 +1,227 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testWebhooks(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"CreateAndGet": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			created, err := s.CreateWebhook(ctx, &storage.Webhook{
+				URL:     "https://example.com/hook",
+				Secret:  "secret",
+				Events:  []string{"record.created"},
+				Enabled: true,
+				TokenID: token.ID,
+				ZoneIDs: []int64{10, 20},
+			})
+			must(t, "CreateWebhook", err)
+			if created.ID <= 0 {
+				t.Fatalf("expected positive ID, got %d", created.ID)
+			}
+
+			got, err := s.GetWebhook(ctx, created.ID)
+			must(t, "GetWebhook", err)
+			if got.URL != "https://example.com/hook" || got.Secret != "secret" || !got.Enabled || got.TokenID != token.ID {
+				t.Errorf("unexpected webhook: %+v", got)
+			}
+			if len(got.Events) != 1 || got.Events[0] != "record.created" {
+				t.Errorf("Events not preserved: %v", got.Events)
+			}
+			if len(got.ZoneIDs) != 2 || got.ZoneIDs[0] != 10 || got.ZoneIDs[1] != 20 {
+				t.Errorf("ZoneIDs not preserved: %v", got.ZoneIDs)
+			}
+
+			n, err := s.CountWebhooksByToken(ctx, token.ID)
+			must(t, "CountWebhooksByToken", err)
+			if n != 1 {
+				t.Errorf("CountWebhooksByToken = %d, want 1", n)
+			}
+		},
+		"RejectsInvalid": func(t *testing.T, s storage.Storage) {
+			if _, err := s.CreateWebhook(ctx, &storage.Webhook{Secret: "s"}); err == nil {
+				t.Error("expected error for missing URL")
+			}
+			if _, err := s.CreateWebhook(ctx, &storage.Webhook{URL: "https://example.com"}); err == nil {
+				t.Error("expected error for missing secret")
+			}
+		},
+		"ListAndDelete": func(t *testing.T, s storage.Storage) {
+			hooks, err := s.ListWebhooks(ctx)
+			must(t, "ListWebhooks", err)
+			if hooks == nil || len(hooks) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", hooks)
+			}
+
+			var ids []int64
+			for _, url := range []string{"https://a.example", "https://b.example"} {
+				hook, err := s.CreateWebhook(ctx, &storage.Webhook{URL: url, Secret: "s"})
+				must(t, "CreateWebhook", err)
+				ids = append(ids, hook.ID)
+			}
+			hooks, err = s.ListWebhooks(ctx)
+			must(t, "ListWebhooks", err)
+			if len(hooks) != 2 || hooks[0].ID != ids[0] || hooks[1].ID != ids[1] {
+				t.Fatalf("expected webhooks %v in creation order, got %+v", ids, hooks)
+			}
+
+			_, err = s.RecordWebhookDelivery(ctx, &storage.WebhookDelivery{
+				WebhookID: ids[0], EventID: "e1", Event: "record.created", SchemaVersion: 1,
+			})
+			must(t, "RecordWebhookDelivery", err)
+
+			must(t, "DeleteWebhook", s.DeleteWebhook(ctx, ids[0]))
+			wantErr(t, "DeleteWebhook again", s.DeleteWebhook(ctx, ids[0]), storage.ErrNotFound)
+			_, err = s.GetWebhook(ctx, ids[0])
+			wantErr(t, "GetWebhook", err, storage.ErrNotFound)
+
+			deliveries, err := s.ListWebhookDeliveries(ctx, ids[0], 10)
+			must(t, "ListWebhookDeliveries", err)
+			if len(deliveries) != 0 {
+				t.Errorf("deliveries should be deleted with the webhook, got %d", len(deliveries))
+			}
+		},
+		"Deliveries": func(t *testing.T, s storage.Storage) {
+			hook, err := s.CreateWebhook(ctx, &storage.Webhook{URL: "https://example.com", Secret: "s"})
+			must(t, "CreateWebhook", err)
+
+			deliveries, err := s.ListWebhookDeliveries(ctx, hook.ID, 10)
+			must(t, "ListWebhookDeliveries", err)
+			if deliveries == nil || len(deliveries) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", deliveries)
+			}
+
+			for i, eventID := range []string{"e1", "e2", "e3"} {
+				d, err := s.RecordWebhookDelivery(ctx, &storage.WebhookDelivery{
+					WebhookID:     hook.ID,
+					EventID:       eventID,
+					Event:         "record.created",
+					SchemaVersion: 1,
+					StatusCode:    200,
+					Success:       i != 1,
+					DurationMS:    int64(i),
+				})
+				must(t, "RecordWebhookDelivery", err)
+				if d.ID <= 0 {
+					t.Errorf("expected positive ID, got %d", d.ID)
+				}
+			}
+
+			deliveries, err = s.ListWebhookDeliveries(ctx, hook.ID, 2)
+			must(t, "ListWebhookDeliveries", err)
+			if len(deliveries) != 2 || deliveries[0].EventID != "e3" || deliveries[1].EventID != "e2" {
+				t.Fatalf("expected the two newest deliveries first, got %+v", deliveries)
+			}
+			if deliveries[1].Success || deliveries[1].DurationMS != 1 {
+				t.Errorf("delivery fields not preserved: %+v", deliveries[1])
+			}
+		},
+	})
+}
+
+func testOutbox(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"EnqueueAndList": func(t *testing.T, s storage.Storage) {
+			due, err := s.ListDueOutboxEvents(ctx, time.Now(), 10)
+			must(t, "ListDueOutboxEvents", err)
+			if due == nil || len(due) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", due)
+			}
+
+			e, err := s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{
+				EventID: "e1", Event: "record.created", Payload: []byte(`{"id":1}`),
+			})
+			must(t, "EnqueueOutboxEvent", err)
+			if e.ID <= 0 || e.Status != storage.OutboxStatusPending {
+				t.Errorf("unexpected event: %+v", e)
+			}
+			_, err = s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{EventID: "e1", Event: "record.created"})
+			wantErr(t, "EnqueueOutboxEvent duplicate", err, storage.ErrDuplicate)
+
+			// Scheduled for later, so not yet due
+			_, err = s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{
+				EventID: "e2", Event: "record.deleted", NextAttemptAt: now().Add(time.Hour),
+			})
+			must(t, "EnqueueOutboxEvent", err)
+
+			due, err = s.ListDueOutboxEvents(ctx, now().Add(time.Second), 10)
+			must(t, "ListDueOutboxEvents", err)
+			if len(due) != 1 || due[0].EventID != "e1" || string(due[0].Payload) != `{"id":1}` {
+				t.Fatalf("expected only e1 to be due, got %+v", due)
+			}
+
+			due, err = s.ListDueOutboxEvents(ctx, now().Add(2*time.Hour), 10)
+			must(t, "ListDueOutboxEvents", err)
+			if len(due) != 2 || due[0].EventID != "e1" || due[1].EventID != "e2" {
+				t.Fatalf("expected e1 and e2 oldest first, got %+v", due)
+			}
+		},
+		"RejectsInvalid": func(t *testing.T, s storage.Storage) {
+			if _, err := s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{Event: "x"}); err == nil {
+				t.Error("expected error for missing event ID")
+			}
+			if _, err := s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{EventID: "x"}); err == nil {
+				t.Error("expected error for missing event name")
+			}
+		},
+		"DeliveryLifecycle": func(t *testing.T, s storage.Storage) {
+			e, err := s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{EventID: "e1", Event: "record.created"})
+			must(t, "EnqueueOutboxEvent", err)
+
+			retryAt := now().Add(time.Minute)
+			must(t, "MarkOutboxEventFailed", s.MarkOutboxEventFailed(ctx, e.ID, "timeout", retryAt, false))
+			due, err := s.ListDueOutboxEvents(ctx, retryAt, 10)
+			must(t, "ListDueOutboxEvents", err)
+			if len(due) != 1 || due[0].Attempts != 1 || due[0].LastError != "timeout" || !due[0].NextAttemptAt.Equal(retryAt) {
+				t.Fatalf("expected one retry scheduled at %v, got %+v", retryAt, due)
+			}
+
+			deliveredAt := now()
+			must(t, "MarkOutboxEventDelivered", s.MarkOutboxEventDelivered(ctx, e.ID, deliveredAt))
+			due, err = s.ListDueOutboxEvents(ctx, retryAt, 10)
+			must(t, "ListDueOutboxEvents", err)
+			if len(due) != 0 {
+				t.Errorf("delivered event should not be due, got %+v", due)
+			}
+
+			n, err := s.PruneOutboxEvents(ctx, deliveredAt)
+			must(t, "PruneOutboxEvents", err)
+			if n != 0 {
+				t.Errorf("event delivered at the cutoff should be kept, pruned %d", n)
+			}
+			n, err = s.PruneOutboxEvents(ctx, deliveredAt.Add(time.Second))
+			must(t, "PruneOutboxEvents", err)
+			if n != 1 {
+				t.Errorf("PruneOutboxEvents = %d, want 1", n)
+			}
+
+			wantErr(t, "MarkOutboxEventDelivered", s.MarkOutboxEventDelivered(ctx, 999, time.Now()), storage.ErrNotFound)
+			wantErr(t, "MarkOutboxEventFailed", s.MarkOutboxEventFailed(ctx, 999, "x", time.Now(), false), storage.ErrNotFound)
+		},
+		"DeadEventsAreKept": func(t *testing.T, s storage.Storage) {
+			e, err := s.EnqueueOutboxEvent(ctx, &storage.OutboxEvent{EventID: "e1", Event: "record.created"})
+			must(t, "EnqueueOutboxEvent", err)
+			must(t, "MarkOutboxEventFailed", s.MarkOutboxEventFailed(ctx, e.ID, "gone", now(), true))
+
+			due, err := s.ListDueOutboxEvents(ctx, now().Add(time.Hour), 10)
+			must(t, "ListDueOutboxEvents", err)
+			if len(due) != 0 {
+				t.Errorf("dead event should not be retried, got %+v", due)
+			}
+			n, err := s.PruneOutboxEvents(ctx, now().Add(time.Hour))
+			must(t, "PruneOutboxEvents", err)
+			if n != 0 {
+				t.Errorf("dead events should not be pruned, pruned %d", n)
+			}
+		},
+	})
+}
@@ -0,0 +1,71 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testZoneFreezes(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"AddAndDelete": func(t *testing.T, s storage.Storage) {
+			freezes, err := s.ListZoneFreezes(ctx)
+			must(t, "ListZoneFreezes", err)
+			if freezes == nil || len(freezes) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", freezes)
+			}
+			if _, err := s.AddZoneFreeze(ctx, &storage.ZoneFreeze{Reason: "x"}); err == nil {
+				t.Error("expected error for missing zone ID")
+			}
+
+			a, err := s.AddZoneFreeze(ctx, &storage.ZoneFreeze{ZoneID: 7, Reason: "incident", CreatedBy: "admin"})
+			must(t, "AddZoneFreeze", err)
+			if a.ID <= 0 || a.CreatedAt.IsZero() || !a.StartsAt.Equal(a.CreatedAt) || !a.EndsAt.IsZero() {
+				t.Errorf("unexpected freeze: %+v", a)
+			}
+			at := now()
+			b, err := s.AddZoneFreeze(ctx, &storage.ZoneFreeze{ZoneID: 7, StartsAt: at.Add(time.Hour), EndsAt: at.Add(2 * time.Hour)})
+			must(t, "AddZoneFreeze", err)
+
+			freezes, err = s.ListZoneFreezes(ctx)
+			must(t, "ListZoneFreezes", err)
+			if len(freezes) != 2 || freezes[0].ID != a.ID || freezes[1].ID != b.ID {
+				t.Fatalf("expected freezes in creation order, got %+v", freezes)
+			}
+			if freezes[0].Reason != "incident" || freezes[0].CreatedBy != "admin" || freezes[0].ZoneID != 7 {
+				t.Errorf("freeze fields not preserved: %+v", freezes[0])
+			}
+			if !freezes[1].StartsAt.Equal(at.Add(time.Hour)) || !freezes[1].EndsAt.Equal(at.Add(2*time.Hour)) {
+				t.Errorf("freeze window not preserved: %+v", freezes[1])
+			}
+
+			must(t, "DeleteZoneFreeze", s.DeleteZoneFreeze(ctx, a.ID))
+			wantErr(t, "DeleteZoneFreeze again", s.DeleteZoneFreeze(ctx, a.ID), storage.ErrNotFound)
+		},
+		"PruneKeepsOpenEnded": func(t *testing.T, s storage.Storage) {
+			for _, f := range []*storage.ZoneFreeze{
+				{ZoneID: 1},
+				{ZoneID: 2, StartsAt: now().Add(-2 * time.Hour), EndsAt: now().Add(-time.Hour)},
+				{ZoneID: 3, EndsAt: now().Add(time.Hour)},
+			} {
+				_, err := s.AddZoneFreeze(ctx, f)
+				must(t, "AddZoneFreeze", err)
+			}
+
+			n, err := s.PruneZoneFreezes(ctx, now())
+			must(t, "PruneZoneFreezes", err)
+			if n != 1 {
+				t.Errorf("PruneZoneFreezes = %d, want 1", n)
+			}
+			freezes, err := s.ListZoneFreezes(ctx)
+			must(t, "ListZoneFreezes", err)
+			if len(freezes) != 2 || freezes[0].ZoneID != 1 || freezes[1].ZoneID != 3 {
+				t.Errorf("expected the open-ended and unfinished freezes to remain, got %+v", freezes)
+			}
+		},
+	})
+}
@@ -0,0 +1,122 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+func testZoneTags(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	subtests(t, newStorage, map[string]func(t *testing.T, s storage.Storage){
+		"ReplaceAndList": func(t *testing.T, s storage.Storage) {
+			tags, err := s.ListZoneTags(ctx)
+			must(t, "ListZoneTags", err)
+			if tags == nil || len(tags) != 0 {
+				t.Fatalf("expected empty non-nil slice, got %#v", tags)
+			}
+
+			must(t, "ReplaceZoneTags", s.ReplaceZoneTags(ctx, []storage.ZoneTag{
+				{ZoneID: 2, Domain: "b.example", Tag: "prod"},
+				{ZoneID: 1, Domain: "a.example", Tag: "prod"},
+				{ZoneID: 1, Domain: "a.example", Tag: "prod"},
+				{ZoneID: 1, Domain: "a.example", Tag: "eu"},
+			}))
+			tags, err = s.ListZoneTags(ctx)
+			must(t, "ListZoneTags", err)
+			if len(tags) != 3 || tags[0].Tag != "eu" || tags[1].ZoneID != 1 || tags[2].ZoneID != 2 || tags[2].Domain != "b.example" {
+				t.Fatalf("expected tags ordered by tag then zone, without duplicates, got %+v", tags)
+			}
+
+			must(t, "ReplaceZoneTags", s.ReplaceZoneTags(ctx, []storage.ZoneTag{{ZoneID: 3, Tag: "lab"}}))
+			tags, err = s.ListZoneTags(ctx)
+			must(t, "ListZoneTags", err)
+			if len(tags) != 1 || tags[0].ZoneID != 3 {
+				t.Errorf("expected tags to be replaced, got %+v", tags)
+			}
+		},
+		"TagPermissions": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			other := createToken(t, s, "other")
+
+			if _, err := s.AddTagPermission(ctx, &storage.TagPermission{TokenID: token.ID, AllowedActions: []string{"list_records"}, RecordTypes: []string{"A"}}); err == nil {
+				t.Error("expected error for missing tag")
+			}
+			p, err := s.AddTagPermission(ctx, &storage.TagPermission{
+				TokenID: token.ID, Tag: "prod", AllowedActions: []string{"list_records"}, RecordTypes: []string{"A", "TXT"},
+			})
+			must(t, "AddTagPermission", err)
+			if p.ID <= 0 || p.CreatedAt.IsZero() {
+				t.Errorf("unexpected tag permission: %+v", p)
+			}
+
+			perms, err := s.ListTagPermissions(ctx, token.ID)
+			must(t, "ListTagPermissions", err)
+			if len(perms) != 1 || perms[0].Tag != "prod" || len(perms[0].RecordTypes) != 2 {
+				t.Fatalf("unexpected tag permissions: %+v", perms)
+			}
+			perms, err = s.ListTagPermissions(ctx, other.ID)
+			must(t, "ListTagPermissions", err)
+			if perms == nil || len(perms) != 0 {
+				t.Errorf("expected empty non-nil slice for another token, got %#v", perms)
+			}
+
+			wantErr(t, "DeleteTagPermission of another token", s.DeleteTagPermission(ctx, other.ID, p.ID), storage.ErrNotFound)
+			must(t, "DeleteTagPermission", s.DeleteTagPermission(ctx, token.ID, p.ID))
+			wantErr(t, "DeleteTagPermission again", s.DeleteTagPermission(ctx, token.ID, p.ID), storage.ErrNotFound)
+		},
+		"Sync": func(t *testing.T, s storage.Storage) {
+			token := createToken(t, s, "ci")
+			manual, err := s.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+				ZoneID: 9, AllowedActions: []string{"list_records"}, RecordTypes: []string{"A"},
+			})
+			must(t, "AddPermissionForToken", err)
+			p, err := s.AddTagPermission(ctx, &storage.TagPermission{
+				TokenID: token.ID, Tag: "prod", AllowedActions: []string{"add_record"}, RecordTypes: []string{"TXT"},
+			})
+			must(t, "AddTagPermission", err)
+
+			must(t, "ReplaceZoneTags", s.ReplaceZoneTags(ctx, []storage.ZoneTag{
+				{ZoneID: 1, Tag: "prod"}, {ZoneID: 2, Tag: "prod"}, {ZoneID: 9, Tag: "lab"},
+			}))
+			added, removed, err := s.SyncTagPermissions(ctx)
+			must(t, "SyncTagPermissions", err)
+			if added != 2 || removed != 0 {
+				t.Errorf("SyncTagPermissions = %d added, %d removed; want 2, 0", added, removed)
+			}
+			if added, removed, err = s.SyncTagPermissions(ctx); err != nil || added != 0 || removed != 0 {
+				t.Errorf("repeated SyncTagPermissions = %d, %d, %v; want no changes", added, removed, err)
+			}
+
+			got, err := s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(got) != 3 || got[0].ID != manual.ID || got[0].TagPermissionID != 0 {
+				t.Fatalf("expected the manual permission and two tag permissions, got %+v", got)
+			}
+			for _, perm := range got[1:] {
+				if perm.TagPermissionID != p.ID || perm.Resource != storage.ResourceDNSZone ||
+					len(perm.AllowedActions) != 1 || perm.AllowedActions[0] != "add_record" {
+					t.Errorf("unexpected tag-created permission: %+v", perm)
+				}
+			}
+
+			// Zone 2 loses the tag; zone 3 gains it
+			must(t, "ReplaceZoneTags", s.ReplaceZoneTags(ctx, []storage.ZoneTag{{ZoneID: 1, Tag: "prod"}, {ZoneID: 3, Tag: "prod"}}))
+			added, removed, err = s.SyncTagPermissions(ctx)
+			must(t, "SyncTagPermissions", err)
+			if added != 1 || removed != 1 {
+				t.Errorf("SyncTagPermissions = %d added, %d removed; want 1, 1", added, removed)
+			}
+
+			// Deleting the tag permission removes what it created, and nothing else
+			must(t, "DeleteTagPermission", s.DeleteTagPermission(ctx, token.ID, p.ID))
+			got, err = s.GetPermissionsForToken(ctx, token.ID)
+			must(t, "GetPermissionsForToken", err)
+			if len(got) != 1 || got[0].ID != manual.ID {
+				t.Errorf("expected only the manual permission to remain, got %+v", got)
+			}
+		},
+	})
+}
@@ -0,0 +1,182 @@
+// Package ttlpolicy bounds and defaults the TTLs of records written through
+// the proxy, so automation cannot create records with TTLs so short that they
+// drive up DNS query costs.
+//
+// Policies are stored in the database, so every instance sharing it applies
+// them. A global policy applies to every zone without a policy of its own;
+// a zone's policy replaces the global one rather than adding to it. Each
+// instance keeps an in-memory copy that is reloaded periodically and
+// immediately after changes made through it.
+package ttlpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// refreshInterval is how often policies changed by other instances are picked up.
+const refreshInterval = 30 * time.Second
+
+// ErrInvalidPolicy is returned for a policy whose bounds or default are
+// negative or inconsistent.
+var ErrInvalidPolicy = errors.New("invalid TTL policy")
+
+// Store defines the storage operations needed by the policies.
+type Store interface {
+	SetTTLPolicy(ctx context.Context, p *storage.TTLPolicy) (*storage.TTLPolicy, error)
+	ListTTLPolicies(ctx context.Context) ([]*storage.TTLPolicy, error)
+	DeleteTTLPolicy(ctx context.Context, zoneID int64) error
+}
+
+// Policies applies the stored TTL policies to records.
+type Policies struct {
+	store  Store
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	policies map[int64]*storage.TTLPolicy
+}
+
+// New creates the TTL policies. Call Reload or Run to load the stored policies.
+func New(store Store, logger *slog.Logger) *Policies {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Policies{store: store, logger: logger, now: time.Now}
+}
+
+// Set creates or replaces the policy of p.ZoneID, or the global policy for
+// zone 0. Returns ErrInvalidPolicy if a value is negative, the minimum is
+// above the maximum, or the default is outside the bounds.
+func (p *Policies) Set(ctx context.Context, policy *storage.TTLPolicy) (*storage.TTLPolicy, error) {
+	if err := validate(policy); err != nil {
+		return nil, err
+	}
+
+	updated := *policy
+	updated.UpdatedAt = p.now()
+	stored, err := p.store.SetTTLPolicy(ctx, &updated)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("TTL policy set", "zone_id", stored.ZoneID, "min_ttl", stored.MinTTL, "max_ttl", stored.MaxTTL,
+		"default_ttl", stored.DefaultTTL, "updated_by", stored.UpdatedBy)
+	p.reloadAfterChange(ctx)
+	return stored, nil
+}
+
+// Remove deletes the policy of a zone, or the global policy for zone 0.
+// Returns storage.ErrNotFound if there is none.
+func (p *Policies) Remove(ctx context.Context, zoneID int64) error {
+	if err := p.store.DeleteTTLPolicy(ctx, zoneID); err != nil {
+		return err
+	}
+	p.logger.Info("TTL policy removed", "zone_id", zoneID)
+	p.reloadAfterChange(ctx)
+	return nil
+}
+
+// List returns the stored policies, the global one first.
+func (p *Policies) List(ctx context.Context) ([]*storage.TTLPolicy, error) {
+	return p.store.ListTTLPolicies(ctx)
+}
+
+// Reload replaces the in-memory policies with the stored ones.
+func (p *Policies) Reload(ctx context.Context) error {
+	policies, err := p.store.ListTTLPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load TTL policies: %w", err)
+	}
+
+	byZone := make(map[int64]*storage.TTLPolicy, len(policies))
+	for _, policy := range policies {
+		byZone[policy.ZoneID] = policy
+	}
+	p.mu.Lock()
+	p.policies = byZone
+	p.mu.Unlock()
+	return nil
+}
+
+// reloadAfterChange applies a local change right away rather than at the next refresh.
+func (p *Policies) reloadAfterChange(ctx context.Context) {
+	if err := p.Reload(ctx); err != nil {
+		p.logger.Error("failed to reload TTL policies", "error", err)
+	}
+}
+
+// Run reloads the policies until ctx is cancelled.
+func (p *Policies) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Reload(ctx); err != nil {
+				p.logger.Error("failed to reload TTL policies", "error", err)
+			}
+		}
+	}
+}
+
+// For returns the policy in effect for a zone: its own, else the global
+// policy, else nil.
+func (p *Policies) For(zoneID int64) *storage.TTLPolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if policy, ok := p.policies[zoneID]; ok {
+		return policy
+	}
+	return p.policies[0]
+}
+
+// TTL returns the TTL to write for a record in a zone, given the TTL the
+// client sent: the policy's default when ttl is 0, and otherwise ttl clamped
+// to the policy's bounds.
+func (p *Policies) TTL(zoneID int64, ttl int32) int32 {
+	policy := p.For(zoneID)
+	if policy == nil {
+		return ttl
+	}
+	if ttl == 0 {
+		return policy.DefaultTTL
+	}
+	if policy.MinTTL > 0 && ttl < policy.MinTTL {
+		return policy.MinTTL
+	}
+	if policy.MaxTTL > 0 && ttl > policy.MaxTTL {
+		return policy.MaxTTL
+	}
+	return ttl
+}
+
+// validate checks that a policy's values are consistent.
+func validate(policy *storage.TTLPolicy) error {
+	if policy.ZoneID < 0 {
+		return fmt.Errorf("%w: invalid zone ID %d", ErrInvalidPolicy, policy.ZoneID)
+	}
+	if policy.MinTTL < 0 || policy.MaxTTL < 0 || policy.DefaultTTL < 0 {
+		return fmt.Errorf("%w: TTLs must not be negative", ErrInvalidPolicy)
+	}
+	if policy.MinTTL == 0 && policy.MaxTTL == 0 && policy.DefaultTTL == 0 {
+		return fmt.Errorf("%w: set at least one of the minimum, maximum and default TTL", ErrInvalidPolicy)
+	}
+	if policy.MaxTTL > 0 && policy.MinTTL > policy.MaxTTL {
+		return fmt.Errorf("%w: the minimum TTL %d is above the maximum %d", ErrInvalidPolicy, policy.MinTTL, policy.MaxTTL)
+	}
+	if policy.DefaultTTL > 0 && (policy.DefaultTTL < policy.MinTTL || (policy.MaxTTL > 0 && policy.DefaultTTL > policy.MaxTTL)) {
+		return fmt.Errorf("%w: the default TTL %d is outside the bounds", ErrInvalidPolicy, policy.DefaultTTL)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package ttlpolicy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu       sync.Mutex
+	policies map[int64]storage.TTLPolicy
+}
+
+func (m *memStore) SetTTLPolicy(_ context.Context, p *storage.TTLPolicy) (*storage.TTLPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policies[p.ZoneID] = *p
+	stored := *p
+	return &stored, nil
+}
+
+func (m *memStore) ListTTLPolicies(context.Context) ([]*storage.TTLPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	policies := make([]*storage.TTLPolicy, 0, len(m.policies))
+	for _, p := range m.policies {
+		policy := p
+		policies = append(policies, &policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].ZoneID < policies[j].ZoneID })
+	return policies, nil
+}
+
+func (m *memStore) DeleteTTLPolicy(_ context.Context, zoneID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.policies[zoneID]; !ok {
+		return storage.ErrNotFound
+	}
+	delete(m.policies, zoneID)
+	return nil
+}
+
+func newTestPolicies() (*Policies, *memStore) {
+	store := &memStore{policies: make(map[int64]storage.TTLPolicy)}
+	return New(store, slog.New(slog.NewTextHandler(io.Discard, nil))), store
+}
+
+func TestPolicies_TTL(t *testing.T) {
+	t.Parallel()
+	p, _ := newTestPolicies()
+	ctx := context.Background()
+
+	if got := p.TTL(1, 30); got != 30 {
+		t.Errorf("TTL without a policy = %d, want 30", got)
+	}
+
+	if _, err := p.Set(ctx, &storage.TTLPolicy{MinTTL: 60, MaxTTL: 86400, DefaultTTL: 300}); err != nil {
+		t.Fatalf("Set global failed: %v", err)
+	}
+	if _, err := p.Set(ctx, &storage.TTLPolicy{ZoneID: 2, MaxTTL: 600}); err != nil {
+		t.Fatalf("Set zone failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		zoneID    int64
+		ttl, want int32
+	}{
+		{1, 0, 300},
+		{1, 30, 60},
+		{1, 3600, 3600},
+		{1, 100000, 86400},
+		// The zone's policy replaces the global one
+		{2, 30, 30},
+		{2, 3600, 600},
+		{2, 0, 0},
+	} {
+		if got := p.TTL(tc.zoneID, tc.ttl); got != tc.want {
+			t.Errorf("TTL(%d, %d) = %d, want %d", tc.zoneID, tc.ttl, got, tc.want)
+		}
+	}
+
+	if err := p.Remove(ctx, 2); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if got := p.TTL(2, 3600); got != 3600 {
+		t.Errorf("TTL after removing the zone policy = %d, want the global policy's 3600", got)
+	}
+	if err := p.Remove(ctx, 2); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Remove again error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPolicies_SetValidates(t *testing.T) {
+	t.Parallel()
+	p, store := newTestPolicies()
+
+	for name, policy := range map[string]*storage.TTLPolicy{
+		"negative zone":     {ZoneID: -1, MinTTL: 60},
+		"negative TTL":      {MinTTL: -1},
+		"empty":             {ZoneID: 1},
+		"min above max":     {MinTTL: 600, MaxTTL: 60},
+		"default below min": {MinTTL: 60, DefaultTTL: 30},
+		"default above max": {MaxTTL: 600, DefaultTTL: 3600},
+	} {
+		if _, err := p.Set(context.Background(), policy); !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("%s: error = %v, want ErrInvalidPolicy", name, err)
+		}
+	}
+	if len(store.policies) != 0 {
+		t.Errorf("invalid policies were stored: %+v", store.policies)
+	}
+}
+
+func TestPolicies_ReloadPicksUpStoredPolicies(t *testing.T) {
+	t.Parallel()
+	p, store := newTestPolicies()
+
+	// Set by another instance
+	store.policies[5] = storage.TTLPolicy{ZoneID: 5, MinTTL: 120}
+	if got := p.TTL(5, 30); got != 30 {
+		t.Errorf("TTL before reload = %d, want 30", got)
+	}
+	if err := p.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got := p.TTL(5, 30); got != 120 {
+		t.Errorf("TTL after reload = %d, want 120", got)
+	}
+}
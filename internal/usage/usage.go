@@ -0,0 +1,166 @@
+// Package usage tracks when tokens were last used and how often.
+//
+// Recording a use only updates an in-memory counter, so authenticating a
+// request never writes to the database. Run flushes the counters to storage in
+// one batch every flush interval, and once more when it stops, so the stored
+// LastUsedAt and UseCount lag behind by at most that interval.
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// flushInterval is how often pending uses are written to storage.
+	flushInterval = 30 * time.Second
+
+	// flushTimeout bounds the final flush when the tracker stops.
+	flushTimeout = 5 * time.Second
+)
+
+// Store defines the storage operations needed by the tracker.
+type Store interface {
+	RecordTokenUsage(ctx context.Context, usage []storage.TokenUsage) error
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithWriteErrorHandler calls fn with every error from flushing uses to
+// storage, so storage failures can be detected.
+func WithWriteErrorHandler(fn func(error)) Option {
+	return func(t *Tracker) {
+		t.onWriteError = fn
+	}
+}
+
+// Tracker counts token uses in memory and periodically writes them to storage.
+type Tracker struct {
+	store        Store
+	logger       *slog.Logger
+	now          func() time.Time
+	onWriteError func(error)
+
+	mu      sync.Mutex
+	pending map[int64]*storage.TokenUsage
+}
+
+// New creates a usage tracker.
+func New(store Store, logger *slog.Logger, opts ...Option) *Tracker {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	t := &Tracker{
+		store:   store,
+		logger:  logger,
+		now:     time.Now,
+		pending: make(map[int64]*storage.TokenUsage),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Record counts one use of a token.
+func (t *Tracker) Record(tokenID int64) {
+	at := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.pending[tokenID]
+	if u == nil {
+		u = &storage.TokenUsage{TokenID: tokenID}
+		t.pending[tokenID] = u
+	}
+	u.Count++
+	if at.After(u.LastUsedAt) {
+		u.LastUsedAt = at
+	}
+}
+
+// Middleware records a use of the authenticated token for every request that
+// reaches it. It must run after authentication; the master key is not a token
+// and is not tracked.
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := auth.TokenFromContext(r.Context()); token != nil {
+			t.Record(token.ID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Flush writes pending uses to storage. On failure the uses are kept and
+// retried on the next flush.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.pending) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	batch := make([]storage.TokenUsage, 0, len(t.pending))
+	for _, u := range t.pending {
+		batch = append(batch, *u)
+	}
+	t.pending = make(map[int64]*storage.TokenUsage)
+	t.mu.Unlock()
+
+	if err := t.store.RecordTokenUsage(ctx, batch); err != nil {
+		t.requeue(batch)
+		if t.onWriteError != nil {
+			t.onWriteError(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// requeue merges a batch that failed to flush back into the pending uses.
+func (t *Tracker) requeue(batch []storage.TokenUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, b := range batch {
+		u := t.pending[b.TokenID]
+		if u == nil {
+			u = &storage.TokenUsage{TokenID: b.TokenID}
+			t.pending[b.TokenID] = u
+		}
+		u.Count += b.Count
+		if b.LastUsedAt.After(u.LastUsedAt) {
+			u.LastUsedAt = b.LastUsedAt
+		}
+	}
+}
+
+// Run flushes pending uses every flush interval until ctx is cancelled, then
+// flushes once more so uses recorded before shutdown are not lost.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), flushTimeout)
+			defer cancel()
+			if err := t.Flush(flushCtx); err != nil {
+				t.logger.Error("failed to flush token usage on shutdown", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil && ctx.Err() == nil {
+				t.logger.Error("failed to flush token usage", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeStore collects flushed usage batches.
+type fakeStore struct {
+	mu      sync.Mutex
+	batches [][]storage.TokenUsage
+	err     error
+}
+
+func (f *fakeStore) RecordTokenUsage(_ context.Context, usage []storage.TokenUsage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, usage)
+	return nil
+}
+
+// totals sums all flushed batches by token ID.
+func (f *fakeStore) totals() map[int64]storage.TokenUsage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	totals := make(map[int64]storage.TokenUsage)
+	for _, batch := range f.batches {
+		for _, u := range batch {
+			total := totals[u.TokenID]
+			total.TokenID = u.TokenID
+			total.Count += u.Count
+			if u.LastUsedAt.After(total.LastUsedAt) {
+				total.LastUsedAt = u.LastUsedAt
+			}
+			totals[u.TokenID] = total
+		}
+	}
+	return totals
+}
+
+func TestRecordAndFlush(t *testing.T) {
+	t.Parallel()
+	store := &fakeStore{}
+	tracker := New(store, slog.Default())
+
+	base := time.Unix(1700000000, 0)
+	clock := base
+	tracker.now = func() time.Time { return clock }
+
+	tracker.Record(1)
+	clock = base.Add(time.Minute)
+	tracker.Record(1)
+	tracker.Record(2)
+
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(store.batches) != 1 || len(store.batches[0]) != 2 {
+		t.Fatalf("expected one batch with two tokens, got %+v", store.batches)
+	}
+	totals := store.totals()
+	if totals[1].Count != 2 || !totals[1].LastUsedAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("unexpected usage for token 1: %+v", totals[1])
+	}
+	if totals[2].Count != 1 {
+		t.Errorf("unexpected usage for token 2: %+v", totals[2])
+	}
+
+	// Nothing pending means nothing written
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if len(store.batches) != 1 {
+		t.Errorf("empty flush should not write, got %d batches", len(store.batches))
+	}
+}
+
+func TestFlushFailureKeepsUses(t *testing.T) {
+	t.Parallel()
+	store := &fakeStore{err: errors.New("database is locked")}
+	var reported []error
+	tracker := New(store, nil, WithWriteErrorHandler(func(err error) { reported = append(reported, err) }))
+
+	tracker.Record(1)
+	if err := tracker.Flush(context.Background()); err == nil {
+		t.Fatal("expected flush error")
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected the flush error to be reported once, got %d", len(reported))
+	}
+
+	tracker.Record(1)
+	store.err = nil
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := store.totals()[1].Count; got != 2 {
+		t.Errorf("expected failed uses to be retried, got count %d", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+	store := &fakeStore{}
+	tracker := New(store, nil)
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	// Token request is counted
+	req := httptest.NewRequest(http.MethodGet, "/dnszone", nil)
+	req = req.WithContext(auth.WithToken(req.Context(), &storage.Token{ID: 7}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+
+	// Requests without a token are passed through uncounted
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/dnszone", nil))
+
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	totals := store.totals()
+	if len(totals) != 1 || totals[7].Count != 1 {
+		t.Errorf("expected one use of token 7, got %+v", totals)
+	}
+}
+
+func TestRunFlushesOnShutdown(t *testing.T) {
+	t.Parallel()
+	store := &fakeStore{}
+	tracker := New(store, nil)
+	tracker.Record(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracker.Run(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if got := store.totals()[3].Count; got != 1 {
+		t.Errorf("expected shutdown flush to write the pending use, got count %d", got)
+	}
+}
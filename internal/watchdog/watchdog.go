@@ -0,0 +1,192 @@
+// Package watchdog samples process resources (goroutines, open file
+// descriptors and database connections) and alerts when one of them stays
+// above its threshold while still growing, which points to a leak rather than
+// a burst of load.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+const (
+	// interval is how often resources are sampled.
+	interval = time.Minute
+
+	// window is the number of consecutive samples a resource must stay above
+	// its threshold, with net growth across them, before it is reported.
+	window = 5
+
+	// alertResourceLeak is the alert name for sustained resource growth.
+	alertResourceLeak = "resource_leak"
+)
+
+// Resource names, used in logs and alerts.
+const (
+	ResourceGoroutines    = "goroutines"
+	ResourceOpenFDs       = "open_fds"
+	ResourceDBConnections = "db_connections"
+)
+
+// Store defines the storage operations needed by the watchdog.
+type Store interface {
+	OpenConnections() int
+}
+
+// Alerter delivers operational alerts. It is satisfied by *outbox.Outbox.
+type Alerter interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// Thresholds are the per-resource levels above which sustained growth is
+// reported. A zero threshold disables the check for that resource.
+type Thresholds struct {
+	Goroutines    int
+	OpenFDs       int
+	DBConnections int
+}
+
+// Option configures a Watchdog.
+type Option func(*Watchdog)
+
+// WithAlerter publishes a system alert when a leak is suspected.
+func WithAlerter(alerter Alerter) Option {
+	return func(w *Watchdog) {
+		w.alerter = alerter
+	}
+}
+
+// resource tracks recent samples for one resource.
+type resource struct {
+	name      string
+	threshold int
+	sample    func() (int, error)
+
+	samples  []int
+	alerting bool
+}
+
+// Watchdog samples resources and reports sustained growth.
+type Watchdog struct {
+	logger    *slog.Logger
+	alerter   Alerter
+	resources []*resource
+}
+
+// New creates a watchdog for the given thresholds.
+func New(store Store, thresholds Thresholds, logger *slog.Logger, opts ...Option) *Watchdog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	w := &Watchdog{logger: logger}
+	w.resources = []*resource{
+		{name: ResourceGoroutines, threshold: thresholds.Goroutines, sample: func() (int, error) {
+			return runtime.NumGoroutine(), nil
+		}},
+		{name: ResourceOpenFDs, threshold: thresholds.OpenFDs, sample: countOpenFDs},
+		{name: ResourceDBConnections, threshold: thresholds.DBConnections, sample: func() (int, error) {
+			return store.OpenConnections(), nil
+		}},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run samples resources until ctx is cancelled.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check takes one sample of every enabled resource and reports leaks.
+func (w *Watchdog) check(ctx context.Context) {
+	for _, r := range w.resources {
+		if r.threshold <= 0 {
+			continue
+		}
+		v, err := r.sample()
+		if err != nil {
+			w.logger.Debug("failed to sample resource", "resource", r.name, "error", err)
+			continue
+		}
+		if r.observe(v) {
+			w.report(ctx, r, v)
+		}
+	}
+}
+
+// observe records a sample and reports whether the resource just started
+// leaking: every sample in the window is above the threshold and the latest
+// is higher than the first. The alert re-arms once the value drops below
+// the threshold.
+func (r *resource) observe(v int) bool {
+	r.samples = append(r.samples, v)
+	if len(r.samples) > window {
+		r.samples = r.samples[1:]
+	}
+
+	if v < r.threshold {
+		r.alerting = false
+		return false
+	}
+	if r.alerting || len(r.samples) < window {
+		return false
+	}
+	for _, s := range r.samples {
+		if s < r.threshold {
+			return false
+		}
+	}
+	if r.samples[len(r.samples)-1] <= r.samples[0] {
+		return false
+	}
+	r.alerting = true
+	return true
+}
+
+// report logs and publishes a suspected leak.
+func (w *Watchdog) report(ctx context.Context, r *resource, v int) {
+	w.logger.Warn("sustained resource growth, possible leak",
+		"resource", r.name, "value", v, "threshold", r.threshold, "samples", r.samples)
+	if w.alerter == nil {
+		return
+	}
+	err := w.alerter.Publish(ctx, webhook.EventSystemAlert, map[string]any{
+		"alert":     alertResourceLeak,
+		"message":   fmt.Sprintf("%s grew above %d for %d consecutive samples", r.name, r.threshold, window),
+		"resource":  r.name,
+		"value":     v,
+		"threshold": r.threshold,
+	})
+	if err != nil {
+		w.logger.Error("failed to publish resource leak alert", "error", err)
+	}
+}
+
+// countOpenFDs returns the number of file descriptors open by this process.
+// It is only supported on systems with /proc.
+func countOpenFDs() (int, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, fmt.Errorf("failed to count open file descriptors: %w", err)
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,142 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type recordingAlerter struct {
+	events []map[string]any
+}
+
+func (a *recordingAlerter) Publish(_ context.Context, event string, data any) error {
+	if event != webhook.EventSystemAlert {
+		return errors.New("unexpected event " + event)
+	}
+	a.events = append(a.events, data.(map[string]any))
+	return nil
+}
+
+func TestResourceObserve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		samples []int
+		want    bool
+	}{
+		{"below threshold", []int{1, 2, 3, 4, 5}, false},
+		{"not enough samples", []int{10, 11, 12, 13}, false},
+		{"sustained growth", []int{10, 11, 11, 12, 13}, true},
+		{"above but flat", []int{12, 12, 12, 12, 12}, false},
+		{"above but shrinking", []int{15, 14, 13, 12, 11}, false},
+		{"dip below in window", []int{10, 11, 9, 12, 13}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &resource{name: "test", threshold: 10}
+			var got bool
+			for _, v := range tt.samples {
+				got = r.observe(v)
+			}
+			if got != tt.want {
+				t.Errorf("observe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_AlertsOncePerLeak(t *testing.T) {
+	t.Parallel()
+
+	conns := 0
+	store := &mockstore.MockStorage{OpenConnectionsFunc: func() int { return conns }}
+	alerter := &recordingAlerter{}
+	w := New(store, Thresholds{DBConnections: 3}, testLogger(), WithAlerter(alerter))
+	ctx := context.Background()
+
+	for _, v := range []int{3, 4, 5, 6, 7, 8, 9} {
+		conns = v
+		w.check(ctx)
+	}
+	if len(alerter.events) != 1 {
+		t.Fatalf("expected a single alert while leaking, got %d", len(alerter.events))
+	}
+	if e := alerter.events[0]; e["alert"] != alertResourceLeak || e["resource"] != ResourceDBConnections || e["value"] != 7 || e["threshold"] != 3 {
+		t.Errorf("unexpected alert data: %v", e)
+	}
+
+	// Dropping below the threshold re-arms the alert
+	conns = 1
+	w.check(ctx)
+	for _, v := range []int{3, 4, 5, 6, 7} {
+		conns = v
+		w.check(ctx)
+	}
+	if len(alerter.events) != 2 {
+		t.Errorf("expected alert to fire again after re-arming, got %d", len(alerter.events))
+	}
+}
+
+func TestCheck_DisabledAndFailingSamplers(t *testing.T) {
+	t.Parallel()
+
+	alerter := &recordingAlerter{}
+	w := New(&mockstore.MockStorage{}, Thresholds{Goroutines: 1}, testLogger(), WithAlerter(alerter))
+	calls := 0
+	for _, r := range w.resources {
+		switch r.name {
+		case ResourceGoroutines:
+			r.sample = func() (int, error) {
+				calls++
+				return 0, errors.New("unsupported")
+			}
+		default:
+			r.sample = func() (int, error) {
+				t.Errorf("disabled resource %s should not be sampled", r.name)
+				return 0, nil
+			}
+		}
+	}
+
+	for range window + 1 {
+		w.check(context.Background())
+	}
+	if calls != window+1 {
+		t.Errorf("expected goroutines to be sampled every check, got %d", calls)
+	}
+	if len(alerter.events) != 0 {
+		t.Errorf("expected no alerts, got %v", alerter.events)
+	}
+}
+
+func TestRun_StopsOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		New(&mockstore.MockStorage{}, Thresholds{Goroutines: 1000, OpenFDs: 1000}, testLogger()).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
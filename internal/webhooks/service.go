@@ -0,0 +1,405 @@
+// Package webhooks manages webhook registrations and signed event delivery.
+//
+// Payloads use the versioned envelope and signature scheme defined in
+// pkg/webhook, so receivers can verify deliveries with the same code.
+// Every delivery attempt is recorded in storage for troubleshooting.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/outbox"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+// deliveryTimeout bounds a single delivery attempt.
+const deliveryTimeout = 10 * time.Second
+
+// maxRecordedResponseBody is how much of a failed response body is kept in delivery history.
+const maxRecordedResponseBody = 512
+
+// DefaultMaxWebhooksPerToken is the default number of webhooks a scoped token may own.
+const DefaultMaxWebhooksPerToken = 5
+
+var (
+	// ErrInvalidURL is returned when a webhook URL is not an absolute http(s) URL.
+	ErrInvalidURL = errors.New("webhook URL must be an absolute http or https URL")
+
+	// ErrZonesRequired is returned when a scoped token registers a webhook without zones.
+	ErrZonesRequired = errors.New("zone_ids is required for tokens without all-zones access")
+
+	// ErrZoneNotPermitted is returned when a scoped token subscribes to a zone it has no permission for.
+	ErrZoneNotPermitted = errors.New("token has no permission for zone")
+
+	// ErrQuotaExceeded is returned when a scoped token already owns the maximum number of webhooks.
+	ErrQuotaExceeded = errors.New("webhook quota exceeded for token")
+)
+
+// Store defines the storage operations needed by the webhook service.
+type Store interface {
+	CreateWebhook(ctx context.Context, hook *storage.Webhook) (*storage.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (*storage.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*storage.Webhook, error)
+	CountWebhooksByToken(ctx context.Context, tokenID int64) (int, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	RecordWebhookDelivery(ctx context.Context, d *storage.WebhookDelivery) (*storage.WebhookDelivery, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID int64, limit int) ([]*storage.WebhookDelivery, error)
+	GetPermissionsForToken(ctx context.Context, tokenID int64) ([]*storage.Permission, error)
+}
+
+// Service registers webhooks and delivers signed payloads to them.
+type Service struct {
+	store      Store
+	httpClient *http.Client
+	logger     *slog.Logger
+	now        func() time.Time
+
+	// maxPerToken limits webhooks owned by a scoped token; 0 disables them.
+	maxPerToken int
+}
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithHTTPClient sets the HTTP client used for deliveries.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Service) {
+		s.httpClient = client
+	}
+}
+
+// WithMaxWebhooksPerToken sets how many webhooks a scoped token may own.
+// Zero prevents scoped tokens from registering webhooks.
+func WithMaxWebhooksPerToken(n int) Option {
+	return func(s *Service) {
+		s.maxPerToken = n
+	}
+}
+
+// NewService creates a webhook service.
+// Redirects are not followed so a receiver cannot bounce deliveries elsewhere.
+func NewService(store Store, logger *slog.Logger, opts ...Option) *Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	s := &Service{
+		store: store,
+		httpClient: &http.Client{
+			Timeout: deliveryTimeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		logger:      logger,
+		now:         time.Now,
+		maxPerToken: DefaultMaxWebhooksPerToken,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create validates and registers a new webhook.
+// A random secret is generated when hook.Secret is empty.
+// Webhooks owned by a scoped token (hook.TokenID != 0) are limited to zones
+// the token has permissions for and count against the per-token quota.
+func (s *Service) Create(ctx context.Context, hook *storage.Webhook) (*storage.Webhook, error) {
+	if err := validateURL(hook.URL); err != nil {
+		return nil, err
+	}
+
+	if hook.TokenID != 0 {
+		if err := s.authorizeOwner(ctx, hook); err != nil {
+			return nil, err
+		}
+	}
+
+	if hook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		hook.Secret = secret
+	}
+
+	return s.store.CreateWebhook(ctx, hook)
+}
+
+// Get returns a webhook by ID.
+func (s *Service) Get(ctx context.Context, id int64) (*storage.Webhook, error) {
+	return s.store.GetWebhook(ctx, id)
+}
+
+// List returns all registered webhooks.
+func (s *Service) List(ctx context.Context) ([]*storage.Webhook, error) {
+	return s.store.ListWebhooks(ctx)
+}
+
+// Delete removes a webhook and its delivery history.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	return s.store.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns recent delivery attempts for a webhook, newest first.
+func (s *Service) Deliveries(ctx context.Context, id int64, limit int) ([]*storage.WebhookDelivery, error) {
+	return s.store.ListWebhookDeliveries(ctx, id, limit)
+}
+
+// SendTest delivers a webhook.test event to the webhook with the given ID,
+// regardless of its event subscriptions or enabled state.
+func (s *Service) SendTest(ctx context.Context, id int64) (*storage.WebhookDelivery, error) {
+	hook, err := s.store.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Deliver(ctx, hook, webhook.EventTest, map[string]any{
+		"webhook_id": hook.ID,
+		"message":    "This is a test delivery from bunny-api-proxy.",
+	})
+}
+
+// Deliver sends a single signed event to hook and records the attempt.
+// Receiver failures (non-2xx, timeouts) are recorded on the returned delivery,
+// not returned as errors; an error means the attempt could not be made or recorded.
+func (s *Service) Deliver(ctx context.Context, hook *storage.Webhook, event string, data any) (*storage.WebhookDelivery, error) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	return s.deliver(ctx, hook, webhook.Payload{
+		SchemaVersion: webhook.SchemaVersion,
+		ID:            uuid.NewString(),
+		Event:         event,
+		CreatedAt:     s.now().UTC(),
+		Data:          dataJSON,
+	})
+}
+
+// authorizeOwner enforces the zone scope and quota for a token-owned webhook.
+func (s *Service) authorizeOwner(ctx context.Context, hook *storage.Webhook) error {
+	keyInfo, err := s.keyInfo(ctx, hook.TokenID)
+	if err != nil {
+		return err
+	}
+
+	if len(hook.ZoneIDs) == 0 && !auth.HasAllZonesPermission(keyInfo) {
+		return ErrZonesRequired
+	}
+	for _, zoneID := range hook.ZoneIDs {
+		if !auth.HasZoneAccess(keyInfo, zoneID) {
+			return fmt.Errorf("%w %d", ErrZoneNotPermitted, zoneID)
+		}
+	}
+
+	count, err := s.store.CountWebhooksByToken(ctx, hook.TokenID)
+	if err != nil {
+		return err
+	}
+	if count >= s.maxPerToken {
+		return ErrQuotaExceeded
+	}
+
+	return nil
+}
+
+// keyInfo loads the current permissions of a token.
+func (s *Service) keyInfo(ctx context.Context, tokenID int64) (*auth.KeyInfo, error) {
+	perms, err := s.store.GetPermissionsForToken(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token permissions: %w", err)
+	}
+	return &auth.KeyInfo{KeyID: tokenID, Permissions: perms}, nil
+}
+
+// Send delivers an outbox event to every enabled webhook subscribed to it.
+// The outbox event ID is used as the payload ID, so redeliveries after a
+// failure carry the same X-Webhook-ID and receivers can deduplicate them.
+// Zone-filtered and token-owned webhooks only receive events for their zones;
+// for token-owned webhooks the token's current permissions are re-checked, so
+// revoking a permission stops notifications for that zone.
+// An error is returned if any receiver did not accept the event.
+func (s *Service) Send(ctx context.Context, ev outbox.Event) error {
+	hooks, err := s.store.ListWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	zoneID, hasZone := eventZoneID(ev.Data)
+	owners := make(map[int64]*auth.KeyInfo)
+
+	createdAt := ev.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = s.now()
+	}
+	payload := webhook.Payload{
+		SchemaVersion: webhook.SchemaVersion,
+		ID:            ev.ID,
+		Event:         ev.Name,
+		CreatedAt:     createdAt.UTC(),
+		Data:          ev.Data,
+	}
+
+	var errs []error
+	for _, hook := range hooks {
+		if !hook.Enabled || !subscribed(hook, ev.Name) {
+			continue
+		}
+		if len(hook.ZoneIDs) > 0 || hook.TokenID != 0 {
+			if !hasZone || (len(hook.ZoneIDs) > 0 && !slices.Contains(hook.ZoneIDs, zoneID)) {
+				continue
+			}
+		}
+		if hook.TokenID != 0 {
+			keyInfo, ok := owners[hook.TokenID]
+			if !ok {
+				if keyInfo, err = s.keyInfo(ctx, hook.TokenID); err != nil {
+					errs = append(errs, fmt.Errorf("webhook %d: %w", hook.ID, err))
+					continue
+				}
+				owners[hook.TokenID] = keyInfo
+			}
+			if !auth.HasZoneAccess(keyInfo, zoneID) {
+				continue
+			}
+		}
+		delivery, err := s.deliver(ctx, hook, payload)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", hook.ID, err))
+			continue
+		}
+		if !delivery.Success {
+			errs = append(errs, fmt.Errorf("webhook %d: %s", hook.ID, delivery.Error))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// deliver posts payload to hook and records the attempt.
+func (s *Service) deliver(ctx context.Context, hook *storage.Webhook, payload webhook.Payload) (*storage.WebhookDelivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	delivery := &storage.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventID:       payload.ID,
+		Event:         payload.Event,
+		SchemaVersion: payload.SchemaVersion,
+	}
+
+	start := time.Now()
+	statusCode, deliverErr := s.post(ctx, hook, payload, body, s.now())
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	delivery.StatusCode = statusCode
+	delivery.Success = deliverErr == nil
+	if deliverErr != nil {
+		delivery.Error = deliverErr.Error()
+	}
+
+	if delivery.Success {
+		s.logger.Info("webhook delivered", "webhook_id", hook.ID, "event", payload.Event, "event_id", payload.ID, "status", statusCode)
+	} else {
+		s.logger.Warn("webhook delivery failed", "webhook_id", hook.ID, "event", payload.Event, "event_id", payload.ID,
+			"status", statusCode, "error", delivery.Error)
+	}
+
+	recorded, err := s.store.RecordWebhookDelivery(ctx, delivery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record delivery: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// post performs the HTTP request and returns the response status code.
+// A nil error means the receiver answered with a 2xx status.
+func (s *Service) post(ctx context.Context, hook *storage.Webhook, payload webhook.Payload, body []byte, ts time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bunny-api-proxy-webhooks")
+	req.Header.Set(webhook.HeaderID, payload.ID)
+	req.Header.Set(webhook.HeaderEvent, payload.Event)
+	req.Header.Set(webhook.HeaderSchemaVersion, strconv.Itoa(payload.SchemaVersion))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(hook.Secret, body, ts))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		//nolint:errcheck
+		resp.Body.Close()
+	}()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxRecordedResponseBody)) //nolint:errcheck
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if len(respBody) > 0 {
+			return resp.StatusCode, fmt.Errorf("receiver returned %d: %s", resp.StatusCode, respBody)
+		}
+		return resp.StatusCode, fmt.Errorf("receiver returned %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// subscribed reports whether hook wants event. No subscriptions means all events.
+func subscribed(hook *storage.Webhook, event string) bool {
+	return len(hook.Events) == 0 || slices.Contains(hook.Events, event)
+}
+
+// eventZoneID extracts the zone_id field from event data, if present.
+func eventZoneID(data json.RawMessage) (int64, bool) {
+	var v struct {
+		ZoneID *int64 `json:"zone_id"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil || v.ZoneID == nil {
+		return 0, false
+	}
+	return *v.ZoneID, true
+}
+
+// validateURL checks that raw is an absolute http(s) URL with a host.
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// generateSecret returns a random signing secret with a recognizable prefix.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
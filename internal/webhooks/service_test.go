@@ -0,0 +1,398 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/outbox"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockstore"
+	"github.com/sipico/bunny-api-proxy/pkg/webhook"
+)
+
+func newTestStore(t *testing.T) *storage.SQLiteStorage {
+	t.Helper()
+	s, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+	svc := NewService(newTestStore(t), nil)
+	ctx := context.Background()
+
+	hook, err := svc.Create(ctx, &storage.Webhook{URL: "https://example.com/hook", Enabled: true})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(hook.Secret, "whsec_") || len(hook.Secret) != len("whsec_")+64 {
+		t.Errorf("expected generated secret, got %q", hook.Secret)
+	}
+
+	custom, err := svc.Create(ctx, &storage.Webhook{URL: "http://localhost:9000", Secret: "mine"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if custom.Secret != "mine" {
+		t.Errorf("expected provided secret to be kept, got %q", custom.Secret)
+	}
+
+	for _, raw := range []string{"", "ftp://example.com", "/relative", "https://", "not a url"} {
+		if _, err := svc.Create(ctx, &storage.Webhook{URL: raw}); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Create(%q) error = %v, want ErrInvalidURL", raw, err)
+		}
+	}
+}
+
+func TestSendTest_SignedVersionedPayload(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan *http.Request, 1)
+	var receivedBody []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	svc := NewService(newTestStore(t), nil)
+	ctx := context.Background()
+	hook, err := svc.Create(ctx, &storage.Webhook{URL: ts.URL, Secret: "s3cret", Enabled: true})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	delivery, err := svc.SendTest(ctx, hook.ID)
+	if err != nil {
+		t.Fatalf("SendTest() error = %v", err)
+	}
+	if !delivery.Success || delivery.StatusCode != http.StatusNoContent || delivery.Error != "" {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+
+	r := <-received
+	if err := webhook.Verify("s3cret", receivedBody, r.Header.Get(webhook.HeaderSignature), webhook.DefaultTolerance); err != nil {
+		t.Errorf("signature did not verify: %v", err)
+	}
+	if r.Header.Get(webhook.HeaderSchemaVersion) != "1" {
+		t.Errorf("expected schema version header 1, got %q", r.Header.Get(webhook.HeaderSchemaVersion))
+	}
+	if r.Header.Get(webhook.HeaderEvent) != webhook.EventTest {
+		t.Errorf("expected event header %q, got %q", webhook.EventTest, r.Header.Get(webhook.HeaderEvent))
+	}
+
+	payload, err := webhook.ParsePayload(receivedBody)
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	if payload.ID != delivery.EventID || payload.ID != r.Header.Get(webhook.HeaderID) {
+		t.Errorf("event ID mismatch: payload=%s delivery=%s header=%s", payload.ID, delivery.EventID, r.Header.Get(webhook.HeaderID))
+	}
+
+	history, err := svc.Deliveries(ctx, hook.ID, 10)
+	if err != nil {
+		t.Fatalf("Deliveries() error = %v", err)
+	}
+	if len(history) != 1 || history[0].EventID != payload.ID {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestDeliver_ReceiverFailureIsRecorded(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(strings.Repeat("x", 2*maxRecordedResponseBody)))
+	}))
+	defer ts.Close()
+
+	svc := NewService(newTestStore(t), nil)
+	ctx := context.Background()
+	hook, err := svc.Create(ctx, &storage.Webhook{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	delivery, err := svc.Deliver(ctx, hook, "record.created", map[string]string{"name": "www"})
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if delivery.Success || delivery.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected recorded failure, got %+v", delivery)
+	}
+	if len(delivery.Error) > maxRecordedResponseBody+64 {
+		t.Errorf("expected truncated error, got %d bytes", len(delivery.Error))
+	}
+}
+
+func TestDeliver_DoesNotFollowRedirects(t *testing.T) {
+	t.Parallel()
+
+	var followed bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		followed = true
+	}))
+	defer target.Close()
+	ts := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer ts.Close()
+
+	svc := NewService(newTestStore(t), nil)
+	hook, err := svc.Create(context.Background(), &storage.Webhook{URL: ts.URL})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	delivery, err := svc.Deliver(context.Background(), hook, "record.created", nil)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if followed || delivery.Success || delivery.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("expected unfollowed redirect recorded as failure, got %+v (followed=%v)", delivery, followed)
+	}
+}
+
+func TestDeliver_TransportError(t *testing.T) {
+	t.Parallel()
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	svc := NewService(newTestStore(t), nil, WithHTTPClient(client))
+	hook, err := svc.Create(context.Background(), &storage.Webhook{URL: "http://127.0.0.1:1/unreachable"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	delivery, err := svc.Deliver(context.Background(), hook, "record.created", nil)
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if delivery.Success || delivery.StatusCode != 0 || delivery.Error == "" {
+		t.Errorf("expected transport failure, got %+v", delivery)
+	}
+}
+
+func TestDeliver_RecordError(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer ts.Close()
+
+	store := &mockstore.MockStorage{
+		RecordWebhookDeliveryFunc: func(context.Context, *storage.WebhookDelivery) (*storage.WebhookDelivery, error) {
+			return nil, errors.New("disk full")
+		},
+	}
+	svc := NewService(store, nil)
+
+	_, err := svc.Deliver(context.Background(), &storage.Webhook{ID: 1, URL: ts.URL, Secret: "s"}, "record.created", nil)
+	if err == nil {
+		t.Fatal("expected error when delivery cannot be recorded")
+	}
+}
+
+func TestSendTest_NotFound(t *testing.T) {
+	t.Parallel()
+	svc := NewService(newTestStore(t), nil)
+
+	if _, err := svc.SendTest(context.Background(), 42); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("SendTest() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSend_FansOutToSubscribedWebhooks(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	hits := make(map[string][]string) // path -> X-Webhook-ID values
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits[r.URL.Path] = append(hits[r.URL.Path], r.Header.Get(webhook.HeaderID))
+	}))
+	defer ts.Close()
+
+	svc := NewService(newTestStore(t), nil)
+	ctx := context.Background()
+	for _, hook := range []*storage.Webhook{
+		{URL: ts.URL + "/all", Enabled: true},
+		{URL: ts.URL + "/records", Enabled: true, Events: []string{"record.created"}},
+		{URL: ts.URL + "/zones", Enabled: true, Events: []string{"zone.created"}},
+		{URL: ts.URL + "/disabled", Enabled: false},
+	} {
+		if _, err := svc.Create(ctx, hook); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	ev := outbox.Event{ID: "evt-1", Name: "record.created", Data: json.RawMessage(`{"zone_id":1}`)}
+	if err := svc.Send(ctx, ev); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits["/all"]) != 1 || len(hits["/records"]) != 1 {
+		t.Errorf("expected subscribed webhooks to receive the event, got %v", hits)
+	}
+	if len(hits["/zones"]) != 0 || len(hits["/disabled"]) != 0 {
+		t.Errorf("expected unsubscribed and disabled webhooks to be skipped, got %v", hits)
+	}
+	if hits["/all"][0] != "evt-1" {
+		t.Errorf("expected outbox event ID to be used as webhook ID, got %q", hits["/all"][0])
+	}
+}
+
+func TestSend_ReturnsErrorOnReceiverFailure(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	svc := NewService(newTestStore(t), nil)
+	ctx := context.Background()
+	hook, err := svc.Create(ctx, &storage.Webhook{URL: ts.URL, Enabled: true})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if err := svc.Send(ctx, outbox.Event{ID: "evt-2", Name: "zone.deleted", Data: json.RawMessage("null")}); err == nil {
+		t.Fatal("expected error when receiver fails")
+	}
+
+	history, err := svc.Deliveries(ctx, hook.ID, 10)
+	if err != nil {
+		t.Fatalf("Deliveries() error = %v", err)
+	}
+	if len(history) != 1 || history[0].EventID != "evt-2" || history[0].Success {
+		t.Errorf("expected recorded failed delivery, got %+v", history)
+	}
+}
+
+func TestSend_ListError(t *testing.T) {
+	t.Parallel()
+
+	store := &mockstore.MockStorage{
+		ListWebhooksFunc: func(context.Context) ([]*storage.Webhook, error) {
+			return nil, errors.New("db down")
+		},
+	}
+	if err := NewService(store, nil).Send(context.Background(), outbox.Event{ID: "e", Name: "x"}); err == nil {
+		t.Error("expected error when listing webhooks fails")
+	}
+}
+
+func TestCreate_TokenOwnedScopeAndQuota(t *testing.T) {
+	t.Parallel()
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	token, err := store.CreateToken(ctx, "team", false, "hash-team")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if _, err := store.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+		ZoneID: 10, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+	}); err != nil {
+		t.Fatalf("AddPermissionForToken() error = %v", err)
+	}
+
+	svc := NewService(store, nil, WithMaxWebhooksPerToken(1))
+
+	if _, err := svc.Create(ctx, &storage.Webhook{URL: "https://team.example.com", TokenID: token.ID}); !errors.Is(err, ErrZonesRequired) {
+		t.Errorf("expected ErrZonesRequired, got %v", err)
+	}
+	if _, err := svc.Create(ctx, &storage.Webhook{URL: "https://team.example.com", TokenID: token.ID, ZoneIDs: []int64{10, 11}}); !errors.Is(err, ErrZoneNotPermitted) {
+		t.Errorf("expected ErrZoneNotPermitted, got %v", err)
+	}
+	if _, err := svc.Create(ctx, &storage.Webhook{URL: "https://team.example.com", TokenID: token.ID, ZoneIDs: []int64{10}}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Create(ctx, &storage.Webhook{URL: "https://team.example.com/2", TokenID: token.ID, ZoneIDs: []int64{10}}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Admin-managed webhooks are not subject to the quota
+	for i := 0; i < 3; i++ {
+		if _, err := svc.Create(ctx, &storage.Webhook{URL: "https://ops.example.com"}); err != nil {
+			t.Fatalf("Create() admin webhook error = %v", err)
+		}
+	}
+}
+
+func TestSend_ZoneScopedDelivery(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hits[r.URL.Path]++
+	}))
+	defer ts.Close()
+
+	store := newTestStore(t)
+	ctx := context.Background()
+	token, err := store.CreateToken(ctx, "team", false, "hash-team")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	perm, err := store.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+		ZoneID: 10, AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+	})
+	if err != nil {
+		t.Fatalf("AddPermissionForToken() error = %v", err)
+	}
+
+	svc := NewService(store, nil)
+	for _, hook := range []*storage.Webhook{
+		{URL: ts.URL + "/team", Enabled: true, TokenID: token.ID, ZoneIDs: []int64{10}},
+		{URL: ts.URL + "/filtered", Enabled: true, ZoneIDs: []int64{20}},
+		{URL: ts.URL + "/all", Enabled: true},
+	} {
+		if _, err := svc.Create(ctx, hook); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	send := func(id, data string) {
+		t.Helper()
+		if err := svc.Send(ctx, outbox.Event{ID: id, Name: "record.created", Data: json.RawMessage(data)}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	send("e1", `{"zone_id":10}`)
+	send("e2", `{"zone_id":20}`)
+	send("e3", `{"message":"no zone"}`)
+
+	// Revoking the permission stops delivery to the token's webhook
+	if err := store.RemovePermission(ctx, perm.ID); err != nil {
+		t.Fatalf("RemovePermission() error = %v", err)
+	}
+	send("e4", `{"zone_id":10}`)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := map[string]int{"/team": 1, "/filtered": 1, "/all": 4}
+	for path, n := range want {
+		if hits[path] != n {
+			t.Errorf("%s received %d events, want %d (all hits: %v)", path, hits[path], n, hits)
+		}
+	}
+}
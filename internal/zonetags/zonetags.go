@@ -0,0 +1,415 @@
+// Package zonetags tags upstream DNS zones and keeps tag permissions current.
+//
+// Tags come from naming rules (a glob on the zone's domain, such as
+// "prod=*.prod.example.com") and from an external CSV source fetched over
+// HTTP or read from a file. A tag permission grants a token the same DNS zone
+// permission on every zone with a tag. Run lists the account's zones
+// periodically, replaces the stored zone tags and materializes tag permissions
+// as ordinary per-zone permissions, so zones added upstream are covered
+// without manual changes and authentication needs no tag lookups.
+package zonetags
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+const (
+	// defaultInterval is how often zones are re-tagged unless configured.
+	defaultInterval = 5 * time.Minute
+
+	// zoneListPageSize is the page size used to list all zones.
+	zoneListPageSize = 1000
+
+	// fetchTimeout bounds fetching the external source.
+	fetchTimeout = 30 * time.Second
+
+	// maxSourceSize caps the size of the external source.
+	maxSourceSize = 10 << 20
+)
+
+// ErrNotConfigured is returned by Sync when no rules or source are configured.
+var ErrNotConfigured = errors.New("zone tag sync is not configured")
+
+// tagPattern restricts tag names so they are safe in URLs and logs.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// ValidTag reports whether tag is a valid tag name: lowercase letters,
+// digits, '_', '.' and '-', starting with a letter or digit, at most 64 characters.
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// Rule tags every zone whose domain matches Pattern, a path.Match glob.
+type Rule struct {
+	Tag     string
+	Pattern string
+}
+
+// ParseRules parses "tag=pattern" entries, such as "prod=*.prod.example.com".
+// Tags and patterns are lowercased.
+func ParseRules(entries []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(entries))
+	for _, e := range entries {
+		tag, pattern, ok := strings.Cut(e, "=")
+		tag, pattern = strings.ToLower(strings.TrimSpace(tag)), strings.ToLower(strings.TrimSpace(pattern))
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid zone tag rule %q: want tag=pattern", e)
+		}
+		if !ValidTag(tag) {
+			return nil, fmt.Errorf("invalid zone tag rule %q: invalid tag %q", e, tag)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid zone tag rule %q: %w", e, err)
+		}
+		rules = append(rules, Rule{Tag: tag, Pattern: pattern})
+	}
+	return rules, nil
+}
+
+// Store defines the storage operations needed by the syncer.
+type Store interface {
+	ReplaceZoneTags(ctx context.Context, tags []storage.ZoneTag) error
+	ListZoneTags(ctx context.Context) ([]*storage.ZoneTag, error)
+	AddTagPermission(ctx context.Context, p *storage.TagPermission) (*storage.TagPermission, error)
+	ListTagPermissions(ctx context.Context, tokenID int64) ([]*storage.TagPermission, error)
+	DeleteTagPermission(ctx context.Context, tokenID, id int64) error
+	SyncTagPermissions(ctx context.Context) (added, removed int64, err error)
+}
+
+// ZoneLister lists upstream DNS zones. It is satisfied by *bunny.Client.
+type ZoneLister interface {
+	ListZones(ctx context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error)
+}
+
+// AuthCache caches authenticated tokens and their permissions.
+// It is satisfied by *auth.Authenticator.
+type AuthCache interface {
+	InvalidateCache()
+}
+
+// Option configures a Syncer.
+type Option func(*Syncer)
+
+// WithRules tags zones by domain name.
+func WithRules(rules []Rule) Option {
+	return func(s *Syncer) {
+		s.rules = rules
+	}
+}
+
+// WithSource tags zones from a CSV document at an http(s) URL or file path.
+// Each row is a domain or zone ID followed by one or more tags.
+func WithSource(source string) Option {
+	return func(s *Syncer) {
+		s.source = source
+	}
+}
+
+// WithInterval sets how often zones are re-tagged.
+func WithInterval(d time.Duration) Option {
+	return func(s *Syncer) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithHTTPClient sets the client used to fetch an http(s) source.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *Syncer) {
+		s.httpClient = c
+	}
+}
+
+// Result summarizes one sync.
+type Result struct {
+	Zones              int   `json:"zones"`
+	TaggedZones        int   `json:"tagged_zones"`
+	PermissionsAdded   int64 `json:"permissions_added"`
+	PermissionsRemoved int64 `json:"permissions_removed"`
+}
+
+// Syncer tags zones and keeps tag permissions current.
+type Syncer struct {
+	store      Store
+	zones      ZoneLister
+	logger     *slog.Logger
+	rules      []Rule
+	source     string
+	interval   time.Duration
+	httpClient *http.Client
+	authCache  AuthCache
+
+	// mu serializes syncs and tag permission changes.
+	mu sync.Mutex
+}
+
+// New creates a syncer. Without rules or a source, Run does nothing and
+// tag permissions only follow zone tags already stored.
+func New(store Store, zones ZoneLister, logger *slog.Logger, opts ...Option) *Syncer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	s := &Syncer{
+		store:      store,
+		zones:      zones,
+		logger:     logger,
+		interval:   defaultInterval,
+		httpClient: &http.Client{Timeout: fetchTimeout},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetAuthCache drops the cached tokens and permissions after a sync adds or
+// removes permissions, so newly tagged and untagged zones take effect at once.
+// Must be called before Run.
+func (s *Syncer) SetAuthCache(c AuthCache) {
+	s.authCache = c
+}
+
+// Enabled reports whether any rules or a source are configured.
+func (s *Syncer) Enabled() bool {
+	return len(s.rules) > 0 || s.source != ""
+}
+
+// Run syncs zone tags every interval until ctx is cancelled.
+func (s *Syncer) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("zone tag sync failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync lists all zones, replaces the stored zone tags and updates the
+// permissions created by tag permissions. If the zones or the source cannot
+// be read, the stored tags are left unchanged.
+func (s *Syncer) Sync(ctx context.Context) (*Result, error) {
+	if !s.Enabled() {
+		return nil, ErrNotConfigured
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zones, err := s.listAllZones(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list zones: %w", err)
+	}
+	var sourceTags map[string][]string
+	if s.source != "" {
+		if sourceTags, err = s.loadSource(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load zone tag source: %w", err)
+		}
+	}
+
+	tags := s.tagZones(zones, sourceTags)
+	if err := s.store.ReplaceZoneTags(ctx, tags); err != nil {
+		return nil, err
+	}
+	added, removed, err := s.store.SyncTagPermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.authCache != nil && added+removed > 0 {
+		s.authCache.InvalidateCache()
+	}
+
+	tagged := make(map[int64]bool)
+	for _, t := range tags {
+		tagged[t.ZoneID] = true
+	}
+	result := &Result{Zones: len(zones), TaggedZones: len(tagged), PermissionsAdded: added, PermissionsRemoved: removed}
+	s.logger.Info("zone tags synced", "zones", result.Zones, "tagged_zones", result.TaggedZones,
+		"permissions_added", added, "permissions_removed", removed)
+	return result, nil
+}
+
+// listAllZones pages through every zone in the account.
+func (s *Syncer) listAllZones(ctx context.Context) ([]bunny.Zone, error) {
+	var zones []bunny.Zone
+	for page := 1; ; page++ {
+		result, err := s.zones.ListZones(ctx, &bunny.ListZonesOptions{Page: page, PerPage: zoneListPageSize})
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, result.Items...)
+		if !result.HasMoreItems || len(result.Items) == 0 {
+			return zones, nil
+		}
+	}
+}
+
+// tagZones applies the rules and source tags to zones. Source rows are
+// matched by lowercase domain or by zone ID.
+func (s *Syncer) tagZones(zones []bunny.Zone, sourceTags map[string][]string) []storage.ZoneTag {
+	var tags []storage.ZoneTag
+	for _, z := range zones {
+		domain := strings.ToLower(z.Domain)
+		seen := make(map[string]bool)
+		add := func(tag string) {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, storage.ZoneTag{ZoneID: z.ID, Domain: z.Domain, Tag: tag})
+			}
+		}
+		for _, r := range s.rules {
+			if ok, _ := path.Match(r.Pattern, domain); ok { //nolint:errcheck // patterns are validated by ParseRules
+				add(r.Tag)
+			}
+		}
+		for _, tag := range sourceTags[domain] {
+			add(tag)
+		}
+		for _, tag := range sourceTags[strconv.FormatInt(z.ID, 10)] {
+			add(tag)
+		}
+	}
+	return tags
+}
+
+// loadSource reads the external source and returns tags by lowercase
+// domain or zone ID.
+func (s *Syncer) loadSource(ctx context.Context) (map[string][]string, error) {
+	var data io.Reader
+	if strings.HasPrefix(s.source, "http://") || strings.HasPrefix(s.source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close() //nolint:errcheck
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		data = io.LimitReader(resp.Body, maxSourceSize)
+	} else {
+		f, err := os.Open(s.source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close() //nolint:errcheck
+		data = io.LimitReader(f, maxSourceSize)
+	}
+	return parseSource(data, s.logger)
+}
+
+// parseSource parses CSV rows of a domain or zone ID followed by tags.
+// Blank lines, lines starting with '#' and a "zone" or "domain" header are
+// skipped; rows with invalid tags are logged and skipped.
+func parseSource(r io.Reader, logger *slog.Logger) (map[string][]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	tags := make(map[string][]string)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(row[0]), "."))
+		if key == "" || key == "zone" || key == "domain" {
+			continue
+		}
+		for _, tag := range row[1:] {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" {
+				continue
+			}
+			if !ValidTag(tag) {
+				logger.Warn("skipping invalid tag in zone tag source", "zone", key, "tag", tag)
+				continue
+			}
+			tags[key] = append(tags[key], tag)
+		}
+	}
+	return tags, nil
+}
+
+// ListZoneTags returns the stored zone tags grouped by tag, with zone IDs in
+// ascending order.
+func (s *Syncer) ListZoneTags(ctx context.Context) ([]*storage.ZoneTag, error) {
+	return s.store.ListZoneTags(ctx)
+}
+
+// AddTagPermission stores a tag permission and applies it to the zones
+// already tagged.
+func (s *Syncer) AddTagPermission(ctx context.Context, p *storage.TagPermission) (*storage.TagPermission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created, err := s.store.AddTagPermission(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := s.store.SyncTagPermissions(ctx); err != nil {
+		return nil, fmt.Errorf("failed to apply tag permission: %w", err)
+	}
+	return created, nil
+}
+
+// ListTagPermissions returns a token's tag permissions.
+func (s *Syncer) ListTagPermissions(ctx context.Context, tokenID int64) ([]*storage.TagPermission, error) {
+	return s.store.ListTagPermissions(ctx, tokenID)
+}
+
+// DeleteTagPermission deletes a token's tag permission and the permissions
+// it created. Returns storage.ErrNotFound if it doesn't exist.
+func (s *Syncer) DeleteTagPermission(ctx context.Context, tokenID, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.DeleteTagPermission(ctx, tokenID, id)
+}
+
+// TagsByZone groups zone tags by zone ID, with each zone's tags sorted.
+func TagsByZone(tags []*storage.ZoneTag) map[int64][]string {
+	byZone := make(map[int64][]string)
+	for _, t := range tags {
+		byZone[t.ZoneID] = append(byZone[t.ZoneID], t.Tag)
+	}
+	for _, ts := range byZone {
+		sort.Strings(ts)
+	}
+	return byZone
+}
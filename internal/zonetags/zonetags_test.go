@@ -0,0 +1,287 @@
+package zonetags
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+)
+
+// fakeZones serves zones one per page to exercise paging.
+type fakeZones struct {
+	zones []bunny.Zone
+	err   error
+}
+
+func (f *fakeZones) ListZones(_ context.Context, opts *bunny.ListZonesOptions) (*bunny.ListZonesResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	i := opts.Page - 1
+	if i >= len(f.zones) {
+		return &bunny.ListZonesResponse{CurrentPage: opts.Page}, nil
+	}
+	return &bunny.ListZonesResponse{
+		CurrentPage:  opts.Page,
+		TotalItems:   len(f.zones),
+		HasMoreItems: i+1 < len(f.zones),
+		Items:        []bunny.Zone{f.zones[i]},
+	}, nil
+}
+
+func newTestStore(t *testing.T) (*storage.SQLiteStorage, int64) {
+	t.Helper()
+	store, err := storage.New(":memory:")
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	token, err := store.CreateToken(context.Background(), "scoped", false, "hash")
+	if err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+	return store, token.ID
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestParseRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules([]string{"prod=*.prod.example.com", " Staging = *.STAGING.example.com "})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Rule{{Tag: "prod", Pattern: "*.prod.example.com"}, {Tag: "staging", Pattern: "*.staging.example.com"}}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("expected %+v, got %+v", want, rules)
+	}
+
+	for _, entry := range []string{"prod", "prod=", "=*.example.com", "bad tag=*.example.com", "prod=[a"} {
+		if _, err := ParseRules([]string{entry}); err == nil {
+			t.Errorf("expected error for %q", entry)
+		}
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	t.Parallel()
+
+	src := "zone,tags\n# comment\nExample.com.,prod,web\n\n42,staging\nother.com,Bad Tag,ok\n"
+	tags, err := parseSource(strings.NewReader(src), discardLogger())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string][]string{
+		"example.com": {"prod", "web"},
+		"42":          {"staging"},
+		"other.com":   {"ok"},
+	}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected %v, got %v", want, tags)
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store, tokenID := newTestStore(t)
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "tags.csv")
+	if err := os.WriteFile(source, []byte("legacy.com,prod\n3,edge\n"), 0o600); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	zones := &fakeZones{zones: []bunny.Zone{
+		{ID: 1, Domain: "api.prod.example.com"},
+		{ID: 2, Domain: "legacy.com"},
+		{ID: 3, Domain: "dev.example.com"},
+	}}
+	rules, err := ParseRules([]string{"prod=*.prod.example.com"})
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	s := New(store, zones, discardLogger(), WithRules(rules), WithSource(source))
+
+	if _, err := s.AddTagPermission(ctx, &storage.TagPermission{
+		TokenID: tokenID, Tag: "prod", AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+	}); err != nil {
+		t.Fatalf("failed to add tag permission: %v", err)
+	}
+
+	result, err := s.Sync(ctx)
+	if err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	want := &Result{Zones: 3, TaggedZones: 3, PermissionsAdded: 2}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("expected %+v, got %+v", want, result)
+	}
+
+	tags, err := s.ListZoneTags(ctx)
+	if err != nil {
+		t.Fatalf("failed to list zone tags: %v", err)
+	}
+	if got := TagsByZone(tags); !reflect.DeepEqual(got, map[int64][]string{1: {"prod"}, 2: {"prod"}, 3: {"edge"}}) {
+		t.Errorf("unexpected zone tags: %v", got)
+	}
+	if ids := permittedZones(t, store, tokenID); !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("expected permissions on zones [1 2], got %v", ids)
+	}
+
+	// A new upstream zone matching a rule is picked up; a zone that no longer
+	// matches loses its permission.
+	zones.zones = []bunny.Zone{
+		{ID: 1, Domain: "api.prod.example.com"},
+		{ID: 4, Domain: "www.prod.example.com"},
+	}
+	result, err = s.Sync(ctx)
+	if err != nil {
+		t.Fatalf("second sync failed: %v", err)
+	}
+	if result.PermissionsAdded != 1 || result.PermissionsRemoved != 1 {
+		t.Errorf("expected 1 added and 1 removed, got %+v", result)
+	}
+	if ids := permittedZones(t, store, tokenID); !reflect.DeepEqual(ids, []int64{1, 4}) {
+		t.Errorf("expected permissions on zones [1 4], got %v", ids)
+	}
+
+	// Upstream failures leave the stored tags alone.
+	zones.err = errors.New("upstream down")
+	if _, err := s.Sync(ctx); err == nil {
+		t.Fatal("expected sync to fail")
+	}
+	if ids := permittedZones(t, store, tokenID); !reflect.DeepEqual(ids, []int64{1, 4}) {
+		t.Errorf("expected permissions to be kept, got %v", ids)
+	}
+}
+
+func TestSync_HTTPSource(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store, _ := newTestStore(t)
+
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		_, _ = io.WriteString(w, "example.com,prod\n")
+	}))
+	t.Cleanup(server.Close)
+
+	zones := &fakeZones{zones: []bunny.Zone{{ID: 1, Domain: "example.com"}}}
+	s := New(store, zones, discardLogger(), WithSource(server.URL))
+	if _, err := s.Sync(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if tags, _ := store.ListZoneTags(ctx); len(tags) != 1 || tags[0].Tag != "prod" {
+		t.Errorf("unexpected zone tags: %+v", tags)
+	}
+
+	status.Store(http.StatusInternalServerError)
+	if _, err := s.Sync(ctx); err == nil {
+		t.Error("expected sync to fail on a source error")
+	}
+	if tags, _ := store.ListZoneTags(ctx); len(tags) != 1 {
+		t.Errorf("expected zone tags to be kept, got %+v", tags)
+	}
+}
+
+// countingCache counts cache invalidations.
+type countingCache struct {
+	invalidations atomic.Int32
+}
+
+func (c *countingCache) InvalidateCache() {
+	c.invalidations.Add(1)
+}
+
+func TestSync_InvalidatesAuthCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store, tokenID := newTestStore(t)
+
+	zones := &fakeZones{zones: []bunny.Zone{{ID: 1, Domain: "api.prod.example.com"}}}
+	rules, err := ParseRules([]string{"prod=*.prod.example.com"})
+	if err != nil {
+		t.Fatalf("failed to parse rules: %v", err)
+	}
+	s := New(store, zones, discardLogger(), WithRules(rules))
+	cache := &countingCache{}
+	s.SetAuthCache(cache)
+
+	if _, err := s.AddTagPermission(ctx, &storage.TagPermission{
+		TokenID: tokenID, Tag: "prod", AllowedActions: []string{"list_records"}, RecordTypes: []string{"TXT"},
+	}); err != nil {
+		t.Fatalf("failed to add tag permission: %v", err)
+	}
+	// The first sync materializes the permission; an unchanged sync keeps the
+	// cache.
+	for i, want := range []int32{1, 1} {
+		if _, err := s.Sync(ctx); err != nil {
+			t.Fatalf("sync %d failed: %v", i+1, err)
+		}
+		if got := cache.invalidations.Load(); got != want {
+			t.Errorf("sync %d: expected %d invalidations, got %d", i+1, want, got)
+		}
+	}
+
+	// A newly tagged zone gains a permission.
+	zones.zones = append(zones.zones, bunny.Zone{ID: 2, Domain: "www.prod.example.com"})
+	if _, err := s.Sync(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if got := cache.invalidations.Load(); got != 2 {
+		t.Errorf("expected 2 invalidations after a permission was added, got %d", got)
+	}
+
+	// A zone that is no longer tagged loses its permission.
+	zones.zones = zones.zones[:1]
+	if _, err := s.Sync(ctx); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if got := cache.invalidations.Load(); got != 3 {
+		t.Errorf("expected 3 invalidations after a permission was removed, got %d", got)
+	}
+}
+
+func TestSync_NotConfigured(t *testing.T) {
+	t.Parallel()
+	store, _ := newTestStore(t)
+
+	s := New(store, &fakeZones{}, discardLogger())
+	if s.Enabled() {
+		t.Error("expected syncer without rules or source to be disabled")
+	}
+	if _, err := s.Sync(context.Background()); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+}
+
+// permittedZones returns the zone IDs of a token's permissions, in order.
+func permittedZones(t *testing.T, store *storage.SQLiteStorage, tokenID int64) []int64 {
+	t.Helper()
+	perms, err := store.GetPermissionsForToken(context.Background(), tokenID)
+	if err != nil {
+		t.Fatalf("failed to get permissions: %v", err)
+	}
+	var ids []int64
+	for _, p := range perms {
+		ids = append(ids, p.ZoneID)
+	}
+	return ids
+}
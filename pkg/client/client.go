@@ -0,0 +1,220 @@
+// Package client is a Go client for the bunny-api-proxy proxy and admin APIs.
+//
+// A Client authenticates every request with one AccessKey, which may be a
+// scoped token, an admin token or, during bootstrap, the master key:
+//
+//	c, err := client.New("https://dns-proxy.example.com", os.Getenv("PROXY_TOKEN"))
+//	if err != nil {
+//		return err
+//	}
+//	rec, err := c.AddRecord(ctx, zoneID, client.RecordRequest{
+//		Type: client.RecordTypeTXT, Name: "_acme-challenge", Value: token, TTL: 60,
+//	})
+//	if errors.Is(err, client.ErrForbidden) {
+//		// the token may not add TXT records to this zone
+//	}
+//
+// Failed requests return an *APIError, which matches ErrUnauthorized,
+// ErrForbidden, ErrNotFound, ErrConflict and ErrRateLimited with errors.Is.
+// Rate limited and transient gateway failures are retried; see RetryPolicy.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUserAgent is the User-Agent sent when WithUserAgent is not used.
+const DefaultUserAgent = "bunny-api-proxy-client"
+
+// Client calls the proxy and admin APIs of one bunny-api-proxy instance.
+// It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	accessKey  string
+	httpClient *http.Client
+	userAgent  string
+	retry      RetryPolicy
+
+	// replayHeaders adds X-Request-Timestamp and X-Request-Nonce to mutating requests.
+	replayHeaders bool
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests, for example to
+// set a timeout or a client certificate. The default is http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent on every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// WithReplayHeaders sends a fresh X-Request-Timestamp and X-Request-Nonce
+// with every mutating request, as required by proxies running with
+// REPLAY_PROTECTION=required.
+func WithReplayHeaders() Option {
+	return func(c *Client) {
+		c.replayHeaders = true
+	}
+}
+
+// New returns a Client for the proxy at baseURL, such as
+// "https://dns-proxy.example.com", authenticating with accessKey.
+func New(baseURL, accessKey string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q: want an absolute http(s) URL", baseURL)
+	}
+	if accessKey == "" {
+		return nil, fmt.Errorf("client: access key is required")
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		accessKey:  accessKey,
+		httpClient: http.DefaultClient,
+		userAgent:  DefaultUserAgent,
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request describes one API call.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+
+	// idempotent marks proxy requests that are sent with an Idempotency-Key,
+	// so they can be retried after a network error.
+	idempotent bool
+}
+
+// do sends req and decodes a successful JSON response into out, which may be
+// nil. Responses with a status of 400 or above are returned as *APIError.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return fmt.Errorf("client: encoding request: %w", err)
+		}
+	}
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	var idempotencyKey string
+	if req.idempotent {
+		idempotencyKey = randomToken()
+	}
+
+	for attempt := 1; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, target, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("client: %w", err)
+		}
+		c.setHeaders(httpReq, body != nil, idempotencyKey)
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			if delay, ok := c.retry.afterError(ctx, req.method, idempotencyKey != "", attempt); ok {
+				if err := sleepContext(ctx, delay); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("client: %s %s: %w", req.method, req.path, err)
+		}
+
+		if delay, ok := c.retry.afterResponse(resp, attempt); ok {
+			//nolint:errcheck
+			io.Copy(io.Discard, resp.Body)
+			//nolint:errcheck
+			resp.Body.Close()
+			if err := sleepContext(ctx, delay); err != nil {
+				return err
+			}
+			continue
+		}
+		return decodeResponse(resp, out)
+	}
+}
+
+// setHeaders sets the authentication and protocol headers on req.
+func (c *Client) setHeaders(req *http.Request, hasBody bool, idempotencyKey string) {
+	req.Header.Set("AccessKey", c.accessKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if hasBody {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	if c.replayHeaders && isMutating(req.Method) {
+		req.Header.Set("X-Request-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+		req.Header.Set("X-Request-Nonce", randomToken())
+	}
+}
+
+// decodeResponse closes resp and decodes its body into out, or returns the
+// *APIError it describes.
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode >= 400 {
+		return newAPIError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		//nolint:errcheck
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: decoding %s response: %w", resp.Request.URL.Path, err)
+	}
+	return nil
+}
+
+// isMutating reports whether requests with method change state on the proxy.
+func isMutating(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// randomToken returns 16 random bytes in unpadded base64url, for idempotency
+// keys and replay nonces.
+func randomToken() string {
+	b := make([]byte, 16)
+	//nolint:errcheck // crypto/rand.Read never returns an error
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// pathID formats an ID for a URL path.
+func pathID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for a test server running handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL+"/", "test-key", opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		baseURL string
+		key     string
+		wantErr bool
+	}{
+		{"valid", "https://proxy.example.com", "key", false},
+		{"relative URL", "proxy.example.com", "key", true},
+		{"other scheme", "ftp://proxy.example.com", "key", true},
+		{"no key", "https://proxy.example.com", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := New(tt.baseURL, tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Headers(t *testing.T) {
+	t.Parallel()
+	var got http.Header
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"Id": 1}`)) //nolint:errcheck
+	}, WithUserAgent("dns-tool/1.0"), WithReplayHeaders())
+
+	if _, err := c.GetZone(context.Background(), 1); err != nil {
+		t.Fatalf("GetZone() error = %v", err)
+	}
+	if got.Get("AccessKey") != "test-key" || got.Get("User-Agent") != "dns-tool/1.0" {
+		t.Errorf("unexpected headers on GET: %v", got)
+	}
+	if got.Get("X-Request-Nonce") != "" || got.Get("Idempotency-Key") != "" {
+		t.Error("GET requests should not carry replay or idempotency headers")
+	}
+
+	if _, err := c.AddRecord(context.Background(), 1, RecordRequest{Type: RecordTypeTXT, Name: "x", Value: "y"}); err != nil {
+		t.Fatalf("AddRecord() error = %v", err)
+	}
+	if got.Get("X-Request-Timestamp") == "" || len(got.Get("X-Request-Nonce")) < 16 || got.Get("Idempotency-Key") == "" {
+		t.Errorf("expected replay and idempotency headers on POST: %v", got)
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q", got.Get("Content-Type"))
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		want     error
+		wantCode string
+		wantMsg  string
+	}{
+		{"admin error", http.StatusForbidden, `{"error":"admin_required","message":"Admin token required"}`, ErrForbidden, "admin_required", "Admin token required"},
+		{"proxy error", http.StatusNotFound, `{"error":"resource not found"}`, ErrNotFound, "", "resource not found"},
+		{"not JSON", http.StatusUnauthorized, `unauthorized`, ErrUnauthorized, "", ""},
+		{"invalid record", http.StatusBadRequest, `{"error":"invalid record","Fields":[{"Field":"Ttl","Message":"too low"}]}`, ErrInvalid, "", "invalid record"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body)) //nolint:errcheck
+			})
+			err := c.DeleteToken(context.Background(), 7)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got %v", err)
+			}
+			if !errors.Is(err, tt.want) || apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMsg {
+				t.Errorf("got %+v, want %v with code %q and message %q", apiErr, tt.want, tt.wantCode, tt.wantMsg)
+			}
+			if errors.Is(err, ErrConflict) {
+				t.Error("error should only match its own status")
+			}
+		})
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Parallel()
+	var calls atomic.Int32
+	var keys []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"Id": 9, "Type": 3}`)) //nolint:errcheck
+	}, WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}))
+
+	rec, err := c.AddRecord(context.Background(), 1, RecordRequest{Type: RecordTypeTXT})
+	if err != nil || rec.ID != 9 {
+		t.Fatalf("AddRecord() = %+v, %v", rec, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+	if keys[0] == "" || keys[0] != keys[1] || keys[1] != keys[2] {
+		t.Errorf("retries should reuse the Idempotency-Key: %q", keys)
+	}
+}
+
+func TestClient_RetryGivesUp(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		retryAfter string
+		policy     RetryPolicy
+		wantCalls  int32
+	}{
+		{"attempts exhausted", "", RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}, 2},
+		{"retries disabled", "", RetryPolicy{}, 1},
+		{"Retry-After too long", "60", RetryPolicy{MaxAttempts: 3, MaxDelay: time.Second}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var calls atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+			}, WithRetry(tt.policy))
+
+			_, err := c.ListTokens(context.Background())
+			if !errors.Is(err, ErrRateLimited) {
+				t.Errorf("expected ErrRateLimited, got %v", err)
+			}
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("expected %d attempts, got %d", tt.wantCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_AfterError(t *testing.T) {
+	t.Parallel()
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+	ctx := context.Background()
+
+	if _, ok := p.afterError(ctx, http.MethodGet, false, 1); !ok {
+		t.Error("GET should be retried after a network error")
+	}
+	if _, ok := p.afterError(ctx, http.MethodPost, false, 1); ok {
+		t.Error("POST without an Idempotency-Key should not be retried")
+	}
+	if _, ok := p.afterError(ctx, http.MethodPost, true, 1); !ok {
+		t.Error("POST with an Idempotency-Key should be retried")
+	}
+	if _, ok := p.afterError(ctx, http.MethodGet, false, 3); ok {
+		t.Error("the last attempt should not be retried")
+	}
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, ok := p.afterError(canceled, http.MethodGet, false, 1); ok {
+		t.Error("canceled requests should not be retried")
+	}
+}
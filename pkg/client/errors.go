@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Errors matched by *APIError with errors.Is, by response status.
+var (
+	ErrUnauthorized = errors.New("client: unauthorized")  // 401
+	ErrForbidden    = errors.New("client: forbidden")     // 403
+	ErrNotFound     = errors.New("client: not found")     // 404
+	ErrConflict     = errors.New("client: conflict")      // 409
+	ErrRateLimited  = errors.New("client: rate limited")  // 429
+	ErrUnavailable  = errors.New("client: unavailable")   // 503
+	ErrInvalid      = errors.New("client: invalid input") // 400 and 422
+)
+
+// APIError is an error response from the proxy.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+
+	// Code is the machine-readable error code of admin API errors, such as
+	// "admin_required". Proxy API errors have none.
+	Code string
+
+	// Message describes the error.
+	Message string
+
+	// Hint suggests how to resolve the error, when the proxy gives one.
+	Hint string
+
+	// Fields lists the invalid fields of a record rejected by strict record
+	// validation.
+	Fields []FieldError
+}
+
+// FieldError is one problem with a record field.
+type FieldError struct {
+	Field   string `json:"Field"`
+	Message string `json:"Message"`
+}
+
+// Error implements error.
+func (e *APIError) Error() string {
+	msg := "client: " + http.StatusText(e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	return msg
+}
+
+// Is reports whether the error's status matches one of the package's errors.
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusForbidden:
+		return target == ErrForbidden
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	case http.StatusServiceUnavailable:
+		return target == ErrUnavailable
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return target == ErrInvalid
+	}
+	return false
+}
+
+// newAPIError reads an error response. Admin API errors have the form
+// {"error": code, "message": ..., "hint": ...}; proxy API errors have only
+// {"error": message}.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Error   string       `json:"error"`
+		Message string       `json:"message"`
+		Hint    string       `json:"hint"`
+		Fields  []FieldError `json:"Fields"`
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil || json.Unmarshal(data, &body) != nil {
+		return apiErr
+	}
+	if body.Message != "" {
+		apiErr.Code, apiErr.Message = body.Error, body.Message
+	} else {
+		apiErr.Message = body.Error
+	}
+	apiErr.Hint = body.Hint
+	apiErr.Fields = body.Fields
+	return apiErr
+}
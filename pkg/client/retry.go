@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how a Client retries transient failures.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts per request, including the
+	// first. Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each retry
+	// after it. Delays are jittered by up to half.
+	BaseDelay time.Duration
+
+	// MaxDelay caps each delay. A Retry-After header asking for a longer wait
+	// ends the retries instead.
+	MaxDelay time.Duration
+
+	// StatusCodes are the response statuses that are retried.
+	StatusCodes []int
+}
+
+// DefaultRetryPolicy is used when WithRetry is not. It retries rate limiting
+// (429) and transient gateway failures (502, 503, 504) twice.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	StatusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetry replaces DefaultRetryPolicy. Responses with one of the policy's
+// status codes are retried, honoring Retry-After. Network errors are retried
+// for GET and DELETE requests and for the proxy's record and zone writes,
+// which carry an Idempotency-Key; other requests may already have taken
+// effect. Zero fields take their value from DefaultRetryPolicy, except
+// MaxAttempts, so WithRetry(RetryPolicy{}) disables retries.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		if policy.BaseDelay <= 0 {
+			policy.BaseDelay = DefaultRetryPolicy.BaseDelay
+		}
+		if policy.MaxDelay <= 0 {
+			policy.MaxDelay = DefaultRetryPolicy.MaxDelay
+		}
+		if policy.StatusCodes == nil {
+			policy.StatusCodes = DefaultRetryPolicy.StatusCodes
+		}
+		c.retry = policy
+	}
+}
+
+// afterError decides whether a request that failed with a network error is
+// retried, returning the delay before the next attempt.
+func (p RetryPolicy) afterError(ctx context.Context, method string, idempotent bool, attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+	if method != http.MethodGet && method != http.MethodDelete && !idempotent {
+		return 0, false
+	}
+	return p.backoff(attempt), true
+}
+
+// afterResponse decides whether a response is retried, returning the delay
+// before the next attempt.
+func (p RetryPolicy) afterResponse(resp *http.Response, attempt int) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !slices.Contains(p.StatusCodes, resp.StatusCode) {
+		return 0, false
+	}
+	delay := p.backoff(attempt)
+	if after, found := retryAfter(resp.Header.Get("Retry-After")); found {
+		if after > p.MaxDelay {
+			return 0, false
+		}
+		delay = max(delay, after)
+	}
+	return delay, true
+}
+
+// backoff returns the jittered delay after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MaxDelay
+	if shift := attempt - 1; shift < 32 && p.BaseDelay<<shift > 0 && p.BaseDelay<<shift < p.MaxDelay {
+		d = p.BaseDelay << shift
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// retryAfter parses a Retry-After header, given in seconds or as an HTTP date.
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning early with the context's error if it
+// is canceled.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Token is a token as listed by the admin API. The key itself is only
+// returned when the token is created.
+type Token struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	IsAdmin           bool      `json:"is_admin"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at"` // zero for tokens that do not expire
+	Expired           bool      `json:"expired"`
+	PinTLSFingerprint bool      `json:"pin_tls_fingerprint"`
+	Account           string    `json:"account"`
+	LastUsedAt        time.Time `json:"last_used_at"` // zero for tokens never used
+	UseCount          int64     `json:"use_count"`
+}
+
+// TokenDetail is a token with its permissions and roles.
+type TokenDetail struct {
+	Token
+	TLSFingerprint string
+	// Permissions are the token's own permissions; those granted by roles
+	// are listed with the roles.
+	Permissions []Permission
+	Roles       []Role
+}
+
+// Permission grants a scoped token actions on one zone.
+type Permission struct {
+	ID int64 `json:"id"`
+	// Resource is "dnszone", "pullzone" or "storagezone".
+	Resource       string   `json:"resource"`
+	ZoneID         int64    `json:"zone_id"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types"`
+}
+
+// Role is a named set of permissions assigned to tokens.
+type Role struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions"`
+	TokenCount  int          `json:"token_count"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// Whoami describes the caller's token.
+type Whoami struct {
+	TokenID     int64
+	Name        string
+	IsAdmin     bool
+	IsMasterKey bool
+	Permissions []Permission
+}
+
+// CreateTokenRequest describes a token to create. Scoped tokens need zones,
+// actions and record types, or roles to grant them.
+type CreateTokenRequest struct {
+	Name              string     `json:"name"`
+	IsAdmin           bool       `json:"is_admin"`
+	Zones             []int64    `json:"zones,omitempty"`
+	Actions           []string   `json:"actions,omitempty"`
+	RecordTypes       []string   `json:"record_types,omitempty"`
+	Roles             []int64    `json:"roles,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	PinTLSFingerprint bool       `json:"pin_tls_fingerprint,omitempty"`
+	Account           string     `json:"account,omitempty"`
+}
+
+// CreatedToken is a newly created token. Key is shown only once.
+type CreatedToken struct {
+	ID                int64     `json:"id"`
+	Name              string    `json:"name"`
+	Key               string    `json:"token"`
+	IsAdmin           bool      `json:"is_admin"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	PinTLSFingerprint bool      `json:"pin_tls_fingerprint"`
+	Account           string    `json:"account"`
+}
+
+// PermissionRequest describes a permission to add to a scoped token.
+type PermissionRequest struct {
+	// Resource is "dnszone" (the default when empty), "pullzone" or "storagezone".
+	Resource       string   `json:"resource,omitempty"`
+	ZoneID         int64    `json:"zone_id"`
+	AllowedActions []string `json:"allowed_actions"`
+	RecordTypes    []string `json:"record_types"`
+}
+
+// storedPermission is a permission in token details and whoami responses,
+// which use the storage field names.
+type storedPermission struct {
+	ID             int64
+	Resource       string
+	ZoneID         int64
+	AllowedActions []string
+	RecordTypes    []string
+}
+
+func toPermissions(stored []storedPermission) []Permission {
+	perms := make([]Permission, 0, len(stored))
+	for _, p := range stored {
+		resource := p.Resource
+		if resource == "" {
+			resource = "dnszone"
+		}
+		perms = append(perms, Permission{
+			ID:             p.ID,
+			Resource:       resource,
+			ZoneID:         p.ZoneID,
+			AllowedActions: p.AllowedActions,
+			RecordTypes:    p.RecordTypes,
+		})
+	}
+	return perms
+}
+
+// Whoami returns the token the client authenticates with.
+// GET /admin/api/whoami
+func (c *Client) Whoami(ctx context.Context) (*Whoami, error) {
+	var resp struct {
+		TokenID     int64              `json:"token_id"`
+		Name        string             `json:"name"`
+		IsAdmin     bool               `json:"is_admin"`
+		IsMasterKey bool               `json:"is_master_key"`
+		Permissions []storedPermission `json:"permissions"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/api/whoami"}, &resp); err != nil {
+		return nil, err
+	}
+	return &Whoami{
+		TokenID:     resp.TokenID,
+		Name:        resp.Name,
+		IsAdmin:     resp.IsAdmin,
+		IsMasterKey: resp.IsMasterKey,
+		Permissions: toPermissions(resp.Permissions),
+	}, nil
+}
+
+// ListTokens returns all tokens. Requires an admin token.
+// GET /admin/api/tokens
+func (c *Client) ListTokens(ctx context.Context) ([]Token, error) {
+	return c.listTokens(ctx, nil)
+}
+
+// ListTokensUnusedSince returns the tokens not used since t, counting tokens
+// never used from their creation. Requires an admin token.
+// GET /admin/api/tokens?unused_since=...
+func (c *Client) ListTokensUnusedSince(ctx context.Context, t time.Time) ([]Token, error) {
+	return c.listTokens(ctx, url.Values{"unused_since": {t.UTC().Format(time.RFC3339)}})
+}
+
+func (c *Client) listTokens(ctx context.Context, query url.Values) ([]Token, error) {
+	var tokens []Token
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/api/tokens", query: query}, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetToken returns a token with its permissions and roles. Requires an admin token.
+// GET /admin/api/tokens/{id}
+func (c *Client) GetToken(ctx context.Context, id int64) (*TokenDetail, error) {
+	var resp struct {
+		Token
+		TLSFingerprint string             `json:"tls_fingerprint"`
+		Permissions    []storedPermission `json:"permissions"`
+		Roles          []Role             `json:"roles"`
+	}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/admin/api/tokens/" + pathID(id)}, &resp); err != nil {
+		return nil, err
+	}
+	return &TokenDetail{
+		Token:          resp.Token,
+		TLSFingerprint: resp.TLSFingerprint,
+		Permissions:    toPermissions(resp.Permissions),
+		Roles:          resp.Roles,
+	}, nil
+}
+
+// CreateToken creates a token. Requires an admin token, or the master key
+// before the first admin token exists.
+// POST /admin/api/tokens
+func (c *Client) CreateToken(ctx context.Context, req CreateTokenRequest) (*CreatedToken, error) {
+	var token CreatedToken
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/admin/api/tokens", body: req}, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteToken deletes a token. Requires an admin token.
+// DELETE /admin/api/tokens/{id}
+func (c *Client) DeleteToken(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/admin/api/tokens/" + pathID(id)}, nil)
+}
+
+// AddPermission adds a permission to a scoped token. Requires an admin token.
+// POST /admin/api/tokens/{id}/permissions
+func (c *Client) AddPermission(ctx context.Context, tokenID int64, req PermissionRequest) (*Permission, error) {
+	var perm Permission
+	path := "/admin/api/tokens/" + pathID(tokenID) + "/permissions"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req}, &perm); err != nil {
+		return nil, err
+	}
+	return &perm, nil
+}
+
+// DeletePermission removes a permission from a token. Requires an admin token.
+// DELETE /admin/api/tokens/{id}/permissions/{pid}
+func (c *Client) DeletePermission(ctx context.Context, tokenID, permissionID int64) error {
+	path := "/admin/api/tokens/" + pathID(tokenID) + "/permissions/" + pathID(permissionID)
+	return c.do(ctx, request{method: http.MethodDelete, path: path}, nil)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_GetToken(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin/api/tokens/2" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		// Token details list permissions with their storage field names
+		w.Write([]byte(`{"id":2,"name":"acme","is_admin":false,"created_at":"2026-01-15T09:12:03Z","use_count":4,
+			"permissions":[{"ID":5,"TokenID":2,"Resource":"","ZoneID":123,"AllowedActions":["add_record"],"RecordTypes":["TXT"]}],
+			"roles":[{"id":1,"name":"dns","permissions":[{"id":8,"resource":"dnszone","zone_id":9,"allowed_actions":["list_records"],"record_types":["A"]}]}]}`)) //nolint:errcheck
+	})
+
+	token, err := c.GetToken(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("GetToken() error = %v", err)
+	}
+	if token.ID != 2 || token.Name != "acme" || token.UseCount != 4 || !token.CreatedAt.Equal(time.Date(2026, 1, 15, 9, 12, 3, 0, time.UTC)) {
+		t.Errorf("unexpected token: %+v", token.Token)
+	}
+	want := Permission{ID: 5, Resource: "dnszone", ZoneID: 123, AllowedActions: []string{"add_record"}, RecordTypes: []string{"TXT"}}
+	if len(token.Permissions) != 1 || token.Permissions[0].ID != want.ID || token.Permissions[0].ZoneID != want.ZoneID ||
+		token.Permissions[0].Resource != want.Resource || token.Permissions[0].RecordTypes[0] != "TXT" {
+		t.Errorf("Permissions = %+v, want [%+v]", token.Permissions, want)
+	}
+	if len(token.Roles) != 1 || token.Roles[0].Permissions[0].ZoneID != 9 {
+		t.Errorf("unexpected roles: %+v", token.Roles)
+	}
+}
+
+func TestClient_CreateToken(t *testing.T) {
+	t.Parallel()
+	var body map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/admin/api/tokens" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":3,"name":"ci","token":"3.abc","is_admin":false,"expires_at":"2026-06-01T00:00:00Z"}`)) //nolint:errcheck
+	})
+
+	expires := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	token, err := c.CreateToken(context.Background(), CreateTokenRequest{
+		Name: "ci", Zones: []int64{123}, Actions: []string{"add_record"}, RecordTypes: []string{"TXT"}, ExpiresAt: &expires,
+	})
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if token.Key != "3.abc" || !token.ExpiresAt.Equal(expires) {
+		t.Errorf("unexpected token: %+v", token)
+	}
+	if body["expires_at"] != "2026-06-01T00:00:00Z" || body["roles"] != nil {
+		t.Errorf("unexpected request body: %v", body)
+	}
+}
+
+func TestClient_Whoami(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token_id":2,"name":"acme","is_admin":false,"is_master_key":false,
+			"permissions":[{"ID":5,"Resource":"pullzone","ZoneID":77,"AllowedActions":["purge_pullzone"]}]}`)) //nolint:errcheck
+	})
+
+	who, err := c.Whoami(context.Background())
+	if err != nil {
+		t.Fatalf("Whoami() error = %v", err)
+	}
+	if who.TokenID != 2 || len(who.Permissions) != 1 || who.Permissions[0].Resource != "pullzone" || who.Permissions[0].ZoneID != 77 {
+		t.Errorf("unexpected whoami: %+v", who)
+	}
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecordType is a DNS record type, numbered as in the bunny.net API.
+type RecordType int
+
+// Record types.
+const (
+	RecordTypeA        RecordType = 0
+	RecordTypeAAAA     RecordType = 1
+	RecordTypeCNAME    RecordType = 2
+	RecordTypeTXT      RecordType = 3
+	RecordTypeMX       RecordType = 4
+	RecordTypeSPF      RecordType = 5 // labeled Redirect in the bunny.net dashboard
+	RecordTypeFlatten  RecordType = 6
+	RecordTypePullZone RecordType = 7
+	RecordTypeSRV      RecordType = 8
+	RecordTypeCAA      RecordType = 9
+	RecordTypePTR      RecordType = 10
+	RecordTypeScript   RecordType = 11
+	RecordTypeNS       RecordType = 12
+)
+
+// recordTypeNames are the names the proxy uses for record types in
+// permissions, indexed by type.
+var recordTypeNames = []string{"A", "AAAA", "CNAME", "TXT", "MX", "SPF", "Flatten", "PullZone", "SRV", "CAA", "PTR", "Script", "NS"}
+
+// String returns the record type's name as used in permissions, such as "TXT".
+func (t RecordType) String() string {
+	if t < 0 || int(t) >= len(recordTypeNames) {
+		return "RecordType(" + strconv.Itoa(int(t)) + ")"
+	}
+	return recordTypeNames[t]
+}
+
+// ParseRecordType returns the record type named name, such as "TXT" or
+// "txt". "Redirect" is accepted for SPF.
+func ParseRecordType(name string) (RecordType, error) {
+	if strings.EqualFold(name, "Redirect") {
+		return RecordTypeSPF, nil
+	}
+	for i, n := range recordTypeNames {
+		if strings.EqualFold(name, n) {
+			return RecordType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("client: unknown record type %q", name)
+}
+
+// Zone is a DNS zone. Fields of the bunny.net zone the client does not use
+// are left out.
+type Zone struct {
+	ID             int64     `json:"Id"`
+	Domain         string    `json:"Domain"`
+	Records        []Record  `json:"Records"`
+	DateCreated    time.Time `json:"DateCreated"`
+	DateModified   time.Time `json:"DateModified"`
+	Nameserver1    string    `json:"Nameserver1"`
+	Nameserver2    string    `json:"Nameserver2"`
+	SoaEmail       string    `json:"SoaEmail"`
+	LoggingEnabled bool      `json:"LoggingEnabled"`
+	DnsSecEnabled  bool      `json:"DnsSecEnabled"` //nolint:revive // bunny.net field name
+}
+
+// ZoneList is a page of zones.
+type ZoneList struct {
+	CurrentPage  int    `json:"CurrentPage"`
+	TotalItems   int    `json:"TotalItems"`
+	HasMoreItems bool   `json:"HasMoreItems"`
+	Items        []Zone `json:"Items"`
+}
+
+// ListZonesOptions selects a page of zones. Zero fields use the proxy's defaults.
+type ListZonesOptions struct {
+	Page    int
+	PerPage int
+	Search  string
+}
+
+// ZoneMatch is the zone a hostname belongs to.
+type ZoneMatch struct {
+	ZoneID int64  `json:"ZoneId"`
+	Domain string `json:"Domain"`
+	// RecordName is the hostname relative to the zone, empty for the apex.
+	RecordName string `json:"RecordName"`
+}
+
+// Record is a DNS record.
+type Record struct {
+	ID       int64      `json:"Id"`
+	Type     RecordType `json:"Type"`
+	Name     string     `json:"Name"`
+	Value    string     `json:"Value"`
+	TTL      int32      `json:"Ttl"`
+	Priority int32      `json:"Priority"`
+	Weight   int32      `json:"Weight"`
+	Port     int32      `json:"Port"`
+	Flags    int        `json:"Flags"`
+	Tag      string     `json:"Tag"`
+	Disabled bool       `json:"Disabled"`
+	Comment  string     `json:"Comment"`
+}
+
+// RecordRequest is a record to add, or the new values of one to update.
+type RecordRequest struct {
+	Type     RecordType `json:"Type"`
+	Name     string     `json:"Name"`
+	Value    string     `json:"Value"`
+	TTL      int32      `json:"Ttl,omitempty"`
+	Priority int32      `json:"Priority,omitempty"`
+	Weight   int32      `json:"Weight,omitempty"`
+	Port     int32      `json:"Port,omitempty"`
+	Flags    int        `json:"Flags,omitempty"`
+	Tag      string     `json:"Tag,omitempty"`
+	Disabled bool       `json:"Disabled,omitempty"`
+	Comment  string     `json:"Comment,omitempty"`
+}
+
+// RecordFilter selects records in ListRecords. Empty fields match every record.
+type RecordFilter struct {
+	// Type is a record type name such as "TXT", compared case-insensitively.
+	Type string
+	// Name is relative to the zone; "@" is the apex.
+	Name  string
+	Value string
+}
+
+// ListZones returns a page of the zones the token may list.
+// GET /dnszone
+func (c *Client) ListZones(ctx context.Context, opts ListZonesOptions) (*ZoneList, error) {
+	query := url.Values{}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.PerPage > 0 {
+		query.Set("perPage", strconv.Itoa(opts.PerPage))
+	}
+	if opts.Search != "" {
+		query.Set("search", opts.Search)
+	}
+	var zones ZoneList
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/dnszone", query: query}, &zones); err != nil {
+		return nil, err
+	}
+	return &zones, nil
+}
+
+// GetZone returns a zone and the records the token may see.
+// GET /dnszone/{zoneID}
+func (c *Client) GetZone(ctx context.Context, zoneID int64) (*Zone, error) {
+	var zone Zone
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/dnszone/" + pathID(zoneID)}, &zone); err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// FindZoneByName returns the zone a hostname belongs to, such as the zone of
+// "_acme-challenge.www.example.com". It returns an error matching ErrNotFound
+// when no zone the token may access contains the hostname.
+// GET /dnszone/byname/{domain}
+func (c *Client) FindZoneByName(ctx context.Context, hostname string) (*ZoneMatch, error) {
+	var match ZoneMatch
+	path := "/dnszone/byname/" + url.PathEscape(hostname)
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &match); err != nil {
+		return nil, err
+	}
+	return &match, nil
+}
+
+// CreateZone creates a zone. Requires an admin token.
+// POST /dnszone
+func (c *Client) CreateZone(ctx context.Context, domain string) (*Zone, error) {
+	var zone Zone
+	body := struct {
+		Domain string `json:"Domain"`
+	}{domain}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/dnszone", body: body, idempotent: true}, &zone); err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// DeleteZone deletes a zone. Requires an admin token.
+// DELETE /dnszone/{zoneID}
+func (c *Client) DeleteZone(ctx context.Context, zoneID int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: "/dnszone/" + pathID(zoneID)}, nil)
+}
+
+// ListRecords returns the records of a zone that match filter and that the
+// token may see.
+// GET /dnszone/{zoneID}/records
+func (c *Client) ListRecords(ctx context.Context, zoneID int64, filter RecordFilter) ([]Record, error) {
+	query := url.Values{}
+	if filter.Type != "" {
+		query.Set("type", filter.Type)
+	}
+	if filter.Name != "" {
+		query.Set("name", filter.Name)
+	}
+	if filter.Value != "" {
+		query.Set("value", filter.Value)
+	}
+	var records []Record
+	path := "/dnszone/" + pathID(zoneID) + "/records"
+	if err := c.do(ctx, request{method: http.MethodGet, path: path, query: query}, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetRecord returns one record of a zone.
+// GET /dnszone/{zoneID}/records/{recordID}
+func (c *Client) GetRecord(ctx context.Context, zoneID, recordID int64) (*Record, error) {
+	var record Record
+	path := "/dnszone/" + pathID(zoneID) + "/records/" + pathID(recordID)
+	if err := c.do(ctx, request{method: http.MethodGet, path: path}, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// AddRecord adds a record to a zone. The request carries an Idempotency-Key,
+// so a retry after a network error does not create a second record on
+// proxies that keep idempotency keys.
+// POST /dnszone/{zoneID}/records
+func (c *Client) AddRecord(ctx context.Context, zoneID int64, req RecordRequest) (*Record, error) {
+	var record Record
+	path := "/dnszone/" + pathID(zoneID) + "/records"
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, idempotent: true}, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// UpdateRecord updates a record. bunny.net may not return the updated
+// record, in which case UpdateRecord returns nil and no error.
+// POST /dnszone/{zoneID}/records/{recordID}
+func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID int64, req RecordRequest) (*Record, error) {
+	var record *Record
+	path := "/dnszone/" + pathID(zoneID) + "/records/" + pathID(recordID)
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: req, idempotent: true}, &record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// DeleteRecord deletes a record from a zone.
+// DELETE /dnszone/{zoneID}/records/{recordID}
+func (c *Client) DeleteRecord(ctx context.Context, zoneID, recordID int64) error {
+	path := "/dnszone/" + pathID(zoneID) + "/records/" + pathID(recordID)
+	return c.do(ctx, request{method: http.MethodDelete, path: path}, nil)
+}
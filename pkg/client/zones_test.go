@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestClient_ListRecords(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dnszone/123/records" || r.URL.RawQuery != "name=_acme-challenge&type=TXT" {
+			t.Errorf("unexpected request %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		w.Write([]byte(`[{"Id":1,"Type":3,"Name":"_acme-challenge","Value":"v","Ttl":60}]`)) //nolint:errcheck
+	})
+
+	records, err := c.ListRecords(context.Background(), 123, RecordFilter{Type: "TXT", Name: "_acme-challenge"})
+	if err != nil {
+		t.Fatalf("ListRecords() error = %v", err)
+	}
+	if len(records) != 1 || records[0].Type != RecordTypeTXT || records[0].TTL != 60 {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestClient_UpdateRecord_NoContent(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/dnszone/1/records/2" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	record, err := c.UpdateRecord(context.Background(), 1, 2, RecordRequest{Type: RecordTypeA, Value: "192.0.2.1"})
+	if err != nil || record != nil {
+		t.Errorf("UpdateRecord() = %+v, %v; want nil, nil", record, err)
+	}
+}
+
+func TestClient_FindZoneByName(t *testing.T) {
+	t.Parallel()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dnszone/byname/_acme-challenge.example.com" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"ZoneId":12345,"Domain":"example.com","RecordName":"_acme-challenge"}`)) //nolint:errcheck
+	})
+
+	match, err := c.FindZoneByName(context.Background(), "_acme-challenge.example.com")
+	if err != nil || match.ZoneID != 12345 || match.RecordName != "_acme-challenge" {
+		t.Errorf("FindZoneByName() = %+v, %v", match, err)
+	}
+}
+
+func TestRecordType_String(t *testing.T) {
+	t.Parallel()
+	if RecordTypeTXT.String() != "TXT" || RecordTypeSPF.String() != "SPF" || RecordType(99).String() != "RecordType(99)" {
+		t.Errorf("unexpected names: %s %s %s", RecordTypeTXT, RecordTypeSPF, RecordType(99))
+	}
+}
+
+func TestParseRecordType(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]RecordType{"TXT": RecordTypeTXT, "aaaa": RecordTypeAAAA, "redirect": RecordTypeSPF, "PullZone": RecordTypePullZone} {
+		if got, err := ParseRecordType(name); err != nil || got != want {
+			t.Errorf("ParseRecordType(%q) = %v, %v; want %v", name, got, err, want)
+		}
+	}
+	if _, err := ParseRecordType("BOGUS"); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
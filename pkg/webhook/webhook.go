@@ -0,0 +1,183 @@
+// Package webhook defines the payload schema and signature scheme used by
+// bunny-api-proxy webhooks. Receivers can import it to verify deliveries.
+//
+// Every delivery is a POST with a JSON body (see Payload) and these headers:
+//
+//	X-Webhook-ID:             unique event ID (also in the body)
+//	X-Webhook-Event:          event name, e.g. "webhook.test"
+//	X-Webhook-Schema-Version: payload schema version, currently "1"
+//	X-Webhook-Signature:      t=<unix seconds>,v1=<hex HMAC-SHA256>
+//
+// The v1 signature is HMAC-SHA256 over "<t>.<raw request body>" keyed with the
+// webhook secret. Receivers must compute it over the raw body bytes (before any
+// JSON re-encoding), compare in constant time, and reject stale timestamps to
+// limit replay. Verify does all three:
+//
+//	body, _ := io.ReadAll(r.Body)
+//	if err := webhook.Verify(secret, body, r.Header.Get(webhook.HeaderSignature), webhook.DefaultTolerance); err != nil {
+//		http.Error(w, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+//	payload, err := webhook.ParsePayload(body)
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the current payload schema version.
+// Bump it only for breaking changes to the Payload envelope.
+const SchemaVersion = 1
+
+// DefaultTolerance is the recommended maximum age of a signature timestamp.
+const DefaultTolerance = 5 * time.Minute
+
+// HTTP headers set on every delivery.
+const (
+	HeaderID            = "X-Webhook-ID"
+	HeaderEvent         = "X-Webhook-Event"
+	HeaderSchemaVersion = "X-Webhook-Schema-Version"
+	HeaderSignature     = "X-Webhook-Signature"
+)
+
+// Event names.
+const (
+	// EventTest is sent by the admin test delivery endpoint.
+	EventTest = "webhook.test"
+
+	EventZoneCreated   = "zone.created"
+	EventZoneUpdated   = "zone.updated"
+	EventZoneDeleted   = "zone.deleted"
+	EventRecordCreated = "record.created"
+	EventRecordUpdated = "record.updated"
+	EventRecordDeleted = "record.deleted"
+
+	// EventSystemAlert reports an operational problem with the proxy itself,
+	// such as the database approaching the size of its volume. It has no
+	// zone, so only admin webhooks without a zone filter receive it.
+	EventSystemAlert = "system.alert"
+
+	// Security events report changes to access and upstream credential
+	// failures. Like system.alert they have no zone, so only admin webhooks
+	// without a zone filter receive them.
+	EventTokenCreated       = "token.created"
+	EventTokenDeleted       = "token.deleted"
+	EventPermissionAdded    = "permission.added"
+	EventPermissionRemoved  = "permission.removed"
+	EventUpstreamAuthFailed = "upstream.auth_failed"
+)
+
+// Verification errors.
+var (
+	ErrMissingSignature          = errors.New("webhook: missing signature header")
+	ErrInvalidSignatureHeader    = errors.New("webhook: malformed signature header")
+	ErrTimestampOutsideTolerance = errors.New("webhook: signature timestamp outside tolerance")
+	ErrSignatureMismatch         = errors.New("webhook: signature mismatch")
+	ErrUnsupportedSchemaVersion  = errors.New("webhook: unsupported schema version")
+)
+
+// Payload is the versioned envelope sent in every webhook body.
+// Data holds the event-specific object.
+type Payload struct {
+	SchemaVersion int             `json:"schema_version"`
+	ID            string          `json:"id"`
+	Event         string          `json:"event"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// ParsePayload decodes a webhook body and rejects schema versions newer than
+// this package understands.
+func ParsePayload(body []byte) (*Payload, error) {
+	var p Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("webhook: invalid payload: %w", err)
+	}
+	if p.SchemaVersion < 1 || p.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, p.SchemaVersion)
+	}
+	return &p, nil
+}
+
+// Sign returns the X-Webhook-Signature header value for body at time ts.
+func Sign(secret string, body []byte, ts time.Time) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + computeSignature(secret, t, body)
+}
+
+// Verify checks a signature header against body using secret.
+// A tolerance of zero disables the timestamp age check.
+func Verify(secret string, body []byte, header string, tolerance time.Duration) error {
+	return verifyAt(secret, body, header, tolerance, time.Now())
+}
+
+// verifyAt is Verify with an explicit clock for testing.
+func verifyAt(secret string, body []byte, header string, tolerance time.Duration, now time.Time) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	t, sigs, err := parseSignatureHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if tolerance > 0 {
+		ts, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			return ErrInvalidSignatureHeader
+		}
+		age := now.Sub(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampOutsideTolerance
+		}
+	}
+
+	expected := computeSignature(secret, t, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrSignatureMismatch
+}
+
+// parseSignatureHeader splits "t=...,v1=...[,v1=...]" into the timestamp and v1 signatures.
+// Multiple v1 entries are allowed so senders can sign with old and new secrets during rotation.
+func parseSignatureHeader(header string) (string, []string, error) {
+	var t string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", nil, ErrInvalidSignatureHeader
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			sigs = append(sigs, value)
+		}
+	}
+	if t == "" || len(sigs) == 0 {
+		return "", nil, ErrInvalidSignatureHeader
+	}
+	return t, sigs, nil
+}
+
+// computeSignature returns hex(HMAC-SHA256(secret, t + "." + body)).
+func computeSignature(secret, t string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{"schema_version":1,"id":"abc","event":"webhook.test","data":{}}`)
+	now := time.Unix(1700000000, 0)
+	header := Sign("secret", body, now)
+
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("unexpected header format: %s", header)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		body      []byte
+		header    string
+		tolerance time.Duration
+		now       time.Time
+		wantErr   error
+	}{
+		{"valid", "secret", body, header, DefaultTolerance, now, nil},
+		{"valid with zero tolerance", "secret", body, header, 0, now.Add(time.Hour), nil},
+		{"wrong secret", "other", body, header, DefaultTolerance, now, ErrSignatureMismatch},
+		{"tampered body", "secret", []byte(`{}`), header, DefaultTolerance, now, ErrSignatureMismatch},
+		{"stale timestamp", "secret", body, header, DefaultTolerance, now.Add(10 * time.Minute), ErrTimestampOutsideTolerance},
+		{"future timestamp", "secret", body, header, DefaultTolerance, now.Add(-10 * time.Minute), ErrTimestampOutsideTolerance},
+		{"missing header", "secret", body, "", DefaultTolerance, now, ErrMissingSignature},
+		{"no timestamp", "secret", body, "v1=abc", DefaultTolerance, now, ErrInvalidSignatureHeader},
+		{"no v1 signature", "secret", body, "t=1700000000,v0=abc", DefaultTolerance, now, ErrInvalidSignatureHeader},
+		{"garbage", "secret", body, "garbage", DefaultTolerance, now, ErrInvalidSignatureHeader},
+		{"rotated secret", "secret", body, "t=1700000000,v1=deadbeef," + strings.TrimPrefix(header, "t=1700000000,"), DefaultTolerance, now, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := verifyAt(tt.secret, tt.body, tt.header, tt.tolerance, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifyAt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify_UsesCurrentTime(t *testing.T) {
+	t.Parallel()
+	body := []byte(`{}`)
+	if err := Verify("s", body, Sign("s", body, time.Now()), DefaultTolerance); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestParsePayload(t *testing.T) {
+	t.Parallel()
+
+	p, err := ParsePayload([]byte(`{"schema_version":1,"id":"abc","event":"webhook.test","created_at":"2026-01-01T00:00:00Z","data":{"k":"v"}}`))
+	if err != nil {
+		t.Fatalf("ParsePayload() error = %v", err)
+	}
+	if p.ID != "abc" || p.Event != EventTest || string(p.Data) != `{"k":"v"}` {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+
+	if _, err := ParsePayload([]byte(`{"schema_version":2}`)); !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Errorf("expected ErrUnsupportedSchemaVersion for v2, got %v", err)
+	}
+	if _, err := ParsePayload([]byte(`{}`)); !errors.Is(err, ErrUnsupportedSchemaVersion) {
+		t.Errorf("expected ErrUnsupportedSchemaVersion for missing version, got %v", err)
+	}
+	if _, err := ParsePayload([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
@@ -351,6 +351,35 @@ func TestE2E_ExportRecords_Success(t *testing.T) {
 	require.Contains(t, bodyStr, "192.168.1.100", "export should contain record value")
 }
 
+// TestE2E_ExportRecords_ScopedTokenFiltered verifies that a token restricted to
+// TXT records only gets TXT records in its export.
+func TestE2E_ExportRecords_ScopedTokenFiltered(t *testing.T) {
+	env := testenv.Setup(t)
+	zones := env.CreateTestZones(t, 1)
+	zone := zones[0]
+
+	for _, rec := range []map[string]interface{}{
+		{"Type": 0, "Name": "e2e-export-a", "Value": "192.168.1.101", "Ttl": 300},
+		{"Type": 3, "Name": "_acme-challenge", "Value": "e2e-export-token", "Ttl": 300},
+	} {
+		addBody, _ := json.Marshal(rec)
+		addResp := proxyRequest(t, "POST", fmt.Sprintf("/dnszone/%d/records", zone.ID), env.AdminToken, addBody)
+		addResp.Body.Close()
+		require.Equal(t, http.StatusCreated, addResp.StatusCode)
+	}
+
+	scopedKey := createScopedKeyWithRecordTypes(t, env.AdminToken, zone.ID, []string{"TXT"})
+	resp := proxyRequest(t, "GET", fmt.Sprintf("/dnszone/%d/export", zone.ID), scopedKey, nil)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(bodyBytes), "e2e-export-token", "export should contain the TXT record")
+	require.NotContains(t, string(bodyBytes), "192.168.1.101", "export should not contain the A record")
+}
+
 // TestE2E_ExportRecords_EmptyZone verifies exporting from a zone with no records.
 func TestE2E_ExportRecords_EmptyZone(t *testing.T) {
 	env := testenv.Setup(t)
@@ -678,11 +707,6 @@ func TestE2E_ScopedTokenCannotAccessAdminDNSEndpoints(t *testing.T) {
 			path:   fmt.Sprintf("/dnszone/%d/import", zone.ID),
 			body:   []byte("test.com. 300 IN A 1.2.3.4"),
 		},
-		{
-			name:   "ExportRecords",
-			method: "GET",
-			path:   fmt.Sprintf("/dnszone/%d/export", zone.ID),
-		},
 		{
 			name:   "EnableDNSSEC",
 			method: "POST",
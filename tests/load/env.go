@@ -0,0 +1,98 @@
+package load
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/sipico/bunny-api-proxy/internal/auth"
+	"github.com/sipico/bunny-api-proxy/internal/bunny"
+	"github.com/sipico/bunny-api-proxy/internal/proxy"
+	"github.com/sipico/bunny-api-proxy/internal/storage"
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockbunny"
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+)
+
+// envAccessKey is the scoped token of the environment's proxy.
+const envAccessKey = "load-test-access-key"
+
+// Env is an in-process proxy backed by mockbunny, with one zone and a token
+// that may manage its TXT records, as an ACME client's token would.
+type Env struct {
+	// ProxyURL is the base URL of the proxy.
+	ProxyURL string
+	// AccessKey is a token with list_records, add_record and delete_record
+	// for TXT records on ZoneID.
+	AccessKey string
+	// ZoneID is the zone created for the workload.
+	ZoneID int64
+	// Mock is the upstream, for inspecting state or injecting failures.
+	Mock *mockbunny.Server
+
+	server *httptest.Server
+	db     *storage.SQLiteStorage
+}
+
+// NewEnv starts a mockbunny server with a zone for domain and a proxy in
+// front of it using in-memory storage. Close releases both.
+func NewEnv(domain string) (*Env, error) {
+	ctx := context.Background()
+	mock := mockbunny.New()
+	zoneID := mock.AddZone(domain)
+
+	db, err := storage.New(":memory:")
+	if err != nil {
+		mock.Close()
+		return nil, fmt.Errorf("load: failed to create storage: %w", err)
+	}
+	token, err := db.CreateToken(ctx, "load", false, auth.HashToken(envAccessKey))
+	if err == nil {
+		_, err = db.AddPermissionForToken(ctx, token.ID, &storage.Permission{
+			ZoneID:         zoneID,
+			AllowedActions: []string{string(auth.ActionListRecords), string(auth.ActionAddRecord), string(auth.ActionDeleteRecord)},
+			RecordTypes:    []string{"TXT"},
+		})
+	}
+	if err != nil {
+		//nolint:errcheck
+		db.Close()
+		mock.Close()
+		return nil, fmt.Errorf("load: failed to create token: %w", err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	authenticator := auth.NewAuthenticator(db, auth.NewBootstrapService(db, "load-master-key"))
+	authMiddleware := func(next http.Handler) http.Handler {
+		return authenticator.Authenticate(authenticator.CheckPermissions(next))
+	}
+	handler := proxy.NewHandler(bunny.NewClient("load-api-key", bunny.WithBaseURL(mock.URL())), logger)
+	server := httptest.NewServer(proxy.NewRouter(handler, authMiddleware, logger))
+
+	return &Env{
+		ProxyURL:  server.URL,
+		AccessKey: envAccessKey,
+		ZoneID:    zoneID,
+		Mock:      mock,
+		server:    server,
+		db:        db,
+	}, nil
+}
+
+// Config returns cfg with the client and zone of the environment filled in.
+// The client does not retry, so every failure is reported.
+func (e *Env) Config(cfg Config) Config {
+	//nolint:errcheck // The URL and key are always valid
+	cfg.Client, _ = client.New(e.ProxyURL, e.AccessKey, client.WithRetry(client.RetryPolicy{}))
+	cfg.ZoneID = e.ZoneID
+	return cfg
+}
+
+// Close stops the proxy and mockbunny and closes the storage.
+func (e *Env) Close() {
+	e.server.Close()
+	e.Mock.Close()
+	//nolint:errcheck
+	e.db.Close()
+}
@@ -0,0 +1,362 @@
+// Package load drives ACME-like workloads against a bunny-api-proxy instance
+// and reports latency percentiles and error rates per operation.
+//
+// Each worker repeatedly adds a TXT challenge record, polls the zone's
+// records until the value is visible, and deletes the record again, as a
+// DNS-01 client does:
+//
+//	env, err := load.NewEnv("load.example.com")
+//	if err != nil {
+//		return err
+//	}
+//	defer env.Close()
+//	report, err := load.Run(ctx, env.Config(load.Config{Workers: 20, Iterations: 50}))
+//	if err != nil {
+//		return err
+//	}
+//	report.WriteText(os.Stdout)
+//	return report.Check(load.Thresholds{MaxErrorRate: 0.01, MaxP99: 500 * time.Millisecond})
+package load
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/pkg/client"
+)
+
+// Operation names used in reports.
+const (
+	OpAddRecord    = "add_record"
+	OpPollRecords  = "poll_records"
+	OpDeleteRecord = "delete_record"
+)
+
+// challengeTTL is the TTL of the challenge records, as used by ACME clients.
+const challengeTTL = 60
+
+// Config describes a workload. Client and ZoneID are required, as is at
+// least one of Iterations and Duration.
+type Config struct {
+	// Client sends the requests. Its token needs list_records, add_record
+	// and delete_record for TXT records on ZoneID.
+	Client *client.Client
+	// ZoneID is the zone the challenge records are created in.
+	ZoneID int64
+
+	// Workers is the number of concurrent ACME-like clients. Default 10.
+	Workers int
+	// Iterations is the number of challenges each worker solves. Zero means
+	// no limit.
+	Iterations int
+	// Duration stops workers from starting new challenges once it has passed.
+	// Zero means no limit.
+	Duration time.Duration
+	// Polls is the most record listings per challenge while waiting for the
+	// record to be visible. Default 3.
+	Polls int
+	// PollInterval is the wait between listings that did not find the record.
+	PollInterval time.Duration
+	// RecordName prefixes the challenge record names, which are made unique
+	// per worker and iteration. Default "_acme-challenge.load".
+	RecordName string
+}
+
+// Thresholds are the limits Check enforces.
+type Thresholds struct {
+	// MaxErrorRate is the highest acceptable share of failed requests over
+	// all operations, between 0 and 1. Zero allows no failed requests.
+	MaxErrorRate float64
+	// MaxP99 is the highest acceptable 99th percentile latency of any
+	// operation. Zero is not checked.
+	MaxP99 time.Duration
+}
+
+// OperationStats summarizes the requests of one operation. Latencies include
+// failed requests.
+type OperationStats struct {
+	Name     string        `json:"name"`
+	Requests int           `json:"requests"`
+	Errors   int           `json:"errors"`
+	P50      time.Duration `json:"p50_ns"`
+	P90      time.Duration `json:"p90_ns"`
+	P99      time.Duration `json:"p99_ns"`
+	Max      time.Duration `json:"max_ns"`
+	// LastError is the message of the last failed request, if any.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// ErrorRate returns the share of failed requests, or 0 if there were none.
+func (s OperationStats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Workers    int           `json:"workers"`
+	Challenges int           `json:"challenges"`
+	Elapsed    time.Duration `json:"elapsed_ns"`
+	// Operations are ordered as the workload runs them.
+	Operations []OperationStats `json:"operations"`
+}
+
+// Requests returns the number of requests over all operations.
+func (r *Report) Requests() int {
+	n := 0
+	for _, op := range r.Operations {
+		n += op.Requests
+	}
+	return n
+}
+
+// ErrorRate returns the share of failed requests over all operations.
+func (r *Report) ErrorRate() float64 {
+	requests, errs := 0, 0
+	for _, op := range r.Operations {
+		requests += op.Requests
+		errs += op.Errors
+	}
+	if requests == 0 {
+		return 0
+	}
+	return float64(errs) / float64(requests)
+}
+
+// Throughput returns the completed requests per second.
+func (r *Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests()) / r.Elapsed.Seconds()
+}
+
+// Check returns an error naming every threshold the report exceeds, or nil.
+func (r *Report) Check(th Thresholds) error {
+	var problems []string
+	if r.ErrorRate() > th.MaxErrorRate {
+		problems = append(problems, fmt.Sprintf("error rate %.2f%% exceeds %.2f%%", r.ErrorRate()*100, th.MaxErrorRate*100))
+	}
+	if th.MaxP99 > 0 {
+		for _, op := range r.Operations {
+			if op.P99 > th.MaxP99 {
+				problems = append(problems, fmt.Sprintf("%s p99 %v exceeds %v", op.Name, op.P99, th.MaxP99))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New("load: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// WriteText writes the report as a table with one row per operation.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d workers, %d challenges in %v (%.1f req/s, %.2f%% errors)\n\n",
+		r.Workers, r.Challenges, r.Elapsed.Round(time.Millisecond), r.Throughput(), r.ErrorRate()*100)
+	fmt.Fprintln(tw, "OPERATION\tREQUESTS\tERRORS\tP50\tP90\tP99\tMAX")
+	for _, op := range r.Operations {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t%v\t%v\t%v\n", op.Name, op.Requests, op.Errors,
+			round(op.P50), round(op.P90), round(op.P99), round(op.Max))
+	}
+	for _, op := range r.Operations {
+		if op.LastError != "" {
+			fmt.Fprintf(tw, "\nlast %s error: %s", op.Name, op.LastError)
+		}
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
+
+// Run runs the workload until every worker has finished its iterations, the
+// duration has passed or ctx is done, and returns the report. Challenges in
+// progress then are completed, even if ctx is canceled, so their records are
+// cleaned up.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if cfg.Client == nil {
+		return nil, errors.New("load: a client is required")
+	}
+	if cfg.ZoneID <= 0 {
+		return nil, errors.New("load: a zone ID is required")
+	}
+	if cfg.Iterations <= 0 && cfg.Duration <= 0 {
+		return nil, errors.New("load: iterations or a duration is required")
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 10
+	}
+	if cfg.Polls <= 0 {
+		cfg.Polls = 3
+	}
+	if cfg.RecordName == "" {
+		cfg.RecordName = "_acme-challenge.load"
+	}
+
+	rec := newRecorder()
+	start := time.Now()
+	var deadline time.Time
+	if cfg.Duration > 0 {
+		deadline = start.Add(cfg.Duration)
+	}
+
+	var wg sync.WaitGroup
+	challenges := make([]int, cfg.Workers)
+	for w := range cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; cfg.Iterations <= 0 || i < cfg.Iterations; i++ {
+				if ctx.Err() != nil || (!deadline.IsZero() && time.Now().After(deadline)) {
+					return
+				}
+				solveChallenge(context.WithoutCancel(ctx), cfg, rec, fmt.Sprintf("%s-%d-%d", cfg.RecordName, w, i))
+				challenges[w]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Workers: cfg.Workers, Elapsed: time.Since(start)}
+	for _, n := range challenges {
+		report.Challenges += n
+	}
+	for _, name := range []string{OpAddRecord, OpPollRecords, OpDeleteRecord} {
+		report.Operations = append(report.Operations, rec.stats(name))
+	}
+	return report, nil
+}
+
+// solveChallenge adds a challenge record, polls until it is listed and
+// deletes it.
+func solveChallenge(ctx context.Context, cfg Config, rec *recorder, name string) {
+	value := randomValue()
+
+	var record *client.Record
+	err := rec.time(OpAddRecord, func() error {
+		var err error
+		record, err = cfg.Client.AddRecord(ctx, cfg.ZoneID, client.RecordRequest{
+			Type: client.RecordTypeTXT, Name: name, Value: value, TTL: challengeTTL,
+		})
+		return err
+	})
+	if err != nil {
+		return
+	}
+
+	for poll := 0; poll < cfg.Polls; poll++ {
+		var found bool
+		err := rec.time(OpPollRecords, func() error {
+			records, err := cfg.Client.ListRecords(ctx, cfg.ZoneID, client.RecordFilter{Type: "TXT", Name: name})
+			if err != nil {
+				return err
+			}
+			found = slices.ContainsFunc(records, func(r client.Record) bool { return r.Value == value })
+			if !found && poll == cfg.Polls-1 {
+				return fmt.Errorf("record %s not listed after %d polls", name, cfg.Polls)
+			}
+			return nil
+		})
+		if found || err != nil {
+			break
+		}
+		time.Sleep(cfg.PollInterval)
+	}
+
+	//nolint:errcheck // Failures are counted by the recorder
+	rec.time(OpDeleteRecord, func() error {
+		return cfg.Client.DeleteRecord(ctx, cfg.ZoneID, record.ID)
+	})
+}
+
+func randomValue() string {
+	b := make([]byte, 16)
+	//nolint:errcheck // crypto/rand.Read does not fail
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recorder collects request latencies and failures per operation.
+type recorder struct {
+	mu  sync.Mutex
+	ops map[string]*samples
+}
+
+type samples struct {
+	latencies []time.Duration
+	errors    int
+	lastError string
+}
+
+func newRecorder() *recorder {
+	return &recorder{ops: make(map[string]*samples)}
+}
+
+// time runs fn, recording its latency and whether it failed under op.
+func (r *recorder) time(op string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.ops[op]
+	if !ok {
+		s = &samples{}
+		r.ops[op] = s
+	}
+	s.latencies = append(s.latencies, elapsed)
+	if err != nil {
+		s.errors++
+		s.lastError = err.Error()
+	}
+	return err
+}
+
+// stats summarizes the samples of op.
+func (r *recorder) stats(op string) OperationStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := OperationStats{Name: op}
+	s, ok := r.ops[op]
+	if !ok {
+		return stats
+	}
+	sorted := slices.Clone(s.latencies)
+	slices.Sort(sorted)
+	stats.Requests = len(sorted)
+	stats.Errors = s.errors
+	stats.LastError = s.lastError
+	stats.P50 = percentile(sorted, 50)
+	stats.P90 = percentile(sorted, 90)
+	stats.P99 = percentile(sorted, 99)
+	if len(sorted) > 0 {
+		stats.Max = sorted[len(sorted)-1]
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted latencies,
+// or 0 if there are none.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
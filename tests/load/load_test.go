@@ -0,0 +1,145 @@
+package load
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	env, err := NewEnv("load.example.com")
+	if err != nil {
+		t.Fatalf("failed to start environment: %v", err)
+	}
+	defer env.Close()
+
+	report, err := Run(context.Background(), env.Config(Config{Workers: 4, Iterations: 5}))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Challenges != 20 || len(report.Operations) != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for _, op := range report.Operations {
+		if op.Requests != 20 || op.Errors != 0 || op.P50 <= 0 || op.P99 < op.P50 || op.Max < op.P99 {
+			t.Errorf("unexpected %s stats: %+v", op.Name, op)
+		}
+	}
+	if err := report.Check(Thresholds{}); err != nil {
+		t.Errorf("expected the thresholds to pass: %v", err)
+	}
+	// Every challenge record is cleaned up
+	if zone := env.Mock.GetZone(env.ZoneID); len(zone.Records) != 0 {
+		t.Errorf("expected no records left, got %d", len(zone.Records))
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "4 workers, 20 challenges") || !strings.Contains(buf.String(), OpPollRecords) {
+		t.Errorf("unexpected text report:\n%s", buf.String())
+	}
+}
+
+func TestRunUpstreamErrors(t *testing.T) {
+	env, err := NewEnv("load.example.com")
+	if err != nil {
+		t.Fatalf("failed to start environment: %v", err)
+	}
+	defer env.Close()
+
+	env.Mock.SetNextError(http.StatusInternalServerError, "boom", 2)
+	report, err := Run(context.Background(), env.Config(Config{Workers: 1, Iterations: 4}))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	add := report.Operations[0]
+	if add.Name != OpAddRecord || add.Errors != 2 || add.LastError == "" {
+		t.Errorf("expected 2 failed adds, got %+v", add)
+	}
+	// Failed challenges stop after the add
+	if poll := report.Operations[1]; poll.Requests != 2 {
+		t.Errorf("expected 2 polls, got %+v", poll)
+	}
+	if err := report.Check(Thresholds{MaxErrorRate: 0.1}); err == nil || !strings.Contains(err.Error(), "error rate") {
+		t.Errorf("expected the error rate threshold to fail, got %v", err)
+	}
+}
+
+func TestRunDuration(t *testing.T) {
+	env, err := NewEnv("load.example.com")
+	if err != nil {
+		t.Fatalf("failed to start environment: %v", err)
+	}
+	defer env.Close()
+
+	report, err := Run(context.Background(), env.Config(Config{Workers: 2, Duration: 50 * time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Challenges == 0 || report.Operations[2].Requests != report.Challenges {
+		t.Errorf("expected every started challenge to finish, got %+v", report)
+	}
+}
+
+func TestRunValidation(t *testing.T) {
+	t.Parallel()
+	env := &Env{ProxyURL: "http://127.0.0.1:1", AccessKey: "key", ZoneID: 1}
+	for _, cfg := range []Config{
+		{},
+		{Client: env.Config(Config{}).Client, Iterations: 1},
+		env.Config(Config{}),
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(sorted[:1], 99); got != time.Millisecond {
+		t.Errorf("expected the only sample, got %v", got)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("expected 0 without samples, got %v", got)
+	}
+}
+
+func TestReportCheck(t *testing.T) {
+	t.Parallel()
+	report := &Report{Operations: []OperationStats{
+		{Name: OpAddRecord, Requests: 100, Errors: 1, P99: 80 * time.Millisecond},
+		{Name: OpDeleteRecord, Requests: 100, P99: 20 * time.Millisecond},
+	}}
+	if err := report.Check(Thresholds{MaxErrorRate: 0.01, MaxP99: 100 * time.Millisecond}); err != nil {
+		t.Errorf("expected no violations, got %v", err)
+	}
+	err := report.Check(Thresholds{MaxErrorRate: 0.001, MaxP99: 50 * time.Millisecond})
+	if err == nil || !strings.Contains(err.Error(), "error rate 0.50%") || !strings.Contains(err.Error(), "add_record p99") ||
+		strings.Contains(err.Error(), "delete_record") {
+		t.Errorf("unexpected violations: %v", err)
+	}
+}
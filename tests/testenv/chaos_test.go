@@ -0,0 +1,248 @@
+//go:build chaos
+
+package testenv_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/tests/testenv"
+)
+
+// Chaos tests stop and kill the real proxy binary mid-workflow. Run them
+// with:
+//
+//	go test -tags chaos ./tests/testenv/
+
+// chaosImportRecords is the size of the imports interrupted by the tests.
+// With chaosUpstreamLatency per bunny.net call they take a few seconds.
+const (
+	chaosImportRecords   = 40
+	chaosUpstreamLatency = 100 * time.Millisecond
+)
+
+// job is the subset of GET /jobs/{jobID} the tests check.
+type job struct {
+	ID        int64  `json:"Id"`
+	Status    string `json:"Status"`
+	Total     int    `json:"Total"`
+	Processed int    `json:"Processed"`
+	Error     string `json:"Error"`
+}
+
+// chaosZoneFile returns a BIND zone file with chaosImportRecords TXT records.
+func chaosZoneFile() string {
+	var b strings.Builder
+	for i := range chaosImportRecords {
+		fmt.Fprintf(&b, "chaos-%d 300 IN TXT \"value-%d\"\n", i, i)
+	}
+	return b.String()
+}
+
+// importedRecords counts the records of the zone created from chaosZoneFile.
+func importedRecords(p *testenv.ProxyProcess, zoneID int64) int {
+	n := 0
+	for _, r := range p.Mock.GetZone(zoneID).Records {
+		if strings.HasPrefix(r.Name, "chaos-") {
+			n++
+		}
+	}
+	return n
+}
+
+// waitForJobStart polls a job until it is running or has finished.
+func waitForJobStart(t *testing.T, p *testenv.ProxyProcess, key string, id int64) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		var j job
+		if status, err := p.Do(http.MethodGet, fmt.Sprintf("/jobs/%d", id), key, nil, &j); err == nil &&
+			status == http.StatusOK && j.Status != "queued" {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("Job %d did not start", id)
+}
+
+// getJob fetches a job after a restart.
+func getJob(t *testing.T, p *testenv.ProxyProcess, key string, id int64) job {
+	t.Helper()
+	var j job
+	status, err := p.Do(http.MethodGet, fmt.Sprintf("/jobs/%d", id), key, nil, &j)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to get job %d: status %d, %v", id, status, err)
+	}
+	return j
+}
+
+// TestChaos_KillDuringTokenCreation kills the proxy while tokens are being
+// created and checks that every token it confirmed works after a restart.
+func TestChaos_KillDuringTokenCreation(t *testing.T) {
+	p := testenv.StartProxy(t, testenv.ProxyOptions{})
+	admin := p.BootstrapAdminToken(t)
+
+	var (
+		mu      sync.Mutex
+		created []string
+		wg      sync.WaitGroup
+	)
+	for w := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				var result struct {
+					Token string `json:"token"`
+				}
+				body := map[string]any{"name": fmt.Sprintf("chaos-%d-%d", w, i), "is_admin": true}
+				status, err := p.Do(http.MethodPost, "/admin/api/tokens", admin, body, &result)
+				if err != nil {
+					return // The proxy was killed
+				}
+				if status == http.StatusCreated {
+					mu.Lock()
+					created = append(created, result.Token)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	p.Kill(t)
+	wg.Wait()
+	if len(created) == 0 {
+		t.Fatal("No tokens were created before the kill")
+	}
+
+	p.Start(t)
+	for _, token := range append(created, admin) {
+		if status, err := p.Do(http.MethodGet, "/admin/api/whoami", token, nil, nil); err != nil || status != http.StatusOK {
+			t.Errorf("Token confirmed before the kill does not work after the restart: status %d, %v", status, err)
+		}
+	}
+	var tokens []map[string]any
+	if status, err := p.Do(http.MethodGet, "/admin/api/tokens", admin, nil, &tokens); err != nil || status != http.StatusOK {
+		t.Fatalf("Failed to list tokens after the restart: status %d, %v", status, err)
+	}
+	if len(tokens) < len(created)+1 {
+		t.Errorf("Expected at least %d tokens after the restart, got %d", len(created)+1, len(tokens))
+	}
+}
+
+// TestChaos_StopDuringImport stops the proxy gracefully during a synchronous
+// and an asynchronous import and checks that both complete.
+func TestChaos_StopDuringImport(t *testing.T) {
+	p := testenv.StartProxy(t, testenv.ProxyOptions{})
+	admin := p.BootstrapAdminToken(t)
+	syncZone := p.Mock.AddZone("sync-chaos.example.com")
+	asyncZone := p.Mock.AddZone("async-chaos.example.com")
+
+	p.Mock.SetLatency(chaosUpstreamLatency, 10*chaosImportRecords)
+	var j job
+	status, err := p.Do(http.MethodPost, fmt.Sprintf("/dnszone/%d/import?async=true", asyncZone), admin, chaosZoneFile(), &j)
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("Failed to start the async import: status %d, %v", status, err)
+	}
+	waitForJobStart(t, p, admin, j.ID)
+
+	syncDone := make(chan error, 1)
+	go func() {
+		status, err := p.Do(http.MethodPost, fmt.Sprintf("/dnszone/%d/import", syncZone), admin, chaosZoneFile(), nil)
+		if err == nil && status != http.StatusOK {
+			err = fmt.Errorf("status %d", status)
+		}
+		syncDone <- err
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	p.Stop(t)
+	if err := <-syncDone; err != nil {
+		t.Errorf("Synchronous import in progress at shutdown failed: %v", err)
+	}
+	p.Mock.SetLatency(0, 0)
+
+	p.Start(t)
+	if got := getJob(t, p, admin, j.ID); got.Status != "succeeded" || got.Processed != chaosImportRecords {
+		t.Errorf("Expected the async import to finish before shutdown, got %+v", got)
+	}
+	for _, zoneID := range []int64{syncZone, asyncZone} {
+		if n := importedRecords(p, zoneID); n != chaosImportRecords {
+			t.Errorf("Expected %d imported records in zone %d, got %d", chaosImportRecords, zoneID, n)
+		}
+	}
+}
+
+// TestChaos_KillDuringAsyncImport kills the proxy during an asynchronous
+// import and checks that the job is reported failed after a restart rather
+// than left running.
+func TestChaos_KillDuringAsyncImport(t *testing.T) {
+	p := testenv.StartProxy(t, testenv.ProxyOptions{})
+	admin := p.BootstrapAdminToken(t)
+	zoneID := p.Mock.AddZone("kill-chaos.example.com")
+
+	p.Mock.SetLatency(chaosUpstreamLatency, 10*chaosImportRecords)
+	var j job
+	status, err := p.Do(http.MethodPost, fmt.Sprintf("/dnszone/%d/import?async=true", zoneID), admin, chaosZoneFile(), &j)
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("Failed to start the async import: status %d, %v", status, err)
+	}
+	waitForJobStart(t, p, admin, j.ID)
+
+	time.Sleep(300 * time.Millisecond)
+	p.Kill(t)
+	p.Mock.SetLatency(0, 0)
+
+	p.Start(t)
+	got := getJob(t, p, admin, j.ID)
+	if got.Status != "failed" || !strings.Contains(got.Error, "interrupted by a restart") {
+		t.Errorf("Expected the killed job to be failed as interrupted, got %+v", got)
+	}
+	if n := importedRecords(p, zoneID); n >= chaosImportRecords {
+		t.Errorf("Expected the import to be cut short by the kill, got all %d records", n)
+	}
+}
+
+// TestChaos_ReadinessDuringShutdown checks that readiness fails as soon as
+// shutdown begins while requests are still served during the drain, and
+// recovers after a restart.
+func TestChaos_ReadinessDuringShutdown(t *testing.T) {
+	p := testenv.StartProxy(t, testenv.ProxyOptions{ShutdownDrain: 2 * time.Second})
+	admin := p.BootstrapAdminToken(t)
+
+	p.Terminate(t)
+	deadline := time.Now().Add(time.Second)
+	for {
+		status, err := p.ReadyStatus()
+		if err != nil {
+			t.Fatalf("Proxy stopped accepting connections during the drain: %v", err)
+		}
+		if status == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected /ready to return 503 after SIGTERM, got %d", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if status, err := p.Do(http.MethodGet, "/admin/api/whoami", admin, nil, nil); err != nil || status != http.StatusOK {
+		t.Errorf("Expected requests to be served during the drain: status %d, %v", status, err)
+	}
+
+	if err := p.Wait(t); err != nil {
+		t.Errorf("Proxy did not shut down cleanly: %v", err)
+	}
+	if _, err := p.ReadyStatus(); err == nil {
+		t.Error("Expected connections to be refused while the proxy is down")
+	}
+
+	p.Start(t)
+	if status, err := p.ReadyStatus(); err != nil || status != http.StatusOK {
+		t.Errorf("Expected /ready to return 200 after the restart: status %d, %v", status, err)
+	}
+}
@@ -0,0 +1,346 @@
+package testenv
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sipico/bunny-api-proxy/internal/testutil/mockbunny"
+)
+
+// processMasterKey is the bunny.net API key of proxies started by StartProxy
+// unless BUNNY_API_KEY is set, as mockbunny then expects that key.
+const processMasterKey = "process-master-key"
+
+// processReadyTimeout is how long StartProxy and Start wait for /ready.
+const processReadyTimeout = 30 * time.Second
+
+// ProxyOptions configures a proxy started by StartProxy.
+type ProxyOptions struct {
+	// ShutdownDrain is passed as SHUTDOWN_DRAIN_SECONDS, rounded down to
+	// whole seconds.
+	ShutdownDrain time.Duration
+	// Env holds further environment variables of the process, as KEY=value.
+	Env []string
+}
+
+// ProxyProcess is the real bunny-api-proxy binary running as a child process
+// against an in-process mockbunny server. Unlike the E2E environment, tests
+// may stop, kill and restart it mid-workflow; restarts keep its database,
+// listen address and tokens.
+//
+// Example usage in a chaos test:
+//
+//	p := testenv.StartProxy(t, testenv.ProxyOptions{})
+//	admin := p.BootstrapAdminToken(t)
+//	// ... start a workflow ...
+//	p.Kill(t)
+//	p.Start(t)
+//	// ... assert the workflow's state survived ...
+type ProxyProcess struct {
+	// URL is the base URL of the proxy's main listener.
+	URL string
+	// MasterKey is the bunny.net API key the proxy uses upstream, which also
+	// bootstraps its first admin token.
+	MasterKey string
+	// Mock is the upstream, for seeding zones and injecting latency or
+	// failures.
+	Mock *mockbunny.Server
+
+	binary string
+	env    []string
+	logs   *syncBuffer
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	exitErr error
+}
+
+// StartProxy builds the proxy binary, starts it with a database in a
+// temporary directory and waits until it is ready. The process and the mock
+// server are stopped when the test completes, and the proxy's logs are
+// written to the test log if it failed.
+func StartProxy(t *testing.T, opts ProxyOptions) *ProxyProcess {
+	t.Helper()
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "bunny-api-proxy")
+	build := exec.Command("go", "build", "-o", binary, "github.com/sipico/bunny-api-proxy/cmd/bunny-api-proxy")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build the proxy: %v\n%s", err, out)
+	}
+
+	masterKey := os.Getenv("BUNNY_API_KEY")
+	if masterKey == "" {
+		masterKey = processMasterKey
+	}
+	mock := mockbunny.New()
+	addr := freeAddr(t)
+	p := &ProxyProcess{
+		URL:       "http://" + addr,
+		MasterKey: masterKey,
+		Mock:      mock,
+		binary:    binary,
+		logs:      &syncBuffer{},
+		env: append([]string{
+			"PATH=" + os.Getenv("PATH"),
+			"LISTEN_ADDR=" + addr,
+			"METRICS_LISTEN_ADDR=" + freeAddr(t),
+			"DATABASE_PATH=" + filepath.Join(dir, "proxy.db"),
+			"BUNNY_API_URL=" + mock.URL(),
+			"BUNNY_API_KEY=" + masterKey,
+			"SHUTDOWN_DRAIN_SECONDS=" + strconv.Itoa(int(opts.ShutdownDrain/time.Second)),
+			"LOG_LEVEL=debug",
+		}, opts.Env...),
+	}
+	t.Cleanup(func() {
+		p.mu.Lock()
+		running := p.cmd != nil
+		p.mu.Unlock()
+		if running {
+			p.Kill(t)
+		}
+		mock.Close()
+		if t.Failed() {
+			t.Logf("Proxy logs:\n%s", p.Logs())
+		}
+	})
+
+	p.Start(t)
+	return p
+}
+
+// Start starts the proxy process again after Stop or Kill and waits until
+// it is ready.
+func (p *ProxyProcess) Start(t *testing.T) {
+	t.Helper()
+
+	p.mu.Lock()
+	if p.cmd != nil {
+		p.mu.Unlock()
+		t.Fatal("Proxy process is already running")
+	}
+	cmd := exec.Command(p.binary)
+	cmd.Env = p.env
+	cmd.Stdout = p.logs
+	cmd.Stderr = p.logs
+	if err := cmd.Start(); err != nil {
+		p.mu.Unlock()
+		t.Fatalf("Failed to start the proxy: %v", err)
+	}
+	exited := make(chan struct{})
+	p.cmd, p.exited, p.exitErr = cmd, exited, nil
+	p.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		p.mu.Lock()
+		p.exitErr = err
+		p.mu.Unlock()
+		close(exited)
+	}()
+
+	deadline := time.Now().Add(processReadyTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-exited:
+			p.mu.Lock()
+			p.cmd = nil
+			err := p.exitErr
+			p.mu.Unlock()
+			t.Fatalf("Proxy exited during startup: %v\n%s", err, p.Logs())
+		default:
+		}
+		if status, err := p.ReadyStatus(); err == nil && status == http.StatusOK {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("Proxy not ready after %v\n%s", processReadyTimeout, p.Logs())
+}
+
+// Terminate sends SIGTERM without waiting for the process to exit, so a
+// test can observe the shutdown. Use Wait to wait for the exit.
+func (p *ProxyProcess) Terminate(t *testing.T) {
+	t.Helper()
+	p.signal(t, syscall.SIGTERM)
+}
+
+// Stop sends SIGTERM and waits for the process to exit. The test fails if
+// the proxy does not exit cleanly.
+func (p *ProxyProcess) Stop(t *testing.T) {
+	t.Helper()
+	p.Terminate(t)
+	if err := p.Wait(t); err != nil {
+		t.Errorf("Proxy did not shut down cleanly: %v", err)
+	}
+}
+
+// Kill sends SIGKILL, as an OOM kill or a power loss would, and waits for
+// the process to exit.
+func (p *ProxyProcess) Kill(t *testing.T) {
+	t.Helper()
+	p.signal(t, syscall.SIGKILL)
+	//nolint:errcheck // A killed process always reports an error
+	p.Wait(t)
+}
+
+// Wait waits for the process to exit and returns its exit error, if any.
+// The test fails if it has not exited within a minute.
+func (p *ProxyProcess) Wait(t *testing.T) error {
+	t.Helper()
+
+	p.mu.Lock()
+	exited := p.exited
+	p.mu.Unlock()
+	if exited == nil {
+		t.Fatal("Proxy process was never started")
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(time.Minute):
+		t.Fatal("Proxy process did not exit within a minute")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cmd = nil
+	return p.exitErr
+}
+
+// ReadyStatus returns the status code of GET /ready, or an error if the
+// proxy does not accept connections.
+func (p *ProxyProcess) ReadyStatus() (int, error) {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(p.URL + "/ready")
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck // Only the status is needed
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// BootstrapAdminToken creates the first admin token with the master key and
+// returns it.
+func (p *ProxyProcess) BootstrapAdminToken(t *testing.T) string {
+	t.Helper()
+	var result struct {
+		Token string `json:"token"`
+	}
+	body := map[string]any{"name": "process-admin", "is_admin": true}
+	status, err := p.Do(http.MethodPost, "/admin/api/tokens", p.MasterKey, body, &result)
+	if err != nil || status != http.StatusCreated {
+		t.Fatalf("Failed to bootstrap admin token: status %d, %v", status, err)
+	}
+	return result.Token
+}
+
+// Do sends a request with key as its AccessKey and decodes a JSON response
+// into out, if out is not nil. A string body is sent as is, anything else
+// as JSON. It returns the status code, or an error if the request could not
+// be sent, as while the proxy is down.
+func (p *ProxyProcess) Do(method, path, key string, body, out any) (int, error) {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, p.URL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("AccessKey", key)
+	if _, ok := body.(string); !ok && body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		//nolint:errcheck // The body has been read
+		resp.Body.Close()
+	}()
+	if out != nil && resp.StatusCode < http.StatusBadRequest {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// Logs returns everything the process wrote to stdout and stderr, across
+// restarts.
+func (p *ProxyProcess) Logs() string {
+	return p.logs.String()
+}
+
+// signal sends sig to the running process.
+func (p *ProxyProcess) signal(t *testing.T, sig os.Signal) {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil {
+		t.Fatal("Proxy process is not running")
+	}
+	if err := p.cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		t.Fatalf("Failed to send %v to the proxy: %v", sig, err)
+	}
+}
+
+// freeAddr returns a loopback address with a port that was free when it
+// was checked.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	addr := l.Addr().String()
+	if err := l.Close(); err != nil {
+		t.Fatalf("Failed to release port %s: %v", addr, err)
+	}
+	return addr
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of a child
+// process's stdout and stderr.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}